@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/app/config"
+	"github.com/archivus/archivus/internal/app/middleware"
+	"github.com/archivus/archivus/internal/app/server"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql"
+	"github.com/archivus/archivus/internal/infrastructure/storage/local"
+	"github.com/google/uuid"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	command := os.Args[1]
+	args := os.Args[2:]
+
+	switch command {
+	case "repair":
+		os.Exit(runRepair(args))
+	case "routes":
+		os.Exit(runRoutes(args))
+	case "create-tenant":
+		os.Exit(runCreateTenant(args))
+	case "create-admin":
+		os.Exit(runCreateAdmin(args))
+	case "requeue-jobs":
+		os.Exit(runRequeueJobs(args))
+	case "recalculate-quotas":
+		os.Exit(runRecalculateQuotas(args))
+	case "rotate-api-key":
+		os.Exit(runRotateAPIKey(args))
+	case "retention-sweep":
+		os.Exit(runRetentionSweep(args))
+	case "help", "-h", "--help":
+		printUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", command)
+		printUsage()
+		os.Exit(2)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: archivusctl <command> [flags]")
+	fmt.Println("")
+	fmt.Println("Commands:")
+	fmt.Println("  repair - Find and fix inconsistent documents, analytics, storage usage, document counts, tag counts and orphaned files")
+	fmt.Println("  routes - Report the authorization policy of every API route and any route without one")
+	fmt.Println("  create-tenant - Create a tenant")
+	fmt.Println("  create-admin - Create an admin user in a tenant, password from " + adminPasswordEnv + " or stdin")
+	fmt.Println("  requeue-jobs - Queue failed AI jobs again with fresh attempts")
+	fmt.Println("  recalculate-quotas - Recompute tenants' storage used and document counts")
+	fmt.Println("  rotate-api-key - Replace an API key's secret and print the new one")
+	fmt.Println("  retention-sweep - Purge expired trash and archive expired audit logs now")
+	fmt.Println("")
+	fmt.Println("Run 'archivusctl <command> -h' for command flags.")
+}
+
+// connectDatabase opens the database the same way cmd/migrate does:
+// DATABASE_URL_TEST wins, otherwise the regular configuration is used
+func connectDatabase() (*database.DB, error) {
+	databaseURL := os.Getenv("DATABASE_URL_TEST")
+	if databaseURL == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		databaseURL = cfg.GetDatabaseURL()
+	}
+
+	if databaseURL == "" {
+		return nil, fmt.Errorf("no database URL found, set DATABASE_URL_TEST or configure .env")
+	}
+
+	return database.New(databaseURL)
+}
+
+func runRepair(args []string) int {
+	flags := flag.NewFlagSet("repair", flag.ExitOnError)
+	tenant := flags.String("tenant", "", "Only repair this tenant (UUID)")
+	checks := flags.String("checks", "", "Comma-separated checks to run: "+strings.Join(services.AllRepairChecks, ", "))
+	dryRun := flags.Bool("dry-run", false, "Report problems without fixing them")
+	stuckAfter := flags.Duration("stuck-after", time.Hour, "How long a document may sit in processing with no active job")
+	orphanMinAge := flags.Duration("orphan-min-age", 24*time.Hour, "How old an unreferenced file must be before it's deleted")
+	jsonOutput := flags.Bool("json", false, "Print the report as JSON")
+	flags.Parse(args)
+
+	opts := services.RepairOptions{
+		DryRun:       *dryRun,
+		StuckAfter:   *stuckAfter,
+		OrphanMinAge: *orphanMinAge,
+	}
+	if *tenant != "" {
+		tenantID, err := uuid.Parse(*tenant)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid tenant ID: %s\n", *tenant)
+			return 2
+		}
+		opts.TenantID = &tenantID
+	}
+	if *checks != "" {
+		for _, check := range strings.Split(*checks, ",") {
+			if check = strings.TrimSpace(check); check != "" {
+				opts.Checks = append(opts.Checks, check)
+			}
+		}
+	}
+
+	db, err := connectDatabase()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	var storageService services.StorageService = local.NewStorageService(cfg.Storage.Path)
+	if cfg.Storage.ColdPath != "" {
+		storageService = local.NewTieredStorageService(cfg.Storage.Path, cfg.Storage.ColdPath)
+	}
+
+	repos := postgresql.NewRepositories(db)
+	repairService := services.NewRepairService(repos.RepairRepo, repos.AnalyticsRepo, storageService, services.RepairServiceConfig{})
+
+	report, err := repairService.Run(context.Background(), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Repair failed: %v\n", err)
+		return 2
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode report: %v\n", err)
+			return 2
+		}
+	} else {
+		printRepairReport(report)
+	}
+
+	if report.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+func printRepairReport(report *services.RepairReport) {
+	mode := "repair"
+	if report.DryRun {
+		mode = "dry run"
+	}
+	scope := "all tenants"
+	if report.TenantID != nil {
+		scope = "tenant " + report.TenantID.String()
+	}
+	fmt.Printf("Archivus %s (%s), %s\n\n", mode, scope, report.CompletedAt.Sub(report.StartedAt).Round(time.Millisecond))
+
+	for _, check := range report.Checks {
+		fmt.Printf("%s: %d found, %d fixed, %d failed\n", check.Check, check.Found, check.Fixed, check.Failed)
+		if check.Error != "" {
+			fmt.Printf("  ERROR %s\n", check.Error)
+		}
+		for _, issue := range check.Issues {
+			status := "would"
+			switch {
+			case issue.Error != "":
+				status = "FAILED"
+			case issue.Fixed:
+				status = "fixed"
+			case !report.DryRun:
+				status = "skipped"
+			}
+			subject := issue.EntityID.String()
+			if issue.EntityID == uuid.Nil && issue.Path != "" {
+				subject = issue.Path
+			}
+			fmt.Printf("  [%s] %s %s: %s -> %s\n", status, issue.TenantID, subject, issue.Detail, issue.Action)
+			if issue.Error != "" {
+				fmt.Printf("    %s\n", issue.Error)
+			}
+		}
+	}
+
+	fmt.Printf("\nTotal: %d found, %d fixed\n", report.TotalFound(), report.TotalFixed())
+}
+
+// runRoutes builds the router without any services, only to list its routes,
+// and exits non-zero when a route has no policy or a policy has no route
+func runRoutes(args []string) int {
+	flags := flag.NewFlagSet("routes", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "Print the report as JSON")
+	flags.Parse(args)
+
+	srv := server.NewServer(&config.Config{}, &server.Services{}, nil)
+	report := srv.RoutePolicyReport()
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode report: %v\n", err)
+			return 2
+		}
+	} else {
+		printRoutePolicyReport(report)
+	}
+
+	if len(report.Undeclared()) > 0 || len(report.Stale()) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printRoutePolicyReport(report *middleware.RoutePolicyReport) {
+	for _, entry := range report.Entries {
+		policy := entry.Policy.String()
+		switch {
+		case !entry.Declared:
+			policy = "UNDECLARED (refused)"
+		case !entry.Routed:
+			policy += " (STALE: no such route)"
+		}
+		fmt.Printf("%-7s %-55s %s\n", entry.Method, entry.Path, policy)
+	}
+
+	fmt.Printf("\n%d routes, %d public, %d undeclared, %d stale policies\n",
+		len(report.Entries)-len(report.Stale()), len(report.Public()), len(report.Undeclared()), len(report.Stale()))
+}
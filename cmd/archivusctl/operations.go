@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/app/config"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/auth/supabase"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql"
+	"github.com/archivus/archivus/internal/infrastructure/storage/local"
+	supabasestorage "github.com/archivus/archivus/internal/infrastructure/storage/supabase"
+	"github.com/google/uuid"
+)
+
+// adminPasswordEnv holds a new admin's password when it isn't read from stdin,
+// keeping it out of the process list and shell history
+const adminPasswordEnv = "ARCHIVUS_ADMIN_PASSWORD"
+
+// operator is what the operational commands work with: the configuration,
+// database and repositories the server uses. Actions taken through it are
+// audited as the system user.
+type operator struct {
+	cfg   *config.Config
+	db    *database.DB
+	repos *postgresql.Repositories
+}
+
+func openOperator() *operator {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := connectDatabase()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := db.CheckSchemaVersion(context.Background()); err != nil {
+		db.Close()
+		log.Fatalf("Incompatible database schema: %v", err)
+	}
+
+	return &operator{cfg: cfg, db: db, repos: postgresql.NewRepositories(db)}
+}
+
+func (o *operator) Close() {
+	o.db.Close()
+}
+
+// storageService opens the storage backend the server is configured with
+func (o *operator) storageService() services.StorageService {
+	if o.cfg.Storage.Type == "supabase" {
+		apiKey := o.cfg.Supabase.ServiceKey
+		if apiKey == "" {
+			apiKey = o.cfg.Supabase.APIKey
+		}
+		storageService, err := supabasestorage.NewStorageService(supabasestorage.Config{
+			URL:    o.cfg.Supabase.URL,
+			APIKey: apiKey,
+			Bucket: o.cfg.Supabase.Bucket,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize storage service: %v", err)
+		}
+		return storageService
+	}
+	if o.cfg.Storage.ColdPath != "" {
+		return local.NewTieredStorageService(o.cfg.Storage.Path, o.cfg.Storage.ColdPath)
+	}
+	return local.NewStorageService(o.cfg.Storage.Path)
+}
+
+func (o *operator) tenantService() *services.TenantService {
+	return services.NewTenantService(
+		o.repos.TenantRepo,
+		o.repos.UserRepo,
+		o.repos.DocumentRepo,
+		o.repos.AuditRepo,
+		nil,
+		nil,
+		services.TenantServiceConfig{
+			DefaultTrialDays:   30,
+			MinSubdomainLength: 3,
+			MaxSubdomainLength: 20,
+			ReservedSubdomains: []string{"api", "www", "admin", "support", "mail", "ftp"},
+			EnableCompliance:   true,
+		},
+	)
+}
+
+// userService creates users through Supabase Auth, as sign-up does
+func (o *operator) userService() *services.UserService {
+	if o.cfg.Supabase.URL == "" || o.cfg.Supabase.APIKey == "" {
+		log.Fatalf("Supabase credentials are required to create users")
+	}
+	authService, err := supabase.NewAuthService(supabase.Config{
+		URL:       o.cfg.Supabase.URL,
+		APIKey:    o.cfg.Supabase.APIKey,
+		JWTSecret: o.cfg.Supabase.JWTSecret,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize auth service: %v", err)
+	}
+
+	return services.NewUserService(
+		o.repos.UserRepo,
+		o.repos.RoleRepo,
+		o.repos.TenantRepo,
+		o.repos.AuditRepo,
+		o.repos.MFACodeRepo,
+		authService,
+		nil, // No welcome email from the CLI
+		services.UserServiceConfig{
+			MinPasswordLength: 8,
+			RequireUppercase:  true,
+			RequireLowercase:  true,
+			RequireNumbers:    true,
+		},
+		nil,
+		nil,
+	)
+}
+
+// resolveTenant finds a tenant by ID or subdomain
+func (o *operator) resolveTenant(ctx context.Context, value string) (*models.Tenant, error) {
+	if id, err := uuid.Parse(value); err == nil {
+		return o.repos.TenantRepo.GetByID(ctx, id)
+	}
+	return o.repos.TenantRepo.GetBySubdomain(ctx, strings.ToLower(value))
+}
+
+// optionalTenant resolves the -tenant flag, nil meaning every tenant
+func (o *operator) optionalTenant(ctx context.Context, value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	tenant, err := o.resolveTenant(ctx, value)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %s: %w", value, err)
+	}
+	return &tenant.ID, nil
+}
+
+func runCreateTenant(args []string) int {
+	flags := flag.NewFlagSet("create-tenant", flag.ExitOnError)
+	name := flags.String("name", "", "Company name (required)")
+	subdomain := flags.String("subdomain", "", "Subdomain users sign in with (required)")
+	tier := flags.String("tier", string(models.SubscriptionStarter), "Subscription tier: starter, professional or enterprise")
+	industry := flags.String("industry", "", "Industry, which picks the default retention policy")
+	flags.Parse(args)
+
+	if *name == "" || *subdomain == "" {
+		fmt.Fprintln(os.Stderr, "-name and -subdomain are required")
+		return 2
+	}
+	switch models.SubscriptionTier(*tier) {
+	case models.SubscriptionStarter, models.SubscriptionProfessional, models.SubscriptionEnterprise:
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown tier: %s\n", *tier)
+		return 2
+	}
+
+	op := openOperator()
+	defer op.Close()
+
+	tenant, err := op.tenantService().CreateTenant(context.Background(), services.CreateTenantParams{
+		Name:             *name,
+		Subdomain:        *subdomain,
+		SubscriptionTier: models.SubscriptionTier(*tier),
+		Industry:         *industry,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create tenant: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Created tenant %s (%s), %s tier\n", tenant.ID, tenant.Subdomain, tenant.SubscriptionTier)
+	fmt.Printf("Add its first admin with: archivusctl create-admin -tenant %s -email <email>\n", tenant.Subdomain)
+	return 0
+}
+
+func runCreateAdmin(args []string) int {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	tenantFlag := flags.String("tenant", "", "Tenant ID or subdomain (required)")
+	email := flags.String("email", "", "Admin email address (required)")
+	firstName := flags.String("first-name", "", "First name")
+	lastName := flags.String("last-name", "", "Last name")
+	passwordStdin := flags.Bool("password-stdin", false, "Read the password from stdin instead of "+adminPasswordEnv)
+	flags.Parse(args)
+
+	if *tenantFlag == "" || *email == "" {
+		fmt.Fprintln(os.Stderr, "-tenant and -email are required")
+		return 2
+	}
+
+	password := os.Getenv(adminPasswordEnv)
+	if *passwordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintf(os.Stderr, "Failed to read password: %v\n", err)
+			return 2
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if password == "" {
+		fmt.Fprintf(os.Stderr, "Set %s or pass -password-stdin\n", adminPasswordEnv)
+		return 2
+	}
+
+	op := openOperator()
+	defer op.Close()
+	ctx := context.Background()
+
+	tenant, err := op.resolveTenant(ctx, *tenantFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Tenant %s: %v\n", *tenantFlag, err)
+		return 1
+	}
+
+	user, err := op.userService().CreateUser(ctx, services.CreateUserParams{
+		TenantID:  tenant.ID,
+		Email:     *email,
+		Password:  password,
+		FirstName: *firstName,
+		LastName:  *lastName,
+		Role:      models.UserRoleAdmin,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create admin: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Created admin %s (%s) in tenant %s\n", user.ID, user.Email, tenant.Subdomain)
+	return 0
+}
+
+func runRequeueJobs(args []string) int {
+	flags := flag.NewFlagSet("requeue-jobs", flag.ExitOnError)
+	tenantFlag := flags.String("tenant", "", "Only requeue this tenant's jobs (ID or subdomain)")
+	flags.Parse(args)
+
+	op := openOperator()
+	defer op.Close()
+	ctx := context.Background()
+
+	tenantID, err := op.optionalTenant(ctx, *tenantFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Requeueing only touches the queue; no AI client is needed
+	aiService := services.NewAIProcessingService(
+		op.repos.AIJobRepo, op.repos.DocumentRepo, op.repos.TagRepo, op.repos.CategoryRepo,
+		op.repos.TenantRepo, op.repos.AuditRepo, op.repos.LineItemRepo, op.repos.AIFeedbackRepo, op.repos.DocumentChunkRepo,
+		op.repos.FolderRepo, op.repos.FilingRepo, op.repos.EntityRepo, op.repos.FingerprintRepo, op.repos.PromptRepo, op.repos.DocTypeRepo,
+		nil, nil, nil, nil, nil, nil, nil, nil,
+		services.AIServiceConfig{},
+	)
+	requeued, err := aiService.RequeueFailedJobs(ctx, tenantID)
+	fmt.Printf("Requeued %d failed AI jobs\n", requeued)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Requeue failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runRecalculateQuotas recomputes tenants' storage used and document counts
+// with the repair checks that own them
+func runRecalculateQuotas(args []string) int {
+	flags := flag.NewFlagSet("recalculate-quotas", flag.ExitOnError)
+	tenantFlag := flags.String("tenant", "", "Only recalculate this tenant (ID or subdomain)")
+	dryRun := flags.Bool("dry-run", false, "Report drifted counters without fixing them")
+	flags.Parse(args)
+
+	op := openOperator()
+	defer op.Close()
+	ctx := context.Background()
+
+	tenantID, err := op.optionalTenant(ctx, *tenantFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	repairService := services.NewRepairService(op.repos.RepairRepo, op.repos.AnalyticsRepo, op.storageService(), services.RepairServiceConfig{})
+	report, err := repairService.Run(ctx, services.RepairOptions{
+		TenantID: tenantID,
+		Checks:   []string{services.RepairCheckStorageUsage, services.RepairCheckDocumentCount},
+		DryRun:   *dryRun,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Recalculation failed: %v\n", err)
+		return 2
+	}
+
+	printRepairReport(report)
+	if report.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+func runRotateAPIKey(args []string) int {
+	flags := flag.NewFlagSet("rotate-api-key", flag.ExitOnError)
+	tenantFlag := flags.String("tenant", "", "Tenant ID or subdomain (required)")
+	keyFlag := flags.String("key", "", "API key ID (required)")
+	flags.Parse(args)
+
+	keyID, err := uuid.Parse(*keyFlag)
+	if *tenantFlag == "" || err != nil {
+		fmt.Fprintln(os.Stderr, "-tenant and a valid -key ID are required")
+		return 2
+	}
+
+	op := openOperator()
+	defer op.Close()
+	ctx := context.Background()
+
+	tenant, err := op.resolveTenant(ctx, *tenantFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Tenant %s: %v\n", *tenantFlag, err)
+		return 1
+	}
+
+	apiKeyService := services.NewAPIKeyService(op.repos.APIKeyRepo, op.repos.UserRepo, op.repos.AuditRepo, nil)
+	key, plaintext, err := apiKeyService.RotateAPIKey(ctx, keyID, tenant.ID, uuid.Nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to rotate API key: %v\n", err)
+		return 1
+	}
+
+	// The only time the new secret is shown
+	fmt.Fprintf(os.Stderr, "Rotated API key %s (%s); the old secret no longer works\n", key.ID, key.Name)
+	fmt.Println(plaintext)
+	return 0
+}
+
+// runRetentionSweep runs the retention tasks the server schedules, now
+func runRetentionSweep(args []string) int {
+	flags := flag.NewFlagSet("retention-sweep", flag.ExitOnError)
+	trash := flags.Bool("trash", true, "Purge documents past the trash retention period")
+	audit := flags.Bool("audit", true, "Archive audit logs past their tenant's retention period")
+	flags.Parse(args)
+
+	op := openOperator()
+	defer op.Close()
+	ctx := context.Background()
+	storageService := op.storageService()
+	status := 0
+
+	if *trash {
+		documentService := services.NewDocumentService(
+			op.repos.DocumentRepo, op.repos.TenantRepo, op.repos.UserRepo, op.repos.FolderRepo,
+			op.repos.FolderACLRepo, op.repos.DocumentACLRepo, op.repos.TagRepo, op.repos.CategoryRepo,
+			op.repos.AuditRepo, op.repos.AIJobRepo, op.repos.AnalyticsRepo, op.repos.NumberingRepo,
+			op.repos.FingerprintRepo, op.repos.DocTypeRepo, storageService, nil, nil, nil,
+			services.DocumentServiceConfig{TrashRetention: op.cfg.Limits.TrashRetention},
+		)
+		started := time.Now()
+		purged, err := documentService.PurgeTrash(ctx)
+		fmt.Printf("Trash: purged %d documents in %s\n", purged, time.Since(started).Round(time.Millisecond))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Trash purge failed: %v\n", err)
+			status = 1
+		}
+	}
+
+	if *audit {
+		auditRetentionService := services.NewAuditRetentionService(op.repos.AuditRepo, op.repos.TenantRepo, storageService, services.AuditRetentionConfig{})
+		started := time.Now()
+		archived, err := auditRetentionService.ArchiveAll(ctx)
+		fmt.Printf("Audit logs: archived %d entries in %s\n", archived, time.Since(started).Round(time.Millisecond))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Audit archival failed: %v\n", err)
+			status = 1
+		}
+	}
+
+	return status
+}
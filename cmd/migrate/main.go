@@ -1,16 +1,35 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/archivus/archivus/internal/app/config"
+	"github.com/archivus/archivus/internal/domain/services"
 	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/migrations"
 	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql"
+	"github.com/archivus/archivus/internal/infrastructure/storage/local"
 	"github.com/archivus/archivus/pkg/logger"
+	"github.com/google/uuid"
 )
 
+// migrationsDir is where create writes new migrations, relative to the
+// repository root
+const migrationsDir = "internal/infrastructure/database/migrations"
+
+// migrationName matches the names migrations may be created with
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
 func main() {
 	if len(os.Args) < 2 {
 		printUsage()
@@ -41,6 +60,12 @@ func main() {
 		log.Fatalf("No database URL found. Set DATABASE_URL_TEST environment variable or configure in .env file")
 	}
 
+	// Creating a migration only writes files
+	if command == "create" {
+		createMigration(logger, os.Args[2:])
+		return
+	}
+
 	// Connect to database
 	db, err := database.New(databaseURL)
 	if err != nil {
@@ -52,13 +77,29 @@ func main() {
 	case "up":
 		runMigrations(db, logger)
 	case "down":
-		rollbackMigrations(db, logger)
+		rollbackMigrations(db, logger, os.Args[2:])
+	case "force":
+		forceVersion(db, logger, os.Args[2:])
+	case "version":
+		migrationVersion(db, logger)
+	case "plan":
+		planMigrations(db, logger)
+	case "verify":
+		verifySchema(db, logger)
 	case "reset":
 		resetDatabase(db, logger)
 	case "seed":
 		seedDatabase(db, logger)
 	case "status":
 		migrationStatus(db, logger)
+	case "partition":
+		partitionTables(db, logger)
+	case "partition-status":
+		partitionStatus(db, logger)
+	case "export-tenant":
+		exportTenant(db, logger, os.Args[2:])
+	case "import-tenant":
+		importTenant(db, logger, os.Args[2:])
 	default:
 		logger.Error("Unknown command", "command", command)
 		printUsage()
@@ -69,42 +110,176 @@ func printUsage() {
 	fmt.Println("Usage: go run cmd/migrate/main.go <command>")
 	fmt.Println("")
 	fmt.Println("Commands:")
-	fmt.Println("  up     - Run all pending migrations")
-	fmt.Println("  down   - Rollback the last migration")
-	fmt.Println("  reset  - Drop all tables and recreate them")
-	fmt.Println("  seed   - Seed the database with initial data")
-	fmt.Println("  status - Show migration status")
+	fmt.Println("  up      - Run all pending migrations")
+	fmt.Println("  down    - Roll back the last migration (down <steps> for more, down all for every one)")
+	fmt.Println("  force   - Mark the database as at a version after fixing a failed migration (force <version>)")
+	fmt.Println("  version - Show the applied migration version")
+	fmt.Println("  create  - Write the up and down files of a new migration (create <name>)")
+	fmt.Println("  plan    - Print the pending migrations and DDL without applying them")
+	fmt.Println("  verify  - Exit non-zero when the schema differs from the models, e.g. in CI")
+	fmt.Println("  reset   - Drop all tables and recreate them")
+	fmt.Println("  seed    - Seed the database with initial data")
+	fmt.Println("  status  - Show migration status")
+	fmt.Println("  partition        - Convert high-volume tables to partitioned tables")
+	fmt.Println("  partition-status - List partitions of the partitioned tables")
+	fmt.Println("  export-tenant    - Write a tenant and its documents to an archive (-tenant <id> -out <file>)")
+	fmt.Println("  import-tenant    - Create a tenant from an archive (-in <file> -subdomain <subdomain> [-name <name>])")
 }
 
 func runMigrations(db *database.DB, logger *logger.Logger) {
 	logger.Info("Running database migrations...")
 
-	// Auto-migrate all models
-	if err := db.AutoMigrate(models.GetAllModels()...); err != nil {
+	// PostgreSQL applies the versioned migrations under an advisory lock, each
+	// in its own transaction, then builds indexes concurrently so writes keep
+	// flowing; SQLite development databases are migrated from the models
+	if err := db.MigrateUp(context.Background(), models.GetAllModels(), database.MigrationOptions{}); err != nil {
 		logger.Error("Failed to run migrations", "error", err)
 		return
 	}
 
-	// Create indexes for better performance
-	if err := createIndexes(db); err != nil {
-		logger.Error("Failed to create indexes", "error", err)
+	logger.Info("Database migrations completed successfully", "schema_version", database.SchemaVersion)
+}
+
+func rollbackMigrations(db *database.DB, logger *logger.Logger, args []string) {
+	steps := 1
+	if len(args) > 0 {
+		if args[0] == "all" {
+			steps = 0
+		} else if n, err := strconv.Atoi(args[0]); err == nil && n > 0 {
+			steps = n
+		} else {
+			logger.Error("down takes a number of migrations or all", "steps", args[0])
+			return
+		}
+	}
+
+	logger.Info("Rolling back migrations...", "steps", steps)
+
+	if err := db.MigrateDown(context.Background(), steps); err != nil {
+		logger.Error("Failed to roll back migrations", "error", err)
+		return
+	}
+
+	migrationVersion(db, logger)
+}
+
+func forceVersion(db *database.DB, logger *logger.Logger, args []string) {
+	if len(args) < 1 {
+		logger.Error("force needs a version, e.g. force 25")
+		return
+	}
+	version, err := strconv.Atoi(args[0])
+	if err != nil || version < -1 {
+		logger.Error("Invalid version", "version", args[0])
+		return
+	}
+
+	// Nothing runs; the schema must already match the version
+	if err := db.ForceMigrationVersion(context.Background(), version); err != nil {
+		logger.Error("Failed to force migration version", "error", err)
+		return
+	}
+
+	logger.Info("Migration version forced", "version", version)
+}
+
+func migrationVersion(db *database.DB, logger *logger.Logger) {
+	state, err := db.MigrationState(context.Background())
+	if err != nil {
+		logger.Warn("Migration version unknown", "error", err, "build_version", database.SchemaVersion)
+		return
+	}
+
+	logger.Info("Migration version", "version", state.Version, "dirty", state.Dirty, "build_version", database.SchemaVersion)
+	if state.Dirty {
+		logger.Warn("The last migration failed; fix the schema, then run force with the version it is at")
+	}
+}
+
+func planMigrations(db *database.DB, logger *logger.Logger) {
+	plan, err := db.PlanMigration(context.Background(), models.GetAllModels())
+	if err != nil {
+		logger.Error("Failed to plan migrations", "error", err)
 		return
 	}
 
-	logger.Info("Database migrations completed successfully")
+	printPlan(plan)
+	if plan.Empty() {
+		logger.Info("Schema matches the models; nothing to migrate")
+	}
+}
+
+// verifySchema exits with status 1 on drift, so CI and deploy scripts stop
+func verifySchema(db *database.DB, logger *logger.Logger) {
+	plan, err := db.VerifySchema(context.Background(), models.GetAllModels())
+	if err != nil {
+		if plan != nil {
+			printPlan(plan)
+		}
+		logger.Error("Schema verification failed", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("Schema matches the models", "schema_version", database.SchemaVersion)
+}
+
+// printPlan writes the plan to stdout as SQL, so it can be reviewed or saved
+func printPlan(plan *database.SchemaPlan) {
+	for _, migration := range plan.Migrations {
+		fmt.Printf("-- pending migration %s\n", migration)
+	}
+	for _, statement := range plan.Statements {
+		fmt.Printf("%s;\n", statement)
+	}
+	for _, index := range plan.Indexes {
+		fmt.Printf("%s;\n", index)
+	}
 }
 
-func rollbackMigrations(db *database.DB, logger *logger.Logger) {
-	logger.Info("Rolling back migrations...")
+// createMigration writes empty up and down files numbered after the latest
+// migration. Run it from the repository root.
+func createMigration(logger *logger.Logger, args []string) {
+	if len(args) < 1 || !migrationName.MatchString(args[0]) {
+		logger.Error("create needs a name of lowercase letters, digits and underscores, e.g. create add_invoice_terms")
+		return
+	}
+
+	latest, err := migrations.Latest()
+	if err != nil {
+		logger.Error("Failed to read migrations", "error", err)
+		return
+	}
+	version := latest + 1
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(migrationsDir, fmt.Sprintf("%06d_%s.%s.sql", version, args[0], direction))
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			logger.Error("Failed to create migration", "path", path, "error", err)
+			return
+		}
+		file.Close()
+		logger.Info("Created migration", "path", path)
+	}
 
-	// Note: GORM doesn't support rollbacks out of the box
-	// This is a simplified version - in production you might want to use a migration tool like golang-migrate
-	logger.Warn("Rollback not implemented - use 'reset' to recreate schema")
+	logger.Info("Raise database.SchemaVersion to the new version", "version", version)
 }
 
 func resetDatabase(db *database.DB, logger *logger.Logger) {
 	logger.Info("Resetting database...")
 
+	// PostgreSQL reverts every versioned migration, which drops every table
+	err := db.MigrateDown(context.Background(), 0)
+	if err == nil {
+		runMigrations(db, logger)
+		logger.Info("Database reset completed")
+		return
+	}
+	if !errors.Is(err, database.ErrVersionedMigrationsUnsupported) {
+		logger.Error("Failed to drop tables", "error", err)
+		return
+	}
+
 	// Drop all tables in reverse order to handle foreign key constraints
 	tables := []interface{}{
 		&models.Share{},
@@ -246,6 +421,17 @@ func seedDatabase(db *database.DB, logger *logger.Logger) {
 func migrationStatus(db *database.DB, logger *logger.Logger) {
 	logger.Info("Checking migration status...")
 
+	status, err := db.SchemaStatus(context.Background())
+	if err != nil {
+		logger.Warn("Schema version unknown", "error", err, "build_version", database.SchemaVersion)
+	} else {
+		logger.Info("Schema version", "version", status.Version, "min_compatible", status.MinCompatible,
+			"applied_at", status.AppliedAt, "build_version", database.SchemaVersion)
+		if err := db.CheckSchemaVersion(context.Background()); err != nil {
+			logger.Warn("Schema is not compatible with this build", "error", err)
+		}
+	}
+
 	// Check if tables exist
 	tables := map[string]interface{}{
 		"tenants":            &models.Tenant{},
@@ -281,24 +467,193 @@ func migrationStatus(db *database.DB, logger *logger.Logger) {
 	}
 }
 
-func createIndexes(db *database.DB) error {
-	// Create additional indexes for better performance
-	indexes := []string{
-		"CREATE INDEX IF NOT EXISTS idx_documents_tenant_status ON documents(tenant_id, status)",
-		"CREATE INDEX IF NOT EXISTS idx_documents_created_at ON documents(created_at)",
-		"CREATE INDEX IF NOT EXISTS idx_documents_file_size ON documents(file_size)",
-		"CREATE INDEX IF NOT EXISTS idx_ai_jobs_status_priority ON ai_processing_jobs(status, priority)",
-		"CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at)",
-		"CREATE INDEX IF NOT EXISTS idx_shares_expires_at ON shares(expires_at)",
-		"CREATE INDEX IF NOT EXISTS idx_folders_path_gin ON folders USING gin(to_tsvector('english', path))",
-		"CREATE INDEX IF NOT EXISTS idx_documents_text_gin ON documents USING gin(to_tsvector('english', coalesce(extracted_text, '') || ' ' || coalesce(ocr_text, '')))",
-	}
-
-	for _, indexSQL := range indexes {
-		if err := db.Exec(indexSQL).Error; err != nil {
-			return fmt.Errorf("failed to create index: %w", err)
+func partitionTables(db *database.DB, logger *logger.Logger) {
+	logger.Info("Partitioning high-volume tables...")
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		return
+	}
+
+	ctx := context.Background()
+	manager := database.NewPartitionManager(db, database.PartitionConfig{
+		MonthsAhead:          cfg.Database.PartitionMonthsAhead,
+		AIJobRetentionMonths: cfg.Database.AIJobRetentionMonths,
+	})
+	if !manager.Supported() {
+		logger.Error("Table partitioning requires PostgreSQL")
+		return
+	}
+
+	// Make sure every table exists before converting it
+	runMigrations(db, logger)
+
+	for _, table := range manager.Tables() {
+		// Each conversion locks and copies the table, so large tables take a while
+		logger.Info("Converting table", "table", table.Name, "strategy", table.Strategy, "key", table.Column)
+		if err := manager.Convert(ctx, table, time.Now().UTC()); err != nil {
+			logger.Error("Failed to partition table", "table", table.Name, "error", err)
+			return
+		}
+	}
+
+	// Recreate indexes and foreign keys on the partitioned tables, which
+	// auto-migration derives from the models
+	if err := db.Migrate(ctx, models.GetAllModels(), database.MigrationOptions{}); err != nil {
+		logger.Error("Failed to recreate indexes", "error", err)
+		return
+	}
+
+	if _, err := manager.EnsurePartitions(ctx, time.Now().UTC()); err != nil {
+		logger.Error("Failed to create partitions", "error", err)
+		return
+	}
+
+	logger.Info("Table partitioning completed successfully")
+}
+
+func partitionStatus(db *database.DB, logger *logger.Logger) {
+	manager := database.NewPartitionManager(db, database.PartitionConfig{})
+	if !manager.Supported() {
+		logger.Error("Table partitioning requires PostgreSQL")
+		return
+	}
+
+	ctx := context.Background()
+	for _, table := range manager.Tables() {
+		partitioned, err := manager.IsPartitioned(ctx, table.Name)
+		if err != nil {
+			logger.Error("Failed to check table", "table", table.Name, "error", err)
+			continue
+		}
+		status := "✓ partitioned"
+		if !partitioned {
+			status = "✗ not partitioned"
 		}
+		logger.Info("Table partitioning", "table", table.Name, "strategy", table.Strategy, "status", status)
+	}
+
+	partitions, err := manager.Status(ctx)
+	if err != nil {
+		logger.Error("Failed to list partitions", "error", err)
+		return
+	}
+	for _, partition := range partitions {
+		logger.Info("Partition", "table", partition.Table, "partition", partition.Partition,
+			"bound", partition.Bound, "estimated_rows", partition.EstimatedRows)
+	}
+}
+
+// newTenantTransferService builds the transfer service over the configured
+// file storage, which must be the storage the server uses
+func newTenantTransferService(db *database.DB) (*services.TenantTransferService, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	var storageService services.StorageService = local.NewStorageService(cfg.Storage.Path)
+	if cfg.Storage.ColdPath != "" {
+		storageService = local.NewTieredStorageService(cfg.Storage.Path, cfg.Storage.ColdPath)
+	}
+
+	repos := postgresql.NewRepositories(db)
+	return services.NewTenantTransferService(repos.TransferRepo, repos.TenantRepo, repos.UserRepo, repos.AuditRepo, storageService), nil
+}
+
+func exportTenant(db *database.DB, logger *logger.Logger, args []string) {
+	flags := flag.NewFlagSet("export-tenant", flag.ExitOnError)
+	tenant := flags.String("tenant", "", "Tenant to export (UUID)")
+	out := flags.String("out", "", "Archive file to write")
+	flags.Parse(args)
+
+	tenantID, err := uuid.Parse(*tenant)
+	if err != nil || *out == "" {
+		logger.Error("export-tenant needs -tenant <id> and -out <file>")
+		return
+	}
+
+	transferService, err := newTenantTransferService(db)
+	if err != nil {
+		logger.Error("Failed to set up tenant export", "error", err)
+		return
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		logger.Error("Failed to create archive", "path", *out, "error", err)
+		return
+	}
+	defer file.Close()
+
+	logger.Info("Exporting tenant...", "tenant_id", tenantID)
+	manifest, err := transferService.Export(context.Background(), tenantID, uuid.Nil, file)
+	if err != nil {
+		logger.Error("Failed to export tenant", "error", err)
+		os.Remove(*out)
+		return
+	}
+
+	logger.Info("Tenant exported successfully",
+		"path", *out,
+		"subdomain", manifest.Subdomain,
+		"users", manifest.Users,
+		"folders", manifest.Folders,
+		"workflows", manifest.Workflows,
+		"documents", manifest.Documents,
+		"file_bytes", manifest.FileBytes)
+}
+
+func importTenant(db *database.DB, logger *logger.Logger, args []string) {
+	flags := flag.NewFlagSet("import-tenant", flag.ExitOnError)
+	in := flags.String("in", "", "Archive file to import")
+	subdomain := flags.String("subdomain", "", "Subdomain of the new tenant")
+	name := flags.String("name", "", "Name of the new tenant; defaults to the exported tenant's")
+	flags.Parse(args)
+
+	if *in == "" || *subdomain == "" {
+		logger.Error("import-tenant needs -in <file> and -subdomain <subdomain>")
+		return
+	}
+
+	transferService, err := newTenantTransferService(db)
+	if err != nil {
+		logger.Error("Failed to set up tenant import", "error", err)
+		return
+	}
+
+	file, err := os.Open(*in)
+	if err != nil {
+		logger.Error("Failed to open archive", "path", *in, "error", err)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		logger.Error("Failed to read archive", "path", *in, "error", err)
+		return
+	}
+
+	// The schema must be current before rows are written into it
+	runMigrations(db, logger)
+
+	logger.Info("Importing tenant...", "path", *in, "subdomain", *subdomain)
+	result, err := transferService.Import(context.Background(), file, info.Size(), services.TenantImportOptions{
+		Subdomain: *subdomain,
+		Name:      *name,
+	})
+	if err != nil {
+		logger.Error("Failed to import tenant", "error", err)
+		return
 	}
 
-	return nil
+	logger.Info("Tenant imported successfully",
+		"tenant_id", result.TenantID,
+		"subdomain", result.Subdomain,
+		"source_tenant_id", result.Source,
+		"users", result.Users.Created,
+		"folders", result.Folders.Created,
+		"workflows", result.Workflows,
+		"documents", result.Documents,
+		"file_bytes", result.FileBytes)
 }
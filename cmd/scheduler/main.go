@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/archivus/archivus/internal/app/config"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/locking"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql"
+	"github.com/archivus/archivus/pkg/logger"
+)
+
+// The scheduler queues runs of tenants' scheduled jobs as their cron
+// expressions fire; the server's workers execute them. Run as many scheduler
+// processes as needed for availability: one is elected leader through Redis
+// and the others stand by.
+func main() {
+	log := logger.New()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	databaseURL := cfg.GetDatabaseURL()
+	if databaseURL == "" {
+		log.Error("Database URL is required")
+		os.Exit(1)
+	}
+
+	db, err := database.New(databaseURL)
+	if err != nil {
+		log.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.CheckSchemaVersion(context.Background()); err != nil {
+		log.Error("Incompatible database schema", "error", err)
+		os.Exit(1)
+	}
+
+	// Without Redis there is no election; enqueueing is still conditional, so
+	// several schedulers never fire a slot twice, they only duplicate polling
+	var locker services.DistributedLocker
+	if redisLocker, err := locking.NewRedisLocker(cfg.Redis.URL); err != nil {
+		log.Warn("Distributed locks unavailable, running without leader election", "error", err)
+	} else {
+		defer redisLocker.Close()
+		locker = redisLocker
+	}
+
+	repos := postgresql.NewRepositories(db)
+	schedulerService := services.NewSchedulerService(
+		repos.ScheduledJobRepo,
+		repos.AuditRepo,
+		locker,
+		services.SchedulerServiceConfig{},
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Report the scheduler's polls for the API's /healthz
+	heartbeats := services.NewWorkerHeartbeatService(repos.HeartbeatRepo, services.WorkerHeartbeatConfig{})
+	go heartbeats.Run(ctx)
+
+	log.Info("Starting Archivus scheduler", "environment", cfg.Environment)
+	schedulerService.RunScheduler(heartbeats.Attach(ctx))
+	log.Info("Scheduler stopped")
+}
@@ -1,19 +1,37 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/archivus/archivus/internal/app/config"
 	"github.com/archivus/archivus/internal/app/server"
 	appservices "github.com/archivus/archivus/internal/app/services"
+	"github.com/archivus/archivus/internal/domain/repositories"
 	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/antivirus"
 	"github.com/archivus/archivus/internal/infrastructure/auth/supabase"
+	"github.com/archivus/archivus/internal/infrastructure/captcha"
 	"github.com/archivus/archivus/internal/infrastructure/database"
 	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/ingestion"
+	"github.com/archivus/archivus/internal/infrastructure/integrations"
+	"github.com/archivus/archivus/internal/infrastructure/llm"
+	"github.com/archivus/archivus/internal/infrastructure/locking"
+	"github.com/archivus/archivus/internal/infrastructure/notifications/email"
+	"github.com/archivus/archivus/internal/infrastructure/notifications/push"
+	"github.com/archivus/archivus/internal/infrastructure/notifications/slack"
+	"github.com/archivus/archivus/internal/infrastructure/notifications/sms"
+	"github.com/archivus/archivus/internal/infrastructure/pdf"
+	"github.com/archivus/archivus/internal/infrastructure/realtime"
 	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql"
 	"github.com/archivus/archivus/internal/infrastructure/storage/local"
+	supabasestorage "github.com/archivus/archivus/internal/infrastructure/storage/supabase"
+	"github.com/archivus/archivus/internal/infrastructure/tracing"
 	"github.com/archivus/archivus/pkg/logger"
 )
 
@@ -30,6 +48,10 @@ func main() {
 
 	log.Info("Starting Archivus DMS", "environment", cfg.Environment, "port", cfg.Server.Port)
 
+	// Export traces when an OTLP collector is configured
+	shutdownTracing := initializeTracing(cfg, log)
+	defer shutdownTracing(context.Background())
+
 	// Initialize database and repositories
 	db, err := initializeDatabase(cfg, log)
 	if err != nil {
@@ -67,6 +89,84 @@ func main() {
 	// Initialize business services with Redis cache
 	businessServices := initializeBusinessServices(repos, storageService, authService, cfg, serviceManager.CacheService, log)
 
+	// Background workers report their polls for /healthz
+	heartbeats := businessServices.WorkerHeartbeatService
+	go heartbeats.Run(context.Background())
+	workerCtx := heartbeats.Attach(context.Background())
+
+	// Send queued email and Slack notifications
+	go businessServices.NotificationService.RunOutboxWorker(workerCtx)
+
+	// Create upcoming monthly partitions and detach expired ones
+	partitionManager := database.NewPartitionManager(db, database.PartitionConfig{
+		MonthsAhead:          cfg.Database.PartitionMonthsAhead,
+		AIJobRetentionMonths: cfg.Database.AIJobRetentionMonths,
+	})
+
+	// Singleton scheduled work runs once per interval across all instances
+	taskRegistry := services.NewTaskRegistry(initializeLocker(cfg, log), services.TaskRegistryConfig{})
+	scheduledTasks := []services.ScheduledTask{
+		// Revoke temporary access grants as they expire
+		businessServices.AccessGrantService.ExpiryTask(),
+		// Reset drifted tenant storage counters to their recomputed usage
+		businessServices.RepairService.StorageReconcileTask(),
+		// Escalate, auto-complete and send reminders for pending workflow tasks
+		businessServices.WorkflowService.AutomationTask(),
+		// Archive audit logs past their tenant's retention period
+		businessServices.AuditRetentionService.RetentionTask(),
+		// Mirror new audit entries to tenants' SIEM streams
+		businessServices.AuditExportService.StreamTask(),
+		// Export or erase users' data on GDPR requests
+		businessServices.DataSubjectService.RequestTask(),
+		// Tag and categorize the documents matching bulk label searches
+		businessServices.BulkLabelService.JobTask(),
+		// Queue the AI jobs of reprocessing batches as their earlier jobs finish
+		businessServices.AIReprocessService.BatchTask(),
+		// Purge documents past the trash retention period
+		businessServices.DocumentService.TrashPurgeTask(),
+		// Delete files of direct uploads that were never finalized
+		businessServices.DirectUploadService.ExpiryTask(),
+		// Virus scan and DLP check documents held back by the download gate
+		businessServices.DocumentCheckService.CheckTask(),
+		// Upload the files dropped into tenants' watched folders
+		businessServices.FolderIngestionService.PollTask(),
+		// Import new and changed files from tenants' Google Drive, OneDrive, Dropbox and Box folders
+		businessServices.IntegrationService.SyncTask(),
+		// Archive folders on their schedules and move archived files to cold storage after the undo window
+		businessServices.ArchivalService.ArchivalTask(),
+		// Book new invoices as bills in tenants' QuickBooks Online or Xero companies
+		businessServices.AccountingService.ExportTask(),
+		// Export tenants scheduled for deletion and tear them down after the grace period
+		businessServices.TenantDeletionService.DeletionTask(),
+		// Write metered API usage to the database for billing
+		businessServices.APIUsageService.FlushTask(),
+		// Warn admins of ending trials, then suspend or downgrade expired ones
+		businessServices.TrialService.ExpiryTask(),
+		// Drop webhook delivery attempts past the log retention period
+		businessServices.WebhookService.DeliveryLogRetentionTask(),
+		// Forget workers of instances that have been gone for a day
+		heartbeats.CleanupTask(),
+		{Name: "partition_maintenance", Interval: partitionManager.Interval(), Run: partitionManager.Maintain},
+	}
+	for _, task := range scheduledTasks {
+		if err := taskRegistry.Register(task); err != nil {
+			log.Error("Failed to register scheduled task", "task", task.Name, "error", err)
+			os.Exit(1)
+		}
+	}
+	go taskRegistry.Run(workerCtx)
+
+	// Run tenants' scheduled jobs queued by cmd/scheduler
+	go businessServices.SchedulerService.RunWorker(workerCtx)
+
+	// Relay realtime events published by other server instances
+	go businessServices.RealtimeHub.Run(context.Background())
+
+	// Deliver queued webhook events with retry and backoff
+	if cfg.Features.Webhooks {
+		go businessServices.WebhookService.RunDeliveryWorker(workerCtx)
+	}
+
 	// Create HTTP server
 	srv := server.NewServer(cfg, businessServices, log)
 
@@ -120,16 +220,64 @@ func initializeDatabase(cfg *config.Config, log *logger.Logger) (*database.DB, e
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Run auto-migrations
-	log.Info("Running database migrations...")
-	if err := db.AutoMigrate(models.GetAllModels()...); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	// Trace queries made while handling requests and jobs
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to enable query tracing: %w", err)
+	}
+
+	// Migrating at startup can lock large tables, so outside development the
+	// schema is migrated by the migrate command and only checked here
+	if cfg.Database.AutoMigrate {
+		log.Info("Running database migrations...")
+		if err := db.MigrateUp(context.Background(), models.GetAllModels(), database.MigrationOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to run migrations: %w", err)
+		}
+	} else if err := db.CheckSchemaVersion(context.Background()); err != nil {
+		return nil, fmt.Errorf("incompatible database schema: %w", err)
+	}
+
+	// Catch schemas changed by hand or migrated by another build
+	if cfg.Database.VerifySchema {
+		if _, err := db.VerifySchema(context.Background(), models.GetAllModels()); err != nil {
+			return nil, fmt.Errorf("database schema verification failed: %w", err)
+		}
 	}
 
 	log.Info("Database initialized successfully")
 	return db, nil
 }
 
+// initializeTracing sets up OpenTelemetry and returns the function flushing
+// pending spans on shutdown
+func initializeTracing(cfg *config.Config, log *logger.Logger) func(context.Context) error {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "archivus-server",
+		Environment: cfg.Environment,
+		Endpoint:    cfg.Tracing.Endpoint,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Error("Failed to initialize tracing", "error", err)
+		return func(context.Context) error { return nil }
+	}
+
+	if cfg.Tracing.Endpoint != "" {
+		log.Info("Tracing initialized", "endpoint", cfg.Tracing.Endpoint, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+	return shutdown
+}
+
+// initializeLocker returns the Redis locker that coordinates scheduled work
+// between instances, or nil to run every task locally
+func initializeLocker(cfg *config.Config, log *logger.Logger) services.DistributedLocker {
+	locker, err := locking.NewRedisLocker(cfg.Redis.URL)
+	if err != nil {
+		log.Warn("Distributed locks unavailable, scheduled tasks run on every instance", "error", err)
+		return nil
+	}
+	return locker
+}
+
 // Repository initialization function
 func initializeRepositories(db *database.DB, log *logger.Logger) *postgresql.Repositories {
 	log.Info("Initializing all 13 repositories...")
@@ -153,7 +301,31 @@ func initializeRepositories(db *database.DB, log *logger.Logger) *postgresql.Rep
 }
 
 // Storage service initialization
-func initializeStorageService(cfg *config.Config, log *logger.Logger) *local.StorageService {
+func initializeStorageService(cfg *config.Config, log *logger.Logger) services.StorageService {
+	if cfg.Storage.Type == "supabase" {
+		// Signing direct uploads needs the service key
+		apiKey := cfg.Supabase.ServiceKey
+		if apiKey == "" {
+			apiKey = cfg.Supabase.APIKey
+		}
+
+		log.Info("Initializing Supabase storage service", "bucket", cfg.Supabase.Bucket)
+		storageService, err := supabasestorage.NewStorageService(supabasestorage.Config{
+			URL:    cfg.Supabase.URL,
+			APIKey: apiKey,
+			Bucket: cfg.Supabase.Bucket,
+		})
+		if err != nil {
+			log.Error("Failed to initialize storage service", "error", err)
+			os.Exit(1)
+		}
+		return storageService
+	}
+
+	if cfg.Storage.ColdPath != "" {
+		log.Info("Initializing local storage service", "path", cfg.Storage.Path, "cold_path", cfg.Storage.ColdPath)
+		return local.NewTieredStorageService(cfg.Storage.Path, cfg.Storage.ColdPath)
+	}
 	log.Info("Initializing local storage service", "path", cfg.Storage.Path)
 	return local.NewStorageService(cfg.Storage.Path)
 }
@@ -168,8 +340,9 @@ func initializeAuthService(cfg *config.Config, log *logger.Logger) *supabase.Aut
 	}
 
 	authService, err := supabase.NewAuthService(supabase.Config{
-		URL:    cfg.Supabase.URL,
-		APIKey: cfg.Supabase.APIKey,
+		URL:       cfg.Supabase.URL,
+		APIKey:    cfg.Supabase.APIKey,
+		JWTSecret: cfg.Supabase.JWTSecret,
 	})
 	if err != nil {
 		log.Error("Failed to initialize auth service", "error", err)
@@ -180,10 +353,264 @@ func initializeAuthService(cfg *config.Config, log *logger.Logger) *supabase.Aut
 	return authService
 }
 
+// Health checks of /healthz and /readyz. The instance can't serve requests
+// without the database, Redis or storage; Supabase Auth and the Claude API
+// failing only degrades it, since taking instances out of rotation wouldn't
+// help there.
+func initializeHealthChecks(
+	cfg *config.Config,
+	repos *postgresql.Repositories,
+	storageService services.StorageService,
+	authService *supabase.AuthService,
+	cacheService services.CacheService,
+) []services.HealthCheck {
+	checks := []services.HealthCheck{
+		{Name: "postgres", Critical: true, Check: repos.HealthCheck},
+		{Name: "redis", Critical: true, Check: cacheService.Ping},
+	}
+	if checker, ok := storageService.(services.HealthChecker); ok {
+		checks = append(checks, services.HealthCheck{Name: "storage", Critical: true, Check: checker.HealthCheck})
+	}
+	if authService != nil {
+		checks = append(checks, services.HealthCheck{Name: "supabase_auth", Check: authService.HealthCheck})
+	}
+	if cfg.Health.ClaudeAPIURL != "" {
+		// Unauthenticated, so any answer short of a server error means it's up
+		checks = append(checks, services.HealthCheck{
+			Name: "claude_api",
+			Check: services.HTTPHealthCheck(&http.Client{}, strings.TrimRight(cfg.Health.ClaudeAPIURL, "/")+"/v1/models",
+				map[string]string{"anthropic-version": "2023-06-01"}),
+		})
+	}
+	return checks
+}
+
+// Push provider initialization - platforms without credentials are skipped
+func initializePushProviders(cfg *config.Config, log *logger.Logger) map[models.DevicePlatform]services.PushProvider {
+	providers := make(map[models.DevicePlatform]services.PushProvider)
+
+	if cfg.Push.FCMCredentialsFile != "" {
+		fcm, err := push.NewFCMProvider(push.FCMConfig{CredentialsFile: cfg.Push.FCMCredentialsFile})
+		if err != nil {
+			log.Error("Failed to initialize FCM push provider", "error", err)
+		} else {
+			// FCM also serves web push subscriptions
+			providers[models.DevicePlatformAndroid] = fcm
+			providers[models.DevicePlatformWeb] = fcm
+		}
+	}
+
+	if cfg.Push.APNsKeyFile != "" {
+		apns, err := push.NewAPNsProvider(push.APNsConfig{
+			KeyFile:    cfg.Push.APNsKeyFile,
+			KeyID:      cfg.Push.APNsKeyID,
+			TeamID:     cfg.Push.APNsTeamID,
+			Topic:      cfg.Push.APNsTopic,
+			Production: cfg.Push.APNsProduction,
+		})
+		if err != nil {
+			log.Error("Failed to initialize APNs push provider", "error", err)
+		} else {
+			providers[models.DevicePlatformIOS] = apns
+		}
+	}
+
+	log.Info("Push providers initialized", "count", len(providers))
+	return providers
+}
+
+// initializeSMSProvider returns nil (SMS disabled) unless Twilio is configured
+func initializeSMSProvider(cfg *config.Config, log *logger.Logger) services.SMSProvider {
+	if cfg.SMS.TwilioAccountSID == "" {
+		return nil
+	}
+
+	provider, err := sms.NewTwilioProvider(sms.TwilioConfig{
+		AccountSID:     cfg.SMS.TwilioAccountSID,
+		AuthToken:      cfg.SMS.TwilioAuthToken,
+		FromNumber:     cfg.SMS.TwilioFromNumber,
+		CostPerSegment: cfg.SMS.CostPerSegment,
+	})
+	if err != nil {
+		log.Error("Failed to initialize Twilio SMS provider", "error", err)
+		return nil
+	}
+
+	log.Info("SMS provider initialized", "provider", "twilio")
+	return provider
+}
+
+// initializeCaptchaVerifier returns nil (CAPTCHAs disabled) unless a provider is configured
+func initializeCaptchaVerifier(cfg *config.Config, log *logger.Logger) services.CaptchaVerifier {
+	if cfg.Captcha.VerifyURL == "" {
+		return nil
+	}
+
+	verifier, err := captcha.NewSiteVerifier(captcha.SiteVerifierConfig{
+		VerifyURL: cfg.Captcha.VerifyURL,
+		Secret:    cfg.Captcha.Secret,
+	})
+	if err != nil {
+		log.Error("Failed to initialize CAPTCHA verifier", "error", err)
+		return nil
+	}
+
+	log.Info("CAPTCHA verifier initialized")
+	return verifier
+}
+
+// initializeVirusScanner returns nil (no virus scans) unless clamd is configured
+func initializeVirusScanner(cfg *config.Config, log *logger.Logger) services.VirusScanner {
+	if cfg.Scanning.ClamAVAddress == "" {
+		return nil
+	}
+
+	scanner, err := antivirus.NewClamAVScanner(antivirus.ClamAVConfig{
+		Address: cfg.Scanning.ClamAVAddress,
+	})
+	if err != nil {
+		log.Error("Failed to initialize ClamAV scanner", "error", err)
+		return nil
+	}
+
+	log.Info("Virus scanner initialized", "provider", "clamav")
+	return scanner
+}
+
+// initializeIngestionConnector returns nil (no watched folders) unless a
+// watch root or SFTP key is configured
+func initializeIngestionConnector(cfg *config.Config, log *logger.Logger) services.IngestionConnector {
+	if cfg.Ingestion.WatchRoot == "" && cfg.Ingestion.SSHKeyFile == "" {
+		return nil
+	}
+
+	connector, err := ingestion.NewConnector(ingestion.Config{
+		WatchRoot:         cfg.Ingestion.WatchRoot,
+		SSHKeyFile:        cfg.Ingestion.SSHKeyFile,
+		AllowPrivateHosts: cfg.Ingestion.AllowPrivateHosts,
+	})
+	if err != nil {
+		log.Error("Failed to initialize ingestion connector", "error", err)
+		return nil
+	}
+
+	log.Info("Watched folders initialized",
+		"local", connector.Supports(services.WatchedFolderLocal),
+		"sftp", connector.Supports(services.WatchedFolderSFTP))
+	return connector
+}
+
+// initializeIntegrationProviders returns the importers and accounting systems
+// whose OAuth client is configured; with none, tenants can't connect accounts
+func initializeIntegrationProviders(cfg *config.Config, log *logger.Logger) []services.IntegrationConnector {
+	var providers []services.IntegrationConnector
+	if cfg.Integrations.GoogleClientID != "" {
+		providers = append(providers, integrations.NewGoogleDrive(integrations.GoogleDriveConfig{
+			ClientID:     cfg.Integrations.GoogleClientID,
+			ClientSecret: cfg.Integrations.GoogleClientSecret,
+		}))
+	}
+	if cfg.Integrations.MicrosoftClientID != "" {
+		providers = append(providers, integrations.NewOneDrive(integrations.OneDriveConfig{
+			ClientID:     cfg.Integrations.MicrosoftClientID,
+			ClientSecret: cfg.Integrations.MicrosoftClientSecret,
+			Tenant:       cfg.Integrations.MicrosoftTenant,
+		}))
+	}
+	if cfg.Integrations.DropboxClientID != "" {
+		providers = append(providers, integrations.NewDropbox(integrations.DropboxConfig{
+			ClientID:     cfg.Integrations.DropboxClientID,
+			ClientSecret: cfg.Integrations.DropboxClientSecret,
+		}))
+	}
+	if cfg.Integrations.BoxClientID != "" {
+		providers = append(providers, integrations.NewBox(integrations.BoxConfig{
+			ClientID:            cfg.Integrations.BoxClientID,
+			ClientSecret:        cfg.Integrations.BoxClientSecret,
+			WebhookPrimaryKey:   cfg.Integrations.BoxWebhookPrimaryKey,
+			WebhookSecondaryKey: cfg.Integrations.BoxWebhookSecondaryKey,
+		}))
+	}
+	if cfg.Integrations.QuickBooksClientID != "" {
+		providers = append(providers, integrations.NewQuickBooks(integrations.QuickBooksConfig{
+			ClientID:     cfg.Integrations.QuickBooksClientID,
+			ClientSecret: cfg.Integrations.QuickBooksClientSecret,
+			Sandbox:      cfg.Integrations.QuickBooksSandbox,
+		}))
+	}
+	if cfg.Integrations.XeroClientID != "" {
+		providers = append(providers, integrations.NewXero(integrations.XeroConfig{
+			ClientID:     cfg.Integrations.XeroClientID,
+			ClientSecret: cfg.Integrations.XeroClientSecret,
+		}))
+	}
+
+	if len(providers) > 0 {
+		log.Info("Integrations initialized", "providers", len(providers))
+	}
+	return providers
+}
+
+// initializeMailer returns nil (email disabled) unless an email provider is configured
+func initializeMailer(cfg *config.Config, tenantRepo repositories.TenantRepository, log *logger.Logger) *services.Mailer {
+	var transport services.EmailTransport
+	var err error
+
+	switch cfg.Email.Provider {
+	case "":
+		return nil
+	case "smtp":
+		transport, err = email.NewSMTPTransport(email.SMTPConfig{
+			Host:     cfg.Email.SMTPHost,
+			Port:     cfg.Email.SMTPPort,
+			Username: cfg.Email.SMTPUsername,
+			Password: cfg.Email.SMTPPassword,
+		})
+	case "ses":
+		transport, err = email.NewSESTransport(email.SESConfig{
+			Region:          cfg.Email.SESRegion,
+			AccessKeyID:     cfg.Storage.AccessKey,
+			SecretAccessKey: cfg.Storage.SecretKey,
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		})
+	default:
+		err = fmt.Errorf("unknown email provider %q", cfg.Email.Provider)
+	}
+	if err != nil {
+		log.Error("Failed to initialize email transport", "error", err)
+		return nil
+	}
+
+	mailer, err := services.NewMailer(tenantRepo, transport, services.MailerConfig{
+		FromAddress:     cfg.Email.FromAddress,
+		VerifiedDomains: cfg.Email.VerifiedDomains,
+		AppURL:          cfg.Email.AppURL,
+	})
+	if err != nil {
+		log.Error("Failed to initialize mailer", "error", err)
+		return nil
+	}
+
+	log.Info("Email initialized", "provider", cfg.Email.Provider)
+	return mailer
+}
+
+// initializeNotificationSenders returns the outbox channel senders. Slack is
+// always available; email needs a mailer.
+func initializeNotificationSenders(mailer *services.Mailer) map[models.NotificationChannel]services.ChannelSender {
+	senders := map[models.NotificationChannel]services.ChannelSender{
+		models.NotifySlack: slack.NewWebhookSender(),
+	}
+	if mailer != nil {
+		senders[models.NotifyEmail] = mailer
+	}
+	return senders
+}
+
 // Business services initialization - THE BIG ONE!
 func initializeBusinessServices(
 	repos *postgresql.Repositories,
-	storageService *local.StorageService,
+	storageService services.StorageService,
 	authService *supabase.AuthService,
 	cfg *config.Config,
 	cacheService services.CacheService,
@@ -212,6 +639,7 @@ func initializeBusinessServices(
 		MaxSubdomainLength:    20,
 		ReservedSubdomains:    []string{"api", "www", "admin", "support", "mail", "ftp"},
 		DefaultStorageQuota:   5 * 1024 * 1024 * 1024, // 5GB
+		DefaultDocumentQuota:  10000,
 		DefaultAPIQuota:       1000,
 		RequireBusinessInfo:   false,
 		EnableCompliance:      true,
@@ -219,6 +647,12 @@ func initializeBusinessServices(
 		SupportedCompanySizes: []string{"1-10", "11-50", "51-200", "201-500", "500+"},
 	}
 
+	// Checks documents must pass before they can be downloaded or shared
+	requiredChecks := make([]models.DocumentCheck, len(cfg.Scanning.RequiredChecks))
+	for i, check := range cfg.Scanning.RequiredChecks {
+		requiredChecks[i] = models.DocumentCheck(check)
+	}
+
 	// Configure DocumentService
 	documentServiceConfig := services.DocumentServiceConfig{
 		MaxFileSize:            cfg.Limits.MaxFileSize,
@@ -229,18 +663,72 @@ func initializeBusinessServices(
 		EnableAIProcessing:     cfg.Features.AIProcessing,
 		EnableDuplicateCheck:   true,
 		AutoGenerateThumbnails: true,
+		TrashRetention:         cfg.Limits.TrashRetention,
+		RequiredChecks:         requiredChecks,
 	}
 
+	// Initialize transactional email (SMTP or SES); nil when not configured
+	mailer := initializeMailer(cfg, repos.TenantRepo, log)
+	var emailService services.EmailService
+	if mailer != nil {
+		emailService = mailer
+	}
+
+	// Initialize AbuseProtectionService (rate limits, CAPTCHA, share password delays)
+	abuseProtectionService := services.NewAbuseProtectionService(
+		repos.TenantRepo,
+		repos.AuditRepo,
+		cacheService,
+		initializeCaptchaVerifier(cfg, log),
+		services.AbuseProtectionConfig{
+			RateLimit:              cfg.Limits.RateLimit,
+			RateLimitWindow:        cfg.Limits.RateLimitWindow,
+			CaptchaAfterFailures:   cfg.Limits.CaptchaAfterFailures,
+			SharePasswordBaseDelay: cfg.Limits.SharePasswordBaseDelay,
+			SharePasswordMaxDelay:  cfg.Limits.SharePasswordMaxDelay,
+		},
+	)
+
 	// Initialize UserService with full dependencies
 	userService := services.NewUserService(
 		repos.UserRepo,
+		repos.RoleRepo,
 		repos.TenantRepo,
 		repos.AuditRepo,
+		repos.MFACodeRepo,
 		authService,
-		nil, // emailService - will be implemented in Phase 3
+		emailService,
 		userServiceConfig,
 		cacheService,
+		abuseProtectionService,
+	)
+
+	// Initialize RoleService for tenant-defined custom roles
+	roleService := services.NewRoleService(
+		repos.RoleRepo,
+		repos.UserRepo,
+		repos.AuditRepo,
+		cacheService,
+	)
+
+	// Initialize APIKeyService for machine-to-machine access
+	apiKeyService := services.NewAPIKeyService(
+		repos.APIKeyRepo,
+		repos.UserRepo,
+		repos.AuditRepo,
+		userService,
+	)
+
+	// Initialize AIKeyService for tenant-supplied AI provider keys (BYOK)
+	aiKeyService, err := services.NewAIKeyService(
+		repos.AIKeyRepo,
+		repos.AuditRepo,
+		cfg.AI.KeyEncryptionKey,
 	)
+	if err != nil {
+		log.Error("Invalid AI key encryption key, tenant AI keys disabled", "error", err)
+		aiKeyService, _ = services.NewAIKeyService(repos.AIKeyRepo, repos.AuditRepo, "")
+	}
 
 	// Initialize TenantService with full dependencies
 	tenantService := services.NewTenantService(
@@ -248,36 +736,206 @@ func initializeBusinessServices(
 		repos.UserRepo,
 		repos.DocumentRepo,
 		repos.AuditRepo,
+		cacheService,
 		nil, // subscriptionService - will be implemented in Phase 4
 		tenantServiceConfig,
 	)
 
-	// Initialize DocumentService with ALL 9 repositories + external services
+	// Initialize WebhookService; events are only queued when webhooks are enabled
+	webhookService := services.NewWebhookService(
+		repos.WebhookRepo,
+		repos.AuditRepo,
+		services.WebhookServiceConfig{
+			PollInterval:         10 * time.Second,
+			MaxAttempts:          8,
+			AllowInsecureURLs:    cfg.IsDevelopment(),
+			AllowPrivateNetworks: cfg.IsDevelopment(),
+		},
+	)
+	// Initialize RealtimeHub; Redis pub/sub reaches clients on every instance
+	var realtimeBroker services.RealtimeBroker
+	if broker, err := realtime.NewRedisBroker(cfg.Redis.URL); err != nil {
+		log.Warn("Realtime broker unavailable, events only reach this instance", "error", err)
+	} else {
+		realtimeBroker = broker
+	}
+	realtimeHub := services.NewRealtimeHub(realtimeBroker, services.RealtimeHubConfig{
+		HeartbeatInterval: 25 * time.Second,
+	})
+
+	eventPublisher := services.EventPublishers{realtimeHub}
+	if cfg.Features.Webhooks {
+		eventPublisher = append(eventPublisher, webhookService)
+	}
+
+	// Initialize DocumentService with ALL 12 repositories + external services
 	documentService := services.NewDocumentService(
-		repos.DocumentRepo,  // docRepo
-		repos.TenantRepo,    // tenantRepo
-		repos.UserRepo,      // userRepo
-		repos.FolderRepo,    // folderRepo
-		repos.TagRepo,       // tagRepo
-		repos.CategoryRepo,  // categoryRepo
-		repos.AuditRepo,     // auditRepo
-		repos.AIJobRepo,     // aiJobRepo
-		repos.AnalyticsRepo, // analyticsRepo
-		storageService,      // storageService
-		nil,                 // aiService - will be implemented in Phase 3
+		repos.DocumentRepo,    // docRepo
+		repos.TenantRepo,      // tenantRepo
+		repos.UserRepo,        // userRepo
+		repos.FolderRepo,      // folderRepo
+		repos.FolderACLRepo,   // folderACLRepo
+		repos.DocumentACLRepo, // documentACLRepo
+		repos.TagRepo,         // tagRepo
+		repos.CategoryRepo,    // categoryRepo
+		repos.AuditRepo,       // auditRepo
+		repos.AIJobRepo,       // aiJobRepo
+		repos.AnalyticsRepo,   // analyticsRepo
+		repos.NumberingRepo,   // numberingRepo
+		repos.FingerprintRepo, // fingerprintRepo
+		repos.DocTypeRepo,     // docTypeRepo
+		storageService,        // storageService
+		cacheService,          // cacheService
+		nil,                   // aiService - will be implemented in Phase 3
+		eventPublisher,        // events
 		documentServiceConfig,
 	)
 
+	// Initialize notification templates (tenant overrides, system defaults as fallback)
+	templateService := services.NewNotificationTemplateService(
+		repos.TemplateRepo,
+		repos.AuditRepo,
+	)
+
+	// Initialize notification dispatcher (in-app + push + critical SMS + email/Slack outbox)
+	notificationService := services.NewNotificationDispatcher(
+		repos.NotificationRepo,
+		repos.DeviceTokenRepo,
+		repos.UserRepo,
+		repos.DocumentRepo,
+		repos.TenantRepo,
+		repos.SMSMessageRepo,
+		repos.DeliveryRepo,
+		templateService,
+		initializePushProviders(cfg, log),
+		initializeSMSProvider(cfg, log),
+		initializeNotificationSenders(mailer),
+		realtimeHub,
+	)
+
+	// Initialize NetworkPolicyService (tenant IP allowlists + share geo restrictions)
+	networkPolicyService := services.NewNetworkPolicyService(
+		repos.TenantRepo,
+		repos.AuditRepo,
+		cacheService,
+	)
+
+	// Initialize ShareService (public links + access log)
+	shareService := services.NewShareService(
+		repos.ShareRepo,
+		repos.ShareAccessRepo,
+		repos.DocumentRepo,
+		repos.AuditRepo,
+		repos.AnalyticsRepo,
+		storageService,
+		documentService,
+		networkPolicyService,
+		abuseProtectionService,
+		notificationService,
+		emailService,
+		eventPublisher,
+		services.ShareServiceConfig{
+			DownloadURLExpiry: 15 * time.Minute,
+			NotifyOnAccess:    true,
+			RequiredChecks:    requiredChecks,
+		},
+	)
+
+	// Initialize AccessGrantService (temporary grant expiry + report)
+	accessGrantService := services.NewAccessGrantService(
+		repos.FolderACLRepo,
+		repos.DocumentACLRepo,
+		repos.FolderRepo,
+		repos.DocumentRepo,
+		repos.UserRepo,
+		repos.AuditRepo,
+		notificationService,
+		services.AccessGrantServiceConfig{
+			CheckInterval: 15 * time.Minute,
+			NoticeWindow:  72 * time.Hour,
+		},
+	)
+
+	// Initialize SCIMService for identity provider provisioning
+	scimService := services.NewSCIMService(
+		repos.UserRepo,
+		repos.RoleRepo,
+		repos.AuditRepo,
+		userService,
+		roleService,
+		cacheService,
+	)
+
+	// Initialize RepairService (storage usage and file reconciliation)
+	repairService := services.NewRepairService(
+		repos.RepairRepo,
+		repos.AnalyticsRepo,
+		storageService,
+		services.RepairServiceConfig{
+			StorageReconcileInterval: 6 * time.Hour,
+		},
+	)
+
+	// Initialize AuditRetentionService (audit archival to object storage)
+	auditRetentionService := services.NewAuditRetentionService(
+		repos.AuditRepo,
+		repos.TenantRepo,
+		storageService,
+		services.AuditRetentionConfig{
+			Interval:  24 * time.Hour,
+			BatchSize: 5000,
+		},
+	)
+
+	// Initialize AuditExportService (audit exports and SIEM streams)
+	auditExportService := services.NewAuditExportService(
+		repos.AuditRepo,
+		repos.AuditStreamRepo,
+		auditRetentionService,
+		services.AuditExportConfig{
+			Interval:             15 * time.Second,
+			AllowInsecureURLs:    cfg.IsDevelopment(),
+			AllowPrivateNetworks: cfg.IsDevelopment(),
+		},
+	)
+
+	// Initialize BusinessCalendarService; workflow deadlines count business days
+	businessCalendarService := services.NewBusinessCalendarService(
+		repos.TenantRepo,
+		repos.AuditRepo,
+		cacheService,
+	)
+
+	// Initialize ApprovalStampService; workflows can stamp approved PDFs
+	approvalStampService := services.NewApprovalStampService(
+		repos.DocumentRepo,
+		repos.UserRepo,
+		repos.TenantRepo,
+		repos.AuditRepo,
+		storageService,
+		pdf.NewStamper(),
+		services.ApprovalStampServiceConfig{
+			MaxFileSize: cfg.Limits.MaxFileSize,
+		},
+	)
+
 	// Initialize WorkflowService with correct dependencies
 	workflowService := services.NewWorkflowService(
-		repos.WorkflowRepo,     // workflowRepo
-		repos.WorkflowTaskRepo, // taskRepo
-		repos.DocumentRepo,     // documentRepo
-		repos.UserRepo,         // userRepo
-		repos.TenantRepo,       // tenantRepo
-		repos.AuditRepo,        // auditRepo
-		repos.NotificationRepo, // notificationRepo
-		nil,                    // notificationService - will be implemented in Phase 4
+		repos.WorkflowRepo,      // workflowRepo
+		repos.WorkflowTaskRepo,  // taskRepo
+		repos.InstanceRepo,      // instanceRepo
+		repos.ChecklistRepo,     // checklistRepo
+		repos.DocumentRepo,      // documentRepo
+		repos.UserRepo,          // userRepo
+		repos.TenantRepo,        // tenantRepo
+		repos.AuditRepo,         // auditRepo
+		repos.NotificationRepo,  // notificationRepo
+		repos.OutOfOfficeRepo,   // outOfOfficeRepo
+		repos.DocTypeRepo,       // docTypeRepo
+		notificationService,     // notificationService
+		eventPublisher,          // events
+		businessCalendarService, // calendars
+		approvalStampService,    // approvalStamps
 	)
 
 	// AnalyticsService configuration with correct fields
@@ -295,24 +953,445 @@ func initializeBusinessServices(
 		repos.UserRepo,      // userRepo
 		repos.TenantRepo,    // tenantRepo
 		repos.AuditRepo,     // auditRepo
+		repos.ChecklistRepo, // checklistRepo
 		analyticsServiceConfig,
 	)
 
+	// Initialize SchedulerService; cmd/scheduler queues tenants' scheduled jobs
+	// and the workers here run them
+	schedulerService := services.NewSchedulerService(
+		repos.ScheduledJobRepo,
+		repos.AuditRepo,
+		nil, // Leader election only matters to cmd/scheduler
+		services.SchedulerServiceConfig{},
+	)
+	jobHandlers := map[string]services.JobHandler{
+		services.JobTypeRetentionSweep:     auditRetentionService.RetentionJob(),
+		services.JobTypeExpiringDocuments:  notificationService.ExpiringDocumentsJob(),
+		services.JobTypeWorkflowAutomation: workflowService.AutomationJob(),
+		services.JobTypeAnalyticsRollup:    repairService.AnalyticsRollupJob(),
+	}
+	for jobType, handler := range jobHandlers {
+		if err := schedulerService.RegisterHandler(jobType, handler); err != nil {
+			log.Error("Failed to register scheduled job handler", "job_type", jobType, "error", err)
+		}
+	}
+
+	// Initialize LegalHoldService; owners hear about holds on their documents
+	legalHoldService := services.NewLegalHoldService(
+		repos.LegalHoldRepo,
+		repos.DocumentRepo,
+		repos.FolderRepo,
+		repos.AuditRepo,
+		notificationService,
+		services.LegalHoldServiceConfig{},
+	)
+
+	// Initialize BulkLabelService; changes run in the background in batches
+	bulkLabelService := services.NewBulkLabelService(
+		documentService,
+		repos.DocumentRepo,
+		repos.TagRepo,
+		repos.CategoryRepo,
+		repos.BulkLabelRepo,
+		repos.AuditRepo,
+		services.BulkLabelConfig{},
+	)
+
+	// Initialize AIReprocessService; batches queue their AI jobs in the background, throttled
+	aiReprocessService := services.NewAIReprocessService(
+		documentService,
+		repos.DocumentRepo,
+		repos.AIJobRepo,
+		repos.ReprocessRepo,
+		repos.AuditRepo,
+		services.AIReprocessConfig{},
+	)
+
+	// Initialize DataSubjectService; GDPR exports and erasures run in the background
+	dataSubjectService := services.NewDataSubjectService(
+		repos.DataSubjectRepo,
+		repos.UserRepo,
+		repos.AuditRepo,
+		storageService,
+		authService,
+		userService, // Revokes the erased user's sessions
+		cacheService,
+		services.DataSubjectConfig{},
+	)
+
+	// Initialize OwnershipService; both parties hear about transfers
+	ownershipService := services.NewOwnershipService(
+		repos.OwnershipRepo,
+		repos.DocumentRepo,
+		repos.FolderRepo,
+		repos.UserRepo,
+		repos.AuditRepo,
+		notificationService,
+		services.OwnershipServiceConfig{},
+	)
+
+	// Initialize TenantDeletionService; exports and teardowns run in the background
+	tenantDeletionService := services.NewTenantDeletionService(
+		repos.TenantDeletionRepo,
+		repos.TenantRepo,
+		repos.UserRepo,
+		repos.RepairRepo,
+		repos.LegalHoldRepo,
+		repos.AuditRepo,
+		storageService,
+		authService,
+		userService, // Signs out everyone but admins
+		services.TenantDeletionConfig{
+			GracePeriod: cfg.Limits.TenantDeletionGracePeriod,
+		},
+	)
+
+	// Initialize APIUsageService; counters live in Redis and are flushed to the database
+	apiUsageService := services.NewAPIUsageService(
+		repos.TenantRepo,
+		repos.APIUsageRepo,
+		cacheService,
+		services.APIUsageConfig{},
+	)
+
+	// Initialize TrialService; admins are warned before their trial ends
+	trialExpiryAction, err := services.ParseTrialExpiryAction(cfg.Limits.TrialExpiryAction)
+	if err != nil {
+		log.Error("Invalid trial expiry action, suspending expired trials", "error", err)
+		trialExpiryAction = services.TrialExpirySuspend
+	}
+	trialService := services.NewTrialService(
+		repos.TenantRepo,
+		repos.UserRepo,
+		repos.AuditRepo,
+		tenantService,
+		notificationService,
+		userService, // Signs out everyone but admins on suspension
+		services.TrialConfig{ExpiryAction: trialExpiryAction},
+	)
+
+	// Initialize SearchSuggestionService for typeahead
+	searchSuggestionService := services.NewSearchSuggestionService(
+		documentService,
+		repos.SuggestionRepo,
+		cacheService,
+		services.SearchSuggestionConfig{},
+	)
+
+	// Initialize VendorProfileService; financial extraction consults its profiles
+	vendorProfileService := services.NewVendorProfileService(
+		repos.VendorProfileRepo,
+		repos.DocumentRepo,
+		repos.AuditRepo,
+		repos.AIFeedbackRepo,
+		documentService,
+	)
+
+	// Initialize AIFeedbackService (corrections of AI output, fed back to later jobs)
+	aiFeedbackService := services.NewAIFeedbackService(repos.AIFeedbackRepo, repos.DocumentRepo, repos.EntityRepo, documentService)
+
+	// Initialize FilingSuggestionService (folders, categories and tags AI suggests for documents)
+	filingSuggestionService := services.NewFilingSuggestionService(
+		repos.FilingRepo,
+		repos.DocumentRepo,
+		repos.FolderRepo,
+		repos.TagRepo,
+		repos.CategoryRepo,
+		documentService,
+	)
+
+	// Initialize EntityService (browsing documents by the entities they mention)
+	entityService := services.NewEntityService(repos.EntityRepo, documentService)
+
+	// Initialize RelatedDocumentService (documents near by embedding or sharing entities)
+	relatedDocumentService := services.NewRelatedDocumentService(repos.DocumentChunkRepo, repos.EntityRepo, documentService)
+
+	// Initialize AIUsageService (token usage and cost per tenant, monthly AI budgets)
+	aiModelPrices, err := services.ParseAIModelPrices(cfg.AI.ModelPrices)
+	if err != nil {
+		log.Error("Invalid AI model prices, using list prices", "error", err)
+	}
+	aiUsageService := services.NewAIUsageService(
+		repos.AIUsageRepo,
+		repos.TenantRepo,
+		repos.AuditRepo,
+		eventPublisher,
+		services.AIUsageConfig{Prices: aiModelPrices},
+	)
+
+	// Initialize ChatService (questions answered from documents' passages)
+	llmFactory := llm.NewFactory(llm.Config{
+		MaxTokens: cfg.AI.OpenAI.MaxTokens,
+		OpenAI:    llm.ProviderConfig{APIKey: cfg.AI.OpenAI.APIKey, Model: cfg.AI.OpenAI.Model, EmbeddingModel: cfg.AI.OpenAI.EmbeddingModel},
+		Anthropic: llm.ProviderConfig{APIKey: cfg.AI.Anthropic.APIKey, Model: cfg.AI.Anthropic.Model},
+		Gemini:    llm.ProviderConfig{APIKey: cfg.AI.Gemini.APIKey, Model: cfg.AI.Gemini.Model, EmbeddingModel: cfg.AI.Gemini.EmbeddingModel},
+		Ollama:    llm.ProviderConfig{Model: cfg.AI.Ollama.Model, EmbeddingModel: cfg.AI.Ollama.EmbeddingModel, BaseURL: cfg.AI.Ollama.Host},
+	})
+	aiJobProviders, err := services.ParseAIJobProviders(cfg.AI.JobProviders)
+	if err != nil {
+		log.Error("Invalid AI job providers, using the platform provider", "error", err)
+	}
+	chatConfig := services.ChatServiceConfig{PlatformProvider: cfg.AI.Provider, JobProviders: aiJobProviders}
+	if cfg.Features.AIProcessing {
+		chatConfig.Providers = llmFactory.PlatformProviders()
+	}
+	chatService := services.NewChatService(
+		repos.DocumentChunkRepo,
+		repos.TenantRepo,
+		documentService,
+		aiUsageService,
+		aiKeyService,
+		llmFactory,
+		chatConfig,
+	)
+
+	// Initialize PromptTemplateService; prompts are tested on the providers chat uses
+	promptTemplateService := services.NewPromptTemplateService(
+		repos.PromptRepo,
+		repos.AuditRepo,
+		documentService,
+		chatService,
+	)
+
+	// Initialize DocumentTypeService for tenants' own document types
+	documentTypeService := services.NewDocumentTypeService(
+		repos.DocTypeRepo,
+		repos.AuditRepo,
+	)
+
+	// Initialize NumberingService; DocumentService numbers uploads from its sequences
+	numberingService := services.NewNumberingService(
+		repos.NumberingRepo,
+		repos.AuditRepo,
+	)
+
+	// Initialize TenantTransferService for moving tenants between instances
+	tenantTransferService := services.NewTenantTransferService(
+		repos.TransferRepo,
+		repos.TenantRepo,
+		repos.UserRepo,
+		repos.AuditRepo,
+		storageService,
+	)
+
+	// Initialize DirectUploadService for large files uploaded straight to storage
+	directUploadService := services.NewDirectUploadService(
+		repos.UploadSessionRepo,
+		documentService,
+		storageService,
+		services.DirectUploadServiceConfig{},
+	)
+
+	// Initialize EmailIngestionService (email-in: inbound mail to documents)
+	emailIngestionService := services.NewEmailIngestionService(
+		repos.TenantRepo,
+		repos.UserRepo,
+		repos.FolderRepo,
+		repos.AuditRepo,
+		documentService,
+		services.EmailIngestionServiceConfig{
+			Domain:         cfg.Email.InboundDomain,
+			LocalPart:      cfg.Email.InboundLocalPart,
+			WebhookSecret:  cfg.Email.InboundWebhookSecret,
+			MaxMessageSize: cfg.Email.InboundMaxMessageSize,
+		},
+	)
+
+	// Initialize FolderIngestionService (watched local and SFTP folders)
+	folderIngestionService := services.NewFolderIngestionService(
+		repos.TenantRepo,
+		repos.UserRepo,
+		repos.FolderRepo,
+		repos.AuditRepo,
+		documentService,
+		initializeIngestionConnector(cfg, log),
+		services.FolderIngestionServiceConfig{
+			PollInterval: cfg.Ingestion.PollInterval,
+		},
+	)
+
+	// Initialize IntegrationService (Google Drive, OneDrive, Dropbox and Box
+	// importers, QuickBooks Online and Xero companies)
+	integrationServiceConfig := services.IntegrationServiceConfig{
+		RedirectURL:  cfg.Integrations.RedirectURL,
+		ReturnURL:    cfg.Integrations.ReturnURL,
+		WebhookURL:   cfg.Integrations.WebhookURL,
+		SyncInterval: cfg.Integrations.SyncInterval,
+	}
+	integrationService, err := services.NewIntegrationService(
+		repos.IntegrationRepo,
+		repos.TenantRepo,
+		repos.UserRepo,
+		repos.FolderRepo,
+		repos.AuditRepo,
+		documentService,
+		initializeIntegrationProviders(cfg, log),
+		cfg.Integrations.EncryptionKey,
+		integrationServiceConfig,
+	)
+	if err != nil {
+		log.Error("Invalid integrations encryption key, integrations disabled", "error", err)
+		integrationService, _ = services.NewIntegrationService(repos.IntegrationRepo, repos.TenantRepo, repos.UserRepo,
+			repos.FolderRepo, repos.AuditRepo, documentService, nil, "", integrationServiceConfig)
+	}
+
+	// Initialize AccountingService (invoice export to QuickBooks Online and Xero)
+	accountingService := services.NewAccountingService(
+		repos.AccountingRepo,
+		repos.LineItemRepo,
+		repos.DocumentRepo,
+		repos.TenantRepo,
+		repos.AuditRepo,
+		integrationService,
+		services.AccountingConfig{},
+	)
+
+	// Initialize LineItemService (correcting extracted invoice line items)
+	lineItemService := services.NewLineItemService(repos.LineItemRepo, documentService, repos.AuditRepo)
+
+	// Initialize ArchivalService (scheduled folder archival)
+	archivalService := services.NewArchivalService(
+		repos.ArchivalRepo,
+		repos.FolderRepo,
+		repos.TenantRepo,
+		repos.AuditRepo,
+		storageService,
+		notificationService,
+		services.ArchivalConfig{
+			UndoWindow: cfg.Limits.ArchivalUndoWindow,
+		},
+	)
+
+	// Initialize DocumentCheckService (virus scan and DLP for the download gate)
+	documentCheckService := services.NewDocumentCheckService(
+		repos.DocumentRepo,
+		repos.AIJobRepo,
+		storageService,
+		initializeVirusScanner(cfg, log),
+		services.DocumentCheckServiceConfig{
+			RequiredChecks: requiredChecks,
+			CheckInterval:  cfg.Scanning.CheckInterval,
+		},
+	)
+
+	// Initialize WorkerHeartbeatService (live background workers for /healthz)
+	workerHeartbeatService := services.NewWorkerHeartbeatService(repos.HeartbeatRepo, services.WorkerHeartbeatConfig{})
+
+	// Initialize HealthService (dependency checks for /healthz and /readyz)
+	healthService := services.NewHealthService(
+		initializeHealthChecks(cfg, repos, storageService, authService, cacheService),
+		workerHeartbeatService,
+		services.HealthServiceConfig{Timeout: cfg.Health.CheckTimeout},
+	)
+
 	log.Info("🎉 Business services initialized successfully!",
 		"user_service", userService != nil,
+		"role_service", roleService != nil,
+		"api_key_service", apiKeyService != nil,
+		"ai_key_service", aiKeyService != nil,
 		"tenant_service", tenantService != nil,
 		"document_service", documentService != nil,
 		"workflow_service", workflowService != nil,
 		"analytics_service", analyticsService != nil,
+		"notification_service", notificationService != nil,
+		"access_grant_service", accessGrantService != nil,
+		"network_policy_service", networkPolicyService != nil,
+		"scim_service", scimService != nil,
+		"repair_service", repairService != nil,
+		"share_service", shareService != nil,
+		"webhook_service", webhookService != nil,
+		"realtime_hub", realtimeHub != nil,
+		"audit_retention_service", auditRetentionService != nil,
+		"audit_export_service", auditExportService != nil,
+		"scheduler_service", schedulerService != nil,
+		"legal_hold_service", legalHoldService != nil,
+		"bulk_label_service", bulkLabelService != nil,
+		"ai_reprocess_service", aiReprocessService != nil,
+		"prompt_template_service", promptTemplateService != nil,
+		"document_type_service", documentTypeService != nil,
+		"business_calendar_service", businessCalendarService != nil,
+		"data_subject_service", dataSubjectService != nil,
+		"ownership_service", ownershipService != nil,
+		"tenant_deletion_service", tenantDeletionService != nil,
+		"api_usage_service", apiUsageService != nil,
+		"search_suggestion_service", searchSuggestionService != nil,
+		"trial_service", trialService != nil,
+		"vendor_profile_service", vendorProfileService != nil,
+		"numbering_service", numberingService != nil,
+		"tenant_transfer_service", tenantTransferService != nil,
+		"direct_upload_service", directUploadService != nil,
+		"email_ingestion_service", emailIngestionService != nil,
+		"folder_ingestion_service", folderIngestionService != nil,
+		"integration_service", integrationService != nil,
+		"archival_service", archivalService != nil,
+		"accounting_service", accountingService != nil,
+		"line_item_service", lineItemService != nil,
+		"ai_feedback_service", aiFeedbackService != nil,
+		"ai_usage_service", aiUsageService != nil,
+		"chat_service", chatService != nil,
+		"filing_suggestion_service", filingSuggestionService != nil,
+		"entity_service", entityService != nil,
+		"related_document_service", relatedDocumentService != nil,
+		"document_check_service", documentCheckService != nil,
+		"worker_heartbeat_service", workerHeartbeatService != nil,
+		"health_service", healthService != nil,
 	)
 
 	return &server.Services{
-		UserService:      userService,
-		TenantService:    tenantService,
-		DocumentService:  documentService,
-		WorkflowService:  workflowService,
-		AIService:        nil, // Will be implemented in Phase 3
-		AnalyticsService: analyticsService,
-		AuthService:      authService, // Fixed: Pass the auth service
+		UserService:             userService,
+		RoleService:             roleService,
+		APIKeyService:           apiKeyService,
+		AIKeyService:            aiKeyService,
+		TenantService:           tenantService,
+		DocumentService:         documentService,
+		WorkflowService:         workflowService,
+		AIService:               nil, // Will be implemented in Phase 3
+		AnalyticsService:        analyticsService,
+		NotificationService:     notificationService,
+		TemplateService:         templateService,
+		ShareService:            shareService,
+		AccessGrantService:      accessGrantService,
+		NetworkPolicyService:    networkPolicyService,
+		AbuseProtectionService:  abuseProtectionService,
+		SCIMService:             scimService,
+		RepairService:           repairService,
+		WebhookService:          webhookService,
+		RealtimeHub:             realtimeHub,
+		AuditRetentionService:   auditRetentionService,
+		AuditExportService:      auditExportService,
+		SchedulerService:        schedulerService,
+		LegalHoldService:        legalHoldService,
+		BulkLabelService:        bulkLabelService,
+		AIReprocessService:      aiReprocessService,
+		PromptTemplateService:   promptTemplateService,
+		DocumentTypeService:     documentTypeService,
+		BusinessCalendarService: businessCalendarService,
+		DataSubjectService:      dataSubjectService,
+		OwnershipService:        ownershipService,
+		TenantDeletionService:   tenantDeletionService,
+		APIUsageService:         apiUsageService,
+		SearchSuggestionService: searchSuggestionService,
+		TrialService:            trialService,
+		VendorProfileService:    vendorProfileService,
+		NumberingService:        numberingService,
+		TenantTransferService:   tenantTransferService,
+		DirectUploadService:     directUploadService,
+		EmailIngestionService:   emailIngestionService,
+		FolderIngestionService:  folderIngestionService,
+		IntegrationService:      integrationService,
+		ArchivalService:         archivalService,
+		AccountingService:       accountingService,
+		LineItemService:         lineItemService,
+		AIFeedbackService:       aiFeedbackService,
+		AIUsageService:          aiUsageService,
+		ChatService:             chatService,
+		FilingSuggestionService: filingSuggestionService,
+		EntityService:           entityService,
+		RelatedDocumentService:  relatedDocumentService,
+		DocumentCheckService:    documentCheckService,
+		WorkerHeartbeatService:  workerHeartbeatService,
+		HealthService:           healthService,
+		AuthService:             authService, // Fixed: Pass the auth service
 	}
 }
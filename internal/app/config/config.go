@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -11,27 +12,61 @@ import (
 )
 
 type Config struct {
-	Environment string
-	Server      ServerConfig
-	Database    DatabaseConfig
-	Redis       RedisConfig
-	JWT         JWTConfig
-	Storage     StorageConfig
-	Supabase    SupabaseConfig
-	AI          AIConfig
-	Features    FeatureConfig
-	Limits      LimitsConfig
+	Environment  string
+	Server       ServerConfig
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	JWT          JWTConfig
+	Storage      StorageConfig
+	Supabase     SupabaseConfig
+	AI           AIConfig
+	Features     FeatureConfig
+	Limits       LimitsConfig
+	Push         PushConfig
+	SMS          SMSConfig
+	Email        EmailConfig
+	Captcha      CaptchaConfig
+	Scanning     ScanningConfig
+	Ingestion    IngestionConfig
+	Integrations IntegrationsConfig
+	Tracing      TracingConfig
+	Health       HealthConfig
 }
 
 type ServerConfig struct {
 	Host           string
 	Port           string
 	AllowedOrigins []string
+
+	// TrustedProxies lists the proxy IPs or CIDRs whose X-Forwarded-For and
+	// geo headers are believed. Empty trusts no proxy and uses the peer address.
+	TrustedProxies []string
+
+	// TrustedPlatform names a CDN header carrying the client IP (e.g.
+	// CF-Connecting-IP). Only set it when the server is unreachable except
+	// through that platform, as the header is believed from any peer.
+	TrustedPlatform string
+
+	// GeoCountryHeader is the edge proxy header carrying the client's country.
+	// It is only read on requests arriving from a trusted proxy.
+	GeoCountryHeader string
 }
 
 type DatabaseConfig struct {
 	URL     string
 	TestURL string
+
+	// AutoMigrate migrates the schema at startup. Production deploys run the
+	// migrate command instead, and the server only checks the schema version.
+	AutoMigrate bool
+
+	// VerifySchema refuses to start when the schema differs from the models.
+	// On by default in production.
+	VerifySchema bool
+
+	// Partition maintenance for high-volume tables
+	PartitionMonthsAhead int
+	AIJobRetentionMonths int
 }
 
 type RedisConfig struct {
@@ -52,6 +87,7 @@ type JWTConfig struct {
 type StorageConfig struct {
 	Type      string
 	Path      string
+	ColdPath  string // Local cold tier for archived files; unset disables cold storage
 	S3Bucket  string
 	S3Region  string
 	AccessKey string
@@ -67,20 +103,52 @@ type SupabaseConfig struct {
 }
 
 type AIConfig struct {
-	OpenAI  OpenAIConfig
-	Ollama  OllamaConfig
-	Enabled bool
+	OpenAI    OpenAIConfig
+	Anthropic AnthropicConfig
+	Gemini    GeminiConfig
+	Ollama    OllamaConfig
+	Enabled   bool
+
+	// Provider is the platform's provider: openai, anthropic, gemini or
+	// ollama for local models
+	Provider string
+
+	// JobProviders run job types on other providers, as job_type=provider
+	JobProviders []string
+
+	// KeyEncryptionKey encrypts tenant-supplied provider keys (base64, 32 bytes)
+	KeyEncryptionKey string
+
+	// DryRun returns deterministic AI results without calling providers
+	DryRun bool
+
+	// ModelPrices override the list prices AI usage is costed at, as
+	// model=input:output in USD per million tokens
+	ModelPrices []string
 }
 
 type OpenAIConfig struct {
-	APIKey    string
-	Model     string
-	MaxTokens int
+	APIKey         string
+	Model          string
+	EmbeddingModel string
+	MaxTokens      int
+}
+
+type AnthropicConfig struct {
+	APIKey string
+	Model  string
+}
+
+type GeminiConfig struct {
+	APIKey         string
+	Model          string
+	EmbeddingModel string
 }
 
 type OllamaConfig struct {
-	Host  string
-	Model string
+	Host           string
+	Model          string
+	EmbeddingModel string
 }
 
 type FeatureConfig struct {
@@ -89,11 +157,130 @@ type FeatureConfig struct {
 	Webhooks     bool
 }
 
+type PushConfig struct {
+	FCMCredentialsFile string
+	APNsKeyFile        string
+	APNsKeyID          string
+	APNsTeamID         string
+	APNsTopic          string
+	APNsProduction     bool
+}
+
+type SMSConfig struct {
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	TwilioFromNumber string
+	CostPerSegment   float64 // fallback cost estimate when the provider doesn't report a price
+}
+
+type EmailConfig struct {
+	Provider        string // smtp, ses, or empty to disable email
+	FromAddress     string // e.g. "Archivus <notifications@example.com>"
+	VerifiedDomains []string
+	AppURL          string // Web app base URL for links in emails
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	SESRegion string
+
+	// Email-in: mail to <InboundLocalPart>+<tenant subdomain>@InboundDomain
+	// becomes documents. The mail provider posts it to the inbound webhook
+	// with InboundWebhookSecret. Empty InboundDomain disables email-in.
+	InboundDomain         string
+	InboundLocalPart      string
+	InboundWebhookSecret  string
+	InboundMaxMessageSize int64
+}
+
 type LimitsConfig struct {
 	MaxFileSize      int64
 	AllowedFileTypes []string
 	RateLimit        int
 	RateLimitWindow  time.Duration
+
+	// Abuse protection of public endpoints; tenants can override these
+	CaptchaAfterFailures   int
+	SharePasswordBaseDelay time.Duration
+	SharePasswordMaxDelay  time.Duration
+
+	// How long deleted documents stay restorable before they're purged
+	TrashRetention time.Duration
+
+	// How long a folder archival run can be undone; files move to cold
+	// storage only afterwards
+	ArchivalUndoWindow time.Duration
+
+	// How long a tenant scheduled for deletion can still export or cancel
+	TenantDeletionGracePeriod time.Duration
+
+	// What happens to a tenant when its trial ends: "suspend" or "downgrade"
+	TrialExpiryAction string
+}
+
+// ScanningConfig configures the checks documents must pass before they can
+// be downloaded or shared
+type ScanningConfig struct {
+	RequiredChecks []string // virus_scan, dlp; empty gates nothing
+	ClamAVAddress  string   // host:port of clamd, needed for virus_scan
+	CheckInterval  time.Duration
+}
+
+// IngestionConfig configures watched folders. Without a watch root or SSH
+// key tenants can't add watched folders of that type.
+type IngestionConfig struct {
+	WatchRoot         string // Local watched folders are directories under it
+	SSHKeyFile        string // Private key the server signs in to SFTP servers with
+	AllowPrivateHosts bool   // Allow SFTP servers on private networks, for self-hosted installs
+	PollInterval      time.Duration
+}
+
+// IntegrationsConfig configures the Google Drive, OneDrive, Dropbox and Box
+// importers and the QuickBooks Online and Xero accounting exports. Without an encryption key no accounts can be connected; a
+// provider is offered once its OAuth client is set.
+type IntegrationsConfig struct {
+	EncryptionKey          string // Base64 32-byte key the refresh tokens are encrypted with
+	RedirectURL            string // Public URL of /api/v1/integrations/oauth/callback
+	ReturnURL              string // Where the browser goes once an account is connected
+	GoogleClientID         string
+	GoogleClientSecret     string
+	MicrosoftClientID      string
+	MicrosoftClientSecret  string
+	MicrosoftTenant        string // Directory accounts sign in from; common allows any
+	DropboxClientID        string
+	DropboxClientSecret    string // Also signs Dropbox's webhooks
+	BoxClientID            string
+	BoxClientSecret        string
+	BoxWebhookPrimaryKey   string // Box webhook signature keys
+	BoxWebhookSecondaryKey string
+	QuickBooksClientID     string
+	QuickBooksClientSecret string
+	QuickBooksSandbox      bool // Connect Intuit's sandbox companies instead of real ones
+	XeroClientID           string
+	XeroClientSecret       string
+	WebhookURL             string // Public URL of /api/v1/integrations/webhooks
+	SyncInterval           time.Duration
+}
+
+// TracingConfig configures OpenTelemetry trace export; without an endpoint
+// no spans are exported
+type TracingConfig struct {
+	Endpoint    string  // OTLP/HTTP collector URL, e.g. http://otel-collector:4318
+	SampleRatio float64 // Share of new traces recorded
+}
+
+// HealthConfig configures the dependency checks of /healthz and /readyz
+type HealthConfig struct {
+	CheckTimeout time.Duration
+	ClaudeAPIURL string // Probed for reachability; empty skips the check
+}
+
+// CaptchaConfig configures the CAPTCHA provider; empty disables CAPTCHAs
+type CaptchaConfig struct {
+	VerifyURL string
+	Secret    string
 }
 
 // Load configuration from environment variables
@@ -112,10 +299,18 @@ func Load() (*Config, error) {
 			Host:           getEnv("HOST", "localhost"),
 			Port:           getEnv("PORT", "8080"),
 			AllowedOrigins: strings.Split(getEnv("ALLOWED_ORIGINS", "http://localhost:3000"), ","),
+
+			TrustedProxies:   splitList(getEnv("TRUSTED_PROXIES", "")),
+			TrustedPlatform:  getEnv("TRUSTED_PLATFORM", ""),
+			GeoCountryHeader: getEnv("GEO_COUNTRY_HEADER", ""),
 		},
 		Database: DatabaseConfig{
-			URL:     getEnv("DATABASE_URL", ""),
-			TestURL: getEnv("DATABASE_URL_TEST", ""),
+			URL:                  getEnv("DATABASE_URL", ""),
+			TestURL:              getEnv("DATABASE_URL_TEST", ""),
+			AutoMigrate:          parseBool(getEnv("DB_AUTO_MIGRATE", strconv.FormatBool(getEnv("ENVIRONMENT", "development") == "development"))),
+			VerifySchema:         parseBool(getEnv("DB_VERIFY_SCHEMA", strconv.FormatBool(getEnv("ENVIRONMENT", "development") == "production"))),
+			PartitionMonthsAhead: parseInt(getEnv("DB_PARTITION_MONTHS_AHEAD", "3")),
+			AIJobRetentionMonths: parseInt(getEnv("DB_AI_JOB_RETENTION_MONTHS", "6")),
 		},
 		Redis: RedisConfig{
 			URL:      getEnv("REDIS_URL", "redis://localhost:6379"),
@@ -133,6 +328,7 @@ func Load() (*Config, error) {
 		Storage: StorageConfig{
 			Type:      getEnv("STORAGE_TYPE", "local"),
 			Path:      getEnv("STORAGE_PATH", "./uploads"),
+			ColdPath:  getEnv("STORAGE_COLD_PATH", ""),
 			S3Bucket:  getEnv("S3_BUCKET", ""),
 			S3Region:  getEnv("S3_REGION", "us-west-2"),
 			AccessKey: getEnv("AWS_ACCESS_KEY_ID", ""),
@@ -147,15 +343,31 @@ func Load() (*Config, error) {
 		},
 		AI: AIConfig{
 			OpenAI: OpenAIConfig{
-				APIKey:    getEnv("OPENAI_API_KEY", ""),
-				Model:     getEnv("OPENAI_MODEL", "gpt-3.5-turbo"),
-				MaxTokens: parseInt(getEnv("OPENAI_MAX_TOKENS", "1000")),
+				APIKey:         getEnv("OPENAI_API_KEY", ""),
+				Model:          getEnv("OPENAI_MODEL", "gpt-3.5-turbo"),
+				EmbeddingModel: getEnv("OPENAI_EMBEDDING_MODEL", "text-embedding-3-small"),
+				MaxTokens:      parseInt(getEnv("OPENAI_MAX_TOKENS", "1000")),
+			},
+			Anthropic: AnthropicConfig{
+				APIKey: getEnv("ANTHROPIC_API_KEY", ""),
+				Model:  getEnv("ANTHROPIC_MODEL", "claude-3-5-sonnet-latest"),
+			},
+			Gemini: GeminiConfig{
+				APIKey:         getEnv("GEMINI_API_KEY", ""),
+				Model:          getEnv("GEMINI_MODEL", "gemini-1.5-flash"),
+				EmbeddingModel: getEnv("GEMINI_EMBEDDING_MODEL", "text-embedding-004"),
 			},
 			Ollama: OllamaConfig{
-				Host:  getEnv("OLLAMA_HOST", "http://localhost:11434"),
-				Model: getEnv("OLLAMA_MODEL", "llama2"),
+				Host:           getEnv("OLLAMA_HOST", "http://localhost:11434"),
+				Model:          getEnv("OLLAMA_MODEL", "llama2"),
+				EmbeddingModel: getEnv("OLLAMA_EMBEDDING_MODEL", "nomic-embed-text"),
 			},
-			Enabled: parseBool(getEnv("ENABLE_AI_PROCESSING", "false")),
+			Provider:         getEnv("AI_PROVIDER", "openai"),
+			JobProviders:     splitList(getEnv("AI_JOB_PROVIDERS", "")),
+			Enabled:          parseBool(getEnv("ENABLE_AI_PROCESSING", "false")),
+			KeyEncryptionKey: getEnv("AI_KEY_ENCRYPTION_KEY", ""),
+			DryRun:           parseBool(getEnv("AI_DRY_RUN", "false")),
+			ModelPrices:      splitList(getEnv("AI_MODEL_PRICES", "")),
 		},
 		Features: FeatureConfig{
 			AIProcessing: parseBool(getEnv("ENABLE_AI_PROCESSING", "false")),
@@ -167,6 +379,94 @@ func Load() (*Config, error) {
 			AllowedFileTypes: strings.Split(getEnv("ALLOWED_FILE_TYPES", "pdf,doc,docx,txt,jpg,jpeg,png"), ","),
 			RateLimit:        parseInt(getEnv("RATE_LIMIT_REQUESTS", "100")),
 			RateLimitWindow:  parseDuration(getEnv("RATE_LIMIT_WINDOW", "60s")),
+
+			CaptchaAfterFailures:   parseInt(getEnv("CAPTCHA_AFTER_FAILURES", "3")),
+			SharePasswordBaseDelay: parseDuration(getEnv("SHARE_PASSWORD_BASE_DELAY", "1s")),
+			SharePasswordMaxDelay:  parseDuration(getEnv("SHARE_PASSWORD_MAX_DELAY", "5m")),
+
+			TrashRetention: parseDuration(getEnv("TRASH_RETENTION", "720h")),
+
+			ArchivalUndoWindow: parseDuration(getEnv("ARCHIVAL_UNDO_WINDOW", "168h")),
+
+			TenantDeletionGracePeriod: parseDuration(getEnv("TENANT_DELETION_GRACE_PERIOD", "720h")),
+
+			TrialExpiryAction: getEnv("TRIAL_EXPIRY_ACTION", "suspend"),
+		},
+		Push: PushConfig{
+			FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
+			APNsKeyFile:        getEnv("APNS_KEY_FILE", ""),
+			APNsKeyID:          getEnv("APNS_KEY_ID", ""),
+			APNsTeamID:         getEnv("APNS_TEAM_ID", ""),
+			APNsTopic:          getEnv("APNS_TOPIC", ""),
+			APNsProduction:     parseBool(getEnv("APNS_PRODUCTION", "false")),
+		},
+		SMS: SMSConfig{
+			TwilioAccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:  getEnv("TWILIO_AUTH_TOKEN", ""),
+			TwilioFromNumber: getEnv("TWILIO_FROM_NUMBER", ""),
+			CostPerSegment:   parseFloat(getEnv("SMS_COST_PER_SEGMENT", "0.0079")),
+		},
+		Email: EmailConfig{
+			Provider:        getEnv("EMAIL_PROVIDER", ""),
+			FromAddress:     getEnv("EMAIL_FROM_ADDRESS", "Archivus <no-reply@localhost>"),
+			VerifiedDomains: splitList(getEnv("EMAIL_VERIFIED_DOMAINS", "")),
+			AppURL:          getEnv("APP_URL", "http://localhost:3000"),
+			SMTPHost:        getEnv("SMTP_HOST", ""),
+			SMTPPort:        parseInt(getEnv("SMTP_PORT", "587")),
+			SMTPUsername:    getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:    getEnv("SMTP_PASSWORD", ""),
+			SESRegion:       getEnv("SES_REGION", getEnv("S3_REGION", "us-west-2")),
+
+			InboundDomain:         getEnv("EMAIL_INBOUND_DOMAIN", ""),
+			InboundLocalPart:      getEnv("EMAIL_INBOUND_LOCAL_PART", "docs"),
+			InboundWebhookSecret:  getEnv("EMAIL_INBOUND_WEBHOOK_SECRET", ""),
+			InboundMaxMessageSize: parseInt64(getEnv("EMAIL_INBOUND_MAX_MESSAGE_SIZE", "26214400")), // 25MB
+		},
+		Captcha: CaptchaConfig{
+			VerifyURL: getEnv("CAPTCHA_VERIFY_URL", ""),
+			Secret:    getEnv("CAPTCHA_SECRET", ""),
+		},
+		Scanning: ScanningConfig{
+			RequiredChecks: splitList(getEnv("DOWNLOAD_REQUIRED_CHECKS", "")),
+			ClamAVAddress:  getEnv("CLAMAV_ADDRESS", ""),
+			CheckInterval:  parseDuration(getEnv("DOCUMENT_CHECK_INTERVAL", "1m")),
+		},
+		Ingestion: IngestionConfig{
+			WatchRoot:         getEnv("INGEST_WATCH_ROOT", ""),
+			SSHKeyFile:        getEnv("INGEST_SSH_KEY_FILE", ""),
+			AllowPrivateHosts: parseBool(getEnv("INGEST_SFTP_ALLOW_PRIVATE_HOSTS", "false")),
+			PollInterval:      parseDuration(getEnv("INGEST_POLL_INTERVAL", "1m")),
+		},
+		Integrations: IntegrationsConfig{
+			EncryptionKey:          getEnv("INTEGRATIONS_ENCRYPTION_KEY", ""),
+			RedirectURL:            getEnv("INTEGRATIONS_REDIRECT_URL", ""),
+			ReturnURL:              getEnv("INTEGRATIONS_RETURN_URL", ""),
+			GoogleClientID:         getEnv("GOOGLE_DRIVE_CLIENT_ID", ""),
+			GoogleClientSecret:     getEnv("GOOGLE_DRIVE_CLIENT_SECRET", ""),
+			MicrosoftClientID:      getEnv("ONEDRIVE_CLIENT_ID", ""),
+			MicrosoftClientSecret:  getEnv("ONEDRIVE_CLIENT_SECRET", ""),
+			MicrosoftTenant:        getEnv("ONEDRIVE_TENANT", "common"),
+			DropboxClientID:        getEnv("DROPBOX_CLIENT_ID", ""),
+			DropboxClientSecret:    getEnv("DROPBOX_CLIENT_SECRET", ""),
+			BoxClientID:            getEnv("BOX_CLIENT_ID", ""),
+			BoxClientSecret:        getEnv("BOX_CLIENT_SECRET", ""),
+			BoxWebhookPrimaryKey:   getEnv("BOX_WEBHOOK_PRIMARY_KEY", ""),
+			BoxWebhookSecondaryKey: getEnv("BOX_WEBHOOK_SECONDARY_KEY", ""),
+			QuickBooksClientID:     getEnv("QUICKBOOKS_CLIENT_ID", ""),
+			QuickBooksClientSecret: getEnv("QUICKBOOKS_CLIENT_SECRET", ""),
+			QuickBooksSandbox:      parseBool(getEnv("QUICKBOOKS_SANDBOX", "false")),
+			XeroClientID:           getEnv("XERO_CLIENT_ID", ""),
+			XeroClientSecret:       getEnv("XERO_CLIENT_SECRET", ""),
+			WebhookURL:             getEnv("INTEGRATIONS_WEBHOOK_URL", ""),
+			SyncInterval:           parseDuration(getEnv("INTEGRATIONS_SYNC_INTERVAL", "5m")),
+		},
+		Tracing: TracingConfig{
+			Endpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+			SampleRatio: parseFloat(getEnv("OTEL_TRACES_SAMPLER_ARG", "1")),
+		},
+		Health: HealthConfig{
+			CheckTimeout: parseDuration(getEnv("HEALTH_CHECK_TIMEOUT", "3s")),
+			ClaudeAPIURL: getEnv("CLAUDE_API_URL", "https://api.anthropic.com"),
 		},
 	}
 
@@ -209,8 +509,41 @@ func validate(config *Config) error {
 	if config.JWT.Secret == "" {
 		return fmt.Errorf("JWT_SECRET is required")
 	}
-	if config.Features.AIProcessing && config.AI.OpenAI.APIKey == "" {
-		return fmt.Errorf("OPENAI_API_KEY is required when AI processing is enabled")
+	for _, proxy := range config.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("TRUSTED_PROXIES contains an invalid IP or CIDR: %s", proxy)
+			}
+		}
+	}
+	if config.Features.AIProcessing {
+		providers := []string{config.AI.Provider}
+		for _, entry := range config.AI.JobProviders {
+			_, provider, ok := strings.Cut(entry, "=")
+			if !ok {
+				return fmt.Errorf("AI_JOB_PROVIDERS entries must be job_type=provider: %s", entry)
+			}
+			providers = append(providers, strings.TrimSpace(provider))
+		}
+		for _, provider := range providers {
+			if err := validateAIProvider(config.AI, provider); err != nil {
+				return err
+			}
+		}
+	}
+	if config.Email.InboundDomain != "" && len(config.Email.InboundWebhookSecret) < 16 {
+		return fmt.Errorf("EMAIL_INBOUND_WEBHOOK_SECRET of at least 16 characters is required when EMAIL_INBOUND_DOMAIN is set")
+	}
+	for _, check := range config.Scanning.RequiredChecks {
+		switch check {
+		case "virus_scan":
+			if config.Scanning.ClamAVAddress == "" {
+				return fmt.Errorf("CLAMAV_ADDRESS is required when virus scans are required")
+			}
+		case "dlp":
+		default:
+			return fmt.Errorf("DOWNLOAD_REQUIRED_CHECKS contains an unknown check: %s", check)
+		}
 	}
 	return nil
 }
@@ -222,6 +555,32 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// validateAIProvider checks the platform has what a provider needs: a key,
+// or for Ollama the server
+func validateAIProvider(ai AIConfig, provider string) error {
+	switch provider {
+	case "openai":
+		if ai.OpenAI.APIKey == "" {
+			return fmt.Errorf("OPENAI_API_KEY is required when AI processing runs on openai")
+		}
+	case "anthropic":
+		if ai.Anthropic.APIKey == "" {
+			return fmt.Errorf("ANTHROPIC_API_KEY is required when AI processing runs on anthropic")
+		}
+	case "gemini":
+		if ai.Gemini.APIKey == "" {
+			return fmt.Errorf("GEMINI_API_KEY is required when AI processing runs on gemini")
+		}
+	case "ollama":
+		if ai.Ollama.Host == "" {
+			return fmt.Errorf("OLLAMA_HOST is required when AI processing runs on ollama")
+		}
+	default:
+		return fmt.Errorf("AI provider must be openai, anthropic, gemini or ollama: %s", provider)
+	}
+	return nil
+}
+
 func parseInt(value string) int {
 	if i, err := strconv.Atoi(value); err == nil {
 		return i
@@ -243,9 +602,27 @@ func parseBool(value string) bool {
 	return false
 }
 
+func parseFloat(value string) float64 {
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return 0
+}
+
 func parseDuration(value string) time.Duration {
 	if d, err := time.ParseDuration(value); err == nil {
 		return d
 	}
 	return 0
 }
+
+// splitList parses a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
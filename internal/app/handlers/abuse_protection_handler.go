@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AbuseProtectionHandler manages the tenant's rate limits, CAPTCHA and share
+// password delays for public endpoints
+type AbuseProtectionHandler struct {
+	*BaseHandler
+	abuseProtectionService *services.AbuseProtectionService
+}
+
+// NewAbuseProtectionHandler creates a new abuse protection handler
+func NewAbuseProtectionHandler(abuseProtectionService *services.AbuseProtectionService) *AbuseProtectionHandler {
+	return &AbuseProtectionHandler{
+		BaseHandler:            NewBaseHandler(),
+		abuseProtectionService: abuseProtectionService,
+	}
+}
+
+// RegisterRoutes sets up the abuse protection routes
+func (h *AbuseProtectionHandler) RegisterRoutes(router *gin.RouterGroup) {
+	policy := router.Group("/abuse-protection")
+	// Note: Auth middleware should be applied at server level
+	{
+		policy.GET("", h.GetPolicy)
+		policy.PUT("", h.UpdatePolicy)
+	}
+}
+
+// Request/Response DTOs
+
+// AbuseProtectionRequest contains a tenant's abuse protection settings. Zero
+// values use the server defaults.
+type AbuseProtectionRequest struct {
+	RateLimit                     int `json:"rate_limit" binding:"min=0"`
+	RateLimitWindowSeconds        int `json:"rate_limit_window_seconds" binding:"min=0"`
+	CaptchaAfterFailures          int `json:"captcha_after_failures" binding:"min=0"`
+	SharePasswordBaseDelaySeconds int `json:"share_password_base_delay_seconds" binding:"min=0"`
+	SharePasswordMaxDelaySeconds  int `json:"share_password_max_delay_seconds" binding:"min=0"`
+}
+
+// AbuseProtectionResponse represents a tenant's abuse protection settings
+type AbuseProtectionResponse struct {
+	RateLimit                     int        `json:"rate_limit"`
+	RateLimitWindowSeconds        int        `json:"rate_limit_window_seconds"`
+	CaptchaAfterFailures          int        `json:"captcha_after_failures"`
+	SharePasswordBaseDelaySeconds int        `json:"share_password_base_delay_seconds"`
+	SharePasswordMaxDelaySeconds  int        `json:"share_password_max_delay_seconds"`
+	CaptchaEnabled                bool       `json:"captcha_enabled"`
+	UpdatedBy                     *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt                     *string    `json:"updated_at,omitempty"`
+}
+
+// Handler Methods
+
+// GetPolicy returns the tenant's abuse protection settings
+// @Summary Get abuse protection settings
+// @Description Get the tenant's rate limit, CAPTCHA threshold and share password delays for login and share links
+// @Tags abuse-protection
+// @Produce json
+// @Success 200 {object} AbuseProtectionResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /abuse-protection [get]
+func (h *AbuseProtectionHandler) GetPolicy(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.abuseProtectionService.GetPolicy(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleAbuseProtectionError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, h.convertToAbuseProtectionResponse(policy))
+}
+
+// UpdatePolicy replaces the tenant's abuse protection settings
+// @Summary Update abuse protection settings
+// @Description Replace the tenant's rate limit, CAPTCHA threshold and share password delays. Zero values use the server defaults. CAPTCHAs only apply when the server has a CAPTCHA provider.
+// @Tags abuse-protection
+// @Accept json
+// @Produce json
+// @Param request body AbuseProtectionRequest true "Abuse protection settings"
+// @Success 200 {object} AbuseProtectionResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /abuse-protection [put]
+func (h *AbuseProtectionHandler) UpdatePolicy(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req AbuseProtectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	policy, err := h.abuseProtectionService.UpdatePolicy(c.Request.Context(), services.UpdateAbuseProtectionParams{
+		TenantID:  userCtx.TenantID,
+		UpdatedBy: userCtx.UserID,
+		IPAddress: c.ClientIP(),
+		Policy: services.AbuseProtectionPolicy{
+			RateLimit:                     req.RateLimit,
+			RateLimitWindowSeconds:        req.RateLimitWindowSeconds,
+			CaptchaAfterFailures:          req.CaptchaAfterFailures,
+			SharePasswordBaseDelaySeconds: req.SharePasswordBaseDelaySeconds,
+			SharePasswordMaxDelaySeconds:  req.SharePasswordMaxDelaySeconds,
+		},
+	})
+	if err != nil {
+		h.handleAbuseProtectionError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, h.convertToAbuseProtectionResponse(policy))
+}
+
+// Helper Methods
+
+func (h *AbuseProtectionHandler) handleAbuseProtectionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAbuseProtectionPolicy):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	default:
+		h.RespondInternalError(c, "Failed to process abuse protection settings", err.Error())
+	}
+}
+
+// Conversion functions
+
+func (h *AbuseProtectionHandler) convertToAbuseProtectionResponse(policy *services.AbuseProtectionPolicy) AbuseProtectionResponse {
+	response := AbuseProtectionResponse{
+		RateLimit:                     policy.RateLimit,
+		RateLimitWindowSeconds:        policy.RateLimitWindowSeconds,
+		CaptchaAfterFailures:          policy.CaptchaAfterFailures,
+		SharePasswordBaseDelaySeconds: policy.SharePasswordBaseDelaySeconds,
+		SharePasswordMaxDelaySeconds:  policy.SharePasswordMaxDelaySeconds,
+		CaptchaEnabled:                h.abuseProtectionService.CaptchaEnabled(),
+		UpdatedBy:                     policy.UpdatedBy,
+	}
+	if policy.UpdatedAt != nil {
+		updatedAt := policy.UpdatedAt.Format("2006-01-02T15:04:05Z")
+		response.UpdatedAt = &updatedAt
+	}
+	return response
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AccessGrantHandler reports on temporary folder and document grants
+type AccessGrantHandler struct {
+	*BaseHandler
+	accessGrantService *services.AccessGrantService
+}
+
+// NewAccessGrantHandler creates a new access grant handler
+func NewAccessGrantHandler(accessGrantService *services.AccessGrantService) *AccessGrantHandler {
+	return &AccessGrantHandler{
+		BaseHandler:        NewBaseHandler(),
+		accessGrantService: accessGrantService,
+	}
+}
+
+// RegisterRoutes sets up the access grant routes
+func (h *AccessGrantHandler) RegisterRoutes(router *gin.RouterGroup) {
+	grants := router.Group("/access-grants")
+	// Note: Auth middleware should be applied at server level
+	{
+		grants.GET("/active", h.ListActiveGrants)
+	}
+}
+
+// Request/Response DTOs
+
+// ActiveGrantResponse represents one temporary grant in the active-grants report
+type ActiveGrantResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	ResourceType string     `json:"resource_type"`
+	ResourceID   uuid.UUID  `json:"resource_id"`
+	ResourceName string     `json:"resource_name"`
+	UserID       *uuid.UUID `json:"user_id,omitempty"`
+	UserEmail    string     `json:"user_email,omitempty"`
+	Role         string     `json:"role,omitempty"`
+	Permission   string     `json:"permission"`
+	GrantedBy    uuid.UUID  `json:"granted_by"`
+	ExpiresAt    string     `json:"expires_at"`
+	CreatedAt    string     `json:"created_at"`
+}
+
+// ActiveGrantsResponse is the active-grants report
+type ActiveGrantsResponse struct {
+	Grants      []ActiveGrantResponse `json:"grants"`
+	GeneratedAt string                `json:"generated_at"`
+}
+
+// Handler Methods
+
+// ListActiveGrants lists unexpired temporary grants
+// @Summary List active temporary grants
+// @Description List the tenant's time-limited folder and document grants that have not yet expired
+// @Tags access-grants
+// @Produce json
+// @Success 200 {object} ActiveGrantsResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /access-grants/active [get]
+func (h *AccessGrantHandler) ListActiveGrants(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.accessGrantService.ListActiveGrants(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list active grants", err.Error())
+		return
+	}
+
+	grants := make([]ActiveGrantResponse, 0, len(report.FolderGrants)+len(report.DocumentGrants))
+	for _, acl := range report.FolderGrants {
+		grant := ActiveGrantResponse{
+			ID:           acl.ID,
+			ResourceType: "folder",
+			ResourceID:   acl.FolderID,
+			ResourceName: acl.Folder.Name,
+			UserID:       acl.UserID,
+			Role:         string(acl.Role),
+			Permission:   string(acl.Permission),
+			GrantedBy:    acl.GrantedBy,
+			ExpiresAt:    acl.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+			CreatedAt:    acl.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		}
+		if acl.User != nil {
+			grant.UserEmail = acl.User.Email
+		}
+		grants = append(grants, grant)
+	}
+	for _, acl := range report.DocumentGrants {
+		userID := acl.UserID
+		grants = append(grants, ActiveGrantResponse{
+			ID:           acl.ID,
+			ResourceType: "document",
+			ResourceID:   acl.DocumentID,
+			ResourceName: acl.Document.Title,
+			UserID:       &userID,
+			UserEmail:    acl.User.Email,
+			Permission:   string(acl.Permission),
+			GrantedBy:    acl.GrantedBy,
+			ExpiresAt:    acl.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+			CreatedAt:    acl.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+
+	h.RespondSuccess(c, ActiveGrantsResponse{
+		Grants:      grants,
+		GeneratedAt: report.GeneratedAt.Format("2006-01-02T15:04:05Z"),
+	})
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AccountingHandler handles exporting invoices to QuickBooks Online and
+// Xero as bills and the tenant's export settings
+type AccountingHandler struct {
+	*BaseHandler
+	accountingService *services.AccountingService
+}
+
+// NewAccountingHandler creates a new accounting handler
+func NewAccountingHandler(accountingService *services.AccountingService) *AccountingHandler {
+	return &AccountingHandler{
+		BaseHandler:       NewBaseHandler(),
+		accountingService: accountingService,
+	}
+}
+
+// RegisterRoutes sets up the accounting routes
+func (h *AccountingHandler) RegisterRoutes(router *gin.RouterGroup) {
+	accounting := router.Group("/accounting")
+	// Note: Auth middleware should be applied at server level
+	{
+		accounting.GET("/settings", h.GetSettings)
+		accounting.PUT("/settings", h.UpdateSettings)
+		accounting.GET("/exports", h.ListExports)
+	}
+
+	docs := router.Group("/documents")
+	{
+		docs.POST("/:id/accounting-export", h.ExportDocument)
+	}
+}
+
+// Request/Response DTOs
+
+// UpdateAccountingSettingsRequest contains where and how invoices are exported
+type UpdateAccountingSettingsRequest struct {
+	IntegrationID  *uuid.UUID `json:"integration_id,omitempty"` // Turns exports off when empty
+	ExpenseAccount string     `json:"expense_account,omitempty"`
+	AutoExport     bool       `json:"auto_export"`
+}
+
+// Handler Methods
+
+// GetSettings returns the tenant's accounting settings
+// @Summary Get accounting settings
+// @Description Get which QuickBooks Online or Xero company invoices are exported to, and whether they are exported automatically
+// @Tags accounting
+// @Produce json
+// @Success 200 {object} services.AccountingSettings
+// @Failure 403 {object} ErrorResponse
+// @Router /accounting/settings [get]
+func (h *AccountingHandler) GetSettings(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.accountingService.GetSettings(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleAccountingError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, settings)
+}
+
+// UpdateSettings replaces the tenant's accounting settings
+// @Summary Update accounting settings
+// @Description Choose the connected QuickBooks Online or Xero company invoices are exported to and the expense account their bills are booked on. Automatic export applies to invoices uploaded after it is switched on.
+// @Tags accounting
+// @Accept json
+// @Produce json
+// @Param request body UpdateAccountingSettingsRequest true "Accounting settings"
+// @Success 200 {object} services.AccountingSettings
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /accounting/settings [put]
+func (h *AccountingHandler) UpdateSettings(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateAccountingSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	settings, err := h.accountingService.UpdateSettings(c.Request.Context(), userCtx.TenantID, userCtx.UserID, services.AccountingSettings{
+		IntegrationID:  req.IntegrationID,
+		ExpenseAccount: req.ExpenseAccount,
+		AutoExport:     req.AutoExport,
+	})
+	if err != nil {
+		h.handleAccountingError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, settings)
+}
+
+// ListExports lists the documents exported to accounting
+// @Summary List accounting exports
+// @Description List the tenant's documents that were exported as bills or failed to be, most recent first
+// @Tags accounting
+// @Produce json
+// @Param status query string false "Only exports with this status" Enums(exporting, synced, failed)
+// @Param page query int false "Page number"
+// @Param per_page query int false "Page size"
+// @Success 200 {object} PaginatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /accounting/exports [get]
+func (h *AccountingHandler) ListExports(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	status := models.AccountingSyncStatus(c.Query("status"))
+	switch status {
+	case "", models.AccountingSyncExporting, models.AccountingSyncSynced, models.AccountingSyncFailed:
+	default:
+		h.RespondBadRequest(c, "Invalid export status")
+		return
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	documents, total, err := h.accountingService.ListExports(c.Request.Context(), userCtx.TenantID, status, repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.handleAccountingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       documents,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// ExportDocument books a document's bill now
+// @Summary Export a document to accounting
+// @Description Book an invoice's extracted vendor, amounts and dates as a bill in the tenant's accounting system. Failed exports can be retried; booked ones are not booked twice.
+// @Tags accounting
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {object} models.Document
+// @Failure 400 {object} ErrorResponse "The document has no amount or vendor"
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Exports aren't set up, or the document was already exported"
+// @Failure 502 {object} ErrorResponse "The accounting system refused the bill"
+// @Router /documents/{id}/accounting-export [post]
+func (h *AccountingHandler) ExportDocument(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	document, err := h.accountingService.ExportDocument(c.Request.Context(), userCtx.TenantID, userCtx.UserID, documentID)
+	if err != nil {
+		h.handleAccountingError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, document)
+}
+
+// Helper methods
+
+func (h *AccountingHandler) handleAccountingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAccountingSettings), errors.Is(err, services.ErrDocumentNotExportable),
+		errors.Is(err, services.ErrNotAccountingIntegration), errors.Is(err, services.ErrUnknownIntegrationProvider):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrIntegrationNotFound):
+		h.RespondNotFound(c, "Integration not found")
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	case errors.Is(err, services.ErrAccountingNotConfigured):
+		h.RespondError(c, http.StatusConflict, "accounting_not_configured", err.Error())
+	case errors.Is(err, services.ErrAccountingAlreadyExported):
+		h.RespondError(c, http.StatusConflict, "accounting_already_exported", err.Error())
+	case errors.Is(err, services.ErrIntegrationTokenRevoked):
+		h.RespondError(c, http.StatusConflict, "integration_revoked", err.Error())
+	case errors.Is(err, services.ErrAccountingExportFailed):
+		h.RespondError(c, http.StatusBadGateway, "accounting_export_failed", err.Error())
+	default:
+		h.RespondInternalError(c, "Failed to process accounting request", err.Error())
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AIFeedbackHandler handles users' corrections of the summaries,
+// classifications and entities AI produced for documents
+type AIFeedbackHandler struct {
+	*BaseHandler
+	feedbackService *services.AIFeedbackService
+}
+
+// NewAIFeedbackHandler creates a new AI feedback handler
+func NewAIFeedbackHandler(feedbackService *services.AIFeedbackService) *AIFeedbackHandler {
+	return &AIFeedbackHandler{
+		BaseHandler:     NewBaseHandler(),
+		feedbackService: feedbackService,
+	}
+}
+
+// RegisterRoutes sets up the AI feedback routes
+func (h *AIFeedbackHandler) RegisterRoutes(router *gin.RouterGroup) {
+	docs := router.Group("/documents")
+	// Note: Auth middleware should be applied at server level
+	{
+		docs.GET("/:id/ai-feedback", h.ListFeedback)
+		docs.POST("/:id/ai-feedback", h.SubmitCorrections)
+	}
+}
+
+// Request/Response DTOs
+
+// AICorrectionRequest contains corrected AI output; fields left out aren't corrected
+type AICorrectionRequest struct {
+	Summary      *string                `json:"summary,omitempty"`
+	DocumentType *models.DocumentType   `json:"document_type,omitempty"`
+	Entities     map[string]interface{} `json:"entities,omitempty"` // Replaces all extracted entities
+}
+
+// Handler Methods
+
+// ListFeedback returns the corrections made to a document's AI output
+// @Summary List AI corrections
+// @Description List the corrections users made to a document's summary, classification, entities and financial data, newest first
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {array} models.AIFeedback
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/ai-feedback [get]
+func (h *AIFeedbackHandler) ListFeedback(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	feedback, err := h.feedbackService.ListFeedback(c.Request.Context(), userCtx.TenantID, userCtx.UserID, documentID)
+	if err != nil {
+		h.handleAIFeedbackError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, feedback)
+}
+
+// SubmitCorrections corrects a document's AI output
+// @Summary Correct AI output
+// @Description Correct the summary, classification or entities AI produced for a document. Corrected fields are marked verified and kept when the document is processed again, and the tenant's later jobs get the corrections as examples. Financial fields are corrected through /documents/{id}/extraction-feedback.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param request body AICorrectionRequest true "Corrections"
+// @Success 200 {object} models.Document
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/ai-feedback [post]
+func (h *AIFeedbackHandler) SubmitCorrections(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req AICorrectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	document, err := h.feedbackService.SubmitCorrections(c.Request.Context(), userCtx.TenantID, userCtx.UserID, documentID, services.AICorrectionParams{
+		Summary:      req.Summary,
+		DocumentType: req.DocumentType,
+		Entities:     req.Entities,
+	})
+	if err != nil {
+		h.handleAIFeedbackError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, document)
+}
+
+// Helper methods
+
+func (h *AIFeedbackHandler) handleAIFeedbackError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAICorrection):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrDocumentAccessDenied):
+		h.RespondError(c, http.StatusForbidden, "document_access_denied", err.Error())
+	default:
+		h.RespondInternalError(c, "Failed to process AI feedback", err.Error())
+	}
+}
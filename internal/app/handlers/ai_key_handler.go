@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AIKeyHandler manages tenant-supplied AI provider keys (bring your own key)
+type AIKeyHandler struct {
+	*BaseHandler
+	aiKeyService *services.AIKeyService
+	userService  *services.UserService
+}
+
+// NewAIKeyHandler creates a new AI key handler
+func NewAIKeyHandler(aiKeyService *services.AIKeyService, userService *services.UserService) *AIKeyHandler {
+	return &AIKeyHandler{
+		BaseHandler:  NewBaseHandler(),
+		aiKeyService: aiKeyService,
+		userService:  userService,
+	}
+}
+
+// RegisterRoutes sets up the AI key routes
+func (h *AIKeyHandler) RegisterRoutes(router *gin.RouterGroup) {
+	keys := router.Group("/ai-keys")
+	// Note: Auth middleware should be applied at server level
+	{
+		keys.GET("", h.ListAIKeys)
+		keys.PUT("/:provider", h.SetAIKey)
+		keys.PATCH("/:provider", h.UpdateAIKey)
+		keys.DELETE("/:provider", h.DeleteAIKey)
+	}
+}
+
+// Request/Response DTOs
+
+// SetAIKeyRequest contains a provider API key
+type SetAIKeyRequest struct {
+	APIKey string `json:"api_key" binding:"required"`
+}
+
+// UpdateAIKeyRequest pauses or resumes a provider key
+type UpdateAIKeyRequest struct {
+	IsEnabled *bool `json:"is_enabled" binding:"required"`
+}
+
+// AIKeyResponse represents a tenant AI provider key; the key itself is never returned
+type AIKeyResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Provider   string    `json:"provider"`
+	KeyHint    string    `json:"key_hint"`
+	IsEnabled  bool      `json:"is_enabled"`
+	CreatedBy  uuid.UUID `json:"created_by"`
+	LastUsedAt *string   `json:"last_used_at,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
+	UpdatedAt  string    `json:"updated_at"`
+}
+
+// Handler Methods
+
+// ListAIKeys lists the tenant's AI provider keys
+// @Summary List AI provider keys
+// @Description List the tenant's own AI provider keys. Jobs use these instead of the platform key when enabled.
+// @Tags ai-keys
+// @Produce json
+// @Success 200 {array} AIKeyResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /ai-keys [get]
+func (h *AIKeyHandler) ListAIKeys(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.aiKeyService.ListKeys(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list AI keys", err.Error())
+		return
+	}
+
+	response := make([]AIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, convertToAIKeyResponse(&keys[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// SetAIKey stores the tenant's key for a provider
+// @Summary Set AI provider key
+// @Description Store the tenant's own API key for openai, anthropic or gemini, replacing any existing key. The key is encrypted at rest and never returned.
+// @Tags ai-keys
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider (openai, anthropic or gemini)"
+// @Param request body SetAIKeyRequest true "Provider key"
+// @Success 200 {object} AIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /ai-keys/{provider} [put]
+func (h *AIKeyHandler) SetAIKey(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req SetAIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	key, err := h.aiKeyService.SetKey(c.Request.Context(), services.SetAIKeyParams{
+		TenantID: userCtx.TenantID,
+		Provider: c.Param("provider"),
+		APIKey:   req.APIKey,
+		SetBy:    userCtx.UserID,
+	})
+	if err != nil {
+		h.respondAIKeyError(c, err, "Failed to set AI key")
+		return
+	}
+
+	h.RespondSuccess(c, convertToAIKeyResponse(key))
+}
+
+// UpdateAIKey enables or disables the tenant's key for a provider
+// @Summary Enable or disable AI provider key
+// @Description Pause or resume use of the tenant's key; while disabled, jobs fall back to the platform key
+// @Tags ai-keys
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider (openai, anthropic or gemini)"
+// @Param request body UpdateAIKeyRequest true "Key state"
+// @Success 200 {object} AIKeyResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /ai-keys/{provider} [patch]
+func (h *AIKeyHandler) UpdateAIKey(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateAIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	key, err := h.aiKeyService.SetEnabled(c.Request.Context(), userCtx.TenantID, userCtx.UserID, c.Param("provider"), *req.IsEnabled)
+	if err != nil {
+		h.respondAIKeyError(c, err, "Failed to update AI key")
+		return
+	}
+
+	h.RespondSuccess(c, convertToAIKeyResponse(key))
+}
+
+// DeleteAIKey removes the tenant's key for a provider
+// @Summary Delete AI provider key
+// @Description Remove the tenant's key; jobs fall back to the platform key
+// @Tags ai-keys
+// @Param provider path string true "Provider (openai, anthropic or gemini)"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /ai-keys/{provider} [delete]
+func (h *AIKeyHandler) DeleteAIKey(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.aiKeyService.DeleteKey(c.Request.Context(), userCtx.TenantID, userCtx.UserID, c.Param("provider")); err != nil {
+		h.respondAIKeyError(c, err, "Failed to delete AI key")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Helper Methods
+
+func (h *AIKeyHandler) respondAIKeyError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrAIKeyNotFound):
+		h.RespondNotFound(c, "AI key not found")
+	case errors.Is(err, services.ErrInvalidAIProvider), errors.Is(err, services.ErrInvalidAIKey):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrAIKeyEncryptionDisabled):
+		h.RespondError(c, http.StatusServiceUnavailable, "ai_keys_unavailable", err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
+	}
+}
+
+// Conversion functions
+
+func convertToAIKeyResponse(key *models.TenantAIKey) AIKeyResponse {
+	response := AIKeyResponse{
+		ID:        key.ID,
+		Provider:  key.Provider,
+		KeyHint:   key.KeyHint,
+		IsEnabled: key.IsEnabled,
+		CreatedBy: key.CreatedBy,
+		LastError: key.LastError,
+		UpdatedAt: key.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if key.LastUsedAt != nil {
+		lastUsedAt := key.LastUsedAt.Format("2006-01-02T15:04:05Z")
+		response.LastUsedAt = &lastUsedAt
+	}
+	return response
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AIReprocessHandler handles queuing AI jobs again for documents in bulk
+type AIReprocessHandler struct {
+	*BaseHandler
+	reprocessService *services.AIReprocessService
+}
+
+// NewAIReprocessHandler creates a new AI reprocessing handler
+func NewAIReprocessHandler(reprocessService *services.AIReprocessService) *AIReprocessHandler {
+	return &AIReprocessHandler{
+		BaseHandler:      NewBaseHandler(),
+		reprocessService: reprocessService,
+	}
+}
+
+// RegisterRoutes sets up the AI reprocessing routes
+func (h *AIReprocessHandler) RegisterRoutes(router *gin.RouterGroup) {
+	batches := router.Group("/admin/ai/reprocess")
+	// Note: Auth middleware should be applied at server level
+	{
+		batches.POST("", h.StartReprocess)
+		batches.GET("", h.ListReprocessBatches)
+		batches.GET("/:id", h.GetReprocessBatch)
+	}
+}
+
+// Request/Response DTOs
+
+// AIReprocessRequest queues AI jobs again for every document matching the
+// filter
+type AIReprocessRequest struct {
+	JobTypes           []string                 `json:"job_types" binding:"required,min=1"`
+	Filter             AIReprocessFilterRequest `json:"filter"`
+	ProcessingPriority string                   `json:"processing_priority,omitempty"` // high, normal or low; defaults to low
+	MaxInFlight        int                      `json:"max_in_flight,omitempty"`       // Jobs of the batch waiting or running at once
+}
+
+// AIReprocessFilterRequest selects documents; empty fields match everything.
+// Dates bound when documents were created.
+type AIReprocessFilterRequest struct {
+	FolderID       *string    `json:"folder_id,omitempty"`
+	DocumentTypes  []string   `json:"document_types,omitempty"`
+	DateFrom       *time.Time `json:"date_from,omitempty"`
+	DateTo         *time.Time `json:"date_to,omitempty"`
+	MissingSummary bool       `json:"missing_summary,omitempty"`
+}
+
+// Handler Methods
+
+// StartReprocess queues a reprocessing batch
+// @Summary Reprocess documents with AI
+// @Description Queue AI jobs again, such as summarization or embedding_generation, for every document matching a filter, e.g. after prompts improved or embeddings were turned on. Jobs are queued in the background a page of documents at a time, at low priority unless asked otherwise, holding back while max_in_flight of the batch's jobs are waiting or running.
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param request body AIReprocessRequest true "Reprocessing batch"
+// @Success 202 {object} models.AIReprocessBatch
+// @Failure 400 {object} ErrorResponse
+// @Router /admin/ai/reprocess [post]
+func (h *AIReprocessHandler) StartReprocess(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req AIReprocessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	params := services.AIReprocessParams{
+		TenantID:    userCtx.TenantID,
+		RequestedBy: userCtx.UserID,
+		JobTypes:    req.JobTypes,
+		MaxInFlight: req.MaxInFlight,
+		Filters: repositories.DocumentFilters{
+			DateFrom:       req.Filter.DateFrom,
+			DateTo:         req.Filter.DateTo,
+			MissingSummary: req.Filter.MissingSummary,
+		},
+	}
+	if req.ProcessingPriority != "" {
+		priority, err := services.ParseProcessingPriority(req.ProcessingPriority)
+		if err != nil {
+			h.RespondBadRequest(c, err.Error())
+			return
+		}
+		params.Priority = priority
+	}
+	if req.Filter.FolderID != nil {
+		folderID, ok := h.ValidateUUID(c, "Folder ID", *req.Filter.FolderID)
+		if !ok {
+			return
+		}
+		params.Filters.FolderID = &folderID
+	}
+	for _, documentType := range req.Filter.DocumentTypes {
+		params.Filters.DocumentType = append(params.Filters.DocumentType, models.DocumentType(documentType))
+	}
+
+	batch, err := h.reprocessService.StartBatch(c.Request.Context(), params)
+	if err != nil {
+		h.handleReprocessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, batch)
+}
+
+// ListReprocessBatches lists the tenant's reprocessing batches
+// @Summary List AI reprocessing batches
+// @Description List the tenant's AI reprocessing batches, newest first
+// @Tags ai
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+// @Success 200 {object} PaginatedResponse
+// @Router /admin/ai/reprocess [get]
+func (h *AIReprocessHandler) ListReprocessBatches(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	batches, total, err := h.reprocessService.ListBatches(c.Request.Context(), userCtx.TenantID, repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list AI reprocessing batches", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       batches,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// GetReprocessBatch returns a reprocessing batch and its progress
+// @Summary Get AI reprocessing batch
+// @Description Get a reprocessing batch: how many documents matched and have their jobs queued, and its jobs counted by status
+// @Tags ai
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Success 200 {object} services.AIReprocessProgress
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/ai/reprocess/{id} [get]
+func (h *AIReprocessHandler) GetReprocessBatch(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	batchID, ok := h.ValidateUUID(c, "Batch ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	progress, err := h.reprocessService.GetBatch(c.Request.Context(), userCtx.TenantID, batchID)
+	if err != nil {
+		h.handleReprocessError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, progress)
+}
+
+// Helper Methods
+
+func (h *AIReprocessHandler) handleReprocessError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAIReprocessBatchNotFound):
+		h.RespondNotFound(c, "AI reprocessing batch not found")
+	case errors.Is(err, services.ErrInvalidAIReprocess),
+		errors.Is(err, services.ErrAIReprocessEmpty),
+		errors.Is(err, services.ErrAIReprocessTooLarge):
+		h.RespondBadRequest(c, err.Error())
+	default:
+		h.RespondInternalError(c, "Failed to process AI reprocessing batch", err.Error())
+	}
+}
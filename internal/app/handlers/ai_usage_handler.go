@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AIUsageHandler reports the tenant's AI token usage and cost and manages
+// its monthly AI budget
+type AIUsageHandler struct {
+	*BaseHandler
+	usageService *services.AIUsageService
+}
+
+// NewAIUsageHandler creates a new AI usage handler
+func NewAIUsageHandler(usageService *services.AIUsageService) *AIUsageHandler {
+	return &AIUsageHandler{
+		BaseHandler:  NewBaseHandler(),
+		usageService: usageService,
+	}
+}
+
+// RegisterRoutes sets up the AI usage routes
+func (h *AIUsageHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	router.GET("/tenant/ai-usage", h.GetUsage)
+	router.GET("/tenant/ai-budget", h.GetBudget)
+	router.PUT("/tenant/ai-budget", h.UpdateBudget)
+}
+
+// Request/Response DTOs
+
+// UpdateAIBudgetRequest contains the tenant's monthly AI budget
+type UpdateAIBudgetRequest struct {
+	MonthlyLimit     float64 `json:"monthly_limit"`                // USD; 0 removes the budget
+	SoftLimitPercent int     `json:"soft_limit_percent,omitempty"` // Defaults to 80
+	HardLimit        bool    `json:"hard_limit"`
+}
+
+// Handler Methods
+
+// GetUsage returns the tenant's AI usage and cost
+// @Summary Get AI usage
+// @Description The tokens and estimated cost in USD of the tenant's AI calls this month, against its budget, and the monthly history per model. Tokens are the provider's counts where it reported them and estimated from the text otherwise.
+// @Tags tenant
+// @Produce json
+// @Success 200 {object} services.AIUsageReport
+// @Failure 404 {object} ErrorResponse
+// @Router /tenant/ai-usage [get]
+func (h *AIUsageHandler) GetUsage(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.usageService.GetUsage(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleAIUsageError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, report)
+}
+
+// GetBudget returns the tenant's monthly AI budget
+// @Summary Get AI budget
+// @Description Get the tenant's monthly budget for AI calls in USD, the share of it admins are warned at, and whether AI processing stops once it's used up
+// @Tags tenant
+// @Produce json
+// @Success 200 {object} services.AIBudget
+// @Failure 404 {object} ErrorResponse
+// @Router /tenant/ai-budget [get]
+func (h *AIUsageHandler) GetBudget(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	budget, err := h.usageService.GetBudget(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleAIUsageError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, budget)
+}
+
+// UpdateBudget replaces the tenant's monthly AI budget
+// @Summary Update AI budget
+// @Description Set the tenant's monthly budget for AI calls in USD. A tenant.ai_budget_warning event is sent once a month when spending reaches the soft limit. With a hard limit, AI jobs fail once the month's spending reaches the budget; thumbnails and previews are still drawn.
+// @Tags tenant
+// @Accept json
+// @Produce json
+// @Param request body UpdateAIBudgetRequest true "AI budget"
+// @Success 200 {object} services.AIBudget
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /tenant/ai-budget [put]
+func (h *AIUsageHandler) UpdateBudget(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateAIBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	budget, err := h.usageService.UpdateBudget(c.Request.Context(), userCtx.TenantID, userCtx.UserID, services.AIBudget{
+		MonthlyLimit:     req.MonthlyLimit,
+		SoftLimitPercent: req.SoftLimitPercent,
+		HardLimit:        req.HardLimit,
+	})
+	if err != nil {
+		h.handleAIUsageError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, budget)
+}
+
+// Helper methods
+
+func (h *AIUsageHandler) handleAIUsageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAIBudget):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	default:
+		h.RespondInternalError(c, "Failed to process AI usage request", err.Error())
+	}
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/app/middleware"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// APIKeyHandler handles API key management for machine-to-machine access
+type APIKeyHandler struct {
+	*BaseHandler
+	apiKeyService *services.APIKeyService
+	userService   *services.UserService
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService, userService *services.UserService) *APIKeyHandler {
+	return &APIKeyHandler{
+		BaseHandler:   NewBaseHandler(),
+		apiKeyService: apiKeyService,
+		userService:   userService,
+	}
+}
+
+// RegisterRoutes sets up the API key management routes
+func (h *APIKeyHandler) RegisterRoutes(router *gin.RouterGroup) {
+	keys := router.Group("/api-keys")
+	// Note: Auth middleware should be applied at server level
+	{
+		keys.GET("", h.ListAPIKeys)
+		keys.POST("", h.CreateAPIKey)
+		keys.GET("/:id", h.GetAPIKey)
+		keys.PUT("/:id/scopes", h.UpdateScopes)
+		keys.POST("/:id/rotate", h.RotateAPIKey)
+		keys.DELETE("/:id", h.RevokeAPIKey)
+	}
+}
+
+// Request/Response DTOs
+
+// CreateAPIKeyRequest contains API key creation data
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// UpdateAPIKeyScopesRequest replaces an API key's scopes
+type UpdateAPIKeyScopesRequest struct {
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+}
+
+// APIKeyResponse represents an API key in API responses
+type APIKeyResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	Scopes     []string  `json:"scopes"`
+	CreatedBy  uuid.UUID `json:"created_by"`
+	ExpiresAt  *string   `json:"expires_at,omitempty"`
+	LastUsedAt *string   `json:"last_used_at,omitempty"`
+	RevokedAt  *string   `json:"revoked_at,omitempty"`
+	CreatedAt  string    `json:"created_at"`
+}
+
+// APIKeySecretResponse is returned once when a key is created or rotated
+type APIKeySecretResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// Handler Methods
+
+// ListAPIKeys lists the tenant's API keys
+// @Summary List API keys
+// @Description List the tenant's API keys, including revoked ones
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} APIKeyResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userCtx, ok := h.authenticateKeyManager(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list API keys", err.Error())
+		return
+	}
+
+	response := make([]APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, convertToAPIKeyResponse(&keys[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// CreateAPIKey creates an API key
+// @Summary Create API key
+// @Description Create a scoped API key. The key is only returned once.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body CreateAPIKeyRequest true "API key data"
+// @Success 201 {object} APIKeySecretResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userCtx, ok := h.authenticateKeyManager(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		h.RespondBadRequest(c, "Expiry must be in the future")
+		return
+	}
+
+	key, plaintext, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), services.CreateAPIKeyParams{
+		TenantID:  userCtx.TenantID,
+		CreatedBy: userCtx.UserID,
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		h.respondAPIKeyError(c, err, "Failed to create API key")
+		return
+	}
+
+	h.RespondCreated(c, APIKeySecretResponse{
+		APIKeyResponse: convertToAPIKeyResponse(key),
+		Key:            plaintext,
+	})
+}
+
+// GetAPIKey returns an API key
+// @Summary Get API key
+// @Description Get an API key's metadata and scopes
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} APIKeyResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api-keys/{id} [get]
+func (h *APIKeyHandler) GetAPIKey(c *gin.Context) {
+	userCtx, ok := h.authenticateKeyManager(c)
+	if !ok {
+		return
+	}
+
+	keyID, ok := h.ValidateUUID(c, "API key ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	key, err := h.apiKeyService.GetAPIKey(c.Request.Context(), keyID, userCtx.TenantID)
+	if err != nil {
+		h.respondAPIKeyError(c, err, "Failed to get API key")
+		return
+	}
+
+	h.RespondSuccess(c, convertToAPIKeyResponse(key))
+}
+
+// UpdateScopes replaces an API key's scopes
+// @Summary Update API key scopes
+// @Description Replace the permissions granted to an API key
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Param request body UpdateAPIKeyScopesRequest true "Scopes"
+// @Success 200 {object} APIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api-keys/{id}/scopes [put]
+func (h *APIKeyHandler) UpdateScopes(c *gin.Context) {
+	userCtx, ok := h.authenticateKeyManager(c)
+	if !ok {
+		return
+	}
+
+	keyID, ok := h.ValidateUUID(c, "API key ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req UpdateAPIKeyScopesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	key, err := h.apiKeyService.UpdateScopes(c.Request.Context(), keyID, userCtx.TenantID, userCtx.UserID, req.Scopes)
+	if err != nil {
+		h.respondAPIKeyError(c, err, "Failed to update API key scopes")
+		return
+	}
+
+	h.RespondSuccess(c, convertToAPIKeyResponse(key))
+}
+
+// RotateAPIKey issues a new secret for an API key
+// @Summary Rotate API key
+// @Description Replace an API key's secret. The old key stops working immediately.
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} APIKeySecretResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Router /api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	userCtx, ok := h.authenticateKeyManager(c)
+	if !ok {
+		return
+	}
+
+	keyID, ok := h.ValidateUUID(c, "API key ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	key, plaintext, err := h.apiKeyService.RotateAPIKey(c.Request.Context(), keyID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		h.respondAPIKeyError(c, err, "Failed to rotate API key")
+		return
+	}
+
+	h.RespondSuccess(c, APIKeySecretResponse{
+		APIKeyResponse: convertToAPIKeyResponse(key),
+		Key:            plaintext,
+	})
+}
+
+// RevokeAPIKey revokes an API key
+// @Summary Revoke API key
+// @Description Permanently disable an API key
+// @Tags api-keys
+// @Param id path string true "API key ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userCtx, ok := h.authenticateKeyManager(c)
+	if !ok {
+		return
+	}
+
+	keyID, ok := h.ValidateUUID(c, "API key ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), keyID, userCtx.TenantID, userCtx.UserID); err != nil {
+		h.respondAPIKeyError(c, err, "Failed to revoke API key")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Helper Methods
+
+// authenticateKeyManager rejects API key requests so keys cannot mint other keys
+func (h *APIKeyHandler) authenticateKeyManager(c *gin.Context) (*middleware.UserContext, bool) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return nil, false
+	}
+	if userCtx.APIKeyID != nil {
+		h.RespondError(c, http.StatusForbidden, "user_token_required", "API keys cannot manage API keys")
+		return nil, false
+	}
+	return userCtx, true
+}
+
+func (h *APIKeyHandler) respondAPIKeyError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		h.RespondNotFound(c, "API key not found")
+	case errors.Is(err, services.ErrAPIKeyRevoked):
+		h.RespondError(c, http.StatusGone, "api_key_revoked", err.Error())
+	case errors.Is(err, services.ErrScopeNotHeld):
+		h.RespondError(c, http.StatusForbidden, "scope_not_held", err.Error())
+	case errors.Is(err, services.ErrInvalidAPIKeyName), errors.Is(err, services.ErrAPIKeyScopeRequired),
+		errors.Is(err, services.ErrUnknownPermission):
+		h.RespondBadRequest(c, err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
+	}
+}
+
+// Conversion functions
+
+func convertToAPIKeyResponse(key *models.APIKey) APIKeyResponse {
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, scope.Permission)
+	}
+
+	response := APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    scopes,
+		CreatedBy: key.CreatedBy,
+		CreatedAt: key.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+
+	if key.ExpiresAt != nil {
+		expiresAt := key.ExpiresAt.Format("2006-01-02T15:04:05Z")
+		response.ExpiresAt = &expiresAt
+	}
+	if key.LastUsedAt != nil {
+		lastUsedAt := key.LastUsedAt.Format("2006-01-02T15:04:05Z")
+		response.LastUsedAt = &lastUsedAt
+	}
+	if key.RevokedAt != nil {
+		revokedAt := key.RevokedAt.Format("2006-01-02T15:04:05Z")
+		response.RevokedAt = &revokedAt
+	}
+
+	return response
+}
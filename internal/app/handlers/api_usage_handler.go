@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// APIUsageHandler reports the tenant's metered API usage
+type APIUsageHandler struct {
+	*BaseHandler
+	usageService *services.APIUsageService
+}
+
+// NewAPIUsageHandler creates a new API usage handler
+func NewAPIUsageHandler(usageService *services.APIUsageService) *APIUsageHandler {
+	return &APIUsageHandler{
+		BaseHandler:  NewBaseHandler(),
+		usageService: usageService,
+	}
+}
+
+// RegisterRoutes sets up the API usage routes
+func (h *APIUsageHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	router.GET("/tenant/api-usage", h.GetUsage)
+}
+
+// Handler Methods
+
+// GetUsage returns the tenant's API usage for billing dashboards
+// @Summary Get API usage
+// @Description The tenant's rate limit and monthly request quota (set by its subscription tier), requests so far this month, and monthly history. Requests over the quota are answered with 402 and rate limited ones with 429; both are counted separately from the requests served. This route stays available when the limits are used up.
+// @Tags tenant
+// @Produce json
+// @Success 200 {object} services.APIUsageReport
+// @Failure 404 {object} ErrorResponse
+// @Router /tenant/api-usage [get]
+func (h *APIUsageHandler) GetUsage(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.usageService.GetUsage(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		if errors.Is(err, services.ErrTenantNotFound) {
+			h.RespondNotFound(c, "Tenant not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to get API usage", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, report)
+}
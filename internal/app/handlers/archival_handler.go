@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ArchivalHandler handles scheduled folder archival: the schedules, their
+// previews and the runs that can be undone
+type ArchivalHandler struct {
+	*BaseHandler
+	archivalService *services.ArchivalService
+}
+
+// NewArchivalHandler creates a new archival handler
+func NewArchivalHandler(archivalService *services.ArchivalService) *ArchivalHandler {
+	return &ArchivalHandler{
+		BaseHandler:     NewBaseHandler(),
+		archivalService: archivalService,
+	}
+}
+
+// RegisterRoutes sets up the archival routes
+func (h *ArchivalHandler) RegisterRoutes(router *gin.RouterGroup) {
+	schedules := router.Group("/archival-schedules")
+	// Note: Auth middleware should be applied at server level
+	{
+		schedules.GET("", h.ListSchedules)
+		schedules.POST("", h.CreateSchedule)
+		schedules.POST("/preview", h.Preview)
+		schedules.GET("/:id", h.GetSchedule)
+		schedules.PUT("/:id", h.UpdateSchedule)
+		schedules.DELETE("/:id", h.DeleteSchedule)
+		schedules.GET("/:id/preview", h.PreviewSchedule)
+	}
+
+	runs := router.Group("/archival-runs")
+	{
+		runs.GET("", h.ListRuns)
+		runs.GET("/:id", h.GetRun)
+		runs.POST("/:id/undo", h.UndoRun)
+	}
+}
+
+// Request/Response DTOs
+
+// ArchivalScheduleRequest contains a folder archival schedule
+type ArchivalScheduleRequest struct {
+	Name              string    `json:"name" binding:"required"`
+	FolderID          uuid.UUID `json:"folder_id" binding:"required"`
+	IncludeSubfolders *bool     `json:"include_subfolders,omitempty"` // Defaults to true
+	MinAgeDays        int       `json:"min_age_days,omitempty"`       // Only documents dated at least this long ago
+	ColdStorage       bool      `json:"cold_storage"`
+	Recurrence        string    `json:"recurrence,omitempty" binding:"omitempty,oneof=once yearly"`
+	RunAt             time.Time `json:"run_at" binding:"required"`
+	Enabled           *bool     `json:"enabled,omitempty"` // Defaults to true
+}
+
+// Handler Methods
+
+// ListSchedules lists the tenant's archival schedules
+// @Summary List archival schedules
+// @Tags archival
+// @Produce json
+// @Success 200 {array} models.ArchivalSchedule
+// @Failure 403 {object} ErrorResponse
+// @Router /archival-schedules [get]
+func (h *ArchivalHandler) ListSchedules(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.archivalService.ListSchedules(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleArchivalError(c, err)
+		return
+	}
+	if schedules == nil {
+		schedules = []models.ArchivalSchedule{}
+	}
+
+	h.RespondSuccess(c, schedules)
+}
+
+// CreateSchedule schedules a folder's archival
+// @Summary Create an archival schedule
+// @Description Archive a folder's documents, optionally with its subfolders and only those dated at least min_age_days ago, at run_at (e.g. after the fiscal year closes) and, for yearly schedules, every year after. Archived documents still count against the quota; folder owners are notified and a run can be undone for a while. With cold_storage the files then move to the server's cold tier.
+// @Tags archival
+// @Accept json
+// @Produce json
+// @Param request body ArchivalScheduleRequest true "Archival schedule"
+// @Success 201 {object} models.ArchivalSchedule
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Cold storage isn't configured on this server"
+// @Router /archival-schedules [post]
+func (h *ArchivalHandler) CreateSchedule(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req ArchivalScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	schedule, err := h.archivalService.CreateSchedule(c.Request.Context(), req.params(userCtx.TenantID, userCtx.UserID))
+	if err != nil {
+		h.handleArchivalError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, schedule)
+}
+
+// Preview evaluates a proposed archival schedule
+// @Summary Preview an archival schedule
+// @Description Count the documents, and bytes, a proposed schedule would archive at its run time, per folder, with those left alone for legal holds, the folder owners who'd be notified and a sample. Nothing is saved.
+// @Tags archival
+// @Accept json
+// @Produce json
+// @Param request body ArchivalScheduleRequest true "Archival schedule"
+// @Success 200 {object} services.ArchivalPreview
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /archival-schedules/preview [post]
+func (h *ArchivalHandler) Preview(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req ArchivalScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	preview, err := h.archivalService.Preview(c.Request.Context(), req.params(userCtx.TenantID, userCtx.UserID))
+	if err != nil {
+		h.handleArchivalError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, preview)
+}
+
+// GetSchedule returns an archival schedule
+// @Summary Get an archival schedule
+// @Tags archival
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} models.ArchivalSchedule
+// @Failure 404 {object} ErrorResponse
+// @Router /archival-schedules/{id} [get]
+func (h *ArchivalHandler) GetSchedule(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	scheduleID, ok := h.ValidateUUID(c, "Schedule ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	schedule, err := h.archivalService.GetSchedule(c.Request.Context(), userCtx.TenantID, scheduleID)
+	if err != nil {
+		h.handleArchivalError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, schedule)
+}
+
+// UpdateSchedule replaces an archival schedule
+// @Summary Update an archival schedule
+// @Description Replace a schedule's settings. Its next run moves to run_at, so a one-time schedule that already ran can run again.
+// @Tags archival
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Param request body ArchivalScheduleRequest true "Archival schedule"
+// @Success 200 {object} models.ArchivalSchedule
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /archival-schedules/{id} [put]
+func (h *ArchivalHandler) UpdateSchedule(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	scheduleID, ok := h.ValidateUUID(c, "Schedule ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ArchivalScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	schedule, err := h.archivalService.UpdateSchedule(c.Request.Context(), scheduleID, req.params(userCtx.TenantID, userCtx.UserID))
+	if err != nil {
+		h.handleArchivalError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, schedule)
+}
+
+// DeleteSchedule deletes an archival schedule
+// @Summary Delete an archival schedule
+// @Description Delete a schedule. Documents it archived stay archived; its runs can still be undone within their windows.
+// @Tags archival
+// @Param id path string true "Schedule ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /archival-schedules/{id} [delete]
+func (h *ArchivalHandler) DeleteSchedule(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	scheduleID, ok := h.ValidateUUID(c, "Schedule ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.archivalService.DeleteSchedule(c.Request.Context(), userCtx.TenantID, userCtx.UserID, scheduleID); err != nil {
+		h.handleArchivalError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PreviewSchedule evaluates a saved archival schedule
+// @Summary Preview an archival schedule's next run
+// @Description Count what the schedule would archive at its next run, with today's documents
+// @Tags archival
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} services.ArchivalPreview
+// @Failure 404 {object} ErrorResponse
+// @Router /archival-schedules/{id}/preview [get]
+func (h *ArchivalHandler) PreviewSchedule(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	scheduleID, ok := h.ValidateUUID(c, "Schedule ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	preview, err := h.archivalService.PreviewSchedule(c.Request.Context(), userCtx.TenantID, scheduleID)
+	if err != nil {
+		h.handleArchivalError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, preview)
+}
+
+// ListRuns lists archival runs
+// @Summary List archival runs
+// @Description List the tenant's archival runs, newest first
+// @Tags archival
+// @Produce json
+// @Param schedule_id query string false "Only this schedule's runs"
+// @Param page query int false "Page number"
+// @Param per_page query int false "Page size"
+// @Success 200 {object} PaginatedResponse
+// @Router /archival-runs [get]
+func (h *ArchivalHandler) ListRuns(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var scheduleID *uuid.UUID
+	if value := c.Query("schedule_id"); value != "" {
+		id, ok := h.ValidateUUID(c, "Schedule ID", value)
+		if !ok {
+			return
+		}
+		scheduleID = &id
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	runs, total, err := h.archivalService.ListRuns(c.Request.Context(), userCtx.TenantID, scheduleID, repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.handleArchivalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       runs,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// GetRun returns an archival run
+// @Summary Get an archival run
+// @Tags archival
+// @Produce json
+// @Param id path string true "Run ID"
+// @Success 200 {object} models.ArchivalRun
+// @Failure 404 {object} ErrorResponse
+// @Router /archival-runs/{id} [get]
+func (h *ArchivalHandler) GetRun(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	runID, ok := h.ValidateUUID(c, "Run ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	run, err := h.archivalService.GetRun(c.Request.Context(), userCtx.TenantID, runID)
+	if err != nil {
+		h.handleArchivalError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, run)
+}
+
+// UndoRun undoes an archival run
+// @Summary Undo an archival run
+// @Description Give the run's documents their previous status back, until its undo_until. Documents trashed or changed since are left alone.
+// @Tags archival
+// @Produce json
+// @Param id path string true "Run ID"
+// @Success 200 {object} models.ArchivalRun
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Already undone, or the undo window has closed"
+// @Router /archival-runs/{id}/undo [post]
+func (h *ArchivalHandler) UndoRun(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	runID, ok := h.ValidateUUID(c, "Run ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	run, err := h.archivalService.UndoRun(c.Request.Context(), userCtx.TenantID, userCtx.UserID, runID)
+	if err != nil {
+		h.handleArchivalError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, run)
+}
+
+// Helper methods
+
+// params converts the request, applying its defaults
+func (r ArchivalScheduleRequest) params(tenantID, userID uuid.UUID) services.ArchivalScheduleParams {
+	params := services.ArchivalScheduleParams{
+		TenantID:          tenantID,
+		UserID:            userID,
+		Name:              r.Name,
+		FolderID:          r.FolderID,
+		IncludeSubfolders: true,
+		MinAgeDays:        r.MinAgeDays,
+		ColdStorage:       r.ColdStorage,
+		Recurrence:        models.ArchivalRecurrence(r.Recurrence),
+		RunAt:             r.RunAt,
+		Enabled:           true,
+	}
+	if r.IncludeSubfolders != nil {
+		params.IncludeSubfolders = *r.IncludeSubfolders
+	}
+	if r.Enabled != nil {
+		params.Enabled = *r.Enabled
+	}
+	return params
+}
+
+func (h *ArchivalHandler) handleArchivalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidArchivalSchedule):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrArchivalScheduleNotFound):
+		h.RespondNotFound(c, "Archival schedule not found")
+	case errors.Is(err, services.ErrArchivalRunNotFound):
+		h.RespondNotFound(c, "Archival run not found")
+	case errors.Is(err, services.ErrFolderNotFound):
+		h.RespondNotFound(c, "Folder not found")
+	case errors.Is(err, services.ErrColdStorageUnavailable):
+		h.RespondError(c, http.StatusConflict, "cold_storage_unavailable", err.Error())
+	case errors.Is(err, services.ErrArchivalRunNotUndoable):
+		h.RespondError(c, http.StatusConflict, "archival_run_not_undoable", err.Error())
+	case errors.Is(err, services.ErrArchivalUndoExpired):
+		h.RespondError(c, http.StatusConflict, "archival_undo_expired", err.Error())
+	default:
+		h.RespondInternalError(c, "Failed to process archival request", err.Error())
+	}
+}
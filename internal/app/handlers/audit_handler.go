@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AuditHandler handles audit archives, chain verification, exports and streams
+type AuditHandler struct {
+	*BaseHandler
+	retentionService *services.AuditRetentionService
+	exportService    *services.AuditExportService
+	userService      *services.UserService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(retentionService *services.AuditRetentionService, exportService *services.AuditExportService, userService *services.UserService) *AuditHandler {
+	return &AuditHandler{
+		BaseHandler:      NewBaseHandler(),
+		retentionService: retentionService,
+		exportService:    exportService,
+		userService:      userService,
+	}
+}
+
+// RegisterRoutes sets up the audit routes
+func (h *AuditHandler) RegisterRoutes(router *gin.RouterGroup) {
+	audit := router.Group("/audit")
+	// Note: Auth middleware should be applied at server level
+	{
+		audit.GET("/archives", h.ListArchives)
+		audit.GET("/archives/:id/download", h.DownloadArchive)
+		audit.GET("/verify", h.VerifyChain)
+		audit.GET("/export", h.ExportAuditLog)
+		audit.GET("/retention", h.GetRetention)
+		audit.PUT("/retention", h.UpdateRetention)
+
+		// Streams to external SIEMs
+		audit.GET("/streams", h.ListStreams)
+		audit.POST("/streams", h.CreateStream)
+		audit.GET("/streams/:id", h.GetStream)
+		audit.PUT("/streams/:id", h.UpdateStream)
+		audit.DELETE("/streams/:id", h.DeleteStream)
+	}
+}
+
+// Request/Response DTOs
+
+// AuditArchiveResponse represents an audit archive in API responses
+type AuditArchiveResponse struct {
+	ID            uuid.UUID `json:"id"`
+	FirstSequence int64     `json:"first_sequence"`
+	LastSequence  int64     `json:"last_sequence"`
+	FirstPrevHash string    `json:"first_prev_hash"`
+	LastHash      string    `json:"last_hash"`
+	FromTime      string    `json:"from_time"`
+	ToTime        string    `json:"to_time"`
+	EntryCount    int       `json:"entry_count"`
+	SizeBytes     int64     `json:"size_bytes"`
+	CreatedAt     string    `json:"created_at"`
+}
+
+// AuditRetentionRequest sets the tenant's audit retention; null restores the
+// subscription tier's default
+type AuditRetentionRequest struct {
+	RetentionDays *int `json:"retention_days"`
+}
+
+// CreateAuditStreamRequest contains audit stream creation data
+type CreateAuditStreamRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Type     string `json:"type" binding:"required"`
+	Endpoint string `json:"endpoint" binding:"required,max=500"`
+	Backfill bool   `json:"backfill"`
+}
+
+// UpdateAuditStreamRequest contains audit stream changes; omitted fields are unchanged
+type UpdateAuditStreamRequest struct {
+	Name     *string `json:"name,omitempty" binding:"omitempty,max=100"`
+	Endpoint *string `json:"endpoint,omitempty" binding:"omitempty,max=500"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+// AuditStreamSecretResponse is returned when a webhook audit stream is created
+type AuditStreamSecretResponse struct {
+	*models.AuditStream
+	Secret string `json:"secret,omitempty"`
+}
+
+// Handler Methods
+
+// ListArchives lists the tenant's audit archives
+// @Summary List audit archives
+// @Description List the tenant's archived audit log batches in chain order
+// @Tags audit
+// @Produce json
+// @Success 200 {array} AuditArchiveResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /audit/archives [get]
+func (h *AuditHandler) ListArchives(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	archives, err := h.retentionService.ListArchives(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list audit archives", err.Error())
+		return
+	}
+
+	response := make([]AuditArchiveResponse, 0, len(archives))
+	for i := range archives {
+		response = append(response, convertToAuditArchiveResponse(&archives[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// DownloadArchive streams an audit archive
+// @Summary Download audit archive
+// @Description Download an archive as gzip-compressed JSON lines, one audit entry per line
+// @Tags audit
+// @Produce application/gzip
+// @Param id path string true "Archive ID"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /audit/archives/{id}/download [get]
+func (h *AuditHandler) DownloadArchive(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	archiveID, ok := h.ValidateUUID(c, "Archive ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	archive, reader, err := h.retentionService.OpenArchive(c.Request.Context(), userCtx.TenantID, archiveID)
+	if err != nil {
+		if errors.Is(err, services.ErrAuditArchiveNotFound) {
+			h.RespondNotFound(c, "Audit archive not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to read audit archive", err.Error())
+		return
+	}
+	defer reader.Close()
+
+	filename := fmt.Sprintf("audit-%d-%d.jsonl.gz", archive.FirstSequence, archive.LastSequence)
+	c.DataFromReader(http.StatusOK, archive.SizeBytes, "application/gzip", reader, map[string]string{
+		"Content-Disposition": `attachment; filename="` + filename + `"`,
+	})
+}
+
+// VerifyChain verifies the tenant's audit hash chain
+// @Summary Verify audit chain
+// @Description Check every link of the tenant's audit hash chain, across archives and the live log
+// @Tags audit
+// @Produce json
+// @Success 200 {object} services.AuditChainVerification
+// @Failure 403 {object} ErrorResponse
+// @Router /audit/verify [get]
+func (h *AuditHandler) VerifyChain(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.retentionService.VerifyChain(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to verify audit chain", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, result)
+}
+
+// ExportAuditLog streams the tenant's audit entries for a time range
+// @Summary Export audit log
+// @Description Download the audit entries created in [from, to) in chain order, including archived ones, as CSV or JSON lines. Every entry carries its sequence and chain hashes so the export can be verified.
+// @Tags audit
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param from query string true "Start time (RFC 3339, inclusive)"
+// @Param to query string false "End time (RFC 3339, exclusive); defaults to now"
+// @Param format query string false "csv or jsonl" default(jsonl)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Router /audit/export [get]
+func (h *AuditHandler) ExportAuditLog(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	params := services.AuditExportParams{
+		TenantID:   userCtx.TenantID,
+		ExportedBy: userCtx.UserID,
+		To:         time.Now(),
+		Format:     c.DefaultQuery("format", services.AuditExportJSONL),
+	}
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		h.RespondBadRequest(c, "from must be an RFC 3339 time", "")
+		return
+	}
+	params.From = from
+	if raw := c.Query("to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			h.RespondBadRequest(c, "to must be an RFC 3339 time", "")
+			return
+		}
+		params.To = to
+	}
+
+	if err := h.exportService.ValidateExport(params); err != nil {
+		h.handleAuditError(c, err)
+		return
+	}
+
+	contentType, extension := "application/x-ndjson", "jsonl"
+	if params.Format == services.AuditExportCSV {
+		contentType, extension = "text/csv", "csv"
+	}
+	filename := fmt.Sprintf("audit-%s-%s.%s", params.From.UTC().Format("20060102T150405Z"), params.To.UTC().Format("20060102T150405Z"), extension)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	if err := h.exportService.Export(c.Request.Context(), params, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			h.handleAuditError(c, err)
+			return
+		}
+		// Headers are already sent; the download ends short
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// ListStreams lists the tenant's audit streams
+// @Summary List audit streams
+// @Description List the SIEM streams mirroring the tenant's audit log, with their delivery cursor and health
+// @Tags audit
+// @Produce json
+// @Success 200 {array} models.AuditStream
+// @Router /audit/streams [get]
+func (h *AuditHandler) ListStreams(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	streams, err := h.exportService.ListStreams(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list audit streams", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, streams)
+}
+
+// CreateStream creates an audit stream
+// @Summary Create audit stream
+// @Description Mirror new audit entries to a SIEM, in chain order and at least once. Webhook streams POST signed batches to an https URL; the signing secret is only returned here. Syslog streams send RFC 5424 messages to tcp://, udp:// or tls:// host:port. With backfill the stream starts from the oldest entry not yet archived.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param request body CreateAuditStreamRequest true "Audit stream"
+// @Success 201 {object} AuditStreamSecretResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /audit/streams [post]
+func (h *AuditHandler) CreateStream(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAuditStreamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	stream, secret, err := h.exportService.CreateStream(c.Request.Context(), services.CreateAuditStreamParams{
+		TenantID:  userCtx.TenantID,
+		CreatedBy: userCtx.UserID,
+		Name:      req.Name,
+		Type:      models.AuditStreamType(req.Type),
+		Endpoint:  req.Endpoint,
+		Backfill:  req.Backfill,
+	})
+	if err != nil {
+		h.handleAuditError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, AuditStreamSecretResponse{AuditStream: stream, Secret: secret})
+}
+
+// GetStream returns an audit stream
+// @Summary Get audit stream
+// @Tags audit
+// @Produce json
+// @Param id path string true "Audit stream ID"
+// @Success 200 {object} models.AuditStream
+// @Failure 404 {object} ErrorResponse
+// @Router /audit/streams/{id} [get]
+func (h *AuditHandler) GetStream(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	streamID, ok := h.ValidateUUID(c, "Audit stream ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	stream, err := h.exportService.GetStream(c.Request.Context(), streamID, userCtx.TenantID)
+	if err != nil {
+		h.handleAuditError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, stream)
+}
+
+// UpdateStream updates an audit stream
+// @Summary Update audit stream
+// @Description Rename, repoint, pause or resume a stream. A resumed stream continues from its cursor.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param id path string true "Audit stream ID"
+// @Param request body UpdateAuditStreamRequest true "Changes"
+// @Success 200 {object} models.AuditStream
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /audit/streams/{id} [put]
+func (h *AuditHandler) UpdateStream(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	streamID, ok := h.ValidateUUID(c, "Audit stream ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req UpdateAuditStreamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	stream, err := h.exportService.UpdateStream(c.Request.Context(), streamID, userCtx.TenantID, userCtx.UserID, services.UpdateAuditStreamParams{
+		Name:     req.Name,
+		Endpoint: req.Endpoint,
+		IsActive: req.IsActive,
+	})
+	if err != nil {
+		h.handleAuditError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, stream)
+}
+
+// DeleteStream deletes an audit stream
+// @Summary Delete audit stream
+// @Tags audit
+// @Param id path string true "Audit stream ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /audit/streams/{id} [delete]
+func (h *AuditHandler) DeleteStream(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	streamID, ok := h.ValidateUUID(c, "Audit stream ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.exportService.DeleteStream(c.Request.Context(), streamID, userCtx.TenantID, userCtx.UserID); err != nil {
+		h.handleAuditError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetRetention returns the tenant's audit retention
+// @Summary Get audit retention
+// @Description How many days audit entries stay in the hot table before they're archived to compressed storage. Without an override the subscription tier's default applies.
+// @Tags audit
+// @Produce json
+// @Success 200 {object} services.AuditRetentionPolicy
+// @Failure 403 {object} ErrorResponse
+// @Router /audit/retention [get]
+func (h *AuditHandler) GetRetention(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.retentionService.GetRetentionPolicy(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleAuditError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, policy)
+}
+
+// UpdateRetention sets or clears the tenant's audit retention override
+// @Summary Update audit retention
+// @Description Override how many days audit entries stay in the hot table, or send null to use the tier default. Shorter retention applies on the next archival run; archived entries remain downloadable and verifiable.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param request body AuditRetentionRequest true "Retention"
+// @Success 200 {object} services.AuditRetentionPolicy
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /audit/retention [put]
+func (h *AuditHandler) UpdateRetention(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req AuditRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	policy, err := h.retentionService.UpdateRetentionPolicy(c.Request.Context(), services.UpdateAuditRetentionParams{
+		TenantID:      userCtx.TenantID,
+		UpdatedBy:     userCtx.UserID,
+		IPAddress:     c.ClientIP(),
+		RetentionDays: req.RetentionDays,
+	})
+	if err != nil {
+		h.handleAuditError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, policy)
+}
+
+// Helper Methods
+
+func (h *AuditHandler) handleAuditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAuditStreamNotFound):
+		h.RespondNotFound(c, "Audit stream not found")
+	case errors.Is(err, services.ErrInvalidAuditStreamType),
+		errors.Is(err, services.ErrInvalidAuditStreamTarget),
+		errors.Is(err, services.ErrInvalidAuditExportRange),
+		errors.Is(err, services.ErrInvalidAuditExportFormat),
+		errors.Is(err, services.ErrInvalidAuditRetention):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	default:
+		h.RespondInternalError(c, "Failed to process audit request", err.Error())
+	}
+}
+
+// Conversion functions
+
+func convertToAuditArchiveResponse(archive *models.AuditArchive) AuditArchiveResponse {
+	return AuditArchiveResponse{
+		ID:            archive.ID,
+		FirstSequence: archive.FirstSequence,
+		LastSequence:  archive.LastSequence,
+		FirstPrevHash: archive.FirstPrevHash,
+		LastHash:      archive.LastHash,
+		FromTime:      archive.FromTime.Format(time.RFC3339),
+		ToTime:        archive.ToTime.Format(time.RFC3339),
+		EntryCount:    archive.EntryCount,
+		SizeBytes:     archive.SizeBytes,
+		CreatedAt:     archive.CreatedAt.Format(time.RFC3339),
+	}
+}
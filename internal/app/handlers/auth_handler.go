@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -119,6 +120,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Success 200 {object} LoginResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "CAPTCHA required or invalid"
+// @Failure 429 {object} ErrorResponse "Too many failed attempts; see Retry-After"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -146,6 +149,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		Email:           req.Email,
 		Password:        req.Password,
 		MFACode:         req.MFACode,
+		CaptchaToken:    req.CaptchaToken,
 		IPAddress:       c.ClientIP(),
 		UserAgent:       c.GetHeader("User-Agent"),
 	}
@@ -153,6 +157,24 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Authenticate using Supabase
 	result, err := h.userService.Login(c.Request.Context(), loginParams)
 	if err != nil {
+		var locked *services.AccountLockedError
+		if errors.As(err, &locked) {
+			retryAfter := int(time.Until(locked.Until).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			h.RespondError(c, http.StatusTooManyRequests, "account_locked", "Too many failed login attempts, try again later")
+			return
+		}
+		if h.RespondAbuseBlocked(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrTenantPendingDeletion) {
+			h.RespondError(c, http.StatusForbidden, "tenant_pending_deletion", "This account is scheduled for deletion; only admins can sign in")
+			return
+		}
+		if errors.Is(err, services.ErrTrialExpired) {
+			h.RespondError(c, http.StatusForbidden, "trial_expired", "The trial has ended; only admins can sign in to choose a plan")
+			return
+		}
 		h.RespondUnauthorized(c, "Authentication failed")
 		return
 	}
@@ -268,6 +290,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 // @Param Authorization header string true "Bearer token"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
 // @Router /auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
 	// Extract token from Authorization header
@@ -283,11 +306,22 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
+	// Only a verified token may end its session
+	if _, err := h.supabaseAuth.ValidateToken(token); err != nil {
+		h.respondError(c, http.StatusUnauthorized, "Invalid or expired token", nil)
+		return
+	}
+
 	// Sign out from Supabase
 	if err := h.supabaseAuth.SignOut(token); err != nil {
 		// Log error but don't fail logout
 	}
 
+	// Revoke locally too so the token stops working before it expires
+	if err := h.userService.RevokeSessionByToken(c.Request.Context(), token); err != nil {
+		// Log error but don't fail logout
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logged out successfully",
 		"success": true,
@@ -475,6 +509,9 @@ type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 	MFACode  string `json:"mfa_code,omitempty"`
+
+	// CaptchaToken is required after repeated failures when CAPTCHAs are enabled
+	CaptchaToken string `json:"captcha_token,omitempty"`
 }
 
 type LoginResponse struct {
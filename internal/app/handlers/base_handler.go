@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/archivus/archivus/internal/app/middleware"
+	"github.com/archivus/archivus/internal/domain/services"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -71,6 +75,50 @@ func (b *BaseHandler) RespondInternalError(c *gin.Context, message string, detai
 	b.RespondError(c, http.StatusInternalServerError, "internal_error", message, details...)
 }
 
+// RespondAbuseBlocked answers requests refused by abuse protection (rate
+// limits, delays, CAPTCHAs) and reports whether err was one of them
+func (b *BaseHandler) RespondAbuseBlocked(c *gin.Context, err error) bool {
+	var limited *services.RateLimitedError
+	switch {
+	case errors.As(err, &limited):
+		retryAfter := int(time.Until(limited.Until).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		b.RespondError(c, http.StatusTooManyRequests, "rate_limited", "Too many attempts, try again later")
+	case errors.Is(err, services.ErrCaptchaRequired):
+		b.RespondError(c, http.StatusForbidden, "captcha_required", err.Error())
+	case errors.Is(err, services.ErrCaptchaInvalid):
+		b.RespondError(c, http.StatusForbidden, "captcha_invalid", err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// DocumentGatedResponse is the payload of downloads and shares refused by
+// the download gate
+type DocumentGatedResponse struct {
+	ErrorResponse
+	Gate *services.DownloadGateStatus `json:"gate"`
+}
+
+// RespondDocumentGated answers requests for documents whose required checks
+// haven't passed, and reports whether err was one
+func (b *BaseHandler) RespondDocumentGated(c *gin.Context, err error) bool {
+	var gated *services.DocumentGatedError
+	if !errors.As(err, &gated) {
+		return false
+	}
+	c.JSON(http.StatusLocked, DocumentGatedResponse{
+		ErrorResponse: ErrorResponse{
+			Error:   "document_gated",
+			Message: "The document can't be downloaded or shared until its required checks pass",
+			Status:  http.StatusLocked,
+		},
+		Gate: gated.Gate,
+	})
+	return true
+}
+
 // RespondSuccess sends a standardized success response
 func (b *BaseHandler) RespondSuccess(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, data)
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BulkLabelHandler handles tagging and categorizing documents by search
+type BulkLabelHandler struct {
+	*BaseHandler
+	bulkLabelService *services.BulkLabelService
+}
+
+// NewBulkLabelHandler creates a new bulk label handler
+func NewBulkLabelHandler(bulkLabelService *services.BulkLabelService) *BulkLabelHandler {
+	return &BulkLabelHandler{
+		BaseHandler:      NewBaseHandler(),
+		bulkLabelService: bulkLabelService,
+	}
+}
+
+// RegisterRoutes sets up the bulk label routes
+func (h *BulkLabelHandler) RegisterRoutes(router *gin.RouterGroup) {
+	jobs := router.Group("/documents/bulk-labels")
+	// Note: Auth middleware should be applied at server level
+	{
+		jobs.POST("/preview", h.PreviewBulkLabel)
+		jobs.POST("", h.StartBulkLabel)
+		jobs.GET("", h.ListBulkLabelJobs)
+		jobs.GET("/:id", h.GetBulkLabelJob)
+	}
+}
+
+// Request/Response DTOs
+
+// BulkLabelRequest adds or removes tags and categories on every document
+// matching search. Starting a job takes the matched count from a preview.
+type BulkLabelRequest struct {
+	Action        string                 `json:"action" binding:"required,oneof=add remove"`
+	TagIDs        []string               `json:"tag_ids,omitempty"`
+	CategoryIDs   []string               `json:"category_ids,omitempty"`
+	Search        BulkLabelSearchRequest `json:"search"`
+	ExpectedCount *int64                 `json:"expected_count,omitempty"`
+}
+
+// BulkLabelSearchRequest selects documents; empty fields match everything.
+// Dates bound when documents were created.
+type BulkLabelSearchRequest struct {
+	Query         string     `json:"query"`
+	DocumentTypes []string   `json:"document_types,omitempty"`
+	FolderID      *string    `json:"folder_id,omitempty"`
+	TagIDs        []string   `json:"tag_ids,omitempty"`
+	CategoryIDs   []string   `json:"category_ids,omitempty"`
+	DateFrom      *time.Time `json:"date_from,omitempty"`
+	DateTo        *time.Time `json:"date_to,omitempty"`
+}
+
+// Handler Methods
+
+// PreviewBulkLabel counts the documents a bulk label change would touch
+// @Summary Preview bulk label change
+// @Description Count the documents matching a search, with the first few of them, before tagging or categorizing them all. Pass the count as expected_count when starting the job.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body BulkLabelRequest true "Bulk label change"
+// @Success 200 {object} services.BulkLabelPreview
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/bulk-labels/preview [post]
+func (h *BulkLabelHandler) PreviewBulkLabel(c *gin.Context) {
+	params, ok := h.bindBulkLabel(c)
+	if !ok {
+		return
+	}
+
+	preview, err := h.bulkLabelService.Preview(c.Request.Context(), params)
+	if err != nil {
+		h.handleBulkLabelError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, preview)
+}
+
+// StartBulkLabel queues a bulk label change
+// @Summary Start bulk label change
+// @Description Add or remove tags and categories on every document matching a search, in the background. expected_count must be the count from a preview; the job isn't started when the search now matches a different number of documents.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body BulkLabelRequest true "Bulk label change"
+// @Success 202 {object} models.BulkLabelJob
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "The match count changed since the preview"
+// @Router /documents/bulk-labels [post]
+func (h *BulkLabelHandler) StartBulkLabel(c *gin.Context) {
+	params, ok := h.bindBulkLabel(c)
+	if !ok {
+		return
+	}
+	if params.ExpectedCount == nil {
+		h.RespondBadRequest(c, "expected_count from a preview is required", "")
+		return
+	}
+
+	job, err := h.bulkLabelService.StartJob(c.Request.Context(), params)
+	if err != nil {
+		h.handleBulkLabelError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListBulkLabelJobs lists the tenant's bulk label jobs
+// @Summary List bulk label jobs
+// @Description List the tenant's bulk label jobs, newest first
+// @Tags documents
+// @Produce json
+// @Param page query int false "Page number"
+// @Param per_page query int false "Page size"
+// @Success 200 {object} PaginatedResponse
+// @Router /documents/bulk-labels [get]
+func (h *BulkLabelHandler) ListBulkLabelJobs(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	jobs, total, err := h.bulkLabelService.ListJobs(c.Request.Context(), userCtx.TenantID, repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list bulk label jobs", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       jobs,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// GetBulkLabelJob returns a bulk label job and its progress
+// @Summary Get bulk label job
+// @Tags documents
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.BulkLabelJob
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/bulk-labels/{id} [get]
+func (h *BulkLabelHandler) GetBulkLabelJob(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	jobID, ok := h.ValidateUUID(c, "Job ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	job, err := h.bulkLabelService.GetJob(c.Request.Context(), userCtx.TenantID, jobID)
+	if err != nil {
+		h.handleBulkLabelError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, job)
+}
+
+// Helper Methods
+
+// bindBulkLabel reads a bulk label request into service parameters
+func (h *BulkLabelHandler) bindBulkLabel(c *gin.Context) (services.BulkLabelParams, bool) {
+	var params services.BulkLabelParams
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return params, false
+	}
+
+	var req BulkLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return params, false
+	}
+
+	params = services.BulkLabelParams{
+		TenantID:      userCtx.TenantID,
+		RequestedBy:   userCtx.UserID,
+		Action:        models.BulkLabelAction(req.Action),
+		ExpectedCount: req.ExpectedCount,
+		Filters: repositories.DocumentFilters{
+			DateFrom:   req.Search.DateFrom,
+			DateTo:     req.Search.DateTo,
+			ListParams: repositories.ListParams{Search: req.Search.Query},
+		},
+	}
+
+	ids := []struct {
+		field  string
+		values []string
+		target *[]uuid.UUID
+	}{
+		{"Tag ID", req.TagIDs, &params.TagIDs},
+		{"Category ID", req.CategoryIDs, &params.CategoryIDs},
+		{"Tag ID", req.Search.TagIDs, &params.Filters.TagIDs},
+		{"Category ID", req.Search.CategoryIDs, &params.Filters.CategoryIDs},
+	}
+	for _, list := range ids {
+		for _, value := range list.values {
+			id, ok := h.ValidateUUID(c, list.field, value)
+			if !ok {
+				return params, false
+			}
+			*list.target = append(*list.target, id)
+		}
+	}
+
+	if req.Search.FolderID != nil {
+		folderID, ok := h.ValidateUUID(c, "Folder ID", *req.Search.FolderID)
+		if !ok {
+			return params, false
+		}
+		params.Filters.FolderID = &folderID
+	}
+	for _, documentType := range req.Search.DocumentTypes {
+		params.Filters.DocumentType = append(params.Filters.DocumentType, models.DocumentType(documentType))
+	}
+
+	return params, true
+}
+
+func (h *BulkLabelHandler) handleBulkLabelError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBulkLabelJobNotFound):
+		h.RespondNotFound(c, "Bulk label job not found")
+	case errors.Is(err, services.ErrTagNotFound), errors.Is(err, services.ErrCategoryNotFound):
+		h.RespondNotFound(c, err.Error())
+	case errors.Is(err, services.ErrBulkLabelCountChanged):
+		h.RespondConflict(c, err.Error())
+	case errors.Is(err, services.ErrInvalidBulkLabel),
+		errors.Is(err, services.ErrBulkLabelEmpty),
+		errors.Is(err, services.ErrBulkLabelTooLarge):
+		h.RespondBadRequest(c, err.Error(), "")
+	default:
+		h.RespondInternalError(c, "Failed to process bulk label change", err.Error())
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// BusinessCalendarHandler manages the tenant's working week and holidays
+type BusinessCalendarHandler struct {
+	*BaseHandler
+	calendarService *services.BusinessCalendarService
+}
+
+// NewBusinessCalendarHandler creates a new business calendar handler
+func NewBusinessCalendarHandler(calendarService *services.BusinessCalendarService) *BusinessCalendarHandler {
+	return &BusinessCalendarHandler{
+		BaseHandler:     NewBaseHandler(),
+		calendarService: calendarService,
+	}
+}
+
+// RegisterRoutes sets up the business calendar routes
+func (h *BusinessCalendarHandler) RegisterRoutes(router *gin.RouterGroup) {
+	calendar := router.Group("/business-calendar")
+	// Note: Auth middleware should be applied at server level
+	{
+		calendar.GET("", h.GetCalendar)
+		calendar.PUT("", h.UpdateCalendar)
+	}
+}
+
+// Request/Response DTOs
+
+// BusinessCalendarRequest contains a tenant's working week and holidays
+type BusinessCalendarRequest struct {
+	Timezone string             `json:"timezone" binding:"max=64"`
+	Workdays []time.Weekday     `json:"workdays" binding:"required"`
+	Holidays []services.Holiday `json:"holidays"`
+}
+
+// Handler Methods
+
+// GetCalendar returns the tenant's business calendar
+// @Summary Get business calendar
+// @Description Get the tenant's workdays, holidays and time zone. Tenants that never set one work Monday to Friday in UTC.
+// @Tags business-calendar
+// @Produce json
+// @Success 200 {object} services.BusinessCalendar
+// @Router /business-calendar [get]
+func (h *BusinessCalendarHandler) GetCalendar(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	calendar, err := h.calendarService.GetCalendar(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleBusinessCalendarError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, calendar)
+}
+
+// UpdateCalendar replaces the tenant's business calendar
+// @Summary Update business calendar
+// @Description Replace the tenant's workdays (0 = Sunday to 6 = Saturday), holidays and IANA time zone. Workflow due dates, reminders and escalations count business days on this calendar.
+// @Tags business-calendar
+// @Accept json
+// @Produce json
+// @Param request body BusinessCalendarRequest true "Business calendar"
+// @Success 200 {object} services.BusinessCalendar
+// @Failure 400 {object} ErrorResponse
+// @Router /business-calendar [put]
+func (h *BusinessCalendarHandler) UpdateCalendar(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req BusinessCalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	calendar, err := h.calendarService.UpdateCalendar(c.Request.Context(), services.UpdateBusinessCalendarParams{
+		TenantID:  userCtx.TenantID,
+		UpdatedBy: userCtx.UserID,
+		Timezone:  req.Timezone,
+		Workdays:  req.Workdays,
+		Holidays:  req.Holidays,
+	})
+	if err != nil {
+		h.handleBusinessCalendarError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, calendar)
+}
+
+// Helper Methods
+
+func (h *BusinessCalendarHandler) handleBusinessCalendarError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidWorkdays), errors.Is(err, services.ErrInvalidHoliday),
+		errors.Is(err, services.ErrInvalidTimezone):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	default:
+		h.RespondInternalError(c, "Failed to process business calendar", err.Error())
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ChatHandler answers questions about documents and searches their passages
+type ChatHandler struct {
+	*BaseHandler
+	chatService *services.ChatService
+}
+
+// NewChatHandler creates a new chat handler
+func NewChatHandler(chatService *services.ChatService) *ChatHandler {
+	return &ChatHandler{
+		BaseHandler: NewBaseHandler(),
+		chatService: chatService,
+	}
+}
+
+// RegisterRoutes sets up the chat routes
+func (h *ChatHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	router.POST("/documents/:id/chat", h.ChatWithDocument)
+	router.POST("/chat", h.Chat)
+	router.GET("/search/passages", h.SearchPassages)
+}
+
+// Request/Response DTOs
+
+// ChatRequest is a question about documents
+type ChatRequest struct {
+	Question    string                 `json:"question" binding:"required"`
+	DocumentIDs []uuid.UUID            `json:"document_ids,omitempty"` // Only for /chat; all readable documents when empty
+	History     []services.ChatMessage `json:"history,omitempty"`      // Earlier turns, oldest first
+}
+
+// Handler Methods
+
+// ChatWithDocument answers a question about one document
+// @Summary Ask a document
+// @Description Answer a question from the passages of the document nearest to it. The answer cites passages by number, e.g. [1]; citations lists them.
+// @Description With Accept: text/event-stream the answer is streamed as Server-Sent Events: "sources" with the passages found, "delta" with each part of the answer and "done" with the answer; "error" ends a failed stream.
+// @Tags documents
+// @Accept json
+// @Produce json,text/event-stream
+// @Param id path string true "Document ID"
+// @Param request body ChatRequest true "Question"
+// @Success 200 {object} services.ChatAnswer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /documents/{id}/chat [post]
+func (h *ChatHandler) ChatWithDocument(c *gin.Context) {
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+	h.chat(c, &documentID)
+}
+
+// Chat answers a question about several documents
+// @Summary Ask documents
+// @Description Answer a question from the passages nearest to it in the given documents, or in all documents the user can read. The answer cites passages by number, e.g. [1]; citations lists them.
+// @Description With Accept: text/event-stream the answer is streamed as Server-Sent Events: "sources" with the passages found, "delta" with each part of the answer and "done" with the answer; "error" ends a failed stream.
+// @Tags documents
+// @Accept json
+// @Produce json,text/event-stream
+// @Param request body ChatRequest true "Question"
+// @Success 200 {object} services.ChatAnswer
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /chat [post]
+func (h *ChatHandler) Chat(c *gin.Context) {
+	h.chat(c, nil)
+}
+
+// SearchPassages finds the passages nearest to a query
+// @Summary Search passages
+// @Description Semantic search over the embedded passages of documents' text, nearest first. Each hit names its document, its position in the text as character offsets and, for text with page breaks, its page. Only documents the user can read are searched.
+// @Tags search
+// @Produce json
+// @Param q query string true "Query"
+// @Param document_ids query string false "Comma-separated document IDs to search (default all readable documents)"
+// @Param limit query int false "Passages to return (default 10, max 50)"
+// @Success 200 {array} services.ChatSource
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /search/passages [get]
+func (h *ChatHandler) SearchPassages(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	passages, err := h.chatService.SearchPassages(c.Request.Context(), userCtx.TenantID, userCtx.UserID, services.PassageSearchParams{
+		Query:       c.Query("q"),
+		DocumentIDs: getUUIDArrayParam(c, "document_ids"),
+		Limit:       getIntParam(c, "limit", 0),
+	})
+	if err != nil {
+		h.handleChatError(c, err, "Failed to search passages")
+		return
+	}
+
+	h.RespondSuccess(c, passages)
+}
+
+// Helper methods
+
+func (h *ChatHandler) chat(c *gin.Context, documentID *uuid.UUID) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+	if documentID != nil {
+		req.DocumentIDs = []uuid.UUID{*documentID}
+	}
+
+	var stream services.ChatStream
+	sse := &sseChatStream{c: c}
+	if strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		stream = sse
+	}
+
+	answer, err := h.chatService.Ask(c.Request.Context(), userCtx.TenantID, userCtx.UserID, services.ChatParams{
+		Question:    req.Question,
+		DocumentIDs: req.DocumentIDs,
+		History:     req.History,
+	}, stream)
+	switch {
+	case sse.started:
+		// Errors after the stream started can only be sent as events
+		if err != nil {
+			c.SSEvent("error", gin.H{"message": "Failed to answer question"})
+		} else {
+			c.SSEvent("done", answer)
+		}
+		c.Writer.Flush()
+	case err != nil:
+		h.handleChatError(c, err, "Failed to answer question")
+	default:
+		h.RespondSuccess(c, answer)
+	}
+}
+
+func (h *ChatHandler) handleChatError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidChatQuestion), errors.Is(err, services.ErrInvalidChatRequest),
+		errors.Is(err, services.ErrInvalidPassageQuery):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrDocumentAccessDenied):
+		h.RespondError(c, http.StatusForbidden, "document_access_denied", err.Error())
+	case errors.Is(err, services.ErrDocumentsNotIndexed):
+		h.RespondError(c, http.StatusUnprocessableEntity, "documents_not_indexed", err.Error())
+	case errors.Is(err, services.ErrQuotaExceeded):
+		h.RespondError(c, http.StatusPaymentRequired, "quota_exceeded", "AI quota exceeded")
+	case errors.Is(err, services.ErrAIBudgetExceeded):
+		h.RespondError(c, http.StatusPaymentRequired, "ai_budget_exceeded", err.Error())
+	case errors.Is(err, services.ErrAIProviderNotConfigured):
+		h.RespondError(c, http.StatusServiceUnavailable, "ai_unavailable", err.Error())
+	default:
+		h.RespondInternalError(c, failure, err.Error())
+	}
+}
+
+// sseChatStream writes an answer as Server-Sent Events. The stream starts
+// with the sources, so errors before them are plain error responses.
+type sseChatStream struct {
+	c       *gin.Context
+	started bool
+}
+
+func (s *sseChatStream) Sources(sources []services.ChatSource) {
+	// The server's write timeout would otherwise cut long answers off
+	http.NewResponseController(s.c.Writer).SetWriteDeadline(time.Time{})
+
+	s.c.Header("Content-Type", "text/event-stream")
+	s.c.Header("Cache-Control", "no-cache")
+	s.c.Header("Connection", "keep-alive")
+	s.c.Header("X-Accel-Buffering", "no")
+	s.started = true
+
+	s.c.SSEvent("sources", sources)
+	s.c.Writer.Flush()
+}
+
+func (s *sseChatStream) Text(text string) {
+	s.c.SSEvent("delta", gin.H{"text": text})
+	s.c.Writer.Flush()
+}
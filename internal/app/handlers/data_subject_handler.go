@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// DataSubjectHandler handles GDPR export and erasure requests for users
+type DataSubjectHandler struct {
+	*BaseHandler
+	dataSubjectService *services.DataSubjectService
+}
+
+// NewDataSubjectHandler creates a new data subject handler
+func NewDataSubjectHandler(dataSubjectService *services.DataSubjectService) *DataSubjectHandler {
+	return &DataSubjectHandler{
+		BaseHandler:        NewBaseHandler(),
+		dataSubjectService: dataSubjectService,
+	}
+}
+
+// RegisterRoutes sets up the data subject routes
+func (h *DataSubjectHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	router.POST("/users/:id/data-export", h.RequestExport)
+	router.POST("/users/:id/erase", h.RequestErasure)
+
+	requests := router.Group("/data-subject-requests")
+	{
+		requests.GET("", h.ListRequests)
+		requests.GET("/:id", h.GetRequest)
+		requests.GET("/:id/download", h.DownloadExport)
+	}
+}
+
+// Request/Response DTOs
+
+// EraseUserRequest confirms an erasure, which can't be undone
+type EraseUserRequest struct {
+	Confirm bool `json:"confirm"`
+}
+
+// Handler Methods
+
+// RequestExport queues an export of a user's data
+// @Summary Export user data
+// @Description Queue a zip archive of the user's account, the documents they created, their comments and their audit trail. Download it from /data-subject-requests/{id}/download once completed.
+// @Tags data-subject
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 202 {object} models.DataSubjectRequest
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/data-export [post]
+func (h *DataSubjectHandler) RequestExport(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	subjectID, ok := h.ValidateUUID(c, "User ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	request, err := h.dataSubjectService.RequestExport(c.Request.Context(), userCtx.TenantID, subjectID, userCtx.UserID)
+	if err != nil {
+		h.handleDataSubjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, request)
+}
+
+// RequestErasure queues the erasure of a user
+// @Summary Erase user
+// @Description Queue the anonymization of a user across the system: their profile, comments, devices, notifications, grants and API keys are erased, their sessions revoked and their identity provider account deleted. Documents they created stay with the tenant. Audit entries are kept to preserve the hash chain and point to the anonymized account. Requires {"confirm": true}.
+// @Tags data-subject
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body EraseUserRequest true "Confirmation"
+// @Success 202 {object} models.DataSubjectRequest
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/erase [post]
+func (h *DataSubjectHandler) RequestErasure(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	subjectID, ok := h.ValidateUUID(c, "User ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req EraseUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+	if !req.Confirm {
+		h.RespondError(c, http.StatusBadRequest, "confirmation_required", "Erasure can't be undone; send confirm: true")
+		return
+	}
+
+	request, err := h.dataSubjectService.RequestErasure(c.Request.Context(), userCtx.TenantID, subjectID, userCtx.UserID)
+	if err != nil {
+		h.handleDataSubjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, request)
+}
+
+// ListRequests lists the tenant's data subject requests
+// @Summary List data subject requests
+// @Tags data-subject
+// @Produce json
+// @Param page query int false "Page number"
+// @Param per_page query int false "Page size"
+// @Success 200 {object} PaginatedResponse
+// @Router /data-subject-requests [get]
+func (h *DataSubjectHandler) ListRequests(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	requests, total, err := h.dataSubjectService.ListRequests(c.Request.Context(), userCtx.TenantID, repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list data subject requests", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       requests,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// GetRequest returns a data subject request's progress
+// @Summary Get data subject request
+// @Tags data-subject
+// @Produce json
+// @Param id path string true "Request ID"
+// @Success 200 {object} models.DataSubjectRequest
+// @Failure 404 {object} ErrorResponse
+// @Router /data-subject-requests/{id} [get]
+func (h *DataSubjectHandler) GetRequest(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	requestID, ok := h.ValidateUUID(c, "Request ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	request, err := h.dataSubjectService.GetRequest(c.Request.Context(), userCtx.TenantID, requestID)
+	if err != nil {
+		h.handleDataSubjectError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, request)
+}
+
+// DownloadExport streams a completed export archive
+// @Summary Download user data export
+// @Tags data-subject
+// @Produce application/zip
+// @Param id path string true "Request ID"
+// @Success 200 {file} binary
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /data-subject-requests/{id}/download [get]
+func (h *DataSubjectHandler) DownloadExport(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	requestID, ok := h.ValidateUUID(c, "Request ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	request, reader, err := h.dataSubjectService.OpenExport(c.Request.Context(), userCtx.TenantID, requestID)
+	if err != nil {
+		h.handleDataSubjectError(c, err)
+		return
+	}
+	defer reader.Close()
+
+	filename := "data-export-" + request.SubjectID.String() + ".zip"
+	c.DataFromReader(http.StatusOK, request.SizeBytes, "application/zip", reader, map[string]string{
+		"Content-Disposition": `attachment; filename="` + filename + `"`,
+	})
+}
+
+// Helper Methods
+
+func (h *DataSubjectHandler) handleDataSubjectError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrDataSubjectRequestNotFound):
+		h.RespondNotFound(c, "Data subject request not found")
+	case errors.Is(err, services.ErrUserNotFound):
+		h.RespondNotFound(c, "User not found")
+	case errors.Is(err, services.ErrCannotEraseSelf):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrExportNotReady):
+		h.RespondConflict(c, "Data export is not ready")
+	default:
+		h.RespondInternalError(c, "Failed to process data subject request", err.Error())
+	}
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/archivus/archivus/internal/app/middleware"
 	"github.com/archivus/archivus/internal/domain/repositories"
@@ -16,14 +18,16 @@ import (
 type DocumentHandler struct {
 	*BaseHandler
 	documentService *services.DocumentService
+	uploadService   *services.DirectUploadService
 	userService     *services.UserService
 }
 
 // NewDocumentHandler creates a new document handler
-func NewDocumentHandler(documentService *services.DocumentService, userService *services.UserService) *DocumentHandler {
+func NewDocumentHandler(documentService *services.DocumentService, uploadService *services.DirectUploadService, userService *services.UserService) *DocumentHandler {
 	return &DocumentHandler{
 		BaseHandler:     NewBaseHandler(),
 		documentService: documentService,
+		uploadService:   uploadService,
 		userService:     userService,
 	}
 }
@@ -49,9 +53,49 @@ type UploadDocumentRequest struct {
 	ExpiryDate   string   `form:"expiry_date"`   // ISO format
 
 	// Processing options
-	EnableAI           bool `form:"enable_ai"`
-	EnableOCR          bool `form:"enable_ocr"`
-	SkipDuplicateCheck bool `form:"skip_duplicate_check"`
+	EnableAI           bool   `form:"enable_ai"`
+	EnableOCR          bool   `form:"enable_ocr"`
+	AIDryRun           bool   `form:"ai_dry_run"` // Canned AI results for testing and demos
+	SkipDuplicateCheck bool   `form:"skip_duplicate_check"`
+	ProcessingPriority string `form:"processing_priority"` // high, normal or low
+}
+
+// OverrideDownloadGateRequest lets a document past the download gate
+type OverrideDownloadGateRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// StartDirectUploadRequest describes a file to upload directly to storage,
+// and the document to create from it
+type StartDirectUploadRequest struct {
+	Filename     string                 `json:"filename" binding:"required"`
+	ContentType  string                 `json:"content_type" binding:"required"`
+	Size         int64                  `json:"size" binding:"required"`
+	SHA256       string                 `json:"sha256" binding:"required"`
+	FolderID     *uuid.UUID             `json:"folder_id,omitempty"`
+	Title        string                 `json:"title,omitempty"`
+	Description  string                 `json:"description,omitempty"`
+	DocumentType string                 `json:"document_type,omitempty"`
+	Tags         []string               `json:"tags,omitempty"`
+	Categories   []string               `json:"categories,omitempty"`
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+
+	// Financial fields
+	Amount       *float64   `json:"amount,omitempty"`
+	Currency     string     `json:"currency,omitempty"`
+	TaxAmount    *float64   `json:"tax_amount,omitempty"`
+	VendorName   string     `json:"vendor_name,omitempty"`
+	CustomerName string     `json:"customer_name,omitempty"`
+	DocumentDate *time.Time `json:"document_date,omitempty"`
+	DueDate      *time.Time `json:"due_date,omitempty"`
+	ExpiryDate   *time.Time `json:"expiry_date,omitempty"`
+
+	// Processing options
+	EnableAI           bool   `json:"enable_ai"`
+	EnableOCR          bool   `json:"enable_ocr"`
+	AIDryRun           bool   `json:"ai_dry_run"`
+	SkipDuplicateCheck bool   `json:"skip_duplicate_check"`
+	ProcessingPriority string `json:"processing_priority,omitempty"` // high, normal or low
 }
 
 // DocumentResponse represents the document response
@@ -76,6 +120,30 @@ type SearchRequest struct {
 	PageSize      int      `json:"page_size" form:"page_size"`
 }
 
+// SetDocumentPrivacyRequest represents a request to make a document private or public
+type SetDocumentPrivacyRequest struct {
+	Private bool `json:"private"`
+}
+
+// GrantDocumentAccessRequest represents a request to grant a user access to a private document
+type GrantDocumentAccessRequest struct {
+	UserID     string     `json:"user_id" binding:"required"`
+	Permission string     `json:"permission" binding:"required,oneof=read write"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// DocumentACLResponse represents a document ACL entry in API responses
+type DocumentACLResponse struct {
+	ID         string  `json:"id"`
+	DocumentID string  `json:"document_id"`
+	UserID     string  `json:"user_id"`
+	UserEmail  string  `json:"user_email,omitempty"`
+	Permission string  `json:"permission"`
+	GrantedBy  string  `json:"granted_by"`
+	ExpiresAt  *string `json:"expires_at,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+}
+
 // PaginatedResponse represents paginated API response
 type PaginatedResponse struct {
 	Data       interface{} `json:"data"`
@@ -92,14 +160,26 @@ func (h *DocumentHandler) RegisterRoutes(router *gin.RouterGroup) {
 		docs.POST("/upload", h.UploadDocument)
 		docs.GET("/", h.ListDocuments)
 		docs.GET("/search", h.SearchDocuments)
+		docs.GET("/trash", h.ListTrash)
 		docs.GET("/:id", h.GetDocument)
 		docs.PUT("/:id", h.UpdateDocument)
 		docs.DELETE("/:id", h.DeleteDocument)
+		docs.POST("/:id/restore", h.RestoreDocument)
 		docs.GET("/:id/download", h.DownloadDocument)
 		docs.GET("/:id/preview", h.PreviewDocument)
+		docs.PUT("/:id/privacy", h.SetDocumentPrivacy)
+		docs.GET("/:id/acl", h.ListDocumentACL)
+		docs.POST("/:id/acl", h.GrantDocumentAccess)
+		docs.DELETE("/:id/acl/:userId", h.RevokeDocumentAccess)
 		docs.POST("/:id/process-financial", h.ProcessFinancialDocument)
 		docs.GET("/duplicates", h.FindDuplicates)
 		docs.GET("/expiring", h.GetExpiringDocuments)
+		docs.POST("/retention/simulate", h.SimulateRetention)
+		docs.POST("/uploads", h.StartDirectUpload)
+		docs.POST("/uploads/:id/finalize", h.FinalizeDirectUpload)
+		docs.GET("/:id/gate", h.GetDownloadGate)
+		docs.POST("/:id/gate/override", h.OverrideDownloadGate)
+		docs.DELETE("/:id/gate/override", h.ClearDownloadGateOverride)
 	}
 }
 
@@ -111,6 +191,7 @@ func (h *DocumentHandler) RegisterRoutes(router *gin.RouterGroup) {
 // @Produce json
 // @Param file formData file true "Document file"
 // @Param data formData string false "Document metadata (JSON)"
+// @Param processing_priority formData string false "AI processing priority: high for interactive uploads, low for bulk migrations" Enums(high, normal, low) default(normal)
 // @Success 201 {object} DocumentResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 413 {object} ErrorResponse "File too large"
@@ -137,6 +218,12 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		return
 	}
 
+	processingPriority, err := services.ParseProcessingPriority(req.ProcessingPriority)
+	if err != nil {
+		h.RespondBadRequest(c, err.Error(), "")
+		return
+	}
+
 	// Convert form data to service parameters
 	params := services.UploadDocumentParams{
 		TenantID:           userCtx.TenantID,
@@ -154,7 +241,9 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		CustomerName:       req.CustomerName,
 		EnableAI:           req.EnableAI,
 		EnableOCR:          req.EnableOCR,
+		AIDryRun:           req.AIDryRun,
 		SkipDuplicateCheck: req.SkipDuplicateCheck,
+		ProcessingPriority: processingPriority,
 	}
 
 	// Parse folder ID if provided
@@ -203,6 +292,9 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		case services.ErrQuotaExceeded:
 			statusCode = http.StatusPaymentRequired
 			errorCode = "quota_exceeded"
+		case services.ErrDocumentQuotaExceeded:
+			statusCode = http.StatusPaymentRequired
+			errorCode = "document_quota_exceeded"
 		case services.ErrDocumentTooLarge:
 			statusCode = http.StatusRequestEntityTooLarge
 			errorCode = "file_too_large"
@@ -212,6 +304,12 @@ func (h *DocumentHandler) UploadDocument(c *gin.Context) {
 		case services.ErrDocumentExists:
 			statusCode = http.StatusConflict
 			errorCode = "document_exists"
+		case services.ErrFolderAccessDenied:
+			statusCode = http.StatusForbidden
+			errorCode = "folder_access_denied"
+		case services.ErrInvalidDocumentType:
+			statusCode = http.StatusBadRequest
+			errorCode = "invalid_document_type"
 		}
 
 		c.JSON(statusCode, ErrorResponse{
@@ -291,6 +389,7 @@ func (h *DocumentHandler) GetDocument(c *gin.Context) {
 // @Param folder_id query string false "Filter by folder ID"
 // @Param document_type query string false "Filter by document type"
 // @Param status query string false "Filter by status"
+// @Param owner_id query string false "Filter by owner"
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(20)
 // @Success 200 {object} PaginatedResponse
@@ -330,8 +429,14 @@ func (h *DocumentHandler) ListDocuments(c *gin.Context) {
 		filters.Status = []models.DocStatus{models.DocStatus(status)}
 	}
 
+	if ownerID := c.Query("owner_id"); ownerID != "" {
+		if id, err := uuid.Parse(ownerID); err == nil {
+			filters.OwnedBy = []uuid.UUID{id}
+		}
+	}
+
 	// Get documents
-	documents, total, err := h.documentService.ListDocuments(c.Request.Context(), userCtx.TenantID, filters)
+	documents, total, err := h.documentService.ListDocuments(c.Request.Context(), userCtx.TenantID, userCtx.UserID, filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "list_failed",
@@ -426,7 +531,7 @@ func (h *DocumentHandler) SearchDocuments(c *gin.Context) {
 	}
 
 	// Perform search
-	documents, err := h.documentService.SearchDocuments(c.Request.Context(), userCtx.TenantID, query)
+	documents, err := h.documentService.SearchDocuments(c.Request.Context(), userCtx.TenantID, userCtx.UserID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "search_failed",
@@ -477,16 +582,6 @@ func (h *DocumentHandler) UpdateDocument(c *gin.Context) {
 		return
 	}
 
-	// Check permissions
-	hasPermission, err := h.userService.CheckPermission(c.Request.Context(), userCtx.UserID, "documents.update")
-	if err != nil || !hasPermission {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error:   "permission_denied",
-			Message: "Insufficient permissions to update documents",
-		})
-		return
-	}
-
 	var updates map[string]interface{}
 	if err := c.ShouldBindJSON(&updates); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -498,7 +593,7 @@ func (h *DocumentHandler) UpdateDocument(c *gin.Context) {
 	}
 
 	// Update document
-	document, err := h.documentService.UpdateDocument(c.Request.Context(), documentID, updates, userCtx.UserID)
+	document, err := h.documentService.UpdateDocument(c.Request.Context(), documentID, userCtx.TenantID, updates, userCtx.UserID)
 	if err != nil {
 		if err == services.ErrDocumentNotFound {
 			c.JSON(http.StatusNotFound, ErrorResponse{
@@ -507,6 +602,20 @@ func (h *DocumentHandler) UpdateDocument(c *gin.Context) {
 			})
 			return
 		}
+		if err == services.ErrDocumentAccessDenied {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   "document_access_denied",
+				Message: "You don't have write access to this document",
+			})
+			return
+		}
+		if err == services.ErrInvalidDocumentType {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_document_type",
+				Message: "Document type is not one of the tenant's document types",
+			})
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "update_failed",
@@ -550,18 +659,8 @@ func (h *DocumentHandler) DeleteDocument(c *gin.Context) {
 		return
 	}
 
-	// Check permissions
-	hasPermission, err := h.userService.CheckPermission(c.Request.Context(), userCtx.UserID, "documents.delete")
-	if err != nil || !hasPermission {
-		c.JSON(http.StatusForbidden, ErrorResponse{
-			Error:   "permission_denied",
-			Message: "Insufficient permissions to delete documents",
-		})
-		return
-	}
-
 	// Delete document
-	err = h.documentService.DeleteDocument(c.Request.Context(), documentID, userCtx.UserID)
+	err = h.documentService.DeleteDocument(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID)
 	if err != nil {
 		if err == services.ErrDocumentNotFound {
 			c.JSON(http.StatusNotFound, ErrorResponse{
@@ -570,6 +669,17 @@ func (h *DocumentHandler) DeleteDocument(c *gin.Context) {
 			})
 			return
 		}
+		if err == services.ErrDocumentAccessDenied {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   "document_access_denied",
+				Message: "You don't have write access to this document",
+			})
+			return
+		}
+		if err == services.ErrDocumentOnLegalHold {
+			h.RespondError(c, http.StatusConflict, "document_on_legal_hold", "Document is under legal hold and can't be deleted")
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "delete_failed",
@@ -609,7 +719,7 @@ func (h *DocumentHandler) ProcessFinancialDocument(c *gin.Context) {
 	}
 
 	// Trigger financial processing
-	err = h.documentService.ProcessFinancialDocument(c.Request.Context(), documentID, userCtx.UserID)
+	err = h.documentService.ProcessFinancialDocument(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		errorCode := "processing_failed"
@@ -618,6 +728,9 @@ func (h *DocumentHandler) ProcessFinancialDocument(c *gin.Context) {
 		case services.ErrDocumentNotFound:
 			statusCode = http.StatusNotFound
 			errorCode = "document_not_found"
+		case services.ErrDocumentAccessDenied:
+			statusCode = http.StatusForbidden
+			errorCode = "document_access_denied"
 		case services.ErrInvalidDocumentType:
 			statusCode = http.StatusBadRequest
 			errorCode = "invalid_document_type"
@@ -638,7 +751,7 @@ func (h *DocumentHandler) ProcessFinancialDocument(c *gin.Context) {
 
 // FindDuplicates finds potential duplicate documents
 // @Summary Find duplicate documents
-// @Description Find potential duplicate documents based on content similarity
+// @Description Find potential duplicate documents: identical files, and re-scanned or slightly edited copies whose text simhash or image perceptual hash is at least threshold similar. Fingerprints are computed by fingerprint jobs after upload.
 // @Tags documents
 // @Produce json
 // @Param threshold query float64 false "Similarity threshold (0.0-1.0)" default(0.8)
@@ -674,6 +787,97 @@ func (h *DocumentHandler) FindDuplicates(c *gin.Context) {
 	c.JSON(http.StatusOK, duplicates)
 }
 
+// ListTrash lists deleted documents that can still be restored
+// @Summary List trash
+// @Description List deleted documents, most recently deleted first. Admins see the tenant's whole trash, other users what they deleted or own. Documents are purged once they've been in the trash for the retention period.
+// @Tags documents
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Page size" default(20)
+// @Success 200 {object} PaginatedResponse
+// @Router /api/v1/documents/trash [get]
+func (h *DocumentHandler) ListTrash(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	documents, total, err := h.documentService.ListTrash(c.Request.Context(), userCtx.TenantID, userCtx.UserID, repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+		Search:   c.Query("search"),
+	})
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list trash", err.Error())
+		return
+	}
+
+	responses := make([]DocumentResponse, 0, len(documents))
+	for i := range documents {
+		responses = append(responses, DocumentResponse{
+			Document:    &documents[i],
+			Permissions: h.getDocumentPermissions(userCtx, &documents[i]),
+		})
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// RestoreDocument takes a document out of the trash
+// @Summary Restore document
+// @Description Restore a deleted document. Its owner, whoever deleted it and admins can restore it; it counts against the tenant's quotas again.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {object} DocumentResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/documents/{id}/restore [post]
+func (h *DocumentHandler) RestoreDocument(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	document, err := h.documentService.RestoreDocument(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDocumentNotFound):
+			h.RespondNotFound(c, "Document not found")
+		case errors.Is(err, services.ErrDocumentNotInTrash):
+			h.RespondError(c, http.StatusConflict, "document_not_in_trash", err.Error())
+		case errors.Is(err, services.ErrDocumentAccessDenied):
+			h.RespondError(c, http.StatusForbidden, "document_access_denied", "Only the document owner, whoever deleted it or an admin can restore it")
+		case errors.Is(err, services.ErrQuotaExceeded):
+			h.RespondError(c, http.StatusPaymentRequired, "quota_exceeded", "Storage quota exceeded")
+		case errors.Is(err, services.ErrDocumentQuotaExceeded):
+			h.RespondError(c, http.StatusPaymentRequired, "document_quota_exceeded", "Document quota exceeded")
+		default:
+			h.RespondInternalError(c, "Failed to restore document", err.Error())
+		}
+		return
+	}
+
+	h.RespondSuccess(c, DocumentResponse{
+		Document:    document,
+		Permissions: h.getDocumentPermissions(userCtx, document),
+	})
+}
+
 // GetExpiringDocuments gets documents nearing expiration
 // @Summary Get expiring documents
 // @Description Get documents that are expiring within specified days
@@ -715,6 +919,247 @@ func (h *DocumentHandler) GetExpiringDocuments(c *gin.Context) {
 	c.JSON(http.StatusOK, responses)
 }
 
+// SimulateRetention previews what a retention policy would do
+// @Summary Simulate retention policy
+// @Description Evaluate a proposed retention policy against the tenant's current documents without changing anything. Returns how many documents, and how many bytes, would be archived or deleted per folder and document type, and how many are due but under legal hold.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body services.DocumentRetentionPolicy true "Proposed policy"
+// @Success 200 {object} services.RetentionSimulation
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/documents/retention/simulate [post]
+func (h *DocumentHandler) SimulateRetention(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var policy services.DocumentRetentionPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	simulation, err := h.documentService.SimulateRetention(c.Request.Context(), userCtx.TenantID, policy)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRetentionPolicy) {
+			h.RespondBadRequest(c, err.Error(), "")
+			return
+		}
+		h.RespondInternalError(c, "Failed to simulate retention policy", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, simulation)
+}
+
+// StartDirectUpload issues a URL to upload a large file straight to storage
+// @Summary Start direct upload
+// @Description Start uploading a file directly to storage instead of through the API. Declare the file's size and SHA-256 checksum, upload it to the returned URL with the returned method and headers, then finalize the session to create the document. Quota, folder access, size and type are checked up front.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body StartDirectUploadRequest true "File and document metadata"
+// @Success 201 {object} services.DirectUpload
+// @Failure 400 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse "File too large"
+// @Failure 501 {object} ErrorResponse "Storage backend doesn't support direct uploads"
+// @Router /api/v1/documents/uploads [post]
+func (h *DocumentHandler) StartDirectUpload(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req StartDirectUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	processingPriority, err := services.ParseProcessingPriority(req.ProcessingPriority)
+	if err != nil {
+		h.RespondBadRequest(c, err.Error(), "")
+		return
+	}
+
+	params := services.UploadDocumentParams{
+		TenantID:           userCtx.TenantID,
+		UserID:             userCtx.UserID,
+		FolderID:           req.FolderID,
+		Title:              req.Title,
+		Description:        req.Description,
+		DocumentType:       models.DocumentType(req.DocumentType),
+		Tags:               req.Tags,
+		Categories:         req.Categories,
+		CustomFields:       req.CustomFields,
+		Amount:             req.Amount,
+		Currency:           req.Currency,
+		TaxAmount:          req.TaxAmount,
+		VendorName:         req.VendorName,
+		CustomerName:       req.CustomerName,
+		DocumentDate:       req.DocumentDate,
+		DueDate:            req.DueDate,
+		ExpiryDate:         req.ExpiryDate,
+		EnableAI:           req.EnableAI,
+		EnableOCR:          req.EnableOCR,
+		AIDryRun:           req.AIDryRun,
+		SkipDuplicateCheck: req.SkipDuplicateCheck,
+		ProcessingPriority: processingPriority,
+	}
+
+	upload, err := h.uploadService.StartUpload(c.Request.Context(), params, services.DirectUploadFile{
+		Filename:    req.Filename,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		SHA256:      req.SHA256,
+	})
+	if err != nil {
+		h.handleDirectUploadError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, upload)
+}
+
+// FinalizeDirectUpload creates the document for a file uploaded to storage
+// @Summary Finalize direct upload
+// @Description Verify a directly uploaded file against its declared size and checksum, then create the document and queue its processing. Returns 409 with upload_incomplete while the file isn't in storage yet; any other failure deletes the file, and the upload has to be started again.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Upload session ID"
+// @Success 201 {object} DocumentResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse "Upload session expired"
+// @Failure 422 {object} ErrorResponse "Uploaded file doesn't match"
+// @Router /api/v1/documents/uploads/{id}/finalize [post]
+func (h *DocumentHandler) FinalizeDirectUpload(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.RespondBadRequest(c, "Invalid upload session ID format", "")
+		return
+	}
+
+	document, err := h.uploadService.FinalizeUpload(c.Request.Context(), userCtx.TenantID, userCtx.UserID, sessionID)
+	if err != nil {
+		h.handleDirectUploadError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, &DocumentResponse{
+		Document:    document,
+		Permissions: h.getDocumentPermissions(userCtx, document),
+	})
+}
+
+// GetDownloadGate reports whether a document can be downloaded and shared
+// @Summary Get download gate
+// @Description Show the document's required checks (virus scan, personal data) and whether they have passed. Documents can't be downloaded or shared until they have, unless an admin overrides the gate.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {object} services.DownloadGateStatus
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/documents/{id}/gate [get]
+func (h *DocumentHandler) GetDownloadGate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.RespondBadRequest(c, "Invalid document ID format", "")
+		return
+	}
+
+	gate, err := h.documentService.GetDownloadGate(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		h.handleDownloadGateError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, gate)
+}
+
+// OverrideDownloadGate lets a document be downloaded before its checks pass
+// @Summary Override download gate
+// @Description Allow a document to be downloaded and shared although its required checks haven't passed, e.g. after a false positive. The override and its reason are audited.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param request body OverrideDownloadGateRequest true "Why the gate is overridden"
+// @Success 200 {object} services.DownloadGateStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/documents/{id}/gate/override [post]
+func (h *DocumentHandler) OverrideDownloadGate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.RespondBadRequest(c, "Invalid document ID format", "")
+		return
+	}
+
+	var req OverrideDownloadGateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	gate, err := h.documentService.OverrideDownloadGate(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID, req.Reason)
+	if err != nil {
+		h.handleDownloadGateError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, gate)
+}
+
+// ClearDownloadGateOverride puts a document back behind the download gate
+// @Summary Remove download gate override
+// @Description Remove an override, so the document again can't be downloaded or shared until its required checks pass
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {object} services.DownloadGateStatus
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/documents/{id}/gate/override [delete]
+func (h *DocumentHandler) ClearDownloadGateOverride(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.RespondBadRequest(c, "Invalid document ID format", "")
+		return
+	}
+
+	gate, err := h.documentService.ClearDownloadGateOverride(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		h.handleDownloadGateError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, gate)
+}
+
 // DownloadDocument serves the document file for download
 func (h *DocumentHandler) DownloadDocument(c *gin.Context) {
 	userCtx := middleware.GetUserContext(c)
@@ -735,9 +1180,12 @@ func (h *DocumentHandler) DownloadDocument(c *gin.Context) {
 		return
 	}
 
-	// Get document to verify access
-	document, err := h.documentService.GetDocument(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID)
+	// Get document to verify access, and that its required checks passed
+	document, err := h.documentService.GetDocumentForDownload(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID)
 	if err != nil {
+		if h.RespondDocumentGated(c, err) {
+			return
+		}
 		if err == services.ErrDocumentNotFound {
 			c.JSON(http.StatusNotFound, ErrorResponse{
 				Error:   "document_not_found",
@@ -746,6 +1194,14 @@ func (h *DocumentHandler) DownloadDocument(c *gin.Context) {
 			return
 		}
 
+		if err == services.ErrUnauthorizedAccess {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   "access_denied",
+				Message: "Access denied to this document",
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "access_error",
 			Message: "Failed to access document",
@@ -775,8 +1231,233 @@ func (h *DocumentHandler) PreviewDocument(c *gin.Context) {
 	})
 }
 
+// SetDocumentPrivacy marks a document private or public
+// @Summary Set document privacy
+// @Description Restrict a document to its creator and explicitly granted users, or reopen it to the tenant
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param request body SetDocumentPrivacyRequest true "Privacy setting"
+// @Success 200 {object} DocumentResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/privacy [put]
+func (h *DocumentHandler) SetDocumentPrivacy(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req SetDocumentPrivacyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	document, err := h.documentService.SetDocumentPrivacy(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID, req.Private)
+	if err != nil {
+		h.respondDocumentACLError(c, err, "Failed to update document privacy")
+		return
+	}
+
+	h.RespondSuccess(c, DocumentResponse{
+		Document:    document,
+		Permissions: h.getDocumentPermissions(userCtx, document),
+	})
+}
+
+// ListDocumentACL lists the users explicitly granted access to a document
+// @Summary List document ACL
+// @Description List explicit user grants on a private document
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {array} DocumentACLResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/acl [get]
+func (h *DocumentHandler) ListDocumentACL(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	acls, err := h.documentService.ListDocumentACL(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		h.respondDocumentACLError(c, err, "Failed to list document ACL")
+		return
+	}
+
+	response := make([]DocumentACLResponse, 0, len(acls))
+	for i := range acls {
+		response = append(response, convertToDocumentACLResponse(&acls[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// GrantDocumentAccess grants a user access to a document
+// @Summary Grant document access
+// @Description Grant a user read or write access to a private document
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param request body GrantDocumentAccessRequest true "Grant details"
+// @Success 201 {object} DocumentACLResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/acl [post]
+func (h *DocumentHandler) GrantDocumentAccess(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req GrantDocumentAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	granteeID, ok := h.ValidateUUID(c, "user ID", req.UserID)
+	if !ok {
+		return
+	}
+
+	acl, err := h.documentService.GrantDocumentAccess(c.Request.Context(), services.GrantDocumentAccessParams{
+		TenantID:   userCtx.TenantID,
+		DocumentID: documentID,
+		UserID:     granteeID,
+		Permission: models.DocumentPermission(req.Permission),
+		GrantedBy:  userCtx.UserID,
+		ExpiresAt:  req.ExpiresAt,
+	})
+	if err != nil {
+		h.respondDocumentACLError(c, err, "Failed to grant document access")
+		return
+	}
+
+	h.RespondCreated(c, convertToDocumentACLResponse(acl))
+}
+
+// RevokeDocumentAccess removes a user's grant from a document
+// @Summary Revoke document access
+// @Description Remove a user's explicit grant on a document
+// @Tags documents
+// @Param id path string true "Document ID"
+// @Param userId path string true "User ID"
+// @Success 204
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/acl/{userId} [delete]
+func (h *DocumentHandler) RevokeDocumentAccess(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	granteeID, ok := h.ValidateUUID(c, "user ID", c.Param("userId"))
+	if !ok {
+		return
+	}
+
+	if err := h.documentService.RevokeDocumentAccess(c.Request.Context(), documentID, granteeID, userCtx.TenantID, userCtx.UserID); err != nil {
+		h.respondDocumentACLError(c, err, "Failed to revoke document access")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // Helper methods
 
+func (h *DocumentHandler) respondDocumentACLError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrDocumentACLNotFound):
+		h.RespondNotFound(c, "Document ACL entry not found")
+	case errors.Is(err, services.ErrUserNotFound):
+		h.RespondNotFound(c, "User not found")
+	case errors.Is(err, services.ErrDocumentAccessDenied):
+		h.RespondError(c, http.StatusForbidden, "document_access_denied", "Only the document creator or an admin can manage access")
+	case errors.Is(err, services.ErrInvalidDocumentACL), errors.Is(err, services.ErrInvalidGrantExpiry):
+		h.RespondBadRequest(c, err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
+	}
+}
+
+func (h *DocumentHandler) handleDownloadGateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrDocumentNotFound), errors.Is(err, services.ErrUnauthorizedAccess):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrGateOverrideReasonNeeded):
+		h.RespondBadRequest(c, err.Error(), "")
+	default:
+		h.RespondInternalError(c, "Failed to update download gate", err.Error())
+	}
+}
+
+func (h *DocumentHandler) handleDirectUploadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidDirectUpload),
+		errors.Is(err, services.ErrInvalidProcessingPriority):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrUploadSessionNotFound):
+		h.RespondNotFound(c, "Upload session not found")
+	case errors.Is(err, services.ErrUploadSessionClosed):
+		h.RespondConflict(c, "Upload session is already finalized")
+	case errors.Is(err, services.ErrUploadIncomplete):
+		h.RespondError(c, http.StatusConflict, "upload_incomplete", "The file has not been uploaded yet")
+	case errors.Is(err, services.ErrUploadSessionExpired):
+		h.RespondError(c, http.StatusGone, "upload_expired", "Upload session has expired")
+	case errors.Is(err, services.ErrUploadMismatch):
+		h.RespondError(c, http.StatusUnprocessableEntity, "upload_mismatch", err.Error())
+	case errors.Is(err, services.ErrQuotaExceeded):
+		h.RespondError(c, http.StatusPaymentRequired, "quota_exceeded", "Storage quota exceeded")
+	case errors.Is(err, services.ErrDocumentQuotaExceeded):
+		h.RespondError(c, http.StatusPaymentRequired, "document_quota_exceeded", "Document quota exceeded")
+	case errors.Is(err, services.ErrDocumentTooLarge):
+		h.RespondError(c, http.StatusRequestEntityTooLarge, "file_too_large", err.Error())
+	case errors.Is(err, services.ErrUnsupportedFormat):
+		h.RespondError(c, http.StatusUnsupportedMediaType, "unsupported_format", err.Error())
+	case errors.Is(err, services.ErrDocumentExists):
+		h.RespondError(c, http.StatusConflict, "document_exists", err.Error())
+	case errors.Is(err, services.ErrFolderAccessDenied):
+		h.RespondError(c, http.StatusForbidden, "folder_access_denied", err.Error())
+	case errors.Is(err, services.ErrInvalidDocumentType):
+		h.RespondError(c, http.StatusBadRequest, "invalid_document_type", err.Error())
+	case errors.Is(err, services.ErrDirectUploadUnsupported):
+		h.RespondError(c, http.StatusNotImplemented, "direct_upload_unsupported", "Direct uploads are not available with this storage backend; use the upload endpoint")
+	default:
+		h.RespondInternalError(c, "Failed to process direct upload", err.Error())
+	}
+}
+
 func (h *DocumentHandler) getDocumentPermissions(userCtx *middleware.UserContext, document *models.Document) map[string]bool {
 	permissions := map[string]bool{
 		"read":   true, // User can access document, so they can read
@@ -786,7 +1467,7 @@ func (h *DocumentHandler) getDocumentPermissions(userCtx *middleware.UserContext
 	}
 
 	// Check if user is owner or has admin role
-	if document.CreatedBy == userCtx.UserID || userCtx.Role == models.UserRoleAdmin {
+	if document.Owner() == userCtx.UserID || userCtx.Role == models.UserRoleAdmin {
 		permissions["update"] = true
 		permissions["delete"] = true
 		permissions["share"] = true
@@ -797,3 +1478,20 @@ func (h *DocumentHandler) getDocumentPermissions(userCtx *middleware.UserContext
 
 	return permissions
 }
+
+func convertToDocumentACLResponse(acl *models.DocumentACL) DocumentACLResponse {
+	response := DocumentACLResponse{
+		ID:         acl.ID.String(),
+		DocumentID: acl.DocumentID.String(),
+		UserID:     acl.UserID.String(),
+		UserEmail:  acl.User.Email,
+		Permission: string(acl.Permission),
+		GrantedBy:  acl.GrantedBy.String(),
+		CreatedAt:  acl.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if acl.ExpiresAt != nil {
+		expiresAt := acl.ExpiresAt.Format("2006-01-02T15:04:05Z")
+		response.ExpiresAt = &expiresAt
+	}
+	return response
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+)
+
+// DocumentTypeHandler handles tenants' own document types
+type DocumentTypeHandler struct {
+	*BaseHandler
+	docTypeService *services.DocumentTypeService
+}
+
+// NewDocumentTypeHandler creates a new document type handler
+func NewDocumentTypeHandler(docTypeService *services.DocumentTypeService) *DocumentTypeHandler {
+	return &DocumentTypeHandler{
+		BaseHandler:    NewBaseHandler(),
+		docTypeService: docTypeService,
+	}
+}
+
+// RegisterRoutes sets up the document type routes
+func (h *DocumentTypeHandler) RegisterRoutes(router *gin.RouterGroup) {
+	docTypes := router.Group("/document-types")
+	// Note: Auth middleware should be applied at server level
+	{
+		docTypes.GET("", h.ListDocumentTypes)
+		docTypes.POST("", h.CreateDocumentType)
+		docTypes.PUT("/:key", h.UpdateDocumentType)
+		docTypes.DELETE("/:key", h.DeleteDocumentType)
+	}
+}
+
+// Request/Response DTOs
+
+// CreateDocumentTypeRequest defines a tenant's document type
+type CreateDocumentTypeRequest struct {
+	Key         string  `json:"key" binding:"required,max=50"`
+	Name        string  `json:"name" binding:"required,max=100"`
+	Description string  `json:"description,omitempty" binding:"max=500"` // Helps AI classification tell the type apart
+	MapsTo      *string `json:"maps_to,omitempty"`                       // Built-in type it behaves like
+}
+
+// UpdateDocumentTypeRequest contains the changes to a document type. An
+// empty maps_to removes the mapping.
+type UpdateDocumentTypeRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,max=100"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=500"`
+	MapsTo      *string `json:"maps_to,omitempty"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+// Handler Methods
+
+// ListDocumentTypes lists the built-in document types and the tenant's own
+// @Summary List document types
+// @Description List the built-in document types and the tenant's own, inactive ones included
+// @Tags documents
+// @Produce json
+// @Success 200 {object} services.DocumentTypeList
+// @Router /document-types [get]
+func (h *DocumentTypeHandler) ListDocumentTypes(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	docTypes, err := h.docTypeService.ListDocumentTypes(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list document types", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, docTypes)
+}
+
+// CreateDocumentType defines a document type for the tenant
+// @Summary Create document type
+// @Description Define a document type for the tenant, optionally mapped to the built-in type it behaves like for financial extraction, retention rules and workflows. Documents, workflows, retention policies and AI classification can then use it.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body CreateDocumentTypeRequest true "Document type"
+// @Success 201 {object} models.DocumentTypeDefinition
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /document-types [post]
+func (h *DocumentTypeHandler) CreateDocumentType(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateDocumentTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	definition, err := h.docTypeService.CreateDocumentType(c.Request.Context(), services.CreateDocumentTypeParams{
+		TenantID:    userCtx.TenantID,
+		Key:         models.DocumentType(req.Key),
+		Name:        req.Name,
+		Description: req.Description,
+		MapsTo:      toDocumentType(req.MapsTo),
+		CreatedBy:   userCtx.UserID,
+	})
+	if err != nil {
+		h.respondDocumentTypeError(c, err, "Failed to create document type")
+		return
+	}
+
+	h.RespondCreated(c, definition)
+}
+
+// UpdateDocumentType changes a tenant's document type
+// @Summary Update document type
+// @Description Rename, describe, remap, deactivate or reactivate one of the tenant's document types. Deactivated types stay on the documents that have them but can't be assigned.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param key path string true "Document type key"
+// @Param request body UpdateDocumentTypeRequest true "Changes"
+// @Success 200 {object} models.DocumentTypeDefinition
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /document-types/{key} [put]
+func (h *DocumentTypeHandler) UpdateDocumentType(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateDocumentTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	definition, err := h.docTypeService.UpdateDocumentType(c.Request.Context(), services.UpdateDocumentTypeParams{
+		TenantID:    userCtx.TenantID,
+		Key:         models.DocumentType(c.Param("key")),
+		Name:        req.Name,
+		Description: req.Description,
+		MapsTo:      toDocumentType(req.MapsTo),
+		IsActive:    req.IsActive,
+		UpdatedBy:   userCtx.UserID,
+	})
+	if err != nil {
+		h.respondDocumentTypeError(c, err, "Failed to update document type")
+		return
+	}
+
+	h.RespondSuccess(c, definition)
+}
+
+// DeleteDocumentType removes one of the tenant's document types
+// @Summary Delete document type
+// @Description Delete one of the tenant's document types. Types documents or workflows still have can't be deleted; deactivate them instead.
+// @Tags documents
+// @Param key path string true "Document type key"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /document-types/{key} [delete]
+func (h *DocumentTypeHandler) DeleteDocumentType(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.docTypeService.DeleteDocumentType(c.Request.Context(), userCtx.TenantID,
+		models.DocumentType(c.Param("key")), userCtx.UserID); err != nil {
+		h.respondDocumentTypeError(c, err, "Failed to delete document type")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Helper Methods
+
+func (h *DocumentTypeHandler) respondDocumentTypeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrDocumentTypeNotFound):
+		h.RespondNotFound(c, "Document type not found")
+	case errors.Is(err, services.ErrDocumentTypeExists), errors.Is(err, services.ErrDocumentTypeInUse):
+		h.RespondConflict(c, err.Error())
+	case errors.Is(err, services.ErrInvalidDocumentTypeDefinition):
+		h.RespondBadRequest(c, err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
+	}
+}
+
+func toDocumentType(value *string) *models.DocumentType {
+	if value == nil {
+		return nil
+	}
+	docType := models.DocumentType(*value)
+	return &docType
+}
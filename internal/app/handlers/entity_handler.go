@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+)
+
+// EntityHandler browses documents by the named entities they mention
+type EntityHandler struct {
+	*BaseHandler
+	entityService *services.EntityService
+}
+
+// NewEntityHandler creates a new entity handler
+func NewEntityHandler(entityService *services.EntityService) *EntityHandler {
+	return &EntityHandler{
+		BaseHandler:   NewBaseHandler(),
+		entityService: entityService,
+	}
+}
+
+// RegisterRoutes sets up the entity routes
+func (h *EntityHandler) RegisterRoutes(router *gin.RouterGroup) {
+	entities := router.Group("/entities")
+	// Note: Auth middleware should be applied at server level
+	{
+		entities.GET("", h.ListEntities)
+		entities.GET("/documents", h.ListEntityDocuments)
+	}
+}
+
+// Handler Methods
+
+// ListEntities counts the documents mentioning each entity
+// @Summary List entity facets
+// @Description List the people, organizations, locations, dates, amounts and emails documents mention, with how many documents the user can read mention each, most mentioned first. Entities are matched ignoring case, spacing and surrounding punctuation.
+// @Tags entities
+// @Produce json
+// @Param type query string false "Entity type: person, organization, location, date, amount or email"
+// @Param q query string false "Only entities containing this"
+// @Param limit query int false "Entities to return (default 50, max 500)"
+// @Success 200 {array} repositories.EntityFacet
+// @Failure 400 {object} ErrorResponse
+// @Router /entities [get]
+func (h *EntityHandler) ListEntities(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	facets, err := h.entityService.Facets(c.Request.Context(), userCtx.TenantID, userCtx.UserID, services.EntityFacetParams{
+		Type:   models.EntityType(c.Query("type")),
+		Search: c.Query("q"),
+		Limit:  getIntParam(c, "limit", 0),
+	})
+	if err != nil {
+		h.handleEntityError(c, err, "Failed to list entities")
+		return
+	}
+
+	h.RespondSuccess(c, facets)
+}
+
+// ListEntityDocuments lists the documents mentioning an entity
+// @Summary List documents by entity
+// @Description List the documents the user can read that mention an entity, e.g. every document mentioning "Acme Corp". The value is matched ignoring case, spacing and surrounding punctuation.
+// @Tags entities
+// @Produce json
+// @Param type query string true "Entity type: person, organization, location, date, amount or email"
+// @Param value query string true "Entity value"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+// @Success 200 {object} PaginatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /entities/documents [get]
+func (h *EntityHandler) ListEntityDocuments(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	documents, total, err := h.entityService.ListDocuments(c.Request.Context(), userCtx.TenantID, userCtx.UserID,
+		models.EntityType(c.Query("type")), c.Query("value"), repositories.ListParams{
+			Page:     page,
+			PageSize: pageSize,
+		})
+	if err != nil {
+		h.handleEntityError(c, err, "Failed to list documents")
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       documents,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// Helper methods
+
+func (h *EntityHandler) handleEntityError(c *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidEntityType), errors.Is(err, services.ErrInvalidEntityValue):
+		h.RespondBadRequest(c, err.Error())
+	default:
+		h.RespondInternalError(c, failure, err.Error())
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// FilingSuggestionHandler shows where AI suggests filing documents and
+// applies the suggestions users accept
+type FilingSuggestionHandler struct {
+	*BaseHandler
+	filingService *services.FilingSuggestionService
+}
+
+// NewFilingSuggestionHandler creates a new filing suggestion handler
+func NewFilingSuggestionHandler(filingService *services.FilingSuggestionService) *FilingSuggestionHandler {
+	return &FilingSuggestionHandler{
+		BaseHandler:   NewBaseHandler(),
+		filingService: filingService,
+	}
+}
+
+// RegisterRoutes sets up the filing suggestion routes
+func (h *FilingSuggestionHandler) RegisterRoutes(router *gin.RouterGroup) {
+	docs := router.Group("/documents")
+	// Note: Auth middleware should be applied at server level
+	{
+		docs.GET("/:id/suggestions", h.GetSuggestion)
+		docs.POST("/:id/suggestions/accept", h.AcceptSuggestion)
+	}
+}
+
+// Request/Response DTOs
+
+// AcceptFilingRequest picks the parts of a suggestion to apply
+type AcceptFilingRequest struct {
+	Apply []string `json:"apply,omitempty"` // folder, category and tags; all when empty
+}
+
+// Handler Methods
+
+// GetSuggestion returns where AI suggests filing a document
+// @Summary Get filing suggestion
+// @Description Get the folder, category and tags AI suggests filing a document under, chosen from the tenant's own folders and categories. Suggestions are made by filing_suggestion jobs, queued for unfiled uploads when the tenant's ai_filing_suggestions setting is on.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {object} services.FilingSuggestionView
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/suggestions [get]
+func (h *FilingSuggestionHandler) GetSuggestion(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	suggestion, err := h.filingService.GetSuggestion(c.Request.Context(), userCtx.TenantID, userCtx.UserID, documentID)
+	if err != nil {
+		h.handleFilingError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, suggestion)
+}
+
+// AcceptSuggestion applies a document's filing suggestion
+// @Summary Accept filing suggestion
+// @Description Apply a document's filing suggestion: move it to the suggested folder and add the category and tags, creating tags the tenant doesn't have. Moving needs write access to the folder. The body may pick the parts to apply; all are applied without one.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param request body AcceptFilingRequest false "Parts to apply"
+// @Success 200 {object} models.Document
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /documents/{id}/suggestions/accept [post]
+func (h *FilingSuggestionHandler) AcceptSuggestion(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req AcceptFilingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.RespondBadRequest(c, "Invalid request format", err.Error())
+			return
+		}
+	}
+
+	document, err := h.filingService.Accept(c.Request.Context(), userCtx.TenantID, userCtx.UserID, documentID, services.AcceptFilingParams{
+		Apply: req.Apply,
+	})
+	if err != nil {
+		h.handleFilingError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, document)
+}
+
+// Helper methods
+
+func (h *FilingSuggestionHandler) handleFilingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidFilingAccept):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrFilingSuggestionNotFound):
+		h.RespondNotFound(c, err.Error())
+	case errors.Is(err, services.ErrFolderNotFound):
+		h.RespondNotFound(c, "Suggested folder not found")
+	case errors.Is(err, services.ErrDocumentAccessDenied):
+		h.RespondError(c, http.StatusForbidden, "document_access_denied", err.Error())
+	case errors.Is(err, services.ErrFolderAccessDenied):
+		h.RespondError(c, http.StatusForbidden, "folder_access_denied", err.Error())
+	case errors.Is(err, services.ErrFilingSuggestionAccepted):
+		h.RespondError(c, http.StatusConflict, "filing_suggestion_accepted", err.Error())
+	default:
+		h.RespondInternalError(c, "Failed to process filing suggestion", err.Error())
+	}
+}
@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/archivus/archivus/internal/app/middleware"
 	"github.com/archivus/archivus/internal/domain/repositories"
 	"github.com/archivus/archivus/internal/domain/services"
 	"github.com/archivus/archivus/internal/infrastructure/database/models"
@@ -48,6 +51,11 @@ func (h *FolderHandler) RegisterRoutes(router *gin.RouterGroup) {
 		folders.GET("/:id/tree", h.GetFolderTree)
 		folders.POST("/:id/move", h.MoveFolder)
 		folders.GET("/:id/documents", h.GetFolderDocuments)
+
+		// Access control
+		folders.GET("/:id/acl", h.ListFolderACL)
+		folders.POST("/:id/acl", h.GrantFolderAccess)
+		folders.DELETE("/:id/acl/:aclId", h.RevokeFolderAccess)
 	}
 }
 
@@ -88,6 +96,7 @@ type FolderResponse struct {
 	ParentID      *uuid.UUID      `json:"parent_id,omitempty"`
 	DocumentCount int64           `json:"document_count"`
 	CreatedBy     uuid.UUID       `json:"created_by"`
+	OwnerID       uuid.UUID       `json:"owner_id"`
 	CreatedAt     string          `json:"created_at"`
 	UpdatedAt     string          `json:"updated_at"`
 	Parent        *FolderSummary  `json:"parent,omitempty"`
@@ -156,6 +165,27 @@ type DocumentSummary struct {
 	UpdatedAt    string    `json:"updated_at"`
 }
 
+// GrantFolderAccessRequest grants a user or a role access to a folder
+type GrantFolderAccessRequest struct {
+	UserID     *string    `json:"user_id,omitempty" binding:"omitempty,uuid"`
+	Role       string     `json:"role,omitempty" binding:"omitempty,oneof=admin manager user viewer accountant compliance"`
+	Permission string     `json:"permission" binding:"required,oneof=read write manage"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// FolderACLResponse represents a folder ACL entry
+type FolderACLResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	FolderID   uuid.UUID  `json:"folder_id"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	UserEmail  string     `json:"user_email,omitempty"`
+	Role       string     `json:"role,omitempty"`
+	Permission string     `json:"permission"`
+	GrantedBy  uuid.UUID  `json:"granted_by"`
+	ExpiresAt  *string    `json:"expires_at,omitempty"`
+	CreatedAt  string     `json:"created_at"`
+}
+
 // Handler Methods
 
 // CreateFolder creates a new folder
@@ -194,6 +224,10 @@ func (h *FolderHandler) CreateFolder(c *gin.Context) {
 			return
 		}
 		parentID = &id
+
+		if !h.requireFolderPermission(c, userCtx, id, models.FolderPermWrite) {
+			return
+		}
 	}
 
 	// Create folder
@@ -253,14 +287,24 @@ func (h *FolderHandler) ListFolders(c *gin.Context) {
 		return
 	}
 
+	denied, err := h.unreadableFolders(c.Request.Context(), userCtx)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to resolve folder permissions", err.Error())
+		return
+	}
+
 	// Convert to response format
 	var folderResponses []FolderResponse
 	for _, folder := range folders {
+		if denied[folder.ID] {
+			continue
+		}
+
 		folderResponse := h.convertToFolderResponse(&folder)
 
 		// Add children if requested
 		if includeChildren {
-			children, _ := h.getFolderChildren(c.Request.Context(), folder.ID)
+			children, _ := h.getFolderChildren(c.Request.Context(), folder.ID, userCtx.TenantID)
 			folderResponse.Children = children
 		}
 
@@ -324,11 +368,15 @@ func (h *FolderHandler) GetFolder(c *gin.Context) {
 		return
 	}
 
+	if !h.requireFolderPermission(c, userCtx, folder.ID, models.FolderPermRead) {
+		return
+	}
+
 	response := h.convertToFolderResponse(folder)
 
 	// Add children if requested
 	if includeChildren {
-		children, _ := h.getFolderChildren(c.Request.Context(), folder.ID)
+		children, _ := h.getFolderChildren(c.Request.Context(), folder.ID, userCtx.TenantID)
 		response.Children = children
 	}
 
@@ -359,6 +407,10 @@ func (h *FolderHandler) UpdateFolder(c *gin.Context) {
 		return
 	}
 
+	if !h.requireFolderPermission(c, userCtx, folderID, models.FolderPermManage) {
+		return
+	}
+
 	var req UpdateFolderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -420,6 +472,10 @@ func (h *FolderHandler) DeleteFolder(c *gin.Context) {
 		return
 	}
 
+	if !h.requireFolderPermission(c, userCtx, folderID, models.FolderPermManage) {
+		return
+	}
+
 	// Delete folder
 	err := h.deleteFolder(c.Request.Context(), folderID, userCtx.TenantID, userCtx.UserID)
 	if err != nil {
@@ -488,8 +544,14 @@ func (h *FolderHandler) GetFolderTree(c *gin.Context) {
 		return
 	}
 
+	denied, err := h.unreadableFolders(c.Request.Context(), userCtx)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to resolve folder permissions", err.Error())
+		return
+	}
+
 	response := FolderTreeResponse{
-		Folders: h.convertToFolderTreeNodes(tree),
+		Folders: h.convertToFolderTreeNodes(pruneFolderTree(tree, denied)),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -544,6 +606,13 @@ func (h *FolderHandler) MoveFolder(c *gin.Context) {
 		newParentID = id
 	}
 
+	if !h.requireFolderPermission(c, userCtx, folderID, models.FolderPermManage) {
+		return
+	}
+	if newParentID != uuid.Nil && !h.requireFolderPermission(c, userCtx, newParentID, models.FolderPermWrite) {
+		return
+	}
+
 	// Move folder
 	folder, err := h.moveFolder(c.Request.Context(), folderID, newParentID, userCtx.TenantID, userCtx.UserID)
 	if err != nil {
@@ -592,6 +661,10 @@ func (h *FolderHandler) GetFolderDocuments(c *gin.Context) {
 		return
 	}
 
+	if !h.requireFolderPermission(c, userCtx, folderID, models.FolderPermRead) {
+		return
+	}
+
 	page, pageSize := h.ParsePagination(c)
 	sortBy, sortDesc := h.ParseSorting(c, "created_at")
 
@@ -606,7 +679,7 @@ func (h *FolderHandler) GetFolderDocuments(c *gin.Context) {
 		},
 	}
 
-	documents, total, err := h.documentService.ListDocuments(c.Request.Context(), userCtx.TenantID, filters)
+	documents, total, err := h.documentService.ListDocuments(c.Request.Context(), userCtx.TenantID, userCtx.UserID, filters)
 	if err != nil {
 		h.RespondInternalError(c, "Failed to fetch folder documents", err.Error())
 		return
@@ -631,8 +704,203 @@ func (h *FolderHandler) GetFolderDocuments(c *gin.Context) {
 	h.RespondSuccess(c, response)
 }
 
+// ListFolderACL lists the access grants on a folder
+// @Summary List folder ACL
+// @Description List the users and roles granted access to a folder
+// @Tags folders
+// @Produce json
+// @Param id path string true "Folder ID"
+// @Success 200 {array} FolderACLResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /folders/{id}/acl [get]
+func (h *FolderHandler) ListFolderACL(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	folderID, ok := h.ValidateUUID(c, "folder ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if !h.requireFolderPermission(c, userCtx, folderID, models.FolderPermManage) {
+		return
+	}
+
+	acls, err := h.documentService.ListFolderACL(c.Request.Context(), folderID, userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list folder access", err.Error())
+		return
+	}
+
+	response := make([]FolderACLResponse, 0, len(acls))
+	for i := range acls {
+		response = append(response, h.convertToFolderACLResponse(&acls[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// GrantFolderAccess grants a user or role access to a folder
+// @Summary Grant folder access
+// @Description Grant read, write or manage permission on a folder (and its subfolders) to a user or a role
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID"
+// @Param request body GrantFolderAccessRequest true "Grant request"
+// @Success 201 {object} FolderACLResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /folders/{id}/acl [post]
+func (h *FolderHandler) GrantFolderAccess(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	folderID, ok := h.ValidateUUID(c, "folder ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req GrantFolderAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if !h.requireFolderPermission(c, userCtx, folderID, models.FolderPermManage) {
+		return
+	}
+
+	params := services.GrantFolderAccessParams{
+		TenantID:   userCtx.TenantID,
+		FolderID:   folderID,
+		Role:       models.UserRole(req.Role),
+		Permission: models.FolderPermission(req.Permission),
+		GrantedBy:  userCtx.UserID,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if req.UserID != nil && *req.UserID != "" {
+		granteeID, ok := h.ValidateUUID(c, "user ID", *req.UserID)
+		if !ok {
+			return
+		}
+		params.UserID = &granteeID
+	}
+
+	acl, err := h.documentService.GrantFolderAccess(c.Request.Context(), params)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidFolderACL), errors.Is(err, services.ErrInvalidGrantExpiry):
+			h.RespondBadRequest(c, err.Error())
+		case errors.Is(err, services.ErrUserNotFound):
+			h.RespondNotFound(c, "User not found")
+		default:
+			h.RespondInternalError(c, "Failed to grant folder access", err.Error())
+		}
+		return
+	}
+
+	h.RespondCreated(c, h.convertToFolderACLResponse(acl))
+}
+
+// RevokeFolderAccess removes an access grant from a folder
+// @Summary Revoke folder access
+// @Description Remove a user or role grant from a folder
+// @Tags folders
+// @Param id path string true "Folder ID"
+// @Param aclId path string true "ACL entry ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /folders/{id}/acl/{aclId} [delete]
+func (h *FolderHandler) RevokeFolderAccess(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	folderID, ok := h.ValidateUUID(c, "folder ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	aclID, ok := h.ValidateUUID(c, "ACL entry ID", c.Param("aclId"))
+	if !ok {
+		return
+	}
+
+	if !h.requireFolderPermission(c, userCtx, folderID, models.FolderPermManage) {
+		return
+	}
+
+	if err := h.documentService.RevokeFolderAccess(c.Request.Context(), folderID, aclID, userCtx.TenantID, userCtx.UserID); err != nil {
+		if errors.Is(err, services.ErrFolderACLNotFound) {
+			h.RespondNotFound(c, "Folder access entry not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to revoke folder access", err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // Helper Methods
 
+// requireFolderPermission enforces folder ACLs, writing the error response when access is denied
+func (h *FolderHandler) requireFolderPermission(c *gin.Context, userCtx *middleware.UserContext, folderID uuid.UUID, permission models.FolderPermission) bool {
+	err := h.documentService.CheckFolderAccess(c.Request.Context(), folderID, userCtx.TenantID, userCtx.UserID, permission)
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, services.ErrFolderAccessDenied) {
+		h.RespondError(c, http.StatusForbidden, "folder_access_denied", "Insufficient permissions for this folder")
+		return false
+	}
+
+	h.RespondNotFound(c, "Folder not found")
+	return false
+}
+
+func (h *FolderHandler) unreadableFolders(ctx context.Context, userCtx *middleware.UserContext) (map[uuid.UUID]bool, error) {
+	ids, err := h.documentService.UnreadableFolderIDs(ctx, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	denied := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		denied[id] = true
+	}
+	return denied, nil
+}
+
+func pruneFolderTree(nodes []repositories.FolderNode, denied map[uuid.UUID]bool) []repositories.FolderNode {
+	if len(denied) == 0 {
+		return nodes
+	}
+
+	pruned := make([]repositories.FolderNode, 0, len(nodes))
+	for _, node := range nodes {
+		if denied[node.Folder.ID] {
+			continue
+		}
+		node.Children = pruneFolderTree(node.Children, denied)
+		pruned = append(pruned, node)
+	}
+	return pruned
+}
+
 // Service integration methods - These use the real DocumentService folder methods
 
 func (h *FolderHandler) createFolder(ctx context.Context, tenantID, userID uuid.UUID, name, description string, parentID *uuid.UUID, color, icon string) (*models.Folder, error) {
@@ -689,8 +957,8 @@ func (h *FolderHandler) moveFolder(ctx context.Context, folderID, newParentID, t
 	return h.documentService.MoveFolder(ctx, folderID, newParentID, tenantID, userID)
 }
 
-func (h *FolderHandler) getFolderChildren(ctx context.Context, folderID uuid.UUID) ([]FolderSummary, error) {
-	children, err := h.documentService.GetFolderChildren(ctx, folderID)
+func (h *FolderHandler) getFolderChildren(ctx context.Context, folderID, tenantID uuid.UUID) ([]FolderSummary, error) {
+	children, err := h.documentService.GetFolderChildren(ctx, folderID, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -743,6 +1011,7 @@ func (h *FolderHandler) convertToFolderResponse(folder *models.Folder) FolderRes
 		ParentID:      folder.ParentID,
 		DocumentCount: 0, // Would be populated from service
 		CreatedBy:     folder.CreatedBy,
+		OwnerID:       folder.Owner(),
 		CreatedAt:     folder.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedAt:     folder.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
@@ -782,6 +1051,26 @@ func (h *FolderHandler) convertToFolderTreeNodes(nodes []repositories.FolderNode
 	return result
 }
 
+func (h *FolderHandler) convertToFolderACLResponse(acl *models.FolderACL) FolderACLResponse {
+	response := FolderACLResponse{
+		ID:         acl.ID,
+		FolderID:   acl.FolderID,
+		UserID:     acl.UserID,
+		Role:       string(acl.Role),
+		Permission: string(acl.Permission),
+		GrantedBy:  acl.GrantedBy,
+		CreatedAt:  acl.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if acl.User != nil {
+		response.UserEmail = acl.User.Email
+	}
+	if acl.ExpiresAt != nil {
+		expiresAt := acl.ExpiresAt.Format("2006-01-02T15:04:05Z")
+		response.ExpiresAt = &expiresAt
+	}
+	return response
+}
+
 func (h *FolderHandler) convertToDocumentSummary(doc *models.Document) DocumentSummary {
 	return DocumentSummary{
 		ID:           doc.ID,
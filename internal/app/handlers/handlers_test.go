@@ -183,6 +183,15 @@ func TestBaseHandlerConfig(t *testing.T) {
 }
 
 // Test pagination parsing
+func TestDKIMPassedFor(t *testing.T) {
+	assert.True(t, dkimPassedFor("{@example.com : pass}", "ada@example.com"))
+	assert.True(t, dkimPassedFor("{@mailer.net : pass}, {@example.com : pass}", "ada@eu.example.com"))
+	assert.False(t, dkimPassedFor("{@example.com : fail}", "ada@example.com"))
+	assert.False(t, dkimPassedFor("{@mailer.net : pass}", "ada@example.com"))
+	assert.False(t, dkimPassedFor("{@ample.com : pass}", "ada@example.com"))
+	assert.False(t, dkimPassedFor("", "ada@example.com"))
+}
+
 func TestPaginationParsing(t *testing.T) {
 	handler := NewBaseHandler()
 	router := setupTestRouter()
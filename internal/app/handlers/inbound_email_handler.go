@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// InboundEmailHandler receives email for tenant inboxes from the mail
+// provider and manages the tenant's inbox settings
+type InboundEmailHandler struct {
+	*BaseHandler
+	ingestionService *services.EmailIngestionService
+}
+
+// NewInboundEmailHandler creates a new inbound email handler
+func NewInboundEmailHandler(ingestionService *services.EmailIngestionService) *InboundEmailHandler {
+	return &InboundEmailHandler{
+		BaseHandler:      NewBaseHandler(),
+		ingestionService: ingestionService,
+	}
+}
+
+// RegisterRoutes sets up the inbound email routes
+func (h *InboundEmailHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Called by the mail provider; authenticated by the webhook secret
+	router.POST("/inbound/email", h.ReceiveEmail)
+
+	inbox := router.Group("/email-inbox")
+	// Note: Auth middleware should be applied at server level
+	{
+		inbox.GET("", h.GetSettings)
+		inbox.PUT("", h.UpdateSettings)
+	}
+}
+
+// Request/Response DTOs
+
+// EmailInboxRequest contains a tenant's inbox settings
+type EmailInboxRequest struct {
+	Enabled        bool       `json:"enabled"`
+	FolderID       *uuid.UUID `json:"folder_id,omitempty"`
+	AllowedSenders []string   `json:"allowed_senders"`
+	SubmitterID    *uuid.UUID `json:"submitter_id,omitempty"`
+}
+
+// EmailInboxResponse represents a tenant's inbox settings
+type EmailInboxResponse struct {
+	Address        string     `json:"address,omitempty"` // Empty when email-in isn't configured
+	Enabled        bool       `json:"enabled"`
+	FolderID       *uuid.UUID `json:"folder_id,omitempty"`
+	AllowedSenders []string   `json:"allowed_senders"`
+	SubmitterID    *uuid.UUID `json:"submitter_id,omitempty"`
+	UpdatedBy      *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt      *string    `json:"updated_at,omitempty"`
+}
+
+// snsNotification is an Amazon SNS message, as posted to HTTPS subscribers
+type snsNotification struct {
+	Type         string `json:"Type"`
+	Message      string `json:"Message"`
+	SubscribeURL string `json:"SubscribeURL"`
+}
+
+// sesReceipt is the SES receipt notification an SNS action publishes
+type sesReceipt struct {
+	NotificationType string `json:"notificationType"`
+	Receipt          struct {
+		Recipients []string `json:"recipients"`
+		SPFVerdict struct {
+			Status string `json:"status"`
+		} `json:"spfVerdict"`
+		DKIMVerdict struct {
+			Status string `json:"status"`
+		} `json:"dkimVerdict"`
+		Action struct {
+			Encoding string `json:"encoding"`
+		} `json:"action"`
+	} `json:"receipt"`
+	Content string `json:"content"`
+}
+
+// Handler Methods
+
+// ReceiveEmail files the attachments of an inbound email as documents
+// @Summary Receive inbound email
+// @Description Webhook for the inbound mail provider, called with ?token=<EMAIL_INBOUND_WEBHOOK_SECRET>. Accepts SendGrid Inbound Parse posts (parsed or raw), SES receipts through an SNS HTTPS subscription (SES only publishes mail up to 150KB this way), and raw messages sent as message/rfc822. Mail to docs+<subdomain>@<inbound domain> is filed in that tenant's inbox folder and queued for AI processing. Senders are only matched to users when the provider reports an SPF or DKIM pass; raw messages carry no verdict, so only the inbox's allowed senders apply to them.
+// @Tags inbound-email
+// @Accept multipart/form-data
+// @Accept json
+// @Produce json
+// @Param token query string true "Webhook secret"
+// @Success 200 {object} services.EmailIngestResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /inbound/email [post]
+func (h *InboundEmailHandler) ReceiveEmail(c *gin.Context) {
+	if !h.ingestionService.Enabled() {
+		h.RespondNotFound(c, "Email-in is not configured")
+		return
+	}
+	if !h.ingestionService.VerifyWebhookSecret(c.Query("token")) {
+		h.RespondUnauthorized(c, "Invalid webhook secret")
+		return
+	}
+
+	var email *services.InboundEmail
+	var err error
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	switch {
+	case c.GetHeader("X-Amz-Sns-Message-Type") != "":
+		email, err = h.readSNSNotification(c)
+	case mediaType == "multipart/form-data":
+		email, err = h.readSendGridParse(c)
+	default:
+		email, err = services.ParseInboundEmail(c.Request.Body, h.ingestionService.MaxMessageSize())
+	}
+	if err != nil {
+		h.handleInboundEmailError(c, err)
+		return
+	}
+	if email == nil {
+		// Subscription handshakes and other provider notices
+		h.RespondSuccess(c, services.EmailIngestResult{Deliveries: []services.EmailDelivery{}})
+		return
+	}
+
+	result, err := h.ingestionService.Ingest(c.Request.Context(), email)
+	if err != nil {
+		h.handleInboundEmailError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, result)
+}
+
+// GetSettings returns the tenant's inbox settings
+// @Summary Get email inbox
+// @Description Get the tenant's inbox address and how mail sent to it is filed
+// @Tags inbound-email
+// @Produce json
+// @Success 200 {object} EmailInboxResponse
+// @Router /email-inbox [get]
+func (h *InboundEmailHandler) GetSettings(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	settings, address, err := h.ingestionService.GetSettings(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleInboundEmailError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, convertToEmailInboxResponse(settings, address))
+}
+
+// UpdateSettings replaces the tenant's inbox settings
+// @Summary Update email inbox
+// @Description Enable the tenant's inbox and choose the folder its mail is filed in. Mail from the tenant's users is uploaded as them; mail from allowed_senders (addresses or @domains) is uploaded as submitter_id. Other senders are refused.
+// @Tags inbound-email
+// @Accept json
+// @Produce json
+// @Param request body EmailInboxRequest true "Inbox settings"
+// @Success 200 {object} EmailInboxResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /email-inbox [put]
+func (h *InboundEmailHandler) UpdateSettings(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req EmailInboxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	settings, address, err := h.ingestionService.UpdateSettings(c.Request.Context(), services.UpdateEmailInboxParams{
+		TenantID:  userCtx.TenantID,
+		UpdatedBy: userCtx.UserID,
+		Settings: services.EmailInboxSettings{
+			Enabled:        req.Enabled,
+			FolderID:       req.FolderID,
+			AllowedSenders: req.AllowedSenders,
+			SubmitterID:    req.SubmitterID,
+		},
+	})
+	if err != nil {
+		h.handleInboundEmailError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, convertToEmailInboxResponse(settings, address))
+}
+
+// Helper Methods
+
+// readSendGridParse reads a SendGrid Inbound Parse post: the raw message in
+// "email" when raw posting is on, parsed fields and attachment files otherwise
+func (h *InboundEmailHandler) readSendGridParse(c *gin.Context) (*services.InboundEmail, error) {
+	maxSize := h.ingestionService.MaxMessageSize()
+	if err := c.Request.ParseMultipartForm(maxSize); err != nil {
+		return nil, fmt.Errorf("%w: %w", services.ErrInvalidInboundEmail, err)
+	}
+	form := c.Request.MultipartForm
+
+	var email *services.InboundEmail
+	if raw := c.PostForm("email"); raw != "" {
+		parsed, err := services.ParseInboundEmail(strings.NewReader(raw), maxSize)
+		if err != nil {
+			return nil, err
+		}
+		email = parsed
+	} else {
+		email = &services.InboundEmail{Subject: c.PostForm("subject")}
+		if from, err := mail.ParseAddress(c.PostForm("from")); err == nil {
+			email.From = from.Address
+		}
+		for _, field := range []string{"to", "cc"} {
+			if list, err := mail.ParseAddressList(c.PostForm(field)); err == nil {
+				for _, address := range list {
+					email.Recipients = append(email.Recipients, address.Address)
+				}
+			}
+		}
+
+		// Inline images carry a content ID; like in raw mail they're skipped
+		var info map[string]struct {
+			Type      string `json:"type"`
+			ContentID string `json:"content-id"`
+		}
+		json.Unmarshal([]byte(c.PostForm("attachment-info")), &info)
+
+		var size int64
+		for field, files := range form.File {
+			if strings.HasPrefix(info[field].Type, "image/") && info[field].ContentID != "" {
+				continue
+			}
+			for _, file := range files {
+				size += file.Size
+				if size > maxSize {
+					return nil, services.ErrInboundEmailTooLarge
+				}
+				f, err := file.Open()
+				if err != nil {
+					return nil, err
+				}
+				content, err := io.ReadAll(f)
+				f.Close()
+				if err != nil {
+					return nil, err
+				}
+				email.Attachments = append(email.Attachments, services.InboundAttachment{
+					Filename:    file.Filename,
+					ContentType: file.Header.Get("Content-Type"),
+					Content:     content,
+				})
+			}
+		}
+	}
+
+	// The envelope names the actual recipients, Bcc and forwards included
+	var envelope struct {
+		To []string `json:"to"`
+	}
+	if json.Unmarshal([]byte(c.PostForm("envelope")), &envelope) == nil && len(envelope.To) > 0 {
+		email.Recipients = envelope.To
+	}
+
+	// SendGrid reports SPF as pass/fail/... and DKIM per signing domain
+	spf := strings.ToLower(strings.TrimSpace(c.PostForm("SPF")))
+	dkim := strings.ToLower(c.PostForm("dkim"))
+	email.SenderAuthenticated = spf == "pass" || dkimPassedFor(dkim, email.From)
+	if (spf == "fail" || spf == "softfail") && !strings.Contains(dkim, "pass") {
+		email.SenderAuthFailed = true
+	}
+
+	return email, nil
+}
+
+// readSNSNotification reads an SES receipt delivered by SNS. Subscription
+// confirmations are answered and return no email.
+func (h *InboundEmailHandler) readSNSNotification(c *gin.Context) (*services.InboundEmail, error) {
+	maxSize := h.ingestionService.MaxMessageSize()
+	// Base64 content inside JSON grows by a third
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSize*2))
+	if err != nil {
+		return nil, services.ErrInboundEmailTooLarge
+	}
+
+	var notification snsNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, services.ErrInvalidInboundEmail
+	}
+
+	switch notification.Type {
+	case "SubscriptionConfirmation":
+		return nil, h.ingestionService.ConfirmSNSSubscription(c.Request.Context(), notification.SubscribeURL)
+	case "Notification":
+	default:
+		return nil, nil
+	}
+
+	var receipt sesReceipt
+	if err := json.Unmarshal([]byte(notification.Message), &receipt); err != nil {
+		return nil, services.ErrInvalidInboundEmail
+	}
+	if receipt.NotificationType != "Received" || receipt.Content == "" {
+		return nil, nil
+	}
+
+	raw := []byte(receipt.Content)
+	if strings.EqualFold(receipt.Receipt.Action.Encoding, "BASE64") {
+		if raw, err = base64.StdEncoding.DecodeString(receipt.Content); err != nil {
+			return nil, services.ErrInvalidInboundEmail
+		}
+	}
+	email, err := services.ParseInboundEmail(bytes.NewReader(raw), maxSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(receipt.Receipt.Recipients) > 0 {
+		email.Recipients = receipt.Receipt.Recipients
+	}
+	email.SenderAuthenticated = receipt.Receipt.SPFVerdict.Status == "PASS" || receipt.Receipt.DKIMVerdict.Status == "PASS"
+	if receipt.Receipt.SPFVerdict.Status == "FAIL" && receipt.Receipt.DKIMVerdict.Status == "FAIL" {
+		email.SenderAuthFailed = true
+	}
+	return email, nil
+}
+
+// dkimPassedFor reports whether SendGrid's DKIM results, e.g.
+// "{@example.com : pass}, {@mailer.net : fail}", include a pass for the
+// sender's domain or a parent of it. A signature by an unrelated domain
+// says nothing about the From address.
+func dkimPassedFor(results, from string) bool {
+	_, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(from)), "@")
+	if !ok || domain == "" {
+		return false
+	}
+	for _, result := range strings.Split(results, ",") {
+		signer, verdict, ok := strings.Cut(strings.Trim(strings.TrimSpace(result), "{}"), ":")
+		if !ok || strings.TrimSpace(verdict) != "pass" {
+			continue
+		}
+		signer = strings.TrimPrefix(strings.TrimSpace(signer), "@")
+		if signer != "" && (domain == signer || strings.HasSuffix(domain, "."+signer)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *InboundEmailHandler) handleInboundEmailError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, services.ErrInboundEmailTooLarge), errors.As(err, &maxBytesErr):
+		h.RespondError(c, http.StatusRequestEntityTooLarge, "email_too_large", services.ErrInboundEmailTooLarge.Error())
+	case errors.Is(err, services.ErrInvalidInboundEmail), errors.Is(err, services.ErrInvalidEmailInbox):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrEmailIngestionDisabled):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrFolderNotFound):
+		h.RespondBadRequest(c, "Inbox folder not found", "")
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	default:
+		h.RespondInternalError(c, "Failed to process inbound email", err.Error())
+	}
+}
+
+// Conversion functions
+
+func convertToEmailInboxResponse(settings *services.EmailInboxSettings, address string) EmailInboxResponse {
+	response := EmailInboxResponse{
+		Address:        address,
+		Enabled:        settings.Enabled,
+		FolderID:       settings.FolderID,
+		AllowedSenders: settings.AllowedSenders,
+		SubmitterID:    settings.SubmitterID,
+		UpdatedBy:      settings.UpdatedBy,
+	}
+	if response.AllowedSenders == nil {
+		response.AllowedSenders = []string{}
+	}
+	if settings.UpdatedAt != nil {
+		updatedAt := settings.UpdatedAt.Format("2006-01-02T15:04:05Z")
+		response.UpdatedAt = &updatedAt
+	}
+	return response
+}
@@ -0,0 +1,555 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IntegrationHandler handles connecting Google Drive, OneDrive, Dropbox and
+// Box accounts and QuickBooks Online and Xero companies, the syncs importing
+// the drives' folders and the providers' change webhooks
+type IntegrationHandler struct {
+	*BaseHandler
+	integrationService *services.IntegrationService
+}
+
+// NewIntegrationHandler creates a new integration handler
+func NewIntegrationHandler(integrationService *services.IntegrationService) *IntegrationHandler {
+	return &IntegrationHandler{
+		BaseHandler:        NewBaseHandler(),
+		integrationService: integrationService,
+	}
+}
+
+// RegisterRoutes sets up the integration routes
+func (h *IntegrationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	integrations := router.Group("/integrations")
+	// Note: Auth middleware should be applied at server level
+	{
+		integrations.GET("", h.ListIntegrations)
+		integrations.POST("/connect", h.Connect)
+		integrations.GET("/oauth/callback", h.OAuthCallback)
+		integrations.GET("/:id/browse", h.BrowseFolder)
+		integrations.GET("/:id/mapping", h.GetMapping)
+		integrations.PUT("/:id/mapping", h.UpdateMapping)
+		integrations.DELETE("/:id", h.DeleteIntegration)
+
+		// Called by the providers; authenticated by their signatures
+		integrations.GET("/webhooks/dropbox", h.VerifyDropboxWebhook)
+		integrations.POST("/webhooks/:provider", h.ReceiveWebhook)
+	}
+
+	syncs := router.Group("/import-syncs")
+	{
+		syncs.GET("", h.ListSyncs)
+		syncs.POST("", h.CreateSync)
+		syncs.POST("/:id/run", h.RunSync)
+		syncs.DELETE("/:id", h.DeleteSync)
+	}
+}
+
+// Request/Response DTOs
+
+// IntegrationsResponse lists the tenant's connected accounts
+type IntegrationsResponse struct {
+	Integrations []models.Integration `json:"integrations"`
+	Providers    []string             `json:"providers"` // What can be connected on this server
+}
+
+// ConnectIntegrationRequest names the provider to connect an account of
+type ConnectIntegrationRequest struct {
+	Provider string `json:"provider" binding:"required"`
+}
+
+// ConnectIntegrationResponse is where to send the browser to grant access
+type ConnectIntegrationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// CreateImportSyncRequest contains what to import and where to
+type CreateImportSyncRequest struct {
+	IntegrationID    uuid.UUID  `json:"integration_id" binding:"required"`
+	RemoteFolderID   string     `json:"remote_folder_id,omitempty"` // Defaults to the top of the drive
+	RemoteFolderName string     `json:"remote_folder_name,omitempty"`
+	TargetFolderID   *uuid.UUID `json:"target_folder_id,omitempty"`
+	UploaderID       *uuid.UUID `json:"uploader_id,omitempty"` // Defaults to the requesting user
+	EnableAI         bool       `json:"enable_ai"`
+	Mode             string     `json:"mode,omitempty" binding:"omitempty,oneof=once scheduled"`
+	IntervalMinutes  int        `json:"interval_minutes,omitempty"`
+}
+
+// ConnectorMappingRequest contains how a connected account's files are filed
+type ConnectorMappingRequest struct {
+	DocumentType   string   `json:"document_type,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	Categories     []string `json:"categories,omitempty"`
+	Exclude        []string `json:"exclude,omitempty"`
+	OnRemoteDelete string   `json:"on_remote_delete,omitempty" binding:"omitempty,oneof=keep trash"`
+}
+
+// IntegrationWebhookResponse is how many syncs a change notification made due
+type IntegrationWebhookResponse struct {
+	Triggered int64 `json:"triggered"`
+}
+
+// maxIntegrationWebhookSize bounds a change notification's body
+const maxIntegrationWebhookSize = 1 << 20
+
+// Handler Methods
+
+// ListIntegrations lists the tenant's connected accounts
+// @Summary List integrations
+// @Description List the tenant's connected Google Drive, OneDrive, Dropbox, Box, QuickBooks Online and Xero accounts and the providers that can be connected
+// @Tags integrations
+// @Produce json
+// @Success 200 {object} IntegrationsResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /integrations [get]
+func (h *IntegrationHandler) ListIntegrations(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	integrations, err := h.integrationService.ListIntegrations(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+	if integrations == nil {
+		integrations = []models.Integration{}
+	}
+
+	h.RespondSuccess(c, IntegrationsResponse{
+		Integrations: integrations,
+		Providers:    h.integrationService.Providers(),
+	})
+}
+
+// Connect starts connecting an account
+// @Summary Connect an account
+// @Description Start connecting a Google Drive, OneDrive, Dropbox or Box account. Send the browser to the returned URL; the provider sends it back to the OAuth callback.
+// @Tags integrations
+// @Accept json
+// @Produce json
+// @Param request body ConnectIntegrationRequest true "Provider"
+// @Success 200 {object} ConnectIntegrationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Integrations aren't configured on this server"
+// @Router /integrations/connect [post]
+func (h *IntegrationHandler) Connect(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req ConnectIntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	authURL, err := h.integrationService.AuthorizationURL(userCtx.TenantID, userCtx.UserID, req.Provider)
+	if err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, ConnectIntegrationResponse{AuthorizationURL: authURL})
+}
+
+// OAuthCallback completes connecting an account
+// @Summary OAuth callback
+// @Description Where providers send the browser after access was granted or refused. Redirects to the configured return URL with status, and integration_id or error; answers with JSON without one.
+// @Tags integrations
+// @Produce json
+// @Param state query string true "Authorization request"
+// @Param code query string false "Authorization code"
+// @Param error query string false "Why access wasn't granted"
+// @Success 302
+// @Success 201 {object} models.Integration
+// @Failure 400 {object} ErrorResponse
+// @Router /integrations/oauth/callback [get]
+func (h *IntegrationHandler) OAuthCallback(c *gin.Context) {
+	if denied := c.Query("error"); denied != "" {
+		h.respondCallback(c, nil, errors.New(denied))
+		return
+	}
+
+	integration, err := h.integrationService.CompleteConnection(c.Request.Context(), c.Query("state"), c.Query("code"), c.Request.URL.Query())
+	h.respondCallback(c, integration, err)
+}
+
+// BrowseFolder lists a folder of a connected account
+// @Summary Browse a connected account
+// @Description List the files and folders in a folder of the account, for choosing what to import
+// @Tags integrations
+// @Produce json
+// @Param id path string true "Integration ID"
+// @Param folder_id query string false "Remote folder ID; the top of the drive without one"
+// @Success 200 {array} services.RemoteItem
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "The account must be reconnected"
+// @Router /integrations/{id}/browse [get]
+func (h *IntegrationHandler) BrowseFolder(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	integrationID, ok := h.ValidateUUID(c, "Integration ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	items, err := h.integrationService.BrowseFolder(c.Request.Context(), userCtx.TenantID, integrationID, c.Query("folder_id"))
+	if err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+	if items == nil {
+		items = []services.RemoteItem{}
+	}
+
+	h.RespondSuccess(c, items)
+}
+
+// GetMapping returns how an account's files are filed
+// @Summary Get an integration's mapping
+// @Description Get the document type, tags and categories given to files imported from the account, the files excluded, and whether documents whose remote file was deleted are kept or trashed
+// @Tags integrations
+// @Produce json
+// @Param id path string true "Integration ID"
+// @Success 200 {object} services.ConnectorMapping
+// @Failure 404 {object} ErrorResponse
+// @Router /integrations/{id}/mapping [get]
+func (h *IntegrationHandler) GetMapping(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	integrationID, ok := h.ValidateUUID(c, "Integration ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	mapping, err := h.integrationService.GetMapping(c.Request.Context(), userCtx.TenantID, integrationID)
+	if err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, mapping)
+}
+
+// UpdateMapping sets how an account's files are filed
+// @Summary Update an integration's mapping
+// @Description Set how files imported from the account are filed, for all its syncs from their next run. Exclude patterns with a slash match the path within the synced folder, others the file name. on_remote_delete is keep (the default) or trash.
+// @Tags integrations
+// @Accept json
+// @Produce json
+// @Param id path string true "Integration ID"
+// @Param request body ConnectorMappingRequest true "Mapping"
+// @Success 200 {object} services.ConnectorMapping
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /integrations/{id}/mapping [put]
+func (h *IntegrationHandler) UpdateMapping(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	integrationID, ok := h.ValidateUUID(c, "Integration ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ConnectorMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	mapping, err := h.integrationService.UpdateMapping(c.Request.Context(), userCtx.TenantID, userCtx.UserID, integrationID, services.ConnectorMapping{
+		DocumentType:   models.DocumentType(req.DocumentType),
+		Tags:           req.Tags,
+		Categories:     req.Categories,
+		Exclude:        req.Exclude,
+		OnRemoteDelete: req.OnRemoteDelete,
+	})
+	if err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, mapping)
+}
+
+// DeleteIntegration disconnects an account
+// @Summary Disconnect an account
+// @Description Disconnect an account and delete its import syncs and mapping. Imported documents are kept.
+// @Tags integrations
+// @Param id path string true "Integration ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /integrations/{id} [delete]
+func (h *IntegrationHandler) DeleteIntegration(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	integrationID, ok := h.ValidateUUID(c, "Integration ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.integrationService.DeleteIntegration(c.Request.Context(), userCtx.TenantID, userCtx.UserID, integrationID); err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListSyncs lists the tenant's import syncs
+// @Summary List import syncs
+// @Description List the folders being imported from connected accounts, with their progress
+// @Tags integrations
+// @Produce json
+// @Success 200 {array} models.ImportSync
+// @Failure 403 {object} ErrorResponse
+// @Router /import-syncs [get]
+func (h *IntegrationHandler) ListSyncs(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	syncs, err := h.integrationService.ListSyncs(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+	if syncs == nil {
+		syncs = []models.ImportSync{}
+	}
+
+	h.RespondSuccess(c, syncs)
+}
+
+// CreateSync starts importing a folder
+// @Summary Create an import sync
+// @Description Import a folder of a connected account with its subfolders, once or on a schedule. Folders are mirrored under the target folder and the files' original metadata is kept in custom fields.
+// @Tags integrations
+// @Accept json
+// @Produce json
+// @Param request body CreateImportSyncRequest true "Import sync"
+// @Success 201 {object} models.ImportSync
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /import-syncs [post]
+func (h *IntegrationHandler) CreateSync(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateImportSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	params := services.CreateImportSyncParams{
+		TenantID:         userCtx.TenantID,
+		IntegrationID:    req.IntegrationID,
+		RemoteFolderID:   req.RemoteFolderID,
+		RemoteFolderName: req.RemoteFolderName,
+		TargetFolderID:   req.TargetFolderID,
+		UploaderID:       userCtx.UserID,
+		EnableAI:         req.EnableAI,
+		Mode:             models.ImportSyncMode(req.Mode),
+		IntervalMinutes:  req.IntervalMinutes,
+		CreatedBy:        userCtx.UserID,
+	}
+	if req.UploaderID != nil {
+		params.UploaderID = *req.UploaderID
+	}
+
+	sync, err := h.integrationService.CreateSync(c.Request.Context(), params)
+	if err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, sync)
+}
+
+// RunSync runs an import sync on the next sync pass
+// @Summary Run an import sync now
+// @Description Queue the sync for the next sync pass. A finished one-time import runs again, importing what changed since.
+// @Tags integrations
+// @Produce json
+// @Param id path string true "Import sync ID"
+// @Success 200 {object} models.ImportSync
+// @Failure 404 {object} ErrorResponse
+// @Router /import-syncs/{id}/run [post]
+func (h *IntegrationHandler) RunSync(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	syncID, ok := h.ValidateUUID(c, "Import sync ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	sync, err := h.integrationService.RunSyncNow(c.Request.Context(), userCtx.TenantID, userCtx.UserID, syncID)
+	if err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, sync)
+}
+
+// DeleteSync stops importing a folder
+// @Summary Delete an import sync
+// @Description Stop importing a folder. Imported documents are kept.
+// @Tags integrations
+// @Param id path string true "Import sync ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /import-syncs/{id} [delete]
+func (h *IntegrationHandler) DeleteSync(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	syncID, ok := h.ValidateUUID(c, "Import sync ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.integrationService.DeleteSync(c.Request.Context(), userCtx.TenantID, userCtx.UserID, syncID); err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// VerifyDropboxWebhook answers Dropbox's check of the webhook URL
+// @Summary Verify the Dropbox webhook
+// @Description Called by Dropbox when the webhook URL is set in its app console; echoes the challenge
+// @Tags integrations
+// @Produce plain
+// @Param challenge query string true "Challenge"
+// @Success 200 {string} string
+// @Failure 404 {object} ErrorResponse
+// @Router /integrations/webhooks/dropbox [get]
+func (h *IntegrationHandler) VerifyDropboxWebhook(c *gin.Context) {
+	if !slices.Contains(h.integrationService.Providers(), "dropbox") {
+		h.RespondNotFound(c, "Dropbox is not configured")
+		return
+	}
+
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.String(http.StatusOK, c.Query("challenge"))
+}
+
+// ReceiveWebhook brings forward the syncs a change notification concerns
+// @Summary Receive a change notification
+// @Description Webhook for Dropbox (set in its app console as <INTEGRATIONS_WEBHOOK_URL>/dropbox) and Box (created per scheduled sync). Verified by the provider's signature; the syncs concerned run on the next sync pass, importing new and changed files and reconciling deleted ones.
+// @Tags integrations
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider"
+// @Success 200 {object} IntegrationWebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /integrations/webhooks/{provider} [post]
+func (h *IntegrationHandler) ReceiveWebhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIntegrationWebhookSize+1))
+	if err != nil || len(body) > maxIntegrationWebhookSize {
+		h.RespondBadRequest(c, "Invalid notification")
+		return
+	}
+
+	triggered, err := h.integrationService.HandleWebhook(c.Request.Context(), c.Param("provider"), c.Request.Header, body)
+	if err != nil {
+		h.handleIntegrationError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, IntegrationWebhookResponse{Triggered: triggered})
+}
+
+// Helper methods
+
+// respondCallback sends the browser back to the app, or answers with JSON
+// when no return URL is configured
+func (h *IntegrationHandler) respondCallback(c *gin.Context, integration *models.Integration, err error) {
+	returnURL := h.integrationService.ReturnURL()
+	if returnURL == "" {
+		if err != nil {
+			h.handleIntegrationError(c, err)
+			return
+		}
+		h.RespondCreated(c, integration)
+		return
+	}
+
+	target, parseErr := url.Parse(returnURL)
+	if parseErr != nil {
+		h.RespondInternalError(c, "Invalid integrations return URL")
+		return
+	}
+	query := target.Query()
+	if err != nil {
+		query.Set("status", "error")
+		query.Set("error", err.Error())
+	} else {
+		query.Set("status", "connected")
+		query.Set("integration_id", integration.ID.String())
+	}
+	target.RawQuery = query.Encode()
+	c.Redirect(http.StatusFound, target.String())
+}
+
+func (h *IntegrationHandler) handleIntegrationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidOAuthState), errors.Is(err, services.ErrInvalidImportSync),
+		errors.Is(err, services.ErrInvalidConnectorMapping), errors.Is(err, services.ErrIntegrationHasNoFiles):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrInvalidWebhookSignature):
+		h.RespondUnauthorized(c, err.Error())
+	case errors.Is(err, services.ErrUnknownIntegrationProvider):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrIntegrationNotFound):
+		h.RespondNotFound(c, "Integration not found")
+	case errors.Is(err, services.ErrImportSyncNotFound):
+		h.RespondNotFound(c, "Import sync not found")
+	case errors.Is(err, services.ErrFolderNotFound):
+		h.RespondNotFound(c, "Folder not found")
+	case errors.Is(err, services.ErrIntegrationsDisabled):
+		h.RespondError(c, http.StatusConflict, "integrations_disabled", err.Error())
+	case errors.Is(err, services.ErrIntegrationTokenRevoked):
+		h.RespondError(c, http.StatusConflict, "integration_revoked", err.Error())
+	default:
+		h.RespondInternalError(c, "Failed to process integration request", err.Error())
+	}
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+)
+
+// LegalHoldHandler handles legal holds on documents
+type LegalHoldHandler struct {
+	*BaseHandler
+	legalHoldService *services.LegalHoldService
+}
+
+// NewLegalHoldHandler creates a new legal hold handler
+func NewLegalHoldHandler(legalHoldService *services.LegalHoldService) *LegalHoldHandler {
+	return &LegalHoldHandler{
+		BaseHandler:      NewBaseHandler(),
+		legalHoldService: legalHoldService,
+	}
+}
+
+// RegisterRoutes sets up the legal hold routes
+func (h *LegalHoldHandler) RegisterRoutes(router *gin.RouterGroup) {
+	holds := router.Group("/legal-holds")
+	// Note: Auth middleware should be applied at server level
+	{
+		holds.GET("", h.ListLegalHolds)
+		holds.POST("", h.PlaceLegalHold)
+		holds.GET("/:id", h.GetLegalHold)
+		holds.POST("/:id/lift", h.LiftLegalHold)
+	}
+
+	router.GET("/documents/:id/legal-holds", h.ListDocumentHolds)
+}
+
+// Request/Response DTOs
+
+// PlaceLegalHoldRequest selects the documents to hold with exactly one of
+// document_ids, folder_id or search
+type PlaceLegalHoldRequest struct {
+	Name              string                  `json:"name" binding:"required,max=255"`
+	Reason            string                  `json:"reason" binding:"required"`
+	DocumentIDs       []string                `json:"document_ids,omitempty"`
+	FolderID          *string                 `json:"folder_id,omitempty"`
+	IncludeSubfolders bool                    `json:"include_subfolders"`
+	Search            *LegalHoldSearchRequest `json:"search,omitempty"`
+}
+
+// LegalHoldSearchRequest holds every document matching a search
+type LegalHoldSearchRequest struct {
+	Query         string     `json:"query"`
+	DocumentTypes []string   `json:"document_types,omitempty"`
+	DateFrom      *time.Time `json:"date_from,omitempty"`
+	DateTo        *time.Time `json:"date_to,omitempty"`
+}
+
+// LiftLegalHoldRequest contains why a hold is lifted
+type LiftLegalHoldRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Handler Methods
+
+// ListLegalHolds lists the tenant's legal holds
+// @Summary List legal holds
+// @Description List the tenant's legal holds, newest first
+// @Tags legal-holds
+// @Produce json
+// @Param active query bool false "Only holds that have not been lifted"
+// @Param page query int false "Page number"
+// @Param per_page query int false "Page size"
+// @Success 200 {object} PaginatedResponse
+// @Router /legal-holds [get]
+func (h *LegalHoldHandler) ListLegalHolds(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	holds, total, err := h.legalHoldService.ListLegalHolds(c.Request.Context(), userCtx.TenantID, c.Query("active") == "true", repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list legal holds", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       holds,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// PlaceLegalHold places documents under legal hold
+// @Summary Place legal hold
+// @Description Hold a list of documents, a folder (optionally with its subfolders) or every document matching a search. The set is fixed when the hold is placed; held documents can't be deleted until every hold covering them is lifted.
+// @Tags legal-holds
+// @Accept json
+// @Produce json
+// @Param request body PlaceLegalHoldRequest true "Legal hold"
+// @Success 201 {object} services.LegalHoldDetail
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /legal-holds [post]
+func (h *LegalHoldHandler) PlaceLegalHold(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req PlaceLegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	params := services.PlaceLegalHoldParams{
+		TenantID:          userCtx.TenantID,
+		PlacedBy:          userCtx.UserID,
+		Name:              req.Name,
+		Reason:            req.Reason,
+		IncludeSubfolders: req.IncludeSubfolders,
+	}
+	for _, id := range req.DocumentIDs {
+		documentID, ok := h.ValidateUUID(c, "Document ID", id)
+		if !ok {
+			return
+		}
+		params.DocumentIDs = append(params.DocumentIDs, documentID)
+	}
+	if req.FolderID != nil {
+		folderID, ok := h.ValidateUUID(c, "Folder ID", *req.FolderID)
+		if !ok {
+			return
+		}
+		params.FolderID = &folderID
+	}
+	if req.Search != nil {
+		filters := &repositories.DocumentFilters{
+			DateFrom:   req.Search.DateFrom,
+			DateTo:     req.Search.DateTo,
+			ListParams: repositories.ListParams{Search: req.Search.Query},
+		}
+		for _, documentType := range req.Search.DocumentTypes {
+			filters.DocumentType = append(filters.DocumentType, models.DocumentType(documentType))
+		}
+		params.Filters = filters
+	}
+
+	hold, err := h.legalHoldService.PlaceLegalHold(c.Request.Context(), params)
+	if err != nil {
+		h.handleLegalHoldError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, hold)
+}
+
+// GetLegalHold returns a legal hold with the documents it covers
+// @Summary Get legal hold
+// @Tags legal-holds
+// @Produce json
+// @Param id path string true "Legal hold ID"
+// @Success 200 {object} services.LegalHoldDetail
+// @Failure 404 {object} ErrorResponse
+// @Router /legal-holds/{id} [get]
+func (h *LegalHoldHandler) GetLegalHold(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	holdID, ok := h.ValidateUUID(c, "Legal hold ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	hold, err := h.legalHoldService.GetLegalHold(c.Request.Context(), userCtx.TenantID, holdID)
+	if err != nil {
+		h.handleLegalHoldError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, hold)
+}
+
+// LiftLegalHold lifts a legal hold
+// @Summary Lift legal hold
+// @Description Release a hold. Its documents stay protected while another active hold covers them.
+// @Tags legal-holds
+// @Accept json
+// @Produce json
+// @Param id path string true "Legal hold ID"
+// @Param request body LiftLegalHoldRequest true "Why the hold is lifted"
+// @Success 200 {object} models.LegalHold
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /legal-holds/{id}/lift [post]
+func (h *LegalHoldHandler) LiftLegalHold(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	holdID, ok := h.ValidateUUID(c, "Legal hold ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req LiftLegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	hold, err := h.legalHoldService.LiftLegalHold(c.Request.Context(), userCtx.TenantID, holdID, userCtx.UserID, req.Reason)
+	if err != nil {
+		h.handleLegalHoldError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, hold)
+}
+
+// ListDocumentHolds lists the legal holds covering a document
+// @Summary List document legal holds
+// @Description List every hold, active or lifted, that covers a document
+// @Tags legal-holds
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {array} models.LegalHold
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/legal-holds [get]
+func (h *LegalHoldHandler) ListDocumentHolds(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "Document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	holds, err := h.legalHoldService.ListDocumentHolds(c.Request.Context(), userCtx.TenantID, documentID)
+	if err != nil {
+		h.handleLegalHoldError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, holds)
+}
+
+// Helper Methods
+
+func (h *LegalHoldHandler) handleLegalHoldError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrLegalHoldNotFound):
+		h.RespondNotFound(c, "Legal hold not found")
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, err.Error())
+	case errors.Is(err, services.ErrFolderNotFound):
+		h.RespondNotFound(c, "Folder not found")
+	case errors.Is(err, services.ErrLegalHoldLifted):
+		h.RespondConflict(c, "Legal hold already lifted")
+	case errors.Is(err, services.ErrInvalidLegalHold),
+		errors.Is(err, services.ErrLegalHoldEmpty),
+		errors.Is(err, services.ErrLegalHoldTooLarge),
+		errors.Is(err, services.ErrLegalHoldReasonMissing):
+		h.RespondBadRequest(c, err.Error(), "")
+	default:
+		h.RespondInternalError(c, "Failed to process legal hold", err.Error())
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// LineItemHandler handles viewing and correcting the line items extracted
+// from invoices and receipts
+type LineItemHandler struct {
+	*BaseHandler
+	lineItemService *services.LineItemService
+}
+
+// NewLineItemHandler creates a new line item handler
+func NewLineItemHandler(lineItemService *services.LineItemService) *LineItemHandler {
+	return &LineItemHandler{
+		BaseHandler:     NewBaseHandler(),
+		lineItemService: lineItemService,
+	}
+}
+
+// RegisterRoutes sets up the line item routes
+func (h *LineItemHandler) RegisterRoutes(router *gin.RouterGroup) {
+	docs := router.Group("/documents")
+	// Note: Auth middleware should be applied at server level
+	{
+		docs.GET("/:id/line-items", h.ListLineItems)
+		docs.PUT("/:id/line-items", h.ReplaceLineItems)
+	}
+}
+
+// Request/Response DTOs
+
+// ReplaceLineItemsRequest contains a document's corrected line items, in order
+type ReplaceLineItemsRequest struct {
+	LineItems []services.LineItemInput `json:"line_items"`
+}
+
+// Handler Methods
+
+// ListLineItems returns a document's line items
+// @Summary List document line items
+// @Description List the lines of an invoice or receipt, as financial extraction found them or as they were corrected
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {array} models.InvoiceLineItem
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/line-items [get]
+func (h *LineItemHandler) ListLineItems(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	items, err := h.lineItemService.ListLineItems(c.Request.Context(), userCtx.TenantID, userCtx.UserID, documentID)
+	if err != nil {
+		h.handleLineItemError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, items)
+}
+
+// ReplaceLineItems corrects a document's line items
+// @Summary Correct document line items
+// @Description Replace all of a document's line items. Corrected lines are kept when the document is extracted again; an empty list lets the next extraction fill them in. Lines without an amount are worth quantity times unit price, and lines without a GL code are exported on the tenant's expense account.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param request body ReplaceLineItemsRequest true "Line items"
+// @Success 200 {array} models.InvoiceLineItem
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/line-items [put]
+func (h *LineItemHandler) ReplaceLineItems(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ReplaceLineItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	items, err := h.lineItemService.ReplaceLineItems(c.Request.Context(), userCtx.TenantID, userCtx.UserID, documentID, req.LineItems)
+	if err != nil {
+		h.handleLineItemError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, items)
+}
+
+// Helper methods
+
+func (h *LineItemHandler) handleLineItemError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidLineItems):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrDocumentAccessDenied):
+		h.RespondError(c, http.StatusForbidden, "document_access_denied", err.Error())
+	default:
+		h.RespondInternalError(c, "Failed to process line items", err.Error())
+	}
+}
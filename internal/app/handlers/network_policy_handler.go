@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NetworkPolicyHandler manages tenant IP allowlists and share geo restrictions
+type NetworkPolicyHandler struct {
+	*BaseHandler
+	networkPolicyService *services.NetworkPolicyService
+}
+
+// NewNetworkPolicyHandler creates a new network policy handler
+func NewNetworkPolicyHandler(networkPolicyService *services.NetworkPolicyService) *NetworkPolicyHandler {
+	return &NetworkPolicyHandler{
+		BaseHandler:          NewBaseHandler(),
+		networkPolicyService: networkPolicyService,
+	}
+}
+
+// RegisterRoutes sets up the network policy routes
+func (h *NetworkPolicyHandler) RegisterRoutes(router *gin.RouterGroup) {
+	policy := router.Group("/network-policy")
+	// Note: Auth middleware should be applied at server level
+	{
+		policy.GET("", h.GetPolicy)
+		policy.PUT("", h.UpdatePolicy)
+	}
+}
+
+// Request/Response DTOs
+
+// NetworkPolicyRequest contains a tenant's network restrictions
+type NetworkPolicyRequest struct {
+	APIAllowlist   []string `json:"api_allowlist"`
+	AdminAllowlist []string `json:"admin_allowlist"`
+	ShareGeoMode   string   `json:"share_geo_mode" binding:"omitempty,oneof=allow block"`
+	ShareCountries []string `json:"share_countries"`
+}
+
+// NetworkPolicyResponse represents a tenant's network restrictions
+type NetworkPolicyResponse struct {
+	APIAllowlist   []string   `json:"api_allowlist"`
+	AdminAllowlist []string   `json:"admin_allowlist"`
+	ShareGeoMode   string     `json:"share_geo_mode,omitempty"`
+	ShareCountries []string   `json:"share_countries"`
+	UpdatedBy      *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt      *string    `json:"updated_at,omitempty"`
+	CurrentIP      string     `json:"current_ip"`
+}
+
+// Handler Methods
+
+// GetPolicy returns the tenant's network policy
+// @Summary Get network policy
+// @Description Get the tenant's IP allowlists and share link geo restrictions
+// @Tags network-policy
+// @Produce json
+// @Success 200 {object} NetworkPolicyResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /network-policy [get]
+func (h *NetworkPolicyHandler) GetPolicy(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.networkPolicyService.GetPolicy(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleNetworkPolicyError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, convertToNetworkPolicyResponse(policy, c.ClientIP()))
+}
+
+// UpdatePolicy replaces the tenant's network policy
+// @Summary Update network policy
+// @Description Replace the tenant's IP allowlists and share link geo restrictions. Empty lists remove the restriction. Changes that would block the caller's own address are rejected.
+// @Tags network-policy
+// @Accept json
+// @Produce json
+// @Param request body NetworkPolicyRequest true "Network policy"
+// @Success 200 {object} NetworkPolicyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /network-policy [put]
+func (h *NetworkPolicyHandler) UpdatePolicy(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req NetworkPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	policy, err := h.networkPolicyService.UpdatePolicy(c.Request.Context(), services.UpdateNetworkPolicyParams{
+		TenantID:  userCtx.TenantID,
+		UpdatedBy: userCtx.UserID,
+		IPAddress: c.ClientIP(),
+		Policy: services.NetworkPolicy{
+			APIAllowlist:   req.APIAllowlist,
+			AdminAllowlist: req.AdminAllowlist,
+			ShareGeoMode:   req.ShareGeoMode,
+			ShareCountries: req.ShareCountries,
+		},
+	})
+	if err != nil {
+		h.handleNetworkPolicyError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, convertToNetworkPolicyResponse(policy, c.ClientIP()))
+}
+
+// Helper Methods
+
+func (h *NetworkPolicyHandler) handleNetworkPolicyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCIDR), errors.Is(err, services.ErrInvalidCountryCode),
+		errors.Is(err, services.ErrInvalidGeoMode):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrNetworkPolicyLockout):
+		h.RespondError(c, http.StatusConflict, "network_policy_lockout", err.Error())
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	default:
+		h.RespondInternalError(c, "Failed to process network policy", err.Error())
+	}
+}
+
+// Conversion functions
+
+func convertToNetworkPolicyResponse(policy *services.NetworkPolicy, currentIP string) NetworkPolicyResponse {
+	response := NetworkPolicyResponse{
+		APIAllowlist:   policy.APIAllowlist,
+		AdminAllowlist: policy.AdminAllowlist,
+		ShareGeoMode:   policy.ShareGeoMode,
+		ShareCountries: policy.ShareCountries,
+		UpdatedBy:      policy.UpdatedBy,
+		CurrentIP:      currentIP,
+	}
+	if response.APIAllowlist == nil {
+		response.APIAllowlist = []string{}
+	}
+	if response.AdminAllowlist == nil {
+		response.AdminAllowlist = []string{}
+	}
+	if response.ShareCountries == nil {
+		response.ShareCountries = []string{}
+	}
+	if policy.UpdatedAt != nil {
+		updatedAt := policy.UpdatedAt.Format("2006-01-02T15:04:05Z")
+		response.UpdatedAt = &updatedAt
+	}
+	return response
+}
@@ -0,0 +1,576 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NotificationHandler handles notification preferences and push device registration
+type NotificationHandler struct {
+	*BaseHandler
+	notificationService *services.NotificationDispatcher
+	templateService     *services.NotificationTemplateService
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationService *services.NotificationDispatcher, templateService *services.NotificationTemplateService) *NotificationHandler {
+	return &NotificationHandler{
+		BaseHandler:         NewBaseHandler(),
+		notificationService: notificationService,
+		templateService:     templateService,
+	}
+}
+
+// RegisterRoutes sets up the notification routes
+func (h *NotificationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	notifications := router.Group("/notifications")
+	// Note: Auth middleware should be applied at server level
+	{
+		// Push device registration (per user)
+		notifications.POST("/devices", h.RegisterDevice)
+		notifications.GET("/devices", h.ListDevices)
+		notifications.DELETE("/devices/:id", h.UnregisterDevice)
+
+		// SMS (critical events only) - phone verification per user
+		notifications.PUT("/phone", h.SetPhone)
+		notifications.POST("/phone/verify", h.VerifyPhone)
+		notifications.DELETE("/phone", h.RemovePhone)
+
+		// SMS cost tracking (admin). Tenants opt in via settings.sms_enabled.
+		notifications.GET("/sms/usage", h.GetSMSUsage)
+
+		// Tenant notification templates (admin). Missing templates fall back to system defaults.
+		templates := notifications.Group("/templates")
+		{
+			templates.GET("", h.ListTemplates)
+			templates.GET("/schema", h.ListTemplateSchemas)
+			templates.POST("/preview", h.PreviewTemplate)
+			templates.GET("/:event/:channel", h.GetTemplate)
+			templates.PUT("/:event/:channel", h.SaveTemplate)
+			templates.DELETE("/:event/:channel", h.ResetTemplate)
+		}
+	}
+}
+
+// Request/Response DTOs
+
+// RegisterDeviceRequest contains push device registration data
+type RegisterDeviceRequest struct {
+	Platform   models.DevicePlatform `json:"platform" binding:"required,oneof=ios android web"`
+	Token      string                `json:"token" binding:"required,max=512"`
+	DeviceName string                `json:"device_name,omitempty" binding:"max=255"`
+	AppVersion string                `json:"app_version,omitempty" binding:"max=50"`
+}
+
+// DeviceResponse represents a registered push device
+type DeviceResponse struct {
+	ID         uuid.UUID             `json:"id"`
+	Platform   models.DevicePlatform `json:"platform"`
+	DeviceName string                `json:"device_name"`
+	AppVersion string                `json:"app_version"`
+	LastSeenAt string                `json:"last_seen_at"`
+	CreatedAt  string                `json:"created_at"`
+}
+
+// SetPhoneRequest contains the phone number to verify for SMS alerts
+type SetPhoneRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required,e164"`
+}
+
+// VerifyPhoneRequest contains the one-time code sent by SMS
+type VerifyPhoneRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// SMSUsageResponse reports SMS volume and cost for a period
+type SMSUsageResponse struct {
+	From         string  `json:"from"`
+	To           string  `json:"to"`
+	MessageCount int64   `json:"message_count"`
+	FailedCount  int64   `json:"failed_count"`
+	Segments     int64   `json:"segments"`
+	TotalCost    float64 `json:"total_cost"`
+}
+
+// SaveTemplateRequest contains a tenant's wording for an event and channel
+type SaveTemplateRequest struct {
+	Subject string `json:"subject" binding:"required,max=255"`
+	Body    string `json:"body" binding:"required"`
+}
+
+// PreviewTemplateRequest renders a template without saving it. Subject and body
+// default to the current template; variables override the schema examples.
+type PreviewTemplateRequest struct {
+	EventType string            `json:"event_type" binding:"required"`
+	Channel   string            `json:"channel" binding:"required"`
+	Subject   string            `json:"subject,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// Handler Methods
+
+// RegisterDevice registers a device token for push notifications
+// @Summary Register push device
+// @Description Register (or refresh) an FCM/APNs device token for the current user
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body RegisterDeviceRequest true "Device registration request"
+// @Success 201 {object} DeviceResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /notifications/devices [post]
+func (h *NotificationHandler) RegisterDevice(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	device, err := h.notificationService.RegisterDevice(c.Request.Context(), services.RegisterDeviceParams{
+		TenantID:   userCtx.TenantID,
+		UserID:     userCtx.UserID,
+		Platform:   req.Platform,
+		Token:      req.Token,
+		DeviceName: req.DeviceName,
+		AppVersion: req.AppVersion,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDevicePlatform) || errors.Is(err, services.ErrInvalidDeviceToken) {
+			h.RespondBadRequest(c, err.Error())
+			return
+		}
+		h.RespondInternalError(c, "Failed to register device", err.Error())
+		return
+	}
+
+	h.RespondCreated(c, convertToDeviceResponse(device))
+}
+
+// ListDevices lists the current user's registered push devices
+// @Summary List push devices
+// @Description List active push notification devices for the current user
+// @Tags notifications
+// @Produce json
+// @Success 200 {array} DeviceResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /notifications/devices [get]
+func (h *NotificationHandler) ListDevices(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	devices, err := h.notificationService.ListDevices(c.Request.Context(), userCtx.UserID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list devices", err.Error())
+		return
+	}
+
+	response := make([]DeviceResponse, 0, len(devices))
+	for i := range devices {
+		response = append(response, convertToDeviceResponse(&devices[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// UnregisterDevice removes a push device
+// @Summary Unregister push device
+// @Description Remove a push notification device registered by the current user
+// @Tags notifications
+// @Param id path string true "Device ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /notifications/devices/{id} [delete]
+func (h *NotificationHandler) UnregisterDevice(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	deviceID, ok := h.ValidateUUID(c, "device ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.UnregisterDevice(c.Request.Context(), userCtx.UserID, deviceID); err != nil {
+		if errors.Is(err, services.ErrDeviceTokenNotFound) {
+			h.RespondNotFound(c, "Device not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to unregister device", err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetPhone sets the current user's phone number and sends a verification code
+// @Summary Set SMS phone number
+// @Description Set the phone number used for critical SMS alerts; a verification code is sent to it. The tenant must have SMS enabled, and only a few codes an hour are sent to a user or a number.
+// @Tags notifications
+// @Accept json
+// @Param request body SetPhoneRequest true "Phone number (E.164)"
+// @Success 202
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /notifications/phone [put]
+func (h *NotificationHandler) SetPhone(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req SetPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if err := h.notificationService.StartPhoneVerification(c.Request.Context(), userCtx.UserID, req.PhoneNumber); err != nil {
+		if h.RespondAbuseBlocked(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidPhoneNumber):
+			h.RespondBadRequest(c, err.Error())
+		case errors.Is(err, services.ErrSMSNotConfigured):
+			h.RespondError(c, http.StatusServiceUnavailable, "sms_unavailable", err.Error())
+		case errors.Is(err, services.ErrSMSNotEnabled):
+			h.RespondError(c, http.StatusForbidden, "sms_disabled", err.Error())
+		default:
+			h.RespondInternalError(c, "Failed to send verification code", err.Error())
+		}
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// VerifyPhone confirms the current user's phone number
+// @Summary Verify SMS phone number
+// @Description Confirm the phone number with the code received by SMS. After five incorrect codes a new code must be requested.
+// @Tags notifications
+// @Accept json
+// @Param request body VerifyPhoneRequest true "Verification code"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /notifications/phone/verify [post]
+func (h *NotificationHandler) VerifyPhone(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req VerifyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if err := h.notificationService.VerifyPhone(c.Request.Context(), userCtx.UserID, req.Code); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPhoneCode):
+			h.RespondBadRequest(c, err.Error())
+		case errors.Is(err, services.ErrPhoneCodeLocked):
+			h.RespondError(c, http.StatusTooManyRequests, "code_locked", err.Error())
+		default:
+			h.RespondInternalError(c, "Failed to verify phone", err.Error())
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RemovePhone removes the current user's phone number
+// @Summary Remove SMS phone number
+// @Description Remove the phone number and stop SMS alerts
+// @Tags notifications
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Router /notifications/phone [delete]
+func (h *NotificationHandler) RemovePhone(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.RemovePhone(c.Request.Context(), userCtx.UserID); err != nil {
+		h.RespondInternalError(c, "Failed to remove phone", err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetSMSUsage reports the tenant's SMS volume and cost
+// @Summary Get SMS usage
+// @Description Get SMS message count and cost for the tenant (defaults to the current month)
+// @Tags notifications
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date, exclusive (YYYY-MM-DD)"
+// @Success 200 {object} SMSUsageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /notifications/sms/usage [get]
+func (h *NotificationHandler) GetSMSUsage(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			h.RespondBadRequest(c, "Invalid from date, expected YYYY-MM-DD")
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			h.RespondBadRequest(c, "Invalid to date, expected YYYY-MM-DD")
+			return
+		}
+		to = parsed
+	}
+
+	usage, err := h.notificationService.GetSMSUsage(c.Request.Context(), userCtx.TenantID, from, to)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to get SMS usage", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, SMSUsageResponse{
+		From:         from.Format("2006-01-02"),
+		To:           to.Format("2006-01-02"),
+		MessageCount: usage.MessageCount,
+		FailedCount:  usage.FailedCount,
+		Segments:     usage.Segments,
+		TotalCost:    usage.TotalCost,
+	})
+}
+
+// ListTemplates lists the tenant's effective notification templates
+// @Summary List notification templates
+// @Description List the template used for every event type and channel, marking system defaults
+// @Tags notifications
+// @Produce json
+// @Success 200 {array} services.EffectiveNotificationTemplate
+// @Failure 403 {object} ErrorResponse
+// @Router /notifications/templates [get]
+func (h *NotificationHandler) ListTemplates(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	templates, err := h.templateService.ListTemplates(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list notification templates", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, templates)
+}
+
+// ListTemplateSchemas lists templatable events with their allowed variables
+// @Summary List notification template schemas
+// @Description List event types, their placeholder variables and system default wording
+// @Tags notifications
+// @Produce json
+// @Success 200 {array} services.NotificationEventSchema
+// @Failure 403 {object} ErrorResponse
+// @Router /notifications/templates/schema [get]
+func (h *NotificationHandler) ListTemplateSchemas(c *gin.Context) {
+	h.RespondSuccess(c, h.templateService.ListEventSchemas())
+}
+
+// GetTemplate returns the effective template for an event and channel
+// @Summary Get notification template
+// @Description Get the tenant template for an event and channel, or the system default
+// @Tags notifications
+// @Produce json
+// @Param event path string true "Event type"
+// @Param channel path string true "Channel (in_app, push, sms, email)"
+// @Success 200 {object} services.EffectiveNotificationTemplate
+// @Failure 404 {object} ErrorResponse
+// @Router /notifications/templates/{event}/{channel} [get]
+func (h *NotificationHandler) GetTemplate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(c.Request.Context(), userCtx.TenantID,
+		c.Param("event"), models.NotificationChannel(c.Param("channel")))
+	if err != nil {
+		h.respondTemplateError(c, err, "Failed to get notification template")
+		return
+	}
+
+	h.RespondSuccess(c, template)
+}
+
+// SaveTemplate creates or replaces the tenant's template for an event and channel
+// @Summary Save notification template
+// @Description Save a tenant template; {{variable}} placeholders are validated against the event schema
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param event path string true "Event type"
+// @Param channel path string true "Channel (in_app, push, sms, email)"
+// @Param request body SaveTemplateRequest true "Template wording"
+// @Success 200 {object} services.EffectiveNotificationTemplate
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /notifications/templates/{event}/{channel} [put]
+func (h *NotificationHandler) SaveTemplate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req SaveTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	template, err := h.templateService.SaveTemplate(c.Request.Context(), services.SaveNotificationTemplateParams{
+		TenantID:  userCtx.TenantID,
+		EventType: c.Param("event"),
+		Channel:   models.NotificationChannel(c.Param("channel")),
+		Subject:   req.Subject,
+		Body:      req.Body,
+		UpdatedBy: userCtx.UserID,
+	})
+	if err != nil {
+		h.respondTemplateError(c, err, "Failed to save notification template")
+		return
+	}
+
+	h.RespondSuccess(c, services.EffectiveNotificationTemplate{
+		EventType: template.EventType,
+		Channel:   template.Channel,
+		Subject:   template.Subject,
+		Body:      template.Body,
+		UpdatedAt: &template.UpdatedAt,
+	})
+}
+
+// ResetTemplate removes the tenant's template so the system default applies
+// @Summary Reset notification template
+// @Description Delete the tenant template for an event and channel, restoring the system default
+// @Tags notifications
+// @Param event path string true "Event type"
+// @Param channel path string true "Channel (in_app, push, sms, email)"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /notifications/templates/{event}/{channel} [delete]
+func (h *NotificationHandler) ResetTemplate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.templateService.ResetTemplate(c.Request.Context(), userCtx.TenantID,
+		c.Param("event"), models.NotificationChannel(c.Param("channel")), userCtx.UserID); err != nil {
+		h.respondTemplateError(c, err, "Failed to reset notification template")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PreviewTemplate renders a template with example variables
+// @Summary Preview notification template
+// @Description Render a template (saved or draft) with example or supplied variables
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body PreviewTemplateRequest true "Template to preview"
+// @Success 200 {object} services.RenderedNotification
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /notifications/templates/preview [post]
+func (h *NotificationHandler) PreviewTemplate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req PreviewTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	rendered, err := h.templateService.PreviewTemplate(c.Request.Context(), services.PreviewNotificationTemplateParams{
+		TenantID:  userCtx.TenantID,
+		EventType: req.EventType,
+		Channel:   models.NotificationChannel(req.Channel),
+		Subject:   req.Subject,
+		Body:      req.Body,
+		Variables: req.Variables,
+	})
+	if err != nil {
+		h.respondTemplateError(c, err, "Failed to preview notification template")
+		return
+	}
+
+	h.RespondSuccess(c, rendered)
+}
+
+// Helper Methods
+
+func (h *NotificationHandler) respondTemplateError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrUnknownNotificationEvent), errors.Is(err, services.ErrNotificationTemplateNotFound):
+		h.RespondNotFound(c, err.Error())
+	case errors.Is(err, services.ErrUnsupportedTemplateChannel), errors.Is(err, services.ErrInvalidNotificationTemplate):
+		h.RespondBadRequest(c, err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
+	}
+}
+
+// Conversion functions
+
+func convertToDeviceResponse(device *models.DeviceToken) DeviceResponse {
+	return DeviceResponse{
+		ID:         device.ID,
+		Platform:   device.Platform,
+		DeviceName: device.DeviceName,
+		AppVersion: device.AppVersion,
+		LastSeenAt: device.LastSeenAt.Format("2006-01-02T15:04:05Z"),
+		CreatedAt:  device.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// NumberingHandler handles the sequences uploads are numbered from
+type NumberingHandler struct {
+	*BaseHandler
+	numberingService *services.NumberingService
+}
+
+// NewNumberingHandler creates a new numbering handler
+func NewNumberingHandler(numberingService *services.NumberingService) *NumberingHandler {
+	return &NumberingHandler{
+		BaseHandler:      NewBaseHandler(),
+		numberingService: numberingService,
+	}
+}
+
+// RegisterRoutes sets up the numbering sequence routes
+func (h *NumberingHandler) RegisterRoutes(router *gin.RouterGroup) {
+	sequences := router.Group("/numbering-sequences")
+	// Note: Auth middleware should be applied at server level
+	{
+		sequences.GET("", h.ListSequences)
+		sequences.POST("", h.CreateSequence)
+		sequences.GET("/:id", h.GetSequence)
+		sequences.PUT("/:id", h.UpdateSequence)
+	}
+}
+
+// Request/Response DTOs
+
+// CreateNumberingSequenceRequest contains numbering sequence creation data
+type CreateNumberingSequenceRequest struct {
+	Name          string `json:"name" binding:"required,max=100"`
+	DocumentType  string `json:"document_type" binding:"required"`
+	Prefix        string `json:"prefix,omitempty" binding:"max=50"`
+	Padding       int    `json:"padding,omitempty"`
+	ResetInterval string `json:"reset_interval,omitempty" binding:"omitempty,oneof=never yearly monthly"`
+	NextNumber    *int64 `json:"next_number,omitempty"`
+}
+
+// UpdateNumberingSequenceRequest contains sequence changes; omitted fields are unchanged
+type UpdateNumberingSequenceRequest struct {
+	Name          *string `json:"name,omitempty" binding:"omitempty,max=100"`
+	Prefix        *string `json:"prefix,omitempty" binding:"omitempty,max=50"`
+	Padding       *int    `json:"padding,omitempty"`
+	ResetInterval *string `json:"reset_interval,omitempty" binding:"omitempty,oneof=never yearly monthly"`
+	IsActive      *bool   `json:"is_active,omitempty"`
+	NextNumber    *int64  `json:"next_number,omitempty"`
+}
+
+// NumberingSequenceResponse represents a numbering sequence in API responses
+type NumberingSequenceResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	DocumentType  string    `json:"document_type"`
+	Prefix        string    `json:"prefix"`
+	Padding       int       `json:"padding"`
+	ResetInterval string    `json:"reset_interval"`
+	IsActive      bool      `json:"is_active"`
+	NextNumber    string    `json:"next_number"` // What the next upload will be numbered
+	CreatedAt     string    `json:"created_at"`
+	UpdatedAt     string    `json:"updated_at"`
+}
+
+// Handler Methods
+
+// ListSequences lists the tenant's numbering sequences
+// @Summary List numbering sequences
+// @Description List the sequences the tenant's uploads are numbered from, one per document type
+// @Tags numbering
+// @Produce json
+// @Success 200 {array} NumberingSequenceResponse
+// @Router /numbering-sequences [get]
+func (h *NumberingHandler) ListSequences(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	sequences, err := h.numberingService.ListSequences(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list numbering sequences", err.Error())
+		return
+	}
+
+	response := make([]NumberingSequenceResponse, 0, len(sequences))
+	for i := range sequences {
+		response = append(response, h.convertToNumberingSequenceResponse(&sequences[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// CreateSequence starts numbering uploads of a document type
+// @Summary Create numbering sequence
+// @Description Number every upload of a document type, e.g. INV-2024-00042 from prefix "INV-{YYYY}-" and padding 5. The prefix may hold {YYYY}, {YY} and {MM}; sequences resetting yearly or monthly must include that period. next_number continues an existing numbering.
+// @Tags numbering
+// @Accept json
+// @Produce json
+// @Param request body CreateNumberingSequenceRequest true "Numbering sequence"
+// @Success 201 {object} NumberingSequenceResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /numbering-sequences [post]
+func (h *NumberingHandler) CreateSequence(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateNumberingSequenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	sequence, err := h.numberingService.CreateSequence(c.Request.Context(), services.NumberingSequenceParams{
+		TenantID:      userCtx.TenantID,
+		CreatedBy:     userCtx.UserID,
+		Name:          req.Name,
+		DocumentType:  models.DocumentType(req.DocumentType),
+		Prefix:        req.Prefix,
+		Padding:       req.Padding,
+		ResetInterval: models.NumberingReset(req.ResetInterval),
+		NextNumber:    req.NextNumber,
+	})
+	if err != nil {
+		h.respondNumberingError(c, err, "Failed to create numbering sequence")
+		return
+	}
+
+	h.RespondCreated(c, h.convertToNumberingSequenceResponse(sequence))
+}
+
+// GetSequence returns a numbering sequence
+// @Summary Get numbering sequence
+// @Description Get a numbering sequence and the number it issues next
+// @Tags numbering
+// @Produce json
+// @Param id path string true "Numbering sequence ID"
+// @Success 200 {object} NumberingSequenceResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /numbering-sequences/{id} [get]
+func (h *NumberingHandler) GetSequence(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	sequenceID, ok := h.ValidateUUID(c, "Numbering sequence ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	sequence, err := h.numberingService.GetSequence(c.Request.Context(), sequenceID, userCtx.TenantID)
+	if err != nil {
+		h.respondNumberingError(c, err, "Failed to get numbering sequence")
+		return
+	}
+
+	h.RespondSuccess(c, h.convertToNumberingSequenceResponse(sequence))
+}
+
+// UpdateSequence changes a numbering sequence
+// @Summary Update numbering sequence
+// @Description Change a sequence's format, deactivate it or move its counter. next_number can't go back to numbers already issued unless the prefix changes too. Sequences can't be deleted; deactivated ones stop numbering uploads.
+// @Tags numbering
+// @Accept json
+// @Produce json
+// @Param id path string true "Numbering sequence ID"
+// @Param request body UpdateNumberingSequenceRequest true "Sequence changes"
+// @Success 200 {object} NumberingSequenceResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /numbering-sequences/{id} [put]
+func (h *NumberingHandler) UpdateSequence(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	sequenceID, ok := h.ValidateUUID(c, "Numbering sequence ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req UpdateNumberingSequenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	params := services.UpdateNumberingSequenceParams{
+		Name:       req.Name,
+		Prefix:     req.Prefix,
+		Padding:    req.Padding,
+		IsActive:   req.IsActive,
+		NextNumber: req.NextNumber,
+	}
+	if req.ResetInterval != nil {
+		reset := models.NumberingReset(*req.ResetInterval)
+		params.ResetInterval = &reset
+	}
+
+	sequence, err := h.numberingService.UpdateSequence(c.Request.Context(), sequenceID, userCtx.TenantID, userCtx.UserID, params)
+	if err != nil {
+		h.respondNumberingError(c, err, "Failed to update numbering sequence")
+		return
+	}
+
+	h.RespondSuccess(c, h.convertToNumberingSequenceResponse(sequence))
+}
+
+// Helper Methods
+
+func (h *NumberingHandler) respondNumberingError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNumberingSequenceNotFound):
+		h.RespondNotFound(c, "Numbering sequence not found")
+	case errors.Is(err, services.ErrNumberingSequenceExists), errors.Is(err, services.ErrNumberingSequenceRewind):
+		h.RespondConflict(c, err.Error())
+	case errors.Is(err, services.ErrInvalidNumberingSequence):
+		h.RespondBadRequest(c, err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
+	}
+}
+
+func (h *NumberingHandler) convertToNumberingSequenceResponse(sequence *models.NumberingSequence) NumberingSequenceResponse {
+	return NumberingSequenceResponse{
+		ID:            sequence.ID,
+		Name:          sequence.Name,
+		DocumentType:  string(sequence.DocumentType),
+		Prefix:        sequence.Prefix,
+		Padding:       sequence.Padding,
+		ResetInterval: string(sequence.ResetInterval),
+		IsActive:      sequence.IsActive,
+		NextNumber:    h.numberingService.NextNumber(sequence),
+		CreatedAt:     sequence.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:     sequence.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// OwnershipHandler handles transferring documents and folders between users
+type OwnershipHandler struct {
+	*BaseHandler
+	ownershipService *services.OwnershipService
+}
+
+// NewOwnershipHandler creates a new ownership handler
+func NewOwnershipHandler(ownershipService *services.OwnershipService) *OwnershipHandler {
+	return &OwnershipHandler{
+		BaseHandler:      NewBaseHandler(),
+		ownershipService: ownershipService,
+	}
+}
+
+// RegisterRoutes sets up the ownership transfer routes
+func (h *OwnershipHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	router.POST("/documents/:id/transfer-ownership", h.TransferDocument)
+	router.POST("/folders/:id/transfer-ownership", h.TransferFolder)
+	router.POST("/ownership-transfers", h.TransferOwnership)
+}
+
+// Request/Response DTOs
+
+// TransferItemOwnershipRequest names the new owner of a document or folder
+type TransferItemOwnershipRequest struct {
+	ToUserID        string `json:"to_user_id" binding:"required"`
+	IncludeContents bool   `json:"include_contents"`
+}
+
+// BulkOwnershipTransferRequest selects what to transfer with exactly one of
+// document_ids, folder_ids, search or from_user_id
+type BulkOwnershipTransferRequest struct {
+	ToUserID        string                          `json:"to_user_id" binding:"required"`
+	DocumentIDs     []string                        `json:"document_ids,omitempty"`
+	FolderIDs       []string                        `json:"folder_ids,omitempty"`
+	IncludeContents bool                            `json:"include_contents"`
+	Search          *OwnershipTransferSearchRequest `json:"search,omitempty"`
+	FromUserID      *string                         `json:"from_user_id,omitempty"`
+}
+
+// OwnershipTransferSearchRequest transfers every document matching a search
+type OwnershipTransferSearchRequest struct {
+	Query         string     `json:"query"`
+	DocumentTypes []string   `json:"document_types,omitempty"`
+	OwnerID       *string    `json:"owner_id,omitempty"`
+	DateFrom      *time.Time `json:"date_from,omitempty"`
+	DateTo        *time.Time `json:"date_to,omitempty"`
+}
+
+// Handler Methods
+
+// TransferDocument transfers a document to another user
+// @Summary Transfer document ownership
+// @Description Make another active user of the tenant the document's owner. Both parties are notified.
+// @Tags ownership
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param request body TransferItemOwnershipRequest true "New owner"
+// @Success 200 {object} services.OwnershipTransferResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/transfer-ownership [post]
+func (h *OwnershipHandler) TransferDocument(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "Document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req TransferItemOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+	toUserID, ok := h.ValidateUUID(c, "User ID", req.ToUserID)
+	if !ok {
+		return
+	}
+
+	result, err := h.ownershipService.TransferDocument(c.Request.Context(), userCtx.TenantID, documentID, userCtx.UserID, toUserID, c.ClientIP())
+	if err != nil {
+		h.handleOwnershipError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, result)
+}
+
+// TransferFolder transfers a folder to another user
+// @Summary Transfer folder ownership
+// @Description Make another active user of the tenant the folder's owner; with include_contents, also its subfolders and every document in them. Both parties are notified.
+// @Tags ownership
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID"
+// @Param request body TransferItemOwnershipRequest true "New owner"
+// @Success 200 {object} services.OwnershipTransferResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /folders/{id}/transfer-ownership [post]
+func (h *OwnershipHandler) TransferFolder(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	folderID, ok := h.ValidateUUID(c, "Folder ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req TransferItemOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+	toUserID, ok := h.ValidateUUID(c, "User ID", req.ToUserID)
+	if !ok {
+		return
+	}
+
+	result, err := h.ownershipService.TransferFolder(c.Request.Context(), userCtx.TenantID, folderID, userCtx.UserID, toUserID, req.IncludeContents, c.ClientIP())
+	if err != nil {
+		h.handleOwnershipError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, result)
+}
+
+// TransferOwnership transfers many documents and folders at once
+// @Summary Bulk ownership transfer
+// @Description Transfer a list of documents, folders (optionally with their contents), every document matching a search, or everything a user owns — e.g. when a team is reorganized. Items the new owner already owns are skipped.
+// @Tags ownership
+// @Accept json
+// @Produce json
+// @Param request body BulkOwnershipTransferRequest true "Ownership transfer"
+// @Success 200 {object} services.OwnershipTransferResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /ownership-transfers [post]
+func (h *OwnershipHandler) TransferOwnership(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req BulkOwnershipTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	toUserID, ok := h.ValidateUUID(c, "User ID", req.ToUserID)
+	if !ok {
+		return
+	}
+	params := services.TransferOwnershipParams{
+		TenantID:        userCtx.TenantID,
+		TransferredBy:   userCtx.UserID,
+		ToUserID:        toUserID,
+		IPAddress:       c.ClientIP(),
+		IncludeContents: req.IncludeContents,
+	}
+	for _, id := range req.DocumentIDs {
+		documentID, ok := h.ValidateUUID(c, "Document ID", id)
+		if !ok {
+			return
+		}
+		params.DocumentIDs = append(params.DocumentIDs, documentID)
+	}
+	for _, id := range req.FolderIDs {
+		folderID, ok := h.ValidateUUID(c, "Folder ID", id)
+		if !ok {
+			return
+		}
+		params.FolderIDs = append(params.FolderIDs, folderID)
+	}
+	if req.FromUserID != nil {
+		fromUserID, ok := h.ValidateUUID(c, "User ID", *req.FromUserID)
+		if !ok {
+			return
+		}
+		params.FromUserID = &fromUserID
+	}
+	if req.Search != nil {
+		filters := &repositories.DocumentFilters{
+			DateFrom:   req.Search.DateFrom,
+			DateTo:     req.Search.DateTo,
+			ListParams: repositories.ListParams{Search: req.Search.Query},
+		}
+		for _, documentType := range req.Search.DocumentTypes {
+			filters.DocumentType = append(filters.DocumentType, models.DocumentType(documentType))
+		}
+		if req.Search.OwnerID != nil {
+			ownerID, ok := h.ValidateUUID(c, "Owner ID", *req.Search.OwnerID)
+			if !ok {
+				return
+			}
+			filters.OwnedBy = []uuid.UUID{ownerID}
+		}
+		params.Filters = filters
+	}
+
+	result, err := h.ownershipService.TransferOwnership(c.Request.Context(), params)
+	if err != nil {
+		h.handleOwnershipError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, result)
+}
+
+// Helper Methods
+
+func (h *OwnershipHandler) handleOwnershipError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, err.Error())
+	case errors.Is(err, services.ErrFolderNotFound):
+		h.RespondNotFound(c, err.Error())
+	case errors.Is(err, services.ErrOwnershipTransferDenied):
+		h.RespondError(c, http.StatusForbidden, "access_denied", err.Error())
+	case errors.Is(err, services.ErrInvalidOwnershipTransfer),
+		errors.Is(err, services.ErrInvalidNewOwner),
+		errors.Is(err, services.ErrOwnershipTransferEmpty),
+		errors.Is(err, services.ErrOwnershipTransferTooLarge):
+		h.RespondBadRequest(c, err.Error(), "")
+	default:
+		h.RespondInternalError(c, "Failed to transfer ownership", err.Error())
+	}
+}
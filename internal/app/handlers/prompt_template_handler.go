@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+)
+
+// PromptTemplateHandler handles tenants' wording of the prompts AI jobs send
+type PromptTemplateHandler struct {
+	*BaseHandler
+	promptService *services.PromptTemplateService
+}
+
+// NewPromptTemplateHandler creates a new prompt template handler
+func NewPromptTemplateHandler(promptService *services.PromptTemplateService) *PromptTemplateHandler {
+	return &PromptTemplateHandler{
+		BaseHandler:   NewBaseHandler(),
+		promptService: promptService,
+	}
+}
+
+// RegisterRoutes sets up the prompt template routes
+func (h *PromptTemplateHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Tenant prompt templates (admin). Kinds without an active template use the defaults.
+	prompts := router.Group("/admin/ai/prompts")
+	// Note: Auth middleware should be applied at server level
+	{
+		prompts.GET("", h.ListPromptTemplates)
+		prompts.GET("/schema", h.ListPromptSchemas)
+		prompts.GET("/:kind", h.GetPromptTemplate)
+		prompts.PUT("/:kind", h.SavePromptTemplate)
+		prompts.DELETE("/:kind", h.ResetPromptTemplate)
+		prompts.GET("/:kind/versions", h.ListPromptVersions)
+		prompts.POST("/:kind/versions/:version/activate", h.ActivatePromptVersion)
+		prompts.POST("/:kind/test", h.TestPromptTemplate)
+	}
+}
+
+// Request/Response DTOs
+
+// SavePromptTemplateRequest contains a tenant's instructions for a kind of
+// AI call
+type SavePromptTemplateRequest struct {
+	Body string `json:"body" binding:"required"`
+	Note string `json:"note,omitempty" binding:"max=255"` // What changed
+}
+
+// TestPromptTemplateRequest runs a template on a document without saving
+// it. Body defaults to the current template.
+type TestPromptTemplateRequest struct {
+	DocumentID string `json:"document_id" binding:"required"`
+	Body       string `json:"body,omitempty"`
+}
+
+// Handler Methods
+
+// ListPromptTemplates lists the tenant's effective prompt templates
+// @Summary List AI prompt templates
+// @Description List the instructions used for every kind of AI call, marking defaults
+// @Tags ai
+// @Produce json
+// @Success 200 {array} services.EffectivePromptTemplate
+// @Router /admin/ai/prompts [get]
+func (h *PromptTemplateHandler) ListPromptTemplates(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	templates, err := h.promptService.ListTemplates(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list prompt templates", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, templates)
+}
+
+// ListPromptSchemas lists the kinds of prompts with their defaults and variables
+// @Summary List AI prompt template schemas
+// @Description List the kinds of AI calls tenants can reword: summarization, classification, extraction and tagging, with their default instructions, the variables templates may use and the reply format appended to every template
+// @Tags ai
+// @Produce json
+// @Success 200 {array} services.PromptKindSchema
+// @Router /admin/ai/prompts/schema [get]
+func (h *PromptTemplateHandler) ListPromptSchemas(c *gin.Context) {
+	h.RespondSuccess(c, h.promptService.ListSchemas())
+}
+
+// GetPromptTemplate returns the effective template for a kind
+// @Summary Get AI prompt template
+// @Description Get the tenant's active template for a kind of AI call, or the default
+// @Tags ai
+// @Produce json
+// @Param kind path string true "Prompt kind (summarization, classification, extraction, tagging)"
+// @Success 200 {object} services.EffectivePromptTemplate
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/ai/prompts/{kind} [get]
+func (h *PromptTemplateHandler) GetPromptTemplate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.promptService.GetTemplate(c.Request.Context(), userCtx.TenantID, models.PromptKind(c.Param("kind")))
+	if err != nil {
+		h.respondPromptError(c, err, "Failed to get prompt template")
+		return
+	}
+
+	h.RespondSuccess(c, template)
+}
+
+// SavePromptTemplate saves a new version of the tenant's template for a kind
+// @Summary Save AI prompt template
+// @Description Save the tenant's instructions for a kind of AI call as its next version, which jobs use from then on. {{variable}} placeholders are validated against the kind's schema.
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param kind path string true "Prompt kind (summarization, classification, extraction, tagging)"
+// @Param request body SavePromptTemplateRequest true "Template"
+// @Success 200 {object} models.PromptTemplate
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/ai/prompts/{kind} [put]
+func (h *PromptTemplateHandler) SavePromptTemplate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req SavePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	template, err := h.promptService.SaveTemplate(c.Request.Context(), services.SavePromptTemplateParams{
+		TenantID:  userCtx.TenantID,
+		Kind:      models.PromptKind(c.Param("kind")),
+		Body:      req.Body,
+		Note:      req.Note,
+		CreatedBy: userCtx.UserID,
+	})
+	if err != nil {
+		h.respondPromptError(c, err, "Failed to save prompt template")
+		return
+	}
+
+	h.RespondSuccess(c, template)
+}
+
+// ResetPromptTemplate restores the default for a kind
+// @Summary Reset AI prompt template
+// @Description Deactivate the tenant's template for a kind so the default applies again. Its versions are kept and can be activated later.
+// @Tags ai
+// @Param kind path string true "Prompt kind (summarization, classification, extraction, tagging)"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/ai/prompts/{kind} [delete]
+func (h *PromptTemplateHandler) ResetPromptTemplate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.promptService.ResetTemplate(c.Request.Context(), userCtx.TenantID,
+		models.PromptKind(c.Param("kind")), userCtx.UserID); err != nil {
+		h.respondPromptError(c, err, "Failed to reset prompt template")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListPromptVersions lists every version of the tenant's template for a kind
+// @Summary List AI prompt template versions
+// @Description List the versions of the tenant's template for a kind, newest first, marking the active one
+// @Tags ai
+// @Produce json
+// @Param kind path string true "Prompt kind (summarization, classification, extraction, tagging)"
+// @Success 200 {array} models.PromptTemplate
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/ai/prompts/{kind}/versions [get]
+func (h *PromptTemplateHandler) ListPromptVersions(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.promptService.ListVersions(c.Request.Context(), userCtx.TenantID, models.PromptKind(c.Param("kind")))
+	if err != nil {
+		h.respondPromptError(c, err, "Failed to list prompt template versions")
+		return
+	}
+
+	h.RespondSuccess(c, versions)
+}
+
+// ActivatePromptVersion makes an earlier version the active one
+// @Summary Activate AI prompt template version
+// @Description Make a version of the tenant's template the one jobs use, e.g. to roll back a change
+// @Tags ai
+// @Produce json
+// @Param kind path string true "Prompt kind (summarization, classification, extraction, tagging)"
+// @Param version path int true "Version"
+// @Success 200 {object} models.PromptTemplate
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/ai/prompts/{kind}/versions/{version}/activate [post]
+func (h *PromptTemplateHandler) ActivatePromptVersion(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		h.RespondBadRequest(c, "Invalid version")
+		return
+	}
+
+	template, err := h.promptService.ActivateVersion(c.Request.Context(), userCtx.TenantID,
+		models.PromptKind(c.Param("kind")), version, userCtx.UserID)
+	if err != nil {
+		h.respondPromptError(c, err, "Failed to activate prompt template version")
+		return
+	}
+
+	h.RespondSuccess(c, template)
+}
+
+// TestPromptTemplate runs a template on a document
+// @Summary Test AI prompt template
+// @Description Run a template, saved or draft, on a sample document and return what the AI provider made of it, without saving anything. The call is made on the provider the kind's jobs run on and counts against the AI quota and budget.
+// @Tags ai
+// @Accept json
+// @Produce json
+// @Param kind path string true "Prompt kind (summarization, classification, extraction, tagging)"
+// @Param request body TestPromptTemplateRequest true "Template and document"
+// @Success 200 {object} services.PromptTestResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /admin/ai/prompts/{kind}/test [post]
+func (h *PromptTemplateHandler) TestPromptTemplate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req TestPromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+	documentID, ok := h.ValidateUUID(c, "Document ID", req.DocumentID)
+	if !ok {
+		return
+	}
+
+	result, err := h.promptService.TestTemplate(c.Request.Context(), services.PromptTestParams{
+		TenantID:   userCtx.TenantID,
+		UserID:     userCtx.UserID,
+		Kind:       models.PromptKind(c.Param("kind")),
+		Body:       req.Body,
+		DocumentID: documentID,
+	})
+	if err != nil {
+		h.respondPromptError(c, err, "Failed to test prompt template")
+		return
+	}
+
+	h.RespondSuccess(c, result)
+}
+
+// Helper Methods
+
+func (h *PromptTemplateHandler) respondPromptError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrUnknownPromptKind), errors.Is(err, services.ErrPromptTemplateNotFound):
+		h.RespondNotFound(c, err.Error())
+	case errors.Is(err, services.ErrInvalidPromptTemplate):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrDocumentAccessDenied):
+		h.RespondError(c, http.StatusForbidden, "document_access_denied", err.Error())
+	case errors.Is(err, services.ErrPromptTestNoText):
+		h.RespondError(c, http.StatusUnprocessableEntity, "document_not_extracted", err.Error())
+	case errors.Is(err, services.ErrQuotaExceeded):
+		h.RespondError(c, http.StatusPaymentRequired, "quota_exceeded", "AI quota exceeded")
+	case errors.Is(err, services.ErrAIBudgetExceeded):
+		h.RespondError(c, http.StatusPaymentRequired, "ai_budget_exceeded", err.Error())
+	case errors.Is(err, services.ErrAIProviderNotConfigured):
+		h.RespondError(c, http.StatusServiceUnavailable, "ai_unavailable", err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RealtimeHandler streams tenant events to connected clients
+type RealtimeHandler struct {
+	*BaseHandler
+	hub *services.RealtimeHub
+}
+
+// NewRealtimeHandler creates a new realtime handler
+func NewRealtimeHandler(hub *services.RealtimeHub) *RealtimeHandler {
+	return &RealtimeHandler{
+		BaseHandler: NewBaseHandler(),
+		hub:         hub,
+	}
+}
+
+// RegisterRoutes sets up the realtime routes
+func (h *RealtimeHandler) RegisterRoutes(router *gin.RouterGroup) {
+	events := router.Group("/events")
+	// Note: Auth middleware should be applied at server level
+	{
+		events.GET("/stream", h.Stream)
+	}
+}
+
+// Handler Methods
+
+// Stream pushes the tenant's events over Server-Sent Events
+// @Summary Stream realtime events
+// @Description Server-Sent Events stream of the tenant's events (AI jobs, document status, notifications).
+// @Description The event name is the event type; the data is a JSON event. A heartbeat is sent while idle.
+// @Tags realtime
+// @Produce text/event-stream
+// @Success 200 {object} services.RealtimeEvent
+// @Failure 401 {object} ErrorResponse
+// @Router /events/stream [get]
+func (h *RealtimeHandler) Stream(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	sub := h.hub.Subscribe(userCtx.TenantID, userCtx.UserID)
+	defer sub.Close()
+
+	// The server's write timeout would otherwise cut the stream off
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(h.hub.HeartbeatInterval())
+	defer heartbeat.Stop()
+
+	c.SSEvent("connected", gin.H{"tenant_id": userCtx.TenantID, "user_id": userCtx.UserID})
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-sub.Events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent(services.RealtimeEventHeartbeat, gin.H{"time": time.Now().UTC()})
+			return true
+		}
+	})
+}
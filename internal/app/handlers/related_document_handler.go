@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RelatedDocumentHandler finds the documents related to a document
+type RelatedDocumentHandler struct {
+	*BaseHandler
+	relatedService *services.RelatedDocumentService
+}
+
+// NewRelatedDocumentHandler creates a new related document handler
+func NewRelatedDocumentHandler(relatedService *services.RelatedDocumentService) *RelatedDocumentHandler {
+	return &RelatedDocumentHandler{
+		BaseHandler:    NewBaseHandler(),
+		relatedService: relatedService,
+	}
+}
+
+// RegisterRoutes sets up the related document routes
+func (h *RelatedDocumentHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	router.GET("/documents/:id/related", h.ListRelated)
+}
+
+// Handler Methods
+
+// ListRelated returns the documents most related to a document
+// @Summary List related documents
+// @Description List the documents most related to a document, most related first: those whose passages are nearest to its own by embedding and those mentioning the same people, organizations, places and emails, such as earlier contracts and invoices from the same vendor. Only documents the user can read are listed.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param limit query int false "Documents to return (default 10, max 50)"
+// @Success 200 {array} services.RelatedDocument
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/related [get]
+func (h *RelatedDocumentHandler) ListRelated(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	related, err := h.relatedService.ListRelated(c.Request.Context(), userCtx.TenantID, userCtx.UserID, documentID, getIntParam(c, "limit", 0))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDocumentNotFound):
+			h.RespondNotFound(c, "Document not found")
+		case errors.Is(err, services.ErrDocumentAccessDenied):
+			h.RespondError(c, http.StatusForbidden, "document_access_denied", err.Error())
+		default:
+			h.RespondInternalError(c, "Failed to list related documents", err.Error())
+		}
+		return
+	}
+
+	h.RespondSuccess(c, related)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RenditionHandler serves on-demand thumbnails and previews and the tenant
+// rules for when they are generated
+type RenditionHandler struct {
+	*BaseHandler
+	documentService *services.DocumentService
+}
+
+// NewRenditionHandler creates a new rendition handler
+func NewRenditionHandler(documentService *services.DocumentService) *RenditionHandler {
+	return &RenditionHandler{
+		BaseHandler:     NewBaseHandler(),
+		documentService: documentService,
+	}
+}
+
+// RegisterRoutes sets up the rendition routes
+func (h *RenditionHandler) RegisterRoutes(router *gin.RouterGroup) {
+	docs := router.Group("/documents")
+	// Note: Auth middleware should be applied at server level
+	{
+		docs.POST("/:id/renditions/:kind", h.RequestRendition)
+	}
+
+	rules := router.Group("/preview-rules")
+	{
+		rules.GET("", h.GetPreviewRules)
+		rules.PUT("", h.UpdatePreviewRules)
+	}
+}
+
+// Request/Response DTOs
+
+// UpdatePreviewRulesRequest replaces the tenant's preview rules
+type UpdatePreviewRulesRequest struct {
+	Rules []services.PreviewRule `json:"rules"`
+}
+
+// Handler Methods
+
+// RequestRendition generates a document's thumbnail or preview on demand
+// @Summary Request a rendition
+// @Description Queue a document's thumbnail or preview ahead of other processing. Returns 200 when it already exists and 202 while it is being generated.
+// @Tags documents
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param kind path string true "Rendition" Enums(thumbnail, preview)
+// @Success 200 {object} services.RenditionStatus
+// @Success 202 {object} services.RenditionStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Turned off by the tenant's preview rules"
+// @Router /documents/{id}/renditions/{kind} [post]
+func (h *RenditionHandler) RequestRendition(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	status, err := h.documentService.RequestRendition(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID, c.Param("kind"))
+	if err != nil {
+		h.handleRenditionError(c, err)
+		return
+	}
+
+	if status.Status == services.RenditionStatusReady {
+		h.RespondSuccess(c, status)
+		return
+	}
+	c.JSON(http.StatusAccepted, status)
+}
+
+// GetPreviewRules returns the tenant's preview rules
+// @Summary Get preview rules
+// @Description Get when thumbnails and previews are generated, by content type and file size
+// @Tags documents
+// @Produce json
+// @Success 200 {object} services.PreviewRules
+// @Failure 403 {object} ErrorResponse
+// @Router /preview-rules [get]
+func (h *RenditionHandler) GetPreviewRules(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.documentService.GetPreviewRules(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleRenditionError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, rules)
+}
+
+// UpdatePreviewRules replaces the tenant's preview rules
+// @Summary Update preview rules
+// @Description Replace the tenant's preview rules. The first rule matching a document's content type and size applies; documents no rule matches get both renditions on upload.
+// @Tags documents
+// @Accept json
+// @Produce json
+// @Param request body UpdatePreviewRulesRequest true "Preview rules"
+// @Success 200 {object} services.PreviewRules
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /preview-rules [put]
+func (h *RenditionHandler) UpdatePreviewRules(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req UpdatePreviewRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	rules, err := h.documentService.UpdatePreviewRules(c.Request.Context(), userCtx.TenantID, userCtx.UserID, req.Rules)
+	if err != nil {
+		h.handleRenditionError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, rules)
+}
+
+// Helper methods
+
+func (h *RenditionHandler) handleRenditionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRenditionKind), errors.Is(err, services.ErrInvalidPreviewRules):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrDocumentNotFound), errors.Is(err, services.ErrUnauthorizedAccess):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	case errors.Is(err, services.ErrRenditionDisabled):
+		h.RespondError(c, http.StatusConflict, "rendition_disabled", err.Error())
+	default:
+		h.RespondInternalError(c, "Failed to process rendition request", err.Error())
+	}
+}
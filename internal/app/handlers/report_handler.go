@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultActivityReportDays is the period an activity report covers when no
+// from is given
+const defaultActivityReportDays = 30
+
+// ReportHandler handles reports for managers
+type ReportHandler struct {
+	*BaseHandler
+	analyticsService *services.AnalyticsService
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(analyticsService *services.AnalyticsService) *ReportHandler {
+	return &ReportHandler{
+		BaseHandler:      NewBaseHandler(),
+		analyticsService: analyticsService,
+	}
+}
+
+// RegisterRoutes sets up the report routes
+func (h *ReportHandler) RegisterRoutes(router *gin.RouterGroup) {
+	reports := router.Group("/reports")
+	// Note: Auth middleware should be applied at server level
+	{
+		reports.GET("/user-activity", h.GetUserActivityReport)
+	}
+}
+
+// Handler Methods
+
+// GetUserActivityReport reports what each user did over a period
+// @Summary User activity report
+// @Description Documents uploaded, approvals completed, average approval turnaround and comments per user, for performance and capacity reviews. Admins see every user; anyone else sees their department and everyone reporting to them. from and to are RFC 3339 times or dates (to's whole day is included) and default to the last 30 days; a report covers at most 366 days.
+// @Tags reports
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "Start of the period"
+// @Param to query string false "End of the period"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} services.UserActivityReport
+// @Failure 400 {object} ErrorResponse
+// @Router /reports/user-activity [get]
+func (h *ReportHandler) GetUserActivityReport(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		h.RespondBadRequest(c, "format must be json or csv", "")
+		return
+	}
+
+	to := time.Now()
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := parseReportTime(raw, true)
+		if err != nil {
+			h.RespondBadRequest(c, "to must be an RFC 3339 time or a date", "")
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -defaultActivityReportDays)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := parseReportTime(raw, false)
+		if err != nil {
+			h.RespondBadRequest(c, "from must be an RFC 3339 time or a date", "")
+			return
+		}
+		from = parsed
+	}
+
+	report, err := h.analyticsService.GetUserActivityReport(c.Request.Context(), userCtx.TenantID, userCtx.UserID, from, to)
+	if err != nil {
+		h.handleReportError(c, err)
+		return
+	}
+
+	if format == "json" {
+		h.RespondSuccess(c, report)
+		return
+	}
+
+	filename := fmt.Sprintf("user-activity-%s-%s.csv", from.UTC().Format("20060102"), to.UTC().Format("20060102"))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+	if err := report.WriteCSV(c.Writer); err != nil {
+		// Headers are already sent; the download ends short
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// Helper methods
+
+// parseReportTime reads an RFC 3339 time or a date; a date ending a period
+// includes its whole day
+func parseReportTime(raw string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+func (h *ReportHandler) handleReportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidDateRange),
+		errors.Is(err, services.ErrActivityReportRangeTooLong):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrUserNotFound):
+		h.RespondNotFound(c, "User not found")
+	default:
+		h.RespondInternalError(c, "Failed to build report", err.Error())
+	}
+}
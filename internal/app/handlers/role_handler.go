@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RoleHandler handles tenant-defined custom roles
+type RoleHandler struct {
+	*BaseHandler
+	roleService *services.RoleService
+	userService *services.UserService
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(roleService *services.RoleService, userService *services.UserService) *RoleHandler {
+	return &RoleHandler{
+		BaseHandler: NewBaseHandler(),
+		roleService: roleService,
+		userService: userService,
+	}
+}
+
+// RegisterRoutes sets up the role management routes
+func (h *RoleHandler) RegisterRoutes(router *gin.RouterGroup) {
+	roles := router.Group("/roles")
+	// Note: Auth middleware should be applied at server level
+	{
+		roles.GET("/permissions", h.ListPermissions)
+		roles.GET("", h.ListRoles)
+		roles.POST("", h.CreateRole)
+		roles.GET("/:id", h.GetRole)
+		roles.PUT("/:id", h.UpdateRole)
+		roles.DELETE("/:id", h.DeleteRole)
+
+		// Assignment
+		roles.PUT("/:id/users/:userId", h.AssignRole)
+		roles.DELETE("/:id/users/:userId", h.UnassignRole)
+	}
+}
+
+// Request/Response DTOs
+
+// RoleRequest contains custom role data
+type RoleRequest struct {
+	Name        string   `json:"name" binding:"required,max=50"`
+	Description string   `json:"description,omitempty" binding:"max=500"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleResponse represents a custom role in API responses
+type RoleResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	CreatedAt   string    `json:"created_at"`
+	UpdatedAt   string    `json:"updated_at"`
+}
+
+// Handler Methods
+
+// ListPermissions returns the permission catalog
+// @Summary List permissions
+// @Description List every permission that can be granted to a custom role
+// @Tags roles
+// @Produce json
+// @Success 200 {array} services.PermissionDefinition
+// @Failure 403 {object} ErrorResponse
+// @Router /roles/permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	h.RespondSuccess(c, h.roleService.ListPermissions())
+}
+
+// ListRoles lists the tenant's custom roles
+// @Summary List custom roles
+// @Description List the tenant's custom roles and their permissions
+// @Tags roles
+// @Produce json
+// @Success 200 {array} RoleResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.roleService.ListRoles(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list roles", err.Error())
+		return
+	}
+
+	response := make([]RoleResponse, 0, len(roles))
+	for i := range roles {
+		response = append(response, convertToRoleResponse(&roles[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// CreateRole creates a custom role
+// @Summary Create custom role
+// @Description Create a tenant role with permissions from the catalog
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param request body RoleRequest true "Role data"
+// @Success 201 {object} RoleResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), services.RoleParams{
+		TenantID:    userCtx.TenantID,
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+		ActorID:     userCtx.UserID,
+	})
+	if err != nil {
+		h.respondRoleError(c, err, "Failed to create role")
+		return
+	}
+
+	h.RespondCreated(c, convertToRoleResponse(role))
+}
+
+// GetRole returns a custom role
+// @Summary Get custom role
+// @Description Get a custom role and its permissions
+// @Tags roles
+// @Produce json
+// @Param id path string true "Role ID"
+// @Success 200 {object} RoleResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /roles/{id} [get]
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	roleID, ok := h.ValidateUUID(c, "role ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	role, err := h.roleService.GetRole(c.Request.Context(), roleID, userCtx.TenantID)
+	if err != nil {
+		h.respondRoleError(c, err, "Failed to get role")
+		return
+	}
+
+	h.RespondSuccess(c, convertToRoleResponse(role))
+}
+
+// UpdateRole updates a custom role
+// @Summary Update custom role
+// @Description Rename a custom role and replace its permissions
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param request body RoleRequest true "Role data"
+// @Success 200 {object} RoleResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /roles/{id} [put]
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	roleID, ok := h.ValidateUUID(c, "role ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Request.Context(), roleID, services.RoleParams{
+		TenantID:    userCtx.TenantID,
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+		ActorID:     userCtx.UserID,
+	})
+	if err != nil {
+		h.respondRoleError(c, err, "Failed to update role")
+		return
+	}
+
+	h.RespondSuccess(c, convertToRoleResponse(role))
+}
+
+// DeleteRole deletes a custom role
+// @Summary Delete custom role
+// @Description Delete a custom role that is not assigned to any user
+// @Tags roles
+// @Param id path string true "Role ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	roleID, ok := h.ValidateUUID(c, "role ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.DeleteRole(c.Request.Context(), roleID, userCtx.TenantID, userCtx.UserID); err != nil {
+		h.respondRoleError(c, err, "Failed to delete role")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AssignRole assigns a custom role to a user
+// @Summary Assign custom role
+// @Description Assign a custom role to a user, overriding their built-in role's permissions
+// @Tags roles
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} UserProfileResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /roles/{id}/users/{userId} [put]
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	roleID, ok := h.ValidateUUID(c, "role ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	userID, ok := h.ValidateUUID(c, "user ID", c.Param("userId"))
+	if !ok {
+		return
+	}
+
+	user, err := h.roleService.AssignRole(c.Request.Context(), userID, roleID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		h.respondRoleError(c, err, "Failed to assign role")
+		return
+	}
+
+	h.RespondSuccess(c, convertToUserProfileResponse(user))
+}
+
+// UnassignRole removes a custom role from a user
+// @Summary Unassign custom role
+// @Description Remove a user's custom role so their built-in role applies again
+// @Tags roles
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} UserProfileResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /roles/{id}/users/{userId} [delete]
+func (h *RoleHandler) UnassignRole(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	roleID, ok := h.ValidateUUID(c, "role ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	userID, ok := h.ValidateUUID(c, "user ID", c.Param("userId"))
+	if !ok {
+		return
+	}
+
+	user, err := h.roleService.UnassignRole(c.Request.Context(), userID, roleID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		h.respondRoleError(c, err, "Failed to unassign role")
+		return
+	}
+
+	h.RespondSuccess(c, convertToUserProfileResponse(user))
+}
+
+// Helper Methods
+
+func (h *RoleHandler) respondRoleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound):
+		h.RespondNotFound(c, "Role not found")
+	case errors.Is(err, services.ErrUserNotFound):
+		h.RespondNotFound(c, "User not found")
+	case errors.Is(err, services.ErrRoleExists), errors.Is(err, services.ErrRoleInUse):
+		h.RespondError(c, http.StatusConflict, "role_conflict", err.Error())
+	case errors.Is(err, services.ErrInvalidRoleName), errors.Is(err, services.ErrUnknownPermission):
+		h.RespondBadRequest(c, err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
+	}
+}
+
+// Conversion functions
+
+func convertToRoleResponse(role *models.Role) RoleResponse {
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Permission)
+	}
+
+	return RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		CreatedBy:   role.CreatedBy,
+		CreatedAt:   role.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:   role.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ScheduledJobHandler handles the tenant's recurring jobs
+type ScheduledJobHandler struct {
+	*BaseHandler
+	schedulerService *services.SchedulerService
+}
+
+// NewScheduledJobHandler creates a new scheduled job handler
+func NewScheduledJobHandler(schedulerService *services.SchedulerService) *ScheduledJobHandler {
+	return &ScheduledJobHandler{
+		BaseHandler:      NewBaseHandler(),
+		schedulerService: schedulerService,
+	}
+}
+
+// RegisterRoutes sets up the scheduled job routes
+func (h *ScheduledJobHandler) RegisterRoutes(router *gin.RouterGroup) {
+	jobs := router.Group("/scheduled-jobs")
+	// Note: Auth middleware should be applied at server level
+	{
+		jobs.GET("", h.ListJobs)
+		jobs.POST("", h.CreateJob)
+		jobs.GET("/types", h.ListJobTypes)
+		jobs.GET("/:id", h.GetJob)
+		jobs.PUT("/:id", h.UpdateJob)
+		jobs.DELETE("/:id", h.DeleteJob)
+		jobs.GET("/:id/runs", h.ListRuns)
+	}
+}
+
+// Request/Response DTOs
+
+// CreateScheduledJobRequest contains a recurring job
+type CreateScheduledJobRequest struct {
+	JobType        string       `json:"job_type" binding:"required"`
+	CronExpression string       `json:"cron_expression" binding:"required,max=100"`
+	Timezone       string       `json:"timezone" binding:"max=64"`
+	Parameters     models.JSONB `json:"parameters"`
+	IsEnabled      *bool        `json:"is_enabled"`
+}
+
+// UpdateScheduledJobRequest contains scheduled job changes; omitted fields are unchanged
+type UpdateScheduledJobRequest struct {
+	CronExpression *string       `json:"cron_expression,omitempty" binding:"omitempty,max=100"`
+	Timezone       *string       `json:"timezone,omitempty" binding:"omitempty,max=64"`
+	Parameters     *models.JSONB `json:"parameters,omitempty"`
+	IsEnabled      *bool         `json:"is_enabled,omitempty"`
+}
+
+// Handler Methods
+
+// ListJobs lists the tenant's scheduled jobs
+// @Summary List scheduled jobs
+// @Description List the tenant's recurring jobs with their next run and last result
+// @Tags scheduled-jobs
+// @Produce json
+// @Success 200 {array} models.ScheduledJob
+// @Router /scheduled-jobs [get]
+func (h *ScheduledJobHandler) ListJobs(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.schedulerService.ListJobs(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list scheduled jobs", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, jobs)
+}
+
+// CreateJob schedules a recurring job
+// @Summary Create scheduled job
+// @Description Schedule a recurring job with a five-field cron expression (or @daily, @hourly, ...) evaluated in an IANA time zone, UTC by default
+// @Tags scheduled-jobs
+// @Accept json
+// @Produce json
+// @Param request body CreateScheduledJobRequest true "Scheduled job"
+// @Success 201 {object} models.ScheduledJob
+// @Failure 400 {object} ErrorResponse
+// @Router /scheduled-jobs [post]
+func (h *ScheduledJobHandler) CreateJob(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateScheduledJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	isEnabled := true
+	if req.IsEnabled != nil {
+		isEnabled = *req.IsEnabled
+	}
+
+	job, err := h.schedulerService.CreateJob(c.Request.Context(), services.CreateScheduledJobParams{
+		TenantID:       userCtx.TenantID,
+		CreatedBy:      userCtx.UserID,
+		JobType:        req.JobType,
+		CronExpression: req.CronExpression,
+		Timezone:       req.Timezone,
+		Parameters:     req.Parameters,
+		IsEnabled:      isEnabled,
+	})
+	if err != nil {
+		h.handleScheduledJobError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, job)
+}
+
+// ListJobTypes lists the job types that can be scheduled
+// @Summary List scheduled job types
+// @Tags scheduled-jobs
+// @Produce json
+// @Success 200 {array} string
+// @Router /scheduled-jobs/types [get]
+func (h *ScheduledJobHandler) ListJobTypes(c *gin.Context) {
+	h.RespondSuccess(c, services.ScheduledJobTypes)
+}
+
+// GetJob returns a scheduled job
+// @Summary Get scheduled job
+// @Tags scheduled-jobs
+// @Produce json
+// @Param id path string true "Scheduled job ID"
+// @Success 200 {object} models.ScheduledJob
+// @Failure 404 {object} ErrorResponse
+// @Router /scheduled-jobs/{id} [get]
+func (h *ScheduledJobHandler) GetJob(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	jobID, ok := h.ValidateUUID(c, "Scheduled job ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	job, err := h.schedulerService.GetJob(c.Request.Context(), userCtx.TenantID, jobID)
+	if err != nil {
+		h.handleScheduledJobError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, job)
+}
+
+// UpdateJob changes a scheduled job
+// @Summary Update scheduled job
+// @Description Change a job's schedule, parameters or enable it. The next run is recomputed from now; runs missed while disabled are not caught up.
+// @Tags scheduled-jobs
+// @Accept json
+// @Produce json
+// @Param id path string true "Scheduled job ID"
+// @Param request body UpdateScheduledJobRequest true "Scheduled job changes"
+// @Success 200 {object} models.ScheduledJob
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /scheduled-jobs/{id} [put]
+func (h *ScheduledJobHandler) UpdateJob(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	jobID, ok := h.ValidateUUID(c, "Scheduled job ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req UpdateScheduledJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	job, err := h.schedulerService.UpdateJob(c.Request.Context(), services.UpdateScheduledJobParams{
+		TenantID:       userCtx.TenantID,
+		JobID:          jobID,
+		UpdatedBy:      userCtx.UserID,
+		CronExpression: req.CronExpression,
+		Timezone:       req.Timezone,
+		Parameters:     req.Parameters,
+		IsEnabled:      req.IsEnabled,
+	})
+	if err != nil {
+		h.handleScheduledJobError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, job)
+}
+
+// DeleteJob deletes a scheduled job and its run history
+// @Summary Delete scheduled job
+// @Tags scheduled-jobs
+// @Param id path string true "Scheduled job ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /scheduled-jobs/{id} [delete]
+func (h *ScheduledJobHandler) DeleteJob(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	jobID, ok := h.ValidateUUID(c, "Scheduled job ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.schedulerService.DeleteJob(c.Request.Context(), userCtx.TenantID, jobID, userCtx.UserID); err != nil {
+		h.handleScheduledJobError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListRuns lists a scheduled job's runs
+// @Summary List scheduled job runs
+// @Description List a job's runs, newest first, with their status and error
+// @Tags scheduled-jobs
+// @Produce json
+// @Param id path string true "Scheduled job ID"
+// @Param page query int false "Page number"
+// @Param per_page query int false "Page size"
+// @Success 200 {object} PaginatedResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /scheduled-jobs/{id}/runs [get]
+func (h *ScheduledJobHandler) ListRuns(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	jobID, ok := h.ValidateUUID(c, "Scheduled job ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	runs, total, err := h.schedulerService.ListRuns(c.Request.Context(), userCtx.TenantID, jobID, repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.handleScheduledJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       runs,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// Helper Methods
+
+func (h *ScheduledJobHandler) handleScheduledJobError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrScheduledJobNotFound):
+		h.RespondNotFound(c, "Scheduled job not found")
+	case errors.Is(err, services.ErrUnknownJobType),
+		errors.Is(err, services.ErrInvalidCronExpression):
+		h.RespondBadRequest(c, err.Error(), "")
+	default:
+		h.RespondInternalError(c, "Failed to process scheduled job", err.Error())
+	}
+}
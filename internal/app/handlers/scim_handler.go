@@ -0,0 +1,1018 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SCIM schema URNs
+const (
+	scimUserSchema       = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimEnterpriseSchema = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	scimGroupSchema      = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimListSchema       = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimPatchSchema      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	scimErrorSchema      = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimConfigSchema     = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimContentType      = "application/scim+json"
+	scimDefaultPageSize  = 100
+)
+
+var (
+	scimFilterPattern       = regexp.MustCompile(`(?i)^\s*(\w+)\s+eq\s+"([^"]*)"\s*$`)
+	scimMemberFilterPattern = regexp.MustCompile(`(?i)^members\[value eq "([^"]+)"\]$`)
+)
+
+// SCIMHandler implements SCIM 2.0 Users and Groups for identity provider provisioning
+type SCIMHandler struct {
+	*BaseHandler
+	scimService *services.SCIMService
+	userService *services.UserService
+}
+
+// NewSCIMHandler creates a new SCIM handler
+func NewSCIMHandler(scimService *services.SCIMService, userService *services.UserService) *SCIMHandler {
+	return &SCIMHandler{
+		BaseHandler: NewBaseHandler(),
+		scimService: scimService,
+		userService: userService,
+	}
+}
+
+// RegisterRoutes sets up the SCIM routes on the /scim/v2 group. Identity
+// providers authenticate with an API key holding the scim.provision scope.
+func (h *SCIMHandler) RegisterRoutes(router *gin.RouterGroup) {
+	{
+		router.GET("/ServiceProviderConfig", h.GetServiceProviderConfig)
+
+		users := router.Group("/Users")
+		users.GET("", h.ListUsers)
+		users.POST("", h.CreateUser)
+		users.GET("/:id", h.GetUser)
+		users.PUT("/:id", h.ReplaceUser)
+		users.PATCH("/:id", h.PatchUser)
+		users.DELETE("/:id", h.DeleteUser)
+
+		groups := router.Group("/Groups")
+		groups.GET("", h.ListGroups)
+		groups.POST("", h.CreateGroup)
+		groups.GET("/:id", h.GetGroup)
+		groups.PUT("/:id", h.ReplaceGroup)
+		groups.PATCH("/:id", h.PatchGroup)
+		groups.DELETE("/:id", h.DeleteGroup)
+	}
+}
+
+// Request/Response DTOs
+
+// SCIMName is the SCIM user name complex attribute
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMMultiValue is a SCIM multi-valued attribute entry (emails, roles, groups, members)
+type SCIMMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// SCIMEnterpriseUser is the enterprise user extension
+type SCIMEnterpriseUser struct {
+	Department string `json:"department,omitempty"`
+}
+
+// SCIMMeta is the SCIM resource metadata
+type SCIMMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+// SCIMUserResource is a user in SCIM format
+type SCIMUserResource struct {
+	Schemas     []string            `json:"schemas"`
+	ID          string              `json:"id,omitempty"`
+	ExternalID  string              `json:"externalId,omitempty"`
+	UserName    string              `json:"userName"`
+	Name        *SCIMName           `json:"name,omitempty"`
+	DisplayName string              `json:"displayName,omitempty"`
+	Title       *string             `json:"title,omitempty"`
+	Active      *bool               `json:"active,omitempty"`
+	Emails      []SCIMMultiValue    `json:"emails,omitempty"`
+	Roles       []SCIMMultiValue    `json:"roles,omitempty"`
+	Groups      []SCIMMultiValue    `json:"groups,omitempty"`
+	Enterprise  *SCIMEnterpriseUser `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Meta        *SCIMMeta           `json:"meta,omitempty"`
+}
+
+// SCIMGroupResource is a group in SCIM format
+type SCIMGroupResource struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id,omitempty"`
+	DisplayName string           `json:"displayName"`
+	Members     []SCIMMultiValue `json:"members,omitempty"`
+	Meta        *SCIMMeta        `json:"meta,omitempty"`
+}
+
+// SCIMListResponse is a SCIM list response
+type SCIMListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int64       `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// SCIMPatchOperation is one operation in a SCIM PATCH request
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// SCIMPatchRequest is a SCIM PATCH request
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations" binding:"required,min=1"`
+}
+
+// SCIMErrorResponse is a SCIM error
+type SCIMErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// Handler Methods
+
+// GetServiceProviderConfig describes the supported SCIM features
+// @Summary SCIM service provider configuration
+// @Tags scim
+// @Produce json
+// @Router /scim/v2/ServiceProviderConfig [get]
+func (h *SCIMHandler) GetServiceProviderConfig(c *gin.Context) {
+	h.respondSCIM(c, http.StatusOK, gin.H{
+		"schemas":        []string{scimConfigSchema},
+		"patch":          gin.H{"supported": true},
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": scimDefaultPageSize},
+		"changePassword": gin.H{"supported": false},
+		"sort":           gin.H{"supported": false},
+		"etag":           gin.H{"supported": false},
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "API key",
+			"description": "Archivus API key with the scim.provision scope, sent as a Bearer token",
+		}},
+	})
+}
+
+// ListUsers lists users
+// @Summary List SCIM users
+// @Description List users; supports the filters userName eq "..." and externalId eq "..."
+// @Tags scim
+// @Produce json
+// @Param filter query string false "SCIM filter"
+// @Param startIndex query int false "1-based start index"
+// @Param count query int false "Page size"
+// @Success 200 {object} SCIMListResponse
+// @Router /scim/v2/Users [get]
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var filter services.SCIMUserFilter
+	if raw := c.Query("filter"); raw != "" {
+		attribute, value, ok := parseSCIMFilter(raw)
+		if !ok {
+			h.respondSCIMError(c, http.StatusBadRequest, "invalidFilter", "Only 'userName eq' and 'externalId eq' filters are supported")
+			return
+		}
+		switch strings.ToLower(attribute) {
+		case "username":
+			filter.UserName = value
+		case "externalid":
+			filter.ExternalID = value
+		default:
+			h.respondSCIMError(c, http.StatusBadRequest, "invalidFilter", "Unsupported filter attribute: "+attribute)
+			return
+		}
+	}
+
+	startIndex, count := parseSCIMPagination(c)
+	users, total, err := h.scimService.ListUsers(c.Request.Context(), userCtx.TenantID, filter, repositories.ListParams{
+		Page:     (startIndex-1)/count + 1,
+		PageSize: count,
+	})
+	if err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	resources := make([]SCIMUserResource, 0, len(users))
+	for i := range users {
+		resources = append(resources, convertToSCIMUser(&users[i]))
+	}
+
+	h.respondSCIM(c, http.StatusOK, SCIMListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetUser returns a user
+// @Summary Get SCIM user
+// @Tags scim
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} SCIMUserResource
+// @Failure 404 {object} SCIMErrorResponse
+// @Router /scim/v2/Users/{id} [get]
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.parseSCIMID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.scimService.GetUser(c.Request.Context(), userCtx.TenantID, userID)
+	if err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	h.respondSCIM(c, http.StatusOK, convertToSCIMUser(user))
+}
+
+// CreateUser provisions a user
+// @Summary Create SCIM user
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Param request body SCIMUserResource true "User"
+// @Success 201 {object} SCIMUserResource
+// @Failure 409 {object} SCIMErrorResponse
+// @Router /scim/v2/Users [post]
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req SCIMUserResource
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserName == "" {
+		h.respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", "A user with a userName is required")
+		return
+	}
+
+	attrs, err := scimUserAttributes(&req)
+	if err != nil {
+		h.respondSCIMError(c, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	user, err := h.scimService.CreateUser(c.Request.Context(), userCtx.TenantID, userCtx.UserID, attrs)
+	if err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	h.respondSCIM(c, http.StatusCreated, convertToSCIMUser(user))
+}
+
+// ReplaceUser replaces a user's attributes
+// @Summary Replace SCIM user
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body SCIMUserResource true "User"
+// @Success 200 {object} SCIMUserResource
+// @Failure 404 {object} SCIMErrorResponse
+// @Router /scim/v2/Users/{id} [put]
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.parseSCIMID(c)
+	if !ok {
+		return
+	}
+
+	var req SCIMUserResource
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	attrs, err := scimUserAttributes(&req)
+	if err != nil {
+		h.respondSCIMError(c, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	// PUT replaces the resource: omitted attributes are cleared
+	empty := ""
+	if attrs.ExternalID == nil {
+		attrs.ExternalID = &empty
+	}
+	if attrs.Department == nil {
+		attrs.Department = &empty
+	}
+	if attrs.JobTitle == nil {
+		attrs.JobTitle = &empty
+	}
+
+	user, err := h.scimService.UpdateUser(c.Request.Context(), userCtx.TenantID, userID, userCtx.UserID, attrs)
+	if err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	h.respondSCIM(c, http.StatusOK, convertToSCIMUser(user))
+}
+
+// PatchUser applies SCIM patch operations to a user
+// @Summary Patch SCIM user
+// @Description Supports add/replace/remove on active, externalId, name, title, roles and the enterprise department
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body SCIMPatchRequest true "Patch operations"
+// @Success 200 {object} SCIMUserResource
+// @Failure 404 {object} SCIMErrorResponse
+// @Router /scim/v2/Users/{id} [patch]
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.parseSCIMID(c)
+	if !ok {
+		return
+	}
+
+	var req SCIMPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	var attrs services.SCIMUserAttributes
+	for _, op := range req.Operations {
+		if err := applySCIMUserPatch(&attrs, op); err != nil {
+			h.respondSCIMError(c, http.StatusBadRequest, "invalidPath", err.Error())
+			return
+		}
+	}
+
+	user, err := h.scimService.UpdateUser(c.Request.Context(), userCtx.TenantID, userID, userCtx.UserID, attrs)
+	if err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	h.respondSCIM(c, http.StatusOK, convertToSCIMUser(user))
+}
+
+// DeleteUser deprovisions a user
+// @Summary Delete SCIM user
+// @Description Deactivates the user; documents and audit history are kept
+// @Tags scim
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 404 {object} SCIMErrorResponse
+// @Router /scim/v2/Users/{id} [delete]
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.parseSCIMID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.scimService.DeprovisionUser(c.Request.Context(), userCtx.TenantID, userID, userCtx.UserID); err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListGroups lists groups (built-in and custom roles)
+// @Summary List SCIM groups
+// @Description List roles as groups; supports the filter displayName eq "..." and excludedAttributes=members
+// @Tags scim
+// @Produce json
+// @Success 200 {object} SCIMListResponse
+// @Router /scim/v2/Groups [get]
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	displayName := ""
+	if raw := c.Query("filter"); raw != "" {
+		attribute, value, ok := parseSCIMFilter(raw)
+		if !ok || !strings.EqualFold(attribute, "displayName") {
+			h.respondSCIMError(c, http.StatusBadRequest, "invalidFilter", "Only 'displayName eq' filters are supported")
+			return
+		}
+		displayName = value
+	}
+
+	withMembers := !strings.Contains(c.Query("excludedAttributes"), "members")
+	groups, err := h.scimService.ListGroups(c.Request.Context(), userCtx.TenantID, withMembers)
+	if err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	resources := make([]SCIMGroupResource, 0, len(groups))
+	for i := range groups {
+		if displayName != "" && !strings.EqualFold(groups[i].DisplayName, displayName) {
+			continue
+		}
+		resources = append(resources, convertToSCIMGroup(&groups[i]))
+	}
+
+	// Groups are few; page in memory
+	startIndex, count := parseSCIMPagination(c)
+	total := len(resources)
+	from := startIndex - 1
+	if from > total {
+		from = total
+	}
+	to := from + count
+	if to > total {
+		to = total
+	}
+
+	h.respondSCIM(c, http.StatusOK, SCIMListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: int64(total),
+		StartIndex:   startIndex,
+		ItemsPerPage: to - from,
+		Resources:    resources[from:to],
+	})
+}
+
+// GetGroup returns a group with its members
+// @Summary Get SCIM group
+// @Tags scim
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} SCIMGroupResource
+// @Failure 404 {object} SCIMErrorResponse
+// @Router /scim/v2/Groups/{id} [get]
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	group, err := h.scimService.GetGroup(c.Request.Context(), userCtx.TenantID, c.Param("id"))
+	if err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	h.respondSCIM(c, http.StatusOK, convertToSCIMGroup(group))
+}
+
+// CreateGroup creates a custom role as a group
+// @Summary Create SCIM group
+// @Description Creates a custom role with no permissions; grant permissions in the application
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Param request body SCIMGroupResource true "Group"
+// @Success 201 {object} SCIMGroupResource
+// @Failure 409 {object} SCIMErrorResponse
+// @Router /scim/v2/Groups [post]
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req SCIMGroupResource
+	if err := c.ShouldBindJSON(&req); err != nil || req.DisplayName == "" {
+		h.respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", "A group with a displayName is required")
+		return
+	}
+
+	ctx := c.Request.Context()
+	group, err := h.scimService.CreateGroup(ctx, userCtx.TenantID, userCtx.UserID, req.DisplayName)
+	if err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	if len(req.Members) > 0 {
+		memberIDs, err := scimMemberIDs(req.Members)
+		if err != nil {
+			h.respondSCIMError(c, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+		if err := h.scimService.AddGroupMembers(ctx, userCtx.TenantID, userCtx.UserID, group.ID, memberIDs); err != nil {
+			h.respondSCIMServiceError(c, err)
+			return
+		}
+		if group, err = h.scimService.GetGroup(ctx, userCtx.TenantID, group.ID); err != nil {
+			h.respondSCIMServiceError(c, err)
+			return
+		}
+	}
+
+	h.respondSCIM(c, http.StatusCreated, convertToSCIMGroup(group))
+}
+
+// ReplaceGroup replaces a group's membership
+// @Summary Replace SCIM group
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body SCIMGroupResource true "Group"
+// @Success 200 {object} SCIMGroupResource
+// @Failure 404 {object} SCIMErrorResponse
+// @Router /scim/v2/Groups/{id} [put]
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req SCIMGroupResource
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	memberIDs, err := scimMemberIDs(req.Members)
+	if err != nil {
+		h.respondSCIMError(c, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.scimService.ReplaceGroupMembers(ctx, userCtx.TenantID, userCtx.UserID, c.Param("id"), memberIDs); err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	group, err := h.scimService.GetGroup(ctx, userCtx.TenantID, c.Param("id"))
+	if err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	h.respondSCIM(c, http.StatusOK, convertToSCIMGroup(group))
+}
+
+// PatchGroup adds, removes or replaces group members
+// @Summary Patch SCIM group
+// @Tags scim
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param request body SCIMPatchRequest true "Patch operations"
+// @Success 204
+// @Failure 404 {object} SCIMErrorResponse
+// @Router /scim/v2/Groups/{id} [patch]
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req SCIMPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	groupID := c.Param("id")
+	group, err := h.scimService.GetGroup(ctx, userCtx.TenantID, groupID)
+	if err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	for _, op := range req.Operations {
+		path := strings.TrimSpace(op.Path)
+
+		// Renames aren't supported; accept no-op displayName updates some providers send
+		if strings.EqualFold(path, "displayName") {
+			var name string
+			if json.Unmarshal(op.Value, &name) != nil || name != group.DisplayName {
+				h.respondSCIMError(c, http.StatusBadRequest, "mutability", "Groups cannot be renamed through SCIM")
+				return
+			}
+			continue
+		}
+
+		var memberIDs []uuid.UUID
+		if match := scimMemberFilterPattern.FindStringSubmatch(path); match != nil {
+			id, err := uuid.Parse(match[1])
+			if err != nil {
+				h.respondSCIMError(c, http.StatusBadRequest, "invalidValue", "Invalid member ID: "+match[1])
+				return
+			}
+			memberIDs = []uuid.UUID{id}
+		} else if path == "" || strings.EqualFold(path, "members") {
+			var members []SCIMMultiValue
+			if len(op.Value) > 0 {
+				if err := json.Unmarshal(op.Value, &members); err != nil {
+					// Value may be an object wrapping the members
+					var wrapped struct {
+						Members []SCIMMultiValue `json:"members"`
+					}
+					if err := json.Unmarshal(op.Value, &wrapped); err != nil {
+						h.respondSCIMError(c, http.StatusBadRequest, "invalidValue", "members must be a list")
+						return
+					}
+					members = wrapped.Members
+				}
+			}
+			if memberIDs, err = scimMemberIDs(members); err != nil {
+				h.respondSCIMError(c, http.StatusBadRequest, "invalidValue", err.Error())
+				return
+			}
+		} else {
+			h.respondSCIMError(c, http.StatusBadRequest, "invalidPath", "Unsupported path: "+path)
+			return
+		}
+
+		switch strings.ToLower(op.Op) {
+		case "add":
+			err = h.scimService.AddGroupMembers(ctx, userCtx.TenantID, userCtx.UserID, groupID, memberIDs)
+		case "remove":
+			if len(memberIDs) == 0 {
+				// Removing "members" without a value clears the group
+				err = h.scimService.ReplaceGroupMembers(ctx, userCtx.TenantID, userCtx.UserID, groupID, nil)
+			} else {
+				err = h.scimService.RemoveGroupMembers(ctx, userCtx.TenantID, userCtx.UserID, groupID, memberIDs)
+			}
+		case "replace":
+			err = h.scimService.ReplaceGroupMembers(ctx, userCtx.TenantID, userCtx.UserID, groupID, memberIDs)
+		default:
+			h.respondSCIMError(c, http.StatusBadRequest, "invalidSyntax", "Unsupported operation: "+op.Op)
+			return
+		}
+		if err != nil {
+			h.respondSCIMServiceError(c, err)
+			return
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteGroup deletes a custom role group
+// @Summary Delete SCIM group
+// @Tags scim
+// @Param id path string true "Group ID"
+// @Success 204
+// @Failure 400 {object} SCIMErrorResponse
+// @Failure 404 {object} SCIMErrorResponse
+// @Router /scim/v2/Groups/{id} [delete]
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.scimService.DeleteGroup(c.Request.Context(), userCtx.TenantID, userCtx.UserID, c.Param("id")); err != nil {
+		h.respondSCIMServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Helper Methods
+
+func (h *SCIMHandler) respondSCIM(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, body)
+}
+
+func (h *SCIMHandler) respondSCIMError(c *gin.Context, status int, scimType, detail string) {
+	h.respondSCIM(c, status, SCIMErrorResponse{
+		Schemas:  []string{scimErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+func (h *SCIMHandler) respondSCIMServiceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSCIMUserNotFound), errors.Is(err, services.ErrUserNotFound):
+		h.respondSCIMError(c, http.StatusNotFound, "", "User not found")
+	case errors.Is(err, services.ErrSCIMGroupNotFound), errors.Is(err, services.ErrRoleNotFound):
+		h.respondSCIMError(c, http.StatusNotFound, "", "Group not found")
+	case errors.Is(err, services.ErrUserExists), errors.Is(err, services.ErrRoleExists):
+		h.respondSCIMError(c, http.StatusConflict, "uniqueness", err.Error())
+	case errors.Is(err, services.ErrSCIMUserNameChanged), errors.Is(err, services.ErrSCIMInvalidGroup):
+		h.respondSCIMError(c, http.StatusBadRequest, "mutability", err.Error())
+	case errors.Is(err, services.ErrInvalidEmail), errors.Is(err, services.ErrInvalidRole),
+		errors.Is(err, services.ErrInvalidRoleName), errors.Is(err, services.ErrRoleInUse):
+		h.respondSCIMError(c, http.StatusBadRequest, "invalidValue", err.Error())
+	default:
+		h.respondSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+}
+
+func (h *SCIMHandler) parseSCIMID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.respondSCIMError(c, http.StatusNotFound, "", "User not found")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func parseSCIMFilter(filter string) (string, string, bool) {
+	match := scimFilterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+func parseSCIMPagination(c *gin.Context) (int, int) {
+	startIndex, err := strconv.Atoi(c.Query("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.Query("count"))
+	if err != nil || count < 1 || count > scimDefaultPageSize {
+		count = scimDefaultPageSize
+	}
+	return startIndex, count
+}
+
+func scimMemberIDs(members []SCIMMultiValue) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		id, err := uuid.Parse(member.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid member ID: %s", member.Value)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// scimUserAttributes maps a SCIM user onto the attributes Archivus stores
+func scimUserAttributes(req *SCIMUserResource) (services.SCIMUserAttributes, error) {
+	attrs := services.SCIMUserAttributes{
+		UserName: req.UserName,
+		Active:   req.Active,
+		JobTitle: req.Title,
+	}
+	if req.ExternalID != "" {
+		attrs.ExternalID = &req.ExternalID
+	}
+	if req.Name != nil {
+		attrs.FirstName = &req.Name.GivenName
+		attrs.LastName = &req.Name.FamilyName
+	}
+	if req.Enterprise != nil {
+		attrs.Department = &req.Enterprise.Department
+	}
+	if len(req.Roles) > 0 {
+		role, err := primarySCIMRole(req.Roles)
+		if err != nil {
+			return attrs, err
+		}
+		attrs.Role = &role
+	}
+	return attrs, nil
+}
+
+func primarySCIMRole(roles []SCIMMultiValue) (models.UserRole, error) {
+	selected := roles[0]
+	for _, role := range roles {
+		if role.Primary {
+			selected = role
+			break
+		}
+	}
+	if selected.Value == "" {
+		return "", errors.New("role value is required")
+	}
+	return models.UserRole(strings.ToLower(selected.Value)), nil
+}
+
+// applySCIMUserPatch folds one PATCH operation into the attribute changes
+func applySCIMUserPatch(attrs *services.SCIMUserAttributes, op SCIMPatchOperation) error {
+	remove := strings.EqualFold(op.Op, "remove")
+	if !remove && !strings.EqualFold(op.Op, "add") && !strings.EqualFold(op.Op, "replace") {
+		return fmt.Errorf("unsupported operation: %s", op.Op)
+	}
+
+	// Without a path the value is a partial user resource
+	if op.Path == "" {
+		if remove {
+			return errors.New("remove requires a path")
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &values); err != nil {
+			return errors.New("value must be an object when no path is given")
+		}
+		for path, value := range values {
+			if err := applySCIMUserPatch(attrs, SCIMPatchOperation{Op: op.Op, Path: path, Value: value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path := strings.ToLower(op.Path)
+	if strings.HasPrefix(path, strings.ToLower(scimEnterpriseSchema)+":") {
+		path = "enterprise." + strings.TrimPrefix(path, strings.ToLower(scimEnterpriseSchema)+":")
+	}
+
+	switch path {
+	case "active":
+		active, err := scimBool(op.Value)
+		if err != nil || remove {
+			return errors.New("active must be a boolean")
+		}
+		attrs.Active = &active
+	case "externalid":
+		attrs.ExternalID = scimPatchString(op.Value, remove)
+	case "name.givenname":
+		attrs.FirstName = scimPatchString(op.Value, remove)
+	case "name.familyname":
+		attrs.LastName = scimPatchString(op.Value, remove)
+	case "name":
+		var name SCIMName
+		if !remove {
+			if err := json.Unmarshal(op.Value, &name); err != nil {
+				return errors.New("name must be an object")
+			}
+		}
+		attrs.FirstName, attrs.LastName = &name.GivenName, &name.FamilyName
+	case "title":
+		attrs.JobTitle = scimPatchString(op.Value, remove)
+	case "enterprise.department":
+		attrs.Department = scimPatchString(op.Value, remove)
+	case "roles":
+		role := models.UserRoleViewer
+		if !remove {
+			var roles []SCIMMultiValue
+			if err := json.Unmarshal(op.Value, &roles); err != nil || len(roles) == 0 {
+				return errors.New("roles must be a non-empty list")
+			}
+			var err error
+			if role, err = primarySCIMRole(roles); err != nil {
+				return err
+			}
+		}
+		attrs.Role = &role
+	case "username":
+		var userName string
+		if remove || json.Unmarshal(op.Value, &userName) != nil {
+			return errors.New("userName must be a string")
+		}
+		attrs.UserName = userName
+	case "displayname", "emails", `emails[type eq "work"].value`:
+		// Derived from name and userName
+	default:
+		return fmt.Errorf("unsupported path: %s", op.Path)
+	}
+
+	return nil
+}
+
+// scimBool accepts JSON booleans and the "True"/"False" strings some providers send
+func scimBool(raw json.RawMessage) (bool, error) {
+	var value bool
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value, nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(strings.ToLower(text))
+}
+
+func scimPatchString(raw json.RawMessage, remove bool) *string {
+	value := ""
+	if !remove {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			value = strings.Trim(string(raw), `"`)
+		}
+	}
+	return &value
+}
+
+// Conversion functions
+
+func convertToSCIMUser(user *models.User) SCIMUserResource {
+	active := user.IsActive
+	title := user.JobTitle
+	resource := SCIMUserResource{
+		Schemas:     []string{scimUserSchema, scimEnterpriseSchema},
+		ID:          user.ID.String(),
+		ExternalID:  user.ExternalID,
+		UserName:    user.Email,
+		DisplayName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Title:       &title,
+		Active:      &active,
+		Name: &SCIMName{
+			Formatted:  strings.TrimSpace(user.FirstName + " " + user.LastName),
+			GivenName:  user.FirstName,
+			FamilyName: user.LastName,
+		},
+		Emails: []SCIMMultiValue{{Value: user.Email, Type: "work", Primary: true}},
+		Roles:  []SCIMMultiValue{{Value: string(user.Role), Primary: true}},
+		Groups: []SCIMMultiValue{{Value: string(user.Role), Display: string(user.Role)}},
+		Meta: &SCIMMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			LastModified: user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			Location:     "/scim/v2/Users/" + user.ID.String(),
+		},
+	}
+	if user.CustomRoleID != nil {
+		group := SCIMMultiValue{Value: user.CustomRoleID.String()}
+		if user.CustomRole != nil {
+			group.Display = user.CustomRole.Name
+		}
+		resource.Groups = append(resource.Groups, group)
+	}
+	if user.Department != "" {
+		resource.Enterprise = &SCIMEnterpriseUser{Department: user.Department}
+	}
+	return resource
+}
+
+func convertToSCIMGroup(group *services.SCIMGroup) SCIMGroupResource {
+	resource := SCIMGroupResource{
+		Schemas:     []string{scimGroupSchema},
+		ID:          group.ID,
+		DisplayName: group.DisplayName,
+		Members:     make([]SCIMMultiValue, 0, len(group.Members)),
+		Meta: &SCIMMeta{
+			ResourceType: "Group",
+			Location:     "/scim/v2/Groups/" + group.ID,
+		},
+	}
+	if group.CreatedAt != nil {
+		resource.Meta.Created = group.CreatedAt.Format("2006-01-02T15:04:05Z")
+	}
+	if group.UpdatedAt != nil {
+		resource.Meta.LastModified = group.UpdatedAt.Format("2006-01-02T15:04:05Z")
+	}
+	for _, member := range group.Members {
+		resource.Members = append(resource.Members, SCIMMultiValue{
+			Value:   member.ID.String(),
+			Display: member.Email,
+			Ref:     "/scim/v2/Users/" + member.ID.String(),
+		})
+	}
+	return resource
+}
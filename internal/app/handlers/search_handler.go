@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SearchHandler handles search endpoints beyond document search
+type SearchHandler struct {
+	*BaseHandler
+	suggestionService *services.SearchSuggestionService
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(suggestionService *services.SearchSuggestionService) *SearchHandler {
+	return &SearchHandler{
+		BaseHandler:       NewBaseHandler(),
+		suggestionService: suggestionService,
+	}
+}
+
+// RegisterRoutes sets up the search routes
+func (h *SearchHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	router.GET("/search/suggest", h.Suggest)
+}
+
+// Handler Methods
+
+// Suggest returns typeahead suggestions for a prefix
+// @Summary Search suggestions
+// @Description Fast prefix suggestions for typeahead, grouped by type: document titles, tags, vendors and folders. A suggestion matches when the prefix starts its text or one of its words. Only documents and folders the caller can read are suggested. Prefixes shorter than two characters get empty groups.
+// @Tags search
+// @Produce json
+// @Param q query string true "Prefix typed so far"
+// @Param types query string false "Comma-separated types: documents, tags, vendors, folders (default all)"
+// @Param limit query int false "Suggestions per type (default 5, max 20)"
+// @Success 200 {object} services.SearchSuggestions
+// @Failure 400 {object} ErrorResponse
+// @Router /search/suggest [get]
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	types, err := services.ParseSuggestionTypes(c.Query("types"))
+	if err != nil {
+		h.RespondBadRequest(c, "Invalid suggestion types", err.Error())
+		return
+	}
+
+	// Zero or invalid limits fall back to the default
+	limit := getIntParam(c, "limit", 0)
+
+	suggestions, err := h.suggestionService.Suggest(c.Request.Context(), userCtx.TenantID, userCtx.UserID, c.Query("q"), types, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			h.RespondNotFound(c, "User not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to get search suggestions", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, suggestions)
+}
@@ -0,0 +1,445 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/app/middleware"
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ShareHandler handles document share links and their access analytics
+type ShareHandler struct {
+	*BaseHandler
+	shareService *services.ShareService
+	userService  *services.UserService
+}
+
+// NewShareHandler creates a new share handler
+func NewShareHandler(shareService *services.ShareService, userService *services.UserService) *ShareHandler {
+	return &ShareHandler{
+		BaseHandler:  NewBaseHandler(),
+		shareService: shareService,
+		userService:  userService,
+	}
+}
+
+// RegisterRoutes sets up the share routes
+func (h *ShareHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	docs := router.Group("/documents")
+	{
+		docs.POST("/:id/shares", h.CreateShare)
+		docs.GET("/:id/shares", h.ListDocumentShares)
+	}
+
+	shares := router.Group("/shares")
+	{
+		shares.DELETE("/:id", h.RevokeShare)
+		shares.GET("/:id/access", h.GetShareAccess)
+	}
+
+	// Public share links - no authentication, every attempt is logged
+	public := router.Group("/public/shares")
+	{
+		public.GET("/:token", h.AccessShare)
+		public.GET("/:token/download", h.DownloadShare)
+	}
+}
+
+// Request/Response DTOs
+
+// CreateShareRequest contains share link creation data
+type CreateShareRequest struct {
+	Password     string     `json:"password,omitempty" binding:"max=72"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	MaxDownloads int        `json:"max_downloads,omitempty" binding:"min=0"`
+	Recipients   []string   `json:"recipients,omitempty" binding:"max=20,dive,email"` // Emailed the link
+	Message      string     `json:"message,omitempty" binding:"max=1000"`
+}
+
+// ShareLinkResponse represents a share link
+type ShareLinkResponse struct {
+	ID                uuid.UUID `json:"id"`
+	DocumentID        uuid.UUID `json:"document_id"`
+	Token             string    `json:"token"`
+	CreatedBy         uuid.UUID `json:"created_by"`
+	PasswordProtected bool      `json:"password_protected"`
+	ExpiresAt         *string   `json:"expires_at,omitempty"`
+	MaxDownloads      int       `json:"max_downloads"`
+	DownloadCount     int       `json:"download_count"`
+	IsActive          bool      `json:"is_active"`
+	CreatedAt         string    `json:"created_at"`
+}
+
+// ShareAccessResponse represents a single access log entry
+type ShareAccessResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Action     string    `json:"action"`
+	Success    bool      `json:"success"`
+	Reason     string    `json:"reason,omitempty"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	AccessedAt string    `json:"accessed_at"`
+}
+
+// ShareAccessReportResponse combines access stats with the paginated access log
+type ShareAccessReportResponse struct {
+	Share      ShareLinkResponse              `json:"share"`
+	Stats      *repositories.ShareAccessStats `json:"stats"`
+	Accesses   []ShareAccessResponse          `json:"accesses"`
+	Total      int64                          `json:"total"`
+	Page       int                            `json:"page"`
+	PageSize   int                            `json:"page_size"`
+	TotalPages int                            `json:"total_pages"`
+}
+
+// PublicShareResponse is returned to anonymous visitors of a share link
+type PublicShareResponse struct {
+	DocumentName string `json:"document_name"`
+	ContentType  string `json:"content_type"`
+	FileSize     int64  `json:"file_size"`
+	ExpiresAt    string `json:"expires_at,omitempty"`
+	DownloadURL  string `json:"download_url,omitempty"`
+}
+
+// Handler Methods
+
+// CreateShare creates a public share link for a document
+// @Summary Create share link
+// @Description Create a public share link with optional password, expiry and download limit
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param request body CreateShareRequest true "Share creation request"
+// @Success 201 {object} ShareLinkResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 423 {object} DocumentGatedResponse "Document's required checks haven't passed"
+// @Router /documents/{id}/shares [post]
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	share, err := h.shareService.CreateShare(c.Request.Context(), services.CreateShareParams{
+		TenantID:     userCtx.TenantID,
+		DocumentID:   documentID,
+		CreatedBy:    userCtx.UserID,
+		Password:     req.Password,
+		ExpiresAt:    req.ExpiresAt,
+		MaxDownloads: req.MaxDownloads,
+		Recipients:   req.Recipients,
+		SenderName:   userCtx.Email,
+		Message:      req.Message,
+	})
+	if err != nil {
+		if h.RespondDocumentGated(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrDocumentNotFound), errors.Is(err, services.ErrUnauthorizedAccess):
+			h.RespondNotFound(c, "Document not found")
+		case errors.Is(err, services.ErrShareInvalidExpiry), errors.Is(err, services.ErrSharePasswordTooLong):
+			h.RespondBadRequest(c, err.Error())
+		default:
+			h.RespondInternalError(c, "Failed to create share", err.Error())
+		}
+		return
+	}
+
+	h.RespondCreated(c, convertToShareLinkResponse(share))
+}
+
+// ListDocumentShares lists a document's active share links
+// @Summary List document shares
+// @Description List active (unexpired and unrevoked) share links for a document
+// @Tags shares
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {array} ShareLinkResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/shares [get]
+func (h *ShareHandler) ListDocumentShares(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	shares, err := h.shareService.ListActiveShares(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrDocumentNotFound) || errors.Is(err, services.ErrUnauthorizedAccess) {
+			h.RespondNotFound(c, "Document not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to list shares", err.Error())
+		return
+	}
+
+	response := make([]ShareLinkResponse, 0, len(shares))
+	for i := range shares {
+		response = append(response, convertToShareLinkResponse(&shares[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// RevokeShare revokes a share link
+// @Summary Revoke share link
+// @Description Revoke a share link; only its creator or users allowed to delete documents may revoke it
+// @Tags shares
+// @Param id path string true "Share ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /shares/{id} [delete]
+func (h *ShareHandler) RevokeShare(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	shareID, ok := h.ValidateUUID(c, "share ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	share, err := h.shareService.GetShare(c.Request.Context(), shareID, userCtx.TenantID)
+	if err != nil {
+		h.RespondNotFound(c, "Share not found")
+		return
+	}
+
+	if share.CreatedBy != userCtx.UserID {
+		hasPermission, err := h.userService.CheckPermission(c.Request.Context(), userCtx.UserID, "documents.delete")
+		if err != nil || !hasPermission {
+			h.RespondError(c, http.StatusForbidden, "permission_denied", "Insufficient permissions to revoke this share")
+			return
+		}
+	}
+
+	if err := h.shareService.RevokeShare(c.Request.Context(), shareID, userCtx.TenantID, userCtx.UserID); err != nil {
+		if errors.Is(err, services.ErrShareNotFound) {
+			h.RespondNotFound(c, "Share not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to revoke share", err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetShareAccess returns access stats and the access log for a share link
+// @Summary Get share access stats
+// @Description Get per-share access statistics and the paginated access log (IP, timestamp, user agent)
+// @Tags shares
+// @Produce json
+// @Param id path string true "Share ID"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page" default(20)
+// @Success 200 {object} ShareAccessReportResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /shares/{id}/access [get]
+func (h *ShareHandler) GetShareAccess(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	shareID, ok := h.ValidateUUID(c, "share ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	stats, err := h.shareService.GetShareStats(ctx, shareID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrShareNotFound) || errors.Is(err, services.ErrUnauthorizedAccess) {
+			h.RespondNotFound(c, "Share not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to get share stats", err.Error())
+		return
+	}
+
+	share, err := h.shareService.GetShare(ctx, shareID, userCtx.TenantID)
+	if err != nil {
+		h.RespondNotFound(c, "Share not found")
+		return
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	accesses, total, err := h.shareService.ListShareAccesses(ctx, shareID, userCtx.TenantID, userCtx.UserID, repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list share accesses", err.Error())
+		return
+	}
+
+	response := ShareAccessReportResponse{
+		Share:      convertToShareLinkResponse(share),
+		Stats:      stats,
+		Accesses:   make([]ShareAccessResponse, 0, len(accesses)),
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}
+	for i := range accesses {
+		response.Accesses = append(response.Accesses, convertToShareAccessResponse(&accesses[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// AccessShare opens a public share link
+// @Summary Open share link
+// @Description Open a public share link. Password-protected links require the X-Share-Password header; wrong passwords delay the next attempt, and after repeated failures an X-Captcha-Token header may be required.
+// @Tags shares
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} PublicShareResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse "Too many attempts; see Retry-After"
+// @Router /public/shares/{token} [get]
+func (h *ShareHandler) AccessShare(c *gin.Context) {
+	h.openShare(c, services.ShareActionView)
+}
+
+// DownloadShare returns a short-lived download URL for a public share link
+// @Summary Download shared document
+// @Description Get a download URL for a shared document; counts against the share's download limit
+// @Tags shares
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} PublicShareResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 423 {object} ErrorResponse "Document's required checks haven't passed"
+// @Failure 429 {object} ErrorResponse "Too many attempts; see Retry-After"
+// @Router /public/shares/{token}/download [get]
+func (h *ShareHandler) DownloadShare(c *gin.Context) {
+	h.openShare(c, services.ShareActionDownload)
+}
+
+func (h *ShareHandler) openShare(c *gin.Context, action string) {
+	result, err := h.shareService.AccessShare(c.Request.Context(), services.AccessShareParams{
+		Token:        c.Param("token"),
+		Password:     c.GetHeader("X-Share-Password"),
+		CaptchaToken: c.GetHeader("X-Captcha-Token"),
+		Action:       action,
+		IPAddress:    c.ClientIP(),
+		Country:      middleware.GetClientCountry(c),
+		UserAgent:    c.GetHeader("User-Agent"),
+	})
+	if err != nil {
+		if h.RespondAbuseBlocked(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrShareNotFound):
+			h.RespondNotFound(c, "Share not found")
+		case errors.Is(err, services.ErrSharePasswordRequired), errors.Is(err, services.ErrSharePasswordInvalid):
+			h.RespondError(c, http.StatusUnauthorized, "share_password", err.Error())
+		case errors.Is(err, services.ErrGeoRestricted):
+			h.RespondError(c, http.StatusForbidden, "geo_restricted", err.Error())
+		case errors.Is(err, services.ErrShareExpired), errors.Is(err, services.ErrShareDownloadLimit),
+			errors.Is(err, services.ErrShareAccessRevoked):
+			h.RespondError(c, http.StatusGone, "share_unavailable", err.Error())
+		case errors.Is(err, services.ErrDocumentGated):
+			// The document's check results are the tenant's business
+			h.RespondError(c, http.StatusLocked, "document_unavailable", "The document can't be downloaded yet")
+		default:
+			h.RespondInternalError(c, "Failed to open share", err.Error())
+		}
+		return
+	}
+
+	response := PublicShareResponse{
+		DocumentName: documentLabelForShare(result.Document),
+		ContentType:  result.Document.ContentType,
+		FileSize:     result.Document.FileSize,
+		DownloadURL:  result.DownloadURL,
+	}
+	if result.Share.ExpiresAt != nil {
+		response.ExpiresAt = result.Share.ExpiresAt.Format("2006-01-02T15:04:05Z")
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// Conversion functions
+
+func convertToShareLinkResponse(share *models.Share) ShareLinkResponse {
+	response := ShareLinkResponse{
+		ID:                share.ID,
+		DocumentID:        share.DocumentID,
+		Token:             share.Token,
+		CreatedBy:         share.CreatedBy,
+		PasswordProtected: share.Password != "",
+		MaxDownloads:      share.MaxDownloads,
+		DownloadCount:     share.DownloadCount,
+		IsActive:          share.IsActive,
+		CreatedAt:         share.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if share.ExpiresAt != nil {
+		expiresAt := share.ExpiresAt.Format("2006-01-02T15:04:05Z")
+		response.ExpiresAt = &expiresAt
+	}
+	return response
+}
+
+func convertToShareAccessResponse(access *models.ShareAccess) ShareAccessResponse {
+	return ShareAccessResponse{
+		ID:         access.ID,
+		Action:     access.Action,
+		Success:    access.Success,
+		Reason:     access.Reason,
+		IPAddress:  access.IPAddress,
+		UserAgent:  access.UserAgent,
+		AccessedAt: access.AccessedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+func documentLabelForShare(document *models.Document) string {
+	if document.Title != "" {
+		return document.Title
+	}
+	return document.OriginalName
+}
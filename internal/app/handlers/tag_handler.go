@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"strings"
+
 	"github.com/archivus/archivus/internal/domain/services"
 	"github.com/archivus/archivus/internal/infrastructure/database/models"
 	"github.com/gin-gonic/gin"
@@ -78,6 +81,7 @@ type TagSuggestionsRequest struct {
 type TagSuggestionsResponse struct {
 	Suggestions []string `json:"suggestions"`
 	Count       int      `json:"count"`
+	Language    string   `json:"language"` // Language the text was analyzed as
 }
 
 // TagListResponse represents paginated tag list
@@ -341,6 +345,7 @@ func (h *TagHandler) GetPopularTags(c *gin.Context) {
 // @Produce json
 // @Param text query string true "Text to analyze for tag suggestions"
 // @Param limit query int false "Maximum number of suggestions" default(10)
+// @Param language query string false "ISO 639-1 language of the text (en, de, fr, es, it, pt, nl); detected when omitted"
 // @Success 200 {object} TagSuggestionsResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
@@ -364,8 +369,12 @@ func (h *TagHandler) GetTagSuggestions(c *gin.Context) {
 	}
 
 	// Get tag suggestions
-	suggestions, err := h.documentService.GetTagSuggestions(c.Request.Context(), userCtx.TenantID, text, limit)
+	suggestions, language, err := h.documentService.GetTagSuggestions(c.Request.Context(), userCtx.TenantID, text, c.Query("language"), limit)
 	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedLanguage) {
+			h.RespondBadRequest(c, "Unsupported language", "Supported languages: "+strings.Join(services.KeywordLanguages(), ", "))
+			return
+		}
 		h.RespondInternalError(c, "Failed to generate tag suggestions", err.Error())
 		return
 	}
@@ -373,6 +382,7 @@ func (h *TagHandler) GetTagSuggestions(c *gin.Context) {
 	response := TagSuggestionsResponse{
 		Suggestions: suggestions,
 		Count:       len(suggestions),
+		Language:    language,
 	}
 
 	h.RespondSuccess(c, response)
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantDeletionHandler handles deleting the caller's tenant
+type TenantDeletionHandler struct {
+	*BaseHandler
+	deletionService *services.TenantDeletionService
+}
+
+// NewTenantDeletionHandler creates a new tenant deletion handler
+func NewTenantDeletionHandler(deletionService *services.TenantDeletionService) *TenantDeletionHandler {
+	return &TenantDeletionHandler{
+		BaseHandler:     NewBaseHandler(),
+		deletionService: deletionService,
+	}
+}
+
+// RegisterRoutes sets up the tenant deletion routes
+func (h *TenantDeletionHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	deletion := router.Group("/tenant/deletion")
+	{
+		deletion.POST("", h.RequestDeletion)
+		deletion.GET("", h.GetDeletion)
+		deletion.DELETE("", h.CancelDeletion)
+		deletion.GET("/export", h.DownloadExport)
+	}
+}
+
+// Request/Response DTOs
+
+// RequestTenantDeletionRequest confirms a tenant deletion
+type RequestTenantDeletionRequest struct {
+	Confirm string `json:"confirm" binding:"required"` // The tenant's subdomain
+	Reason  string `json:"reason"`
+}
+
+// Handler Methods
+
+// RequestDeletion schedules the tenant's deletion
+// @Summary Delete tenant
+// @Description Suspend the tenant and schedule the deletion of all its data after a grace period. Everyone but admins is signed out. An export is prepared for download, and the deletion can be cancelled until the grace period ends. Confirm with the tenant's subdomain. Tenants with active legal holds can't be deleted.
+// @Tags tenant
+// @Accept json
+// @Produce json
+// @Param request body RequestTenantDeletionRequest true "Confirmation"
+// @Success 201 {object} models.TenantDeletion
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /tenant/deletion [post]
+func (h *TenantDeletionHandler) RequestDeletion(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req RequestTenantDeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	deletion, err := h.deletionService.RequestDeletion(c.Request.Context(), services.RequestTenantDeletionParams{
+		TenantID:    userCtx.TenantID,
+		RequestedBy: userCtx.UserID,
+		Confirm:     req.Confirm,
+		Reason:      req.Reason,
+	})
+	if err != nil {
+		h.handleTenantDeletionError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, deletion)
+}
+
+// GetDeletion returns the tenant's deletion status
+// @Summary Get tenant deletion
+// @Description Status of the tenant's latest deletion request: exporting, scheduled (grace period), deleting, completed, failed or cancelled
+// @Tags tenant
+// @Produce json
+// @Success 200 {object} models.TenantDeletion
+// @Failure 404 {object} ErrorResponse
+// @Router /tenant/deletion [get]
+func (h *TenantDeletionHandler) GetDeletion(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	deletion, err := h.deletionService.GetDeletion(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleTenantDeletionError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, deletion)
+}
+
+// CancelDeletion cancels the tenant's deletion during the grace period
+// @Summary Cancel tenant deletion
+// @Description Cancel a scheduled deletion and lift the suspension. Not possible once deletion has started.
+// @Tags tenant
+// @Produce json
+// @Success 200 {object} models.TenantDeletion
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /tenant/deletion [delete]
+func (h *TenantDeletionHandler) CancelDeletion(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	deletion, err := h.deletionService.CancelDeletion(c.Request.Context(), userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		h.handleTenantDeletionError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, deletion)
+}
+
+// DownloadExport streams the export prepared for a tenant awaiting deletion
+// @Summary Download tenant export
+// @Tags tenant
+// @Produce application/zip
+// @Success 200 {file} binary
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /tenant/deletion/export [get]
+func (h *TenantDeletionHandler) DownloadExport(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	deletion, reader, err := h.deletionService.OpenExport(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleTenantDeletionError(c, err)
+		return
+	}
+	defer reader.Close()
+
+	filename := "tenant-export-" + deletion.Subdomain + ".zip"
+	c.DataFromReader(http.StatusOK, deletion.ExportSize, "application/zip", reader, map[string]string{
+		"Content-Disposition": `attachment; filename="` + filename + `"`,
+	})
+}
+
+// Helper Methods
+
+func (h *TenantDeletionHandler) handleTenantDeletionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTenantDeletionNotFound):
+		h.RespondNotFound(c, "No tenant deletion requested")
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	case errors.Is(err, services.ErrTenantDeletionConfirm):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrTenantDeletionPending),
+		errors.Is(err, services.ErrTenantDeletionStarted),
+		errors.Is(err, services.ErrTenantUnderLegalHold):
+		h.RespondConflict(c, err.Error())
+	case errors.Is(err, services.ErrExportNotReady):
+		h.RespondConflict(c, "Tenant export is not ready")
+	default:
+		h.RespondInternalError(c, "Failed to process tenant deletion", err.Error())
+	}
+}
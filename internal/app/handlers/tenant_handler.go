@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+
 	"github.com/archivus/archivus/internal/app/middleware"
 	"github.com/archivus/archivus/internal/domain/repositories"
 	"github.com/archivus/archivus/internal/domain/services"
@@ -14,17 +16,20 @@ type TenantHandler struct {
 	*BaseHandler
 	tenantService *services.TenantService
 	userService   *services.UserService
+	repairService *services.RepairService
 }
 
 // NewTenantHandler creates a new tenant handler
 func NewTenantHandler(
 	tenantService *services.TenantService,
 	userService *services.UserService,
+	repairService *services.RepairService,
 ) *TenantHandler {
 	return &TenantHandler{
 		BaseHandler:   NewBaseHandler(),
 		tenantService: tenantService,
 		userService:   userService,
+		repairService: repairService,
 	}
 }
 
@@ -35,14 +40,19 @@ func (h *TenantHandler) RegisterRoutes(router *gin.RouterGroup) {
 	{
 		// Tenant settings
 		tenant.GET("/settings", h.GetSettings)
-		tenant.PUT("/settings", h.requireAdminMiddleware(), h.UpdateSettings)
+		tenant.PUT("/settings", h.UpdateSettings)
+		tenant.PATCH("/settings", h.PatchSettings)
+		tenant.GET("/settings/schema", h.GetSettingsSchema)
 
 		// Usage statistics
 		tenant.GET("/usage", h.GetUsage)
+		tenant.POST("/usage/recompute", h.RecomputeUsage)
+
+		// Storage file reconciliation (admin only)
+		tenant.POST("/storage/reconcile", h.ReconcileStorage)
 
 		// Tenant user management (admin only)
 		tenantUsers := tenant.Group("/users")
-		tenantUsers.Use(h.requireAdminMiddleware())
 		{
 			tenantUsers.GET("", h.GetTenantUsers)
 		}
@@ -62,6 +72,17 @@ type TenantSettingsRequest struct {
 	Settings     map[string]interface{} `json:"settings,omitempty"`
 }
 
+// PatchTenantSettingsRequest changes known settings; a null value resets one
+// to its default
+type PatchTenantSettingsRequest struct {
+	Settings map[string]interface{} `json:"settings" binding:"required"`
+}
+
+// TenantSettingsSchemaResponse lists the known settings
+type TenantSettingsSchemaResponse struct {
+	Settings []services.TenantSettingDefinition `json:"settings"`
+}
+
 // TenantSettingsResponse represents tenant settings in API responses
 type TenantSettingsResponse struct {
 	ID           uuid.UUID              `json:"id"`
@@ -74,22 +95,47 @@ type TenantSettingsResponse struct {
 	Address      map[string]interface{} `json:"address"`
 	Settings     map[string]interface{} `json:"settings"`
 	IsActive     bool                   `json:"is_active"`
-	CreatedAt    string                 `json:"created_at"`
-	UpdatedAt    string                 `json:"updated_at"`
+
+	// Configuration holds the known settings, defaults included
+	Configuration *services.TenantSettings `json:"configuration,omitempty"`
+	CreatedAt     string                   `json:"created_at"`
+	UpdatedAt     string                   `json:"updated_at"`
 }
 
 // TenantUsageResponse represents tenant usage statistics
 type TenantUsageResponse struct {
-	TenantID       uuid.UUID `json:"tenant_id"`
-	StorageUsed    int64     `json:"storage_used_bytes"`
-	StorageQuota   int64     `json:"storage_quota_bytes"`
-	StoragePercent float64   `json:"storage_usage_percent"`
-	APIUsed        int       `json:"api_used"`
-	APIQuota       int       `json:"api_quota"`
-	APIPercent     float64   `json:"api_usage_percent"`
-	TotalUsers     int64     `json:"total_users"`
-	TotalDocuments int64     `json:"total_documents"`
-	LastUpdated    string    `json:"last_updated"`
+	TenantID        uuid.UUID `json:"tenant_id"`
+	StorageUsed     int64     `json:"storage_used_bytes"`
+	StorageQuota    int64     `json:"storage_quota_bytes"`
+	StoragePercent  float64   `json:"storage_usage_percent"`
+	APIUsed         int       `json:"api_used"`
+	APIQuota        int       `json:"api_quota"`
+	APIPercent      float64   `json:"api_usage_percent"`
+	DocumentCount   int64     `json:"document_count"`
+	DocumentQuota   int64     `json:"document_quota"`
+	DocumentPercent float64   `json:"document_usage_percent"`
+	TotalUsers      int64     `json:"total_users"`
+	TotalDocuments  int64     `json:"total_documents"`
+	LastUpdated     string    `json:"last_updated"`
+
+	// Headroom: how much more the tenant can store, add and call
+	StorageRemaining   int64 `json:"storage_remaining_bytes"`
+	APIRemaining       int   `json:"api_remaining"`
+	DocumentsRemaining int64 `json:"documents_remaining"`
+	CanUpload          bool  `json:"can_upload"`
+}
+
+// StorageReconciliationResponse reports a storage usage recomputation
+type StorageReconciliationResponse struct {
+	TenantID      uuid.UUID `json:"tenant_id"`
+	PreviousUsage int64     `json:"previous_storage_used_bytes"`
+	StorageUsed   int64     `json:"storage_used_bytes"`
+	Drift         int64     `json:"drift_bytes"`
+}
+
+// ReconcileStorageRequest controls a storage reconciliation
+type ReconcileStorageRequest struct {
+	DeleteOrphans bool `json:"delete_orphans"`
 }
 
 // TenantUsersResponse represents tenant users list
@@ -118,7 +164,7 @@ type UserSummary struct {
 
 // GetSettings retrieves tenant settings
 // @Summary Get tenant settings
-// @Description Get current tenant's settings and configuration
+// @Description Get current tenant's settings and configuration. configuration holds the known settings with their defaults filled in.
 // @Tags tenant
 // @Produce json
 // @Success 200 {object} TenantSettingsResponse
@@ -131,14 +177,7 @@ func (h *TenantHandler) GetSettings(c *gin.Context) {
 		return
 	}
 
-	// Get tenant information
-	tenantInfo, err := h.tenantService.GetTenant(c.Request.Context(), userCtx.TenantID)
-	if err != nil {
-		h.RespondNotFound(c, "Tenant not found")
-		return
-	}
-
-	h.RespondSuccess(c, convertToTenantSettingsResponse(tenantInfo.Tenant))
+	h.respondTenantSettings(c, userCtx.TenantID)
 }
 
 // UpdateSettings updates tenant settings
@@ -169,18 +208,66 @@ func (h *TenantHandler) UpdateSettings(c *gin.Context) {
 	updates := h.buildSettingsUpdateMap(req)
 
 	// Update tenant
-	tenant, err := h.tenantService.UpdateTenant(c.Request.Context(), userCtx.TenantID, updates, userCtx.UserID)
+	_, err := h.tenantService.UpdateTenant(c.Request.Context(), userCtx.TenantID, updates, userCtx.UserID)
 	if err != nil {
-		h.RespondInternalError(c, "Failed to update tenant settings", err.Error())
+		h.handleTenantSettingsError(c, err)
 		return
 	}
 
-	h.RespondSuccess(c, convertToTenantSettingsResponse(tenant))
+	h.respondTenantSettings(c, userCtx.TenantID)
+}
+
+// PatchSettings changes known tenant settings
+// @Summary Change tenant settings
+// @Description Change known settings (admin only), validated against the settings schema. A null value resets a setting to its default; settings left out keep their values. Changes are audited.
+// @Tags tenant
+// @Accept json
+// @Produce json
+// @Param request body PatchTenantSettingsRequest true "Settings to change"
+// @Success 200 {object} TenantSettingsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /tenant/settings [patch]
+func (h *TenantHandler) PatchSettings(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req PatchTenantSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if _, err := h.tenantService.UpdateSettings(c.Request.Context(), userCtx.TenantID, userCtx.UserID, req.Settings); err != nil {
+		h.handleTenantSettingsError(c, err)
+		return
+	}
+
+	h.respondTenantSettings(c, userCtx.TenantID)
+}
+
+// GetSettingsSchema lists the known tenant settings
+// @Summary Get the tenant settings schema
+// @Description List the settings PATCH /tenant/settings accepts, with their types, defaults and allowed values
+// @Tags tenant
+// @Produce json
+// @Success 200 {object} TenantSettingsSchemaResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /tenant/settings/schema [get]
+func (h *TenantHandler) GetSettingsSchema(c *gin.Context) {
+	if _, ok := h.AuthenticateUser(c); !ok {
+		return
+	}
+
+	h.RespondSuccess(c, TenantSettingsSchemaResponse{Settings: services.TenantSettingsSchema()})
 }
 
 // GetUsage retrieves tenant usage statistics
 // @Summary Get tenant usage
-// @Description Get current tenant's usage statistics and quotas
+// @Description Get current tenant's usage statistics, quotas and the headroom left under each. Storage is a hard limit: an upload that would exceed it is rejected with 402.
 // @Tags tenant
 // @Produce json
 // @Success 200 {object} TenantUsageResponse
@@ -203,6 +290,75 @@ func (h *TenantHandler) GetUsage(c *gin.Context) {
 	h.RespondSuccess(c, convertToTenantUsageResponse(usage))
 }
 
+// RecomputeUsage recomputes storage usage from document and version sizes
+// @Summary Recompute tenant storage usage
+// @Description Recompute exact storage usage from stored documents and versions and reset the usage counter (admin only)
+// @Tags tenant
+// @Produce json
+// @Success 200 {object} StorageReconciliationResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenant/usage/recompute [post]
+func (h *TenantHandler) RecomputeUsage(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.repairService.ReconcileStorageUsage(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to recompute storage usage", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, &StorageReconciliationResponse{
+		TenantID:      result.TenantID,
+		PreviousUsage: result.PreviousUsage,
+		StorageUsed:   result.StorageUsed,
+		Drift:         result.Drift,
+	})
+}
+
+// ReconcileStorage compares the tenant's files in storage with its documents
+// @Summary Reconcile tenant storage
+// @Description Report files in storage no document, version or export references (orphans, e.g. from uploads whose database write failed) and documents whose file is missing. With delete_orphans, orphans older than a day are deleted; missing files are only reported (admin only)
+// @Tags tenant
+// @Accept json
+// @Produce json
+// @Param request body ReconcileStorageRequest false "Reconciliation options"
+// @Success 200 {object} services.RepairReport
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /tenant/storage/reconcile [post]
+func (h *TenantHandler) ReconcileStorage(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req ReconcileStorageRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.RespondBadRequest(c, "Invalid request format", err.Error())
+			return
+		}
+	}
+
+	report, err := h.repairService.Run(c.Request.Context(), services.RepairOptions{
+		TenantID: &userCtx.TenantID,
+		Checks:   services.StorageRepairChecks,
+		DryRun:   !req.DeleteOrphans,
+	})
+	if err != nil {
+		h.RespondInternalError(c, "Failed to reconcile storage", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, report)
+}
+
 // GetTenantUsers lists all users in the tenant
 // @Summary List tenant users
 // @Description List all users in the current tenant (admin only)
@@ -254,19 +410,6 @@ func (h *TenantHandler) GetTenantUsers(c *gin.Context) {
 
 // Helper Methods
 
-// requireAdminMiddleware checks if user has admin privileges
-func (h *TenantHandler) requireAdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userCtx := getUserContextFromGin(c)
-		if userCtx == nil || userCtx.Role != models.UserRoleAdmin {
-			h.RespondError(c, 403, "admin_required", "Administrator privileges required")
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
-}
-
 // getUserContextFromGin extracts user context from gin context (renamed to avoid conflict)
 func getUserContextFromGin(c *gin.Context) *middleware.UserContext {
 	user, exists := c.Get("user")
@@ -340,25 +483,49 @@ func convertToTenantUsageResponse(usage *services.TenantUsage) TenantUsageRespon
 	storageQuota := int64(0)
 	apiUsed := 0
 	apiQuota := 0
+	documentCount := int64(0)
+	documentQuota := int64(0)
+	documentPercent := 0.0
+	storageRemaining := int64(0)
+	apiRemaining := 0
+	documentsRemaining := int64(0)
+	canUpload := false
 
 	if usage.QuotaStatus != nil {
 		storageUsed = usage.QuotaStatus.StorageUsed
 		storageQuota = usage.QuotaStatus.StorageQuota
 		apiUsed = usage.QuotaStatus.APIUsed
 		apiQuota = usage.QuotaStatus.APIQuota
+		documentCount = usage.QuotaStatus.DocumentCount
+		documentQuota = usage.QuotaStatus.DocumentQuota
+		if documentQuota > 0 {
+			documentPercent = usage.QuotaStatus.DocumentPercent
+		}
+		storageRemaining = usage.QuotaStatus.StorageRemaining
+		apiRemaining = usage.QuotaStatus.APIRemaining
+		documentsRemaining = usage.QuotaStatus.DocumentsRemaining
+		canUpload = usage.QuotaStatus.CanUpload && usage.QuotaStatus.CanAddDocument
 	}
 
 	return TenantUsageResponse{
-		TenantID:       usage.TenantID,
-		StorageUsed:    storageUsed,
-		StorageQuota:   storageQuota,
-		StoragePercent: storagePercent,
-		APIUsed:        apiUsed,
-		APIQuota:       apiQuota,
-		APIPercent:     apiPercent,
-		TotalUsers:     usage.TotalUsers,
-		TotalDocuments: usage.TotalDocuments,
-		LastUpdated:    usage.LastUpdated.Format("2006-01-02T15:04:05Z"),
+		TenantID:        usage.TenantID,
+		StorageUsed:     storageUsed,
+		StorageQuota:    storageQuota,
+		StoragePercent:  storagePercent,
+		APIUsed:         apiUsed,
+		APIQuota:        apiQuota,
+		APIPercent:      apiPercent,
+		DocumentCount:   documentCount,
+		DocumentQuota:   documentQuota,
+		DocumentPercent: documentPercent,
+		TotalUsers:      usage.TotalUsers,
+		TotalDocuments:  usage.TotalDocuments,
+		LastUpdated:     usage.LastUpdated.Format("2006-01-02T15:04:05Z"),
+
+		StorageRemaining:   storageRemaining,
+		APIRemaining:       apiRemaining,
+		DocumentsRemaining: documentsRemaining,
+		CanUpload:          canUpload,
 	}
 }
 
@@ -383,6 +550,35 @@ func convertToUserSummary(user *models.User) UserSummary {
 	}
 }
 
+// respondTenantSettings responds with the tenant's settings and configuration
+func (h *TenantHandler) respondTenantSettings(c *gin.Context, tenantID uuid.UUID) {
+	tenantInfo, err := h.tenantService.GetTenant(c.Request.Context(), tenantID)
+	if err != nil {
+		h.RespondNotFound(c, "Tenant not found")
+		return
+	}
+	configuration, err := h.tenantService.GetSettings(c.Request.Context(), tenantID)
+	if err != nil {
+		h.handleTenantSettingsError(c, err)
+		return
+	}
+
+	response := convertToTenantSettingsResponse(tenantInfo.Tenant)
+	response.Configuration = configuration
+	h.RespondSuccess(c, response)
+}
+
+func (h *TenantHandler) handleTenantSettingsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownTenantSetting), errors.Is(err, services.ErrInvalidTenantSetting):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	default:
+		h.RespondInternalError(c, "Failed to update tenant settings", err.Error())
+	}
+}
+
 func (h *TenantHandler) buildSettingsUpdateMap(req TenantSettingsRequest) map[string]interface{} {
 	updates := map[string]interface{}{
 		"name":          req.Name,
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantTransferHandler handles moving a tenant between Archivus instances
+type TenantTransferHandler struct {
+	*BaseHandler
+	transferService *services.TenantTransferService
+}
+
+// NewTenantTransferHandler creates a new tenant transfer handler
+func NewTenantTransferHandler(transferService *services.TenantTransferService) *TenantTransferHandler {
+	return &TenantTransferHandler{
+		BaseHandler:     NewBaseHandler(),
+		transferService: transferService,
+	}
+}
+
+// RegisterRoutes sets up the tenant transfer routes
+func (h *TenantTransferHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	transfer := router.Group("/tenant/transfer")
+	{
+		transfer.GET("/export", h.ExportTenant)
+		transfer.POST("/import", h.ImportTenant)
+	}
+}
+
+// Handler Methods
+
+// ExportTenant streams an archive of the caller's tenant
+// @Summary Export tenant
+// @Description Download the tenant's users, folders, tags, categories, workflows and documents with their files as a zip archive, to import into another Archivus instance. Documents in the trash are left out.
+// @Tags tenant
+// @Produce application/zip
+// @Success 200 {file} binary
+// @Failure 404 {object} ErrorResponse
+// @Router /tenant/transfer/export [get]
+func (h *TenantTransferHandler) ExportTenant(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	filename := "tenant-" + time.Now().UTC().Format("20060102T150405Z") + ".zip"
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	if _, err := h.transferService.Export(c.Request.Context(), userCtx.TenantID, userCtx.UserID, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			h.handleTenantTransferError(c, err)
+			return
+		}
+		// Headers are already sent; the download ends short
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// ImportTenant imports a tenant archive into the caller's tenant
+// @Summary Import tenant
+// @Description Import an archive made by a tenant export into this tenant, which must not have any documents yet. Rows get new IDs; users, folders, tags and categories the tenant already has are matched by email, path or name and reused.
+// @Tags tenant
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Tenant archive"
+// @Success 201 {object} services.TenantImportResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 402 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /tenant/transfer/import [post]
+func (h *TenantTransferHandler) ImportTenant(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		h.RespondBadRequest(c, "No file uploaded or invalid file", err.Error())
+		return
+	}
+	defer file.Close()
+
+	result, err := h.transferService.Import(c.Request.Context(), file, header.Size, services.TenantImportOptions{
+		TenantID:   &userCtx.TenantID,
+		ImportedBy: userCtx.UserID,
+	})
+	if err != nil {
+		h.handleTenantTransferError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, result)
+}
+
+// Helper Methods
+
+func (h *TenantTransferHandler) handleTenantTransferError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	case errors.Is(err, services.ErrInvalidTenantArchive),
+		errors.Is(err, services.ErrUnsupportedTenantArchive):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrTenantNotEmpty):
+		h.RespondConflict(c, "Tenant already has documents; import into an empty tenant")
+	case errors.Is(err, services.ErrQuotaExceeded):
+		h.RespondError(c, http.StatusPaymentRequired, "quota_exceeded", "Storage quota exceeded")
+	case errors.Is(err, services.ErrDocumentQuotaExceeded):
+		h.RespondError(c, http.StatusPaymentRequired, "document_quota_exceeded", "Document quota exceeded")
+	default:
+		h.RespondInternalError(c, "Failed to transfer tenant", err.Error())
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+)
+
+// TrialHandler handles the tenant's trial and reactivation after it ends
+type TrialHandler struct {
+	*BaseHandler
+	trialService *services.TrialService
+}
+
+// NewTrialHandler creates a new trial handler
+func NewTrialHandler(trialService *services.TrialService) *TrialHandler {
+	return &TrialHandler{
+		BaseHandler:  NewBaseHandler(),
+		trialService: trialService,
+	}
+}
+
+// RegisterRoutes sets up the trial routes
+func (h *TrialHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	trial := router.Group("/tenant/trial")
+	{
+		trial.GET("", h.GetTrial)
+		trial.POST("/reactivate", h.Reactivate)
+	}
+}
+
+// Request/Response DTOs
+
+// ReactivateTrialRequest chooses the plan a tenant continues on after its trial
+type ReactivateTrialRequest struct {
+	SubscriptionTier models.SubscriptionTier `json:"subscription_tier" binding:"required"`
+}
+
+// Handler Methods
+
+// GetTrial returns the tenant's trial status
+// @Summary Get trial status
+// @Description When the tenant's trial ends, whether it has expired, and what happens at expiry: suspension, or a move to the starter tier. Admins are warned ahead of expiry.
+// @Tags tenant
+// @Produce json
+// @Success 200 {object} services.TrialStatus
+// @Failure 404 {object} ErrorResponse
+// @Router /tenant/trial [get]
+func (h *TrialHandler) GetTrial(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.trialService.GetTrialStatus(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleTrialError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, status)
+}
+
+// Reactivate puts a tenant whose trial expired on a plan
+// @Summary Reactivate after trial
+// @Description Start a subscription on the given tier after the trial expired. A suspended tenant is reactivated and everyone can sign in again; the tier's quotas apply. Refused when billing isn't configured, since nothing would charge for the tier.
+// @Tags tenant
+// @Accept json
+// @Produce json
+// @Param request body ReactivateTrialRequest true "Plan"
+// @Success 200 {object} services.TrialStatus
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /tenant/trial/reactivate [post]
+func (h *TrialHandler) Reactivate(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req ReactivateTrialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	status, err := h.trialService.Reactivate(c.Request.Context(), userCtx.TenantID, req.SubscriptionTier, userCtx.UserID)
+	if err != nil {
+		h.handleTrialError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, status)
+}
+
+// Helper Methods
+
+func (h *TrialHandler) handleTrialError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	case errors.Is(err, services.ErrInvalidSubscriptionTier):
+		h.RespondBadRequest(c, "Invalid subscription tier", "")
+	case errors.Is(err, services.ErrTrialNotExpired):
+		h.RespondConflict(c, "The trial has not expired")
+	case errors.Is(err, services.ErrBillingNotConfigured):
+		h.RespondError(c, http.StatusServiceUnavailable, "billing_unavailable", err.Error())
+	default:
+		h.RespondInternalError(c, "Failed to process trial", err.Error())
+	}
+}
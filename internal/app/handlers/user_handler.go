@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/archivus/archivus/internal/app/middleware"
@@ -40,9 +41,20 @@ func (h *UserHandler) RegisterRoutes(router *gin.RouterGroup) {
 		users.PUT("/profile", h.UpdateProfile)
 		users.POST("/change-password", h.ChangePassword)
 
+		// MFA enrollment and recovery codes
+		users.GET("/mfa", h.GetMFAStatus)
+		users.POST("/mfa/enroll", h.StartMFAEnrollment)
+		users.POST("/mfa/confirm", h.ConfirmMFAEnrollment)
+		users.POST("/mfa/disable", h.DisableMFA)
+		users.POST("/mfa/recovery-codes", h.RegenerateMFARecoveryCodes)
+
+		// Sessions
+		users.GET("/sessions", h.ListSessions)
+		users.DELETE("/sessions", h.RevokeAllSessions)
+		users.DELETE("/sessions/:session_id", h.RevokeSession)
+
 		// Admin user management routes (require admin privileges)
 		adminUsers := users.Group("")
-		adminUsers.Use(h.requireAdminMiddleware())
 		{
 			adminUsers.GET("", h.ListUsers)
 			adminUsers.POST("", h.CreateUser)
@@ -51,6 +63,7 @@ func (h *UserHandler) RegisterRoutes(router *gin.RouterGroup) {
 			adminUsers.PUT("/:id/role", h.UpdateUserRole)
 			adminUsers.PUT("/:id/activate", h.ActivateUser)
 			adminUsers.PUT("/:id/deactivate", h.DeactivateUser)
+			adminUsers.DELETE("/:id/sessions", h.RevokeUserSessions)
 		}
 	}
 }
@@ -72,6 +85,26 @@ type ChangePasswordRequest struct {
 	NewPassword     string `json:"new_password" binding:"required,min=8"`
 }
 
+// MFACodeRequest carries an authenticator code (or, where allowed, a recovery code)
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFARecoveryCodesResponse returns recovery codes; they are shown only once
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SessionResponse represents an active session
+type SessionResponse struct {
+	ID        string `json:"id"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Current   bool   `json:"current"`
+	CreatedAt string `json:"created_at"`
+	LastSeen  string `json:"last_seen"`
+}
+
 // CreateUserRequest contains user creation data (admin only)
 type CreateUserRequest struct {
 	Email      string          `json:"email" binding:"required,email"`
@@ -91,6 +124,7 @@ type UpdateUserRequest struct {
 	Department *string          `json:"department,omitempty" binding:"omitempty,max=100"`
 	JobTitle   *string          `json:"job_title,omitempty" binding:"omitempty,max=100"`
 	IsActive   *bool            `json:"is_active,omitempty"`
+	ManagerID  *string          `json:"manager_id,omitempty"` // Empty clears the manager
 }
 
 // UpdateRoleRequest contains role update data
@@ -105,8 +139,10 @@ type UserProfileResponse struct {
 	FirstName     string          `json:"first_name"`
 	LastName      string          `json:"last_name"`
 	Role          models.UserRole `json:"role"`
+	CustomRoleID  *uuid.UUID      `json:"custom_role_id,omitempty"`
 	Department    string          `json:"department,omitempty"`
 	JobTitle      string          `json:"job_title,omitempty"`
+	ManagerID     *uuid.UUID      `json:"manager_id,omitempty"`
 	Phone         string          `json:"phone,omitempty"`
 	IsActive      bool            `json:"is_active"`
 	EmailVerified bool            `json:"email_verified"`
@@ -242,6 +278,265 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	})
 }
 
+// GetMFAStatus returns the current user's MFA state
+// @Summary Get MFA status
+// @Description Whether MFA is enabled, enrollment is pending, and how many recovery codes remain
+// @Tags users
+// @Produce json
+// @Success 200 {object} services.MFAStatus
+// @Failure 401 {object} ErrorResponse
+// @Router /users/mfa [get]
+func (h *UserHandler) GetMFAStatus(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.userService.GetMFAStatus(c.Request.Context(), userCtx.UserID)
+	if err != nil {
+		h.respondMFAError(c, err, "Failed to get MFA status")
+		return
+	}
+
+	h.RespondSuccess(c, status)
+}
+
+// StartMFAEnrollment begins MFA enrollment
+// @Summary Start MFA enrollment
+// @Description Generate a TOTP secret to add to an authenticator app. MFA is not enabled until the enrollment is confirmed with a code.
+// @Tags users
+// @Produce json
+// @Success 200 {object} services.MFAEnrollment
+// @Failure 409 {object} ErrorResponse
+// @Router /users/mfa/enroll [post]
+func (h *UserHandler) StartMFAEnrollment(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.userService.StartMFAEnrollment(c.Request.Context(), userCtx.UserID)
+	if err != nil {
+		h.respondMFAError(c, err, "Failed to start MFA enrollment")
+		return
+	}
+
+	h.RespondSuccess(c, enrollment)
+}
+
+// ConfirmMFAEnrollment enables MFA after verifying a code from the authenticator
+// @Summary Confirm MFA enrollment
+// @Description Verify a code from the newly enrolled authenticator, enable MFA and return recovery codes
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body MFACodeRequest true "Authenticator code"
+// @Success 200 {object} MFARecoveryCodesResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /users/mfa/confirm [post]
+func (h *UserHandler) ConfirmMFAEnrollment(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	codes, err := h.userService.ConfirmMFAEnrollment(c.Request.Context(), userCtx.UserID, req.Code)
+	if err != nil {
+		h.respondMFAError(c, err, "Failed to confirm MFA enrollment")
+		return
+	}
+
+	h.RespondSuccess(c, MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA turns MFA off
+// @Summary Disable MFA
+// @Description Disable MFA using an authenticator code or a recovery code
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body MFACodeRequest true "Authenticator or recovery code"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /users/mfa/disable [post]
+func (h *UserHandler) DisableMFA(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if err := h.userService.DisableMFA(c.Request.Context(), userCtx.UserID, req.Code); err != nil {
+		h.respondMFAError(c, err, "Failed to disable MFA")
+		return
+	}
+
+	h.RespondSuccess(c, SuccessResponse{
+		Message: "MFA disabled successfully",
+	})
+}
+
+// RegenerateMFARecoveryCodes replaces the current user's recovery codes
+// @Summary Regenerate MFA recovery codes
+// @Description Invalidate existing recovery codes and issue a new set; requires an authenticator code
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body MFACodeRequest true "Authenticator code"
+// @Success 200 {object} MFARecoveryCodesResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /users/mfa/recovery-codes [post]
+func (h *UserHandler) RegenerateMFARecoveryCodes(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(c.Request.Context(), userCtx.UserID, req.Code)
+	if err != nil {
+		h.respondMFAError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	h.RespondSuccess(c, MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// ListSessions lists the current user's active sessions
+// @Summary List sessions
+// @Description List the current user's active sessions, newest activity first
+// @Tags users
+// @Produce json
+// @Success 200 {array} SessionResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /users/sessions [get]
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.userService.ListUserSessions(c.Request.Context(), userCtx.UserID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list sessions", err.Error())
+		return
+	}
+
+	currentID := c.GetString("session_id")
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			ID:        session.ID,
+			IPAddress: session.IPAddress,
+			UserAgent: session.UserAgent,
+			Current:   session.ID == currentID,
+			CreatedAt: session.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			LastSeen:  session.LastSeen.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// RevokeSession signs out one of the current user's sessions
+// @Summary Revoke session
+// @Description End one session immediately; its tokens stop working on the next request
+// @Tags users
+// @Param session_id path string true "Session ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /users/sessions/{session_id} [delete]
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	err := h.userService.RevokeSession(c.Request.Context(), userCtx.UserID, c.Param("session_id"), userCtx.UserID)
+	if err != nil {
+		if err == services.ErrSessionNotFound {
+			h.RespondNotFound(c, "Session not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to revoke session", err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeAllSessions logs the current user out everywhere
+// @Summary Log out everywhere
+// @Description Revoke every session of the current user, including this one
+// @Tags users
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Router /users/sessions [delete]
+func (h *UserHandler) RevokeAllSessions(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.RevokeAllSessions(c.Request.Context(), userCtx.UserID, userCtx.UserID); err != nil {
+		h.RespondInternalError(c, "Failed to revoke sessions", err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeUserSessions logs a user in the tenant out everywhere (admin only)
+// @Summary Revoke a user's sessions
+// @Description Revoke every session of a user, e.g. when access must end immediately (admin only)
+// @Tags users
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /users/{id}/sessions [delete]
+func (h *UserHandler) RevokeUserSessions(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.ValidateUUID(c, "user ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// Admins may only act on users in their own tenant
+	profile, err := h.userService.GetUserProfile(c.Request.Context(), userID)
+	if err != nil || profile.TenantID != userCtx.TenantID {
+		h.RespondNotFound(c, "User not found")
+		return
+	}
+
+	if err := h.userService.RevokeAllSessions(c.Request.Context(), userID, userCtx.UserID); err != nil {
+		h.RespondInternalError(c, "Failed to revoke sessions", err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // ListUsers lists all users (admin only)
 // @Summary List users
 // @Description List all users in the tenant (admin only)
@@ -398,6 +693,15 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	var managerID *uuid.UUID
+	if req.ManagerID != nil && *req.ManagerID != "" {
+		id, ok := h.ValidateUUID(c, "manager ID", *req.ManagerID)
+		if !ok {
+			return
+		}
+		managerID = &id
+	}
+
 	// Prepare updates map
 	updates := make(map[string]interface{})
 	if req.FirstName != nil {
@@ -427,6 +731,22 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	if req.ManagerID != nil {
+		updatedUser, err = h.userService.SetManager(c.Request.Context(), userCtx.TenantID, userID, managerID, userCtx.UserID)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidManager) {
+				h.RespondError(c, http.StatusBadRequest, "invalid_manager", err.Error())
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "update_failed",
+				Message: "Failed to update user manager",
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
 	// Handle activation/deactivation separately
 	if req.IsActive != nil {
 		if *req.IsActive {
@@ -710,19 +1030,19 @@ func (h *UserHandler) updateUserStatus(c *gin.Context, isActive bool) {
 	c.JSON(http.StatusOK, convertToUserProfileResponse(profile.User))
 }
 
-// requireAdminMiddleware checks if user has admin privileges
-func (h *UserHandler) requireAdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userCtx := getUserContext(c)
-		if userCtx == nil || userCtx.Role != models.UserRoleAdmin {
-			c.JSON(http.StatusForbidden, ErrorResponse{
-				Error:   "admin_required",
-				Message: "Administrator privileges required",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
+// respondMFAError maps MFA service errors to responses
+func (h *UserHandler) respondMFAError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrUserNotFound:
+		h.RespondNotFound(c, "User not found")
+	case services.ErrInvalidMFACode:
+		h.RespondBadRequest(c, "Invalid MFA code")
+	case services.ErrMFAEnrollmentNotFound, services.ErrMFANotEnabled:
+		h.RespondBadRequest(c, err.Error())
+	case services.ErrMFAAlreadyEnabled:
+		h.RespondConflict(c, err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
 	}
 }
 
@@ -772,8 +1092,10 @@ func convertToUserProfileResponse(user *models.User) UserProfileResponse {
 		FirstName:     user.FirstName,
 		LastName:      user.LastName,
 		Role:          user.Role,
+		CustomRoleID:  user.CustomRoleID,
 		Department:    user.Department,
 		JobTitle:      user.JobTitle,
+		ManagerID:     user.ManagerID,
 		Phone:         "", // Phone field not available in User model
 		IsActive:      user.IsActive,
 		EmailVerified: user.EmailVerified,
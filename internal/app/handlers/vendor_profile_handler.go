@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// VendorProfileHandler handles vendor extraction profiles and corrections
+// to extracted financial data
+type VendorProfileHandler struct {
+	*BaseHandler
+	profileService *services.VendorProfileService
+}
+
+// NewVendorProfileHandler creates a new vendor profile handler
+func NewVendorProfileHandler(profileService *services.VendorProfileService) *VendorProfileHandler {
+	return &VendorProfileHandler{
+		BaseHandler:    NewBaseHandler(),
+		profileService: profileService,
+	}
+}
+
+// RegisterRoutes sets up the vendor profile routes
+func (h *VendorProfileHandler) RegisterRoutes(router *gin.RouterGroup) {
+	profiles := router.Group("/vendor-profiles")
+	// Note: Auth middleware should be applied at server level
+	{
+		profiles.GET("", h.ListProfiles)
+		profiles.POST("", h.CreateProfile)
+		profiles.GET("/date-formats", h.ListDateFormats)
+		profiles.GET("/:id", h.GetProfile)
+		profiles.PUT("/:id", h.UpdateProfile)
+		profiles.DELETE("/:id", h.DeleteProfile)
+	}
+
+	router.POST("/documents/:id/extraction-feedback", h.SubmitFeedback)
+}
+
+// Request/Response DTOs
+
+// CreateVendorProfileRequest contains vendor profile creation data
+type CreateVendorProfileRequest struct {
+	VendorName          string   `json:"vendor_name" binding:"required,max=255"`
+	Aliases             []string `json:"aliases,omitempty"`
+	DateFormat          string   `json:"date_format,omitempty"`
+	Currency            string   `json:"currency,omitempty"`
+	DocumentNumberLabel string   `json:"document_number_label,omitempty" binding:"max=100"`
+	Hints               string   `json:"hints,omitempty" binding:"max=2000"`
+}
+
+// UpdateVendorProfileRequest contains profile changes; omitted fields are unchanged
+type UpdateVendorProfileRequest struct {
+	VendorName          *string  `json:"vendor_name,omitempty" binding:"omitempty,max=255"`
+	Aliases             []string `json:"aliases,omitempty"`
+	DateFormat          *string  `json:"date_format,omitempty"`
+	Currency            *string  `json:"currency,omitempty"`
+	DocumentNumberLabel *string  `json:"document_number_label,omitempty" binding:"omitempty,max=100"`
+	Hints               *string  `json:"hints,omitempty" binding:"omitempty,max=2000"`
+}
+
+// ExtractionFeedbackRequest contains corrected financial fields; omitted
+// fields were extracted correctly
+type ExtractionFeedbackRequest struct {
+	VendorName     *string  `json:"vendor_name,omitempty" binding:"omitempty,max=255"`
+	DocumentNumber *string  `json:"document_number,omitempty" binding:"omitempty,max=100"`
+	DocumentDate   *string  `json:"document_date,omitempty"`
+	DueDate        *string  `json:"due_date,omitempty"`
+	Currency       *string  `json:"currency,omitempty"`
+	Amount         *float64 `json:"amount,omitempty"`
+}
+
+// VendorProfileResponse represents a vendor profile in API responses
+type VendorProfileResponse struct {
+	ID                  uuid.UUID `json:"id"`
+	VendorName          string    `json:"vendor_name"`
+	Aliases             []string  `json:"aliases"`
+	DateFormat          string    `json:"date_format,omitempty"`
+	Currency            string    `json:"currency,omitempty"`
+	DocumentNumberLabel string    `json:"document_number_label,omitempty"`
+	Hints               string    `json:"hints,omitempty"`
+	Source              string    `json:"source"`
+	CorrectionCount     int       `json:"correction_count"`
+	LastCorrectedAt     *string   `json:"last_corrected_at,omitempty"`
+	CreatedAt           string    `json:"created_at"`
+	UpdatedAt           string    `json:"updated_at"`
+}
+
+// ExtractionFeedbackResponse is the vendor profile after a correction
+type ExtractionFeedbackResponse struct {
+	DocumentID uuid.UUID             `json:"document_id"`
+	Profile    VendorProfileResponse `json:"profile"`
+	Created    bool                  `json:"created"`
+	Learned    []string              `json:"learned"`
+}
+
+// Handler Methods
+
+// ListProfiles lists the tenant's vendor profiles
+// @Summary List vendor profiles
+// @Description List the tenant's vendor extraction profiles, configured and learned
+// @Tags vendor-profiles
+// @Produce json
+// @Success 200 {array} VendorProfileResponse
+// @Router /vendor-profiles [get]
+func (h *VendorProfileHandler) ListProfiles(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	profiles, err := h.profileService.ListProfiles(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list vendor profiles", err.Error())
+		return
+	}
+
+	response := make([]VendorProfileResponse, 0, len(profiles))
+	for i := range profiles {
+		response = append(response, convertToVendorProfileResponse(&profiles[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// CreateProfile configures a vendor profile
+// @Summary Create vendor profile
+// @Description Tell financial extraction how a vendor lays out its documents: the names it goes by, its date format, currency, the label before its document numbers and free-text layout hints for the AI provider
+// @Tags vendor-profiles
+// @Accept json
+// @Produce json
+// @Param request body CreateVendorProfileRequest true "Vendor profile"
+// @Success 201 {object} VendorProfileResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /vendor-profiles [post]
+func (h *VendorProfileHandler) CreateProfile(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateVendorProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	profile, err := h.profileService.CreateProfile(c.Request.Context(), services.VendorProfileParams{
+		TenantID:            userCtx.TenantID,
+		CreatedBy:           userCtx.UserID,
+		VendorName:          req.VendorName,
+		Aliases:             req.Aliases,
+		DateFormat:          req.DateFormat,
+		Currency:            req.Currency,
+		DocumentNumberLabel: req.DocumentNumberLabel,
+		Hints:               req.Hints,
+	})
+	if err != nil {
+		h.respondVendorProfileError(c, err, "Failed to create vendor profile")
+		return
+	}
+
+	h.RespondCreated(c, convertToVendorProfileResponse(profile))
+}
+
+// ListDateFormats lists the date formats a profile can name
+// @Summary List vendor date formats
+// @Description List the date formats a vendor profile can name
+// @Tags vendor-profiles
+// @Produce json
+// @Success 200 {array} string
+// @Router /vendor-profiles/date-formats [get]
+func (h *VendorProfileHandler) ListDateFormats(c *gin.Context) {
+	h.RespondSuccess(c, services.VendorDateFormats)
+}
+
+// GetProfile returns a vendor profile
+// @Summary Get vendor profile
+// @Description Get a vendor extraction profile
+// @Tags vendor-profiles
+// @Produce json
+// @Param id path string true "Vendor profile ID"
+// @Success 200 {object} VendorProfileResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /vendor-profiles/{id} [get]
+func (h *VendorProfileHandler) GetProfile(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	profileID, ok := h.ValidateUUID(c, "Vendor profile ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	profile, err := h.profileService.GetProfile(c.Request.Context(), profileID, userCtx.TenantID)
+	if err != nil {
+		h.respondVendorProfileError(c, err, "Failed to get vendor profile")
+		return
+	}
+
+	h.RespondSuccess(c, convertToVendorProfileResponse(profile))
+}
+
+// UpdateProfile changes a vendor profile
+// @Summary Update vendor profile
+// @Description Change a vendor profile's name, aliases or extraction settings. Aliases, when given, replace the existing ones.
+// @Tags vendor-profiles
+// @Accept json
+// @Produce json
+// @Param id path string true "Vendor profile ID"
+// @Param request body UpdateVendorProfileRequest true "Profile changes"
+// @Success 200 {object} VendorProfileResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /vendor-profiles/{id} [put]
+func (h *VendorProfileHandler) UpdateProfile(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	profileID, ok := h.ValidateUUID(c, "Vendor profile ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req UpdateVendorProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	profile, err := h.profileService.UpdateProfile(c.Request.Context(), profileID, userCtx.TenantID, userCtx.UserID, services.UpdateVendorProfileParams{
+		VendorName:          req.VendorName,
+		Aliases:             req.Aliases,
+		DateFormat:          req.DateFormat,
+		Currency:            req.Currency,
+		DocumentNumberLabel: req.DocumentNumberLabel,
+		Hints:               req.Hints,
+	})
+	if err != nil {
+		h.respondVendorProfileError(c, err, "Failed to update vendor profile")
+		return
+	}
+
+	h.RespondSuccess(c, convertToVendorProfileResponse(profile))
+}
+
+// DeleteProfile deletes a vendor profile
+// @Summary Delete vendor profile
+// @Description Delete a vendor profile; the vendor's documents are extracted without one
+// @Tags vendor-profiles
+// @Param id path string true "Vendor profile ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /vendor-profiles/{id} [delete]
+func (h *VendorProfileHandler) DeleteProfile(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	profileID, ok := h.ValidateUUID(c, "Vendor profile ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.profileService.DeleteProfile(c.Request.Context(), profileID, userCtx.TenantID, userCtx.UserID); err != nil {
+		h.respondVendorProfileError(c, err, "Failed to delete vendor profile")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SubmitFeedback corrects a document's extracted financial data
+// @Summary Correct financial extraction
+// @Description Correct fields financial extraction got wrong. The document is updated and the vendor's profile, created if needed, learns from the corrections: names the vendor was misread as, its date format, currency and the label before its document numbers. Corrected fields are kept when the document is extracted again, and the tenant's later extractions get the correction as an example.
+// @Tags vendor-profiles
+// @Accept json
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param request body ExtractionFeedbackRequest true "Corrected fields"
+// @Success 200 {object} ExtractionFeedbackResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/extraction-feedback [post]
+func (h *VendorProfileHandler) SubmitFeedback(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "Document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ExtractionFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	result, err := h.profileService.SubmitFeedback(c.Request.Context(), userCtx.TenantID, documentID, userCtx.UserID, services.ExtractionFeedbackParams{
+		VendorName:     req.VendorName,
+		DocumentNumber: req.DocumentNumber,
+		DocumentDate:   req.DocumentDate,
+		DueDate:        req.DueDate,
+		Currency:       req.Currency,
+		Amount:         req.Amount,
+	})
+	if err != nil {
+		h.respondVendorProfileError(c, err, "Failed to submit extraction feedback")
+		return
+	}
+
+	h.RespondSuccess(c, ExtractionFeedbackResponse{
+		DocumentID: result.DocumentID,
+		Profile:    convertToVendorProfileResponse(result.Profile),
+		Created:    result.Created,
+		Learned:    result.Learned,
+	})
+}
+
+// Helper Methods
+
+func (h *VendorProfileHandler) respondVendorProfileError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrVendorProfileNotFound):
+		h.RespondNotFound(c, "Vendor profile not found")
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrDocumentAccessDenied):
+		h.RespondError(c, http.StatusForbidden, "document_access_denied", "You don't have write access to this document")
+	case errors.Is(err, services.ErrVendorProfileExists):
+		h.RespondConflict(c, err.Error())
+	case errors.Is(err, services.ErrVendorNameRequired), errors.Is(err, services.ErrInvalidDateFormat),
+		errors.Is(err, services.ErrInvalidCurrency), errors.Is(err, services.ErrInvalidFeedbackDate):
+		h.RespondBadRequest(c, err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
+	}
+}
+
+// Conversion functions
+
+func convertToVendorProfileResponse(profile *models.VendorProfile) VendorProfileResponse {
+	aliases := make([]string, 0, len(profile.Aliases))
+	for _, alias := range profile.Aliases {
+		aliases = append(aliases, alias.Alias)
+	}
+
+	response := VendorProfileResponse{
+		ID:                  profile.ID,
+		VendorName:          profile.VendorName,
+		Aliases:             aliases,
+		DateFormat:          profile.DateFormat,
+		Currency:            profile.Currency,
+		DocumentNumberLabel: profile.DocumentNumberLabel,
+		Hints:               profile.Hints,
+		Source:              string(profile.Source),
+		CorrectionCount:     profile.CorrectionCount,
+		CreatedAt:           profile.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:           profile.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+
+	if profile.LastCorrectedAt != nil {
+		lastCorrectedAt := profile.LastCorrectedAt.Format("2006-01-02T15:04:05Z")
+		response.LastCorrectedAt = &lastCorrectedAt
+	}
+
+	return response
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WatchedFolderHandler handles the local and SFTP folders a tenant's files
+// are ingested from
+type WatchedFolderHandler struct {
+	*BaseHandler
+	ingestionService *services.FolderIngestionService
+}
+
+// NewWatchedFolderHandler creates a new watched folder handler
+func NewWatchedFolderHandler(ingestionService *services.FolderIngestionService) *WatchedFolderHandler {
+	return &WatchedFolderHandler{
+		BaseHandler:      NewBaseHandler(),
+		ingestionService: ingestionService,
+	}
+}
+
+// RegisterRoutes sets up the watched folder routes
+func (h *WatchedFolderHandler) RegisterRoutes(router *gin.RouterGroup) {
+	folders := router.Group("/watched-folders")
+	// Note: Auth middleware should be applied at server level
+	{
+		folders.GET("", h.ListFolders)
+		folders.POST("", h.CreateFolder)
+		folders.PUT("/:id", h.UpdateFolder)
+		folders.DELETE("/:id", h.DeleteFolder)
+	}
+}
+
+// Request/Response DTOs
+
+// WatchedFolderRequest contains a watched folder's settings
+type WatchedFolderRequest struct {
+	Name        string     `json:"name" binding:"required,max=100"`
+	Type        string     `json:"type" binding:"required,oneof=local sftp"`
+	Enabled     *bool      `json:"enabled,omitempty"` // Defaults to true
+	Path        string     `json:"path" binding:"required"`
+	ArchivePath string     `json:"archive_path,omitempty"`
+	FailedPath  string     `json:"failed_path,omitempty"`
+	Host        string     `json:"host,omitempty"`
+	Port        int        `json:"port,omitempty"`
+	Username    string     `json:"username,omitempty"`
+	HostKey     string     `json:"host_key,omitempty"`
+	FolderID    *uuid.UUID `json:"folder_id,omitempty"`
+	UploaderID  *uuid.UUID `json:"uploader_id,omitempty"` // Defaults to the requesting user
+	EnableAI    bool       `json:"enable_ai"`
+	// Metadata files dropped next to documents and how they map onto fields
+	Sidecar *services.SidecarTemplate `json:"sidecar,omitempty"`
+}
+
+// WatchedFoldersResponse lists the tenant's watched folders
+type WatchedFoldersResponse struct {
+	Folders []services.WatchedFolder `json:"folders"`
+	// SSH key to authorize on SFTP servers; empty when SFTP isn't available
+	PublicKey string `json:"public_key,omitempty"`
+}
+
+// Handler Methods
+
+// ListFolders lists the tenant's watched folders
+// @Summary List watched folders
+// @Description List the local and SFTP folders new files are ingested from, with the SSH key SFTP servers must authorize
+// @Tags ingestion
+// @Produce json
+// @Success 200 {object} WatchedFoldersResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /watched-folders [get]
+func (h *WatchedFolderHandler) ListFolders(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	folders, err := h.ingestionService.ListFolders(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.handleWatchedFolderError(c, err)
+		return
+	}
+	if folders == nil {
+		folders = []services.WatchedFolder{}
+	}
+
+	h.RespondSuccess(c, WatchedFoldersResponse{
+		Folders:   folders,
+		PublicKey: h.ingestionService.PublicKey(),
+	})
+}
+
+// CreateFolder starts watching a folder
+// @Summary Create a watched folder
+// @Description Watch a directory in the tenant's own directory under the server's watch root, named after the tenant ID, or on an SFTP server. New files are uploaded and then moved to the archive path.
+// @Tags ingestion
+// @Accept json
+// @Produce json
+// @Param request body WatchedFolderRequest true "Watched folder"
+// @Success 201 {object} services.WatchedFolder
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Too many folders, or the type isn't available on this server"
+// @Router /watched-folders [post]
+func (h *WatchedFolderHandler) CreateFolder(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req WatchedFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	folder, err := h.ingestionService.CreateFolder(c.Request.Context(), userCtx.TenantID, userCtx.UserID, req.toWatchedFolder(userCtx.UserID))
+	if err != nil {
+		h.handleWatchedFolderError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, folder)
+}
+
+// UpdateFolder changes a watched folder's settings
+// @Summary Update a watched folder
+// @Description Replace a watched folder's settings; its ingestion history is kept
+// @Tags ingestion
+// @Accept json
+// @Produce json
+// @Param id path string true "Watched folder ID"
+// @Param request body WatchedFolderRequest true "Watched folder"
+// @Success 200 {object} services.WatchedFolder
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /watched-folders/{id} [put]
+func (h *WatchedFolderHandler) UpdateFolder(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	folderID, ok := h.ValidateUUID(c, "Watched folder ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req WatchedFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	folder, err := h.ingestionService.UpdateFolder(c.Request.Context(), userCtx.TenantID, userCtx.UserID, folderID, req.toWatchedFolder(userCtx.UserID))
+	if err != nil {
+		h.handleWatchedFolderError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, folder)
+}
+
+// DeleteFolder stops watching a folder
+// @Summary Delete a watched folder
+// @Description Stop watching a folder. Files left in it are not touched.
+// @Tags ingestion
+// @Param id path string true "Watched folder ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /watched-folders/{id} [delete]
+func (h *WatchedFolderHandler) DeleteFolder(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	folderID, ok := h.ValidateUUID(c, "Watched folder ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.ingestionService.DeleteFolder(c.Request.Context(), userCtx.TenantID, userCtx.UserID, folderID); err != nil {
+		h.handleWatchedFolderError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Helper methods
+
+func (r WatchedFolderRequest) toWatchedFolder(userID uuid.UUID) services.WatchedFolder {
+	folder := services.WatchedFolder{
+		Name:        r.Name,
+		Type:        r.Type,
+		Enabled:     r.Enabled == nil || *r.Enabled,
+		Path:        r.Path,
+		ArchivePath: r.ArchivePath,
+		FailedPath:  r.FailedPath,
+		Host:        r.Host,
+		Port:        r.Port,
+		Username:    r.Username,
+		HostKey:     r.HostKey,
+		FolderID:    r.FolderID,
+		UploaderID:  userID,
+		EnableAI:    r.EnableAI,
+		Sidecar:     r.Sidecar,
+	}
+	if r.UploaderID != nil {
+		folder.UploaderID = *r.UploaderID
+	}
+	return folder
+}
+
+func (h *WatchedFolderHandler) handleWatchedFolderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidWatchedFolder):
+		h.RespondBadRequest(c, err.Error())
+	case errors.Is(err, services.ErrWatchedFolderNotFound):
+		h.RespondNotFound(c, "Watched folder not found")
+	case errors.Is(err, services.ErrFolderNotFound):
+		h.RespondNotFound(c, "Folder not found")
+	case errors.Is(err, services.ErrTenantNotFound):
+		h.RespondNotFound(c, "Tenant not found")
+	case errors.Is(err, services.ErrTooManyWatchedFolders):
+		h.RespondConflict(c, err.Error())
+	case errors.Is(err, services.ErrFolderIngestionDisabled):
+		h.RespondError(c, http.StatusConflict, "ingestion_disabled", err.Error())
+	default:
+		h.RespondInternalError(c, "Failed to process watched folder request", err.Error())
+	}
+}
@@ -0,0 +1,585 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WebhookHandler handles tenant webhook management
+type WebhookHandler struct {
+	*BaseHandler
+	webhookService *services.WebhookService
+	userService    *services.UserService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *services.WebhookService, userService *services.UserService) *WebhookHandler {
+	return &WebhookHandler{
+		BaseHandler:    NewBaseHandler(),
+		webhookService: webhookService,
+		userService:    userService,
+	}
+}
+
+// RegisterRoutes sets up the webhook management routes
+func (h *WebhookHandler) RegisterRoutes(router *gin.RouterGroup) {
+	webhooks := router.Group("/webhooks")
+	// Note: Auth middleware should be applied at server level
+	{
+		webhooks.GET("", h.ListWebhooks)
+		webhooks.POST("", h.CreateWebhook)
+		webhooks.GET("/events", h.ListEventTypes)
+		webhooks.GET("/:id", h.GetWebhook)
+		webhooks.PUT("/:id", h.UpdateWebhook)
+		webhooks.DELETE("/:id", h.DeleteWebhook)
+		webhooks.POST("/:id/rotate-secret", h.RotateSecret)
+		webhooks.POST("/:id/test", h.SendTestEvent)
+		webhooks.GET("/:id/deliveries", h.ListDeliveries)
+		webhooks.GET("/:id/deliveries/:delivery_id", h.GetDelivery)
+		webhooks.POST("/:id/replay", h.ReplayDeliveries)
+	}
+}
+
+// Request/Response DTOs
+
+// CreateWebhookRequest contains webhook creation data
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required,max=500"`
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events" binding:"required,min=1"`
+}
+
+// UpdateWebhookRequest contains webhook changes; omitted fields are unchanged
+type UpdateWebhookRequest struct {
+	URL         *string  `json:"url,omitempty" binding:"omitempty,max=500"`
+	Description *string  `json:"description,omitempty" binding:"omitempty,max=255"`
+	Events      []string `json:"events,omitempty"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
+
+// WebhookResponse represents a webhook in API responses
+type WebhookResponse struct {
+	ID             uuid.UUID `json:"id"`
+	URL            string    `json:"url"`
+	Description    string    `json:"description,omitempty"`
+	Events         []string  `json:"events"`
+	IsActive       bool      `json:"is_active"`
+	CreatedBy      uuid.UUID `json:"created_by"`
+	LastDeliveryAt *string   `json:"last_delivery_at,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	FailureCount   int       `json:"failure_count"`
+	CreatedAt      string    `json:"created_at"`
+	UpdatedAt      string    `json:"updated_at"`
+}
+
+// WebhookSecretResponse is returned when a webhook is created or its secret rotated
+type WebhookSecretResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+// WebhookDeliveryResponse represents a webhook delivery
+type WebhookDeliveryResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	EventID        uuid.UUID  `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	ReplayOf       *uuid.UUID `json:"replay_of,omitempty"`
+	CreatedAt      string     `json:"created_at,omitempty"`
+	DeliveredAt    *string    `json:"delivered_at,omitempty"`
+}
+
+// WebhookDeliveryLogResponse is a delivery with its logged attempts
+type WebhookDeliveryLogResponse struct {
+	WebhookDeliveryResponse
+	AttemptLog []models.WebhookDeliveryAttempt `json:"attempt_log"`
+}
+
+// ReplayWebhookRequest selects deliveries to re-send: by ID, or by status,
+// event type and time range (from is required then)
+type ReplayWebhookRequest struct {
+	DeliveryIDs []uuid.UUID `json:"delivery_ids"`
+	Status      string      `json:"status,omitempty"`
+	EventType   string      `json:"event_type,omitempty"`
+	From        *time.Time  `json:"from,omitempty"`
+	To          *time.Time  `json:"to,omitempty"`
+}
+
+// Handler Methods
+
+// ListWebhooks lists the tenant's webhooks
+// @Summary List webhooks
+// @Description List the tenant's webhooks and their delivery health
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} WebhookResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list webhooks", err.Error())
+		return
+	}
+
+	response := make([]WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		response = append(response, convertToWebhookResponse(&webhooks[i]))
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// CreateWebhook creates a webhook
+// @Summary Create webhook
+// @Description Subscribe an HTTPS endpoint to events. The signing secret is only returned once.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body CreateWebhookRequest true "Webhook data"
+// @Success 201 {object} WebhookSecretResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	webhook, secret, err := h.webhookService.CreateWebhook(c.Request.Context(), services.CreateWebhookParams{
+		TenantID:    userCtx.TenantID,
+		CreatedBy:   userCtx.UserID,
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      req.Events,
+	})
+	if err != nil {
+		h.respondWebhookError(c, err, "Failed to create webhook")
+		return
+	}
+
+	h.RespondCreated(c, WebhookSecretResponse{
+		WebhookResponse: convertToWebhookResponse(webhook),
+		Secret:          secret,
+	})
+}
+
+// ListEventTypes lists the events a webhook can subscribe to
+// @Summary List webhook event types
+// @Description List the event types a webhook can subscribe to; "*" subscribes to all of them
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} string
+// @Router /webhooks/events [get]
+func (h *WebhookHandler) ListEventTypes(c *gin.Context) {
+	h.RespondSuccess(c, services.WebhookEventCatalog)
+}
+
+// GetWebhook returns a webhook
+// @Summary Get webhook
+// @Description Get a webhook's subscriptions and delivery health
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} WebhookResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.ValidateUUID(c, "Webhook ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(c.Request.Context(), webhookID, userCtx.TenantID)
+	if err != nil {
+		h.respondWebhookError(c, err, "Failed to get webhook")
+		return
+	}
+
+	h.RespondSuccess(c, convertToWebhookResponse(webhook))
+}
+
+// UpdateWebhook changes a webhook
+// @Summary Update webhook
+// @Description Change a webhook's URL, description, events or active flag. Re-activating resets its failure count.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param request body UpdateWebhookRequest true "Webhook changes"
+// @Success 200 {object} WebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.ValidateUUID(c, "Webhook ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(c.Request.Context(), webhookID, userCtx.TenantID, userCtx.UserID, services.UpdateWebhookParams{
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      req.Events,
+		IsActive:    req.IsActive,
+	})
+	if err != nil {
+		h.respondWebhookError(c, err, "Failed to update webhook")
+		return
+	}
+
+	h.RespondSuccess(c, convertToWebhookResponse(webhook))
+}
+
+// DeleteWebhook deletes a webhook
+// @Summary Delete webhook
+// @Description Delete a webhook and drop its undelivered events
+// @Tags webhooks
+// @Param id path string true "Webhook ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.ValidateUUID(c, "Webhook ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), webhookID, userCtx.TenantID, userCtx.UserID); err != nil {
+		h.respondWebhookError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RotateSecret issues a new signing secret for a webhook
+// @Summary Rotate webhook secret
+// @Description Replace a webhook's signing secret. Deliveries are signed with the new secret immediately.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} WebhookSecretResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id}/rotate-secret [post]
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.ValidateUUID(c, "Webhook ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	webhook, secret, err := h.webhookService.RotateSecret(c.Request.Context(), webhookID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		h.respondWebhookError(c, err, "Failed to rotate webhook secret")
+		return
+	}
+
+	h.RespondSuccess(c, WebhookSecretResponse{
+		WebhookResponse: convertToWebhookResponse(webhook),
+		Secret:          secret,
+	})
+}
+
+// SendTestEvent queues a ping event for a webhook
+// @Summary Send test event
+// @Description Queue a webhook.ping event for this webhook only
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 202 {object} WebhookDeliveryResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id}/test [post]
+func (h *WebhookHandler) SendTestEvent(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.ValidateUUID(c, "Webhook ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.SendTestEvent(c.Request.Context(), webhookID, userCtx.TenantID)
+	if err != nil {
+		h.respondWebhookError(c, err, "Failed to send test event")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, WebhookDeliveryResponse{
+		ID:        delivery.ID,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Status:    string(delivery.Status),
+	})
+}
+
+// ListDeliveries lists a webhook's delivery log
+// @Summary List webhook deliveries
+// @Description List a webhook's deliveries, newest first, with their status, attempts and last response. Filter by status, event type and creation time.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param status query string false "pending, succeeded or failed"
+// @Param event_type query string false "Event type"
+// @Param from query string false "Created at or after (RFC 3339)"
+// @Param to query string false "Created before (RFC 3339)"
+// @Param page query int false "Page number"
+// @Param per_page query int false "Page size"
+// @Success 200 {object} PaginatedResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.ValidateUUID(c, "Webhook ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	filters := repositories.WebhookDeliveryFilters{
+		Status:    models.WebhookDeliveryStatus(c.Query("status")),
+		EventType: c.Query("event_type"),
+	}
+	for name, target := range map[string]**time.Time{"from": &filters.From, "to": &filters.To} {
+		if raw := c.Query(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				h.RespondBadRequest(c, name+" must be an RFC 3339 time", "")
+				return
+			}
+			*target = &parsed
+		}
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	deliveries, total, err := h.webhookService.ListDeliveries(c.Request.Context(), webhookID, userCtx.TenantID, filters, repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		h.respondWebhookError(c, err, "Failed to list webhook deliveries")
+		return
+	}
+
+	response := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		response = append(response, convertToWebhookDeliveryResponse(&deliveries[i]))
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       response,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// GetDelivery returns a delivery with its attempt log
+// @Summary Get webhook delivery
+// @Description Get a delivery and every attempt at it: response status, latency, error, the start of the response body, and a snapshot of the payload with secrets and personal data redacted
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} WebhookDeliveryLogResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /webhooks/{id}/deliveries/{delivery_id} [get]
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.ValidateUUID(c, "Webhook ID", c.Param("id"))
+	if !ok {
+		return
+	}
+	deliveryID, ok := h.ValidateUUID(c, "Delivery ID", c.Param("delivery_id"))
+	if !ok {
+		return
+	}
+
+	delivery, attempts, err := h.webhookService.GetDelivery(c.Request.Context(), webhookID, deliveryID, userCtx.TenantID)
+	if err != nil {
+		h.respondWebhookError(c, err, "Failed to get webhook delivery")
+		return
+	}
+
+	h.RespondSuccess(c, WebhookDeliveryLogResponse{
+		WebhookDeliveryResponse: convertToWebhookDeliveryResponse(delivery),
+		AttemptLog:              attempts,
+	})
+}
+
+// ReplayDeliveries re-sends selected deliveries
+// @Summary Replay webhook deliveries
+// @Description Queue deliveries again, e.g. after the receiving system recovers from an outage. Select up to 500 deliveries by ID, or by status, event type and a time range starting at from. Replays keep the original event ID; pending deliveries are skipped.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param request body ReplayWebhookRequest true "Deliveries to replay"
+// @Success 202 {array} WebhookDeliveryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /webhooks/{id}/replay [post]
+func (h *WebhookHandler) ReplayDeliveries(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := h.ValidateUUID(c, "Webhook ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ReplayWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	replays, err := h.webhookService.ReplayDeliveries(c.Request.Context(), webhookID, userCtx.TenantID, userCtx.UserID, services.ReplayWebhookParams{
+		DeliveryIDs: req.DeliveryIDs,
+		Filters: repositories.WebhookDeliveryFilters{
+			Status:    models.WebhookDeliveryStatus(req.Status),
+			EventType: req.EventType,
+			From:      req.From,
+			To:        req.To,
+		},
+	})
+	if err != nil {
+		h.respondWebhookError(c, err, "Failed to replay webhook deliveries")
+		return
+	}
+
+	response := make([]WebhookDeliveryResponse, 0, len(replays))
+	for i := range replays {
+		response = append(response, convertToWebhookDeliveryResponse(&replays[i]))
+	}
+	c.JSON(http.StatusAccepted, response)
+}
+
+// Helper Methods
+
+func (h *WebhookHandler) respondWebhookError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		h.RespondNotFound(c, "Webhook not found")
+	case errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		h.RespondNotFound(c, err.Error())
+	case errors.Is(err, services.ErrWebhookInactive):
+		h.RespondConflict(c, "Webhook is inactive; activate it before replaying deliveries")
+	case errors.Is(err, services.ErrInvalidWebhookURL), errors.Is(err, services.ErrWebhookEventRequired),
+		errors.Is(err, services.ErrUnknownWebhookEvent), errors.Is(err, services.ErrInvalidWebhookReplay):
+		h.RespondBadRequest(c, err.Error())
+	default:
+		h.RespondInternalError(c, message, err.Error())
+	}
+}
+
+// Conversion functions
+
+func convertToWebhookResponse(webhook *models.Webhook) WebhookResponse {
+	events := make([]string, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, event.EventType)
+	}
+
+	response := WebhookResponse{
+		ID:           webhook.ID,
+		URL:          webhook.URL,
+		Description:  webhook.Description,
+		Events:       events,
+		IsActive:     webhook.IsActive,
+		CreatedBy:    webhook.CreatedBy,
+		LastError:    webhook.LastError,
+		FailureCount: webhook.FailureCount,
+		CreatedAt:    webhook.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:    webhook.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+
+	if webhook.LastDeliveryAt != nil {
+		lastDeliveryAt := webhook.LastDeliveryAt.Format("2006-01-02T15:04:05Z")
+		response.LastDeliveryAt = &lastDeliveryAt
+	}
+
+	return response
+}
+
+func convertToWebhookDeliveryResponse(delivery *models.WebhookDelivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		ReplayOf:       delivery.ReplayOf,
+		CreatedAt:      delivery.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+
+	if delivery.DeliveredAt != nil {
+		deliveredAt := delivery.DeliveredAt.Format("2006-01-02T15:04:05Z")
+		response.DeliveredAt = &deliveredAt
+	}
+
+	return response
+}
@@ -0,0 +1,1059 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WorkflowHandler handles workflow templates, their runs and the approval
+// tasks they assign
+type WorkflowHandler struct {
+	*BaseHandler
+	workflowService *services.WorkflowService
+}
+
+// NewWorkflowHandler creates a new workflow handler
+func NewWorkflowHandler(workflowService *services.WorkflowService) *WorkflowHandler {
+	return &WorkflowHandler{
+		BaseHandler:     NewBaseHandler(),
+		workflowService: workflowService,
+	}
+}
+
+// RegisterRoutes sets up the workflow routes
+func (h *WorkflowHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Note: Auth middleware should be applied at server level
+	workflows := router.Group("/workflows")
+	{
+		workflows.GET("", h.ListWorkflows)
+		workflows.POST("", h.CreateWorkflow)
+		workflows.GET("/:id", h.GetWorkflow)
+		workflows.PUT("/:id", h.UpdateWorkflow)
+		workflows.DELETE("/:id", h.DeleteWorkflow)
+		workflows.GET("/:id/versions", h.ListWorkflowVersions)
+		workflows.GET("/:id/versions/:version", h.GetWorkflowVersion)
+		workflows.GET("/delegation-report", h.GetDelegationReport)
+	}
+
+	tasks := router.Group("/workflow-tasks")
+	{
+		tasks.GET("", h.ListMyTasks)
+		tasks.POST("/bulk", h.BulkTaskAction)
+		tasks.GET("/out-of-office", h.GetOutOfOffice)
+		tasks.PUT("/out-of-office", h.SetOutOfOffice)
+		tasks.DELETE("/out-of-office", h.CancelOutOfOffice)
+		tasks.POST("/:id/approve", h.ApproveTask)
+		tasks.POST("/:id/reject", h.RejectTask)
+		tasks.POST("/:id/delegate", h.DelegateTask)
+		tasks.GET("/:id/checklist", h.GetTaskChecklist)
+		tasks.PUT("/:id/checklist/:key", h.UpdateChecklistItem)
+	}
+
+	instances := router.Group("/workflow-instances")
+	{
+		instances.GET("", h.ListInstances)
+		instances.GET("/:id", h.GetInstanceTimeline)
+	}
+
+	docs := router.Group("/documents")
+	{
+		docs.POST("/:id/workflows/trigger", h.TriggerWorkflow)
+		docs.GET("/:id/workflow-history", h.GetDocumentWorkflowHistory)
+		docs.GET("/:id/workflow-evidence", h.GetEvidencePackage)
+		docs.GET("/:id/workflow-instances", h.ListDocumentInstances)
+	}
+}
+
+// Request/Response DTOs
+
+// WorkflowRequest contains a workflow template
+type WorkflowRequest struct {
+	Name         string                 `json:"name" binding:"required,min=1,max=255"`
+	Description  string                 `json:"description"`
+	DocumentType models.DocumentType    `json:"document_type" binding:"required"`
+	Rules        services.WorkflowRules `json:"rules" binding:"required"`
+	IsActive     *bool                  `json:"is_active"`
+}
+
+// UpdateWorkflowRequest contains workflow template changes; omitted fields are kept
+type UpdateWorkflowRequest struct {
+	Name         *string                 `json:"name" binding:"omitempty,min=1,max=255"`
+	Description  *string                 `json:"description"`
+	DocumentType *models.DocumentType    `json:"document_type"`
+	Rules        *services.WorkflowRules `json:"rules"`
+	IsActive     *bool                   `json:"is_active"`
+
+	// What happens to runs in progress when rules change: "finish" (default)
+	// keeps them on their version, "migrate" moves them to the new one
+	MigrationPolicy models.WorkflowMigrationPolicy `json:"migration_policy" binding:"omitempty,oneof=finish migrate"`
+}
+
+// TaskDecisionRequest contains the reviewer's comments on a task
+type TaskDecisionRequest struct {
+	Comments string `json:"comments"`
+}
+
+// DelegateTaskRequest hands a task over to another user
+type DelegateTaskRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// BulkTaskActionRequest applies one decision to several tasks
+type BulkTaskActionRequest struct {
+	Action     string   `json:"action" binding:"required,oneof=approve reject delegate"`
+	TaskIDs    []string `json:"task_ids" binding:"required,min=1"`
+	Comments   string   `json:"comments"`              // Shared by every approval or rejection
+	DelegateTo string   `json:"delegate_to,omitempty"` // Required to delegate
+	Reason     string   `json:"reason,omitempty"`
+}
+
+// BulkTaskActionResponse lists the outcome for each task, in request order
+type BulkTaskActionResponse struct {
+	Action    string                  `json:"action"`
+	Succeeded int                     `json:"succeeded"`
+	Failed    int                     `json:"failed"`
+	Results   []BulkTaskResultElement `json:"results"`
+}
+
+// BulkTaskResultElement is the outcome of a bulk action on one task
+type BulkTaskResultElement struct {
+	TaskID  uuid.UUID `json:"task_id"`
+	Success bool      `json:"success"`
+	Code    string    `json:"code,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+// ChecklistItemRequest ticks or unticks a checklist item
+type ChecklistItemRequest struct {
+	Checked bool   `json:"checked"`
+	Note    string `json:"note"`
+}
+
+// OutOfOfficeRequest marks the user away; starts_at defaults to now
+type OutOfOfficeRequest struct {
+	DelegateID string     `json:"delegate_id" binding:"required"`
+	StartsAt   *time.Time `json:"starts_at"`
+	EndsAt     time.Time  `json:"ends_at" binding:"required"`
+	Note       string     `json:"note" binding:"max=255"`
+}
+
+// Handler Methods
+
+// ListInstances lists the tenant's workflow runs
+// @Summary List workflow instances
+// @Description List the tenant's workflow runs, newest first, optionally filtered by status (pending, approved, rejected, cancelled)
+// @Tags workflows
+// @Produce json
+// @Param status query string false "Instance status"
+// @Param page query int false "Page number"
+// @Param per_page query int false "Page size"
+// @Success 200 {object} PaginatedResponse
+// @Router /workflow-instances [get]
+func (h *WorkflowHandler) ListInstances(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := h.ParsePagination(c)
+	params := repositories.ListParams{
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	instances, total, err := h.workflowService.ListInstances(c.Request.Context(), userCtx.TenantID, models.WorkflowStatus(c.Query("status")), params)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list workflow instances", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       instances,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// GetInstanceTimeline returns a workflow run with the state of each step
+// @Summary Get workflow instance timeline
+// @Description Get a workflow run, the step it is on and each step's status, timestamps and tasks
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Instance ID"
+// @Success 200 {object} services.WorkflowInstanceTimeline
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /workflow-instances/{id} [get]
+func (h *WorkflowHandler) GetInstanceTimeline(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	instanceID, ok := h.ValidateUUID(c, "Instance ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	timeline, err := h.workflowService.GetInstanceTimeline(c.Request.Context(), userCtx.TenantID, instanceID)
+	if err != nil {
+		if errors.Is(err, services.ErrInstanceNotFound) {
+			h.RespondNotFound(c, "Workflow instance not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to get workflow instance", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, timeline)
+}
+
+// ListDocumentInstances lists the workflow runs of a document
+// @Summary List document workflow instances
+// @Description List the workflow runs of a document, newest first
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {array} models.WorkflowInstance
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/workflow-instances [get]
+func (h *WorkflowHandler) ListDocumentInstances(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "Document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	instances, err := h.workflowService.ListDocumentInstances(c.Request.Context(), userCtx.TenantID, documentID)
+	if err != nil {
+		if errors.Is(err, services.ErrDocumentNotFound) {
+			h.RespondNotFound(c, "Document not found")
+			return
+		}
+		h.RespondInternalError(c, "Failed to list workflow instances", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, instances)
+}
+
+// ListWorkflows lists the tenant's workflow templates
+// @Summary List workflows
+// @Description List the tenant's workflow templates
+// @Tags workflows
+// @Produce json
+// @Success 200 {array} models.Workflow
+// @Router /workflows [get]
+func (h *WorkflowHandler) ListWorkflows(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	workflows, err := h.workflowService.ListWorkflows(c.Request.Context(), userCtx.TenantID)
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list workflows", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, workflows)
+}
+
+// CreateWorkflow creates a workflow template
+// @Summary Create workflow
+// @Description Create a workflow template that runs on documents of a type when its trigger conditions match
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Param request body WorkflowRequest true "Workflow"
+// @Success 201 {object} models.Workflow
+// @Failure 400 {object} ErrorResponse
+// @Router /workflows [post]
+func (h *WorkflowHandler) CreateWorkflow(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req WorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	workflow, err := h.workflowService.CreateWorkflow(c.Request.Context(), services.CreateWorkflowParams{
+		TenantID:     userCtx.TenantID,
+		CreatedBy:    userCtx.UserID,
+		Name:         req.Name,
+		Description:  req.Description,
+		DocumentType: req.DocumentType,
+		Rules:        req.Rules,
+		IsActive:     isActive,
+	})
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondCreated(c, workflow)
+}
+
+// GetWorkflow returns a workflow template
+// @Summary Get workflow
+// @Description Get a workflow template and its rules
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} models.Workflow
+// @Failure 404 {object} ErrorResponse
+// @Router /workflows/{id} [get]
+func (h *WorkflowHandler) GetWorkflow(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	workflowID, ok := h.ValidateUUID(c, "Workflow ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	workflow, err := h.workflowService.GetWorkflow(c.Request.Context(), userCtx.TenantID, workflowID)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, workflow)
+}
+
+// UpdateWorkflow changes a workflow template
+// @Summary Update workflow
+// @Description Change a workflow template. Changing the rules creates a new version that new runs use. With migration_policy "finish" (default) runs in progress keep the rules they started with; with "migrate" they continue from their current step under the new rules, unless that step was removed.
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Param request body UpdateWorkflowRequest true "Workflow changes"
+// @Success 200 {object} models.Workflow
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /workflows/{id} [put]
+func (h *WorkflowHandler) UpdateWorkflow(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	workflowID, ok := h.ValidateUUID(c, "Workflow ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req UpdateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	workflow, err := h.workflowService.UpdateWorkflow(c.Request.Context(), services.UpdateWorkflowParams{
+		TenantID:     userCtx.TenantID,
+		WorkflowID:   workflowID,
+		UpdatedBy:    userCtx.UserID,
+		Name:         req.Name,
+		Description:  req.Description,
+		DocumentType: req.DocumentType,
+		Rules:        req.Rules,
+		IsActive:     req.IsActive,
+
+		MigrationPolicy: req.MigrationPolicy,
+	})
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, workflow)
+}
+
+// ListWorkflowVersions lists a workflow's rules versions
+// @Summary List workflow versions
+// @Description List every rules version of a workflow, newest first, with the migration policy each change used
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {array} models.WorkflowVersion
+// @Failure 404 {object} ErrorResponse
+// @Router /workflows/{id}/versions [get]
+func (h *WorkflowHandler) ListWorkflowVersions(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	workflowID, ok := h.ValidateUUID(c, "Workflow ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	versions, err := h.workflowService.ListWorkflowVersions(c.Request.Context(), userCtx.TenantID, workflowID)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, versions)
+}
+
+// GetWorkflowVersion returns one rules version of a workflow
+// @Summary Get workflow version
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} models.WorkflowVersion
+// @Failure 404 {object} ErrorResponse
+// @Router /workflows/{id}/versions/{version} [get]
+func (h *WorkflowHandler) GetWorkflowVersion(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	workflowID, ok := h.ValidateUUID(c, "Workflow ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		h.RespondBadRequest(c, "Invalid version number", "")
+		return
+	}
+
+	workflowVersion, err := h.workflowService.GetWorkflowVersion(c.Request.Context(), userCtx.TenantID, workflowID, version)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, workflowVersion)
+}
+
+// DeleteWorkflow deletes a workflow template
+// @Summary Delete workflow
+// @Description Delete a workflow template. Workflows with pending tasks can't be deleted; deactivate them instead.
+// @Tags workflows
+// @Param id path string true "Workflow ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /workflows/{id} [delete]
+func (h *WorkflowHandler) DeleteWorkflow(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	workflowID, ok := h.ValidateUUID(c, "Workflow ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := h.workflowService.DeleteWorkflow(c.Request.Context(), userCtx.TenantID, workflowID, userCtx.UserID); err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TriggerWorkflow starts the matching workflows on a document
+// @Summary Trigger workflows on a document
+// @Description Start every active workflow for the document's type whose trigger conditions the document meets
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {array} models.WorkflowInstance
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/workflows/trigger [post]
+func (h *WorkflowHandler) TriggerWorkflow(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "Document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	instances, err := h.workflowService.TriggerWorkflow(c.Request.Context(), documentID, userCtx.TenantID, userCtx.UserID)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, instances)
+}
+
+// ListMyTasks lists the workflow tasks assigned to the current user
+// @Summary List my tasks
+// @Description List the workflow tasks assigned to the current user, optionally filtered by status (pending, approved, rejected, cancelled)
+// @Tags workflows
+// @Produce json
+// @Param status query string false "Task status"
+// @Success 200 {array} models.WorkflowTask
+// @Router /workflow-tasks [get]
+func (h *WorkflowHandler) ListMyTasks(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.workflowService.GetUserTasks(c.Request.Context(), userCtx.UserID, models.WorkflowStatus(c.Query("status")))
+	if err != nil {
+		h.RespondInternalError(c, "Failed to list tasks", err.Error())
+		return
+	}
+
+	h.RespondSuccess(c, tasks)
+}
+
+// ApproveTask approves a workflow task
+// @Summary Approve task
+// @Description Approve a workflow task assigned to the current user. Required checklist items must be ticked first.
+// @Tags workflows
+// @Accept json
+// @Param id path string true "Task ID"
+// @Param request body TaskDecisionRequest false "Comments"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /workflow-tasks/{id}/approve [post]
+func (h *WorkflowHandler) ApproveTask(c *gin.Context) {
+	h.completeTask(c, "approve")
+}
+
+// RejectTask rejects a workflow task
+// @Summary Reject task
+// @Description Reject a workflow task assigned to the current user
+// @Tags workflows
+// @Accept json
+// @Param id path string true "Task ID"
+// @Param request body TaskDecisionRequest false "Comments"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /workflow-tasks/{id}/reject [post]
+func (h *WorkflowHandler) RejectTask(c *gin.Context) {
+	h.completeTask(c, "reject")
+}
+
+// DelegateTask hands a workflow task over to another user
+// @Summary Delegate task
+// @Description Hand a pending task over to another user of the tenant, if the workflow step allows delegation
+// @Tags workflows
+// @Accept json
+// @Param id path string true "Task ID"
+// @Param request body DelegateTaskRequest true "Delegate"
+// @Success 200 {object} SuccessResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /workflow-tasks/{id}/delegate [post]
+func (h *WorkflowHandler) DelegateTask(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	taskID, ok := h.ValidateUUID(c, "Task ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req DelegateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	toUserID, ok := h.ValidateUUID(c, "User ID", req.UserID)
+	if !ok {
+		return
+	}
+
+	if err := h.workflowService.DelegateTask(c.Request.Context(), taskID, userCtx.TenantID, userCtx.UserID, toUserID, req.Reason); err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, gin.H{"message": "Task delegated"})
+}
+
+// BulkTaskAction approves, rejects or delegates several tasks at once
+// @Summary Bulk task action
+// @Description Approve, reject or delegate up to 100 tasks in one call. Each task is checked on its own as a single action would be, so one failing task doesn't stop the others; the response lists the outcome of every task.
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Param request body BulkTaskActionRequest true "Bulk action"
+// @Success 200 {object} BulkTaskActionResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /workflow-tasks/bulk [post]
+func (h *WorkflowHandler) BulkTaskAction(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req BulkTaskActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	params := services.BulkTaskActionParams{
+		TenantID: userCtx.TenantID,
+		UserID:   userCtx.UserID,
+		Action:   req.Action,
+		Comments: req.Comments,
+		Reason:   req.Reason,
+	}
+	for _, id := range req.TaskIDs {
+		taskID, ok := h.ValidateUUID(c, "Task ID", id)
+		if !ok {
+			return
+		}
+		params.TaskIDs = append(params.TaskIDs, taskID)
+	}
+	if req.Action == services.BulkTaskDelegate {
+		delegateTo, ok := h.ValidateUUID(c, "Delegate user ID", req.DelegateTo)
+		if !ok {
+			return
+		}
+		params.DelegateTo = delegateTo
+	}
+
+	result, err := h.workflowService.BulkTaskAction(c.Request.Context(), params)
+	if err != nil {
+		if errors.Is(err, services.ErrBulkTaskEmpty) || errors.Is(err, services.ErrBulkTaskTooLarge) {
+			h.RespondBadRequest(c, err.Error(), "")
+			return
+		}
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	response := BulkTaskActionResponse{
+		Action:    result.Action,
+		Succeeded: result.Succeeded,
+		Failed:    result.Failed,
+		Results:   make([]BulkTaskResultElement, 0, len(result.Outcomes)),
+	}
+	for _, outcome := range result.Outcomes {
+		element := BulkTaskResultElement{TaskID: outcome.TaskID, Success: outcome.Err == nil}
+		if outcome.Err != nil {
+			element.Code, element.Message = bulkTaskError(outcome.Err)
+		}
+		response.Results = append(response.Results, element)
+	}
+
+	h.RespondSuccess(c, response)
+}
+
+// GetTaskChecklist returns the checklist of a workflow task
+// @Summary Get task checklist
+// @Description Get the checklist items of a workflow task and whether they are ticked
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {array} models.WorkflowChecklistItem
+// @Failure 404 {object} ErrorResponse
+// @Router /workflow-tasks/{id}/checklist [get]
+func (h *WorkflowHandler) GetTaskChecklist(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	taskID, ok := h.ValidateUUID(c, "Task ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	items, err := h.workflowService.GetTaskChecklist(c.Request.Context(), taskID, userCtx.TenantID)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, items)
+}
+
+// UpdateChecklistItem ticks or unticks a checklist item of a workflow task
+// @Summary Update task checklist item
+// @Description Tick or untick a checklist item of a pending task assigned to the current user
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param key path string true "Checklist item key"
+// @Param request body ChecklistItemRequest true "Checklist item"
+// @Success 200 {object} models.WorkflowChecklistItem
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /workflow-tasks/{id}/checklist/{key} [put]
+func (h *WorkflowHandler) UpdateChecklistItem(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	taskID, ok := h.ValidateUUID(c, "Task ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	item, err := h.workflowService.UpdateChecklistItem(c.Request.Context(), services.UpdateChecklistItemParams{
+		TenantID:  userCtx.TenantID,
+		TaskID:    taskID,
+		ItemKey:   c.Param("key"),
+		UpdatedBy: userCtx.UserID,
+		Checked:   req.Checked,
+		Note:      req.Note,
+	})
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, item)
+}
+
+// GetDocumentWorkflowHistory returns the workflow tasks of a document
+// @Summary Get document workflow history
+// @Description Get every workflow task of a document with who decided it, when and why
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {array} models.WorkflowTask
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/workflow-history [get]
+func (h *WorkflowHandler) GetDocumentWorkflowHistory(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "Document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	tasks, err := h.workflowService.GetDocumentWorkflow(c.Request.Context(), documentID, userCtx.TenantID)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, tasks)
+}
+
+// GetEvidencePackage returns the approval evidence of a document
+// @Summary Get document workflow evidence
+// @Description Get the document's workflow tasks with their checklists for audits
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {object} services.WorkflowEvidencePackage
+// @Failure 404 {object} ErrorResponse
+// @Router /documents/{id}/workflow-evidence [get]
+func (h *WorkflowHandler) GetEvidencePackage(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	documentID, ok := h.ValidateUUID(c, "Document ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	evidence, err := h.workflowService.GetEvidencePackage(c.Request.Context(), documentID, userCtx.TenantID)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, evidence)
+}
+
+// GetOutOfOffice returns the user's out-of-office period
+// @Summary Get out of office
+// @Description Get the user's out-of-office period in progress, or the next one
+// @Tags workflows
+// @Produce json
+// @Success 200 {object} models.OutOfOffice
+// @Failure 404 {object} ErrorResponse
+// @Router /workflow-tasks/out-of-office [get]
+func (h *WorkflowHandler) GetOutOfOffice(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	period, err := h.workflowService.GetOutOfOffice(c.Request.Context(), userCtx.UserID)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, period)
+}
+
+// SetOutOfOffice marks the user away
+// @Summary Set out of office
+// @Description Mark the user away for a period of up to a year. Approval tasks created for them meanwhile go to the delegate, an active user of the tenant, and are recorded as out-of-office delegations. Replaces the period in progress or upcoming.
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Param request body OutOfOfficeRequest true "Out-of-office period"
+// @Success 200 {object} models.OutOfOffice
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /workflow-tasks/out-of-office [put]
+func (h *WorkflowHandler) SetOutOfOffice(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req OutOfOfficeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.RespondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	delegateID, ok := h.ValidateUUID(c, "Delegate ID", req.DelegateID)
+	if !ok {
+		return
+	}
+
+	params := services.SetOutOfOfficeParams{
+		TenantID:   userCtx.TenantID,
+		UserID:     userCtx.UserID,
+		DelegateID: delegateID,
+		EndsAt:     req.EndsAt,
+		Note:       req.Note,
+	}
+	if req.StartsAt != nil {
+		params.StartsAt = *req.StartsAt
+	}
+
+	period, err := h.workflowService.SetOutOfOffice(c.Request.Context(), params)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, period)
+}
+
+// CancelOutOfOffice ends the user's time away
+// @Summary Cancel out of office
+// @Description End the user's out-of-office period now, or cancel it if it has not started
+// @Tags workflows
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /workflow-tasks/out-of-office [delete]
+func (h *WorkflowHandler) CancelOutOfOffice(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.workflowService.CancelOutOfOffice(c.Request.Context(), userCtx.TenantID, userCtx.UserID); err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetDelegationReport summarizes delegation activity for internal controls reviews
+// @Summary Get delegation report
+// @Description Who delegated approval tasks to whom and how often, by manual hand-over or out of office, how the delegated tasks ended (approved, rejected, pending, cancelled, escalated), and each user's out-of-office days and tasks handed over. Defaults to the current month; covers at most a year.
+// @Tags workflows
+// @Produce json
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date, exclusive (YYYY-MM-DD)"
+// @Success 200 {object} services.DelegationReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /workflows/delegation-report [get]
+func (h *WorkflowHandler) GetDelegationReport(c *gin.Context) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			h.RespondBadRequest(c, "Invalid from date, expected YYYY-MM-DD")
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			h.RespondBadRequest(c, "Invalid to date, expected YYYY-MM-DD")
+			return
+		}
+		to = parsed
+	}
+
+	report, err := h.workflowService.DelegationReport(c.Request.Context(), userCtx.TenantID, from, to)
+	if err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, report)
+}
+
+// Helper Methods
+
+func (h *WorkflowHandler) completeTask(c *gin.Context, action string) {
+	userCtx, ok := h.AuthenticateUser(c)
+	if !ok {
+		return
+	}
+
+	taskID, ok := h.ValidateUUID(c, "Task ID", c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req TaskDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.RespondBadRequest(c, "Invalid request format", err.Error())
+			return
+		}
+	}
+
+	if err := h.workflowService.CompleteTask(c.Request.Context(), taskID, userCtx.TenantID, userCtx.UserID, action, req.Comments); err != nil {
+		h.handleWorkflowError(c, err)
+		return
+	}
+
+	h.RespondSuccess(c, gin.H{"message": "Task " + action + "d"})
+}
+
+// bulkTaskError returns the error code and message reported for one task of
+// a bulk action, matching the single task endpoints
+func bulkTaskError(err error) (string, string) {
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound):
+		return "not_found", "Task not found"
+	case errors.Is(err, services.ErrWorkflowNotFound):
+		return "not_found", "Workflow not found"
+	case errors.Is(err, services.ErrUserNotFound):
+		return "not_found", "User not found"
+	case errors.Is(err, services.ErrUnauthorizedTask):
+		return "unauthorized_task", "You can't act on this task"
+	case errors.Is(err, services.ErrDelegationNotAllowed):
+		return "delegation_not_allowed", "Delegation is not allowed for this task"
+	case errors.Is(err, services.ErrDelegateAlreadyVotes):
+		return "delegate_already_votes", err.Error()
+	case errors.Is(err, services.ErrTaskAlreadyCompleted):
+		return "task_already_completed", "Task is already completed"
+	case errors.Is(err, services.ErrChecklistIncomplete):
+		return "checklist_incomplete", err.Error()
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "not_attempted", "The request ended before this task was processed"
+	default:
+		return "internal_error", "Failed to process task"
+	}
+}
+
+func (h *WorkflowHandler) handleWorkflowError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrWorkflowNotFound):
+		h.RespondNotFound(c, "Workflow not found")
+	case errors.Is(err, services.ErrTaskNotFound):
+		h.RespondNotFound(c, "Task not found")
+	case errors.Is(err, services.ErrDocumentNotFound):
+		h.RespondNotFound(c, "Document not found")
+	case errors.Is(err, services.ErrUserNotFound):
+		h.RespondNotFound(c, "User not found")
+	case errors.Is(err, services.ErrChecklistItemMissing):
+		h.RespondNotFound(c, "Checklist item not found")
+	case errors.Is(err, services.ErrOutOfOfficeNotFound):
+		h.RespondNotFound(c, "No out-of-office period set")
+	case errors.Is(err, services.ErrWorkflowVersionGone):
+		h.RespondNotFound(c, "Workflow version not found")
+	case errors.Is(err, services.ErrInvalidWorkflowRules),
+		errors.Is(err, services.ErrInvalidTaskStatus),
+		errors.Is(err, services.ErrInvalidMigration),
+		errors.Is(err, services.ErrInvalidDocumentType),
+		errors.Is(err, services.ErrInvalidOutOfOffice),
+		errors.Is(err, services.ErrSelfDelegation),
+		errors.Is(err, services.ErrInvalidDateRange):
+		h.RespondBadRequest(c, err.Error(), "")
+	case errors.Is(err, services.ErrUnauthorizedTask):
+		h.RespondError(c, http.StatusForbidden, "unauthorized_task", "You can't act on this task", "")
+	case errors.Is(err, services.ErrDelegationNotAllowed):
+		h.RespondError(c, http.StatusForbidden, "delegation_not_allowed", "Delegation is not allowed for this task", "")
+	case errors.Is(err, services.ErrDelegateAlreadyVotes):
+		h.RespondError(c, http.StatusConflict, "delegate_already_votes", err.Error(), "")
+	case errors.Is(err, services.ErrTaskAlreadyCompleted):
+		h.RespondError(c, http.StatusConflict, "task_already_completed", "Task is already completed", "")
+	case errors.Is(err, services.ErrChecklistIncomplete):
+		h.RespondError(c, http.StatusConflict, "checklist_incomplete", err.Error(), "")
+	case errors.Is(err, services.ErrWorkflowEditConflict):
+		h.RespondError(c, http.StatusConflict, "workflow_edit_conflict", err.Error(), "")
+	case errors.Is(err, services.ErrWorkflowInUse):
+		h.RespondError(c, http.StatusConflict, "workflow_in_use", "Workflow has pending tasks; deactivate it instead", "")
+	default:
+		h.RespondInternalError(c, "Failed to process workflow request", err.Error())
+	}
+}
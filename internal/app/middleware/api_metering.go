@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// APIMeter counts API requests per tenant and enforces the tenant's limits
+type APIMeter interface {
+	Meter(ctx context.Context, tenantID uuid.UUID) (*services.APIRateStatus, error)
+}
+
+// unmeteredRoutes stay reachable when a tenant is over its limits, so
+// admins can still see why
+var unmeteredRoutes = map[string]bool{
+	"GET /api/v1/tenant/api-usage": true,
+}
+
+// APIMeteringMiddleware meters authenticated requests against the tenant's
+// rate limit and monthly quota, answering 429 and 402 when they are used up.
+// Requests without a user context (public routes) pass through.
+func APIMeteringMiddleware(meter APIMeter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userCtx := GetUserContext(c)
+		if userCtx == nil || meter == nil || unmeteredRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		status, err := meter.Meter(c.Request.Context(), userCtx.TenantID)
+		if status != nil {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(status.Limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(status.Remaining))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(status.Reset.Unix(), 10))
+		}
+
+		switch {
+		case err == nil:
+			c.Next()
+		case errors.Is(err, services.ErrAPIQuotaExceeded):
+			c.JSON(http.StatusPaymentRequired, gin.H{
+				"error":   "api_quota_exceeded",
+				"message": err.Error(),
+			})
+			c.Abort()
+		default:
+			RespondRateLimited(c, err)
+			c.Abort()
+		}
+	}
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// APIKeyHeader carries API keys for machine-to-machine requests
+const APIKeyHeader = "X-API-Key"
+
 // UserContext holds user information extracted from JWT token
 type UserContext struct {
 	UserID   uuid.UUID       `json:"user_id"`
@@ -17,97 +20,184 @@ type UserContext struct {
 	Email    string          `json:"email"`
 	Role     models.UserRole `json:"role"`
 	IsActive bool            `json:"is_active"`
+
+	// Set when the request was authenticated with an API key
+	APIKeyID *uuid.UUID `json:"api_key_id,omitempty"`
+	Scopes   []string   `json:"scopes,omitempty"`
 }
 
-// AuthMiddleware creates authentication middleware using Supabase
-func AuthMiddleware(authService services.SupabaseAuthService, userService *services.UserService) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Extract token from Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "missing_authorization",
-				"message": "Authorization header is required",
-			})
-			c.Abort()
-			return
+// HasScope reports whether an API key request was granted the permission
+func (u *UserContext) HasScope(permission string) bool {
+	for _, scope := range u.Scopes {
+		if scope == permission {
+			return true
 		}
+	}
+	return false
+}
 
-		// Check Bearer token format
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "invalid_authorization_format",
-				"message": "Authorization header must be in format: Bearer <token>",
-			})
-			c.Abort()
-			return
+// AuthMiddleware creates authentication middleware using Supabase.
+// Requests carrying an API key (X-API-Key header or "Bearer ak_...") are
+// resolved through apiKeyService instead.
+func AuthMiddleware(authService services.SupabaseAuthService, userService *services.UserService, apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticate(c, authService, userService, apiKeyService) {
+			c.Next()
 		}
+	}
+}
 
-		accessToken := tokenParts[1]
+// authenticate resolves the request's user (or API key) into the gin context.
+// On failure it writes the error response, aborts and returns false.
+func authenticate(c *gin.Context, authService services.SupabaseAuthService, userService *services.UserService, apiKeyService *services.APIKeyService) bool {
+	if apiKey := extractAPIKey(c); apiKey != "" && apiKeyService != nil {
+		return authenticateAPIKey(c, apiKeyService, apiKey)
+	}
 
-		// Validate token with Supabase
-		supabaseUser, err := authService.ValidateToken(accessToken)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "invalid_token",
-				"message": "Token validation failed",
-				"details": err.Error(),
-			})
-			c.Abort()
-			return
-		}
+	// Extract token from Authorization header
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "missing_authorization",
+			"message": "Authorization header is required",
+		})
+		c.Abort()
+		return false
+	}
 
-		if supabaseUser == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "invalid_user",
-				"message": "User not found or inactive",
-			})
-			c.Abort()
-			return
-		}
+	// Check Bearer token format
+	tokenParts := strings.Split(authHeader, " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "invalid_authorization_format",
+			"message": "Authorization header must be in format: Bearer <token>",
+		})
+		c.Abort()
+		return false
+	}
 
-		// Get full user details from our database using the validated token
-		user, err := userService.ValidateToken(c.Request.Context(), accessToken)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "user_not_found",
-				"message": "User not found in system",
-				"details": err.Error(),
-			})
-			c.Abort()
-			return
-		}
+	accessToken := tokenParts[1]
+
+	// Validate token with Supabase
+	supabaseUser, err := authService.ValidateToken(accessToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "invalid_token",
+			"message": "Token validation failed",
+			"details": err.Error(),
+		})
+		c.Abort()
+		return false
+	}
 
-		// Check if user is active
-		if !user.IsActive {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "user_inactive",
-				"message": "User account is inactive",
-			})
-			c.Abort()
-			return
-		}
+	if supabaseUser == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "invalid_user",
+			"message": "User not found or inactive",
+		})
+		c.Abort()
+		return false
+	}
 
-		// Create user context
-		userCtx := &UserContext{
-			UserID:   user.ID,
-			TenantID: user.TenantID,
-			Email:    user.Email,
-			Role:     user.Role,
-			IsActive: user.IsActive,
-		}
+	// Get full user details from our database using the validated token
+	user, err := userService.ValidateToken(c.Request.Context(), accessToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "user_not_found",
+			"message": "User not found in system",
+			"details": err.Error(),
+		})
+		c.Abort()
+		return false
+	}
 
-		// Store user context in gin context
-		c.Set("user", userCtx)
-		c.Set("user_id", user.ID)
-		c.Set("tenant_id", user.TenantID)
-		c.Set("user_role", user.Role)
-		c.Set("access_token", accessToken)
+	// Check if user is active
+	if !user.IsActive {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "user_inactive",
+			"message": "User account is inactive",
+		})
+		c.Abort()
+		return false
+	}
 
-		// Continue to next handler
-		c.Next()
+	// Reject revoked sessions and record activity on live ones
+	sessionID, err := userService.TrackSession(c.Request.Context(), user.ID, accessToken, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "session_revoked",
+			"message": "Session has been revoked, please sign in again",
+		})
+		c.Abort()
+		return false
 	}
+
+	// Create user context
+	userCtx := &UserContext{
+		UserID:   user.ID,
+		TenantID: user.TenantID,
+		Email:    user.Email,
+		Role:     user.Role,
+		IsActive: user.IsActive,
+	}
+
+	// Store user context in gin context
+	c.Set("user", userCtx)
+	c.Set("user_id", user.ID)
+	c.Set("tenant_id", user.TenantID)
+	c.Set("user_role", user.Role)
+	c.Set("access_token", accessToken)
+	c.Set("session_id", sessionID)
+
+	return true
+}
+
+// authenticateAPIKey resolves an API key to its tenant and scoped permission set
+func authenticateAPIKey(c *gin.Context, apiKeyService *services.APIKeyService, apiKey string) bool {
+	key, user, err := apiKeyService.Authenticate(c.Request.Context(), apiKey)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "invalid_api_key",
+			"message": "API key is invalid, expired or revoked",
+		})
+		c.Abort()
+		return false
+	}
+
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, scope.Permission)
+	}
+
+	// API keys act on behalf of their creator but carry scopes instead of a role,
+	// so role-based admin checks never pass for key requests
+	userCtx := &UserContext{
+		UserID:   user.ID,
+		TenantID: key.TenantID,
+		Email:    user.Email,
+		IsActive: true,
+		APIKeyID: &key.ID,
+		Scopes:   scopes,
+	}
+
+	c.Set("user", userCtx)
+	c.Set("user_id", user.ID)
+	c.Set("tenant_id", key.TenantID)
+	c.Set("api_key_id", key.ID)
+	return true
+}
+
+// extractAPIKey returns the API key from X-API-Key or an "ak_" bearer token
+func extractAPIKey(c *gin.Context) string {
+	if apiKey := c.GetHeader(APIKeyHeader); apiKey != "" {
+		return apiKey
+	}
+
+	tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(tokenParts) == 2 && tokenParts[0] == "Bearer" && services.IsAPIKey(tokenParts[1]) {
+		return tokenParts[1]
+	}
+	return ""
 }
 
 // OptionalAuthMiddleware allows both authenticated and unauthenticated requests
@@ -142,6 +232,12 @@ func OptionalAuthMiddleware(authService services.SupabaseAuthService, userServic
 			return
 		}
 
+		sessionID, err := userService.TrackSession(c.Request.Context(), user.ID, accessToken, c.ClientIP(), c.GetHeader("User-Agent"))
+		if err != nil {
+			c.Next()
+			return
+		}
+
 		// Store user context if validation succeeds
 		userCtx := &UserContext{
 			UserID:   user.ID,
@@ -156,6 +252,7 @@ func OptionalAuthMiddleware(authService services.SupabaseAuthService, userServic
 		c.Set("tenant_id", user.TenantID)
 		c.Set("user_role", user.Role)
 		c.Set("access_token", accessToken)
+		c.Set("session_id", sessionID)
 
 		c.Next()
 	}
@@ -164,27 +261,35 @@ func OptionalAuthMiddleware(authService services.SupabaseAuthService, userServic
 // AdminRequiredMiddleware ensures only admin users can access the endpoint
 func AdminRequiredMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userCtx := GetUserContext(c)
-		if userCtx == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "authentication_required",
-				"message": "User must be authenticated",
-			})
-			c.Abort()
-			return
+		if requireAdmin(c) {
+			c.Next()
 		}
+	}
+}
 
-		if userCtx.Role != models.UserRoleAdmin {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "admin_required",
-				"message": "Admin privileges required",
-			})
-			c.Abort()
-			return
-		}
+// requireAdmin checks the authenticated user is a tenant admin. On failure
+// it writes the error response, aborts and returns false.
+func requireAdmin(c *gin.Context) bool {
+	userCtx := GetUserContext(c)
+	if userCtx == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "authentication_required",
+			"message": "User must be authenticated",
+		})
+		c.Abort()
+		return false
+	}
 
-		c.Next()
+	if userCtx.Role != models.UserRoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "admin_required",
+			"message": "Admin privileges required",
+		})
+		c.Abort()
+		return false
 	}
+
+	return true
 }
 
 // TenantIsolationMiddleware ensures users can only access their tenant's data
@@ -247,39 +352,52 @@ func GetTenantID(c *gin.Context) (uuid.UUID, bool) {
 // RequirePermission creates middleware that checks for specific permission
 func RequirePermission(permission string, userService *services.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userCtx := GetUserContext(c)
-		if userCtx == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "authentication_required",
-				"message": "User must be authenticated",
-			})
-			c.Abort()
-			return
+		if authorize(c, permission, userService) {
+			c.Next()
 		}
+	}
+}
 
-		// Check permission using user service
-		hasPermission, err := userService.CheckPermission(c.Request.Context(), userCtx.UserID, permission)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "permission_check_failed",
-				"message": "Failed to check user permissions",
-				"details": err.Error(),
-			})
-			c.Abort()
-			return
-		}
+// authorize checks the authenticated user holds permission. On failure it
+// writes the error response, aborts and returns false.
+func authorize(c *gin.Context, permission string, userService *services.UserService) bool {
+	userCtx := GetUserContext(c)
+	if userCtx == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "authentication_required",
+			"message": "User must be authenticated",
+		})
+		c.Abort()
+		return false
+	}
 
-		if !hasPermission {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "insufficient_permissions",
-				"message": "User does not have required permission: " + permission,
-			})
-			c.Abort()
-			return
-		}
+	// Check permission using user service
+	hasPermission, err := userService.CheckPermission(c.Request.Context(), userCtx.UserID, permission)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "permission_check_failed",
+			"message": "Failed to check user permissions",
+			"details": err.Error(),
+		})
+		c.Abort()
+		return false
+	}
 
-		c.Next()
+	// API keys are limited to their scopes on top of their creator's permissions
+	if hasPermission && userCtx.APIKeyID != nil {
+		hasPermission = userCtx.HasScope(permission)
+	}
+
+	if !hasPermission {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "insufficient_permissions",
+			"message": "User does not have required permission: " + permission,
+		})
+		c.Abort()
+		return false
 	}
+
+	return true
 }
 
 // ErrorResponse represents API error response
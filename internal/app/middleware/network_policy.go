@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/gin-gonic/gin"
+)
+
+// NetworkPolicyMiddleware enforces the tenant's IP allowlists on authenticated
+// requests. Admin requests are also checked against the admin allowlist.
+// Requests without a user context (public routes) pass through.
+func NetworkPolicyMiddleware(policyService *services.NetworkPolicyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userCtx := GetUserContext(c)
+		if userCtx == nil || policyService == nil {
+			c.Next()
+			return
+		}
+
+		err := policyService.CheckAPIAccess(c.Request.Context(), services.APIAccessCheck{
+			TenantID:  userCtx.TenantID,
+			UserID:    userCtx.UserID,
+			IsAdmin:   userCtx.Role == models.UserRoleAdmin,
+			IPAddress: c.ClientIP(),
+			UserAgent: c.GetHeader("User-Agent"),
+			Method:    c.Request.Method,
+			Path:      c.FullPath(),
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrIPNotAllowed) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":   "ip_not_allowed",
+					"message": err.Error(),
+				})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "network_policy_check_failed",
+					"message": "Failed to check tenant network policy",
+					"details": err.Error(),
+				})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ClientCountryMiddleware records the client's country as reported by the
+// edge proxy in the given header (e.g. CF-IPCountry). The header is only
+// believed when the request arrives from one of the trusted proxies, or from
+// anywhere when the server sits behind a trusted platform.
+func ClientCountryMiddleware(header string, trustedProxies []*net.IPNet, trustPlatform bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if header != "" && (trustPlatform || isTrustedPeer(c, trustedProxies)) {
+			if country := strings.ToUpper(strings.TrimSpace(c.GetHeader(header))); len(country) == 2 {
+				c.Set("client_country", country)
+			}
+		}
+		c.Next()
+	}
+}
+
+// ParseTrustedProxies parses proxy IPs and CIDRs into networks
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// isTrustedPeer reports whether the direct peer of the request is a trusted proxy
+func isTrustedPeer(c *gin.Context, trustedProxies []*net.IPNet) bool {
+	peer := net.ParseIP(c.RemoteIP())
+	if peer == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(peer) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClientCountry retrieves the client's ISO country code, if known
+func GetClientCountry(c *gin.Context) string {
+	if country, exists := c.Get("client_country"); exists {
+		if code, ok := country.(string); ok {
+			return code
+		}
+	}
+	return ""
+}
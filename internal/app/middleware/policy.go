@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLevel says who may call a route
+type AccessLevel string
+
+const (
+	AccessPublic        AccessLevel = "public"        // No authentication
+	AccessAuthenticated AccessLevel = "authenticated" // Any signed-in user or API key
+	AccessUser          AccessLevel = "user"          // Signed-in users, not API keys
+	AccessPermission    AccessLevel = "permission"    // Users holding a permission
+	AccessAdmin         AccessLevel = "admin"         // Tenant admins only
+)
+
+// RoutePolicy is the authorization requirement of one route
+type RoutePolicy struct {
+	Access     AccessLevel `json:"access"`
+	Permission string      `json:"permission,omitempty"`
+}
+
+// Public allows unauthenticated requests. Handlers of public routes must
+// do their own checks (share tokens, webhook signatures, ...).
+func Public() RoutePolicy {
+	return RoutePolicy{Access: AccessPublic}
+}
+
+// Authenticated allows any signed-in user. Use it for self-service routes
+// whose handlers only touch the caller's own data.
+func Authenticated() RoutePolicy {
+	return RoutePolicy{Access: AccessAuthenticated}
+}
+
+// UserOnly allows signed-in users but refuses API keys. Use it for routes
+// that manage the caller's own credentials, sessions or contact details,
+// which a key acting on someone's behalf must never touch.
+func UserOnly() RoutePolicy {
+	return RoutePolicy{Access: AccessUser}
+}
+
+// Permission requires the given permission from the catalog
+func Permission(permission string) RoutePolicy {
+	return RoutePolicy{Access: AccessPermission, Permission: permission}
+}
+
+// AdminOnly requires the tenant admin role
+func AdminOnly() RoutePolicy {
+	return RoutePolicy{Access: AccessAdmin}
+}
+
+func (p RoutePolicy) String() string {
+	if p.Access == AccessPermission {
+		return string(p.Access) + " " + p.Permission
+	}
+	return string(p.Access)
+}
+
+// RoutePolicies maps routes, written as "METHOD /full/path" with gin path
+// parameters (e.g. "GET /api/v1/documents/:id"), to their policy
+type RoutePolicies map[string]RoutePolicy
+
+// Lookup returns the policy declared for a route
+func (p RoutePolicies) Lookup(method, path string) (RoutePolicy, bool) {
+	policy, ok := p[method+" "+path]
+	return policy, ok
+}
+
+// PolicyMiddleware enforces the declared policy of every matched route,
+// authenticating the request first unless the route is public. Routes
+// without a declared policy are refused, so a new route can't ship
+// unprotected by accident.
+func PolicyMiddleware(policies RoutePolicies, authService services.SupabaseAuthService, userService *services.UserService, apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			// No route matched - let the 404 handler answer
+			c.Next()
+			return
+		}
+
+		policy, ok := policies.Lookup(c.Request.Method, path)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "route_policy_missing",
+				"message": "No authorization policy is declared for this route",
+			})
+			c.Abort()
+			return
+		}
+
+		if policy.Access == AccessPublic {
+			c.Next()
+			return
+		}
+
+		if GetUserContext(c) == nil && !authenticate(c, authService, userService, apiKeyService) {
+			return
+		}
+
+		switch policy.Access {
+		case AccessUser:
+			if userCtx := GetUserContext(c); userCtx != nil && userCtx.APIKeyID != nil {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":   "api_key_not_allowed",
+					"message": "This route cannot be called with an API key",
+				})
+				c.Abort()
+				return
+			}
+		case AccessPermission:
+			if !authorize(c, policy.Permission, userService) {
+				return
+			}
+		case AccessAdmin:
+			if !requireAdmin(c) {
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RoutePolicyEntry is one line of the route policy report
+type RoutePolicyEntry struct {
+	Method   string      `json:"method"`
+	Path     string      `json:"path"`
+	Policy   RoutePolicy `json:"policy"`
+	Declared bool        `json:"declared"`
+	Routed   bool        `json:"routed"`
+}
+
+// RoutePolicyReport lists every registered route with its policy, plus any
+// declared policy whose route no longer exists. Undeclared routes are
+// refused at runtime and public routes skip authentication, so both
+// deserve a look whenever routes change.
+type RoutePolicyReport struct {
+	Entries []RoutePolicyEntry `json:"entries"`
+}
+
+// BuildRoutePolicyReport matches the registered routes against the policies
+func BuildRoutePolicyReport(routes gin.RoutesInfo, policies RoutePolicies) *RoutePolicyReport {
+	report := &RoutePolicyReport{}
+	routed := make(map[string]bool, len(routes))
+
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		routed[key] = true
+
+		policy, declared := policies[key]
+		report.Entries = append(report.Entries, RoutePolicyEntry{
+			Method:   route.Method,
+			Path:     route.Path,
+			Policy:   policy,
+			Declared: declared,
+			Routed:   true,
+		})
+	}
+
+	for key, policy := range policies {
+		if routed[key] {
+			continue
+		}
+		method, path, _ := strings.Cut(key, " ")
+		report.Entries = append(report.Entries, RoutePolicyEntry{
+			Method:   method,
+			Path:     path,
+			Policy:   policy,
+			Declared: true,
+		})
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+		if report.Entries[i].Path != report.Entries[j].Path {
+			return report.Entries[i].Path < report.Entries[j].Path
+		}
+		return report.Entries[i].Method < report.Entries[j].Method
+	})
+	return report
+}
+
+// Undeclared returns the routes without a policy
+func (r *RoutePolicyReport) Undeclared() []RoutePolicyEntry {
+	var entries []RoutePolicyEntry
+	for _, entry := range r.Entries {
+		if entry.Routed && !entry.Declared {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Public returns the routes reachable without authentication
+func (r *RoutePolicyReport) Public() []RoutePolicyEntry {
+	var entries []RoutePolicyEntry
+	for _, entry := range r.Entries {
+		if entry.Routed && entry.Declared && entry.Policy.Access == AccessPublic {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Stale returns declared policies whose route is not registered
+func (r *RoutePolicyReport) Stale() []RoutePolicyEntry {
+	var entries []RoutePolicyEntry
+	for _, entry := range r.Entries {
+		if !entry.Routed {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RateLimiter limits requests per client address
+type RateLimiter interface {
+	CheckRateLimit(ctx context.Context, tenantID uuid.UUID, ipAddress string) error
+}
+
+// unlimitedRoutes are the health probes and the inbound mail and file
+// provider webhooks. Load balancers and orchestrators poll the probes from
+// a few addresses, and a limited probe would take the instance out of
+// rotation; the mail and file providers likewise post for every tenant from
+// a few addresses.
+var unlimitedRoutes = map[string]bool{
+	"GET /health":                true,
+	"GET /ready":                 true,
+	"POST /api/v1/inbound/email": true,
+	"POST /api/v1/integrations/webhooks/:provider": true,
+}
+
+// PublicRateLimitMiddleware applies the server-wide per-IP rate limit to
+// public routes other than the health probes. Authenticated routes are left
+// to the tenant's own limits.
+func PublicRateLimitMiddleware(policies RoutePolicies, limiter RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil || unlimitedRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		policy, ok := policies.Lookup(c.Request.Method, c.FullPath())
+		if !ok || policy.Access != AccessPublic {
+			c.Next()
+			return
+		}
+
+		if err := limiter.CheckRateLimit(c.Request.Context(), uuid.Nil, c.ClientIP()); err != nil {
+			RespondRateLimited(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RespondRateLimited answers 429 with a Retry-After header when the error
+// says how long to wait
+func RespondRateLimited(c *gin.Context, err error) {
+	var limited *services.RateLimitedError
+	if errors.As(err, &limited) {
+		retryAfter := int(time.Until(limited.Until).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+	}
+
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":   "rate_limited",
+		"message": "Too many requests, try again later",
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"github.com/archivus/archivus/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID correlating a request with the work it causes
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds caller-supplied IDs, which end up in logs and
+// database columns
+const maxRequestIDLength = 64
+
+// RequestIDMiddleware gives every request an ID: the caller's X-Request-ID
+// when it sends a usable one, a new UUID otherwise. The ID is echoed in the
+// response, set on the gin context as "request_id", and carried by the
+// request context, so logs, audit logs and the AI jobs the request queues
+// can all be traced back to it.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		c.Header(RequestIDHeader, requestID)
+		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+}
+
+// validRequestID accepts IDs of letters, digits and a few separators, so
+// callers can't inject anything into the logs they're written to
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, char := range requestID {
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z', char >= '0' && char <= '9':
+		case char == '-', char == '_', char == '.', char == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
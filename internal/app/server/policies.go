@@ -0,0 +1,403 @@
+package server
+
+import (
+	"github.com/archivus/archivus/internal/app/middleware"
+)
+
+// RoutePolicies declares who may call each route. Every route must be listed
+// here: requests to an undeclared route are refused. Run `archivusctl routes`
+// after adding or changing routes to check for gaps.
+var RoutePolicies = middleware.RoutePolicies{
+	// Health checks and static files
+	"GET /health":            middleware.Public(),
+	"GET /ready":             middleware.Public(),
+	"GET /healthz":           middleware.Public(),
+	"GET /readyz":            middleware.Public(),
+	"GET /static/*filepath":  middleware.Public(),
+	"HEAD /static/*filepath": middleware.Public(),
+
+	// Authentication - these handlers validate credentials themselves
+	"POST /api/v1/auth/register":       middleware.Public(),
+	"POST /api/v1/auth/login":          middleware.Public(),
+	"POST /api/v1/auth/logout":         middleware.Public(),
+	"POST /api/v1/auth/refresh":        middleware.Public(),
+	"POST /api/v1/auth/reset-password": middleware.Public(),
+	"GET /api/v1/auth/validate":        middleware.Public(),
+	"POST /api/v1/auth/webhook":        middleware.Public(),
+
+	// Inbound mail from the mail provider; checks its own webhook secret
+	"POST /api/v1/inbound/email": middleware.Public(),
+
+	// Documents
+	"POST /api/v1/documents/upload":                middleware.Permission("documents.create"),
+	"POST /api/v1/documents/uploads":               middleware.Permission("documents.create"),
+	"POST /api/v1/documents/uploads/:id/finalize":  middleware.Permission("documents.create"),
+	"GET /api/v1/documents/":                       middleware.Permission("documents.read"),
+	"GET /api/v1/documents/search":                 middleware.Permission("documents.read"),
+	"GET /api/v1/search/suggest":                   middleware.Permission("documents.read"),
+	"GET /api/v1/documents/trash":                  middleware.Permission("documents.read"),
+	"GET /api/v1/documents/duplicates":             middleware.Permission("documents.read"),
+	"GET /api/v1/documents/expiring":               middleware.Permission("documents.read"),
+	"POST /api/v1/documents/retention/simulate":    middleware.AdminOnly(),
+	"GET /api/v1/documents/:id":                    middleware.Permission("documents.read"),
+	"PUT /api/v1/documents/:id":                    middleware.Permission("documents.update"),
+	"DELETE /api/v1/documents/:id":                 middleware.Permission("documents.delete"),
+	"POST /api/v1/documents/:id/restore":           middleware.Permission("documents.delete"),
+	"GET /api/v1/documents/:id/download":           middleware.Permission("documents.read"),
+	"GET /api/v1/documents/:id/gate":               middleware.Permission("documents.read"),
+	"POST /api/v1/documents/:id/gate/override":     middleware.AdminOnly(),
+	"DELETE /api/v1/documents/:id/gate/override":   middleware.AdminOnly(),
+	"GET /api/v1/documents/:id/preview":            middleware.Permission("documents.read"),
+	"PUT /api/v1/documents/:id/privacy":            middleware.Permission("documents.update"),
+	"GET /api/v1/documents/:id/acl":                middleware.Permission("documents.read"),
+	"POST /api/v1/documents/:id/acl":               middleware.Permission("documents.update"),
+	"DELETE /api/v1/documents/:id/acl/:userId":     middleware.Permission("documents.update"),
+	"POST /api/v1/documents/:id/process-financial": middleware.Permission("documents.update"),
+	"POST /api/v1/documents/:id/shares":            middleware.Permission("documents.read"),
+	"GET /api/v1/documents/:id/shares":             middleware.Permission("documents.read"),
+
+	// Share links; revoking someone else's link is checked by the handler
+	"DELETE /api/v1/shares/:id":                 middleware.Authenticated(),
+	"GET /api/v1/shares/:id/access":             middleware.Permission("documents.read"),
+	"GET /api/v1/public/shares/:token":          middleware.Public(),
+	"GET /api/v1/public/shares/:token/download": middleware.Public(),
+
+	// Folders, tags and categories organize documents
+	"POST /api/v1/folders":                  middleware.Permission("documents.update"),
+	"GET /api/v1/folders":                   middleware.Permission("documents.read"),
+	"GET /api/v1/folders/:id":               middleware.Permission("documents.read"),
+	"PUT /api/v1/folders/:id":               middleware.Permission("documents.update"),
+	"DELETE /api/v1/folders/:id":            middleware.Permission("documents.update"),
+	"GET /api/v1/folders/:id/tree":          middleware.Permission("documents.read"),
+	"POST /api/v1/folders/:id/move":         middleware.Permission("documents.update"),
+	"GET /api/v1/folders/:id/documents":     middleware.Permission("documents.read"),
+	"GET /api/v1/folders/:id/acl":           middleware.Permission("documents.read"),
+	"POST /api/v1/folders/:id/acl":          middleware.Permission("documents.update"),
+	"DELETE /api/v1/folders/:id/acl/:aclId": middleware.Permission("documents.update"),
+
+	"POST /api/v1/tags":            middleware.Permission("documents.update"),
+	"GET /api/v1/tags":             middleware.Permission("documents.read"),
+	"GET /api/v1/tags/:id":         middleware.Permission("documents.read"),
+	"PUT /api/v1/tags/:id":         middleware.Permission("documents.update"),
+	"DELETE /api/v1/tags/:id":      middleware.Permission("documents.update"),
+	"GET /api/v1/tags/popular":     middleware.Permission("documents.read"),
+	"GET /api/v1/tags/suggestions": middleware.Permission("documents.read"),
+
+	"POST /api/v1/categories":       middleware.Permission("documents.update"),
+	"GET /api/v1/categories":        middleware.Permission("documents.read"),
+	"GET /api/v1/categories/:id":    middleware.Permission("documents.read"),
+	"PUT /api/v1/categories/:id":    middleware.Permission("documents.update"),
+	"DELETE /api/v1/categories/:id": middleware.Permission("documents.update"),
+	"GET /api/v1/categories/system": middleware.Permission("documents.read"),
+
+	// Own profile, MFA and sessions; credentials and sessions refuse API keys
+	"GET /api/v1/users/profile":                 middleware.Authenticated(),
+	"PUT /api/v1/users/profile":                 middleware.Authenticated(),
+	"POST /api/v1/users/change-password":        middleware.UserOnly(),
+	"GET /api/v1/users/mfa":                     middleware.UserOnly(),
+	"POST /api/v1/users/mfa/enroll":             middleware.UserOnly(),
+	"POST /api/v1/users/mfa/confirm":            middleware.UserOnly(),
+	"POST /api/v1/users/mfa/disable":            middleware.UserOnly(),
+	"POST /api/v1/users/mfa/recovery-codes":     middleware.UserOnly(),
+	"GET /api/v1/users/sessions":                middleware.UserOnly(),
+	"DELETE /api/v1/users/sessions":             middleware.UserOnly(),
+	"DELETE /api/v1/users/sessions/:session_id": middleware.UserOnly(),
+
+	// User management
+	"GET /api/v1/users":                 middleware.AdminOnly(),
+	"POST /api/v1/users":                middleware.AdminOnly(),
+	"PUT /api/v1/users/:id":             middleware.AdminOnly(),
+	"DELETE /api/v1/users/:id":          middleware.AdminOnly(),
+	"PUT /api/v1/users/:id/role":        middleware.AdminOnly(),
+	"PUT /api/v1/users/:id/activate":    middleware.AdminOnly(),
+	"PUT /api/v1/users/:id/deactivate":  middleware.AdminOnly(),
+	"DELETE /api/v1/users/:id/sessions": middleware.AdminOnly(),
+
+	// Tenant
+	"GET /api/v1/tenant/settings":           middleware.Authenticated(),
+	"PUT /api/v1/tenant/settings":           middleware.AdminOnly(),
+	"PATCH /api/v1/tenant/settings":         middleware.AdminOnly(),
+	"GET /api/v1/tenant/settings/schema":    middleware.Authenticated(),
+	"GET /api/v1/tenant/usage":              middleware.Authenticated(),
+	"POST /api/v1/tenant/usage/recompute":   middleware.AdminOnly(),
+	"POST /api/v1/tenant/storage/reconcile": middleware.AdminOnly(),
+	"POST /api/v1/tenant/deletion":          middleware.AdminOnly(),
+	"GET /api/v1/tenant/deletion":           middleware.AdminOnly(),
+	"DELETE /api/v1/tenant/deletion":        middleware.AdminOnly(),
+	"GET /api/v1/tenant/deletion/export":    middleware.AdminOnly(),
+	"GET /api/v1/tenant/transfer/export":    middleware.AdminOnly(),
+	"POST /api/v1/tenant/transfer/import":   middleware.AdminOnly(),
+	"GET /api/v1/tenant/api-usage":          middleware.AdminOnly(),
+	"GET /api/v1/tenant/ai-usage":           middleware.AdminOnly(),
+	"GET /api/v1/tenant/ai-budget":          middleware.AdminOnly(),
+	"PUT /api/v1/tenant/ai-budget":          middleware.AdminOnly(),
+	"GET /api/v1/tenant/trial":              middleware.Authenticated(),
+	"POST /api/v1/tenant/trial/reactivate":  middleware.AdminOnly(),
+	"GET /api/v1/tenant/users":              middleware.AdminOnly(),
+
+	// Notifications: devices and phone are per user, SMS usage and templates per tenant
+	"POST /api/v1/notifications/devices":                     middleware.Authenticated(),
+	"GET /api/v1/notifications/devices":                      middleware.Authenticated(),
+	"DELETE /api/v1/notifications/devices/:id":               middleware.Authenticated(),
+	"PUT /api/v1/notifications/phone":                        middleware.UserOnly(),
+	"POST /api/v1/notifications/phone/verify":                middleware.UserOnly(),
+	"DELETE /api/v1/notifications/phone":                     middleware.UserOnly(),
+	"GET /api/v1/notifications/sms/usage":                    middleware.AdminOnly(),
+	"GET /api/v1/notifications/templates":                    middleware.AdminOnly(),
+	"GET /api/v1/notifications/templates/schema":             middleware.AdminOnly(),
+	"POST /api/v1/notifications/templates/preview":           middleware.AdminOnly(),
+	"GET /api/v1/notifications/templates/:event/:channel":    middleware.AdminOnly(),
+	"PUT /api/v1/notifications/templates/:event/:channel":    middleware.AdminOnly(),
+	"DELETE /api/v1/notifications/templates/:event/:channel": middleware.AdminOnly(),
+
+	// Roles
+	"GET /api/v1/roles/permissions":          middleware.Permission("roles.manage"),
+	"GET /api/v1/roles":                      middleware.Permission("roles.manage"),
+	"POST /api/v1/roles":                     middleware.Permission("roles.manage"),
+	"GET /api/v1/roles/:id":                  middleware.Permission("roles.manage"),
+	"PUT /api/v1/roles/:id":                  middleware.Permission("roles.manage"),
+	"DELETE /api/v1/roles/:id":               middleware.Permission("roles.manage"),
+	"PUT /api/v1/roles/:id/users/:userId":    middleware.Permission("roles.manage"),
+	"DELETE /api/v1/roles/:id/users/:userId": middleware.Permission("roles.manage"),
+
+	// API keys
+	"GET /api/v1/api-keys":             middleware.Permission("api_keys.manage"),
+	"POST /api/v1/api-keys":            middleware.Permission("api_keys.manage"),
+	"GET /api/v1/api-keys/:id":         middleware.Permission("api_keys.manage"),
+	"PUT /api/v1/api-keys/:id/scopes":  middleware.Permission("api_keys.manage"),
+	"POST /api/v1/api-keys/:id/rotate": middleware.Permission("api_keys.manage"),
+	"DELETE /api/v1/api-keys/:id":      middleware.Permission("api_keys.manage"),
+
+	// AI provider keys
+	"GET /api/v1/ai-keys":              middleware.Permission("ai_keys.manage"),
+	"PUT /api/v1/ai-keys/:provider":    middleware.Permission("ai_keys.manage"),
+	"PATCH /api/v1/ai-keys/:provider":  middleware.Permission("ai_keys.manage"),
+	"DELETE /api/v1/ai-keys/:provider": middleware.Permission("ai_keys.manage"),
+
+	// Security administration
+	"GET /api/v1/access-grants/active": middleware.AdminOnly(),
+	"GET /api/v1/network-policy":       middleware.AdminOnly(),
+	"PUT /api/v1/network-policy":       middleware.AdminOnly(),
+	"GET /api/v1/abuse-protection":     middleware.AdminOnly(),
+	"PUT /api/v1/abuse-protection":     middleware.AdminOnly(),
+
+	// Webhooks
+	"GET /api/v1/webhooks":                             middleware.Permission("webhooks.manage"),
+	"POST /api/v1/webhooks":                            middleware.Permission("webhooks.manage"),
+	"GET /api/v1/webhooks/events":                      middleware.Permission("webhooks.manage"),
+	"GET /api/v1/webhooks/:id":                         middleware.Permission("webhooks.manage"),
+	"PUT /api/v1/webhooks/:id":                         middleware.Permission("webhooks.manage"),
+	"DELETE /api/v1/webhooks/:id":                      middleware.Permission("webhooks.manage"),
+	"POST /api/v1/webhooks/:id/rotate-secret":          middleware.Permission("webhooks.manage"),
+	"POST /api/v1/webhooks/:id/test":                   middleware.Permission("webhooks.manage"),
+	"GET /api/v1/webhooks/:id/deliveries":              middleware.Permission("webhooks.manage"),
+	"GET /api/v1/webhooks/:id/deliveries/:delivery_id": middleware.Permission("webhooks.manage"),
+	"POST /api/v1/webhooks/:id/replay":                 middleware.Permission("webhooks.manage"),
+
+	// Vendor extraction profiles; corrections to a document teach its vendor's profile
+	"GET /api/v1/vendor-profiles":                    middleware.Permission("documents.read"),
+	"POST /api/v1/vendor-profiles":                   middleware.AdminOnly(),
+	"GET /api/v1/vendor-profiles/date-formats":       middleware.Permission("documents.read"),
+	"GET /api/v1/vendor-profiles/:id":                middleware.Permission("documents.read"),
+	"PUT /api/v1/vendor-profiles/:id":                middleware.AdminOnly(),
+	"DELETE /api/v1/vendor-profiles/:id":             middleware.AdminOnly(),
+	"POST /api/v1/documents/:id/extraction-feedback": middleware.Permission("documents.update"),
+
+	// Document numbering sequences
+	"GET /api/v1/numbering-sequences":     middleware.Permission("documents.read"),
+	"POST /api/v1/numbering-sequences":    middleware.AdminOnly(),
+	"GET /api/v1/numbering-sequences/:id": middleware.Permission("documents.read"),
+	"PUT /api/v1/numbering-sequences/:id": middleware.AdminOnly(),
+
+	// Tenants' own document types
+	"GET /api/v1/document-types":         middleware.Permission("documents.read"),
+	"POST /api/v1/document-types":        middleware.AdminOnly(),
+	"PUT /api/v1/document-types/:key":    middleware.AdminOnly(),
+	"DELETE /api/v1/document-types/:key": middleware.AdminOnly(),
+
+	// Email-in inbox settings
+	"GET /api/v1/email-inbox": middleware.AdminOnly(),
+	"PUT /api/v1/email-inbox": middleware.AdminOnly(),
+
+	// Thumbnails and previews on demand, and the rules for generating them
+	"POST /api/v1/documents/:id/renditions/:kind": middleware.Permission("documents.read"),
+	"GET /api/v1/preview-rules":                   middleware.AdminOnly(),
+	"PUT /api/v1/preview-rules":                   middleware.AdminOnly(),
+
+	// Watched folders
+	"GET /api/v1/watched-folders":        middleware.AdminOnly(),
+	"POST /api/v1/watched-folders":       middleware.AdminOnly(),
+	"PUT /api/v1/watched-folders/:id":    middleware.AdminOnly(),
+	"DELETE /api/v1/watched-folders/:id": middleware.AdminOnly(),
+
+	// Google Drive, OneDrive, Dropbox, Box, QuickBooks Online and Xero
+	// integrations. Providers send the browser back to the callback without a
+	// session; it checks its own signed state. Change webhooks are verified by
+	// the providers' signatures.
+	"GET /api/v1/integrations/oauth/callback":      middleware.Public(),
+	"GET /api/v1/integrations/webhooks/dropbox":    middleware.Public(),
+	"POST /api/v1/integrations/webhooks/:provider": middleware.Public(),
+	"GET /api/v1/integrations":                     middleware.AdminOnly(),
+	"POST /api/v1/integrations/connect":            middleware.AdminOnly(),
+	"GET /api/v1/integrations/:id/browse":          middleware.AdminOnly(),
+	"GET /api/v1/integrations/:id/mapping":         middleware.AdminOnly(),
+	"PUT /api/v1/integrations/:id/mapping":         middleware.AdminOnly(),
+	"DELETE /api/v1/integrations/:id":              middleware.AdminOnly(),
+	"GET /api/v1/import-syncs":                     middleware.AdminOnly(),
+	"POST /api/v1/import-syncs":                    middleware.AdminOnly(),
+	"POST /api/v1/import-syncs/:id/run":            middleware.AdminOnly(),
+	"DELETE /api/v1/import-syncs/:id":              middleware.AdminOnly(),
+
+	// Scheduled folder archival
+	"GET /api/v1/archival-schedules":             middleware.AdminOnly(),
+	"POST /api/v1/archival-schedules":            middleware.AdminOnly(),
+	"POST /api/v1/archival-schedules/preview":    middleware.AdminOnly(),
+	"GET /api/v1/archival-schedules/:id":         middleware.AdminOnly(),
+	"PUT /api/v1/archival-schedules/:id":         middleware.AdminOnly(),
+	"DELETE /api/v1/archival-schedules/:id":      middleware.AdminOnly(),
+	"GET /api/v1/archival-schedules/:id/preview": middleware.AdminOnly(),
+	"GET /api/v1/archival-runs":                  middleware.AdminOnly(),
+	"GET /api/v1/archival-runs/:id":              middleware.AdminOnly(),
+	"POST /api/v1/archival-runs/:id/undo":        middleware.AdminOnly(),
+
+	// Invoice export to QuickBooks Online and Xero
+	"GET /api/v1/accounting/settings":              middleware.AdminOnly(),
+	"PUT /api/v1/accounting/settings":              middleware.AdminOnly(),
+	"GET /api/v1/accounting/exports":               middleware.AdminOnly(),
+	"POST /api/v1/documents/:id/accounting-export": middleware.Permission("documents.update"),
+	"GET /api/v1/documents/:id/line-items":         middleware.Permission("documents.read"),
+	"PUT /api/v1/documents/:id/line-items":         middleware.Permission("documents.update"),
+	"GET /api/v1/documents/:id/ai-feedback":        middleware.Permission("documents.read"),
+	"POST /api/v1/documents/:id/ai-feedback":       middleware.Permission("documents.update"),
+
+	// Filing suggestions; accepting one also needs write access to its folder
+	"GET /api/v1/documents/:id/suggestions":         middleware.Permission("documents.read"),
+	"POST /api/v1/documents/:id/suggestions/accept": middleware.Permission("documents.update"),
+
+	// Entity facets and documents count only documents the user can read
+	"GET /api/v1/entities":           middleware.Permission("documents.read"),
+	"GET /api/v1/entities/documents": middleware.Permission("documents.read"),
+
+	// Related documents lists only documents the user can read
+	"GET /api/v1/documents/:id/related": middleware.Permission("documents.read"),
+
+	// AI reprocessing batches
+	"POST /api/v1/admin/ai/reprocess":    middleware.AdminOnly(),
+	"GET /api/v1/admin/ai/reprocess":     middleware.AdminOnly(),
+	"GET /api/v1/admin/ai/reprocess/:id": middleware.AdminOnly(),
+
+	// AI prompt templates, per tenant
+	"GET /api/v1/admin/ai/prompts":                                   middleware.AdminOnly(),
+	"GET /api/v1/admin/ai/prompts/schema":                            middleware.AdminOnly(),
+	"GET /api/v1/admin/ai/prompts/:kind":                             middleware.AdminOnly(),
+	"PUT /api/v1/admin/ai/prompts/:kind":                             middleware.AdminOnly(),
+	"DELETE /api/v1/admin/ai/prompts/:kind":                          middleware.AdminOnly(),
+	"GET /api/v1/admin/ai/prompts/:kind/versions":                    middleware.AdminOnly(),
+	"POST /api/v1/admin/ai/prompts/:kind/versions/:version/activate": middleware.AdminOnly(),
+	"POST /api/v1/admin/ai/prompts/:kind/test":                       middleware.AdminOnly(),
+
+	// Chat and passage search find only documents the user can read
+	"POST /api/v1/documents/:id/chat": middleware.Permission("documents.read"),
+	"POST /api/v1/chat":               middleware.Permission("documents.read"),
+	"GET /api/v1/search/passages":     middleware.Permission("documents.read"),
+
+	// Activity reports are scoped to the requester's team unless they're an admin
+	"GET /api/v1/reports/user-activity": middleware.Permission("reports.read"),
+
+	// Realtime events are filtered to what the user may see
+	"GET /api/v1/events/stream": middleware.Authenticated(),
+
+	// Workflows
+	"GET /api/v1/workflows":                        middleware.Permission("workflows.read"),
+	"POST /api/v1/workflows":                       middleware.Permission("workflows.create"),
+	"GET /api/v1/workflows/:id":                    middleware.Permission("workflows.read"),
+	"PUT /api/v1/workflows/:id":                    middleware.Permission("workflows.update"),
+	"DELETE /api/v1/workflows/:id":                 middleware.Permission("workflows.update"),
+	"GET /api/v1/workflows/:id/versions":           middleware.Permission("workflows.read"),
+	"GET /api/v1/workflows/:id/versions/:version":  middleware.Permission("workflows.read"),
+	"GET /api/v1/workflows/delegation-report":      middleware.Permission("audit.read"),
+	"POST /api/v1/documents/:id/workflows/trigger": middleware.Permission("documents.update"),
+	"GET /api/v1/documents/:id/workflow-history":   middleware.Permission("workflows.read"),
+	"GET /api/v1/documents/:id/workflow-evidence":  middleware.Permission("workflows.read"),
+	"GET /api/v1/workflow-instances":               middleware.Permission("workflows.read"),
+	"GET /api/v1/workflow-instances/:id":           middleware.Permission("workflows.read"),
+	"GET /api/v1/documents/:id/workflow-instances": middleware.Permission("workflows.read"),
+
+	// Workflow tasks are checked against their assignee by the service
+	"GET /api/v1/workflow-tasks":                    middleware.Authenticated(),
+	"POST /api/v1/workflow-tasks/bulk":              middleware.Permission("tasks.complete"),
+	"GET /api/v1/workflow-tasks/out-of-office":      middleware.Authenticated(),
+	"PUT /api/v1/workflow-tasks/out-of-office":      middleware.Permission("tasks.complete"),
+	"DELETE /api/v1/workflow-tasks/out-of-office":   middleware.Permission("tasks.complete"),
+	"POST /api/v1/workflow-tasks/:id/approve":       middleware.Permission("tasks.complete"),
+	"POST /api/v1/workflow-tasks/:id/reject":        middleware.Permission("tasks.complete"),
+	"POST /api/v1/workflow-tasks/:id/delegate":      middleware.Permission("tasks.complete"),
+	"GET /api/v1/workflow-tasks/:id/checklist":      middleware.Authenticated(),
+	"PUT /api/v1/workflow-tasks/:id/checklist/:key": middleware.Permission("tasks.complete"),
+
+	// Scheduled jobs
+	"GET /api/v1/scheduled-jobs":          middleware.AdminOnly(),
+	"POST /api/v1/scheduled-jobs":         middleware.AdminOnly(),
+	"GET /api/v1/scheduled-jobs/types":    middleware.AdminOnly(),
+	"GET /api/v1/scheduled-jobs/:id":      middleware.AdminOnly(),
+	"PUT /api/v1/scheduled-jobs/:id":      middleware.AdminOnly(),
+	"DELETE /api/v1/scheduled-jobs/:id":   middleware.AdminOnly(),
+	"GET /api/v1/scheduled-jobs/:id/runs": middleware.AdminOnly(),
+
+	// Business calendar counts workflow deadlines in business days
+	"GET /api/v1/business-calendar": middleware.Authenticated(),
+	"PUT /api/v1/business-calendar": middleware.AdminOnly(),
+
+	// Legal holds
+	"GET /api/v1/legal-holds":               middleware.Permission("legal_holds.manage"),
+	"POST /api/v1/legal-holds":              middleware.Permission("legal_holds.manage"),
+	"GET /api/v1/legal-holds/:id":           middleware.Permission("legal_holds.manage"),
+	"POST /api/v1/legal-holds/:id/lift":     middleware.Permission("legal_holds.manage"),
+	"GET /api/v1/documents/:id/legal-holds": middleware.Permission("legal_holds.manage"),
+
+	// Bulk tagging and categorizing by search
+	"POST /api/v1/documents/bulk-labels/preview": middleware.Permission("documents.update"),
+	"POST /api/v1/documents/bulk-labels":         middleware.Permission("documents.update"),
+	"GET /api/v1/documents/bulk-labels":          middleware.Permission("documents.update"),
+	"GET /api/v1/documents/bulk-labels/:id":      middleware.Permission("documents.update"),
+
+	// GDPR data subject requests
+	"POST /api/v1/users/:id/data-export":             middleware.AdminOnly(),
+	"POST /api/v1/users/:id/erase":                   middleware.AdminOnly(),
+	"GET /api/v1/data-subject-requests":              middleware.AdminOnly(),
+	"GET /api/v1/data-subject-requests/:id":          middleware.AdminOnly(),
+	"GET /api/v1/data-subject-requests/:id/download": middleware.AdminOnly(),
+
+	// Ownership transfers; owners hand over their own items, admins anything
+	"POST /api/v1/documents/:id/transfer-ownership": middleware.Permission("documents.update"),
+	"POST /api/v1/folders/:id/transfer-ownership":   middleware.Permission("documents.update"),
+	"POST /api/v1/ownership-transfers":              middleware.AdminOnly(),
+
+	// Audit
+	"GET /api/v1/audit/archives":              middleware.Permission("audit.read"),
+	"GET /api/v1/audit/archives/:id/download": middleware.Permission("audit.read"),
+	"GET /api/v1/audit/verify":                middleware.Permission("audit.read"),
+	"GET /api/v1/audit/export":                middleware.Permission("audit.read"),
+	"GET /api/v1/audit/retention":             middleware.Permission("audit.read"),
+	"PUT /api/v1/audit/retention":             middleware.AdminOnly(),
+	"GET /api/v1/audit/streams":               middleware.AdminOnly(),
+	"POST /api/v1/audit/streams":              middleware.AdminOnly(),
+	"GET /api/v1/audit/streams/:id":           middleware.AdminOnly(),
+	"PUT /api/v1/audit/streams/:id":           middleware.AdminOnly(),
+	"DELETE /api/v1/audit/streams/:id":        middleware.AdminOnly(),
+
+	// SCIM provisioning
+	"GET /scim/v2/ServiceProviderConfig": middleware.Permission("scim.provision"),
+	"GET /scim/v2/Users":                 middleware.Permission("scim.provision"),
+	"POST /scim/v2/Users":                middleware.Permission("scim.provision"),
+	"GET /scim/v2/Users/:id":             middleware.Permission("scim.provision"),
+	"PUT /scim/v2/Users/:id":             middleware.Permission("scim.provision"),
+	"PATCH /scim/v2/Users/:id":           middleware.Permission("scim.provision"),
+	"DELETE /scim/v2/Users/:id":          middleware.Permission("scim.provision"),
+	"GET /scim/v2/Groups":                middleware.Permission("scim.provision"),
+	"POST /scim/v2/Groups":               middleware.Permission("scim.provision"),
+	"GET /scim/v2/Groups/:id":            middleware.Permission("scim.provision"),
+	"PUT /scim/v2/Groups/:id":            middleware.Permission("scim.provision"),
+	"PATCH /scim/v2/Groups/:id":          middleware.Permission("scim.provision"),
+	"DELETE /scim/v2/Groups/:id":         middleware.Permission("scim.provision"),
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/archivus/archivus/internal/app/config"
+	"github.com/archivus/archivus/internal/app/middleware"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutePoliciesCoverRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := NewServer(&config.Config{}, &Services{}, nil)
+	report := srv.RoutePolicyReport()
+
+	assert.Empty(t, report.Undeclared(), "every route needs an entry in RoutePolicies")
+	assert.Empty(t, report.Stale(), "every policy needs a registered route")
+}
+
+func TestSelfServiceCredentialRoutesRefuseAPIKeys(t *testing.T) {
+	prefixes := []string{
+		"/api/v1/users/mfa",
+		"/api/v1/users/sessions",
+		"/api/v1/users/change-password",
+		"/api/v1/notifications/phone",
+	}
+
+	matched := 0
+	for key, policy := range RoutePolicies {
+		_, path, _ := strings.Cut(key, " ")
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				matched++
+				assert.Equal(t, middleware.AccessUser, policy.Access, key)
+			}
+		}
+	}
+	assert.Greater(t, matched, 0)
+}
+
+func TestUserOnlyPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policies := middleware.RoutePolicies{"GET /self": middleware.UserOnly()}
+
+	tests := []struct {
+		name     string
+		apiKeyID *uuid.UUID
+		want     int
+	}{
+		{"signed-in user", nil, http.StatusOK},
+		{"api key", func() *uuid.UUID { id := uuid.New(); return &id }(), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &middleware.UserContext{
+					UserID:   uuid.New(),
+					TenantID: uuid.New(),
+					APIKeyID: tt.apiKeyID,
+				})
+			})
+			router.Use(middleware.PolicyMiddleware(policies, nil, nil, nil))
+			router.GET("/self", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/self", nil))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+// exhaustedLimiter refuses every request
+type exhaustedLimiter struct{}
+
+func (exhaustedLimiter) CheckRateLimit(ctx context.Context, tenantID uuid.UUID, ipAddress string) error {
+	return services.ErrRateLimited
+}
+
+func TestPublicRateLimitSkipsHealthProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.PublicRateLimitMiddleware(RoutePolicies, exhaustedLimiter{}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/health", ok)
+	router.GET("/ready", ok)
+	router.POST("/api/v1/auth/login", ok)
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/health", http.StatusOK},
+		{http.MethodGet, "/ready", http.StatusOK},
+		{http.MethodPost, "/api/v1/auth/login", http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestIDMiddleware())
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, logger.RequestIDFromContext(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"generated", "", false},
+		{"propagated", "upload-7f3a:1", true},
+		{"unsafe replaced", "bad id\nforged=1", false},
+		{"too long replaced", strings.Repeat("a", 65), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.header != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(middleware.RequestIDHeader)
+			assert.NotEmpty(t, requestID)
+			assert.Equal(t, requestID, w.Body.String(), "the request context carries the ID")
+			if tt.keep {
+				assert.Equal(t, tt.header, requestID)
+			} else {
+				assert.NotEqual(t, tt.header, requestID)
+			}
+		})
+	}
+}
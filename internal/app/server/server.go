@@ -11,6 +11,7 @@ import (
 
 	"github.com/archivus/archivus/internal/app/config"
 	"github.com/archivus/archivus/internal/app/handlers"
+	"github.com/archivus/archivus/internal/app/middleware"
 	"github.com/archivus/archivus/internal/domain/services"
 	"github.com/archivus/archivus/pkg/logger"
 	"github.com/gin-contrib/cors"
@@ -24,18 +25,62 @@ type Server struct {
 	router   *gin.Engine
 	server   *http.Server
 	handlers *Handlers
+	services *Services
 	logger   *logger.Logger
 }
 
 // Handlers holds all HTTP handlers
 type Handlers struct {
-	AuthHandler     *handlers.AuthHandler
-	DocumentHandler *handlers.DocumentHandler
-	UserHandler     *handlers.UserHandler
-	TenantHandler   *handlers.TenantHandler
-	FolderHandler   *handlers.FolderHandler
-	TagHandler      *handlers.TagHandler
-	CategoryHandler *handlers.CategoryHandler
+	AuthHandler             *handlers.AuthHandler
+	DocumentHandler         *handlers.DocumentHandler
+	UserHandler             *handlers.UserHandler
+	TenantHandler           *handlers.TenantHandler
+	FolderHandler           *handlers.FolderHandler
+	TagHandler              *handlers.TagHandler
+	CategoryHandler         *handlers.CategoryHandler
+	NotificationHandler     *handlers.NotificationHandler
+	ShareHandler            *handlers.ShareHandler
+	RoleHandler             *handlers.RoleHandler
+	APIKeyHandler           *handlers.APIKeyHandler
+	AIKeyHandler            *handlers.AIKeyHandler
+	AccessGrantHandler      *handlers.AccessGrantHandler
+	NetworkPolicyHandler    *handlers.NetworkPolicyHandler
+	AbuseProtectionHandler  *handlers.AbuseProtectionHandler
+	SCIMHandler             *handlers.SCIMHandler
+	WebhookHandler          *handlers.WebhookHandler
+	RealtimeHandler         *handlers.RealtimeHandler
+	AuditHandler            *handlers.AuditHandler
+	WorkflowHandler         *handlers.WorkflowHandler
+	ScheduledJobHandler     *handlers.ScheduledJobHandler
+	LegalHoldHandler        *handlers.LegalHoldHandler
+	BulkLabelHandler        *handlers.BulkLabelHandler
+	BusinessCalendarHandler *handlers.BusinessCalendarHandler
+	DataSubjectHandler      *handlers.DataSubjectHandler
+	OwnershipHandler        *handlers.OwnershipHandler
+	TenantDeletionHandler   *handlers.TenantDeletionHandler
+	APIUsageHandler         *handlers.APIUsageHandler
+	SearchHandler           *handlers.SearchHandler
+	TrialHandler            *handlers.TrialHandler
+	VendorProfileHandler    *handlers.VendorProfileHandler
+	NumberingHandler        *handlers.NumberingHandler
+	TenantTransferHandler   *handlers.TenantTransferHandler
+	ReportHandler           *handlers.ReportHandler
+	InboundEmailHandler     *handlers.InboundEmailHandler
+	RenditionHandler        *handlers.RenditionHandler
+	WatchedFolderHandler    *handlers.WatchedFolderHandler
+	IntegrationHandler      *handlers.IntegrationHandler
+	ArchivalHandler         *handlers.ArchivalHandler
+	AccountingHandler       *handlers.AccountingHandler
+	LineItemHandler         *handlers.LineItemHandler
+	AIFeedbackHandler       *handlers.AIFeedbackHandler
+	AIUsageHandler          *handlers.AIUsageHandler
+	ChatHandler             *handlers.ChatHandler
+	FilingSuggestionHandler *handlers.FilingSuggestionHandler
+	EntityHandler           *handlers.EntityHandler
+	RelatedDocumentHandler  *handlers.RelatedDocumentHandler
+	AIReprocessHandler      *handlers.AIReprocessHandler
+	PromptTemplateHandler   *handlers.PromptTemplateHandler
+	DocumentTypeHandler     *handlers.DocumentTypeHandler
 	// Add other handlers as they're created
 }
 
@@ -50,42 +95,148 @@ func NewServer(
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Create router
+	// Create router. Forwarded client IPs are only believed from the
+	// configured proxies; config validation rejects malformed entries.
 	router := gin.New()
+	router.TrustedPlatform = cfg.Server.TrustedPlatform
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		router.SetTrustedProxies(nil)
+		if logger != nil {
+			logger.Error("Invalid trusted proxies; trusting none", "error", err)
+		}
+	}
 
 	// Create handlers
 	handlers := &Handlers{
-		AuthHandler:     handlers.NewAuthHandler(services.UserService, services.TenantService, services.AuthService),
-		DocumentHandler: handlers.NewDocumentHandler(services.DocumentService, services.UserService),
-		UserHandler:     handlers.NewUserHandler(services.UserService, services.TenantService),
-		TenantHandler:   handlers.NewTenantHandler(services.TenantService, services.UserService),
-		FolderHandler:   handlers.NewFolderHandler(services.DocumentService, services.UserService),
-		TagHandler:      handlers.NewTagHandler(services.DocumentService, services.UserService),
-		CategoryHandler: handlers.NewCategoryHandler(services.DocumentService, services.UserService),
+		AuthHandler:             handlers.NewAuthHandler(services.UserService, services.TenantService, services.AuthService),
+		DocumentHandler:         handlers.NewDocumentHandler(services.DocumentService, services.DirectUploadService, services.UserService),
+		UserHandler:             handlers.NewUserHandler(services.UserService, services.TenantService),
+		TenantHandler:           handlers.NewTenantHandler(services.TenantService, services.UserService, services.RepairService),
+		FolderHandler:           handlers.NewFolderHandler(services.DocumentService, services.UserService),
+		TagHandler:              handlers.NewTagHandler(services.DocumentService, services.UserService),
+		CategoryHandler:         handlers.NewCategoryHandler(services.DocumentService, services.UserService),
+		NotificationHandler:     handlers.NewNotificationHandler(services.NotificationService, services.TemplateService),
+		ShareHandler:            handlers.NewShareHandler(services.ShareService, services.UserService),
+		RoleHandler:             handlers.NewRoleHandler(services.RoleService, services.UserService),
+		APIKeyHandler:           handlers.NewAPIKeyHandler(services.APIKeyService, services.UserService),
+		AIKeyHandler:            handlers.NewAIKeyHandler(services.AIKeyService, services.UserService),
+		AccessGrantHandler:      handlers.NewAccessGrantHandler(services.AccessGrantService),
+		NetworkPolicyHandler:    handlers.NewNetworkPolicyHandler(services.NetworkPolicyService),
+		AbuseProtectionHandler:  handlers.NewAbuseProtectionHandler(services.AbuseProtectionService),
+		SCIMHandler:             handlers.NewSCIMHandler(services.SCIMService, services.UserService),
+		WebhookHandler:          handlers.NewWebhookHandler(services.WebhookService, services.UserService),
+		RealtimeHandler:         handlers.NewRealtimeHandler(services.RealtimeHub),
+		AuditHandler:            handlers.NewAuditHandler(services.AuditRetentionService, services.AuditExportService, services.UserService),
+		WorkflowHandler:         handlers.NewWorkflowHandler(services.WorkflowService),
+		ScheduledJobHandler:     handlers.NewScheduledJobHandler(services.SchedulerService),
+		LegalHoldHandler:        handlers.NewLegalHoldHandler(services.LegalHoldService),
+		BulkLabelHandler:        handlers.NewBulkLabelHandler(services.BulkLabelService),
+		BusinessCalendarHandler: handlers.NewBusinessCalendarHandler(services.BusinessCalendarService),
+		DataSubjectHandler:      handlers.NewDataSubjectHandler(services.DataSubjectService),
+		OwnershipHandler:        handlers.NewOwnershipHandler(services.OwnershipService),
+		TenantDeletionHandler:   handlers.NewTenantDeletionHandler(services.TenantDeletionService),
+		APIUsageHandler:         handlers.NewAPIUsageHandler(services.APIUsageService),
+		SearchHandler:           handlers.NewSearchHandler(services.SearchSuggestionService),
+		TrialHandler:            handlers.NewTrialHandler(services.TrialService),
+		VendorProfileHandler:    handlers.NewVendorProfileHandler(services.VendorProfileService),
+		NumberingHandler:        handlers.NewNumberingHandler(services.NumberingService),
+		TenantTransferHandler:   handlers.NewTenantTransferHandler(services.TenantTransferService),
+		ReportHandler:           handlers.NewReportHandler(services.AnalyticsService),
+		InboundEmailHandler:     handlers.NewInboundEmailHandler(services.EmailIngestionService),
+		RenditionHandler:        handlers.NewRenditionHandler(services.DocumentService),
+		WatchedFolderHandler:    handlers.NewWatchedFolderHandler(services.FolderIngestionService),
+		IntegrationHandler:      handlers.NewIntegrationHandler(services.IntegrationService),
+		ArchivalHandler:         handlers.NewArchivalHandler(services.ArchivalService),
+		AccountingHandler:       handlers.NewAccountingHandler(services.AccountingService),
+		LineItemHandler:         handlers.NewLineItemHandler(services.LineItemService),
+		AIFeedbackHandler:       handlers.NewAIFeedbackHandler(services.AIFeedbackService),
+		AIUsageHandler:          handlers.NewAIUsageHandler(services.AIUsageService),
+		ChatHandler:             handlers.NewChatHandler(services.ChatService),
+		FilingSuggestionHandler: handlers.NewFilingSuggestionHandler(services.FilingSuggestionService),
+		EntityHandler:           handlers.NewEntityHandler(services.EntityService),
+		RelatedDocumentHandler:  handlers.NewRelatedDocumentHandler(services.RelatedDocumentService),
+		AIReprocessHandler:      handlers.NewAIReprocessHandler(services.AIReprocessService),
+		PromptTemplateHandler:   handlers.NewPromptTemplateHandler(services.PromptTemplateService),
+		DocumentTypeHandler:     handlers.NewDocumentTypeHandler(services.DocumentTypeService),
 	}
 
 	server := &Server{
 		config:   cfg,
 		router:   router,
 		handlers: handlers,
+		services: services,
 		logger:   logger,
 	}
 
 	server.setupMiddleware()
 	server.setupRoutes()
 
+	// Undeclared routes are refused at runtime; make the gap visible early
+	if logger != nil {
+		for _, route := range server.RoutePolicyReport().Undeclared() {
+			logger.Warn("Route has no authorization policy and will be refused", "method", route.Method, "path", route.Path)
+		}
+	}
+
 	return server
 }
 
 // Services holds all business services
 type Services struct {
-	UserService      *services.UserService
-	TenantService    *services.TenantService
-	DocumentService  *services.DocumentService
-	WorkflowService  *services.WorkflowService
-	AIService        *services.AIService
-	AnalyticsService *services.AnalyticsService
-	AuthService      services.SupabaseAuthService // Added auth service
+	UserService             *services.UserService
+	RoleService             *services.RoleService
+	APIKeyService           *services.APIKeyService
+	AIKeyService            *services.AIKeyService
+	AccessGrantService      *services.AccessGrantService
+	NetworkPolicyService    *services.NetworkPolicyService
+	AbuseProtectionService  *services.AbuseProtectionService
+	SCIMService             *services.SCIMService
+	RepairService           *services.RepairService
+	WebhookService          *services.WebhookService
+	RealtimeHub             *services.RealtimeHub
+	AuditRetentionService   *services.AuditRetentionService
+	AuditExportService      *services.AuditExportService
+	SchedulerService        *services.SchedulerService
+	LegalHoldService        *services.LegalHoldService
+	BulkLabelService        *services.BulkLabelService
+	BusinessCalendarService *services.BusinessCalendarService
+	DataSubjectService      *services.DataSubjectService
+	OwnershipService        *services.OwnershipService
+	TenantDeletionService   *services.TenantDeletionService
+	APIUsageService         *services.APIUsageService
+	SearchSuggestionService *services.SearchSuggestionService
+	TrialService            *services.TrialService
+	VendorProfileService    *services.VendorProfileService
+	NumberingService        *services.NumberingService
+	TenantTransferService   *services.TenantTransferService
+	DirectUploadService     *services.DirectUploadService
+	EmailIngestionService   *services.EmailIngestionService
+	FolderIngestionService  *services.FolderIngestionService
+	IntegrationService      *services.IntegrationService
+	ArchivalService         *services.ArchivalService
+	AccountingService       *services.AccountingService
+	LineItemService         *services.LineItemService
+	AIFeedbackService       *services.AIFeedbackService
+	AIUsageService          *services.AIUsageService
+	ChatService             *services.ChatService
+	FilingSuggestionService *services.FilingSuggestionService
+	EntityService           *services.EntityService
+	RelatedDocumentService  *services.RelatedDocumentService
+	AIReprocessService      *services.AIReprocessService
+	PromptTemplateService   *services.PromptTemplateService
+	DocumentTypeService     *services.DocumentTypeService
+	DocumentCheckService    *services.DocumentCheckService
+	WorkerHeartbeatService  *services.WorkerHeartbeatService
+	HealthService           *services.HealthService
+	TenantService           *services.TenantService
+	DocumentService         *services.DocumentService
+	WorkflowService         *services.WorkflowService
+	AIService               *services.AIService
+	AnalyticsService        *services.AnalyticsService
+	NotificationService     *services.NotificationDispatcher
+	TemplateService         *services.NotificationTemplateService
+	ShareService            *services.ShareService
+	AuthService             services.SupabaseAuthService // Added auth service
 }
 
 // setupMiddleware configures all middleware
@@ -93,6 +244,12 @@ func (s *Server) setupMiddleware() {
 	// Recovery middleware
 	s.router.Use(gin.Recovery())
 
+	// Correlate each request's logs, audit logs and background jobs
+	s.router.Use(middleware.RequestIDMiddleware())
+
+	// Trace requests end to end, continuing callers' traces
+	s.router.Use(middleware.TracingMiddleware())
+
 	// Logging middleware
 	s.router.Use(s.loggingMiddleware())
 
@@ -103,17 +260,28 @@ func (s *Server) setupMiddleware() {
 	s.router.Use(cors.New(cors.Config{
 		AllowOrigins:     s.getAllowedOrigins(),
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Tenant"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Tenant", "X-API-Key", "X-Captcha-Token", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Client country from the edge proxy, used for share geo restrictions
+	trustedProxies, _ := middleware.ParseTrustedProxies(s.config.Server.TrustedProxies)
+	s.router.Use(middleware.ClientCountryMiddleware(s.config.Server.GeoCountryHeader, trustedProxies, s.config.Server.TrustedPlatform != ""))
+
 	// Request size limit middleware
 	s.router.Use(s.requestSizeLimitMiddleware())
 
-	// Rate limiting middleware (placeholder)
-	s.router.Use(s.rateLimitMiddleware())
+	// Per-IP rate limiting of public routes; tenants add their own limits
+	// on login and share links
+	s.router.Use(middleware.PublicRateLimitMiddleware(RoutePolicies, s.rateLimiter()))
+
+	// Authentication and authorization, as declared per route in RoutePolicies
+	s.router.Use(middleware.PolicyMiddleware(RoutePolicies, s.services.AuthService, s.services.UserService, s.services.APIKeyService))
+
+	// Per-tenant API metering: tier rate limits and monthly request quotas
+	s.router.Use(middleware.APIMeteringMiddleware(s.apiMeter()))
 }
 
 // setupRoutes configures all API routes
@@ -121,9 +289,13 @@ func (s *Server) setupRoutes() {
 	// Health check endpoint
 	s.router.GET("/health", s.healthCheck)
 	s.router.GET("/ready", s.readinessCheck)
+	s.router.GET("/healthz", s.healthReport)
+	s.router.GET("/readyz", s.readinessCheck)
 
 	// API version 1
 	v1 := s.router.Group("/api/v1")
+	// Tenant IP allowlists apply to every authenticated request
+	v1.Use(middleware.NetworkPolicyMiddleware(s.services.NetworkPolicyService))
 	{
 		// Register handler routes
 		s.handlers.AuthHandler.SetupRoutes(v1)
@@ -133,12 +305,59 @@ func (s *Server) setupRoutes() {
 		s.handlers.FolderHandler.RegisterRoutes(v1)
 		s.handlers.TagHandler.RegisterRoutes(v1)
 		s.handlers.CategoryHandler.RegisterRoutes(v1)
+		s.handlers.NotificationHandler.RegisterRoutes(v1)
+		s.handlers.ShareHandler.RegisterRoutes(v1)
+		s.handlers.RoleHandler.RegisterRoutes(v1)
+		s.handlers.APIKeyHandler.RegisterRoutes(v1)
+		s.handlers.AIKeyHandler.RegisterRoutes(v1)
+		s.handlers.AccessGrantHandler.RegisterRoutes(v1)
+		s.handlers.NetworkPolicyHandler.RegisterRoutes(v1)
+		s.handlers.AbuseProtectionHandler.RegisterRoutes(v1)
+		s.handlers.WebhookHandler.RegisterRoutes(v1)
+		s.handlers.RealtimeHandler.RegisterRoutes(v1)
+		s.handlers.AuditHandler.RegisterRoutes(v1)
+		s.handlers.WorkflowHandler.RegisterRoutes(v1)
+		s.handlers.ScheduledJobHandler.RegisterRoutes(v1)
+		s.handlers.LegalHoldHandler.RegisterRoutes(v1)
+		s.handlers.BulkLabelHandler.RegisterRoutes(v1)
+		s.handlers.BusinessCalendarHandler.RegisterRoutes(v1)
+		s.handlers.DataSubjectHandler.RegisterRoutes(v1)
+		s.handlers.OwnershipHandler.RegisterRoutes(v1)
+		s.handlers.TenantDeletionHandler.RegisterRoutes(v1)
+		s.handlers.APIUsageHandler.RegisterRoutes(v1)
+		s.handlers.SearchHandler.RegisterRoutes(v1)
+		s.handlers.TrialHandler.RegisterRoutes(v1)
+		s.handlers.VendorProfileHandler.RegisterRoutes(v1)
+		s.handlers.NumberingHandler.RegisterRoutes(v1)
+		s.handlers.TenantTransferHandler.RegisterRoutes(v1)
+		s.handlers.ReportHandler.RegisterRoutes(v1)
+		s.handlers.InboundEmailHandler.RegisterRoutes(v1)
+		s.handlers.RenditionHandler.RegisterRoutes(v1)
+		s.handlers.WatchedFolderHandler.RegisterRoutes(v1)
+		s.handlers.IntegrationHandler.RegisterRoutes(v1)
+		s.handlers.ArchivalHandler.RegisterRoutes(v1)
+		s.handlers.AccountingHandler.RegisterRoutes(v1)
+		s.handlers.LineItemHandler.RegisterRoutes(v1)
+		s.handlers.AIFeedbackHandler.RegisterRoutes(v1)
+		s.handlers.AIUsageHandler.RegisterRoutes(v1)
+		s.handlers.ChatHandler.RegisterRoutes(v1)
+		s.handlers.FilingSuggestionHandler.RegisterRoutes(v1)
+		s.handlers.EntityHandler.RegisterRoutes(v1)
+		s.handlers.RelatedDocumentHandler.RegisterRoutes(v1)
+		s.handlers.AIReprocessHandler.RegisterRoutes(v1)
+		s.handlers.PromptTemplateHandler.RegisterRoutes(v1)
+		s.handlers.DocumentTypeHandler.RegisterRoutes(v1)
 
 		// Add other handler routes as they're created
-		// s.handlers.WorkflowHandler.RegisterRoutes(v1)
 		// s.handlers.AnalyticsHandler.RegisterRoutes(v1)
 	}
 
+	// SCIM 2.0 provisioning for identity providers; lives outside /api/v1 at
+	// the path IdPs expect and authenticates with scoped API keys
+	scim := s.router.Group("/scim/v2")
+	scim.Use(middleware.NetworkPolicyMiddleware(s.services.NetworkPolicyService))
+	s.handlers.SCIMHandler.RegisterRoutes(scim)
+
 	// Serve static files (if any)
 	s.router.Static("/static", "./web/static")
 
@@ -208,6 +427,9 @@ func (s *Server) waitForShutdown() error {
 	<-quit
 	s.logger.Info("Shutting down server...")
 
+	// End open event streams so they don't hold up shutdown
+	s.services.RealtimeHub.Close()
+
 	// Create context with timeout for shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -237,7 +459,7 @@ func (s *Server) Stop() error {
 // loggingMiddleware logs HTTP requests
 func (s *Server) loggingMiddleware() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
+		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\" request_id=%v\n",
 			param.ClientIP,
 			param.TimeStamp.Format(time.RFC1123),
 			param.Method,
@@ -247,6 +469,7 @@ func (s *Server) loggingMiddleware() gin.HandlerFunc {
 			param.Latency,
 			param.Request.UserAgent(),
 			param.ErrorMessage,
+			param.Keys["request_id"],
 		)
 	})
 }
@@ -283,18 +506,31 @@ func (s *Server) requestSizeLimitMiddleware() gin.HandlerFunc {
 			maxSize = s.config.Limits.MaxFileSize // From config
 		}
 
+		// Inbound mail, with room for the encoding and form fields it arrives in
+		if c.Request.URL.Path == "/api/v1/inbound/email" {
+			maxSize = 2 * s.config.Email.InboundMaxMessageSize
+		}
+
 		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
 		c.Next()
 	}
 }
 
-// rateLimitMiddleware implements rate limiting (placeholder)
-func (s *Server) rateLimitMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// TODO: Implement rate limiting using Redis
-		// For now, just pass through
-		c.Next()
+// rateLimiter returns the abuse protection service, or nil when it isn't
+// configured (a nil pointer in an interface would not compare to nil)
+func (s *Server) rateLimiter() middleware.RateLimiter {
+	if s.services.AbuseProtectionService == nil {
+		return nil
 	}
+	return s.services.AbuseProtectionService
+}
+
+// apiMeter returns the API usage service, or nil when it isn't configured
+func (s *Server) apiMeter() middleware.APIMeter {
+	if s.services.APIUsageService == nil {
+		return nil
+	}
+	return s.services.APIUsageService
 }
 
 // Health check handlers
@@ -309,39 +545,32 @@ func (s *Server) healthCheck(c *gin.Context) {
 	})
 }
 
-// readinessCheck checks if server is ready to handle requests
-func (s *Server) readinessCheck(c *gin.Context) {
-	// TODO: Add checks for database, Redis, external services
-	checks := map[string]string{
-		"database": "ok",
-		"redis":    "ok",
-		"storage":  "ok",
+// healthReport reports every dependency's status and latency, and the
+// fleet's background workers with their last poll. It answers 200 as long
+// as the server is up; readiness is for taking traffic away.
+func (s *Server) healthReport(c *gin.Context) {
+	if s.services.HealthService == nil {
+		s.healthCheck(c)
+		return
 	}
 
-	allHealthy := true
-	for _, status := range checks {
-		if status != "ok" {
-			allHealthy = false
-			break
-		}
+	c.JSON(http.StatusOK, s.services.HealthService.Check(c.Request.Context()))
+}
+
+// readinessCheck checks if server is ready to handle requests, answering 503
+// while a critical dependency is failing
+func (s *Server) readinessCheck(c *gin.Context) {
+	if s.services.HealthService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": services.HealthUnavailable})
+		return
 	}
 
+	report := s.services.HealthService.CheckDependencies(c.Request.Context())
 	statusCode := http.StatusOK
-	if !allHealthy {
+	if !report.Ready() {
 		statusCode = http.StatusServiceUnavailable
 	}
-
-	c.JSON(statusCode, gin.H{
-		"status": map[string]string{"ready": func() string {
-			if allHealthy {
-				return "true"
-			} else {
-				return "false"
-			}
-		}()},
-		"checks":    checks,
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	})
+	c.JSON(statusCode, report)
 }
 
 // Helper functions
@@ -364,6 +593,11 @@ func (s *Server) getAllowedOrigins() []string {
 	}
 }
 
+// RoutePolicyReport matches the registered routes against RoutePolicies
+func (s *Server) RoutePolicyReport() *middleware.RoutePolicyReport {
+	return middleware.BuildRoutePolicyReport(s.router.Routes(), RoutePolicies)
+}
+
 // GetRouter returns the Gin router (useful for testing)
 func (s *Server) GetRouter() *gin.Engine {
 	return s.router
@@ -16,8 +16,19 @@ type TenantRepository interface {
 	GetBySubdomain(ctx context.Context, subdomain string) (*models.Tenant, error)
 	Update(ctx context.Context, tenant *models.Tenant) error
 	UpdateUsage(ctx context.Context, tenantID uuid.UUID, storageUsed int64, apiUsed int) error
+	// SetAPIUsed sets the tenant's API requests this month
+	SetAPIUsed(ctx context.Context, tenantID uuid.UUID, apiUsed int64) error
+	ReserveDocumentSlots(ctx context.Context, tenantID uuid.UUID, count int64) (bool, error)
+	ReleaseDocumentSlots(ctx context.Context, tenantID uuid.UUID, count int64) error
+	ReserveStorage(ctx context.Context, tenantID uuid.UUID, bytes int64) (bool, error)
+	ReleaseStorage(ctx context.Context, tenantID uuid.UUID, bytes int64) error
 	CheckQuotaLimits(ctx context.Context, tenantID uuid.UUID) (*QuotaStatus, error)
+	ListTrialsEndingBefore(ctx context.Context, before time.Time) ([]models.Tenant, error)
+	MarkTrialWarned(ctx context.Context, tenantID uuid.UUID, warnedAt time.Time) error
+	ClaimTrialExpiry(ctx context.Context, tenantID uuid.UUID, expiredAt time.Time) (bool, error)
 	List(ctx context.Context, params ListParams) ([]models.Tenant, int64, error)
+	// ListWithSetting lists the tenants whose settings hold key
+	ListWithSetting(ctx context.Context, key string) ([]models.Tenant, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -25,16 +36,62 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetByEmail(ctx context.Context, tenantID uuid.UUID, email string) (*models.User, error)
+	GetByExternalID(ctx context.Context, tenantID uuid.UUID, externalID string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
 	UpdateLastLogin(ctx context.Context, userID uuid.UUID) error
 	ListByTenant(ctx context.Context, tenantID uuid.UUID, params ListParams) ([]models.User, int64, error)
+	ListByRole(ctx context.Context, tenantID uuid.UUID, role models.UserRole) ([]models.User, error)
+	ListByCustomRole(ctx context.Context, roleID uuid.UUID) ([]models.User, error)
 	SetMFA(ctx context.Context, userID uuid.UUID, enabled bool, secret string) error
+	SetPendingMFA(ctx context.Context, userID uuid.UUID, secret string) error
+	ClaimMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	// RecordPhoneCodeAttempt counts a guess at the user's phone verification
+	// code and reports false once maxAttempts guesses were made
+	RecordPhoneCodeAttempt(ctx context.Context, userID uuid.UUID, maxAttempts int) (bool, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *models.Role) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Role, error)
+	GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*models.Role, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Role, error)
+	Update(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	CountUsers(ctx context.Context, roleID uuid.UUID) (int64, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.APIKey, error)
+	Update(ctx context.Context, key *models.APIKey) error
+	UpdateLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+type MFARecoveryCodeRepository interface {
+	ReplaceForUser(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	Consume(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error)
+	CountUnused(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type TenantAIKeyRepository interface {
+	Upsert(ctx context.Context, key *models.TenantAIKey) error
+	GetByProvider(ctx context.Context, tenantID uuid.UUID, provider string) (*models.TenantAIKey, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.TenantAIKey, error)
+	Update(ctx context.Context, key *models.TenantAIKey) error
+	RecordUse(ctx context.Context, id uuid.UUID, usedAt time.Time, lastError string) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type DocumentRepository interface {
 	Create(ctx context.Context, document *models.Document) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Document, error)
+	// GetForTenant is GetByID constrained to the tenant; use it whenever
+	// the ID comes from a tenant's request
+	GetForTenant(ctx context.Context, tenantID, id uuid.UUID) (*models.Document, error)
 	GetByContentHash(ctx context.Context, tenantID uuid.UUID, hash string) (*models.Document, error)
 	Update(ctx context.Context, document *models.Document) error
 	List(ctx context.Context, tenantID uuid.UUID, filters DocumentFilters) ([]models.Document, int64, error)
@@ -49,13 +106,44 @@ type DocumentRepository interface {
 	UpdateStatus(ctx context.Context, id uuid.UUID, status models.DocStatus) error
 	AssociateTags(ctx context.Context, documentID uuid.UUID, tagIDs []uuid.UUID) error
 	AssociateCategories(ctx context.Context, documentID uuid.UUID, categoryIDs []uuid.UUID) error
+	// ListIDsAfter pages through the IDs of documents matching filters in ID
+	// order, starting after the given ID; pagination and sorting are ignored
+	ListIDsAfter(ctx context.Context, tenantID uuid.UUID, filters DocumentFilters, after *uuid.UUID, limit int) ([]uuid.UUID, error)
+	// AddLabels and RemoveLabels attach and detach the tenant's tags and
+	// categories on the tenant's documents, keeping tag usage counts current
+	AddLabels(ctx context.Context, tenantID uuid.UUID, documentIDs, tagIDs, categoryIDs []uuid.UUID) error
+	RemoveLabels(ctx context.Context, tenantID uuid.UUID, documentIDs, tagIDs, categoryIDs []uuid.UUID) error
 	SoftDelete(ctx context.Context, id uuid.UUID, deletedBy uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID, restoredBy uuid.UUID) error
+	ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]models.Document, error)
+	// Download gate checks
+	ListPendingCheck(ctx context.Context, check models.DocumentCheck, after *models.Document, limit int) ([]models.Document, error)
+	SetCheckResult(ctx context.Context, id uuid.UUID, check models.DocumentCheck, status models.CheckStatus, findings models.JSONB) error
+	SetGateOverride(ctx context.Context, id uuid.UUID, overriddenBy *uuid.UUID, reason string, at time.Time) error
+	// SummarizeRetentionDue counts the tenant's documents a retention policy
+	// would act on at now
+	SummarizeRetentionDue(ctx context.Context, tenantID uuid.UUID, cutoffs RetentionCutoffs, now time.Time) ([]RetentionStats, error)
+	// ReplaceFile points the document at a new file and bumps its version,
+	// keeping the current file as a version. It reports false, changing
+	// nothing, when the document no longer has the given version's file.
+	ReplaceFile(ctx context.Context, document *models.Document, file DocumentFile, changes string, replacedBy uuid.UUID) (bool, error)
+	// Purge hard-deletes a trashed document and the rows referencing it,
+	// returning the storage paths of its files for the caller to remove
+	Purge(ctx context.Context, id uuid.UUID) ([]string, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// DocumentFile is a stored file a document can point at
+type DocumentFile struct {
+	StoragePath string
+	FileSize    int64
+	ContentHash string
+}
+
 type FolderRepository interface {
 	Create(ctx context.Context, folder *models.Folder) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Folder, error)
+	GetForTenant(ctx context.Context, tenantID, id uuid.UUID) (*models.Folder, error)
 	GetByPath(ctx context.Context, tenantID uuid.UUID, path string) (*models.Folder, error)
 	Update(ctx context.Context, folder *models.Folder) error
 	GetChildren(ctx context.Context, parentID uuid.UUID) ([]models.Folder, error)
@@ -63,11 +151,39 @@ type FolderRepository interface {
 	GetDocumentCount(ctx context.Context, folderID uuid.UUID) (int64, error)
 	Move(ctx context.Context, folderID, newParentID uuid.UUID) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Folder, error)
+}
+
+// OwnershipRepository moves documents and folders between owners
+type OwnershipRepository interface {
+	Transfer(ctx context.Context, tenantID uuid.UUID, documentIDs, folderIDs []uuid.UUID, ownerID uuid.UUID, at time.Time) error
+}
+
+type FolderACLRepository interface {
+	Upsert(ctx context.Context, acl *models.FolderACL) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.FolderACL, error)
+	ListByFolder(ctx context.Context, folderID uuid.UUID) ([]models.FolderACL, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.FolderACL, error)
+	ListTemporary(ctx context.Context, tenantID uuid.UUID) ([]models.FolderACL, error)
+	ListExpiringBefore(ctx context.Context, before time.Time) ([]models.FolderACL, error)
+	MarkExpiryNotified(ctx context.Context, id uuid.UUID, notifiedAt time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type DocumentACLRepository interface {
+	Upsert(ctx context.Context, acl *models.DocumentACL) error
+	GetForUser(ctx context.Context, documentID, userID uuid.UUID) (*models.DocumentACL, error)
+	ListByDocument(ctx context.Context, documentID uuid.UUID) ([]models.DocumentACL, error)
+	ListTemporary(ctx context.Context, tenantID uuid.UUID) ([]models.DocumentACL, error)
+	ListExpiringBefore(ctx context.Context, before time.Time) ([]models.DocumentACL, error)
+	MarkExpiryNotified(ctx context.Context, id uuid.UUID, notifiedAt time.Time) error
+	Delete(ctx context.Context, documentID, userID uuid.UUID) error
 }
 
 type TagRepository interface {
 	Create(ctx context.Context, tag *models.Tag) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Tag, error)
+	GetForTenant(ctx context.Context, tenantID, id uuid.UUID) (*models.Tag, error)
 	GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*models.Tag, error)
 	Update(ctx context.Context, tag *models.Tag) error
 	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Tag, error)
@@ -80,6 +196,7 @@ type TagRepository interface {
 type CategoryRepository interface {
 	Create(ctx context.Context, category *models.Category) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Category, error)
+	GetForTenant(ctx context.Context, tenantID, id uuid.UUID) (*models.Category, error)
 	GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*models.Category, error)
 	Update(ctx context.Context, category *models.Category) error
 	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Category, error)
@@ -94,6 +211,12 @@ type WorkflowRepository interface {
 	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Workflow, error)
 	GetByDocumentType(ctx context.Context, tenantID uuid.UUID, docType models.DocumentType) ([]models.Workflow, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Versioning: UpdateRules saves the workflow with its new version and
+	// reports false if another change took the version number first
+	UpdateRules(ctx context.Context, workflow *models.Workflow, version *models.WorkflowVersion, migrateSteps []int) (bool, error)
+	GetVersion(ctx context.Context, workflowID uuid.UUID, version int) (*models.WorkflowVersion, error)
+	ListVersions(ctx context.Context, workflowID uuid.UUID) ([]models.WorkflowVersion, error)
 }
 
 type WorkflowTaskRepository interface {
@@ -106,6 +229,49 @@ type WorkflowTaskRepository interface {
 	GetOverdueTasks(ctx context.Context, tenantID uuid.UUID) ([]models.WorkflowTask, error)
 	Complete(ctx context.Context, taskID uuid.UUID, completedBy uuid.UUID, comments string) error
 	Delete(ctx context.Context, id uuid.UUID) error
+
+	ListByInstance(ctx context.Context, instanceID uuid.UUID) ([]models.WorkflowTask, error)
+
+	// Multi-approver steps
+	ListByStepGroup(ctx context.Context, groupID uuid.UUID) ([]models.WorkflowTask, error)
+	CancelPendingInGroup(ctx context.Context, groupID uuid.UUID, taskType, reason string) (int64, error)
+
+	// Workflow automation
+	ListPending(ctx context.Context, tenantID *uuid.UUID, afterID uuid.UUID, limit int) ([]models.WorkflowTask, error)
+	ClaimReminder(ctx context.Context, taskID uuid.UUID, remindAt time.Time, next *time.Time) (bool, error)
+	Escalate(ctx context.Context, taskID, fromUserID, toUserID uuid.UUID) (bool, error)
+
+	// Delegation history
+	CreateDelegation(ctx context.Context, delegation *models.WorkflowTaskDelegation) error
+	SummarizeDelegations(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]DelegationStats, error)
+}
+
+// OutOfOfficeRepository stores the periods users are away
+type OutOfOfficeRepository interface {
+	Create(ctx context.Context, period *models.OutOfOffice) error
+	Update(ctx context.Context, period *models.OutOfOffice) error
+	// GetCurrent returns the user's period in progress at the given time, or
+	// the next one to start
+	GetCurrent(ctx context.Context, userID uuid.UUID, at time.Time) (*models.OutOfOffice, error)
+	ListOverlapping(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.OutOfOffice, error)
+}
+
+type WorkflowInstanceRepository interface {
+	Create(ctx context.Context, instance *models.WorkflowInstance) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.WorkflowInstance, error)
+	ListByDocument(ctx context.Context, documentID uuid.UUID) ([]models.WorkflowInstance, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID, status models.WorkflowStatus, params ListParams) ([]models.WorkflowInstance, int64, error)
+
+	// State changes only apply to instances still in progress
+	AdvanceStep(ctx context.Context, id uuid.UUID, fromStep, toStep int) (bool, error)
+	Complete(ctx context.Context, id uuid.UUID, status models.WorkflowStatus) (bool, error)
+}
+
+type WorkflowChecklistRepository interface {
+	CreateBatch(ctx context.Context, items []models.WorkflowChecklistItem) error
+	ListByTask(ctx context.Context, taskID uuid.UUID) ([]models.WorkflowChecklistItem, error)
+	Update(ctx context.Context, item *models.WorkflowChecklistItem) error
+	GetStats(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]ChecklistItemStats, error)
 }
 
 type AIProcessingJobRepository interface {
@@ -117,6 +283,14 @@ type AIProcessingJobRepository interface {
 	ListByDocument(ctx context.Context, documentID uuid.UUID) ([]models.AIProcessingJob, error)
 	GetFailedJobs(ctx context.Context, tenantID uuid.UUID) ([]models.AIProcessingJob, error)
 	RetryJob(ctx context.Context, jobID uuid.UUID) error
+	// Requeue queues a failed job again with its attempts reset, reporting
+	// false when the job is no longer failed
+	Requeue(ctx context.Context, jobID uuid.UUID) (bool, error)
+	// Prioritize raises a queued job's priority, reporting false when the
+	// job is no longer queued
+	Prioritize(ctx context.Context, jobID uuid.UUID, priority int) (bool, error)
+	// CountByBatch counts a reprocessing batch's jobs by status
+	CountByBatch(ctx context.Context, batchID uuid.UUID) (map[models.ProcessingStatus]int64, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -126,6 +300,36 @@ type AuditLogRepository interface {
 	ListByUser(ctx context.Context, userID uuid.UUID, params ListParams) ([]models.AuditLog, int64, error)
 	ListByTenant(ctx context.Context, tenantID uuid.UUID, params ListParams) ([]models.AuditLog, int64, error)
 	GetSecurityEvents(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]models.AuditLog, error)
+
+	// Hash chain and archival
+	GetChainHead(ctx context.Context, tenantID uuid.UUID) (*models.AuditChainHead, error)
+	ListChain(ctx context.Context, tenantID uuid.UUID, afterSequence int64, limit int) ([]models.AuditLog, error)
+	ListArchivable(ctx context.Context, tenantID uuid.UUID, before time.Time, limit int) ([]models.AuditLog, error)
+	ArchiveEntries(ctx context.Context, archive *models.AuditArchive, entryIDs []uuid.UUID) error
+	ListArchives(ctx context.Context, tenantID uuid.UUID) ([]models.AuditArchive, error)
+	GetArchive(ctx context.Context, id uuid.UUID) (*models.AuditArchive, error)
+
+	// Export
+	ListRange(ctx context.Context, tenantID uuid.UUID, from, to time.Time, after *AuditLogCursor, limit int) ([]models.AuditLog, error)
+}
+
+// AuditLogCursor is the last entry read when paging through audit logs in chain order
+type AuditLogCursor struct {
+	Sequence int64
+	ID       uuid.UUID
+}
+
+type AuditStreamRepository interface {
+	Create(ctx context.Context, stream *models.AuditStream) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AuditStream, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.AuditStream, error)
+	ListDue(ctx context.Context, now time.Time) ([]models.AuditStream, error)
+	Update(ctx context.Context, stream *models.AuditStream) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Advance moves the stream's cursor forward, reporting false when it
+	// is no longer at fromSequence
+	Advance(ctx context.Context, id uuid.UUID, fromSequence, toSequence int64, deliveredAt time.Time) (bool, error)
+	RecordFailure(ctx context.Context, id uuid.UUID, message string, nextAttemptAt time.Time) error
 }
 
 type ShareRepository interface {
@@ -133,10 +337,20 @@ type ShareRepository interface {
 	GetByToken(ctx context.Context, token string) (*models.Share, error)
 	GetByDocument(ctx context.Context, documentID uuid.UUID) ([]models.Share, error)
 	Update(ctx context.Context, share *models.Share) error
-	IncrementDownload(ctx context.Context, shareID uuid.UUID) error
+	// ClaimDownload counts a download, reporting false when the share's
+	// download limit is already reached
+	ClaimDownload(ctx context.Context, shareID uuid.UUID) (bool, error)
 	ExpireShare(ctx context.Context, shareID uuid.UUID) error
 	ListByCreator(ctx context.Context, creatorID uuid.UUID) ([]models.Share, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Share, error)
+	ListActiveByDocument(ctx context.Context, documentID uuid.UUID) ([]models.Share, error)
+}
+
+type ShareAccessRepository interface {
+	Create(ctx context.Context, access *models.ShareAccess) error
+	ListByShare(ctx context.Context, shareID uuid.UUID, params ListParams) ([]models.ShareAccess, int64, error)
+	GetStats(ctx context.Context, shareID uuid.UUID) (*ShareAccessStats, error)
 }
 
 type AnalyticsRepository interface {
@@ -148,6 +362,9 @@ type AnalyticsRepository interface {
 	GetTenantDashboard(ctx context.Context, tenantID uuid.UUID, period string) (*DashboardStats, error)
 	GetStorageAnalytics(ctx context.Context, tenantID uuid.UUID) (*StorageAnalytics, error)
 	GetUserActivity(ctx context.Context, tenantID uuid.UUID, days int) ([]UserActivityStats, error)
+	// GetUserActivityReport totals the users' activity from from (inclusive)
+	// to to (exclusive); users without any are omitted
+	GetUserActivityReport(ctx context.Context, tenantID uuid.UUID, userIDs []uuid.UUID, from, to time.Time) ([]UserActivityTotals, error)
 }
 
 type NotificationRepository interface {
@@ -160,6 +377,448 @@ type NotificationRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// NotificationDeliveryRepository is the outbox for email and Slack notifications
+type NotificationDeliveryRepository interface {
+	Create(ctx context.Context, delivery *models.NotificationDelivery) error
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.NotificationDelivery, error)
+	Claim(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error)
+	Update(ctx context.Context, delivery *models.NotificationDelivery) error
+}
+
+type DeviceTokenRepository interface {
+	Upsert(ctx context.Context, device *models.DeviceToken) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DeviceToken, error)
+	ListActiveByUser(ctx context.Context, userID uuid.UUID) ([]models.DeviceToken, error)
+	Deactivate(ctx context.Context, token string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type SMSMessageRepository interface {
+	Create(ctx context.Context, message *models.SMSMessage) error
+	GetUsage(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*SMSUsage, error)
+	// CountSince counts messages of an event type sent since a time, to a
+	// user and to a number, for rate limiting
+	CountSince(ctx context.Context, eventType string, userID uuid.UUID, toNumber string, since time.Time) (byUser, byNumber int64, err error)
+}
+
+type APIUsageRepository interface {
+	Upsert(ctx context.Context, usage *models.APIUsage) error
+	ListByTenant(ctx context.Context, tenantID uuid.UUID, from time.Time) ([]models.APIUsage, error)
+}
+
+// WorkerHeartbeatRepository stores the last poll of every background worker
+// across the fleet
+type WorkerHeartbeatRepository interface {
+	Upsert(ctx context.Context, heartbeat *models.WorkerHeartbeat) error
+	List(ctx context.Context) ([]models.WorkerHeartbeat, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type NotificationTemplateRepository interface {
+	Upsert(ctx context.Context, template *models.NotificationTemplate) error
+	Get(ctx context.Context, tenantID uuid.UUID, eventType string, channel models.NotificationChannel) (*models.NotificationTemplate, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.NotificationTemplate, error)
+	Delete(ctx context.Context, tenantID uuid.UUID, eventType string, channel models.NotificationChannel) error
+}
+
+// PromptTemplateRepository stores tenants' versioned AI prompt templates
+type PromptTemplateRepository interface {
+	// Create saves the template as the kind's next version and activates it
+	Create(ctx context.Context, template *models.PromptTemplate) error
+	GetActive(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind) (*models.PromptTemplate, error)
+	ListActive(ctx context.Context, tenantID uuid.UUID) ([]models.PromptTemplate, error)
+	// ListVersions returns a kind's versions, newest first
+	ListVersions(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind) ([]models.PromptTemplate, error)
+	// Activate makes a version the active one, deactivating the others
+	Activate(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind, version int) (*models.PromptTemplate, error)
+	// Deactivate leaves a kind without an active version; returns whether
+	// one was active
+	Deactivate(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind) (bool, error)
+}
+
+// DocumentTypeRepository stores tenants' own document types
+type DocumentTypeRepository interface {
+	Create(ctx context.Context, definition *models.DocumentTypeDefinition) error
+	// GetByKey returns nil when the tenant has no such type
+	GetByKey(ctx context.Context, tenantID uuid.UUID, key models.DocumentType) (*models.DocumentTypeDefinition, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.DocumentTypeDefinition, error)
+	Update(ctx context.Context, definition *models.DocumentTypeDefinition) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// CountUsage counts the documents, trashed ones included, and workflows
+	// that have the type
+	CountUsage(ctx context.Context, tenantID uuid.UUID, key models.DocumentType) (int64, error)
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Webhook, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Webhook, error)
+	ListSubscribed(ctx context.Context, tenantID uuid.UUID, eventType string) ([]models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	RecordDeliveryResult(ctx context.Context, id uuid.UUID, deliveredAt time.Time, lastError string) error
+
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+	ClaimDelivery(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// Delivery logs
+	GetDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, filters WebhookDeliveryFilters, params ListParams) ([]models.WebhookDelivery, int64, error)
+	CreateDeliveryAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error
+	ListDeliveryAttempts(ctx context.Context, deliveryID uuid.UUID) ([]models.WebhookDeliveryAttempt, error)
+	DeleteDeliveryAttemptsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ScheduledJobRepository stores tenants' recurring jobs and the queue of
+// their runs. Enqueue and ClaimRun are conditional updates, so concurrent
+// schedulers and workers never fire or run the same slot twice.
+type ScheduledJobRepository interface {
+	Create(ctx context.Context, job *models.ScheduledJob) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ScheduledJob, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.ScheduledJob, error)
+	Update(ctx context.Context, job *models.ScheduledJob) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Scheduling
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.ScheduledJob, error)
+	Enqueue(ctx context.Context, run *models.ScheduledJobRun, nextRunAt *time.Time) (bool, error)
+
+	// Run queue
+	ListRuns(ctx context.Context, jobID uuid.UUID, params ListParams) ([]models.ScheduledJobRun, int64, error)
+	ListDueRuns(ctx context.Context, now time.Time, limit int) ([]models.ScheduledJobRun, error)
+	ClaimRun(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error)
+	FinishRun(ctx context.Context, run *models.ScheduledJobRun) error
+}
+
+// LegalHoldRepository stores legal holds and keeps documents.legal_hold in
+// step with the holds that cover each document
+type LegalHoldRepository interface {
+	Create(ctx context.Context, hold *models.LegalHold, documentIDs []uuid.UUID) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.LegalHold, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID, activeOnly bool, params ListParams) ([]models.LegalHold, int64, error)
+	ListByDocument(ctx context.Context, documentID uuid.UUID) ([]models.LegalHold, error)
+	ListDocumentIDs(ctx context.Context, holdID uuid.UUID) ([]uuid.UUID, error)
+	// Lift reports false when the hold was already lifted
+	Lift(ctx context.Context, id, liftedBy uuid.UUID, reason string, liftedAt time.Time) (bool, error)
+}
+
+// BulkLabelJobRepository queues bulk tag and category changes
+type BulkLabelJobRepository interface {
+	Create(ctx context.Context, job *models.BulkLabelJob) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.BulkLabelJob, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID, params ListParams) ([]models.BulkLabelJob, int64, error)
+
+	// Work queue
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.BulkLabelJob, error)
+	Claim(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error)
+	// SaveProgress records the last document done and extends the lease
+	SaveProgress(ctx context.Context, id uuid.UUID, cursor uuid.UUID, processed int64, leaseUntil time.Time) error
+	Finish(ctx context.Context, job *models.BulkLabelJob) error
+}
+
+// AIReprocessBatchRepository stores AI reprocessing batches and hands them
+// out to workers, like BulkLabelJobRepository
+type AIReprocessBatchRepository interface {
+	Create(ctx context.Context, batch *models.AIReprocessBatch) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AIReprocessBatch, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID, params ListParams) ([]models.AIReprocessBatch, int64, error)
+
+	// Work queue
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.AIReprocessBatch, error)
+	Claim(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error)
+	// SaveProgress records the last document queued and extends the lease
+	SaveProgress(ctx context.Context, id uuid.UUID, cursor uuid.UUID, queued int64, leaseUntil time.Time) error
+	Finish(ctx context.Context, batch *models.AIReprocessBatch) error
+}
+
+// NumberingSequenceRepository stores document numbering sequences. Numbers
+// are issued with Allocate only, so concurrent uploads never share one.
+type NumberingSequenceRepository interface {
+	Create(ctx context.Context, sequence *models.NumberingSequence) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.NumberingSequence, error)
+	GetByDocumentType(ctx context.Context, tenantID uuid.UUID, documentType models.DocumentType) (*models.NumberingSequence, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.NumberingSequence, error)
+	// Update saves the sequence's settings, not its counter
+	Update(ctx context.Context, sequence *models.NumberingSequence) error
+	// Allocate issues the next value in period, starting over at 1 when the
+	// sequence was last used in another period
+	Allocate(ctx context.Context, id uuid.UUID, period string) (int64, error)
+	// SetLastValue moves the counter, so the next value issued in period is lastValue+1
+	SetLastValue(ctx context.Context, id uuid.UUID, lastValue int64, period string) error
+}
+
+// IntegrationRepository stores integrations, their import syncs and the
+// remote items each sync has handled
+type IntegrationRepository interface {
+	Create(ctx context.Context, integration *models.Integration) error
+	GetForTenant(ctx context.Context, tenantID, id uuid.UUID) (*models.Integration, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Integration, error)
+	Update(ctx context.Context, integration *models.Integration) error
+	// Delete removes the integration with its syncs and their items; the
+	// documents they imported stay
+	Delete(ctx context.Context, id uuid.UUID) error
+	// ListByExternalAccount returns every tenant's integrations of the
+	// provider's account
+	ListByExternalAccount(ctx context.Context, provider, accountID string) ([]models.Integration, error)
+
+	CreateSync(ctx context.Context, sync *models.ImportSync) error
+	GetSync(ctx context.Context, tenantID, id uuid.UUID) (*models.ImportSync, error)
+	ListSyncs(ctx context.Context, tenantID uuid.UUID) ([]models.ImportSync, error)
+	UpdateSync(ctx context.Context, sync *models.ImportSync) error
+	DeleteSync(ctx context.Context, id uuid.UUID) error
+	// ListDueSyncs returns the syncs of every tenant due to run, longest due first
+	ListDueSyncs(ctx context.Context, now time.Time, limit int) ([]models.ImportSync, error)
+	// TriggerSyncs makes the integrations' scheduled syncs due now, and
+	// TriggerWebhookSync the sync with the webhook. Finished and failed syncs
+	// stay as they are.
+	TriggerSyncs(ctx context.Context, integrationIDs []uuid.UUID, now time.Time) (int64, error)
+	TriggerWebhookSync(ctx context.Context, webhookID string, now time.Time) (int64, error)
+
+	ListItems(ctx context.Context, syncID uuid.UUID) ([]models.ImportSyncItem, error)
+	// SaveItem creates or replaces the sync's item for its remote ID
+	SaveItem(ctx context.Context, item *models.ImportSyncItem) error
+	DeleteItems(ctx context.Context, syncID uuid.UUID, remoteIDs []string) error
+}
+
+// ArchivalRepository stores folder archival schedules and their runs. Runs
+// record the documents they archived with their previous status, so a run
+// can be undone.
+type ArchivalRepository interface {
+	CreateSchedule(ctx context.Context, schedule *models.ArchivalSchedule) error
+	GetSchedule(ctx context.Context, id uuid.UUID) (*models.ArchivalSchedule, error)
+	ListSchedules(ctx context.Context, tenantID uuid.UUID) ([]models.ArchivalSchedule, error)
+	UpdateSchedule(ctx context.Context, schedule *models.ArchivalSchedule) error
+	DeleteSchedule(ctx context.Context, id uuid.UUID) error
+	ListDueSchedules(ctx context.Context, now time.Time, limit int) ([]models.ArchivalSchedule, error)
+
+	// Documents in the folders that can be archived: live, finished
+	// processing and dated before datedBefore, when set
+	SummarizeCandidates(ctx context.Context, tenantID uuid.UUID, folderIDs []uuid.UUID, datedBefore *time.Time) ([]ArchivalCandidateStats, error)
+	ListCandidates(ctx context.Context, tenantID uuid.UUID, folderIDs []uuid.UUID, datedBefore *time.Time, limit int) ([]models.Document, error)
+	// ArchiveDocuments archives the documents that are still candidates and
+	// not held, recording them on the run, and returns the ones it archived
+	ArchiveDocuments(ctx context.Context, run *models.ArchivalRun, documentIDs []uuid.UUID) ([]models.Document, error)
+
+	CreateRun(ctx context.Context, run *models.ArchivalRun) error
+	GetRun(ctx context.Context, id uuid.UUID) (*models.ArchivalRun, error)
+	ListRuns(ctx context.Context, tenantID uuid.UUID, scheduleID *uuid.UUID, params ListParams) ([]models.ArchivalRun, int64, error)
+	UpdateRun(ctx context.Context, run *models.ArchivalRun) error
+	// UndoRun marks a finished run undone and gives its documents that are
+	// still archived their previous status back, returning how many and
+	// their size. ok is false when the run was running or already undone.
+	UndoRun(ctx context.Context, id, undoneBy uuid.UUID, at time.Time) (restored int64, bytes int64, ok bool, err error)
+
+	// Cold storage, once the undo window has closed
+	ListColdStorageDue(ctx context.Context, now time.Time, limit int) ([]models.ArchivalRun, error)
+	ListArchivedPaths(ctx context.Context, runID uuid.UUID) ([]string, error)
+	MarkColdStored(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// AccountingRepository tracks the bills booked for documents in tenants'
+// accounting systems
+type AccountingRepository interface {
+	// ListExportCandidates returns, across the tenants exporting
+	// automatically, live invoices with an amount and vendor uploaded since
+	// automatic export was switched on and not exported yet, oldest first
+	ListExportCandidates(ctx context.Context, limit int) ([]models.Document, error)
+	// ClaimExport marks a document as exporting to the integration. ok is
+	// false when its bill was booked, or is being booked since staleBefore.
+	ClaimExport(ctx context.Context, tenantID, documentID, integrationID uuid.UUID, now, staleBefore time.Time) (ok bool, err error)
+	// SaveExport stores a document's accounting fields, and only those
+	SaveExport(ctx context.Context, document *models.Document) error
+	// ListExports returns the tenant's documents with an accounting status,
+	// or with the status when one is given, most recently changed first
+	ListExports(ctx context.Context, tenantID uuid.UUID, status models.AccountingSyncStatus, params ListParams) ([]models.Document, int64, error)
+}
+
+// InvoiceLineItemRepository stores the lines of invoices and receipts
+type InvoiceLineItemRepository interface {
+	// ListByDocument returns a document's line items in order
+	ListByDocument(ctx context.Context, tenantID, documentID uuid.UUID) ([]models.InvoiceLineItem, error)
+	// Replace swaps all of a document's line items for items
+	Replace(ctx context.Context, tenantID, documentID uuid.UUID, items []models.InvoiceLineItem) error
+	// ReplaceExtracted swaps a document's line items for extracted ones. ok
+	// is false, and nothing changes, when a user has corrected them.
+	ReplaceExtracted(ctx context.Context, tenantID, documentID uuid.UUID, items []models.InvoiceLineItem) (ok bool, err error)
+}
+
+// AIFeedbackRepository stores users' corrections of AI output
+type AIFeedbackRepository interface {
+	Create(ctx context.Context, feedback *models.AIFeedback) error
+	// ListByDocument returns a document's corrections, newest first
+	ListByDocument(ctx context.Context, tenantID, documentID uuid.UUID) ([]models.AIFeedback, error)
+	// ListRecent returns the tenant's latest corrections of a kind, newest first
+	ListRecent(ctx context.Context, tenantID uuid.UUID, kind models.AIFeedbackKind, limit int) ([]models.AIFeedback, error)
+}
+
+// AIUsageRepository records the tokens and cost of AI provider calls
+type AIUsageRepository interface {
+	CreateBatch(ctx context.Context, usage []models.AIUsage) error
+	// CostSince returns what the tenant's AI calls cost from since on
+	CostSince(ctx context.Context, tenantID uuid.UUID, since time.Time) (float64, error)
+	// MonthlyTotals returns the tenant's usage per month and model from the
+	// given month on, newest first
+	MonthlyTotals(ctx context.Context, tenantID uuid.UUID, from time.Time) ([]AIUsageTotal, error)
+}
+
+// DocumentChunkRepository stores the embedded passages of documents' text
+type DocumentChunkRepository interface {
+	// ReplaceForDocument swaps a document's passages for chunks
+	ReplaceForDocument(ctx context.Context, tenantID, documentID uuid.UUID, chunks []models.DocumentChunk) error
+	// ListByDocument returns a document's passages in the order of its text
+	ListByDocument(ctx context.Context, tenantID, documentID uuid.UUID) ([]models.DocumentChunk, error)
+	// Search returns the tenant's passages nearest to the embedding, nearest
+	// first, from the given documents when there are any. Only passages
+	// embedded with as many dimensions are compared.
+	Search(ctx context.Context, tenantID uuid.UUID, embedding []float32, documentIDs []uuid.UUID, limit int) ([]DocumentChunkMatch, error)
+	// NearestDocuments returns the documents whose passages come nearest to
+	// the embedding, nearest first, leaving out excludeID
+	NearestDocuments(ctx context.Context, tenantID uuid.UUID, embedding []float32, excludeID uuid.UUID, limit int) ([]DocumentDistance, error)
+}
+
+// FilingSuggestionRepository stores the filing AI suggests for documents,
+// one suggestion per document
+type FilingSuggestionRepository interface {
+	// Upsert stores a suggestion, replacing the document's earlier one
+	Upsert(ctx context.Context, suggestion *models.FilingSuggestion) error
+	// GetByDocument returns the document's suggestion, or nil when it has none
+	GetByDocument(ctx context.Context, tenantID, documentID uuid.UUID) (*models.FilingSuggestion, error)
+	Update(ctx context.Context, suggestion *models.FilingSuggestion) error
+}
+
+// DocumentFingerprintRepository stores documents' fuzzy hashes, one
+// fingerprint per document
+type DocumentFingerprintRepository interface {
+	// Upsert stores a fingerprint, replacing the document's earlier one
+	Upsert(ctx context.Context, fingerprint *models.DocumentFingerprint) error
+	// NearDuplicates pairs the tenant's documents whose text or image hashes
+	// differ in at most maxDistance bits, older document first. Only
+	// documents sharing a band of their hashes are compared, and at most
+	// limit pairs, the closest, are returned.
+	NearDuplicates(ctx context.Context, tenantID uuid.UUID, maxDistance, limit int) ([]DocumentDuplicate, error)
+}
+
+// EntityRepository indexes the named entities documents mention
+type EntityRepository interface {
+	// ReplaceForDocument swaps a document's entities for entities
+	ReplaceForDocument(ctx context.Context, tenantID, documentID uuid.UUID, entities []models.Entity) error
+	// Facets counts the documents mentioning each entity, most mentioned first
+	Facets(ctx context.Context, tenantID uuid.UUID, query EntityFacetQuery) ([]EntityFacet, error)
+	// ListByDocuments returns the entities of the documents
+	ListByDocuments(ctx context.Context, tenantID uuid.UUID, documentIDs []uuid.UUID) ([]models.Entity, error)
+	// SharedWith returns the documents mentioning the most entities of the
+	// given types that documentID mentions, most shared first
+	SharedWith(ctx context.Context, tenantID, documentID uuid.UUID, types []models.EntityType, limit int) ([]EntityOverlap, error)
+}
+
+// DataSubjectRepository queues GDPR requests and reads and erases the data
+// held about one user
+type DataSubjectRepository interface {
+	Create(ctx context.Context, request *models.DataSubjectRequest) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.DataSubjectRequest, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID, params ListParams) ([]models.DataSubjectRequest, int64, error)
+	ListBySubject(ctx context.Context, subjectID uuid.UUID) ([]models.DataSubjectRequest, error)
+
+	// Work queue
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.DataSubjectRequest, error)
+	Claim(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error)
+	Finish(ctx context.Context, request *models.DataSubjectRequest) error
+
+	// Subject data
+	ListCreatedDocuments(ctx context.Context, userID uuid.UUID) ([]models.Document, error)
+	ListComments(ctx context.Context, userID uuid.UUID) ([]models.DocumentComment, error)
+	EraseUser(ctx context.Context, userID uuid.UUID, erasedAt time.Time) error
+}
+
+// TenantDeletionRepository queues tenant teardowns and deletes a tenant's rows
+type TenantDeletionRepository interface {
+	Create(ctx context.Context, deletion *models.TenantDeletion) error
+	GetLatest(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletion, error)
+	Cancel(ctx context.Context, id, cancelledBy uuid.UUID, cancelledAt time.Time) (bool, error)
+
+	// Work queue. Claiming a scheduled deletion whose grace period is over
+	// moves it to deleting, so it can no longer be cancelled.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.TenantDeletion, error)
+	Claim(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error)
+	SaveProgress(ctx context.Context, deletion *models.TenantDeletion, leaseUntil time.Time) error
+	Finish(ctx context.Context, deletion *models.TenantDeletion, from models.TenantDeletionStatus) (bool, error)
+
+	// Tenant data
+	ListDocuments(ctx context.Context, tenantID uuid.UUID, after uuid.UUID, limit int) ([]models.Document, error)
+	DeleteTenantRows(ctx context.Context, tenantID uuid.UUID, batchSize int, progress func(table string, deleted int64) error) error
+	CreateCertificate(ctx context.Context, certificate *models.TenantDeletionCertificate) error
+	GetCertificate(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletionCertificate, error)
+}
+
+// TenantTransferRepository reads a tenant's data for export and writes the
+// rows of an imported tenant
+type TenantTransferRepository interface {
+	// Folders come parents first
+	ListFolders(ctx context.Context, tenantID uuid.UUID) ([]models.Folder, error)
+	ListTags(ctx context.Context, tenantID uuid.UUID) ([]models.Tag, error)
+	ListCategories(ctx context.Context, tenantID uuid.UUID) ([]models.Category, error)
+	ListWorkflows(ctx context.Context, tenantID uuid.UUID) ([]models.Workflow, error)
+	// ListDocuments pages through the documents outside the trash in ID
+	// order, with the IDs of their tags and categories
+	ListDocuments(ctx context.Context, tenantID uuid.UUID, after uuid.UUID, limit int) ([]models.Document, error)
+	CountDocuments(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	// UsedUserIDs returns which of the IDs belong to users of any tenant
+	UsedUserIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error)
+
+	// Import writes every row in one transaction
+	Import(ctx context.Context, data *TenantImportData) error
+}
+
+// SearchSuggestionRepository finds typeahead suggestions by prefix. A
+// suggestion matches when the prefix starts its text or one of its words;
+// those matching at the start rank first.
+type SearchSuggestionRepository interface {
+	DocumentTitles(ctx context.Context, tenantID uuid.UUID, query SuggestionQuery) ([]Suggestion, error)
+	Tags(ctx context.Context, tenantID uuid.UUID, query SuggestionQuery) ([]Suggestion, error)
+	Vendors(ctx context.Context, tenantID uuid.UUID, query SuggestionQuery) ([]Suggestion, error)
+	Folders(ctx context.Context, tenantID uuid.UUID, query SuggestionQuery) ([]Suggestion, error)
+}
+
+// VendorProfileRepository stores vendor extraction profiles. Names are
+// looked up normalized, against the profile's name and its aliases.
+type VendorProfileRepository interface {
+	Create(ctx context.Context, profile *models.VendorProfile) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.VendorProfile, error)
+	GetByName(ctx context.Context, tenantID uuid.UUID, normalizedName string) (*models.VendorProfile, error)
+	ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.VendorProfile, error)
+	Update(ctx context.Context, profile *models.VendorProfile) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// UploadSessionRepository stores direct upload sessions. Status changes are
+// conditional, so a session is finalized or expired only once.
+type UploadSessionRepository interface {
+	Create(ctx context.Context, session *models.UploadSession) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.UploadSession, error)
+	// Transition saves the session's status and outcome if it's still in
+	// status from, and reports whether it was
+	Transition(ctx context.Context, session *models.UploadSession, from models.UploadSessionStatus) (bool, error)
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]models.UploadSession, error)
+}
+
+// RepairRepository finds and fixes data that has drifted out of sync.
+// Every fix is a conditional update, so running a repair twice is harmless.
+type RepairRepository interface {
+	FindStuckDocuments(ctx context.Context, tenantID *uuid.UUID, staleBefore time.Time) ([]StuckDocument, error)
+	ResolveStuckDocument(ctx context.Context, documentID uuid.UUID, status models.DocStatus) (bool, error)
+	FindDocumentsMissingAnalytics(ctx context.Context, tenantID *uuid.UUID) ([]models.Document, error)
+	FindStorageDrift(ctx context.Context, tenantID *uuid.UUID) ([]StorageDrift, error)
+	ReconcileStorageUsed(ctx context.Context, tenantID uuid.UUID) (*StorageDrift, error)
+	FindTagUsageDrift(ctx context.Context, tenantID *uuid.UUID) ([]TagUsageDrift, error)
+	ReconcileTagUsage(ctx context.Context, tagID uuid.UUID) (int, error)
+	FindDocumentCountDrift(ctx context.Context, tenantID *uuid.UUID) ([]DocumentCountDrift, error)
+	ReconcileDocumentCount(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	ListTenantIDs(ctx context.Context) ([]uuid.UUID, error)
+	ListStorageReferences(ctx context.Context, tenantID uuid.UUID) ([]StorageReference, error)
+}
+
 // Supporting types for repository operations
 
 type ListParams struct {
@@ -170,10 +829,20 @@ type ListParams struct {
 	Search   string `json:"search"`
 }
 
+// WebhookDeliveryFilters narrows a webhook's delivery log; zero fields match
+// everything. From is inclusive and To exclusive.
+type WebhookDeliveryFilters struct {
+	Status    models.WebhookDeliveryStatus `json:"status"`
+	EventType string                       `json:"event_type"`
+	From      *time.Time                   `json:"from"`
+	To        *time.Time                   `json:"to"`
+}
+
 type DocumentFilters struct {
 	Status       []models.DocStatus        `json:"status"`
 	DocumentType []models.DocumentType     `json:"document_type"`
 	CreatedBy    []uuid.UUID               `json:"created_by"`
+	OwnedBy      []uuid.UUID               `json:"owned_by"`
 	FolderID     *uuid.UUID                `json:"folder_id"`
 	TagIDs       []uuid.UUID               `json:"tag_ids"`
 	CategoryIDs  []uuid.UUID               `json:"category_ids"`
@@ -183,6 +852,23 @@ type DocumentFilters struct {
 	MaxSize      *int64                    `json:"max_size"`
 	HasAI        *bool                     `json:"has_ai"`
 	Compliance   []models.ComplianceStatus `json:"compliance"`
+	// MissingSummary lists documents without a summary
+	MissingSummary bool `json:"missing_summary"`
+	// EntityType and Entity, a normalized value, list documents mentioning the entity
+	EntityType models.EntityType `json:"entity_type"`
+	Entity     string            `json:"entity"`
+	// ExcludeFolderIDs hides documents in folders the caller cannot read, or
+	// for changes cannot write (folder ACLs)
+	ExcludeFolderIDs []uuid.UUID `json:"-"`
+	// ViewerID hides private documents the viewer neither created nor was granted
+	ViewerID *uuid.UUID `json:"-"`
+	// EditorID hides private documents the editor neither created nor was
+	// granted write access to
+	EditorID *uuid.UUID `json:"-"`
+	// Trashed lists deleted documents instead of live ones
+	Trashed bool `json:"trashed"`
+	// TrashViewerID limits the trash to documents the viewer deleted or owns
+	TrashViewerID *uuid.UUID `json:"-"`
 	ListParams
 }
 
@@ -195,6 +881,28 @@ type SearchQuery struct {
 	DateTo        *time.Time            `json:"date_to"`
 	Fuzzy         bool                  `json:"fuzzy"`
 	Limit         int                   `json:"limit"`
+
+	// Access filters applied by the document service
+	ExcludeFolderIDs []uuid.UUID `json:"-"`
+	ViewerID         *uuid.UUID  `json:"-"`
+}
+
+type SuggestionQuery struct {
+	Prefix string // Lowercase
+	Limit  int
+
+	// Access filters applied by the suggestion service
+	ExcludeFolderIDs []uuid.UUID
+	ViewerID         *uuid.UUID
+}
+
+// Suggestion is a typeahead match. Vendors have no ID; Count is how many
+// documents name the vendor, or how often a tag is used.
+type Suggestion struct {
+	ID    *uuid.UUID `json:"id,omitempty"`
+	Text  string     `json:"text"`
+	Path  string     `json:"path,omitempty"` // Folders only
+	Count int64      `json:"count,omitempty"`
 }
 
 type FinancialFilters struct {
@@ -209,14 +917,51 @@ type FinancialFilters struct {
 }
 
 type QuotaStatus struct {
-	StorageUsed    int64   `json:"storage_used"`
-	StorageQuota   int64   `json:"storage_quota"`
-	StoragePercent float64 `json:"storage_percent"`
-	APIUsed        int     `json:"api_used"`
-	APIQuota       int     `json:"api_quota"`
-	APIPercent     float64 `json:"api_percent"`
-	CanUpload      bool    `json:"can_upload"`
-	CanProcessAI   bool    `json:"can_process_ai"`
+	StorageUsed     int64   `json:"storage_used"`
+	StorageQuota    int64   `json:"storage_quota"`
+	StoragePercent  float64 `json:"storage_percent"`
+	APIUsed         int     `json:"api_used"`
+	APIQuota        int     `json:"api_quota"`
+	APIPercent      float64 `json:"api_percent"`
+	DocumentCount   int64   `json:"document_count"`
+	DocumentQuota   int64   `json:"document_quota"`
+	DocumentPercent float64 `json:"document_percent"`
+	CanUpload       bool    `json:"can_upload"`
+	CanAddDocument  bool    `json:"can_add_document"`
+	CanProcessAI    bool    `json:"can_process_ai"`
+
+	// Headroom left under each quota, never negative
+	StorageRemaining   int64 `json:"storage_remaining"`
+	APIRemaining       int   `json:"api_remaining"`
+	DocumentsRemaining int64 `json:"documents_remaining"`
+}
+
+// RetentionCutoffs selects documents dated before the cutoff of their
+// type, or before Default for other types. A nil Default leaves documents of
+// other types out. Documents with their own retention date are due once it
+// has passed.
+type RetentionCutoffs struct {
+	ByType  map[models.DocumentType]time.Time
+	Default *time.Time
+}
+
+// RetentionStats counts due documents of one folder and type
+type RetentionStats struct {
+	FolderID     *uuid.UUID          `json:"folder_id"`
+	DocumentType models.DocumentType `json:"document_type"`
+	LegalHold    bool                `json:"legal_hold"`
+	Archived     bool                `json:"archived"`
+	Count        int64               `json:"count"`
+	Bytes        int64               `json:"bytes"`
+}
+
+// ArchivalCandidateStats counts a folder's documents an archival schedule
+// would archive, or would but for their legal hold
+type ArchivalCandidateStats struct {
+	FolderID  uuid.UUID `json:"folder_id"`
+	LegalHold bool      `json:"legal_hold"`
+	Count     int64     `json:"count"`
+	Bytes     int64     `json:"bytes"`
 }
 
 type DocumentDuplicate struct {
@@ -224,8 +969,18 @@ type DocumentDuplicate struct {
 	DuplicateID  uuid.UUID `json:"duplicate_id"`
 	Similarity   float64   `json:"similarity"`
 	ContentMatch bool      `json:"content_match"`
+	// Method is what matched: content_hash for identical files, text or
+	// image for near-duplicates found by their fingerprints
+	Method string `json:"method"`
 }
 
+// Duplicate detection methods
+const (
+	DuplicateByContentHash = "content_hash"
+	DuplicateByText        = "text"
+	DuplicateByImage       = "image"
+)
+
 type FolderNode struct {
 	*models.Folder
 	Children      []FolderNode `json:"children"`
@@ -260,6 +1015,15 @@ type UserActivityStats struct {
 	LastActivity     time.Time `json:"last_activity"`
 }
 
+// UserActivityTotals is what a user did over a reporting period
+type UserActivityTotals struct {
+	UserID             uuid.UUID `json:"user_id"`
+	DocumentsUploaded  int64     `json:"documents_uploaded"`
+	ApprovalsCompleted int64     `json:"approvals_completed"` // Tasks approved or rejected
+	TurnaroundSeconds  float64   `json:"turnaround_seconds"`  // Average from task assignment to decision
+	Comments           int64     `json:"comments"`
+}
+
 type ActivityItem struct {
 	Type        string    `json:"type"`
 	Description string    `json:"description"`
@@ -279,8 +1043,145 @@ type StoragePoint struct {
 	Size int64     `json:"size"`
 }
 
+type SMSUsage struct {
+	MessageCount int64   `json:"message_count"`
+	FailedCount  int64   `json:"failed_count"`
+	Segments     int64   `json:"segments"`
+	TotalCost    float64 `json:"total_cost"`
+}
+
+type AIUsageTotal struct {
+	Period       time.Time `json:"period"` // First of the month, UTC
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model"`
+	Calls        int64     `json:"calls"`
+	InputTokens  int64     `json:"input_tokens"`
+	OutputTokens int64     `json:"output_tokens"`
+	Cost         float64   `json:"cost"`
+}
+
+// DocumentChunkMatch is a passage found for a question. Distance is the
+// cosine distance to the question, from 0 for the same direction to 2.
+type DocumentChunkMatch struct {
+	models.DocumentChunk
+	Title    string  `json:"title"` // The document's title, or its file name
+	Distance float64 `json:"distance"`
+}
+
+// DocumentDistance is how near a document's nearest passage is to an
+// embedding, as cosine distance
+type DocumentDistance struct {
+	DocumentID uuid.UUID
+	Distance   float64
+}
+
+// EntityOverlap is how many entities a document shares with another
+type EntityOverlap struct {
+	DocumentID uuid.UUID
+	Shared     int64
+}
+
+// EntityFacetQuery narrows entity facets to a type and to normalized values
+// containing Search
+type EntityFacetQuery struct {
+	Type   models.EntityType
+	Search string
+	Limit  int
+
+	// Access filters applied by the document service
+	ExcludeFolderIDs []uuid.UUID
+	ViewerID         *uuid.UUID
+}
+
+// EntityFacet is an entity and how many documents mention it
+type EntityFacet struct {
+	Type            models.EntityType `json:"type"`
+	Value           string            `json:"value"` // One of the ways documents write it
+	NormalizedValue string            `json:"normalized_value"`
+	Documents       int64             `json:"documents"`
+}
+
+type ChecklistItemStats struct {
+	WorkflowID   uuid.UUID `json:"workflow_id"`
+	WorkflowName string    `json:"workflow_name"`
+	ItemKey      string    `json:"item_key"`
+	Label        string    `json:"label"`
+	TotalTasks   int64     `json:"total_tasks"`
+	CheckedTasks int64     `json:"checked_tasks"`
+}
+
+type ShareAccessStats struct {
+	TotalAccesses  int64      `json:"total_accesses"`
+	Views          int64      `json:"views"`
+	Downloads      int64      `json:"downloads"`
+	DeniedAttempts int64      `json:"denied_attempts"`
+	UniqueIPs      int64      `json:"unique_ips"`
+	LastAccessedAt *time.Time `json:"last_accessed_at"`
+}
+
 type DocumentSizeInfo struct {
 	DocumentID   uuid.UUID `json:"document_id"`
 	DocumentName string    `json:"document_name"`
 	Size         int64     `json:"size"`
 }
+
+type StuckDocument struct {
+	DocumentID    uuid.UUID `json:"document_id"`
+	TenantID      uuid.UUID `json:"tenant_id"`
+	FileName      string    `json:"file_name"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	CompletedJobs int64     `json:"completed_jobs"`
+	FailedJobs    int64     `json:"failed_jobs"`
+}
+
+type StorageDrift struct {
+	TenantID    uuid.UUID `json:"tenant_id"`
+	TenantName  string    `json:"tenant_name"`
+	StorageUsed int64     `json:"storage_used"`
+	ActualUsage int64     `json:"actual_usage"`
+}
+
+// StorageReference is a storage path a row points at
+type StorageReference struct {
+	Path       string    `json:"path"`
+	Resource   string    `json:"resource"` // document, document_version, audit_archive, data_subject_request, tenant_deletion or upload_session
+	ResourceID uuid.UUID `json:"resource_id"`
+}
+
+type DocumentCountDrift struct {
+	TenantID      uuid.UUID `json:"tenant_id"`
+	TenantName    string    `json:"tenant_name"`
+	DocumentCount int64     `json:"document_count"`
+	ActualCount   int64     `json:"actual_count"`
+}
+
+// TenantImportData holds the rows of an imported tenant, already given
+// their IDs in the target. Tenant is nil when importing into an existing
+// tenant. Documents reference their tags and categories by ID only.
+type TenantImportData struct {
+	Tenant     *models.Tenant
+	Users      []models.User
+	Folders    []models.Folder // Parents first
+	Tags       []models.Tag
+	Categories []models.Category
+	Workflows  []models.Workflow
+	Documents  []models.Document
+}
+
+// DelegationStats counts the delegations of one kind from one user to
+// another whose tasks are now in the given status
+type DelegationStats struct {
+	FromUserID uuid.UUID
+	ToUserID   uuid.UUID
+	Kind       models.DelegationKind
+	Status     models.WorkflowStatus
+	Count      int64
+}
+
+type TagUsageDrift struct {
+	TagID       uuid.UUID `json:"tag_id"`
+	TenantID    uuid.UUID `json:"tenant_id"`
+	Name        string    `json:"name"`
+	UsageCount  int       `json:"usage_count"`
+	ActualCount int       `json:"actual_count"`
+}
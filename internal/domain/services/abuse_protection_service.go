@@ -0,0 +1,417 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRateLimited                  = errors.New("too many requests")
+	ErrCaptchaRequired              = errors.New("captcha verification required")
+	ErrCaptchaInvalid               = errors.New("captcha verification failed")
+	ErrInvalidAbuseProtectionPolicy = errors.New("abuse protection settings must not be negative, and the maximum delay must not be below the base delay")
+)
+
+// RateLimitedError is returned while a client must wait before trying again.
+// It matches ErrRateLimited with errors.Is.
+type RateLimitedError struct {
+	Until time.Time
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("%s, try again after %s", ErrRateLimited, e.Until.Format(time.RFC3339))
+}
+
+func (e *RateLimitedError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// Attempt scopes, each counting failures separately
+const (
+	AttemptScopeLogin         = "login"
+	AttemptScopeSharePassword = "share_password"
+)
+
+// tenantAbuseProtectionSetting is the tenant settings key holding the policy
+const tenantAbuseProtectionSetting = "abuse_protection"
+
+// CaptchaVerifier checks a CAPTCHA response token with the provider
+type CaptchaVerifier interface {
+	VerifyCaptcha(ctx context.Context, token, ipAddress string) (bool, error)
+}
+
+// AbuseProtectionPolicy is a tenant's protection of its public endpoints.
+// Zero values fall back to the server defaults.
+type AbuseProtectionPolicy struct {
+	// Requests allowed per client address within the window
+	RateLimit              int `json:"rate_limit"`
+	RateLimitWindowSeconds int `json:"rate_limit_window_seconds"`
+	// CaptchaAfterFailures failed attempts require a CAPTCHA from then on
+	CaptchaAfterFailures int `json:"captcha_after_failures"`
+	// Failed share passwords delay the next attempt, doubling each time
+	SharePasswordBaseDelaySeconds int `json:"share_password_base_delay_seconds"`
+	SharePasswordMaxDelaySeconds  int `json:"share_password_max_delay_seconds"`
+
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// AbuseProtectionConfig holds the server-wide defaults
+type AbuseProtectionConfig struct {
+	RateLimit              int
+	RateLimitWindow        time.Duration
+	CaptchaAfterFailures   int
+	SharePasswordBaseDelay time.Duration
+	SharePasswordMaxDelay  time.Duration
+	FailureWindow          time.Duration // how long failed attempts are remembered
+}
+
+// AttemptGuard protects public endpoints of a tenant from abuse
+type AttemptGuard interface {
+	CheckRateLimit(ctx context.Context, tenantID uuid.UUID, ipAddress string) error
+	CheckAttempt(ctx context.Context, attempt Attempt) error
+	RecordFailure(ctx context.Context, attempt Attempt) int64
+	ClearFailures(ctx context.Context, attempt Attempt)
+}
+
+// Attempt identifies a guarded action by a client, e.g. a login for an email
+// or a password attempt on a share link
+type Attempt struct {
+	TenantID     uuid.UUID `json:"tenant_id"`
+	Scope        string    `json:"scope"`
+	Subject      string    `json:"subject"`
+	IPAddress    string    `json:"ip_address"`
+	CaptchaToken string    `json:"-"`
+}
+
+// AbuseProtectionService rate limits public endpoints per client address and
+// slows down repeated failures with CAPTCHAs and exponential delays. Cache
+// outages fail open so Redis being down doesn't block every request.
+type AbuseProtectionService struct {
+	tenantRepo   repositories.TenantRepository
+	auditRepo    repositories.AuditLogRepository
+	cacheService CacheService
+	captcha      CaptchaVerifier
+	config       AbuseProtectionConfig
+}
+
+// NewAbuseProtectionService creates a new abuse protection service. CAPTCHAs
+// are only required when a verifier is configured.
+func NewAbuseProtectionService(
+	tenantRepo repositories.TenantRepository,
+	auditRepo repositories.AuditLogRepository,
+	cacheService CacheService,
+	captcha CaptchaVerifier,
+	config AbuseProtectionConfig,
+) *AbuseProtectionService {
+	if config.RateLimitWindow <= 0 {
+		config.RateLimitWindow = RateLimitWindow
+	}
+	if config.SharePasswordBaseDelay <= 0 {
+		config.SharePasswordBaseDelay = time.Second
+	}
+	if config.SharePasswordMaxDelay < config.SharePasswordBaseDelay {
+		config.SharePasswordMaxDelay = 5 * time.Minute
+	}
+	if config.FailureWindow <= 0 {
+		config.FailureWindow = CacheMediumTerm
+	}
+
+	return &AbuseProtectionService{
+		tenantRepo:   tenantRepo,
+		auditRepo:    auditRepo,
+		cacheService: cacheService,
+		captcha:      captcha,
+		config:       config,
+	}
+}
+
+// UpdateAbuseProtectionParams contains the new policy and who set it
+type UpdateAbuseProtectionParams struct {
+	TenantID  uuid.UUID             `json:"tenant_id"`
+	UpdatedBy uuid.UUID             `json:"updated_by"`
+	IPAddress string                `json:"ip_address"`
+	Policy    AbuseProtectionPolicy `json:"policy"`
+}
+
+// CaptchaEnabled reports whether a CAPTCHA provider is configured
+func (s *AbuseProtectionService) CaptchaEnabled() bool {
+	return s.captcha != nil
+}
+
+// GetPolicy returns the tenant's abuse protection policy
+func (s *AbuseProtectionService) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*AbuseProtectionPolicy, error) {
+	cacheKey := fmt.Sprintf(AbuseProtectionPolicyKeyPattern, tenantID.String())
+	if s.cacheService != nil {
+		if cached, err := s.cacheService.Get(ctx, cacheKey); err == nil {
+			var policy AbuseProtectionPolicy
+			if json.Unmarshal([]byte(cached), &policy) == nil {
+				return &policy, nil
+			}
+		}
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	policy := &AbuseProtectionPolicy{}
+	if raw, ok := tenant.Settings[tenantAbuseProtectionSetting]; ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read abuse protection policy: %w", err)
+		}
+		if err := json.Unmarshal(data, policy); err != nil {
+			return nil, fmt.Errorf("failed to read abuse protection policy: %w", err)
+		}
+	}
+
+	if s.cacheService != nil {
+		if data, err := json.Marshal(policy); err == nil {
+			s.cacheService.Set(ctx, cacheKey, string(data), CacheShortTerm)
+		}
+	}
+
+	return policy, nil
+}
+
+// UpdatePolicy validates and stores the tenant's abuse protection policy
+func (s *AbuseProtectionService) UpdatePolicy(ctx context.Context, params UpdateAbuseProtectionParams) (*AbuseProtectionPolicy, error) {
+	policy := params.Policy
+	if policy.RateLimit < 0 || policy.RateLimitWindowSeconds < 0 || policy.CaptchaAfterFailures < 0 ||
+		policy.SharePasswordBaseDelaySeconds < 0 || policy.SharePasswordMaxDelaySeconds < 0 {
+		return nil, ErrInvalidAbuseProtectionPolicy
+	}
+	if policy.SharePasswordMaxDelaySeconds > 0 && policy.SharePasswordMaxDelaySeconds < policy.SharePasswordBaseDelaySeconds {
+		return nil, ErrInvalidAbuseProtectionPolicy
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, params.TenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	now := time.Now()
+	policy.UpdatedBy = &params.UpdatedBy
+	policy.UpdatedAt = &now
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode abuse protection policy: %w", err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to encode abuse protection policy: %w", err)
+	}
+
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+	tenant.Settings[tenantAbuseProtectionSetting] = stored
+	tenant.UpdatedAt = now
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, fmt.Errorf("failed to update abuse protection policy: %w", err)
+	}
+
+	if s.cacheService != nil {
+		if err := s.cacheService.Delete(ctx, fmt.Sprintf(AbuseProtectionPolicyKeyPattern, params.TenantID.String())); err != nil {
+			// Log but don't fail - the cached policy expires on its own
+		}
+	}
+
+	s.createAuditLog(&models.AuditLog{
+		TenantID:     params.TenantID,
+		UserID:       params.UpdatedBy,
+		ResourceID:   params.TenantID,
+		Action:       models.AuditUpdate,
+		ResourceType: "abuse_protection",
+		IPAddress:    params.IPAddress,
+		Details: models.JSONB{
+			"message":                           "Abuse protection policy updated",
+			"rate_limit":                        policy.RateLimit,
+			"rate_limit_window_seconds":         policy.RateLimitWindowSeconds,
+			"captcha_after_failures":            policy.CaptchaAfterFailures,
+			"share_password_base_delay_seconds": policy.SharePasswordBaseDelaySeconds,
+			"share_password_max_delay_seconds":  policy.SharePasswordMaxDelaySeconds,
+		},
+	})
+
+	return &policy, nil
+}
+
+// CheckRateLimit counts a request from the address and returns a
+// RateLimitedError once the limit for the window is used up. A nil tenant
+// applies the server-wide limit, otherwise the tenant's.
+func (s *AbuseProtectionService) CheckRateLimit(ctx context.Context, tenantID uuid.UUID, ipAddress string) error {
+	if s.cacheService == nil || ipAddress == "" {
+		return nil
+	}
+
+	limit, window := s.config.RateLimit, s.config.RateLimitWindow
+	scope := "global"
+	if tenantID != uuid.Nil {
+		policy := s.effectivePolicy(ctx, tenantID)
+		limit, window = policy.RateLimit, time.Duration(policy.RateLimitWindowSeconds)*time.Second
+		scope = tenantID.String()
+	}
+	if limit <= 0 || window <= 0 {
+		return nil
+	}
+
+	// Fixed windows: the first request of a window sets the expiry
+	windowStart := time.Now().Truncate(window)
+	key := fmt.Sprintf(PublicRateLimitKeyPattern, scope, ipAddress, windowStart.Unix())
+	s.cacheService.SetNX(ctx, key, 0, window)
+	count, err := s.cacheService.Increment(ctx, key)
+	if err != nil || count <= int64(limit) {
+		return nil
+	}
+
+	return &RateLimitedError{Until: windowStart.Add(window)}
+}
+
+// CheckAttempt refuses an attempt while its exponential delay runs, and asks
+// for a CAPTCHA once the client has failed too often
+func (s *AbuseProtectionService) CheckAttempt(ctx context.Context, attempt Attempt) error {
+	if s.cacheService == nil {
+		return nil
+	}
+
+	key := attemptKey(attempt)
+	if value, err := s.cacheService.Get(ctx, fmt.Sprintf(AttemptDelayKeyPattern, key)); err == nil {
+		if until, err := strconv.ParseInt(value, 10, 64); err == nil && time.Now().Unix() < until {
+			return &RateLimitedError{Until: time.Unix(until, 0)}
+		}
+	}
+
+	if s.captcha == nil {
+		return nil
+	}
+
+	policy := s.effectivePolicy(ctx, attempt.TenantID)
+	if policy.CaptchaAfterFailures <= 0 || s.failureCount(ctx, key) < policy.CaptchaAfterFailures {
+		return nil
+	}
+
+	if attempt.CaptchaToken == "" {
+		return ErrCaptchaRequired
+	}
+	valid, err := s.captcha.VerifyCaptcha(ctx, attempt.CaptchaToken, attempt.IPAddress)
+	if err != nil {
+		return fmt.Errorf("failed to verify captcha: %w", err)
+	}
+	if !valid {
+		return ErrCaptchaInvalid
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed attempt and returns the client's failures
+// within the failure window, or 0 when they can't be counted. Share password
+// failures also delay the next attempt, doubling the delay with every
+// failure up to the maximum.
+func (s *AbuseProtectionService) RecordFailure(ctx context.Context, attempt Attempt) int64 {
+	if s.cacheService == nil {
+		return 0
+	}
+
+	key := attemptKey(attempt)
+	counterKey := fmt.Sprintf(AttemptFailuresKeyPattern, key)
+	s.cacheService.SetNX(ctx, counterKey, 0, s.config.FailureWindow)
+	count, err := s.cacheService.Increment(ctx, counterKey)
+	if err != nil {
+		return 0
+	}
+	if attempt.Scope != AttemptScopeSharePassword {
+		return count
+	}
+
+	if delay := shareDelay(s.effectivePolicy(ctx, attempt.TenantID), count); delay > 0 {
+		until := time.Now().Add(delay)
+		s.cacheService.Set(ctx, fmt.Sprintf(AttemptDelayKeyPattern, key), until.Unix(), delay)
+	}
+	return count
+}
+
+// ClearFailures forgets the failures of a client after a successful attempt
+func (s *AbuseProtectionService) ClearFailures(ctx context.Context, attempt Attempt) {
+	if s.cacheService == nil {
+		return
+	}
+
+	key := attemptKey(attempt)
+	s.cacheService.Delete(ctx, fmt.Sprintf(AttemptFailuresKeyPattern, key))
+	s.cacheService.Delete(ctx, fmt.Sprintf(AttemptDelayKeyPattern, key))
+}
+
+// Helper methods
+
+// effectivePolicy returns the tenant's policy with the server defaults filled in
+func (s *AbuseProtectionService) effectivePolicy(ctx context.Context, tenantID uuid.UUID) AbuseProtectionPolicy {
+	policy := AbuseProtectionPolicy{}
+	if stored, err := s.GetPolicy(ctx, tenantID); err == nil {
+		policy = *stored
+	}
+
+	if policy.RateLimit == 0 {
+		policy.RateLimit = s.config.RateLimit
+	}
+	if policy.RateLimitWindowSeconds == 0 {
+		policy.RateLimitWindowSeconds = int(s.config.RateLimitWindow / time.Second)
+	}
+	if policy.CaptchaAfterFailures == 0 {
+		policy.CaptchaAfterFailures = s.config.CaptchaAfterFailures
+	}
+	if policy.SharePasswordBaseDelaySeconds == 0 {
+		policy.SharePasswordBaseDelaySeconds = int(s.config.SharePasswordBaseDelay / time.Second)
+	}
+	if policy.SharePasswordMaxDelaySeconds == 0 {
+		policy.SharePasswordMaxDelaySeconds = int(s.config.SharePasswordMaxDelay / time.Second)
+	}
+	return policy
+}
+
+func (s *AbuseProtectionService) failureCount(ctx context.Context, key string) int {
+	value, err := s.cacheService.Get(ctx, fmt.Sprintf(AttemptFailuresKeyPattern, key))
+	if err != nil {
+		return 0
+	}
+	count, _ := strconv.Atoi(value)
+	return count
+}
+
+func (s *AbuseProtectionService) createAuditLog(log *models.AuditLog) {
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+func attemptKey(attempt Attempt) string {
+	return fmt.Sprintf("%s:%s:%s:%s", attempt.TenantID, attempt.Scope, attempt.Subject, attempt.IPAddress)
+}
+
+// shareDelay returns the delay after the given number of failures: the base
+// delay, doubled for every further failure, capped at the maximum
+func shareDelay(policy AbuseProtectionPolicy, failures int64) time.Duration {
+	delay := time.Duration(policy.SharePasswordBaseDelaySeconds) * time.Second
+	maxDelay := time.Duration(policy.SharePasswordMaxDelaySeconds) * time.Second
+	for i := int64(1); i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
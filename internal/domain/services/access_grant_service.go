@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// AccessExpiryNotifier warns users about temporary grants that are about to lapse
+type AccessExpiryNotifier interface {
+	SendAccessExpiring(ctx context.Context, userID uuid.UUID, resourceName, granteeName string, expiresAt time.Time) error
+}
+
+// AccessGrantService revokes temporary folder and document grants at expiry
+// and reports on the grants that are still active
+type AccessGrantService struct {
+	folderACLRepo repositories.FolderACLRepository
+	docACLRepo    repositories.DocumentACLRepository
+	folderRepo    repositories.FolderRepository
+	documentRepo  repositories.DocumentRepository
+	userRepo      repositories.UserRepository
+	auditRepo     repositories.AuditLogRepository
+
+	notifier AccessExpiryNotifier
+	config   AccessGrantServiceConfig
+}
+
+// AccessGrantServiceConfig holds configuration for the grant expiry worker
+type AccessGrantServiceConfig struct {
+	CheckInterval time.Duration // How often the worker looks for expired grants
+	NoticeWindow  time.Duration // How long before expiry users are warned
+}
+
+// NewAccessGrantService creates a new access grant service
+func NewAccessGrantService(
+	folderACLRepo repositories.FolderACLRepository,
+	docACLRepo repositories.DocumentACLRepository,
+	folderRepo repositories.FolderRepository,
+	documentRepo repositories.DocumentRepository,
+	userRepo repositories.UserRepository,
+	auditRepo repositories.AuditLogRepository,
+	notifier AccessExpiryNotifier,
+	config AccessGrantServiceConfig,
+) *AccessGrantService {
+	return &AccessGrantService{
+		folderACLRepo: folderACLRepo,
+		docACLRepo:    docACLRepo,
+		folderRepo:    folderRepo,
+		documentRepo:  documentRepo,
+		userRepo:      userRepo,
+		auditRepo:     auditRepo,
+		notifier:      notifier,
+		config:        config,
+	}
+}
+
+// ActiveGrantsReport lists a tenant's temporary grants that have not yet expired
+type ActiveGrantsReport struct {
+	FolderGrants   []models.FolderACL   `json:"folder_grants"`
+	DocumentGrants []models.DocumentACL `json:"document_grants"`
+	GeneratedAt    time.Time            `json:"generated_at"`
+}
+
+// ExpiryRunResult summarises one pass of the expiry worker
+type ExpiryRunResult struct {
+	Revoked  int `json:"revoked"`
+	Notified int `json:"notified"`
+}
+
+// ListActiveGrants returns the tenant's unexpired temporary grants, soonest expiry first
+func (s *AccessGrantService) ListActiveGrants(ctx context.Context, tenantID uuid.UUID) (*ActiveGrantsReport, error) {
+	folderGrants, err := s.folderACLRepo.ListTemporary(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	documentGrants, err := s.docACLRepo.ListTemporary(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActiveGrantsReport{
+		FolderGrants:   folderGrants,
+		DocumentGrants: documentGrants,
+		GeneratedAt:    time.Now(),
+	}, nil
+}
+
+// ProcessExpirations revokes grants past their expiry and warns grantees and
+// granters about grants expiring within the notice window
+func (s *AccessGrantService) ProcessExpirations(ctx context.Context) (*ExpiryRunResult, error) {
+	now := time.Now()
+	cutoff := now.Add(s.config.NoticeWindow)
+	result := &ExpiryRunResult{}
+
+	folderGrants, err := s.folderACLRepo.ListExpiringBefore(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range folderGrants {
+		acl := &folderGrants[i]
+		if acl.IsExpired(now) {
+			if err := s.folderACLRepo.Delete(ctx, acl.ID); err != nil {
+				continue
+			}
+			s.createAuditLog(ctx, acl.TenantID, acl.GrantedBy, acl.FolderID, "folder", "Temporary folder access expired")
+			result.Revoked++
+			continue
+		}
+
+		if acl.ExpiryNotifiedAt == nil {
+			s.notifyExpiring(ctx, acl.UserID, acl.GrantedBy, s.folderName(ctx, acl.FolderID), s.folderGranteeName(ctx, acl), *acl.ExpiresAt)
+			if err := s.folderACLRepo.MarkExpiryNotified(ctx, acl.ID, now); err != nil {
+				// Log but don't fail - worst case the warning is sent again
+			}
+			result.Notified++
+		}
+	}
+
+	documentGrants, err := s.docACLRepo.ListExpiringBefore(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range documentGrants {
+		acl := &documentGrants[i]
+		if acl.IsExpired(now) {
+			if err := s.docACLRepo.Delete(ctx, acl.DocumentID, acl.UserID); err != nil {
+				continue
+			}
+			s.createAuditLog(ctx, acl.TenantID, acl.GrantedBy, acl.DocumentID, "document", "Temporary document access expired")
+			result.Revoked++
+			continue
+		}
+
+		if acl.ExpiryNotifiedAt == nil {
+			s.notifyExpiring(ctx, &acl.UserID, acl.GrantedBy, s.documentName(ctx, acl.DocumentID), s.userName(ctx, acl.UserID), *acl.ExpiresAt)
+			if err := s.docACLRepo.MarkExpiryNotified(ctx, acl.ID, now); err != nil {
+				// Log but don't fail - worst case the warning is sent again
+			}
+			result.Notified++
+		}
+	}
+
+	return result, nil
+}
+
+// ExpiryTask is the scheduled task that revokes expired grants
+func (s *AccessGrantService) ExpiryTask() ScheduledTask {
+	interval := s.config.CheckInterval
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+
+	return ScheduledTask{
+		Name:     "access_grant_expiry",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := s.ProcessExpirations(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+func (s *AccessGrantService) notifyExpiring(ctx context.Context, granteeID *uuid.UUID, grantedBy uuid.UUID, resourceName, granteeName string, expiresAt time.Time) {
+	if s.notifier == nil {
+		return
+	}
+
+	if granteeID != nil {
+		if err := s.notifier.SendAccessExpiring(ctx, *granteeID, resourceName, granteeName, expiresAt); err != nil {
+			// Log but don't fail
+		}
+	}
+	if granteeID == nil || *granteeID != grantedBy {
+		if err := s.notifier.SendAccessExpiring(ctx, grantedBy, resourceName, granteeName, expiresAt); err != nil {
+			// Log but don't fail
+		}
+	}
+}
+
+func (s *AccessGrantService) folderName(ctx context.Context, folderID uuid.UUID) string {
+	folder, err := s.folderRepo.GetByID(ctx, folderID)
+	if err != nil {
+		return "a folder"
+	}
+	return folder.Name
+}
+
+func (s *AccessGrantService) documentName(ctx context.Context, documentID uuid.UUID) string {
+	document, err := s.documentRepo.GetByID(ctx, documentID)
+	if err != nil {
+		return "a document"
+	}
+	return documentLabel(document)
+}
+
+func (s *AccessGrantService) folderGranteeName(ctx context.Context, acl *models.FolderACL) string {
+	if acl.UserID == nil {
+		return fmt.Sprintf("all %s users", acl.Role)
+	}
+	return s.userName(ctx, *acl.UserID)
+}
+
+func (s *AccessGrantService) userName(ctx context.Context, userID uuid.UUID) string {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "a user"
+	}
+	return user.FirstName + " " + user.LastName
+}
+
+func (s *AccessGrantService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, resourceType, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       models.AuditUpdate,
+		ResourceType: resourceType,
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
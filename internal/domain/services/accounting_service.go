@@ -0,0 +1,523 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAccountingNotConfigured   = errors.New("no accounting integration is set up for the tenant")
+	ErrInvalidAccountingSettings = errors.New("invalid accounting settings")
+	ErrNotAccountingIntegration  = errors.New("integration is not an accounting system")
+	ErrDocumentNotExportable     = errors.New("document has no bill to export")
+	ErrAccountingAlreadyExported = errors.New("document was already exported, or is being exported")
+	ErrAccountingExportFailed    = errors.New("accounting system refused the bill")
+)
+
+// tenantAccountingSetting is the tenant setting holding its AccountingSettings
+const tenantAccountingSetting = "accounting_export"
+
+// AccountingProvider books bills in an accounting system, such as
+// QuickBooks Online or Xero. Its accounts are companies.
+type AccountingProvider interface {
+	IntegrationConnector
+	// CreateBill books the bill in the company, finding its vendor by name
+	// or creating it, and returns the bill's ID
+	CreateBill(ctx context.Context, accessToken, accountID string, bill AccountingBill) (string, error)
+}
+
+// AccountingBill is an invoice received, as it's booked: its line items,
+// or without them one line for the net amount on the expense account, plus
+// the tax
+type AccountingBill struct {
+	VendorName     string
+	Number         string // The vendor's invoice number
+	Date           *time.Time
+	DueDate        *time.Time
+	Currency       string
+	Total          float64
+	Tax            *float64
+	Description    string
+	Memo           string // Points back to the document
+	ExpenseAccount string // QuickBooks account ID or Xero account code
+	Lines          []AccountingBillLine
+}
+
+// AccountingBillLine is one line of a bill. Its tax is the line's share of
+// the bill's tax.
+type AccountingBillLine struct {
+	Description string
+	Amount      float64 // Before tax
+	Tax         *float64
+	Account     string
+}
+
+// Net is the bill's amount before tax
+func (b AccountingBill) Net() float64 {
+	if b.Tax == nil {
+		return b.Total
+	}
+	return b.Total - *b.Tax
+}
+
+// BillLines returns the lines the bill is booked with
+func (b AccountingBill) BillLines() []AccountingBillLine {
+	if len(b.Lines) > 0 {
+		return b.Lines
+	}
+	return []AccountingBillLine{{
+		Description: b.Description,
+		Amount:      b.Net(),
+		Tax:         b.Tax,
+		Account:     b.ExpenseAccount,
+	}}
+}
+
+// AccountingSettings is where and how a tenant's invoices are exported
+type AccountingSettings struct {
+	IntegrationID  *uuid.UUID `json:"integration_id,omitempty"` // Exports are off without one
+	ExpenseAccount string     `json:"expense_account,omitempty"`
+	// AutoExport books invoices once they have an amount and vendor, as
+	// financial extraction gives them. Invoices uploaded before it was
+	// switched on are only exported on request.
+	AutoExport      bool       `json:"auto_export"`
+	AutoExportSince *time.Time `json:"auto_export_since,omitempty"`
+	UpdatedBy       *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"`
+}
+
+// AccountingService exports the financial data extracted from invoices to
+// tenants' QuickBooks Online or Xero companies as bills, and records on each
+// document whether its bill was booked. The companies are connected as
+// integrations.
+type AccountingService struct {
+	accountingRepo repositories.AccountingRepository
+	lineItemRepo   repositories.InvoiceLineItemRepository
+	documentRepo   repositories.DocumentRepository
+	tenantRepo     repositories.TenantRepository
+	auditRepo      repositories.AuditLogRepository
+	integrations   *IntegrationService
+	config         AccountingConfig
+}
+
+// AccountingConfig holds configuration for accounting exports
+type AccountingConfig struct {
+	Interval      time.Duration // How often invoices are exported automatically; defaults to 5 minutes
+	BatchSize     int           // Invoices per pass; defaults to 50
+	ExportTimeout time.Duration // After which an unfinished export may be retried; defaults to 10 minutes
+}
+
+// NewAccountingService creates a new accounting service
+func NewAccountingService(
+	accountingRepo repositories.AccountingRepository,
+	lineItemRepo repositories.InvoiceLineItemRepository,
+	documentRepo repositories.DocumentRepository,
+	tenantRepo repositories.TenantRepository,
+	auditRepo repositories.AuditLogRepository,
+	integrations *IntegrationService,
+	config AccountingConfig,
+) *AccountingService {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Minute
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 50
+	}
+	if config.ExportTimeout <= 0 {
+		config.ExportTimeout = 10 * time.Minute
+	}
+
+	return &AccountingService{
+		accountingRepo: accountingRepo,
+		lineItemRepo:   lineItemRepo,
+		documentRepo:   documentRepo,
+		tenantRepo:     tenantRepo,
+		auditRepo:      auditRepo,
+		integrations:   integrations,
+		config:         config,
+	}
+}
+
+// GetSettings returns the tenant's accounting settings
+func (s *AccountingService) GetSettings(ctx context.Context, tenantID uuid.UUID) (*AccountingSettings, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	return accountingSettings(tenant)
+}
+
+// UpdateSettings validates and stores the tenant's accounting settings.
+// Switching automatic export on starts it from the invoices uploaded next.
+func (s *AccountingService) UpdateSettings(ctx context.Context, tenantID, userID uuid.UUID, settings AccountingSettings) (*AccountingSettings, error) {
+	settings.ExpenseAccount = strings.TrimSpace(settings.ExpenseAccount)
+	if settings.IntegrationID != nil {
+		integration, err := s.integrations.integrationRepo.GetForTenant(ctx, tenantID, *settings.IntegrationID)
+		if err != nil {
+			return nil, ErrIntegrationNotFound
+		}
+		provider, err := s.integrations.provider(integration.Provider)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := provider.(AccountingProvider); !ok {
+			return nil, ErrNotAccountingIntegration
+		}
+		if settings.ExpenseAccount == "" {
+			return nil, fmt.Errorf("%w: an expense account is required", ErrInvalidAccountingSettings)
+		}
+	} else if settings.AutoExport {
+		return nil, fmt.Errorf("%w: automatic export needs an integration", ErrInvalidAccountingSettings)
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	current, err := accountingSettings(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	settings.AutoExportSince = nil
+	if settings.AutoExport {
+		settings.AutoExportSince = &now
+		if current.AutoExport && current.AutoExportSince != nil {
+			settings.AutoExportSince = current.AutoExportSince
+		}
+	}
+	settings.UpdatedBy = &userID
+	settings.UpdatedAt = &now
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode accounting settings: %w", err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to encode accounting settings: %w", err)
+	}
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+	tenant.Settings[tenantAccountingSetting] = stored
+	tenant.UpdatedAt = now
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, fmt.Errorf("failed to update accounting settings: %w", err)
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, tenantID, "tenant", "Accounting settings updated", nil)
+	return &settings, nil
+}
+
+// ExportDocument books the document's bill now. Documents whose export
+// failed are tried again; ones already booked aren't booked twice.
+func (s *AccountingService) ExportDocument(ctx context.Context, tenantID, userID, documentID uuid.UUID) (*models.Document, error) {
+	document, err := s.documentRepo.GetForTenant(ctx, tenantID, documentID)
+	if err != nil || document.DeletedAt != nil {
+		return nil, ErrDocumentNotFound
+	}
+	settings, err := s.GetSettings(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.IntegrationID == nil {
+		return nil, ErrAccountingNotConfigured
+	}
+	if _, err := s.buildBill(ctx, document, settings); err != nil {
+		return nil, err
+	}
+
+	claimed, err := s.claim(ctx, document, settings)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrAccountingAlreadyExported
+	}
+	return document, s.export(ctx, document, settings, userID)
+}
+
+// ListExports returns the tenant's documents that were exported or tried
+// to be, optionally only those with the status
+func (s *AccountingService) ListExports(ctx context.Context, tenantID uuid.UUID, status models.AccountingSyncStatus, params repositories.ListParams) ([]models.Document, int64, error) {
+	return s.accountingRepo.ListExports(ctx, tenantID, status, params)
+}
+
+// ExportDue books the bills of the invoices waiting for automatic export
+// and returns how many were booked. Invoices whose export fails are marked
+// failed and wait to be retried on request.
+func (s *AccountingService) ExportDue(ctx context.Context) (int, error) {
+	documents, err := s.accountingRepo.ListExportCandidates(ctx, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	settingsByTenant := make(map[uuid.UUID]*AccountingSettings)
+	for i := range documents {
+		if ctx.Err() != nil {
+			return exported, ctx.Err()
+		}
+		document := &documents[i]
+
+		settings, ok := settingsByTenant[document.TenantID]
+		if !ok {
+			if settings, err = s.GetSettings(ctx, document.TenantID); err != nil {
+				return exported, err
+			}
+			settingsByTenant[document.TenantID] = settings
+		}
+		if settings.IntegrationID == nil {
+			continue
+		}
+
+		claimed, err := s.claim(ctx, document, settings)
+		if err != nil {
+			return exported, err
+		}
+		if !claimed {
+			continue
+		}
+		userID := uuid.Nil
+		if settings.UpdatedBy != nil {
+			userID = *settings.UpdatedBy
+		}
+		if err := s.export(ctx, document, settings, userID); err == nil {
+			exported++
+		}
+	}
+	return exported, nil
+}
+
+// ExportTask is the scheduled task that exports invoices automatically
+func (s *AccountingService) ExportTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "accounting_export",
+		Interval: s.config.Interval,
+		Run: func(ctx context.Context) error {
+			_, err := s.ExportDue(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+func (s *AccountingService) claim(ctx context.Context, document *models.Document, settings *AccountingSettings) (bool, error) {
+	now := time.Now()
+	claimed, err := s.accountingRepo.ClaimExport(ctx, document.TenantID, document.ID, *settings.IntegrationID, now, now.Add(-s.config.ExportTimeout))
+	if err != nil || !claimed {
+		return false, err
+	}
+	document.AccountingStatus = models.AccountingSyncExporting
+	document.AccountingIntegrationID = settings.IntegrationID
+	document.AccountingUpdatedAt = &now
+	return true, nil
+}
+
+// export books a claimed document's bill and records the outcome on it
+func (s *AccountingService) export(ctx context.Context, document *models.Document, settings *AccountingSettings, userID uuid.UUID) error {
+	billID, err := s.createBill(ctx, document, settings)
+
+	now := time.Now()
+	document.AccountingUpdatedAt = &now
+	if err != nil {
+		document.AccountingStatus = models.AccountingSyncFailed
+		document.AccountingError = err.Error()
+	} else {
+		document.AccountingStatus = models.AccountingSyncSynced
+		document.AccountingBillID = billID
+		document.AccountingError = ""
+	}
+	if saveErr := s.accountingRepo.SaveExport(context.WithoutCancel(ctx), document); saveErr != nil {
+		return saveErr
+	}
+
+	if err != nil {
+		return err
+	}
+	s.createAuditLog(ctx, document.TenantID, userID, document.ID, "document", "Document exported to accounting", models.JSONB{
+		"integration_id": settings.IntegrationID.String(),
+		"bill_id":        billID,
+	})
+	return nil
+}
+
+func (s *AccountingService) createBill(ctx context.Context, document *models.Document, settings *AccountingSettings) (string, error) {
+	bill, err := s.buildBill(ctx, document, settings)
+	if err != nil {
+		return "", err
+	}
+	integration, connector, accessToken, err := s.integrations.connection(ctx, document.TenantID, *settings.IntegrationID)
+	if err != nil {
+		return "", err
+	}
+	provider, ok := connector.(AccountingProvider)
+	if !ok {
+		return "", ErrNotAccountingIntegration
+	}
+
+	billID, err := provider.CreateBill(ctx, accessToken, integration.ExternalAccountID, *bill)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAccountingExportFailed, err)
+	}
+	return billID, nil
+}
+
+// buildBill books the document's line items when it has them
+func (s *AccountingService) buildBill(ctx context.Context, document *models.Document, settings *AccountingSettings) (*AccountingBill, error) {
+	lines, err := s.lineItemRepo.ListByDocument(ctx, document.TenantID, document.ID)
+	if err != nil {
+		return nil, err
+	}
+	return buildAccountingBill(document, settings, lines)
+}
+
+func (s *AccountingService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, resourceType, message string, details models.JSONB) {
+	if details == nil {
+		details = models.JSONB{}
+	}
+	details["message"] = message
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       models.AuditUpdate,
+		ResourceType: resourceType,
+		Details:      details,
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+// Helper functions
+
+func accountingSettings(tenant *models.Tenant) (*AccountingSettings, error) {
+	settings := &AccountingSettings{}
+	if raw, ok := tenant.Settings[tenantAccountingSetting]; ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read accounting settings: %w", err)
+		}
+		if err := json.Unmarshal(data, settings); err != nil {
+			return nil, fmt.Errorf("failed to read accounting settings: %w", err)
+		}
+	}
+	return settings, nil
+}
+
+// buildAccountingBill maps a document's financial data and line items to a
+// bill. Numbers issued by the tenant's own sequences aren't the vendor's;
+// the vendor's number is then kept as the reference number.
+func buildAccountingBill(document *models.Document, settings *AccountingSettings, lineItems []models.InvoiceLineItem) (*AccountingBill, error) {
+	vendor := strings.TrimSpace(document.VendorName)
+	if document.Amount == nil || vendor == "" {
+		return nil, fmt.Errorf("%w: it needs an amount and a vendor", ErrDocumentNotExportable)
+	}
+	if *document.Amount <= 0 {
+		return nil, fmt.Errorf("%w: the amount must be positive", ErrDocumentNotExportable)
+	}
+	if tax := document.TaxAmount; tax != nil && (*tax < 0 || *tax > *document.Amount) {
+		return nil, fmt.Errorf("%w: the tax must be between zero and the amount", ErrDocumentNotExportable)
+	}
+
+	number := document.DocumentNumber
+	if document.NumberingSequenceID != nil {
+		number = document.ReferenceNumber
+	}
+	description := document.Title
+	if description == "" {
+		description = document.OriginalName
+	}
+
+	bill := &AccountingBill{
+		VendorName:     vendor,
+		Number:         number,
+		Date:           document.DocumentDate,
+		DueDate:        document.DueDate,
+		Currency:       strings.ToUpper(document.Currency),
+		Total:          *document.Amount,
+		Description:    description,
+		Memo:           "Archivus document " + document.ID.String(),
+		ExpenseAccount: settings.ExpenseAccount,
+	}
+	if document.TaxAmount != nil && *document.TaxAmount > 0 {
+		tax := *document.TaxAmount
+		bill.Tax = &tax
+	}
+	if len(lineItems) > 0 {
+		lines, err := accountingBillLines(lineItems, bill, settings)
+		if err != nil {
+			return nil, err
+		}
+		bill.Lines = lines
+	}
+	return bill, nil
+}
+
+// accountingBillLines books each line item on its GL code, or the expense
+// account without one. The lines must add up to the bill's net amount, give
+// or take a cent a line for rounding. Their tax is kept when it adds up to
+// the bill's; otherwise the bill's tax is shared out by amount.
+func accountingBillLines(lineItems []models.InvoiceLineItem, bill *AccountingBill, settings *AccountingSettings) ([]AccountingBillLine, error) {
+	lines := make([]AccountingBillLine, len(lineItems))
+	var net, lineTax float64
+	taxed := true
+	for i, item := range lineItems {
+		account := strings.TrimSpace(item.GLCode)
+		if account == "" {
+			account = settings.ExpenseAccount
+		}
+		description := item.Description
+		if description == "" {
+			description = bill.Description
+		}
+		lines[i] = AccountingBillLine{Description: description, Amount: item.Amount, Account: account}
+		net += item.Amount
+		if item.TaxAmount == nil {
+			taxed = false
+		} else {
+			lineTax += *item.TaxAmount
+		}
+	}
+	tolerance := 0.01 * float64(len(lines))
+	if math.Abs(net-bill.Net()) > tolerance {
+		return nil, fmt.Errorf("%w: its line items add up to %.2f, not %.2f before tax", ErrDocumentNotExportable, net, bill.Net())
+	}
+	if bill.Tax == nil {
+		return lines, nil
+	}
+
+	if taxed && math.Abs(lineTax-*bill.Tax) <= tolerance {
+		for i, item := range lineItems {
+			tax := *item.TaxAmount
+			lines[i].Tax = &tax
+		}
+		return lines, nil
+	}
+	remaining := *bill.Tax
+	for i := range lines {
+		tax := remaining
+		if i < len(lines)-1 && net != 0 {
+			tax = math.Round(*bill.Tax*lines[i].Amount/net*100) / 100
+		}
+		remaining -= tax
+		lines[i].Tax = &tax
+	}
+	return lines, nil
+}
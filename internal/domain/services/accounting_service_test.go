@@ -0,0 +1,114 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func accountingAmount(amount float64) *float64 {
+	return &amount
+}
+
+func TestBuildAccountingBill(t *testing.T) {
+	settings := &AccountingSettings{ExpenseAccount: "400"}
+	document := &models.Document{
+		ID:             uuid.New(),
+		OriginalName:   "scan.pdf",
+		VendorName:     " Acme Supplies ",
+		DocumentNumber: "INV-7",
+		Amount:         accountingAmount(119),
+		TaxAmount:      accountingAmount(19),
+		Currency:       "eur",
+	}
+
+	bill, err := buildAccountingBill(document, settings, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Acme Supplies", bill.VendorName)
+	assert.Equal(t, "INV-7", bill.Number)
+	assert.Equal(t, "EUR", bill.Currency)
+	assert.Equal(t, "scan.pdf", bill.Description)
+	assert.Equal(t, "400", bill.ExpenseAccount)
+	assert.Equal(t, 100.0, bill.Net())
+
+	// A number issued by the tenant's own sequence isn't the vendor's
+	sequenceID := uuid.New()
+	document.NumberingSequenceID = &sequenceID
+	document.ReferenceNumber = "A-123"
+	document.TaxAmount = accountingAmount(0)
+	bill, err = buildAccountingBill(document, settings, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "A-123", bill.Number)
+	assert.Nil(t, bill.Tax)
+	assert.Equal(t, 119.0, bill.Net())
+}
+
+func TestBuildAccountingBill_RejectsIncompleteDocuments(t *testing.T) {
+	settings := &AccountingSettings{ExpenseAccount: "400"}
+
+	_, err := buildAccountingBill(&models.Document{Amount: accountingAmount(10)}, settings, nil)
+	assert.ErrorIs(t, err, ErrDocumentNotExportable)
+
+	_, err = buildAccountingBill(&models.Document{VendorName: "Acme", Amount: accountingAmount(-10)}, settings, nil)
+	assert.ErrorIs(t, err, ErrDocumentNotExportable)
+
+	_, err = buildAccountingBill(&models.Document{VendorName: "Acme", Amount: accountingAmount(10), TaxAmount: accountingAmount(11)}, settings, nil)
+	assert.ErrorIs(t, err, ErrDocumentNotExportable)
+}
+
+func TestAccountingSettings_RoundTrip(t *testing.T) {
+	integrationID := uuid.New()
+	tenant := &models.Tenant{Settings: models.JSONB{tenantAccountingSetting: map[string]interface{}{
+		"integration_id":  integrationID.String(),
+		"expense_account": "400",
+		"auto_export":     true,
+	}}}
+
+	settings, err := accountingSettings(tenant)
+	require.NoError(t, err)
+	require.NotNil(t, settings.IntegrationID)
+	assert.Equal(t, integrationID, *settings.IntegrationID)
+	assert.True(t, settings.AutoExport)
+
+	settings, err = accountingSettings(&models.Tenant{})
+	require.NoError(t, err)
+	assert.Nil(t, settings.IntegrationID)
+}
+
+func TestBuildAccountingBill_LineItems(t *testing.T) {
+	settings := &AccountingSettings{ExpenseAccount: "400"}
+	document := &models.Document{
+		ID:         uuid.New(),
+		Title:      "Office supplies",
+		VendorName: "Acme Supplies",
+		Amount:     accountingAmount(119),
+		TaxAmount:  accountingAmount(19),
+	}
+	lines := []models.InvoiceLineItem{
+		{Description: "Paper", Amount: 25, GLCode: "6100"},
+		{Amount: 75},
+	}
+
+	// The bill's tax is shared out by amount when the lines have none
+	bill, err := buildAccountingBill(document, settings, lines)
+	require.NoError(t, err)
+	require.Len(t, bill.BillLines(), 2)
+	assert.Equal(t, AccountingBillLine{Description: "Paper", Amount: 25, Tax: accountingAmount(4.75), Account: "6100"}, bill.Lines[0])
+	assert.Equal(t, AccountingBillLine{Description: "Office supplies", Amount: 75, Tax: accountingAmount(14.25), Account: "400"}, bill.Lines[1])
+
+	// Lines' own tax is kept when it adds up
+	lines[0].TaxAmount = accountingAmount(0)
+	lines[1].TaxAmount = accountingAmount(19)
+	bill, err = buildAccountingBill(document, settings, lines)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, *bill.Lines[0].Tax)
+	assert.Equal(t, 19.0, *bill.Lines[1].Tax)
+
+	// Lines that don't add up to the net amount aren't booked
+	lines[1].Amount = 80
+	_, err = buildAccountingBill(document, settings, lines)
+	assert.ErrorIs(t, err, ErrDocumentNotExportable)
+}
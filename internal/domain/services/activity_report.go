@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// MaxActivityReportRange is the longest period one activity report covers
+const MaxActivityReportRange = 366 * 24 * time.Hour
+
+var ErrActivityReportRangeTooLong = errors.New("activity reports cover at most 366 days")
+
+// Who an activity report covers
+const (
+	ActivityReportScopeTenant = "tenant" // Everyone; for admins
+	ActivityReportScopeTeam   = "team"   // The requester's department and reporting line
+)
+
+// UserActivityReport is what each user in the requester's scope did over a
+// period, for performance and capacity reviews
+type UserActivityReport struct {
+	From  time.Time         `json:"from"`
+	To    time.Time         `json:"to"`
+	Scope string            `json:"scope"`
+	Users []UserActivityRow `json:"users"`
+}
+
+// UserActivityRow is one user's line of an activity report
+type UserActivityRow struct {
+	UserID                 uuid.UUID       `json:"user_id"`
+	Name                   string          `json:"name"`
+	Email                  string          `json:"email"`
+	Department             string          `json:"department,omitempty"`
+	Role                   models.UserRole `json:"role"`
+	DocumentsUploaded      int64           `json:"documents_uploaded"`
+	ApprovalsCompleted     int64           `json:"approvals_completed"`
+	AverageTurnaroundHours *float64        `json:"average_turnaround_hours"` // Nil without completed approvals
+	Comments               int64           `json:"comments"`
+}
+
+var activityReportCSVHeader = []string{
+	"user_id", "name", "email", "department", "role",
+	"documents_uploaded", "approvals_completed", "average_turnaround_hours", "comments",
+}
+
+// GetUserActivityReport reports the activity of the users the requester may
+// review from from (inclusive) to to (exclusive). Admins see the whole
+// tenant; anyone else sees their department and everyone who reports to
+// them, directly or not.
+func (s *AnalyticsService) GetUserActivityReport(ctx context.Context, tenantID, requesterID uuid.UUID, from, to time.Time) (*UserActivityReport, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidDateRange
+	}
+	if to.Sub(from) > MaxActivityReportRange {
+		return nil, ErrActivityReportRangeTooLong
+	}
+
+	requester, err := s.userRepo.GetByID(ctx, requesterID)
+	if err != nil || requester.TenantID != tenantID {
+		return nil, ErrUserNotFound
+	}
+
+	users, err := s.listTenantUsers(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UserActivityReport{From: from, To: to, Scope: ActivityReportScopeTenant}
+	if requester.Role != models.UserRoleAdmin {
+		report.Scope = ActivityReportScopeTeam
+		users = activityReportScope(requester, users)
+	}
+
+	userIDs := make([]uuid.UUID, len(users))
+	for i := range users {
+		userIDs[i] = users[i].ID
+	}
+	totals, err := s.analyticsRepo.GetUserActivityReport(ctx, tenantID, userIDs, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user activity: %w", err)
+	}
+	byUser := make(map[uuid.UUID]repositories.UserActivityTotals, len(totals))
+	for _, t := range totals {
+		byUser[t.UserID] = t
+	}
+
+	// Users without activity are listed too; they matter for capacity
+	report.Users = make([]UserActivityRow, 0, len(users))
+	for _, user := range users {
+		t := byUser[user.ID]
+		row := UserActivityRow{
+			UserID:             user.ID,
+			Name:               strings.TrimSpace(user.FirstName + " " + user.LastName),
+			Email:              user.Email,
+			Department:         user.Department,
+			Role:               user.Role,
+			DocumentsUploaded:  t.DocumentsUploaded,
+			ApprovalsCompleted: t.ApprovalsCompleted,
+			Comments:           t.Comments,
+		}
+		if t.ApprovalsCompleted > 0 {
+			hours := t.TurnaroundSeconds / 3600
+			row.AverageTurnaroundHours = &hours
+		}
+		report.Users = append(report.Users, row)
+	}
+	sort.Slice(report.Users, func(i, j int) bool {
+		a, b := report.Users[i], report.Users[j]
+		if a.Name != b.Name {
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		}
+		return a.Email < b.Email
+	})
+
+	return report, nil
+}
+
+// WriteCSV writes the report as CSV, one row per user
+func (r *UserActivityReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(activityReportCSVHeader); err != nil {
+		return err
+	}
+	for _, row := range r.Users {
+		turnaround := ""
+		if row.AverageTurnaroundHours != nil {
+			turnaround = strconv.FormatFloat(*row.AverageTurnaroundHours, 'f', 2, 64)
+		}
+		err := writer.Write([]string{
+			row.UserID.String(),
+			csvText(row.Name),
+			csvText(row.Email),
+			csvText(row.Department),
+			string(row.Role),
+			strconv.FormatInt(row.DocumentsUploaded, 10),
+			strconv.FormatInt(row.ApprovalsCompleted, 10),
+			turnaround,
+			strconv.FormatInt(row.Comments, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Helper methods
+
+func (s *AnalyticsService) listTenantUsers(ctx context.Context, tenantID uuid.UUID) ([]models.User, error) {
+	const pageSize = 500
+
+	var all []models.User
+	for page := 1; ; page++ {
+		users, total, err := s.userRepo.ListByTenant(ctx, tenantID, repositories.ListParams{Page: page, PageSize: pageSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+		all = append(all, users...)
+		if len(users) < pageSize || int64(len(all)) >= total {
+			return all, nil
+		}
+	}
+}
+
+// activityReportScope picks the users a non-admin requester may review:
+// themselves, their department and everyone below them in the reporting line
+func activityReportScope(requester *models.User, users []models.User) []models.User {
+	managerOf := make(map[uuid.UUID]*uuid.UUID, len(users))
+	for i := range users {
+		managerOf[users[i].ID] = users[i].ManagerID
+	}
+	reportsToRequester := func(user *models.User) bool {
+		manager := user.ManagerID
+		for depth := 0; manager != nil && depth < maxReportingDepth; depth++ {
+			if *manager == requester.ID {
+				return true
+			}
+			manager = managerOf[*manager]
+		}
+		return false
+	}
+
+	department := strings.TrimSpace(requester.Department)
+	var scoped []models.User
+	for i := range users {
+		user := &users[i]
+		switch {
+		case user.ID == requester.ID,
+			department != "" && strings.EqualFold(strings.TrimSpace(user.Department), department),
+			reportsToRequester(user):
+			scoped = append(scoped, *user)
+		}
+	}
+	return scoped
+}
+
+// csvText keeps spreadsheets from running user-entered text as a formula
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
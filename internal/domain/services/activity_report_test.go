@@ -0,0 +1,56 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityReportScope(t *testing.T) {
+	manager := models.User{ID: uuid.New(), Department: "Finance"}
+	lead := models.User{ID: uuid.New(), Department: "Operations", ManagerID: &manager.ID}
+	analyst := models.User{ID: uuid.New(), Department: "Operations", ManagerID: &lead.ID}
+	colleague := models.User{ID: uuid.New(), Department: " finance "}
+	outsider := models.User{ID: uuid.New(), Department: "Legal"}
+	users := []models.User{manager, lead, analyst, colleague, outsider}
+
+	scoped := activityReportScope(&manager, users)
+	var ids []uuid.UUID
+	for _, user := range scoped {
+		ids = append(ids, user.ID)
+	}
+	// The reporting line is followed down; the department matches loosely
+	assert.ElementsMatch(t, []uuid.UUID{manager.ID, lead.ID, analyst.ID, colleague.ID}, ids)
+
+	// Without a department only the reporting line counts
+	scoped = activityReportScope(&models.User{ID: lead.ID, ManagerID: &manager.ID}, users)
+	ids = nil
+	for _, user := range scoped {
+		ids = append(ids, user.ID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{lead.ID, analyst.ID}, ids)
+}
+
+func TestUserActivityReportWriteCSV(t *testing.T) {
+	hours := 5.25
+	report := &UserActivityReport{Users: []UserActivityRow{
+		{UserID: uuid.New(), Name: "Ada Lovelace", Email: "ada@example.com", Role: models.UserRoleManager,
+			DocumentsUploaded: 4, ApprovalsCompleted: 2, AverageTurnaroundHours: &hours, Comments: 7},
+		{UserID: uuid.New(), Name: "=HYPERLINK(\"x\")", Email: "eve@example.com", Role: models.UserRoleUser},
+	}}
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteCSV(&out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, strings.Join(activityReportCSVHeader, ","), lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], ",Ada Lovelace,ada@example.com,,manager,4,2,5.25,7"), lines[1])
+	// No turnaround without approvals, and formulas are neutralised
+	assert.Contains(t, lines[2], `"'=HYPERLINK(""x"")"`)
+	assert.True(t, strings.HasSuffix(lines[2], ",user,0,0,,0"), lines[2])
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+)
+
+// Dry-run mode runs AI jobs end to end without calling external providers.
+// Results are derived from a small sample of the document text so the same
+// document always produces the same output, which keeps integration tests
+// and demos stable and free.
+const (
+	dryRunProvider           = "dry_run"
+	dryRunSampleChars        = 2000
+	dryRunEmbeddingDimension = 1536
+
+	// tenantAIDryRunSetting is the tenant settings key that puts every AI job
+	// for the tenant into dry-run mode
+	tenantAIDryRunSetting = "ai_dry_run"
+)
+
+var (
+	dryRunEmailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	dryRunAmountPattern = regexp.MustCompile(`[$€£]\s?([0-9][0-9,]*\.?[0-9]{0,2})`)
+	dryRunDatePattern   = regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2})\b`)
+	dryRunWordPattern   = regexp.MustCompile(`[a-z]{5,}`)
+)
+
+// dryRunKeywords maps words in the sample to the document type they suggest
+var dryRunKeywords = []struct {
+	keyword string
+	docType models.DocumentType
+}{
+	{"invoice", models.DocTypeInvoice},
+	{"receipt", models.DocTypeReceipt},
+	{"statement", models.DocTypeBankStatement},
+	{"payroll", models.DocTypePayroll},
+	{"contract", models.DocTypeContract},
+	{"agreement", models.DocTypeContract},
+	{"tax", models.DocTypeTaxDocument},
+	{"policy", models.DocTypeInsurance},
+	{"report", models.DocTypeReport},
+}
+
+var dryRunStopWords = map[string]bool{
+	"about": true, "after": true, "there": true, "these": true, "their": true,
+	"which": true, "would": true, "could": true, "should": true, "other": true,
+	"where": true, "while": true, "being": true, "shall": true, "total": true,
+}
+
+// dryRunAIService is a deterministic stand-in for a provider client
+type dryRunAIService struct{}
+
+func (d dryRunAIService) ExtractText(ctx context.Context, text string) (string, error) {
+	return dryRunSample(text), nil
+}
+
+func (d dryRunAIService) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	rng := rand.New(rand.NewSource(int64(dryRunSeed(text))))
+	embedding := make([]float32, dryRunEmbeddingDimension)
+	for i := range embedding {
+		embedding[i] = rng.Float32()*2 - 1
+	}
+	return embedding, nil
+}
+
+func (d dryRunAIService) GenerateSummary(ctx context.Context, text string) (string, error) {
+	sample := strings.Join(strings.Fields(dryRunSample(text)), " ")
+	if len(sample) > 200 {
+		sample = sample[:200]
+		if cut := strings.LastIndex(sample, " "); cut > 0 {
+			sample = sample[:cut]
+		}
+		sample += "..."
+	}
+	return "[dry run] " + sample, nil
+}
+
+func (d dryRunAIService) ExtractEntities(ctx context.Context, text string) (map[string]interface{}, error) {
+	sample := dryRunSample(text)
+	return map[string]interface{}{
+		"emails":  dryRunUnique(dryRunEmailPattern.FindAllString(sample, -1)),
+		"amounts": dryRunAmounts(sample),
+		"dates":   dryRunUnique(dryRunDatePattern.FindAllString(sample, -1)),
+	}, nil
+}
+
+func (d dryRunAIService) ClassifyDocument(ctx context.Context, text string) (models.DocumentType, float64, error) {
+	sample := strings.ToLower(dryRunSample(text))
+	for _, candidate := range dryRunKeywords {
+		if strings.Contains(sample, candidate.keyword) {
+			return candidate.docType, 0.9, nil
+		}
+	}
+	return models.DocTypeGeneral, 0.5, nil
+}
+
+func (d dryRunAIService) GenerateTags(ctx context.Context, text string) ([]string, error) {
+	counts := make(map[string]int)
+	for _, word := range dryRunWordPattern.FindAllString(strings.ToLower(dryRunSample(text)), -1) {
+		if !dryRunStopWords[word] {
+			counts[word]++
+		}
+	}
+
+	words := make([]string, 0, len(counts))
+	for word := range counts {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+
+	if len(words) > 3 {
+		words = words[:3]
+	}
+	return words, nil
+}
+
+func (d dryRunAIService) ExtractFinancialData(ctx context.Context, text string, docType models.DocumentType) (map[string]interface{}, error) {
+	sample := dryRunSample(text)
+	data := map[string]interface{}{
+		"currency": "USD",
+		"dry_run":  true,
+	}
+
+	amounts := dryRunAmounts(sample)
+	if len(amounts) > 0 {
+		// The largest figure on a financial document is usually the total
+		total := amounts[0]
+		for _, amount := range amounts[1:] {
+			if amount > total {
+				total = amount
+			}
+		}
+		data["amount"] = total
+	}
+	if dates := dryRunDatePattern.FindAllString(sample, -1); len(dates) > 0 {
+		data["document_date"] = dates[0]
+	}
+	data["document_number"] = fmt.Sprintf("DRY-%06d", dryRunSeed(text)%1000000)
+
+	return data, nil
+}
+
+// SuggestFiling picks the folder and category whose name appears in the
+// sample, and the existing tags that do, falling back to GenerateTags
+func (d dryRunAIService) SuggestFiling(ctx context.Context, text string, taxonomy FilingTaxonomy) (*FilingAdvice, error) {
+	sample := strings.ToLower(dryRunSample(text))
+	advice := &FilingAdvice{Confidence: 0.5, Reason: "[dry run] names in the text"}
+	for _, folder := range taxonomy.Folders {
+		if name := strings.ToLower(path.Base(folder)); name != "/" && strings.Contains(sample, name) {
+			advice.FolderPath = folder
+			advice.Confidence = 0.9
+			break
+		}
+	}
+	for _, category := range taxonomy.Categories {
+		if strings.Contains(sample, strings.ToLower(category)) {
+			advice.Category = category
+			break
+		}
+	}
+	for _, tag := range taxonomy.Tags {
+		if len(advice.Tags) < 3 && strings.Contains(sample, strings.ToLower(tag)) {
+			advice.Tags = append(advice.Tags, tag)
+		}
+	}
+	if len(advice.Tags) == 0 {
+		advice.Tags, _ = d.GenerateTags(ctx, text)
+	}
+	return advice, nil
+}
+
+// dryRunOCRService returns placeholder OCR text without calling an OCR provider
+type dryRunOCRService struct{}
+
+func (d dryRunOCRService) ExtractText(ctx context.Context, imagePath string) (string, error) {
+	return fmt.Sprintf("[dry run] OCR text for %s", path.Base(imagePath)), nil
+}
+
+func (d dryRunOCRService) GetConfidence(ctx context.Context, imagePath string) (float64, error) {
+	return 1.0, nil
+}
+
+// Helper functions
+
+func dryRunSample(text string) string {
+	if len(text) > dryRunSampleChars {
+		return text[:dryRunSampleChars]
+	}
+	return text
+}
+
+func dryRunSeed(text string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(dryRunSample(text)))
+	return hash.Sum64()
+}
+
+func dryRunAmounts(text string) []float64 {
+	var amounts []float64
+	for _, match := range dryRunAmountPattern.FindAllStringSubmatch(text, -1) {
+		if amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64); err == nil {
+			amounts = append(amounts, amount)
+		}
+	}
+	return amounts
+}
+
+func dryRunUnique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidAICorrection = errors.New("invalid AI correction")
+
+// documentVerifiedFieldsKey is the extracted data key listing the fields a
+// user corrected, which AI processing leaves alone from then on
+const documentVerifiedFieldsKey = "verified_fields"
+
+// Defaults for the examples AI jobs are given
+const (
+	defaultAIFeedbackExamples = 3
+	aiFeedbackExcerptRunes    = 1000
+)
+
+// AIExample is a correction shown to the AI provider as a few-shot example:
+// the start of a document's text and what the user said the output should
+// have been
+type AIExample struct {
+	Input  string                 `json:"input"`
+	Output map[string]interface{} `json:"output"`
+}
+
+type aiExamplesContextKey struct{}
+
+// WithAIExamples returns a context carrying examples for the AI call made
+// with it
+func WithAIExamples(ctx context.Context, examples []AIExample) context.Context {
+	return context.WithValue(ctx, aiExamplesContextKey{}, examples)
+}
+
+// AIExamplesFromContext returns the examples AI clients add to their
+// prompt, if any
+func AIExamplesFromContext(ctx context.Context) []AIExample {
+	examples, _ := ctx.Value(aiExamplesContextKey{}).([]AIExample)
+	return examples
+}
+
+// AIFeedbackService lets users correct the summaries, classifications and
+// entities AI produced for documents. Corrected fields are marked verified
+// on the document so later jobs keep them, and the tenant's recent
+// corrections become examples in later prompts. Financial fields are
+// corrected through the VendorProfileService, which also learns from them.
+type AIFeedbackService struct {
+	feedbackRepo    repositories.AIFeedbackRepository
+	documentRepo    repositories.DocumentRepository
+	entityRepo      repositories.EntityRepository
+	documentService *DocumentService
+}
+
+// AICorrectionParams are a user's corrections of a document's AI output.
+// Fields left out aren't corrected.
+type AICorrectionParams struct {
+	Summary      *string                `json:"summary,omitempty"`
+	DocumentType *models.DocumentType   `json:"document_type,omitempty"`
+	Entities     map[string]interface{} `json:"entities,omitempty"` // Replaces all extracted entities
+}
+
+// NewAIFeedbackService creates a new AI feedback service
+func NewAIFeedbackService(
+	feedbackRepo repositories.AIFeedbackRepository,
+	documentRepo repositories.DocumentRepository,
+	entityRepo repositories.EntityRepository,
+	documentService *DocumentService,
+) *AIFeedbackService {
+	return &AIFeedbackService{
+		feedbackRepo:    feedbackRepo,
+		documentRepo:    documentRepo,
+		entityRepo:      entityRepo,
+		documentService: documentService,
+	}
+}
+
+// SubmitCorrections applies a user's corrections to a document, recording
+// one piece of feedback per kind of output corrected
+func (s *AIFeedbackService) SubmitCorrections(ctx context.Context, tenantID, userID, documentID uuid.UUID, params AICorrectionParams) (*models.Document, error) {
+	document, err := s.documentService.getTenantDocument(ctx, documentID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.documentService.CheckDocumentAccess(ctx, document, userID, models.DocPermWrite); err != nil {
+		return nil, err
+	}
+	if err := params.normalize(); err != nil {
+		return nil, err
+	}
+	if params.DocumentType != nil && *params.DocumentType != document.DocumentType {
+		err := checkDocumentType(ctx, s.documentService.docTypeRepo, tenantID, *params.DocumentType)
+		if errors.Is(err, ErrInvalidDocumentType) {
+			return nil, fmt.Errorf("%w: unknown document type %q", ErrInvalidAICorrection, *params.DocumentType)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var feedback []*models.AIFeedback
+	if params.Summary != nil {
+		feedback = append(feedback, newAIFeedback(document, userID, models.AIFeedbackSummary,
+			models.JSONB{"summary": document.Summary}, models.JSONB{"summary": *params.Summary}))
+		document.Summary = *params.Summary
+		markVerifiedFields(document, "summary")
+	}
+	if params.DocumentType != nil {
+		feedback = append(feedback, newAIFeedback(document, userID, models.AIFeedbackClassification,
+			models.JSONB{"document_type": document.DocumentType}, models.JSONB{"document_type": *params.DocumentType}))
+		document.DocumentType = *params.DocumentType
+		markVerifiedFields(document, "document_type")
+	}
+	if params.Entities != nil {
+		if document.ExtractedData == nil {
+			document.ExtractedData = make(models.JSONB)
+		}
+		feedback = append(feedback, newAIFeedback(document, userID, models.AIFeedbackEntities,
+			models.JSONB{"entities": document.ExtractedData["entities"]}, models.JSONB{"entities": params.Entities}))
+		document.ExtractedData["entities"] = params.Entities
+		markVerifiedFields(document, "entities")
+	}
+
+	document.UpdatedBy = &userID
+	document.UpdatedAt = time.Now()
+	if err := s.documentRepo.Update(ctx, document); err != nil {
+		return nil, fmt.Errorf("failed to update document: %w", err)
+	}
+	if params.Entities != nil {
+		if err := s.entityRepo.ReplaceForDocument(ctx, tenantID, document.ID, documentEntities(params.Entities)); err != nil {
+			return nil, fmt.Errorf("failed to index entities: %w", err)
+		}
+	}
+	for _, entry := range feedback {
+		if err := s.feedbackRepo.Create(ctx, entry); err != nil {
+			// Log but continue - the document has the corrections
+		}
+	}
+
+	s.documentService.createAuditLog(ctx, tenantID, userID, document.ID, models.AuditUpdate, "AI output corrected")
+
+	return document, nil
+}
+
+// ListFeedback returns the corrections made to a document, newest first
+func (s *AIFeedbackService) ListFeedback(ctx context.Context, tenantID, userID, documentID uuid.UUID) ([]models.AIFeedback, error) {
+	document, err := s.documentService.getTenantDocument(ctx, documentID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.documentService.CheckDocumentAccess(ctx, document, userID, models.DocPermRead); err != nil {
+		return nil, err
+	}
+	return s.feedbackRepo.ListByDocument(ctx, tenantID, documentID)
+}
+
+// Helper methods
+
+func (p *AICorrectionParams) normalize() error {
+	if p.Summary == nil && p.DocumentType == nil && p.Entities == nil {
+		return fmt.Errorf("%w: nothing to correct", ErrInvalidAICorrection)
+	}
+	if p.Summary != nil {
+		*p.Summary = strings.TrimSpace(*p.Summary)
+		if *p.Summary == "" {
+			return fmt.Errorf("%w: summary is empty", ErrInvalidAICorrection)
+		}
+	}
+	if p.DocumentType != nil && !documentTypeKeyPattern.MatchString(string(*p.DocumentType)) {
+		return fmt.Errorf("%w: malformed document type %q", ErrInvalidAICorrection, *p.DocumentType)
+	}
+	for name := range p.Entities {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%w: entities contains an empty key", ErrInvalidAICorrection)
+		}
+	}
+	return nil
+}
+
+// Helper functions
+
+// newAIFeedback records a correction, keeping the start of the document's
+// text as the example's input
+func newAIFeedback(document *models.Document, userID uuid.UUID, kind models.AIFeedbackKind, original, corrected models.JSONB) *models.AIFeedback {
+	excerpt := []rune(documentText(document))
+	if len(excerpt) > aiFeedbackExcerptRunes {
+		excerpt = excerpt[:aiFeedbackExcerptRunes]
+	}
+	return &models.AIFeedback{
+		TenantID:   document.TenantID,
+		DocumentID: document.ID,
+		UserID:     userID,
+		Kind:       kind,
+		Original:   original,
+		Corrected:  corrected,
+		Excerpt:    string(excerpt),
+	}
+}
+
+// aiExamples turns corrections into examples; ones without text to show
+// the provider are left out
+func aiExamples(feedback []models.AIFeedback) []AIExample {
+	var examples []AIExample
+	for _, entry := range feedback {
+		if strings.TrimSpace(entry.Excerpt) == "" || len(entry.Corrected) == 0 {
+			continue
+		}
+		examples = append(examples, AIExample{Input: entry.Excerpt, Output: entry.Corrected})
+	}
+	return examples
+}
+
+// verifiedFields returns the fields a user corrected
+func verifiedFields(document *models.Document) map[string]bool {
+	return extractedDataFields(document, documentVerifiedFieldsKey)
+}
+
+// keptFields returns the fields AI processing leaves alone: those a sidecar
+// set and those a user corrected
+func keptFields(document *models.Document) map[string]bool {
+	kept := sidecarFields(document)
+	for field := range verifiedFields(document) {
+		kept[field] = true
+	}
+	return kept
+}
+
+// markVerifiedFields records fields as corrected by a user
+func markVerifiedFields(document *models.Document, fields ...string) {
+	verified := verifiedFields(document)
+	for _, field := range fields {
+		verified[field] = true
+	}
+	names := make([]string, 0, len(verified))
+	for name := range verified {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if document.ExtractedData == nil {
+		document.ExtractedData = make(models.JSONB)
+	}
+	document.ExtractedData[documentVerifiedFieldsKey] = names
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAICorrectionParams_Normalize(t *testing.T) {
+	summary := "  A short summary. "
+	docType := models.DocTypeInvoice
+	params := AICorrectionParams{Summary: &summary, DocumentType: &docType}
+	require.NoError(t, params.normalize())
+	assert.Equal(t, "A short summary.", *params.Summary)
+
+	empty, malformed := " ", models.DocumentType("Horoscope!")
+	for _, invalid := range []AICorrectionParams{
+		{},
+		{Summary: &empty},
+		{DocumentType: &malformed},
+		{Entities: map[string]interface{}{" ": "Acme"}},
+	} {
+		assert.ErrorIs(t, invalid.normalize(), ErrInvalidAICorrection)
+	}
+}
+
+func TestApplyFinancialData_KeepsVerifiedFields(t *testing.T) {
+	amount := 10.0
+	document := &models.Document{VendorName: "Acme Ltd", DocumentNumber: "INV-1"}
+	markVerifiedFields(document, "vendor_name", "document_number")
+
+	service := &AIProcessingService{}
+	service.applyFinancialData(document, &FinancialExtractionResult{VendorName: "ACME", DocumentNumber: "1NV-1", Amount: &amount})
+
+	assert.Equal(t, "Acme Ltd", document.VendorName)
+	assert.Equal(t, "INV-1", document.DocumentNumber)
+	assert.Equal(t, 10.0, *document.Amount)
+	assert.Equal(t, []string{"document_number", "vendor_name"}, document.ExtractedData[documentVerifiedFieldsKey])
+}
+
+func TestExtractionFeedbackParams_Feedback(t *testing.T) {
+	vendor, amount := "Acme Ltd", 12.5
+	extractedAmount := 21.5
+	params := ExtractionFeedbackParams{VendorName: &vendor, Amount: &amount}
+
+	original, corrected := params.feedback(&FinancialExtractionResult{VendorName: "ACME", Amount: &extractedAmount, Currency: "USD"})
+	assert.Equal(t, models.JSONB{"vendor_name": "ACME", "amount": &extractedAmount}, original)
+	assert.Equal(t, models.JSONB{"vendor_name": "Acme Ltd", "amount": 12.5}, corrected)
+}
+
+func TestAIExamples(t *testing.T) {
+	document := &models.Document{ID: uuid.New(), ExtractedText: strings.Repeat("x", aiFeedbackExcerptRunes+10)}
+	feedback := newAIFeedback(document, uuid.New(), models.AIFeedbackSummary, models.JSONB{"summary": "old"}, models.JSONB{"summary": "new"})
+	assert.Len(t, feedback.Excerpt, aiFeedbackExcerptRunes)
+
+	// Corrections of documents without text make no example
+	examples := aiExamples([]models.AIFeedback{*feedback, {Corrected: models.JSONB{"summary": "new"}}})
+	require.Len(t, examples, 1)
+	assert.Equal(t, "new", examples[0].Output["summary"])
+
+	ctx := WithAIExamples(context.Background(), examples)
+	assert.Equal(t, examples, AIExamplesFromContext(ctx))
+	assert.Empty(t, AIExamplesFromContext(context.Background()))
+}
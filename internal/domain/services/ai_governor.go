@@ -0,0 +1,418 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// AIGovernor bounds the calls in flight to AI providers across two lanes.
+// Interactive calls, where a user is waiting on the answer, go ahead of batch
+// jobs and have slots batch jobs can never take. Each user's interactive
+// calls are rate limited, and waiting tenants and their users take turns so
+// one busy user can't hold up everybody else.
+type AIGovernor struct {
+	config AIGovernorConfig
+
+	mu            sync.Mutex
+	inFlight      int
+	batchInFlight int
+	tenants       []*aiTenantQueue // Tenants with interactive calls waiting, in turn order
+	batch         []*aiWaiter
+	buckets       map[aiUserKey]*aiTokenBucket
+	lastSweep     time.Time
+}
+
+// AIGovernorConfig holds the provider-call limits
+type AIGovernorConfig struct {
+	MaxConcurrentCalls int // Calls in flight across both lanes
+	// InteractiveReserved slots are only ever used by interactive calls
+	InteractiveReserved int
+	// Each user may start InteractiveRatePerMinute interactive calls a
+	// minute, with bursts of up to InteractiveBurst
+	InteractiveRatePerMinute int
+	InteractiveBurst         int
+}
+
+// AIGovernorStats is a snapshot of the governor's lanes
+type AIGovernorStats struct {
+	InFlight           int
+	BatchInFlight      int
+	InteractiveWaiting int
+	BatchWaiting       int
+}
+
+type aiUserKey struct {
+	tenantID uuid.UUID
+	userID   uuid.UUID
+}
+
+// aiWaiter is a call waiting for a slot; ready is closed once it has one
+type aiWaiter struct {
+	key     aiUserKey
+	batch   bool
+	granted bool
+	ready   chan struct{}
+}
+
+type aiTenantQueue struct {
+	tenantID uuid.UUID
+	users    []*aiUserQueue // Users with calls waiting, in turn order
+}
+
+type aiUserQueue struct {
+	userID  uuid.UUID
+	waiters []*aiWaiter
+}
+
+type aiTokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewAIGovernor creates a provider-call governor
+func NewAIGovernor(config AIGovernorConfig) *AIGovernor {
+	if config.MaxConcurrentCalls <= 0 {
+		config.MaxConcurrentCalls = 8
+	}
+	if config.InteractiveReserved <= 0 {
+		config.InteractiveReserved = max(1, config.MaxConcurrentCalls/4)
+	}
+	// Batch jobs always keep at least one slot
+	if config.InteractiveReserved >= config.MaxConcurrentCalls {
+		config.InteractiveReserved = config.MaxConcurrentCalls - 1
+	}
+	if config.InteractiveRatePerMinute <= 0 {
+		config.InteractiveRatePerMinute = 20
+	}
+	if config.InteractiveBurst <= 0 {
+		config.InteractiveBurst = 5
+	}
+
+	return &AIGovernor{
+		config:  config,
+		buckets: make(map[aiUserKey]*aiTokenBucket),
+	}
+}
+
+// AcquireInteractive waits for a slot for a call a user is waiting on. The
+// returned release must be called once the provider has answered. A user
+// over their rate limit gets a *RateLimitedError straight away.
+func (g *AIGovernor) AcquireInteractive(ctx context.Context, tenantID, userID uuid.UUID) (func(), error) {
+	key := aiUserKey{tenantID: tenantID, userID: userID}
+
+	g.mu.Lock()
+	if until, ok := g.takeToken(key, time.Now()); !ok {
+		g.mu.Unlock()
+		return nil, &RateLimitedError{Until: until}
+	}
+	if len(g.tenants) == 0 && g.inFlight < g.config.MaxConcurrentCalls {
+		g.inFlight++
+		g.mu.Unlock()
+		return g.releaser(false), nil
+	}
+
+	waiter := &aiWaiter{key: key, ready: make(chan struct{})}
+	g.enqueueInteractive(waiter)
+	g.mu.Unlock()
+
+	return g.wait(ctx, waiter)
+}
+
+// AcquireBatch waits for a slot for a background job's call. Batch calls
+// only get a slot when no interactive call is waiting for it.
+func (g *AIGovernor) AcquireBatch(ctx context.Context) (func(), error) {
+	g.mu.Lock()
+	if len(g.tenants) == 0 && len(g.batch) == 0 &&
+		g.inFlight < g.config.MaxConcurrentCalls && g.batchInFlight < g.batchLimit() {
+		g.inFlight++
+		g.batchInFlight++
+		g.mu.Unlock()
+		return g.releaser(true), nil
+	}
+
+	waiter := &aiWaiter{batch: true, ready: make(chan struct{})}
+	g.batch = append(g.batch, waiter)
+	g.mu.Unlock()
+
+	return g.wait(ctx, waiter)
+}
+
+// Stats reports the calls in flight and waiting
+func (g *AIGovernor) Stats() AIGovernorStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stats := AIGovernorStats{
+		InFlight:      g.inFlight,
+		BatchInFlight: g.batchInFlight,
+		BatchWaiting:  len(g.batch),
+	}
+	for _, tenant := range g.tenants {
+		for _, user := range tenant.users {
+			stats.InteractiveWaiting += len(user.waiters)
+		}
+	}
+	return stats
+}
+
+func (g *AIGovernor) batchLimit() int {
+	return g.config.MaxConcurrentCalls - g.config.InteractiveReserved
+}
+
+func (g *AIGovernor) wait(ctx context.Context, waiter *aiWaiter) (func(), error) {
+	select {
+	case <-waiter.ready:
+		return g.releaser(waiter.batch), nil
+	case <-ctx.Done():
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if waiter.granted {
+		// The slot came through as the caller gave up; pass it on
+		g.releaseLocked(waiter.batch)
+	} else {
+		g.removeWaiter(waiter)
+	}
+	return nil, ctx.Err()
+}
+
+func (g *AIGovernor) releaser(batch bool) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			g.releaseLocked(batch)
+		})
+	}
+}
+
+func (g *AIGovernor) releaseLocked(batch bool) {
+	g.inFlight--
+	if batch {
+		g.batchInFlight--
+	}
+	g.dispatch()
+}
+
+// dispatch hands free slots to waiting calls, interactive ones first
+func (g *AIGovernor) dispatch() {
+	for g.inFlight < g.config.MaxConcurrentCalls {
+		if waiter := g.nextInteractive(); waiter != nil {
+			g.grant(waiter)
+			continue
+		}
+		if len(g.batch) == 0 || g.batchInFlight >= g.batchLimit() {
+			return
+		}
+		waiter := g.batch[0]
+		g.batch = g.batch[1:]
+		g.grant(waiter)
+	}
+}
+
+func (g *AIGovernor) grant(waiter *aiWaiter) {
+	g.inFlight++
+	if waiter.batch {
+		g.batchInFlight++
+	}
+	waiter.granted = true
+	close(waiter.ready)
+}
+
+func (g *AIGovernor) enqueueInteractive(waiter *aiWaiter) {
+	var tenant *aiTenantQueue
+	for _, queued := range g.tenants {
+		if queued.tenantID == waiter.key.tenantID {
+			tenant = queued
+			break
+		}
+	}
+	if tenant == nil {
+		tenant = &aiTenantQueue{tenantID: waiter.key.tenantID}
+		g.tenants = append(g.tenants, tenant)
+	}
+
+	for _, user := range tenant.users {
+		if user.userID == waiter.key.userID {
+			user.waiters = append(user.waiters, waiter)
+			return
+		}
+	}
+	tenant.users = append(tenant.users, &aiUserQueue{userID: waiter.key.userID, waiters: []*aiWaiter{waiter}})
+}
+
+// nextInteractive takes the next call in turn: the tenant and the user whose
+// turn it is go to the back of their queues after each call
+func (g *AIGovernor) nextInteractive() *aiWaiter {
+	if len(g.tenants) == 0 {
+		return nil
+	}
+	tenant := g.tenants[0]
+	g.tenants = g.tenants[1:]
+	user := tenant.users[0]
+	tenant.users = tenant.users[1:]
+
+	waiter := user.waiters[0]
+	user.waiters = user.waiters[1:]
+	if len(user.waiters) > 0 {
+		tenant.users = append(tenant.users, user)
+	}
+	if len(tenant.users) > 0 {
+		g.tenants = append(g.tenants, tenant)
+	}
+	return waiter
+}
+
+func (g *AIGovernor) removeWaiter(waiter *aiWaiter) {
+	if waiter.batch {
+		for i, queued := range g.batch {
+			if queued == waiter {
+				g.batch = append(g.batch[:i], g.batch[i+1:]...)
+				return
+			}
+		}
+		return
+	}
+
+	for i, tenant := range g.tenants {
+		if tenant.tenantID != waiter.key.tenantID {
+			continue
+		}
+		for j, user := range tenant.users {
+			if user.userID != waiter.key.userID {
+				continue
+			}
+			for k, queued := range user.waiters {
+				if queued == waiter {
+					user.waiters = append(user.waiters[:k], user.waiters[k+1:]...)
+					break
+				}
+			}
+			if len(user.waiters) == 0 {
+				tenant.users = append(tenant.users[:j], tenant.users[j+1:]...)
+			}
+			break
+		}
+		if len(tenant.users) == 0 {
+			g.tenants = append(g.tenants[:i], g.tenants[i+1:]...)
+		}
+		return
+	}
+}
+
+// takeToken spends one of the user's interactive calls, or reports when
+// the next one becomes available
+func (g *AIGovernor) takeToken(key aiUserKey, now time.Time) (time.Time, bool) {
+	rate := float64(g.config.InteractiveRatePerMinute) / 60 // Per second
+	burst := float64(g.config.InteractiveBurst)
+
+	// Forget users whose buckets have filled up again
+	if now.Sub(g.lastSweep) > time.Minute {
+		for bucketKey, bucket := range g.buckets {
+			if bucket.tokens+now.Sub(bucket.updated).Seconds()*rate >= burst {
+				delete(g.buckets, bucketKey)
+			}
+		}
+		g.lastSweep = now
+	}
+
+	bucket := g.buckets[key]
+	if bucket == nil {
+		bucket = &aiTokenBucket{tokens: burst, updated: now}
+		g.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+		return now.Add(wait), false
+	}
+	bucket.tokens--
+	return time.Time{}, true
+}
+
+// batchAIClient holds a batch slot for each call a job makes to its provider
+type batchAIClient struct {
+	OpenAIService
+	governor *AIGovernor
+}
+
+func (c batchAIClient) ExtractText(ctx context.Context, text string) (string, error) {
+	release, err := c.governor.AcquireBatch(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return c.OpenAIService.ExtractText(ctx, text)
+}
+
+func (c batchAIClient) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	release, err := c.governor.AcquireBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.OpenAIService.GenerateEmbedding(ctx, text)
+}
+
+func (c batchAIClient) GenerateSummary(ctx context.Context, text string) (string, error) {
+	release, err := c.governor.AcquireBatch(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return c.OpenAIService.GenerateSummary(ctx, text)
+}
+
+func (c batchAIClient) ExtractEntities(ctx context.Context, text string) (map[string]interface{}, error) {
+	release, err := c.governor.AcquireBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.OpenAIService.ExtractEntities(ctx, text)
+}
+
+func (c batchAIClient) ClassifyDocument(ctx context.Context, text string) (models.DocumentType, float64, error) {
+	release, err := c.governor.AcquireBatch(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	defer release()
+	return c.OpenAIService.ClassifyDocument(ctx, text)
+}
+
+func (c batchAIClient) GenerateTags(ctx context.Context, text string) ([]string, error) {
+	release, err := c.governor.AcquireBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.OpenAIService.GenerateTags(ctx, text)
+}
+
+func (c batchAIClient) ExtractFinancialData(ctx context.Context, text string, docType models.DocumentType) (map[string]interface{}, error) {
+	release, err := c.governor.AcquireBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.OpenAIService.ExtractFinancialData(ctx, text, docType)
+}
+
+func (c batchAIClient) SuggestFiling(ctx context.Context, text string, taxonomy FilingTaxonomy) (*FilingAdvice, error) {
+	release, err := c.governor.AcquireBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.OpenAIService.SuggestFiling(ctx, text, taxonomy)
+}
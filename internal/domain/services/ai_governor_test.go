@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type aiGrant struct {
+	label   string
+	release func()
+}
+
+func TestAIGovernorRateLimitsInteractiveCalls(t *testing.T) {
+	governor := NewAIGovernor(AIGovernorConfig{InteractiveRatePerMinute: 60, InteractiveBurst: 2})
+	key := aiUserKey{tenantID: uuid.New(), userID: uuid.New()}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		_, ok := governor.takeToken(key, now)
+		require.True(t, ok)
+	}
+	until, ok := governor.takeToken(key, now)
+	assert.False(t, ok)
+	assert.Equal(t, now.Add(time.Second), until)
+
+	// Other users have their own allowance
+	_, ok = governor.takeToken(aiUserKey{tenantID: key.tenantID, userID: uuid.New()}, now)
+	assert.True(t, ok)
+
+	_, ok = governor.takeToken(key, now.Add(time.Second))
+	assert.True(t, ok)
+
+	// Spent buckets that filled up again are forgotten
+	governor.takeToken(aiUserKey{tenantID: key.tenantID, userID: uuid.New()}, now.Add(time.Hour))
+	assert.Len(t, governor.buckets, 1)
+
+	ctx := context.Background()
+	tenantID, userID := uuid.New(), uuid.New()
+	for i := 0; i < 2; i++ {
+		release, err := governor.AcquireInteractive(ctx, tenantID, userID)
+		require.NoError(t, err)
+		release()
+	}
+	_, err := governor.AcquireInteractive(ctx, tenantID, userID)
+	var limited *RateLimitedError
+	require.True(t, errors.As(err, &limited))
+	assert.ErrorIs(t, err, ErrRateLimited)
+}
+
+func TestAIGovernorInteractiveGoesFirst(t *testing.T) {
+	governor := NewAIGovernor(AIGovernorConfig{MaxConcurrentCalls: 2, InteractiveReserved: 1, InteractiveBurst: 10})
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	releaseBatch, err := governor.AcquireBatch(ctx)
+	require.NoError(t, err)
+
+	// The reserved slot is out of the batch lane's reach
+	grants := make(chan aiGrant, 2)
+	go func() {
+		release, err := governor.AcquireBatch(ctx)
+		if err == nil {
+			grants <- aiGrant{"batch", release}
+		}
+	}()
+	require.Eventually(t, func() bool { return governor.Stats().BatchWaiting == 1 }, time.Second, time.Millisecond)
+
+	releaseInteractive, err := governor.AcquireInteractive(ctx, tenantID, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, AIGovernorStats{InFlight: 2, BatchInFlight: 1, BatchWaiting: 1}, governor.Stats())
+
+	go func() {
+		release, err := governor.AcquireInteractive(ctx, tenantID, uuid.New())
+		if err == nil {
+			grants <- aiGrant{"interactive", release}
+		}
+	}()
+	require.Eventually(t, func() bool { return governor.Stats().InteractiveWaiting == 1 }, time.Second, time.Millisecond)
+
+	// The freed batch slot goes to the waiting user, not the waiting job
+	releaseBatch()
+	grant := <-grants
+	assert.Equal(t, "interactive", grant.label)
+
+	releaseInteractive()
+	grant.release()
+	grant = <-grants
+	assert.Equal(t, "batch", grant.label)
+	grant.release()
+	grant.release() // Releasing twice is harmless
+
+	assert.Equal(t, AIGovernorStats{}, governor.Stats())
+}
+
+func TestAIGovernorTakesTurnsAcrossUsers(t *testing.T) {
+	governor := NewAIGovernor(AIGovernorConfig{MaxConcurrentCalls: 1, InteractiveBurst: 10})
+	ctx := context.Background()
+	tenantID, busyUser, otherUser := uuid.New(), uuid.New(), uuid.New()
+
+	release, err := governor.AcquireInteractive(ctx, tenantID, busyUser)
+	require.NoError(t, err)
+
+	grants := make(chan aiGrant, 3)
+	queue := func(label string, userID uuid.UUID) {
+		waiting := governor.Stats().InteractiveWaiting
+		go func() {
+			release, err := governor.AcquireInteractive(ctx, tenantID, userID)
+			if err == nil {
+				grants <- aiGrant{label, release}
+			}
+		}()
+		require.Eventually(t, func() bool { return governor.Stats().InteractiveWaiting == waiting+1 }, time.Second, time.Millisecond)
+	}
+	queue("busy-1", busyUser)
+	queue("busy-2", busyUser)
+	queue("other", otherUser)
+
+	// The other user doesn't wait behind all of the busy user's calls
+	release()
+	var order []string
+	for i := 0; i < 3; i++ {
+		grant := <-grants
+		order = append(order, grant.label)
+		grant.release()
+	}
+	assert.Equal(t, []string{"busy-1", "other", "busy-2"}, order)
+}
+
+func TestAIGovernorCancelledWait(t *testing.T) {
+	governor := NewAIGovernor(AIGovernorConfig{MaxConcurrentCalls: 1, InteractiveBurst: 10})
+	release, err := governor.AcquireInteractive(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = governor.AcquireInteractive(ctx, uuid.New(), uuid.New())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = governor.AcquireBatch(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Callers that gave up leave no trace in the queues
+	assert.Equal(t, AIGovernorStats{InFlight: 1}, governor.Stats())
+	release()
+	assert.Equal(t, AIGovernorStats{}, governor.Stats())
+}
@@ -0,0 +1,552 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidAIResult            = errors.New("AI job result failed schema validation")
+	ErrUnsupportedAIResultVersion = errors.New("AI job result schema version not supported")
+)
+
+// aiResultSchemaVersion is written to every job result as "schema_version".
+// Bump it for a job type when its result shape changes incompatibly and teach
+// DecodeAIJobResult to read the older version.
+const aiResultSchemaVersion = 1
+
+// aiResultSchemaKey and aiResultDryRunKey are envelope fields shared by every
+// result; aiResultPromptVersionKey is the version of the tenant's prompt
+// template a job used, left out when it used the default
+const (
+	aiResultSchemaKey        = "schema_version"
+	aiResultDryRunKey        = "dry_run"
+	aiResultPromptVersionKey = "prompt_version"
+)
+
+// knownDocumentTypes are the built-in document types, which AI
+// classification can always choose
+var knownDocumentTypes = []models.DocumentType{
+	models.DocTypeInvoice, models.DocTypeReceipt, models.DocTypeContract,
+	models.DocTypeSpreadsheet, models.DocTypePresentationn, models.DocTypeReport,
+	models.DocTypeTaxDocument, models.DocTypePayroll, models.DocTypeBankStatement,
+	models.DocTypeInsurance, models.DocTypeLegal, models.DocTypeHR,
+	models.DocTypeMarketing, models.DocTypeGeneral,
+}
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// AIJobResult is the typed result of one AI processing job
+type AIJobResult interface {
+	Validate() error
+}
+
+// TextExtractionResult is the result of a text_extraction job
+type TextExtractionResult struct {
+	ExtractedText string `json:"extracted_text"`
+	TextLength    int    `json:"text_length"`
+	Language      string `json:"language,omitempty"` // Detected from the text
+}
+
+func (r *TextExtractionResult) Validate() error {
+	if r.TextLength != len(r.ExtractedText) {
+		return invalidAIResult("text_length does not match extracted_text")
+	}
+	return nil
+}
+
+// OCRResult is the result of an ocr job
+type OCRResult struct {
+	OCRText    string `json:"ocr_text"`
+	TextLength int    `json:"text_length"`
+	Language   string `json:"language,omitempty"` // Detected from the text
+}
+
+func (r *OCRResult) Validate() error {
+	if r.TextLength != len(r.OCRText) {
+		return invalidAIResult("text_length does not match ocr_text")
+	}
+	return nil
+}
+
+// ClassificationResult is the result of a categorization job
+type ClassificationResult struct {
+	DocumentType models.DocumentType `json:"document_type"`
+	Confidence   float64             `json:"confidence"`
+	Applied      bool                `json:"applied"`
+}
+
+func (r *ClassificationResult) Validate() error {
+	// Tenants' own types are checked against their registry when applied
+	if !isKnownDocumentType(r.DocumentType) && !documentTypeKeyPattern.MatchString(string(r.DocumentType)) {
+		return invalidAIResult(fmt.Sprintf("unknown document_type %q", r.DocumentType))
+	}
+	if !isProbability(r.Confidence) {
+		return invalidAIResult("confidence must be between 0 and 1")
+	}
+	return nil
+}
+
+// TaggingResult is the result of a tagging job
+type TaggingResult struct {
+	SuggestedTags []string `json:"suggested_tags"`
+	CreatedTags   []string `json:"created_tags"`
+	TagCount      int      `json:"tag_count"`
+}
+
+func (r *TaggingResult) Validate() error {
+	if r.TagCount != len(r.CreatedTags) {
+		return invalidAIResult("tag_count does not match created_tags")
+	}
+	for _, tag := range r.SuggestedTags {
+		if strings.TrimSpace(tag) == "" {
+			return invalidAIResult("suggested_tags contains an empty tag")
+		}
+	}
+	return nil
+}
+
+// FinancialExtractionResult is the result of a financial_extraction job
+type FinancialExtractionResult struct {
+	Amount         *float64               `json:"amount,omitempty"`
+	Currency       string                 `json:"currency,omitempty"`
+	TaxAmount      *float64               `json:"tax_amount,omitempty"`
+	VendorName     string                 `json:"vendor_name,omitempty"`
+	CustomerName   string                 `json:"customer_name,omitempty"`
+	DocumentNumber string                 `json:"document_number,omitempty"`
+	DocumentDate   string                 `json:"document_date,omitempty"` // YYYY-MM-DD
+	DueDate        string                 `json:"due_date,omitempty"`      // YYYY-MM-DD
+	Extra          map[string]interface{} `json:"extra,omitempty"`         // Provider fields without a typed home
+	LineItems      []FinancialLineItem    `json:"line_items,omitempty"`
+
+	// Vendor profile applied to the provider's output, if any
+	VendorProfileID *uuid.UUID `json:"vendor_profile_id,omitempty"`
+}
+
+func (r *FinancialExtractionResult) Validate() error {
+	for name, value := range map[string]*float64{"amount": r.Amount, "tax_amount": r.TaxAmount} {
+		if value != nil && (math.IsNaN(*value) || math.IsInf(*value, 0)) {
+			return invalidAIResult(name + " is not a finite number")
+		}
+	}
+	if len(r.LineItems) > maxFinancialLineItems {
+		return invalidAIResult(fmt.Sprintf("more than %d line items", maxFinancialLineItems))
+	}
+	for i, item := range r.LineItems {
+		if err := item.validate(); err != nil {
+			return invalidAIResult(fmt.Sprintf("line item %d: %s", i+1, err))
+		}
+	}
+	if r.Currency != "" && !currencyCodePattern.MatchString(r.Currency) {
+		return invalidAIResult(fmt.Sprintf("currency %q is not an ISO 4217 code", r.Currency))
+	}
+	for name, value := range map[string]string{"document_date": r.DocumentDate, "due_date": r.DueDate} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return invalidAIResult(name + " must be YYYY-MM-DD")
+		}
+	}
+	return nil
+}
+
+// maxFinancialLineItems bounds the lines kept from one document
+const maxFinancialLineItems = 500
+
+// FinancialLineItem is one line of an invoice or receipt as extracted.
+// Amount is before tax.
+type FinancialLineItem struct {
+	Description string   `json:"description,omitempty"`
+	Quantity    *float64 `json:"quantity,omitempty"`
+	UnitPrice   *float64 `json:"unit_price,omitempty"`
+	TaxAmount   *float64 `json:"tax_amount,omitempty"`
+	Amount      float64  `json:"amount"`
+	GLCode      string   `json:"gl_code,omitempty"`
+}
+
+func (i FinancialLineItem) validate() error {
+	for name, value := range map[string]*float64{"quantity": i.Quantity, "unit_price": i.UnitPrice, "tax_amount": i.TaxAmount, "amount": &i.Amount} {
+		if value != nil && (math.IsNaN(*value) || math.IsInf(*value, 0)) {
+			return fmt.Errorf("%s is not a finite number", name)
+		}
+	}
+	if len(i.GLCode) > 100 {
+		return fmt.Errorf("gl_code is too long")
+	}
+	return nil
+}
+
+// SummarizationResult is the result of a summarization job
+type SummarizationResult struct {
+	Summary          string  `json:"summary"`
+	SummaryLength    int     `json:"summary_length"`
+	CompressionRatio float64 `json:"compression_ratio"`
+}
+
+func (r *SummarizationResult) Validate() error {
+	if strings.TrimSpace(r.Summary) == "" {
+		return invalidAIResult("summary is empty")
+	}
+	if r.SummaryLength != len(r.Summary) {
+		return invalidAIResult("summary_length does not match summary")
+	}
+	if math.IsNaN(r.CompressionRatio) || math.IsInf(r.CompressionRatio, 0) || r.CompressionRatio < 0 {
+		return invalidAIResult("compression_ratio is not a valid ratio")
+	}
+	return nil
+}
+
+// EntityExtractionResult is the result of an entity_extraction job
+type EntityExtractionResult struct {
+	Entities    map[string]interface{} `json:"entities"`
+	EntityCount int                    `json:"entity_count"`
+}
+
+func (r *EntityExtractionResult) Validate() error {
+	if r.EntityCount != len(r.Entities) {
+		return invalidAIResult("entity_count does not match entities")
+	}
+	for name := range r.Entities {
+		if strings.TrimSpace(name) == "" {
+			return invalidAIResult("entities contains an empty key")
+		}
+	}
+	return nil
+}
+
+// ChunkingResult is the result of a chunking job
+type ChunkingResult struct {
+	Chunks    int  `json:"chunks"`              // Passages the text was split into
+	Pages     int  `json:"pages,omitempty"`     // Pages of text with page breaks
+	Unchanged bool `json:"unchanged,omitempty"` // The stored passages were kept
+}
+
+func (r *ChunkingResult) Validate() error {
+	if r.Chunks < 0 || r.Pages < 0 {
+		return invalidAIResult("chunks and pages must not be negative")
+	}
+	return nil
+}
+
+// EmbeddingResult is the result of an embedding_generation job
+type EmbeddingResult struct {
+	EmbeddingDimensions int  `json:"embedding_dimensions"`
+	Passages            int  `json:"passages,omitempty"` // Passages of the text embedded
+	Generated           bool `json:"generated"`
+}
+
+func (r *EmbeddingResult) Validate() error {
+	if r.Generated && r.EmbeddingDimensions <= 0 {
+		return invalidAIResult("embedding_dimensions must be positive")
+	}
+	return nil
+}
+
+// FilingSuggestionResult is the result of a filing_suggestion job. Folder
+// and category are left out when the provider named none of the tenant's.
+type FilingSuggestionResult struct {
+	FolderPath string   `json:"folder_path,omitempty"`
+	Category   string   `json:"category,omitempty"`
+	Tags       []string `json:"tags"`
+	Confidence float64  `json:"confidence"`
+}
+
+func (r *FilingSuggestionResult) Validate() error {
+	if !isProbability(r.Confidence) {
+		return invalidAIResult("confidence must be between 0 and 1")
+	}
+	for _, tag := range r.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return invalidAIResult("tags contains an empty tag")
+		}
+	}
+	return nil
+}
+
+// FingerprintResult is the result of a fingerprint job. Documents with too
+// little text have no text hash, and only images have an image hash.
+type FingerprintResult struct {
+	TextHashed  bool `json:"text_hashed"`
+	ImageHashed bool `json:"image_hashed"`
+}
+
+func (r *FingerprintResult) Validate() error {
+	return nil
+}
+
+// RenditionResult is the result of a thumbnail or preview job. Files no
+// renderer can draw complete without one.
+type RenditionResult struct {
+	Generated bool   `json:"generated"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	Reason    string `json:"reason,omitempty"` // Why none was generated
+}
+
+func (r *RenditionResult) Validate() error {
+	if r.Generated && (r.Width <= 0 || r.Height <= 0) {
+		return invalidAIResult("width and height must be positive")
+	}
+	return nil
+}
+
+// newAIJobResult returns an empty typed result for a job type
+func newAIJobResult(jobType string) (AIJobResult, error) {
+	switch jobType {
+	case "text_extraction":
+		return &TextExtractionResult{}, nil
+	case "ocr":
+		return &OCRResult{}, nil
+	case "categorization":
+		return &ClassificationResult{}, nil
+	case "tagging":
+		return &TaggingResult{}, nil
+	case "financial_extraction":
+		return &FinancialExtractionResult{}, nil
+	case "summarization":
+		return &SummarizationResult{}, nil
+	case "entity_extraction":
+		return &EntityExtractionResult{}, nil
+	case "chunking":
+		return &ChunkingResult{}, nil
+	case "embedding_generation":
+		return &EmbeddingResult{}, nil
+	case "filing_suggestion":
+		return &FilingSuggestionResult{}, nil
+	case "fingerprint":
+		return &FingerprintResult{}, nil
+	case RenditionThumbnail, RenditionPreview:
+		return &RenditionResult{}, nil
+	default:
+		return nil, fmt.Errorf("unknown job type: %s", jobType)
+	}
+}
+
+// encodeAIJobResult validates a typed result and converts it to the JSONB
+// stored on the job, stamped with the schema version
+func encodeAIJobResult(result AIJobResult) (models.JSONB, error) {
+	if err := result.Validate(); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode AI result: %w", err)
+	}
+
+	encoded := make(models.JSONB)
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to encode AI result: %w", err)
+	}
+	encoded[aiResultSchemaKey] = aiResultSchemaVersion
+
+	return encoded, nil
+}
+
+// DecodeAIJobResult reads a completed job's result into its typed form.
+// Results written before schemas were introduced have the same shape as
+// version 1 and are read as such.
+func DecodeAIJobResult(job *models.AIProcessingJob) (AIJobResult, error) {
+	result, err := newAIJobResult(job.JobType)
+	if err != nil {
+		return nil, err
+	}
+	if job.Result == nil {
+		return nil, invalidAIResult("result is empty")
+	}
+
+	version := aiResultSchemaVersion
+	if raw, ok := job.Result[aiResultSchemaKey]; ok {
+		number, ok := toFloat(raw)
+		if !ok {
+			return nil, invalidAIResult("schema_version is not a number")
+		}
+		version = int(number)
+	}
+	if version != aiResultSchemaVersion {
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnsupportedAIResultVersion, job.JobType, version)
+	}
+
+	raw, err := json.Marshal(job.Result)
+	if err != nil {
+		return nil, invalidAIResult(err.Error())
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return nil, invalidAIResult(err.Error())
+	}
+	if err := result.Validate(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// parseFinancialData converts free-form provider output into a typed result.
+// Numbers may arrive as JSON numbers or numeric strings; anything that can't
+// be read is rejected rather than silently dropped.
+func parseFinancialData(data map[string]interface{}) (*FinancialExtractionResult, error) {
+	result := &FinancialExtractionResult{}
+
+	for key, value := range data {
+		if value == nil {
+			continue
+		}
+		switch key {
+		case "amount", "tax_amount":
+			number, ok := toFloat(value)
+			if !ok {
+				return nil, invalidAIResult(key + " is not a number")
+			}
+			if key == "amount" {
+				result.Amount = &number
+			} else {
+				result.TaxAmount = &number
+			}
+		case "currency", "vendor_name", "customer_name", "document_number", "document_date", "due_date":
+			text, ok := value.(string)
+			if !ok {
+				return nil, invalidAIResult(key + " is not a string")
+			}
+			text = strings.TrimSpace(text)
+			switch key {
+			case "currency":
+				result.Currency = strings.ToUpper(text)
+			case "vendor_name":
+				result.VendorName = text
+			case "customer_name":
+				result.CustomerName = text
+			case "document_number":
+				result.DocumentNumber = text
+			case "document_date":
+				result.DocumentDate = text
+			case "due_date":
+				result.DueDate = text
+			}
+		case "line_items":
+			items, err := parseFinancialLineItems(value)
+			if err != nil {
+				return nil, err
+			}
+			result.LineItems = items
+		default:
+			if result.Extra == nil {
+				result.Extra = make(map[string]interface{})
+			}
+			result.Extra[key] = value
+		}
+	}
+
+	if err := result.Validate(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseFinancialLineItems reads the lines of an invoice or receipt. A line
+// without an amount gets quantity times unit price; one with neither can't
+// be booked and is rejected.
+func parseFinancialLineItems(value interface{}) ([]FinancialLineItem, error) {
+	lines, ok := value.([]interface{})
+	if !ok {
+		return nil, invalidAIResult("line_items is not a list")
+	}
+
+	items := make([]FinancialLineItem, 0, len(lines))
+	for i, line := range lines {
+		fields, ok := line.(map[string]interface{})
+		if !ok {
+			return nil, invalidAIResult(fmt.Sprintf("line item %d is not an object", i+1))
+		}
+
+		var item FinancialLineItem
+		var amount *float64
+		for key, value := range fields {
+			if value == nil {
+				continue
+			}
+			switch key {
+			case "quantity", "unit_price", "tax_amount", "amount":
+				number, ok := toFloat(value)
+				if !ok {
+					return nil, invalidAIResult(fmt.Sprintf("line item %d: %s is not a number", i+1, key))
+				}
+				switch key {
+				case "quantity":
+					item.Quantity = &number
+				case "unit_price":
+					item.UnitPrice = &number
+				case "tax_amount":
+					item.TaxAmount = &number
+				case "amount":
+					amount = &number
+				}
+			case "description", "gl_code":
+				text, ok := value.(string)
+				if !ok {
+					return nil, invalidAIResult(fmt.Sprintf("line item %d: %s is not a string", i+1, key))
+				}
+				if key == "description" {
+					item.Description = strings.TrimSpace(text)
+				} else {
+					item.GLCode = strings.TrimSpace(text)
+				}
+			}
+		}
+
+		switch {
+		case amount != nil:
+			item.Amount = *amount
+		case item.Quantity != nil && item.UnitPrice != nil:
+			item.Amount = math.Round(*item.Quantity**item.UnitPrice*100) / 100
+		default:
+			return nil, invalidAIResult(fmt.Sprintf("line item %d has no amount", i+1))
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Helper functions
+
+func invalidAIResult(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidAIResult, reason)
+}
+
+func isProbability(value float64) bool {
+	return !math.IsNaN(value) && value >= 0 && value <= 1
+}
+
+func isKnownDocumentType(docType models.DocumentType) bool {
+	return slices.Contains(knownDocumentTypes, docType)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v), ",", ""), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
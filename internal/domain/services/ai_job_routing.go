@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+)
+
+// Strategies a job's text is sent to its provider with, recorded on the job
+// result as "strategy"
+const (
+	AIStrategyDirect    = "direct"     // The whole text in one call
+	AIStrategyMapReduce = "map_reduce" // Chunks summarized, then the summaries summarized
+	AIStrategyChunked   = "chunked"    // Each chunk in its own call, results merged
+	AIStrategyTruncated = "truncated"  // Only the start of the text
+)
+
+// Envelope fields describing how a job was routed
+const (
+	aiResultStrategyKey        = "strategy"
+	aiResultEstimatedTokensKey = "estimated_tokens"
+	aiResultChunksKey          = "chunks"
+	aiResultTruncatedKey       = "truncated"
+)
+
+// Defaults for pre-flight sizing. Budgets leave room for the prompt and the
+// response within the context of the smaller models.
+const (
+	defaultAIMaxInputTokens = 6000
+	defaultAIMaxChunks      = 40
+
+	// Map-reduce summarizes summaries at most this many times before the
+	// rest is cut
+	maxAIReduceRounds = 3
+)
+
+// aiJobRoute records how a job's text was sent to its provider
+type aiJobRoute struct {
+	budget    int // Tokens per call
+	maxChunks int
+
+	Strategy        string
+	EstimatedTokens int
+	Chunks          int
+	Truncated       bool // Chunks past maxChunks were left out
+}
+
+// apply stores the route on a job's result
+func (r *aiJobRoute) apply(job *models.AIProcessingJob) {
+	if r.Strategy == "" {
+		return
+	}
+	if job.Result == nil {
+		job.Result = make(models.JSONB)
+	}
+	job.Result[aiResultStrategyKey] = r.Strategy
+	job.Result[aiResultEstimatedTokensKey] = r.EstimatedTokens
+	if r.Chunks > 0 {
+		job.Result[aiResultChunksKey] = r.Chunks
+	}
+	if r.Truncated {
+		job.Result[aiResultTruncatedKey] = true
+	}
+}
+
+// plan sizes a text before it is sent and splits it when it's over budget.
+// A job's first call decides its strategy.
+func (r *aiJobRoute) plan(text, oversized string) []string {
+	tokens := estimateTokens(text)
+	if r.Strategy == "" {
+		r.EstimatedTokens = tokens
+	}
+	if tokens <= r.budget {
+		if r.Strategy == "" {
+			r.Strategy = AIStrategyDirect
+		}
+		return []string{text}
+	}
+
+	chunks := splitAIText(text, r.budget)
+	if len(chunks) > r.maxChunks {
+		chunks = chunks[:r.maxChunks]
+		r.Truncated = true
+	}
+	if r.Strategy == "" || r.Strategy == AIStrategyDirect {
+		r.Strategy = oversized
+	}
+	if oversized == AIStrategyTruncated {
+		r.Truncated = true
+		return chunks[:1]
+	}
+	r.Chunks += len(chunks)
+	return chunks
+}
+
+// routedAIClient sends oversized texts to the provider in pieces: summaries
+// are map-reduced, entities and tags extracted per chunk and merged, and
+// calls that need a single answer over the text see only its start, where
+// the type, parties and amounts of a document usually are
+type routedAIClient struct {
+	OpenAIService
+	route *aiJobRoute
+}
+
+func (c routedAIClient) GenerateSummary(ctx context.Context, text string) (string, error) {
+	for round := 0; ; round++ {
+		strategy := AIStrategyMapReduce
+		if round == maxAIReduceRounds {
+			strategy = AIStrategyTruncated
+		}
+		chunks := c.route.plan(text, strategy)
+		if len(chunks) == 1 {
+			return c.OpenAIService.GenerateSummary(ctx, chunks[0])
+		}
+
+		summaries := make([]string, 0, len(chunks))
+		for i, chunk := range chunks {
+			summary, err := c.OpenAIService.GenerateSummary(ctx, chunk)
+			if err != nil {
+				return "", fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+			}
+			summaries = append(summaries, strings.TrimSpace(summary))
+		}
+		text = strings.Join(summaries, "\n\n")
+	}
+}
+
+func (c routedAIClient) ExtractEntities(ctx context.Context, text string) (map[string]interface{}, error) {
+	chunks := c.route.plan(text, AIStrategyChunked)
+	if len(chunks) == 1 {
+		return c.OpenAIService.ExtractEntities(ctx, chunks[0])
+	}
+
+	entities := make(map[string]interface{})
+	for i, chunk := range chunks {
+		found, err := c.OpenAIService.ExtractEntities(ctx, chunk)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+		for name, value := range found {
+			entities[name] = mergeEntityValues(entities[name], value)
+		}
+	}
+	return entities, nil
+}
+
+func (c routedAIClient) GenerateTags(ctx context.Context, text string) ([]string, error) {
+	chunks := c.route.plan(text, AIStrategyChunked)
+	if len(chunks) == 1 {
+		return c.OpenAIService.GenerateTags(ctx, chunks[0])
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+	for i, chunk := range chunks {
+		found, err := c.OpenAIService.GenerateTags(ctx, chunk)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+		for _, tag := range found {
+			key := strings.ToLower(strings.TrimSpace(tag))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (c routedAIClient) ClassifyDocument(ctx context.Context, text string) (models.DocumentType, float64, error) {
+	return c.OpenAIService.ClassifyDocument(ctx, c.route.plan(text, AIStrategyTruncated)[0])
+}
+
+func (c routedAIClient) ExtractFinancialData(ctx context.Context, text string, docType models.DocumentType) (map[string]interface{}, error) {
+	return c.OpenAIService.ExtractFinancialData(ctx, c.route.plan(text, AIStrategyTruncated)[0], docType)
+}
+
+func (c routedAIClient) SuggestFiling(ctx context.Context, text string, taxonomy FilingTaxonomy) (*FilingAdvice, error) {
+	return c.OpenAIService.SuggestFiling(ctx, c.route.plan(text, AIStrategyTruncated)[0], taxonomy)
+}
+
+func (c routedAIClient) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	return c.OpenAIService.GenerateEmbedding(ctx, c.route.plan(text, AIStrategyTruncated)[0])
+}
+
+// estimateTokens approximates the tokens a text costs. English averages about
+// four characters a token; counting runes keeps other scripts from being
+// underestimated as badly as counting bytes would.
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// splitAIText splits a text into chunks of about maxTokens, breaking at the
+// last paragraph, line or word boundary of each chunk where there is one
+func splitAIText(text string, maxTokens int) []string {
+	maxRunes := maxTokens * 4
+	runes := []rune(text)
+
+	var chunks []string
+	for len(runes) > maxRunes {
+		cut := aiChunkBoundary(runes[:maxRunes])
+		chunk := strings.TrimSpace(string(runes[:cut]))
+		if chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		runes = runes[cut:]
+	}
+	if rest := strings.TrimSpace(string(runes)); rest != "" || len(chunks) == 0 {
+		chunks = append(chunks, rest)
+	}
+	return chunks
+}
+
+// aiChunkBoundary returns where to cut a window of text, preferring a
+// paragraph break, then a line break, then a space in its second half
+func aiChunkBoundary(window []rune) int {
+	half := len(window) / 2
+	for _, isBoundary := range []func(i int) bool{
+		func(i int) bool { return window[i] == '\n' && i > 0 && window[i-1] == '\n' },
+		func(i int) bool { return window[i] == '\n' },
+		func(i int) bool { return unicode.IsSpace(window[i]) },
+	} {
+		for i := len(window) - 1; i >= half; i-- {
+			if isBoundary(i) {
+				return i + 1
+			}
+		}
+	}
+	return len(window)
+}
+
+// chunkDocumentText splits a document's text into passages of about
+// maxTokens like splitAIText, recording where each lies in the text. Form
+// feeds are taken as page breaks: passages don't cross them and, when the
+// text has any, carry the page they are on.
+func chunkDocumentText(text string, maxTokens int) []models.DocumentChunk {
+	maxRunes := maxTokens * 4
+	runes := []rune(text)
+	paged := strings.ContainsRune(text, '\f')
+
+	var chunks []models.DocumentChunk
+	page := 1
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		if end-start > maxRunes {
+			end = start + aiChunkBoundary(runes[start:start+maxRunes])
+		}
+		pageBreak := false
+		for i := start; i < end; i++ {
+			if runes[i] == '\f' {
+				end, pageBreak = i+1, true
+				break
+			}
+		}
+
+		// Offsets exclude the whitespace around the passage
+		from, to := start, end
+		for from < to && unicode.IsSpace(runes[from]) {
+			from++
+		}
+		for to > from && unicode.IsSpace(runes[to-1]) {
+			to--
+		}
+		if from < to {
+			chunk := models.DocumentChunk{
+				ChunkIndex:  len(chunks),
+				Content:     string(runes[from:to]),
+				StartOffset: from,
+				EndOffset:   to,
+			}
+			if paged {
+				chunk.Page = new(int)
+				*chunk.Page = page
+			}
+			chunks = append(chunks, chunk)
+		}
+
+		if pageBreak {
+			page++
+		}
+		start = end
+	}
+	return chunks
+}
+
+// sameDocumentChunks reports whether stored passages are split as chunks are
+func sameDocumentChunks(stored, chunks []models.DocumentChunk) bool {
+	if len(stored) != len(chunks) {
+		return false
+	}
+	for i := range chunks {
+		a, b := stored[i], chunks[i]
+		if a.Content != b.Content || a.StartOffset != b.StartOffset || a.EndOffset != b.EndOffset ||
+			(a.Page == nil) != (b.Page == nil) || (a.Page != nil && *a.Page != *b.Page) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeEntityValues combines the values a name was extracted with from
+// several chunks, keeping each distinct value once
+func mergeEntityValues(existing, found interface{}) interface{} {
+	if existing == nil {
+		return found
+	}
+
+	var values []interface{}
+	seen := make(map[string]bool)
+	for _, value := range []interface{}{existing, found} {
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		for _, item := range items {
+			key := fmt.Sprint(item)
+			if !seen[key] {
+				seen[key] = true
+				values = append(values, item)
+			}
+		}
+	}
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingAIService records the texts it is called with
+type recordingAIService struct {
+	dryRunAIService
+	calls    []string
+	entities []map[string]interface{}
+}
+
+func (r *recordingAIService) GenerateSummary(ctx context.Context, text string) (string, error) {
+	r.calls = append(r.calls, text)
+	return "summary", nil
+}
+
+func (r *recordingAIService) ExtractEntities(ctx context.Context, text string) (map[string]interface{}, error) {
+	r.calls = append(r.calls, text)
+	entities := r.entities[0]
+	r.entities = r.entities[1:]
+	return entities, nil
+}
+
+func (r *recordingAIService) ClassifyDocument(ctx context.Context, text string) (models.DocumentType, float64, error) {
+	r.calls = append(r.calls, text)
+	return models.DocTypeInvoice, 0.9, nil
+}
+
+// paragraphs returns n paragraphs of about tokens tokens each
+func paragraphs(n, tokens int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = strings.Repeat("word ", tokens*4/5)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func TestSplitAIText(t *testing.T) {
+	assert.Equal(t, []string{"short text"}, splitAIText("short text", 100))
+
+	chunks := splitAIText(paragraphs(5, 90), 100)
+	require.Len(t, chunks, 5)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, estimateTokens(chunk), 100)
+		assert.False(t, strings.HasPrefix(chunk, " ") || strings.HasSuffix(chunk, " "))
+	}
+
+	// Text without any boundary is cut hard
+	chunks = splitAIText(strings.Repeat("x", 1000), 100)
+	require.Len(t, chunks, 3)
+	assert.Len(t, chunks[0], 400)
+}
+
+func TestChunkDocumentText(t *testing.T) {
+	text := paragraphs(3, 90)
+	chunks := chunkDocumentText("  "+text+"\n", 100)
+	require.Len(t, chunks, 3)
+	for i, chunk := range chunks {
+		assert.Equal(t, i, chunk.ChunkIndex)
+		assert.Nil(t, chunk.Page)
+		// Offsets locate the passage in the text
+		assert.Equal(t, chunk.Content, string([]rune("  " + text)[chunk.StartOffset:chunk.EndOffset]))
+	}
+	assert.Equal(t, 2, chunks[0].StartOffset)
+
+	// Passages end at page breaks and carry their page
+	chunks = chunkDocumentText("Invoice 42\fTerms: 30 days\n\fÜbersicht", 100)
+	require.Len(t, chunks, 3)
+	for i, want := range []string{"Invoice 42", "Terms: 30 days", "Übersicht"} {
+		assert.Equal(t, want, chunks[i].Content)
+		require.NotNil(t, chunks[i].Page)
+		assert.Equal(t, i+1, *chunks[i].Page)
+	}
+	assert.Equal(t, 27, chunks[2].StartOffset)
+	assert.Equal(t, 36, chunks[2].EndOffset)
+
+	assert.Empty(t, chunkDocumentText(" \f ", 100))
+}
+
+func TestSameDocumentChunks(t *testing.T) {
+	chunks := chunkDocumentText("Page one\fPage two", 100)
+	stored := chunkDocumentText("Page one\fPage two", 100)
+	assert.True(t, sameDocumentChunks(stored, chunks))
+
+	// Passages stored before they had positions are split again
+	stored[1].StartOffset, stored[1].EndOffset = 0, 0
+	assert.False(t, sameDocumentChunks(stored, chunks))
+	assert.False(t, sameDocumentChunks(nil, chunks))
+}
+
+func TestRoutedAIClient_DirectBelowBudget(t *testing.T) {
+	provider := &recordingAIService{}
+	route := &aiJobRoute{budget: 100, maxChunks: 10}
+	client := routedAIClient{OpenAIService: provider, route: route}
+
+	_, err := client.GenerateSummary(context.Background(), "a short document")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a short document"}, provider.calls)
+	assert.Equal(t, AIStrategyDirect, route.Strategy)
+	assert.Equal(t, 4, route.EstimatedTokens)
+}
+
+func TestRoutedAIClient_MapReducesLargeSummaries(t *testing.T) {
+	provider := &recordingAIService{}
+	route := &aiJobRoute{budget: 100, maxChunks: 10}
+	client := routedAIClient{OpenAIService: provider, route: route}
+
+	summary, err := client.GenerateSummary(context.Background(), paragraphs(4, 90))
+	require.NoError(t, err)
+	assert.Equal(t, "summary", summary)
+	// Four chunk summaries, then one summary of them
+	require.Len(t, provider.calls, 5)
+	assert.Equal(t, strings.Repeat("summary\n\n", 3)+"summary", provider.calls[4])
+	assert.Equal(t, AIStrategyMapReduce, route.Strategy)
+	assert.Equal(t, 4, route.Chunks)
+	assert.False(t, route.Truncated)
+
+	job := &models.AIProcessingJob{Result: models.JSONB{"summary": summary}}
+	route.apply(job)
+	assert.Equal(t, AIStrategyMapReduce, job.Result[aiResultStrategyKey])
+	assert.Equal(t, 4, job.Result[aiResultChunksKey])
+}
+
+func TestRoutedAIClient_MergesChunkedEntities(t *testing.T) {
+	provider := &recordingAIService{entities: []map[string]interface{}{
+		{"vendor": "Acme", "people": []interface{}{"Ann"}},
+		{"vendor": "Acme", "people": []interface{}{"Bob", "Ann"}, "iban": "DE89"},
+	}}
+	route := &aiJobRoute{budget: 100, maxChunks: 10}
+	client := routedAIClient{OpenAIService: provider, route: route}
+
+	entities, err := client.ExtractEntities(context.Background(), paragraphs(2, 90))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"vendor": "Acme",
+		"people": []interface{}{"Ann", "Bob"},
+		"iban":   "DE89",
+	}, entities)
+	assert.Equal(t, AIStrategyChunked, route.Strategy)
+}
+
+func TestRoutedAIClient_TruncatesSingleAnswerCalls(t *testing.T) {
+	provider := &recordingAIService{}
+	route := &aiJobRoute{budget: 100, maxChunks: 10}
+	client := routedAIClient{OpenAIService: provider, route: route}
+
+	text := paragraphs(3, 90)
+	_, _, err := client.ClassifyDocument(context.Background(), text)
+	require.NoError(t, err)
+	require.Len(t, provider.calls, 1)
+	assert.True(t, strings.HasPrefix(text, provider.calls[0]))
+	assert.Equal(t, AIStrategyTruncated, route.Strategy)
+	assert.True(t, route.Truncated)
+}
+
+func TestRoutedAIClient_CapsChunks(t *testing.T) {
+	provider := &recordingAIService{}
+	route := &aiJobRoute{budget: 100, maxChunks: 2}
+	client := routedAIClient{OpenAIService: provider, route: route}
+
+	_, err := client.GenerateSummary(context.Background(), paragraphs(5, 90))
+	require.NoError(t, err)
+	assert.Len(t, provider.calls, 3)
+	assert.True(t, route.Truncated)
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAIKeyNotFound             = errors.New("AI provider key not found")
+	ErrInvalidAIProvider         = errors.New("AI provider must be openai, anthropic or gemini")
+	ErrInvalidAIKey              = errors.New("AI provider key is not in the expected format")
+	ErrAIKeyEncryptionDisabled   = errors.New("AI key encryption is not configured")
+	ErrAIKeyDecryptFailed        = errors.New("stored AI key could not be decrypted")
+	ErrInvalidAIKeyEncryptionKey = errors.New("AI key encryption key must be 32 bytes, base64 encoded")
+)
+
+// Supported AI providers. Ollama runs models on the deployment's own
+// servers, so tenants have no keys for it.
+const (
+	AIProviderOpenAI    = "openai"
+	AIProviderAnthropic = "anthropic"
+	AIProviderGemini    = "gemini"
+	AIProviderOllama    = "ollama"
+)
+
+// AI key sources recorded on processing jobs
+const (
+	AIKeySourcePlatform = "platform"
+	AIKeySourceTenant   = "tenant"
+	AIKeySourceDryRun   = "dry_run"
+)
+
+// encryptedKeyVersion prefixes ciphertexts so the scheme can be rotated later
+const encryptedKeyVersion = "v1:"
+
+// ResolvedAIKey is a decrypted tenant key ready to hand to a provider client
+type ResolvedAIKey struct {
+	KeyID    uuid.UUID
+	Provider string
+	APIKey   string
+}
+
+// AIKeyService manages tenant-supplied AI provider keys (bring your own key)
+type AIKeyService struct {
+	aiKeyRepo repositories.TenantAIKeyRepository
+	auditRepo repositories.AuditLogRepository
+
+	aead cipher.AEAD
+}
+
+// NewAIKeyService creates a new AI key service. encryptionKey is a base64
+// encoded 32-byte key; when empty, tenants cannot store keys.
+func NewAIKeyService(
+	aiKeyRepo repositories.TenantAIKeyRepository,
+	auditRepo repositories.AuditLogRepository,
+	encryptionKey string,
+) (*AIKeyService, error) {
+	service := &AIKeyService{
+		aiKeyRepo: aiKeyRepo,
+		auditRepo: auditRepo,
+	}
+
+	if encryptionKey == "" {
+		return service, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encryptionKey)
+	if err != nil || len(raw) != 32 {
+		return nil, ErrInvalidAIKeyEncryptionKey
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI key cipher: %w", err)
+	}
+	service.aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI key cipher: %w", err)
+	}
+
+	return service, nil
+}
+
+// SetAIKeyParams contains a tenant's provider key
+type SetAIKeyParams struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Provider string    `json:"provider"`
+	APIKey   string    `json:"-"`
+	SetBy    uuid.UUID `json:"set_by"`
+}
+
+// SetKey encrypts and stores the tenant's key for a provider, replacing any
+// existing key for that provider
+func (s *AIKeyService) SetKey(ctx context.Context, params SetAIKeyParams) (*models.TenantAIKey, error) {
+	provider := strings.ToLower(strings.TrimSpace(params.Provider))
+	if err := validateAIKey(provider, params.APIKey); err != nil {
+		return nil, err
+	}
+	apiKey := strings.TrimSpace(params.APIKey)
+
+	encrypted, err := s.encrypt(apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.TenantAIKey{
+		TenantID:     params.TenantID,
+		Provider:     provider,
+		EncryptedKey: encrypted,
+		KeyHint:      apiKey[len(apiKey)-4:],
+		IsEnabled:    true,
+		CreatedBy:    params.SetBy,
+	}
+	if err := s.aiKeyRepo.Upsert(ctx, key); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.SetBy, key.ID, models.AuditUpdate, "AI provider key set for "+provider)
+
+	return key, nil
+}
+
+// ListKeys lists the tenant's provider keys; plaintext keys are never returned
+func (s *AIKeyService) ListKeys(ctx context.Context, tenantID uuid.UUID) ([]models.TenantAIKey, error) {
+	return s.aiKeyRepo.ListByTenant(ctx, tenantID)
+}
+
+// SetEnabled pauses or resumes use of the tenant's key for a provider
+func (s *AIKeyService) SetEnabled(ctx context.Context, tenantID, updatedBy uuid.UUID, provider string, enabled bool) (*models.TenantAIKey, error) {
+	key, err := s.aiKeyRepo.GetByProvider(ctx, tenantID, strings.ToLower(provider))
+	if err != nil {
+		return nil, ErrAIKeyNotFound
+	}
+
+	key.IsEnabled = enabled
+	key.UpdatedAt = time.Now()
+	if err := s.aiKeyRepo.Update(ctx, key); err != nil {
+		return nil, err
+	}
+
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	s.createAuditLog(ctx, tenantID, updatedBy, key.ID, models.AuditUpdate, fmt.Sprintf("AI provider key %s for %s", state, key.Provider))
+
+	return key, nil
+}
+
+// DeleteKey removes the tenant's key for a provider; jobs fall back to the platform key
+func (s *AIKeyService) DeleteKey(ctx context.Context, tenantID, deletedBy uuid.UUID, provider string) error {
+	key, err := s.aiKeyRepo.GetByProvider(ctx, tenantID, strings.ToLower(provider))
+	if err != nil {
+		return ErrAIKeyNotFound
+	}
+
+	if err := s.aiKeyRepo.Delete(ctx, key.ID); err != nil {
+		return err
+	}
+
+	s.createAuditLog(ctx, tenantID, deletedBy, key.ID, models.AuditDelete, "AI provider key removed for "+key.Provider)
+
+	return nil
+}
+
+// ResolveKey returns the tenant's enabled key, preferring the given provider.
+// It returns nil when the tenant has no usable key and the platform key applies.
+func (s *AIKeyService) ResolveKey(ctx context.Context, tenantID uuid.UUID, preferredProvider string) (*ResolvedAIKey, error) {
+	keys, err := s.aiKeyRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidate *models.TenantAIKey
+	for i := range keys {
+		if !keys[i].IsEnabled {
+			continue
+		}
+		if candidate == nil || keys[i].Provider == preferredProvider {
+			candidate = &keys[i]
+		}
+	}
+	if candidate == nil {
+		return nil, nil
+	}
+
+	apiKey, err := s.decrypt(candidate.EncryptedKey)
+	if err != nil {
+		if err := s.aiKeyRepo.RecordUse(ctx, candidate.ID, time.Now(), err.Error()); err != nil {
+			// Log but don't fail
+		}
+		return nil, err
+	}
+
+	return &ResolvedAIKey{
+		KeyID:    candidate.ID,
+		Provider: candidate.Provider,
+		APIKey:   apiKey,
+	}, nil
+}
+
+// RecordUse stamps a tenant key after a provider call, keeping the last error
+// so admins can spot revoked or exhausted keys
+func (s *AIKeyService) RecordUse(ctx context.Context, keyID uuid.UUID, callErr error) error {
+	lastError := ""
+	if callErr != nil {
+		lastError = callErr.Error()
+	}
+	return s.aiKeyRepo.RecordUse(ctx, keyID, time.Now(), lastError)
+}
+
+// Helper methods
+
+func (s *AIKeyService) encrypt(plaintext string) (string, error) {
+	if s.aead == nil {
+		return "", ErrAIKeyEncryptionDisabled
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedKeyVersion + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *AIKeyService) decrypt(ciphertext string) (string, error) {
+	if s.aead == nil {
+		return "", ErrAIKeyEncryptionDisabled
+	}
+	if !strings.HasPrefix(ciphertext, encryptedKeyVersion) {
+		return "", ErrAIKeyDecryptFailed
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, encryptedKeyVersion))
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return "", ErrAIKeyDecryptFailed
+	}
+
+	nonce, sealed := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrAIKeyDecryptFailed
+	}
+	return string(plaintext), nil
+}
+
+func (s *AIKeyService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "ai_key",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+func validateAIKey(provider, apiKey string) error {
+	apiKey = strings.TrimSpace(apiKey)
+	if len(apiKey) < 20 || strings.ContainsAny(apiKey, " \t\n") {
+		return ErrInvalidAIKey
+	}
+
+	switch provider {
+	case AIProviderOpenAI:
+		if !strings.HasPrefix(apiKey, "sk-") {
+			return ErrInvalidAIKey
+		}
+	case AIProviderAnthropic:
+		if !strings.HasPrefix(apiKey, "sk-ant-") {
+			return ErrInvalidAIKey
+		}
+	case AIProviderGemini:
+		if !strings.HasPrefix(apiKey, "AIza") {
+			return ErrInvalidAIKey
+		}
+	default:
+		return ErrInvalidAIProvider
+	}
+	return nil
+}
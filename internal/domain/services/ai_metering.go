@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// aiResultUsageKey is the envelope field summing the tokens and cost of a
+// job's calls
+const aiResultUsageKey = "usage"
+
+// AITokenUsage is what a provider says a request used
+type AITokenUsage struct {
+	Model        string
+	InputTokens  int
+	OutputTokens int
+}
+
+type aiUsageContextKey struct{}
+
+// aiCallUsage collects the usage reported during one call
+type aiCallUsage struct {
+	mu       sync.Mutex
+	requests []AITokenUsage
+}
+
+// ReportAIUsage records what a provider request used against the job the
+// call belongs to. Clients call it with the call's context after each
+// request they make; calls that report nothing are estimated from their
+// text.
+func ReportAIUsage(ctx context.Context, usage AITokenUsage) {
+	if call, ok := ctx.Value(aiUsageContextKey{}).(*aiCallUsage); ok {
+		call.mu.Lock()
+		call.requests = append(call.requests, usage)
+		call.mu.Unlock()
+	}
+}
+
+// aiUsageMeter collects the usage of a job's provider calls, to be recorded
+// once the job is done
+type aiUsageMeter struct {
+	usage          *AIUsageService
+	tenantID       uuid.UUID
+	jobID          uuid.UUID
+	documentID     uuid.UUID
+	provider       string
+	keySource      string
+	model          string // Assumed for calls that don't report one
+	embeddingModel string
+
+	mu    sync.Mutex
+	calls []models.AIUsage
+}
+
+// start returns the context a call reports its usage through
+func (m *aiUsageMeter) start(ctx context.Context) (context.Context, *aiCallUsage) {
+	call := &aiCallUsage{}
+	return context.WithValue(ctx, aiUsageContextKey{}, call), call
+}
+
+// record prices a call. Failed calls are only recorded when the provider
+// reported what they used.
+func (m *aiUsageMeter) record(call *aiCallUsage, operation, input, output string, err error) {
+	call.mu.Lock()
+	requests := call.requests
+	call.mu.Unlock()
+
+	usage := models.AIUsage{
+		TenantID:   m.tenantID,
+		JobID:      &m.jobID,
+		DocumentID: &m.documentID,
+		Provider:   m.provider,
+		Model:      m.model,
+		Operation:  operation,
+		KeySource:  m.keySource,
+	}
+	if operation == "generate_embedding" && m.embeddingModel != "" {
+		usage.Model = m.embeddingModel
+	}
+
+	switch {
+	case len(requests) > 0:
+		for _, request := range requests {
+			if request.Model != "" {
+				usage.Model = request.Model
+			}
+			usage.InputTokens += request.InputTokens
+			usage.OutputTokens += request.OutputTokens
+		}
+	case err != nil:
+		return
+	default:
+		usage.Estimated = true
+		usage.InputTokens = estimateTokens(input)
+		usage.OutputTokens = estimateTokens(output)
+	}
+	usage.Cost = m.usage.Cost(usage.Provider, usage.Model, usage.InputTokens, usage.OutputTokens)
+
+	m.mu.Lock()
+	m.calls = append(m.calls, usage)
+	m.mu.Unlock()
+}
+
+// totals sums the job's calls for its result
+func (m *aiUsageMeter) totals() (calls []models.AIUsage, summary models.JSONB) {
+	m.mu.Lock()
+	calls = append([]models.AIUsage(nil), m.calls...)
+	m.mu.Unlock()
+
+	var inputTokens, outputTokens int
+	var cost float64
+	for _, call := range calls {
+		inputTokens += call.InputTokens
+		outputTokens += call.OutputTokens
+		cost += call.Cost
+	}
+	return calls, models.JSONB{
+		"calls":         len(calls),
+		"input_tokens":  inputTokens,
+		"output_tokens": outputTokens,
+		"cost":          cost,
+	}
+}
+
+// meteredAIClient records the tokens and cost of the calls a job makes to
+// its AI provider
+type meteredAIClient struct {
+	OpenAIService
+	meter *aiUsageMeter
+}
+
+func (c meteredAIClient) ExtractText(ctx context.Context, text string) (string, error) {
+	ctx, call := c.meter.start(ctx)
+	result, err := c.OpenAIService.ExtractText(ctx, text)
+	c.meter.record(call, "extract_text", text, result, err)
+	return result, err
+}
+
+func (c meteredAIClient) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	ctx, call := c.meter.start(ctx)
+	result, err := c.OpenAIService.GenerateEmbedding(ctx, text)
+	c.meter.record(call, "generate_embedding", text, "", err)
+	return result, err
+}
+
+func (c meteredAIClient) GenerateSummary(ctx context.Context, text string) (string, error) {
+	ctx, call := c.meter.start(ctx)
+	result, err := c.OpenAIService.GenerateSummary(ctx, text)
+	c.meter.record(call, "generate_summary", text, result, err)
+	return result, err
+}
+
+func (c meteredAIClient) ExtractEntities(ctx context.Context, text string) (map[string]interface{}, error) {
+	ctx, call := c.meter.start(ctx)
+	result, err := c.OpenAIService.ExtractEntities(ctx, text)
+	c.meter.record(call, "extract_entities", text, meteredJSON(result), err)
+	return result, err
+}
+
+func (c meteredAIClient) ClassifyDocument(ctx context.Context, text string) (models.DocumentType, float64, error) {
+	ctx, call := c.meter.start(ctx)
+	documentType, confidence, err := c.OpenAIService.ClassifyDocument(ctx, text)
+	c.meter.record(call, "classify_document", text, string(documentType), err)
+	return documentType, confidence, err
+}
+
+func (c meteredAIClient) GenerateTags(ctx context.Context, text string) ([]string, error) {
+	ctx, call := c.meter.start(ctx)
+	result, err := c.OpenAIService.GenerateTags(ctx, text)
+	c.meter.record(call, "generate_tags", text, strings.Join(result, ", "), err)
+	return result, err
+}
+
+func (c meteredAIClient) ExtractFinancialData(ctx context.Context, text string, docType models.DocumentType) (map[string]interface{}, error) {
+	ctx, call := c.meter.start(ctx)
+	result, err := c.OpenAIService.ExtractFinancialData(ctx, text, docType)
+	c.meter.record(call, "extract_financial_data", text, meteredJSON(result), err)
+	return result, err
+}
+
+func (c meteredAIClient) SuggestFiling(ctx context.Context, text string, taxonomy FilingTaxonomy) (*FilingAdvice, error) {
+	ctx, call := c.meter.start(ctx)
+	result, err := c.OpenAIService.SuggestFiling(ctx, text, taxonomy)
+	var output string
+	if result != nil {
+		data, _ := json.Marshal(result)
+		output = string(data)
+	}
+	c.meter.record(call, "suggest_filing", text, output, err)
+	return result, err
+}
+
+// meteredJSON is a structured result as the provider would have written it
+func meteredJSON(result map[string]interface{}) string {
+	if len(result) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(result)
+	return string(data)
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// AI reprocessing errors
+var (
+	ErrAIReprocessBatchNotFound = errors.New("AI reprocessing batch not found")
+	ErrInvalidAIReprocess       = errors.New("reprocessing needs at least one known AI job type")
+	ErrAIReprocessEmpty         = errors.New("no documents match the filter")
+	ErrAIReprocessTooLarge      = errors.New("filter matches too many documents")
+)
+
+// errAIReprocessThrottled stops a run while enough of the batch's jobs are
+// still waiting or running
+var errAIReprocessThrottled = errors.New("batch has as many jobs in flight as it may")
+
+// AIReprocessConfig holds configuration for AI reprocessing batches
+type AIReprocessConfig struct {
+	Interval      time.Duration // How often due batches are run; defaults to 15 seconds
+	Lease         time.Duration // How long a worker owns a batch between pages; defaults to 5 minutes
+	MaxAttempts   int           // Attempts before a batch fails; defaults to 3
+	BatchesPerRun int           // Batches run per interval; defaults to 5
+	PageSize      int           // Documents queued between throttle checks; defaults to 100
+	MaxInFlight   int           // Jobs of a batch waiting or running at once; defaults to 500
+	MaxDocuments  int           // Most documents one batch may match; defaults to 100000
+}
+
+// AIReprocessParams selects the AI jobs to queue again and the documents to
+// queue them for
+type AIReprocessParams struct {
+	TenantID    uuid.UUID
+	RequestedBy uuid.UUID
+	JobTypes    []string
+	Filters     repositories.DocumentFilters
+	Priority    ProcessingPriority // Defaults to low, behind uploads
+	MaxInFlight int                // Defaults to the configured limit, which it may not exceed
+}
+
+// AIReprocessProgress is a batch with how far its jobs got
+type AIReprocessProgress struct {
+	models.AIReprocessBatch
+	Jobs AIReprocessJobCounts `json:"jobs"`
+}
+
+// AIReprocessJobCounts counts a batch's jobs by status
+type AIReprocessJobCounts struct {
+	Total      int64 `json:"total"`
+	Queued     int64 `json:"queued"`
+	Processing int64 `json:"processing"`
+	Completed  int64 `json:"completed"`
+	Failed     int64 `json:"failed"`
+}
+
+// aiReprocessJobTypes are the job types a batch queues
+type aiReprocessJobTypes struct {
+	JobTypes []string `json:"job_types"`
+}
+
+// AIReprocessService queues AI jobs again for every document matching a
+// filter, such as after prompts improved or embeddings were turned on. Jobs
+// are queued in the background a page of documents at a time, at low
+// priority by default, and the worker holds back while the batch has
+// MaxInFlight jobs waiting or running, so a large batch doesn't crowd out
+// new uploads. Each job records its batch, so its progress can be followed.
+type AIReprocessService struct {
+	documentService *DocumentService
+	docRepo         repositories.DocumentRepository
+	aiJobRepo       repositories.AIProcessingJobRepository
+	batchRepo       repositories.AIReprocessBatchRepository
+	auditRepo       repositories.AuditLogRepository
+	config          AIReprocessConfig
+}
+
+// NewAIReprocessService creates a new AI reprocessing service
+func NewAIReprocessService(
+	documentService *DocumentService,
+	docRepo repositories.DocumentRepository,
+	aiJobRepo repositories.AIProcessingJobRepository,
+	batchRepo repositories.AIReprocessBatchRepository,
+	auditRepo repositories.AuditLogRepository,
+	config AIReprocessConfig,
+) *AIReprocessService {
+	if config.Interval <= 0 {
+		config.Interval = 15 * time.Second
+	}
+	if config.Lease <= 0 {
+		config.Lease = 5 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.BatchesPerRun <= 0 {
+		config.BatchesPerRun = 5
+	}
+	if config.PageSize <= 0 {
+		config.PageSize = 100
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = 500
+	}
+	if config.MaxDocuments <= 0 {
+		config.MaxDocuments = 100000
+	}
+
+	return &AIReprocessService{
+		documentService: documentService,
+		docRepo:         docRepo,
+		aiJobRepo:       aiJobRepo,
+		batchRepo:       batchRepo,
+		auditRepo:       auditRepo,
+		config:          config,
+	}
+}
+
+// StartBatch queues a reprocessing batch for the background worker
+func (s *AIReprocessService) StartBatch(ctx context.Context, params AIReprocessParams) (*models.AIReprocessBatch, error) {
+	if err := s.validate(&params); err != nil {
+		return nil, err
+	}
+
+	filters := params.Filters
+	filters.ListParams = repositories.ListParams{Page: 1, PageSize: 1, Search: filters.Search}
+	_, matched, err := s.documentService.ListDocuments(ctx, params.TenantID, params.RequestedBy, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	if matched == 0 {
+		return nil, ErrAIReprocessEmpty
+	}
+	if matched > int64(s.config.MaxDocuments) {
+		return nil, fmt.Errorf("%w: %d match, at most %d can be reprocessed at once", ErrAIReprocessTooLarge, matched, s.config.MaxDocuments)
+	}
+
+	criteria, err := filterCriteria(params.Filters)
+	if err != nil {
+		return nil, err
+	}
+	jobTypes, err := toJSONB(aiReprocessJobTypes{JobTypes: params.JobTypes})
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &models.AIReprocessBatch{
+		ID:          uuid.New(),
+		TenantID:    params.TenantID,
+		JobTypes:    jobTypes,
+		Criteria:    criteria,
+		Priority:    params.Priority.JobPriority(),
+		MaxInFlight: params.MaxInFlight,
+		Status:      models.AIReprocessPending,
+		Matched:     matched,
+		RequestedBy: params.RequestedBy,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.batchRepo.Create(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(batch, models.AuditCreate, fmt.Sprintf("AI reprocessing queued for %d documents", batch.Matched))
+	return batch, nil
+}
+
+// GetBatch returns one of the tenant's batches with how far its jobs got
+func (s *AIReprocessService) GetBatch(ctx context.Context, tenantID, batchID uuid.UUID) (*AIReprocessProgress, error) {
+	batch, err := s.batchRepo.GetByID(ctx, batchID)
+	if err != nil || batch.TenantID != tenantID {
+		return nil, ErrAIReprocessBatchNotFound
+	}
+
+	counts, err := s.aiJobRepo.CountByBatch(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &AIReprocessProgress{AIReprocessBatch: *batch, Jobs: newAIReprocessJobCounts(counts)}, nil
+}
+
+// ListBatches returns the tenant's batches, newest first
+func (s *AIReprocessService) ListBatches(ctx context.Context, tenantID uuid.UUID, params repositories.ListParams) ([]models.AIReprocessBatch, int64, error) {
+	return s.batchRepo.ListByTenant(ctx, tenantID, params)
+}
+
+// ProcessDue runs the batches that are waiting and returns how many
+// finished queuing their jobs
+func (s *AIReprocessService) ProcessDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.batchRepo.ListDue(ctx, now, s.config.BatchesPerRun)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range due {
+		batch := &due[i]
+		claimed, err := s.batchRepo.Claim(ctx, batch.ID, now, now.Add(s.config.Lease))
+		if err != nil {
+			return processed, err
+		}
+		if !claimed {
+			// Another instance got it first
+			continue
+		}
+		batch.Attempts++
+
+		err = s.run(ctx, batch)
+		switch {
+		case errors.Is(err, errAIReprocessThrottled):
+			// Waiting for the batch's jobs isn't an attempt
+			batch.Attempts--
+			batch.Status = models.AIReprocessPending
+		case err != nil:
+			batch.Error = err.Error()
+			batch.Status = models.AIReprocessPending
+			if batch.Attempts >= s.config.MaxAttempts {
+				batch.Status = models.AIReprocessFailed
+			}
+		default:
+			completedAt := time.Now()
+			batch.Error = ""
+			batch.Status = models.AIReprocessCompleted
+			batch.CompletedAt = &completedAt
+		}
+
+		if err := s.batchRepo.Finish(ctx, batch); err != nil {
+			return processed, err
+		}
+		if batch.Status != models.AIReprocessPending {
+			processed++
+			s.createAuditLog(batch, models.AuditUpdate, fmt.Sprintf("AI reprocessing %s for %d documents", batch.Status, batch.Queued))
+		}
+	}
+
+	return processed, nil
+}
+
+// BatchTask is the scheduled task that runs AI reprocessing batches
+func (s *AIReprocessService) BatchTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "ai_reprocess_batches",
+		Interval: s.config.Interval,
+		Run: func(ctx context.Context) error {
+			_, err := s.ProcessDue(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+// validate checks the job types and applies the defaults
+func (s *AIReprocessService) validate(params *AIReprocessParams) error {
+	if len(params.JobTypes) == 0 {
+		return ErrInvalidAIReprocess
+	}
+	seen := make(map[string]bool, len(params.JobTypes))
+	jobTypes := make([]string, 0, len(params.JobTypes))
+	for _, jobType := range params.JobTypes {
+		if _, err := newAIJobResult(jobType); err != nil {
+			return fmt.Errorf("%w: unknown job type %q", ErrInvalidAIReprocess, jobType)
+		}
+		if !seen[jobType] {
+			seen[jobType] = true
+			jobTypes = append(jobTypes, jobType)
+		}
+	}
+	params.JobTypes = jobTypes
+
+	if params.Priority == "" {
+		params.Priority = ProcessingPriorityLow
+	}
+	if params.MaxInFlight <= 0 || params.MaxInFlight > s.config.MaxInFlight {
+		params.MaxInFlight = s.config.MaxInFlight
+	}
+
+	// Only live documents are reprocessed
+	params.Filters.Trashed = false
+	params.Filters.TrashViewerID = nil
+	return nil
+}
+
+// run queues the jobs of the matching documents after the batch's cursor a
+// page at a time, saving progress after each page. The filter is run again
+// with the requester's current access, so documents they lost access to are
+// skipped.
+func (s *AIReprocessService) run(ctx context.Context, batch *models.AIReprocessBatch) error {
+	filters, err := criteriaFilters(batch.Criteria)
+	if err != nil {
+		return err
+	}
+	var jobTypes aiReprocessJobTypes
+	if err := fromJSONB(batch.JobTypes, &jobTypes); err != nil {
+		return err
+	}
+
+	denied, viewerID, err := s.documentService.documentVisibility(ctx, batch.TenantID, batch.RequestedBy)
+	if err != nil {
+		return err
+	}
+	filters.ExcludeFolderIDs = denied
+	filters.ViewerID = viewerID
+
+	for {
+		counts, err := s.aiJobRepo.CountByBatch(ctx, batch.ID)
+		if err != nil {
+			return err
+		}
+		if counts[models.ProcessingQueued]+counts[models.ProcessingInProgress] >= int64(batch.MaxInFlight) {
+			return errAIReprocessThrottled
+		}
+
+		documentIDs, err := s.docRepo.ListIDsAfter(ctx, batch.TenantID, filters, batch.Cursor, s.config.PageSize)
+		if err != nil {
+			return err
+		}
+		if len(documentIDs) == 0 {
+			return nil
+		}
+
+		for _, documentID := range documentIDs {
+			for _, jobType := range jobTypes.JobTypes {
+				job := &models.AIProcessingJob{
+					TenantID:   batch.TenantID,
+					DocumentID: documentID,
+					JobType:    jobType,
+					Priority:   batch.Priority,
+					BatchID:    &batch.ID,
+				}
+				if err := s.aiJobRepo.Create(ctx, job); err != nil {
+					return fmt.Errorf("failed to queue job %s: %w", jobType, err)
+				}
+			}
+		}
+
+		cursor := documentIDs[len(documentIDs)-1]
+		batch.Cursor = &cursor
+		batch.Queued += int64(len(documentIDs))
+		if err := s.batchRepo.SaveProgress(ctx, batch.ID, cursor, batch.Queued, time.Now().Add(s.config.Lease)); err != nil {
+			return err
+		}
+
+		if len(documentIDs) < s.config.PageSize {
+			return nil
+		}
+	}
+}
+
+func (s *AIReprocessService) createAuditLog(batch *models.AIReprocessBatch, action models.AuditAction, message string) {
+	log := &models.AuditLog{
+		TenantID:     batch.TenantID,
+		UserID:       batch.RequestedBy,
+		ResourceID:   batch.ID,
+		Action:       action,
+		ResourceType: "ai_reprocess_batch",
+		Details: models.JSONB{
+			"message":   message,
+			"criteria":  batch.Criteria,
+			"job_types": batch.JobTypes,
+		},
+	}
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+// Helper functions
+
+func newAIReprocessJobCounts(counts map[models.ProcessingStatus]int64) AIReprocessJobCounts {
+	jobs := AIReprocessJobCounts{
+		Queued:     counts[models.ProcessingQueued],
+		Processing: counts[models.ProcessingInProgress],
+		Completed:  counts[models.ProcessingCompleted],
+		Failed:     counts[models.ProcessingFailed],
+	}
+	for _, count := range counts {
+		jobs.Total += count
+	}
+	return jobs
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAIReprocessValidate(t *testing.T) {
+	service := NewAIReprocessService(nil, nil, nil, nil, nil, AIReprocessConfig{MaxInFlight: 50})
+
+	viewer := uuid.New()
+	params := AIReprocessParams{
+		JobTypes:    []string{"summarization", "embedding_generation", "summarization"},
+		MaxInFlight: 1000,
+	}
+	params.Filters.Trashed = true
+	params.Filters.TrashViewerID = &viewer
+	require.NoError(t, service.validate(&params))
+	assert.Equal(t, []string{"summarization", "embedding_generation"}, params.JobTypes)
+	assert.Equal(t, ProcessingPriorityLow, params.Priority)
+	assert.Equal(t, 50, params.MaxInFlight)
+	assert.False(t, params.Filters.Trashed)
+	assert.Nil(t, params.Filters.TrashViewerID)
+
+	params = AIReprocessParams{JobTypes: []string{"summarization"}, Priority: ProcessingPriorityHigh, MaxInFlight: 10}
+	require.NoError(t, service.validate(&params))
+	assert.Equal(t, ProcessingPriorityHigh, params.Priority)
+	assert.Equal(t, 10, params.MaxInFlight)
+
+	assert.ErrorIs(t, service.validate(&AIReprocessParams{}), ErrInvalidAIReprocess)
+	assert.ErrorIs(t, service.validate(&AIReprocessParams{JobTypes: []string{"summarization", "teleport"}}), ErrInvalidAIReprocess)
+}
+
+func TestNewAIReprocessJobCounts(t *testing.T) {
+	jobs := newAIReprocessJobCounts(map[models.ProcessingStatus]int64{
+		models.ProcessingQueued:     4,
+		models.ProcessingInProgress: 1,
+		models.ProcessingCompleted:  10,
+		models.ProcessingFailed:     2,
+	})
+	assert.Equal(t, AIReprocessJobCounts{Total: 17, Queued: 4, Processing: 1, Completed: 10, Failed: 2}, jobs)
+	assert.Equal(t, AIReprocessJobCounts{}, newAIReprocessJobCounts(nil))
+}
@@ -1,17 +1,23 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/archivus/archivus/internal/domain/repositories"
 	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/pkg/logger"
 	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -19,26 +25,45 @@ var (
 	ErrInvalidFileFormat    = errors.New("invalid file format for AI processing")
 	ErrProcessingTimeout    = errors.New("AI processing timeout")
 	ErrInsufficientCredits  = errors.New("insufficient AI credits")
+
+	ErrAIProviderNotConfigured = errors.New("AI provider is not configured")
 )
 
 // AIProcessingService orchestrates AI-powered document analysis
 type AIProcessingService struct {
-	aiJobRepo    repositories.AIProcessingJobRepository
-	documentRepo repositories.DocumentRepository
-	tagRepo      repositories.TagRepository
-	categoryRepo repositories.CategoryRepository
-	tenantRepo   repositories.TenantRepository
-	auditRepo    repositories.AuditLogRepository
+	aiJobRepo       repositories.AIProcessingJobRepository
+	documentRepo    repositories.DocumentRepository
+	tagRepo         repositories.TagRepository
+	categoryRepo    repositories.CategoryRepository
+	tenantRepo      repositories.TenantRepository
+	auditRepo       repositories.AuditLogRepository
+	lineItemRepo    repositories.InvoiceLineItemRepository
+	feedbackRepo    repositories.AIFeedbackRepository
+	chunkRepo       repositories.DocumentChunkRepository
+	folderRepo      repositories.FolderRepository
+	filingRepo      repositories.FilingSuggestionRepository
+	entityRepo      repositories.EntityRepository
+	fingerprintRepo repositories.DocumentFingerprintRepository
+	promptRepo      repositories.PromptTemplateRepository
+	docTypeRepo     repositories.DocumentTypeRepository
 
 	openAIService  OpenAIService
+	keyResolver    AIKeyResolver
+	clientFactory  AIClientFactory
 	ocrService     OCRService
 	storageService StorageService
+	events         EventPublisher
+	vendorProfiles *VendorProfileService
+	aiUsage        *AIUsageService
+	governor       *AIGovernor
 	config         AIServiceConfig
 }
 
 // AIServiceConfig holds configuration for AI processing
 type AIServiceConfig struct {
 	OpenAIAPIKey             string
+	PlatformProvider         string // Provider behind openAIService; tenant keys for it are preferred
+	DryRun                   bool   // Run every job in dry-run mode (staging, demos)
 	MaxConcurrentJobs        int
 	ProcessingTimeout        time.Duration
 	EnableSemanticSearch     bool
@@ -48,6 +73,26 @@ type AIServiceConfig struct {
 	EmbeddingModel           string
 	MaxTokens                int
 	Temperature              float64
+
+	// Pre-flight sizing: texts estimated above MaxInputTokens are split into
+	// chunks of that size, at most MaxChunks of them per call
+	MaxInputTokens int
+	MaxChunks      int
+
+	// Providers are the platform's clients for other providers than
+	// PlatformProvider. Tenants pick one in their ai_provider setting and
+	// JobProviders runs job types on one, e.g. embeddings on OpenAI or
+	// everything on a local Ollama server.
+	Providers    map[string]OpenAIService
+	JobProviders map[string]string // Job type to provider
+
+	// FeedbackExamples is how many of the tenant's recent corrections are
+	// given to the provider as examples per job; defaults to 3
+	FeedbackExamples int
+
+	// Governor is shared with interactive AI so user requests get ahead of
+	// jobs; without one the jobs get a governor of their own
+	Governor *AIGovernor
 }
 
 // NewAIProcessingService creates a new AI processing service
@@ -58,27 +103,82 @@ func NewAIProcessingService(
 	categoryRepo repositories.CategoryRepository,
 	tenantRepo repositories.TenantRepository,
 	auditRepo repositories.AuditLogRepository,
+	lineItemRepo repositories.InvoiceLineItemRepository,
+	feedbackRepo repositories.AIFeedbackRepository,
+	chunkRepo repositories.DocumentChunkRepository,
+	folderRepo repositories.FolderRepository,
+	filingRepo repositories.FilingSuggestionRepository,
+	entityRepo repositories.EntityRepository,
+	fingerprintRepo repositories.DocumentFingerprintRepository,
+	promptRepo repositories.PromptTemplateRepository,
+	docTypeRepo repositories.DocumentTypeRepository,
 	openAIService OpenAIService,
+	keyResolver AIKeyResolver,
+	clientFactory AIClientFactory,
 	ocrService OCRService,
 	storageService StorageService,
+	events EventPublisher,
+	vendorProfiles *VendorProfileService,
+	aiUsage *AIUsageService,
 	config AIServiceConfig,
 ) *AIProcessingService {
+	governor := config.Governor
+	if governor == nil {
+		governor = NewAIGovernor(AIGovernorConfig{MaxConcurrentCalls: config.MaxConcurrentJobs})
+	}
+	if config.MaxInputTokens <= 0 {
+		config.MaxInputTokens = defaultAIMaxInputTokens
+	}
+	if config.MaxChunks <= 0 {
+		config.MaxChunks = defaultAIMaxChunks
+	}
+	if config.FeedbackExamples <= 0 {
+		config.FeedbackExamples = defaultAIFeedbackExamples
+	}
+	if config.PlatformProvider == "" {
+		config.PlatformProvider = AIProviderOpenAI
+	}
+
 	return &AIProcessingService{
-		aiJobRepo:      aiJobRepo,
-		documentRepo:   documentRepo,
-		tagRepo:        tagRepo,
-		categoryRepo:   categoryRepo,
-		tenantRepo:     tenantRepo,
-		auditRepo:      auditRepo,
-		openAIService:  openAIService,
-		ocrService:     ocrService,
-		storageService: storageService,
-		config:         config,
+		aiJobRepo:       aiJobRepo,
+		documentRepo:    documentRepo,
+		tagRepo:         tagRepo,
+		categoryRepo:    categoryRepo,
+		tenantRepo:      tenantRepo,
+		auditRepo:       auditRepo,
+		lineItemRepo:    lineItemRepo,
+		feedbackRepo:    feedbackRepo,
+		chunkRepo:       chunkRepo,
+		folderRepo:      folderRepo,
+		filingRepo:      filingRepo,
+		entityRepo:      entityRepo,
+		fingerprintRepo: fingerprintRepo,
+		promptRepo:      promptRepo,
+		docTypeRepo:     docTypeRepo,
+		openAIService:   openAIService,
+		keyResolver:     keyResolver,
+		clientFactory:   clientFactory,
+		ocrService:      ocrService,
+		storageService:  storageService,
+		events:          events,
+		vendorProfiles:  vendorProfiles,
+		aiUsage:         aiUsage,
+		governor:        governor,
+		config:          config,
 	}
 }
 
+// jobClient is the AI client a job runs with and the key it bills to
+type jobClient struct {
+	ai       OpenAIService
+	ocr      OCRService
+	provider string
+	source   string
+	keyID    *uuid.UUID
+}
+
 // ProcessNextJob processes the next available AI job
-func (s *AIProcessingService) ProcessNextJob(ctx context.Context) error {
+func (s *AIProcessingService) ProcessNextJob(ctx context.Context) (err error) {
 	// Get next job from queue
 	job, err := s.aiJobRepo.GetNextJob(ctx)
 	if err != nil {
@@ -89,15 +189,68 @@ func (s *AIProcessingService) ProcessNextJob(ctx context.Context) error {
 		return nil // No jobs to process
 	}
 
-	// Check tenant quota
-	quotaStatus, err := s.tenantRepo.CheckQuotaLimits(ctx, job.TenantID)
-	if err != nil {
-		return fmt.Errorf("failed to check quota: %w", err)
+	// Continue the trace of the request that queued the job, and log and
+	// audit under its request ID
+	ctx = logger.ContextWithRequestID(ctx, job.RequestID)
+	ctx, span := tracer.Start(contextWithTraceParent(ctx, job.TraceParent), "AIProcessingService.ProcessJob",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("request.id", job.RequestID),
+			attribute.String("tenant.id", job.TenantID.String()),
+			attribute.String("document.id", job.DocumentID.String()),
+			attribute.String("ai_job.id", job.ID.String()),
+			attribute.String("ai_job.type", job.JobType),
+		),
+	)
+	defer func() { endSpan(span, err) }()
+
+	// Resolve the tenant's own key, falling back to the platform key.
+	// Dry-run jobs get a deterministic offline client instead.
+	client := s.resolveClient(ctx, job)
+	if client.ai == nil && !isLocalJob(job.JobType) {
+		s.failJob(ctx, job, fmt.Sprintf("AI provider %s is not configured", client.provider))
+		return ErrAIProviderNotConfigured
+	}
+	billed := client.ai != nil && client.source != AIKeySourceDryRun
+	var meter *aiUsageMeter
+	if billed && s.aiUsage != nil {
+		meter = s.usageMeter(job, client)
+		client.ai = meteredAIClient{OpenAIService: client.ai, meter: meter}
+	}
+	if client.ai != nil {
+		client.ai = tracedAIClient{OpenAIService: client.ai, provider: client.provider}
+		// Provider calls queue in the batch lane, behind interactive AI
+		if client.source != AIKeySourceDryRun {
+			client.ai = batchAIClient{OpenAIService: client.ai, governor: s.governor}
+		}
 	}
+	job.Provider = client.provider
+	job.KeySource = client.source
 
-	if !quotaStatus.CanProcessAI {
-		s.failJob(ctx, job, "AI quota exceeded")
-		return ErrInsufficientCredits
+	// Calls on a tenant key bill to the tenant's provider account, so only
+	// platform-billed jobs count against the AI quota
+	if client.source == AIKeySourcePlatform {
+		quotaStatus, err := s.tenantRepo.CheckQuotaLimits(ctx, job.TenantID)
+		if err != nil {
+			return fmt.Errorf("failed to check quota: %w", err)
+		}
+
+		if !quotaStatus.CanProcessAI {
+			s.failJob(ctx, job, "AI quota exceeded")
+			return ErrInsufficientCredits
+		}
+	}
+
+	// The tenant's AI budget covers every provider call, whoever's key it
+	// bills to
+	if billed && s.aiUsage != nil {
+		if err := s.aiUsage.CheckBudget(ctx, job.TenantID); err != nil {
+			if errors.Is(err, ErrAIBudgetExceeded) {
+				s.failJob(ctx, job, "AI budget exceeded")
+				return err
+			}
+			return fmt.Errorf("failed to check AI budget: %w", err)
+		}
 	}
 
 	// Mark job as started
@@ -111,7 +264,27 @@ func (s *AIProcessingService) ProcessNextJob(ctx context.Context) error {
 	}
 
 	// Process the job
-	err = s.processJob(ctx, job)
+	err = s.processJob(ctx, job, client)
+	if err == nil && client.source == AIKeySourceDryRun {
+		if job.Result == nil {
+			job.Result = make(models.JSONB)
+		}
+		job.Result[aiResultDryRunKey] = true
+	}
+
+	// Calls cost the same whether or not the job succeeded
+	if meter != nil {
+		calls, summary := meter.totals()
+		if len(calls) > 0 {
+			if err := s.aiUsage.Record(ctx, calls); err != nil {
+				// Log but continue - the job's result still has its usage
+			}
+			if job.Result == nil {
+				job.Result = make(models.JSONB)
+			}
+			job.Result[aiResultUsageKey] = summary
+		}
+	}
 
 	// Update job completion status
 	endTime := time.Now()
@@ -132,16 +305,59 @@ func (s *AIProcessingService) ProcessNextJob(ctx context.Context) error {
 
 	s.aiJobRepo.Update(ctx, job)
 
-	// Update tenant API usage
-	s.tenantRepo.UpdateUsage(ctx, job.TenantID, 0, 1)
+	if s.events != nil {
+		switch job.Status {
+		case models.ProcessingCompleted:
+			s.events.Publish(ctx, job.TenantID, WebhookEventDocumentProcessed, map[string]interface{}{
+				"document_id": job.DocumentID.String(),
+				"job_id":      job.ID.String(),
+				"job_type":    job.JobType,
+				"dry_run":     client.source == AIKeySourceDryRun,
+			})
+		case models.ProcessingFailed:
+			s.events.Publish(ctx, job.TenantID, RealtimeEventJobFailed, map[string]interface{}{
+				"document_id": job.DocumentID.String(),
+				"job_id":      job.ID.String(),
+				"job_type":    job.JobType,
+				"error":       job.ErrorMessage,
+			})
+		}
+	}
+
+	// Calls on the tenant's own key count against the key; platform calls are
+	// metered as AI usage and dry runs cost nothing
+	if client.keyID != nil {
+		if err := s.keyResolver.RecordUse(ctx, *client.keyID, err); err != nil {
+			// Log but don't fail
+		}
+	}
 
 	return err
 }
 
+// usageMeter meters a job's calls. Calls that don't report their model are
+// assumed to use the configured one when the platform's provider serves
+// them.
+func (s *AIProcessingService) usageMeter(job *models.AIProcessingJob, client jobClient) *aiUsageMeter {
+	meter := &aiUsageMeter{
+		usage:      s.aiUsage,
+		tenantID:   job.TenantID,
+		jobID:      job.ID,
+		documentID: job.DocumentID,
+		provider:   client.provider,
+		keySource:  client.source,
+	}
+	if client.provider == s.config.PlatformProvider {
+		meter.model = s.config.DefaultModel
+		meter.embeddingModel = s.config.EmbeddingModel
+	}
+	return meter
+}
+
 // processJob handles the actual AI processing based on job type
-func (s *AIProcessingService) processJob(ctx context.Context, job *models.AIProcessingJob) error {
+func (s *AIProcessingService) processJob(ctx context.Context, job *models.AIProcessingJob, client jobClient) error {
 	// Get document
-	document, err := s.documentRepo.GetByID(ctx, job.DocumentID)
+	document, err := s.documentRepo.GetForTenant(ctx, job.TenantID, job.DocumentID)
 	if err != nil {
 		return fmt.Errorf("failed to get document: %w", err)
 	}
@@ -153,30 +369,68 @@ func (s *AIProcessingService) processJob(ctx context.Context, job *models.AIProc
 	}
 	defer fileContent.Close()
 
+	// Texts too large for one call are split up or cut before they reach
+	// the provider; the route taken is recorded on the result
+	route := &aiJobRoute{budget: s.config.MaxInputTokens, maxChunks: s.config.MaxChunks}
+	ai := client.ai
+	if ai != nil {
+		ai = routedAIClient{OpenAIService: ai, route: route}
+	}
+
+	// The tenant's wording of the job's prompt, if they have one
+	prompt := s.promptTemplate(ctx, job)
+	if prompt != nil {
+		ctx = WithAIPromptTemplate(ctx, prompt)
+	}
+
 	switch job.JobType {
 	case "text_extraction":
-		return s.processTextExtraction(ctx, job, document, fileContent)
+		err = s.processTextExtraction(ctx, job, document, fileContent, client.ocr)
 	case "ocr":
-		return s.processOCR(ctx, job, document, fileContent)
+		err = s.processOCR(ctx, job, document, fileContent, client.ocr)
 	case "categorization":
-		return s.processDocumentClassification(ctx, job, document)
+		err = s.processDocumentClassification(ctx, job, document, ai)
 	case "tagging":
-		return s.processAutoTagging(ctx, job, document)
+		err = s.processAutoTagging(ctx, job, document, ai)
 	case "financial_extraction":
-		return s.processFinancialExtraction(ctx, job, document)
+		err = s.processFinancialExtraction(ctx, job, document, ai)
 	case "summarization":
-		return s.processSummarization(ctx, job, document)
+		err = s.processSummarization(ctx, job, document, ai)
 	case "entity_extraction":
-		return s.processEntityExtraction(ctx, job, document)
+		err = s.processEntityExtraction(ctx, job, document, ai)
+	case "chunking":
+		err = s.processChunking(ctx, job, document)
 	case "embedding_generation":
-		return s.processEmbeddingGeneration(ctx, job, document)
+		err = s.processEmbeddingGeneration(ctx, job, document, ai)
+	case "filing_suggestion":
+		err = s.processFilingSuggestion(ctx, job, document, ai)
+	case "fingerprint":
+		err = s.processFingerprint(ctx, job, document, fileContent)
+	case RenditionThumbnail, RenditionPreview:
+		err = s.processRendition(ctx, job, document, fileContent)
 	default:
-		return fmt.Errorf("unknown job type: %s", job.JobType)
+		err = fmt.Errorf("unknown job type: %s", job.JobType)
+	}
+
+	if route.Strategy != "" {
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.String("ai_job.strategy", route.Strategy),
+			attribute.Int("ai_job.estimated_tokens", route.EstimatedTokens),
+			attribute.Int("ai_job.chunks", route.Chunks),
+		)
 	}
+	if err != nil {
+		return err
+	}
+	route.apply(job)
+	if prompt != nil && job.Result != nil {
+		job.Result[aiResultPromptVersionKey] = prompt.Version
+	}
+	return nil
 }
 
 // processTextExtraction extracts text from documents
-func (s *AIProcessingService) processTextExtraction(ctx context.Context, job *models.AIProcessingJob, document *models.Document, fileContent io.ReadCloser) error {
+func (s *AIProcessingService) processTextExtraction(ctx context.Context, job *models.AIProcessingJob, document *models.Document, fileContent io.ReadCloser, ocr OCRService) error {
 	var extractedText string
 	var err error
 
@@ -190,65 +444,105 @@ func (s *AIProcessingService) processTextExtraction(ctx context.Context, job *mo
 		extractedText, err = s.extractTextFromPlain(fileContent)
 	default:
 		// Try OCR for image formats
-		extractedText, err = s.ocrService.ExtractText(ctx, document.StoragePath)
+		extractedText, err = ocr.ExtractText(ctx, document.StoragePath)
 	}
 
 	if err != nil {
 		return fmt.Errorf("text extraction failed: %w", err)
 	}
 
-	// Update document with extracted text
+	result := &TextExtractionResult{
+		ExtractedText: extractedText,
+		TextLength:    len(extractedText),
+		Language:      detectTextLanguage(extractedText),
+	}
+	if err := result.Validate(); err != nil {
+		return err
+	}
+
+	// Update document with extracted text and the language it is in
 	document.ExtractedText = extractedText
+	if result.Language != "" {
+		document.Language = result.Language
+	}
 	if err := s.documentRepo.Update(ctx, document); err != nil {
 		return fmt.Errorf("failed to update document: %w", err)
 	}
 
 	// Store result in job
-	job.Result = models.JSONB{
-		"extracted_text": extractedText,
-		"text_length":    len(extractedText),
-	}
-
-	return nil
+	return s.setJobResult(job, result)
 }
 
 // processOCR performs OCR on image documents
-func (s *AIProcessingService) processOCR(ctx context.Context, job *models.AIProcessingJob, document *models.Document, fileContent io.ReadCloser) error {
-	ocrText, err := s.ocrService.ExtractText(ctx, document.StoragePath)
+func (s *AIProcessingService) processOCR(ctx context.Context, job *models.AIProcessingJob, document *models.Document, fileContent io.ReadCloser, ocr OCRService) error {
+	ocrText, err := ocr.ExtractText(ctx, document.StoragePath)
 	if err != nil {
 		return fmt.Errorf("OCR failed: %w", err)
 	}
 
-	// Update document with OCR text
+	result := &OCRResult{
+		OCRText:    ocrText,
+		TextLength: len(ocrText),
+		Language:   detectTextLanguage(ocrText),
+	}
+	if err := result.Validate(); err != nil {
+		return err
+	}
+
+	// Update document with OCR text, and the language it is in unless the
+	// extracted text already told
 	document.OCRText = ocrText
+	if result.Language != "" && document.ExtractedText == "" {
+		document.Language = result.Language
+	}
 	if err := s.documentRepo.Update(ctx, document); err != nil {
 		return fmt.Errorf("failed to update document: %w", err)
 	}
 
-	job.Result = models.JSONB{
-		"ocr_text":    ocrText,
-		"text_length": len(ocrText),
-	}
-
-	return nil
+	return s.setJobResult(job, result)
 }
 
 // processDocumentClassification classifies documents using AI
-func (s *AIProcessingService) processDocumentClassification(ctx context.Context, job *models.AIProcessingJob, document *models.Document) error {
+func (s *AIProcessingService) processDocumentClassification(ctx context.Context, job *models.AIProcessingJob, document *models.Document, ai OpenAIService) error {
 	// Get text content for classification
 	text := s.getDocumentText(document)
 	if text == "" {
 		return errors.New("no text available for classification")
 	}
 
+	// The tenant's own types are offered besides the built-in ones
+	docTypes, err := loadDocumentTypes(ctx, s.docTypeRepo, document.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load document types: %w", err)
+	}
+
 	// Use AI to classify document
-	docType, confidence, err := s.openAIService.ClassifyDocument(ctx, text)
+	classifyCtx := WithAILanguage(s.withFeedbackExamples(ctx, document.TenantID, models.AIFeedbackClassification), document.Language)
+	classifyCtx = WithAIDocumentTypes(classifyCtx, docTypes.active())
+	docType, confidence, err := ai.ClassifyDocument(classifyCtx, text)
 	if err != nil {
 		return fmt.Errorf("classification failed: %w", err)
 	}
 
-	// Update document if confidence is high enough
-	if confidence > 0.7 {
+	// Reject malformed provider output before it reaches the document
+	result := &ClassificationResult{
+		DocumentType: docType,
+		Confidence:   confidence,
+		Applied:      confidence > 0.7,
+	}
+	if err := result.Validate(); err != nil {
+		return err
+	}
+	if !docTypes.assignable(docType) {
+		return invalidAIResult(fmt.Sprintf("document_type %q is not one of the tenant's types", docType))
+	}
+
+	// Update document if confidence is high enough, unless its sidecar
+	// named the type or a user corrected it
+	if result.Applied && keptFields(document)["document_type"] {
+		result.Applied = false
+	}
+	if result.Applied {
 		document.DocumentType = docType
 		document.AIConfidence = confidence
 
@@ -257,30 +551,24 @@ func (s *AIProcessingService) processDocumentClassification(ctx context.Context,
 		}
 	}
 
-	job.Result = models.JSONB{
-		"document_type": string(docType),
-		"confidence":    confidence,
-		"applied":       confidence > 0.7,
-	}
-
-	return nil
+	return s.setJobResult(job, result)
 }
 
 // processAutoTagging generates and applies tags using AI
-func (s *AIProcessingService) processAutoTagging(ctx context.Context, job *models.AIProcessingJob, document *models.Document) error {
+func (s *AIProcessingService) processAutoTagging(ctx context.Context, job *models.AIProcessingJob, document *models.Document, ai OpenAIService) error {
 	text := s.getDocumentText(document)
 	if text == "" {
 		return errors.New("no text available for tagging")
 	}
 
-	// Generate tags using AI
-	suggestedTags, err := s.openAIService.GenerateTags(ctx, text)
+	// Generate tags using AI, in the document's language
+	suggestedTags, err := ai.GenerateTags(WithAILanguage(ctx, document.Language), text)
 	if err != nil {
 		return fmt.Errorf("tag generation failed: %w", err)
 	}
 
 	// Create or get existing tags
-	var createdTags []string
+	createdTags := make([]string, 0, len(suggestedTags))
 	for _, tagName := range suggestedTags {
 		// Clean and validate tag name
 		cleanTag := s.cleanTagName(tagName)
@@ -309,130 +597,429 @@ func (s *AIProcessingService) processAutoTagging(ctx context.Context, job *model
 		}
 	}
 
-	job.Result = models.JSONB{
-		"suggested_tags": suggestedTags,
-		"created_tags":   createdTags,
-		"tag_count":      len(createdTags),
+	// Suggestions are stored as the provider sent them, minus blanks
+	suggested := make([]string, 0, len(suggestedTags))
+	for _, tagName := range suggestedTags {
+		if strings.TrimSpace(tagName) != "" {
+			suggested = append(suggested, tagName)
+		}
 	}
 
-	return nil
+	return s.setJobResult(job, &TaggingResult{
+		SuggestedTags: suggested,
+		CreatedTags:   createdTags,
+		TagCount:      len(createdTags),
+	})
 }
 
 // processFinancialExtraction extracts financial data from documents
-func (s *AIProcessingService) processFinancialExtraction(ctx context.Context, job *models.AIProcessingJob, document *models.Document) error {
+func (s *AIProcessingService) processFinancialExtraction(ctx context.Context, job *models.AIProcessingJob, document *models.Document, ai OpenAIService) error {
 	text := s.getDocumentText(document)
 	if text == "" {
 		return errors.New("no text available for financial extraction")
 	}
 
+	// A profile of the vendor sharpens extraction of its documents
+	var profile *models.VendorProfile
+	if s.vendorProfiles != nil {
+		profile = s.vendorProfiles.MatchProfile(ctx, document.TenantID, document.VendorName, text)
+	}
+
 	// Extract financial data using AI
-	financialData, err := s.openAIService.ExtractFinancialData(ctx, text, document.DocumentType)
+	ctx = s.withFeedbackExamples(ctx, document.TenantID, models.AIFeedbackFinancial)
+	var financialData map[string]interface{}
+	var err error
+	if extractor, ok := ai.(ProfiledFinancialExtractor); ok && profile != nil {
+		financialData, err = extractor.ExtractFinancialDataWithProfile(ctx, text, document.DocumentType, ExtractionHints(profile))
+	} else {
+		financialData, err = ai.ExtractFinancialData(ctx, text, document.DocumentType)
+	}
 	if err != nil {
 		return fmt.Errorf("financial extraction failed: %w", err)
 	}
 
+	// Reject malformed provider output before it reaches the document
+	result, err := parseFinancialData(financialData)
+	if err != nil {
+		return err
+	}
+	if profile != nil {
+		applyVendorProfile(profile, result, text)
+	}
+
 	// Apply extracted data to document
-	s.applyFinancialData(document, financialData)
+	s.applyFinancialData(document, result)
 
 	// Update document
 	if err := s.documentRepo.Update(ctx, document); err != nil {
 		return fmt.Errorf("failed to update document: %w", err)
 	}
 
-	job.Result = models.JSONB(financialData)
+	// Lines a user corrected are kept
+	if _, err := s.lineItemRepo.ReplaceExtracted(ctx, document.TenantID, document.ID, extractedLineItems(result.LineItems)); err != nil {
+		return fmt.Errorf("failed to store line items: %w", err)
+	}
 
-	return nil
+	return s.setJobResult(job, result)
 }
 
 // processSummarization generates document summaries
-func (s *AIProcessingService) processSummarization(ctx context.Context, job *models.AIProcessingJob, document *models.Document) error {
+func (s *AIProcessingService) processSummarization(ctx context.Context, job *models.AIProcessingJob, document *models.Document, ai OpenAIService) error {
 	text := s.getDocumentText(document)
 	if text == "" {
 		return errors.New("no text available for summarization")
 	}
 
-	// Generate summary using AI
-	summary, err := s.openAIService.GenerateSummary(ctx, text)
+	// Generate summary using AI, in the document's language
+	summaryCtx := WithAILanguage(s.withFeedbackExamples(ctx, document.TenantID, models.AIFeedbackSummary), document.Language)
+	summary, err := ai.GenerateSummary(summaryCtx, text)
 	if err != nil {
 		return fmt.Errorf("summarization failed: %w", err)
 	}
 
-	// Update document with summary
-	document.Summary = summary
-	if err := s.documentRepo.Update(ctx, document); err != nil {
-		return fmt.Errorf("failed to update document: %w", err)
+	result := &SummarizationResult{
+		Summary:          summary,
+		SummaryLength:    len(summary),
+		CompressionRatio: float64(len(summary)) / float64(len(text)),
+	}
+	if err := result.Validate(); err != nil {
+		return err
 	}
 
-	job.Result = models.JSONB{
-		"summary":           summary,
-		"summary_length":    len(summary),
-		"compression_ratio": float64(len(summary)) / float64(len(text)),
+	// Update document with summary, unless a user corrected it
+	if !verifiedFields(document)["summary"] {
+		document.Summary = summary
+		if err := s.documentRepo.Update(ctx, document); err != nil {
+			return fmt.Errorf("failed to update document: %w", err)
+		}
 	}
 
-	return nil
+	return s.setJobResult(job, result)
 }
 
 // processEntityExtraction extracts entities from documents
-func (s *AIProcessingService) processEntityExtraction(ctx context.Context, job *models.AIProcessingJob, document *models.Document) error {
+func (s *AIProcessingService) processEntityExtraction(ctx context.Context, job *models.AIProcessingJob, document *models.Document, ai OpenAIService) error {
 	text := s.getDocumentText(document)
 	if text == "" {
 		return errors.New("no text available for entity extraction")
 	}
 
 	// Extract entities using AI
-	entities, err := s.openAIService.ExtractEntities(ctx, text)
+	entitiesCtx := WithAILanguage(s.withFeedbackExamples(ctx, document.TenantID, models.AIFeedbackEntities), document.Language)
+	entities, err := ai.ExtractEntities(entitiesCtx, text)
 	if err != nil {
 		return fmt.Errorf("entity extraction failed: %w", err)
 	}
+	if entities == nil {
+		entities = make(map[string]interface{})
+	}
 
-	// Store extracted entities in document
-	if document.ExtractedData == nil {
-		document.ExtractedData = make(models.JSONB)
+	result := &EntityExtractionResult{
+		Entities:    entities,
+		EntityCount: len(entities),
+	}
+	if err := result.Validate(); err != nil {
+		return err
 	}
-	document.ExtractedData["entities"] = entities
 
-	if err := s.documentRepo.Update(ctx, document); err != nil {
-		return fmt.Errorf("failed to update document: %w", err)
+	// Store extracted entities in document and index them, unless a user
+	// corrected them
+	if !verifiedFields(document)["entities"] {
+		if document.ExtractedData == nil {
+			document.ExtractedData = make(models.JSONB)
+		}
+		document.ExtractedData["entities"] = entities
+
+		if err := s.documentRepo.Update(ctx, document); err != nil {
+			return fmt.Errorf("failed to update document: %w", err)
+		}
+		if err := s.entityRepo.ReplaceForDocument(ctx, document.TenantID, document.ID, documentEntities(entities)); err != nil {
+			return fmt.Errorf("failed to index entities: %w", err)
+		}
+	}
+
+	return s.setJobResult(job, result)
+}
+
+// processChunking splits a document's text into the passages that are
+// embedded and searched. Passages already stored as they would be split
+// are kept, with their embeddings.
+func (s *AIProcessingService) processChunking(ctx context.Context, job *models.AIProcessingJob, document *models.Document) error {
+	text := s.getDocumentText(document)
+	if text == "" {
+		return errors.New("no text available for chunking")
 	}
 
-	job.Result = models.JSONB{
-		"entities":     entities,
-		"entity_count": len(entities),
+	chunks := s.chunkDocument(text)
+	existing, err := s.chunkRepo.ListByDocument(ctx, document.TenantID, document.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get document passages: %w", err)
 	}
 
-	return nil
+	result := &ChunkingResult{Chunks: len(chunks), Unchanged: sameDocumentChunks(existing, chunks)}
+	if len(chunks) > 0 && chunks[len(chunks)-1].Page != nil {
+		result.Pages = *chunks[len(chunks)-1].Page
+	}
+	if err := result.Validate(); err != nil {
+		return err
+	}
+
+	if !result.Unchanged {
+		if err := s.chunkRepo.ReplaceForDocument(ctx, document.TenantID, document.ID, chunks); err != nil {
+			return fmt.Errorf("failed to store document passages: %w", err)
+		}
+	}
+
+	return s.setJobResult(job, result)
+}
+
+// processEmbeddingGeneration embeds each passage of a document on its own so
+// searches and questions find the passages nearest to them. Documents not
+// chunked yet are chunked first.
+func (s *AIProcessingService) processEmbeddingGeneration(ctx context.Context, job *models.AIProcessingJob, document *models.Document, ai OpenAIService) error {
+	chunks, err := s.chunkRepo.ListByDocument(ctx, document.TenantID, document.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get document passages: %w", err)
+	}
+	if len(chunks) == 0 {
+		text := s.getDocumentText(document)
+		if text == "" {
+			return errors.New("no text available for embedding generation")
+		}
+		chunks = s.chunkDocument(text)
+	}
+
+	dimensions := 0
+	for i := range chunks {
+		embedding, err := ai.GenerateEmbedding(ctx, chunks[i].Content)
+		if err != nil {
+			return fmt.Errorf("embedding generation failed: %w", err)
+		}
+		for _, value := range embedding {
+			if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+				return invalidAIResult("embedding contains a non-finite value")
+			}
+		}
+		if i > 0 && len(embedding) != dimensions {
+			return invalidAIResult("embeddings of one document differ in dimensions")
+		}
+		dimensions = len(embedding)
+		vector := pgvector.NewVector(embedding)
+		chunks[i].Embedding = &vector
+	}
+
+	result := &EmbeddingResult{
+		EmbeddingDimensions: dimensions,
+		Passages:            len(chunks),
+		Generated:           true,
+	}
+	if err := result.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.chunkRepo.ReplaceForDocument(ctx, document.TenantID, document.ID, chunks); err != nil {
+		return fmt.Errorf("failed to store document passages: %w", err)
+	}
+
+	return s.setJobResult(job, result)
 }
 
-// processEmbeddingGeneration generates vector embeddings for semantic search
-func (s *AIProcessingService) processEmbeddingGeneration(ctx context.Context, job *models.AIProcessingJob, document *models.Document) error {
+// processFilingSuggestion suggests where to file a document among the
+// tenant's folders, categories and tags. The suggestion waits for a user to
+// accept it; nothing is applied to the document here.
+func (s *AIProcessingService) processFilingSuggestion(ctx context.Context, job *models.AIProcessingJob, document *models.Document, ai OpenAIService) error {
 	text := s.getDocumentText(document)
 	if text == "" {
-		return errors.New("no text available for embedding generation")
+		return errors.New("no text available for filing suggestion")
+	}
+
+	folders, err := s.folderRepo.ListByTenant(ctx, document.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list folders: %w", err)
+	}
+	categories, err := s.categoryRepo.ListByTenant(ctx, document.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list categories: %w", err)
+	}
+	tags, err := s.tagRepo.GetPopular(ctx, document.TenantID, maxFilingTaxonomyItems)
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	advice, err := ai.SuggestFiling(ctx, text, newFilingTaxonomy(folders, categories, tags))
+	if err != nil {
+		return fmt.Errorf("filing suggestion failed: %w", err)
+	}
+
+	// Only the tenant's own folders and categories are suggested
+	folder, category := matchFilingAdvice(advice, folders, categories)
+	result := &FilingSuggestionResult{Confidence: advice.Confidence, Tags: make([]string, 0, len(advice.Tags))}
+	seen := make(map[string]bool)
+	for _, tagName := range advice.Tags {
+		cleanTag := s.cleanTagName(tagName)
+		if cleanTag == "" || len(cleanTag) > 50 || seen[cleanTag] {
+			continue
+		}
+		seen[cleanTag] = true
+		result.Tags = append(result.Tags, cleanTag)
+	}
+	suggestion := &models.FilingSuggestion{
+		TenantID:   document.TenantID,
+		DocumentID: document.ID,
+		JobID:      &job.ID,
+		Confidence: advice.Confidence,
+		Reason:     strings.TrimSpace(advice.Reason),
+		Status:     models.FilingSuggestionPending,
+	}
+	if folder != nil {
+		suggestion.FolderID = &folder.ID
+		result.FolderPath = folder.Path
+	}
+	if category != nil {
+		suggestion.CategoryID = &category.ID
+		result.Category = category.Name
+	}
+	if err := result.Validate(); err != nil {
+		return err
 	}
 
-	// Generate embedding using AI
-	embedding, err := s.openAIService.GenerateEmbedding(ctx, text)
+	suggestion.Tags, err = toJSONB(filingTags{Names: result.Tags})
 	if err != nil {
-		return fmt.Errorf("embedding generation failed: %w", err)
+		return fmt.Errorf("failed to encode suggested tags: %w", err)
+	}
+	if err := s.filingRepo.Upsert(ctx, suggestion); err != nil {
+		return fmt.Errorf("failed to store filing suggestion: %w", err)
 	}
 
-	// Update document with embedding
-	// Note: You'll need to convert []float32 to pgvector.Vector
-	// document.Embedding = pgvector.NewVector(embedding)
+	return s.setJobResult(job, result)
+}
+
+// processFingerprint computes the fuzzy hashes near-duplicates are found
+// by: a simhash of the document's text and, for images, a perceptual hash
+func (s *AIProcessingService) processFingerprint(ctx context.Context, job *models.AIProcessingJob, document *models.Document, fileContent io.Reader) error {
+	fingerprint := &models.DocumentFingerprint{
+		TenantID:   document.TenantID,
+		DocumentID: document.ID,
+	}
+	if hash, ok := textSimhash(s.getDocumentText(document)); ok {
+		value := int64(hash)
+		fingerprint.TextHash = &value
+	}
+	if s.isImageFormat(document.ContentType) {
+		hash, err := imagePerceptualHash(fileContent)
+		switch {
+		case err == nil:
+			value := int64(hash)
+			fingerprint.ImageHash = &value
+		case !errors.Is(err, errFingerprintUnsupported):
+			return fmt.Errorf("image fingerprinting failed: %w", err)
+		}
+	}
+
+	if err := s.fingerprintRepo.Upsert(ctx, fingerprint); err != nil {
+		return err
+	}
+
+	return s.setJobResult(job, &FingerprintResult{
+		TextHashed:  fingerprint.TextHash != nil,
+		ImageHashed: fingerprint.ImageHash != nil,
+	})
+}
+
+// processRendition draws a thumbnail or preview and stores it beside the document
+func (s *AIProcessingService) processRendition(ctx context.Context, job *models.AIProcessingJob, document *models.Document, fileContent io.Reader) error {
+	rendered, size, err := renderImageRendition(fileContent, renditionMaxDimensions[job.JobType])
+	if errors.Is(err, errRenditionUnsupported) {
+		return s.setJobResult(job, &RenditionResult{Reason: err.Error()})
+	}
+	if err != nil {
+		return fmt.Errorf("rendition failed: %w", err)
+	}
+
+	path, err := s.storageService.Store(ctx, StorageParams{
+		TenantID:    document.TenantID,
+		FileReader:  bytes.NewReader(rendered),
+		Filename:    fmt.Sprintf("%s-%s.jpg", document.ID, job.JobType),
+		ContentType: "image/jpeg",
+		Size:        int64(len(rendered)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store rendition: %w", err)
+	}
+
+	previous := renditionPath(document, job.JobType)
+	if job.JobType == RenditionThumbnail {
+		document.ThumbnailPath = path
+	} else {
+		document.PreviewPath = path
+	}
 	if err := s.documentRepo.Update(ctx, document); err != nil {
+		s.storageService.Delete(ctx, path)
 		return fmt.Errorf("failed to update document: %w", err)
 	}
+	if previous != "" && previous != path {
+		s.storageService.Delete(ctx, previous)
+	}
 
-	job.Result = models.JSONB{
-		"embedding_dimensions": len(embedding),
-		"generated":            true,
+	return s.setJobResult(job, &RenditionResult{Generated: true, Width: size.X, Height: size.Y})
+}
+
+// AIJobResultView is one job's outcome with its typed result
+type AIJobResultView struct {
+	JobID       uuid.UUID               `json:"job_id"`
+	JobType     string                  `json:"job_type"`
+	Status      models.ProcessingStatus `json:"status"`
+	DryRun      bool                    `json:"dry_run"`
+	Strategy    string                  `json:"strategy,omitempty"` // How the text was sent to the provider
+	Result      AIJobResult             `json:"result,omitempty"`
+	ResultError string                  `json:"result_error,omitempty"` // Stored result couldn't be read
+	Error       string                  `json:"error,omitempty"`        // Job failure reason
+	CompletedAt *time.Time              `json:"completed_at,omitempty"`
+}
+
+// GetDocumentAIResults returns the typed results of a document's AI jobs. A
+// stored result that fails validation is reported on its own entry instead
+// of failing the whole response.
+func (s *AIProcessingService) GetDocumentAIResults(ctx context.Context, documentID uuid.UUID) ([]AIJobResultView, error) {
+	jobs, err := s.aiJobRepo.ListByDocument(ctx, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AI jobs: %w", err)
+	}
+
+	views := make([]AIJobResultView, 0, len(jobs))
+	for i := range jobs {
+		job := &jobs[i]
+		view := AIJobResultView{
+			JobID:       job.ID,
+			JobType:     job.JobType,
+			Status:      job.Status,
+			DryRun:      job.DryRun,
+			CompletedAt: job.CompletedAt,
+		}
+		if job.Status == models.ProcessingFailed {
+			view.Error = job.ErrorMessage
+		}
+		if job.Status == models.ProcessingCompleted {
+			view.Strategy, _ = job.Result[aiResultStrategyKey].(string)
+			if result, err := DecodeAIJobResult(job); err != nil {
+				view.ResultError = err.Error()
+			} else {
+				view.Result = result
+			}
+		}
+		views = append(views, view)
 	}
 
-	return nil
+	return views, nil
+}
+
+// AIJobOptions controls how queued jobs run
+type AIJobOptions struct {
+	DryRun bool `json:"dry_run"` // Return deterministic results without calling providers
 }
 
 // QueueDocumentProcessing queues AI processing jobs for a document
-func (s *AIProcessingService) QueueDocumentProcessing(ctx context.Context, documentID uuid.UUID, jobTypes []string) error {
+func (s *AIProcessingService) QueueDocumentProcessing(ctx context.Context, documentID uuid.UUID, jobTypes []string, opts AIJobOptions) error {
 	document, err := s.documentRepo.GetByID(ctx, documentID)
 	if err != nil {
 		return fmt.Errorf("failed to get document: %w", err)
@@ -440,10 +1027,13 @@ func (s *AIProcessingService) QueueDocumentProcessing(ctx context.Context, docum
 
 	for i, jobType := range jobTypes {
 		job := &models.AIProcessingJob{
-			TenantID:   document.TenantID,
-			DocumentID: documentID,
-			JobType:    jobType,
-			Priority:   5 - i, // Earlier jobs get higher priority
+			TenantID:    document.TenantID,
+			DocumentID:  documentID,
+			JobType:     jobType,
+			Priority:    5 - i, // Earlier jobs get higher priority
+			DryRun:      opts.DryRun,
+			TraceParent: traceParent(ctx),
+			RequestID:   logger.RequestIDFromContext(ctx),
 		}
 
 		if err := s.aiJobRepo.Create(ctx, job); err != nil {
@@ -455,7 +1045,7 @@ func (s *AIProcessingService) QueueDocumentProcessing(ctx context.Context, docum
 }
 
 // GetRecommendedJobs returns recommended AI processing jobs for a document
-func (s *AIProcessingService) GetRecommendedJobs(document *models.Document) []string {
+func (s *AIProcessingService) GetRecommendedJobs(ctx context.Context, document *models.Document) []string {
 	var jobs []string
 
 	// Always recommend text extraction if not done
@@ -477,7 +1067,7 @@ func (s *AIProcessingService) GetRecommendedJobs(document *models.Document) []st
 	jobs = append(jobs, "tagging")
 
 	// Recommend financial extraction for financial documents
-	if s.isFinancialDocument(document.DocumentType) {
+	if s.isFinancialDocument(ctx, document) {
 		jobs = append(jobs, "financial_extraction")
 	}
 
@@ -486,18 +1076,26 @@ func (s *AIProcessingService) GetRecommendedJobs(document *models.Document) []st
 		jobs = append(jobs, "summarization")
 	}
 
-	// Recommend embedding generation for semantic search
+	// Recommend filing suggestions for documents not filed in a folder
+	if document.FolderID == nil {
+		jobs = append(jobs, "filing_suggestion")
+	}
+
+	// Recommend fingerprinting for near-duplicate detection
+	jobs = append(jobs, "fingerprint")
+
+	// Recommend chunking and embedding generation for semantic search
 	if s.config.EnableSemanticSearch {
-		jobs = append(jobs, "embedding_generation")
+		jobs = append(jobs, "chunking", "embedding_generation")
 	}
 
 	return jobs
 }
 
 // ProcessBatch processes multiple documents in batch
-func (s *AIProcessingService) ProcessBatch(ctx context.Context, documentIDs []uuid.UUID, jobTypes []string) error {
+func (s *AIProcessingService) ProcessBatch(ctx context.Context, documentIDs []uuid.UUID, jobTypes []string, opts AIJobOptions) error {
 	for _, documentID := range documentIDs {
-		if err := s.QueueDocumentProcessing(ctx, documentID, jobTypes); err != nil {
+		if err := s.QueueDocumentProcessing(ctx, documentID, jobTypes, opts); err != nil {
 			// Log error but continue with other documents
 			continue
 		}
@@ -505,8 +1103,210 @@ func (s *AIProcessingService) ProcessBatch(ctx context.Context, documentIDs []uu
 	return nil
 }
 
+// RequeueFailedJobs queues the failed jobs of a tenant, or of every tenant
+// when tenantID is nil, again with a fresh set of attempts, returning how
+// many were requeued. Operators use it once the cause of the failures, such
+// as an exhausted provider quota, is fixed.
+func (s *AIProcessingService) RequeueFailedJobs(ctx context.Context, tenantID *uuid.UUID) (int, error) {
+	if tenantID != nil {
+		return s.requeueTenantJobs(ctx, *tenantID)
+	}
+
+	requeued := 0
+	params := repositories.ListParams{Page: 1, PageSize: 100}
+	for {
+		tenants, total, err := s.tenantRepo.List(ctx, params)
+		if err != nil {
+			return requeued, fmt.Errorf("failed to list tenants: %w", err)
+		}
+
+		for _, tenant := range tenants {
+			count, err := s.requeueTenantJobs(ctx, tenant.ID)
+			requeued += count
+			if err != nil {
+				return requeued, err
+			}
+		}
+
+		if int64(params.Page*params.PageSize) >= total || len(tenants) == 0 {
+			return requeued, nil
+		}
+		params.Page++
+	}
+}
+
 // Helper methods
 
+func (s *AIProcessingService) requeueTenantJobs(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	jobs, err := s.aiJobRepo.GetFailedJobs(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, job := range jobs {
+		ok, err := s.aiJobRepo.Requeue(ctx, job.ID)
+		if err != nil {
+			return requeued, err
+		}
+		if ok {
+			requeued++
+		}
+	}
+
+	if requeued > 0 && s.auditRepo != nil {
+		log := &models.AuditLog{
+			TenantID:     tenantID,
+			ResourceID:   tenantID,
+			ResourceType: "ai_processing_job",
+			Action:       models.AuditUpdate,
+			Details:      models.JSONB{"message": "Failed AI jobs requeued", "count": requeued},
+		}
+		go func() {
+			s.auditRepo.Create(context.WithoutCancel(ctx), log)
+		}()
+	}
+	return requeued, nil
+}
+
+// resolveClient picks the dry-run client for dry-run jobs, then the tenant's
+// own provider key when one is configured, otherwise the platform client
+func (s *AIProcessingService) resolveClient(ctx context.Context, job *models.AIProcessingJob) jobClient {
+	// Renditions and passages are made locally; no provider is called or billed
+	if isLocalJob(job.JobType) {
+		return jobClient{}
+	}
+
+	if s.isDryRun(ctx, job) {
+		job.DryRun = true
+		return jobClient{
+			ai:       dryRunAIService{},
+			ocr:      dryRunOCRService{},
+			provider: dryRunProvider,
+			source:   AIKeySourceDryRun,
+		}
+	}
+
+	tenantID := job.TenantID
+	provider, chosen := s.jobProvider(ctx, job)
+	platform := jobClient{
+		ai:       s.platformClient(provider),
+		ocr:      s.ocrService,
+		provider: provider,
+		source:   AIKeySourcePlatform,
+	}
+
+	if s.keyResolver == nil || s.clientFactory == nil {
+		return platform
+	}
+
+	// A provider the tenant or job type chose is only swapped for the
+	// tenant's key to that same provider
+	key, err := s.keyResolver.ResolveKey(ctx, tenantID, provider)
+	if err != nil || key == nil || (chosen && key.Provider != provider) {
+		return platform
+	}
+
+	ai, err := s.clientFactory.NewClient(key.Provider, key.APIKey)
+	if err != nil {
+		if err := s.keyResolver.RecordUse(ctx, key.KeyID, err); err != nil {
+			// Log but don't fail
+		}
+		return platform
+	}
+
+	return jobClient{
+		ai:       ai,
+		ocr:      s.ocrService,
+		provider: key.Provider,
+		source:   AIKeySourceTenant,
+		keyID:    &key.KeyID,
+	}
+}
+
+// jobProvider picks the provider a job runs on: the one configured for the
+// job type, then the tenant's choice, then the platform's. Job types come
+// first so a tenant's choice can't send embeddings to a provider without
+// them. chosen is false for the platform's.
+func (s *AIProcessingService) jobProvider(ctx context.Context, job *models.AIProcessingJob) (provider string, chosen bool) {
+	if provider := s.config.JobProviders[job.JobType]; provider != "" {
+		return provider, true
+	}
+	if tenant, err := s.tenantRepo.GetByID(ctx, job.TenantID); err == nil {
+		if provider := resolveTenantSettings(tenant).AIProvider; provider != "" {
+			return provider, true
+		}
+	}
+	return s.config.PlatformProvider, false
+}
+
+// platformClient returns the platform's client for a provider, nil when
+// the provider isn't configured
+func (s *AIProcessingService) platformClient(provider string) OpenAIService {
+	if provider == s.config.PlatformProvider && s.openAIService != nil {
+		return s.openAIService
+	}
+	if client, ok := s.config.Providers[provider]; ok {
+		return client
+	}
+	return nil
+}
+
+// isDryRun reports whether a job was queued as a dry run or its tenant, or the
+// whole platform, has dry-run mode switched on
+func (s *AIProcessingService) isDryRun(ctx context.Context, job *models.AIProcessingJob) bool {
+	if job.DryRun || s.config.DryRun {
+		return true
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, job.TenantID)
+	if err != nil {
+		return false
+	}
+	return resolveTenantSettings(tenant).AIDryRun
+}
+
+// withFeedbackExamples gives the AI call the tenant's recent corrections of
+// the kind as examples
+func (s *AIProcessingService) withFeedbackExamples(ctx context.Context, tenantID uuid.UUID, kind models.AIFeedbackKind) context.Context {
+	feedback, err := s.feedbackRepo.ListRecent(ctx, tenantID, kind, s.config.FeedbackExamples)
+	if err != nil || len(feedback) == 0 {
+		// Log but continue - the job runs without examples
+		return ctx
+	}
+	return WithAIExamples(ctx, aiExamples(feedback))
+}
+
+// promptTemplate returns the tenant's active template for the prompt a job
+// sends, nil when it sends none or the default applies
+func (s *AIProcessingService) promptTemplate(ctx context.Context, job *models.AIProcessingJob) *models.PromptTemplate {
+	if s.promptRepo == nil {
+		return nil
+	}
+	for kind, schema := range promptKindSchemas {
+		if schema.JobType != job.JobType {
+			continue
+		}
+		template, err := s.promptRepo.GetActive(ctx, job.TenantID, kind)
+		if err != nil {
+			// Log but continue - the job runs with the default prompt
+			return nil
+		}
+		return template
+	}
+	return nil
+}
+
+// chunkDocument splits a document's text into passages for embedding, at
+// most MaxChunks of them
+func (s *AIProcessingService) chunkDocument(text string) []models.DocumentChunk {
+	chunks := chunkDocumentText(text, documentPassageTokens)
+	if len(chunks) > s.config.MaxChunks {
+		chunks = chunks[:s.config.MaxChunks]
+	}
+	return chunks
+}
+
 func (s *AIProcessingService) getDocumentText(document *models.Document) string {
 	if document.ExtractedText != "" {
 		return document.ExtractedText
@@ -533,42 +1333,49 @@ func (s *AIProcessingService) cleanTagName(tag string) string {
 	return strings.TrimSpace(tag)
 }
 
-func (s *AIProcessingService) applyFinancialData(document *models.Document, data map[string]interface{}) {
-	if amount, ok := data["amount"].(float64); ok {
+func (s *AIProcessingService) applyFinancialData(document *models.Document, data *FinancialExtractionResult) {
+	// Values from the document's sidecar or corrected by a user are kept
+	kept := keptFields(document)
+
+	if data.Amount != nil && !kept["amount"] {
+		amount := *data.Amount
 		document.Amount = &amount
 	}
 
-	if currency, ok := data["currency"].(string); ok {
-		document.Currency = currency
+	if data.Currency != "" && !kept["currency"] {
+		document.Currency = data.Currency
 	}
 
-	if taxAmount, ok := data["tax_amount"].(float64); ok {
+	if data.TaxAmount != nil && !kept["tax_amount"] {
+		taxAmount := *data.TaxAmount
 		document.TaxAmount = &taxAmount
 	}
 
-	if vendor, ok := data["vendor_name"].(string); ok {
-		document.VendorName = vendor
+	if data.VendorName != "" && !kept["vendor_name"] {
+		document.VendorName = data.VendorName
 	}
 
-	if customer, ok := data["customer_name"].(string); ok {
-		document.CustomerName = customer
+	if data.CustomerName != "" && !kept["customer_name"] {
+		document.CustomerName = data.CustomerName
 	}
 
-	if docNumber, ok := data["document_number"].(string); ok {
-		document.DocumentNumber = docNumber
+	// Numbers issued by a sequence are kept; the extracted one, usually the
+	// sender's, becomes the reference number
+	if data.DocumentNumber != "" && !kept["document_number"] {
+		if document.NumberingSequenceID == nil {
+			document.DocumentNumber = data.DocumentNumber
+		} else if document.ReferenceNumber == "" {
+			document.ReferenceNumber = data.DocumentNumber
+		}
 	}
 
-	// Parse dates
-	if dateStr, ok := data["document_date"].(string); ok {
-		if date, err := time.Parse("2006-01-02", dateStr); err == nil {
-			document.DocumentDate = &date
-		}
+	// Dates were validated as YYYY-MM-DD
+	if date, err := time.Parse("2006-01-02", data.DocumentDate); err == nil && !kept["document_date"] {
+		document.DocumentDate = &date
 	}
 
-	if dueDateStr, ok := data["due_date"].(string); ok {
-		if date, err := time.Parse("2006-01-02", dueDateStr); err == nil {
-			document.DueDate = &date
-		}
+	if date, err := time.Parse("2006-01-02", data.DueDate); err == nil && !kept["due_date"] {
+		document.DueDate = &date
 	}
 
 	// Store all extracted data
@@ -578,6 +1385,21 @@ func (s *AIProcessingService) applyFinancialData(document *models.Document, data
 	document.ExtractedData["financial_data"] = data
 }
 
+// setJobResult validates a typed result and stores it on the job
+func (s *AIProcessingService) setJobResult(job *models.AIProcessingJob, result AIJobResult) error {
+	encoded, err := encodeAIJobResult(result)
+	if err != nil {
+		return err
+	}
+	job.Result = encoded
+	return nil
+}
+
+// isLocalJob reports whether a job type runs without an AI provider
+func isLocalJob(jobType string) bool {
+	return isRenditionJob(jobType) || jobType == "chunking" || jobType == "fingerprint"
+}
+
 func (s *AIProcessingService) isImageFormat(contentType string) bool {
 	imageTypes := []string{
 		"image/jpeg", "image/jpg", "image/png", "image/tiff", "image/bmp", "image/gif",
@@ -591,7 +1413,10 @@ func (s *AIProcessingService) isImageFormat(contentType string) bool {
 	return false
 }
 
-func (s *AIProcessingService) isFinancialDocument(docType models.DocumentType) bool {
+// isFinancialDocument reports whether a document's type, or the built-in type
+// it maps to, holds financial data
+func (s *AIProcessingService) isFinancialDocument(ctx context.Context, document *models.Document) bool {
+	docType := builtinDocumentType(ctx, s.docTypeRepo, document.TenantID, document.DocumentType)
 	financial := []models.DocumentType{
 		models.DocTypeInvoice,
 		models.DocTypeReceipt,
@@ -636,6 +1461,10 @@ func (s *AIProcessingService) extractTextFromPlain(reader io.ReadCloser) (string
 
 // External service interfaces
 
+// OpenAIService is an AI provider's client. Clients add the examples and
+// the document language a call's context carries (see AIExamplesFromContext
+// and AILanguageFromContext) to their prompt and report the tokens each
+// request used (see ReportAIUsage).
 type OpenAIService interface {
 	ExtractText(ctx context.Context, text string) (string, error)
 	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
@@ -644,6 +1473,18 @@ type OpenAIService interface {
 	ClassifyDocument(ctx context.Context, text string) (models.DocumentType, float64, error)
 	GenerateTags(ctx context.Context, text string) ([]string, error)
 	ExtractFinancialData(ctx context.Context, text string, docType models.DocumentType) (map[string]interface{}, error)
+	SuggestFiling(ctx context.Context, text string, taxonomy FilingTaxonomy) (*FilingAdvice, error)
+}
+
+// AIKeyResolver looks up tenant-supplied provider keys for processing jobs
+type AIKeyResolver interface {
+	ResolveKey(ctx context.Context, tenantID uuid.UUID, preferredProvider string) (*ResolvedAIKey, error)
+	RecordUse(ctx context.Context, keyID uuid.UUID, callErr error) error
+}
+
+// AIClientFactory builds a provider client bound to a specific API key
+type AIClientFactory interface {
+	NewClient(provider, apiKey string) (OpenAIService, error)
 }
 
 type OCRService interface {
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAIBudgetExceeded = errors.New("monthly AI budget exceeded")
+	ErrInvalidAIBudget  = errors.New("invalid AI budget")
+)
+
+// tenantAIBudgetSetting is the tenant setting holding its AIBudget
+const tenantAIBudgetSetting = "ai_budget"
+
+// defaultAIBudgetSoftLimitPercent is the share of the budget admins are
+// warned at
+const defaultAIBudgetSoftLimitPercent = 80
+
+// AIModelPrice is what a model costs in USD per million tokens
+type AIModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// defaultAIModelPrices are the providers' list prices, matched by model name
+// prefix so dated versions of a model share its price
+var defaultAIModelPrices = map[string]AIModelPrice{
+	"claude-opus-4":          {Input: 15, Output: 75},
+	"claude-sonnet-4":        {Input: 3, Output: 15},
+	"claude-3-7-sonnet":      {Input: 3, Output: 15},
+	"claude-3-5-sonnet":      {Input: 3, Output: 15},
+	"claude-3-5-haiku":       {Input: 0.8, Output: 4},
+	"claude-3-opus":          {Input: 15, Output: 75},
+	"claude-3-haiku":         {Input: 0.25, Output: 1.25},
+	"gpt-4o":                 {Input: 2.5, Output: 10},
+	"gpt-4o-mini":            {Input: 0.15, Output: 0.6},
+	"gpt-4-turbo":            {Input: 10, Output: 30},
+	"gpt-3.5-turbo":          {Input: 0.5, Output: 1.5},
+	"text-embedding-3-small": {Input: 0.02},
+	"text-embedding-3-large": {Input: 0.13},
+	"text-embedding-ada-002": {Input: 0.1},
+	"gemini-2.0-flash":       {Input: 0.1, Output: 0.4},
+	"gemini-1.5-flash":       {Input: 0.075, Output: 0.3},
+	"gemini-1.5-pro":         {Input: 1.25, Output: 5},
+	"text-embedding-004":     {},
+}
+
+// defaultAIProviderPrices price calls to models the price table doesn't
+// know, at the provider's mid-range model
+var defaultAIProviderPrices = map[string]AIModelPrice{
+	AIProviderAnthropic: {Input: 3, Output: 15},
+	AIProviderOpenAI:    {Input: 2.5, Output: 10},
+	AIProviderGemini:    {Input: 1.25, Output: 5},
+}
+
+// ParseAIModelPrices parses prices written as model=input:output, in USD
+// per million tokens, e.g. claude-3-5-sonnet=3:15
+func ParseAIModelPrices(entries []string) (map[string]AIModelPrice, error) {
+	prices := make(map[string]AIModelPrice, len(entries))
+	for _, entry := range entries {
+		model, rates, ok := strings.Cut(entry, "=")
+		input, output, ok2 := strings.Cut(rates, ":")
+		model = strings.TrimSpace(model)
+		if !ok || !ok2 || model == "" {
+			return nil, fmt.Errorf("AI model price %q is not model=input:output", entry)
+		}
+		var price AIModelPrice
+		var err error
+		if price.Input, err = strconv.ParseFloat(strings.TrimSpace(input), 64); err != nil || price.Input < 0 {
+			return nil, fmt.Errorf("AI model price %q has an invalid input price", entry)
+		}
+		if price.Output, err = strconv.ParseFloat(strings.TrimSpace(output), 64); err != nil || price.Output < 0 {
+			return nil, fmt.Errorf("AI model price %q has an invalid output price", entry)
+		}
+		prices[model] = price
+	}
+	return prices, nil
+}
+
+// AIUsageConfig holds configuration for AI usage tracking
+type AIUsageConfig struct {
+	Prices        map[string]AIModelPrice // By model name prefix; added to the list prices, overriding them
+	HistoryMonths int                     // Months of history the usage report includes; defaults to 12
+}
+
+// AIBudget is a tenant's monthly budget for AI calls, in USD. Admins are
+// warned once a month when spending reaches the soft limit; with a hard
+// limit AI jobs stop once the budget is used up.
+type AIBudget struct {
+	MonthlyLimit     float64    `json:"monthly_limit"`      // No budget when 0
+	SoftLimitPercent int        `json:"soft_limit_percent"` // Share of the limit admins are warned at; defaults to 80
+	HardLimit        bool       `json:"hard_limit"`
+	WarnedPeriod     string     `json:"warned_period,omitempty"` // Month the last warning was sent for
+	UpdatedBy        *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"`
+}
+
+// AIUsageReport is a tenant's AI spending for admins: this month's totals
+// against its budget and the monthly history per model
+type AIUsageReport struct {
+	TenantID     uuid.UUID                   `json:"tenant_id"`
+	Budget       AIBudget                    `json:"budget"`
+	Period       string                      `json:"period"` // The current month, e.g. 2024-05
+	Calls        int64                       `json:"calls"`
+	InputTokens  int64                       `json:"input_tokens"`
+	OutputTokens int64                       `json:"output_tokens"`
+	Cost         float64                     `json:"cost"`
+	Remaining    *float64                    `json:"remaining,omitempty"` // Left of the budget this month
+	History      []repositories.AIUsageTotal `json:"history"`
+}
+
+// AIUsageService records the tokens and cost of every AI provider call
+// against the tenant and job that made it, enforces tenants' monthly AI
+// budgets in the worker and reports spending to admins
+type AIUsageService struct {
+	usageRepo  repositories.AIUsageRepository
+	tenantRepo repositories.TenantRepository
+	auditRepo  repositories.AuditLogRepository
+	events     EventPublisher
+	prices     map[string]AIModelPrice
+	config     AIUsageConfig
+}
+
+// NewAIUsageService creates a new AI usage service
+func NewAIUsageService(
+	usageRepo repositories.AIUsageRepository,
+	tenantRepo repositories.TenantRepository,
+	auditRepo repositories.AuditLogRepository,
+	events EventPublisher,
+	config AIUsageConfig,
+) *AIUsageService {
+	if config.HistoryMonths <= 0 {
+		config.HistoryMonths = 12
+	}
+	prices := make(map[string]AIModelPrice, len(defaultAIModelPrices)+len(config.Prices))
+	for model, price := range defaultAIModelPrices {
+		prices[model] = price
+	}
+	for model, price := range config.Prices {
+		prices[strings.ToLower(model)] = price
+	}
+
+	return &AIUsageService{
+		usageRepo:  usageRepo,
+		tenantRepo: tenantRepo,
+		auditRepo:  auditRepo,
+		events:     events,
+		prices:     prices,
+		config:     config,
+	}
+}
+
+// Cost prices a call in USD: at the longest matching model name in the
+// price table, or at the provider's default price
+func (s *AIUsageService) Cost(provider, model string, inputTokens, outputTokens int) float64 {
+	if provider == AIProviderOllama {
+		return 0 // Local models
+	}
+	price, match := defaultAIProviderPrices[provider], ""
+	model = strings.ToLower(model)
+	for prefix, p := range s.prices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(match) {
+			price, match = p, prefix
+		}
+	}
+	cost := (float64(inputTokens)*price.Input + float64(outputTokens)*price.Output) / 1e6
+	return math.Round(cost*1e6) / 1e6
+}
+
+// Record stores the usage of a job's calls
+func (s *AIUsageService) Record(ctx context.Context, usage []models.AIUsage) error {
+	return s.usageRepo.CreateBatch(ctx, usage)
+}
+
+// CheckBudget checks the tenant's spending this month against its budget
+// before a job runs. Admins are warned once a month when spending reaches
+// the soft limit; ErrAIBudgetExceeded is returned once a hard limit is
+// reached.
+func (s *AIUsageService) CheckBudget(ctx context.Context, tenantID uuid.UUID) error {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return ErrTenantNotFound
+	}
+	budget, err := aiBudget(tenant)
+	if err != nil || budget.MonthlyLimit <= 0 {
+		return err
+	}
+
+	now := time.Now().UTC()
+	spent, err := s.usageRepo.CostSince(ctx, tenantID, monthStart(now))
+	if err != nil {
+		return err
+	}
+
+	period := now.Format(apiUsagePeriodFormat)
+	if spent >= budget.MonthlyLimit*float64(budget.SoftLimitPercent)/100 && budget.WarnedPeriod != period {
+		s.warn(ctx, tenant, budget, period, spent)
+	}
+	if budget.HardLimit && spent >= budget.MonthlyLimit {
+		return ErrAIBudgetExceeded
+	}
+	return nil
+}
+
+// GetBudget returns the tenant's AI budget
+func (s *AIUsageService) GetBudget(ctx context.Context, tenantID uuid.UUID) (*AIBudget, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	return aiBudget(tenant)
+}
+
+// UpdateBudget validates and stores the tenant's AI budget. A changed
+// limit warns admins again when spending reaches it.
+func (s *AIUsageService) UpdateBudget(ctx context.Context, tenantID, userID uuid.UUID, budget AIBudget) (*AIBudget, error) {
+	if math.IsNaN(budget.MonthlyLimit) || math.IsInf(budget.MonthlyLimit, 0) || budget.MonthlyLimit < 0 {
+		return nil, fmt.Errorf("%w: monthly_limit must be a positive amount, or 0 for no budget", ErrInvalidAIBudget)
+	}
+	if budget.SoftLimitPercent == 0 {
+		budget.SoftLimitPercent = defaultAIBudgetSoftLimitPercent
+	}
+	if budget.SoftLimitPercent < 1 || budget.SoftLimitPercent > 100 {
+		return nil, fmt.Errorf("%w: soft_limit_percent must be between 1 and 100", ErrInvalidAIBudget)
+	}
+	if budget.HardLimit && budget.MonthlyLimit == 0 {
+		return nil, fmt.Errorf("%w: a hard limit needs a monthly_limit", ErrInvalidAIBudget)
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	current, err := aiBudget(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	budget.WarnedPeriod = ""
+	if budget.MonthlyLimit == current.MonthlyLimit && budget.SoftLimitPercent == current.SoftLimitPercent {
+		budget.WarnedPeriod = current.WarnedPeriod
+	}
+	now := time.Now()
+	budget.UpdatedBy = &userID
+	budget.UpdatedAt = &now
+
+	if err := s.storeBudget(ctx, tenant, &budget); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, models.JSONB{
+		"message":            "AI budget updated",
+		"monthly_limit":      budget.MonthlyLimit,
+		"soft_limit_percent": budget.SoftLimitPercent,
+		"hard_limit":         budget.HardLimit,
+	})
+	return &budget, nil
+}
+
+// GetUsage returns the tenant's AI spending this month against its budget
+// and its monthly history per model
+func (s *AIUsageService) GetUsage(ctx context.Context, tenantID uuid.UUID) (*AIUsageReport, error) {
+	budget, err := s.GetBudget(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	currentPeriod := monthStart(now)
+	from := currentPeriod.AddDate(0, -(s.config.HistoryMonths - 1), 0)
+	history, err := s.usageRepo.MonthlyTotals(ctx, tenantID, from)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []repositories.AIUsageTotal{}
+	}
+
+	report := &AIUsageReport{
+		TenantID: tenantID,
+		Budget:   *budget,
+		Period:   now.Format(apiUsagePeriodFormat),
+		History:  history,
+	}
+	for _, total := range history {
+		if total.Period.UTC().Equal(currentPeriod) {
+			report.Calls += total.Calls
+			report.InputTokens += total.InputTokens
+			report.OutputTokens += total.OutputTokens
+			report.Cost += total.Cost
+		}
+	}
+	if budget.MonthlyLimit > 0 {
+		remaining := math.Max(budget.MonthlyLimit-report.Cost, 0)
+		report.Remaining = &remaining
+	}
+
+	return report, nil
+}
+
+// Helper methods
+
+// warn tells the tenant's admins spending reached the soft limit and notes
+// the month so they're warned once
+func (s *AIUsageService) warn(ctx context.Context, tenant *models.Tenant, budget *AIBudget, period string, spent float64) {
+	budget.WarnedPeriod = period
+	if err := s.storeBudget(ctx, tenant, budget); err != nil {
+		// Log but continue - warn again on the next job rather than never
+		return
+	}
+	if s.events != nil {
+		s.events.Publish(ctx, tenant.ID, WebhookEventAIBudgetWarning, map[string]interface{}{
+			"period":             period,
+			"spent":              spent,
+			"monthly_limit":      budget.MonthlyLimit,
+			"soft_limit_percent": budget.SoftLimitPercent,
+			"hard_limit":         budget.HardLimit,
+		})
+	}
+}
+
+func (s *AIUsageService) storeBudget(ctx context.Context, tenant *models.Tenant, budget *AIBudget) error {
+	data, err := json.Marshal(budget)
+	if err != nil {
+		return fmt.Errorf("failed to encode AI budget: %w", err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to encode AI budget: %w", err)
+	}
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+	tenant.Settings[tenantAIBudgetSetting] = stored
+	tenant.UpdatedAt = time.Now()
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return fmt.Errorf("failed to update AI budget: %w", err)
+	}
+	return nil
+}
+
+func (s *AIUsageService) createAuditLog(ctx context.Context, tenantID, userID uuid.UUID, details models.JSONB) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   tenantID,
+		Action:       models.AuditUpdate,
+		ResourceType: "tenant",
+		Details:      details,
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+// Helper functions
+
+func aiBudget(tenant *models.Tenant) (*AIBudget, error) {
+	budget := &AIBudget{SoftLimitPercent: defaultAIBudgetSoftLimitPercent}
+	if raw, ok := tenant.Settings[tenantAIBudgetSetting]; ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read AI budget: %w", err)
+		}
+		if err := json.Unmarshal(data, budget); err != nil {
+			return nil, fmt.Errorf("failed to read AI budget: %w", err)
+		}
+	}
+	return budget, nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportingAIService reports the usage a provider would have returned
+type reportingAIService struct {
+	dryRunAIService
+	err error
+}
+
+func (r reportingAIService) GenerateSummary(ctx context.Context, text string) (string, error) {
+	ReportAIUsage(ctx, AITokenUsage{Model: "claude-3-5-sonnet-20241022", InputTokens: 1000, OutputTokens: 200})
+	ReportAIUsage(ctx, AITokenUsage{InputTokens: 500, OutputTokens: 100})
+	return "A summary.", r.err
+}
+
+func TestAIUsageService_Cost(t *testing.T) {
+	service := NewAIUsageService(nil, nil, nil, nil, AIUsageConfig{Prices: map[string]AIModelPrice{"Custom-Model": {Input: 1, Output: 2}}})
+
+	// Dated versions share their model's price; the longest prefix wins
+	assert.Equal(t, 0.0045, service.Cost(AIProviderAnthropic, "claude-3-5-sonnet-20241022", 1000, 100))
+	assert.Equal(t, 0.00075, service.Cost(AIProviderOpenAI, "gpt-4o-mini-2024-07-18", 1000, 1000))
+	assert.Equal(t, 0.003, service.Cost(AIProviderOpenAI, "custom-model", 1000, 1000))
+
+	// Unknown models are priced at the provider's default
+	assert.Equal(t, 0.018, service.Cost(AIProviderAnthropic, "", 1000, 1000))
+	assert.Equal(t, 0.0, service.Cost("ollama", "llama2", 1000, 1000))
+}
+
+func TestParseAIModelPrices(t *testing.T) {
+	prices, err := ParseAIModelPrices([]string{"claude-3-5-sonnet=3:15", " gpt-4o = 2.5 : 10 "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]AIModelPrice{
+		"claude-3-5-sonnet": {Input: 3, Output: 15},
+		"gpt-4o":            {Input: 2.5, Output: 10},
+	}, prices)
+
+	for _, invalid := range []string{"gpt-4o", "gpt-4o=3", "=1:2", "gpt-4o=x:1", "gpt-4o=1:-2"} {
+		_, err := ParseAIModelPrices([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMeteredAIClient(t *testing.T) {
+	service := NewAIUsageService(nil, nil, nil, nil, AIUsageConfig{})
+	newMeter := func() *aiUsageMeter {
+		return &aiUsageMeter{usage: service, tenantID: uuid.New(), jobID: uuid.New(), documentID: uuid.New(),
+			provider: AIProviderAnthropic, keySource: AIKeySourcePlatform, model: "claude-3-haiku"}
+	}
+	ctx := context.Background()
+
+	// Reported requests are summed under the model the provider named
+	meter := newMeter()
+	_, err := meteredAIClient{OpenAIService: reportingAIService{}, meter: meter}.GenerateSummary(ctx, "text")
+	require.NoError(t, err)
+	calls, summary := meter.totals()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "claude-3-5-sonnet-20241022", calls[0].Model)
+	assert.Equal(t, "generate_summary", calls[0].Operation)
+	assert.Equal(t, 1500, calls[0].InputTokens)
+	assert.Equal(t, 300, calls[0].OutputTokens)
+	assert.False(t, calls[0].Estimated)
+	assert.Equal(t, 0.009, calls[0].Cost)
+	assert.Equal(t, meter.jobID, *calls[0].JobID)
+	assert.Equal(t, 1, summary["calls"])
+
+	// Unreported calls are estimated from their text at the assumed model
+	meter = newMeter()
+	_, err = meteredAIClient{OpenAIService: dryRunAIService{}, meter: meter}.GenerateTags(ctx, "An invoice from Acme Ltd for consulting")
+	require.NoError(t, err)
+	calls, _ = meter.totals()
+	require.Len(t, calls, 1)
+	assert.True(t, calls[0].Estimated)
+	assert.Equal(t, "claude-3-haiku", calls[0].Model)
+	assert.Equal(t, estimateTokens("An invoice from Acme Ltd for consulting"), calls[0].InputTokens)
+
+	// Failed calls count only when the provider billed them
+	meter = newMeter()
+	failing := meteredAIClient{OpenAIService: reportingAIService{err: errors.New("overloaded")}, meter: meter}
+	_, err = failing.GenerateSummary(ctx, "text")
+	require.Error(t, err)
+	_, err = meteredAIClient{OpenAIService: failingAIService{}, meter: meter}.ExtractEntities(ctx, "text")
+	require.Error(t, err)
+	calls, _ = meter.totals()
+	assert.Len(t, calls, 1)
+}
+
+func TestAIBudget_Defaults(t *testing.T) {
+	budget, err := aiBudget(&models.Tenant{})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, budget.MonthlyLimit)
+	assert.Equal(t, defaultAIBudgetSoftLimitPercent, budget.SoftLimitPercent)
+
+	budget, err = aiBudget(&models.Tenant{Settings: models.JSONB{tenantAIBudgetSetting: map[string]interface{}{
+		"monthly_limit": 50.0, "soft_limit_percent": 90, "hard_limit": true,
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, AIBudget{MonthlyLimit: 50, SoftLimitPercent: 90, HardLimit: true}, *budget)
+}
+
+// failingAIService fails every entity extraction without reporting usage
+type failingAIService struct {
+	dryRunAIService
+}
+
+func (failingAIService) ExtractEntities(ctx context.Context, text string) (map[string]interface{}, error) {
+	return nil, errors.New("connection refused")
+}
@@ -23,6 +23,7 @@ type AnalyticsService struct {
 	userRepo      repositories.UserRepository
 	tenantRepo    repositories.TenantRepository
 	auditRepo     repositories.AuditLogRepository
+	checklistRepo repositories.WorkflowChecklistRepository
 
 	config AnalyticsServiceConfig
 }
@@ -42,6 +43,7 @@ func NewAnalyticsService(
 	userRepo repositories.UserRepository,
 	tenantRepo repositories.TenantRepository,
 	auditRepo repositories.AuditLogRepository,
+	checklistRepo repositories.WorkflowChecklistRepository,
 	config AnalyticsServiceConfig,
 ) *AnalyticsService {
 	return &AnalyticsService{
@@ -50,6 +52,7 @@ func NewAnalyticsService(
 		userRepo:      userRepo,
 		tenantRepo:    tenantRepo,
 		auditRepo:     auditRepo,
+		checklistRepo: checklistRepo,
 		config:        config,
 	}
 }
@@ -130,6 +133,7 @@ type WorkflowMetrics struct {
 	WorkflowEfficiency []WorkflowEfficiency `json:"workflow_efficiency"`
 	OverdueTasks       []OverdueTaskSummary `json:"overdue_tasks"`
 	TaskCompletionRate float64              `json:"task_completion_rate"`
+	ChecklistItems     []ChecklistItemRate  `json:"checklist_items"`
 }
 
 // ComplianceMetrics shows compliance and audit status
@@ -231,6 +235,16 @@ type WorkflowEfficiency struct {
 	BottleneckStep string    `json:"bottleneck_step,omitempty"`
 }
 
+type ChecklistItemRate struct {
+	WorkflowID   uuid.UUID `json:"workflow_id"`
+	WorkflowName string    `json:"workflow_name"`
+	ItemKey      string    `json:"item_key"`
+	Label        string    `json:"label"`
+	TotalTasks   int64     `json:"total_tasks"`
+	CheckedTasks int64     `json:"checked_tasks"`
+	CheckedRate  float64   `json:"checked_rate"`
+}
+
 type OverdueTaskSummary struct {
 	TaskID       uuid.UUID `json:"task_id"`
 	DocumentName string    `json:"document_name"`
@@ -430,6 +444,22 @@ func (s *AnalyticsService) validatePeriod(period string) error {
 	return ErrInvalidPeriod
 }
 
+// periodStart returns the beginning of the reporting window for a dashboard period
+func periodStart(period string, now time.Time) time.Time {
+	switch period {
+	case "day":
+		return now.AddDate(0, 0, -1)
+	case "week":
+		return now.AddDate(0, 0, -7)
+	case "quarter":
+		return now.AddDate(0, -3, 0)
+	case "year":
+		return now.AddDate(-1, 0, 0)
+	default:
+		return now.AddDate(0, -1, 0)
+	}
+}
+
 func (s *AnalyticsService) validateDateRange(from, to *time.Time) error {
 	if from != nil && to != nil && from.After(*to) {
 		return ErrInvalidDateRange
@@ -495,7 +525,37 @@ func (s *AnalyticsService) getWorkflowMetrics(ctx context.Context, tenantID uuid
 		PendingTasks:   0,
 		TasksByStatus:  make(map[string]int64),
 		TasksByType:    make(map[string]int64),
+		ChecklistItems: s.getChecklistItemRates(ctx, tenantID, period),
+	}
+}
+
+func (s *AnalyticsService) getChecklistItemRates(ctx context.Context, tenantID uuid.UUID, period string) []ChecklistItemRate {
+	rates := []ChecklistItemRate{}
+	if s.checklistRepo == nil {
+		return rates
 	}
+
+	stats, err := s.checklistRepo.GetStats(ctx, tenantID, periodStart(period, time.Now()))
+	if err != nil {
+		return rates
+	}
+
+	for _, stat := range stats {
+		rate := ChecklistItemRate{
+			WorkflowID:   stat.WorkflowID,
+			WorkflowName: stat.WorkflowName,
+			ItemKey:      stat.ItemKey,
+			Label:        stat.Label,
+			TotalTasks:   stat.TotalTasks,
+			CheckedTasks: stat.CheckedTasks,
+		}
+		if stat.TotalTasks > 0 {
+			rate.CheckedRate = float64(stat.CheckedTasks) / float64(stat.TotalTasks) * 100
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates
 }
 
 func (s *AnalyticsService) getComplianceMetrics(ctx context.Context, tenantID uuid.UUID) *ComplianceMetrics {
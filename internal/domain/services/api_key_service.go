@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAPIKeyNotFound      = errors.New("api key not found")
+	ErrInvalidAPIKey       = errors.New("invalid, expired or revoked api key")
+	ErrAPIKeyRevoked       = errors.New("api key has been revoked")
+	ErrInvalidAPIKeyName   = errors.New("api key name is required and must be at most 100 characters")
+	ErrAPIKeyScopeRequired = errors.New("api key needs at least one scope")
+	ErrScopeNotHeld        = errors.New("cannot grant a scope you do not hold")
+)
+
+// apiKeyPrefix marks Archivus API keys so they can be told apart from user tokens
+const apiKeyPrefix = "ak_"
+
+// APIKeyService manages API keys for machine-to-machine access
+type APIKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+	userRepo   repositories.UserRepository
+	auditRepo  repositories.AuditLogRepository
+
+	userService *UserService
+}
+
+// NewAPIKeyService creates a new API key service instance
+func NewAPIKeyService(
+	apiKeyRepo repositories.APIKeyRepository,
+	userRepo repositories.UserRepository,
+	auditRepo repositories.AuditLogRepository,
+	userService *UserService,
+) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:  apiKeyRepo,
+		userRepo:    userRepo,
+		auditRepo:   auditRepo,
+		userService: userService,
+	}
+}
+
+// CreateAPIKeyParams contains parameters for creating an API key
+type CreateAPIKeyParams struct {
+	TenantID  uuid.UUID  `json:"tenant_id"`
+	CreatedBy uuid.UUID  `json:"created_by"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKey creates an API key and returns its plaintext, which is never stored
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, params CreateAPIKeyParams) (*models.APIKey, string, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" || len(name) > 100 {
+		return nil, "", ErrInvalidAPIKeyName
+	}
+
+	scopes, err := s.validateScopes(ctx, params.CreatedBy, params.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	prefix, plaintext, err := generateAPIKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key := &models.APIKey{
+		TenantID:  params.TenantID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(plaintext),
+		CreatedBy: params.CreatedBy,
+		ExpiresAt: params.ExpiresAt,
+		Scopes:    scopes,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.CreatedBy, key.ID, models.AuditCreate, "API key created: "+name)
+
+	return key, plaintext, nil
+}
+
+// ListAPIKeys lists a tenant's API keys, including revoked ones
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, tenantID uuid.UUID) ([]models.APIKey, error) {
+	return s.apiKeyRepo.ListByTenant(ctx, tenantID)
+}
+
+// GetAPIKey returns an API key belonging to the tenant
+func (s *APIKeyService) GetAPIKey(ctx context.Context, keyID, tenantID uuid.UUID) (*models.APIKey, error) {
+	key, err := s.apiKeyRepo.GetByID(ctx, keyID)
+	if err != nil || key.TenantID != tenantID {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// UpdateScopes replaces the scopes of an active API key
+func (s *APIKeyService) UpdateScopes(ctx context.Context, keyID, tenantID, updatedBy uuid.UUID, scopes []string) (*models.APIKey, error) {
+	key, err := s.getActiveKey(ctx, keyID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	validated, err := s.validateScopes(ctx, updatedBy, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = validated
+	key.UpdatedAt = time.Now()
+	if err := s.apiKeyRepo.Update(ctx, key); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, tenantID, updatedBy, key.ID, models.AuditUpdate, "API key scopes updated: "+key.Name)
+
+	return key, nil
+}
+
+// RotateAPIKey replaces the secret of an API key; the old key stops working immediately
+func (s *APIKeyService) RotateAPIKey(ctx context.Context, keyID, tenantID, rotatedBy uuid.UUID) (*models.APIKey, string, error) {
+	key, err := s.getActiveKey(ctx, keyID, tenantID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	prefix, plaintext, err := generateAPIKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key.Prefix = prefix
+	key.KeyHash = hashAPIKey(plaintext)
+	key.UpdatedAt = time.Now()
+	if err := s.apiKeyRepo.Update(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	s.createAuditLog(ctx, tenantID, rotatedBy, key.ID, models.AuditUpdate, "API key rotated: "+key.Name)
+
+	return key, plaintext, nil
+}
+
+// RevokeAPIKey permanently disables an API key
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, keyID, tenantID, revokedBy uuid.UUID) error {
+	key, err := s.getActiveKey(ctx, keyID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	key.UpdatedAt = now
+	if err := s.apiKeyRepo.Update(ctx, key); err != nil {
+		return err
+	}
+
+	s.createAuditLog(ctx, tenantID, revokedBy, key.ID, models.AuditDelete, "API key revoked: "+key.Name)
+
+	return nil
+}
+
+// IsAPIKey reports whether a credential looks like an Archivus API key
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
+
+// Authenticate resolves a plaintext API key to the key and the user who created it
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext string) (*models.APIKey, *models.User, error) {
+	prefix, ok := parseAPIKeyPrefix(plaintext)
+	if !ok {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(plaintext)), []byte(key.KeyHash)) != 1 {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	// Keys stop working when their creator leaves the tenant
+	user, err := s.userRepo.GetByID(ctx, key.CreatedBy)
+	if err != nil || !user.IsActive || user.TenantID != key.TenantID {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if err := s.apiKeyRepo.UpdateLastUsed(ctx, key.ID, now); err != nil {
+		// Log but don't fail - usage tracking is informational
+	}
+
+	return key, user, nil
+}
+
+// Helper methods
+
+func (s *APIKeyService) getActiveKey(ctx context.Context, keyID, tenantID uuid.UUID) (*models.APIKey, error) {
+	key, err := s.GetAPIKey(ctx, keyID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	return key, nil
+}
+
+// validateScopes checks scopes against the permission catalog and the granting user's own permissions
+func (s *APIKeyService) validateScopes(ctx context.Context, grantedBy uuid.UUID, scopes []string) ([]models.APIKeyScope, error) {
+	if len(scopes) == 0 {
+		return nil, ErrAPIKeyScopeRequired
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	validated := make([]models.APIKeyScope, 0, len(scopes))
+	for _, scope := range scopes {
+		if !isCatalogPermission(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, scope)
+		}
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+
+		held, err := s.userService.CheckPermission(ctx, grantedBy, scope)
+		if err != nil {
+			return nil, err
+		}
+		if !held {
+			return nil, fmt.Errorf("%w: %s", ErrScopeNotHeld, scope)
+		}
+
+		validated = append(validated, models.APIKeyScope{Permission: scope})
+	}
+
+	return validated, nil
+}
+
+func (s *APIKeyService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "api_key",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+// generateAPIKey returns a lookup prefix and the full key in the form ak_<prefix>_<secret>
+func generateAPIKey() (string, string, error) {
+	prefixBytes := make([]byte, 8)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix := hex.EncodeToString(prefixBytes)
+	return prefix, apiKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func parseAPIKeyPrefix(plaintext string) (string, bool) {
+	if !IsAPIKey(plaintext) {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(plaintext, apiKeyPrefix), "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,212 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAPIKeyService(db *testutil.TestDB) *services.APIKeyService {
+	repos := postgresql.NewRepositories(db.DB)
+	userService := services.NewUserService(
+		repos.UserRepo,
+		repos.RoleRepo,
+		repos.TenantRepo,
+		repos.AuditRepo,
+		repos.MFACodeRepo,
+		nil,
+		nil,
+		services.UserServiceConfig{},
+		nil,
+		nil,
+	)
+	return services.NewAPIKeyService(repos.APIKeyRepo, repos.UserRepo, repos.AuditRepo, userService)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	service := newTestAPIKeyService(db)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	creator := db.CreateTestUser(t, tenant)
+
+	key, plaintext, err := service.CreateAPIKey(ctx, services.CreateAPIKeyParams{
+		TenantID:  tenant.ID,
+		CreatedBy: creator.ID,
+		Name:      "Integration",
+		Scopes:    []string{"documents.read"},
+	})
+	require.NoError(t, err)
+	assert.True(t, services.IsAPIKey(plaintext))
+	assert.NotContains(t, key.KeyHash, plaintext)
+
+	t.Run("valid key resolves to its creator", func(t *testing.T) {
+		found, user, err := service.Authenticate(ctx, plaintext)
+		require.NoError(t, err)
+		assert.Equal(t, key.ID, found.ID)
+		assert.Equal(t, creator.ID, user.ID)
+	})
+
+	t.Run("malformed keys are rejected", func(t *testing.T) {
+		for _, credential := range []string{"", "ak_", "ak_" + key.Prefix, "ak__secret", "not-a-key"} {
+			_, _, err := service.Authenticate(ctx, credential)
+			assert.ErrorIs(t, err, services.ErrInvalidAPIKey, credential)
+		}
+	})
+
+	t.Run("unknown prefix is rejected", func(t *testing.T) {
+		_, _, err := service.Authenticate(ctx, "ak_0000000000000000_secret")
+		assert.ErrorIs(t, err, services.ErrInvalidAPIKey)
+	})
+
+	t.Run("wrong secret for a known prefix is rejected", func(t *testing.T) {
+		_, _, err := service.Authenticate(ctx, "ak_"+key.Prefix+"_wrongsecret")
+		assert.ErrorIs(t, err, services.ErrInvalidAPIKey)
+	})
+
+	t.Run("expired key is rejected", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		_, expiring, err := service.CreateAPIKey(ctx, services.CreateAPIKeyParams{
+			TenantID:  tenant.ID,
+			CreatedBy: creator.ID,
+			Name:      "Expiring",
+			Scopes:    []string{"documents.read"},
+			ExpiresAt: &expiresAt,
+		})
+		require.NoError(t, err)
+
+		_, _, err = service.Authenticate(ctx, expiring)
+		require.NoError(t, err)
+
+		require.NoError(t, db.Model(&models.APIKey{}).
+			Where("name = ?", "Expiring").
+			Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		_, _, err = service.Authenticate(ctx, expiring)
+		assert.ErrorIs(t, err, services.ErrInvalidAPIKey)
+	})
+
+	t.Run("rotated key stops working", func(t *testing.T) {
+		_, rotated, err := service.RotateAPIKey(ctx, key.ID, tenant.ID, creator.ID)
+		require.NoError(t, err)
+
+		_, _, err = service.Authenticate(ctx, plaintext)
+		assert.ErrorIs(t, err, services.ErrInvalidAPIKey)
+
+		_, _, err = service.Authenticate(ctx, rotated)
+		require.NoError(t, err)
+		plaintext = rotated
+	})
+
+	t.Run("revoked key is rejected", func(t *testing.T) {
+		require.NoError(t, service.RevokeAPIKey(ctx, key.ID, tenant.ID, creator.ID))
+
+		_, _, err := service.Authenticate(ctx, plaintext)
+		assert.ErrorIs(t, err, services.ErrInvalidAPIKey)
+
+		err = service.RevokeAPIKey(ctx, key.ID, tenant.ID, creator.ID)
+		assert.ErrorIs(t, err, services.ErrAPIKeyRevoked)
+	})
+
+	t.Run("keys of deactivated creators are rejected", func(t *testing.T) {
+		_, active, err := service.CreateAPIKey(ctx, services.CreateAPIKeyParams{
+			TenantID:  tenant.ID,
+			CreatedBy: creator.ID,
+			Name:      "Orphaned",
+			Scopes:    []string{"documents.read"},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, db.Model(&models.User{}).
+			Where("id = ?", creator.ID).
+			Update("is_active", false).Error)
+
+		_, _, err = service.Authenticate(ctx, active)
+		assert.ErrorIs(t, err, services.ErrInvalidAPIKey)
+	})
+}
+
+func TestAPIKeyService_ScopeValidation(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	service := newTestAPIKeyService(db)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	other := db.CreateTestTenant(t)
+
+	create := func(scopes ...string) (*models.APIKey, error) {
+		key, _, err := service.CreateAPIKey(ctx, services.CreateAPIKeyParams{
+			TenantID:  tenant.ID,
+			CreatedBy: user.ID,
+			Name:      "Scoped",
+			Scopes:    scopes,
+		})
+		return key, err
+	}
+
+	t.Run("at least one scope is required", func(t *testing.T) {
+		_, err := create()
+		assert.ErrorIs(t, err, services.ErrAPIKeyScopeRequired)
+	})
+
+	t.Run("unknown permissions are rejected", func(t *testing.T) {
+		_, err := create("documents.teleport")
+		assert.ErrorIs(t, err, services.ErrUnknownPermission)
+	})
+
+	t.Run("scopes cannot exceed the creator's permissions", func(t *testing.T) {
+		_, err := create("documents.read", "users.create")
+		assert.ErrorIs(t, err, services.ErrScopeNotHeld)
+	})
+
+	t.Run("duplicate scopes are collapsed", func(t *testing.T) {
+		key, err := create("documents.read", "documents.read", "documents.create")
+		require.NoError(t, err)
+		assert.Len(t, key.Scopes, 2)
+	})
+
+	t.Run("updating scopes applies the same rules", func(t *testing.T) {
+		key, err := create("documents.read")
+		require.NoError(t, err)
+
+		_, err = service.UpdateScopes(ctx, key.ID, tenant.ID, user.ID, []string{"users.create"})
+		assert.ErrorIs(t, err, services.ErrScopeNotHeld)
+
+		updated, err := service.UpdateScopes(ctx, key.ID, tenant.ID, user.ID, []string{"documents.update"})
+		require.NoError(t, err)
+		require.Len(t, updated.Scopes, 1)
+		assert.Equal(t, "documents.update", updated.Scopes[0].Permission)
+	})
+
+	t.Run("keys are scoped to their tenant", func(t *testing.T) {
+		key, err := create("documents.read")
+		require.NoError(t, err)
+
+		_, err = service.GetAPIKey(ctx, key.ID, other.ID)
+		assert.ErrorIs(t, err, services.ErrAPIKeyNotFound)
+	})
+
+	t.Run("names are validated", func(t *testing.T) {
+		_, _, err := service.CreateAPIKey(ctx, services.CreateAPIKeyParams{
+			TenantID:  tenant.ID,
+			CreatedBy: user.ID,
+			Name:      strings.Repeat("x", 101),
+			Scopes:    []string{"documents.read"},
+		})
+		assert.ErrorIs(t, err, services.ErrInvalidAPIKeyName)
+	})
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAPIQuotaExceeded = errors.New("monthly API request quota exceeded")
+)
+
+// API usage counters kept per tenant and month
+const (
+	apiUsageRequests      = "requests" // Every request past the rate limit, including those over quota
+	apiUsageQuotaRejected = "quota_rejected"
+	apiUsageRateLimited   = "rate_limited"
+)
+
+// apiUsagePeriodFormat names a month in cache keys
+const apiUsagePeriodFormat = "2006-01"
+
+// APIUsageConfig holds configuration for API metering
+type APIUsageConfig struct {
+	RateLimitWindow time.Duration // Window the tier rate limits apply to; defaults to a minute
+	FlushInterval   time.Duration // How often counters are written to the database; defaults to a minute
+	HistoryMonths   int           // Months of history the usage report includes; defaults to 12
+}
+
+// APIRateStatus is a tenant's standing in the current rate limit window,
+// sent back in X-RateLimit-* headers
+type APIRateStatus struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// APIUsageLimits are the limits that apply to a tenant: the rate limit of its
+// tier and its own monthly API quota
+type APIUsageLimits struct {
+	Tier         models.SubscriptionTier `json:"tier"`
+	RateLimit    int                     `json:"rate_limit"`    // Requests per window
+	MonthlyQuota int64                   `json:"monthly_quota"` // Requests per calendar month
+}
+
+// APIUsageReport is a tenant's API usage for billing dashboards
+type APIUsageReport struct {
+	TenantID               uuid.UUID               `json:"tenant_id"`
+	Tier                   models.SubscriptionTier `json:"tier"`
+	RateLimit              int                     `json:"rate_limit"`
+	RateLimitWindowSeconds int                     `json:"rate_limit_window_seconds"`
+	MonthlyQuota           int64                   `json:"monthly_quota"`
+	Current                models.APIUsage         `json:"current"`
+	Remaining              int64                   `json:"remaining"`
+	History                []models.APIUsage       `json:"history"`
+}
+
+// APIUsageService meters authenticated API requests per tenant in Redis,
+// enforces the tier's rate limit and the tenant's monthly API quota, and
+// flushes the counters to the database for billing, keeping the tenant's
+// APIUsed at the month's requests. Cache outages fail open so Redis being
+// down doesn't block every request.
+type APIUsageService struct {
+	tenantRepo   repositories.TenantRepository
+	usageRepo    repositories.APIUsageRepository
+	cacheService CacheService
+	config       APIUsageConfig
+}
+
+// NewAPIUsageService creates a new API usage service
+func NewAPIUsageService(
+	tenantRepo repositories.TenantRepository,
+	usageRepo repositories.APIUsageRepository,
+	cacheService CacheService,
+	config APIUsageConfig,
+) *APIUsageService {
+	if config.RateLimitWindow <= 0 {
+		config.RateLimitWindow = time.Minute
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Minute
+	}
+	if config.HistoryMonths <= 0 {
+		config.HistoryMonths = 12
+	}
+
+	return &APIUsageService{
+		tenantRepo:   tenantRepo,
+		usageRepo:    usageRepo,
+		cacheService: cacheService,
+		config:       config,
+	}
+}
+
+// Meter counts a request by the tenant. It returns a RateLimitedError once
+// the tier's rate limit for the window is used up, and ErrAPIQuotaExceeded
+// once the tenant's monthly API quota is. The rate status is returned either way, when
+// known.
+func (s *APIUsageService) Meter(ctx context.Context, tenantID uuid.UUID) (*APIRateStatus, error) {
+	if s.cacheService == nil {
+		return nil, nil
+	}
+
+	limits := s.limits(ctx, tenantID)
+	now := time.Now().UTC()
+	period := now.Format(apiUsagePeriodFormat)
+
+	var status *APIRateStatus
+	if limits.RateLimit > 0 {
+		// Fixed windows: the first request of a window sets the expiry
+		windowStart := now.Truncate(s.config.RateLimitWindow)
+		key := fmt.Sprintf(APIRateLimitKeyPattern, tenantID.String(), windowStart.Unix())
+		s.cacheService.SetNX(ctx, key, 0, s.config.RateLimitWindow)
+		if count, err := s.cacheService.Increment(ctx, key); err == nil {
+			status = &APIRateStatus{
+				Limit:     limits.RateLimit,
+				Remaining: max(limits.RateLimit-int(count), 0),
+				Reset:     windowStart.Add(s.config.RateLimitWindow),
+			}
+			if count > int64(limits.RateLimit) {
+				s.increment(ctx, tenantID, period, apiUsageRateLimited)
+				return status, &RateLimitedError{Until: status.Reset}
+			}
+		}
+	}
+
+	count, err := s.increment(ctx, tenantID, period, apiUsageRequests)
+	if err != nil {
+		return status, nil
+	}
+	if count == 1 {
+		// First request of the month; the flush finds the tenant from here
+		s.cacheService.SAdd(ctx, fmt.Sprintf(APIUsageTenantsKeyPattern, period), tenantID.String())
+	}
+	if limits.MonthlyQuota > 0 && count > limits.MonthlyQuota {
+		s.increment(ctx, tenantID, period, apiUsageQuotaRejected)
+		return status, ErrAPIQuotaExceeded
+	}
+
+	return status, nil
+}
+
+// GetUsage returns the tenant's limits, its usage so far this month and its
+// monthly history
+func (s *APIUsageService) GetUsage(ctx context.Context, tenantID uuid.UUID) (*APIUsageReport, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	limits := tenantAPIUsageLimits(tenant)
+
+	now := time.Now().UTC()
+	currentPeriod := monthStart(now)
+	from := currentPeriod.AddDate(0, -(s.config.HistoryMonths - 1), 0)
+	history, err := s.usageRepo.ListByTenant(ctx, tenantID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	// The live counters are ahead of the last flush
+	current := models.APIUsage{TenantID: tenantID, Period: currentPeriod}
+	for _, usage := range history {
+		if usage.Period.Equal(currentPeriod) {
+			current = usage
+		}
+	}
+	if live, ok := s.readCounters(ctx, tenantID, now.Format(apiUsagePeriodFormat)); ok {
+		current.Requests = max(current.Requests, live.Requests)
+		current.QuotaRejected = max(current.QuotaRejected, live.QuotaRejected)
+		current.RateLimited = max(current.RateLimited, live.RateLimited)
+		current.UpdatedAt = now
+	}
+
+	report := &APIUsageReport{
+		TenantID:               tenantID,
+		Tier:                   limits.Tier,
+		RateLimit:              limits.RateLimit,
+		RateLimitWindowSeconds: int(s.config.RateLimitWindow / time.Second),
+		MonthlyQuota:           limits.MonthlyQuota,
+		Current:                current,
+		History:                history,
+	}
+	if limits.MonthlyQuota > 0 {
+		report.Remaining = max(limits.MonthlyQuota-current.Requests, 0)
+	}
+
+	return report, nil
+}
+
+// FlushUsage writes this and last month's counters to the database and sets
+// the tenants' APIUsed to this month's requests. Last month is included so
+// requests counted just before the turn of the month aren't lost, and so
+// tenants with none since start the month at zero.
+func (s *APIUsageService) FlushUsage(ctx context.Context) error {
+	if s.cacheService == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	current := monthStart(now)
+	apiUsed := make(map[uuid.UUID]int64)
+	var errs []error
+	for _, period := range []time.Time{current.AddDate(0, -1, 0), current} {
+		name := period.Format(apiUsagePeriodFormat)
+		tenants, err := s.cacheService.SMembers(ctx, fmt.Sprintf(APIUsageTenantsKeyPattern, name))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list metered tenants: %w", err))
+			continue
+		}
+
+		for _, member := range tenants {
+			tenantID, err := uuid.Parse(member)
+			if err != nil {
+				continue
+			}
+			usage, ok := s.readCounters(ctx, tenantID, name)
+			if !ok {
+				continue
+			}
+			usage.Period = period
+			if err := s.usageRepo.Upsert(ctx, usage); err != nil {
+				errs = append(errs, err)
+			}
+			if period.Equal(current) {
+				apiUsed[tenantID] = usage.Requests
+			} else if _, ok := apiUsed[tenantID]; !ok {
+				apiUsed[tenantID] = 0
+			}
+		}
+	}
+
+	for tenantID, used := range apiUsed {
+		if err := s.tenantRepo.SetAPIUsed(ctx, tenantID, used); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// FlushTask is the scheduled task that writes API usage to the database
+func (s *APIUsageService) FlushTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "api_usage_flush",
+		Interval: s.config.FlushInterval,
+		Run:      s.FlushUsage,
+	}
+}
+
+// Helper methods
+
+// limits returns the tenant's limits, cached briefly since every request
+// needs them. A tier or quota change takes effect once the cache expires.
+func (s *APIUsageService) limits(ctx context.Context, tenantID uuid.UUID) APIUsageLimits {
+	cacheKey := fmt.Sprintf(APIUsageLimitsKeyPattern, tenantID.String())
+	if cached, err := s.cacheService.Get(ctx, cacheKey); err == nil {
+		var limits APIUsageLimits
+		if json.Unmarshal([]byte(cached), &limits) == nil {
+			return limits
+		}
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		// Unknown tenants get the lowest tier's limits; not cached so a
+		// transient error doesn't stick
+		limits := tierAPIUsageLimits(models.SubscriptionStarter)
+		limits.MonthlyQuota = models.TierAPIRequestQuotas[models.SubscriptionStarter]
+		return limits
+	}
+
+	limits := tenantAPIUsageLimits(tenant)
+	if data, err := json.Marshal(limits); err == nil {
+		s.cacheService.Set(ctx, cacheKey, string(data), CacheShortTerm)
+	}
+	return limits
+}
+
+func (s *APIUsageService) increment(ctx context.Context, tenantID uuid.UUID, period, counter string) (int64, error) {
+	key := fmt.Sprintf(APIUsageCounterKeyPattern, tenantID.String(), period, counter)
+	// Counters outlive their month long enough to be flushed
+	s.cacheService.SetNX(ctx, key, 0, 45*24*time.Hour)
+	return s.cacheService.Increment(ctx, key)
+}
+
+// readCounters returns the tenant's live counters for a month, with the
+// requests over quota taken out of Requests
+func (s *APIUsageService) readCounters(ctx context.Context, tenantID uuid.UUID, period string) (*models.APIUsage, bool) {
+	if s.cacheService == nil {
+		return nil, false
+	}
+
+	counts := make(map[string]int64, 3)
+	for _, counter := range []string{apiUsageRequests, apiUsageQuotaRejected, apiUsageRateLimited} {
+		value, err := s.cacheService.Get(ctx, fmt.Sprintf(APIUsageCounterKeyPattern, tenantID.String(), period, counter))
+		if err != nil {
+			continue
+		}
+		counts[counter], _ = strconv.ParseInt(value, 10, 64)
+	}
+	if len(counts) == 0 {
+		return nil, false
+	}
+
+	return &models.APIUsage{
+		TenantID:      tenantID,
+		Requests:      max(counts[apiUsageRequests]-counts[apiUsageQuotaRejected], 0),
+		QuotaRejected: counts[apiUsageQuotaRejected],
+		RateLimited:   counts[apiUsageRateLimited],
+	}, true
+}
+
+// tenantAPIUsageLimits returns a tenant's limits: its tier's rate limit and
+// its own monthly API quota
+func tenantAPIUsageLimits(tenant *models.Tenant) APIUsageLimits {
+	limits := tierAPIUsageLimits(tenant.SubscriptionTier)
+	limits.MonthlyQuota = int64(tenant.APIQuota)
+	return limits
+}
+
+// tierAPIUsageLimits returns the rate limit of a tier, or the starter tier's
+// for unknown tiers
+func tierAPIUsageLimits(tier models.SubscriptionTier) APIUsageLimits {
+	if _, ok := models.TierAPIRateLimits[tier]; !ok {
+		tier = models.SubscriptionStarter
+	}
+	return APIUsageLimits{
+		Tier:      tier,
+		RateLimit: models.TierAPIRateLimits[tier],
+	}
+}
+
+// monthStart returns midnight UTC on the first day of t's month
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCache is the part of CacheService the meter uses, kept in memory
+type memoryCache struct {
+	CacheService
+	mu     sync.Mutex
+	values map[string]string
+	sets   map[string]map[string]bool
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: make(map[string]string), sets: make(map[string]map[string]bool)}
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = fmt.Sprint(value)
+	return nil
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.values[key]
+	if !ok {
+		return "", errors.New("cache miss")
+	}
+	return value, nil
+}
+
+func (m *memoryCache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.values[key]; ok {
+		return false, nil
+	}
+	m.values[key] = fmt.Sprint(value)
+	return true, nil
+}
+
+func (m *memoryCache) Increment(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count, _ := strconv.ParseInt(m.values[key], 10, 64)
+	count++
+	m.values[key] = strconv.FormatInt(count, 10)
+	return count, nil
+}
+
+func (m *memoryCache) SAdd(ctx context.Context, key string, members ...interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sets[key] == nil {
+		m.sets[key] = make(map[string]bool)
+	}
+	for _, member := range members {
+		m.sets[key][fmt.Sprint(member)] = true
+	}
+	return nil
+}
+
+func (m *memoryCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var members []string
+	for member := range m.sets[key] {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// memoryAPIUsageRepo keeps flushed usage in memory
+type memoryAPIUsageRepo struct {
+	repositories.APIUsageRepository
+	usage []models.APIUsage
+}
+
+func (m *memoryAPIUsageRepo) Upsert(ctx context.Context, usage *models.APIUsage) error {
+	m.usage = append(m.usage, *usage)
+	return nil
+}
+
+// apiUsedTenantRepo records the API usage set on tenants
+type apiUsedTenantRepo struct {
+	repositories.TenantRepository
+	apiUsed map[uuid.UUID]int64
+}
+
+func (r *apiUsedTenantRepo) SetAPIUsed(ctx context.Context, tenantID uuid.UUID, apiUsed int64) error {
+	r.apiUsed[tenantID] = apiUsed
+	return nil
+}
+
+func TestAPIUsageService_Meter(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemoryCache()
+	service := NewAPIUsageService(nil, nil, cache, APIUsageConfig{RateLimitWindow: time.Hour})
+
+	// Cached limits keep the tenant lookup out of the way
+	tenantID := uuid.New()
+	limits, err := json.Marshal(APIUsageLimits{Tier: models.SubscriptionStarter, RateLimit: 3, MonthlyQuota: 2})
+	require.NoError(t, err)
+	cache.Set(ctx, fmt.Sprintf(APIUsageLimitsKeyPattern, tenantID), string(limits), time.Hour)
+
+	for i := 0; i < 2; i++ {
+		status, err := service.Meter(ctx, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, status.Limit)
+		assert.Equal(t, 2-i, status.Remaining)
+	}
+
+	// Over the monthly quota
+	_, err = service.Meter(ctx, tenantID)
+	assert.ErrorIs(t, err, ErrAPIQuotaExceeded)
+
+	// Over the rate limit
+	status, err := service.Meter(ctx, tenantID)
+	var limited *RateLimitedError
+	require.ErrorAs(t, err, &limited)
+	assert.Equal(t, status.Reset, limited.Until)
+	assert.Equal(t, 0, status.Remaining)
+
+	period := time.Now().UTC().Format(apiUsagePeriodFormat)
+	usage, ok := service.readCounters(ctx, tenantID, period)
+	require.True(t, ok)
+	assert.Equal(t, int64(2), usage.Requests)
+	assert.Equal(t, int64(1), usage.QuotaRejected)
+	assert.Equal(t, int64(1), usage.RateLimited)
+	assert.True(t, cache.sets[fmt.Sprintf(APIUsageTenantsKeyPattern, period)][tenantID.String()])
+}
+
+func TestAPIUsageService_FlushSetsAPIUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemoryCache()
+	tenantRepo := &apiUsedTenantRepo{apiUsed: make(map[uuid.UUID]int64)}
+	usageRepo := &memoryAPIUsageRepo{}
+	service := NewAPIUsageService(tenantRepo, usageRepo, cache, APIUsageConfig{})
+
+	active, idle := uuid.New(), uuid.New()
+	limits, err := json.Marshal(APIUsageLimits{Tier: models.SubscriptionStarter, MonthlyQuota: 2})
+	require.NoError(t, err)
+	cache.Set(ctx, fmt.Sprintf(APIUsageLimitsKeyPattern, active), string(limits), time.Hour)
+	for i := 0; i < 3; i++ {
+		service.Meter(ctx, active)
+	}
+
+	// A tenant that only made requests last month starts this one at zero
+	lastMonth := monthStart(time.Now()).AddDate(0, -1, 0).Format(apiUsagePeriodFormat)
+	cache.Set(ctx, fmt.Sprintf(APIUsageCounterKeyPattern, idle, lastMonth, apiUsageRequests), 40, time.Hour)
+	cache.SAdd(ctx, fmt.Sprintf(APIUsageTenantsKeyPattern, lastMonth), idle.String())
+
+	require.NoError(t, service.FlushUsage(ctx))
+	assert.Equal(t, map[uuid.UUID]int64{active: 2, idle: 0}, tenantRepo.apiUsed)
+	assert.Len(t, usageRepo.usage, 2)
+}
+
+func TestTenantAPIUsageLimits(t *testing.T) {
+	starter := tierAPIUsageLimits(models.SubscriptionStarter)
+	enterprise := tierAPIUsageLimits(models.SubscriptionEnterprise)
+	assert.Less(t, starter.RateLimit, enterprise.RateLimit)
+
+	// Unknown tiers get the starter limits
+	assert.Equal(t, starter, tierAPIUsageLimits("legacy"))
+
+	// The monthly quota is the tenant's own
+	limits := tenantAPIUsageLimits(&models.Tenant{SubscriptionTier: models.SubscriptionEnterprise, APIQuota: 250})
+	assert.Equal(t, enterprise.RateLimit, limits.RateLimit)
+	assert.Equal(t, int64(250), limits.MonthlyQuota)
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrStampNotPDF        = errors.New("only PDF documents can be stamped")
+	ErrStampLegalHold     = errors.New("documents under legal hold can't be stamped")
+	ErrStampFileTooLarge  = errors.New("document is too large to stamp")
+	ErrStampFileChanged   = errors.New("document file changed while it was being stamped")
+	ErrInvalidStampConfig = errors.New("invalid approval stamp settings")
+)
+
+// DefaultStampText is the stamp's title when a workflow doesn't set one
+const DefaultStampText = "APPROVED"
+
+// ApprovalStampSettings has a workflow stamp its documents once approved.
+// The stamped PDF becomes the document's current version; the original is
+// kept as the version before it.
+type ApprovalStampSettings struct {
+	Enabled  bool   `json:"enabled"`
+	Text     string `json:"text,omitempty"`     // Defaults to APPROVED
+	Position string `json:"position,omitempty"` // "top_right" (default), "top_left", "bottom_right", "bottom_left"
+	Pages    string `json:"pages,omitempty"`    // "first" (default), "last", "all"
+}
+
+// Validate checks the settings are ones the stamper understands
+func (s ApprovalStampSettings) Validate() error {
+	if len(s.Text) > 40 {
+		return fmt.Errorf("%w: text can be at most 40 characters", ErrInvalidStampConfig)
+	}
+	switch s.Position {
+	case "", StampPositionTopRight, StampPositionTopLeft, StampPositionBottomRight, StampPositionBottomLeft:
+	default:
+		return fmt.Errorf("%w: unknown position %q", ErrInvalidStampConfig, s.Position)
+	}
+	switch s.Pages {
+	case "", StampPagesFirst, StampPagesLast, StampPagesAll:
+	default:
+		return fmt.Errorf("%w: unknown pages %q", ErrInvalidStampConfig, s.Pages)
+	}
+	return nil
+}
+
+// ApprovalStampService stamps approved documents with who approved them and
+// when, so whoever receives the PDF can see it was approved
+type ApprovalStampService struct {
+	documentRepo repositories.DocumentRepository
+	userRepo     repositories.UserRepository
+	tenantRepo   repositories.TenantRepository
+	auditRepo    repositories.AuditLogRepository
+	storage      StorageService
+	stamper      PDFStamper
+	config       ApprovalStampServiceConfig
+}
+
+// ApprovalStampServiceConfig holds configuration for approval stamps
+type ApprovalStampServiceConfig struct {
+	MaxFileSize int64 // Larger documents aren't stamped; defaults to 100MB
+}
+
+// NewApprovalStampService creates a new approval stamp service
+func NewApprovalStampService(
+	documentRepo repositories.DocumentRepository,
+	userRepo repositories.UserRepository,
+	tenantRepo repositories.TenantRepository,
+	auditRepo repositories.AuditLogRepository,
+	storage StorageService,
+	stamper PDFStamper,
+	config ApprovalStampServiceConfig,
+) *ApprovalStampService {
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = 100 * 1024 * 1024
+	}
+
+	return &ApprovalStampService{
+		documentRepo: documentRepo,
+		userRepo:     userRepo,
+		tenantRepo:   tenantRepo,
+		auditRepo:    auditRepo,
+		storage:      storage,
+		stamper:      stamper,
+		config:       config,
+	}
+}
+
+// StampApproval stamps the document's PDF with the approver and approval
+// time, storing the result as the document's new version
+func (s *ApprovalStampService) StampApproval(ctx context.Context, documentID, approverID uuid.UUID, approvedAt time.Time, settings ApprovalStampSettings) (*models.Document, error) {
+	document, err := s.documentRepo.GetByID(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if document.ContentType != "application/pdf" {
+		return nil, ErrStampNotPDF
+	}
+	if document.LegalHold {
+		return nil, ErrStampLegalHold
+	}
+	if document.FileSize > s.config.MaxFileSize {
+		return nil, ErrStampFileTooLarge
+	}
+
+	original, err := s.readFile(ctx, document.StoragePath)
+	if err != nil {
+		return nil, err
+	}
+
+	stamped, err := s.stamper.Stamp(ctx, original, PDFStamp{
+		Lines:    s.stampLines(ctx, approverID, approvedAt, settings),
+		Position: settings.Position,
+		Pages:    settings.Pages,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stamp document: %w", err)
+	}
+
+	path, err := s.storage.Store(ctx, StorageParams{
+		TenantID:    document.TenantID,
+		FileReader:  bytes.NewReader(stamped),
+		Filename:    document.FileName,
+		ContentType: document.ContentType,
+		Size:        int64(len(stamped)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store stamped document: %w", err)
+	}
+
+	sum := sha256.Sum256(stamped)
+	file := repositories.DocumentFile{StoragePath: path, FileSize: int64(len(stamped)), ContentHash: hex.EncodeToString(sum[:])}
+	replaced, err := s.documentRepo.ReplaceFile(ctx, document, file, "Approval stamp", approverID)
+	if err != nil || !replaced {
+		s.storage.Delete(context.WithoutCancel(ctx), path)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrStampFileChanged
+	}
+
+	// The original is kept as a version, so the stamped copy adds to usage
+	if err := s.tenantRepo.UpdateUsage(ctx, document.TenantID, file.FileSize, 0); err != nil {
+		// Log but continue - storage reconciliation corrects usage
+	}
+
+	s.createAuditLog(ctx, document.TenantID, approverID, document.ID, document.Version+1)
+
+	document.StoragePath = file.StoragePath
+	document.FileSize = file.FileSize
+	document.ContentHash = file.ContentHash
+	document.Version++
+	return document, nil
+}
+
+// Helper methods
+
+func (s *ApprovalStampService) readFile(ctx context.Context, path string) ([]byte, error) {
+	reader, err := s.storage.Get(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document file: %w", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(io.LimitReader(reader, s.config.MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document file: %w", err)
+	}
+	if int64(len(content)) > s.config.MaxFileSize {
+		return nil, ErrStampFileTooLarge
+	}
+	return content, nil
+}
+
+// stampLines are the stamp's title, the approver and the approval time
+func (s *ApprovalStampService) stampLines(ctx context.Context, approverID uuid.UUID, approvedAt time.Time, settings ApprovalStampSettings) []string {
+	title := strings.TrimSpace(settings.Text)
+	if title == "" {
+		title = DefaultStampText
+	}
+
+	approver := approverID.String()
+	if user, err := s.userRepo.GetByID(ctx, approverID); err == nil {
+		if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+			approver = name
+		} else {
+			approver = user.Email
+		}
+	}
+
+	return []string{
+		strings.ToUpper(title),
+		"By " + approver,
+		approvedAt.UTC().Format("2006-01-02 15:04 UTC"),
+	}
+}
+
+func (s *ApprovalStampService) createAuditLog(ctx context.Context, tenantID, userID, documentID uuid.UUID, version int) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   documentID,
+		ResourceType: "document",
+		Action:       models.AuditUpdate,
+		Details:      models.JSONB{"message": "Approval stamp applied", "version": version},
+	}
+
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
@@ -0,0 +1,667 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// Archival schedule errors
+var (
+	ErrArchivalScheduleNotFound = errors.New("archival schedule not found")
+	ErrArchivalRunNotFound      = errors.New("archival run not found")
+	ErrInvalidArchivalSchedule  = errors.New("invalid archival schedule")
+	ErrArchivalRunNotUndoable   = errors.New("archival run is running or was already undone")
+	ErrArchivalUndoExpired      = errors.New("the archival run's undo window has closed")
+	ErrColdStorageUnavailable   = errors.New("cold storage isn't configured on this server")
+)
+
+// archivalSampleSize is how many documents a preview shows
+const archivalSampleSize = 10
+
+// maxArchivalAgeDays bounds how old a schedule may require documents to be
+const maxArchivalAgeDays = maxRetentionYears * 366
+
+// ArchivalNotifier tells folder owners documents in their folders were archived
+type ArchivalNotifier interface {
+	SendFolderArchived(ctx context.Context, userID uuid.UUID, run *models.ArchivalRun, scheduleName, folderPath string, documents int64) error
+}
+
+// ArchivalConfig holds configuration for folder archival
+type ArchivalConfig struct {
+	Interval        time.Duration // How often due schedules are run; defaults to 5 minutes
+	UndoWindow      time.Duration // How long a run can be undone; defaults to 7 days
+	SchedulesPerRun int           // Schedules run per interval; defaults to 10
+	BatchSize       int           // Documents archived per batch; defaults to 500
+}
+
+// ArchivalScheduleParams describes a schedule: the folder, which of its
+// documents to archive, and when. RunAt is the first run, e.g. the day after
+// a fiscal year closes; yearly schedules run again on its anniversary.
+type ArchivalScheduleParams struct {
+	TenantID          uuid.UUID
+	UserID            uuid.UUID
+	Name              string
+	FolderID          uuid.UUID
+	IncludeSubfolders bool
+	MinAgeDays        int
+	ColdStorage       bool
+	Recurrence        models.ArchivalRecurrence
+	RunAt             time.Time
+	Enabled           bool
+}
+
+// ArchivalFolderImpact is what a schedule would archive in one folder
+type ArchivalFolderImpact struct {
+	FolderID uuid.UUID       `json:"folder_id"`
+	Path     string          `json:"path"`
+	Archive  RetentionImpact `json:"archive"`
+	Held     RetentionImpact `json:"held"`
+}
+
+// ArchivalPreview is what a schedule would archive if it ran at EvaluatedAt
+// with today's documents. Held documents match but are under legal hold, so
+// they'd be left alone.
+type ArchivalPreview struct {
+	EvaluatedAt time.Time              `json:"evaluated_at"`
+	DatedBefore *time.Time             `json:"dated_before,omitempty"`
+	Archive     RetentionImpact        `json:"archive"`
+	Held        RetentionImpact        `json:"held"`
+	Folders     []ArchivalFolderImpact `json:"folders"`
+	Owners      []uuid.UUID            `json:"owners"` // Folder owners who'd be notified
+	Sample      []models.Document      `json:"sample"`
+}
+
+// archivalScope is the folders a schedule covers
+type archivalScope struct {
+	folderIDs []uuid.UUID
+	folders   map[uuid.UUID]models.Folder
+}
+
+// ArchivalService archives folders on a schedule, e.g. a project folder once
+// its fiscal year has closed. Archived documents keep counting against the
+// tenant's quota. Each run can be undone for a while; only then are the
+// files moved to cold storage, when the schedule asks for it.
+type ArchivalService struct {
+	archivalRepo repositories.ArchivalRepository
+	folderRepo   repositories.FolderRepository
+	tenantRepo   repositories.TenantRepository
+	auditRepo    repositories.AuditLogRepository
+	storage      StorageService
+	notifier     ArchivalNotifier
+	config       ArchivalConfig
+}
+
+// NewArchivalService creates a new archival service. notifier may be nil.
+func NewArchivalService(
+	archivalRepo repositories.ArchivalRepository,
+	folderRepo repositories.FolderRepository,
+	tenantRepo repositories.TenantRepository,
+	auditRepo repositories.AuditLogRepository,
+	storage StorageService,
+	notifier ArchivalNotifier,
+	config ArchivalConfig,
+) *ArchivalService {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Minute
+	}
+	if config.UndoWindow <= 0 {
+		config.UndoWindow = 7 * 24 * time.Hour
+	}
+	if config.SchedulesPerRun <= 0 {
+		config.SchedulesPerRun = 10
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+
+	return &ArchivalService{
+		archivalRepo: archivalRepo,
+		folderRepo:   folderRepo,
+		tenantRepo:   tenantRepo,
+		auditRepo:    auditRepo,
+		storage:      storage,
+		notifier:     notifier,
+		config:       config,
+	}
+}
+
+// CreateSchedule saves a new schedule; it first runs at RunAt
+func (s *ArchivalService) CreateSchedule(ctx context.Context, params ArchivalScheduleParams) (*models.ArchivalSchedule, error) {
+	if err := s.validate(ctx, &params); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	runAt := params.RunAt
+	schedule := &models.ArchivalSchedule{
+		ID:                uuid.New(),
+		TenantID:          params.TenantID,
+		FolderID:          params.FolderID,
+		Name:              params.Name,
+		IncludeSubfolders: params.IncludeSubfolders,
+		MinAgeDays:        params.MinAgeDays,
+		ColdStorage:       params.ColdStorage,
+		Recurrence:        params.Recurrence,
+		Enabled:           params.Enabled,
+		NextRunAt:         &runAt,
+		CreatedBy:         params.UserID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := s.archivalRepo.CreateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(schedule.TenantID, params.UserID, schedule.ID, "archival_schedule", models.AuditCreate,
+		fmt.Sprintf("Archival schedule %q created", schedule.Name))
+	return schedule, nil
+}
+
+// GetSchedule returns one of the tenant's schedules
+func (s *ArchivalService) GetSchedule(ctx context.Context, tenantID, scheduleID uuid.UUID) (*models.ArchivalSchedule, error) {
+	schedule, err := s.archivalRepo.GetSchedule(ctx, scheduleID)
+	if err != nil || schedule.TenantID != tenantID {
+		return nil, ErrArchivalScheduleNotFound
+	}
+	return schedule, nil
+}
+
+// ListSchedules returns the tenant's schedules, oldest first
+func (s *ArchivalService) ListSchedules(ctx context.Context, tenantID uuid.UUID) ([]models.ArchivalSchedule, error) {
+	return s.archivalRepo.ListSchedules(ctx, tenantID)
+}
+
+// UpdateSchedule replaces a schedule's settings. Its next run moves to RunAt,
+// so a one-time schedule that already ran can be run again.
+func (s *ArchivalService) UpdateSchedule(ctx context.Context, scheduleID uuid.UUID, params ArchivalScheduleParams) (*models.ArchivalSchedule, error) {
+	schedule, err := s.GetSchedule(ctx, params.TenantID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(ctx, &params); err != nil {
+		return nil, err
+	}
+
+	runAt := params.RunAt
+	schedule.Name = params.Name
+	schedule.FolderID = params.FolderID
+	schedule.IncludeSubfolders = params.IncludeSubfolders
+	schedule.MinAgeDays = params.MinAgeDays
+	schedule.ColdStorage = params.ColdStorage
+	schedule.Recurrence = params.Recurrence
+	schedule.Enabled = params.Enabled
+	schedule.NextRunAt = &runAt
+	schedule.UpdatedAt = time.Now()
+	if err := s.archivalRepo.UpdateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(schedule.TenantID, params.UserID, schedule.ID, "archival_schedule", models.AuditUpdate,
+		fmt.Sprintf("Archival schedule %q updated", schedule.Name))
+	return schedule, nil
+}
+
+// DeleteSchedule deletes a schedule. Its runs, and their undo windows, stay.
+func (s *ArchivalService) DeleteSchedule(ctx context.Context, tenantID, userID, scheduleID uuid.UUID) error {
+	schedule, err := s.GetSchedule(ctx, tenantID, scheduleID)
+	if err != nil {
+		return err
+	}
+	if err := s.archivalRepo.DeleteSchedule(ctx, schedule.ID); err != nil {
+		return err
+	}
+
+	s.createAuditLog(tenantID, userID, schedule.ID, "archival_schedule", models.AuditDelete,
+		fmt.Sprintf("Archival schedule %q deleted", schedule.Name))
+	return nil
+}
+
+// Preview evaluates a proposed schedule without saving it
+func (s *ArchivalService) Preview(ctx context.Context, params ArchivalScheduleParams) (*ArchivalPreview, error) {
+	if err := s.validate(ctx, &params); err != nil {
+		return nil, err
+	}
+	return s.preview(ctx, &models.ArchivalSchedule{
+		TenantID:          params.TenantID,
+		FolderID:          params.FolderID,
+		IncludeSubfolders: params.IncludeSubfolders,
+		MinAgeDays:        params.MinAgeDays,
+		NextRunAt:         &params.RunAt,
+	})
+}
+
+// PreviewSchedule evaluates a saved schedule as of its next run
+func (s *ArchivalService) PreviewSchedule(ctx context.Context, tenantID, scheduleID uuid.UUID) (*ArchivalPreview, error) {
+	schedule, err := s.GetSchedule(ctx, tenantID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.preview(ctx, schedule)
+}
+
+// ListRuns returns the tenant's runs, newest first, optionally of one schedule
+func (s *ArchivalService) ListRuns(ctx context.Context, tenantID uuid.UUID, scheduleID *uuid.UUID, params repositories.ListParams) ([]models.ArchivalRun, int64, error) {
+	return s.archivalRepo.ListRuns(ctx, tenantID, scheduleID, params)
+}
+
+// GetRun returns one of the tenant's runs
+func (s *ArchivalService) GetRun(ctx context.Context, tenantID, runID uuid.UUID) (*models.ArchivalRun, error) {
+	run, err := s.archivalRepo.GetRun(ctx, runID)
+	if err != nil || run.TenantID != tenantID {
+		return nil, ErrArchivalRunNotFound
+	}
+	return run, nil
+}
+
+// UndoRun gives a run's documents their previous status back within its
+// undo window. Documents trashed or changed since stay as they are.
+func (s *ArchivalService) UndoRun(ctx context.Context, tenantID, userID, runID uuid.UUID) (*models.ArchivalRun, error) {
+	run, err := s.GetRun(ctx, tenantID, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != models.ArchivalRunCompleted && run.Status != models.ArchivalRunFailed {
+		return nil, ErrArchivalRunNotUndoable
+	}
+	if time.Now().After(run.UndoUntil) || run.ColdStoredAt != nil {
+		return nil, ErrArchivalUndoExpired
+	}
+
+	restored, _, ok, err := s.archivalRepo.UndoRun(ctx, run.ID, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrArchivalRunNotUndoable
+	}
+
+	s.createAuditLog(tenantID, userID, run.ID, "archival_run", models.AuditUpdate,
+		fmt.Sprintf("Archival run undone; %d documents restored", restored))
+	return s.GetRun(ctx, tenantID, run.ID)
+}
+
+// RunDue runs the schedules that are due and returns how many documents
+// were archived. A failing schedule doesn't hold up the others; its error is
+// kept on its run.
+func (s *ArchivalService) RunDue(ctx context.Context) (int64, error) {
+	due, err := s.archivalRepo.ListDueSchedules(ctx, time.Now(), s.config.SchedulesPerRun)
+	if err != nil {
+		return 0, err
+	}
+
+	var archived int64
+	for i := range due {
+		if ctx.Err() != nil {
+			return archived, ctx.Err()
+		}
+		run, err := s.runSchedule(ctx, &due[i])
+		if err != nil {
+			return archived, err
+		}
+		archived += run.DocumentsArchived
+	}
+	return archived, nil
+}
+
+// MoveToColdStorage moves the files of runs whose undo window has closed to
+// the storage's cold tier and returns how many were moved. A file that can't
+// be moved stays where it is; the run is retried on the next pass.
+func (s *ArchivalService) MoveToColdStorage(ctx context.Context) (int, error) {
+	tierer, ok := s.storage.(StorageTierer)
+	if !ok {
+		return 0, nil
+	}
+
+	runs, err := s.archivalRepo.ListColdStorageDue(ctx, time.Now(), s.config.SchedulesPerRun)
+	if err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for _, run := range runs {
+		paths, err := s.archivalRepo.ListArchivedPaths(ctx, run.ID)
+		if err != nil {
+			return moved, err
+		}
+		failed := false
+		for _, path := range paths {
+			if err := tierer.MoveToCold(ctx, path); err != nil {
+				// Log but continue
+				failed = true
+				continue
+			}
+			moved++
+		}
+		if failed {
+			continue
+		}
+		if err := s.archivalRepo.MarkColdStored(ctx, run.ID, time.Now()); err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+// ArchivalTask is the scheduled task that runs due archival schedules and
+// moves the files of runs past their undo window to cold storage
+func (s *ArchivalService) ArchivalTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "folder_archival",
+		Interval: s.config.Interval,
+		Run: func(ctx context.Context) error {
+			if _, err := s.RunDue(ctx); err != nil {
+				return err
+			}
+			_, err := s.MoveToColdStorage(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+// validate checks a schedule's settings and that its folder is the tenant's
+func (s *ArchivalService) validate(ctx context.Context, params *ArchivalScheduleParams) error {
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" || len(params.Name) > 255 {
+		return fmt.Errorf("%w: name is required and at most 255 characters", ErrInvalidArchivalSchedule)
+	}
+	if params.MinAgeDays < 0 || params.MinAgeDays > maxArchivalAgeDays {
+		return fmt.Errorf("%w: min_age_days must be between 0 and %d", ErrInvalidArchivalSchedule, maxArchivalAgeDays)
+	}
+	if params.Recurrence == "" {
+		params.Recurrence = models.ArchivalOnce
+	}
+	if params.Recurrence != models.ArchivalOnce && params.Recurrence != models.ArchivalYearly {
+		return fmt.Errorf("%w: recurrence must be once or yearly", ErrInvalidArchivalSchedule)
+	}
+	if params.RunAt.IsZero() {
+		return fmt.Errorf("%w: run_at is required", ErrInvalidArchivalSchedule)
+	}
+	if params.ColdStorage {
+		if _, ok := s.storage.(StorageTierer); !ok {
+			return ErrColdStorageUnavailable
+		}
+	}
+	if _, err := s.folderRepo.GetForTenant(ctx, params.TenantID, params.FolderID); err != nil {
+		return ErrFolderNotFound
+	}
+	return nil
+}
+
+// scope resolves the folders a schedule covers
+func (s *ArchivalService) scope(ctx context.Context, schedule *models.ArchivalSchedule) (*archivalScope, error) {
+	folders, err := s.folderRepo.ListByTenant(ctx, schedule.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+
+	scope := &archivalScope{folders: make(map[uuid.UUID]models.Folder, len(folders))}
+	for _, folder := range folders {
+		scope.folders[folder.ID] = folder
+	}
+	if _, ok := scope.folders[schedule.FolderID]; !ok {
+		return nil, ErrFolderNotFound
+	}
+
+	scope.folderIDs = []uuid.UUID{schedule.FolderID}
+	if schedule.IncludeSubfolders {
+		scope.folderIDs = descendantFolderIDs(schedule.FolderID, folders)
+	}
+	return scope, nil
+}
+
+func (s *ArchivalService) preview(ctx context.Context, schedule *models.ArchivalSchedule) (*ArchivalPreview, error) {
+	scope, err := s.scope(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	// As of the next run, or now when it's due or the schedule has finished
+	evaluatedAt := time.Now()
+	if schedule.NextRunAt != nil && schedule.NextRunAt.After(evaluatedAt) {
+		evaluatedAt = *schedule.NextRunAt
+	}
+	datedBefore := archivalCutoff(schedule, evaluatedAt)
+
+	stats, err := s.archivalRepo.SummarizeCandidates(ctx, schedule.TenantID, scope.folderIDs, datedBefore)
+	if err != nil {
+		return nil, err
+	}
+	sample, err := s.archivalRepo.ListCandidates(ctx, schedule.TenantID, scope.folderIDs, datedBefore, archivalSampleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := buildArchivalPreview(stats, scope)
+	preview.EvaluatedAt = evaluatedAt
+	preview.DatedBefore = datedBefore
+	preview.Sample = sample
+	if preview.Sample == nil {
+		preview.Sample = []models.Document{}
+	}
+	return preview, nil
+}
+
+// runSchedule archives a due schedule's documents in batches, records the
+// run and moves the schedule on to its next run
+func (s *ArchivalService) runSchedule(ctx context.Context, schedule *models.ArchivalSchedule) (*models.ArchivalRun, error) {
+	now := time.Now()
+	run := &models.ArchivalRun{
+		ID:          uuid.New(),
+		TenantID:    schedule.TenantID,
+		ScheduleID:  schedule.ID,
+		FolderID:    schedule.FolderID,
+		Status:      models.ArchivalRunRunning,
+		ColdStorage: schedule.ColdStorage,
+		UndoUntil:   now.Add(s.config.UndoWindow),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.archivalRepo.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	scope, archivedByFolder, err := s.archive(ctx, schedule, run, now)
+	run.Status = models.ArchivalRunCompleted
+	if err != nil {
+		run.Status = models.ArchivalRunFailed
+		run.LastError = err.Error()
+	}
+	// The undo window starts once the documents are archived
+	run.UndoUntil = time.Now().Add(s.config.UndoWindow)
+	if err := s.archivalRepo.UpdateRun(context.WithoutCancel(ctx), run); err != nil {
+		return nil, err
+	}
+
+	schedule.LastRunAt = &now
+	schedule.NextRunAt = nextArchivalRun(schedule, now)
+	if err := s.archivalRepo.UpdateSchedule(context.WithoutCancel(ctx), schedule); err != nil {
+		return nil, err
+	}
+
+	if run.DocumentsArchived > 0 {
+		s.createAuditLog(run.TenantID, schedule.CreatedBy, run.ID, "archival_run", models.AuditUpdate,
+			fmt.Sprintf("Archival schedule %q archived %d documents", schedule.Name, run.DocumentsArchived))
+		s.notifyOwners(schedule, run, scope, archivedByFolder)
+	}
+	return run, nil
+}
+
+// archive archives the schedule's documents onto the run and returns how many
+// were archived per folder. Archived documents keep counting against the
+// tenant's quota, since their files stay in storage.
+func (s *ArchivalService) archive(ctx context.Context, schedule *models.ArchivalSchedule, run *models.ArchivalRun, now time.Time) (*archivalScope, map[uuid.UUID]int64, error) {
+	scope, err := s.scope(ctx, schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	datedBefore := archivalCutoff(schedule, now)
+
+	archivedByFolder := make(map[uuid.UUID]int64)
+	for {
+		candidates, err := s.archivalRepo.ListCandidates(ctx, run.TenantID, scope.folderIDs, datedBefore, s.config.BatchSize)
+		if err != nil {
+			return scope, archivedByFolder, err
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		documentIDs := make([]uuid.UUID, len(candidates))
+		for i, document := range candidates {
+			documentIDs[i] = document.ID
+		}
+
+		archived, err := s.archivalRepo.ArchiveDocuments(ctx, run, documentIDs)
+		if err != nil {
+			return scope, archivedByFolder, err
+		}
+		var bytes int64
+		for _, document := range archived {
+			bytes += document.FileSize
+			if document.FolderID != nil {
+				archivedByFolder[*document.FolderID]++
+			}
+		}
+		run.DocumentsArchived += int64(len(archived))
+		run.BytesArchived += bytes
+
+		// Stop when the batch was the last, or changed under us entirely
+		if len(candidates) < s.config.BatchSize || len(archived) == 0 {
+			break
+		}
+	}
+
+	stats, err := s.archivalRepo.SummarizeCandidates(ctx, run.TenantID, scope.folderIDs, datedBefore)
+	if err != nil {
+		return scope, archivedByFolder, err
+	}
+	for _, stat := range stats {
+		if stat.LegalHold {
+			run.DocumentsHeld += stat.Count
+		}
+	}
+	return scope, archivedByFolder, nil
+}
+
+// notifyOwners tells each owner of a folder with archived documents, once,
+// in the background. The schedule's folder stands for the documents of
+// subfolders they own.
+func (s *ArchivalService) notifyOwners(schedule *models.ArchivalSchedule, run *models.ArchivalRun, scope *archivalScope, archivedByFolder map[uuid.UUID]int64) {
+	if s.notifier == nil || scope == nil {
+		return
+	}
+
+	byOwner := make(map[uuid.UUID]int64)
+	var owners []uuid.UUID
+	for folderID, count := range archivedByFolder {
+		folder, ok := scope.folders[folderID]
+		if !ok {
+			continue
+		}
+		owner := folder.Owner()
+		if _, ok := byOwner[owner]; !ok {
+			owners = append(owners, owner)
+		}
+		byOwner[owner] += count
+	}
+
+	folderPath := scope.folders[schedule.FolderID].Path
+	go func() {
+		ctx := context.Background()
+		for _, owner := range owners {
+			if err := s.notifier.SendFolderArchived(ctx, owner, run, schedule.Name, folderPath, byOwner[owner]); err != nil {
+				// Log but don't fail
+			}
+		}
+	}()
+}
+
+func (s *ArchivalService) createAuditLog(tenantID, userID, resourceID uuid.UUID, resourceType string, action models.AuditAction, message string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: resourceType,
+		Details:      models.JSONB{"message": message},
+	}
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+// Helper functions
+
+// archivalCutoff is the date documents must predate for a run at the given
+// time to archive them; nil archives them all
+func archivalCutoff(schedule *models.ArchivalSchedule, at time.Time) *time.Time {
+	if schedule.MinAgeDays <= 0 {
+		return nil
+	}
+	before := at.AddDate(0, 0, -schedule.MinAgeDays)
+	return &before
+}
+
+// nextArchivalRun is when a schedule that ran at now runs next: never for
+// one-time schedules, otherwise the first anniversary of its run date that's
+// still ahead
+func nextArchivalRun(schedule *models.ArchivalSchedule, now time.Time) *time.Time {
+	if schedule.Recurrence != models.ArchivalYearly || schedule.NextRunAt == nil {
+		return nil
+	}
+	next := *schedule.NextRunAt
+	for years := 1; !next.After(now); years++ {
+		next = schedule.NextRunAt.AddDate(years, 0, 0)
+	}
+	return &next
+}
+
+func buildArchivalPreview(stats []repositories.ArchivalCandidateStats, scope *archivalScope) *ArchivalPreview {
+	preview := &ArchivalPreview{
+		Folders: []ArchivalFolderImpact{},
+		Owners:  []uuid.UUID{},
+	}
+
+	byFolder := make(map[uuid.UUID]*ArchivalFolderImpact)
+	owners := make(map[uuid.UUID]bool)
+	for _, stat := range stats {
+		folder, ok := byFolder[stat.FolderID]
+		if !ok {
+			folder = &ArchivalFolderImpact{FolderID: stat.FolderID, Path: scope.folders[stat.FolderID].Path}
+			byFolder[stat.FolderID] = folder
+		}
+
+		impacts := []*RetentionImpact{&preview.Archive, &folder.Archive}
+		if stat.LegalHold {
+			impacts = []*RetentionImpact{&preview.Held, &folder.Held}
+		} else if owner, ok := scope.folders[stat.FolderID]; ok && !owners[owner.Owner()] {
+			owners[owner.Owner()] = true
+			preview.Owners = append(preview.Owners, owner.Owner())
+		}
+		for _, impact := range impacts {
+			impact.Documents += stat.Count
+			impact.Bytes += stat.Bytes
+		}
+	}
+
+	for _, folder := range byFolder {
+		preview.Folders = append(preview.Folders, *folder)
+	}
+	sort.Slice(preview.Folders, func(i, j int) bool {
+		return preview.Folders[i].Path < preview.Folders[j].Path
+	})
+	sort.Slice(preview.Owners, func(i, j int) bool {
+		return preview.Owners[i].String() < preview.Owners[j].String()
+	})
+	return preview
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextArchivalRun(t *testing.T) {
+	runAt := time.Date(2023, 1, 31, 6, 0, 0, 0, time.UTC)
+	now := time.Date(2023, 1, 31, 6, 5, 0, 0, time.UTC)
+
+	once := &models.ArchivalSchedule{Recurrence: models.ArchivalOnce, NextRunAt: &runAt}
+	assert.Nil(t, nextArchivalRun(once, now))
+
+	yearly := &models.ArchivalSchedule{Recurrence: models.ArchivalYearly, NextRunAt: &runAt}
+	next := nextArchivalRun(yearly, now)
+	require.NotNil(t, next)
+	assert.Equal(t, time.Date(2024, 1, 31, 6, 0, 0, 0, time.UTC), *next)
+
+	// A schedule that was missed for years skips to its next anniversary
+	next = nextArchivalRun(yearly, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.NotNil(t, next)
+	assert.Equal(t, time.Date(2026, 1, 31, 6, 0, 0, 0, time.UTC), *next)
+}
+
+func TestArchivalCutoff(t *testing.T) {
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, archivalCutoff(&models.ArchivalSchedule{}, at))
+
+	cutoff := archivalCutoff(&models.ArchivalSchedule{MinAgeDays: 365}, at)
+	require.NotNil(t, cutoff)
+	assert.Equal(t, time.Date(2023, 3, 2, 0, 0, 0, 0, time.UTC), *cutoff)
+}
+
+func TestBuildArchivalPreview(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	projects := models.Folder{ID: uuid.New(), Path: "/Projects/2022", CreatedBy: alice}
+	designs := models.Folder{ID: uuid.New(), Path: "/Projects/2022/Designs", CreatedBy: alice, OwnerID: &bob}
+	scope := &archivalScope{
+		folderIDs: []uuid.UUID{projects.ID, designs.ID},
+		folders:   map[uuid.UUID]models.Folder{projects.ID: projects, designs.ID: designs},
+	}
+
+	preview := buildArchivalPreview([]repositories.ArchivalCandidateStats{
+		{FolderID: designs.ID, Count: 4, Bytes: 400},
+		{FolderID: projects.ID, Count: 10, Bytes: 1000},
+		{FolderID: projects.ID, LegalHold: true, Count: 2, Bytes: 200},
+	}, scope)
+
+	assert.Equal(t, RetentionImpact{Documents: 14, Bytes: 1400}, preview.Archive)
+	assert.Equal(t, RetentionImpact{Documents: 2, Bytes: 200}, preview.Held)
+	require.Len(t, preview.Folders, 2)
+	assert.Equal(t, "/Projects/2022", preview.Folders[0].Path)
+	assert.Equal(t, RetentionImpact{Documents: 10, Bytes: 1000}, preview.Folders[0].Archive)
+	assert.Equal(t, RetentionImpact{Documents: 2, Bytes: 200}, preview.Folders[0].Held)
+	assert.Equal(t, "/Projects/2022/Designs", preview.Folders[1].Path)
+	// Owners are notified of their own folders; the transferred one is Bob's
+	assert.ElementsMatch(t, []uuid.UUID{alice, bob}, preview.Owners)
+}
+
+func TestArchivalService_Validate(t *testing.T) {
+	service := NewArchivalService(nil, nil, nil, nil, nil, nil, ArchivalConfig{})
+	valid := ArchivalScheduleParams{Name: "Projects 2022", FolderID: uuid.New(), RunAt: time.Now()}
+
+	for _, invalid := range []ArchivalScheduleParams{
+		{Name: " ", FolderID: valid.FolderID, RunAt: valid.RunAt},
+		{Name: valid.Name, FolderID: valid.FolderID},
+		{Name: valid.Name, FolderID: valid.FolderID, RunAt: valid.RunAt, MinAgeDays: -1},
+		{Name: valid.Name, FolderID: valid.FolderID, RunAt: valid.RunAt, Recurrence: "monthly"},
+	} {
+		assert.ErrorIs(t, service.validate(context.Background(), &invalid), ErrInvalidArchivalSchedule)
+	}
+
+	// Cold storage needs a storage backend with a cold tier
+	coldStorage := valid
+	coldStorage.ColdStorage = true
+	assert.ErrorIs(t, service.validate(context.Background(), &coldStorage), ErrColdStorageUnavailable)
+}
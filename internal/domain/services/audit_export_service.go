@@ -0,0 +1,663 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAuditStreamNotFound      = errors.New("audit stream not found")
+	ErrInvalidAuditStreamType   = errors.New("audit stream type must be webhook or syslog")
+	ErrInvalidAuditStreamTarget = errors.New("audit stream endpoint must be an https URL for webhooks, or tcp://, udp:// or tls:// host:port for syslog")
+	ErrInvalidAuditExportRange  = errors.New("audit export needs a from time before its to time, within the maximum range")
+	ErrInvalidAuditExportFormat = errors.New("audit export format must be csv or jsonl")
+)
+
+// Audit export formats
+const (
+	AuditExportCSV   = "csv"
+	AuditExportJSONL = "jsonl"
+)
+
+// Audit stream webhook event. The request body is {"stream_id", "entries"},
+// signed like other webhooks.
+const AuditStreamWebhookEvent = "audit.entries"
+
+// syslogAuditPriority is facility 13 (log audit) at severity 6 (informational)
+const syslogAuditPriority = 13*8 + 6
+
+// auditExportCSVHeader is the first row of a CSV export
+var auditExportCSVHeader = []string{
+	"sequence", "id", "created_at", "user_id", "action", "resource_type", "resource_id",
+	"ip_address", "user_agent", "details", "request_id", "prev_hash", "hash",
+}
+
+// AuditExportConfig holds configuration for audit exports and streams
+type AuditExportConfig struct {
+	Interval             time.Duration // How often streams are delivered; defaults to 15 seconds
+	BatchSize            int           // Entries per delivery; defaults to 200
+	MaxBatchesPerStream  int           // Deliveries per stream per run; defaults to 10
+	RequestTimeout       time.Duration // Per-delivery timeout; defaults to 10 seconds
+	RetryBaseDelay       time.Duration // First retry delay; doubles on each failure
+	RetryMaxDelay        time.Duration // Cap on the retry delay
+	MaxExportRange       time.Duration // Longest export time range; defaults to 366 days
+	AllowInsecureURLs    bool          // Allow http:// webhook endpoints (development only)
+	AllowPrivateNetworks bool          // Allow loopback and private addresses (development only)
+}
+
+// AuditExportService exports a tenant's audit log as evidence and mirrors
+// new entries to external SIEMs. Exports and streams carry each entry's chain
+// hashes, so the receiving side can verify nothing was altered or left out.
+type AuditExportService struct {
+	auditRepo        repositories.AuditLogRepository
+	streamRepo       repositories.AuditStreamRepository
+	retentionService *AuditRetentionService
+
+	dialer *net.Dialer
+	client *http.Client
+	config AuditExportConfig
+}
+
+// NewAuditExportService creates a new audit export service
+func NewAuditExportService(
+	auditRepo repositories.AuditLogRepository,
+	streamRepo repositories.AuditStreamRepository,
+	retentionService *AuditRetentionService,
+	config AuditExportConfig,
+) *AuditExportService {
+	if config.Interval <= 0 {
+		config.Interval = 15 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 200
+	}
+	if config.MaxBatchesPerStream <= 0 {
+		config.MaxBatchesPerStream = 10
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = 10 * time.Second
+	}
+	if config.RetryBaseDelay <= 0 {
+		config.RetryBaseDelay = 30 * time.Second
+	}
+	if config.RetryMaxDelay <= 0 {
+		config.RetryMaxDelay = time.Hour
+	}
+	if config.MaxExportRange <= 0 {
+		config.MaxExportRange = 366 * 24 * time.Hour
+	}
+
+	dialer := &net.Dialer{Timeout: config.RequestTimeout}
+	if !config.AllowPrivateNetworks {
+		// Checked on the resolved address so DNS cannot point a stream inside the network
+		dialer.Control = rejectPrivateAddress
+	}
+
+	return &AuditExportService{
+		auditRepo:        auditRepo,
+		streamRepo:       streamRepo,
+		retentionService: retentionService,
+		dialer:           dialer,
+		client: &http.Client{
+			Timeout:   config.RequestTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// Redirects could bypass the destination check and signature scope
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		config: config,
+	}
+}
+
+// AuditExportParams selects the entries to export
+type AuditExportParams struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	ExportedBy uuid.UUID `json:"exported_by"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	Format     string    `json:"format"`
+}
+
+// CreateAuditStreamParams contains parameters for creating an audit stream
+type CreateAuditStreamParams struct {
+	TenantID  uuid.UUID              `json:"tenant_id"`
+	CreatedBy uuid.UUID              `json:"created_by"`
+	Name      string                 `json:"name"`
+	Type      models.AuditStreamType `json:"type"`
+	Endpoint  string                 `json:"endpoint"`
+	Backfill  bool                   `json:"backfill"` // Also send entries written before the stream existed
+}
+
+// UpdateAuditStreamParams contains parameters for updating an audit stream; nil fields are unchanged
+type UpdateAuditStreamParams struct {
+	Name     *string `json:"name"`
+	Endpoint *string `json:"endpoint"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// ValidateExport checks the export parameters before anything is written
+func (s *AuditExportService) ValidateExport(params AuditExportParams) error {
+	if params.Format != AuditExportCSV && params.Format != AuditExportJSONL {
+		return ErrInvalidAuditExportFormat
+	}
+	if params.From.IsZero() || !params.From.Before(params.To) || params.To.Sub(params.From) > s.config.MaxExportRange {
+		return ErrInvalidAuditExportRange
+	}
+	return nil
+}
+
+// Export writes the tenant's entries created in [From, To) to w in chain
+// order, including entries already moved into archives
+func (s *AuditExportService) Export(ctx context.Context, params AuditExportParams, w io.Writer) error {
+	if err := s.ValidateExport(params); err != nil {
+		return err
+	}
+
+	writer := newAuditEntryWriter(params.Format, w)
+	written := 0
+
+	if s.retentionService != nil {
+		archives, err := s.auditRepo.ListArchives(ctx, params.TenantID)
+		if err != nil {
+			return err
+		}
+		for i := range archives {
+			archive := &archives[i]
+			if archive.ToTime.Before(params.From) || !archive.FromTime.Before(params.To) {
+				continue
+			}
+			entries, err := s.retentionService.ReadArchiveEntries(ctx, archive)
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if entry.CreatedAt.Before(params.From) || !entry.CreatedAt.Before(params.To) {
+					continue
+				}
+				if err := writer.Write(entry); err != nil {
+					return err
+				}
+				written++
+			}
+		}
+	}
+
+	var cursor *repositories.AuditLogCursor
+	for {
+		entries, err := s.auditRepo.ListRange(ctx, params.TenantID, params.From, params.To, cursor, s.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := writer.Write(entry); err != nil {
+				return err
+			}
+		}
+		written += len(entries)
+		if len(entries) < s.config.BatchSize {
+			break
+		}
+		last := entries[len(entries)-1]
+		cursor = &repositories.AuditLogCursor{Sequence: last.Sequence, ID: last.ID}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	s.createAuditLog(params.TenantID, params.ExportedBy, params.TenantID, models.AuditDownload, "audit_log", models.JSONB{
+		"message": "Audit log exported",
+		"from":    params.From,
+		"to":      params.To,
+		"format":  params.Format,
+		"entries": written,
+	})
+	return nil
+}
+
+// CreateStream creates an audit stream and returns its webhook signing
+// secret, which is only shown once. Syslog streams have no secret.
+func (s *AuditExportService) CreateStream(ctx context.Context, params CreateAuditStreamParams) (*models.AuditStream, string, error) {
+	endpoint, err := s.validateEndpoint(params.Type, params.Endpoint)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := ""
+	if params.Type == models.AuditStreamWebhook {
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, "", fmt.Errorf("failed to generate audit stream secret: %w", err)
+		}
+	}
+
+	// New streams start at the chain head unless asked to backfill
+	var lastSequence int64
+	if !params.Backfill {
+		if head, err := s.auditRepo.GetChainHead(ctx, params.TenantID); err == nil {
+			lastSequence = head.Sequence
+		}
+	}
+
+	now := time.Now()
+	stream := &models.AuditStream{
+		ID:           uuid.New(),
+		TenantID:     params.TenantID,
+		Name:         strings.TrimSpace(params.Name),
+		Type:         params.Type,
+		Endpoint:     endpoint,
+		Secret:       secret,
+		IsActive:     true,
+		LastSequence: lastSequence,
+		CreatedBy:    params.CreatedBy,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.streamRepo.Create(ctx, stream); err != nil {
+		return nil, "", err
+	}
+
+	s.createAuditLog(params.TenantID, params.CreatedBy, stream.ID, models.AuditCreate, "audit_stream", models.JSONB{
+		"message":  fmt.Sprintf("Audit stream %q created", stream.Name),
+		"type":     stream.Type,
+		"endpoint": stream.Endpoint,
+	})
+	return stream, secret, nil
+}
+
+// ListStreams returns the tenant's audit streams
+func (s *AuditExportService) ListStreams(ctx context.Context, tenantID uuid.UUID) ([]models.AuditStream, error) {
+	return s.streamRepo.ListByTenant(ctx, tenantID)
+}
+
+// GetStream returns one of the tenant's audit streams
+func (s *AuditExportService) GetStream(ctx context.Context, streamID, tenantID uuid.UUID) (*models.AuditStream, error) {
+	stream, err := s.streamRepo.GetByID(ctx, streamID)
+	if err != nil || stream.TenantID != tenantID {
+		return nil, ErrAuditStreamNotFound
+	}
+	return stream, nil
+}
+
+// UpdateStream changes an audit stream's name, endpoint or active state.
+// Reactivating a stream retries it right away.
+func (s *AuditExportService) UpdateStream(ctx context.Context, streamID, tenantID, updatedBy uuid.UUID, params UpdateAuditStreamParams) (*models.AuditStream, error) {
+	stream, err := s.GetStream(ctx, streamID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Name != nil {
+		stream.Name = strings.TrimSpace(*params.Name)
+	}
+	if params.Endpoint != nil {
+		endpoint, err := s.validateEndpoint(stream.Type, *params.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		stream.Endpoint = endpoint
+	}
+	if params.IsActive != nil {
+		if *params.IsActive && !stream.IsActive {
+			stream.NextAttemptAt = nil
+		}
+		stream.IsActive = *params.IsActive
+	}
+	stream.UpdatedAt = time.Now()
+
+	if err := s.streamRepo.Update(ctx, stream); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(tenantID, updatedBy, stream.ID, models.AuditUpdate, "audit_stream", models.JSONB{
+		"message":   fmt.Sprintf("Audit stream %q updated", stream.Name),
+		"endpoint":  stream.Endpoint,
+		"is_active": stream.IsActive,
+	})
+	return stream, nil
+}
+
+// DeleteStream stops and removes an audit stream
+func (s *AuditExportService) DeleteStream(ctx context.Context, streamID, tenantID, deletedBy uuid.UUID) error {
+	stream, err := s.GetStream(ctx, streamID, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.streamRepo.Delete(ctx, stream.ID); err != nil {
+		return err
+	}
+
+	s.createAuditLog(tenantID, deletedBy, stream.ID, models.AuditDelete, "audit_stream", models.JSONB{
+		"message": fmt.Sprintf("Audit stream %q deleted", stream.Name),
+	})
+	return nil
+}
+
+// DeliverDue sends every due stream the entries written since its cursor and
+// returns the number of entries delivered
+func (s *AuditExportService) DeliverDue(ctx context.Context) (int, error) {
+	streams, err := s.streamRepo.ListDue(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range streams {
+		count, err := s.deliverStream(ctx, &streams[i])
+		delivered += count
+		if err != nil {
+			// Recorded on the stream; other streams still get delivered
+			continue
+		}
+	}
+	return delivered, nil
+}
+
+// StreamTask is the scheduled task that mirrors audit entries to streams
+func (s *AuditExportService) StreamTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "audit_streams",
+		Interval: s.config.Interval,
+		Run: func(ctx context.Context) error {
+			_, err := s.DeliverDue(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+func (s *AuditExportService) deliverStream(ctx context.Context, stream *models.AuditStream) (int, error) {
+	delivered := 0
+	for i := 0; i < s.config.MaxBatchesPerStream; i++ {
+		entries, err := s.auditRepo.ListChain(ctx, stream.TenantID, stream.LastSequence, s.config.BatchSize)
+		if err != nil {
+			return delivered, err
+		}
+		if len(entries) == 0 {
+			return delivered, nil
+		}
+
+		if err := s.send(ctx, stream, entries); err != nil {
+			retryAt := time.Now().Add(s.retryDelay(stream.FailureCount + 1))
+			if recordErr := s.streamRepo.RecordFailure(ctx, stream.ID, err.Error(), retryAt); recordErr != nil {
+				return delivered, recordErr
+			}
+			return delivered, err
+		}
+
+		last := entries[len(entries)-1].Sequence
+		advanced, err := s.streamRepo.Advance(ctx, stream.ID, stream.LastSequence, last, time.Now())
+		if err != nil {
+			return delivered, err
+		}
+		if !advanced {
+			// The stream was reset or deleted while sending
+			return delivered, nil
+		}
+		stream.LastSequence = last
+		stream.FailureCount = 0
+		delivered += len(entries)
+
+		if len(entries) < s.config.BatchSize {
+			return delivered, nil
+		}
+	}
+	return delivered, nil
+}
+
+func (s *AuditExportService) send(ctx context.Context, stream *models.AuditStream, entries []models.AuditLog) error {
+	switch stream.Type {
+	case models.AuditStreamWebhook:
+		return s.sendWebhook(ctx, stream, entries)
+	case models.AuditStreamSyslog:
+		return s.sendSyslog(ctx, stream, entries)
+	default:
+		return ErrInvalidAuditStreamType
+	}
+}
+
+func (s *AuditExportService) sendWebhook(ctx context.Context, stream *models.AuditStream, entries []models.AuditLog) error {
+	lines := make([]auditArchiveEntry, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, newAuditArchiveEntry(entry))
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"stream_id": stream.ID,
+		"entries":   lines,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entries: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, stream.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(WebhookEventHeader, AuditStreamWebhookEvent)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(stream.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint responded with HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendSyslog writes one RFC 5424 message per entry. TCP and TLS use octet
+// counting framing (RFC 6587); UDP sends a datagram per message.
+func (s *AuditExportService) sendSyslog(ctx context.Context, stream *models.AuditStream, entries []models.AuditLog) error {
+	target, err := url.Parse(stream.Endpoint)
+	if err != nil {
+		return ErrInvalidAuditStreamTarget
+	}
+
+	var conn net.Conn
+	switch target.Scheme {
+	case "tls":
+		tlsDialer := &tls.Dialer{NetDialer: s.dialer, Config: &tls.Config{ServerName: target.Hostname()}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", target.Host)
+	default:
+		conn, err = s.dialer.DialContext(ctx, target.Scheme, target.Host)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.config.RequestTimeout))
+
+	for _, entry := range entries {
+		message, err := formatSyslogAuditEntry(entry)
+		if err != nil {
+			return err
+		}
+		if target.Scheme != "udp" {
+			message = append([]byte(strconv.Itoa(len(message))+" "), message...)
+		}
+		if _, err := conn.Write(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryDelay doubles from RetryBaseDelay on each failure, capped at RetryMaxDelay
+func (s *AuditExportService) retryDelay(failures int) time.Duration {
+	delay := s.config.RetryBaseDelay
+	for i := 1; i < failures && delay < s.config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.config.RetryMaxDelay {
+		delay = s.config.RetryMaxDelay
+	}
+	return delay
+}
+
+func (s *AuditExportService) validateEndpoint(streamType models.AuditStreamType, raw string) (string, error) {
+	endpoint := strings.TrimSpace(raw)
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" || parsed.User != nil || len(endpoint) > 500 {
+		return "", ErrInvalidAuditStreamTarget
+	}
+
+	switch streamType {
+	case models.AuditStreamWebhook:
+		if parsed.Scheme != "https" && !(s.config.AllowInsecureURLs && parsed.Scheme == "http") {
+			return "", ErrInvalidAuditStreamTarget
+		}
+	case models.AuditStreamSyslog:
+		if parsed.Scheme != "tcp" && parsed.Scheme != "udp" && parsed.Scheme != "tls" {
+			return "", ErrInvalidAuditStreamTarget
+		}
+		if parsed.Port() == "" || (parsed.Path != "" && parsed.Path != "/") {
+			return "", ErrInvalidAuditStreamTarget
+		}
+	default:
+		return "", ErrInvalidAuditStreamType
+	}
+	return endpoint, nil
+}
+
+func (s *AuditExportService) createAuditLog(tenantID, userID, resourceID uuid.UUID, action models.AuditAction, resourceType string, details models.JSONB) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: resourceType,
+		Details:      details,
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+// formatSyslogAuditEntry renders an entry as an RFC 5424 message with the
+// entry as JSON in the message body
+func formatSyslogAuditEntry(entry models.AuditLog) ([]byte, error) {
+	body, err := json.Marshal(newAuditArchiveEntry(entry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	header := fmt.Sprintf("<%d>1 %s archivus archivus - %s [archivus@32473 tenant=\"%s\" sequence=\"%d\"] ",
+		syslogAuditPriority,
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		syslogMessageID(entry.ResourceType, entry.Action),
+		entry.TenantID,
+		entry.Sequence,
+	)
+	return append([]byte(header), body...), nil
+}
+
+// syslogMessageID is "<resource_type>.<action>", limited to the 32 printable
+// characters RFC 5424 allows
+func syslogMessageID(resourceType string, action models.AuditAction) string {
+	id := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, resourceType+"."+string(action))
+	if len(id) > 32 {
+		id = id[:32]
+	}
+	return id
+}
+
+// auditEntryWriter writes entries in an export format
+type auditEntryWriter interface {
+	Write(entry models.AuditLog) error
+	Flush() error
+}
+
+func newAuditEntryWriter(format string, w io.Writer) auditEntryWriter {
+	if format == AuditExportCSV {
+		return &csvAuditEntryWriter{writer: csv.NewWriter(w)}
+	}
+	return &jsonlAuditEntryWriter{encoder: json.NewEncoder(w)}
+}
+
+type csvAuditEntryWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvAuditEntryWriter) Write(entry models.AuditLog) error {
+	if !w.headerWritten {
+		if err := w.writer.Write(auditExportCSVHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit details: %w", err)
+	}
+	return w.writer.Write([]string{
+		strconv.FormatInt(entry.Sequence, 10),
+		entry.ID.String(),
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		entry.UserID.String(),
+		string(entry.Action),
+		entry.ResourceType,
+		entry.ResourceID.String(),
+		entry.IPAddress,
+		entry.UserAgent,
+		string(details),
+		entry.RequestID,
+		entry.PrevHash,
+		entry.Hash,
+	})
+}
+
+func (w *csvAuditEntryWriter) Flush() error {
+	if !w.headerWritten {
+		if err := w.writer.Write(auditExportCSVHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonlAuditEntryWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlAuditEntryWriter) Write(entry models.AuditLog) error {
+	return w.encoder.Encode(newAuditArchiveEntry(entry))
+}
+
+func (w *jsonlAuditEntryWriter) Flush() error {
+	return nil
+}
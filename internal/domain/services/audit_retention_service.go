@@ -0,0 +1,566 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAuditArchiveNotFound  = errors.New("audit archive not found")
+	ErrInvalidAuditRetention = errors.New("audit retention is outside the allowed range")
+)
+
+// tenantAuditRetentionSetting is the tenant settings key overriding the tier's
+// audit retention, in days
+const tenantAuditRetentionSetting = "audit_retention_days"
+
+// AuditRetentionConfig holds configuration for audit log archival
+type AuditRetentionConfig struct {
+	Interval                  time.Duration // How often RunRetentionWorker runs; defaults to one day
+	BatchSize                 int           // Entries per archive file; defaults to 5000
+	MaxBatchesPerTenant       int           // Archives written per tenant per run; defaults to 20
+	StarterRetentionDays      int           // Defaults to 90
+	ProfessionalRetentionDays int           // Defaults to 365
+	EnterpriseRetentionDays   int           // Defaults to 2555 (seven years)
+	MinRetentionDays          int           // Shortest tenant override; defaults to 30
+	MaxRetentionDays          int           // Longest tenant override; defaults to 3650
+}
+
+// AuditRetentionService moves audit entries past their tenant's retention
+// period into compressed archives in object storage and prunes them from the
+// hot table. Archives keep each entry's chain hash, so the tenant's chain can
+// still be verified end to end.
+type AuditRetentionService struct {
+	auditRepo      repositories.AuditLogRepository
+	tenantRepo     repositories.TenantRepository
+	storageService StorageService
+	config         AuditRetentionConfig
+}
+
+// NewAuditRetentionService creates a new audit retention service
+func NewAuditRetentionService(
+	auditRepo repositories.AuditLogRepository,
+	tenantRepo repositories.TenantRepository,
+	storageService StorageService,
+	config AuditRetentionConfig,
+) *AuditRetentionService {
+	if config.Interval <= 0 {
+		config.Interval = 24 * time.Hour
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 5000
+	}
+	if config.MaxBatchesPerTenant <= 0 {
+		config.MaxBatchesPerTenant = 20
+	}
+	if config.StarterRetentionDays <= 0 {
+		config.StarterRetentionDays = 90
+	}
+	if config.ProfessionalRetentionDays <= 0 {
+		config.ProfessionalRetentionDays = 365
+	}
+	if config.EnterpriseRetentionDays <= 0 {
+		config.EnterpriseRetentionDays = 2555
+	}
+	if config.MinRetentionDays <= 0 {
+		config.MinRetentionDays = 30
+	}
+	if config.MaxRetentionDays < config.MinRetentionDays {
+		config.MaxRetentionDays = 3650
+	}
+
+	return &AuditRetentionService{
+		auditRepo:      auditRepo,
+		tenantRepo:     tenantRepo,
+		storageService: storageService,
+		config:         config,
+	}
+}
+
+// AuditRetentionPolicy describes how long a tenant's audit entries stay in
+// the hot table before they're archived
+type AuditRetentionPolicy struct {
+	TenantID        uuid.UUID `json:"tenant_id"`
+	RetentionDays   int       `json:"retention_days"`
+	TierDefaultDays int       `json:"tier_default_days"`
+	Override        bool      `json:"override"`
+	MinDays         int       `json:"min_days"`
+	MaxDays         int       `json:"max_days"`
+}
+
+// UpdateAuditRetentionParams contains a tenant retention change; a nil
+// RetentionDays clears the override and falls back to the tier default
+type UpdateAuditRetentionParams struct {
+	TenantID      uuid.UUID
+	UpdatedBy     uuid.UUID
+	IPAddress     string
+	RetentionDays *int
+}
+
+// AuditChainVerification is the result of checking a tenant's audit chain
+type AuditChainVerification struct {
+	TenantID        uuid.UUID `json:"tenant_id"`
+	Valid           bool      `json:"valid"`
+	ArchivesChecked int       `json:"archives_checked"`
+	EntriesChecked  int       `json:"entries_checked"`
+	HeadSequence    int64     `json:"head_sequence"`
+	BrokenAt        *int64    `json:"broken_at,omitempty"` // Sequence of the first bad entry
+	Error           string    `json:"error,omitempty"`
+	CheckedAt       time.Time `json:"checked_at"`
+}
+
+// auditArchiveEntry is one line of an archive file
+type auditArchiveEntry struct {
+	ID           uuid.UUID          `json:"id"`
+	TenantID     uuid.UUID          `json:"tenant_id"`
+	UserID       uuid.UUID          `json:"user_id"`
+	ResourceID   uuid.UUID          `json:"resource_id"`
+	Action       models.AuditAction `json:"action"`
+	ResourceType string             `json:"resource_type"`
+	IPAddress    string             `json:"ip_address"`
+	UserAgent    string             `json:"user_agent"`
+	Details      models.JSONB       `json:"details"`
+	RequestID    string             `json:"request_id,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+	Sequence     int64              `json:"sequence"`
+	PrevHash     string             `json:"prev_hash"`
+	Hash         string             `json:"hash"`
+}
+
+// newAuditArchiveEntry converts an entry to its archive line
+func newAuditArchiveEntry(entry models.AuditLog) auditArchiveEntry {
+	return auditArchiveEntry{
+		ID:           entry.ID,
+		TenantID:     entry.TenantID,
+		UserID:       entry.UserID,
+		ResourceID:   entry.ResourceID,
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		IPAddress:    entry.IPAddress,
+		UserAgent:    entry.UserAgent,
+		Details:      entry.Details,
+		RequestID:    entry.RequestID,
+		CreatedAt:    entry.CreatedAt.UTC(),
+		Sequence:     entry.Sequence,
+		PrevHash:     entry.PrevHash,
+		Hash:         entry.Hash,
+	}
+}
+
+// RetentionDays returns how long the tenant's audit entries stay in the hot table
+func (s *AuditRetentionService) RetentionDays(tenant *models.Tenant) int {
+	// Settings come back from JSON, so numbers are float64
+	if days, ok := tenant.Settings[tenantAuditRetentionSetting].(float64); ok && days >= 1 {
+		return int(days)
+	}
+
+	return s.tierRetentionDays(tenant.SubscriptionTier)
+}
+
+// GetRetentionPolicy returns the tenant's effective audit retention
+func (s *AuditRetentionService) GetRetentionPolicy(ctx context.Context, tenantID uuid.UUID) (*AuditRetentionPolicy, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	return s.retentionPolicy(tenant), nil
+}
+
+// UpdateRetentionPolicy sets or clears the tenant's audit retention override.
+// Shortening retention takes effect on the next archival run; entries are
+// archived, not deleted, so the chain stays verifiable.
+func (s *AuditRetentionService) UpdateRetentionPolicy(ctx context.Context, params UpdateAuditRetentionParams) (*AuditRetentionPolicy, error) {
+	if params.RetentionDays != nil {
+		days := *params.RetentionDays
+		if days < s.config.MinRetentionDays || days > s.config.MaxRetentionDays {
+			return nil, fmt.Errorf("%w: %d to %d days", ErrInvalidAuditRetention, s.config.MinRetentionDays, s.config.MaxRetentionDays)
+		}
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, params.TenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	previous := s.RetentionDays(tenant)
+
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+	if params.RetentionDays != nil {
+		// Stored as float64 so it reads back the same as it does from JSON
+		tenant.Settings[tenantAuditRetentionSetting] = float64(*params.RetentionDays)
+	} else {
+		delete(tenant.Settings, tenantAuditRetentionSetting)
+	}
+	tenant.UpdatedAt = time.Now()
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, fmt.Errorf("failed to update audit retention: %w", err)
+	}
+
+	policy := s.retentionPolicy(tenant)
+	s.createAuditLog(&models.AuditLog{
+		TenantID:     params.TenantID,
+		UserID:       params.UpdatedBy,
+		ResourceID:   params.TenantID,
+		Action:       models.AuditUpdate,
+		ResourceType: "audit_retention",
+		IPAddress:    params.IPAddress,
+		Details: models.JSONB{
+			"message":        "Audit retention updated",
+			"previous_days":  previous,
+			"retention_days": policy.RetentionDays,
+			"override":       policy.Override,
+		},
+	})
+
+	return policy, nil
+}
+
+// ArchiveTenant archives the tenant's expired audit entries and returns the
+// archives written
+func (s *AuditRetentionService) ArchiveTenant(ctx context.Context, tenantID uuid.UUID) ([]models.AuditArchive, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -s.RetentionDays(tenant))
+
+	var archives []models.AuditArchive
+	for i := 0; i < s.config.MaxBatchesPerTenant; i++ {
+		entries, err := s.auditRepo.ListArchivable(ctx, tenantID, cutoff, s.config.BatchSize)
+		if err != nil {
+			return archives, err
+		}
+		if len(entries) == 0 {
+			break
+		}
+
+		archive, err := s.archiveBatch(ctx, tenantID, entries)
+		if err != nil {
+			return archives, err
+		}
+		archives = append(archives, *archive)
+
+		if len(entries) < s.config.BatchSize {
+			break
+		}
+	}
+
+	return archives, nil
+}
+
+// ArchiveAll runs ArchiveTenant for every tenant and returns the number of
+// entries archived
+func (s *AuditRetentionService) ArchiveAll(ctx context.Context) (int, error) {
+	archived := 0
+	params := repositories.ListParams{Page: 1, PageSize: 100}
+
+	for {
+		tenants, total, err := s.tenantRepo.List(ctx, params)
+		if err != nil {
+			return archived, fmt.Errorf("failed to list tenants: %w", err)
+		}
+
+		for _, tenant := range tenants {
+			archives, err := s.ArchiveTenant(ctx, tenant.ID)
+			for _, archive := range archives {
+				archived += archive.EntryCount
+			}
+			if err != nil {
+				// Log but don't fail - other tenants still get archived
+				continue
+			}
+		}
+
+		if int64(params.Page*params.PageSize) >= total || len(tenants) == 0 {
+			return archived, nil
+		}
+		params.Page++
+	}
+}
+
+// RetentionTask is the scheduled task that archives expired audit entries
+func (s *AuditRetentionService) RetentionTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "audit_retention",
+		Interval: s.config.Interval,
+		Run: func(ctx context.Context) error {
+			_, err := s.ArchiveAll(ctx)
+			return err
+		},
+	}
+}
+
+// RetentionJob is the tenant job that archives the tenant's expired audit entries
+func (s *AuditRetentionService) RetentionJob() JobHandler {
+	return func(ctx context.Context, tenantID uuid.UUID, params models.JSONB) error {
+		_, err := s.ArchiveTenant(ctx, tenantID)
+		return err
+	}
+}
+
+// ListArchives returns the tenant's archives in chain order
+func (s *AuditRetentionService) ListArchives(ctx context.Context, tenantID uuid.UUID) ([]models.AuditArchive, error) {
+	return s.auditRepo.ListArchives(ctx, tenantID)
+}
+
+// OpenArchive returns the archive's gzip-compressed JSON lines. The caller
+// must close the reader.
+func (s *AuditRetentionService) OpenArchive(ctx context.Context, tenantID, archiveID uuid.UUID) (*models.AuditArchive, io.ReadCloser, error) {
+	archive, err := s.auditRepo.GetArchive(ctx, archiveID)
+	if err != nil || archive.TenantID != tenantID {
+		return nil, nil, ErrAuditArchiveNotFound
+	}
+
+	reader, err := s.storageService.Get(ctx, archive.StoragePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read audit archive: %w", err)
+	}
+	return archive, reader, nil
+}
+
+// ReadArchiveEntries decodes an archive's entries in chain order
+func (s *AuditRetentionService) ReadArchiveEntries(ctx context.Context, archive *models.AuditArchive) ([]models.AuditLog, error) {
+	reader, err := s.storageService.Get(ctx, archive.StoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit archive: %w", err)
+	}
+	defer reader.Close()
+
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress audit archive: %w", err)
+	}
+	defer gz.Close()
+
+	entries := make([]models.AuditLog, 0, archive.EntryCount)
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var line auditArchiveEntry
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("failed to decode audit archive: %w", err)
+		}
+		entries = append(entries, models.AuditLog{
+			ID:           line.ID,
+			TenantID:     line.TenantID,
+			UserID:       line.UserID,
+			ResourceID:   line.ResourceID,
+			Action:       line.Action,
+			ResourceType: line.ResourceType,
+			IPAddress:    line.IPAddress,
+			UserAgent:    line.UserAgent,
+			Details:      line.Details,
+			RequestID:    line.RequestID,
+			CreatedAt:    line.CreatedAt,
+			Sequence:     line.Sequence,
+			PrevHash:     line.PrevHash,
+			Hash:         line.Hash,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit archive: %w", err)
+	}
+
+	return entries, nil
+}
+
+// VerifyChain walks the tenant's audit chain through its archives and the hot
+// table, checking every link and hash up to the chain head
+func (s *AuditRetentionService) VerifyChain(ctx context.Context, tenantID uuid.UUID) (*AuditChainVerification, error) {
+	result := &AuditChainVerification{TenantID: tenantID, CheckedAt: time.Now()}
+
+	var lastSequence int64
+	lastHash := ""
+	broken := func(sequence int64, format string, args ...interface{}) (*AuditChainVerification, error) {
+		result.BrokenAt = &sequence
+		result.Error = fmt.Sprintf(format, args...)
+		return result, nil
+	}
+	check := func(entry *models.AuditLog) (bool, string) {
+		if entry.Sequence != lastSequence+1 {
+			return false, fmt.Sprintf("expected sequence %d, found %d", lastSequence+1, entry.Sequence)
+		}
+		if entry.PrevHash != lastHash {
+			return false, "previous hash does not match the preceding entry"
+		}
+		if entry.ComputeHash() != entry.Hash {
+			return false, "entry hash does not match its content"
+		}
+		lastSequence = entry.Sequence
+		lastHash = entry.Hash
+		result.EntriesChecked++
+		return true, ""
+	}
+
+	archives, err := s.auditRepo.ListArchives(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range archives {
+		archive := &archives[i]
+		result.ArchivesChecked++
+
+		// Archives holding only pre-chain entries have nothing to verify
+		if archive.LastSequence == 0 {
+			continue
+		}
+		if archive.FirstPrevHash != lastHash {
+			return broken(archive.FirstSequence, "archive %s does not continue the chain", archive.ID)
+		}
+
+		entries, err := s.ReadArchiveEntries(ctx, archive)
+		if err != nil {
+			return nil, err
+		}
+		for j := range entries {
+			if entries[j].Sequence == 0 {
+				continue
+			}
+			if ok, reason := check(&entries[j]); !ok {
+				return broken(entries[j].Sequence, "archive %s: %s", archive.ID, reason)
+			}
+		}
+		if lastHash != archive.LastHash || lastSequence != archive.LastSequence {
+			return broken(archive.LastSequence, "archive %s is incomplete", archive.ID)
+		}
+	}
+
+	for {
+		entries, err := s.auditRepo.ListChain(ctx, tenantID, lastSequence, s.config.BatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for j := range entries {
+			if ok, reason := check(&entries[j]); !ok {
+				return broken(entries[j].Sequence, "%s", reason)
+			}
+		}
+		if len(entries) < s.config.BatchSize {
+			break
+		}
+	}
+
+	// The head catches entries removed from the end of the chain
+	head, err := s.auditRepo.GetChainHead(ctx, tenantID)
+	if err == nil {
+		result.HeadSequence = head.Sequence
+		if head.Sequence != lastSequence || head.Hash != lastHash {
+			return broken(lastSequence+1, "chain ends at %d but the head is at %d", lastSequence, head.Sequence)
+		}
+	} else if lastSequence != 0 {
+		return broken(lastSequence, "chain head is missing")
+	}
+
+	result.Valid = true
+	return result, nil
+}
+
+// Helper methods
+
+func (s *AuditRetentionService) tierRetentionDays(tier models.SubscriptionTier) int {
+	switch tier {
+	case models.SubscriptionProfessional:
+		return s.config.ProfessionalRetentionDays
+	case models.SubscriptionEnterprise:
+		return s.config.EnterpriseRetentionDays
+	default:
+		return s.config.StarterRetentionDays
+	}
+}
+
+func (s *AuditRetentionService) retentionPolicy(tenant *models.Tenant) *AuditRetentionPolicy {
+	_, override := tenant.Settings[tenantAuditRetentionSetting]
+	return &AuditRetentionPolicy{
+		TenantID:        tenant.ID,
+		RetentionDays:   s.RetentionDays(tenant),
+		TierDefaultDays: s.tierRetentionDays(tenant.SubscriptionTier),
+		Override:        override,
+		MinDays:         s.config.MinRetentionDays,
+		MaxDays:         s.config.MaxRetentionDays,
+	}
+}
+
+func (s *AuditRetentionService) createAuditLog(log *models.AuditLog) {
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+// archiveBatch writes the entries to storage, then records the archive and
+// prunes the entries together. The stored file is removed if that fails.
+func (s *AuditRetentionService) archiveBatch(ctx context.Context, tenantID uuid.UUID, entries []models.AuditLog) (*models.AuditArchive, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+
+	archive := &models.AuditArchive{
+		ID:         uuid.New(),
+		TenantID:   tenantID,
+		FromTime:   entries[0].CreatedAt,
+		ToTime:     entries[0].CreatedAt,
+		EntryCount: len(entries),
+	}
+	ids := make([]uuid.UUID, 0, len(entries))
+
+	for _, entry := range entries {
+		if err := encoder.Encode(newAuditArchiveEntry(entry)); err != nil {
+			return nil, fmt.Errorf("failed to encode audit archive: %w", err)
+		}
+		ids = append(ids, entry.ID)
+
+		if entry.Sequence > 0 {
+			if archive.FirstSequence == 0 {
+				archive.FirstSequence = entry.Sequence
+				archive.FirstPrevHash = entry.PrevHash
+			}
+			archive.LastSequence = entry.Sequence
+			archive.LastHash = entry.Hash
+		}
+		if entry.CreatedAt.Before(archive.FromTime) {
+			archive.FromTime = entry.CreatedAt
+		}
+		if entry.CreatedAt.After(archive.ToTime) {
+			archive.ToTime = entry.CreatedAt
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress audit archive: %w", err)
+	}
+
+	archive.SizeBytes = int64(buf.Len())
+	storagePath, err := s.storageService.Store(ctx, StorageParams{
+		TenantID:    tenantID,
+		FileReader:  bytes.NewReader(buf.Bytes()),
+		Filename:    fmt.Sprintf("audit-%d-%d.jsonl.gz", archive.FirstSequence, archive.LastSequence),
+		ContentType: "application/gzip",
+		Size:        archive.SizeBytes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store audit archive: %w", err)
+	}
+	archive.StoragePath = storagePath
+
+	if err := s.auditRepo.ArchiveEntries(ctx, archive, ids); err != nil {
+		// The entries are still in the hot table, so drop the orphaned file
+		s.storageService.Delete(ctx, storagePath)
+		return nil, err
+	}
+
+	return archive, nil
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// Bulk label errors
+var (
+	ErrBulkLabelJobNotFound  = errors.New("bulk label job not found")
+	ErrInvalidBulkLabel      = errors.New("bulk label needs an action of add or remove and at least one tag or category")
+	ErrBulkLabelEmpty        = errors.New("no documents match the search")
+	ErrBulkLabelTooLarge     = errors.New("search matches too many documents")
+	ErrBulkLabelCountChanged = errors.New("the number of matching documents changed since the preview")
+)
+
+// bulkLabelSampleSize is how many matching documents a preview shows
+const bulkLabelSampleSize = 10
+
+// BulkLabelConfig holds configuration for bulk label jobs
+type BulkLabelConfig struct {
+	Interval     time.Duration // How often due jobs are run; defaults to 15 seconds
+	Lease        time.Duration // How long a worker owns a job between batches; defaults to 5 minutes
+	MaxAttempts  int           // Attempts before a job fails; defaults to 3
+	JobsPerRun   int           // Jobs run per interval; defaults to 5
+	BatchSize    int           // Documents changed per batch; defaults to 500
+	MaxDocuments int           // Most documents one job may match; defaults to 100000
+}
+
+// BulkLabelParams describes a bulk tag or category change: the labels to add
+// or remove, and the search selecting the documents
+type BulkLabelParams struct {
+	TenantID    uuid.UUID
+	RequestedBy uuid.UUID
+	Action      models.BulkLabelAction
+	TagIDs      []uuid.UUID
+	CategoryIDs []uuid.UUID
+	Filters     repositories.DocumentFilters
+
+	// ExpectedCount is the preview's match count. A job isn't started when
+	// the search matches a different number of documents by then.
+	ExpectedCount *int64
+}
+
+// BulkLabelPreview is how many documents a bulk label change would touch,
+// with the first few of them
+type BulkLabelPreview struct {
+	Matched int64             `json:"matched"`
+	Sample  []models.Document `json:"sample"`
+}
+
+// bulkLabels are the tags and categories a job adds or removes
+type bulkLabels struct {
+	TagIDs      []uuid.UUID `json:"tag_ids"`
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+}
+
+// BulkLabelService adds or removes tags and categories on every document
+// matching a search. A preview counts the matches first; the change itself
+// runs as a background job in batches, so reorganizing thousands of documents
+// doesn't hold up the request. Searches match only the documents the
+// requester may change.
+type BulkLabelService struct {
+	documentService *DocumentService
+	docRepo         repositories.DocumentRepository
+	tagRepo         repositories.TagRepository
+	categoryRepo    repositories.CategoryRepository
+	jobRepo         repositories.BulkLabelJobRepository
+	auditRepo       repositories.AuditLogRepository
+	config          BulkLabelConfig
+}
+
+// NewBulkLabelService creates a new bulk label service
+func NewBulkLabelService(
+	documentService *DocumentService,
+	docRepo repositories.DocumentRepository,
+	tagRepo repositories.TagRepository,
+	categoryRepo repositories.CategoryRepository,
+	jobRepo repositories.BulkLabelJobRepository,
+	auditRepo repositories.AuditLogRepository,
+	config BulkLabelConfig,
+) *BulkLabelService {
+	if config.Interval <= 0 {
+		config.Interval = 15 * time.Second
+	}
+	if config.Lease <= 0 {
+		config.Lease = 5 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.JobsPerRun <= 0 {
+		config.JobsPerRun = 5
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.MaxDocuments <= 0 {
+		config.MaxDocuments = 100000
+	}
+
+	return &BulkLabelService{
+		documentService: documentService,
+		docRepo:         docRepo,
+		tagRepo:         tagRepo,
+		categoryRepo:    categoryRepo,
+		jobRepo:         jobRepo,
+		auditRepo:       auditRepo,
+		config:          config,
+	}
+}
+
+// Preview counts the documents a bulk label change would touch
+func (s *BulkLabelService) Preview(ctx context.Context, params BulkLabelParams) (*BulkLabelPreview, error) {
+	if err := s.validate(ctx, &params); err != nil {
+		return nil, err
+	}
+	return s.preview(ctx, params)
+}
+
+// StartJob queues a bulk label change for the background worker
+func (s *BulkLabelService) StartJob(ctx context.Context, params BulkLabelParams) (*models.BulkLabelJob, error) {
+	if err := s.validate(ctx, &params); err != nil {
+		return nil, err
+	}
+	preview, err := s.preview(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if preview.Matched == 0 {
+		return nil, ErrBulkLabelEmpty
+	}
+	if preview.Matched > int64(s.config.MaxDocuments) {
+		return nil, fmt.Errorf("%w: %d match, at most %d can be changed at once", ErrBulkLabelTooLarge, preview.Matched, s.config.MaxDocuments)
+	}
+	if params.ExpectedCount != nil && *params.ExpectedCount != preview.Matched {
+		return nil, fmt.Errorf("%w: %d now match", ErrBulkLabelCountChanged, preview.Matched)
+	}
+
+	criteria, err := filterCriteria(params.Filters)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := toJSONB(bulkLabels{TagIDs: params.TagIDs, CategoryIDs: params.CategoryIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.BulkLabelJob{
+		ID:          uuid.New(),
+		TenantID:    params.TenantID,
+		Action:      params.Action,
+		Criteria:    criteria,
+		Labels:      labels,
+		Status:      models.BulkLabelPending,
+		Matched:     preview.Matched,
+		RequestedBy: params.RequestedBy,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(job, models.AuditCreate, fmt.Sprintf("Bulk label %s queued for %d documents", job.Action, job.Matched))
+	return job, nil
+}
+
+// GetJob returns one of the tenant's jobs
+func (s *BulkLabelService) GetJob(ctx context.Context, tenantID, jobID uuid.UUID) (*models.BulkLabelJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil || job.TenantID != tenantID {
+		return nil, ErrBulkLabelJobNotFound
+	}
+	return job, nil
+}
+
+// ListJobs returns the tenant's jobs, newest first
+func (s *BulkLabelService) ListJobs(ctx context.Context, tenantID uuid.UUID, params repositories.ListParams) ([]models.BulkLabelJob, int64, error) {
+	return s.jobRepo.ListByTenant(ctx, tenantID, params)
+}
+
+// ProcessDue runs the jobs that are waiting and returns how many finished
+func (s *BulkLabelService) ProcessDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.jobRepo.ListDue(ctx, now, s.config.JobsPerRun)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range due {
+		job := &due[i]
+		claimed, err := s.jobRepo.Claim(ctx, job.ID, now, now.Add(s.config.Lease))
+		if err != nil {
+			return processed, err
+		}
+		if !claimed {
+			// Another instance got it first
+			continue
+		}
+		job.Attempts++
+
+		if err := s.run(ctx, job); err != nil {
+			job.Error = err.Error()
+			job.Status = models.BulkLabelPending
+			if job.Attempts >= s.config.MaxAttempts {
+				job.Status = models.BulkLabelFailed
+			}
+		} else {
+			completedAt := time.Now()
+			job.Error = ""
+			job.Status = models.BulkLabelCompleted
+			job.CompletedAt = &completedAt
+		}
+
+		if err := s.jobRepo.Finish(ctx, job); err != nil {
+			return processed, err
+		}
+		if job.Status != models.BulkLabelPending {
+			processed++
+			s.createAuditLog(job, models.AuditUpdate, fmt.Sprintf("Bulk label %s %s on %d documents", job.Action, job.Status, job.Processed))
+		}
+	}
+
+	return processed, nil
+}
+
+// JobTask is the scheduled task that runs bulk label jobs
+func (s *BulkLabelService) JobTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "bulk_label_jobs",
+		Interval: s.config.Interval,
+		Run: func(ctx context.Context) error {
+			_, err := s.ProcessDue(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+// preview counts the matches of validated params
+func (s *BulkLabelService) preview(ctx context.Context, params BulkLabelParams) (*BulkLabelPreview, error) {
+	filters, err := s.writableFilters(ctx, params.TenantID, params.RequestedBy, params.Filters)
+	if err != nil {
+		return nil, err
+	}
+	filters.ListParams = repositories.ListParams{Page: 1, PageSize: bulkLabelSampleSize, Search: filters.Search}
+	sample, matched, err := s.docRepo.List(ctx, params.TenantID, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	return &BulkLabelPreview{Matched: matched, Sample: sample}, nil
+}
+
+// validate checks the action and that every label is the tenant's
+func (s *BulkLabelService) validate(ctx context.Context, params *BulkLabelParams) error {
+	if params.Action != models.BulkLabelAdd && params.Action != models.BulkLabelRemove {
+		return ErrInvalidBulkLabel
+	}
+	if len(params.TagIDs) == 0 && len(params.CategoryIDs) == 0 {
+		return ErrInvalidBulkLabel
+	}
+
+	for _, tagID := range params.TagIDs {
+		if _, err := s.tagRepo.GetForTenant(ctx, params.TenantID, tagID); err != nil {
+			return fmt.Errorf("%w: %s", ErrTagNotFound, tagID)
+		}
+	}
+	for _, categoryID := range params.CategoryIDs {
+		if _, err := s.categoryRepo.GetForTenant(ctx, params.TenantID, categoryID); err != nil {
+			return fmt.Errorf("%w: %s", ErrCategoryNotFound, categoryID)
+		}
+	}
+
+	// Only live documents are relabeled
+	params.Filters.Trashed = false
+	params.Filters.TrashViewerID = nil
+	return nil
+}
+
+// writableFilters narrows filters to the documents the user may change, as a
+// single document's labels need write access to it
+func (s *BulkLabelService) writableFilters(ctx context.Context, tenantID, userID uuid.UUID, filters repositories.DocumentFilters) (repositories.DocumentFilters, error) {
+	denied, editorID, err := s.documentService.documentEditability(ctx, tenantID, userID)
+	if err != nil {
+		return filters, err
+	}
+	filters.ExcludeFolderIDs = denied
+	filters.ViewerID = nil
+	filters.EditorID = editorID
+	return filters, nil
+}
+
+// run changes the matching documents in batches after the job's cursor,
+// saving progress after each batch. The search is run again with the
+// requester's current write access, so documents they can no longer change
+// are skipped.
+func (s *BulkLabelService) run(ctx context.Context, job *models.BulkLabelJob) error {
+	criteria, err := criteriaFilters(job.Criteria)
+	if err != nil {
+		return err
+	}
+	var labels bulkLabels
+	if err := fromJSONB(job.Labels, &labels); err != nil {
+		return err
+	}
+
+	filters, err := s.writableFilters(ctx, job.TenantID, job.RequestedBy, criteria)
+	if err != nil {
+		return err
+	}
+
+	apply := s.docRepo.AddLabels
+	if job.Action == models.BulkLabelRemove {
+		apply = s.docRepo.RemoveLabels
+	}
+
+	for {
+		documentIDs, err := s.docRepo.ListIDsAfter(ctx, job.TenantID, filters, job.Cursor, s.config.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(documentIDs) == 0 {
+			return nil
+		}
+
+		if err := apply(ctx, job.TenantID, documentIDs, labels.TagIDs, labels.CategoryIDs); err != nil {
+			return err
+		}
+
+		cursor := documentIDs[len(documentIDs)-1]
+		job.Cursor = &cursor
+		job.Processed += int64(len(documentIDs))
+		if err := s.jobRepo.SaveProgress(ctx, job.ID, cursor, job.Processed, time.Now().Add(s.config.Lease)); err != nil {
+			return err
+		}
+
+		if len(documentIDs) < s.config.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *BulkLabelService) createAuditLog(job *models.BulkLabelJob, action models.AuditAction, message string) {
+	log := &models.AuditLog{
+		TenantID:     job.TenantID,
+		UserID:       job.RequestedBy,
+		ResourceID:   job.ID,
+		Action:       action,
+		ResourceType: "bulk_label_job",
+		Details: models.JSONB{
+			"message":  message,
+			"criteria": job.Criteria,
+			"labels":   job.Labels,
+		},
+	}
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+// Helper functions
+
+// criteriaFilters reads back the search filters recorded by filterCriteria
+func criteriaFilters(criteria models.JSONB) (repositories.DocumentFilters, error) {
+	var filters repositories.DocumentFilters
+	if err := fromJSONB(criteria, &filters); err != nil {
+		return filters, fmt.Errorf("failed to decode search filters: %w", err)
+	}
+	return filters, nil
+}
+
+func toJSONB(value interface{}) (models.JSONB, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var stored models.JSONB
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+func fromJSONB(stored models.JSONB, target interface{}) error {
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkLabelCriteriaRoundTrip(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	folderID := uuid.New()
+	filters := repositories.DocumentFilters{
+		DocumentType: []models.DocumentType{models.DocTypeInvoice},
+		FolderID:     &folderID,
+		TagIDs:       []uuid.UUID{uuid.New()},
+		DateFrom:     &from,
+		DateTo:       &to,
+		ListParams:   repositories.ListParams{Search: "acme"},
+		// Access filters are applied again when the job runs
+		ViewerID: &folderID,
+	}
+
+	criteria, err := filterCriteria(filters)
+	require.NoError(t, err)
+	decoded, err := criteriaFilters(criteria)
+	require.NoError(t, err)
+
+	assert.Equal(t, filters.DocumentType, decoded.DocumentType)
+	assert.Equal(t, filters.FolderID, decoded.FolderID)
+	assert.Equal(t, filters.TagIDs, decoded.TagIDs)
+	assert.True(t, from.Equal(*decoded.DateFrom))
+	assert.True(t, to.Equal(*decoded.DateTo))
+	assert.Equal(t, "acme", decoded.Search)
+	assert.Nil(t, decoded.ViewerID)
+}
+
+func TestBulkLabelValidation(t *testing.T) {
+	service := NewBulkLabelService(nil, nil, nil, nil, nil, nil, BulkLabelConfig{})
+	ctx := context.Background()
+
+	_, err := service.Preview(ctx, BulkLabelParams{Action: "rename", TagIDs: []uuid.UUID{uuid.New()}})
+	assert.ErrorIs(t, err, ErrInvalidBulkLabel)
+
+	_, err = service.StartJob(ctx, BulkLabelParams{Action: models.BulkLabelAdd})
+	assert.ErrorIs(t, err, ErrInvalidBulkLabel)
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidWorkdays = errors.New("workdays must list at least one weekday between 0 (Sunday) and 6 (Saturday)")
+	ErrInvalidHoliday  = errors.New("holidays need a date in YYYY-MM-DD format")
+	ErrInvalidTimezone = errors.New("unknown time zone")
+)
+
+// tenantBusinessCalendarSetting is the tenant settings key holding the business calendar
+const tenantBusinessCalendarSetting = "business_calendar"
+
+// maxCalendarSearchDays bounds the walk to the next business day, so a
+// calendar whose holidays cover every workday can't loop forever
+const maxCalendarSearchDays = 3660
+
+// BusinessCalendar is a tenant's working week and holidays in its time
+// zone. Workflow due dates, reminders and escalations count business days on
+// it. Tenants that never configured one work Monday to Friday in UTC.
+type BusinessCalendar struct {
+	Timezone string         `json:"timezone"`
+	Workdays []time.Weekday `json:"workdays"`
+	Holidays []Holiday      `json:"holidays"`
+
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+
+	location *time.Location
+	workdays [7]bool
+	dates    map[string]bool
+	annual   map[string]bool
+}
+
+// Holiday is a day off. Recurring holidays fall on the same month and day every year.
+type Holiday struct {
+	Date      string `json:"date"` // YYYY-MM-DD
+	Name      string `json:"name"`
+	Recurring bool   `json:"recurring"`
+}
+
+// DefaultBusinessCalendar returns the Monday to Friday calendar in UTC
+func DefaultBusinessCalendar() *BusinessCalendar {
+	calendar := &BusinessCalendar{
+		Timezone: "UTC",
+		Workdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}
+	calendar.prepare()
+	return calendar
+}
+
+// Location returns the calendar's time zone
+func (c *BusinessCalendar) Location() *time.Location {
+	return c.location
+}
+
+// IsBusinessDay reports whether t falls on a workday that isn't a holiday,
+// in the calendar's time zone
+func (c *BusinessCalendar) IsBusinessDay(t time.Time) bool {
+	local := t.In(c.location)
+	if !c.workdays[local.Weekday()] {
+		return false
+	}
+	return !c.dates[local.Format("2006-01-02")] && !c.annual[local.Format("01-02")]
+}
+
+// AddBusinessDays moves t by days business days, backwards when days is
+// negative, keeping its wall clock time. A start on a day off counts from
+// the next business day in the direction of travel.
+func (c *BusinessCalendar) AddBusinessDays(t time.Time, days int) time.Time {
+	if days == 0 {
+		return t
+	}
+
+	step, remaining := 1, days
+	if days < 0 {
+		step, remaining = -1, -days
+	}
+
+	local := t.In(c.location)
+	for walked := 0; remaining > 0; walked++ {
+		if walked > maxCalendarSearchDays {
+			// Holidays cover every workday; fall back to calendar days
+			return t.AddDate(0, 0, days)
+		}
+		local = local.AddDate(0, 0, step)
+		if c.IsBusinessDay(local) {
+			remaining--
+		}
+	}
+	return local
+}
+
+// BusinessDaysBetween counts the business days that have fully passed from
+// from until to, the most days AddBusinessDays can move from without
+// passing to. It is zero when to isn't after from.
+func (c *BusinessCalendar) BusinessDaysBetween(from, to time.Time) int {
+	days := 0
+	local := from.In(c.location)
+	for walked := 0; walked < maxCalendarSearchDays; walked++ {
+		local = local.AddDate(0, 0, 1)
+		if local.After(to) {
+			break
+		}
+		if c.IsBusinessDay(local) {
+			days++
+		}
+	}
+	return days
+}
+
+// prepare resolves the time zone and indexes the workdays and holidays
+func (c *BusinessCalendar) prepare() {
+	c.location = time.UTC
+	if c.Timezone != "" {
+		if location, err := time.LoadLocation(c.Timezone); err == nil {
+			c.location = location
+		}
+	}
+
+	c.workdays = [7]bool{}
+	for _, day := range c.Workdays {
+		if day >= time.Sunday && day <= time.Saturday {
+			c.workdays[day] = true
+		}
+	}
+
+	c.dates = make(map[string]bool)
+	c.annual = make(map[string]bool)
+	for _, holiday := range c.Holidays {
+		if len(holiday.Date) != len("2006-01-02") {
+			continue
+		}
+		if holiday.Recurring {
+			c.annual[holiday.Date[5:]] = true
+		} else {
+			c.dates[holiday.Date] = true
+		}
+	}
+}
+
+// BusinessCalendarProvider returns the calendar a tenant's deadlines are counted on
+type BusinessCalendarProvider interface {
+	GetCalendar(ctx context.Context, tenantID uuid.UUID) (*BusinessCalendar, error)
+}
+
+// BusinessCalendarService stores tenants' business calendars
+type BusinessCalendarService struct {
+	tenantRepo   repositories.TenantRepository
+	auditRepo    repositories.AuditLogRepository
+	cacheService CacheService
+}
+
+// NewBusinessCalendarService creates a new business calendar service
+func NewBusinessCalendarService(
+	tenantRepo repositories.TenantRepository,
+	auditRepo repositories.AuditLogRepository,
+	cacheService CacheService,
+) *BusinessCalendarService {
+	return &BusinessCalendarService{
+		tenantRepo:   tenantRepo,
+		auditRepo:    auditRepo,
+		cacheService: cacheService,
+	}
+}
+
+// UpdateBusinessCalendarParams contains the new calendar
+type UpdateBusinessCalendarParams struct {
+	TenantID  uuid.UUID      `json:"tenant_id"`
+	UpdatedBy uuid.UUID      `json:"updated_by"`
+	Timezone  string         `json:"timezone"`
+	Workdays  []time.Weekday `json:"workdays"`
+	Holidays  []Holiday      `json:"holidays"`
+}
+
+// GetCalendar returns the tenant's business calendar, or the default one
+func (s *BusinessCalendarService) GetCalendar(ctx context.Context, tenantID uuid.UUID) (*BusinessCalendar, error) {
+	cacheKey := fmt.Sprintf(BusinessCalendarKeyPattern, tenantID.String())
+	if s.cacheService != nil {
+		if cached, err := s.cacheService.Get(ctx, cacheKey); err == nil {
+			var calendar BusinessCalendar
+			if json.Unmarshal([]byte(cached), &calendar) == nil {
+				calendar.prepare()
+				return &calendar, nil
+			}
+		}
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	calendar := DefaultBusinessCalendar()
+	if raw, ok := tenant.Settings[tenantBusinessCalendarSetting]; ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read business calendar: %w", err)
+		}
+		calendar = &BusinessCalendar{}
+		if err := json.Unmarshal(data, calendar); err != nil {
+			return nil, fmt.Errorf("failed to read business calendar: %w", err)
+		}
+		calendar.prepare()
+	}
+
+	if s.cacheService != nil {
+		if data, err := json.Marshal(calendar); err == nil {
+			s.cacheService.Set(ctx, cacheKey, string(data), CacheShortTerm)
+		}
+	}
+
+	return calendar, nil
+}
+
+// UpdateCalendar validates and stores the tenant's business calendar
+func (s *BusinessCalendarService) UpdateCalendar(ctx context.Context, params UpdateBusinessCalendarParams) (*BusinessCalendar, error) {
+	calendar, err := newBusinessCalendar(params.Timezone, params.Workdays, params.Holidays)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, params.TenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	now := time.Now()
+	calendar.UpdatedBy = &params.UpdatedBy
+	calendar.UpdatedAt = &now
+
+	data, err := json.Marshal(calendar)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode business calendar: %w", err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to encode business calendar: %w", err)
+	}
+
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+	tenant.Settings[tenantBusinessCalendarSetting] = stored
+	tenant.UpdatedAt = now
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, fmt.Errorf("failed to update business calendar: %w", err)
+	}
+
+	if s.cacheService != nil {
+		if err := s.cacheService.Delete(ctx, fmt.Sprintf(BusinessCalendarKeyPattern, params.TenantID.String())); err != nil {
+			// Log but don't fail - the cached calendar expires on its own
+		}
+	}
+
+	log := &models.AuditLog{
+		TenantID:     params.TenantID,
+		UserID:       params.UpdatedBy,
+		ResourceID:   params.TenantID,
+		Action:       models.AuditUpdate,
+		ResourceType: "business_calendar",
+		Details: models.JSONB{
+			"message":  "Business calendar updated",
+			"timezone": calendar.Timezone,
+			"workdays": calendar.Workdays,
+			"holidays": len(calendar.Holidays),
+		},
+	}
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+
+	return calendar, nil
+}
+
+// newBusinessCalendar validates and normalizes a calendar
+func newBusinessCalendar(timezone string, workdays []time.Weekday, holidays []Holiday) (*BusinessCalendar, error) {
+	timezone = strings.TrimSpace(timezone)
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTimezone, timezone)
+	}
+
+	seenDays := make(map[time.Weekday]bool)
+	var days []time.Weekday
+	for _, day := range workdays {
+		if day < time.Sunday || day > time.Saturday {
+			return nil, ErrInvalidWorkdays
+		}
+		if !seenDays[day] {
+			seenDays[day] = true
+			days = append(days, day)
+		}
+	}
+	if len(days) == 0 {
+		return nil, ErrInvalidWorkdays
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+
+	seenDates := make(map[string]bool)
+	normalized := make([]Holiday, 0, len(holidays))
+	for _, holiday := range holidays {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(holiday.Date))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidHoliday, holiday.Date)
+		}
+		holiday.Date = date.Format("2006-01-02")
+		holiday.Name = strings.TrimSpace(holiday.Name)
+		if seenDates[holiday.Date] {
+			continue
+		}
+		seenDates[holiday.Date] = true
+		normalized = append(normalized, holiday)
+	}
+	sort.Slice(normalized, func(i, j int) bool { return normalized[i].Date < normalized[j].Date })
+
+	calendar := &BusinessCalendar{Timezone: timezone, Workdays: days, Holidays: normalized}
+	calendar.prepare()
+	return calendar, nil
+}
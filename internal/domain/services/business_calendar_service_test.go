@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusinessCalendarAddBusinessDays(t *testing.T) {
+	calendar, err := newBusinessCalendar("UTC", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, []Holiday{
+		{Date: "2024-12-25", Name: "Christmas Day", Recurring: true},
+		{Date: "2024-12-26", Name: "Boxing Day"},
+	})
+	require.NoError(t, err)
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		days  int
+		want  time.Time
+	}{
+		{"zero days", at(2024, 3, 16), 0, at(2024, 3, 16)},
+		{"within the week", at(2024, 3, 11), 3, at(2024, 3, 14)},
+		{"over the weekend", at(2024, 3, 14), 3, at(2024, 3, 19)},
+		{"from a saturday", at(2024, 3, 16), 1, at(2024, 3, 18)},
+		{"backwards over the weekend", at(2024, 3, 20), -3, at(2024, 3, 15)},
+		{"over holidays", at(2024, 12, 24), 1, at(2024, 12, 27)},
+		{"recurring holiday next year", at(2025, 12, 24), 1, at(2025, 12, 26)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, calendar.AddBusinessDays(tt.start, tt.days))
+		})
+	}
+}
+
+func TestBusinessCalendarBusinessDaysBetween(t *testing.T) {
+	calendar := DefaultBusinessCalendar()
+	due := time.Date(2024, time.March, 14, 17, 0, 0, 0, time.UTC) // Thursday
+
+	assert.Equal(t, 0, calendar.BusinessDaysBetween(due, due.Add(-time.Hour)))
+	assert.Equal(t, 0, calendar.BusinessDaysBetween(due, due.Add(23*time.Hour)))
+	assert.Equal(t, 1, calendar.BusinessDaysBetween(due, due.AddDate(0, 0, 1)))
+	// The weekend doesn't count
+	assert.Equal(t, 1, calendar.BusinessDaysBetween(due, due.AddDate(0, 0, 3)))
+	assert.Equal(t, 2, calendar.BusinessDaysBetween(due, due.AddDate(0, 0, 4)))
+
+	// The inverse of AddBusinessDays
+	for days := 1; days <= 10; days++ {
+		assert.Equal(t, days, calendar.BusinessDaysBetween(due, calendar.AddBusinessDays(due, days)))
+	}
+}
+
+func TestBusinessCalendarTimezone(t *testing.T) {
+	calendar, err := newBusinessCalendar("Asia/Tokyo", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, nil)
+	require.NoError(t, err)
+
+	// Friday 20:00 UTC is already Saturday in Tokyo
+	friday := time.Date(2024, 3, 15, 20, 0, 0, 0, time.UTC)
+	assert.False(t, calendar.IsBusinessDay(friday))
+
+	due := calendar.AddBusinessDays(friday, 1)
+	assert.Equal(t, time.Monday, due.Weekday())
+	assert.Equal(t, 5, due.Hour())
+	assert.True(t, friday.Add(48*time.Hour).Equal(due))
+}
+
+func TestBusinessCalendarNeverFindsBusinessDay(t *testing.T) {
+	calendar, err := newBusinessCalendar("UTC", []time.Weekday{time.Monday}, nil)
+	require.NoError(t, err)
+	// Every day of the year is a holiday
+	for day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); day.Year() == 2024; day = day.AddDate(0, 0, 1) {
+		calendar.Holidays = append(calendar.Holidays, Holiday{Date: day.Format("2006-01-02"), Recurring: true})
+	}
+	calendar.prepare()
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, start.AddDate(0, 0, 2), calendar.AddBusinessDays(start, 2))
+}
+
+func TestNewBusinessCalendarValidation(t *testing.T) {
+	weekdays := []time.Weekday{time.Monday, time.Friday}
+
+	_, err := newBusinessCalendar("Mars/Olympus", weekdays, nil)
+	assert.ErrorIs(t, err, ErrInvalidTimezone)
+
+	_, err = newBusinessCalendar("UTC", nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidWorkdays)
+
+	_, err = newBusinessCalendar("UTC", []time.Weekday{7}, nil)
+	assert.ErrorIs(t, err, ErrInvalidWorkdays)
+
+	_, err = newBusinessCalendar("UTC", weekdays, []Holiday{{Date: "25/12/2024"}})
+	assert.ErrorIs(t, err, ErrInvalidHoliday)
+
+	calendar, err := newBusinessCalendar(" ", []time.Weekday{time.Friday, time.Monday, time.Friday}, []Holiday{
+		{Date: "2024-12-26"}, {Date: "2024-12-25"}, {Date: "2024-12-25"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", calendar.Timezone)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Friday}, calendar.Workdays)
+	require.Len(t, calendar.Holidays, 2)
+	assert.Equal(t, "2024-12-25", calendar.Holidays[0].Date)
+}
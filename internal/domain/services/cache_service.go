@@ -40,9 +40,20 @@ const (
 	// Session keys
 	SessionKeyPattern = "session:%s"
 
+	// Session tracking and revocation
+	UserSessionsKeyPattern        = "user_sessions:%s"
+	SessionRevokedKeyPattern      = "session_revoked:%s"
+	UserSessionsRevokedKeyPattern = "sessions_revoked_before:%s" // unix time; older tokens are rejected
+
+	// Brute-force protection, keyed by tenant:email:ip
+	LoginLockoutKeyPattern = "login_lockout:%s" // unix time the lockout ends
+
 	// User cache keys
 	UserCacheKeyPattern = "user:%s"
 
+	// Custom role permission cache keys
+	RolePermissionsKeyPattern = "role_permissions:%s"
+
 	// Document cache keys
 	DocumentCacheKeyPattern = "doc:%s"
 	DocumentListKeyPattern  = "doc_list:%s:%s" // tenant:filter_hash
@@ -50,6 +61,15 @@ const (
 	// Tenant cache keys
 	TenantCacheKeyPattern = "tenant:%s"
 
+	// Tenant settings cache keys
+	TenantSettingsKeyPattern = "tenant_settings:%s"
+
+	// Tenant network policy cache keys
+	NetworkPolicyKeyPattern = "network_policy:%s"
+
+	// Tenant business calendar cache keys
+	BusinessCalendarKeyPattern = "business_calendar:%s"
+
 	// AI processing cache
 	AIJobQueueKey      = "ai_jobs:queue"
 	AIResultKeyPattern = "ai_result:%s"
@@ -57,11 +77,24 @@ const (
 	// Rate limiting keys
 	RateLimitKeyPattern = "rate_limit:%s:%s" // tenant:user
 
+	// Public endpoint abuse protection
+	AbuseProtectionPolicyKeyPattern = "abuse_protection:%s"
+	PublicRateLimitKeyPattern       = "public_rate:%s:%s:%d" // tenant or "global":ip:window start
+	AttemptFailuresKeyPattern       = "attempt_failures:%s"  // tenant:scope:subject:ip
+	AttemptDelayKeyPattern          = "attempt_delay:%s"     // unix time the delay ends
+
+	// API metering
+	APIUsageLimitsKeyPattern  = "api_limits:%s"
+	APIRateLimitKeyPattern    = "api_rate:%s:%d"       // tenant:window start
+	APIUsageCounterKeyPattern = "api_usage:%s:%s:%s"   // tenant:month:counter
+	APIUsageTenantsKeyPattern = "api_usage_tenants:%s" // month
+
 	// Analytics cache
 	DashboardCacheKeyPattern = "dashboard:%s:%s" // tenant:period
 
 	// Search cache
-	SearchCacheKeyPattern = "search:%s:%s" // tenant:query_hash
+	SearchCacheKeyPattern   = "search:%s:%s"            // tenant:query_hash
+	SearchSuggestKeyPattern = "search_suggest:%s:%s:%s" // tenant:user:query_hash
 )
 
 // Common cache durations
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidChatQuestion = errors.New("question must be 1 to 2000 characters")
+	ErrInvalidChatRequest  = errors.New("chat takes at most 20 documents and 10 earlier messages, each from the user or the assistant")
+	ErrDocumentsNotIndexed = errors.New("no indexed passages found; the documents need embeddings generated first")
+	ErrInvalidPassageQuery = errors.New("passage search takes a query of 1 to 2000 characters and at most 20 documents")
+)
+
+// Job types chat calls are routed by, like AI jobs: questions are embedded
+// on the provider that embedded the passages
+const (
+	chatJobType      = "chat"
+	embeddingJobType = "embedding_generation"
+)
+
+const (
+	// documentPassageTokens is about how long the passages documents are
+	// split into for embedding are
+	documentPassageTokens = 400
+
+	maxChatQuestionLength = 2000
+	maxChatDocuments      = 20
+	maxChatHistory        = 10
+	defaultChatPassages   = 6
+	defaultPassageResults = 10
+	maxPassageResults     = 50
+	chatCandidateFactor   = 4 // Passages fetched per passage used, leaving room for ones the user can't read
+	chatExcerptRunes      = 300
+)
+
+const chatSystemPrompt = "You answer questions about an organization's documents using only the numbered passages given. " +
+	"Cite the passages each statement is based on by their number in square brackets, e.g. [2]. " +
+	"If the passages don't answer the question, say so; don't use outside knowledge."
+
+// chatCitationPattern matches citations such as [2] and [1, 3]
+var chatCitationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// ChatService answers questions about documents from the passages of their
+// text nearest to the question (retrieval-augmented generation). Answers
+// cite the passages they are based on; only documents the user can read are
+// searched.
+type ChatService struct {
+	chunkRepo       repositories.DocumentChunkRepository
+	tenantRepo      repositories.TenantRepository
+	documentService *DocumentService
+	aiUsage         *AIUsageService
+	keyResolver     AIKeyResolver
+	factory         LLMProviderFactory
+	config          ChatServiceConfig
+}
+
+// ChatServiceConfig holds configuration for document chat
+type ChatServiceConfig struct {
+	// PlatformProvider, Providers and JobProviders pick providers the way
+	// AIServiceConfig does; JobProviders may name a provider for "chat"
+	PlatformProvider string
+	Providers        map[string]LLMProvider // The platform's, by name
+	JobProviders     map[string]string
+
+	MaxPassages int // Passages an answer is grounded in; defaults to 6
+	MaxTokens   int // Per answer; defaults to 1024
+}
+
+// LLMProviderFactory builds a provider's API bound to a tenant's key
+type LLMProviderFactory interface {
+	Provider(provider, apiKey string) (LLMProvider, error)
+}
+
+// ChatMessage is an earlier turn of a conversation
+type ChatMessage struct {
+	Role    string `json:"role"` // user or assistant
+	Content string `json:"content"`
+}
+
+// ChatParams is a question about documents
+type ChatParams struct {
+	Question    string
+	DocumentIDs []uuid.UUID // Documents to search; all the user can read when empty
+	History     []ChatMessage
+}
+
+// ChatSource is a passage an answer is grounded in
+type ChatSource struct {
+	Number     int       `json:"number"` // As cited in the answer, e.g. [1]
+	DocumentID uuid.UUID `json:"document_id"`
+	Title      string    `json:"title"`
+	Passage    int       `json:"passage"`        // Position in the document's text
+	Page       *int      `json:"page,omitempty"` // When the text has page breaks
+	Excerpt    string    `json:"excerpt"`
+	Score      float64   `json:"score"` // Cosine similarity to the question
+
+	// Where the passage lies in the document's text, in characters
+	StartOffset int `json:"start_offset"`
+	EndOffset   int `json:"end_offset"`
+
+	content string
+}
+
+// PassageSearchParams is a search for the passages nearest to a query
+type PassageSearchParams struct {
+	Query       string
+	DocumentIDs []uuid.UUID // Documents to search; all the user can read when empty
+	Limit       int         // Defaults to 10, at most 50
+}
+
+// ChatAnswer is an answer and the passages it cites
+type ChatAnswer struct {
+	Answer    string       `json:"answer"`
+	Citations []ChatSource `json:"citations"`
+	Provider  string       `json:"provider"`
+	Model     string       `json:"model"`
+}
+
+// ChatStream receives an answer as it is written
+type ChatStream interface {
+	// Sources is called with the passages found, before the answer
+	Sources(sources []ChatSource)
+	Text(text string)
+}
+
+// chatProvider is a provider chat calls go to and who pays for them
+type chatProvider struct {
+	LLMProvider
+	source string
+	keyID  *uuid.UUID
+}
+
+// NewChatService creates a new chat service
+func NewChatService(
+	chunkRepo repositories.DocumentChunkRepository,
+	tenantRepo repositories.TenantRepository,
+	documentService *DocumentService,
+	aiUsage *AIUsageService,
+	keyResolver AIKeyResolver,
+	factory LLMProviderFactory,
+	config ChatServiceConfig,
+) *ChatService {
+	if config.PlatformProvider == "" {
+		config.PlatformProvider = AIProviderOpenAI
+	}
+	if config.MaxPassages <= 0 {
+		config.MaxPassages = defaultChatPassages
+	}
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = defaultLLMMaxTokens
+	}
+
+	return &ChatService{
+		chunkRepo:       chunkRepo,
+		tenantRepo:      tenantRepo,
+		documentService: documentService,
+		aiUsage:         aiUsage,
+		keyResolver:     keyResolver,
+		factory:         factory,
+		config:          config,
+	}
+}
+
+// Ask answers a question from the passages nearest to it in the documents
+// asked about, or in all documents the user can read. With a stream the
+// sources and the answer are passed on as they come.
+func (s *ChatService) Ask(ctx context.Context, tenantID, userID uuid.UUID, params ChatParams, stream ChatStream) (*ChatAnswer, error) {
+	if err := params.normalize(); err != nil {
+		return nil, err
+	}
+	if err := s.checkDocuments(ctx, tenantID, userID, params.DocumentIDs); err != nil {
+		return nil, err
+	}
+
+	embedder, err := s.provider(ctx, tenantID, embeddingJobType)
+	if err != nil {
+		return nil, err
+	}
+	answerer, err := s.provider(ctx, tenantID, chatJobType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLimits(ctx, tenantID, embedder, answerer); err != nil {
+		return nil, err
+	}
+
+	embedding, err := embedder.Embed(ctx, params.Question)
+	s.recordKeyUse(ctx, embedder, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed question: %w", err)
+	}
+	s.recordUsage(ctx, tenantID, embedder, "chat_embedding", params.Question, "", &LLMResponse{Model: embedding.Model, InputTokens: embedding.InputTokens})
+
+	sources, err := s.retrieve(ctx, tenantID, userID, embedding.Vector, params.DocumentIDs, s.config.MaxPassages)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, ErrDocumentsNotIndexed
+	}
+	onText := func(string) {}
+	if stream != nil {
+		stream.Sources(sources)
+		onText = stream.Text
+	}
+
+	prompt := chatPrompt(params, sources)
+	response, err := answerer.Stream(ctx, LLMRequest{System: chatSystemPrompt, Prompt: prompt, MaxTokens: s.config.MaxTokens}, onText)
+	s.recordKeyUse(ctx, answerer, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer question: %w", err)
+	}
+	s.recordUsage(ctx, tenantID, answerer, "chat", prompt, response.Text, response)
+
+	answer := &ChatAnswer{
+		Answer:    strings.TrimSpace(response.Text),
+		Citations: citedSources(response.Text, sources),
+		Provider:  answerer.Name(),
+		Model:     response.Model,
+	}
+	cited := make(map[uuid.UUID]bool)
+	for _, citation := range answer.Citations {
+		if !cited[citation.DocumentID] {
+			cited[citation.DocumentID] = true
+			s.documentService.createAuditLog(ctx, tenantID, userID, citation.DocumentID, models.AuditRead, "Document cited in a chat answer")
+		}
+	}
+	return answer, nil
+}
+
+// SearchPassages returns the passages nearest to a query in the given
+// documents, or in all documents the user can read, nearest first. Only
+// embedded passages are found.
+func (s *ChatService) SearchPassages(ctx context.Context, tenantID, userID uuid.UUID, params PassageSearchParams) ([]ChatSource, error) {
+	params.Query = strings.TrimSpace(params.Query)
+	if params.Query == "" || len([]rune(params.Query)) > maxChatQuestionLength || len(params.DocumentIDs) > maxChatDocuments {
+		return nil, ErrInvalidPassageQuery
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultPassageResults
+	}
+	if params.Limit > maxPassageResults {
+		params.Limit = maxPassageResults
+	}
+	if err := s.checkDocuments(ctx, tenantID, userID, params.DocumentIDs); err != nil {
+		return nil, err
+	}
+
+	embedder, err := s.provider(ctx, tenantID, embeddingJobType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLimits(ctx, tenantID, embedder); err != nil {
+		return nil, err
+	}
+
+	embedding, err := embedder.Embed(ctx, params.Query)
+	s.recordKeyUse(ctx, embedder, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	s.recordUsage(ctx, tenantID, embedder, "passage_search", params.Query, "", &LLMResponse{Model: embedding.Model, InputTokens: embedding.InputTokens})
+
+	return s.retrieve(ctx, tenantID, userID, embedding.Vector, params.DocumentIDs, params.Limit)
+}
+
+// checkDocuments checks the user can read the documents asked about
+func (s *ChatService) checkDocuments(ctx context.Context, tenantID, userID uuid.UUID, documentIDs []uuid.UUID) error {
+	for _, documentID := range documentIDs {
+		document, err := s.documentService.getTenantDocument(ctx, documentID, tenantID)
+		if err != nil || document.DeletedAt != nil {
+			return ErrDocumentNotFound
+		}
+		if err := s.documentService.CheckDocumentAccess(ctx, document, userID, models.DocPermRead); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retrieve returns up to limit passages nearest to the embedding that the
+// user can read, numbered for citing
+func (s *ChatService) retrieve(ctx context.Context, tenantID, userID uuid.UUID, embedding []float32, documentIDs []uuid.UUID, limit int) ([]ChatSource, error) {
+	matches, err := s.chunkRepo.Search(ctx, tenantID, embedding, documentIDs, limit*chatCandidateFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Passage search is not access-aware, so filter its results here
+	readable := make(map[uuid.UUID]bool, len(documentIDs))
+	for _, documentID := range documentIDs {
+		readable[documentID] = true
+	}
+	sources := make([]ChatSource, 0, limit)
+	for _, match := range matches {
+		allowed, checked := readable[match.DocumentID]
+		if !checked {
+			document, err := s.documentService.getTenantDocument(ctx, match.DocumentID, tenantID)
+			allowed = err == nil && document.DeletedAt == nil &&
+				s.documentService.CheckDocumentAccess(ctx, document, userID, models.DocPermRead) == nil
+			readable[match.DocumentID] = allowed
+		}
+		if !allowed {
+			continue
+		}
+
+		sources = append(sources, ChatSource{
+			Number:      len(sources) + 1,
+			DocumentID:  match.DocumentID,
+			Title:       match.Title,
+			Passage:     match.ChunkIndex,
+			Page:        match.Page,
+			Excerpt:     chatExcerpt(match.Content),
+			Score:       1 - match.Distance,
+			StartOffset: match.StartOffset,
+			EndOffset:   match.EndOffset,
+			content:     match.Content,
+		})
+		if len(sources) == limit {
+			break
+		}
+	}
+	return sources, nil
+}
+
+// provider picks the provider for a kind of call the way AI jobs pick
+// theirs: the job type's, then the tenant's choice, then the platform's,
+// with the tenant's own key when they have one to it
+func (s *ChatService) provider(ctx context.Context, tenantID uuid.UUID, jobType string) (*chatProvider, error) {
+	name, chosen := s.config.JobProviders[jobType], true
+	if name == "" {
+		if tenant, err := s.tenantRepo.GetByID(ctx, tenantID); err == nil {
+			name = resolveTenantSettings(tenant).AIProvider
+		}
+	}
+	if name == "" {
+		name, chosen = s.config.PlatformProvider, false
+	}
+
+	if s.keyResolver != nil && s.factory != nil {
+		key, err := s.keyResolver.ResolveKey(ctx, tenantID, name)
+		if err == nil && key != nil && (!chosen || key.Provider == name) {
+			if provider, err := s.factory.Provider(key.Provider, key.APIKey); err == nil {
+				return &chatProvider{LLMProvider: provider, source: AIKeySourceTenant, keyID: &key.KeyID}, nil
+			}
+		}
+	}
+
+	provider, ok := s.config.Providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAIProviderNotConfigured, name)
+	}
+	return &chatProvider{LLMProvider: provider, source: AIKeySourcePlatform}, nil
+}
+
+// checkLimits checks the tenant's AI quota, when the platform pays for a
+// call, and its AI budget
+func (s *ChatService) checkLimits(ctx context.Context, tenantID uuid.UUID, providers ...*chatProvider) error {
+	for _, provider := range providers {
+		if provider.source != AIKeySourcePlatform {
+			continue
+		}
+		quotaStatus, err := s.tenantRepo.CheckQuotaLimits(ctx, tenantID)
+		if err != nil {
+			return fmt.Errorf("failed to check quota: %w", err)
+		}
+		if !quotaStatus.CanProcessAI {
+			return fmt.Errorf("%w: AI processing", ErrQuotaExceeded)
+		}
+		break
+	}
+	if s.aiUsage != nil {
+		return s.aiUsage.CheckBudget(ctx, tenantID)
+	}
+	return nil
+}
+
+// recordUsage records a call's tokens and cost, estimated from the text
+// when the provider didn't report them
+func (s *ChatService) recordUsage(ctx context.Context, tenantID uuid.UUID, provider *chatProvider, operation, input, output string, response *LLMResponse) {
+	if s.aiUsage == nil {
+		return
+	}
+	usage := models.AIUsage{
+		TenantID:     tenantID,
+		Provider:     provider.Name(),
+		Model:        response.Model,
+		Operation:    operation,
+		KeySource:    provider.source,
+		InputTokens:  response.InputTokens,
+		OutputTokens: response.OutputTokens,
+	}
+	if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+		usage.Estimated = true
+		usage.InputTokens = estimateTokens(input)
+		usage.OutputTokens = estimateTokens(output)
+	}
+	usage.Cost = s.aiUsage.Cost(usage.Provider, usage.Model, usage.InputTokens, usage.OutputTokens)
+	if err := s.aiUsage.Record(ctx, []models.AIUsage{usage}); err != nil {
+		// Log but continue - the answer is already written
+	}
+}
+
+func (s *ChatService) recordKeyUse(ctx context.Context, provider *chatProvider, callErr error) {
+	if provider.keyID == nil {
+		return
+	}
+	if err := s.keyResolver.RecordUse(ctx, *provider.keyID, callErr); err != nil {
+		// Log but don't fail
+	}
+}
+
+// normalize trims the question and checks the request's size
+func (p *ChatParams) normalize() error {
+	p.Question = strings.TrimSpace(p.Question)
+	if p.Question == "" || len([]rune(p.Question)) > maxChatQuestionLength {
+		return ErrInvalidChatQuestion
+	}
+	if len(p.DocumentIDs) > maxChatDocuments || len(p.History) > maxChatHistory {
+		return ErrInvalidChatRequest
+	}
+	for _, message := range p.History {
+		if message.Role != "user" && message.Role != "assistant" {
+			return ErrInvalidChatRequest
+		}
+	}
+	return nil
+}
+
+// Helper functions
+
+// chatPrompt gives the model the numbered passages, the conversation so far
+// and the question
+func chatPrompt(params ChatParams, sources []ChatSource) string {
+	var prompt strings.Builder
+	prompt.WriteString("<passages>\n")
+	for _, source := range sources {
+		location := fmt.Sprintf("passage %d", source.Passage+1)
+		if source.Page != nil {
+			location = fmt.Sprintf("page %d, %s", *source.Page, location)
+		}
+		fmt.Fprintf(&prompt, "[%d] %s (%s)\n%s\n\n", source.Number, source.Title, location, source.content)
+	}
+	prompt.WriteString("</passages>\n")
+
+	if len(params.History) > 0 {
+		prompt.WriteString("\n<conversation>\n")
+		for _, message := range params.History {
+			speaker := "User"
+			if message.Role == "assistant" {
+				speaker = "Assistant"
+			}
+			fmt.Fprintf(&prompt, "%s: %s\n", speaker, strings.TrimSpace(message.Content))
+		}
+		prompt.WriteString("</conversation>\n")
+	}
+
+	prompt.WriteString("\nQuestion: ")
+	prompt.WriteString(params.Question)
+	return prompt.String()
+}
+
+// citedSources returns the sources an answer cites, in the order it first
+// cites them
+func citedSources(answer string, sources []ChatSource) []ChatSource {
+	cited := make([]ChatSource, 0)
+	seen := make(map[int]bool)
+	for _, match := range chatCitationPattern.FindAllStringSubmatch(answer, -1) {
+		for _, number := range strings.Split(match[1], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(number))
+			if err != nil || n < 1 || n > len(sources) || seen[n] {
+				continue
+			}
+			seen[n] = true
+			cited = append(cited, sources[n-1])
+		}
+	}
+	return cited
+}
+
+func chatExcerpt(content string) string {
+	runes := []rune(content)
+	if len(runes) <= chatExcerptRunes {
+		return content
+	}
+	return strings.TrimSpace(string(runes[:chatExcerptRunes])) + "…"
+}
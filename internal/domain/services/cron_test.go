@@ -0,0 +1,134 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		timezone   string
+	}{
+		{"too few fields", "0 0 * *", ""},
+		{"too many fields", "0 0 * * * *", ""},
+		{"minute out of range", "60 * * * *", ""},
+		{"hour out of range", "0 24 * * *", ""},
+		{"day of month zero", "0 0 0 * *", ""},
+		{"month out of range", "0 0 1 13 *", ""},
+		{"day of week out of range", "0 0 * * 8", ""},
+		{"reversed range", "0 0 * * 5-1", ""},
+		{"zero step", "*/0 * * * *", ""},
+		{"bad step", "*/x * * * *", ""},
+		{"unknown name", "0 0 * foo *", ""},
+		{"unknown time zone", "0 0 * * *", "Mars/Olympus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCronSchedule(tt.expression, tt.timezone)
+			assert.ErrorIs(t, err, ErrInvalidCronExpression)
+		})
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	// Monday, 15 January 2024
+	start := time.Date(2024, 1, 15, 10, 7, 30, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		want       []time.Time
+	}{
+		{"every minute", "* * * * *", []time.Time{at(1, 15, 10, 8), at(1, 15, 10, 9)}},
+		{"minute step", "*/15 * * * *", []time.Time{at(1, 15, 10, 15), at(1, 15, 10, 30), at(1, 15, 10, 45)}},
+		{"step from offset", "5/20 * * * *", []time.Time{at(1, 15, 10, 25), at(1, 15, 10, 45), at(1, 15, 11, 5)}},
+		{"hour range", "0 9-11 * * *", []time.Time{at(1, 15, 11, 0), at(1, 16, 9, 0), at(1, 16, 10, 0)}},
+		{"range with step", "0 8-18/5 * * *", []time.Time{at(1, 15, 13, 0), at(1, 15, 18, 0), at(1, 16, 8, 0)}},
+		{"list", "0 6,12 * * *", []time.Time{at(1, 15, 12, 0), at(1, 16, 6, 0)}},
+		{"month names", "0 0 1 mar,JUN *", []time.Time{at(3, 1, 0, 0), at(6, 1, 0, 0)}},
+		{"weekday names", "0 9 * * mon-wed", []time.Time{at(1, 16, 9, 0), at(1, 17, 9, 0), at(1, 22, 9, 0)}},
+		{"sunday as 0", "0 0 * * 0", []time.Time{at(1, 21, 0, 0), at(1, 28, 0, 0)}},
+		{"sunday as 7", "0 0 * * 7", []time.Time{at(1, 21, 0, 0), at(1, 28, 0, 0)}},
+		{"saturday to sunday range", "0 0 * * 6-7", []time.Time{at(1, 20, 0, 0), at(1, 21, 0, 0), at(1, 27, 0, 0)}},
+		// Either the 20th or any Monday
+		{"both day fields restricted", "0 0 20 * mon", []time.Time{at(1, 20, 0, 0), at(1, 22, 0, 0), at(1, 29, 0, 0), at(2, 5, 0, 0)}},
+		{"day of month only", "0 0 31 * *", []time.Time{at(1, 31, 0, 0), at(3, 31, 0, 0)}},
+		{"leap day", "0 0 29 2 *", []time.Time{at(2, 29, 0, 0)}},
+		{"descriptor", "@weekly", []time.Time{at(1, 21, 0, 0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expression, "")
+			require.NoError(t, err)
+
+			after := start
+			for _, want := range tt.want {
+				after = schedule.Next(after)
+				assert.Equal(t, want, after)
+			}
+		})
+	}
+}
+
+func TestCronScheduleNeverFires(t *testing.T) {
+	schedule, err := ParseCronSchedule("0 0 30 2 *", "")
+	require.NoError(t, err)
+
+	assert.True(t, schedule.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero())
+}
+
+func TestCronScheduleDaylightSaving(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, newYork)
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		after      time.Time
+		want       []time.Time
+	}{
+		// Clocks jump from 02:00 to 03:00 on 10 March
+		{"hourly over spring forward", "0 * * * *", at(3, 10, 1, 30), []time.Time{at(3, 10, 3, 0), at(3, 10, 4, 0)}},
+		{"skipped time doesn't fire", "30 2 * * *", at(3, 9, 12, 0), []time.Time{at(3, 11, 2, 30)}},
+		{"daily keeps wall clock time", "0 9 * * *", at(3, 9, 12, 0), []time.Time{at(3, 10, 9, 0), at(3, 11, 9, 0)}},
+		// Clocks go back from 02:00 to 01:00 on 3 November; 01:00 happens twice
+		{"hourly over fall back", "0 * * * *", at(11, 3, 0, 30), []time.Time{
+			time.Date(2024, 11, 3, 5, 0, 0, 0, time.UTC),
+			time.Date(2024, 11, 3, 6, 0, 0, 0, time.UTC),
+			time.Date(2024, 11, 3, 7, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expression, "America/New_York")
+			require.NoError(t, err)
+			assert.Equal(t, newYork, schedule.Location())
+
+			after := tt.after
+			for _, want := range tt.want {
+				after = schedule.Next(after)
+				assert.True(t, want.Equal(after), "want %s, got %s", want, after)
+			}
+		})
+	}
+
+	// Spring forward makes the day 23 hours long
+	schedule, err := ParseCronSchedule("0 9 * * *", "America/New_York")
+	require.NoError(t, err)
+	first := schedule.Next(at(3, 9, 8, 0))
+	assert.Equal(t, 23*time.Hour, schedule.Next(first).Sub(first))
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDataSubjectRequestNotFound = errors.New("data subject request not found")
+	ErrCannotEraseSelf            = errors.New("you cannot erase your own account")
+	ErrExportNotReady             = errors.New("data export is not ready")
+)
+
+// auditExportPageSize is how many audit entries are read per page while
+// building an export
+const auditExportPageSize = 500
+
+// SessionRevoker ends every session a user holds
+type SessionRevoker interface {
+	RevokeAllSessions(ctx context.Context, userID, revokedBy uuid.UUID) error
+}
+
+// DataSubjectConfig holds configuration for data subject requests
+type DataSubjectConfig struct {
+	Interval    time.Duration // How often due requests are processed; defaults to one minute
+	Lease       time.Duration // How long a worker owns a request; defaults to 30 minutes
+	MaxAttempts int           // Attempts before a request fails; defaults to 3
+	BatchSize   int           // Requests processed per run; defaults to 10
+}
+
+// DataSubjectService carries out GDPR requests for one user's data: an
+// export archive of everything they created, or an erasure that anonymizes
+// them across the system. Requests are queued and processed by a scheduled
+// task, so large exports don't hold up the request.
+type DataSubjectService struct {
+	repo           repositories.DataSubjectRepository
+	userRepo       repositories.UserRepository
+	auditRepo      repositories.AuditLogRepository
+	storageService StorageService
+	supabaseAuth   SupabaseAuthService
+	sessions       SessionRevoker
+	cacheService   CacheService
+	config         DataSubjectConfig
+}
+
+// NewDataSubjectService creates a new data subject service
+func NewDataSubjectService(
+	repo repositories.DataSubjectRepository,
+	userRepo repositories.UserRepository,
+	auditRepo repositories.AuditLogRepository,
+	storageService StorageService,
+	supabaseAuth SupabaseAuthService,
+	sessions SessionRevoker,
+	cacheService CacheService,
+	config DataSubjectConfig,
+) *DataSubjectService {
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.Lease <= 0 {
+		config.Lease = 30 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 10
+	}
+
+	return &DataSubjectService{
+		repo:           repo,
+		userRepo:       userRepo,
+		auditRepo:      auditRepo,
+		storageService: storageService,
+		supabaseAuth:   supabaseAuth,
+		sessions:       sessions,
+		cacheService:   cacheService,
+		config:         config,
+	}
+}
+
+// RequestExport queues an archive of the user's data
+func (s *DataSubjectService) RequestExport(ctx context.Context, tenantID, subjectID, requestedBy uuid.UUID) (*models.DataSubjectRequest, error) {
+	return s.createRequest(ctx, tenantID, subjectID, requestedBy, models.DataSubjectExport)
+}
+
+// RequestErasure queues the anonymization of the user across the system
+func (s *DataSubjectService) RequestErasure(ctx context.Context, tenantID, subjectID, requestedBy uuid.UUID) (*models.DataSubjectRequest, error) {
+	if subjectID == requestedBy {
+		return nil, ErrCannotEraseSelf
+	}
+	return s.createRequest(ctx, tenantID, subjectID, requestedBy, models.DataSubjectErasure)
+}
+
+// GetRequest returns one of the tenant's requests
+func (s *DataSubjectService) GetRequest(ctx context.Context, tenantID, requestID uuid.UUID) (*models.DataSubjectRequest, error) {
+	request, err := s.repo.GetByID(ctx, requestID)
+	if err != nil || request.TenantID != tenantID {
+		return nil, ErrDataSubjectRequestNotFound
+	}
+	return request, nil
+}
+
+// ListRequests returns the tenant's requests, newest first
+func (s *DataSubjectService) ListRequests(ctx context.Context, tenantID uuid.UUID, params repositories.ListParams) ([]models.DataSubjectRequest, int64, error) {
+	return s.repo.ListByTenant(ctx, tenantID, params)
+}
+
+// OpenExport returns a finished export's zip archive. The caller must close
+// the reader.
+func (s *DataSubjectService) OpenExport(ctx context.Context, tenantID, requestID uuid.UUID) (*models.DataSubjectRequest, io.ReadCloser, error) {
+	request, err := s.GetRequest(ctx, tenantID, requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if request.Type != models.DataSubjectExport || request.Status != models.DataSubjectCompleted || request.StoragePath == "" {
+		return nil, nil, ErrExportNotReady
+	}
+
+	reader, err := s.storageService.Get(ctx, request.StoragePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read data export: %w", err)
+	}
+	return request, reader, nil
+}
+
+// ProcessDue runs the requests that are waiting and returns how many finished
+func (s *DataSubjectService) ProcessDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.repo.ListDue(ctx, now, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range due {
+		request := &due[i]
+		claimed, err := s.repo.Claim(ctx, request.ID, now, now.Add(s.config.Lease))
+		if err != nil {
+			return processed, err
+		}
+		if !claimed {
+			// Another instance got it first
+			continue
+		}
+		request.Attempts++
+
+		if err := s.process(ctx, request); err != nil {
+			request.Error = err.Error()
+			request.Status = models.DataSubjectPending
+			if request.Attempts >= s.config.MaxAttempts {
+				request.Status = models.DataSubjectFailed
+			}
+		} else {
+			completedAt := time.Now()
+			request.Error = ""
+			request.Status = models.DataSubjectCompleted
+			request.CompletedAt = &completedAt
+		}
+
+		if err := s.repo.Finish(ctx, request); err != nil {
+			return processed, err
+		}
+		if request.Status != models.DataSubjectPending {
+			processed++
+			s.auditFinished(request)
+		}
+	}
+
+	return processed, nil
+}
+
+// RequestTask is the scheduled task that processes data subject requests
+func (s *DataSubjectService) RequestTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "data_subject_requests",
+		Interval: s.config.Interval,
+		Run: func(ctx context.Context) error {
+			_, err := s.ProcessDue(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+func (s *DataSubjectService) createRequest(ctx context.Context, tenantID, subjectID, requestedBy uuid.UUID, requestType models.DataSubjectRequestType) (*models.DataSubjectRequest, error) {
+	subject, err := s.userRepo.GetByID(ctx, subjectID)
+	if err != nil || subject.TenantID != tenantID {
+		return nil, ErrUserNotFound
+	}
+
+	request := &models.DataSubjectRequest{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		SubjectID:   subjectID,
+		Type:        requestType,
+		Status:      models.DataSubjectPending,
+		RequestedBy: requestedBy,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.repo.Create(ctx, request); err != nil {
+		return nil, fmt.Errorf("failed to create data subject request: %w", err)
+	}
+
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       requestedBy,
+		ResourceID:   request.ID,
+		Action:       models.AuditCreate,
+		ResourceType: "data_subject_request",
+		Details: models.JSONB{
+			"message":    fmt.Sprintf("Data %s requested", requestType),
+			"subject_id": subjectID,
+		},
+	}
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+
+	return request, nil
+}
+
+func (s *DataSubjectService) process(ctx context.Context, request *models.DataSubjectRequest) error {
+	switch request.Type {
+	case models.DataSubjectExport:
+		storagePath, size, err := s.export(ctx, request)
+		if err != nil {
+			return err
+		}
+		request.StoragePath = storagePath
+		request.SizeBytes = size
+		return nil
+	case models.DataSubjectErasure:
+		return s.erase(ctx, request)
+	default:
+		return fmt.Errorf("unknown data subject request type %q", request.Type)
+	}
+}
+
+// export writes the subject's account, comments, audit trail and the
+// documents they created into a zip archive in object storage
+func (s *DataSubjectService) export(ctx context.Context, request *models.DataSubjectRequest) (string, int64, error) {
+	subject, err := s.userRepo.GetByID(ctx, request.SubjectID)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	file, err := os.CreateTemp("", "data-export-*.zip")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create data export: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	if err := s.writeExport(ctx, archive, subject); err != nil {
+		return "", 0, err
+	}
+	if err := archive.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to write data export: %w", err)
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write data export: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", 0, fmt.Errorf("failed to write data export: %w", err)
+	}
+
+	storagePath, err := s.storageService.Store(ctx, StorageParams{
+		TenantID:    request.TenantID,
+		FileReader:  file,
+		Filename:    fmt.Sprintf("data-export-%s.zip", request.SubjectID),
+		ContentType: "application/zip",
+		Size:        size,
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to store data export: %w", err)
+	}
+	return storagePath, size, nil
+}
+
+func (s *DataSubjectService) writeExport(ctx context.Context, archive *zip.Writer, subject *models.User) error {
+	if err := writeZipJSON(archive, "user.json", subject); err != nil {
+		return err
+	}
+
+	documents, err := s.repo.ListCreatedDocuments(ctx, subject.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeZipJSON(archive, "documents.json", documents); err != nil {
+		return err
+	}
+	for _, document := range documents {
+		if err := writeZipDocument(ctx, s.storageService, archive, document); err != nil {
+			return err
+		}
+	}
+
+	comments, err := s.repo.ListComments(ctx, subject.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeZipJSON(archive, "comments.json", comments); err != nil {
+		return err
+	}
+
+	return s.writeAuditTrail(ctx, archive, subject.ID)
+}
+
+// writeAuditTrail writes the subject's audit entries as JSON lines
+func (s *DataSubjectService) writeAuditTrail(ctx context.Context, archive *zip.Writer, userID uuid.UUID) error {
+	entry, err := archive.Create("audit_log.jsonl")
+	if err != nil {
+		return fmt.Errorf("failed to write data export: %w", err)
+	}
+	encoder := json.NewEncoder(entry)
+
+	params := repositories.ListParams{Page: 1, PageSize: auditExportPageSize}
+	for {
+		logs, total, err := s.auditRepo.ListByUser(ctx, userID, params)
+		if err != nil {
+			return fmt.Errorf("failed to list audit entries: %w", err)
+		}
+		for _, log := range logs {
+			if err := encoder.Encode(log); err != nil {
+				return fmt.Errorf("failed to write data export: %w", err)
+			}
+		}
+		if int64(params.Page*params.PageSize) >= total || len(logs) == 0 {
+			return nil
+		}
+		params.Page++
+	}
+}
+
+// erase anonymizes the subject, ends their sessions and removes their
+// identity provider account. Audit entries are kept: rewriting them would
+// break the tenant's hash chain, and the anonymized account they point to no
+// longer identifies anyone.
+func (s *DataSubjectService) erase(ctx context.Context, request *models.DataSubjectRequest) error {
+	if err := s.repo.EraseUser(ctx, request.SubjectID, time.Now()); err != nil {
+		return err
+	}
+
+	if s.sessions != nil {
+		if err := s.sessions.RevokeAllSessions(ctx, request.SubjectID, request.RequestedBy); err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+
+	// Earlier exports hold the data that was just erased
+	previous, err := s.repo.ListBySubject(ctx, request.SubjectID)
+	if err != nil {
+		return err
+	}
+	for i := range previous {
+		export := &previous[i]
+		if export.Type != models.DataSubjectExport || export.StoragePath == "" {
+			continue
+		}
+		if err := s.storageService.Delete(ctx, export.StoragePath); err != nil {
+			return fmt.Errorf("failed to delete data export: %w", err)
+		}
+		export.StoragePath = ""
+		export.SizeBytes = 0
+		if err := s.repo.Finish(ctx, export); err != nil {
+			return err
+		}
+	}
+
+	if s.supabaseAuth != nil {
+		if err := s.supabaseAuth.AdminDeleteUser(request.SubjectID.String()); err != nil {
+			return fmt.Errorf("failed to delete identity provider account: %w", err)
+		}
+	}
+
+	if s.cacheService != nil {
+		s.cacheService.Delete(ctx, fmt.Sprintf(UserCacheKeyPattern, request.SubjectID.String()))
+	}
+	return nil
+}
+
+func (s *DataSubjectService) auditFinished(request *models.DataSubjectRequest) {
+	message := fmt.Sprintf("Data %s completed", request.Type)
+	if request.Status == models.DataSubjectFailed {
+		message = fmt.Sprintf("Data %s failed", request.Type)
+	}
+
+	log := &models.AuditLog{
+		TenantID:     request.TenantID,
+		UserID:       request.RequestedBy,
+		ResourceID:   request.ID,
+		Action:       models.AuditUpdate,
+		ResourceType: "data_subject_request",
+		Details: models.JSONB{
+			"message":    message,
+			"subject_id": request.SubjectID,
+			"attempts":   request.Attempts,
+			"error":      request.Error,
+		},
+	}
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+func writeZipJSON(archive *zip.Writer, name string, value interface{}) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write data export: %w", err)
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("failed to write data export: %w", err)
+	}
+	return nil
+}
+
+// writeZipDocument copies a document's file into an export archive
+func writeZipDocument(ctx context.Context, storageService StorageService, archive *zip.Writer, document models.Document) error {
+	reader, err := storageService.Get(ctx, document.StoragePath)
+	if err != nil {
+		return fmt.Errorf("failed to read document %s: %w", document.ID, err)
+	}
+	defer reader.Close()
+
+	entry, err := archive.Create(path.Join("documents", document.ID.String()+"-"+path.Base(document.FileName)))
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if _, err := io.Copy(entry, reader); err != nil {
+		return fmt.Errorf("failed to copy document %s: %w", document.ID, err)
+	}
+	return nil
+}
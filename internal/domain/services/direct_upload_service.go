@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrDirectUploadUnsupported = errors.New("storage backend does not support direct uploads")
+	ErrInvalidDirectUpload     = errors.New("invalid direct upload")
+	ErrUploadSessionNotFound   = errors.New("upload session not found")
+	ErrUploadSessionExpired    = errors.New("upload session has expired")
+	ErrUploadSessionClosed     = errors.New("upload session is already finalized")
+	ErrUploadIncomplete        = errors.New("file has not been uploaded yet")
+	ErrUploadMismatch          = errors.New("uploaded file does not match the declared size and checksum")
+)
+
+// expiredUploadBatchSize is how many expired sessions are cleaned up at a time
+const expiredUploadBatchSize = 100
+
+// DirectUploadService lets clients upload large files straight to storage.
+// The API hands out a pre-signed upload URL, and when the client finalizes
+// the upload it verifies the file and creates the document from it.
+type DirectUploadService struct {
+	sessionRepo     repositories.UploadSessionRepository
+	documentService *DocumentService
+	storageService  StorageService
+	config          DirectUploadServiceConfig
+}
+
+// DirectUploadServiceConfig holds configuration for direct uploads
+type DirectUploadServiceConfig struct {
+	URLExpiry      time.Duration // How long upload URLs work; defaults to an hour
+	FinalizeWindow time.Duration // How long after the URL expires uploads can still be finalized; defaults to an hour
+	CheckInterval  time.Duration // How often expired sessions are cleaned up; defaults to 15 minutes
+}
+
+// NewDirectUploadService creates a new direct upload service
+func NewDirectUploadService(
+	sessionRepo repositories.UploadSessionRepository,
+	documentService *DocumentService,
+	storageService StorageService,
+	config DirectUploadServiceConfig,
+) *DirectUploadService {
+	if config.URLExpiry <= 0 {
+		config.URLExpiry = time.Hour
+	}
+	if config.FinalizeWindow <= 0 {
+		config.FinalizeWindow = time.Hour
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 15 * time.Minute
+	}
+
+	return &DirectUploadService{
+		sessionRepo:     sessionRepo,
+		documentService: documentService,
+		storageService:  storageService,
+		config:          config,
+	}
+}
+
+// DirectUploadFile describes the file the client is going to upload
+type DirectUploadFile struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"` // Hex
+}
+
+// DirectUpload is a started upload: the session to finalize, and where to
+// upload the file before doing so
+type DirectUpload struct {
+	Session      *models.UploadSession `json:"session"`
+	Upload       *PresignedUpload      `json:"upload"`
+	URLExpiresAt time.Time             `json:"url_expires_at"`
+}
+
+// StartUpload checks that the file may be uploaded with these parameters,
+// then returns a URL to upload it to. The document is created with the
+// parameters once the upload is finalized.
+func (s *DirectUploadService) StartUpload(ctx context.Context, params UploadDocumentParams, file DirectUploadFile) (_ *DirectUpload, err error) {
+	ctx, span := startSpan(ctx, "DirectUploadService.StartUpload",
+		attribute.String("tenant.id", params.TenantID.String()))
+	defer func() { endSpan(span, err) }()
+
+	uploader, ok := s.storageService.(StorageUploader)
+	if !ok {
+		return nil, ErrDirectUploadUnsupported
+	}
+
+	file.Filename = strings.TrimSpace(file.Filename)
+	file.SHA256 = strings.ToLower(strings.TrimSpace(file.SHA256))
+	if err := validateDirectUploadFile(file); err != nil {
+		return nil, err
+	}
+
+	// Refuse what finalizing would refuse before the client uploads anything
+	if _, err := s.documentService.checkUpload(ctx, params, file.Size, file.ContentType); err != nil {
+		return nil, err
+	}
+	if err := s.documentService.checkDuplicate(ctx, params, file.SHA256); err != nil {
+		return nil, err
+	}
+
+	storedParams, err := uploadParamsToJSONB(params)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := uploader.PresignUpload(ctx, params.TenantID, file.Filename, file.ContentType, s.config.URLExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload URL: %w", err)
+	}
+
+	now := time.Now()
+	session := &models.UploadSession{
+		ID:          uuid.New(),
+		TenantID:    params.TenantID,
+		UserID:      params.UserID,
+		StoragePath: upload.Path,
+		Filename:    file.Filename,
+		ContentType: file.ContentType,
+		FileSize:    file.Size,
+		ContentHash: file.SHA256,
+		Params:      storedParams,
+		Status:      models.UploadSessionPending,
+		ExpiresAt:   now.Add(s.config.URLExpiry + s.config.FinalizeWindow),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return &DirectUpload{
+		Session:      session,
+		Upload:       upload,
+		URLExpiresAt: now.Add(s.config.URLExpiry),
+	}, nil
+}
+
+// FinalizeUpload verifies the uploaded file against the size and checksum
+// declared when the upload started, creates its document and queues its
+// processing. Only the user who started the upload can finalize it.
+//
+// Until the file is in storage ErrUploadIncomplete is returned and the
+// upload can be finalized again. Any other failure deletes the file; the
+// client starts a new upload to try again.
+func (s *DirectUploadService) FinalizeUpload(ctx context.Context, tenantID, userID, sessionID uuid.UUID) (_ *models.Document, err error) {
+	ctx, span := startSpan(ctx, "DirectUploadService.FinalizeUpload",
+		attribute.String("tenant.id", tenantID.String()),
+		attribute.String("upload_session.id", sessionID.String()))
+	defer func() { endSpan(span, err) }()
+
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil || session.TenantID != tenantID || session.UserID != userID {
+		return nil, ErrUploadSessionNotFound
+	}
+	if session.Status != models.UploadSessionPending {
+		return nil, ErrUploadSessionClosed
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, ErrUploadSessionExpired
+	}
+
+	uploader, ok := s.storageService.(StorageUploader)
+	if !ok {
+		return nil, ErrDirectUploadUnsupported
+	}
+	object, err := uploader.Stat(ctx, session.StoragePath)
+	if errors.Is(err, ErrStoredObjectNotFound) {
+		return nil, ErrUploadIncomplete
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check uploaded file: %w", err)
+	}
+
+	// Claim the session, so concurrent finalizes create one document
+	session.Status = models.UploadSessionFinalizing
+	claimed, err := s.sessionRepo.Transition(ctx, session, models.UploadSessionPending)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrUploadSessionClosed
+	}
+
+	document, err := s.createDocument(ctx, session, object)
+	if err != nil {
+		s.storageService.Delete(ctx, session.StoragePath)
+		session.Status = models.UploadSessionFailed
+		session.FailureReason = err.Error()
+		if _, updateErr := s.sessionRepo.Transition(ctx, session, models.UploadSessionFinalizing); updateErr != nil {
+			// Log but don't fail - the session stays finalizing
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	session.Status = models.UploadSessionCompleted
+	session.DocumentID = &document.ID
+	session.FinalizedAt = &now
+	if _, err := s.sessionRepo.Transition(ctx, session, models.UploadSessionFinalizing); err != nil {
+		// Log but don't fail - the document exists
+	}
+
+	return document, nil
+}
+
+// ExpireSessions deletes the files of uploads that were never finalized
+func (s *DirectUploadService) ExpireSessions(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		sessions, err := s.sessionRepo.ListExpired(ctx, time.Now(), expiredUploadBatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		batchExpired := 0
+		for i := range sessions {
+			session := &sessions[i]
+			session.Status = models.UploadSessionExpired
+			ok, err := s.sessionRepo.Transition(ctx, session, models.UploadSessionPending)
+			if err != nil || !ok {
+				// Finalized meanwhile, or retried on the next run
+				continue
+			}
+			// The client may never have uploaded anything
+			s.storageService.Delete(ctx, session.StoragePath)
+			batchExpired++
+		}
+		expired += batchExpired
+
+		if len(sessions) < expiredUploadBatchSize || batchExpired == 0 {
+			return expired, nil
+		}
+	}
+}
+
+// ExpiryTask is the scheduled task that cleans up expired upload sessions
+func (s *DirectUploadService) ExpiryTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "upload_session_expiry",
+		Interval: s.config.CheckInterval,
+		Run: func(ctx context.Context) error {
+			_, err := s.ExpireSessions(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+func (s *DirectUploadService) createDocument(ctx context.Context, session *models.UploadSession, object *StoredObject) (*models.Document, error) {
+	if object.Size != session.FileSize {
+		return nil, fmt.Errorf("%w: %d bytes were uploaded, %d declared", ErrUploadMismatch, object.Size, session.FileSize)
+	}
+
+	reader, err := s.storageService.Get(ctx, session.StoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	contentHash, err := s.documentService.calculateContentHash(reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	if contentHash != session.ContentHash {
+		return nil, fmt.Errorf("%w: checksum differs", ErrUploadMismatch)
+	}
+
+	params, err := uploadParamsFromJSONB(session.Params)
+	if err != nil {
+		return nil, err
+	}
+	params.TenantID = session.TenantID
+	params.UserID = session.UserID
+
+	// Access and quotas may have changed since the upload started
+	quotaStatus, err := s.documentService.checkUpload(ctx, params, session.FileSize, session.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.documentService.checkDuplicate(ctx, params, contentHash); err != nil {
+		return nil, err
+	}
+	s.documentService.applyUploadDefaults(ctx, &params, session.Filename, session.ContentType)
+	if err := s.documentService.reserveUploadStorage(ctx, session.TenantID, session.FileSize, quotaStatus); err != nil {
+		return nil, err
+	}
+
+	return s.documentService.createUploadedDocument(ctx, params, uploadedFile{
+		Filename:    session.Filename,
+		ContentType: session.ContentType,
+		Size:        session.FileSize,
+		StoragePath: session.StoragePath,
+		ContentHash: contentHash,
+	}, quotaStatus)
+}
+
+func validateDirectUploadFile(file DirectUploadFile) error {
+	if file.Filename == "" {
+		return fmt.Errorf("%w: filename is required", ErrInvalidDirectUpload)
+	}
+	if file.Size <= 0 {
+		return fmt.Errorf("%w: size must be positive", ErrInvalidDirectUpload)
+	}
+	if hash, err := hex.DecodeString(file.SHA256); err != nil || len(hash) != 32 {
+		return fmt.Errorf("%w: sha256 must be a hex SHA-256 checksum", ErrInvalidDirectUpload)
+	}
+	return nil
+}
+
+// uploadParamsToJSONB keeps the document metadata of an upload until it's
+// finalized
+func uploadParamsToJSONB(params UploadDocumentParams) (models.JSONB, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode upload parameters: %w", err)
+	}
+	var stored models.JSONB
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to encode upload parameters: %w", err)
+	}
+	return stored, nil
+}
+
+func uploadParamsFromJSONB(stored models.JSONB) (UploadDocumentParams, error) {
+	var params UploadDocumentParams
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return params, fmt.Errorf("failed to decode upload parameters: %w", err)
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return params, fmt.Errorf("failed to decode upload parameters: %w", err)
+	}
+	return params, nil
+}
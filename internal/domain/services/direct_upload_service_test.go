@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDirectUploadFile(t *testing.T) {
+	valid := DirectUploadFile{
+		Filename:    "scan.pdf",
+		ContentType: "application/pdf",
+		Size:        5 << 30,
+		SHA256:      strings.Repeat("ab", 32),
+	}
+	assert.NoError(t, validateDirectUploadFile(valid))
+
+	for _, mutate := range []func(*DirectUploadFile){
+		func(f *DirectUploadFile) { f.Filename = "" },
+		func(f *DirectUploadFile) { f.Size = 0 },
+		func(f *DirectUploadFile) { f.SHA256 = "abc" },
+		func(f *DirectUploadFile) { f.SHA256 = strings.Repeat("zz", 32) },
+	} {
+		file := valid
+		mutate(&file)
+		assert.ErrorIs(t, validateDirectUploadFile(file), ErrInvalidDirectUpload)
+	}
+}
+
+func TestUploadParamsJSONB(t *testing.T) {
+	folderID := uuid.New()
+	amount := 129.5
+	dueDate := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	params := UploadDocumentParams{
+		TenantID:           uuid.New(),
+		FolderID:           &folderID,
+		Title:              "Q1 invoices",
+		DocumentType:       models.DocTypeInvoice,
+		Tags:               []string{"finance"},
+		Amount:             &amount,
+		DueDate:            &dueDate,
+		EnableAI:           true,
+		ProcessingPriority: ProcessingPriorityLow,
+	}
+
+	stored, err := uploadParamsToJSONB(params)
+	require.NoError(t, err)
+	restored, err := uploadParamsFromJSONB(stored)
+	require.NoError(t, err)
+
+	assert.Equal(t, params.TenantID, restored.TenantID)
+	assert.Equal(t, folderID, *restored.FolderID)
+	assert.Equal(t, params.Title, restored.Title)
+	assert.Equal(t, params.DocumentType, restored.DocumentType)
+	assert.Equal(t, params.Tags, restored.Tags)
+	assert.Equal(t, amount, *restored.Amount)
+	assert.True(t, dueDate.Equal(*restored.DueDate))
+	assert.True(t, restored.EnableAI)
+	assert.Equal(t, ProcessingPriorityLow, restored.ProcessingPriority)
+}
+
+func TestDirectUploadService_RequiresUploaderStorage(t *testing.T) {
+	service := NewDirectUploadService(nil, nil, nil, DirectUploadServiceConfig{})
+
+	_, err := service.StartUpload(context.Background(), UploadDocumentParams{}, DirectUploadFile{})
+	assert.ErrorIs(t, err, ErrDirectUploadUnsupported)
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+)
+
+// DocumentCheckService runs the checks documents must pass before the
+// download gate lets them out: a virus scan of the file, and a DLP check
+// of its extracted text for personal data
+type DocumentCheckService struct {
+	docRepo        repositories.DocumentRepository
+	aiJobRepo      repositories.AIProcessingJobRepository
+	storageService StorageService
+	scanner        VirusScanner
+	config         DocumentCheckServiceConfig
+}
+
+// DocumentCheckServiceConfig holds configuration for document checks
+type DocumentCheckServiceConfig struct {
+	RequiredChecks []models.DocumentCheck
+	CheckInterval  time.Duration // How often pending documents are checked; defaults to a minute
+	BatchSize      int           // Documents fetched at a time; defaults to 50
+}
+
+// NewDocumentCheckService creates a new document check service. The scanner
+// may be nil when virus scans aren't required.
+func NewDocumentCheckService(
+	docRepo repositories.DocumentRepository,
+	aiJobRepo repositories.AIProcessingJobRepository,
+	storageService StorageService,
+	scanner VirusScanner,
+	config DocumentCheckServiceConfig,
+) *DocumentCheckService {
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = time.Minute
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 50
+	}
+
+	return &DocumentCheckService{
+		docRepo:        docRepo,
+		aiJobRepo:      aiJobRepo,
+		storageService: storageService,
+		scanner:        scanner,
+		config:         config,
+	}
+}
+
+// RunChecks runs every required check on the documents still pending it.
+// Documents that can't be checked yet, or whose check fails to run, stay
+// pending and are retried on the next run.
+func (s *DocumentCheckService) RunChecks(ctx context.Context) (int, error) {
+	checked := 0
+	for _, check := range s.config.RequiredChecks {
+		var run func(context.Context, *models.Document) (bool, error)
+		switch check {
+		case models.DocCheckVirusScan:
+			if s.scanner == nil {
+				return checked, fmt.Errorf("virus scans are required but no scanner is configured")
+			}
+			run = s.scanDocument
+		case models.DocCheckDLP:
+			run = s.inspectDocument
+		default:
+			return checked, fmt.Errorf("unknown document check: %s", check)
+		}
+
+		var after *models.Document
+		for {
+			documents, err := s.docRepo.ListPendingCheck(ctx, check, after, s.config.BatchSize)
+			if err != nil {
+				return checked, err
+			}
+
+			for i := range documents {
+				done, err := run(ctx, &documents[i])
+				if err != nil {
+					// Log but continue - retried on the next run
+					continue
+				}
+				if done {
+					checked++
+				}
+			}
+
+			if len(documents) < s.config.BatchSize {
+				break
+			}
+			after = &documents[len(documents)-1]
+		}
+	}
+	return checked, nil
+}
+
+// CheckTask is the scheduled task that runs pending document checks
+func (s *DocumentCheckService) CheckTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "document_checks",
+		Interval: s.config.CheckInterval,
+		Run: func(ctx context.Context) error {
+			_, err := s.RunChecks(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+func (s *DocumentCheckService) scanDocument(ctx context.Context, document *models.Document) (bool, error) {
+	reader, err := s.storageService.Get(ctx, document.StoragePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read document file: %w", err)
+	}
+	defer reader.Close()
+
+	result, err := s.scanner.Scan(ctx, reader)
+	if err != nil {
+		return false, fmt.Errorf("failed to scan document: %w", err)
+	}
+
+	if result.Infected {
+		return true, s.docRepo.SetCheckResult(ctx, document.ID, models.DocCheckVirusScan, models.CheckStatusFailed,
+			models.JSONB{"threat": result.Threat})
+	}
+	return true, s.docRepo.SetCheckResult(ctx, document.ID, models.DocCheckVirusScan, models.CheckStatusPassed, nil)
+}
+
+// inspectDocument looks for personal data in the document's extracted and
+// OCR text, once the jobs producing that text have finished. Documents
+// without text have nothing to leak and pass.
+func (s *DocumentCheckService) inspectDocument(ctx context.Context, document *models.Document) (bool, error) {
+	jobs, err := s.aiJobRepo.ListByDocument(ctx, document.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list document jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if job.JobType != "text_extraction" && job.JobType != "ocr" {
+			continue
+		}
+		if job.Status == models.ProcessingQueued || job.Status == models.ProcessingInProgress {
+			return false, nil
+		}
+	}
+
+	found := detectPersonalData(document.ExtractedText + "\n" + document.OCRText)
+	if len(found) > 0 {
+		findings := models.JSONB{}
+		for kind, count := range found {
+			findings[kind] = count
+		}
+		return true, s.docRepo.SetCheckResult(ctx, document.ID, models.DocCheckDLP, models.CheckStatusFailed, findings)
+	}
+	return true, s.docRepo.SetCheckResult(ctx, document.ID, models.DocCheckDLP, models.CheckStatusPassed, nil)
+}
+
+// Helper functions
+
+var (
+	ssnPattern         = regexp.MustCompile(`\b(\d{3})-(\d{2})-(\d{4})\b`)
+	paymentCardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	ibanPattern        = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`)
+)
+
+// detectPersonalData counts the personal data in text by kind: US social
+// security numbers, payment card numbers and IBANs. Candidates must pass
+// the format's own validation, so order numbers and the like aren't counted.
+// Only counts are returned; the findings never hold the data itself.
+func detectPersonalData(text string) map[string]int {
+	found := make(map[string]int)
+
+	for _, match := range ssnPattern.FindAllStringSubmatch(text, -1) {
+		// Area 000, 666 and 9xx, group 00 and serial 0000 are never issued
+		if match[1] == "000" || match[1] == "666" || match[1][0] == '9' || match[2] == "00" || match[3] == "0000" {
+			continue
+		}
+		found["ssn"]++
+	}
+	for _, match := range paymentCardPattern.FindAllString(text, -1) {
+		if luhnValid(stripSeparators(match)) {
+			found["payment_card"]++
+		}
+	}
+	for _, match := range ibanPattern.FindAllString(text, -1) {
+		if ibanValid(stripSeparators(match)) {
+			found["iban"]++
+		}
+	}
+
+	return found
+}
+
+// redactPersonalData masks the personal data detectPersonalData counts
+func redactPersonalData(text string) string {
+	text = ssnPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if detectPersonalData(match)["ssn"] > 0 {
+			return "[REDACTED]"
+		}
+		return match
+	})
+	text = paymentCardPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if luhnValid(stripSeparators(match)) {
+			return "[REDACTED]"
+		}
+		return match
+	})
+	return ibanPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if ibanValid(stripSeparators(match)) {
+			return "[REDACTED]"
+		}
+		return match
+	})
+}
+
+func stripSeparators(value string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(value)
+}
+
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// ibanValid checks an IBAN's mod-97 checksum
+func ibanValid(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	rearranged := iban[4:] + iban[:4]
+
+	remainder := 0
+	for _, char := range rearranged {
+		switch {
+		case char >= '0' && char <= '9':
+			remainder = (remainder*10 + int(char-'0')) % 97
+		case char >= 'A' && char <= 'Z':
+			remainder = (remainder*100 + int(char-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
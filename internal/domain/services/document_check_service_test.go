@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectPersonalData(t *testing.T) {
+	found := detectPersonalData(`
+		Employee SSN: 123-45-6789, old record 000-12-3456
+		Card: 4111 1111 1111 1111, order no. 4111 1111 1111 1112
+		Pay to GB82 WEST 1234 5698 7654 32 or DE89370400440532013000
+		Invoice INV-2024-000123, phone 555-123-4567`)
+
+	assert.Equal(t, map[string]int{"ssn": 1, "payment_card": 1, "iban": 2}, found)
+	assert.Empty(t, detectPersonalData("Quarterly report, revenue up 12%"))
+}
+
+func TestRedactPersonalData(t *testing.T) {
+	redacted := redactPersonalData("SSN 123-45-6789, card 4111 1111 1111 1111, order 4111 1111 1111 1112")
+
+	assert.Equal(t, "SSN [REDACTED], card [REDACTED], order 4111 1111 1111 1112", redacted)
+}
+
+func TestDownloadGate(t *testing.T) {
+	required := []models.DocumentCheck{models.DocCheckVirusScan, models.DocCheckDLP}
+	document := &models.Document{
+		ID:            uuid.New(),
+		ScanStatus:    models.CheckStatusPassed,
+		DLPStatus:     models.CheckStatusFailed,
+		CheckFindings: models.JSONB{"dlp": map[string]interface{}{"ssn": 2}},
+	}
+
+	gate := downloadGate(document, required)
+	assert.False(t, gate.Downloadable)
+	require.Len(t, gate.Checks, 2)
+	assert.Equal(t, models.CheckStatusPassed, gate.Checks[0].Status)
+	assert.Equal(t, models.CheckStatusFailed, gate.Checks[1].Status)
+	assert.NotNil(t, gate.Checks[1].Findings)
+
+	err := checkDownloadGate(document, required)
+	assert.ErrorIs(t, err, ErrDocumentGated)
+
+	// Documents from before the checks were required wait for them
+	legacy := &models.Document{ID: uuid.New()}
+	gate = downloadGate(legacy, required)
+	assert.False(t, gate.Downloadable)
+	assert.Equal(t, models.CheckStatusPending, gate.Checks[0].Status)
+
+	adminID := uuid.New()
+	document.GateOverrideBy = &adminID
+	document.GateOverrideReason = "False positive: test data"
+	assert.NoError(t, checkDownloadGate(document, required))
+
+	// Without required checks every document can be downloaded
+	assert.NoError(t, checkDownloadGate(legacy, nil))
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// Fingerprints are 64-bit hashes; near-duplicates differ in few of the bits
+const fingerprintBits = 64
+
+// maxNearDuplicates is the most near-duplicate pairs a search reports, the
+// closest first
+const maxNearDuplicates = 1000
+
+// minSimhashWords is the least text a simhash is computed for. Shorter texts
+// share too many of their words with unrelated documents.
+const minSimhashWords = 20
+
+// simhashShingleWords is how many words each hashed feature of a text spans
+const simhashShingleWords = 3
+
+// Sizes for perceptual image hashes: images are scaled to a square of
+// phashSize and the lowest phashBlock frequencies of its DCT kept
+const (
+	phashSize  = 32
+	phashBlock = 8
+)
+
+// errFingerprintUnsupported is returned for images that can't be decoded
+var errFingerprintUnsupported = errors.New("image can't be fingerprinted")
+
+// textSimhash is a simhash of a text's overlapping word shingles, so texts
+// differing in a few words, such as OCR of two scans of a page, differ in
+// few bits. Texts too short to compare have none.
+func textSimhash(text string) (uint64, bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) < minSimhashWords {
+		return 0, false
+	}
+
+	var weights [fingerprintBits]int
+	for i := 0; i+simhashShingleWords <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+simhashShingleWords], " ")))
+		feature := h.Sum64()
+		for bit := 0; bit < fingerprintBits; bit++ {
+			if feature&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash, true
+}
+
+// imagePerceptualHash is a pHash of an image: the signs of its lowest
+// frequencies against their median, which survive rescaling, recompression
+// and small edits
+func imagePerceptualHash(content io.Reader) (uint64, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return 0, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, errFingerprintUnsupported
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxRenditionSourcePixels {
+		return 0, fmt.Errorf("%w: image is too large", errFingerprintUnsupported)
+	}
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// Grey levels of the image squeezed into phashSize × phashSize
+	var pixels [phashSize][phashSize]float64
+	bounds := source.Bounds()
+	for y := 0; y < phashSize; y++ {
+		for x := 0; x < phashSize; x++ {
+			r, g, b, _ := boxAverage(source, image.Rect(
+				bounds.Min.X+x*bounds.Dx()/phashSize, bounds.Min.Y+y*bounds.Dy()/phashSize,
+				bounds.Min.X+(x+1)*bounds.Dx()/phashSize, bounds.Min.Y+(y+1)*bounds.Dy()/phashSize,
+			)).RGBA()
+			pixels[y][x] = 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+		}
+	}
+
+	// The lowest frequencies of the 2D DCT-II
+	var coefficients []float64
+	for v := 0; v < phashBlock; v++ {
+		for u := 0; u < phashBlock; u++ {
+			sum := 0.0
+			for y := 0; y < phashSize; y++ {
+				for x := 0; x < phashSize; x++ {
+					sum += pixels[y][x] *
+						math.Cos(float64(2*x+1)*float64(u)*math.Pi/(2*phashSize)) *
+						math.Cos(float64(2*y+1)*float64(v)*math.Pi/(2*phashSize))
+				}
+			}
+			coefficients = append(coefficients, sum)
+		}
+	}
+
+	// The average brightness, the first coefficient, is left out of the median
+	sorted := append([]float64(nil), coefficients[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var hash uint64
+	for i, coefficient := range coefficients {
+		if coefficient > median {
+			hash |= 1 << i
+		}
+	}
+	return hash, nil
+}
+
+// fingerprintMaxDistance is how many bits two fingerprints may differ in to
+// be at least threshold similar
+func fingerprintMaxDistance(threshold float64) int {
+	return int(math.Floor((1 - threshold) * fingerprintBits))
+}
+
+// fingerprintDistance counts the bits two fingerprints differ in
+func fingerprintDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// mergeDuplicates adds near-duplicates to exact ones, keeping the closest
+// match of each pair of documents
+func mergeDuplicates(exact, near []repositories.DocumentDuplicate) []repositories.DocumentDuplicate {
+	type pair struct{ a, b uuid.UUID }
+	key := func(d repositories.DocumentDuplicate) pair {
+		if d.OriginalID.String() < d.DuplicateID.String() {
+			return pair{d.OriginalID, d.DuplicateID}
+		}
+		return pair{d.DuplicateID, d.OriginalID}
+	}
+
+	merged := make([]repositories.DocumentDuplicate, 0, len(exact)+len(near))
+	index := make(map[pair]int)
+	for _, duplicate := range append(append([]repositories.DocumentDuplicate(nil), exact...), near...) {
+		if i, ok := index[key(duplicate)]; ok {
+			if duplicate.Similarity > merged[i].Similarity {
+				merged[i] = duplicate
+			}
+			continue
+		}
+		index[key(duplicate)] = len(merged)
+		merged = append(merged, duplicate)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Similarity > merged[j].Similarity
+	})
+	return merged
+}
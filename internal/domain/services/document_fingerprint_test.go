@@ -0,0 +1,82 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fingerprintTestText = `This agreement is made between Acme Corporation and Globex Limited for the supply
+of office furniture. Delivery is due within thirty days of the order and payment within sixty days of the invoice.
+Either party may end the agreement with ninety days written notice to the other party.`
+
+func TestTextSimhash(t *testing.T) {
+	original, ok := textSimhash(fingerprintTestText)
+	require.True(t, ok)
+
+	// A re-scan with a few OCR errors stays close, other text doesn't
+	rescan, ok := textSimhash(strings.NewReplacer("thirty", "thlrty", "Globex", "G1obex").Replace(fingerprintTestText))
+	require.True(t, ok)
+	other, ok := textSimhash(`Minutes of the quarterly board meeting held in Berlin. The board approved the budget for
+the coming year, elected a new treasurer and agreed to review the travel policy before the summer break starts.`)
+	require.True(t, ok)
+
+	assert.Less(t, fingerprintDistance(original, rescan), fingerprintDistance(original, other))
+	assert.LessOrEqual(t, fingerprintDistance(original, rescan), fingerprintMaxDistance(0.8))
+
+	_, ok = textSimhash("Too short to compare")
+	assert.False(t, ok)
+}
+
+func TestImagePerceptualHash(t *testing.T) {
+	gradient := func(width, height int, shade uint8) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				v := uint8(x * 255 / width)
+				if y > height/2 {
+					v = 255 - v
+				}
+				img.Set(x, y, color.RGBA{R: v, G: v, B: shade, A: 255})
+			}
+		}
+		var out bytes.Buffer
+		require.NoError(t, png.Encode(&out, img))
+		return out.Bytes()
+	}
+
+	original, err := imagePerceptualHash(bytes.NewReader(gradient(200, 100, 0)))
+	require.NoError(t, err)
+	rescaled, err := imagePerceptualHash(bytes.NewReader(gradient(400, 200, 10)))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, fingerprintDistance(original, rescaled), fingerprintMaxDistance(0.9))
+
+	_, err = imagePerceptualHash(strings.NewReader("not an image"))
+	assert.ErrorIs(t, err, errFingerprintUnsupported)
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	merged := mergeDuplicates(
+		[]repositories.DocumentDuplicate{{OriginalID: a, DuplicateID: b, Similarity: 1, ContentMatch: true, Method: repositories.DuplicateByContentHash}},
+		[]repositories.DocumentDuplicate{
+			{OriginalID: b, DuplicateID: a, Similarity: 1, Method: repositories.DuplicateByText},
+			{OriginalID: a, DuplicateID: c, Similarity: 0.84, Method: repositories.DuplicateByText},
+			{OriginalID: a, DuplicateID: c, Similarity: 0.91, Method: repositories.DuplicateByImage},
+		},
+	)
+
+	require.Len(t, merged, 2)
+	assert.Equal(t, repositories.DuplicateByContentHash, merged[0].Method)
+	assert.Equal(t, c, merged[1].DuplicateID)
+	assert.Equal(t, repositories.DuplicateByImage, merged[1].Method)
+	assert.Equal(t, 12, fingerprintMaxDistance(0.8))
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDocumentGated            = errors.New("document is held back until its required checks pass")
+	ErrGateOverrideReasonNeeded = errors.New("a reason is required to override the download gate")
+)
+
+// DocumentGatedError is returned for downloads and shares of a document
+// whose required checks haven't passed. It matches ErrDocumentGated with
+// errors.Is.
+type DocumentGatedError struct {
+	Gate *DownloadGateStatus
+}
+
+func (e *DocumentGatedError) Error() string {
+	var waiting []string
+	for _, check := range e.Gate.Checks {
+		if check.Status != models.CheckStatusPassed {
+			waiting = append(waiting, fmt.Sprintf("%s %s", check.Check, check.Status))
+		}
+	}
+	return fmt.Sprintf("%s (%s)", ErrDocumentGated, strings.Join(waiting, ", "))
+}
+
+func (e *DocumentGatedError) Unwrap() error {
+	return ErrDocumentGated
+}
+
+// DocumentCheckState is where one required check of a document stands
+type DocumentCheckState struct {
+	Check    models.DocumentCheck `json:"check"`
+	Status   models.CheckStatus   `json:"status"`
+	Findings interface{}          `json:"findings,omitempty"`
+}
+
+// DownloadGateOverride records an admin letting a document past the gate
+type DownloadGateOverride struct {
+	By     uuid.UUID `json:"by"`
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason"`
+}
+
+// DownloadGateStatus says whether a document can be downloaded and shared,
+// and which required checks it's waiting for or failed
+type DownloadGateStatus struct {
+	DocumentID   uuid.UUID             `json:"document_id"`
+	Downloadable bool                  `json:"downloadable"`
+	Checks       []DocumentCheckState  `json:"checks"`
+	Override     *DownloadGateOverride `json:"override,omitempty"`
+}
+
+// GetDownloadGate returns whether the document can be downloaded and shared
+func (s *DocumentService) GetDownloadGate(ctx context.Context, documentID, tenantID, userID uuid.UUID) (*DownloadGateStatus, error) {
+	document, err := s.GetDocument(ctx, documentID, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return downloadGate(document, s.config.RequiredChecks), nil
+}
+
+// GetDocumentForDownload is GetDocument for handing out the document's file:
+// it fails with a DocumentGatedError until the required checks have passed
+func (s *DocumentService) GetDocumentForDownload(ctx context.Context, documentID, tenantID, userID uuid.UUID) (*models.Document, error) {
+	document, err := s.GetDocument(ctx, documentID, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDownloadGate(document, s.config.RequiredChecks); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// OverrideDownloadGate lets a document be downloaded and shared although its
+// required checks haven't passed, e.g. after a false positive. Callers must
+// be admins; the override is audited.
+func (s *DocumentService) OverrideDownloadGate(ctx context.Context, documentID, tenantID, adminID uuid.UUID, reason string) (*DownloadGateStatus, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrGateOverrideReasonNeeded
+	}
+
+	document, err := s.docRepo.GetForTenant(ctx, tenantID, documentID)
+	if err != nil || document.DeletedAt != nil {
+		return nil, ErrDocumentNotFound
+	}
+
+	now := time.Now()
+	if err := s.docRepo.SetGateOverride(ctx, document.ID, &adminID, reason, now); err != nil {
+		return nil, err
+	}
+	document.GateOverrideBy = &adminID
+	document.GateOverrideAt = &now
+	document.GateOverrideReason = reason
+
+	gate := downloadGate(document, s.config.RequiredChecks)
+	s.createAuditLog(ctx, tenantID, adminID, document.ID, models.AuditUpdate,
+		fmt.Sprintf("Download gate overridden: %s (checks: %s)", reason, describeChecks(gate.Checks)))
+
+	return gate, nil
+}
+
+// ClearDownloadGateOverride puts a document back behind the download gate
+func (s *DocumentService) ClearDownloadGateOverride(ctx context.Context, documentID, tenantID, adminID uuid.UUID) (*DownloadGateStatus, error) {
+	document, err := s.docRepo.GetForTenant(ctx, tenantID, documentID)
+	if err != nil || document.DeletedAt != nil {
+		return nil, ErrDocumentNotFound
+	}
+
+	if err := s.docRepo.SetGateOverride(ctx, document.ID, nil, "", time.Now()); err != nil {
+		return nil, err
+	}
+	document.GateOverrideBy = nil
+	document.GateOverrideAt = nil
+	document.GateOverrideReason = ""
+
+	s.createAuditLog(ctx, tenantID, adminID, document.ID, models.AuditUpdate, "Download gate override removed")
+
+	return downloadGate(document, s.config.RequiredChecks), nil
+}
+
+// Helper functions
+
+// downloadGate evaluates the required checks of a document. Documents from
+// before a check was required count as pending until it runs on them.
+func downloadGate(document *models.Document, required []models.DocumentCheck) *DownloadGateStatus {
+	gate := &DownloadGateStatus{
+		DocumentID: document.ID,
+		Checks:     []DocumentCheckState{},
+	}
+
+	passed := true
+	for _, check := range required {
+		state := DocumentCheckState{Check: check, Status: documentCheckStatus(document, check)}
+		if state.Status == "" {
+			state.Status = models.CheckStatusPending
+		}
+		if state.Status != models.CheckStatusPassed {
+			passed = false
+		}
+		if findings, ok := document.CheckFindings[string(check)]; ok {
+			state.Findings = findings
+		}
+		gate.Checks = append(gate.Checks, state)
+	}
+
+	if document.GateOverrideBy != nil {
+		gate.Override = &DownloadGateOverride{
+			By:     *document.GateOverrideBy,
+			Reason: document.GateOverrideReason,
+		}
+		if document.GateOverrideAt != nil {
+			gate.Override.At = *document.GateOverrideAt
+		}
+	}
+
+	gate.Downloadable = passed || gate.Override != nil
+	return gate
+}
+
+// checkDownloadGate returns a DocumentGatedError unless the document can be
+// downloaded and shared
+func checkDownloadGate(document *models.Document, required []models.DocumentCheck) error {
+	if len(required) == 0 {
+		return nil
+	}
+	if gate := downloadGate(document, required); !gate.Downloadable {
+		return &DocumentGatedError{Gate: gate}
+	}
+	return nil
+}
+
+func documentCheckStatus(document *models.Document, check models.DocumentCheck) models.CheckStatus {
+	switch check {
+	case models.DocCheckVirusScan:
+		return document.ScanStatus
+	case models.DocCheckDLP:
+		return document.DLPStatus
+	}
+	return ""
+}
+
+func describeChecks(checks []DocumentCheckState) string {
+	if len(checks) == 0 {
+		return "none required"
+	}
+	parts := make([]string, len(checks))
+	for i, check := range checks {
+		parts[i] = fmt.Sprintf("%s %s", check.Check, check.Status)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// Language detection for documents' text. The language is stored on the
+// document, picks the text search configuration it is indexed with and is
+// given to AI providers as a hint, so summaries and tags are written in the
+// document's language.
+
+const (
+	languageSampleWords = 2000 // Words at the start of a text its language is detected from
+	minLanguageHits     = 5    // Stop words needed before a text's language is trusted
+)
+
+// languageNames are the languages documents may be in, by ISO 639-1 code,
+// as named in prompts
+var languageNames = map[string]string{
+	"da": "Danish",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fi": "Finnish",
+	"fr": "French",
+	"hu": "Hungarian",
+	"it": "Italian",
+	"nl": "Dutch",
+	"no": "Norwegian",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"tr": "Turkish",
+}
+
+// detectTextLanguage returns the language of a document's text, or "" when
+// it has too few stop words of any supported language to tell
+func detectTextLanguage(text string) string {
+	words := tokenizeText(text)
+	if len(words) > languageSampleWords {
+		words = words[:languageSampleWords]
+	}
+	language, hits := stopWordLanguage(words)
+	if hits < minLanguageHits {
+		return ""
+	}
+	return language
+}
+
+type aiLanguageContextKey struct{}
+
+// WithAILanguage returns a context carrying the language of the document an
+// AI call is made for
+func WithAILanguage(ctx context.Context, language string) context.Context {
+	return context.WithValue(ctx, aiLanguageContextKey{}, language)
+}
+
+// AILanguageFromContext returns the language of the document an AI call is
+// made for, if known
+func AILanguageFromContext(ctx context.Context) string {
+	language, _ := ctx.Value(aiLanguageContextKey{}).(string)
+	return language
+}
+
+// aiLanguageHint asks for output in the language the call's context
+// carries, e.g. " The document is in German; write the summary in German."
+func aiLanguageHint(ctx context.Context, output string) string {
+	name, ok := languageNames[AILanguageFromContext(ctx)]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" The document is in %s; write the %s in %s.", name, output, name)
+}
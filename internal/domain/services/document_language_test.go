@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectTextLanguage(t *testing.T) {
+	assert.Equal(t, "de", detectTextLanguage("Die Rechnung ist bis zum 1. März zu zahlen. Bei Fragen wenden Sie sich an uns, wir helfen Ihnen gern mit der Zahlung."))
+	assert.Equal(t, "fr", detectTextLanguage("La facture est à payer avant le 1er mars. Pour toute question, contactez-nous et nous vous aiderons avec le paiement."))
+	assert.Equal(t, "en", detectTextLanguage("The invoice is due by 1 March. If you have any questions about the payment, please contact us and we will help."))
+
+	// Too few stop words to tell, e.g. a receipt's line items
+	assert.Empty(t, detectTextLanguage("ACME GmbH 2x Widget 19,99 EUR Total 39,98"))
+	assert.Empty(t, detectTextLanguage(strings.Repeat("invoice ", 100)))
+}
+
+func TestAILanguageHint(t *testing.T) {
+	assert.Empty(t, aiLanguageHint(context.Background(), "summary"))
+	assert.Empty(t, aiLanguageHint(WithAILanguage(context.Background(), "xx"), "summary"))
+	assert.Equal(t, " The document is in German; write the tags in German.",
+		aiLanguageHint(WithAILanguage(context.Background(), "de"), "tags"))
+}
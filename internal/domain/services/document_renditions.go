@@ -0,0 +1,436 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/pkg/logger"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidPreviewRules  = errors.New("preview rules need a content type such as application/pdf, video/* or *, sizes that are not negative with the minimum below the maximum, and triggers of auto, on_demand or never")
+	ErrInvalidRenditionKind = errors.New("rendition must be thumbnail or preview")
+	ErrRenditionDisabled    = errors.New("this rendition is turned off for the document by the tenant's preview rules")
+)
+
+// Rendition kinds, each queued as a processing job of the same name
+const (
+	RenditionThumbnail = "thumbnail"
+	RenditionPreview   = "preview"
+)
+
+// Preview triggers say when a rendition is generated
+const (
+	PreviewTriggerAuto     = "auto"      // On upload
+	PreviewTriggerOnDemand = "on_demand" // When first requested
+	PreviewTriggerNever    = "never"
+)
+
+// Rendition states reported to callers requesting one
+const (
+	RenditionStatusReady      = "ready"
+	RenditionStatusQueued     = "queued"
+	RenditionStatusProcessing = "processing"
+)
+
+// tenantPreviewRulesSetting is the tenant settings key holding the rules
+const tenantPreviewRulesSetting = "preview_rules"
+
+// maxPreviewRules bounds the rules a tenant can keep
+const maxPreviewRules = 50
+
+// renditionOnDemandPriority puts requested renditions ahead of all queued
+// processing, high priority uploads included, since someone is looking at
+// a placeholder until they are done
+const renditionOnDemandPriority = 0
+
+// renditionMaxDimensions is the longest side of each rendition in pixels
+var renditionMaxDimensions = map[string]int{
+	RenditionThumbnail: 256,
+	RenditionPreview:   1600,
+}
+
+// maxRenditionSourcePixels keeps huge images from exhausting the worker's memory
+const maxRenditionSourcePixels = 100_000_000
+
+// defaultPreviewRules apply to tenants without rules of their own: very
+// large files get no preview and video posters are only drawn on demand
+var defaultPreviewRules = []PreviewRule{
+	{ContentType: "*", MinSizeBytes: 500 << 20, Thumbnail: PreviewTriggerOnDemand, Preview: PreviewTriggerNever},
+	{ContentType: "video/*", Thumbnail: PreviewTriggerOnDemand, Preview: PreviewTriggerOnDemand},
+}
+
+// PreviewRule sets when the renditions of documents of a content type and
+// size range are generated. Sizes are inclusive; a zero maximum has no limit.
+type PreviewRule struct {
+	ContentType  string `json:"content_type"` // e.g. application/pdf, video/* or *
+	MinSizeBytes int64  `json:"min_size_bytes,omitempty"`
+	MaxSizeBytes int64  `json:"max_size_bytes,omitempty"`
+	Thumbnail    string `json:"thumbnail"` // auto, on_demand or never
+	Preview      string `json:"preview"`
+}
+
+// PreviewRules are a tenant's rendition triggers. The first rule matching a
+// document applies; documents no rule matches get both renditions on upload.
+type PreviewRules struct {
+	Rules []PreviewRule `json:"rules"`
+
+	// Default is set while the tenant uses the server's rules
+	Default   bool       `json:"default"`
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// RenditionStatus is the state of a document's rendition
+type RenditionStatus struct {
+	Kind   string     `json:"kind"`
+	Status string     `json:"status"`
+	Path   string     `json:"path,omitempty"`
+	JobID  *uuid.UUID `json:"job_id,omitempty"`
+}
+
+// GetPreviewRules returns the tenant's preview rules, or the server's when
+// the tenant has none
+func (s *DocumentService) GetPreviewRules(ctx context.Context, tenantID uuid.UUID) (*PreviewRules, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	raw, ok := tenant.Settings[tenantPreviewRulesSetting]
+	if !ok {
+		return &PreviewRules{Rules: s.config.PreviewRules, Default: true}, nil
+	}
+
+	rules := &PreviewRules{}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preview rules: %w", err)
+	}
+	if err := json.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("failed to read preview rules: %w", err)
+	}
+	return rules, nil
+}
+
+// UpdatePreviewRules validates and stores the tenant's preview rules.
+// Documents already uploaded keep the renditions they have.
+func (s *DocumentService) UpdatePreviewRules(ctx context.Context, tenantID, updatedBy uuid.UUID, rules []PreviewRule) (*PreviewRules, error) {
+	normalized, err := normalizePreviewRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	now := time.Now()
+	stored := &PreviewRules{Rules: normalized, UpdatedBy: &updatedBy, UpdatedAt: &now}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode preview rules: %w", err)
+	}
+	var setting map[string]interface{}
+	if err := json.Unmarshal(data, &setting); err != nil {
+		return nil, fmt.Errorf("failed to encode preview rules: %w", err)
+	}
+
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+	tenant.Settings[tenantPreviewRulesSetting] = setting
+	tenant.UpdatedAt = now
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, fmt.Errorf("failed to update preview rules: %w", err)
+	}
+
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       updatedBy,
+		ResourceID:   tenantID,
+		Action:       models.AuditUpdate,
+		ResourceType: "preview_rules",
+		Details: models.JSONB{
+			"message": "Preview rules updated",
+			"rules":   len(normalized),
+		},
+	}
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+
+	return stored, nil
+}
+
+// RequestRendition generates a document's thumbnail or preview on demand.
+// The job jumps the processing queue; a job already queued for it is moved
+// to the front instead of queueing another.
+func (s *DocumentService) RequestRendition(ctx context.Context, documentID, tenantID, userID uuid.UUID, kind string) (*RenditionStatus, error) {
+	if _, ok := renditionMaxDimensions[kind]; !ok {
+		return nil, ErrInvalidRenditionKind
+	}
+
+	document, err := s.GetDocument(ctx, documentID, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &RenditionStatus{Kind: kind, Status: RenditionStatusReady, Path: renditionPath(document, kind)}
+	if status.Path != "" {
+		return status, nil
+	}
+
+	if s.renditionTrigger(ctx, document, kind) == PreviewTriggerNever {
+		return nil, ErrRenditionDisabled
+	}
+
+	jobs, err := s.aiJobRepo.ListByDocument(ctx, document.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document jobs: %w", err)
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if job.JobType != kind {
+			continue
+		}
+		switch {
+		case job.Status == models.ProcessingInProgress:
+			return &RenditionStatus{Kind: kind, Status: RenditionStatusProcessing, JobID: &job.ID}, nil
+		case job.Status == models.ProcessingQueued && job.Attempts < job.MaxAttempts:
+			prioritized, err := s.aiJobRepo.Prioritize(ctx, job.ID, renditionOnDemandPriority)
+			if err != nil {
+				return nil, fmt.Errorf("failed to prioritize rendition: %w", err)
+			}
+			// A worker picked the job up in the meantime
+			if !prioritized {
+				return &RenditionStatus{Kind: kind, Status: RenditionStatusProcessing, JobID: &job.ID}, nil
+			}
+			return &RenditionStatus{Kind: kind, Status: RenditionStatusQueued, JobID: &job.ID}, nil
+		}
+	}
+
+	job := &models.AIProcessingJob{
+		TenantID:    document.TenantID,
+		DocumentID:  document.ID,
+		JobType:     kind,
+		Priority:    renditionOnDemandPriority,
+		TraceParent: traceParent(ctx),
+		RequestID:   logger.RequestIDFromContext(ctx),
+	}
+	if err := s.aiJobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to queue rendition: %w", err)
+	}
+	return &RenditionStatus{Kind: kind, Status: RenditionStatusQueued, JobID: &job.ID}, nil
+}
+
+// queueRenditions queues the renditions the tenant's rules generate on upload
+func (s *DocumentService) queueRenditions(ctx context.Context, document *models.Document, priority ProcessingPriority) error {
+	rules := s.previewRules(ctx, document.TenantID)
+	for _, kind := range []string{RenditionThumbnail, RenditionPreview} {
+		if matchPreviewTrigger(rules, kind, document.ContentType, document.FileSize) != PreviewTriggerAuto {
+			continue
+		}
+
+		job := &models.AIProcessingJob{
+			TenantID:    document.TenantID,
+			DocumentID:  document.ID,
+			JobType:     kind,
+			Priority:    priority.JobPriority(),
+			TraceParent: traceParent(ctx),
+			RequestID:   logger.RequestIDFromContext(ctx),
+		}
+		if err := s.aiJobRepo.Create(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DocumentService) renditionTrigger(ctx context.Context, document *models.Document, kind string) string {
+	return matchPreviewTrigger(s.previewRules(ctx, document.TenantID), kind, document.ContentType, document.FileSize)
+}
+
+// previewRules are the rules in force for a tenant; unreadable tenant rules
+// fall back to the server's
+func (s *DocumentService) previewRules(ctx context.Context, tenantID uuid.UUID) []PreviewRule {
+	rules, err := s.GetPreviewRules(ctx, tenantID)
+	if err != nil {
+		return s.config.PreviewRules
+	}
+	return rules.Rules
+}
+
+// matchPreviewTrigger returns the trigger of the first rule matching the document
+func matchPreviewTrigger(rules []PreviewRule, kind, contentType string, size int64) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	contentType = strings.ToLower(contentType)
+
+	for _, rule := range rules {
+		if !rule.matches(contentType, size) {
+			continue
+		}
+		trigger := rule.Preview
+		if kind == RenditionThumbnail {
+			trigger = rule.Thumbnail
+		}
+		if trigger == "" {
+			return PreviewTriggerAuto
+		}
+		return trigger
+	}
+	return PreviewTriggerAuto
+}
+
+func (r PreviewRule) matches(contentType string, size int64) bool {
+	if size < r.MinSizeBytes || (r.MaxSizeBytes > 0 && size > r.MaxSizeBytes) {
+		return false
+	}
+	switch {
+	case r.ContentType == "*":
+		return true
+	case strings.HasSuffix(r.ContentType, "/*"):
+		return strings.HasPrefix(contentType, strings.TrimSuffix(r.ContentType, "*"))
+	default:
+		return contentType == r.ContentType
+	}
+}
+
+func normalizePreviewRules(rules []PreviewRule) ([]PreviewRule, error) {
+	if len(rules) > maxPreviewRules {
+		return nil, fmt.Errorf("%w: at most %d rules are allowed", ErrInvalidPreviewRules, maxPreviewRules)
+	}
+
+	normalized := make([]PreviewRule, 0, len(rules))
+	for _, rule := range rules {
+		rule.ContentType = strings.ToLower(strings.TrimSpace(rule.ContentType))
+		if rule.ContentType != "*" {
+			mediaType, _, err := mime.ParseMediaType(rule.ContentType)
+			if err != nil || mediaType != rule.ContentType || strings.Count(mediaType, "/") != 1 || strings.HasPrefix(mediaType, "*") {
+				return nil, ErrInvalidPreviewRules
+			}
+		}
+		if rule.MinSizeBytes < 0 || rule.MaxSizeBytes < 0 ||
+			(rule.MaxSizeBytes > 0 && rule.MinSizeBytes > rule.MaxSizeBytes) {
+			return nil, ErrInvalidPreviewRules
+		}
+		for _, trigger := range []*string{&rule.Thumbnail, &rule.Preview} {
+			*trigger = strings.ToLower(strings.TrimSpace(*trigger))
+			switch *trigger {
+			case "":
+				*trigger = PreviewTriggerAuto
+			case PreviewTriggerAuto, PreviewTriggerOnDemand, PreviewTriggerNever:
+			default:
+				return nil, ErrInvalidPreviewRules
+			}
+		}
+		normalized = append(normalized, rule)
+	}
+	return normalized, nil
+}
+
+func renditionPath(document *models.Document, kind string) string {
+	if kind == RenditionThumbnail {
+		return document.ThumbnailPath
+	}
+	return document.PreviewPath
+}
+
+func isRenditionJob(jobType string) bool {
+	_, ok := renditionMaxDimensions[jobType]
+	return ok
+}
+
+// errRenditionUnsupported is returned for files no renderer can draw
+var errRenditionUnsupported = errors.New("no renderer for this content type")
+
+// renderImageRendition scales an image down to fit maxDimension and encodes
+// it as JPEG. Images already small enough are re-encoded at their size.
+func renderImageRendition(content io.Reader, maxDimension int) ([]byte, image.Point, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, image.Point{}, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, image.Point{}, errRenditionUnsupported
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxRenditionSourcePixels {
+		return nil, image.Point{}, fmt.Errorf("%w: image is too large", errRenditionUnsupported)
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, image.Point{}, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	size := fitWithin(source.Bounds().Size(), maxDimension)
+	scaled := image.NewRGBA(image.Rectangle{Max: size})
+	bounds := source.Bounds()
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			scaled.Set(x, y, boxAverage(source, image.Rect(
+				bounds.Min.X+x*bounds.Dx()/size.X, bounds.Min.Y+y*bounds.Dy()/size.Y,
+				bounds.Min.X+(x+1)*bounds.Dx()/size.X, bounds.Min.Y+(y+1)*bounds.Dy()/size.Y,
+			)))
+		}
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, scaled, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, image.Point{}, fmt.Errorf("failed to encode rendition: %w", err)
+	}
+	return out.Bytes(), size, nil
+}
+
+// fitWithin scales size down, keeping its aspect ratio, so neither side
+// exceeds maxDimension
+func fitWithin(size image.Point, maxDimension int) image.Point {
+	longest := max(size.X, size.Y)
+	if longest <= maxDimension {
+		return size
+	}
+	return image.Pt(max(1, size.X*maxDimension/longest), max(1, size.Y*maxDimension/longest))
+}
+
+// boxAverage is the mean colour of the source pixels a scaled pixel covers,
+// flattened onto white so transparent images don't turn black as JPEG
+func boxAverage(source image.Image, area image.Rectangle) color.Color {
+	if area.Empty() {
+		area.Max = area.Min.Add(image.Pt(1, 1))
+	}
+	var r, g, b, a, n uint64
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			pr, pg, pb, pa := source.At(x, y).RGBA()
+			r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+			n++
+		}
+	}
+	white := n*0xffff - a // Premultiplied colours plus the white showing through
+	return color.RGBA64{
+		R: uint16((r + white) / n),
+		G: uint16((g + white) / n),
+		B: uint16((b + white) / n),
+		A: 0xffff,
+	}
+}
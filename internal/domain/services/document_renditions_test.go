@@ -0,0 +1,95 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPreviewTrigger(t *testing.T) {
+	rules := append([]PreviewRule{
+		{ContentType: "application/pdf", MaxSizeBytes: 10 << 20, Thumbnail: PreviewTriggerAuto, Preview: PreviewTriggerOnDemand},
+	}, defaultPreviewRules...)
+
+	assert.Equal(t, PreviewTriggerAuto, matchPreviewTrigger(rules, RenditionThumbnail, "application/pdf", 1<<20))
+	assert.Equal(t, PreviewTriggerOnDemand, matchPreviewTrigger(rules, RenditionPreview, "application/PDF; name=a.pdf", 1<<20))
+
+	// Large files fall through to the size rule, whatever their type
+	assert.Equal(t, PreviewTriggerOnDemand, matchPreviewTrigger(rules, RenditionThumbnail, "application/pdf", 600<<20))
+	assert.Equal(t, PreviewTriggerNever, matchPreviewTrigger(rules, RenditionPreview, "image/png", 500<<20))
+
+	assert.Equal(t, PreviewTriggerOnDemand, matchPreviewTrigger(rules, RenditionThumbnail, "video/mp4", 1<<20))
+	assert.Equal(t, PreviewTriggerAuto, matchPreviewTrigger(rules, RenditionPreview, "image/png", 1<<20))
+	assert.Equal(t, PreviewTriggerAuto, matchPreviewTrigger(nil, RenditionPreview, "video/mp4", 1<<30))
+}
+
+func TestNormalizePreviewRules(t *testing.T) {
+	rules, err := normalizePreviewRules([]PreviewRule{
+		{ContentType: " Video/* ", Thumbnail: "ON_DEMAND"},
+		{ContentType: "*", MinSizeBytes: 100, MaxSizeBytes: 200, Preview: PreviewTriggerNever},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []PreviewRule{
+		{ContentType: "video/*", Thumbnail: PreviewTriggerOnDemand, Preview: PreviewTriggerAuto},
+		{ContentType: "*", MinSizeBytes: 100, MaxSizeBytes: 200, Thumbnail: PreviewTriggerAuto, Preview: PreviewTriggerNever},
+	}, rules)
+
+	for _, invalid := range []PreviewRule{
+		{ContentType: ""},
+		{ContentType: "*/*"},
+		{ContentType: "pdf"},
+		{ContentType: "application/pdf; q=1"},
+		{ContentType: "*", MinSizeBytes: -1},
+		{ContentType: "*", MinSizeBytes: 300, MaxSizeBytes: 200},
+		{ContentType: "*", Thumbnail: "sometimes"},
+	} {
+		_, err := normalizePreviewRules([]PreviewRule{invalid})
+		assert.ErrorIs(t, err, ErrInvalidPreviewRules, invalid.ContentType)
+	}
+
+	_, err = normalizePreviewRules(make([]PreviewRule, maxPreviewRules+1))
+	assert.ErrorIs(t, err, ErrInvalidPreviewRules)
+}
+
+func TestRenderImageRendition(t *testing.T) {
+	// Left half red, right half transparent
+	source := image.NewNRGBA(image.Rect(0, 0, 800, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 400; x++ {
+			source.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, source))
+
+	rendered, size, err := renderImageRendition(bytes.NewReader(encoded.Bytes()), renditionMaxDimensions[RenditionThumbnail])
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(256, 128), size)
+
+	thumbnail, err := jpeg.Decode(bytes.NewReader(rendered))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 256, 128), thumbnail.Bounds())
+	r, g, _, _ := thumbnail.At(64, 64).RGBA()
+	assert.Greater(t, r>>8, uint32(200))
+	assert.Less(t, g>>8, uint32(60))
+	// Transparency turns white rather than black
+	r, g, _, _ = thumbnail.At(192, 64).RGBA()
+	assert.Greater(t, r>>8, uint32(200))
+	assert.Greater(t, g>>8, uint32(200))
+
+	// Small images keep their size
+	_, size, err = renderImageRendition(bytes.NewReader(encoded.Bytes()), 1600)
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(800, 400), size)
+
+	_, _, err = renderImageRendition(strings.NewReader("%PDF-1.4"), 256)
+	assert.True(t, errors.Is(err, errRenditionUnsupported))
+}
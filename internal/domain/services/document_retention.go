@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+
+// maxRetentionYears bounds the retention periods a policy may set
+const maxRetentionYears = 100
+
+// RetentionAction is what a retention policy does with documents once their
+// retention period has passed
+type RetentionAction string
+
+const (
+	RetentionArchive RetentionAction = "archive"
+	RetentionDelete  RetentionAction = "delete" // Moved to the trash, then purged
+	RetentionKeep    RetentionAction = "keep"
+)
+
+// RetentionRule keeps documents for a number of years, then applies Action
+type RetentionRule struct {
+	RetentionYears int             `json:"retention_years"`
+	Action         RetentionAction `json:"action"`
+}
+
+// DocumentRetentionPolicy sets retention per document type. Documents of
+// types without a rule follow the rule of the built-in type theirs maps to,
+// then Default; without one they're kept.
+type DocumentRetentionPolicy struct {
+	Default       *RetentionRule                        `json:"default,omitempty"`
+	DocumentTypes map[models.DocumentType]RetentionRule `json:"document_types,omitempty"`
+}
+
+// RetentionImpact counts documents and the bytes they hold
+type RetentionImpact struct {
+	Documents int64 `json:"documents"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// RetentionBreakdown splits due documents by what would happen to them.
+// Held documents are due but under legal hold, so they'd be left alone.
+type RetentionBreakdown struct {
+	Archive RetentionImpact `json:"archive"`
+	Delete  RetentionImpact `json:"delete"`
+	Held    RetentionImpact `json:"held"`
+}
+
+// RetentionFolderImpact is a simulation's result for one folder; documents
+// outside any folder have no folder ID
+type RetentionFolderImpact struct {
+	FolderID *uuid.UUID `json:"folder_id"`
+	Path     string     `json:"path"`
+	RetentionBreakdown
+}
+
+// RetentionTypeImpact is a simulation's result for one document type
+type RetentionTypeImpact struct {
+	DocumentType models.DocumentType `json:"document_type"`
+	Rule         RetentionRule       `json:"rule"`
+	RetentionBreakdown
+}
+
+// RetentionSimulation is what a retention policy would do if it were
+// applied at EvaluatedAt
+type RetentionSimulation struct {
+	EvaluatedAt   time.Time               `json:"evaluated_at"`
+	Policy        DocumentRetentionPolicy `json:"policy"`
+	Total         RetentionBreakdown      `json:"total"`
+	Folders       []RetentionFolderImpact `json:"folders"`
+	DocumentTypes []RetentionTypeImpact   `json:"document_types"`
+}
+
+// SimulateRetention evaluates a proposed retention policy against the
+// tenant's documents without changing any. Documents already archived are
+// not counted again for archival, and trashed documents are left out.
+func (s *DocumentService) SimulateRetention(ctx context.Context, tenantID uuid.UUID, policy DocumentRetentionPolicy) (*RetentionSimulation, error) {
+	if err := validateRetentionPolicy(policy); err != nil {
+		return nil, err
+	}
+	docTypes, err := loadDocumentTypes(ctx, s.docTypeRepo, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document types: %w", err)
+	}
+	for documentType := range policy.DocumentTypes {
+		if !docTypes.defined(documentType) {
+			return nil, fmt.Errorf("%w: unknown document type %s", ErrInvalidRetentionPolicy, documentType)
+		}
+	}
+
+	now := time.Now()
+	effective := policy.withMappedRules(docTypes)
+	stats, err := s.docRepo.SummarizeRetentionDue(ctx, tenantID, retentionCutoffs(effective, now), now)
+	if err != nil {
+		return nil, err
+	}
+	folders, err := s.folderRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+
+	simulation := buildRetentionSimulation(effective, stats, folders, now)
+	simulation.Policy = policy
+	return simulation, nil
+}
+
+// Helper methods
+
+func validateRetentionPolicy(policy DocumentRetentionPolicy) error {
+	if policy.Default == nil && len(policy.DocumentTypes) == 0 {
+		return fmt.Errorf("%w: set a default rule or rules for document types", ErrInvalidRetentionPolicy)
+	}
+
+	validate := func(name string, rule RetentionRule) error {
+		switch rule.Action {
+		case RetentionKeep:
+			return nil
+		case RetentionArchive, RetentionDelete:
+		default:
+			return fmt.Errorf("%w: %s action must be archive, delete or keep", ErrInvalidRetentionPolicy, name)
+		}
+		if rule.RetentionYears < 1 || rule.RetentionYears > maxRetentionYears {
+			return fmt.Errorf("%w: %s retention must be between 1 and %d years", ErrInvalidRetentionPolicy, name, maxRetentionYears)
+		}
+		return nil
+	}
+
+	if policy.Default != nil {
+		if err := validate("default", *policy.Default); err != nil {
+			return err
+		}
+	}
+	for documentType, rule := range policy.DocumentTypes {
+		if documentType == "" {
+			return fmt.Errorf("%w: document type is required", ErrInvalidRetentionPolicy)
+		}
+		if err := validate(string(documentType), rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withMappedRules gives the tenant's own types without a rule the rule of
+// the built-in type they map to, if it has one
+func (p DocumentRetentionPolicy) withMappedRules(docTypes *documentTypeRegistry) DocumentRetentionPolicy {
+	effective := DocumentRetentionPolicy{
+		Default:       p.Default,
+		DocumentTypes: make(map[models.DocumentType]RetentionRule, len(p.DocumentTypes)),
+	}
+	for documentType, rule := range p.DocumentTypes {
+		effective.DocumentTypes[documentType] = rule
+	}
+	for documentType := range docTypes.custom {
+		if _, ok := p.DocumentTypes[documentType]; ok {
+			continue
+		}
+		if rule, ok := p.DocumentTypes[docTypes.builtin(documentType)]; ok {
+			effective.DocumentTypes[documentType] = rule
+		}
+	}
+	return effective
+}
+
+// ruleFor returns the rule a document type follows; ok is false when the
+// policy leaves the type alone
+func (p DocumentRetentionPolicy) ruleFor(documentType models.DocumentType) (RetentionRule, bool) {
+	rule, ok := p.DocumentTypes[documentType]
+	if !ok && p.Default != nil {
+		rule, ok = *p.Default, true
+	}
+	if !ok || rule.Action == RetentionKeep {
+		return RetentionRule{}, false
+	}
+	return rule, true
+}
+
+// retentionCutoffs turns the policy's periods into the dates documents
+// must predate to be due. Kept types get no cutoff, so they never are.
+func retentionCutoffs(policy DocumentRetentionPolicy, now time.Time) repositories.RetentionCutoffs {
+	cutoffs := repositories.RetentionCutoffs{ByType: make(map[models.DocumentType]time.Time)}
+	if policy.Default != nil && policy.Default.Action != RetentionKeep {
+		before := now.AddDate(-policy.Default.RetentionYears, 0, 0)
+		cutoffs.Default = &before
+	}
+	for documentType, rule := range policy.DocumentTypes {
+		if rule.Action == RetentionKeep {
+			// The zero time: nothing predates it
+			cutoffs.ByType[documentType] = time.Time{}
+			continue
+		}
+		cutoffs.ByType[documentType] = now.AddDate(-rule.RetentionYears, 0, 0)
+	}
+	return cutoffs
+}
+
+func buildRetentionSimulation(policy DocumentRetentionPolicy, stats []repositories.RetentionStats, folders []models.Folder, now time.Time) *RetentionSimulation {
+	simulation := &RetentionSimulation{
+		EvaluatedAt:   now,
+		Policy:        policy,
+		Folders:       []RetentionFolderImpact{},
+		DocumentTypes: []RetentionTypeImpact{},
+	}
+
+	paths := make(map[uuid.UUID]string, len(folders))
+	for _, folder := range folders {
+		paths[folder.ID] = folder.Path
+	}
+
+	byFolder := make(map[uuid.UUID]*RetentionFolderImpact)
+	byType := make(map[models.DocumentType]*RetentionTypeImpact)
+	for _, stat := range stats {
+		rule, ok := policy.ruleFor(stat.DocumentType)
+		if !ok {
+			// Due by their own retention date, but the policy leaves the type alone
+			continue
+		}
+
+		var bucket func(*RetentionBreakdown) *RetentionImpact
+		switch {
+		case stat.LegalHold:
+			bucket = func(b *RetentionBreakdown) *RetentionImpact { return &b.Held }
+		case rule.Action == RetentionDelete:
+			bucket = func(b *RetentionBreakdown) *RetentionImpact { return &b.Delete }
+		case stat.Archived:
+			continue
+		default:
+			bucket = func(b *RetentionBreakdown) *RetentionImpact { return &b.Archive }
+		}
+
+		folderKey := uuid.Nil
+		if stat.FolderID != nil {
+			folderKey = *stat.FolderID
+		}
+		folder, ok := byFolder[folderKey]
+		if !ok {
+			folder = &RetentionFolderImpact{FolderID: stat.FolderID, Path: "/"}
+			if stat.FolderID != nil {
+				folder.Path = paths[*stat.FolderID]
+			}
+			byFolder[folderKey] = folder
+		}
+		documentType, ok := byType[stat.DocumentType]
+		if !ok {
+			documentType = &RetentionTypeImpact{DocumentType: stat.DocumentType, Rule: rule}
+			byType[stat.DocumentType] = documentType
+		}
+
+		for _, breakdown := range []*RetentionBreakdown{&simulation.Total, &folder.RetentionBreakdown, &documentType.RetentionBreakdown} {
+			impact := bucket(breakdown)
+			impact.Documents += stat.Count
+			impact.Bytes += stat.Bytes
+		}
+	}
+
+	for _, folder := range byFolder {
+		simulation.Folders = append(simulation.Folders, *folder)
+	}
+	sort.Slice(simulation.Folders, func(i, j int) bool {
+		return simulation.Folders[i].Path < simulation.Folders[j].Path
+	})
+	for _, documentType := range byType {
+		simulation.DocumentTypes = append(simulation.DocumentTypes, *documentType)
+	}
+	sort.Slice(simulation.DocumentTypes, func(i, j int) bool {
+		return simulation.DocumentTypes[i].DocumentType < simulation.DocumentTypes[j].DocumentType
+	})
+
+	return simulation
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRetentionSimulation(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	finance := models.Folder{ID: uuid.New(), Path: "/finance"}
+	policy := DocumentRetentionPolicy{
+		Default: &RetentionRule{RetentionYears: 7, Action: RetentionArchive},
+		DocumentTypes: map[models.DocumentType]RetentionRule{
+			models.DocTypeReceipt:   {RetentionYears: 2, Action: RetentionDelete},
+			models.DocTypeMarketing: {Action: RetentionKeep},
+		},
+	}
+
+	stats := []repositories.RetentionStats{
+		{FolderID: &finance.ID, DocumentType: models.DocTypeReceipt, Count: 4, Bytes: 400},
+		{FolderID: &finance.ID, DocumentType: models.DocTypeReceipt, LegalHold: true, Count: 1, Bytes: 100},
+		{FolderID: &finance.ID, DocumentType: models.DocTypeInvoice, Count: 2, Bytes: 2000},
+		// Already archived: nothing left to do
+		{FolderID: &finance.ID, DocumentType: models.DocTypeInvoice, Archived: true, Count: 3, Bytes: 3000},
+		{DocumentType: models.DocTypeContract, Count: 1, Bytes: 50},
+		// Due by its own retention date, but the policy keeps marketing
+		{DocumentType: models.DocTypeMarketing, Count: 9, Bytes: 900},
+	}
+
+	simulation := buildRetentionSimulation(policy, stats, []models.Folder{finance}, now)
+
+	assert.Equal(t, RetentionImpact{Documents: 3, Bytes: 2050}, simulation.Total.Archive)
+	assert.Equal(t, RetentionImpact{Documents: 4, Bytes: 400}, simulation.Total.Delete)
+	assert.Equal(t, RetentionImpact{Documents: 1, Bytes: 100}, simulation.Total.Held)
+
+	require.Len(t, simulation.Folders, 2)
+	assert.Equal(t, "/", simulation.Folders[0].Path)
+	assert.Nil(t, simulation.Folders[0].FolderID)
+	assert.Equal(t, int64(1), simulation.Folders[0].Archive.Documents)
+	assert.Equal(t, "/finance", simulation.Folders[1].Path)
+	assert.Equal(t, int64(2), simulation.Folders[1].Archive.Documents)
+	assert.Equal(t, int64(4), simulation.Folders[1].Delete.Documents)
+
+	require.Len(t, simulation.DocumentTypes, 3)
+	assert.Equal(t, models.DocTypeContract, simulation.DocumentTypes[0].DocumentType)
+	assert.Equal(t, models.DocTypeInvoice, simulation.DocumentTypes[1].DocumentType)
+	assert.Equal(t, RetentionArchive, simulation.DocumentTypes[1].Rule.Action)
+	assert.Equal(t, models.DocTypeReceipt, simulation.DocumentTypes[2].DocumentType)
+	assert.Equal(t, int64(1), simulation.DocumentTypes[2].Held.Documents)
+}
+
+func TestRetentionCutoffs(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	cutoffs := retentionCutoffs(DocumentRetentionPolicy{
+		Default: &RetentionRule{RetentionYears: 7, Action: RetentionArchive},
+		DocumentTypes: map[models.DocumentType]RetentionRule{
+			models.DocTypeReceipt:   {RetentionYears: 2, Action: RetentionDelete},
+			models.DocTypeMarketing: {Action: RetentionKeep},
+		},
+	}, now)
+
+	require.NotNil(t, cutoffs.Default)
+	assert.Equal(t, now.AddDate(-7, 0, 0), *cutoffs.Default)
+	assert.Equal(t, now.AddDate(-2, 0, 0), cutoffs.ByType[models.DocTypeReceipt])
+	assert.True(t, cutoffs.ByType[models.DocTypeMarketing].IsZero())
+
+	// Keeping by default leaves other types out entirely
+	cutoffs = retentionCutoffs(DocumentRetentionPolicy{Default: &RetentionRule{Action: RetentionKeep}}, now)
+	assert.Nil(t, cutoffs.Default)
+}
+
+func TestDocumentService_SimulateRetentionValidation(t *testing.T) {
+	service := &DocumentService{}
+
+	for _, policy := range []DocumentRetentionPolicy{
+		{},
+		{Default: &RetentionRule{RetentionYears: 7, Action: "shred"}},
+		{Default: &RetentionRule{Action: RetentionDelete}},
+		{DocumentTypes: map[models.DocumentType]RetentionRule{models.DocTypeInvoice: {RetentionYears: 500, Action: RetentionArchive}}},
+		{DocumentTypes: map[models.DocumentType]RetentionRule{"": {RetentionYears: 1, Action: RetentionArchive}}},
+	} {
+		_, err := service.SimulateRetention(context.Background(), uuid.New(), policy)
+		assert.ErrorIs(t, err, ErrInvalidRetentionPolicy)
+	}
+}
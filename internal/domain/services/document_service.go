@@ -14,19 +14,74 @@ import (
 
 	"github.com/archivus/archivus/internal/domain/repositories"
 	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/pkg/logger"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
-	ErrDocumentNotFound    = errors.New("document not found")
-	ErrDocumentExists      = errors.New("document already exists")
-	ErrInvalidDocumentType = errors.New("invalid document type")
-	ErrProcessingFailed    = errors.New("document processing failed")
-	ErrUnauthorizedAccess  = errors.New("unauthorized access to document")
-	ErrDocumentTooLarge    = errors.New("document exceeds maximum size limit")
-	ErrUnsupportedFormat   = errors.New("unsupported document format")
+	ErrDocumentNotFound     = errors.New("document not found")
+	ErrDocumentExists       = errors.New("document already exists")
+	ErrInvalidDocumentType  = errors.New("invalid document type")
+	ErrProcessingFailed     = errors.New("document processing failed")
+	ErrUnauthorizedAccess   = errors.New("unauthorized access to document")
+	ErrDocumentTooLarge     = errors.New("document exceeds maximum size limit")
+	ErrUnsupportedFormat    = errors.New("unsupported document format")
+	ErrFolderAccessDenied   = errors.New("insufficient folder permissions")
+	ErrFolderACLNotFound    = errors.New("folder ACL entry not found")
+	ErrInvalidFolderACL     = errors.New("folder ACL requires a valid permission and exactly one of user or role")
+	ErrDocumentAccessDenied = errors.New("insufficient document permissions")
+	ErrDocumentACLNotFound  = errors.New("document ACL entry not found")
+	ErrInvalidDocumentACL   = errors.New("document ACL requires a valid permission and user")
+	ErrInvalidGrantExpiry   = errors.New("grant expiry must be in the future")
+	ErrFolderNotFound       = errors.New("folder not found")
+	ErrTagNotFound          = errors.New("tag not found")
+	ErrCategoryNotFound     = errors.New("category not found")
+	ErrDocumentOnLegalHold  = errors.New("document is under legal hold")
+	ErrDocumentNotInTrash   = errors.New("document is not in the trash")
+
+	ErrInvalidProcessingPriority = errors.New("processing priority must be high, normal or low")
 )
 
+// ProcessingPriority says how urgently an upload's AI processing should run
+type ProcessingPriority string
+
+const (
+	ProcessingPriorityHigh   ProcessingPriority = "high"   // Someone is waiting on the result
+	ProcessingPriorityNormal ProcessingPriority = "normal" // The default
+	ProcessingPriorityLow    ProcessingPriority = "low"    // Bulk imports and migrations
+)
+
+// processingPriorityJobPriorities maps onto AIProcessingJob.Priority, where lower runs first
+var processingPriorityJobPriorities = map[ProcessingPriority]int{
+	ProcessingPriorityHigh:   1,
+	ProcessingPriorityNormal: 5,
+	ProcessingPriorityLow:    9,
+}
+
+// ParseProcessingPriority validates a requested priority; empty means normal
+func ParseProcessingPriority(value string) (ProcessingPriority, error) {
+	if value == "" {
+		return ProcessingPriorityNormal, nil
+	}
+	priority := ProcessingPriority(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := processingPriorityJobPriorities[priority]; !ok {
+		return "", ErrInvalidProcessingPriority
+	}
+	return priority, nil
+}
+
+// JobPriority returns the AIProcessingJob priority for p, treating unknown values as normal
+func (p ProcessingPriority) JobPriority() int {
+	if priority, ok := processingPriorityJobPriorities[p]; ok {
+		return priority
+	}
+	return processingPriorityJobPriorities[ProcessingPriorityNormal]
+}
+
+// trashPurgeBatchSize is how many trashed documents a purge run reads at a time
+const trashPurgeBatchSize = 100
+
 // DocumentServiceConfig holds configuration for the document service
 type DocumentServiceConfig struct {
 	MaxFileSize            int64 // bytes
@@ -36,23 +91,36 @@ type DocumentServiceConfig struct {
 	PreviewPath            string
 	EnableAIProcessing     bool
 	EnableDuplicateCheck   bool
-	AutoGenerateThumbnails bool
+	AutoGenerateThumbnails bool          // Queue the renditions preview rules generate on upload
+	PreviewRules           []PreviewRule // Rules for tenants without their own; defaults to defaultPreviewRules
+	TrashRetention         time.Duration // How long deleted documents stay restorable; defaults to 30 days
+	TrashPurgeInterval     time.Duration // How often the trash is purged; defaults to an hour
+
+	// RequiredChecks must pass before documents can be downloaded or shared
+	RequiredChecks []models.DocumentCheck
 }
 
 // DocumentService handles all document-related business logic
 type DocumentService struct {
-	docRepo       repositories.DocumentRepository
-	tenantRepo    repositories.TenantRepository
-	userRepo      repositories.UserRepository
-	folderRepo    repositories.FolderRepository
-	tagRepo       repositories.TagRepository
-	categoryRepo  repositories.CategoryRepository
-	auditRepo     repositories.AuditLogRepository
-	aiJobRepo     repositories.AIProcessingJobRepository
-	analyticsRepo repositories.AnalyticsRepository
+	docRepo         repositories.DocumentRepository
+	tenantRepo      repositories.TenantRepository
+	userRepo        repositories.UserRepository
+	folderRepo      repositories.FolderRepository
+	folderACLRepo   repositories.FolderACLRepository
+	docACLRepo      repositories.DocumentACLRepository
+	tagRepo         repositories.TagRepository
+	categoryRepo    repositories.CategoryRepository
+	auditRepo       repositories.AuditLogRepository
+	aiJobRepo       repositories.AIProcessingJobRepository
+	analyticsRepo   repositories.AnalyticsRepository
+	numberingRepo   repositories.NumberingSequenceRepository
+	fingerprintRepo repositories.DocumentFingerprintRepository
+	docTypeRepo     repositories.DocumentTypeRepository
 
 	storageService StorageService
+	cacheService   CacheService
 	aiService      AIService
+	events         EventPublisher
 	config         DocumentServiceConfig
 }
 
@@ -62,28 +130,52 @@ func NewDocumentService(
 	tenantRepo repositories.TenantRepository,
 	userRepo repositories.UserRepository,
 	folderRepo repositories.FolderRepository,
+	folderACLRepo repositories.FolderACLRepository,
+	docACLRepo repositories.DocumentACLRepository,
 	tagRepo repositories.TagRepository,
 	categoryRepo repositories.CategoryRepository,
 	auditRepo repositories.AuditLogRepository,
 	aiJobRepo repositories.AIProcessingJobRepository,
 	analyticsRepo repositories.AnalyticsRepository,
+	numberingRepo repositories.NumberingSequenceRepository,
+	fingerprintRepo repositories.DocumentFingerprintRepository,
+	docTypeRepo repositories.DocumentTypeRepository,
 	storageService StorageService,
+	cacheService CacheService,
 	aiService AIService,
+	events EventPublisher,
 	config DocumentServiceConfig,
 ) *DocumentService {
+	if config.TrashRetention <= 0 {
+		config.TrashRetention = 30 * 24 * time.Hour
+	}
+	if config.TrashPurgeInterval <= 0 {
+		config.TrashPurgeInterval = time.Hour
+	}
+	if config.PreviewRules == nil {
+		config.PreviewRules = defaultPreviewRules
+	}
+
 	return &DocumentService{
-		docRepo:        docRepo,
-		tenantRepo:     tenantRepo,
-		userRepo:       userRepo,
-		folderRepo:     folderRepo,
-		tagRepo:        tagRepo,
-		categoryRepo:   categoryRepo,
-		auditRepo:      auditRepo,
-		aiJobRepo:      aiJobRepo,
-		analyticsRepo:  analyticsRepo,
-		storageService: storageService,
-		aiService:      aiService,
-		config:         config,
+		docRepo:         docRepo,
+		tenantRepo:      tenantRepo,
+		userRepo:        userRepo,
+		folderRepo:      folderRepo,
+		folderACLRepo:   folderACLRepo,
+		docACLRepo:      docACLRepo,
+		tagRepo:         tagRepo,
+		categoryRepo:    categoryRepo,
+		auditRepo:       auditRepo,
+		aiJobRepo:       aiJobRepo,
+		analyticsRepo:   analyticsRepo,
+		numberingRepo:   numberingRepo,
+		fingerprintRepo: fingerprintRepo,
+		docTypeRepo:     docTypeRepo,
+		storageService:  storageService,
+		cacheService:    cacheService,
+		aiService:       aiService,
+		events:          events,
+		config:          config,
 	}
 }
 
@@ -101,6 +193,9 @@ type UploadDocumentParams struct {
 	Categories   []string               `json:"categories,omitempty"`
 	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 
+	// Fields set from a sidecar metadata file, which AI extraction leaves alone
+	SidecarFields []string `json:"-"`
+
 	// Financial document fields
 	Amount       *float64   `json:"amount,omitempty"`
 	Currency     string     `json:"currency,omitempty"`
@@ -114,30 +209,25 @@ type UploadDocumentParams struct {
 	// Processing options
 	EnableAI           bool `json:"enable_ai"`
 	EnableOCR          bool `json:"enable_ocr"`
+	AIDryRun           bool `json:"ai_dry_run"` // Canned AI results, no provider calls
+	SuggestFiling      bool `json:"-"`          // Set from the tenant's settings
 	SkipDuplicateCheck bool `json:"skip_duplicate_check"`
+
+	// ProcessingPriority orders the upload's AI jobs against other uploads; defaults to normal
+	ProcessingPriority ProcessingPriority `json:"processing_priority,omitempty"`
 }
 
 // UploadDocument handles document upload with intelligent processing
-func (s *DocumentService) UploadDocument(ctx context.Context, params UploadDocumentParams) (*models.Document, error) {
-	// 1. Validate tenant and quota
-	quotaStatus, err := s.tenantRepo.CheckQuotaLimits(ctx, params.TenantID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check quota: %w", err)
-	}
+func (s *DocumentService) UploadDocument(ctx context.Context, params UploadDocumentParams) (document *models.Document, err error) {
+	ctx, span := startSpan(ctx, "DocumentService.UploadDocument",
+		attribute.String("tenant.id", params.TenantID.String()))
+	defer func() { endSpan(span, err) }()
 
-	if !quotaStatus.CanUpload {
-		return nil, ErrQuotaExceeded
-	}
-
-	// 2. Validate file
-	if params.File != nil && params.File.Size > s.config.MaxFileSize {
-		return nil, ErrDocumentTooLarge
-	}
-
-	// 3. Validate file type
+	// 0-3. Validate access, quota, file size and type
 	contentType := params.File.Header.Get("Content-Type")
-	if !s.isAllowedMimeType(contentType) {
-		return nil, ErrUnsupportedFormat
+	quotaStatus, err := s.checkUpload(ctx, params, params.File.Size, contentType)
+	if err != nil {
+		return nil, err
 	}
 
 	// 4. Open and read file
@@ -161,50 +251,194 @@ func (s *DocumentService) UploadDocument(ctx context.Context, params UploadDocum
 		}
 	}
 
+	return s.storeUpload(ctx, params, params.File.Filename, contentType, fileContent, quotaStatus)
+}
+
+// UploadFileContent uploads a file already read into memory, for files that
+// don't arrive as a form upload (email attachments)
+func (s *DocumentService) UploadFileContent(ctx context.Context, params UploadDocumentParams, filename, contentType string, content []byte) (document *models.Document, err error) {
+	ctx, span := startSpan(ctx, "DocumentService.UploadFileContent",
+		attribute.String("tenant.id", params.TenantID.String()))
+	defer func() { endSpan(span, err) }()
+
+	quotaStatus, err := s.checkUpload(ctx, params, int64(len(content)), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storeUpload(ctx, params, filename, contentType, content, quotaStatus)
+}
+
+// storeUpload stores a checked upload and creates its document
+func (s *DocumentService) storeUpload(ctx context.Context, params UploadDocumentParams, filename, contentType string, content []byte, quotaStatus *repositories.QuotaStatus) (*models.Document, error) {
+	size := int64(len(content))
+
 	// 5. Calculate content hash for duplicate detection
-	contentHash := s.calculateContentHashFromBytes(fileContent)
+	contentHash := s.calculateContentHashFromBytes(content)
 
 	// 6. Check for duplicates if enabled
-	if s.config.EnableDuplicateCheck && !params.SkipDuplicateCheck {
-		existing, err := s.docRepo.GetByContentHash(ctx, params.TenantID, contentHash)
-		if err == nil && existing != nil {
-			return nil, ErrDocumentExists
-		}
+	if err := s.checkDuplicate(ctx, params, contentHash); err != nil {
+		return nil, err
 	}
 
-	// 7. Auto-detect document type if not provided
-	if params.DocumentType == "" {
-		params.DocumentType = s.detectDocumentType(params.File.Filename, contentType)
+	// 7. Apply the tenant's upload defaults and auto-detect the document type if not provided
+	s.applyUploadDefaults(ctx, &params, filename, contentType)
+
+	// 8. Reserve the file's storage, then store it using bytes reader. The
+	// reservation is what enforces the quota: the check above can race with
+	// concurrent uploads, the conditional update can't.
+	if err := s.reserveUploadStorage(ctx, params.TenantID, size, quotaStatus); err != nil {
+		return nil, err
 	}
 
-	// 8. Store file using bytes reader
 	storagePath, err := s.storageService.Store(ctx, StorageParams{
 		TenantID:    params.TenantID,
-		FileReader:  bytes.NewReader(fileContent),
-		Filename:    params.File.Filename,
+		FileReader:  bytes.NewReader(content),
+		Filename:    filename,
 		ContentType: contentType,
-		Size:        params.File.Size,
+		Size:        size,
 	})
 	if err != nil {
+		s.tenantRepo.ReleaseStorage(ctx, params.TenantID, size)
 		return nil, fmt.Errorf("failed to store file: %w", err)
 	}
 
+	// 9-16. Create the document and start processing it
+	return s.createUploadedDocument(ctx, params, uploadedFile{
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+		StoragePath: storagePath,
+		ContentHash: contentHash,
+	}, quotaStatus)
+}
+
+// uploadedFile is an uploaded file that is already in storage, with its
+// storage reserved against the tenant's quota
+type uploadedFile struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	StoragePath string
+	ContentHash string
+}
+
+// checkUpload checks that the user may upload a file of the given size and
+// type with these parameters, and returns the tenant's quota status
+func (s *DocumentService) checkUpload(ctx context.Context, params UploadDocumentParams, size int64, contentType string) (*repositories.QuotaStatus, error) {
+	if _, ok := processingPriorityJobPriorities[params.ProcessingPriority]; params.ProcessingPriority != "" && !ok {
+		return nil, ErrInvalidProcessingPriority
+	}
+	if params.DocumentType != "" {
+		if err := checkDocumentType(ctx, s.docTypeRepo, params.TenantID, params.DocumentType); err != nil {
+			return nil, err
+		}
+	}
+
+	// Uploading into a folder requires write access to it
+	if params.FolderID != nil {
+		if err := s.CheckFolderAccess(ctx, *params.FolderID, params.TenantID, params.UserID, models.FolderPermWrite); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate tenant and quota
+	quotaStatus, err := s.tenantRepo.CheckQuotaLimits(ctx, params.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check quota: %w", err)
+	}
+
+	if !quotaStatus.CanUpload {
+		s.publishQuotaExceeded(ctx, params.TenantID, "storage", quotaStatus.StorageUsed, quotaStatus.StorageQuota)
+		return nil, ErrQuotaExceeded
+	}
+
+	if !quotaStatus.CanAddDocument {
+		s.publishQuotaExceeded(ctx, params.TenantID, "documents", quotaStatus.DocumentCount, quotaStatus.DocumentQuota)
+		return nil, ErrDocumentQuotaExceeded
+	}
+
+	// Validate file
+	if size > s.config.MaxFileSize {
+		return nil, ErrDocumentTooLarge
+	}
+
+	// Validate file type
+	if !s.isAllowedMimeType(contentType) {
+		return nil, ErrUnsupportedFormat
+	}
+
+	return quotaStatus, nil
+}
+
+// applyUploadDefaults fills in what an upload leaves out from the tenant's
+// settings. Uploads without a type get the one their name suggests, or the
+// tenant's default type when it suggests none; tenants can turn AI
+// processing off for every upload, and filing suggestions on.
+func (s *DocumentService) applyUploadDefaults(ctx context.Context, params *UploadDocumentParams, filename, contentType string) {
+	settings, err := loadTenantSettings(ctx, s.tenantRepo, s.cacheService, params.TenantID)
+	if err != nil {
+		// Log but continue - uploads work without the tenant's defaults
+		settings = resolveTenantSettings(&models.Tenant{})
+	}
+
+	if params.DocumentType == "" {
+		params.DocumentType = s.detectDocumentType(filename, contentType)
+		if params.DocumentType == models.DocTypeGeneral && settings.UploadDefaultDocumentType != "" {
+			params.DocumentType = settings.UploadDefaultDocumentType
+		}
+	}
+	if len(params.Tags) == 0 {
+		params.Tags = settings.UploadDefaultTags
+	}
+	if !settings.AIProcessingEnabled {
+		params.EnableAI = false
+	}
+	params.SuggestFiling = settings.AIFilingSuggestions
+}
+
+func (s *DocumentService) checkDuplicate(ctx context.Context, params UploadDocumentParams, contentHash string) error {
+	if s.config.EnableDuplicateCheck && !params.SkipDuplicateCheck {
+		existing, err := s.docRepo.GetByContentHash(ctx, params.TenantID, contentHash)
+		if err == nil && existing != nil {
+			return ErrDocumentExists
+		}
+	}
+	return nil
+}
+
+func (s *DocumentService) reserveUploadStorage(ctx context.Context, tenantID uuid.UUID, size int64, quotaStatus *repositories.QuotaStatus) error {
+	reserved, err := s.tenantRepo.ReserveStorage(ctx, tenantID, size)
+	if err != nil {
+		return fmt.Errorf("failed to reserve storage quota: %w", err)
+	}
+	if !reserved {
+		s.publishQuotaExceeded(ctx, tenantID, "storage", quotaStatus.StorageUsed, quotaStatus.StorageQuota)
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// createUploadedDocument creates the document for a stored file and starts
+// processing it. On failure the file is deleted and its storage released.
+func (s *DocumentService) createUploadedDocument(ctx context.Context, params UploadDocumentParams, file uploadedFile, quotaStatus *repositories.QuotaStatus) (*models.Document, error) {
 	// 9. Create document record
 	document := &models.Document{
 		ID:           uuid.New(),
 		TenantID:     params.TenantID,
 		FolderID:     params.FolderID,
-		FileName:     s.generateFileName(params.File.Filename),
-		OriginalName: params.File.Filename,
-		ContentType:  contentType,
-		FileSize:     params.File.Size,
-		StoragePath:  storagePath,
-		ContentHash:  contentHash,
+		FileName:     s.generateFileName(file.Filename),
+		OriginalName: file.Filename,
+		ContentType:  file.ContentType,
+		FileSize:     file.Size,
+		StoragePath:  file.StoragePath,
+		ContentHash:  file.ContentHash,
 		Title:        params.Title,
 		Description:  params.Description,
 		DocumentType: params.DocumentType,
 		Status:       models.DocStatusPending,
 		CreatedBy:    params.UserID,
+		OwnerID:      &params.UserID,
 
 		// Financial fields
 		Amount:       params.Amount,
@@ -222,23 +456,52 @@ func (s *DocumentService) UploadDocument(ctx context.Context, params UploadDocum
 
 	// Set default title if not provided
 	if document.Title == "" {
-		document.Title = s.generateTitle(params.File.Filename)
+		document.Title = s.generateTitle(file.Filename)
 	}
 
-	// 10. Save document to database
-	if err := s.docRepo.Create(ctx, document); err != nil {
-		// Cleanup stored file on database error
-		s.storageService.Delete(ctx, storagePath)
-		return nil, fmt.Errorf("failed to create document record: %w", err)
+	if len(params.SidecarFields) > 0 {
+		document.ExtractedData = models.JSONB{documentSidecarFieldsKey: params.SidecarFields}
 	}
 
-	// 11. Update tenant storage usage
-	if err := s.tenantRepo.UpdateUsage(ctx, params.TenantID, params.File.Size, 0); err != nil {
-		// Log but don't fail - this is non-critical
-		// TODO: Add proper logging
+	// Hold the document back until its checks have run
+	for _, check := range s.config.RequiredChecks {
+		switch check {
+		case models.DocCheckVirusScan:
+			document.ScanStatus = models.CheckStatusPending
+		case models.DocCheckDLP:
+			document.DLPStatus = models.CheckStatusPending
+		}
+	}
+
+	// Number the document from its type's sequence. Numbers of uploads
+	// failing after this aren't reused, so sequences can have gaps.
+	if err := s.assignDocumentNumber(ctx, document); err != nil {
+		s.storageService.Delete(ctx, file.StoragePath)
+		s.tenantRepo.ReleaseStorage(ctx, params.TenantID, file.Size)
+		return nil, err
+	}
+
+	// 10. Reserve a document slot, then save document to database
+	reserved, err := s.tenantRepo.ReserveDocumentSlots(ctx, params.TenantID, 1)
+	if err != nil || !reserved {
+		s.storageService.Delete(ctx, file.StoragePath)
+		s.tenantRepo.ReleaseStorage(ctx, params.TenantID, file.Size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve document quota: %w", err)
+		}
+		s.publishQuotaExceeded(ctx, params.TenantID, "documents", quotaStatus.DocumentQuota, quotaStatus.DocumentQuota)
+		return nil, ErrDocumentQuotaExceeded
 	}
 
-	// 12. Process tags and categories
+	if err := s.docRepo.Create(ctx, document); err != nil {
+		// Cleanup stored file and reservations on database error
+		s.storageService.Delete(ctx, file.StoragePath)
+		s.tenantRepo.ReleaseStorage(ctx, params.TenantID, file.Size)
+		s.tenantRepo.ReleaseDocumentSlots(ctx, params.TenantID, 1)
+		return nil, fmt.Errorf("failed to create document record: %w", err)
+	}
+
+	// 11. Process tags and categories
 	if err := s.processTags(ctx, document.ID, params.TenantID, params.Tags); err != nil {
 		// Log but don't fail - this is non-critical
 	}
@@ -247,41 +510,117 @@ func (s *DocumentService) UploadDocument(ctx context.Context, params UploadDocum
 		// Log but don't fail - this is non-critical
 	}
 
-	// 13. Queue AI processing if enabled
+	// 12. Queue AI processing if enabled
 	if params.EnableAI && s.config.EnableAIProcessing {
-		if err := s.queueAIProcessing(ctx, document, params.EnableOCR); err != nil {
+		if err := s.queueAIProcessing(ctx, document, params.EnableOCR, params.SuggestFiling, params.AIDryRun, params.ProcessingPriority); err != nil {
 			// Log but don't fail - AI processing is optional
 		}
 	}
 
-	// 14. Generate thumbnails if enabled
+	// 13. Queue thumbnails and previews the tenant's rules generate on upload
 	if s.config.AutoGenerateThumbnails {
-		if err := s.generateThumbnail(ctx, document); err != nil {
+		if err := s.queueRenditions(ctx, document, params.ProcessingPriority); err != nil {
 			// Log but don't fail - thumbnails are optional
 		}
 	}
 
-	// 15. Create audit log
+	// 14. Create audit log
 	s.createAuditLog(ctx, params.TenantID, params.UserID, document.ID, models.AuditCreate, "Document uploaded")
 
-	// 16. Create analytics record
+	// 15. Create analytics record
 	s.analyticsRepo.CreateDocumentAnalytics(ctx, &models.DocumentAnalytics{
 		TenantID:   params.TenantID,
 		DocumentID: document.ID,
 	})
 
+	// 16. Notify webhook subscribers
+	s.publishEvent(ctx, params.TenantID, WebhookEventDocumentUploaded, document, params.UserID)
+
+	return document, nil
+}
+
+// ReplaceFileContent stores content as the document's new version, keeping
+// the current file as a version. It's how importers bring in files that
+// changed at their source.
+func (s *DocumentService) ReplaceFileContent(ctx context.Context, documentID, tenantID, userID uuid.UUID, contentType string, content []byte, changes string) (*models.Document, error) {
+	document, err := s.getTenantDocument(ctx, documentID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if document.LegalHold {
+		return nil, ErrDocumentOnLegalHold
+	}
+
+	size := int64(len(content))
+	if size > s.config.MaxFileSize {
+		return nil, ErrDocumentTooLarge
+	}
+	if !s.isAllowedMimeType(contentType) {
+		return nil, ErrUnsupportedFormat
+	}
+
+	contentHash := s.calculateContentHashFromBytes(content)
+	if contentHash == document.ContentHash {
+		return document, nil
+	}
+
+	quotaStatus, err := s.tenantRepo.CheckQuotaLimits(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check quota: %w", err)
+	}
+	if !quotaStatus.CanUpload {
+		s.publishQuotaExceeded(ctx, tenantID, "storage", quotaStatus.StorageUsed, quotaStatus.StorageQuota)
+		return nil, ErrQuotaExceeded
+	}
+	// The current file is kept as a version, so the new one adds to usage
+	if err := s.reserveUploadStorage(ctx, tenantID, size, quotaStatus); err != nil {
+		return nil, err
+	}
+
+	storagePath, err := s.storageService.Store(ctx, StorageParams{
+		TenantID:    tenantID,
+		FileReader:  bytes.NewReader(content),
+		Filename:    document.FileName,
+		ContentType: document.ContentType,
+		Size:        size,
+	})
+	if err != nil {
+		s.tenantRepo.ReleaseStorage(ctx, tenantID, size)
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	file := repositories.DocumentFile{StoragePath: storagePath, FileSize: size, ContentHash: contentHash}
+	replaced, err := s.docRepo.ReplaceFile(ctx, document, file, changes, userID)
+	if err != nil || !replaced {
+		s.storageService.Delete(context.WithoutCancel(ctx), storagePath)
+		s.tenantRepo.ReleaseStorage(context.WithoutCancel(ctx), tenantID, size)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrDocumentNotFound // Replaced or deleted meanwhile
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, document.ID, models.AuditUpdate,
+		fmt.Sprintf("Document file replaced (version %d): %s", document.Version+1, changes))
+
+	document.StoragePath = file.StoragePath
+	document.FileSize = file.FileSize
+	document.ContentHash = file.ContentHash
+	document.Version++
 	return document, nil
 }
 
 // GetDocument retrieves a document with access control
 func (s *DocumentService) GetDocument(ctx context.Context, documentID, tenantID, userID uuid.UUID) (*models.Document, error) {
-	document, err := s.docRepo.GetByID(ctx, documentID)
-	if err != nil {
+	// Documents of other tenants don't exist as far as the caller knows, and
+	// trashed ones only through the trash
+	document, err := s.docRepo.GetForTenant(ctx, tenantID, documentID)
+	if err != nil || document.DeletedAt != nil {
 		return nil, ErrDocumentNotFound
 	}
 
-	// Verify tenant access
-	if document.TenantID != tenantID {
+	// Verify private document and folder ACLs
+	if err := s.CheckDocumentAccess(ctx, document, userID, models.DocPermRead); err != nil {
 		return nil, ErrUnauthorizedAccess
 	}
 
@@ -294,19 +633,45 @@ func (s *DocumentService) GetDocument(ctx context.Context, documentID, tenantID,
 	return document, nil
 }
 
-// ListDocuments lists documents with filtering and pagination
-func (s *DocumentService) ListDocuments(ctx context.Context, tenantID uuid.UUID, filters repositories.DocumentFilters) ([]models.Document, int64, error) {
+// ListDocuments lists documents with filtering and pagination, hiding documents
+// in folders the user is not allowed to read and private documents they were
+// not granted
+func (s *DocumentService) ListDocuments(ctx context.Context, tenantID, userID uuid.UUID, filters repositories.DocumentFilters) ([]models.Document, int64, error) {
+	denied, viewerID, err := s.documentVisibility(ctx, tenantID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filters.ExcludeFolderIDs = append(filters.ExcludeFolderIDs, denied...)
+	filters.ViewerID = viewerID
+
 	return s.docRepo.List(ctx, tenantID, filters)
 }
 
-// SearchDocuments performs intelligent document search
-func (s *DocumentService) SearchDocuments(ctx context.Context, tenantID uuid.UUID, query repositories.SearchQuery) ([]models.Document, error) {
+// SearchDocuments performs intelligent document search, applying the same
+// visibility rules as ListDocuments
+func (s *DocumentService) SearchDocuments(ctx context.Context, tenantID, userID uuid.UUID, query repositories.SearchQuery) ([]models.Document, error) {
+	denied, viewerID, err := s.documentVisibility(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	query.ExcludeFolderIDs = append(query.ExcludeFolderIDs, denied...)
+	query.ViewerID = viewerID
+
 	// First try semantic search if query is complex
 	if len(query.Query) > 10 && s.aiService != nil {
 		if embedding, err := s.aiService.GenerateEmbedding(ctx, query.Query); err == nil {
 			results, err := s.docRepo.SemanticSearch(ctx, tenantID, embedding, query.Limit)
 			if err == nil && len(results) > 0 {
-				return results, nil
+				// Semantic search is not access-aware, so filter its results here
+				visible := make([]models.Document, 0, len(results))
+				for i := range results {
+					if s.CheckDocumentAccess(ctx, &results[i], userID, models.DocPermRead) == nil {
+						visible = append(visible, results[i])
+					}
+				}
+				if len(visible) > 0 {
+					return visible, nil
+				}
 			}
 		}
 	}
@@ -316,23 +681,28 @@ func (s *DocumentService) SearchDocuments(ctx context.Context, tenantID uuid.UUI
 }
 
 // ProcessFinancialDocument extracts financial data using AI
-func (s *DocumentService) ProcessFinancialDocument(ctx context.Context, documentID uuid.UUID, userID uuid.UUID) error {
-	document, err := s.docRepo.GetByID(ctx, documentID)
+func (s *DocumentService) ProcessFinancialDocument(ctx context.Context, documentID, tenantID, userID uuid.UUID) error {
+	document, err := s.getTenantDocument(ctx, documentID, tenantID)
 	if err != nil {
-		return ErrDocumentNotFound
+		return err
+	}
+	if err := s.CheckDocumentAccess(ctx, document, userID, models.DocPermWrite); err != nil {
+		return err
 	}
 
 	// Only process financial document types
-	if !s.isFinancialDocument(document.DocumentType) {
+	if !s.isFinancialDocument(ctx, document) {
 		return ErrInvalidDocumentType
 	}
 
 	// Queue specialized financial AI processing
 	job := &models.AIProcessingJob{
-		TenantID:   document.TenantID,
-		DocumentID: document.ID,
-		JobType:    "financial_extraction",
-		Priority:   3, // Higher priority for financial docs
+		TenantID:    document.TenantID,
+		DocumentID:  document.ID,
+		JobType:     "financial_extraction",
+		Priority:    3, // Higher priority for financial docs
+		TraceParent: traceParent(ctx),
+		RequestID:   logger.RequestIDFromContext(ctx),
 	}
 
 	if err := s.aiJobRepo.Create(ctx, job); err != nil {
@@ -345,15 +715,36 @@ func (s *DocumentService) ProcessFinancialDocument(ctx context.Context, document
 		return fmt.Errorf("failed to update document status: %w", err)
 	}
 
+	if s.events != nil {
+		s.events.Publish(ctx, document.TenantID, RealtimeEventDocumentStatusChanged, map[string]interface{}{
+			"document_id": document.ID.String(),
+			"status":      string(document.Status),
+		})
+	}
+
 	// Create audit log
 	s.createAuditLog(ctx, document.TenantID, userID, document.ID, models.AuditUpdate, "Financial processing initiated")
 
 	return nil
 }
 
-// FindDuplicates identifies potential duplicate documents
+// FindDuplicates identifies potential duplicate documents: identical files,
+// then re-scanned or slightly edited copies whose fingerprints are at least
+// threshold similar. Each pair is reported once, by its closest match.
 func (s *DocumentService) FindDuplicates(ctx context.Context, tenantID uuid.UUID, threshold float64) ([]repositories.DocumentDuplicate, error) {
-	return s.docRepo.GetDuplicates(ctx, tenantID, threshold)
+	duplicates, err := s.docRepo.GetDuplicates(ctx, tenantID, threshold)
+	if err != nil {
+		return nil, err
+	}
+	if s.fingerprintRepo == nil {
+		return duplicates, nil
+	}
+
+	near, err := s.fingerprintRepo.NearDuplicates(ctx, tenantID, fingerprintMaxDistance(threshold), maxNearDuplicates)
+	if err != nil {
+		return nil, err
+	}
+	return mergeDuplicates(duplicates, near), nil
 }
 
 // GetExpiringDocuments finds documents nearing expiration
@@ -362,10 +753,13 @@ func (s *DocumentService) GetExpiringDocuments(ctx context.Context, tenantID uui
 }
 
 // UpdateDocument updates document metadata and handles versioning
-func (s *DocumentService) UpdateDocument(ctx context.Context, documentID uuid.UUID, updates map[string]interface{}, userID uuid.UUID) (*models.Document, error) {
-	document, err := s.docRepo.GetByID(ctx, documentID)
+func (s *DocumentService) UpdateDocument(ctx context.Context, documentID, tenantID uuid.UUID, updates map[string]interface{}, userID uuid.UUID) (*models.Document, error) {
+	document, err := s.getTenantDocument(ctx, documentID, tenantID)
 	if err != nil {
-		return nil, ErrDocumentNotFound
+		return nil, err
+	}
+	if err := s.CheckDocumentAccess(ctx, document, userID, models.DocPermWrite); err != nil {
+		return nil, err
 	}
 
 	// Apply updates
@@ -375,7 +769,15 @@ func (s *DocumentService) UpdateDocument(ctx context.Context, documentID uuid.UU
 	if description, ok := updates["description"].(string); ok {
 		document.Description = description
 	}
-	if docType, ok := updates["document_type"].(models.DocumentType); ok {
+	// Updates decoded from JSON carry the type as a string
+	docType, ok := updates["document_type"].(models.DocumentType)
+	if value, isString := updates["document_type"].(string); isString {
+		docType, ok = models.DocumentType(value), true
+	}
+	if ok && docType != document.DocumentType {
+		if err := checkDocumentType(ctx, s.docTypeRepo, tenantID, docType); err != nil {
+			return nil, err
+		}
 		document.DocumentType = docType
 	}
 
@@ -403,11 +805,18 @@ func (s *DocumentService) UpdateDocument(ctx context.Context, documentID uuid.UU
 	return document, nil
 }
 
-// DeleteDocument soft deletes a document
-func (s *DocumentService) DeleteDocument(ctx context.Context, documentID, userID uuid.UUID) error {
-	document, err := s.docRepo.GetByID(ctx, documentID)
+// DeleteDocument moves a document to the trash, where it can be restored
+// until it's purged after the trash retention period
+func (s *DocumentService) DeleteDocument(ctx context.Context, documentID, tenantID, userID uuid.UUID) error {
+	document, err := s.getTenantDocument(ctx, documentID, tenantID)
 	if err != nil {
-		return ErrDocumentNotFound
+		return err
+	}
+	if err := s.CheckDocumentAccess(ctx, document, userID, models.DocPermWrite); err != nil {
+		return err
+	}
+	if document.LegalHold {
+		return ErrDocumentOnLegalHold
 	}
 
 	// Soft delete the document
@@ -415,17 +824,46 @@ func (s *DocumentService) DeleteDocument(ctx context.Context, documentID, userID
 		return fmt.Errorf("failed to delete document: %w", err)
 	}
 
-	// Update tenant storage usage
-	s.tenantRepo.UpdateUsage(ctx, document.TenantID, -document.FileSize, 0)
+	// Update tenant storage usage and document count
+	s.tenantRepo.ReleaseStorage(ctx, document.TenantID, document.FileSize)
+	s.tenantRepo.ReleaseDocumentSlots(ctx, document.TenantID, 1)
 
 	// Create audit log
 	s.createAuditLog(ctx, document.TenantID, userID, documentID, models.AuditDelete, "Document deleted")
 
+	s.publishEvent(ctx, document.TenantID, WebhookEventDocumentDeleted, document, userID)
+
 	return nil
 }
 
 // Helper methods
 
+func (s *DocumentService) publishEvent(ctx context.Context, tenantID uuid.UUID, eventType string, document *models.Document, userID uuid.UUID) {
+	if s.events == nil {
+		return
+	}
+	s.events.Publish(ctx, tenantID, eventType, map[string]interface{}{
+		"document_id":  document.ID.String(),
+		"title":        document.Title,
+		"file_name":    document.FileName,
+		"content_type": document.ContentType,
+		"file_size":    document.FileSize,
+		"user_id":      userID.String(),
+	})
+}
+
+// publishQuotaExceeded tells billing subscribers an upload was refused for quota
+func (s *DocumentService) publishQuotaExceeded(ctx context.Context, tenantID uuid.UUID, quota string, used, limit int64) {
+	if s.events == nil {
+		return
+	}
+	s.events.Publish(ctx, tenantID, WebhookEventQuotaExceeded, map[string]interface{}{
+		"quota": quota,
+		"used":  used,
+		"limit": limit,
+	})
+}
+
 func (s *DocumentService) isAllowedMimeType(contentType string) bool {
 	if len(s.config.AllowedMimeTypes) == 0 {
 		return true // Allow all if not specified
@@ -553,23 +991,34 @@ func (s *DocumentService) processCategories(ctx context.Context, documentID, ten
 	return s.docRepo.AssociateCategories(ctx, documentID, categoryIDs)
 }
 
-func (s *DocumentService) queueAIProcessing(ctx context.Context, document *models.Document, enableOCR bool) error {
+func (s *DocumentService) queueAIProcessing(ctx context.Context, document *models.Document, enableOCR, suggestFiling, dryRun bool, priority ProcessingPriority) error {
 	jobs := []string{"text_extraction", "categorization", "tagging"}
 
 	if enableOCR {
 		jobs = append(jobs, "ocr")
 	}
 
-	if s.isFinancialDocument(document.DocumentType) {
+	if s.isFinancialDocument(ctx, document) {
 		jobs = append(jobs, "financial_extraction")
 	}
 
+	// Uploads filed in a folder already need no suggestion
+	if suggestFiling && document.FolderID == nil {
+		jobs = append(jobs, "filing_suggestion")
+	}
+
+	// Fingerprinted once the text is extracted, for near-duplicate detection
+	jobs = append(jobs, "fingerprint")
+
 	for _, jobType := range jobs {
 		job := &models.AIProcessingJob{
-			TenantID:   document.TenantID,
-			DocumentID: document.ID,
-			JobType:    jobType,
-			Priority:   5,
+			TenantID:    document.TenantID,
+			DocumentID:  document.ID,
+			JobType:     jobType,
+			Priority:    priority.JobPriority(),
+			DryRun:      dryRun,
+			TraceParent: traceParent(ctx),
+			RequestID:   logger.RequestIDFromContext(ctx),
 		}
 
 		if err := s.aiJobRepo.Create(ctx, job); err != nil {
@@ -580,13 +1029,10 @@ func (s *DocumentService) queueAIProcessing(ctx context.Context, document *model
 	return nil
 }
 
-func (s *DocumentService) generateThumbnail(ctx context.Context, document *models.Document) error {
-	// TODO: Implement thumbnail generation
-	// This would use image processing libraries or external services
-	return nil
-}
-
-func (s *DocumentService) isFinancialDocument(docType models.DocumentType) bool {
+// isFinancialDocument reports whether a document's type, or the built-in type
+// it maps to, holds financial data
+func (s *DocumentService) isFinancialDocument(ctx context.Context, document *models.Document) bool {
+	docType := builtinDocumentType(ctx, s.docTypeRepo, document.TenantID, document.DocumentType)
 	financial := []models.DocumentType{
 		models.DocTypeInvoice,
 		models.DocTypeReceipt,
@@ -603,6 +1049,26 @@ func (s *DocumentService) isFinancialDocument(docType models.DocumentType) bool
 	return false
 }
 
+// assignDocumentNumber numbers the document when the tenant has an active
+// sequence for its type and the upload didn't bring a number of its own
+func (s *DocumentService) assignDocumentNumber(ctx context.Context, document *models.Document) error {
+	if s.numberingRepo == nil || document.DocumentNumber != "" {
+		return nil
+	}
+	sequence, err := s.numberingRepo.GetByDocumentType(ctx, document.TenantID, document.DocumentType)
+	if err != nil || !sequence.IsActive {
+		return nil
+	}
+
+	number, err := allocateDocumentNumber(ctx, s.numberingRepo, sequence, time.Now())
+	if err != nil {
+		return err
+	}
+	document.DocumentNumber = number
+	document.NumberingSequenceID = &sequence.ID
+	return nil
+}
+
 func (s *DocumentService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
 	log := &models.AuditLog{
 		TenantID:     tenantID,
@@ -615,7 +1081,7 @@ func (s *DocumentService) createAuditLog(ctx context.Context, tenantID, userID,
 
 	// Don't block on audit log creation
 	go func() {
-		s.auditRepo.Create(context.Background(), log)
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
 	}()
 }
 
@@ -643,14 +1109,11 @@ func (s *DocumentService) CreateFolder(ctx context.Context, tenantID, userID uui
 	level := 0
 
 	if parentID != nil {
-		// Get parent folder and validate it belongs to the same tenant
-		parent, err := s.folderRepo.GetByID(ctx, *parentID)
+		// Get parent folder; it must belong to the same tenant
+		parent, err := s.folderRepo.GetForTenant(ctx, tenantID, *parentID)
 		if err != nil {
 			return nil, fmt.Errorf("parent folder not found: %w", err)
 		}
-		if parent.TenantID != tenantID {
-			return nil, fmt.Errorf("parent folder belongs to different tenant")
-		}
 
 		// Check for name conflicts in the same parent
 		if existingFolder, err := s.folderRepo.GetByPath(ctx, tenantID, parent.Path+"/"+name); err == nil && existingFolder != nil {
@@ -689,6 +1152,7 @@ func (s *DocumentService) CreateFolder(ctx context.Context, tenantID, userID uui
 		Color:       color,
 		Icon:        icon,
 		CreatedBy:   userID,
+		OwnerID:     &userID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -705,14 +1169,10 @@ func (s *DocumentService) CreateFolder(ctx context.Context, tenantID, userID uui
 
 // GetFolder retrieves a folder with access control
 func (s *DocumentService) GetFolder(ctx context.Context, folderID, tenantID uuid.UUID) (*models.Folder, error) {
-	folder, err := s.folderRepo.GetByID(ctx, folderID)
+	// Other tenants' folders are reported as missing
+	folder, err := s.folderRepo.GetForTenant(ctx, tenantID, folderID)
 	if err != nil {
-		return nil, fmt.Errorf("folder not found")
-	}
-
-	// Verify tenant access
-	if folder.TenantID != tenantID {
-		return nil, fmt.Errorf("unauthorized access to folder")
+		return nil, ErrFolderNotFound
 	}
 
 	return folder, nil
@@ -853,7 +1313,7 @@ func (s *DocumentService) MoveFolder(ctx context.Context, folderID, newParentID,
 	// Validate new parent exists and belongs to same tenant
 	newParent, err := s.GetFolder(ctx, newParentID, tenantID)
 	if err != nil {
-		return nil, fmt.Errorf("new parent folder not found")
+		return nil, fmt.Errorf("new parent %w", err)
 	}
 
 	// Prevent moving folder to itself or its descendant
@@ -896,7 +1356,12 @@ func (s *DocumentService) GetFolderDocuments(ctx context.Context, folderID, tena
 }
 
 // GetFolderChildren gets immediate child folders
-func (s *DocumentService) GetFolderChildren(ctx context.Context, folderID uuid.UUID) ([]models.Folder, error) {
+func (s *DocumentService) GetFolderChildren(ctx context.Context, folderID, tenantID uuid.UUID) ([]models.Folder, error) {
+	// Verify folder access
+	if _, err := s.GetFolder(ctx, folderID, tenantID); err != nil {
+		return nil, err
+	}
+
 	return s.folderRepo.GetChildren(ctx, folderID)
 }
 
@@ -914,28 +1379,654 @@ func (s *DocumentService) populateDocumentCount(ctx context.Context, node *repos
 	}
 }
 
-// TAG MANAGEMENT METHODS
+// FOLDER ACCESS CONTROL METHODS
+//
+// Folders without ACL entries on themselves or any ancestor are open to every
+// user in the tenant. Once an ACL exists in a folder's ancestry, only matching
+// users/roles get access, at the highest level granted anywhere up the chain.
+// Admins always have full access.
+
+// GrantFolderAccessParams contains parameters for granting folder access
+type GrantFolderAccessParams struct {
+	TenantID   uuid.UUID               `json:"tenant_id"`
+	FolderID   uuid.UUID               `json:"folder_id"`
+	UserID     *uuid.UUID              `json:"user_id,omitempty"`
+	Role       models.UserRole         `json:"role,omitempty"`
+	Permission models.FolderPermission `json:"permission"`
+	GrantedBy  uuid.UUID               `json:"granted_by"`
+	ExpiresAt  *time.Time              `json:"expires_at,omitempty"`
+}
 
-// CreateTag creates a new tag with validation
-func (s *DocumentService) CreateTag(ctx context.Context, tenantID, userID uuid.UUID, name, color string) (*models.Tag, error) {
-	// Validate name
-	if strings.TrimSpace(name) == "" {
-		return nil, fmt.Errorf("tag name cannot be empty")
+// GetFolderPermission returns the effective permission a user holds on a folder.
+// An empty permission means no access.
+func (s *DocumentService) GetFolderPermission(ctx context.Context, folderID, tenantID, userID uuid.UUID) (models.FolderPermission, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+	if user.TenantID != tenantID {
+		return "", ErrUnauthorizedAccess
+	}
+	if user.Role == models.UserRoleAdmin {
+		return models.FolderPermManage, nil
 	}
 
-	// Check for duplicate names in tenant
-	if existingTag, err := s.tagRepo.GetByName(ctx, tenantID, name); err == nil && existingTag != nil {
-		return nil, fmt.Errorf("tag with name '%s' already exists", name)
+	acls, err := s.folderACLRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+	if len(acls) == 0 {
+		return models.FolderPermManage, nil
 	}
 
-	// Set defaults
-	if color == "" {
-		color = "#6B7280"
+	// Walk up the hierarchy to collect the folder's ancestry
+	parents := make(map[uuid.UUID]*uuid.UUID)
+	for current := &folderID; current != nil; {
+		if _, seen := parents[*current]; seen {
+			break
+		}
+		folder, err := s.folderRepo.GetForTenant(ctx, tenantID, *current)
+		if err != nil {
+			return "", ErrFolderNotFound
+		}
+		parents[folder.ID] = folder.ParentID
+		current = folder.ParentID
 	}
 
-	// Create tag
-	tag := &models.Tag{
-		ID:            uuid.New(),
+	return resolveFolderPermission(folderID, parents, groupFolderACLs(acls), user), nil
+}
+
+// CheckFolderAccess returns ErrFolderAccessDenied unless the user holds at least
+// the required permission on the folder
+func (s *DocumentService) CheckFolderAccess(ctx context.Context, folderID, tenantID, userID uuid.UUID, required models.FolderPermission) error {
+	permission, err := s.GetFolderPermission(ctx, folderID, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if folderPermissionRank(permission) < folderPermissionRank(required) {
+		return ErrFolderAccessDenied
+	}
+	return nil
+}
+
+// UnreadableFolderIDs lists the folders whose contents must be hidden from a user
+func (s *DocumentService) UnreadableFolderIDs(ctx context.Context, tenantID, userID uuid.UUID) ([]uuid.UUID, error) {
+	return s.foldersBelow(ctx, tenantID, userID, models.FolderPermRead)
+}
+
+// UnwritableFolderIDs lists the folders whose contents a user can't change
+func (s *DocumentService) UnwritableFolderIDs(ctx context.Context, tenantID, userID uuid.UUID) ([]uuid.UUID, error) {
+	return s.foldersBelow(ctx, tenantID, userID, models.FolderPermWrite)
+}
+
+// foldersBelow lists the folders on which a user holds less than the required
+// permission
+func (s *DocumentService) foldersBelow(ctx context.Context, tenantID, userID uuid.UUID, required models.FolderPermission) ([]uuid.UUID, error) {
+	acls, err := s.folderACLRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(acls) == 0 {
+		return nil, nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Role == models.UserRoleAdmin {
+		return nil, nil
+	}
+
+	folders, err := s.folderRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[uuid.UUID]*uuid.UUID, len(folders))
+	for _, folder := range folders {
+		parents[folder.ID] = folder.ParentID
+	}
+
+	aclsByFolder := groupFolderACLs(acls)
+	var denied []uuid.UUID
+	for _, folder := range folders {
+		if folderPermissionRank(resolveFolderPermission(folder.ID, parents, aclsByFolder, user)) < folderPermissionRank(required) {
+			denied = append(denied, folder.ID)
+		}
+	}
+
+	return denied, nil
+}
+
+// ListFolderACL lists the ACL entries set directly on a folder
+func (s *DocumentService) ListFolderACL(ctx context.Context, folderID, tenantID uuid.UUID) ([]models.FolderACL, error) {
+	if _, err := s.GetFolder(ctx, folderID, tenantID); err != nil {
+		return nil, err
+	}
+	return s.folderACLRepo.ListByFolder(ctx, folderID)
+}
+
+// GrantFolderAccess grants (or changes) a user's or role's permission on a folder
+func (s *DocumentService) GrantFolderAccess(ctx context.Context, params GrantFolderAccessParams) (*models.FolderACL, error) {
+	if folderPermissionRank(params.Permission) == 0 || (params.UserID == nil) == (params.Role == "") {
+		return nil, ErrInvalidFolderACL
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidGrantExpiry
+	}
+
+	if _, err := s.GetFolder(ctx, params.FolderID, params.TenantID); err != nil {
+		return nil, err
+	}
+
+	if params.UserID != nil {
+		grantee, err := s.userRepo.GetByID(ctx, *params.UserID)
+		if err != nil || grantee.TenantID != params.TenantID {
+			return nil, ErrUserNotFound
+		}
+	}
+
+	existing, err := s.folderACLRepo.ListByFolder(ctx, params.FolderID)
+	if err != nil {
+		return nil, err
+	}
+
+	acl := &models.FolderACL{
+		TenantID:   params.TenantID,
+		FolderID:   params.FolderID,
+		UserID:     params.UserID,
+		Role:       params.Role,
+		Permission: params.Permission,
+		GrantedBy:  params.GrantedBy,
+		ExpiresAt:  params.ExpiresAt,
+	}
+	if err := s.folderACLRepo.Upsert(ctx, acl); err != nil {
+		return nil, err
+	}
+
+	// The first grant restricts the folder; keep the granting user from locking
+	// themselves out unless they are an admin (who always has access). A first
+	// grant that is temporary also gets a permanent anchor so the folder stays
+	// restricted once the grant expires.
+	if len(existing) == 0 && (params.UserID == nil || *params.UserID != params.GrantedBy) {
+		if granter, err := s.userRepo.GetByID(ctx, params.GrantedBy); err == nil && (granter.Role != models.UserRoleAdmin || params.ExpiresAt != nil) {
+			if err := s.folderACLRepo.Upsert(ctx, &models.FolderACL{
+				TenantID:   params.TenantID,
+				FolderID:   params.FolderID,
+				UserID:     &params.GrantedBy,
+				Permission: models.FolderPermManage,
+				GrantedBy:  params.GrantedBy,
+			}); err != nil {
+				// Log but don't fail
+			}
+		}
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.GrantedBy, params.FolderID, models.AuditUpdate,
+		fmt.Sprintf("Folder access granted: %s%s", params.Permission, grantExpirySuffix(params.ExpiresAt)))
+
+	return acl, nil
+}
+
+// RevokeFolderAccess removes an ACL entry from a folder
+func (s *DocumentService) RevokeFolderAccess(ctx context.Context, folderID, aclID, tenantID, userID uuid.UUID) error {
+	acl, err := s.folderACLRepo.GetByID(ctx, aclID)
+	if err != nil {
+		return ErrFolderACLNotFound
+	}
+	if acl.FolderID != folderID || acl.TenantID != tenantID {
+		return ErrFolderACLNotFound
+	}
+
+	if err := s.folderACLRepo.Delete(ctx, aclID); err != nil {
+		return err
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, folderID, models.AuditUpdate, "Folder access revoked")
+
+	return nil
+}
+
+// resolveFolderPermission computes a user's permission on a folder from the ACLs
+// on the folder and its ancestors
+func resolveFolderPermission(folderID uuid.UUID, parents map[uuid.UUID]*uuid.UUID, aclsByFolder map[uuid.UUID][]models.FolderACL, user *models.User) models.FolderPermission {
+	restricted := false
+	best := 0
+	visited := make(map[uuid.UUID]bool)
+
+	now := time.Now()
+
+	for current := &folderID; current != nil && !visited[*current]; current = parents[*current] {
+		visited[*current] = true
+		for _, acl := range aclsByFolder[*current] {
+			// Expired grants still restrict the folder but no longer grant access
+			restricted = true
+			if acl.IsExpired(now) {
+				continue
+			}
+			matches := (acl.UserID != nil && *acl.UserID == user.ID) || (acl.UserID == nil && acl.Role == user.Role)
+			if matches && folderPermissionRank(acl.Permission) > best {
+				best = folderPermissionRank(acl.Permission)
+			}
+		}
+	}
+
+	if !restricted {
+		return models.FolderPermManage
+	}
+
+	switch best {
+	case 3:
+		return models.FolderPermManage
+	case 2:
+		return models.FolderPermWrite
+	case 1:
+		return models.FolderPermRead
+	default:
+		return ""
+	}
+}
+
+func grantExpirySuffix(expiresAt *time.Time) string {
+	if expiresAt == nil {
+		return ""
+	}
+	return " until " + expiresAt.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+func folderPermissionRank(permission models.FolderPermission) int {
+	switch permission {
+	case models.FolderPermRead:
+		return 1
+	case models.FolderPermWrite:
+		return 2
+	case models.FolderPermManage:
+		return 3
+	default:
+		return 0
+	}
+}
+
+func groupFolderACLs(acls []models.FolderACL) map[uuid.UUID][]models.FolderACL {
+	grouped := make(map[uuid.UUID][]models.FolderACL)
+	for _, acl := range acls {
+		grouped[acl.FolderID] = append(grouped[acl.FolderID], acl)
+	}
+	return grouped
+}
+
+// DOCUMENT ACCESS CONTROL METHODS
+//
+// A private document is visible only to its creator, tenant admins and users
+// holding an explicit DocumentACL grant. Folder ACLs still apply on top of
+// that, so a grant never exposes a document inside a folder the user cannot read.
+
+// GrantDocumentAccessParams contains parameters for granting document access
+type GrantDocumentAccessParams struct {
+	TenantID   uuid.UUID                 `json:"tenant_id"`
+	DocumentID uuid.UUID                 `json:"document_id"`
+	UserID     uuid.UUID                 `json:"user_id"`
+	Permission models.DocumentPermission `json:"permission"`
+	GrantedBy  uuid.UUID                 `json:"granted_by"`
+	ExpiresAt  *time.Time                `json:"expires_at,omitempty"`
+}
+
+// CheckDocumentAccess returns ErrDocumentAccessDenied unless the user may access
+// the document at the required level
+func (s *DocumentService) CheckDocumentAccess(ctx context.Context, document *models.Document, userID uuid.UUID, required models.DocumentPermission) error {
+	if document.IsPrivate && document.Owner() != userID {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil || user.TenantID != document.TenantID {
+			return ErrDocumentAccessDenied
+		}
+		if user.Role != models.UserRoleAdmin {
+			acl, err := s.docACLRepo.GetForUser(ctx, document.ID, userID)
+			if err != nil || acl.IsExpired(time.Now()) || documentPermissionRank(acl.Permission) < documentPermissionRank(required) {
+				return ErrDocumentAccessDenied
+			}
+		}
+	}
+
+	if document.FolderID != nil {
+		folderPerm := models.FolderPermRead
+		if required == models.DocPermWrite {
+			folderPerm = models.FolderPermWrite
+		}
+		if err := s.CheckFolderAccess(ctx, *document.FolderID, document.TenantID, userID, folderPerm); err != nil {
+			return ErrDocumentAccessDenied
+		}
+	}
+
+	return nil
+}
+
+// SetDocumentPrivacy marks a document private or public. Only the creator or
+// an admin may change it.
+func (s *DocumentService) SetDocumentPrivacy(ctx context.Context, documentID, tenantID, userID uuid.UUID, private bool) (*models.Document, error) {
+	document, err := s.getManagedDocument(ctx, documentID, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if document.IsPrivate == private {
+		return document, nil
+	}
+
+	document.IsPrivate = private
+	document.UpdatedBy = &userID
+	document.UpdatedAt = time.Now()
+
+	if err := s.docRepo.Update(ctx, document); err != nil {
+		return nil, fmt.Errorf("failed to update document privacy: %w", err)
+	}
+
+	details := "Document made public"
+	if private {
+		details = "Document made private"
+	}
+	s.createAuditLog(ctx, tenantID, userID, documentID, models.AuditUpdate, details)
+
+	return document, nil
+}
+
+// ListDocumentACL lists the explicit grants on a document
+func (s *DocumentService) ListDocumentACL(ctx context.Context, documentID, tenantID, userID uuid.UUID) ([]models.DocumentACL, error) {
+	if _, err := s.getManagedDocument(ctx, documentID, tenantID, userID); err != nil {
+		return nil, err
+	}
+	return s.docACLRepo.ListByDocument(ctx, documentID)
+}
+
+// GrantDocumentAccess grants (or changes) a user's permission on a document
+func (s *DocumentService) GrantDocumentAccess(ctx context.Context, params GrantDocumentAccessParams) (*models.DocumentACL, error) {
+	if documentPermissionRank(params.Permission) == 0 || params.UserID == uuid.Nil {
+		return nil, ErrInvalidDocumentACL
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidGrantExpiry
+	}
+
+	if _, err := s.getManagedDocument(ctx, params.DocumentID, params.TenantID, params.GrantedBy); err != nil {
+		return nil, err
+	}
+
+	grantee, err := s.userRepo.GetByID(ctx, params.UserID)
+	if err != nil || grantee.TenantID != params.TenantID {
+		return nil, ErrUserNotFound
+	}
+
+	acl := &models.DocumentACL{
+		TenantID:   params.TenantID,
+		DocumentID: params.DocumentID,
+		UserID:     params.UserID,
+		Permission: params.Permission,
+		GrantedBy:  params.GrantedBy,
+		ExpiresAt:  params.ExpiresAt,
+	}
+	if err := s.docACLRepo.Upsert(ctx, acl); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.GrantedBy, params.DocumentID, models.AuditUpdate,
+		fmt.Sprintf("Document access granted: %s%s", params.Permission, grantExpirySuffix(params.ExpiresAt)))
+
+	return acl, nil
+}
+
+// RevokeDocumentAccess removes a user's grant from a document
+func (s *DocumentService) RevokeDocumentAccess(ctx context.Context, documentID, granteeID, tenantID, userID uuid.UUID) error {
+	if _, err := s.getManagedDocument(ctx, documentID, tenantID, userID); err != nil {
+		return err
+	}
+
+	if err := s.docACLRepo.Delete(ctx, documentID, granteeID); err != nil {
+		return ErrDocumentACLNotFound
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, documentID, models.AuditUpdate, "Document access revoked")
+
+	return nil
+}
+
+// documentVisibility returns the list filters that hide documents a user may not
+// read. Admins see private documents, so viewerID is nil for them.
+func (s *DocumentService) documentVisibility(ctx context.Context, tenantID, userID uuid.UUID) ([]uuid.UUID, *uuid.UUID, error) {
+	denied, err := s.UnreadableFolderIDs(ctx, tenantID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+	if user.Role == models.UserRoleAdmin {
+		return denied, nil, nil
+	}
+
+	return denied, &userID, nil
+}
+
+// documentEditability returns the list filters that leave out documents a
+// user may not change, the same rules CheckDocumentAccess applies for
+// DocPermWrite. Admins may change private documents, so editorID is nil for
+// them.
+func (s *DocumentService) documentEditability(ctx context.Context, tenantID, userID uuid.UUID) ([]uuid.UUID, *uuid.UUID, error) {
+	denied, err := s.UnwritableFolderIDs(ctx, tenantID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+	if user.Role == models.UserRoleAdmin {
+		return denied, nil, nil
+	}
+
+	return denied, &userID, nil
+}
+
+// getTenantDocument loads a document of the tenant. Documents of other
+// tenants are reported as not found so their existence isn't leaked, and so
+// are trashed documents.
+func (s *DocumentService) getTenantDocument(ctx context.Context, documentID, tenantID uuid.UUID) (*models.Document, error) {
+	document, err := s.docRepo.GetForTenant(ctx, tenantID, documentID)
+	if err != nil || document.DeletedAt != nil {
+		return nil, ErrDocumentNotFound
+	}
+	return document, nil
+}
+
+// getManagedDocument loads a document the user is allowed to manage access for
+func (s *DocumentService) getManagedDocument(ctx context.Context, documentID, tenantID, userID uuid.UUID) (*models.Document, error) {
+	document, err := s.getTenantDocument(ctx, documentID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if document.Owner() != userID {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil || user.TenantID != tenantID || user.Role != models.UserRoleAdmin {
+			return nil, ErrDocumentAccessDenied
+		}
+	}
+
+	return document, nil
+}
+
+func documentPermissionRank(permission models.DocumentPermission) int {
+	switch permission {
+	case models.DocPermRead:
+		return 1
+	case models.DocPermWrite:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// TRASH METHODS
+
+// ListTrash lists deleted documents, most recently deleted first. Admins see
+// the tenant's whole trash; other users what they deleted or own.
+func (s *DocumentService) ListTrash(ctx context.Context, tenantID, userID uuid.UUID, params repositories.ListParams) ([]models.Document, int64, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, 0, ErrUserNotFound
+	}
+
+	filters := repositories.DocumentFilters{Trashed: true, ListParams: params}
+	if user.Role != models.UserRoleAdmin {
+		filters.TrashViewerID = &userID
+	}
+	if filters.SortBy == "" {
+		filters.SortBy = "deleted_at"
+		filters.SortDesc = true
+	}
+
+	return s.docRepo.List(ctx, tenantID, filters)
+}
+
+// RestoreDocument takes a document out of the trash. Its owner, whoever
+// deleted it and admins can restore it, within the tenant's quotas.
+func (s *DocumentService) RestoreDocument(ctx context.Context, documentID, tenantID, userID uuid.UUID) (*models.Document, error) {
+	document, err := s.docRepo.GetForTenant(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, ErrDocumentNotFound
+	}
+	if document.DeletedAt == nil {
+		return nil, ErrDocumentNotInTrash
+	}
+
+	deletedByUser := document.DeletedBy != nil && *document.DeletedBy == userID
+	if document.Owner() != userID && !deletedByUser {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil || user.TenantID != tenantID || user.Role != models.UserRoleAdmin {
+			return nil, ErrDocumentAccessDenied
+		}
+	}
+
+	// Deleting gave the document's storage and slot back, so take them again
+	quotaStatus, err := s.tenantRepo.CheckQuotaLimits(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check quota: %w", err)
+	}
+	if !quotaStatus.CanUpload {
+		s.publishQuotaExceeded(ctx, tenantID, "storage", quotaStatus.StorageUsed, quotaStatus.StorageQuota)
+		return nil, ErrQuotaExceeded
+	}
+	reservedStorage, err := s.tenantRepo.ReserveStorage(ctx, tenantID, document.FileSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve storage quota: %w", err)
+	}
+	if !reservedStorage {
+		s.publishQuotaExceeded(ctx, tenantID, "storage", quotaStatus.StorageUsed, quotaStatus.StorageQuota)
+		return nil, ErrQuotaExceeded
+	}
+	reserved, err := s.tenantRepo.ReserveDocumentSlots(ctx, tenantID, 1)
+	if err != nil || !reserved {
+		s.tenantRepo.ReleaseStorage(ctx, tenantID, document.FileSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve document slot: %w", err)
+		}
+		s.publishQuotaExceeded(ctx, tenantID, "documents", quotaStatus.DocumentCount, quotaStatus.DocumentQuota)
+		return nil, ErrDocumentQuotaExceeded
+	}
+
+	if err := s.docRepo.Restore(ctx, documentID, userID); err != nil {
+		s.tenantRepo.ReleaseStorage(ctx, tenantID, document.FileSize)
+		s.tenantRepo.ReleaseDocumentSlots(ctx, tenantID, 1)
+		return nil, ErrDocumentNotInTrash
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, documentID, models.AuditUpdate, "Document restored from trash")
+	s.publishEvent(ctx, tenantID, WebhookEventDocumentRestored, document, userID)
+
+	return s.getTenantDocument(ctx, documentID, tenantID)
+}
+
+// PurgeTrash hard-deletes documents that have been in the trash longer than
+// the retention period, along with their stored files, and returns how many
+// were purged. Held documents stay until their holds are lifted.
+func (s *DocumentService) PurgeTrash(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-s.config.TrashRetention)
+	purged := 0
+
+	for {
+		documents, err := s.docRepo.ListTrashedBefore(ctx, cutoff, trashPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		batchPurged := 0
+		for _, document := range documents {
+			paths, err := s.docRepo.Purge(ctx, document.ID)
+			if err != nil {
+				// Log but don't fail - the next run retries it
+				continue
+			}
+			batchPurged++
+
+			// Files are removed after the rows; a leftover file is only wasted space
+			for _, path := range paths {
+				if err := s.storageService.Delete(ctx, path); err != nil {
+					// Log but don't fail
+				}
+			}
+
+			deletedBy := uuid.Nil
+			if document.DeletedBy != nil {
+				deletedBy = *document.DeletedBy
+			}
+			s.createAuditLog(ctx, document.TenantID, deletedBy, document.ID, models.AuditDelete,
+				fmt.Sprintf("Document purged from trash: %s", document.Title))
+		}
+		purged += batchPurged
+
+		// Stop when the trash is drained, or when nothing in a batch could be purged
+		if len(documents) < trashPurgeBatchSize || batchPurged == 0 {
+			return purged, nil
+		}
+	}
+}
+
+// TrashPurgeTask is the scheduled task that purges expired trash
+func (s *DocumentService) TrashPurgeTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "trash_purge",
+		Interval: s.config.TrashPurgeInterval,
+		Run: func(ctx context.Context) error {
+			_, err := s.PurgeTrash(ctx)
+			return err
+		},
+	}
+}
+
+// TAG MANAGEMENT METHODS
+
+// CreateTag creates a new tag with validation
+func (s *DocumentService) CreateTag(ctx context.Context, tenantID, userID uuid.UUID, name, color string) (*models.Tag, error) {
+	// Validate name
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("tag name cannot be empty")
+	}
+
+	// Check for duplicate names in tenant
+	if existingTag, err := s.tagRepo.GetByName(ctx, tenantID, name); err == nil && existingTag != nil {
+		return nil, fmt.Errorf("tag with name '%s' already exists", name)
+	}
+
+	// Set defaults
+	if color == "" {
+		color = "#6B7280"
+	}
+
+	// Create tag
+	tag := &models.Tag{
+		ID:            uuid.New(),
 		TenantID:      tenantID,
 		Name:          name,
 		Color:         color,
@@ -956,14 +2047,10 @@ func (s *DocumentService) CreateTag(ctx context.Context, tenantID, userID uuid.U
 
 // GetTag retrieves a tag with access control
 func (s *DocumentService) GetTag(ctx context.Context, tagID, tenantID uuid.UUID) (*models.Tag, error) {
-	tag, err := s.tagRepo.GetByID(ctx, tagID)
+	// Other tenants' tags are reported as missing
+	tag, err := s.tagRepo.GetForTenant(ctx, tenantID, tagID)
 	if err != nil {
-		return nil, fmt.Errorf("tag not found")
-	}
-
-	// Verify tenant access
-	if tag.TenantID != tenantID {
-		return nil, fmt.Errorf("unauthorized access to tag")
+		return nil, ErrTagNotFound
 	}
 
 	return tag, nil
@@ -1044,25 +2131,35 @@ func (s *DocumentService) DeleteTag(ctx context.Context, tagID, tenantID, userID
 	return nil
 }
 
-// GetTagSuggestions generates tag suggestions for text using keyword extraction
-func (s *DocumentService) GetTagSuggestions(ctx context.Context, tenantID uuid.UUID, text string, limit int) ([]string, error) {
+// GetTagSuggestions generates tag suggestions for text using keyword
+// extraction. Language is an ISO 639-1 code; when empty it is detected from
+// the text. The language used is returned with the suggestions.
+func (s *DocumentService) GetTagSuggestions(ctx context.Context, tenantID uuid.UUID, text, language string, limit int) ([]string, string, error) {
 	// Extract keywords from text and match with existing tags
-	keywords := s.extractKeywordsFromText(text)
+	keywords, language, err := extractKeywords(text, language, maxKeywords)
+	if err != nil {
+		return nil, "", err
+	}
 	existingTags, err := s.tagRepo.ListByTenant(ctx, tenantID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get existing tags: %w", err)
+		return nil, "", fmt.Errorf("failed to get existing tags: %w", err)
 	}
 
 	var suggestions []string
 	suggestionMap := make(map[string]bool)
 
-	// Match keywords with existing tag names
+	// Match keywords with existing tag names, by stem so inflected forms match
+	tagStems := make([]map[string]bool, len(existingTags))
+	for i, tag := range existingTags {
+		tagStems[i] = keywordStems(tag.Name, language)
+	}
 	for _, keyword := range keywords {
-		for _, tag := range existingTags {
-			if strings.Contains(strings.ToLower(tag.Name), strings.ToLower(keyword)) {
-				if !suggestionMap[tag.Name] {
+		stem := stemWord(keyword, keywordLanguages[language])
+		for i, tag := range existingTags {
+			if tagStems[i][stem] || strings.Contains(strings.ToLower(tag.Name), keyword) {
+				if !suggestionMap[strings.ToLower(tag.Name)] {
 					suggestions = append(suggestions, tag.Name)
-					suggestionMap[tag.Name] = true
+					suggestionMap[strings.ToLower(tag.Name)] = true
 				}
 			}
 		}
@@ -1070,7 +2167,7 @@ func (s *DocumentService) GetTagSuggestions(ctx context.Context, tenantID uuid.U
 
 	// Add raw keywords as suggestions if they don't match existing tags
 	for _, keyword := range keywords {
-		if !suggestionMap[keyword] && len(keyword) > 2 {
+		if !suggestionMap[keyword] {
 			suggestions = append(suggestions, keyword)
 			suggestionMap[keyword] = true
 		}
@@ -1081,7 +2178,7 @@ func (s *DocumentService) GetTagSuggestions(ctx context.Context, tenantID uuid.U
 		suggestions = suggestions[:limit]
 	}
 
-	return suggestions, nil
+	return suggestions, language, nil
 }
 
 // BulkCreateTags creates multiple tags at once
@@ -1133,53 +2230,6 @@ func (s *DocumentService) BulkCreateTags(ctx context.Context, tenantID, userID u
 	return createdTags, nil
 }
 
-// Helper method to extract keywords from text for tag suggestions
-func (s *DocumentService) extractKeywordsFromText(text string) []string {
-	// Simple keyword extraction - split by common separators and filter
-	text = strings.ToLower(text)
-	separators := []string{" ", ",", ".", ";", ":", "\n", "\t", "-", "_", "(", ")", "[", "]", "{", "}"}
-
-	words := []string{text}
-	for _, sep := range separators {
-		var newWords []string
-		for _, word := range words {
-			newWords = append(newWords, strings.Split(word, sep)...)
-		}
-		words = newWords
-	}
-
-	// Filter and clean keywords
-	var keywords []string
-	stopWords := map[string]bool{
-		"the": true, "and": true, "or": true, "but": true, "in": true, "on": true, "at": true, "to": true,
-		"for": true, "of": true, "with": true, "by": true, "is": true, "are": true, "was": true, "were": true,
-		"be": true, "been": true, "have": true, "has": true, "had": true, "do": true, "does": true, "did": true,
-		"a": true, "an": true, "as": true, "if": true, "it": true, "its": true, "this": true, "that": true,
-	}
-
-	for _, word := range words {
-		word = strings.TrimSpace(word)
-		if len(word) > 2 && !stopWords[word] {
-			keywords = append(keywords, word)
-		}
-	}
-
-	// Remove duplicates and limit
-	uniqueKeywords := make(map[string]bool)
-	var result []string
-	for _, keyword := range keywords {
-		if !uniqueKeywords[keyword] {
-			result = append(result, keyword)
-			uniqueKeywords[keyword] = true
-		}
-		if len(result) >= 20 { // Limit to 20 keywords
-			break
-		}
-	}
-
-	return result
-}
-
 // CATEGORY MANAGEMENT METHODS
 
 // CreateCategory creates a new category with validation
@@ -1230,14 +2280,10 @@ func (s *DocumentService) CreateCategory(ctx context.Context, tenantID, userID u
 
 // GetCategory retrieves a category with access control
 func (s *DocumentService) GetCategory(ctx context.Context, categoryID, tenantID uuid.UUID) (*models.Category, error) {
-	category, err := s.categoryRepo.GetByID(ctx, categoryID)
+	// Other tenants' categories are reported as missing
+	category, err := s.categoryRepo.GetForTenant(ctx, tenantID, categoryID)
 	if err != nil {
-		return nil, fmt.Errorf("category not found")
-	}
-
-	// Verify tenant access
-	if category.TenantID != tenantID {
-		return nil, fmt.Errorf("unauthorized access to category")
+		return nil, ErrCategoryNotFound
 	}
 
 	return category, nil
@@ -0,0 +1,251 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDocumentService(db *testutil.TestDB) *services.DocumentService {
+	repos := postgresql.NewRepositories(db.DB)
+	return services.NewDocumentService(
+		repos.DocumentRepo,
+		repos.TenantRepo,
+		repos.UserRepo,
+		repos.FolderRepo,
+		repos.FolderACLRepo,
+		repos.DocumentACLRepo,
+		repos.TagRepo,
+		repos.CategoryRepo,
+		repos.AuditRepo,
+		repos.AIJobRepo,
+		repos.AnalyticsRepo,
+		repos.NumberingRepo,
+		repos.FingerprintRepo,
+		repos.DocTypeRepo,
+		nil,
+		nil,
+		nil,
+		nil,
+		services.DocumentServiceConfig{},
+	)
+}
+
+func TestDocumentService_TenantIsolation(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	service := newTestDocumentService(db)
+	docRepo := postgresql.NewDocumentRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	owner := db.CreateTestUser(t, tenant)
+	other := db.CreateTestTenant(t)
+	intruder := db.CreateTestUser(t, other)
+
+	document := db.CreateTestDocument(t, tenant, owner)
+	document.DocumentType = models.DocTypeInvoice
+	require.NoError(t, docRepo.Update(ctx, document))
+
+	folder, err := service.CreateFolder(ctx, tenant.ID, owner.ID, "Invoices", "", nil, "", "")
+	require.NoError(t, err)
+	_, err = service.CreateFolder(ctx, tenant.ID, owner.ID, "2024", "", &folder.ID, "", "")
+	require.NoError(t, err)
+	archive, err := service.CreateFolder(ctx, tenant.ID, owner.ID, "Archive", "", nil, "", "")
+	require.NoError(t, err)
+	intruderFolder, err := service.CreateFolder(ctx, other.ID, intruder.ID, "Loot", "", nil, "", "")
+	require.NoError(t, err)
+
+	acl, err := service.GrantFolderAccess(ctx, services.GrantFolderAccessParams{
+		TenantID:   tenant.ID,
+		FolderID:   folder.ID,
+		UserID:     &owner.ID,
+		Permission: models.FolderPermManage,
+		GrantedBy:  owner.ID,
+	})
+	require.NoError(t, err)
+
+	tag, err := service.CreateTag(ctx, tenant.ID, owner.ID, "urgent", "#ff0000")
+	require.NoError(t, err)
+	category, err := service.CreateCategory(ctx, tenant.ID, owner.ID, "Expenses", "", "#00ff00", "", 1)
+	require.NoError(t, err)
+
+	t.Run("GetDocument", func(t *testing.T) {
+		_, err := service.GetDocument(ctx, document.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrDocumentNotFound)
+	})
+
+	t.Run("UpdateDocument", func(t *testing.T) {
+		_, err := service.UpdateDocument(ctx, document.ID, other.ID, map[string]interface{}{"title": "Hijacked"}, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrDocumentNotFound)
+	})
+
+	t.Run("DeleteDocument", func(t *testing.T) {
+		err := service.DeleteDocument(ctx, document.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrDocumentNotFound)
+	})
+
+	t.Run("ProcessFinancialDocument", func(t *testing.T) {
+		err := service.ProcessFinancialDocument(ctx, document.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrDocumentNotFound)
+	})
+
+	t.Run("SetDocumentPrivacy", func(t *testing.T) {
+		_, err := service.SetDocumentPrivacy(ctx, document.ID, other.ID, intruder.ID, true)
+		assert.ErrorIs(t, err, services.ErrDocumentNotFound)
+	})
+
+	t.Run("ListDocumentACL", func(t *testing.T) {
+		_, err := service.ListDocumentACL(ctx, document.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrDocumentNotFound)
+	})
+
+	t.Run("GrantDocumentAccess", func(t *testing.T) {
+		_, err := service.GrantDocumentAccess(ctx, services.GrantDocumentAccessParams{
+			TenantID:   other.ID,
+			DocumentID: document.ID,
+			UserID:     intruder.ID,
+			Permission: models.DocPermRead,
+			GrantedBy:  intruder.ID,
+		})
+		assert.ErrorIs(t, err, services.ErrDocumentNotFound)
+	})
+
+	t.Run("RevokeDocumentAccess", func(t *testing.T) {
+		err := service.RevokeDocumentAccess(ctx, document.ID, owner.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrDocumentNotFound)
+	})
+
+	t.Run("ListDocuments", func(t *testing.T) {
+		documents, total, err := service.ListDocuments(ctx, other.ID, intruder.ID, repositories.DocumentFilters{
+			ListParams: repositories.ListParams{Page: 1, PageSize: 10},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.Empty(t, documents)
+	})
+
+	t.Run("GetFolder", func(t *testing.T) {
+		_, err := service.GetFolder(ctx, folder.ID, other.ID)
+		assert.ErrorIs(t, err, services.ErrFolderNotFound)
+	})
+
+	t.Run("GetFolderChildren", func(t *testing.T) {
+		_, err := service.GetFolderChildren(ctx, folder.ID, other.ID)
+		assert.ErrorIs(t, err, services.ErrFolderNotFound)
+	})
+
+	t.Run("GetFolderDocuments", func(t *testing.T) {
+		_, _, err := service.GetFolderDocuments(ctx, folder.ID, other.ID, repositories.DocumentFilters{})
+		assert.ErrorIs(t, err, services.ErrFolderNotFound)
+	})
+
+	t.Run("UpdateFolder", func(t *testing.T) {
+		_, err := service.UpdateFolder(ctx, folder.ID, other.ID, map[string]interface{}{"name": "Hijacked"}, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrFolderNotFound)
+	})
+
+	t.Run("DeleteFolder", func(t *testing.T) {
+		err := service.DeleteFolder(ctx, folder.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrFolderNotFound)
+	})
+
+	t.Run("MoveFolder", func(t *testing.T) {
+		_, err := service.MoveFolder(ctx, folder.ID, intruderFolder.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrFolderNotFound)
+	})
+
+	t.Run("MoveFolderIntoOtherTenant", func(t *testing.T) {
+		_, err := service.MoveFolder(ctx, intruderFolder.ID, archive.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrFolderNotFound)
+	})
+
+	t.Run("GrantFolderAccess", func(t *testing.T) {
+		_, err := service.GrantFolderAccess(ctx, services.GrantFolderAccessParams{
+			TenantID:   other.ID,
+			FolderID:   folder.ID,
+			UserID:     &intruder.ID,
+			Permission: models.FolderPermManage,
+			GrantedBy:  intruder.ID,
+		})
+		assert.ErrorIs(t, err, services.ErrFolderNotFound)
+	})
+
+	t.Run("RevokeFolderAccess", func(t *testing.T) {
+		err := service.RevokeFolderAccess(ctx, folder.ID, acl.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrFolderACLNotFound)
+	})
+
+	t.Run("UpdateTag", func(t *testing.T) {
+		_, err := service.UpdateTag(ctx, tag.ID, other.ID, map[string]interface{}{"name": "hijacked"}, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrTagNotFound)
+	})
+
+	t.Run("DeleteTag", func(t *testing.T) {
+		err := service.DeleteTag(ctx, tag.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrTagNotFound)
+	})
+
+	t.Run("UpdateCategory", func(t *testing.T) {
+		_, err := service.UpdateCategory(ctx, category.ID, other.ID, map[string]interface{}{"name": "Hijacked"}, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrCategoryNotFound)
+	})
+
+	t.Run("DeleteCategory", func(t *testing.T) {
+		err := service.DeleteCategory(ctx, category.ID, other.ID, intruder.ID)
+		assert.ErrorIs(t, err, services.ErrCategoryNotFound)
+	})
+
+	// The owner's tenant still sees the document untouched
+	found, err := service.GetDocument(ctx, document.ID, tenant.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, document.Title, found.Title)
+	assert.Equal(t, models.DocStatusPending, found.Status)
+	assert.False(t, found.IsPrivate)
+
+	children, err := service.GetFolderChildren(ctx, folder.ID, tenant.ID)
+	require.NoError(t, err)
+	assert.Len(t, children, 1)
+
+	moved, err := service.GetFolder(ctx, folder.ID, tenant.ID)
+	require.NoError(t, err)
+	assert.Nil(t, moved.ParentID)
+
+	acls, err := service.ListFolderACL(ctx, folder.ID, tenant.ID)
+	require.NoError(t, err)
+	assert.Len(t, acls, 1)
+
+	unchangedTag, err := service.GetTag(ctx, tag.ID, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "urgent", unchangedTag.Name)
+
+	unchangedCategory, err := service.GetCategory(ctx, category.ID, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Expenses", unchangedCategory.Name)
+}
+
+func TestParseProcessingPriority(t *testing.T) {
+	priority, err := services.ParseProcessingPriority("")
+	require.NoError(t, err)
+	assert.Equal(t, services.ProcessingPriorityNormal, priority)
+
+	high, err := services.ParseProcessingPriority("High")
+	require.NoError(t, err)
+	low, err := services.ParseProcessingPriority("low")
+	require.NoError(t, err)
+
+	// The AI worker takes the lowest job priority first
+	assert.Less(t, high.JobPriority(), priority.JobPriority())
+	assert.Less(t, priority.JobPriority(), low.JobPriority())
+
+	_, err = services.ParseProcessingPriority("urgent")
+	assert.ErrorIs(t, err, services.ErrInvalidProcessingPriority)
+}
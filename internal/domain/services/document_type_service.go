@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidDocumentTypeDefinition = errors.New("invalid document type definition")
+	ErrDocumentTypeNotFound          = errors.New("document type not found")
+	ErrDocumentTypeExists            = errors.New("document type already exists")
+	ErrDocumentTypeInUse             = errors.New("document type is in use")
+)
+
+const (
+	maxDocumentTypeNameLength        = 100
+	maxDocumentTypeDescriptionLength = 500
+)
+
+// documentTypeKeyPattern is the form of custom document type keys, the same
+// as the built-in ones'
+var documentTypeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// DocumentTypeService manages tenants' own document types, which documents,
+// workflows, retention policies and AI classification use alongside the
+// built-in ones
+type DocumentTypeService struct {
+	docTypeRepo repositories.DocumentTypeRepository
+	auditRepo   repositories.AuditLogRepository
+}
+
+// NewDocumentTypeService creates a new document type service
+func NewDocumentTypeService(
+	docTypeRepo repositories.DocumentTypeRepository,
+	auditRepo repositories.AuditLogRepository,
+) *DocumentTypeService {
+	return &DocumentTypeService{
+		docTypeRepo: docTypeRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// DocumentTypeList is every document type a tenant has: the built-in ones
+// and its own, inactive ones included
+type DocumentTypeList struct {
+	BuiltIn []models.DocumentType           `json:"built_in"`
+	Custom  []models.DocumentTypeDefinition `json:"custom"`
+}
+
+// CreateDocumentTypeParams contains parameters for defining a document type
+type CreateDocumentTypeParams struct {
+	TenantID    uuid.UUID            `json:"tenant_id"`
+	Key         models.DocumentType  `json:"key"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	MapsTo      *models.DocumentType `json:"maps_to"`
+	CreatedBy   uuid.UUID            `json:"created_by"`
+}
+
+// UpdateDocumentTypeParams contains the changes to a document type; nil
+// fields are left as they are and an empty MapsTo removes the mapping. The
+// key can't change, since documents and workflows store it.
+type UpdateDocumentTypeParams struct {
+	TenantID    uuid.UUID            `json:"tenant_id"`
+	Key         models.DocumentType  `json:"key"`
+	Name        *string              `json:"name"`
+	Description *string              `json:"description"`
+	MapsTo      *models.DocumentType `json:"maps_to"`
+	IsActive    *bool                `json:"is_active"`
+	UpdatedBy   uuid.UUID            `json:"updated_by"`
+}
+
+// ListDocumentTypes returns the built-in types and the tenant's own
+func (s *DocumentTypeService) ListDocumentTypes(ctx context.Context, tenantID uuid.UUID) (*DocumentTypeList, error) {
+	definitions, err := s.docTypeRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if definitions == nil {
+		definitions = []models.DocumentTypeDefinition{}
+	}
+	return &DocumentTypeList{BuiltIn: knownDocumentTypes, Custom: definitions}, nil
+}
+
+// CreateDocumentType defines a document type for the tenant. Its key can't
+// be a built-in type's.
+func (s *DocumentTypeService) CreateDocumentType(ctx context.Context, params CreateDocumentTypeParams) (*models.DocumentTypeDefinition, error) {
+	definition := &models.DocumentTypeDefinition{
+		TenantID:    params.TenantID,
+		Key:         models.DocumentType(strings.TrimSpace(string(params.Key))),
+		Name:        strings.TrimSpace(params.Name),
+		Description: strings.TrimSpace(params.Description),
+		MapsTo:      normalizeDocumentTypeMapping(params.MapsTo),
+		IsActive:    true,
+		CreatedBy:   params.CreatedBy,
+	}
+	if !documentTypeKeyPattern.MatchString(string(definition.Key)) {
+		return nil, fmt.Errorf("%w: key must be 2 to 50 lowercase letters, digits or underscores, starting with a letter", ErrInvalidDocumentTypeDefinition)
+	}
+	if isKnownDocumentType(definition.Key) {
+		return nil, fmt.Errorf("%w: %s is a built-in type", ErrDocumentTypeExists, definition.Key)
+	}
+	if err := validateDocumentTypeDefinition(definition); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.docTypeRepo.GetByKey(ctx, params.TenantID, definition.Key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrDocumentTypeExists
+	}
+
+	if err := s.docTypeRepo.Create(ctx, definition); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.CreatedBy, definition.ID, models.AuditCreate,
+		fmt.Sprintf("Document type created: %s", definition.Key))
+
+	return definition, nil
+}
+
+// UpdateDocumentType changes a tenant's document type. Deactivated types
+// stay on the documents that have them but can no longer be assigned.
+func (s *DocumentTypeService) UpdateDocumentType(ctx context.Context, params UpdateDocumentTypeParams) (*models.DocumentTypeDefinition, error) {
+	definition, err := s.getDefinition(ctx, params.TenantID, params.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Name != nil {
+		definition.Name = strings.TrimSpace(*params.Name)
+	}
+	if params.Description != nil {
+		definition.Description = strings.TrimSpace(*params.Description)
+	}
+	if params.MapsTo != nil {
+		definition.MapsTo = normalizeDocumentTypeMapping(params.MapsTo)
+	}
+	if params.IsActive != nil {
+		definition.IsActive = *params.IsActive
+	}
+	if err := validateDocumentTypeDefinition(definition); err != nil {
+		return nil, err
+	}
+	definition.UpdatedAt = time.Now()
+
+	if err := s.docTypeRepo.Update(ctx, definition); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.UpdatedBy, definition.ID, models.AuditUpdate,
+		fmt.Sprintf("Document type updated: %s", definition.Key))
+
+	return definition, nil
+}
+
+// DeleteDocumentType removes a tenant's document type. Types documents or
+// workflows still have can only be deactivated.
+func (s *DocumentTypeService) DeleteDocumentType(ctx context.Context, tenantID uuid.UUID, key models.DocumentType, deletedBy uuid.UUID) error {
+	definition, err := s.getDefinition(ctx, tenantID, key)
+	if err != nil {
+		return err
+	}
+
+	used, err := s.docTypeRepo.CountUsage(ctx, tenantID, key)
+	if err != nil {
+		return err
+	}
+	if used > 0 {
+		return fmt.Errorf("%w by %d documents or workflows; deactivate it instead", ErrDocumentTypeInUse, used)
+	}
+
+	if err := s.docTypeRepo.Delete(ctx, definition.ID); err != nil {
+		return err
+	}
+
+	s.createAuditLog(ctx, tenantID, deletedBy, definition.ID, models.AuditDelete,
+		fmt.Sprintf("Document type deleted: %s", key))
+
+	return nil
+}
+
+// Helper methods
+
+func (s *DocumentTypeService) getDefinition(ctx context.Context, tenantID uuid.UUID, key models.DocumentType) (*models.DocumentTypeDefinition, error) {
+	definition, err := s.docTypeRepo.GetByKey(ctx, tenantID, key)
+	if err != nil {
+		return nil, err
+	}
+	if definition == nil {
+		return nil, ErrDocumentTypeNotFound
+	}
+	return definition, nil
+}
+
+func (s *DocumentTypeService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "document_type",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+// Helper functions
+
+func validateDocumentTypeDefinition(definition *models.DocumentTypeDefinition) error {
+	if definition.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidDocumentTypeDefinition)
+	}
+	if len(definition.Name) > maxDocumentTypeNameLength {
+		return fmt.Errorf("%w: name must be at most %d characters", ErrInvalidDocumentTypeDefinition, maxDocumentTypeNameLength)
+	}
+	if len(definition.Description) > maxDocumentTypeDescriptionLength {
+		return fmt.Errorf("%w: description must be at most %d characters", ErrInvalidDocumentTypeDefinition, maxDocumentTypeDescriptionLength)
+	}
+	if definition.MapsTo != nil && !isKnownDocumentType(*definition.MapsTo) {
+		return fmt.Errorf("%w: maps_to must be a built-in type", ErrInvalidDocumentTypeDefinition)
+	}
+	return nil
+}
+
+// normalizeDocumentTypeMapping trims a mapping, turning an empty one into none
+func normalizeDocumentTypeMapping(mapsTo *models.DocumentType) *models.DocumentType {
+	if mapsTo == nil {
+		return nil
+	}
+	trimmed := models.DocumentType(strings.TrimSpace(string(*mapsTo)))
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// documentTypeRegistry is the document types a tenant has: the built-in
+// ones and its own
+type documentTypeRegistry struct {
+	custom map[models.DocumentType]models.DocumentTypeDefinition
+}
+
+// loadDocumentTypes returns a tenant's document types; without a repository
+// only the built-in ones are known
+func loadDocumentTypes(ctx context.Context, docTypeRepo repositories.DocumentTypeRepository, tenantID uuid.UUID) (*documentTypeRegistry, error) {
+	if docTypeRepo == nil {
+		return newDocumentTypeRegistry(nil), nil
+	}
+	definitions, err := docTypeRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return newDocumentTypeRegistry(definitions), nil
+}
+
+func newDocumentTypeRegistry(definitions []models.DocumentTypeDefinition) *documentTypeRegistry {
+	registry := &documentTypeRegistry{custom: make(map[models.DocumentType]models.DocumentTypeDefinition, len(definitions))}
+	for _, definition := range definitions {
+		registry.custom[definition.Key] = definition
+	}
+	return registry
+}
+
+// defined reports whether the type is built in or one of the tenant's,
+// active or not
+func (r *documentTypeRegistry) defined(docType models.DocumentType) bool {
+	_, ok := r.custom[docType]
+	return ok || isKnownDocumentType(docType)
+}
+
+// assignable reports whether documents and workflows can be given the type:
+// it's built in or one of the tenant's active types
+func (r *documentTypeRegistry) assignable(docType models.DocumentType) bool {
+	if isKnownDocumentType(docType) {
+		return true
+	}
+	definition, ok := r.custom[docType]
+	return ok && definition.IsActive
+}
+
+// builtin returns the built-in type a type behaves like: itself, the one a
+// custom type maps to, or "" for custom types without a mapping
+func (r *documentTypeRegistry) builtin(docType models.DocumentType) models.DocumentType {
+	if isKnownDocumentType(docType) {
+		return docType
+	}
+	if definition, ok := r.custom[docType]; ok && definition.MapsTo != nil {
+		return *definition.MapsTo
+	}
+	return ""
+}
+
+// active returns the tenant's active types, for classification to choose from
+func (r *documentTypeRegistry) active() []models.DocumentTypeDefinition {
+	var definitions []models.DocumentTypeDefinition
+	for _, definition := range r.custom {
+		if definition.IsActive {
+			definitions = append(definitions, definition)
+		}
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Key < definitions[j].Key })
+	return definitions
+}
+
+// checkDocumentType rejects a type the tenant's documents and workflows
+// can't be given
+func checkDocumentType(ctx context.Context, docTypeRepo repositories.DocumentTypeRepository, tenantID uuid.UUID, docType models.DocumentType) error {
+	if isKnownDocumentType(docType) {
+		return nil
+	}
+	if docTypeRepo == nil || !documentTypeKeyPattern.MatchString(string(docType)) {
+		return ErrInvalidDocumentType
+	}
+	definition, err := docTypeRepo.GetByKey(ctx, tenantID, docType)
+	if err != nil {
+		return err
+	}
+	if definition == nil || !definition.IsActive {
+		return ErrInvalidDocumentType
+	}
+	return nil
+}
+
+// builtinDocumentType returns the built-in type a document type behaves
+// like, "" when it's a custom type without a mapping or can't be looked up
+func builtinDocumentType(ctx context.Context, docTypeRepo repositories.DocumentTypeRepository, tenantID uuid.UUID, docType models.DocumentType) models.DocumentType {
+	if isKnownDocumentType(docType) {
+		return docType
+	}
+	if docTypeRepo == nil || docType == "" {
+		return ""
+	}
+	definition, err := docTypeRepo.GetByKey(ctx, tenantID, docType)
+	if err != nil || definition == nil || definition.MapsTo == nil {
+		// Log but continue - the type gets no built-in behaviour
+		return ""
+	}
+	return *definition.MapsTo
+}
+
+type aiDocumentTypesContextKey struct{}
+
+// WithAIDocumentTypes returns a context carrying a tenant's own document
+// types, which classification made with it may choose besides the built-in
+// ones
+func WithAIDocumentTypes(ctx context.Context, definitions []models.DocumentTypeDefinition) context.Context {
+	return context.WithValue(ctx, aiDocumentTypesContextKey{}, definitions)
+}
+
+// AIDocumentTypesFromContext returns the tenant's document types
+// classification may choose, if any
+func AIDocumentTypesFromContext(ctx context.Context) []models.DocumentTypeDefinition {
+	definitions, _ := ctx.Value(aiDocumentTypesContextKey{}).([]models.DocumentTypeDefinition)
+	return definitions
+}
+
+// aiDocumentTypeChoices lists the types classification may choose: the
+// built-in ones, then the tenant's with their names and descriptions so the
+// provider knows what they are
+func aiDocumentTypeChoices(ctx context.Context) string {
+	choices := make([]string, 0, len(knownDocumentTypes))
+	for _, docType := range knownDocumentTypes {
+		choices = append(choices, string(docType))
+	}
+	for _, definition := range AIDocumentTypesFromContext(ctx) {
+		choice := fmt.Sprintf("%s (%s", definition.Key, definition.Name)
+		if definition.Description != "" {
+			choice += ": " + definition.Description
+		}
+		choices = append(choices, choice+")")
+	}
+	return strings.Join(choices, ", ")
+}
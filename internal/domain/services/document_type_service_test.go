@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentTypeRegistry(t *testing.T) {
+	invoice := models.DocTypeInvoice
+	docTypes := newDocumentTypeRegistry([]models.DocumentTypeDefinition{
+		{Key: "purchase_order", Name: "Purchase order", MapsTo: &invoice, IsActive: true},
+		{Key: "lab_report", Name: "Lab report", Description: "Results from the testing lab", IsActive: true},
+		{Key: "fax", Name: "Fax", IsActive: false},
+	})
+
+	assert.True(t, docTypes.assignable(models.DocTypeContract))
+	assert.True(t, docTypes.assignable("purchase_order"))
+	assert.False(t, docTypes.assignable("fax"))
+	assert.True(t, docTypes.defined("fax"))
+	assert.False(t, docTypes.defined("horoscope"))
+
+	assert.Equal(t, models.DocTypeContract, docTypes.builtin(models.DocTypeContract))
+	assert.Equal(t, models.DocTypeInvoice, docTypes.builtin("purchase_order"))
+	assert.Equal(t, models.DocumentType(""), docTypes.builtin("lab_report"))
+
+	// Classification offers the active types after the built-in ones
+	ctx := WithAIDocumentTypes(context.Background(), docTypes.active())
+	assert.Contains(t, aiPromptInstructions(ctx, models.PromptClassification),
+		"marketing, general, lab_report (Lab report: Results from the testing lab), purchase_order (Purchase order), and")
+	assert.NotContains(t, aiPromptInstructions(ctx, models.PromptClassification), "fax")
+}
+
+func TestDocumentRetentionPolicy_WithMappedRules(t *testing.T) {
+	invoice, receipt := models.DocTypeInvoice, models.DocTypeReceipt
+	docTypes := newDocumentTypeRegistry([]models.DocumentTypeDefinition{
+		{Key: "purchase_order", MapsTo: &invoice},
+		{Key: "till_slip", MapsTo: &receipt},
+		{Key: "lab_report"},
+	})
+	policy := DocumentRetentionPolicy{
+		Default: &RetentionRule{RetentionYears: 10, Action: RetentionArchive},
+		DocumentTypes: map[models.DocumentType]RetentionRule{
+			models.DocTypeInvoice: {RetentionYears: 7, Action: RetentionDelete},
+			"till_slip":           {Action: RetentionKeep},
+		},
+	}
+
+	effective := policy.withMappedRules(docTypes)
+	assert.Equal(t, RetentionRule{RetentionYears: 7, Action: RetentionDelete}, effective.DocumentTypes["purchase_order"])
+	assert.Equal(t, RetentionRule{Action: RetentionKeep}, effective.DocumentTypes["till_slip"])
+	_, ok := effective.DocumentTypes["lab_report"]
+	assert.False(t, ok)
+	assert.Len(t, policy.DocumentTypes, 2)
+}
+
+func TestCreateDocumentType_Validation(t *testing.T) {
+	service := NewDocumentTypeService(nil, nil)
+	general := models.DocTypeGeneral
+	unknown := models.DocumentType("purchase_order")
+
+	for _, params := range []CreateDocumentTypeParams{
+		{Key: "Purchase Order", Name: "Purchase order"},
+		{Key: "p", Name: "Purchase order"},
+		{Key: "purchase_order"},
+		{Key: "purchase_order", Name: "Purchase order", MapsTo: &unknown},
+	} {
+		_, err := service.CreateDocumentType(context.Background(), params)
+		assert.ErrorIs(t, err, ErrInvalidDocumentTypeDefinition, params.Key)
+	}
+
+	_, err := service.CreateDocumentType(context.Background(), CreateDocumentTypeParams{Key: "invoice", Name: "Invoice", MapsTo: &general})
+	assert.ErrorIs(t, err, ErrDocumentTypeExists)
+}
+
+func TestCheckDocumentType_BuiltInOnlyWithoutRepository(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, checkDocumentType(ctx, nil, uuid.Nil, models.DocTypeInvoice))
+	assert.ErrorIs(t, checkDocumentType(ctx, nil, uuid.Nil, "purchase_order"), ErrInvalidDocumentType)
+	assert.Equal(t, models.DocTypeReceipt, builtinDocumentType(ctx, nil, uuid.Nil, models.DocTypeReceipt))
+	assert.Equal(t, models.DocumentType(""), builtinDocumentType(ctx, nil, uuid.Nil, "purchase_order"))
+}
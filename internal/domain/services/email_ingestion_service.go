@@ -0,0 +1,487 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEmailIngestionDisabled = errors.New("email-in is not configured")
+	ErrInvalidEmailInbox      = errors.New("invalid email inbox settings")
+)
+
+// snsHost matches Amazon SNS endpoints, e.g. sns.us-east-1.amazonaws.com
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// tenantEmailInboxSetting is the tenant settings key holding the email inbox
+const tenantEmailInboxSetting = "email_inbox"
+
+// EmailInboxSettings controls how a tenant's inbox address turns mail into
+// documents. Mail from the tenant's own users that passed SPF or DKIM is
+// uploaded as them; other mail from AllowedSenders is uploaded as Submitter.
+// Anything else is refused.
+type EmailInboxSettings struct {
+	Enabled        bool       `json:"enabled"`
+	FolderID       *uuid.UUID `json:"folder_id,omitempty"`       // Where attachments are filed; the root without one
+	AllowedSenders []string   `json:"allowed_senders,omitempty"` // Outside addresses, or @domains
+	SubmitterID    *uuid.UUID `json:"submitter_id,omitempty"`    // Uploads mail from AllowedSenders
+
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// EmailIngestionService turns email sent to tenant inbox addresses, e.g.
+// docs+acme@in.example.com, into documents
+type EmailIngestionService struct {
+	tenantRepo      repositories.TenantRepository
+	userRepo        repositories.UserRepository
+	folderRepo      repositories.FolderRepository
+	auditRepo       repositories.AuditLogRepository
+	documentService *DocumentService
+	config          EmailIngestionServiceConfig
+	httpClient      *http.Client
+}
+
+// EmailIngestionServiceConfig holds configuration for email-in
+type EmailIngestionServiceConfig struct {
+	Domain         string // Inbound mail domain; empty disables email-in
+	LocalPart      string // Defaults to "docs"
+	WebhookSecret  string // The mail provider calls the inbound webhook with it
+	MaxMessageSize int64  // Defaults to 25MB
+	MaxAttachments int    // Per email; defaults to 20
+}
+
+// NewEmailIngestionService creates a new email ingestion service
+func NewEmailIngestionService(
+	tenantRepo repositories.TenantRepository,
+	userRepo repositories.UserRepository,
+	folderRepo repositories.FolderRepository,
+	auditRepo repositories.AuditLogRepository,
+	documentService *DocumentService,
+	config EmailIngestionServiceConfig,
+) *EmailIngestionService {
+	config.Domain = strings.ToLower(strings.TrimSpace(config.Domain))
+	if config.LocalPart == "" {
+		config.LocalPart = "docs"
+	}
+	config.LocalPart = strings.ToLower(config.LocalPart)
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = 25 * 1024 * 1024
+	}
+	if config.MaxAttachments <= 0 {
+		config.MaxAttachments = 20
+	}
+
+	return &EmailIngestionService{
+		tenantRepo:      tenantRepo,
+		userRepo:        userRepo,
+		folderRepo:      folderRepo,
+		auditRepo:       auditRepo,
+		documentService: documentService,
+		config:          config,
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// UpdateEmailInboxParams contains new inbox settings
+type UpdateEmailInboxParams struct {
+	TenantID  uuid.UUID          `json:"tenant_id"`
+	UpdatedBy uuid.UUID          `json:"updated_by"`
+	Settings  EmailInboxSettings `json:"settings"`
+}
+
+// EmailIngestResult says what became of an email at each tenant it reached
+type EmailIngestResult struct {
+	Deliveries []EmailDelivery `json:"deliveries"`
+}
+
+// EmailDelivery is an email's outcome at one tenant
+type EmailDelivery struct {
+	TenantID  uuid.UUID            `json:"tenant_id"`
+	Documents []uuid.UUID          `json:"documents"`
+	Rejected  []RejectedAttachment `json:"rejected,omitempty"`
+	Refused   string               `json:"refused,omitempty"` // Why no attachment was accepted at all
+}
+
+// RejectedAttachment is an attachment that didn't become a document
+type RejectedAttachment struct {
+	Filename string `json:"filename"`
+	Reason   string `json:"reason"`
+}
+
+// Enabled reports whether email-in is configured
+func (s *EmailIngestionService) Enabled() bool {
+	return s.config.Domain != ""
+}
+
+// MaxMessageSize is the largest email accepted, in bytes
+func (s *EmailIngestionService) MaxMessageSize() int64 {
+	return s.config.MaxMessageSize
+}
+
+// VerifyWebhookSecret checks the secret the inbound webhook was called with
+func (s *EmailIngestionService) VerifyWebhookSecret(secret string) bool {
+	return s.config.WebhookSecret != "" &&
+		subtle.ConstantTimeCompare([]byte(secret), []byte(s.config.WebhookSecret)) == 1
+}
+
+// ConfirmSNSSubscription confirms the Amazon SNS subscription SES delivers
+// inbound mail through. Only SNS endpoints are visited.
+func (s *EmailIngestionService) ConfirmSNSSubscription(ctx context.Context, subscribeURL string) error {
+	parsed, err := url.Parse(subscribeURL)
+	if err != nil || parsed.Scheme != "https" || !snsHost.MatchString(parsed.Hostname()) || parsed.Port() != "" {
+		return fmt.Errorf("%w: subscription URL is not an SNS endpoint", ErrInvalidInboundEmail)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to confirm SNS subscription: %w", err)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to confirm SNS subscription: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to confirm SNS subscription: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// InboxAddress is the address mail for the tenant is sent to
+func (s *EmailIngestionService) InboxAddress(tenant *models.Tenant) string {
+	if !s.Enabled() {
+		return ""
+	}
+	return fmt.Sprintf("%s+%s@%s", s.config.LocalPart, strings.ToLower(tenant.Subdomain), s.config.Domain)
+}
+
+// GetSettings returns the tenant's inbox settings and address
+func (s *EmailIngestionService) GetSettings(ctx context.Context, tenantID uuid.UUID) (*EmailInboxSettings, string, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, "", ErrTenantNotFound
+	}
+	settings, err := emailInboxSettings(tenant)
+	if err != nil {
+		return nil, "", err
+	}
+	return settings, s.InboxAddress(tenant), nil
+}
+
+// UpdateSettings validates and stores the tenant's inbox settings
+func (s *EmailIngestionService) UpdateSettings(ctx context.Context, params UpdateEmailInboxParams) (*EmailInboxSettings, string, error) {
+	settings := params.Settings
+	if settings.Enabled && !s.Enabled() {
+		return nil, "", ErrEmailIngestionDisabled
+	}
+
+	senders, err := normalizeAllowedSenders(settings.AllowedSenders)
+	if err != nil {
+		return nil, "", err
+	}
+	settings.AllowedSenders = senders
+
+	if settings.FolderID != nil {
+		if _, err := s.folderRepo.GetForTenant(ctx, params.TenantID, *settings.FolderID); err != nil {
+			return nil, "", ErrFolderNotFound
+		}
+	}
+	if settings.SubmitterID != nil {
+		submitter, err := s.userRepo.GetByID(ctx, *settings.SubmitterID)
+		if err != nil || submitter.TenantID != params.TenantID || !submitter.IsActive {
+			return nil, "", fmt.Errorf("%w: submitter must be an active user", ErrInvalidEmailInbox)
+		}
+	} else if len(settings.AllowedSenders) > 0 {
+		return nil, "", fmt.Errorf("%w: allowed senders need a submitter to upload their mail", ErrInvalidEmailInbox)
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, params.TenantID)
+	if err != nil {
+		return nil, "", ErrTenantNotFound
+	}
+
+	now := time.Now()
+	settings.UpdatedBy = &params.UpdatedBy
+	settings.UpdatedAt = &now
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode email inbox settings: %w", err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, "", fmt.Errorf("failed to encode email inbox settings: %w", err)
+	}
+
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+	tenant.Settings[tenantEmailInboxSetting] = stored
+	tenant.UpdatedAt = now
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, "", fmt.Errorf("failed to update email inbox settings: %w", err)
+	}
+
+	s.createAuditLog(ctx, &models.AuditLog{
+		TenantID:     params.TenantID,
+		UserID:       params.UpdatedBy,
+		ResourceID:   params.TenantID,
+		Action:       models.AuditUpdate,
+		ResourceType: "email_inbox",
+		Details: models.JSONB{
+			"message":         "Email inbox settings updated",
+			"enabled":         settings.Enabled,
+			"allowed_senders": settings.AllowedSenders,
+		},
+	})
+
+	return &settings, s.InboxAddress(tenant), nil
+}
+
+// Ingest files the attachments of an inbound email as documents in each
+// tenant it was addressed to, and queues them for AI processing. Recipients
+// that aren't tenant inboxes are ignored.
+func (s *EmailIngestionService) Ingest(ctx context.Context, email *InboundEmail) (*EmailIngestResult, error) {
+	if !s.Enabled() {
+		return nil, ErrEmailIngestionDisabled
+	}
+
+	result := &EmailIngestResult{Deliveries: []EmailDelivery{}}
+	seen := make(map[string]bool)
+	for _, recipient := range email.Recipients {
+		subdomain, ok := s.inboxSubdomain(recipient)
+		if !ok || seen[subdomain] {
+			continue
+		}
+		seen[subdomain] = true
+
+		tenant, err := s.tenantRepo.GetBySubdomain(ctx, subdomain)
+		if err != nil || tenant == nil || !tenant.IsActive {
+			continue
+		}
+		delivery, err := s.deliver(ctx, tenant, email)
+		if err != nil {
+			return nil, err
+		}
+		result.Deliveries = append(result.Deliveries, *delivery)
+	}
+
+	return result, nil
+}
+
+// Helper methods
+
+// inboxSubdomain reads the tenant subdomain from an inbox address
+func (s *EmailIngestionService) inboxSubdomain(recipient string) (string, bool) {
+	address, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return "", false
+	}
+	at := strings.LastIndex(address.Address, "@")
+	if at < 0 || !strings.EqualFold(address.Address[at+1:], s.config.Domain) {
+		return "", false
+	}
+	local := strings.ToLower(address.Address[:at])
+	subdomain, ok := strings.CutPrefix(local, s.config.LocalPart+"+")
+	if !ok || subdomain == "" {
+		return "", false
+	}
+	return subdomain, true
+}
+
+func (s *EmailIngestionService) deliver(ctx context.Context, tenant *models.Tenant, email *InboundEmail) (*EmailDelivery, error) {
+	delivery := &EmailDelivery{TenantID: tenant.ID, Documents: []uuid.UUID{}}
+
+	settings, err := emailInboxSettings(tenant)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		delivery.Refused = "inbox disabled"
+		return delivery, nil
+	}
+
+	uploaderID, refused := s.uploader(ctx, tenant.ID, settings, email)
+	if refused != "" {
+		delivery.Refused = refused
+		s.createAuditLog(ctx, &models.AuditLog{
+			TenantID:     tenant.ID,
+			ResourceID:   tenant.ID,
+			Action:       models.AuditBlocked,
+			ResourceType: "email_inbox",
+			Details: models.JSONB{
+				"message":    "Inbound email refused: " + refused,
+				"from":       email.From,
+				"subject":    email.Subject,
+				"message_id": email.MessageID,
+			},
+		})
+		return delivery, nil
+	}
+
+	if len(email.Attachments) == 0 {
+		delivery.Refused = "no attachments"
+		return delivery, nil
+	}
+
+	description := "Received by email from " + email.From
+	if email.Subject != "" {
+		description += ": " + email.Subject
+	}
+
+	for i, attachment := range email.Attachments {
+		if i >= s.config.MaxAttachments {
+			delivery.Rejected = append(delivery.Rejected, RejectedAttachment{Filename: attachment.Filename, Reason: "too many attachments"})
+			continue
+		}
+
+		document, err := s.documentService.UploadFileContent(ctx, UploadDocumentParams{
+			TenantID:    tenant.ID,
+			UserID:      uploaderID,
+			FolderID:    settings.FolderID,
+			Description: description,
+			CustomFields: map[string]interface{}{
+				"source":           "email",
+				"email_from":       email.From,
+				"email_subject":    email.Subject,
+				"email_message_id": email.MessageID,
+			},
+			EnableAI:  true,
+			EnableOCR: true,
+		}, attachment.Filename, attachment.ContentType, attachment.Content)
+		if err != nil {
+			reason, ok := rejectedUploadReason(err)
+			if !ok {
+				return nil, fmt.Errorf("failed to upload %s: %w", attachment.Filename, err)
+			}
+			delivery.Rejected = append(delivery.Rejected, RejectedAttachment{Filename: attachment.Filename, Reason: reason})
+			continue
+		}
+		delivery.Documents = append(delivery.Documents, document.ID)
+	}
+
+	return delivery, nil
+}
+
+// uploader picks whom the email's documents are uploaded as, or says why
+// the email is refused. The sender is only taken for a user when the
+// provider vouched for it with an SPF or DKIM pass; otherwise the From
+// address could be forged, and only the allow-list applies.
+func (s *EmailIngestionService) uploader(ctx context.Context, tenantID uuid.UUID, settings *EmailInboxSettings, email *InboundEmail) (uuid.UUID, string) {
+	if email.SenderAuthFailed {
+		return uuid.Nil, "sender failed SPF and DKIM"
+	}
+	sender := strings.ToLower(strings.TrimSpace(email.From))
+	if sender == "" {
+		return uuid.Nil, "no sender"
+	}
+
+	if email.SenderAuthenticated {
+		if user, err := s.userRepo.GetByEmail(ctx, tenantID, sender); err == nil && user != nil {
+			if !user.IsActive {
+				return uuid.Nil, "sender is deactivated"
+			}
+			return user.ID, ""
+		}
+	}
+
+	if settings.SubmitterID != nil && senderAllowed(sender, settings.AllowedSenders) {
+		return *settings.SubmitterID, ""
+	}
+	if !email.SenderAuthenticated {
+		return uuid.Nil, "sender not authenticated by SPF or DKIM"
+	}
+	return uuid.Nil, "sender not allowed"
+}
+
+// rejectedUploadReason explains upload failures caused by the attachment or
+// the tenant's limits; other failures are worth the provider retrying
+func rejectedUploadReason(err error) (string, bool) {
+	switch {
+	case errors.Is(err, ErrUnsupportedFormat):
+		return "file type not allowed", true
+	case errors.Is(err, ErrDocumentTooLarge):
+		return "file too large", true
+	case errors.Is(err, ErrDocumentExists):
+		return "duplicate of an existing document", true
+	case errors.Is(err, ErrQuotaExceeded), errors.Is(err, ErrDocumentQuotaExceeded):
+		return "quota exceeded", true
+	case errors.Is(err, ErrFolderNotFound), errors.Is(err, ErrFolderAccessDenied):
+		return "inbox folder not writable", true
+	default:
+		return "", false
+	}
+}
+
+func emailInboxSettings(tenant *models.Tenant) (*EmailInboxSettings, error) {
+	settings := &EmailInboxSettings{}
+	if raw, ok := tenant.Settings[tenantEmailInboxSetting]; ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read email inbox settings: %w", err)
+		}
+		if err := json.Unmarshal(data, settings); err != nil {
+			return nil, fmt.Errorf("failed to read email inbox settings: %w", err)
+		}
+	}
+	return settings, nil
+}
+
+// normalizeAllowedSenders validates sender entries: addresses, or domains
+// written as @example.com
+func normalizeAllowedSenders(entries []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" || seen[entry] {
+			continue
+		}
+		if domain, ok := strings.CutPrefix(entry, "@"); ok {
+			if domain == "" || strings.ContainsAny(domain, "@ ") || !strings.Contains(domain, ".") {
+				return nil, fmt.Errorf("%w: %q is not a domain", ErrInvalidEmailInbox, entry)
+			}
+		} else if address, err := mail.ParseAddress(entry); err != nil || address.Address != entry {
+			return nil, fmt.Errorf("%w: %q is not an email address", ErrInvalidEmailInbox, entry)
+		}
+		seen[entry] = true
+		normalized = append(normalized, entry)
+	}
+	return normalized, nil
+}
+
+// senderAllowed matches a lower-cased sender against allowed addresses and @domains
+func senderAllowed(sender string, allowed []string) bool {
+	at := strings.LastIndex(sender, "@")
+	for _, entry := range allowed {
+		if entry == sender || (at >= 0 && strings.HasPrefix(entry, "@") && entry == sender[at:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *EmailIngestionService) createAuditLog(ctx context.Context, log *models.AuditLog) {
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailIngestion_UploaderNeedsAuthenticatedSender(t *testing.T) {
+	ctx := context.Background()
+	tenantID, submitterID := uuid.New(), uuid.New()
+	user := models.User{ID: uuid.New(), TenantID: tenantID, Email: "ada@example.com", IsActive: true}
+	userRepo := &memoryUserRepo{users: map[uuid.UUID]models.User{user.ID: user}}
+	service := NewEmailIngestionService(nil, userRepo, nil, nil, nil, EmailIngestionServiceConfig{})
+
+	closed := &EmailInboxSettings{Enabled: true}
+	allowList := &EmailInboxSettings{Enabled: true, SubmitterID: &submitterID, AllowedSenders: []string{"@example.com"}}
+
+	// A user is only trusted with an SPF or DKIM pass
+	uploaderID, refused := service.uploader(ctx, tenantID, closed, &InboundEmail{From: "ada@example.com", SenderAuthenticated: true})
+	assert.Equal(t, user.ID, uploaderID)
+	assert.Empty(t, refused)
+
+	uploaderID, refused = service.uploader(ctx, tenantID, closed, &InboundEmail{From: "ada@example.com"})
+	assert.Equal(t, uuid.Nil, uploaderID)
+	assert.Equal(t, "sender not authenticated by SPF or DKIM", refused)
+
+	// Without a verdict the allow-list still applies
+	uploaderID, refused = service.uploader(ctx, tenantID, allowList, &InboundEmail{From: "ada@example.com"})
+	assert.Equal(t, submitterID, uploaderID)
+	assert.Empty(t, refused)
+
+	// A failed verdict is refused outright
+	_, refused = service.uploader(ctx, tenantID, allowList, &InboundEmail{From: "ada@example.com", SenderAuthFailed: true})
+	assert.Equal(t, "sender failed SPF and DKIM", refused)
+
+	_, refused = service.uploader(ctx, tenantID, closed, &InboundEmail{From: "eve@example.net", SenderAuthenticated: true})
+	assert.Equal(t, "sender not allowed", refused)
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"net/mail"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// Tenant settings keys for outgoing email
+const (
+	tenantEmailFromNameSetting    = "email_from_name"
+	tenantEmailFromAddressSetting = "email_from_address" // Must be on a verified domain
+	tenantEmailReplyToSetting     = "email_reply_to"
+)
+
+// MailerConfig holds configuration for transactional email
+type MailerConfig struct {
+	FromAddress     string   // Platform default, e.g. "Archivus <no-reply@archivus.app>"
+	VerifiedDomains []string // Domains tenants may send from; others fall back to FromAddress
+	AppURL          string   // Base URL of the web app for links in emails
+	ProductName     string   // Defaults to "Archivus"
+}
+
+// TaskAssignmentEmail describes a workflow task assigned to the recipient
+type TaskAssignmentEmail struct {
+	AssigneeName string
+	TaskID       uuid.UUID
+	TaskType     string
+	DocumentName string
+	Message      string // Optional; replaces the default wording
+	DueDate      *time.Time
+}
+
+// ShareLinkEmail describes a share link sent to an external recipient
+type ShareLinkEmail struct {
+	SenderName   string
+	DocumentName string
+	Token        string
+	Message      string // Optional note from the sender
+	ExpiresAt    *time.Time
+}
+
+// Mailer renders transactional email from templates and sends it from the
+// tenant's configured address. It implements EmailService, and ChannelSender
+// so queued email notifications share the same templates.
+type Mailer struct {
+	tenantRepo repositories.TenantRepository
+	transport  EmailTransport
+	config     MailerConfig
+}
+
+var (
+	_ EmailService  = (*Mailer)(nil)
+	_ ChannelSender = (*Mailer)(nil)
+)
+
+// NewMailer creates a new mailer
+func NewMailer(tenantRepo repositories.TenantRepository, transport EmailTransport, config MailerConfig) (*Mailer, error) {
+	if _, err := mail.ParseAddress(config.FromAddress); err != nil {
+		return nil, fmt.Errorf("invalid default from address: %w", err)
+	}
+	if config.ProductName == "" {
+		config.ProductName = "Archivus"
+	}
+	config.AppURL = strings.TrimRight(config.AppURL, "/")
+
+	return &Mailer{
+		tenantRepo: tenantRepo,
+		transport:  transport,
+		config:     config,
+	}, nil
+}
+
+// emailContent is the data every template renders
+type emailContent struct {
+	Subject     string
+	Greeting    string
+	Lines       []string
+	ActionLabel string
+	ActionURL   string
+	Footer      string
+	ProductName string
+}
+
+var emailTextTemplate = texttemplate.Must(texttemplate.New("email").Parse(`{{if .Greeting}}{{.Greeting}}
+
+{{end}}{{range .Lines}}{{.}}
+
+{{end}}{{if .ActionURL}}{{.ActionLabel}}: {{.ActionURL}}
+
+{{end}}{{if .Footer}}{{.Footer}}
+
+{{end}}--
+{{.ProductName}}
+`))
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; color: #1f2933; line-height: 1.5;">
+<div style="max-width: 560px; margin: 0 auto; padding: 24px;">
+{{if .Greeting}}<p>{{.Greeting}}</p>{{end}}
+{{range .Lines}}<p>{{.}}</p>
+{{end}}
+{{if .ActionURL}}<p style="margin: 28px 0;"><a href="{{.ActionURL}}" style="background: #2563eb; color: #ffffff; padding: 10px 18px; border-radius: 6px; text-decoration: none;">{{.ActionLabel}}</a></p>{{end}}
+{{if .Footer}}<p style="color: #6b7280; font-size: 13px;">{{.Footer}}</p>{{end}}
+<p style="color: #9ca3af; font-size: 12px;">{{.ProductName}}</p>
+</div>
+</body>
+</html>
+`))
+
+// SendEmailVerification sends the email verification link
+func (m *Mailer) SendEmailVerification(ctx context.Context, tenantID uuid.UUID, email, token string) error {
+	return m.send(ctx, tenantID, email, emailContent{
+		Subject:     "Verify your email address",
+		Lines:       []string{"Please confirm this is your email address to finish setting up your account."},
+		ActionLabel: "Verify email",
+		ActionURL:   m.link("/verify-email", url.Values{"token": {token}}),
+		Footer:      "If you didn't create an account, you can ignore this email.",
+	})
+}
+
+// SendPasswordReset sends the password reset link
+func (m *Mailer) SendPasswordReset(ctx context.Context, tenantID uuid.UUID, email, token string) error {
+	return m.send(ctx, tenantID, email, emailContent{
+		Subject:     "Reset your password",
+		Lines:       []string{"We received a request to reset your password."},
+		ActionLabel: "Reset password",
+		ActionURL:   m.link("/reset-password", url.Values{"token": {token}}),
+		Footer:      "If you didn't request a password reset, you can ignore this email; your password won't change.",
+	})
+}
+
+// SendWelcomeEmail welcomes a newly created user
+func (m *Mailer) SendWelcomeEmail(ctx context.Context, tenantID uuid.UUID, email, name string) error {
+	return m.send(ctx, tenantID, email, emailContent{
+		Subject:     "Welcome to " + m.config.ProductName,
+		Greeting:    greeting(name),
+		Lines:       []string{"Your account is ready. Upload documents, route them for approval and find anything in seconds."},
+		ActionLabel: "Sign in",
+		ActionURL:   m.link("/login", nil),
+	})
+}
+
+// SendTaskAssignment tells a user about a workflow task assigned to them
+func (m *Mailer) SendTaskAssignment(ctx context.Context, tenantID uuid.UUID, email string, task TaskAssignmentEmail) error {
+	message := task.Message
+	if message == "" {
+		message = fmt.Sprintf("You have a new %s task for %s.", task.TaskType, task.DocumentName)
+	}
+	lines := []string{message}
+	if task.DueDate != nil {
+		lines = append(lines, "Due "+task.DueDate.UTC().Format("Monday, January 2, 2006"))
+	}
+
+	return m.send(ctx, tenantID, email, emailContent{
+		Subject:     fmt.Sprintf("New task: %s", task.DocumentName),
+		Greeting:    greeting(task.AssigneeName),
+		Lines:       lines,
+		ActionLabel: "Open task",
+		ActionURL:   m.link("/tasks/"+task.TaskID.String(), nil),
+	})
+}
+
+// SendShareLink sends a document share link to a recipient
+func (m *Mailer) SendShareLink(ctx context.Context, tenantID uuid.UUID, email string, link ShareLinkEmail) error {
+	sender := link.SenderName
+	if sender == "" {
+		sender = "Someone"
+	}
+
+	lines := []string{fmt.Sprintf("%s shared %q with you.", sender, link.DocumentName)}
+	if link.Message != "" {
+		lines = append(lines, link.Message)
+	}
+	footer := ""
+	if link.ExpiresAt != nil {
+		footer = "This link expires " + link.ExpiresAt.UTC().Format("January 2, 2006 at 15:04 UTC") + "."
+	}
+
+	return m.send(ctx, tenantID, email, emailContent{
+		Subject:     fmt.Sprintf("%s shared a document with you", sender),
+		Lines:       lines,
+		ActionLabel: "View document",
+		ActionURL:   m.link("/share/"+url.PathEscape(link.Token), nil),
+		Footer:      footer,
+	})
+}
+
+// Send delivers a queued email notification (ChannelSender implementation).
+// Task assignments use the task template; everything else is sent as is.
+func (m *Mailer) Send(ctx context.Context, message ChannelMessage) error {
+	if message.Type == NotificationTaskAssignment {
+		if taskID, err := uuid.Parse(fmt.Sprint(message.Data["task_id"])); err == nil {
+			return m.send(ctx, message.TenantID, message.Recipient, emailContent{
+				Subject:     message.Title,
+				Greeting:    greeting(message.RecipientName),
+				Lines:       []string{message.Message},
+				ActionLabel: "Open task",
+				ActionURL:   m.link("/tasks/"+taskID.String(), nil),
+			})
+		}
+	}
+
+	return m.send(ctx, message.TenantID, message.Recipient, emailContent{
+		Subject:     message.Title,
+		Greeting:    greeting(message.RecipientName),
+		Lines:       []string{message.Message},
+		ActionLabel: "Open " + m.config.ProductName,
+		ActionURL:   m.link("/notifications", nil),
+		Footer:      "You can change which notifications you receive by email in your settings.",
+	})
+}
+
+// Helper methods
+
+func (m *Mailer) send(ctx context.Context, tenantID uuid.UUID, to string, content emailContent) error {
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+	content.ProductName = m.config.ProductName
+
+	var text, html bytes.Buffer
+	if err := emailTextTemplate.Execute(&text, content); err != nil {
+		return fmt.Errorf("failed to render email: %w", err)
+	}
+	if err := emailHTMLTemplate.Execute(&html, content); err != nil {
+		return fmt.Errorf("failed to render email: %w", err)
+	}
+
+	from, replyTo := m.senderFor(ctx, tenantID)
+	return m.transport.SendEmail(ctx, EmailMessage{
+		From:     from,
+		To:       recipient.Address,
+		ReplyTo:  replyTo,
+		Subject:  content.Subject,
+		TextBody: text.String(),
+		HTMLBody: html.String(),
+	})
+}
+
+// senderFor resolves the tenant's from and reply-to addresses. A custom from
+// address is only used on a verified domain; otherwise the tenant's display
+// name is sent with the platform address.
+func (m *Mailer) senderFor(ctx context.Context, tenantID uuid.UUID) (string, string) {
+	defaultFrom, _ := mail.ParseAddress(m.config.FromAddress)
+	if tenantID == uuid.Nil {
+		return defaultFrom.String(), ""
+	}
+
+	tenant, err := m.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return defaultFrom.String(), ""
+	}
+
+	from := *defaultFrom
+	if name, _ := tenant.Settings[tenantEmailFromNameSetting].(string); name != "" {
+		from.Name = name
+	} else {
+		from.Name = tenant.Name
+	}
+	if address, _ := tenant.Settings[tenantEmailFromAddressSetting].(string); address != "" {
+		if parsed, err := mail.ParseAddress(address); err == nil && m.isVerifiedDomain(parsed.Address) {
+			from.Address = parsed.Address
+		}
+	}
+
+	replyTo := ""
+	if address, _ := tenant.Settings[tenantEmailReplyToSetting].(string); address != "" {
+		if parsed, err := mail.ParseAddress(address); err == nil {
+			replyTo = parsed.Address
+		}
+	}
+
+	return from.String(), replyTo
+}
+
+func (m *Mailer) isVerifiedDomain(address string) bool {
+	domain := strings.ToLower(address[strings.LastIndex(address, "@")+1:])
+	for _, verified := range m.config.VerifiedDomains {
+		if strings.EqualFold(strings.TrimSpace(verified), domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Mailer) link(path string, query url.Values) string {
+	link := m.config.AppURL + path
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
+
+func greeting(name string) string {
+	if name == "" {
+		return "Hello,"
+	}
+	return "Hi " + name + ","
+}
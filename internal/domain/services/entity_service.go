@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidEntityType  = errors.New("entity type must be person, organization, location, date, amount or email")
+	ErrInvalidEntityValue = errors.New("entity value is required")
+)
+
+// Limits for entity facets
+const (
+	defaultEntityFacets = 50
+	maxEntityFacets     = 500
+)
+
+// maxEntityValueRunes is the longest entity value indexed
+const maxEntityValueRunes = 255
+
+// entityValueTrim is the punctuation and quotes trimmed from around
+// entity values
+const entityValueTrim = ` .,;:!?"'()[]{}“”‘’«»`
+
+// entityTypesByKey maps the keys of extracted entities to their types
+var entityTypesByKey = map[string]models.EntityType{
+	"people":        models.EntityPerson,
+	"organizations": models.EntityOrganization,
+	"locations":     models.EntityLocation,
+	"dates":         models.EntityDate,
+	"amounts":       models.EntityAmount,
+	"emails":        models.EntityEmail,
+}
+
+// EntityService browses documents by the named entities they mention, such
+// as every document mentioning Acme Corp. Entities are indexed by
+// entity_extraction jobs and by users correcting them.
+type EntityService struct {
+	entityRepo      repositories.EntityRepository
+	documentService *DocumentService
+}
+
+// EntityFacetParams narrow entity facets to a type and to values containing
+// Search
+type EntityFacetParams struct {
+	Type   models.EntityType
+	Search string
+	Limit  int
+}
+
+// NewEntityService creates a new entity service
+func NewEntityService(entityRepo repositories.EntityRepository, documentService *DocumentService) *EntityService {
+	return &EntityService{
+		entityRepo:      entityRepo,
+		documentService: documentService,
+	}
+}
+
+// Facets counts the documents the user can read that mention each entity,
+// most mentioned first
+func (s *EntityService) Facets(ctx context.Context, tenantID, userID uuid.UUID, params EntityFacetParams) ([]repositories.EntityFacet, error) {
+	if params.Type != "" && !isKnownEntityType(params.Type) {
+		return nil, ErrInvalidEntityType
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultEntityFacets
+	}
+	if params.Limit > maxEntityFacets {
+		params.Limit = maxEntityFacets
+	}
+
+	denied, viewerID, err := s.documentService.documentVisibility(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	facets, err := s.entityRepo.Facets(ctx, tenantID, repositories.EntityFacetQuery{
+		Type:             params.Type,
+		Search:           normalizeEntityValue(params.Search),
+		Limit:            params.Limit,
+		ExcludeFolderIDs: denied,
+		ViewerID:         viewerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if facets == nil {
+		facets = []repositories.EntityFacet{}
+	}
+	return facets, nil
+}
+
+// ListDocuments lists the documents the user can read that mention an
+// entity, however each of them writes it
+func (s *EntityService) ListDocuments(ctx context.Context, tenantID, userID uuid.UUID, entityType models.EntityType, value string, params repositories.ListParams) ([]models.Document, int64, error) {
+	if !isKnownEntityType(entityType) {
+		return nil, 0, ErrInvalidEntityType
+	}
+	normalized := normalizeEntityValue(value)
+	if normalized == "" {
+		return nil, 0, ErrInvalidEntityValue
+	}
+
+	return s.documentService.ListDocuments(ctx, tenantID, userID, repositories.DocumentFilters{
+		ListParams: params,
+		EntityType: entityType,
+		Entity:     normalized,
+	})
+}
+
+// Helper functions
+
+func isKnownEntityType(entityType models.EntityType) bool {
+	for _, known := range entityTypesByKey {
+		if entityType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// documentEntities turns a document's extracted entities into the rows that
+// index it. Keys other than the known entity types are skipped, and each
+// entity is indexed once however often it is listed.
+func documentEntities(entities map[string]interface{}) []models.Entity {
+	var rows []models.Entity
+	seen := make(map[string]bool)
+	for key, raw := range entities {
+		entityType, ok := entityTypesByKey[strings.ToLower(strings.TrimSpace(key))]
+		if !ok {
+			continue
+		}
+
+		values, ok := raw.([]interface{})
+		if !ok {
+			values = []interface{}{raw}
+		}
+		for _, value := range values {
+			var text string
+			switch v := value.(type) {
+			case string:
+				text = v
+			case float64:
+				text = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				continue // Objects and other shapes aren't indexed
+			}
+
+			normalized := normalizeEntityValue(text)
+			if normalized == "" || seen[string(entityType)+":"+normalized] {
+				continue
+			}
+			seen[string(entityType)+":"+normalized] = true
+			rows = append(rows, models.Entity{
+				Type:            entityType,
+				Value:           truncateRunes(strings.Join(strings.Fields(text), " "), maxEntityValueRunes),
+				NormalizedValue: normalized,
+			})
+		}
+	}
+	return rows
+}
+
+// normalizeEntityValue is the form entities are matched in, so that
+// "Acme Corp." and "ACME  corp" are the same entity
+func normalizeEntityValue(value string) string {
+	value = strings.ToLower(strings.Join(strings.Fields(value), " "))
+	value = strings.Trim(value, entityValueTrim)
+	return truncateRunes(strings.TrimSpace(value), maxEntityValueRunes)
+}
+
+// truncateRunes cuts s to at most n runes
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
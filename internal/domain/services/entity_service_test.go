@@ -0,0 +1,38 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeEntityValue(t *testing.T) {
+	assert.Equal(t, "acme corp", normalizeEntityValue("  ACME   Corp. "))
+	assert.Equal(t, "acme corp", normalizeEntityValue("“Acme Corp”"))
+	assert.Equal(t, "j.doe@acme.com", normalizeEntityValue("J.Doe@acme.com,"))
+	assert.Equal(t, "$1,200.00", normalizeEntityValue("$1,200.00"))
+	assert.Empty(t, normalizeEntityValue(" ... "))
+	assert.Len(t, []rune(normalizeEntityValue(strings.Repeat("é", maxEntityValueRunes+10))), maxEntityValueRunes)
+}
+
+func TestDocumentEntities(t *testing.T) {
+	entities := documentEntities(map[string]interface{}{
+		"organizations": []interface{}{"Acme Corp", "ACME corp.", "Globex"},
+		"amounts":       []interface{}{1200.5},
+		"emails":        "billing@acme.com",
+		"people":        []interface{}{map[string]interface{}{"name": "Jane"}, " "},
+		"products":      []interface{}{"Widget"},
+	})
+
+	byValue := make(map[string]models.Entity)
+	for _, entity := range entities {
+		byValue[entity.NormalizedValue] = entity
+	}
+	assert.Len(t, entities, 4)
+	assert.Equal(t, models.Entity{Type: models.EntityOrganization, Value: "Acme Corp", NormalizedValue: "acme corp"}, byValue["acme corp"])
+	assert.Equal(t, models.EntityOrganization, byValue["globex"].Type)
+	assert.Equal(t, models.EntityAmount, byValue["1200.5"].Type)
+	assert.Equal(t, models.EntityEmail, byValue["billing@acme.com"].Type)
+}
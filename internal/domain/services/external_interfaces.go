@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
 
@@ -20,6 +21,47 @@ type StorageService interface {
 	GetPublicURL(bucketName, filePath string) string
 }
 
+// StorageLister is implemented by storage backends that can enumerate their
+// files, which storage reconciliation needs
+type StorageLister interface {
+	List(ctx context.Context, prefix string) ([]StoredObject, error)
+}
+
+// StorageTierer is implemented by storage backends with a cheaper, slower
+// cold tier for archived files. Files keep their paths when moved, so they
+// can still be read and deleted.
+type StorageTierer interface {
+	MoveToCold(ctx context.Context, path string) error
+}
+
+// StoredObject is a file in storage
+type StoredObject struct {
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"` // Zero when storage doesn't report it
+}
+
+// ErrStoredObjectNotFound is returned by StorageUploader.Stat for paths
+// nothing has been uploaded to
+var ErrStoredObjectNotFound = errors.New("stored object not found")
+
+// StorageUploader is implemented by storage backends that clients can upload
+// to directly, so large files don't pass through the API server
+type StorageUploader interface {
+	// PresignUpload reserves a new path for the tenant's file and returns a
+	// URL the client uploads it to
+	PresignUpload(ctx context.Context, tenantID uuid.UUID, filename, contentType string, expiry time.Duration) (*PresignedUpload, error)
+	Stat(ctx context.Context, path string) (*StoredObject, error)
+}
+
+// PresignedUpload is where and how a client uploads a file directly to storage
+type PresignedUpload struct {
+	Path    string            `json:"-"`
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers,omitempty"` // To send with the upload
+}
+
 // StorageParams contains parameters for storing files
 type StorageParams struct {
 	TenantID    uuid.UUID
@@ -30,6 +72,44 @@ type StorageParams struct {
 	BucketName  string // Supabase bucket name
 }
 
+// VirusScanner scans file content for malware (ClamAV)
+type VirusScanner interface {
+	Scan(ctx context.Context, content io.Reader) (*VirusScanResult, error)
+}
+
+// VirusScanResult is a scanner's verdict on a file
+type VirusScanResult struct {
+	Infected bool
+	Threat   string // Signature name, when infected
+}
+
+// PDFStamper draws a stamp onto the pages of a PDF
+type PDFStamper interface {
+	Stamp(ctx context.Context, pdf []byte, stamp PDFStamp) ([]byte, error)
+}
+
+// Where a stamp goes on the page
+const (
+	StampPositionTopRight    = "top_right"
+	StampPositionTopLeft     = "top_left"
+	StampPositionBottomRight = "bottom_right"
+	StampPositionBottomLeft  = "bottom_left"
+)
+
+// Which pages are stamped
+const (
+	StampPagesFirst = "first"
+	StampPagesLast  = "last"
+	StampPagesAll   = "all"
+)
+
+// PDFStamp is a box of text drawn onto a PDF
+type PDFStamp struct {
+	Lines    []string // The first is set larger, as the title
+	Position string   // Defaults to top right
+	Pages    string   // Defaults to the first page
+}
+
 // AIService interface for AI/ML operations
 type AIService interface {
 	ExtractText(ctx context.Context, filePath string) (string, error)
@@ -42,11 +122,70 @@ type AIService interface {
 	PerformOCR(ctx context.Context, filePath string) (string, error)
 }
 
-// EmailService interface for email operations
+// EmailService interface for transactional email. tenantID selects the
+// tenant's from address; uuid.Nil sends from the platform default.
 type EmailService interface {
-	SendEmailVerification(ctx context.Context, email, token string) error
-	SendPasswordReset(ctx context.Context, email, token string) error
-	SendWelcomeEmail(ctx context.Context, email, name string) error
+	SendEmailVerification(ctx context.Context, tenantID uuid.UUID, email, token string) error
+	SendPasswordReset(ctx context.Context, tenantID uuid.UUID, email, token string) error
+	SendWelcomeEmail(ctx context.Context, tenantID uuid.UUID, email, name string) error
+	SendTaskAssignment(ctx context.Context, tenantID uuid.UUID, email string, task TaskAssignmentEmail) error
+	SendShareLink(ctx context.Context, tenantID uuid.UUID, email string, link ShareLinkEmail) error
+}
+
+// EmailTransport delivers a rendered email (SMTP, SES)
+type EmailTransport interface {
+	SendEmail(ctx context.Context, message EmailMessage) error
+}
+
+// EmailMessage is a rendered email ready for delivery
+type EmailMessage struct {
+	From     string // RFC 5322 address, e.g. "Acme <docs@acme.com>"
+	To       string
+	ReplyTo  string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// PushProvider interface for mobile push delivery (FCM, APNs)
+type PushProvider interface {
+	SendPush(ctx context.Context, deviceToken string, message PushMessage) error
+}
+
+// PushMessage contains the payload delivered to a device
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// SMSProvider interface for SMS delivery (Twilio-compatible)
+type SMSProvider interface {
+	SendSMS(ctx context.Context, to, body string) (*SMSResult, error)
+}
+
+// SMSResult describes a message accepted by the SMS provider
+type SMSResult struct {
+	MessageID string
+	Segments  int
+	Cost      float64 // 0 when the provider hasn't priced the message yet
+	Currency  string
+}
+
+// ChannelSender delivers queued notifications over an external channel (email, Slack)
+type ChannelSender interface {
+	Send(ctx context.Context, message ChannelMessage) error
+}
+
+// ChannelMessage is a notification addressed to one channel recipient
+type ChannelMessage struct {
+	TenantID      uuid.UUID
+	Recipient     string // Email address or Slack webhook URL
+	RecipientName string
+	Type          string
+	Title         string
+	Message       string
+	Data          map[string]interface{}
 }
 
 // SupabaseAuthService interface for Supabase authentication operations
@@ -72,6 +211,7 @@ type SupabaseAuthService interface {
 	AdminGetUser(userID string) (*SupabaseUser, error)
 	AdminUpdateUser(userID string, updates map[string]interface{}) (*SupabaseUser, error)
 	AdminDeleteUser(userID string) error
+	AdminSignOutAll(userID string) error // Revokes every refresh token of the user
 }
 
 // SupabaseUser represents a user from Supabase Auth
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrFilingSuggestionNotFound = errors.New("document has no filing suggestion")
+	ErrFilingSuggestionAccepted = errors.New("filing suggestion was already accepted")
+	ErrInvalidFilingAccept      = errors.New("apply takes folder, category and tags")
+)
+
+// Parts of a filing suggestion that can be applied
+const (
+	FilingApplyFolder   = "folder"
+	FilingApplyCategory = "category"
+	FilingApplyTags     = "tags"
+)
+
+// maxFilingTaxonomyItems is how many of each of the tenant's folders,
+// categories and most used tags a provider chooses from
+const maxFilingTaxonomyItems = 200
+
+// FilingTaxonomy is what a tenant files documents under, which filing
+// suggestions choose from
+type FilingTaxonomy struct {
+	Folders    []string // Paths, e.g. /Finance/Invoices
+	Categories []string
+	Tags       []string
+}
+
+// FilingAdvice is where a provider suggests filing a document
+type FilingAdvice struct {
+	FolderPath string   `json:"folder_path"`
+	Category   string   `json:"category"`
+	Tags       []string `json:"tags"`
+	Confidence float64  `json:"confidence"`
+	Reason     string   `json:"reason"`
+}
+
+// filingTags are the tag names a suggestion stores
+type filingTags struct {
+	Names []string `json:"names"`
+}
+
+// FilingSuggestionService shows users where AI suggests filing their
+// documents and applies the suggestions they accept: moving the document to
+// the folder and adding the category and tags, creating tags the tenant
+// doesn't have yet.
+type FilingSuggestionService struct {
+	suggestionRepo  repositories.FilingSuggestionRepository
+	documentRepo    repositories.DocumentRepository
+	folderRepo      repositories.FolderRepository
+	tagRepo         repositories.TagRepository
+	categoryRepo    repositories.CategoryRepository
+	documentService *DocumentService
+}
+
+// FilingSuggestionView is a document's filing suggestion with the names of
+// the folder and category it suggests. Folders and categories deleted since
+// are left out.
+type FilingSuggestionView struct {
+	DocumentID   uuid.UUID                     `json:"document_id"`
+	FolderID     *uuid.UUID                    `json:"folder_id,omitempty"`
+	FolderPath   string                        `json:"folder_path,omitempty"`
+	CategoryID   *uuid.UUID                    `json:"category_id,omitempty"`
+	CategoryName string                        `json:"category_name,omitempty"`
+	Tags         []string                      `json:"tags"`
+	Confidence   float64                       `json:"confidence"`
+	Reason       string                        `json:"reason,omitempty"`
+	Status       models.FilingSuggestionStatus `json:"status"`
+	ReviewedBy   *uuid.UUID                    `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time                    `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time                     `json:"created_at"`
+}
+
+// AcceptFilingParams picks the parts of a suggestion to apply: folder,
+// category and tags. All of them are applied when none are picked.
+type AcceptFilingParams struct {
+	Apply []string `json:"apply,omitempty"`
+}
+
+// NewFilingSuggestionService creates a new filing suggestion service
+func NewFilingSuggestionService(
+	suggestionRepo repositories.FilingSuggestionRepository,
+	documentRepo repositories.DocumentRepository,
+	folderRepo repositories.FolderRepository,
+	tagRepo repositories.TagRepository,
+	categoryRepo repositories.CategoryRepository,
+	documentService *DocumentService,
+) *FilingSuggestionService {
+	return &FilingSuggestionService{
+		suggestionRepo:  suggestionRepo,
+		documentRepo:    documentRepo,
+		folderRepo:      folderRepo,
+		tagRepo:         tagRepo,
+		categoryRepo:    categoryRepo,
+		documentService: documentService,
+	}
+}
+
+// GetSuggestion returns where AI suggests filing a document
+func (s *FilingSuggestionService) GetSuggestion(ctx context.Context, tenantID, userID, documentID uuid.UUID) (*FilingSuggestionView, error) {
+	document, err := s.documentService.getTenantDocument(ctx, documentID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.documentService.CheckDocumentAccess(ctx, document, userID, models.DocPermRead); err != nil {
+		return nil, err
+	}
+
+	suggestion, err := s.suggestionRepo.GetByDocument(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if suggestion == nil {
+		return nil, ErrFilingSuggestionNotFound
+	}
+	return s.view(ctx, suggestion)
+}
+
+// Accept applies a document's filing suggestion. Moving the document needs
+// write access to it and to the suggested folder.
+func (s *FilingSuggestionService) Accept(ctx context.Context, tenantID, userID, documentID uuid.UUID, params AcceptFilingParams) (*models.Document, error) {
+	apply, err := params.parts()
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := s.documentService.getTenantDocument(ctx, documentID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.documentService.CheckDocumentAccess(ctx, document, userID, models.DocPermWrite); err != nil {
+		return nil, err
+	}
+
+	suggestion, err := s.suggestionRepo.GetByDocument(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if suggestion == nil {
+		return nil, ErrFilingSuggestionNotFound
+	}
+	if suggestion.Status == models.FilingSuggestionAccepted {
+		return nil, ErrFilingSuggestionAccepted
+	}
+
+	if apply[FilingApplyFolder] && suggestion.FolderID != nil {
+		if _, err := s.folderRepo.GetForTenant(ctx, tenantID, *suggestion.FolderID); err != nil {
+			return nil, ErrFolderNotFound
+		}
+		if err := s.documentService.CheckFolderAccess(ctx, *suggestion.FolderID, tenantID, userID, models.FolderPermWrite); err != nil {
+			return nil, err
+		}
+		document.FolderID = suggestion.FolderID
+		document.Folder = nil // The preloaded folder would otherwise be saved back
+		document.UpdatedBy = &userID
+		document.UpdatedAt = time.Now()
+		if err := s.documentRepo.Update(ctx, document); err != nil {
+			return nil, fmt.Errorf("failed to update document: %w", err)
+		}
+	}
+
+	var tagIDs, categoryIDs []uuid.UUID
+	if apply[FilingApplyCategory] && suggestion.CategoryID != nil {
+		categoryIDs = append(categoryIDs, *suggestion.CategoryID)
+	}
+	if apply[FilingApplyTags] {
+		var tags filingTags
+		if err := fromJSONB(suggestion.Tags, &tags); err != nil {
+			return nil, fmt.Errorf("failed to decode suggested tags: %w", err)
+		}
+		for _, name := range tags.Names {
+			tag, err := s.tagRepo.GetByName(ctx, tenantID, name)
+			if err != nil {
+				tag = &models.Tag{TenantID: tenantID, Name: name, IsAIGenerated: true}
+				if err := s.tagRepo.Create(ctx, tag); err != nil {
+					continue // Skip this tag if creation fails
+				}
+			}
+			tagIDs = append(tagIDs, tag.ID)
+		}
+	}
+	if err := s.documentRepo.AddLabels(ctx, tenantID, []uuid.UUID{document.ID}, tagIDs, categoryIDs); err != nil {
+		return nil, fmt.Errorf("failed to label document: %w", err)
+	}
+
+	now := time.Now()
+	suggestion.Status = models.FilingSuggestionAccepted
+	suggestion.ReviewedBy = &userID
+	suggestion.ReviewedAt = &now
+	if err := s.suggestionRepo.Update(ctx, suggestion); err != nil {
+		return nil, err
+	}
+
+	s.documentService.createAuditLog(ctx, tenantID, userID, document.ID, models.AuditUpdate, "Filing suggestion accepted")
+
+	return document, nil
+}
+
+// Helper methods
+
+func (s *FilingSuggestionService) view(ctx context.Context, suggestion *models.FilingSuggestion) (*FilingSuggestionView, error) {
+	var tags filingTags
+	if err := fromJSONB(suggestion.Tags, &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode suggested tags: %w", err)
+	}
+	if tags.Names == nil {
+		tags.Names = []string{}
+	}
+
+	view := &FilingSuggestionView{
+		DocumentID: suggestion.DocumentID,
+		Tags:       tags.Names,
+		Confidence: suggestion.Confidence,
+		Reason:     suggestion.Reason,
+		Status:     suggestion.Status,
+		ReviewedBy: suggestion.ReviewedBy,
+		ReviewedAt: suggestion.ReviewedAt,
+		CreatedAt:  suggestion.CreatedAt,
+	}
+	if suggestion.FolderID != nil {
+		if folder, err := s.folderRepo.GetForTenant(ctx, suggestion.TenantID, *suggestion.FolderID); err == nil {
+			view.FolderID = &folder.ID
+			view.FolderPath = folder.Path
+		}
+	}
+	if suggestion.CategoryID != nil {
+		if category, err := s.categoryRepo.GetForTenant(ctx, suggestion.TenantID, *suggestion.CategoryID); err == nil {
+			view.CategoryID = &category.ID
+			view.CategoryName = category.Name
+		}
+	}
+	return view, nil
+}
+
+// parts returns the parts of the suggestion to apply
+func (p AcceptFilingParams) parts() (map[string]bool, error) {
+	all := []string{FilingApplyFolder, FilingApplyCategory, FilingApplyTags}
+	if len(p.Apply) == 0 {
+		p.Apply = all
+	}
+	parts := make(map[string]bool, len(all))
+	for _, part := range p.Apply {
+		if !slices.Contains(all, part) {
+			return nil, ErrInvalidFilingAccept
+		}
+		parts[part] = true
+	}
+	return parts, nil
+}
+
+// Helper functions
+
+// newFilingTaxonomy lists the tenant's folders, categories and tags for a
+// provider to choose from
+func newFilingTaxonomy(folders []models.Folder, categories []models.Category, tags []models.Tag) FilingTaxonomy {
+	var taxonomy FilingTaxonomy
+	for _, folder := range folders {
+		if len(taxonomy.Folders) < maxFilingTaxonomyItems {
+			taxonomy.Folders = append(taxonomy.Folders, folder.Path)
+		}
+	}
+	for _, category := range categories {
+		if len(taxonomy.Categories) < maxFilingTaxonomyItems {
+			taxonomy.Categories = append(taxonomy.Categories, category.Name)
+		}
+	}
+	for _, tag := range tags {
+		if len(taxonomy.Tags) < maxFilingTaxonomyItems {
+			taxonomy.Tags = append(taxonomy.Tags, tag.Name)
+		}
+	}
+	return taxonomy
+}
+
+// matchFilingAdvice finds the folder and category a provider named among
+// the tenant's, ignoring case and the slashes around folder paths
+func matchFilingAdvice(advice *FilingAdvice, folders []models.Folder, categories []models.Category) (*models.Folder, *models.Category) {
+	var folder *models.Folder
+	var category *models.Category
+	if path := strings.Trim(strings.TrimSpace(advice.FolderPath), "/"); path != "" {
+		for i := range folders {
+			if strings.EqualFold(strings.Trim(folders[i].Path, "/"), path) {
+				folder = &folders[i]
+				break
+			}
+		}
+	}
+	if name := strings.TrimSpace(advice.Category); name != "" {
+		for i := range categories {
+			if strings.EqualFold(categories[i].Name, name) {
+				category = &categories[i]
+				break
+			}
+		}
+	}
+	return folder, category
+}
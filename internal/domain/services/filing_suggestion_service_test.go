@@ -0,0 +1,54 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchFilingAdvice(t *testing.T) {
+	folders := []models.Folder{{ID: uuid.New(), Path: "/Finance"}, {ID: uuid.New(), Path: "/Finance/Invoices"}}
+	categories := []models.Category{{ID: uuid.New(), Name: "Accounts Payable"}}
+
+	// Case and the slashes around paths don't matter
+	folder, category := matchFilingAdvice(&FilingAdvice{FolderPath: "finance/invoices/", Category: " accounts payable"}, folders, categories)
+	require.NotNil(t, folder)
+	require.NotNil(t, category)
+	assert.Equal(t, folders[1].ID, folder.ID)
+	assert.Equal(t, categories[0].ID, category.ID)
+
+	// Folders and categories the tenant doesn't have aren't suggested
+	folder, category = matchFilingAdvice(&FilingAdvice{FolderPath: "/Legal", Category: "Contracts"}, folders, categories)
+	assert.Nil(t, folder)
+	assert.Nil(t, category)
+	folder, _ = matchFilingAdvice(&FilingAdvice{FolderPath: "/"}, folders, categories)
+	assert.Nil(t, folder)
+}
+
+func TestNewFilingTaxonomy(t *testing.T) {
+	taxonomy := newFilingTaxonomy(
+		[]models.Folder{{Path: "/Finance"}},
+		[]models.Category{{Name: "Accounts Payable"}},
+		[]models.Tag{{Name: "acme"}, {Name: "q3"}},
+	)
+	assert.Equal(t, FilingTaxonomy{Folders: []string{"/Finance"}, Categories: []string{"Accounts Payable"}, Tags: []string{"acme", "q3"}}, taxonomy)
+
+	folders := make([]models.Folder, maxFilingTaxonomyItems+10)
+	assert.Len(t, newFilingTaxonomy(folders, nil, nil).Folders, maxFilingTaxonomyItems)
+}
+
+func TestAcceptFilingParams_Parts(t *testing.T) {
+	parts, err := AcceptFilingParams{}.parts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{FilingApplyFolder: true, FilingApplyCategory: true, FilingApplyTags: true}, parts)
+
+	parts, err = AcceptFilingParams{Apply: []string{FilingApplyTags}}.parts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{FilingApplyTags: true}, parts)
+
+	_, err = AcceptFilingParams{Apply: []string{"title"}}.parts()
+	assert.ErrorIs(t, err, ErrInvalidFilingAccept)
+}
@@ -0,0 +1,659 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrFolderIngestionDisabled = errors.New("watched folders of this type are not configured on this server")
+	ErrInvalidWatchedFolder    = errors.New("invalid watched folder")
+	ErrWatchedFolderNotFound   = errors.New("watched folder not found")
+	ErrTooManyWatchedFolders   = errors.New("too many watched folders")
+)
+
+// Watched folder types
+const (
+	WatchedFolderLocal = "local" // A directory under the server's watch root, e.g. a mounted network share
+	WatchedFolderSFTP  = "sftp"
+)
+
+// tenantWatchedFoldersSetting is the tenant settings key holding the folders
+const tenantWatchedFoldersSetting = "watched_folders"
+
+// maxWatchedFolders bounds the folders a tenant can watch
+const maxWatchedFolders = 20
+
+// WatchedFolder is a directory a tenant's scanners or systems drop files
+// into. New files are uploaded as Uploader and moved to ArchivePath, or to
+// FailedPath when they can never be uploaded.
+type WatchedFolder struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"` // local or sftp
+	Enabled     bool      `json:"enabled"`
+	Path        string    `json:"path"`                   // Relative to the tenant's directory under the watch root for local folders
+	ArchivePath string    `json:"archive_path,omitempty"` // Defaults to processed/ inside Path
+	FailedPath  string    `json:"failed_path,omitempty"`  // Defaults to failed/ inside Path
+
+	// SFTP endpoint; the server signs in with its own key, which the tenant
+	// authorizes for Username
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	HostKey  string `json:"host_key,omitempty"` // SHA256 fingerprint of the server's host key
+
+	FolderID   *uuid.UUID `json:"folder_id,omitempty"` // Where documents are filed; the root without one
+	UploaderID uuid.UUID  `json:"uploader_id"`
+	EnableAI   bool       `json:"enable_ai"`
+
+	// Sidecar reads the metadata files dropped next to documents; sidecar
+	// files are never uploaded themselves
+	Sidecar *SidecarTemplate `json:"sidecar,omitempty"`
+
+	// Kept by the ingestion worker
+	FilesIngested  int64      `json:"files_ingested"`
+	LastIngestedAt *time.Time `json:"last_ingested_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// IngestionConnector opens the directories watched folders point at
+type IngestionConnector interface {
+	Supports(folderType string) bool
+	// Open opens a tenant's folder; local folders are confined to the
+	// tenant's own directory under the watch root
+	Open(ctx context.Context, tenantID uuid.UUID, folder WatchedFolder) (IngestionDirectory, error)
+	// PublicKey is the SSH key tenants authorize on their SFTP servers,
+	// in authorized_keys format; empty without SFTP
+	PublicKey() string
+}
+
+// IngestionDirectory is an open watched folder
+type IngestionDirectory interface {
+	List() ([]IngestionFile, error)
+	Open(name string) (io.ReadCloser, error)
+	// Move moves a file into dir, which is created when missing, as newName
+	Move(name, dir, newName string) error
+	Close() error
+}
+
+// IngestionFile is a regular file in a watched folder
+type IngestionFile struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// FolderIngestionService uploads the files dropped into tenants' watched
+// folders, local or over SFTP
+type FolderIngestionService struct {
+	tenantRepo      repositories.TenantRepository
+	userRepo        repositories.UserRepository
+	folderRepo      repositories.FolderRepository
+	auditRepo       repositories.AuditLogRepository
+	documentService *DocumentService
+	connector       IngestionConnector
+	config          FolderIngestionServiceConfig
+}
+
+// FolderIngestionServiceConfig holds configuration for watched folders
+type FolderIngestionServiceConfig struct {
+	PollInterval    time.Duration // Defaults to a minute
+	MaxFilesPerPoll int           // Per folder; defaults to 50
+	// Files modified more recently may still be being written; defaults to 30s
+	SettleTime  time.Duration
+	PollTimeout time.Duration // Per folder; defaults to 5 minutes
+}
+
+// NewFolderIngestionService creates a new folder ingestion service. Without
+// a connector no folders can be watched.
+func NewFolderIngestionService(
+	tenantRepo repositories.TenantRepository,
+	userRepo repositories.UserRepository,
+	folderRepo repositories.FolderRepository,
+	auditRepo repositories.AuditLogRepository,
+	documentService *DocumentService,
+	connector IngestionConnector,
+	config FolderIngestionServiceConfig,
+) *FolderIngestionService {
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Minute
+	}
+	if config.MaxFilesPerPoll <= 0 {
+		config.MaxFilesPerPoll = 50
+	}
+	if config.SettleTime <= 0 {
+		config.SettleTime = 30 * time.Second
+	}
+	if config.PollTimeout <= 0 {
+		config.PollTimeout = 5 * time.Minute
+	}
+
+	return &FolderIngestionService{
+		tenantRepo:      tenantRepo,
+		userRepo:        userRepo,
+		folderRepo:      folderRepo,
+		auditRepo:       auditRepo,
+		documentService: documentService,
+		connector:       connector,
+		config:          config,
+	}
+}
+
+// PublicKey returns the SSH key SFTP servers must authorize, if any
+func (s *FolderIngestionService) PublicKey() string {
+	if s.connector == nil {
+		return ""
+	}
+	return s.connector.PublicKey()
+}
+
+// ListFolders returns the tenant's watched folders
+func (s *FolderIngestionService) ListFolders(ctx context.Context, tenantID uuid.UUID) ([]WatchedFolder, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	return watchedFolders(tenant)
+}
+
+// CreateFolder starts watching a folder for the tenant
+func (s *FolderIngestionService) CreateFolder(ctx context.Context, tenantID, createdBy uuid.UUID, folder WatchedFolder) (*WatchedFolder, error) {
+	if err := s.validateFolder(ctx, tenantID, &folder); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	folder.ID = uuid.New()
+	folder.CreatedBy = &createdBy
+	folder.UpdatedAt = &now
+	folder.FilesIngested, folder.LastIngestedAt, folder.LastError, folder.LastErrorAt = 0, nil, "", nil
+
+	err := s.updateFolders(ctx, tenantID, func(folders []WatchedFolder) ([]WatchedFolder, error) {
+		if len(folders) >= maxWatchedFolders {
+			return nil, ErrTooManyWatchedFolders
+		}
+		return append(folders, folder), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, tenantID, createdBy, folder.ID, models.AuditCreate, "Watched folder created", &folder)
+	return &folder, nil
+}
+
+// UpdateFolder changes a watched folder's settings; its ingestion history is kept
+func (s *FolderIngestionService) UpdateFolder(ctx context.Context, tenantID, updatedBy, folderID uuid.UUID, folder WatchedFolder) (*WatchedFolder, error) {
+	if err := s.validateFolder(ctx, tenantID, &folder); err != nil {
+		return nil, err
+	}
+
+	err := s.updateFolders(ctx, tenantID, func(folders []WatchedFolder) ([]WatchedFolder, error) {
+		for i := range folders {
+			if folders[i].ID != folderID {
+				continue
+			}
+			now := time.Now()
+			existing := folders[i]
+			folder.ID = existing.ID
+			folder.CreatedBy = existing.CreatedBy
+			folder.UpdatedAt = &now
+			folder.FilesIngested, folder.LastIngestedAt = existing.FilesIngested, existing.LastIngestedAt
+			folder.LastError, folder.LastErrorAt = existing.LastError, existing.LastErrorAt
+			folders[i] = folder
+			return folders, nil
+		}
+		return nil, ErrWatchedFolderNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, tenantID, updatedBy, folderID, models.AuditUpdate, "Watched folder updated", &folder)
+	return &folder, nil
+}
+
+// DeleteFolder stops watching a folder. Files left in it are not touched.
+func (s *FolderIngestionService) DeleteFolder(ctx context.Context, tenantID, deletedBy, folderID uuid.UUID) error {
+	err := s.updateFolders(ctx, tenantID, func(folders []WatchedFolder) ([]WatchedFolder, error) {
+		for i := range folders {
+			if folders[i].ID == folderID {
+				return append(folders[:i], folders[i+1:]...), nil
+			}
+		}
+		return nil, ErrWatchedFolderNotFound
+	})
+	if err != nil {
+		return err
+	}
+
+	s.createAuditLog(ctx, tenantID, deletedBy, folderID, models.AuditDelete, "Watched folder deleted", nil)
+	return nil
+}
+
+// PollTask is the scheduled task that ingests new files from watched folders
+func (s *FolderIngestionService) PollTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "watched_folders",
+		Interval: s.config.PollInterval,
+		Run: func(ctx context.Context) error {
+			_, err := s.PollAll(ctx)
+			return err
+		},
+	}
+}
+
+// PollAll ingests new files from every enabled watched folder and returns
+// how many were uploaded. A failing folder doesn't hold up the others; its
+// error is kept on the folder for the tenant's admins.
+func (s *FolderIngestionService) PollAll(ctx context.Context) (int, error) {
+	if s.connector == nil {
+		return 0, nil
+	}
+
+	tenants, err := s.tenantRepo.ListWithSetting(ctx, tenantWatchedFoldersSetting)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list tenants with watched folders: %w", err)
+	}
+
+	ingested := 0
+	for i := range tenants {
+		tenant := &tenants[i]
+		if !tenant.IsActive || tenant.DeletionScheduledFor != nil {
+			continue
+		}
+		folders, err := watchedFolders(tenant)
+		if err != nil {
+			continue
+		}
+		for _, folder := range folders {
+			if !folder.Enabled || !s.connector.Supports(folder.Type) {
+				continue
+			}
+			count, err := s.pollFolder(ctx, tenant.ID, folder)
+			ingested += count
+			s.recordPoll(ctx, tenant.ID, folder, count, err)
+			if ctx.Err() != nil {
+				return ingested, ctx.Err()
+			}
+		}
+	}
+	return ingested, nil
+}
+
+// pollFolder uploads the settled files of one folder and archives them.
+// Files that can never be uploaded are moved aside; other failures, like an
+// exceeded quota, leave the file for the next poll and end the folder's run,
+// as they usually affect every file.
+func (s *FolderIngestionService) pollFolder(ctx context.Context, tenantID uuid.UUID, folder WatchedFolder) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.PollTimeout)
+	defer cancel()
+
+	directory, err := s.connector.Open(ctx, tenantID, folder)
+	if err != nil {
+		return 0, err
+	}
+	defer directory.Close()
+
+	files, err := directory.List()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	byName := make(map[string]IngestionFile, len(files))
+	for _, file := range files {
+		byName[file.Name] = file
+	}
+
+	ingested, attempted := 0, 0
+	settled := time.Now().Add(-s.config.SettleTime)
+	for _, file := range files {
+		if attempted >= s.config.MaxFilesPerPoll || ctx.Err() != nil {
+			break
+		}
+		if !ingestibleFileName(file.Name) || file.ModTime.After(settled) {
+			continue
+		}
+
+		var sidecar *IngestionFile
+		if folder.Sidecar != nil {
+			if folder.Sidecar.isSidecar(file.Name) {
+				continue // Moved along with the file it describes
+			}
+			sidecar = folder.Sidecar.find(file.Name, byName)
+			if sidecar == nil && folder.Sidecar.Required {
+				continue
+			}
+			if sidecar != nil {
+				if sidecar.ModTime.After(settled) {
+					continue
+				}
+				delete(byName, sidecar.Name) // Not shared with another file of the same base name
+			}
+		}
+		attempted++
+
+		// Stamped names keep files dropped twice under one name apart
+		stamp := time.Now().UTC().Format("20060102T150405") + "_"
+		move := func(dir string) error {
+			if err := directory.Move(file.Name, dir, stamp+file.Name); err != nil {
+				return err
+			}
+			if sidecar != nil {
+				return directory.Move(sidecar.Name, dir, stamp+sidecar.Name)
+			}
+			return nil
+		}
+
+		if file.Size > s.documentService.config.MaxFileSize {
+			if err := move(folder.FailedPath); err != nil {
+				return ingested, fmt.Errorf("failed to move %s aside: %w", file.Name, err)
+			}
+			continue
+		}
+
+		err := s.ingestFile(ctx, tenantID, folder, directory, file, sidecar)
+		switch {
+		case err == nil:
+			ingested++
+			if err := move(folder.ArchivePath); err != nil {
+				// Left in place it would be uploaded again on every poll
+				return ingested, fmt.Errorf("failed to archive %s: %w", file.Name, err)
+			}
+		case errors.Is(err, ErrUnsupportedFormat), errors.Is(err, ErrDocumentTooLarge), errors.Is(err, ErrInvalidSidecar):
+			if err := move(folder.FailedPath); err != nil {
+				return ingested, fmt.Errorf("failed to move %s aside: %w", file.Name, err)
+			}
+		default:
+			return ingested, fmt.Errorf("failed to upload %s: %w", file.Name, err)
+		}
+	}
+	return ingested, nil
+}
+
+func (s *FolderIngestionService) ingestFile(ctx context.Context, tenantID uuid.UUID, folder WatchedFolder, directory IngestionDirectory, file IngestionFile, sidecar *IngestionFile) error {
+	params := UploadDocumentParams{
+		TenantID:  tenantID,
+		UserID:    folder.UploaderID,
+		FolderID:  folder.FolderID,
+		EnableAI:  folder.EnableAI,
+		EnableOCR: folder.EnableAI,
+	}
+
+	// The sidecar is read first, so a broken one fails before the upload
+	if sidecar != nil {
+		data, err := readSidecar(directory, *sidecar)
+		if err != nil {
+			return err
+		}
+		if params.SidecarFields, err = folder.Sidecar.apply(data, &params); err != nil {
+			return err
+		}
+	}
+	if params.CustomFields == nil {
+		params.CustomFields = make(map[string]interface{})
+	}
+	params.CustomFields["source"] = "watched_folder"
+	params.CustomFields["watched_folder"] = folder.Name
+	params.CustomFields["source_path"] = path.Join(folder.Path, file.Name)
+
+	reader, err := directory.Open(file.Name)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(io.LimitReader(reader, s.documentService.config.MaxFileSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	_, err = s.documentService.UploadFileContent(ctx, params, file.Name, detectFileContentType(file.Name, content), content)
+	if errors.Is(err, ErrDocumentExists) {
+		return nil // Already in the archive, so the file is done with
+	}
+	return err
+}
+
+// recordPoll keeps the folder's ingestion history. The tenant is only
+// written when something changed, not on every quiet poll.
+func (s *FolderIngestionService) recordPoll(ctx context.Context, tenantID uuid.UUID, polled WatchedFolder, ingested int, pollErr error) {
+	lastError := ""
+	if pollErr != nil {
+		lastError = pollErr.Error()
+	}
+	if ingested == 0 && lastError == polled.LastError {
+		return
+	}
+
+	err := s.updateFolders(context.WithoutCancel(ctx), tenantID, func(folders []WatchedFolder) ([]WatchedFolder, error) {
+		for i := range folders {
+			if folders[i].ID != polled.ID {
+				continue
+			}
+			now := time.Now()
+			if ingested > 0 {
+				folders[i].FilesIngested += int64(ingested)
+				folders[i].LastIngestedAt = &now
+			}
+			folders[i].LastError = lastError
+			folders[i].LastErrorAt = nil
+			if pollErr != nil {
+				folders[i].LastErrorAt = &now
+			}
+			return folders, nil
+		}
+		return nil, ErrWatchedFolderNotFound // Deleted while it was polled
+	})
+	if err != nil {
+		// Log but continue
+	}
+}
+
+// validateFolder normalizes a folder's settings and checks them against the
+// tenant and what the server can connect to
+func (s *FolderIngestionService) validateFolder(ctx context.Context, tenantID uuid.UUID, folder *WatchedFolder) error {
+	folder.Type = strings.ToLower(strings.TrimSpace(folder.Type))
+	if folder.Type != WatchedFolderLocal && folder.Type != WatchedFolderSFTP {
+		return fmt.Errorf("%w: type must be local or sftp", ErrInvalidWatchedFolder)
+	}
+	if s.connector == nil || !s.connector.Supports(folder.Type) {
+		return ErrFolderIngestionDisabled
+	}
+
+	folder.Name = strings.TrimSpace(folder.Name)
+	if folder.Name == "" || len(folder.Name) > 100 {
+		return fmt.Errorf("%w: name must be 1 to 100 characters", ErrInvalidWatchedFolder)
+	}
+
+	var err error
+	if folder.Path, err = cleanWatchedPath(folder.Type, folder.Path, ""); err != nil {
+		return err
+	}
+	if folder.ArchivePath, err = cleanWatchedPath(folder.Type, folder.ArchivePath, path.Join(folder.Path, "processed")); err != nil {
+		return err
+	}
+	if folder.FailedPath, err = cleanWatchedPath(folder.Type, folder.FailedPath, path.Join(folder.Path, "failed")); err != nil {
+		return err
+	}
+	if folder.ArchivePath == folder.Path || folder.FailedPath == folder.Path {
+		return fmt.Errorf("%w: processed files must be moved out of the watched path", ErrInvalidWatchedFolder)
+	}
+
+	if folder.Type == WatchedFolderSFTP {
+		folder.Host = strings.ToLower(strings.TrimSpace(folder.Host))
+		folder.Username = strings.TrimSpace(folder.Username)
+		folder.HostKey = strings.TrimSpace(folder.HostKey)
+		if folder.Port == 0 {
+			folder.Port = 22
+		}
+		switch {
+		case folder.Host == "" || strings.ContainsAny(folder.Host, "/@: "):
+			return fmt.Errorf("%w: host must be a host name or IPv4 address", ErrInvalidWatchedFolder)
+		case folder.Port < 1 || folder.Port > 65535:
+			return fmt.Errorf("%w: port must be between 1 and 65535", ErrInvalidWatchedFolder)
+		case folder.Username == "":
+			return fmt.Errorf("%w: username is required", ErrInvalidWatchedFolder)
+		case !strings.HasPrefix(folder.HostKey, "SHA256:"):
+			return fmt.Errorf("%w: host_key must be the SHA256 fingerprint of the server's host key", ErrInvalidWatchedFolder)
+		}
+	} else {
+		folder.Host, folder.Port, folder.Username, folder.HostKey = "", 0, "", ""
+	}
+
+	if folder.Sidecar != nil {
+		if err := normalizeSidecarTemplate(folder.Sidecar); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWatchedFolder, err)
+		}
+	}
+
+	if folder.FolderID != nil {
+		if _, err := s.folderRepo.GetForTenant(ctx, tenantID, *folder.FolderID); err != nil {
+			return ErrFolderNotFound
+		}
+	}
+	uploader, err := s.userRepo.GetByID(ctx, folder.UploaderID)
+	if err != nil || uploader.TenantID != tenantID || !uploader.IsActive {
+		return fmt.Errorf("%w: uploader must be an active user", ErrInvalidWatchedFolder)
+	}
+	return nil
+}
+
+// updateFolders applies change to the tenant's stored folders
+func (s *FolderIngestionService) updateFolders(ctx context.Context, tenantID uuid.UUID, change func([]WatchedFolder) ([]WatchedFolder, error)) error {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return ErrTenantNotFound
+	}
+	folders, err := watchedFolders(tenant)
+	if err != nil {
+		return err
+	}
+	if folders, err = change(folders); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(folders)
+	if err != nil {
+		return fmt.Errorf("failed to encode watched folders: %w", err)
+	}
+	var stored []interface{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to encode watched folders: %w", err)
+	}
+
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+	tenant.Settings[tenantWatchedFoldersSetting] = stored
+	tenant.UpdatedAt = time.Now()
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return fmt.Errorf("failed to update watched folders: %w", err)
+	}
+	return nil
+}
+
+func (s *FolderIngestionService) createAuditLog(ctx context.Context, tenantID, userID, folderID uuid.UUID, action models.AuditAction, message string, folder *WatchedFolder) {
+	details := models.JSONB{"message": message}
+	if folder != nil {
+		details["name"] = folder.Name
+		details["type"] = folder.Type
+		details["path"] = folder.Path
+		details["host"] = folder.Host
+		details["enabled"] = folder.Enabled
+	}
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   folderID,
+		Action:       action,
+		ResourceType: "watched_folder",
+		Details:      details,
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+func watchedFolders(tenant *models.Tenant) ([]WatchedFolder, error) {
+	var folders []WatchedFolder
+	if raw, ok := tenant.Settings[tenantWatchedFoldersSetting]; ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read watched folders: %w", err)
+		}
+		if err := json.Unmarshal(data, &folders); err != nil {
+			return nil, fmt.Errorf("failed to read watched folders: %w", err)
+		}
+	}
+	return folders, nil
+}
+
+// cleanWatchedPath normalizes a folder path. Local paths are relative to the
+// tenant's directory under the watch root and can't climb out of it; SFTP
+// paths are as on the server.
+func cleanWatchedPath(folderType, value, fallback string) (string, error) {
+	value = strings.TrimSpace(strings.ReplaceAll(value, "\\", "/"))
+	if value == "" {
+		value = fallback
+	}
+	if value == "" || strings.ContainsRune(value, 0) {
+		return "", fmt.Errorf("%w: path is required", ErrInvalidWatchedFolder)
+	}
+
+	if folderType == WatchedFolderLocal {
+		cleaned := strings.TrimPrefix(path.Clean("/"+value), "/")
+		if cleaned == "" {
+			cleaned = "."
+		}
+		return cleaned, nil
+	}
+	return path.Clean(value), nil
+}
+
+// ingestibleFileName skips hidden files and the partial files scanners and
+// SFTP clients write before renaming into place
+func ingestibleFileName(name string) bool {
+	if name == "" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~") {
+		return false
+	}
+	lower := strings.ToLower(name)
+	for _, suffix := range []string{".part", ".partial", ".tmp", ".filepart", ".crdownload", ".lock"} {
+		if strings.HasSuffix(lower, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// detectFileContentType goes by the file's extension, falling back to
+// sniffing its content
+func detectFileContentType(filename string, content []byte) string {
+	if byExtension := mime.TypeByExtension(strings.ToLower(path.Ext(filename))); byExtension != "" {
+		if mediaType, _, err := mime.ParseMediaType(byExtension); err == nil {
+			return mediaType
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	return mediaType
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanWatchedPath(t *testing.T) {
+	cleaned, err := cleanWatchedPath(WatchedFolderLocal, "../../etc/scans/", "")
+	require.NoError(t, err)
+	assert.Equal(t, "etc/scans", cleaned)
+
+	cleaned, err = cleanWatchedPath(WatchedFolderLocal, "", "scans/processed")
+	require.NoError(t, err)
+	assert.Equal(t, "scans/processed", cleaned)
+
+	cleaned, err = cleanWatchedPath(WatchedFolderLocal, "/", "")
+	require.NoError(t, err)
+	assert.Equal(t, ".", cleaned)
+
+	cleaned, err = cleanWatchedPath(WatchedFolderSFTP, `upload\inbox/`, "")
+	require.NoError(t, err)
+	assert.Equal(t, "upload/inbox", cleaned)
+
+	_, err = cleanWatchedPath(WatchedFolderSFTP, "  ", "")
+	assert.ErrorIs(t, err, ErrInvalidWatchedFolder)
+	_, err = cleanWatchedPath(WatchedFolderLocal, "scans\x00", "")
+	assert.ErrorIs(t, err, ErrInvalidWatchedFolder)
+}
+
+func TestIngestibleFileName(t *testing.T) {
+	for _, name := range []string{"invoice.pdf", "Scan 2024-01-02.TIFF", "notes"} {
+		assert.True(t, ingestibleFileName(name), name)
+	}
+	for _, name := range []string{"", ".DS_Store", "~$report.docx", "scan.pdf.part", "upload.FILEPART", "x.tmp", "a.crdownload"} {
+		assert.False(t, ingestibleFileName(name), name)
+	}
+}
+
+func TestDetectFileContentType(t *testing.T) {
+	assert.Equal(t, "application/pdf", detectFileContentType("Invoice.PDF", nil))
+	assert.Equal(t, "application/pdf", detectFileContentType("scan", []byte("%PDF-1.7\n")))
+	assert.Equal(t, "text/plain", detectFileContentType("README", []byte("hello")))
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthStatus summarizes the health of the instance or one dependency
+type HealthStatus string
+
+const (
+	HealthOK          HealthStatus = "ok"
+	HealthDegraded    HealthStatus = "degraded"    // A non-critical dependency is failing
+	HealthUnavailable HealthStatus = "unavailable" // A critical dependency is failing
+	HealthFailing     HealthStatus = "failing"     // Of a single dependency
+)
+
+// HealthCheck probes one dependency. Failing critical checks make the
+// instance unready; other failures only degrade it.
+type HealthCheck struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+// HealthChecker is implemented by infrastructure clients that can probe the
+// service behind them (storage backends, Supabase Auth)
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthServiceConfig holds configuration for health checks
+type HealthServiceConfig struct {
+	Timeout  time.Duration // Per check; defaults to 3 seconds
+	CacheTTL time.Duration // How long a report is reused; defaults to 5 seconds
+}
+
+// DependencyStatus is the result of one dependency's check
+type DependencyStatus struct {
+	Name      string       `json:"name"`
+	Status    HealthStatus `json:"status"`
+	Critical  bool         `json:"critical"`
+	LatencyMS int64        `json:"latency_ms"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// HealthReport is the instance's health: every dependency's status and, when
+// requested, the background workers of the fleet
+type HealthReport struct {
+	Status       HealthStatus       `json:"status"`
+	CheckedAt    time.Time          `json:"checked_at"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+	Workers      []WorkerStatus     `json:"workers,omitempty"`
+}
+
+// Ready reports whether every critical dependency is up
+func (r *HealthReport) Ready() bool {
+	return r.Status != HealthUnavailable
+}
+
+// HealthService checks the instance's dependencies for the health and
+// readiness endpoints. Reports are cached briefly, so frequent probes don't
+// hammer the dependencies.
+type HealthService struct {
+	checks     []HealthCheck
+	heartbeats *WorkerHeartbeatService
+	config     HealthServiceConfig
+
+	mu     sync.Mutex
+	cached *HealthReport
+}
+
+// NewHealthService creates a new health service. heartbeats may be nil when
+// workers aren't tracked.
+func NewHealthService(checks []HealthCheck, heartbeats *WorkerHeartbeatService, config HealthServiceConfig) *HealthService {
+	if config.Timeout <= 0 {
+		config.Timeout = 3 * time.Second
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = 5 * time.Second
+	}
+
+	return &HealthService{
+		checks:     checks,
+		heartbeats: heartbeats,
+		config:     config,
+	}
+}
+
+// CheckDependencies checks every dependency concurrently, each within the
+// timeout
+func (s *HealthService) CheckDependencies(ctx context.Context) *HealthReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached != nil && time.Since(s.cached.CheckedAt) < s.config.CacheTTL {
+		return s.cached
+	}
+
+	// The report is shared with other callers, so one caller going away
+	// mustn't fail its checks
+	ctx = context.WithoutCancel(ctx)
+
+	dependencies := make([]DependencyStatus, len(s.checks))
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			dependencies[i] = s.runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	s.cached = &HealthReport{
+		Status:       overallHealth(dependencies),
+		CheckedAt:    time.Now(),
+		Dependencies: dependencies,
+	}
+	return s.cached
+}
+
+// Check returns the dependency report along with the fleet's workers. A
+// failure to list workers degrades the report, since the database check
+// will show why.
+func (s *HealthService) Check(ctx context.Context) *HealthReport {
+	report := *s.CheckDependencies(ctx)
+	if s.heartbeats == nil {
+		return &report
+	}
+
+	workers, err := s.heartbeats.ListWorkers(ctx)
+	if err != nil {
+		if report.Status == HealthOK {
+			report.Status = HealthDegraded
+		}
+		return &report
+	}
+	report.Workers = workers
+	return &report
+}
+
+// Helper methods
+
+func (s *HealthService) runCheck(ctx context.Context, check HealthCheck) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	status := DependencyStatus{Name: check.Name, Status: HealthOK, Critical: check.Critical}
+	started := time.Now()
+	err := check.Check(ctx)
+	status.LatencyMS = time.Since(started).Milliseconds()
+	if err != nil {
+		status.Status = HealthFailing
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// Helper functions
+
+func overallHealth(dependencies []DependencyStatus) HealthStatus {
+	status := HealthOK
+	for _, dependency := range dependencies {
+		if dependency.Status == HealthOK {
+			continue
+		}
+		if dependency.Critical {
+			return HealthUnavailable
+		}
+		status = HealthDegraded
+	}
+	return status
+}
+
+// HTTPHealthCheck returns a check that requests url and passes when the
+// response status is below 500, i.e. when the service is up even if it
+// refuses an unauthenticated probe. Headers are sent with the request.
+func HTTPHealthCheck(client *http.Client, url string, headers map[string]string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryHeartbeatRepo keeps worker heartbeats in memory
+type memoryHeartbeatRepo struct {
+	mu         sync.Mutex
+	heartbeats map[string]models.WorkerHeartbeat
+}
+
+func (m *memoryHeartbeatRepo) Upsert(ctx context.Context, heartbeat *models.WorkerHeartbeat) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.heartbeats[heartbeat.InstanceID+"/"+heartbeat.Worker] = *heartbeat
+	return nil
+}
+
+func (m *memoryHeartbeatRepo) List(ctx context.Context) ([]models.WorkerHeartbeat, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	heartbeats := []models.WorkerHeartbeat{}
+	for _, heartbeat := range m.heartbeats {
+		heartbeats = append(heartbeats, heartbeat)
+	}
+	return heartbeats, nil
+}
+
+func (m *memoryHeartbeatRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestHealthService_CheckDependencies(t *testing.T) {
+	calls := 0
+	failing := errors.New("connection refused")
+	service := NewHealthService([]HealthCheck{
+		{Name: "postgres", Critical: true, Check: func(ctx context.Context) error { calls++; return nil }},
+		{Name: "claude_api", Check: func(ctx context.Context) error { return failing }},
+	}, nil, HealthServiceConfig{})
+
+	report := service.CheckDependencies(context.Background())
+	assert.Equal(t, HealthDegraded, report.Status)
+	assert.True(t, report.Ready())
+	require.Len(t, report.Dependencies, 2)
+	assert.Equal(t, HealthOK, report.Dependencies[0].Status)
+	assert.Equal(t, HealthFailing, report.Dependencies[1].Status)
+	assert.Equal(t, "connection refused", report.Dependencies[1].Error)
+
+	// Probes within the cache TTL reuse the report
+	service.CheckDependencies(context.Background())
+	assert.Equal(t, 1, calls)
+
+	// A failing critical dependency makes the instance unready
+	service = NewHealthService([]HealthCheck{
+		{Name: "redis", Critical: true, Check: func(ctx context.Context) error { return failing }},
+	}, nil, HealthServiceConfig{})
+	report = service.CheckDependencies(context.Background())
+	assert.Equal(t, HealthUnavailable, report.Status)
+	assert.False(t, report.Ready())
+}
+
+func TestHealthService_CheckTimeout(t *testing.T) {
+	service := NewHealthService([]HealthCheck{
+		{Name: "storage", Critical: true, Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}, nil, HealthServiceConfig{Timeout: 10 * time.Millisecond})
+
+	report := service.CheckDependencies(context.Background())
+	assert.Equal(t, HealthUnavailable, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Dependencies[0].Error)
+}
+
+func TestWorkerHeartbeats(t *testing.T) {
+	repo := &memoryHeartbeatRepo{heartbeats: make(map[string]models.WorkerHeartbeat)}
+	heartbeats := NewWorkerHeartbeatService(repo, WorkerHeartbeatConfig{FlushInterval: time.Second})
+
+	// Untracked contexts report nothing
+	reportPoll(startWorker(context.Background(), "webhook_delivery", time.Second), nil)
+	require.NoError(t, heartbeats.Flush(context.Background()))
+	assert.Empty(t, repo.heartbeats)
+
+	ctx := startWorker(heartbeats.Attach(context.Background()), "webhook_delivery", 10*time.Second)
+	reportPoll(ctx, errors.New("database unavailable"))
+	require.NoError(t, heartbeats.Flush(context.Background()))
+
+	// A stale worker is listed, but no longer live
+	require.NoError(t, repo.Upsert(context.Background(), &models.WorkerHeartbeat{
+		InstanceID: "gone", Worker: "notification_outbox", PollInterval: 15, LastPollAt: time.Now().Add(-time.Hour),
+	}))
+
+	service := NewHealthService(nil, heartbeats, HealthServiceConfig{})
+	report := service.Check(context.Background())
+	assert.Equal(t, HealthOK, report.Status)
+	require.Len(t, report.Workers, 2)
+
+	live := make(map[string]WorkerStatus)
+	for _, worker := range report.Workers {
+		live[worker.Worker] = worker
+	}
+	assert.True(t, live["webhook_delivery"].Live)
+	assert.Equal(t, 10, live["webhook_delivery"].PollInterval)
+	assert.Equal(t, "database unavailable", live["webhook_delivery"].LastError)
+	assert.False(t, live["notification_outbox"].Live)
+}
+
+func TestHTTPHealthCheck(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2023-06-01", r.Header.Get("anthropic-version"))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	check := HTTPHealthCheck(server.Client(), server.URL, map[string]string{"anthropic-version": "2023-06-01"})
+
+	// Up, even if it refuses the unauthenticated probe
+	assert.NoError(t, check(context.Background()))
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, check(context.Background()))
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrInvalidInboundEmail  = errors.New("invalid inbound email")
+	ErrInboundEmailTooLarge = errors.New("inbound email is too large")
+)
+
+// maxMIMEDepth bounds how deeply nested multipart bodies are read
+const maxMIMEDepth = 10
+
+// InboundEmail is a received email, as handed over by the inbound mail
+// provider
+type InboundEmail struct {
+	From       string   // Sender address
+	Recipients []string // Envelope recipients, or the To and Cc addresses without an envelope
+	Subject    string
+	MessageID  string
+
+	// SenderAuthenticated is set when the provider found the sender passed
+	// SPF or DKIM. Only then is the From address trusted to name a user.
+	SenderAuthenticated bool
+	// SenderAuthFailed is set when the provider found the sender failed
+	// both SPF and DKIM, so the From address is likely forged
+	SenderAuthFailed bool
+
+	Attachments []InboundAttachment
+}
+
+// InboundAttachment is a file attached to an inbound email
+type InboundAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// ParseInboundEmail reads a raw RFC 5322 message. Recipients are taken from
+// the To and Cc headers; providers that know the envelope should replace them.
+func ParseInboundEmail(raw io.Reader, maxSize int64) (*InboundEmail, error) {
+	data, err := io.ReadAll(io.LimitReader(raw, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrInboundEmailTooLarge
+	}
+
+	message, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInboundEmail, err)
+	}
+
+	email := &InboundEmail{
+		Subject:   decodeHeader(message.Header.Get("Subject")),
+		MessageID: strings.Trim(strings.TrimSpace(message.Header.Get("Message-Id")), "<>"),
+	}
+	if from, err := mail.ParseAddress(message.Header.Get("From")); err == nil {
+		email.From = from.Address
+	}
+	for _, header := range []string{"To", "Cc"} {
+		if list, err := message.Header.AddressList(header); err == nil {
+			for _, address := range list {
+				email.Recipients = append(email.Recipients, address.Address)
+			}
+		}
+	}
+
+	part := mimePart{header: message.Header, body: message.Body}
+	if err := collectAttachments(part, 0, &email.Attachments); err != nil {
+		return nil, err
+	}
+	return email, nil
+}
+
+// mimePart is a MIME entity: its headers and its still-encoded body
+type mimePart struct {
+	header map[string][]string
+	body   io.Reader
+}
+
+func (p mimePart) get(key string) string {
+	if values := p.header[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// collectAttachments walks the MIME tree, keeping the parts sent as files.
+// Inline images are skipped; they are signatures and logos far more often
+// than documents.
+func collectAttachments(part mimePart, depth int, attachments *[]InboundAttachment) error {
+	mediaType, params, err := mime.ParseMediaType(part.get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMIMEDepth || params["boundary"] == "" {
+			return fmt.Errorf("%w: malformed multipart body", ErrInvalidInboundEmail)
+		}
+		reader := multipart.NewReader(part.body, params["boundary"])
+		for {
+			child, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidInboundEmail, err)
+			}
+			if err := collectAttachments(mimePart{header: child.Header, body: child}, depth+1, attachments); err != nil {
+				return err
+			}
+		}
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(part.get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	filename = sanitizeAttachmentName(decodeHeader(filename))
+
+	switch {
+	case disposition == "attachment":
+	case filename == "":
+		return nil // Message text
+	case disposition == "inline" && strings.HasPrefix(mediaType, "image/"):
+		return nil
+	}
+
+	content, err := decodeTransferEncoding(part.get("Content-Transfer-Encoding"), part.body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInboundEmail, err)
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	if filename == "" {
+		filename = "attachment"
+		if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+			filename += extensions[0]
+		}
+	}
+
+	*attachments = append(*attachments, InboundAttachment{
+		Filename:    filename,
+		ContentType: attachmentContentType(mediaType, filename),
+		Content:     content,
+	})
+	return nil
+}
+
+func decodeTransferEncoding(encoding string, body io.Reader) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// Mailers wrap base64 lines; the decoder skips line breaks
+		return io.ReadAll(base64.NewDecoder(base64.StdEncoding, body))
+	case "quoted-printable":
+		return io.ReadAll(quotedprintable.NewReader(body))
+	default:
+		return io.ReadAll(body)
+	}
+}
+
+// attachmentContentType trusts the declared type unless it is the generic
+// binary type, which mailers send for anything they don't recognise
+func attachmentContentType(mediaType, filename string) string {
+	if mediaType != "" && mediaType != "application/octet-stream" && mediaType != "text/plain" {
+		return mediaType
+	}
+	if guessed := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))); guessed != "" {
+		guessed, _, _ = mime.ParseMediaType(guessed)
+		return guessed
+	}
+	if mediaType == "" {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+func decodeHeader(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// sanitizeAttachmentName drops any path from a sender-chosen file name
+func sanitizeAttachmentName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = strings.TrimSpace(filepath.Base(name))
+	if name == "." || name == "/" {
+		return ""
+	}
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if len(name) > 255 {
+		extension := filepath.Ext(name)
+		if len(extension) > 20 {
+			extension = ""
+		}
+		name = strings.ToValidUTF8(name[:255-len(extension)], "") + extension
+	}
+	return name
+}
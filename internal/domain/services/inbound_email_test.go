@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const inboundMessage = "From: Ada Lovelace <ada@example.com>\r\n" +
+	"To: docs+acme@in.archivus.test, Bob <bob@example.com>\r\n" +
+	"Cc: docs+globex@in.archivus.test\r\n" +
+	"Subject: =?utf-8?q?Invoice_f=C3=BCr_M=C3=A4rz?=\r\n" +
+	"Message-ID: <abc123@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/related; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Please file the attached.</p>\r\n" +
+	"--inner\r\n" +
+	"Content-Type: image/png; name=logo.png\r\n" +
+	"Content-Disposition: inline; filename=logo.png\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"iVBORw0KGgo=\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/octet-stream; name=\"../../invoice.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"../../invoice.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQK\r\n" +
+	"JSVFT0YK\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/csv\r\n" +
+	"Content-Disposition: attachment; filename*=utf-8''r%C3%A9sum%C3%A9.csv\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"a,b=\r\n" +
+	",c\r\n" +
+	"--outer--\r\n"
+
+func TestParseInboundEmail(t *testing.T) {
+	email, err := ParseInboundEmail(strings.NewReader(inboundMessage), 1<<20)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ada@example.com", email.From)
+	assert.Equal(t, []string{"docs+acme@in.archivus.test", "bob@example.com", "docs+globex@in.archivus.test"}, email.Recipients)
+	assert.Equal(t, "Invoice für März", email.Subject)
+	assert.Equal(t, "abc123@example.com", email.MessageID)
+
+	// The inline logo and the message text aren't attachments
+	require.Len(t, email.Attachments, 2)
+	assert.Equal(t, "invoice.pdf", email.Attachments[0].Filename)
+	assert.Equal(t, "application/pdf", email.Attachments[0].ContentType)
+	assert.Equal(t, "%PDF-1.4\n%%EOF\n", string(email.Attachments[0].Content))
+	assert.Equal(t, "résumé.csv", email.Attachments[1].Filename)
+	assert.Equal(t, "text/csv", email.Attachments[1].ContentType)
+	assert.Equal(t, "a,b,c", strings.TrimSpace(string(email.Attachments[1].Content)))
+
+	_, err = ParseInboundEmail(strings.NewReader(inboundMessage), 100)
+	assert.True(t, errors.Is(err, ErrInboundEmailTooLarge))
+
+	_, err = ParseInboundEmail(strings.NewReader("not an email"), 1<<20)
+	assert.True(t, errors.Is(err, ErrInvalidInboundEmail))
+}
+
+func TestEmailInboxAddresses(t *testing.T) {
+	service := NewEmailIngestionService(nil, nil, nil, nil, nil, EmailIngestionServiceConfig{Domain: "In.Archivus.test"})
+
+	subdomain, ok := service.inboxSubdomain("Docs+Acme@in.archivus.test")
+	assert.True(t, ok)
+	assert.Equal(t, "acme", subdomain)
+	for _, recipient := range []string{"docs@in.archivus.test", "docs+acme@example.com", "files+acme@in.archivus.test", "nonsense"} {
+		_, ok := service.inboxSubdomain(recipient)
+		assert.False(t, ok, recipient)
+	}
+
+	senders, err := normalizeAllowedSenders([]string{" Billing@Vendor.com ", "@supplier.co.uk", "billing@vendor.com", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing@vendor.com", "@supplier.co.uk"}, senders)
+	for _, invalid := range []string{"@", "@localhost", "not an address", "Bob <bob@example.com>"} {
+		_, err := normalizeAllowedSenders([]string{invalid})
+		assert.ErrorIs(t, err, ErrInvalidEmailInbox, invalid)
+	}
+
+	assert.True(t, senderAllowed("billing@vendor.com", senders))
+	assert.True(t, senderAllowed("ap@supplier.co.uk", senders))
+	assert.False(t, senderAllowed("ap@evil-supplier.co.uk", senders))
+	assert.False(t, senderAllowed("other@vendor.com", senders))
+}
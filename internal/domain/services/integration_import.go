@@ -0,0 +1,450 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// Why an import sync skipped a remote file. Skipped files are tried again
+// when they change.
+const (
+	importSkippedTooLarge    = "too_large"
+	importSkippedUnsupported = "unsupported"
+	importSkippedRemoved     = "document_removed" // Deleted from Archivus since it was imported
+	importSkippedLegalHold   = "legal_hold"
+)
+
+// dueSyncBatchSize bounds the syncs run per pass
+const dueSyncBatchSize = 20
+
+// importRun is a sync's progress through one run
+type importRun struct {
+	sync        *models.ImportSync
+	provider    IntegrationProvider
+	accessToken string
+	mapping     ConnectorMapping
+	items       map[string]models.ImportSyncItem // By remote ID
+	seen        map[string]bool                  // Remote IDs found this run
+	complete    bool                             // The whole tree was walked
+
+	attempted, imported, updated, skipped, deleted int64
+}
+
+// SyncTask is the scheduled task that runs due import syncs
+func (s *IntegrationService) SyncTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "integration_sync",
+		Interval: s.config.SyncInterval,
+		Run: func(ctx context.Context) error {
+			_, err := s.RunDueSyncs(ctx)
+			return err
+		},
+	}
+}
+
+// RunDueSyncs runs the syncs that are due and returns how many files were
+// imported or updated. A failing sync doesn't hold up the others; its error
+// is kept on the sync.
+func (s *IntegrationService) RunDueSyncs(ctx context.Context) (int, error) {
+	if s.aead == nil {
+		return 0, nil
+	}
+
+	syncs, err := s.integrationRepo.ListDueSyncs(ctx, time.Now(), dueSyncBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due import syncs: %w", err)
+	}
+
+	total := 0
+	for i := range syncs {
+		run, err := s.runSync(ctx, &syncs[i])
+		total += int(run.imported + run.updated)
+		s.recordRun(ctx, run, err)
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+	}
+	return total, nil
+}
+
+// runSync walks the sync's remote folder breadth first, mirroring its
+// subfolders and importing files that are new or changed since the last
+// run. A run stops after MaxFilesPerRun files or RunTimeout; the next run
+// carries on. Only a run that walked the whole tree reconciles the files
+// deleted remotely.
+func (s *IntegrationService) runSync(ctx context.Context, sync *models.ImportSync) (*importRun, error) {
+	run := &importRun{sync: sync}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, sync.TenantID)
+	if err != nil || !tenant.IsActive || tenant.DeletionScheduledFor != nil {
+		return run, ErrTenantNotFound
+	}
+	integration, err := s.integrationRepo.GetForTenant(ctx, sync.TenantID, sync.IntegrationID)
+	if err != nil {
+		return run, ErrIntegrationNotFound
+	}
+	if integration.Status == models.IntegrationError {
+		return run, ErrIntegrationTokenRevoked
+	}
+	if run.provider, err = s.fileProvider(integration.Provider); err != nil {
+		return run, err
+	}
+	mappings, err := connectorMappings(tenant)
+	if err != nil {
+		return run, err
+	}
+	run.mapping = mappings[integration.ID.String()]
+
+	runCtx, cancel := context.WithTimeout(ctx, s.config.RunTimeout)
+	defer cancel()
+
+	if run.accessToken, err = s.accessToken(runCtx, run.provider, integration); err != nil {
+		return run, err
+	}
+
+	items, err := s.integrationRepo.ListItems(runCtx, sync.ID)
+	if err != nil {
+		return run, err
+	}
+	run.items = make(map[string]models.ImportSyncItem, len(items))
+	for _, item := range items {
+		run.items[item.RemoteID] = item
+	}
+	run.seen = make(map[string]bool)
+
+	err = s.walk(runCtx, run)
+	if err == nil && run.complete {
+		err = s.reconcile(runCtx, run)
+	}
+	if err != nil && runCtx.Err() != nil && ctx.Err() == nil {
+		run.complete = false
+		return run, nil // Out of time; the next run carries on
+	}
+	return run, err
+}
+
+func (s *IntegrationService) walk(ctx context.Context, run *importRun) error {
+	type remoteFolder struct {
+		id       string
+		path     string
+		relative string // Within the synced folder
+		folderID *uuid.UUID
+	}
+	queue := []remoteFolder{{id: run.sync.RemoteFolderID, path: run.sync.RemoteFolderName, folderID: run.sync.TargetFolderID}}
+	visited := map[string]bool{run.sync.RemoteFolderID: true}
+
+	for len(queue) > 0 {
+		folder := queue[0]
+		queue = queue[1:]
+
+		children, err := run.provider.ListFolder(ctx, run.accessToken, folder.id)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", folder.path, err)
+		}
+
+		for _, child := range children {
+			run.seen[child.ID] = true
+			childPath := path.Join(folder.path, child.Name)
+			relativePath := path.Join(folder.relative, child.Name)
+			if child.IsFolder {
+				// Drive files can have several parents
+				if visited[child.ID] {
+					continue
+				}
+				visited[child.ID] = true
+
+				folderID, err := s.mirrorFolder(ctx, run, folder.folderID, child)
+				if err != nil {
+					return fmt.Errorf("failed to create folder for %s: %w", childPath, err)
+				}
+				queue = append(queue, remoteFolder{id: child.ID, path: childPath, relative: relativePath, folderID: folderID})
+				continue
+			}
+
+			// Excluded files aren't recorded, so they're imported once the
+			// mapping stops excluding them
+			if _, ok := run.items[child.ID]; !ok && run.mapping.excludes(relativePath) {
+				continue
+			}
+			if item, ok := run.items[child.ID]; ok && item.Version == child.Version {
+				continue
+			}
+			if run.attempted >= int64(s.config.MaxFilesPerRun) {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			run.attempted++
+
+			if err := s.importFile(ctx, run, folder.folderID, childPath, child); err != nil {
+				return fmt.Errorf("failed to import %s: %w", childPath, err)
+			}
+		}
+	}
+
+	run.complete = true
+	return nil
+}
+
+// mirrorFolder returns the Archivus folder for a remote folder, creating it
+// under parentID on first sight. A folder of the same name already there is
+// reused, so syncs into the same place share their folders.
+func (s *IntegrationService) mirrorFolder(ctx context.Context, run *importRun, parentID *uuid.UUID, remote RemoteItem) (*uuid.UUID, error) {
+	tenantID := run.sync.TenantID
+	if item, ok := run.items[remote.ID]; ok && item.FolderID != nil {
+		if _, err := s.folderRepo.GetForTenant(ctx, tenantID, *item.FolderID); err == nil {
+			return item.FolderID, nil
+		}
+	}
+
+	name := importFolderName(remote.Name)
+	folderPath := "/" + name
+	if parentID != nil {
+		parent, err := s.folderRepo.GetForTenant(ctx, tenantID, *parentID)
+		if err != nil {
+			return nil, ErrFolderNotFound
+		}
+		folderPath = parent.Path + "/" + name
+	}
+
+	folder, err := s.folderRepo.GetByPath(ctx, tenantID, folderPath)
+	if err != nil || folder == nil {
+		folder, err = s.documentService.CreateFolder(ctx, tenantID, run.sync.UploaderID, name,
+			"Imported from "+run.provider.Name(), parentID, "", "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	item := models.ImportSyncItem{
+		TenantID: tenantID,
+		SyncID:   run.sync.ID,
+		RemoteID: remote.ID,
+		IsFolder: true,
+		Version:  remote.Version,
+		FolderID: &folder.ID,
+	}
+	if err := s.saveItem(ctx, run, item); err != nil {
+		return nil, err
+	}
+	return &folder.ID, nil
+}
+
+// importFile uploads a new remote file, or stores a changed one as a new
+// version of the document it was imported as
+func (s *IntegrationService) importFile(ctx context.Context, run *importRun, folderID *uuid.UUID, remotePath string, remote RemoteItem) error {
+	item, seen := run.items[remote.ID]
+	item.TenantID = run.sync.TenantID
+	item.SyncID = run.sync.ID
+	item.RemoteID = remote.ID
+	item.Version = remote.Version
+	item.Skipped = ""
+
+	skip := func(reason string) error {
+		item.Skipped = reason
+		run.skipped++
+		return s.saveItem(ctx, run, item)
+	}
+
+	maxSize := s.documentService.config.MaxFileSize
+	if remote.Unsupported {
+		return skip(importSkippedUnsupported)
+	}
+	if remote.Size > maxSize {
+		return skip(importSkippedTooLarge)
+	}
+
+	reader, err := run.provider.Download(ctx, run.accessToken, remote)
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	content, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	if int64(len(content)) > maxSize {
+		return skip(importSkippedTooLarge)
+	}
+
+	contentType := remote.ContentType
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = detectFileContentType(remote.Name, content)
+	}
+
+	if seen && item.DocumentID != nil {
+		_, err := s.documentService.ReplaceFileContent(ctx, *item.DocumentID, run.sync.TenantID, run.sync.UploaderID,
+			contentType, content, "Updated in "+run.provider.Name())
+		switch {
+		case err == nil:
+			run.updated++
+		case errors.Is(err, ErrDocumentNotFound):
+			return skip(importSkippedRemoved)
+		case errors.Is(err, ErrDocumentOnLegalHold):
+			return skip(importSkippedLegalHold)
+		case errors.Is(err, ErrUnsupportedFormat):
+			return skip(importSkippedUnsupported)
+		case errors.Is(err, ErrDocumentTooLarge):
+			return skip(importSkippedTooLarge)
+		default:
+			return err
+		}
+		return s.saveItem(ctx, run, item)
+	}
+
+	document, err := s.documentService.UploadFileContent(ctx, UploadDocumentParams{
+		TenantID:           run.sync.TenantID,
+		UserID:             run.sync.UploaderID,
+		FolderID:           folderID,
+		DocumentType:       run.mapping.DocumentType,
+		Tags:               run.mapping.Tags,
+		Categories:         run.mapping.Categories,
+		EnableAI:           run.sync.EnableAI,
+		EnableOCR:          run.sync.EnableAI,
+		ProcessingPriority: ProcessingPriorityLow,
+		CustomFields:       importSourceFields(run.provider.Name(), remotePath, remote),
+	}, remote.Name, contentType, content)
+	switch {
+	case err == nil:
+		run.imported++
+		item.DocumentID = &document.ID
+	case errors.Is(err, ErrDocumentExists):
+		// Already in the archive, so the file is done with
+	case errors.Is(err, ErrUnsupportedFormat):
+		return skip(importSkippedUnsupported)
+	case errors.Is(err, ErrDocumentTooLarge):
+		return skip(importSkippedTooLarge)
+	default:
+		return err
+	}
+	return s.saveItem(ctx, run, item)
+}
+
+// reconcile forgets the files and folders no longer in the remote folder.
+// With a mapping that says so, the documents imported from deleted files
+// are moved to the trash; documents on legal hold stay.
+func (s *IntegrationService) reconcile(ctx context.Context, run *importRun) error {
+	var gone []string
+	for remoteID, item := range run.items {
+		if run.seen[remoteID] {
+			continue
+		}
+		if !item.IsFolder && item.DocumentID != nil && run.mapping.OnRemoteDelete == RemoteDeleteTrash {
+			err := s.documentService.DeleteDocument(ctx, *item.DocumentID, run.sync.TenantID, run.sync.UploaderID)
+			switch {
+			case err == nil:
+				run.deleted++
+			case errors.Is(err, ErrDocumentNotFound), errors.Is(err, ErrDocumentOnLegalHold), errors.Is(err, ErrDocumentAccessDenied):
+				// Already gone, or to be kept
+			default:
+				return fmt.Errorf("failed to trash document of deleted file: %w", err)
+			}
+		}
+		gone = append(gone, remoteID)
+	}
+
+	if err := s.integrationRepo.DeleteItems(context.WithoutCancel(ctx), run.sync.ID, gone); err != nil {
+		return err
+	}
+	for _, remoteID := range gone {
+		delete(run.items, remoteID)
+	}
+	return nil
+}
+
+func (s *IntegrationService) saveItem(ctx context.Context, run *importRun, item models.ImportSyncItem) error {
+	item.UpdatedAt = time.Now()
+	if item.ID == uuid.Nil {
+		item.ID = uuid.New()
+		item.CreatedAt = item.UpdatedAt
+	}
+	// Items are what keep files from being imported twice, so they're saved
+	// even when the run is out of time
+	if err := s.integrationRepo.SaveItem(context.WithoutCancel(ctx), &item); err != nil {
+		return err
+	}
+	run.items[item.RemoteID] = item
+	return nil
+}
+
+// recordRun keeps the run's counts on the sync and schedules its next run
+func (s *IntegrationService) recordRun(ctx context.Context, run *importRun, runErr error) {
+	sync := run.sync
+	now := time.Now()
+	sync.FilesImported += run.imported
+	sync.FilesUpdated += run.updated
+	sync.FilesSkipped += run.skipped
+	sync.FilesDeleted += run.deleted
+	sync.LastRunAt = &now
+	sync.LastError = ""
+	sync.UpdatedAt = now
+
+	next := now.Add(time.Duration(sync.IntervalMinutes) * time.Minute)
+	switch {
+	case runErr != nil && (errors.Is(runErr, context.Canceled) || errors.Is(runErr, context.DeadlineExceeded)):
+		// Interrupted, by shutdown or a slow provider; carry on next pass
+		sync.NextRunAt = &now
+	case runErr != nil:
+		sync.LastError = runErr.Error()
+		sync.NextRunAt = nil
+		sync.Status = models.ImportSyncFailed
+		// Scheduled syncs retry, unless they can't ever succeed as they are
+		if sync.Mode == models.ImportSyncScheduled && !errors.Is(runErr, ErrIntegrationTokenRevoked) &&
+			!errors.Is(runErr, ErrIntegrationNotFound) && !errors.Is(runErr, ErrIntegrationsDisabled) {
+			sync.NextRunAt = &next
+		}
+	case !run.complete:
+		sync.NextRunAt = &now // More to import; continues next pass
+	case sync.Mode == models.ImportSyncScheduled:
+		sync.Status = models.ImportSyncActive
+		sync.NextRunAt = &next
+	default:
+		sync.Status = models.ImportSyncCompleted
+		sync.NextRunAt = nil
+	}
+
+	if err := s.integrationRepo.UpdateSync(context.WithoutCancel(ctx), sync); err != nil {
+		// Log but continue
+	}
+}
+
+// importSourceFields are the custom fields keeping where a document was
+// imported from and the file's original metadata
+func importSourceFields(provider, remotePath string, remote RemoteItem) map[string]interface{} {
+	fields := map[string]interface{}{
+		"source":      provider,
+		"source_id":   remote.ID,
+		"source_path": remotePath,
+	}
+	if remote.WebURL != "" {
+		fields["source_url"] = remote.WebURL
+	}
+	if remote.Owner != "" {
+		fields["source_owner"] = remote.Owner
+	}
+	if remote.CreatedAt != nil {
+		fields["source_created_at"] = remote.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if remote.ModifiedAt != nil {
+		fields["source_modified_at"] = remote.ModifiedAt.UTC().Format(time.RFC3339)
+	}
+	return fields
+}
+
+// importFolderName makes a remote folder's name usable as a folder name
+func importFolderName(name string) string {
+	name = strings.TrimSpace(strings.ReplaceAll(name, "/", "-"))
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
@@ -0,0 +1,1082 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrIntegrationsDisabled          = errors.New("integrations are not configured on this server")
+	ErrInvalidIntegrationsKey        = errors.New("integrations encryption key must be 32 bytes, base64 encoded")
+	ErrUnknownIntegrationProvider    = errors.New("integration provider is not available")
+	ErrIntegrationNotFound           = errors.New("integration not found")
+	ErrInvalidOAuthState             = errors.New("authorization request is invalid or expired")
+	ErrIntegrationTokenRevoked       = errors.New("the provider no longer accepts the integration's authorization; reconnect it")
+	ErrIntegrationTokenDecryptFailed = errors.New("failed to decrypt integration token")
+	ErrImportSyncNotFound            = errors.New("import sync not found")
+	ErrInvalidImportSync             = errors.New("invalid import sync")
+	ErrInvalidConnectorMapping       = errors.New("invalid connector mapping")
+	ErrInvalidWebhookSignature       = errors.New("webhook notification signature is invalid")
+	ErrIntegrationHasNoFiles         = errors.New("integration does not provide files to import")
+)
+
+// RemoteRootFolder is the ID every provider accepts for the top of an account's drive
+const RemoteRootFolder = "root"
+
+// tenantIntegrationMappingsSetting is the tenant setting holding each
+// integration's ConnectorMapping, by integration ID
+const tenantIntegrationMappingsSetting = "integration_mappings"
+
+// What a sync does with a document whose remote file was deleted
+const (
+	RemoteDeleteKeep  = "keep"
+	RemoteDeleteTrash = "trash"
+)
+
+// IntegrationConnector connects a tenant's account of an external service.
+// Connectors authorize with OAuth 2.0 authorization codes and PKCE.
+type IntegrationConnector interface {
+	Name() string
+	// AuthURL is where the browser is sent to grant access
+	AuthURL(state, codeChallenge, redirectURL string) string
+	Exchange(ctx context.Context, code, codeVerifier, redirectURL string) (*OAuthToken, error)
+	// Refresh returns ErrIntegrationTokenRevoked when the grant is gone.
+	// Some providers rotate refresh tokens; the returned one replaces the old.
+	Refresh(ctx context.Context, refreshToken string) (*OAuthToken, error)
+	Account(ctx context.Context, accessToken string) (*RemoteAccount, error)
+}
+
+// IntegrationProvider connects to an external file service
+type IntegrationProvider interface {
+	IntegrationConnector
+	ListFolder(ctx context.Context, accessToken, folderID string) ([]RemoteItem, error)
+	Download(ctx context.Context, accessToken string, item RemoteItem) (io.ReadCloser, error)
+}
+
+// IntegrationCallbackAccount is implemented by providers that name the
+// connected account in the OAuth callback rather than through their API
+type IntegrationCallbackAccount interface {
+	CallbackAccountID(callback url.Values) string
+}
+
+// IntegrationWebhookReceiver is implemented by providers that notify of
+// changes. ParseWebhook returns ErrInvalidWebhookSignature for
+// notifications the provider didn't sign.
+type IntegrationWebhookReceiver interface {
+	ParseWebhook(header http.Header, body []byte) (*IntegrationWebhook, error)
+}
+
+// IntegrationWebhookSubscriber is implemented by providers whose webhooks
+// are created per folder rather than once for the app
+type IntegrationWebhookSubscriber interface {
+	// Subscribe asks for changes in the folder to be sent to address and
+	// returns the webhook's ID
+	Subscribe(ctx context.Context, accessToken, folderID, address string) (string, error)
+	Unsubscribe(ctx context.Context, accessToken, webhookID string) error
+}
+
+// IntegrationWebhook is a change notification: the accounts whose files
+// changed, or the webhooks that fired
+type IntegrationWebhook struct {
+	AccountIDs []string
+	WebhookIDs []string
+}
+
+// RemoteAccount is a connected provider account
+type RemoteAccount struct {
+	ID    string // The provider's ID, which its webhooks name
+	Email string // For accounting providers, the company's name
+}
+
+// OAuthToken is a provider's token response
+type OAuthToken struct {
+	AccessToken  string
+	RefreshToken string // Empty when the provider keeps the current one
+	ExpiresAt    time.Time
+}
+
+// RemoteItem is a file or folder in a provider's drive
+type RemoteItem struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"` // Files converted on download carry the converted extension
+	IsFolder    bool       `json:"is_folder"`
+	Version     string     `json:"version,omitempty"` // Changes whenever the content does
+	Size        int64      `json:"size,omitempty"`    // Zero when only known after download
+	ContentType string     `json:"content_type,omitempty"`
+	SourceType  string     `json:"-"` // The provider's own type, e.g. which Google Docs type to export
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ModifiedAt  *time.Time `json:"modified_at,omitempty"`
+	Owner       string     `json:"owner,omitempty"`
+	WebURL      string     `json:"web_url,omitempty"`
+	// Unsupported files, like Google Forms, have no content to import
+	Unsupported bool `json:"unsupported,omitempty"`
+}
+
+// IntegrationService connects tenants' Google Drive, OneDrive, Dropbox and
+// Box accounts and imports their folders, once or on a schedule. Providers
+// with change webhooks bring scheduled syncs forward when files change.
+// QuickBooks and Xero companies are connected the same way, for the
+// AccountingService to book bills in.
+type IntegrationService struct {
+	integrationRepo repositories.IntegrationRepository
+	tenantRepo      repositories.TenantRepository
+	userRepo        repositories.UserRepository
+	folderRepo      repositories.FolderRepository
+	auditRepo       repositories.AuditLogRepository
+	documentService *DocumentService
+	providers       map[string]IntegrationConnector
+	config          IntegrationServiceConfig
+
+	aead     cipher.AEAD
+	stateKey []byte
+
+	// Access tokens by integration, reused until they expire. Refreshes are
+	// serialized, as some providers' refresh tokens can be used only once.
+	tokensMu sync.Mutex
+	tokens   map[uuid.UUID]OAuthToken
+}
+
+// IntegrationServiceConfig holds configuration for integrations
+type IntegrationServiceConfig struct {
+	// RedirectURL is the OAuth callback registered with the providers, the
+	// public URL of /api/v1/integrations/oauth/callback
+	RedirectURL string
+	// ReturnURL is where the callback sends the browser once connected; the
+	// callback answers with JSON without one
+	ReturnURL string
+	// WebhookURL is the public URL of /api/v1/integrations/webhooks, for
+	// providers that subscribe per folder; without it they're polled only
+	WebhookURL         string
+	SyncInterval       time.Duration // How often due syncs are run; defaults to 5 minutes
+	MaxFilesPerRun     int           // Per sync; defaults to 200. Larger imports continue on the next run.
+	RunTimeout         time.Duration // Per sync; defaults to 15 minutes
+	MinIntervalMinutes int           // Shortest schedule allowed; defaults to 60
+	StateTTL           time.Duration // How long an authorization request is valid; defaults to 15 minutes
+}
+
+// NewIntegrationService creates a new integration service. encryptionKey
+// is a base64 encoded 32-byte key; without it, or without providers, no
+// integrations can be connected.
+func NewIntegrationService(
+	integrationRepo repositories.IntegrationRepository,
+	tenantRepo repositories.TenantRepository,
+	userRepo repositories.UserRepository,
+	folderRepo repositories.FolderRepository,
+	auditRepo repositories.AuditLogRepository,
+	documentService *DocumentService,
+	providers []IntegrationConnector,
+	encryptionKey string,
+	config IntegrationServiceConfig,
+) (*IntegrationService, error) {
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = 5 * time.Minute
+	}
+	if config.MaxFilesPerRun <= 0 {
+		config.MaxFilesPerRun = 200
+	}
+	if config.RunTimeout <= 0 {
+		config.RunTimeout = 15 * time.Minute
+	}
+	if config.MinIntervalMinutes <= 0 {
+		config.MinIntervalMinutes = 60
+	}
+	if config.StateTTL <= 0 {
+		config.StateTTL = 15 * time.Minute
+	}
+
+	service := &IntegrationService{
+		integrationRepo: integrationRepo,
+		tenantRepo:      tenantRepo,
+		userRepo:        userRepo,
+		folderRepo:      folderRepo,
+		auditRepo:       auditRepo,
+		documentService: documentService,
+		providers:       make(map[string]IntegrationConnector),
+		config:          config,
+		tokens:          make(map[uuid.UUID]OAuthToken),
+	}
+
+	if encryptionKey == "" {
+		return service, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encryptionKey)
+	if err != nil || len(raw) != 32 {
+		return nil, ErrInvalidIntegrationsKey
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create integrations cipher: %w", err)
+	}
+	if service.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, fmt.Errorf("failed to create integrations cipher: %w", err)
+	}
+
+	// Authorization requests are signed with a key of their own
+	mac := hmac.New(sha256.New, raw)
+	mac.Write([]byte("oauth-state"))
+	service.stateKey = mac.Sum(nil)
+
+	for _, provider := range providers {
+		service.providers[provider.Name()] = provider
+	}
+	return service, nil
+}
+
+// Providers returns the names of the providers tenants can connect
+func (s *IntegrationService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReturnURL is where the OAuth callback sends the browser, if anywhere
+func (s *IntegrationService) ReturnURL() string {
+	return s.config.ReturnURL
+}
+
+// AuthorizationURL starts connecting an account of the provider for the
+// tenant. The state carried through the provider identifies the request, so
+// the callback needs no session.
+func (s *IntegrationService) AuthorizationURL(tenantID, userID uuid.UUID, providerName string) (string, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := s.signState(oauthState{
+		TenantID:  tenantID,
+		UserID:    userID,
+		Provider:  provider.Name(),
+		ExpiresAt: time.Now().Add(s.config.StateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(s.codeVerifier(state)))
+	return provider.AuthURL(state, base64.RawURLEncoding.EncodeToString(challenge[:]), s.config.RedirectURL), nil
+}
+
+// CompleteConnection finishes an authorization request with the code the
+// provider returned, and the rest of its callback. Connecting an account
+// that is already connected replaces its authorization, which is how failed
+// integrations are repaired.
+func (s *IntegrationService) CompleteConnection(ctx context.Context, state, code string, callback url.Values) (*models.Integration, error) {
+	request, err := s.verifyState(state)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := s.provider(request.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(code) == "" {
+		return nil, ErrInvalidOAuthState
+	}
+
+	token, err := provider.Exchange(ctx, code, s.codeVerifier(state), s.config.RedirectURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authorize with %s: %w", provider.Name(), err)
+	}
+	if token.RefreshToken == "" {
+		return nil, fmt.Errorf("%s did not grant offline access", provider.Name())
+	}
+	account, err := provider.Account(ctx, token.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s account: %w", provider.Name(), err)
+	}
+	if named, ok := provider.(IntegrationCallbackAccount); ok {
+		if account.ID = named.CallbackAccountID(callback); account.ID == "" {
+			return nil, ErrInvalidOAuthState
+		}
+	}
+	encrypted, err := s.encrypt(token.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.integrationRepo.ListByTenant(ctx, request.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range existing {
+		integration := &existing[i]
+		if integration.Provider != provider.Name() || !sameAccount(integration, account) {
+			continue
+		}
+		integration.AccountEmail = account.Email
+		integration.ExternalAccountID = account.ID
+		integration.EncryptedToken = encrypted
+		s.forgetAccessToken(integration.ID)
+		integration.Status = models.IntegrationActive
+		integration.LastError = ""
+		integration.ConnectedBy = request.UserID
+		integration.UpdatedAt = now
+		if err := s.integrationRepo.Update(ctx, integration); err != nil {
+			return nil, err
+		}
+		s.createAuditLog(ctx, request.TenantID, request.UserID, integration.ID, models.AuditUpdate, "Integration reconnected", integration)
+		return integration, nil
+	}
+
+	integration := &models.Integration{
+		ID:                uuid.New(),
+		TenantID:          request.TenantID,
+		Provider:          provider.Name(),
+		AccountEmail:      account.Email,
+		ExternalAccountID: account.ID,
+		EncryptedToken:    encrypted,
+		Status:            models.IntegrationActive,
+		ConnectedBy:       request.UserID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := s.integrationRepo.Create(ctx, integration); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, request.TenantID, request.UserID, integration.ID, models.AuditCreate, "Integration connected", integration)
+	return integration, nil
+}
+
+// ListIntegrations returns the tenant's connected accounts
+func (s *IntegrationService) ListIntegrations(ctx context.Context, tenantID uuid.UUID) ([]models.Integration, error) {
+	return s.integrationRepo.ListByTenant(ctx, tenantID)
+}
+
+// DeleteIntegration disconnects an account and removes its syncs and
+// mapping. Imported documents stay.
+func (s *IntegrationService) DeleteIntegration(ctx context.Context, tenantID, userID, integrationID uuid.UUID) error {
+	integration, err := s.integrationRepo.GetForTenant(ctx, tenantID, integrationID)
+	if err != nil {
+		return ErrIntegrationNotFound
+	}
+	syncs, err := s.integrationRepo.ListSyncs(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	var webhooks []models.ImportSync
+	for _, sync := range syncs {
+		if sync.IntegrationID == integration.ID && sync.WebhookID != "" {
+			webhooks = append(webhooks, sync)
+		}
+	}
+	s.unsubscribe(ctx, integration, webhooks...)
+
+	if err := s.integrationRepo.Delete(ctx, integration.ID); err != nil {
+		return err
+	}
+	s.forgetAccessToken(integration.ID)
+	err = s.updateMappings(ctx, tenantID, func(mappings map[string]ConnectorMapping) error {
+		delete(mappings, integration.ID.String())
+		return nil
+	})
+	if err != nil {
+		// Log but continue
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, integration.ID, models.AuditDelete, "Integration disconnected", integration)
+	return nil
+}
+
+// BrowseFolder lists a folder of a connected account, for choosing what to import
+func (s *IntegrationService) BrowseFolder(ctx context.Context, tenantID, integrationID uuid.UUID, folderID string) ([]RemoteItem, error) {
+	integration, err := s.integrationRepo.GetForTenant(ctx, tenantID, integrationID)
+	if err != nil {
+		return nil, ErrIntegrationNotFound
+	}
+	provider, err := s.fileProvider(integration.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if folderID = strings.TrimSpace(folderID); folderID == "" {
+		folderID = RemoteRootFolder
+	}
+
+	accessToken, err := s.accessToken(ctx, provider, integration)
+	if err != nil {
+		return nil, err
+	}
+	return provider.ListFolder(ctx, accessToken, folderID)
+}
+
+// CreateImportSyncParams contains what to import and where to
+type CreateImportSyncParams struct {
+	TenantID         uuid.UUID
+	IntegrationID    uuid.UUID
+	RemoteFolderID   string
+	RemoteFolderName string
+	TargetFolderID   *uuid.UUID
+	UploaderID       uuid.UUID
+	EnableAI         bool
+	Mode             models.ImportSyncMode
+	IntervalMinutes  int
+	CreatedBy        uuid.UUID
+}
+
+// CreateSync imports a folder of a connected account. The first run starts
+// on the next sync pass. Scheduled syncs of providers with per-folder
+// webhooks get one, so changes bring their next run forward.
+func (s *IntegrationService) CreateSync(ctx context.Context, params CreateImportSyncParams) (*models.ImportSync, error) {
+	integration, err := s.integrationRepo.GetForTenant(ctx, params.TenantID, params.IntegrationID)
+	if err != nil {
+		return nil, ErrIntegrationNotFound
+	}
+	if _, err := s.fileProvider(integration.Provider); err != nil {
+		return nil, err
+	}
+
+	params.RemoteFolderID = strings.TrimSpace(params.RemoteFolderID)
+	params.RemoteFolderName = strings.TrimSpace(params.RemoteFolderName)
+	if params.RemoteFolderID == "" {
+		params.RemoteFolderID = RemoteRootFolder
+	}
+	if params.RemoteFolderName == "" {
+		params.RemoteFolderName = integration.AccountEmail
+	}
+	switch params.Mode {
+	case "", models.ImportSyncOnce:
+		params.Mode = models.ImportSyncOnce
+		params.IntervalMinutes = 0
+	case models.ImportSyncScheduled:
+		if params.IntervalMinutes < s.config.MinIntervalMinutes {
+			return nil, fmt.Errorf("%w: interval must be at least %d minutes", ErrInvalidImportSync, s.config.MinIntervalMinutes)
+		}
+	default:
+		return nil, fmt.Errorf("%w: mode must be once or scheduled", ErrInvalidImportSync)
+	}
+
+	if params.TargetFolderID != nil {
+		if _, err := s.folderRepo.GetForTenant(ctx, params.TenantID, *params.TargetFolderID); err != nil {
+			return nil, ErrFolderNotFound
+		}
+	}
+	uploader, err := s.userRepo.GetByID(ctx, params.UploaderID)
+	if err != nil || uploader.TenantID != params.TenantID || !uploader.IsActive {
+		return nil, fmt.Errorf("%w: uploader must be an active user", ErrInvalidImportSync)
+	}
+
+	now := time.Now()
+	sync := &models.ImportSync{
+		ID:               uuid.New(),
+		TenantID:         params.TenantID,
+		IntegrationID:    integration.ID,
+		RemoteFolderID:   params.RemoteFolderID,
+		RemoteFolderName: params.RemoteFolderName,
+		TargetFolderID:   params.TargetFolderID,
+		UploaderID:       params.UploaderID,
+		EnableAI:         params.EnableAI,
+		Mode:             params.Mode,
+		IntervalMinutes:  params.IntervalMinutes,
+		Status:           models.ImportSyncPending,
+		NextRunAt:        &now,
+		CreatedBy:        params.CreatedBy,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := s.integrationRepo.CreateSync(ctx, sync); err != nil {
+		return nil, err
+	}
+	if sync.Mode == models.ImportSyncScheduled {
+		s.subscribe(ctx, integration, sync)
+	}
+
+	s.createSyncAuditLog(ctx, params.CreatedBy, sync, models.AuditCreate, "Import sync created")
+	return sync, nil
+}
+
+// ListSyncs returns the tenant's import syncs
+func (s *IntegrationService) ListSyncs(ctx context.Context, tenantID uuid.UUID) ([]models.ImportSync, error) {
+	return s.integrationRepo.ListSyncs(ctx, tenantID)
+}
+
+// RunSyncNow schedules a sync's next run for the next sync pass. A finished
+// one-time import runs again, importing what changed since.
+func (s *IntegrationService) RunSyncNow(ctx context.Context, tenantID, userID, syncID uuid.UUID) (*models.ImportSync, error) {
+	sync, err := s.integrationRepo.GetSync(ctx, tenantID, syncID)
+	if err != nil {
+		return nil, ErrImportSyncNotFound
+	}
+
+	now := time.Now()
+	sync.NextRunAt = &now
+	if sync.Status == models.ImportSyncCompleted || sync.Status == models.ImportSyncFailed {
+		sync.Status = models.ImportSyncPending
+	}
+	sync.UpdatedAt = now
+	if err := s.integrationRepo.UpdateSync(ctx, sync); err != nil {
+		return nil, err
+	}
+
+	s.createSyncAuditLog(ctx, userID, sync, models.AuditUpdate, "Import sync run requested")
+	return sync, nil
+}
+
+// DeleteSync stops a sync and removes its webhook. Imported documents stay.
+func (s *IntegrationService) DeleteSync(ctx context.Context, tenantID, userID, syncID uuid.UUID) error {
+	sync, err := s.integrationRepo.GetSync(ctx, tenantID, syncID)
+	if err != nil {
+		return ErrImportSyncNotFound
+	}
+	if sync.WebhookID != "" {
+		if integration, err := s.integrationRepo.GetForTenant(ctx, tenantID, sync.IntegrationID); err == nil {
+			s.unsubscribe(ctx, integration, *sync)
+		}
+	}
+	if err := s.integrationRepo.DeleteSync(ctx, sync.ID); err != nil {
+		return err
+	}
+
+	s.createSyncAuditLog(ctx, userID, sync, models.AuditDelete, "Import sync deleted")
+	return nil
+}
+
+// HandleWebhook brings forward the scheduled syncs a provider's change
+// notification concerns, returning how many are now due. The next sync pass
+// imports what changed and reconciles what was deleted.
+func (s *IntegrationService) HandleWebhook(ctx context.Context, providerName string, header http.Header, body []byte) (int64, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return 0, err
+	}
+	receiver, ok := provider.(IntegrationWebhookReceiver)
+	if !ok {
+		return 0, ErrUnknownIntegrationProvider
+	}
+	notice, err := receiver.ParseWebhook(header, body)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var integrationIDs []uuid.UUID
+	for _, accountID := range notice.AccountIDs {
+		integrations, err := s.integrationRepo.ListByExternalAccount(ctx, provider.Name(), accountID)
+		if err != nil {
+			return 0, err
+		}
+		for _, integration := range integrations {
+			if integration.Status == models.IntegrationActive {
+				integrationIDs = append(integrationIDs, integration.ID)
+			}
+		}
+	}
+	triggered, err := s.integrationRepo.TriggerSyncs(ctx, integrationIDs, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, webhookID := range notice.WebhookIDs {
+		count, err := s.integrationRepo.TriggerWebhookSync(ctx, webhookID, now)
+		if err != nil {
+			return triggered, err
+		}
+		triggered += count
+	}
+	return triggered, nil
+}
+
+// ConnectorMapping is how a connected account's files are filed. It applies
+// to every sync of the integration.
+type ConnectorMapping struct {
+	// DocumentType, Tags and Categories are given to newly imported
+	// documents; without a type it's detected from the file
+	DocumentType models.DocumentType `json:"document_type,omitempty"`
+	Tags         []string            `json:"tags,omitempty"`
+	Categories   []string            `json:"categories,omitempty"`
+	// Exclude lists path.Match patterns for files not to import. Patterns
+	// with a slash match the path within the synced folder, others the name.
+	Exclude []string `json:"exclude,omitempty"`
+	// OnRemoteDelete is keep or trash: whether a document whose remote file
+	// was deleted stays, or is moved to the trash
+	OnRemoteDelete string     `json:"on_remote_delete"`
+	UpdatedBy      *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// GetMapping returns the integration's mapping; integrations without one
+// keep documents whose remote file was deleted
+func (s *IntegrationService) GetMapping(ctx context.Context, tenantID, integrationID uuid.UUID) (*ConnectorMapping, error) {
+	if _, err := s.integrationRepo.GetForTenant(ctx, tenantID, integrationID); err != nil {
+		return nil, ErrIntegrationNotFound
+	}
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	mappings, err := connectorMappings(tenant)
+	if err != nil {
+		return nil, err
+	}
+	mapping := mappings[integrationID.String()]
+	if mapping.OnRemoteDelete == "" {
+		mapping.OnRemoteDelete = RemoteDeleteKeep
+	}
+	return &mapping, nil
+}
+
+// UpdateMapping validates and stores the integration's mapping. It applies
+// from the next sync run on.
+func (s *IntegrationService) UpdateMapping(ctx context.Context, tenantID, userID, integrationID uuid.UUID, mapping ConnectorMapping) (*ConnectorMapping, error) {
+	integration, err := s.integrationRepo.GetForTenant(ctx, tenantID, integrationID)
+	if err != nil {
+		return nil, ErrIntegrationNotFound
+	}
+	if err := normalizeConnectorMapping(&mapping); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	mapping.UpdatedBy = &userID
+	mapping.UpdatedAt = &now
+	err = s.updateMappings(ctx, tenantID, func(mappings map[string]ConnectorMapping) error {
+		mappings[integration.ID.String()] = mapping
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, integration.ID, models.AuditUpdate, "Integration mapping updated", integration)
+	return &mapping, nil
+}
+
+// Helper methods
+
+// sameAccount matches a reconnected account by the provider's account ID,
+// or by email for integrations connected before IDs were kept
+func sameAccount(integration *models.Integration, account *RemoteAccount) bool {
+	if integration.ExternalAccountID != "" && account.ID != "" {
+		return integration.ExternalAccountID == account.ID
+	}
+	return strings.EqualFold(integration.AccountEmail, account.Email)
+}
+
+// subscribe creates a change webhook for a scheduled sync's folder when the
+// provider needs one. Without it the sync still runs on its schedule.
+func (s *IntegrationService) subscribe(ctx context.Context, integration *models.Integration, sync *models.ImportSync) {
+	if s.config.WebhookURL == "" {
+		return
+	}
+	provider, err := s.provider(integration.Provider)
+	if err != nil {
+		return
+	}
+	subscriber, ok := provider.(IntegrationWebhookSubscriber)
+	if !ok {
+		return
+	}
+	accessToken, err := s.accessToken(ctx, provider, integration)
+	if err != nil {
+		return
+	}
+
+	address := strings.TrimSuffix(s.config.WebhookURL, "/") + "/" + provider.Name()
+	webhookID, err := subscriber.Subscribe(ctx, accessToken, sync.RemoteFolderID, address)
+	if err != nil {
+		// Log but continue
+		return
+	}
+	sync.WebhookID = webhookID
+	if err := s.integrationRepo.UpdateSync(ctx, sync); err != nil {
+		// Log but continue
+	}
+}
+
+// unsubscribe removes the syncs' change webhooks, as far as the provider
+// still allows
+func (s *IntegrationService) unsubscribe(ctx context.Context, integration *models.Integration, syncs ...models.ImportSync) {
+	if len(syncs) == 0 {
+		return
+	}
+	provider, err := s.provider(integration.Provider)
+	if err != nil {
+		return
+	}
+	subscriber, ok := provider.(IntegrationWebhookSubscriber)
+	if !ok {
+		return
+	}
+	accessToken, err := s.accessToken(ctx, provider, integration)
+	if err != nil {
+		return
+	}
+	for _, sync := range syncs {
+		if err := subscriber.Unsubscribe(ctx, accessToken, sync.WebhookID); err != nil {
+			// Log but continue
+		}
+	}
+}
+
+// normalizeConnectorMapping validates a mapping, trimming and deduplicating
+// its lists
+func normalizeConnectorMapping(mapping *ConnectorMapping) error {
+	switch mapping.OnRemoteDelete {
+	case "":
+		mapping.OnRemoteDelete = RemoteDeleteKeep
+	case RemoteDeleteKeep, RemoteDeleteTrash:
+	default:
+		return fmt.Errorf("%w: on_remote_delete must be keep or trash", ErrInvalidConnectorMapping)
+	}
+	if mapping.DocumentType != "" && !isKnownDocumentType(mapping.DocumentType) {
+		return fmt.Errorf("%w: unknown document type %q", ErrInvalidConnectorMapping, mapping.DocumentType)
+	}
+
+	mapping.Tags = normalizeMappingList(mapping.Tags)
+	mapping.Categories = normalizeMappingList(mapping.Categories)
+	mapping.Exclude = normalizeMappingList(mapping.Exclude)
+	for _, pattern := range mapping.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: invalid exclude pattern %q", ErrInvalidConnectorMapping, pattern)
+		}
+	}
+	return nil
+}
+
+func normalizeMappingList(values []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		normalized = append(normalized, value)
+	}
+	return normalized
+}
+
+// excludes reports whether the mapping keeps a file out, by its path within
+// the synced folder
+func (m ConnectorMapping) excludes(relativePath string) bool {
+	for _, pattern := range m.Exclude {
+		target := path.Base(relativePath)
+		if strings.Contains(pattern, "/") {
+			target = relativePath
+		}
+		if matched, _ := path.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func connectorMappings(tenant *models.Tenant) (map[string]ConnectorMapping, error) {
+	mappings := make(map[string]ConnectorMapping)
+	if raw, ok := tenant.Settings[tenantIntegrationMappingsSetting]; ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read integration mappings: %w", err)
+		}
+		if err := json.Unmarshal(data, &mappings); err != nil {
+			return nil, fmt.Errorf("failed to read integration mappings: %w", err)
+		}
+	}
+	return mappings, nil
+}
+
+// updateMappings applies change to the tenant's stored mappings
+func (s *IntegrationService) updateMappings(ctx context.Context, tenantID uuid.UUID, change func(map[string]ConnectorMapping) error) error {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return ErrTenantNotFound
+	}
+	mappings, err := connectorMappings(tenant)
+	if err != nil {
+		return err
+	}
+	if err := change(mappings); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(mappings)
+	if err != nil {
+		return fmt.Errorf("failed to encode integration mappings: %w", err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to encode integration mappings: %w", err)
+	}
+
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+	tenant.Settings[tenantIntegrationMappingsSetting] = stored
+	tenant.UpdatedAt = time.Now()
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return fmt.Errorf("failed to update integration mappings: %w", err)
+	}
+	return nil
+}
+
+func (s *IntegrationService) provider(name string) (IntegrationConnector, error) {
+	if s.aead == nil || len(s.providers) == 0 {
+		return nil, ErrIntegrationsDisabled
+	}
+	provider, ok := s.providers[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, ErrUnknownIntegrationProvider
+	}
+	return provider, nil
+}
+
+// fileProvider returns a provider whose files can be imported
+func (s *IntegrationService) fileProvider(name string) (IntegrationProvider, error) {
+	provider, err := s.provider(name)
+	if err != nil {
+		return nil, err
+	}
+	files, ok := provider.(IntegrationProvider)
+	if !ok {
+		return nil, ErrIntegrationHasNoFiles
+	}
+	return files, nil
+}
+
+// connection returns a tenant's active integration with its provider and a
+// current access token
+func (s *IntegrationService) connection(ctx context.Context, tenantID, integrationID uuid.UUID) (*models.Integration, IntegrationConnector, string, error) {
+	integration, err := s.integrationRepo.GetForTenant(ctx, tenantID, integrationID)
+	if err != nil {
+		return nil, nil, "", ErrIntegrationNotFound
+	}
+	if integration.Status == models.IntegrationError {
+		return nil, nil, "", ErrIntegrationTokenRevoked
+	}
+	provider, err := s.provider(integration.Provider)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	accessToken, err := s.accessToken(ctx, provider, integration)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return integration, provider, accessToken, nil
+}
+
+// accessToken returns the integration's access token, trading its refresh
+// token for a new one once the last has expired and storing the refresh
+// token when the provider rotated it. An integration whose grant was revoked
+// is marked as failed.
+func (s *IntegrationService) accessToken(ctx context.Context, provider IntegrationConnector, integration *models.Integration) (string, error) {
+	s.tokensMu.Lock()
+	defer s.tokensMu.Unlock()
+	if cached, ok := s.tokens[integration.ID]; ok && time.Until(cached.ExpiresAt) > time.Minute {
+		return cached.AccessToken, nil
+	}
+
+	refreshToken, err := s.decrypt(integration.EncryptedToken)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := provider.Refresh(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, ErrIntegrationTokenRevoked) {
+			integration.Status = models.IntegrationError
+			integration.LastError = err.Error()
+			integration.UpdatedAt = time.Now()
+			if err := s.integrationRepo.Update(context.WithoutCancel(ctx), integration); err != nil {
+				// Log but continue
+			}
+		}
+		return "", err
+	}
+
+	rotated := token.RefreshToken != "" && token.RefreshToken != refreshToken
+	if rotated {
+		if integration.EncryptedToken, err = s.encrypt(token.RefreshToken); err != nil {
+			return "", err
+		}
+	}
+	if rotated || integration.Status != models.IntegrationActive {
+		integration.Status = models.IntegrationActive
+		integration.LastError = ""
+		integration.UpdatedAt = time.Now()
+		if err := s.integrationRepo.Update(context.WithoutCancel(ctx), integration); err != nil {
+			return "", err
+		}
+	}
+	s.tokens[integration.ID] = *token
+	return token.AccessToken, nil
+}
+
+func (s *IntegrationService) forgetAccessToken(integrationID uuid.UUID) {
+	s.tokensMu.Lock()
+	defer s.tokensMu.Unlock()
+	delete(s.tokens, integrationID)
+}
+
+// oauthState identifies an authorization request while the browser is at
+// the provider
+type oauthState struct {
+	TenantID  uuid.UUID
+	UserID    uuid.UUID
+	Provider  string
+	ExpiresAt time.Time
+}
+
+// signState encodes the request with a nonce, so no two states are alike,
+// and signs it
+func (s *IntegrationService) signState(state oauthState) (string, error) {
+	if s.stateKey == nil {
+		return "", ErrIntegrationsDisabled
+	}
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	payload := strings.Join([]string{
+		state.TenantID.String(),
+		state.UserID.String(),
+		state.Provider,
+		strconv.FormatInt(state.ExpiresAt.Unix(), 10),
+		base64.RawURLEncoding.EncodeToString(nonce),
+	}, "|")
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + s.stateSignature(encoded), nil
+}
+
+func (s *IntegrationService) verifyState(value string) (*oauthState, error) {
+	if s.stateKey == nil {
+		return nil, ErrIntegrationsDisabled
+	}
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.stateSignature(encoded))) {
+		return nil, ErrInvalidOAuthState
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+
+	parts := strings.Split(string(payload), "|")
+	if len(parts) != 5 {
+		return nil, ErrInvalidOAuthState
+	}
+	tenantID, err1 := uuid.Parse(parts[0])
+	userID, err2 := uuid.Parse(parts[1])
+	expiresAt, err3 := strconv.ParseInt(parts[3], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || time.Now().After(time.Unix(expiresAt, 0)) {
+		return nil, ErrInvalidOAuthState
+	}
+	return &oauthState{TenantID: tenantID, UserID: userID, Provider: parts[2], ExpiresAt: time.Unix(expiresAt, 0)}, nil
+}
+
+func (s *IntegrationService) stateSignature(encoded string) string {
+	mac := hmac.New(sha256.New, s.stateKey)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// codeVerifier derives the request's PKCE verifier from its state, so it
+// needn't be stored between the redirect and the callback
+func (s *IntegrationService) codeVerifier(state string) string {
+	mac := hmac.New(sha256.New, s.stateKey)
+	mac.Write([]byte("pkce:" + state))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *IntegrationService) encrypt(plaintext string) (string, error) {
+	if s.aead == nil {
+		return "", ErrIntegrationsDisabled
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedKeyVersion + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *IntegrationService) decrypt(ciphertext string) (string, error) {
+	if s.aead == nil {
+		return "", ErrIntegrationsDisabled
+	}
+	if !strings.HasPrefix(ciphertext, encryptedKeyVersion) {
+		return "", ErrIntegrationTokenDecryptFailed
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, encryptedKeyVersion))
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return "", ErrIntegrationTokenDecryptFailed
+	}
+
+	nonce, sealed := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrIntegrationTokenDecryptFailed
+	}
+	return string(plaintext), nil
+}
+
+func (s *IntegrationService) createAuditLog(ctx context.Context, tenantID, userID, integrationID uuid.UUID, action models.AuditAction, message string, integration *models.Integration) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   integrationID,
+		Action:       action,
+		ResourceType: "integration",
+		Details: models.JSONB{
+			"message":  message,
+			"provider": integration.Provider,
+			"account":  integration.AccountEmail,
+		},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+func (s *IntegrationService) createSyncAuditLog(ctx context.Context, userID uuid.UUID, sync *models.ImportSync, action models.AuditAction, message string) {
+	log := &models.AuditLog{
+		TenantID:     sync.TenantID,
+		UserID:       userID,
+		ResourceID:   sync.ID,
+		Action:       action,
+		ResourceType: "import_sync",
+		Details: models.JSONB{
+			"message":        message,
+			"integration_id": sync.IntegrationID.String(),
+			"remote_folder":  sync.RemoteFolderName,
+			"mode":           string(sync.Mode),
+		},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
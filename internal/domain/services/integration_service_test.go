@@ -0,0 +1,127 @@
+package services
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIntegrationService(t *testing.T) *IntegrationService {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	service, err := NewIntegrationService(nil, nil, nil, nil, nil, nil, nil, key, IntegrationServiceConfig{})
+	require.NoError(t, err)
+	return service
+}
+
+func TestIntegrationService_OAuthState(t *testing.T) {
+	service := newTestIntegrationService(t)
+	tenantID, userID := uuid.New(), uuid.New()
+
+	state, err := service.signState(oauthState{TenantID: tenantID, UserID: userID, Provider: "onedrive", ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	request, err := service.verifyState(state)
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, request.TenantID)
+	assert.Equal(t, userID, request.UserID)
+	assert.Equal(t, "onedrive", request.Provider)
+
+	// Every state gets its own PKCE verifier
+	other, err := service.signState(oauthState{TenantID: tenantID, UserID: userID, Provider: "onedrive", ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	assert.NotEqual(t, state, other)
+	assert.NotEqual(t, service.codeVerifier(state), service.codeVerifier(other))
+	assert.Len(t, service.codeVerifier(state), 43)
+
+	encoded, signature, _ := strings.Cut(state, ".")
+	_, err = service.verifyState(encoded + "x." + signature)
+	assert.ErrorIs(t, err, ErrInvalidOAuthState)
+	_, err = service.verifyState(encoded)
+	assert.ErrorIs(t, err, ErrInvalidOAuthState)
+
+	expired, err := service.signState(oauthState{TenantID: tenantID, UserID: userID, Provider: "onedrive", ExpiresAt: time.Now().Add(-time.Second)})
+	require.NoError(t, err)
+	_, err = service.verifyState(expired)
+	assert.ErrorIs(t, err, ErrInvalidOAuthState)
+}
+
+func TestIntegrationService_TokenEncryption(t *testing.T) {
+	service := newTestIntegrationService(t)
+
+	encrypted, err := service.encrypt("refresh-token")
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "refresh-token")
+	decrypted, err := service.decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-token", decrypted)
+
+	_, err = service.decrypt(encrypted[:len(encrypted)-4] + "AAAA")
+	assert.ErrorIs(t, err, ErrIntegrationTokenDecryptFailed)
+
+	_, err = NewIntegrationService(nil, nil, nil, nil, nil, nil, nil, "short", IntegrationServiceConfig{})
+	assert.ErrorIs(t, err, ErrInvalidIntegrationsKey)
+
+	disabled, err := NewIntegrationService(nil, nil, nil, nil, nil, nil, nil, "", IntegrationServiceConfig{})
+	require.NoError(t, err)
+	_, err = disabled.AuthorizationURL(uuid.New(), uuid.New(), "google_drive")
+	assert.ErrorIs(t, err, ErrIntegrationsDisabled)
+}
+
+func TestImportSourceFields(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	fields := importSourceFields("google_drive", "Finance/2024/invoice.pdf", RemoteItem{
+		ID:        "abc",
+		Owner:     "jane@example.com",
+		WebURL:    "https://drive.google.com/file/d/abc",
+		CreatedAt: &created,
+	})
+	assert.Equal(t, map[string]interface{}{
+		"source":            "google_drive",
+		"source_id":         "abc",
+		"source_path":       "Finance/2024/invoice.pdf",
+		"source_url":        "https://drive.google.com/file/d/abc",
+		"source_owner":      "jane@example.com",
+		"source_created_at": "2024-01-02T02:04:05Z",
+	}, fields)
+
+	assert.Equal(t, "Q1-Q2", importFolderName(" Q1/Q2 "))
+	assert.Equal(t, "Untitled", importFolderName(""))
+}
+
+func TestConnectorMapping(t *testing.T) {
+	mapping := ConnectorMapping{
+		Tags:    []string{" finance ", "finance", ""},
+		Exclude: []string{"*.tmp", "Drafts/*"},
+	}
+	require.NoError(t, normalizeConnectorMapping(&mapping))
+	assert.Equal(t, RemoteDeleteKeep, mapping.OnRemoteDelete)
+	assert.Equal(t, []string{"finance"}, mapping.Tags)
+
+	assert.True(t, mapping.excludes("Scans/~lock.tmp"))
+	assert.True(t, mapping.excludes("Drafts/plan.pdf"))
+	assert.False(t, mapping.excludes("Scans/Drafts/plan.pdf"))
+	assert.False(t, mapping.excludes("plan.pdf"))
+
+	for _, invalid := range []ConnectorMapping{
+		{OnRemoteDelete: "purge"},
+		{DocumentType: "recipe"},
+		{Exclude: []string{"[a-"}},
+	} {
+		assert.ErrorIs(t, normalizeConnectorMapping(&invalid), ErrInvalidConnectorMapping)
+	}
+}
+
+func TestSameAccount(t *testing.T) {
+	legacy := &models.Integration{AccountEmail: "Jane@example.com"}
+	assert.True(t, sameAccount(legacy, &RemoteAccount{ID: "dbid:1", Email: "jane@example.com"}))
+
+	// Accounts are matched by ID once known, as the email can change
+	connected := &models.Integration{AccountEmail: "jane@example.com", ExternalAccountID: "dbid:1"}
+	assert.True(t, sameAccount(connected, &RemoteAccount{ID: "dbid:1", Email: "jane@new.example.com"}))
+	assert.False(t, sameAccount(connected, &RemoteAccount{ID: "dbid:2", Email: "jane@example.com"}))
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// Keyword extraction for tag suggestions. Text is split into words on
+// anything that is not a letter or digit, so accented and non-Latin words
+// survive intact. The language is detected from its stop words unless given,
+// and words are grouped by a light stem so "invoice", "invoices" and
+// "invoiced" count as one keyword.
+
+const (
+	defaultKeywordLanguage = "en"
+	minKeywordRunes        = 3
+	minStemRunes           = 3
+	maxKeywords            = 20
+)
+
+// suffixRule replaces a word ending while building a stem
+type suffixRule struct {
+	suffix      string
+	replacement string
+}
+
+// keywordLanguage is what keyword extraction knows about a language. The
+// first matching suffix rule is applied; rules are listed longest first.
+type keywordLanguage struct {
+	stopWords map[string]bool
+	suffixes  []suffixRule
+	dropFinal []string // Endings stripped after the suffix rules, e.g. a silent "e"
+}
+
+var keywordLanguages = map[string]*keywordLanguage{
+	"en": {
+		stopWords: stopWordSet(`a about above after again against all also am an and any are as at be because
+			been before being below between both but by can could did do does doing down during each few for
+			from further had has have having he her here hers herself him himself his how i if in into is it
+			its itself just me more most my myself no nor not now of off on once only or other our ours
+			ourselves out over own per same she should so some such than that the their theirs them
+			themselves then there these they this those through to too under until up upon very was we were
+			what when where which while who whom why will with would you your yours yourself yourselves`),
+		suffixes: []suffixRule{
+			{"ational", "ate"}, {"ations", "ate"}, {"ation", "ate"}, {"ments", ""}, {"ment", ""},
+			{"ness", ""}, {"sses", "ss"}, {"ings", ""}, {"ies", "y"}, {"ing", ""}, {"ied", "y"},
+			{"ed", ""}, {"ly", ""}, {"ss", "ss"}, {"s", ""},
+		},
+		dropFinal: []string{"e"},
+	},
+	"de": {
+		stopWords: stopWordSet(`aber alle allem allen aller alles als also am an ander andere anderen auch auf
+			aus bei beim bin bis bist da damit dann das dass dein deine dem den denn der des dessen dich die
+			dies diese diesem diesen dieser dieses dir doch dort du durch ein eine einem einen einer eines
+			er es euer eure für gegen hat hatte hier hin hinter ich ihm ihn ihnen ihr ihre im in ist jede
+			jedem jeden jeder jedes jetzt kann kein keine mein meine mich mir mit muss nach nicht noch nun
+			nur ob oder ohne sehr sein seine sich sie sind so über um und uns unser unter vom von vor war
+			waren was weil wenn wer werden wie wieder wir wird wo zu zum zur zwischen`),
+		suffixes: []suffixRule{
+			{"ungen", "ung"}, {"heiten", "heit"}, {"keiten", "keit"}, {"ern", ""}, {"em", ""},
+			{"en", ""}, {"er", ""}, {"es", ""}, {"e", ""}, {"s", ""}, {"n", ""},
+		},
+	},
+	"fr": {
+		stopWords: stopWordSet(`au aux avec ce ces cette dans de des du elle elles en est et eux il ils je
+			la le les leur leurs lui ma mais me même mes moi mon ne nos notre nous on ou où par pas pour qu
+			que qui sa se ses son sont sur ta te tes toi ton tu un une vos votre vous été être avoir ont
+			était sans sous entre chez comme aussi plus très tout tous toute toutes cet leurs dont`),
+		suffixes: []suffixRule{
+			{"eaux", "eau"}, {"aux", "al"}, {"ements", ""}, {"ement", ""}, {"euses", "eux"},
+			{"euse", "eux"}, {"ées", ""}, {"ée", ""}, {"és", ""}, {"es", ""}, {"s", ""}, {"x", ""},
+		},
+		dropFinal: []string{"e"},
+	},
+	"es": {
+		stopWords: stopWordSet(`a al algo algunos ante antes como con contra cual cuando de del desde donde
+			durante e el ella ellas ellos en entre era es esa esas ese eso esos esta estas este esto estos
+			fue ha hay la las le les lo los más me mi mis muy nada ni no nos nuestra nuestro o os otra otros
+			para pero poco por porque que quien se ser si sin sobre son su sus también te tiene todo todos
+			tu tus un una uno unos y ya yo`),
+		suffixes: []suffixRule{
+			{"aciones", "acion"}, {"mente", ""}, {"ces", "z"}, {"es", ""}, {"s", ""},
+		},
+		dropFinal: []string{"a", "o", "e"},
+	},
+	"it": {
+		stopWords: stopWordSet(`a ad al alla alle anche avere che chi ci come con contro da dal dalla dei del
+			della delle dello di dove e ed era gli ha hanno ho il in io la le lei li lo loro lui ma mi mia
+			mio ne negli nei nel nella nelle noi non nostro o per perché più quale quando quella quello
+			questa questo se sei si sia sono su sua sue suo sul sulla tra tu tutti tutto un una uno voi`),
+		suffixes: []suffixRule{
+			{"azioni", "azion"}, {"azione", "azion"}, {"mente", ""},
+		},
+		dropFinal: []string{"i", "e", "a", "o"},
+	},
+	"pt": {
+		stopWords: stopWordSet(`a ao aos as até com como da das de dela dele deles depois do dos e ela elas
+			ele eles em entre era essa esse esta este eu foi há isso isto já la lhe lo mais mas me mesmo
+			meu minha muito na nas não nem no nos nossa nosso num numa o os ou para pela pelo por qual
+			quando que quem se sem ser seu sua são também te tem tu um uma você`),
+		suffixes: []suffixRule{
+			{"ções", "ção"}, {"mente", ""}, {"ões", "ão"}, {"ais", "al"}, {"es", ""}, {"s", ""},
+		},
+		dropFinal: []string{"a", "o", "e"},
+	},
+	"nl": {
+		stopWords: stopWordSet(`aan al alles als bij dan dat de der deze die dit doch doen door dus een en er
+			ge geen had heb hebben heeft hem het hier hij hoe hun iets ik in is ja je kan kon maar me meer
+			men met mij mijn na naar niet niets nog nu of om omdat ons ook op over reeds te tegen toch toen
+			tot u uit uw van veel voor want waren was wat we wel werd wie wij wordt zal ze zei zelf zich
+			zij zijn zo zonder zou`),
+		suffixes: []suffixRule{
+			{"heden", "heid"}, {"ingen", "ing"}, {"en", ""}, {"s", ""}, {"e", ""},
+		},
+	},
+}
+
+// foldDiacritics maps accented Latin letters to their base letter, so stems
+// match whether or not the accents were typed
+var foldDiacritics = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ÿ", "y", "ß", "ss",
+)
+
+func stopWordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// KeywordLanguages returns the language codes keyword extraction supports
+func KeywordLanguages() []string {
+	codes := make([]string, 0, len(keywordLanguages))
+	for code := range keywordLanguages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// tokenizeText lowercases text and splits it into words
+func tokenizeText(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// detectLanguage returns the supported language whose stop words occur most
+// often among the words, or English when none do
+func detectLanguage(words []string) string {
+	language, _ := stopWordLanguage(words)
+	return language
+}
+
+// stopWordLanguage returns the supported language whose stop words occur
+// most often among the words and how many of them do, or English and 0 when
+// none do
+func stopWordLanguage(words []string) (string, int) {
+	best, bestHits := defaultKeywordLanguage, 0
+	for _, code := range KeywordLanguages() {
+		hits := 0
+		for _, word := range words {
+			if keywordLanguages[code].stopWords[word] {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = code, hits
+		}
+	}
+	return best, bestHits
+}
+
+// stemWord strips the language's inflections from a lowercase word
+func stemWord(word string, language *keywordLanguage) string {
+	for _, rule := range language.suffixes {
+		if !strings.HasSuffix(word, rule.suffix) {
+			continue
+		}
+		stem := strings.TrimSuffix(word, rule.suffix) + rule.replacement
+		if utf8.RuneCountInString(stem) >= minStemRunes {
+			word = stem
+		}
+		break
+	}
+
+	for _, ending := range language.dropFinal {
+		if strings.HasSuffix(word, ending) && utf8.RuneCountInString(word) > minStemRunes {
+			word = strings.TrimSuffix(word, ending)
+			break
+		}
+	}
+	return foldDiacritics.Replace(word)
+}
+
+// extractKeywords returns the text's keywords, most frequent first, and the
+// language they were extracted for. An empty language is detected from the
+// text.
+func extractKeywords(text, language string, limit int) ([]string, string, error) {
+	words := tokenizeText(text)
+	if language == "" {
+		language = detectLanguage(words)
+	}
+	lang, ok := keywordLanguages[strings.ToLower(language)]
+	if !ok {
+		return nil, "", ErrUnsupportedLanguage
+	}
+	language = strings.ToLower(language)
+
+	type keyword struct {
+		forms map[string]int // Spellings seen, by count
+		count int
+		first int
+	}
+	keywords := make(map[string]*keyword)
+	var stems []string
+	for i, word := range words {
+		if utf8.RuneCountInString(word) < minKeywordRunes || lang.stopWords[word] || isNumber(word) {
+			continue
+		}
+
+		stem := stemWord(word, lang)
+		kw, ok := keywords[stem]
+		if !ok {
+			kw = &keyword{forms: make(map[string]int), first: i}
+			keywords[stem] = kw
+			stems = append(stems, stem)
+		}
+		kw.forms[word]++
+		kw.count++
+	}
+
+	sort.SliceStable(stems, func(i, j int) bool {
+		a, b := keywords[stems[i]], keywords[stems[j]]
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return a.first < b.first
+	})
+	if limit > 0 && len(stems) > limit {
+		stems = stems[:limit]
+	}
+
+	// Suggest each keyword in its most common spelling; ties go to the
+	// alphabetically first, usually the singular
+	result := make([]string, 0, len(stems))
+	for _, stem := range stems {
+		best, bestCount := "", 0
+		for form, count := range keywords[stem].forms {
+			if count > bestCount || (count == bestCount && form < best) {
+				best, bestCount = form, count
+			}
+		}
+		result = append(result, best)
+	}
+
+	return result, language, nil
+}
+
+// keywordStems returns the stems of the words in a phrase, e.g. a tag name
+func keywordStems(phrase, language string) map[string]bool {
+	lang, ok := keywordLanguages[language]
+	if !ok {
+		lang = keywordLanguages[defaultKeywordLanguage]
+	}
+
+	stems := make(map[string]bool)
+	for _, word := range tokenizeText(phrase) {
+		stems[stemWord(word, lang)] = true
+	}
+	return stems
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
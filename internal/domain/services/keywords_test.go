@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractKeywords_GroupsInflectionsAndRanksByFrequency(t *testing.T) {
+	keywords, language, err := extractKeywords("The invoices were paid. Invoice 2024-17 is for the consulting; consulting invoiced monthly.", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "en", language)
+
+	// Three spellings of one keyword outrank a word seen twice
+	require.GreaterOrEqual(t, len(keywords), 2)
+	assert.Equal(t, "invoice", keywords[0])
+	assert.Equal(t, "consulting", keywords[1])
+	assert.NotContains(t, keywords, "invoices")
+	assert.NotContains(t, keywords, "invoiced")
+
+	// Stop words and numbers are not keywords
+	assert.NotContains(t, keywords, "the")
+	assert.NotContains(t, keywords, "were")
+	assert.NotContains(t, keywords, "2024")
+}
+
+func TestExtractKeywords_DetectsLanguage(t *testing.T) {
+	tests := []struct {
+		text     string
+		language string
+		keyword  string
+	}{
+		{"Die Rechnungen für den Mietvertrag und die Rechnung vom März", "de", "rechnung"},
+		{"Les factures de la société et une facture pour le contrat", "fr", "facture"},
+		{"Las facturas del cliente y la factura de los servicios", "es", "factura"},
+		{"Le fatture del cliente e la fattura per il contratto", "it", "fattura"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			keywords, language, err := extractKeywords(tt.text, "", 0)
+			require.NoError(t, err)
+			assert.Equal(t, tt.language, language)
+			// Spellings seen equally often are suggested alphabetically first,
+			// which is usually the singular
+			require.NotEmpty(t, keywords)
+			assert.Equal(t, tt.keyword, keywords[0])
+			// Stop words of the detected language are dropped
+			assert.NotContains(t, keywords, "und")
+			assert.NotContains(t, keywords, "les")
+			assert.NotContains(t, keywords, "del")
+		})
+	}
+}
+
+func TestExtractKeywords_KeepsAccentsAndRejectsUnknownLanguages(t *testing.T) {
+	keywords, _, err := extractKeywords("Übersicht der Verträge: Vertrag über Gebäudereinigung", "de", 0)
+	require.NoError(t, err)
+	assert.Contains(t, keywords, "übersicht")
+	assert.Contains(t, keywords, "gebäudereinigung")
+	// "Verträge" and "Vertrag" stem alike once the umlaut is folded
+	assert.Len(t, keywords, 3)
+
+	_, _, err = extractKeywords("some text", "xx", 0)
+	assert.ErrorIs(t, err, ErrUnsupportedLanguage)
+}
+
+func TestKeywordStems_MatchInflectedTagNames(t *testing.T) {
+	stems := keywordStems("Monthly Invoices", "en")
+	assert.True(t, stems[stemWord("invoice", keywordLanguages["en"])])
+	assert.True(t, stems[stemWord("monthly", keywordLanguages["en"])])
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// Legal hold errors
+var (
+	ErrLegalHoldNotFound      = errors.New("legal hold not found")
+	ErrLegalHoldLifted        = errors.New("legal hold already lifted")
+	ErrInvalidLegalHold       = errors.New("legal hold requires a name, a reason and exactly one of document IDs, a folder or search filters")
+	ErrLegalHoldEmpty         = errors.New("no documents match the legal hold")
+	ErrLegalHoldTooLarge      = errors.New("legal hold covers too many documents")
+	ErrLegalHoldReasonMissing = errors.New("a reason is required to lift a legal hold")
+)
+
+// legalHoldPageSize is how many documents are read per page when resolving a hold
+const legalHoldPageSize = 500
+
+// LegalHoldNotifier tells document owners their documents were placed under
+// hold, once per owner and hold
+type LegalHoldNotifier interface {
+	SendLegalHoldPlaced(ctx context.Context, userID, holdID uuid.UUID, documentIDs []uuid.UUID, reason string) error
+}
+
+// LegalHoldService places and lifts legal holds. A hold covers the documents
+// that matched when it was placed; held documents can't be deleted, purged or
+// pruned until every hold covering them is lifted.
+type LegalHoldService struct {
+	holdRepo   repositories.LegalHoldRepository
+	docRepo    repositories.DocumentRepository
+	folderRepo repositories.FolderRepository
+	auditRepo  repositories.AuditLogRepository
+
+	notifier LegalHoldNotifier
+	config   LegalHoldServiceConfig
+}
+
+// LegalHoldServiceConfig holds configuration for legal holds
+type LegalHoldServiceConfig struct {
+	MaxDocuments int // Most documents one hold may cover
+}
+
+// NewLegalHoldService creates a new legal hold service. notifier may be nil.
+func NewLegalHoldService(
+	holdRepo repositories.LegalHoldRepository,
+	docRepo repositories.DocumentRepository,
+	folderRepo repositories.FolderRepository,
+	auditRepo repositories.AuditLogRepository,
+	notifier LegalHoldNotifier,
+	config LegalHoldServiceConfig,
+) *LegalHoldService {
+	if config.MaxDocuments <= 0 {
+		config.MaxDocuments = 50000
+	}
+
+	return &LegalHoldService{
+		holdRepo:   holdRepo,
+		docRepo:    docRepo,
+		folderRepo: folderRepo,
+		auditRepo:  auditRepo,
+		notifier:   notifier,
+		config:     config,
+	}
+}
+
+// PlaceLegalHoldParams selects the documents to hold: explicit documents, a
+// folder (optionally with its subfolders), or the results of a search
+type PlaceLegalHoldParams struct {
+	TenantID uuid.UUID
+	PlacedBy uuid.UUID
+	Name     string
+	Reason   string
+
+	DocumentIDs       []uuid.UUID
+	FolderID          *uuid.UUID
+	IncludeSubfolders bool
+	Filters           *repositories.DocumentFilters
+}
+
+// LegalHoldDetail is a hold with the documents it covers
+type LegalHoldDetail struct {
+	models.LegalHold
+	DocumentIDs []uuid.UUID `json:"document_ids"`
+}
+
+// PlaceLegalHold resolves the documents, holds them and notifies their owners
+func (s *LegalHoldService) PlaceLegalHold(ctx context.Context, params PlaceLegalHoldParams) (*LegalHoldDetail, error) {
+	params.Name = strings.TrimSpace(params.Name)
+	params.Reason = strings.TrimSpace(params.Reason)
+	if params.Name == "" || params.Reason == "" {
+		return nil, ErrInvalidLegalHold
+	}
+
+	selectors := 0
+	if len(params.DocumentIDs) > 0 {
+		selectors++
+	}
+	if params.FolderID != nil {
+		selectors++
+	}
+	if params.Filters != nil {
+		selectors++
+	}
+	if selectors != 1 {
+		return nil, ErrInvalidLegalHold
+	}
+
+	var (
+		scope     models.LegalHoldScope
+		criteria  models.JSONB
+		documents []models.Document
+		err       error
+	)
+	switch {
+	case len(params.DocumentIDs) > 0:
+		scope = models.LegalHoldScopeDocuments
+		criteria = models.JSONB{"document_ids": params.DocumentIDs}
+		documents, err = s.resolveDocuments(ctx, params.TenantID, params.DocumentIDs)
+	case params.FolderID != nil:
+		scope = models.LegalHoldScopeFolder
+		criteria = models.JSONB{"folder_id": params.FolderID.String(), "include_subfolders": params.IncludeSubfolders}
+		documents, err = s.resolveFolder(ctx, params.TenantID, *params.FolderID, params.IncludeSubfolders)
+	default:
+		scope = models.LegalHoldScopeSearch
+		criteria, err = filterCriteria(*params.Filters)
+		if err == nil {
+			documents, err = s.resolveSearch(ctx, params.TenantID, *params.Filters)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(documents) == 0 {
+		return nil, ErrLegalHoldEmpty
+	}
+
+	hold := &models.LegalHold{
+		ID:       uuid.New(),
+		TenantID: params.TenantID,
+		Name:     params.Name,
+		Reason:   params.Reason,
+		Scope:    scope,
+		Criteria: criteria,
+		PlacedBy: params.PlacedBy,
+		PlacedAt: time.Now(),
+	}
+	documentIDs := make([]uuid.UUID, len(documents))
+	for i, document := range documents {
+		documentIDs[i] = document.ID
+	}
+	if err := s.holdRepo.Create(ctx, hold, documentIDs); err != nil {
+		return nil, fmt.Errorf("failed to place legal hold: %w", err)
+	}
+
+	s.createAuditLogs(params.TenantID, params.PlacedBy, hold, models.AuditCreate, "Legal hold placed", params.Reason, documentIDs)
+	s.notifyOwners(hold, documents)
+
+	return &LegalHoldDetail{LegalHold: *hold, DocumentIDs: documentIDs}, nil
+}
+
+// LiftLegalHold releases a hold; documents stay held while another hold covers them
+func (s *LegalHoldService) LiftLegalHold(ctx context.Context, tenantID, holdID, userID uuid.UUID, reason string) (*models.LegalHold, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrLegalHoldReasonMissing
+	}
+
+	hold, err := s.getTenantHold(ctx, tenantID, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.LiftedAt != nil {
+		return nil, ErrLegalHoldLifted
+	}
+
+	documentIDs, err := s.holdRepo.ListDocumentIDs(ctx, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list held documents: %w", err)
+	}
+
+	liftedAt := time.Now()
+	lifted, err := s.holdRepo.Lift(ctx, holdID, userID, reason, liftedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lift legal hold: %w", err)
+	}
+	if !lifted {
+		return nil, ErrLegalHoldLifted
+	}
+
+	hold.LiftedBy = &userID
+	hold.LiftedAt = &liftedAt
+	hold.LiftReason = reason
+	s.createAuditLogs(tenantID, userID, hold, models.AuditUpdate, "Legal hold lifted", reason, documentIDs)
+
+	return hold, nil
+}
+
+// GetLegalHold returns a hold with the documents it covers
+func (s *LegalHoldService) GetLegalHold(ctx context.Context, tenantID, holdID uuid.UUID) (*LegalHoldDetail, error) {
+	hold, err := s.getTenantHold(ctx, tenantID, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	documentIDs, err := s.holdRepo.ListDocumentIDs(ctx, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list held documents: %w", err)
+	}
+
+	return &LegalHoldDetail{LegalHold: *hold, DocumentIDs: documentIDs}, nil
+}
+
+// ListLegalHolds lists the tenant's holds, newest first
+func (s *LegalHoldService) ListLegalHolds(ctx context.Context, tenantID uuid.UUID, activeOnly bool, params repositories.ListParams) ([]models.LegalHold, int64, error) {
+	return s.holdRepo.ListByTenant(ctx, tenantID, activeOnly, params)
+}
+
+// ListDocumentHolds lists every hold, active or lifted, that covers a document
+func (s *LegalHoldService) ListDocumentHolds(ctx context.Context, tenantID, documentID uuid.UUID) ([]models.LegalHold, error) {
+	if _, err := s.docRepo.GetForTenant(ctx, tenantID, documentID); err != nil {
+		return nil, ErrDocumentNotFound
+	}
+
+	return s.holdRepo.ListByDocument(ctx, documentID)
+}
+
+// Helper methods
+
+func (s *LegalHoldService) getTenantHold(ctx context.Context, tenantID, holdID uuid.UUID) (*models.LegalHold, error) {
+	hold, err := s.holdRepo.GetByID(ctx, holdID)
+	if err != nil || hold.TenantID != tenantID {
+		return nil, ErrLegalHoldNotFound
+	}
+	return hold, nil
+}
+
+func (s *LegalHoldService) resolveDocuments(ctx context.Context, tenantID uuid.UUID, documentIDs []uuid.UUID) ([]models.Document, error) {
+	if len(documentIDs) > s.config.MaxDocuments {
+		return nil, ErrLegalHoldTooLarge
+	}
+
+	seen := make(map[uuid.UUID]bool, len(documentIDs))
+	documents := make([]models.Document, 0, len(documentIDs))
+	for _, documentID := range documentIDs {
+		if seen[documentID] {
+			continue
+		}
+		seen[documentID] = true
+
+		document, err := s.docRepo.GetForTenant(ctx, tenantID, documentID)
+		if err != nil {
+			return nil, fmt.Errorf("document %s: %w", documentID, ErrDocumentNotFound)
+		}
+		documents = append(documents, *document)
+	}
+	return documents, nil
+}
+
+func (s *LegalHoldService) resolveFolder(ctx context.Context, tenantID, folderID uuid.UUID, includeSubfolders bool) ([]models.Document, error) {
+	if _, err := s.folderRepo.GetForTenant(ctx, tenantID, folderID); err != nil {
+		return nil, ErrFolderNotFound
+	}
+
+	folderIDs := []uuid.UUID{folderID}
+	if includeSubfolders {
+		folders, err := s.folderRepo.ListByTenant(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list folders: %w", err)
+		}
+		folderIDs = descendantFolderIDs(folderID, folders)
+	}
+
+	var documents []models.Document
+	for _, id := range folderIDs {
+		id := id
+		page, err := s.collectDocuments(ctx, tenantID, repositories.DocumentFilters{FolderID: &id}, s.config.MaxDocuments-len(documents))
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, page...)
+	}
+	return documents, nil
+}
+
+func (s *LegalHoldService) resolveSearch(ctx context.Context, tenantID uuid.UUID, filters repositories.DocumentFilters) ([]models.Document, error) {
+	// Holds cover everything that matches, not only what the caller can see
+	filters.ExcludeFolderIDs = nil
+	filters.ViewerID = nil
+	return s.collectDocuments(ctx, tenantID, filters, s.config.MaxDocuments)
+}
+
+// collectDocuments pages through every document matching filters, refusing
+// sets larger than limit
+func (s *LegalHoldService) collectDocuments(ctx context.Context, tenantID uuid.UUID, filters repositories.DocumentFilters, limit int) ([]models.Document, error) {
+	filters.PageSize = legalHoldPageSize
+	filters.SortBy = "id"
+	filters.SortDesc = false
+
+	var documents []models.Document
+	for page := 1; ; page++ {
+		filters.Page = page
+		batch, total, err := s.docRepo.List(ctx, tenantID, filters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		if total > int64(limit) {
+			return nil, ErrLegalHoldTooLarge
+		}
+		documents = append(documents, batch...)
+		if len(batch) < legalHoldPageSize {
+			return documents, nil
+		}
+	}
+}
+
+// descendantFolderIDs returns root and every folder below it
+func descendantFolderIDs(root uuid.UUID, folders []models.Folder) []uuid.UUID {
+	children := make(map[uuid.UUID][]uuid.UUID)
+	for _, folder := range folders {
+		if folder.ParentID != nil {
+			children[*folder.ParentID] = append(children[*folder.ParentID], folder.ID)
+		}
+	}
+
+	ids := []uuid.UUID{root}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids
+}
+
+// filterCriteria records the search filters a hold was placed with
+func filterCriteria(filters repositories.DocumentFilters) (models.JSONB, error) {
+	encoded, err := json.Marshal(filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode search filters: %w", err)
+	}
+	var criteria models.JSONB
+	if err := json.Unmarshal(encoded, &criteria); err != nil {
+		return nil, fmt.Errorf("failed to encode search filters: %w", err)
+	}
+	return criteria, nil
+}
+
+// notifyOwners tells each owner about the hold in the background, with one
+// notification covering all of their documents
+func (s *LegalHoldService) notifyOwners(hold *models.LegalHold, documents []models.Document) {
+	if s.notifier == nil {
+		return
+	}
+
+	byOwner := make(map[uuid.UUID][]uuid.UUID)
+	var owners []uuid.UUID
+	for _, document := range documents {
+		owner := document.Owner()
+		if byOwner[owner] == nil {
+			owners = append(owners, owner)
+		}
+		byOwner[owner] = append(byOwner[owner], document.ID)
+	}
+
+	go func() {
+		for _, owner := range owners {
+			if err := s.notifier.SendLegalHoldPlaced(context.Background(), owner, hold.ID, byOwner[owner], hold.Reason); err != nil {
+				// Log but don't fail
+			}
+		}
+	}()
+}
+
+// createAuditLogs records the action on the hold and on every document it covers
+func (s *LegalHoldService) createAuditLogs(tenantID, userID uuid.UUID, hold *models.LegalHold, action models.AuditAction, message, reason string, documentIDs []uuid.UUID) {
+	logs := make([]*models.AuditLog, 0, len(documentIDs)+1)
+	logs = append(logs, &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   hold.ID,
+		Action:       action,
+		ResourceType: "legal_hold",
+		Details: models.JSONB{
+			"message":        message,
+			"reason":         reason,
+			"name":           hold.Name,
+			"scope":          string(hold.Scope),
+			"document_count": len(documentIDs),
+		},
+	})
+	for _, documentID := range documentIDs {
+		logs = append(logs, &models.AuditLog{
+			TenantID:     tenantID,
+			UserID:       userID,
+			ResourceID:   documentID,
+			Action:       models.AuditUpdate,
+			ResourceType: "document",
+			Details: models.JSONB{
+				"message": message,
+				"reason":  reason,
+				"hold_id": hold.ID.String(),
+			},
+		})
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		for _, log := range logs {
+			s.auditRepo.Create(context.Background(), log)
+		}
+	}()
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidLineItems = errors.New("invalid line items")
+
+// LineItemService shows and corrects the line items financial extraction
+// found on invoices and receipts. Corrected lines replace the extracted
+// ones and survive later extractions; accounting exports book them line by
+// line.
+type LineItemService struct {
+	lineItemRepo    repositories.InvoiceLineItemRepository
+	documentService *DocumentService
+	auditRepo       repositories.AuditLogRepository
+}
+
+// LineItemInput is a line item as a user enters it. Without an amount the
+// line is worth quantity times unit price.
+type LineItemInput struct {
+	Description string   `json:"description"`
+	Quantity    *float64 `json:"quantity,omitempty"`
+	UnitPrice   *float64 `json:"unit_price,omitempty"`
+	TaxAmount   *float64 `json:"tax_amount,omitempty"`
+	Amount      *float64 `json:"amount,omitempty"`
+	GLCode      string   `json:"gl_code,omitempty"`
+}
+
+// NewLineItemService creates a new line item service
+func NewLineItemService(
+	lineItemRepo repositories.InvoiceLineItemRepository,
+	documentService *DocumentService,
+	auditRepo repositories.AuditLogRepository,
+) *LineItemService {
+	return &LineItemService{
+		lineItemRepo:    lineItemRepo,
+		documentService: documentService,
+		auditRepo:       auditRepo,
+	}
+}
+
+// ListLineItems returns a document's line items in order
+func (s *LineItemService) ListLineItems(ctx context.Context, tenantID, userID, documentID uuid.UUID) ([]models.InvoiceLineItem, error) {
+	if _, err := s.accessibleDocument(ctx, tenantID, userID, documentID, models.DocPermRead); err != nil {
+		return nil, err
+	}
+	return s.lineItemRepo.ListByDocument(ctx, tenantID, documentID)
+}
+
+// ReplaceLineItems corrects a document's line items, replacing all of them.
+// Extraction leaves corrected lines alone; clearing them lets the next
+// extraction fill them in again.
+func (s *LineItemService) ReplaceLineItems(ctx context.Context, tenantID, userID, documentID uuid.UUID, inputs []LineItemInput) ([]models.InvoiceLineItem, error) {
+	if _, err := s.accessibleDocument(ctx, tenantID, userID, documentID, models.DocPermWrite); err != nil {
+		return nil, err
+	}
+	if len(inputs) > maxFinancialLineItems {
+		return nil, fmt.Errorf("%w: at most %d lines", ErrInvalidLineItems, maxFinancialLineItems)
+	}
+
+	items := make([]models.InvoiceLineItem, len(inputs))
+	for i, input := range inputs {
+		item, err := lineItemFromInput(input)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidLineItems, i+1, err)
+		}
+		item.Source = models.LineItemManual
+		item.UpdatedBy = &userID
+		items[i] = *item
+	}
+
+	previous, err := s.lineItemRepo.ListByDocument(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.lineItemRepo.Replace(ctx, tenantID, documentID, items); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, documentID, models.JSONB{
+		"message":        "Document line items corrected",
+		"previous_lines": len(previous),
+		"lines":          len(items),
+	})
+
+	return s.lineItemRepo.ListByDocument(ctx, tenantID, documentID)
+}
+
+// Helper methods
+
+func (s *LineItemService) accessibleDocument(ctx context.Context, tenantID, userID, documentID uuid.UUID, required models.DocumentPermission) (*models.Document, error) {
+	document, err := s.documentService.getTenantDocument(ctx, documentID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.documentService.CheckDocumentAccess(ctx, document, userID, required); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+func (s *LineItemService) createAuditLog(ctx context.Context, tenantID, userID, documentID uuid.UUID, details models.JSONB) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   documentID,
+		Action:       models.AuditUpdate,
+		ResourceType: "document",
+		Details:      details,
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+// Helper functions
+
+func lineItemFromInput(input LineItemInput) (*models.InvoiceLineItem, error) {
+	description := strings.TrimSpace(input.Description)
+	if len(description) > 1000 {
+		return nil, errors.New("description is too long")
+	}
+	glCode := strings.TrimSpace(input.GLCode)
+	if len(glCode) > 100 {
+		return nil, errors.New("gl_code is too long")
+	}
+	for name, value := range map[string]*float64{"quantity": input.Quantity, "unit_price": input.UnitPrice, "tax_amount": input.TaxAmount, "amount": input.Amount} {
+		if value != nil && (math.IsNaN(*value) || math.IsInf(*value, 0)) {
+			return nil, fmt.Errorf("%s is not a finite number", name)
+		}
+	}
+	if input.TaxAmount != nil && *input.TaxAmount < 0 {
+		return nil, errors.New("tax_amount can't be negative")
+	}
+
+	var amount float64
+	switch {
+	case input.Amount != nil:
+		amount = *input.Amount
+	case input.Quantity != nil && input.UnitPrice != nil:
+		amount = math.Round(*input.Quantity**input.UnitPrice*100) / 100
+	default:
+		return nil, errors.New("amount is required")
+	}
+
+	return &models.InvoiceLineItem{
+		Description: description,
+		Quantity:    input.Quantity,
+		UnitPrice:   input.UnitPrice,
+		TaxAmount:   input.TaxAmount,
+		Amount:      amount,
+		GLCode:      glCode,
+	}, nil
+}
+
+// extractedLineItems converts the lines financial extraction found to
+// line items
+func extractedLineItems(lines []FinancialLineItem) []models.InvoiceLineItem {
+	items := make([]models.InvoiceLineItem, len(lines))
+	for i, line := range lines {
+		items[i] = models.InvoiceLineItem{
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			TaxAmount:   line.TaxAmount,
+			Amount:      line.Amount,
+			GLCode:      line.GLCode,
+			Source:      models.LineItemExtracted,
+		}
+	}
+	return items
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFinancialData_LineItems(t *testing.T) {
+	result, err := parseFinancialData(map[string]interface{}{
+		"amount": 119.0,
+		"line_items": []interface{}{
+			map[string]interface{}{"description": " Paper ", "quantity": "2", "unit_price": 25.0, "gl_code": "400"},
+			map[string]interface{}{"description": "Toner", "amount": "50.00", "tax_amount": 9.5},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.LineItems, 2)
+	assert.Equal(t, "Paper", result.LineItems[0].Description)
+	assert.Equal(t, 50.0, result.LineItems[0].Amount)
+	assert.Equal(t, "400", result.LineItems[0].GLCode)
+	assert.Equal(t, 50.0, result.LineItems[1].Amount)
+	assert.Equal(t, 9.5, *result.LineItems[1].TaxAmount)
+
+	items := extractedLineItems(result.LineItems)
+	assert.Equal(t, "Paper", items[0].Description)
+	assert.EqualValues(t, "extracted", items[1].Source)
+}
+
+func TestParseFinancialData_RejectsUnreadableLineItems(t *testing.T) {
+	for name, lines := range map[string]interface{}{
+		"not a list":    "Paper, 2x25",
+		"not an object": []interface{}{"Paper"},
+		"no amount":     []interface{}{map[string]interface{}{"description": "Paper", "quantity": 2}},
+		"bad number":    []interface{}{map[string]interface{}{"amount": "lots"}},
+	} {
+		_, err := parseFinancialData(map[string]interface{}{"line_items": lines})
+		assert.ErrorIs(t, err, ErrInvalidAIResult, name)
+	}
+}
+
+func TestLineItemFromInput(t *testing.T) {
+	quantity, price := 3.0, 1.5
+	item, err := lineItemFromInput(LineItemInput{Description: " Pens ", Quantity: &quantity, UnitPrice: &price, GLCode: " 6100 "})
+	require.NoError(t, err)
+	assert.Equal(t, "Pens", item.Description)
+	assert.Equal(t, 4.5, item.Amount)
+	assert.Equal(t, "6100", item.GLCode)
+
+	_, err = lineItemFromInput(LineItemInput{Description: "Pens"})
+	assert.Error(t, err)
+
+	tax := -1.0
+	_, err = lineItemFromInput(LineItemInput{Amount: &price, TaxAmount: &tax})
+	assert.Error(t, err)
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+)
+
+var ErrLLMEmbeddingsUnsupported = errors.New("AI provider has no embeddings API")
+
+// Defaults for the prompts jobs send
+const (
+	defaultLLMMaxTokens = 1024
+	maxLLMTags          = 5
+)
+
+// LLMProvider is a model API AI processing runs on: Anthropic, OpenAI,
+// Gemini or a local Ollama server. Implementations live in
+// infrastructure/llm; NewLLMClient turns one into the client jobs use.
+type LLMProvider interface {
+	Name() string
+	// Complete sends a prompt and returns the model's reply
+	Complete(ctx context.Context, request LLMRequest) (*LLMResponse, error)
+	// Stream sends a prompt and passes the reply to onText as it is written
+	Stream(ctx context.Context, request LLMRequest, onText func(text string)) (*LLMResponse, error)
+	// Embed returns the text's embedding, or ErrLLMEmbeddingsUnsupported
+	Embed(ctx context.Context, text string) (*LLMEmbedding, error)
+}
+
+// LLMRequest is a prompt for a model
+type LLMRequest struct {
+	System    string
+	Prompt    string
+	MaxTokens int
+	JSON      bool // The reply must be a JSON object
+}
+
+// LLMResponse is a model's reply and the tokens the request used
+type LLMResponse struct {
+	Text         string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+}
+
+// LLMEmbedding is a text's embedding and the tokens it used
+type LLMEmbedding struct {
+	Vector      []float32
+	Model       string
+	InputTokens int
+}
+
+// llmClient runs AI jobs' calls as prompts on an LLMProvider, adding the
+// call's examples and reporting the tokens each request used
+type llmClient struct {
+	provider  LLMProvider
+	maxTokens int
+}
+
+// NewLLMClient returns the client AI jobs use to call a provider's models.
+// maxTokens bounds each reply; it defaults to 1024.
+func NewLLMClient(provider LLMProvider, maxTokens int) OpenAIService {
+	if maxTokens <= 0 {
+		maxTokens = defaultLLMMaxTokens
+	}
+	return llmClient{provider: provider, maxTokens: maxTokens}
+}
+
+// ParseAIJobProviders parses the providers job types run on, written as
+// job_type=provider, e.g. embedding_generation=openai
+func ParseAIJobProviders(entries []string) (map[string]string, error) {
+	providers := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		jobType, provider, ok := strings.Cut(entry, "=")
+		jobType, provider = strings.TrimSpace(jobType), strings.TrimSpace(provider)
+		if !ok || jobType == "" {
+			return nil, fmt.Errorf("AI job provider %q is not job_type=provider", entry)
+		}
+		switch provider {
+		case AIProviderOpenAI, AIProviderAnthropic, AIProviderGemini, AIProviderOllama:
+		default:
+			return nil, fmt.Errorf("AI job provider %q names an unknown provider", entry)
+		}
+		providers[jobType] = provider
+	}
+	return providers, nil
+}
+
+func (c llmClient) ExtractText(ctx context.Context, text string) (string, error) {
+	return c.complete(ctx, false,
+		"Clean up this text extracted from a document: fix OCR errors and broken lines, keep the wording and layout. Reply with the text only.",
+		text)
+}
+
+func (c llmClient) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embedding, err := c.provider.Embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	// Providers that don't count embedding tokens leave them to be estimated
+	if embedding.InputTokens > 0 {
+		ReportAIUsage(ctx, AITokenUsage{Model: embedding.Model, InputTokens: embedding.InputTokens})
+	}
+	return embedding.Vector, nil
+}
+
+func (c llmClient) GenerateSummary(ctx context.Context, text string) (string, error) {
+	summary, err := c.complete(ctx, false, aiPromptInstructions(ctx, models.PromptSummarization), text)
+	return strings.TrimSpace(summary), err
+}
+
+func (c llmClient) ExtractEntities(ctx context.Context, text string) (map[string]interface{}, error) {
+	reply, err := c.complete(ctx, true, aiPromptInstructions(ctx, models.PromptExtraction), text)
+	if err != nil {
+		return nil, err
+	}
+	var entities map[string]interface{}
+	if err := decodeLLMJSON(reply, &entities); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (c llmClient) ClassifyDocument(ctx context.Context, text string) (models.DocumentType, float64, error) {
+	reply, err := c.complete(ctx, true, aiPromptInstructions(ctx, models.PromptClassification), text)
+	if err != nil {
+		return "", 0, err
+	}
+	var classification struct {
+		DocumentType models.DocumentType `json:"document_type"`
+		Confidence   float64             `json:"confidence"`
+	}
+	if err := decodeLLMJSON(reply, &classification); err != nil {
+		return "", 0, err
+	}
+	return classification.DocumentType, classification.Confidence, nil
+}
+
+func (c llmClient) GenerateTags(ctx context.Context, text string) ([]string, error) {
+	reply, err := c.complete(ctx, true, aiPromptInstructions(ctx, models.PromptTagging), text)
+	if err != nil {
+		return nil, err
+	}
+	var tags struct {
+		Tags []string `json:"tags"`
+	}
+	if err := decodeLLMJSON(reply, &tags); err != nil {
+		return nil, err
+	}
+	if len(tags.Tags) > maxLLMTags {
+		tags.Tags = tags.Tags[:maxLLMTags]
+	}
+	return tags.Tags, nil
+}
+
+func (c llmClient) ExtractFinancialData(ctx context.Context, text string, docType models.DocumentType) (map[string]interface{}, error) {
+	reply, err := c.complete(ctx, true,
+		fmt.Sprintf(`Extract the financial data of this %s as a JSON object with "vendor_name", "customer_name", "document_number", "document_date" and "due_date" (YYYY-MM-DD), "currency" (ISO 4217 code), "amount" (the total) and "tax_amount" as numbers, and "line_items", a list of objects with "description", "quantity", "unit_price", "tax_amount" and "amount" (before tax). Leave out what the document doesn't say.`, docType),
+		text)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err := decodeLLMJSON(reply, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (c llmClient) SuggestFiling(ctx context.Context, text string, taxonomy FilingTaxonomy) (*FilingAdvice, error) {
+	var instructions strings.Builder
+	fmt.Fprintf(&instructions, `Suggest where to file this document. Choose "folder_path" from the folders and "category" from the categories below, leaving either empty when none fits, and up to %d short lowercase "tags", preferring the existing tags. Reply with a JSON object with "folder_path", "category", "tags", "confidence", between 0 and 1, and "reason", one sentence on why.`, maxLLMTags)
+	for _, list := range []struct {
+		name  string
+		items []string
+	}{
+		{"Folders", taxonomy.Folders},
+		{"Categories", taxonomy.Categories},
+		{"Existing tags", taxonomy.Tags},
+	} {
+		if len(list.items) > 0 {
+			fmt.Fprintf(&instructions, "\n\n%s:\n- %s", list.name, strings.Join(list.items, "\n- "))
+		}
+	}
+
+	reply, err := c.complete(ctx, true, instructions.String(), text)
+	if err != nil {
+		return nil, err
+	}
+	var advice FilingAdvice
+	if err := decodeLLMJSON(reply, &advice); err != nil {
+		return nil, err
+	}
+	if len(advice.Tags) > maxLLMTags {
+		advice.Tags = advice.Tags[:maxLLMTags]
+	}
+	return &advice, nil
+}
+
+// Helper methods
+
+// complete sends one prompt, with the examples the call's context carries
+func (c llmClient) complete(ctx context.Context, jsonReply bool, instructions, text string) (string, error) {
+	var prompt strings.Builder
+	prompt.WriteString(instructions)
+	if examples := AIExamplesFromContext(ctx); len(examples) > 0 {
+		prompt.WriteString("\n\nThese documents were answered correctly like this:")
+		for _, example := range examples {
+			prompt.WriteString("\n\n<example>\n<document>\n")
+			prompt.WriteString(example.Input)
+			prompt.WriteString("\n</document>\n<answer>\n")
+			prompt.WriteString(llmExampleAnswer(example.Output))
+			prompt.WriteString("\n</answer>\n</example>")
+		}
+	}
+	prompt.WriteString("\n\n<document>\n")
+	prompt.WriteString(text)
+	prompt.WriteString("\n</document>")
+
+	response, err := c.provider.Complete(ctx, LLMRequest{
+		System:    "You process business documents for a document management system. Answer exactly in the format asked for.",
+		Prompt:    prompt.String(),
+		MaxTokens: c.maxTokens,
+		JSON:      jsonReply,
+	})
+	if err != nil {
+		return "", err
+	}
+	ReportAIUsage(ctx, AITokenUsage{Model: response.Model, InputTokens: response.InputTokens, OutputTokens: response.OutputTokens})
+	return response.Text, nil
+}
+
+// Helper functions
+
+// llmExampleAnswer shows a correction the way the model is asked to answer:
+// plain text for a single text field, JSON otherwise
+func llmExampleAnswer(output map[string]interface{}) string {
+	if len(output) == 1 {
+		for _, value := range output {
+			if text, ok := value.(string); ok {
+				return text
+			}
+		}
+	}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
+
+// decodeLLMJSON reads the JSON object in a model's reply, which some
+// models wrap in prose or a code fence
+func decodeLLMJSON(reply string, out interface{}) error {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return invalidAIResult("provider reply has no JSON object")
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), out); err != nil {
+		return invalidAIResult(fmt.Sprintf("provider reply is not valid JSON: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLLMProvider replies with a canned answer and keeps the last request
+type fakeLLMProvider struct {
+	reply   string
+	request LLMRequest
+}
+
+func (f *fakeLLMProvider) Name() string {
+	return "fake"
+}
+
+func (f *fakeLLMProvider) Complete(ctx context.Context, request LLMRequest) (*LLMResponse, error) {
+	f.request = request
+	return &LLMResponse{Text: f.reply, Model: "fake-model", InputTokens: 120, OutputTokens: 30}, nil
+}
+
+func (f *fakeLLMProvider) Stream(ctx context.Context, request LLMRequest, onText func(text string)) (*LLMResponse, error) {
+	response, err := f.Complete(ctx, request)
+	if err == nil {
+		onText(response.Text)
+	}
+	return response, err
+}
+
+func (f *fakeLLMProvider) Embed(ctx context.Context, text string) (*LLMEmbedding, error) {
+	return &LLMEmbedding{Vector: []float32{0.1, 0.2}, Model: "fake-embedding"}, nil
+}
+
+func TestLLMClient_ParsesRepliesAndReportsUsage(t *testing.T) {
+	provider := &fakeLLMProvider{reply: "Sure:\n```json\n{\"document_type\": \"invoice\", \"confidence\": 0.92}\n```"}
+	client := NewLLMClient(provider, 0)
+	meter := &aiUsageMeter{}
+	ctx, call := meter.start(WithAIExamples(context.Background(), []AIExample{
+		{Input: "Bill from Acme", Output: map[string]interface{}{"document_type": "invoice"}},
+	}))
+
+	docType, confidence, err := client.ClassifyDocument(ctx, "Invoice #42")
+	require.NoError(t, err)
+	assert.Equal(t, models.DocTypeInvoice, docType)
+	assert.Equal(t, 0.92, confidence)
+
+	// Corrections are given as examples ahead of the document
+	assert.True(t, provider.request.JSON)
+	assert.Equal(t, defaultLLMMaxTokens, provider.request.MaxTokens)
+	assert.Contains(t, provider.request.Prompt, "<example>\n<document>\nBill from Acme")
+	assert.Contains(t, provider.request.Prompt, "<document>\nInvoice #42\n</document>")
+	assert.Equal(t, []AITokenUsage{{Model: "fake-model", InputTokens: 120, OutputTokens: 30}}, call.requests)
+
+	// Embeddings without a token count are left to be estimated
+	ctx, call = meter.start(context.Background())
+	vector, err := client.GenerateEmbedding(ctx, "text")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.1, 0.2}, vector)
+	assert.Empty(t, call.requests)
+
+	// Summaries are asked for in the document's language
+	provider.reply = "Rechnung von Acme über 120 EUR."
+	summary, err := client.GenerateSummary(WithAILanguage(context.Background(), "de"), "Rechnung")
+	require.NoError(t, err)
+	assert.Equal(t, "Rechnung von Acme über 120 EUR.", summary)
+	assert.Contains(t, provider.request.Prompt, "write the summary in German")
+
+	// Filing is suggested from the tenant's taxonomy
+	provider.reply = `{"folder_path": "/Finance/Invoices", "category": "Accounts Payable", "tags": ["acme"], "confidence": 0.8, "reason": "An invoice from Acme."}`
+	advice, err := client.SuggestFiling(context.Background(), "Invoice #42", FilingTaxonomy{Folders: []string{"/Finance", "/Finance/Invoices"}, Tags: []string{"acme"}})
+	require.NoError(t, err)
+	assert.Equal(t, &FilingAdvice{FolderPath: "/Finance/Invoices", Category: "Accounts Payable", Tags: []string{"acme"}, Confidence: 0.8, Reason: "An invoice from Acme."}, advice)
+	assert.Contains(t, provider.request.Prompt, "Folders:\n- /Finance\n- /Finance/Invoices")
+	assert.Contains(t, provider.request.Prompt, "Existing tags:\n- acme")
+	assert.NotContains(t, provider.request.Prompt, "Categories:")
+
+	provider.reply = "not json"
+	_, err = client.GenerateTags(context.Background(), "text")
+	assert.Error(t, err)
+}
+
+func TestParseAIJobProviders(t *testing.T) {
+	providers, err := ParseAIJobProviders([]string{"embedding_generation=openai", " summarization = ollama "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"embedding_generation": "openai", "summarization": "ollama"}, providers)
+
+	for _, invalid := range []string{"summarization", "=openai", "summarization=mistral"} {
+		_, err := ParseAIJobProviders([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCIDR          = errors.New("invalid IP address or CIDR range")
+	ErrInvalidCountryCode   = errors.New("country codes must be two-letter ISO 3166 codes")
+	ErrInvalidGeoMode       = errors.New("share geo mode must be allow or block")
+	ErrNetworkPolicyLockout = errors.New("policy would block the address making this change")
+	ErrIPNotAllowed         = errors.New("request address is not allowed by the tenant network policy")
+	ErrGeoRestricted        = errors.New("share links are not available in this country")
+)
+
+// Share geo-restriction modes
+const (
+	GeoModeOff   = ""
+	GeoModeAllow = "allow" // Only the listed countries may open share links
+	GeoModeBlock = "block" // The listed countries may not open share links
+)
+
+// tenantNetworkPolicySetting is the tenant settings key holding the network policy
+const tenantNetworkPolicySetting = "network_policy"
+
+// NetworkPolicy is a tenant's network restriction policy. Empty lists leave
+// the corresponding access unrestricted.
+type NetworkPolicy struct {
+	// APIAllowlist restricts every authenticated API request
+	APIAllowlist []string `json:"api_allowlist"`
+	// AdminAllowlist additionally restricts requests made with admin privileges
+	AdminAllowlist []string `json:"admin_allowlist"`
+	// ShareGeoMode and ShareCountries restrict where public share links open
+	ShareGeoMode   string   `json:"share_geo_mode"`
+	ShareCountries []string `json:"share_countries"`
+
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// NetworkPolicyService manages and enforces tenant network restrictions
+type NetworkPolicyService struct {
+	tenantRepo   repositories.TenantRepository
+	auditRepo    repositories.AuditLogRepository
+	cacheService CacheService
+}
+
+// NewNetworkPolicyService creates a new network policy service
+func NewNetworkPolicyService(
+	tenantRepo repositories.TenantRepository,
+	auditRepo repositories.AuditLogRepository,
+	cacheService CacheService,
+) *NetworkPolicyService {
+	return &NetworkPolicyService{
+		tenantRepo:   tenantRepo,
+		auditRepo:    auditRepo,
+		cacheService: cacheService,
+	}
+}
+
+// UpdateNetworkPolicyParams contains the new policy and the request it came from
+type UpdateNetworkPolicyParams struct {
+	TenantID  uuid.UUID     `json:"tenant_id"`
+	UpdatedBy uuid.UUID     `json:"updated_by"`
+	IPAddress string        `json:"ip_address"`
+	Policy    NetworkPolicy `json:"policy"`
+}
+
+// APIAccessCheck describes an authenticated request to evaluate against the policy
+type APIAccessCheck struct {
+	TenantID  uuid.UUID `json:"tenant_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	IsAdmin   bool      `json:"is_admin"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+}
+
+// GetPolicy returns the tenant's network policy
+func (s *NetworkPolicyService) GetPolicy(ctx context.Context, tenantID uuid.UUID) (*NetworkPolicy, error) {
+	cacheKey := fmt.Sprintf(NetworkPolicyKeyPattern, tenantID.String())
+	if s.cacheService != nil {
+		if cached, err := s.cacheService.Get(ctx, cacheKey); err == nil {
+			var policy NetworkPolicy
+			if json.Unmarshal([]byte(cached), &policy) == nil {
+				return &policy, nil
+			}
+		}
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	policy := &NetworkPolicy{}
+	if raw, ok := tenant.Settings[tenantNetworkPolicySetting]; ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read network policy: %w", err)
+		}
+		if err := json.Unmarshal(data, policy); err != nil {
+			return nil, fmt.Errorf("failed to read network policy: %w", err)
+		}
+	}
+
+	if s.cacheService != nil {
+		if data, err := json.Marshal(policy); err == nil {
+			s.cacheService.Set(ctx, cacheKey, string(data), CacheShortTerm)
+		}
+	}
+
+	return policy, nil
+}
+
+// UpdatePolicy validates and stores the tenant's network policy. Changes that
+// would lock out the caller's own address are rejected.
+func (s *NetworkPolicyService) UpdatePolicy(ctx context.Context, params UpdateNetworkPolicyParams) (*NetworkPolicy, error) {
+	policy := params.Policy
+
+	var err error
+	if policy.APIAllowlist, err = normalizeCIDRs(policy.APIAllowlist); err != nil {
+		return nil, err
+	}
+	if policy.AdminAllowlist, err = normalizeCIDRs(policy.AdminAllowlist); err != nil {
+		return nil, err
+	}
+	if policy.ShareCountries, err = normalizeCountries(policy.ShareCountries); err != nil {
+		return nil, err
+	}
+
+	policy.ShareGeoMode = strings.ToLower(strings.TrimSpace(policy.ShareGeoMode))
+	switch policy.ShareGeoMode {
+	case GeoModeOff:
+		policy.ShareCountries = nil
+	case GeoModeAllow, GeoModeBlock:
+	default:
+		return nil, ErrInvalidGeoMode
+	}
+
+	// The caller is an admin, so both lists must still admit them
+	if !ipAllowed(params.IPAddress, policy.APIAllowlist) || !ipAllowed(params.IPAddress, policy.AdminAllowlist) {
+		return nil, ErrNetworkPolicyLockout
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, params.TenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	now := time.Now()
+	policy.UpdatedBy = &params.UpdatedBy
+	policy.UpdatedAt = &now
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode network policy: %w", err)
+	}
+	var stored map[string]interface{}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to encode network policy: %w", err)
+	}
+
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+	tenant.Settings[tenantNetworkPolicySetting] = stored
+	tenant.UpdatedAt = now
+
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, fmt.Errorf("failed to update network policy: %w", err)
+	}
+
+	if s.cacheService != nil {
+		if err := s.cacheService.Delete(ctx, fmt.Sprintf(NetworkPolicyKeyPattern, params.TenantID.String())); err != nil {
+			// Log but don't fail - the cached policy expires on its own
+		}
+	}
+
+	s.createAuditLog(&models.AuditLog{
+		TenantID:     params.TenantID,
+		UserID:       params.UpdatedBy,
+		ResourceID:   params.TenantID,
+		Action:       models.AuditUpdate,
+		ResourceType: "network_policy",
+		IPAddress:    params.IPAddress,
+		Details: models.JSONB{
+			"message":         "Network policy updated",
+			"api_allowlist":   policy.APIAllowlist,
+			"admin_allowlist": policy.AdminAllowlist,
+			"share_geo_mode":  policy.ShareGeoMode,
+			"share_countries": policy.ShareCountries,
+		},
+	})
+
+	return &policy, nil
+}
+
+// CheckAPIAccess enforces the API allowlist, and the admin allowlist for
+// admin requests. Blocked attempts are audited.
+func (s *NetworkPolicyService) CheckAPIAccess(ctx context.Context, check APIAccessCheck) error {
+	policy, err := s.GetPolicy(ctx, check.TenantID)
+	if err != nil {
+		return err
+	}
+
+	rule := ""
+	switch {
+	case !ipAllowed(check.IPAddress, policy.APIAllowlist):
+		rule = "api_allowlist"
+	case check.IsAdmin && !ipAllowed(check.IPAddress, policy.AdminAllowlist):
+		rule = "admin_allowlist"
+	default:
+		return nil
+	}
+
+	s.createAuditLog(&models.AuditLog{
+		TenantID:     check.TenantID,
+		UserID:       check.UserID,
+		ResourceID:   check.TenantID,
+		Action:       models.AuditBlocked,
+		ResourceType: "network_policy",
+		IPAddress:    check.IPAddress,
+		UserAgent:    check.UserAgent,
+		Details: models.JSONB{
+			"message": fmt.Sprintf("Request from %s blocked by %s", check.IPAddress, rule),
+			"rule":    rule,
+			"method":  check.Method,
+			"path":    check.Path,
+		},
+	})
+
+	return ErrIPNotAllowed
+}
+
+// CheckShareAccess enforces the tenant's share geo restrictions. When the
+// country is unknown, allow-lists fail closed and block-lists fail open.
+func (s *NetworkPolicyService) CheckShareAccess(ctx context.Context, share *models.Share, ipAddress, country string) error {
+	policy, err := s.GetPolicy(ctx, share.TenantID)
+	if err != nil {
+		return err
+	}
+
+	country = strings.ToUpper(strings.TrimSpace(country))
+	listed := false
+	for _, code := range policy.ShareCountries {
+		if code == country {
+			listed = true
+			break
+		}
+	}
+
+	switch policy.ShareGeoMode {
+	case GeoModeAllow:
+		if listed {
+			return nil
+		}
+	case GeoModeBlock:
+		if !listed {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if country == "" {
+		country = "unknown"
+	}
+
+	s.createAuditLog(&models.AuditLog{
+		TenantID:     share.TenantID,
+		UserID:       share.CreatedBy,
+		ResourceID:   share.ID,
+		Action:       models.AuditBlocked,
+		ResourceType: "share",
+		IPAddress:    ipAddress,
+		Details: models.JSONB{
+			"message": fmt.Sprintf("Share link opened from %s (%s) blocked by geo restriction", ipAddress, country),
+			"rule":    "share_geo_" + policy.ShareGeoMode,
+			"country": country,
+		},
+	})
+
+	return ErrGeoRestricted
+}
+
+// Helper methods
+
+func (s *NetworkPolicyService) createAuditLog(log *models.AuditLog) {
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+// normalizeCIDRs validates allowlist entries, turning bare addresses into
+// single-host ranges
+func normalizeCIDRs(entries []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidCIDR, entry)
+			}
+			if ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCIDR, entry)
+		}
+
+		cidr := network.String()
+		if !seen[cidr] {
+			seen[cidr] = true
+			normalized = append(normalized, cidr)
+		}
+	}
+
+	return normalized, nil
+}
+
+func normalizeCountries(codes []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+		if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCountryCode, code)
+		}
+		if !seen[code] {
+			seen[code] = true
+			normalized = append(normalized, code)
+		}
+	}
+
+	return normalized, nil
+}
+
+// ipAllowed reports whether the address falls within the allowlist. An empty
+// allowlist allows every address.
+func ipAllowed(address string, allowlist []string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(address))
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range allowlist {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			continue
+		}
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
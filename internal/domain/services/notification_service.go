@@ -0,0 +1,1041 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDeviceTokenNotFound   = errors.New("device token not found")
+	ErrInvalidDevicePlatform = errors.New("invalid device platform")
+	ErrInvalidDeviceToken    = errors.New("device token is required")
+	ErrPushTokenExpired      = errors.New("push token is no longer registered")
+	ErrInvalidPhoneNumber    = errors.New("phone number must be in E.164 format")
+	ErrInvalidPhoneCode      = errors.New("invalid or expired phone verification code")
+	ErrPhoneCodeLocked       = errors.New("too many incorrect verification codes; request a new code")
+	ErrSMSNotConfigured      = errors.New("sms delivery is not configured")
+	ErrSMSNotEnabled         = errors.New("sms delivery is not enabled for this tenant")
+)
+
+// Notification types. Only critical types are ever delivered over SMS.
+const (
+	NotificationSecurityAlert   = "security_alert"
+	NotificationLegalHoldPlaced = "legal_hold_placed"
+	NotificationTaskEscalation  = "task_escalation"
+)
+
+var criticalNotificationTypes = map[string]bool{
+	NotificationSecurityAlert:   true,
+	NotificationLegalHoldPlaced: true,
+	NotificationTaskEscalation:  true,
+}
+
+// defaultExpiryNoticeDays is how far ahead expiring document warnings look
+const defaultExpiryNoticeDays = 30
+
+// tenantSMSEnabledSetting is the tenant settings key that opts a tenant into SMS delivery
+const tenantSMSEnabledSetting = "sms_enabled"
+
+// tenantSlackWebhookSetting is the tenant settings key holding the Slack
+// incoming webhook that receives the tenant's Slack notifications
+const tenantSlackWebhookSetting = "slack_webhook_url"
+
+// userNotificationTypesSetting is the notification_settings key holding
+// per-type opt-outs, e.g. {"types": {"task_reminder": false}}
+const userNotificationTypesSetting = "types"
+
+// Outbox delivery for email and Slack
+const (
+	outboxPollInterval = 15 * time.Second
+	outboxBatchSize    = 50
+	outboxLease        = 2 * time.Minute // Longer than any send, so crashed workers' deliveries are retried
+	outboxRetryBase    = 30 * time.Second
+	outboxRetryMax     = time.Hour
+)
+
+// Phone verification codes expire, allow a few guesses and can only be sent
+// so often, to a user and to a number across all users, so verification
+// can't be used to guess codes or to send texts to numbers at our cost
+const (
+	phoneVerificationTTL         = 10 * time.Minute
+	phoneCodeMaxAttempts         = 5
+	phoneVerificationWindow      = time.Hour
+	phoneVerificationsPerUser    = 5
+	phoneVerificationsPerNumber  = 5
+	phoneVerificationMessageType = "phone_verification"
+)
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// NotificationDispatcher fans notifications out to the configured delivery channels.
+// It implements the NotificationService interface used by the workflow engine.
+type NotificationDispatcher struct {
+	notificationRepo repositories.NotificationRepository
+	deviceTokenRepo  repositories.DeviceTokenRepository
+	userRepo         repositories.UserRepository
+	documentRepo     repositories.DocumentRepository
+	tenantRepo       repositories.TenantRepository
+	smsMessageRepo   repositories.SMSMessageRepository
+	deliveryRepo     repositories.NotificationDeliveryRepository
+
+	templates     *NotificationTemplateService
+	pushProviders map[models.DevicePlatform]PushProvider
+	smsProvider   SMSProvider
+	senders       map[models.NotificationChannel]ChannelSender
+	realtime      UserEventPublisher
+}
+
+// NewNotificationDispatcher creates a new notification dispatcher.
+// pushProviders maps each device platform to its delivery provider; platforms
+// without a provider are skipped. senders maps the outbox channels (email,
+// Slack) to their sender; channels without one are never queued. smsProvider
+// may be nil to disable SMS, templates may be nil to always use the system
+// default wording, and realtime may be nil to skip pushing in-app
+// notifications to connected clients.
+func NewNotificationDispatcher(
+	notificationRepo repositories.NotificationRepository,
+	deviceTokenRepo repositories.DeviceTokenRepository,
+	userRepo repositories.UserRepository,
+	documentRepo repositories.DocumentRepository,
+	tenantRepo repositories.TenantRepository,
+	smsMessageRepo repositories.SMSMessageRepository,
+	deliveryRepo repositories.NotificationDeliveryRepository,
+	templates *NotificationTemplateService,
+	pushProviders map[models.DevicePlatform]PushProvider,
+	smsProvider SMSProvider,
+	senders map[models.NotificationChannel]ChannelSender,
+	realtime UserEventPublisher,
+) *NotificationDispatcher {
+	if pushProviders == nil {
+		pushProviders = make(map[models.DevicePlatform]PushProvider)
+	}
+	if senders == nil {
+		senders = make(map[models.NotificationChannel]ChannelSender)
+	}
+
+	return &NotificationDispatcher{
+		notificationRepo: notificationRepo,
+		deviceTokenRepo:  deviceTokenRepo,
+		userRepo:         userRepo,
+		documentRepo:     documentRepo,
+		tenantRepo:       tenantRepo,
+		smsMessageRepo:   smsMessageRepo,
+		deliveryRepo:     deliveryRepo,
+		templates:        templates,
+		pushProviders:    pushProviders,
+		smsProvider:      smsProvider,
+		senders:          senders,
+		realtime:         realtime,
+	}
+}
+
+// DispatchParams contains parameters for dispatching a notification
+type DispatchParams struct {
+	UserID   uuid.UUID                    `json:"user_id"`
+	Type     string                       `json:"type"`
+	Title    string                       `json:"title"`
+	Message  string                       `json:"message"`
+	Data     models.JSONB                 `json:"data"`
+	Channels []models.NotificationChannel `json:"channels"`
+
+	// Variables render the event's template per channel, replacing Title/Message
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// RegisterDeviceParams contains parameters for registering a push device
+type RegisterDeviceParams struct {
+	TenantID   uuid.UUID             `json:"tenant_id"`
+	UserID     uuid.UUID             `json:"user_id"`
+	Platform   models.DevicePlatform `json:"platform"`
+	Token      string                `json:"token"`
+	DeviceName string                `json:"device_name"`
+	AppVersion string                `json:"app_version"`
+}
+
+// Dispatch delivers a notification to a user on every requested channel.
+// Defaults to the tenant's default channels, or in-app, push, email and Slack,
+// when no channels are given. Email and Slack are queued in the outbox and
+// sent by RunOutboxWorker.
+func (d *NotificationDispatcher) Dispatch(ctx context.Context, params DispatchParams) error {
+	user, err := d.userRepo.GetByID(ctx, params.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get notification recipient: %w", err)
+	}
+	if !user.IsActive || !d.typeEnabled(user, params.Type) {
+		return nil
+	}
+
+	channels := params.Channels
+	if len(channels) == 0 {
+		channels = []models.NotificationChannel{models.NotifyInApp, models.NotifyPush, models.NotifyEmail, models.NotifySlack}
+		if settings, err := loadTenantSettings(ctx, d.tenantRepo, nil, user.TenantID); err == nil {
+			channels = settings.NotificationDefaultChannels
+		}
+	}
+
+	for _, channel := range channels {
+		if !d.channelEnabled(user, channel) {
+			continue
+		}
+
+		content := d.renderContent(ctx, user, channel, params)
+
+		switch channel {
+		case models.NotifyInApp:
+			notification := &models.Notification{
+				TenantID: user.TenantID,
+				UserID:   user.ID,
+				Type:     content.Type,
+				Title:    content.Title,
+				Message:  content.Message,
+				Channel:  models.NotifyInApp,
+				Data:     content.Data,
+			}
+			if err := d.notificationRepo.Create(ctx, notification); err != nil {
+				return fmt.Errorf("failed to store notification: %w", err)
+			}
+			if d.realtime != nil {
+				d.realtime.PublishToUser(ctx, user.TenantID, user.ID, RealtimeEventNotificationCreated, map[string]interface{}{
+					"notification_id": notification.ID.String(),
+					"type":            notification.Type,
+					"title":           notification.Title,
+					"message":         notification.Message,
+				})
+			}
+		case models.NotifyPush:
+			d.sendPush(ctx, user.ID, content)
+		case models.NotifySMS:
+			d.sendCriticalSMS(ctx, user, content)
+		case models.NotifyEmail, models.NotifySlack:
+			if err := d.enqueue(ctx, user, channel, content); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunOutboxWorker sends queued email and Slack notifications until the
+// context is cancelled
+func (d *NotificationDispatcher) RunOutboxWorker(ctx context.Context) {
+	ctx = startWorker(ctx, "notification_outbox", outboxPollInterval)
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := d.DeliverOutbox(ctx)
+		// Log errors but keep running - the next tick retries
+		reportPoll(ctx, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverOutbox attempts every due outbox delivery once and returns how many
+// were attempted
+func (d *NotificationDispatcher) DeliverOutbox(ctx context.Context) (int, error) {
+	now := time.Now()
+	deliveries, err := d.deliveryRepo.ListDue(ctx, now, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		claimed, err := d.deliveryRepo.Claim(ctx, delivery.ID, now, now.Add(outboxLease))
+		if err != nil || !claimed {
+			continue
+		}
+
+		d.attemptDelivery(ctx, delivery)
+		attempted++
+	}
+
+	return attempted, nil
+}
+
+// RegisterDevice registers (or refreshes) a device token for push delivery
+func (d *NotificationDispatcher) RegisterDevice(ctx context.Context, params RegisterDeviceParams) (*models.DeviceToken, error) {
+	if params.Token == "" {
+		return nil, ErrInvalidDeviceToken
+	}
+	if !isValidDevicePlatform(params.Platform) {
+		return nil, ErrInvalidDevicePlatform
+	}
+
+	device := &models.DeviceToken{
+		TenantID:   params.TenantID,
+		UserID:     params.UserID,
+		Platform:   params.Platform,
+		Token:      params.Token,
+		DeviceName: params.DeviceName,
+		AppVersion: params.AppVersion,
+		IsActive:   true,
+	}
+
+	if err := d.deviceTokenRepo.Upsert(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to register device: %w", err)
+	}
+
+	return device, nil
+}
+
+// ListDevices returns the active push devices registered by a user
+func (d *NotificationDispatcher) ListDevices(ctx context.Context, userID uuid.UUID) ([]models.DeviceToken, error) {
+	return d.deviceTokenRepo.ListActiveByUser(ctx, userID)
+}
+
+// UnregisterDevice removes a device registered by the given user
+func (d *NotificationDispatcher) UnregisterDevice(ctx context.Context, userID, deviceID uuid.UUID) error {
+	device, err := d.deviceTokenRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		return ErrDeviceTokenNotFound
+	}
+	if device.UserID != userID {
+		return ErrDeviceTokenNotFound
+	}
+
+	return d.deviceTokenRepo.Delete(ctx, deviceID)
+}
+
+// StartPhoneVerification stores a user's phone number and texts them a
+// one-time code. The tenant must have SMS enabled, and codes sent to the user
+// or the number within the last hour are limited.
+func (d *NotificationDispatcher) StartPhoneVerification(ctx context.Context, userID uuid.UUID, phoneNumber string) error {
+	if !e164Pattern.MatchString(phoneNumber) {
+		return ErrInvalidPhoneNumber
+	}
+	if d.smsProvider == nil {
+		return ErrSMSNotConfigured
+	}
+
+	user, err := d.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !d.smsEnabled(ctx, user.TenantID) {
+		return ErrSMSNotEnabled
+	}
+
+	now := time.Now()
+	byUser, byNumber, err := d.smsMessageRepo.CountSince(ctx, phoneVerificationMessageType, user.ID, phoneNumber, now.Add(-phoneVerificationWindow))
+	if err != nil {
+		return err
+	}
+	if byUser >= phoneVerificationsPerUser || byNumber >= phoneVerificationsPerNumber {
+		return &RateLimitedError{Until: now.Add(phoneVerificationWindow)}
+	}
+
+	code, err := generatePhoneCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	expiresAt := now.Add(phoneVerificationTTL)
+	user.PhoneNumber = phoneNumber
+	user.PhoneVerified = false
+	user.PhoneVerificationHash = hashPhoneCode(user.ID, code)
+	user.PhoneVerificationExpiresAt = &expiresAt
+	user.PhoneVerificationAttempts = 0
+
+	if err := d.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to save phone number: %w", err)
+	}
+
+	body := fmt.Sprintf("Your Archivus verification code is %s. It expires in %d minutes.", code, int(phoneVerificationTTL.Minutes()))
+	return d.deliverSMS(ctx, user, phoneVerificationMessageType, body)
+}
+
+// VerifyPhone confirms a user's phone number with the code sent by
+// StartPhoneVerification. Each code allows phoneCodeMaxAttempts guesses;
+// after that a new code must be requested.
+func (d *NotificationDispatcher) VerifyPhone(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := d.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if user.PhoneVerificationHash == "" || user.PhoneVerificationExpiresAt == nil ||
+		time.Now().After(*user.PhoneVerificationExpiresAt) {
+		return ErrInvalidPhoneCode
+	}
+
+	// Counted before comparing, atomically, so parallel guesses can't get
+	// past the limit
+	allowed, err := d.userRepo.RecordPhoneCodeAttempt(ctx, user.ID, phoneCodeMaxAttempts)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrPhoneCodeLocked
+	}
+	if subtle.ConstantTimeCompare([]byte(hashPhoneCode(user.ID, code)), []byte(user.PhoneVerificationHash)) != 1 {
+		return ErrInvalidPhoneCode
+	}
+
+	user.PhoneVerified = true
+	user.PhoneVerificationHash = ""
+	user.PhoneVerificationExpiresAt = nil
+	user.PhoneVerificationAttempts = 0
+
+	if err := d.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to verify phone: %w", err)
+	}
+	return nil
+}
+
+// RemovePhone clears a user's phone number, stopping SMS delivery
+func (d *NotificationDispatcher) RemovePhone(ctx context.Context, userID uuid.UUID) error {
+	user, err := d.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	user.PhoneNumber = ""
+	user.PhoneVerified = false
+	user.PhoneVerificationHash = ""
+	user.PhoneVerificationExpiresAt = nil
+	user.PhoneVerificationAttempts = 0
+
+	if err := d.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to remove phone: %w", err)
+	}
+	return nil
+}
+
+// GetSMSUsage reports a tenant's SMS volume and cost for a period
+func (d *NotificationDispatcher) GetSMSUsage(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*repositories.SMSUsage, error) {
+	return d.smsMessageRepo.GetUsage(ctx, tenantID, from, to)
+}
+
+// Critical event notifications
+
+// SendSecurityAlert notifies a user about a security-relevant event on their account
+func (d *NotificationDispatcher) SendSecurityAlert(ctx context.Context, userID uuid.UUID, title, message string, data models.JSONB) error {
+	return d.Dispatch(ctx, DispatchParams{
+		UserID:    userID,
+		Type:      NotificationSecurityAlert,
+		Title:     title,
+		Message:   message,
+		Data:      data,
+		Channels:  []models.NotificationChannel{models.NotifyInApp, models.NotifyPush, models.NotifyEmail, models.NotifySMS},
+		Variables: map[string]string{"title": title, "message": message},
+	})
+}
+
+// SendLegalHoldPlaced notifies a user that documents they own were placed
+// under legal hold. One document is named; more are counted.
+func (d *NotificationDispatcher) SendLegalHoldPlaced(ctx context.Context, userID, holdID uuid.UUID, documentIDs []uuid.UUID, reason string) error {
+	data := models.JSONB{
+		"hold_id":        holdID.String(),
+		"document_count": len(documentIDs),
+		"reason":         reason,
+	}
+	documentName := fmt.Sprintf("%d documents", len(documentIDs))
+	if len(documentIDs) == 1 {
+		data["document_id"] = documentIDs[0].String()
+		documentName = d.documentName(ctx, documentIDs[0])
+	}
+
+	return d.Dispatch(ctx, DispatchParams{
+		UserID:   userID,
+		Type:     NotificationLegalHoldPlaced,
+		Data:     data,
+		Channels: []models.NotificationChannel{models.NotifyInApp, models.NotifyPush, models.NotifyEmail, models.NotifySMS},
+		Variables: map[string]string{
+			"document_name":  documentName,
+			"document_count": fmt.Sprint(len(documentIDs)),
+			"reason":         reason,
+		},
+	})
+}
+
+// SendOwnershipTransferred tells a party of an ownership transfer what moved
+func (d *NotificationDispatcher) SendOwnershipTransferred(ctx context.Context, userID uuid.UUID, items, fromName, toName string) error {
+	return d.Dispatch(ctx, DispatchParams{
+		UserID: userID,
+		Type:   NotificationOwnershipTransfer,
+		Data: models.JSONB{
+			"items":     items,
+			"from_name": fromName,
+			"to_name":   toName,
+		},
+		Variables: map[string]string{
+			"items":     items,
+			"from_name": fromName,
+			"to_name":   toName,
+		},
+	})
+}
+
+// SendFolderArchived tells a folder owner that an archival schedule archived
+// documents in their folders
+func (d *NotificationDispatcher) SendFolderArchived(ctx context.Context, userID uuid.UUID, run *models.ArchivalRun, scheduleName, folderPath string, documents int64) error {
+	return d.Dispatch(ctx, DispatchParams{
+		UserID: userID,
+		Type:   NotificationFolderArchived,
+		Data: models.JSONB{
+			"run_id":      run.ID.String(),
+			"schedule_id": run.ScheduleID.String(),
+			"folder_id":   run.FolderID.String(),
+			"documents":   documents,
+			"undo_until":  run.UndoUntil.UTC().Format(time.RFC3339),
+		},
+		Variables: map[string]string{
+			"schedule_name": scheduleName,
+			"folder_path":   folderPath,
+			"documents":     fmt.Sprint(documents),
+			"undo_until":    run.UndoUntil.UTC().Format("2006-01-02 15:04 UTC"),
+		},
+	})
+}
+
+// SendAccessExpiring warns a user that a temporary access grant is about to expire
+func (d *NotificationDispatcher) SendAccessExpiring(ctx context.Context, userID uuid.UUID, resourceName, granteeName string, expiresAt time.Time) error {
+	return d.Dispatch(ctx, DispatchParams{
+		UserID: userID,
+		Type:   NotificationAccessExpiring,
+		Data: models.JSONB{
+			"resource_name": resourceName,
+			"expires_at":    expiresAt.UTC().Format(time.RFC3339),
+		},
+		Variables: map[string]string{
+			"resource_name": resourceName,
+			"grantee_name":  granteeName,
+			"expires_at":    expiresAt.UTC().Format("2006-01-02 15:04 UTC"),
+		},
+	})
+}
+
+// SendTrialExpiring warns a tenant admin that the trial ends soon
+func (d *NotificationDispatcher) SendTrialExpiring(ctx context.Context, userID uuid.UUID, tenantName string, endsAt time.Time) error {
+	// Whole days, rounded up so the last day reads "1"
+	daysRemaining := max(int((time.Until(endsAt)+24*time.Hour-1)/(24*time.Hour)), 0)
+
+	return d.Dispatch(ctx, DispatchParams{
+		UserID: userID,
+		Type:   NotificationTrialExpiring,
+		Data: models.JSONB{
+			"ends_at":        endsAt.UTC().Format(time.RFC3339),
+			"days_remaining": daysRemaining,
+		},
+		Variables: map[string]string{
+			"tenant_name":    tenantName,
+			"ends_at":        endsAt.UTC().Format("2006-01-02"),
+			"days_remaining": fmt.Sprint(daysRemaining),
+		},
+	})
+}
+
+// SendTrialExpired tells a tenant admin the trial ended and what happened to
+// the tenant, e.g. "suspended"
+func (d *NotificationDispatcher) SendTrialExpired(ctx context.Context, userID uuid.UUID, tenantName, outcome string) error {
+	return d.Dispatch(ctx, DispatchParams{
+		UserID: userID,
+		Type:   NotificationTrialExpired,
+		Data: models.JSONB{
+			"outcome": outcome,
+		},
+		Variables: map[string]string{
+			"tenant_name": tenantName,
+			"outcome":     outcome,
+		},
+	})
+}
+
+// SendDocumentExpiring warns a user that a document they own expires soon
+func (d *NotificationDispatcher) SendDocumentExpiring(ctx context.Context, userID uuid.UUID, document *models.Document) error {
+	expiresAt := ""
+	if document.ExpiryDate != nil {
+		expiresAt = document.ExpiryDate.UTC().Format("2006-01-02")
+	}
+
+	return d.Dispatch(ctx, DispatchParams{
+		UserID: userID,
+		Type:   NotificationDocumentExpiring,
+		Data: models.JSONB{
+			"document_id": document.ID.String(),
+			"expires_at":  expiresAt,
+		},
+		Variables: map[string]string{
+			"document_name": document.Title,
+			"expires_at":    expiresAt,
+		},
+	})
+}
+
+// NotifyExpiringDocuments warns the uploaders of the tenant's documents that
+// expire within the given number of days, and returns how many were sent
+func (d *NotificationDispatcher) NotifyExpiringDocuments(ctx context.Context, tenantID uuid.UUID, days int) (int, error) {
+	documents, err := d.documentRepo.GetExpiring(ctx, tenantID, days)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var lastErr error
+	for i := range documents {
+		if err := d.SendDocumentExpiring(ctx, documents[i].Owner(), &documents[i]); err != nil {
+			// Log but continue - other uploaders still get their warning
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+
+	return sent, lastErr
+}
+
+// ExpiringDocumentsJob is the tenant job that warns uploaders of expiring
+// documents. The "days" parameter sets how far ahead to look.
+func (d *NotificationDispatcher) ExpiringDocumentsJob() JobHandler {
+	return func(ctx context.Context, tenantID uuid.UUID, params models.JSONB) error {
+		days := defaultExpiryNoticeDays
+		// Parameters come back from JSON, so numbers are float64
+		if value, ok := params["days"].(float64); ok && value >= 1 {
+			days = int(value)
+		}
+		_, err := d.NotifyExpiringDocuments(ctx, tenantID, days)
+		return err
+	}
+}
+
+// Workflow notifications (NotificationService implementation)
+
+func (d *NotificationDispatcher) SendTaskAssignment(ctx context.Context, task *models.WorkflowTask, userID uuid.UUID) error {
+	return d.Dispatch(ctx, DispatchParams{
+		UserID:    userID,
+		Type:      NotificationTaskAssignment,
+		Data:      taskNotificationData(task),
+		Variables: d.taskVariables(ctx, task),
+	})
+}
+
+func (d *NotificationDispatcher) SendTaskCompletion(ctx context.Context, task *models.WorkflowTask, completedBy uuid.UUID, action string) error {
+	document, err := d.documentRepo.GetByID(ctx, task.DocumentID)
+	if err != nil {
+		return fmt.Errorf("failed to get task document: %w", err)
+	}
+
+	// Don't notify users about their own actions
+	if document.Owner() == completedBy {
+		return nil
+	}
+
+	data := taskNotificationData(task)
+	data["action"] = action
+
+	return d.Dispatch(ctx, DispatchParams{
+		UserID: document.Owner(),
+		Type:   NotificationTaskCompletion,
+		Data:   data,
+		Variables: map[string]string{
+			"task_type":     task.TaskType,
+			"document_name": documentLabel(document),
+			"action":        action,
+		},
+	})
+}
+
+func (d *NotificationDispatcher) SendTaskReminder(ctx context.Context, task *models.WorkflowTask) error {
+	return d.Dispatch(ctx, DispatchParams{
+		UserID:    task.AssignedTo,
+		Type:      NotificationTaskReminder,
+		Data:      taskNotificationData(task),
+		Variables: d.taskVariables(ctx, task),
+	})
+}
+
+func (d *NotificationDispatcher) SendTaskEscalation(ctx context.Context, task *models.WorkflowTask, escalatedTo uuid.UUID) error {
+	return d.Dispatch(ctx, DispatchParams{
+		UserID:    escalatedTo,
+		Type:      NotificationTaskEscalation,
+		Data:      taskNotificationData(task),
+		Channels:  []models.NotificationChannel{models.NotifyInApp, models.NotifyPush, models.NotifyEmail, models.NotifySMS},
+		Variables: d.taskVariables(ctx, task),
+	})
+}
+
+// SendTaskEscalatedAway tells a task's previous assignee it was escalated to
+// someone else
+func (d *NotificationDispatcher) SendTaskEscalatedAway(ctx context.Context, task *models.WorkflowTask, previousAssignee uuid.UUID) error {
+	escalatedToName := "another user"
+	if user, err := d.userRepo.GetByID(ctx, task.AssignedTo); err == nil {
+		escalatedToName = userDisplayName(user)
+	}
+
+	data := taskNotificationData(task)
+	data["escalated_to"] = task.AssignedTo.String()
+	variables := d.taskVariables(ctx, task)
+	variables["escalated_to_name"] = escalatedToName
+
+	return d.Dispatch(ctx, DispatchParams{
+		UserID:    previousAssignee,
+		Type:      NotificationTaskEscalatedAway,
+		Data:      data,
+		Variables: variables,
+	})
+}
+
+// SendShareActivity notifies the owner of a share link that it was used
+func (d *NotificationDispatcher) SendShareActivity(ctx context.Context, share *models.Share, activity string) error {
+	return d.Dispatch(ctx, DispatchParams{
+		UserID: share.CreatedBy,
+		Type:   NotificationShareActivity,
+		Data: models.JSONB{
+			"share_id":    share.ID.String(),
+			"document_id": share.DocumentID.String(),
+			"activity":    activity,
+		},
+		Variables: map[string]string{
+			"document_name": d.documentName(ctx, share.DocumentID),
+			"activity":      activity,
+		},
+	})
+}
+
+// Helper methods
+
+func (d *NotificationDispatcher) sendPush(ctx context.Context, userID uuid.UUID, params DispatchParams) {
+	devices, err := d.deviceTokenRepo.ListActiveByUser(ctx, userID)
+	if err != nil || len(devices) == 0 {
+		return
+	}
+
+	message := PushMessage{
+		Title: params.Title,
+		Body:  params.Message,
+		Data:  map[string]string{"type": params.Type},
+	}
+	for key, value := range params.Data {
+		message.Data[key] = fmt.Sprint(value)
+	}
+
+	for _, device := range devices {
+		provider, ok := d.pushProviders[device.Platform]
+		if !ok {
+			continue
+		}
+
+		if err := provider.SendPush(ctx, device.Token, message); err != nil {
+			// Providers report uninstalled apps / rotated tokens; stop sending to them
+			if errors.Is(err, ErrPushTokenExpired) {
+				if err := d.deviceTokenRepo.Deactivate(ctx, device.Token); err != nil {
+					// Log but don't fail
+				}
+			}
+		}
+	}
+}
+
+// sendCriticalSMS texts critical notifications to users with a verified phone,
+// provided their tenant has opted into SMS (settings.sms_enabled)
+func (d *NotificationDispatcher) sendCriticalSMS(ctx context.Context, user *models.User, params DispatchParams) {
+	if d.smsProvider == nil || !criticalNotificationTypes[params.Type] {
+		return
+	}
+	if user.PhoneNumber == "" || !user.PhoneVerified {
+		return
+	}
+
+	if !d.smsEnabled(ctx, user.TenantID) {
+		return
+	}
+
+	body := params.Title + ": " + params.Message
+	if len(body) > 320 {
+		body = body[:317] + "..."
+	}
+
+	if err := d.deliverSMS(ctx, user, params.Type, body); err != nil {
+		// Log but don't fail
+	}
+}
+
+// smsEnabled reports whether the tenant opted into SMS delivery
+func (d *NotificationDispatcher) smsEnabled(ctx context.Context, tenantID uuid.UUID) bool {
+	tenant, err := d.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return false
+	}
+	enabled, _ := tenant.Settings[tenantSMSEnabledSetting].(bool)
+	return enabled
+}
+
+// deliverSMS sends a message and records it (successful or not) for cost tracking
+func (d *NotificationDispatcher) deliverSMS(ctx context.Context, user *models.User, eventType, body string) error {
+	record := &models.SMSMessage{
+		TenantID:  user.TenantID,
+		UserID:    user.ID,
+		EventType: eventType,
+		ToNumber:  user.PhoneNumber,
+		Segments:  1,
+		Currency:  "USD",
+		Status:    "sent",
+	}
+
+	result, sendErr := d.smsProvider.SendSMS(ctx, user.PhoneNumber, body)
+	if sendErr != nil {
+		record.Status = "failed"
+		record.Error = sendErr.Error()
+	} else {
+		record.ProviderMessageID = result.MessageID
+		record.Segments = result.Segments
+		record.Cost = result.Cost
+		if result.Currency != "" {
+			record.Currency = result.Currency
+		}
+	}
+
+	if err := d.smsMessageRepo.Create(ctx, record); err != nil {
+		// Log but don't fail
+	}
+
+	if sendErr != nil {
+		return fmt.Errorf("failed to send sms: %w", sendErr)
+	}
+	return nil
+}
+
+// enqueue queues an email or Slack notification when the channel has a
+// sender and the user has a recipient on it
+func (d *NotificationDispatcher) enqueue(ctx context.Context, user *models.User, channel models.NotificationChannel, params DispatchParams) error {
+	if _, ok := d.senders[channel]; !ok {
+		return nil
+	}
+
+	recipient := d.channelRecipient(ctx, user, channel)
+	if recipient == "" {
+		return nil
+	}
+
+	delivery := &models.NotificationDelivery{
+		TenantID:      user.TenantID,
+		UserID:        user.ID,
+		Channel:       channel,
+		Recipient:     recipient,
+		Type:          params.Type,
+		Title:         params.Title,
+		Message:       params.Message,
+		Data:          params.Data,
+		Status:        models.NotificationDeliveryPending,
+		MaxAttempts:   5,
+		NextAttemptAt: time.Now(),
+	}
+	if err := d.deliveryRepo.Create(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to queue %s notification: %w", channel, err)
+	}
+	return nil
+}
+
+// channelRecipient is the user's email address, or the tenant's Slack webhook
+func (d *NotificationDispatcher) channelRecipient(ctx context.Context, user *models.User, channel models.NotificationChannel) string {
+	switch channel {
+	case models.NotifyEmail:
+		return user.Email
+	case models.NotifySlack:
+		tenant, err := d.tenantRepo.GetByID(ctx, user.TenantID)
+		if err != nil {
+			return ""
+		}
+		webhookURL, _ := tenant.Settings[tenantSlackWebhookSetting].(string)
+		return webhookURL
+	default:
+		return ""
+	}
+}
+
+// attemptDelivery sends one outbox delivery and schedules a retry or records
+// the final outcome
+func (d *NotificationDispatcher) attemptDelivery(ctx context.Context, delivery *models.NotificationDelivery) {
+	now := time.Now()
+	delivery.Attempts++
+
+	var sendErr error
+	sender, ok := d.senders[delivery.Channel]
+	if !ok {
+		sendErr = fmt.Errorf("no sender configured for %s", delivery.Channel)
+	} else {
+		message := ChannelMessage{
+			TenantID:  delivery.TenantID,
+			Recipient: delivery.Recipient,
+			Type:      delivery.Type,
+			Title:     delivery.Title,
+			Message:   delivery.Message,
+			Data:      delivery.Data,
+		}
+		if user, err := d.userRepo.GetByID(ctx, delivery.UserID); err == nil {
+			message.RecipientName = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		}
+		sendErr = sender.Send(ctx, message)
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.NotificationDeliverySent
+		delivery.LastError = ""
+		delivery.SentAt = &now
+	case delivery.Attempts >= delivery.MaxAttempts || !ok:
+		delivery.Status = models.NotificationDeliveryFailed
+		delivery.LastError = sendErr.Error()
+	default:
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = now.Add(outboxRetryDelay(delivery.Attempts))
+	}
+
+	if err := d.deliveryRepo.Update(ctx, delivery); err != nil {
+		// Log but continue - the lease expires and the attempt is retried
+	}
+}
+
+// typeEnabled honours per-type opt-outs stored under notification_settings.types.
+// Critical notifications can't be turned off.
+func (d *NotificationDispatcher) typeEnabled(user *models.User, notificationType string) bool {
+	if criticalNotificationTypes[notificationType] {
+		return true
+	}
+	// Accounts created before per-type settings have a task_reminders flag
+	if notificationType == NotificationTaskReminder {
+		if enabled, ok := user.NotificationSettings["task_reminders"].(bool); ok && !enabled {
+			return false
+		}
+	}
+	types, ok := user.NotificationSettings[userNotificationTypesSetting].(map[string]interface{})
+	if !ok {
+		return true
+	}
+	if enabled, ok := types[notificationType].(bool); ok {
+		return enabled
+	}
+	return true
+}
+
+// channelEnabled honours per-user opt-outs stored in notification_settings,
+// e.g. {"push": false}. Channels are enabled unless explicitly disabled.
+func (d *NotificationDispatcher) channelEnabled(user *models.User, channel models.NotificationChannel) bool {
+	if user.NotificationSettings == nil {
+		return true
+	}
+	if enabled, ok := user.NotificationSettings[string(channel)].(bool); ok {
+		return enabled
+	}
+	// Accounts created before per-channel settings have an email_notifications flag
+	if channel == models.NotifyEmail {
+		if enabled, ok := user.NotificationSettings["email_notifications"].(bool); ok {
+			return enabled
+		}
+	}
+	return true
+}
+
+// renderContent fills in Title/Message from the tenant's template for the
+// channel (or the system default) when the notification carries variables
+func (d *NotificationDispatcher) renderContent(ctx context.Context, user *models.User, channel models.NotificationChannel, params DispatchParams) DispatchParams {
+	if params.Variables == nil {
+		return params
+	}
+
+	vars := make(map[string]string, len(params.Variables)+1)
+	for name, value := range params.Variables {
+		vars[name] = value
+	}
+	vars["recipient_name"] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+
+	var rendered *RenderedNotification
+	if d.templates != nil {
+		rendered = d.templates.Render(ctx, user.TenantID, params.Type, channel, vars)
+	} else {
+		rendered = renderSystemNotification(params.Type, vars)
+	}
+	if rendered == nil {
+		return params
+	}
+
+	params.Title = rendered.Subject
+	params.Message = rendered.Body
+	return params
+}
+
+func (d *NotificationDispatcher) taskVariables(ctx context.Context, task *models.WorkflowTask) map[string]string {
+	return map[string]string{
+		"task_type":     task.TaskType,
+		"document_name": d.documentName(ctx, task.DocumentID),
+	}
+}
+
+func (d *NotificationDispatcher) documentName(ctx context.Context, documentID uuid.UUID) string {
+	document, err := d.documentRepo.GetByID(ctx, documentID)
+	if err != nil {
+		return "a document"
+	}
+	return documentLabel(document)
+}
+
+func documentLabel(document *models.Document) string {
+	if document.Title != "" {
+		return document.Title
+	}
+	return document.OriginalName
+}
+
+func taskNotificationData(task *models.WorkflowTask) models.JSONB {
+	return models.JSONB{
+		"task_id":     task.ID.String(),
+		"workflow_id": task.WorkflowID.String(),
+		"document_id": task.DocumentID.String(),
+	}
+}
+
+// outboxRetryDelay doubles from outboxRetryBase on each attempt, capped at outboxRetryMax
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := outboxRetryBase
+	for i := 1; i < attempts && delay < outboxRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > outboxRetryMax {
+		delay = outboxRetryMax
+	}
+	return delay
+}
+
+func generatePhoneCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashPhoneCode binds the code to the user so a hash can't be replayed across accounts
+func hashPhoneCode(userID uuid.UUID, code string) string {
+	sum := sha256.Sum256([]byte(userID.String() + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+func isValidDevicePlatform(platform models.DevicePlatform) bool {
+	switch platform {
+	case models.DevicePlatformIOS, models.DevicePlatformAndroid, models.DevicePlatformWeb:
+		return true
+	}
+	return false
+}
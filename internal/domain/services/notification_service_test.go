@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUserRepo keeps users in memory
+type memoryUserRepo struct {
+	repositories.UserRepository
+	mu    sync.Mutex
+	users map[uuid.UUID]models.User
+}
+
+func (m *memoryUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, ok := m.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (m *memoryUserRepo) GetByEmail(ctx context.Context, tenantID uuid.UUID, email string) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, user := range m.users {
+		if user.TenantID == tenantID && user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (m *memoryUserRepo) Update(ctx context.Context, user *models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[user.ID] = *user
+	return nil
+}
+
+func (m *memoryUserRepo) RecordPhoneCodeAttempt(ctx context.Context, userID uuid.UUID, maxAttempts int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user := m.users[userID]
+	if user.PhoneVerificationAttempts >= maxAttempts {
+		return false, nil
+	}
+	user.PhoneVerificationAttempts++
+	m.users[userID] = user
+	return true, nil
+}
+
+// memorySMSRepo records sent messages in memory
+type memorySMSRepo struct {
+	repositories.SMSMessageRepository
+	messages []models.SMSMessage
+}
+
+func (m *memorySMSRepo) Create(ctx context.Context, message *models.SMSMessage) error {
+	message.CreatedAt = time.Now()
+	m.messages = append(m.messages, *message)
+	return nil
+}
+
+func (m *memorySMSRepo) CountSince(ctx context.Context, eventType string, userID uuid.UUID, toNumber string, since time.Time) (int64, int64, error) {
+	var byUser, byNumber int64
+	for _, message := range m.messages {
+		if message.EventType != eventType || message.CreatedAt.Before(since) {
+			continue
+		}
+		if message.UserID == userID {
+			byUser++
+		}
+		if message.ToNumber == toNumber {
+			byNumber++
+		}
+	}
+	return byUser, byNumber, nil
+}
+
+// settingsTenantRepo returns tenants with the given settings
+type settingsTenantRepo struct {
+	repositories.TenantRepository
+	settings models.JSONB
+}
+
+func (r *settingsTenantRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Tenant, error) {
+	return &models.Tenant{ID: id, Settings: r.settings}, nil
+}
+
+// recordingSMSProvider keeps the texts it was asked to send
+type recordingSMSProvider struct {
+	bodies []string
+}
+
+func (p *recordingSMSProvider) SendSMS(ctx context.Context, to, body string) (*SMSResult, error) {
+	p.bodies = append(p.bodies, body)
+	return &SMSResult{Segments: 1}, nil
+}
+
+func newPhoneVerificationDispatcher(smsEnabled bool, users ...models.User) (*NotificationDispatcher, *memoryUserRepo, *recordingSMSProvider) {
+	userRepo := &memoryUserRepo{users: make(map[uuid.UUID]models.User)}
+	for _, user := range users {
+		userRepo.users[user.ID] = user
+	}
+	provider := &recordingSMSProvider{}
+	dispatcher := NewNotificationDispatcher(nil, nil, userRepo, nil,
+		&settingsTenantRepo{settings: models.JSONB{tenantSMSEnabledSetting: smsEnabled}},
+		&memorySMSRepo{}, nil, nil, nil, provider, nil, nil)
+	return dispatcher, userRepo, provider
+}
+
+var phoneCodePattern = regexp.MustCompile(`\d{6}`)
+
+func TestStartPhoneVerification_RequiresTenantSMS(t *testing.T) {
+	user := models.User{ID: uuid.New(), TenantID: uuid.New()}
+	dispatcher, _, provider := newPhoneVerificationDispatcher(false, user)
+
+	err := dispatcher.StartPhoneVerification(context.Background(), user.ID, "+14155550100")
+	assert.ErrorIs(t, err, ErrSMSNotEnabled)
+	assert.Empty(t, provider.bodies)
+}
+
+func TestStartPhoneVerification_RateLimited(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	users := make([]models.User, phoneVerificationsPerNumber+1)
+	for i := range users {
+		users[i] = models.User{ID: uuid.New(), TenantID: tenantID}
+	}
+	dispatcher, _, provider := newPhoneVerificationDispatcher(true, users...)
+
+	// Per user, whichever number they ask for
+	for i := 0; i < phoneVerificationsPerUser; i++ {
+		require.NoError(t, dispatcher.StartPhoneVerification(ctx, users[0].ID, fmt.Sprintf("+1415555010%d", i)))
+	}
+	err := dispatcher.StartPhoneVerification(ctx, users[0].ID, "+14155550199")
+	var limited *RateLimitedError
+	require.ErrorAs(t, err, &limited)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.True(t, limited.Until.After(time.Now()))
+	assert.Len(t, provider.bodies, phoneVerificationsPerUser)
+
+	// Per number, whoever asks for it; the first user already had one code sent to it
+	for _, user := range users[1:phoneVerificationsPerNumber] {
+		require.NoError(t, dispatcher.StartPhoneVerification(ctx, user.ID, "+14155550100"))
+	}
+	assert.ErrorIs(t, dispatcher.StartPhoneVerification(ctx, users[phoneVerificationsPerNumber].ID, "+14155550100"), ErrRateLimited)
+}
+
+func TestVerifyPhone_LocksAfterTooManyAttempts(t *testing.T) {
+	ctx := context.Background()
+	user := models.User{ID: uuid.New(), TenantID: uuid.New()}
+	dispatcher, userRepo, provider := newPhoneVerificationDispatcher(true, user)
+
+	require.NoError(t, dispatcher.StartPhoneVerification(ctx, user.ID, "+14155550100"))
+	code := phoneCodePattern.FindString(provider.bodies[0])
+	require.NotEmpty(t, code)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for i := 0; i < phoneCodeMaxAttempts; i++ {
+		assert.ErrorIs(t, dispatcher.VerifyPhone(ctx, user.ID, wrong), ErrInvalidPhoneCode)
+	}
+	// The right code no longer works either
+	assert.ErrorIs(t, dispatcher.VerifyPhone(ctx, user.ID, code), ErrPhoneCodeLocked)
+	assert.False(t, userRepo.users[user.ID].PhoneVerified)
+
+	// A new code starts over
+	require.NoError(t, dispatcher.StartPhoneVerification(ctx, user.ID, "+14155550100"))
+	code = phoneCodePattern.FindString(provider.bodies[1])
+	require.NoError(t, dispatcher.VerifyPhone(ctx, user.ID, code))
+	verified := userRepo.users[user.ID]
+	assert.True(t, verified.PhoneVerified)
+	assert.Zero(t, verified.PhoneVerificationAttempts)
+	assert.Empty(t, verified.PhoneVerificationHash)
+}
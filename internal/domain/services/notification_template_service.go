@@ -0,0 +1,476 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownNotificationEvent     = errors.New("unknown notification event type")
+	ErrUnsupportedTemplateChannel   = errors.New("notification channel does not support templates")
+	ErrInvalidNotificationTemplate  = errors.New("invalid notification template")
+	ErrNotificationTemplateNotFound = errors.New("notification template not found")
+)
+
+// Notification event types without their own constant in notification_service.go
+const (
+	NotificationTaskAssignment    = "task_assignment"
+	NotificationTaskCompletion    = "task_completion"
+	NotificationTaskReminder      = "task_reminder"
+	NotificationTaskEscalatedAway = "task_escalated_away"
+	NotificationShareActivity     = "share_activity"
+	NotificationAccessExpiring    = "access_expiring"
+	NotificationDocumentExpiring  = "document_expiring"
+	NotificationOwnershipTransfer = "ownership_transfer"
+	NotificationTrialExpiring     = "trial_expiring"
+	NotificationTrialExpired      = "trial_expired"
+	NotificationFolderArchived    = "folder_archived"
+)
+
+// templatePlaceholder matches {{variable}} placeholders, allowing inner spaces
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]*)\s*\}\}`)
+
+// TemplateChannels are the channels a tenant can customise wording for
+var TemplateChannels = []models.NotificationChannel{
+	models.NotifyInApp,
+	models.NotifyPush,
+	models.NotifySMS,
+	models.NotifyEmail,
+}
+
+// NotificationEventSchema describes an event's system default wording and the
+// variables its templates may reference (name -> example value used in previews)
+type NotificationEventSchema struct {
+	EventType      string            `json:"event_type"`
+	Description    string            `json:"description"`
+	Variables      map[string]string `json:"variables"`
+	DefaultSubject string            `json:"default_subject"`
+	DefaultBody    string            `json:"default_body"`
+}
+
+// commonTemplateVariables are filled in by the dispatcher for every event
+var commonTemplateVariables = map[string]string{
+	"recipient_name": "Jane Smith",
+}
+
+var notificationEventSchemas = map[string]NotificationEventSchema{
+	NotificationTaskAssignment: {
+		Description:    "A workflow task was assigned to the recipient",
+		Variables:      map[string]string{"task_type": "approval", "document_name": "Invoice INV-1042"},
+		DefaultSubject: "New task assigned",
+		DefaultBody:    "You have a new {{task_type}} task for {{document_name}}",
+	},
+	NotificationTaskCompletion: {
+		Description:    "A task on a document the recipient owns was completed",
+		Variables:      map[string]string{"task_type": "approval", "document_name": "Invoice INV-1042", "action": "approved"},
+		DefaultSubject: "Task {{action}}",
+		DefaultBody:    "The {{task_type}} task for {{document_name}} was {{action}}",
+	},
+	NotificationTaskReminder: {
+		Description:    "A task assigned to the recipient is still pending",
+		Variables:      map[string]string{"task_type": "approval", "document_name": "Invoice INV-1042"},
+		DefaultSubject: "Task reminder",
+		DefaultBody:    "Your {{task_type}} task for {{document_name}} is awaiting action",
+	},
+	NotificationTaskEscalation: {
+		Description:    "An overdue task was escalated to the recipient",
+		Variables:      map[string]string{"task_type": "approval", "document_name": "Invoice INV-1042"},
+		DefaultSubject: "Task escalated to you",
+		DefaultBody:    "An overdue {{task_type}} task for {{document_name}} has been escalated to you",
+	},
+	NotificationTaskEscalatedAway: {
+		Description:    "An overdue task assigned to the recipient was escalated to someone else",
+		Variables:      map[string]string{"task_type": "approval", "document_name": "Invoice INV-1042", "escalated_to_name": "Alex Doe"},
+		DefaultSubject: "Your task was escalated",
+		DefaultBody:    "Your overdue {{task_type}} task for {{document_name}} was escalated to {{escalated_to_name}}",
+	},
+	NotificationShareActivity: {
+		Description:    "A share link created by the recipient was opened",
+		Variables:      map[string]string{"document_name": "Q3 Report", "activity": "downloaded"},
+		DefaultSubject: "Shared document {{activity}}",
+		DefaultBody:    "Your shared link for {{document_name}} was {{activity}}",
+	},
+	NotificationAccessExpiring: {
+		Description:    "A temporary access grant held or issued by the recipient is about to expire",
+		Variables:      map[string]string{"resource_name": "Q3 Audit folder", "grantee_name": "External Auditor", "expires_at": "2025-03-31 17:00 UTC"},
+		DefaultSubject: "Access expiring soon",
+		DefaultBody:    "Access for {{grantee_name}} to {{resource_name}} expires on {{expires_at}}",
+	},
+	NotificationDocumentExpiring: {
+		Description:    "A document owned by the recipient expires soon or has expired",
+		Variables:      map[string]string{"document_name": "Lease agreement", "expires_at": "2025-03-31"},
+		DefaultSubject: "Document expiring",
+		DefaultBody:    "{{document_name}} expires on {{expires_at}}",
+	},
+	NotificationOwnershipTransfer: {
+		Description:    "Documents or folders were transferred to or from the recipient",
+		Variables:      map[string]string{"items": "12 documents and 2 folders", "from_name": "Jane Smith", "to_name": "Alex Doe"},
+		DefaultSubject: "Ownership transferred",
+		DefaultBody:    "Ownership of {{items}} was transferred from {{from_name}} to {{to_name}}",
+	},
+	NotificationTrialExpiring: {
+		Description:    "The tenant's trial, administered by the recipient, ends soon",
+		Variables:      map[string]string{"tenant_name": "Acme Ltd", "ends_at": "2025-03-31", "days_remaining": "7"},
+		DefaultSubject: "Your trial ends in {{days_remaining}} days",
+		DefaultBody:    "The trial of {{tenant_name}} ends on {{ends_at}}. Choose a plan to keep using your documents.",
+	},
+	NotificationTrialExpired: {
+		Description:    "The tenant's trial, administered by the recipient, has ended",
+		Variables:      map[string]string{"tenant_name": "Acme Ltd", "outcome": "suspended"},
+		DefaultSubject: "Your trial has ended",
+		DefaultBody:    "The trial of {{tenant_name}} has ended and the account was {{outcome}}. Choose a plan to reactivate it.",
+	},
+	NotificationFolderArchived: {
+		Description:    "Documents in folders owned by the recipient were archived by an archival schedule",
+		Variables:      map[string]string{"schedule_name": "Projects 2022 close", "folder_path": "/Projects/2022", "documents": "148", "undo_until": "2025-03-31 17:00 UTC"},
+		DefaultSubject: "Documents archived",
+		DefaultBody:    "{{documents}} documents in {{folder_path}} were archived by {{schedule_name}}. An admin can undo this until {{undo_until}}.",
+	},
+	NotificationSecurityAlert: {
+		Description:    "A security-relevant event occurred on the recipient's account",
+		Variables:      map[string]string{"title": "New sign-in", "message": "Your account was accessed from a new device"},
+		DefaultSubject: "{{title}}",
+		DefaultBody:    "{{message}}",
+	},
+	NotificationLegalHoldPlaced: {
+		Description:    "Documents owned by the recipient were placed under legal hold; document_name is the document's name, or e.g. \"12 documents\" for several",
+		Variables:      map[string]string{"document_name": "Contract MSA-7", "document_count": "1", "reason": "Pending litigation"},
+		DefaultSubject: "Legal hold placed",
+		DefaultBody:    "A legal hold was placed for {{document_name}}: {{reason}}",
+	},
+}
+
+// NotificationTemplateService manages per-tenant notification wording, falling
+// back to the system defaults above when a tenant has no override
+type NotificationTemplateService struct {
+	templateRepo repositories.NotificationTemplateRepository
+	auditRepo    repositories.AuditLogRepository
+}
+
+// NewNotificationTemplateService creates a new notification template service
+func NewNotificationTemplateService(
+	templateRepo repositories.NotificationTemplateRepository,
+	auditRepo repositories.AuditLogRepository,
+) *NotificationTemplateService {
+	return &NotificationTemplateService{
+		templateRepo: templateRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+// EffectiveNotificationTemplate is the wording used for an event on a channel
+type EffectiveNotificationTemplate struct {
+	EventType string                     `json:"event_type"`
+	Channel   models.NotificationChannel `json:"channel"`
+	Subject   string                     `json:"subject"`
+	Body      string                     `json:"body"`
+	IsDefault bool                       `json:"is_default"`
+	UpdatedAt *time.Time                 `json:"updated_at,omitempty"`
+}
+
+// SaveNotificationTemplateParams contains parameters for saving a tenant template
+type SaveNotificationTemplateParams struct {
+	TenantID  uuid.UUID                  `json:"tenant_id"`
+	EventType string                     `json:"event_type"`
+	Channel   models.NotificationChannel `json:"channel"`
+	Subject   string                     `json:"subject"`
+	Body      string                     `json:"body"`
+	UpdatedBy uuid.UUID                  `json:"updated_by"`
+}
+
+// PreviewNotificationTemplateParams contains parameters for rendering a preview.
+// Subject and Body default to the effective template; Variables override the
+// schema's example values.
+type PreviewNotificationTemplateParams struct {
+	TenantID  uuid.UUID                  `json:"tenant_id"`
+	EventType string                     `json:"event_type"`
+	Channel   models.NotificationChannel `json:"channel"`
+	Subject   string                     `json:"subject"`
+	Body      string                     `json:"body"`
+	Variables map[string]string          `json:"variables"`
+}
+
+// RenderedNotification is a template with its placeholders substituted
+type RenderedNotification struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// ListEventSchemas returns every templatable event, ordered by event type
+func (s *NotificationTemplateService) ListEventSchemas() []NotificationEventSchema {
+	schemas := make([]NotificationEventSchema, 0, len(notificationEventSchemas))
+	for eventType := range notificationEventSchemas {
+		schema, _ := notificationEventSchema(eventType)
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].EventType < schemas[j].EventType })
+	return schemas
+}
+
+// ListTemplates returns the effective template for every event and channel
+func (s *NotificationTemplateService) ListTemplates(ctx context.Context, tenantID uuid.UUID) ([]EffectiveNotificationTemplate, error) {
+	overrides, err := s.templateRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*models.NotificationTemplate, len(overrides))
+	for i := range overrides {
+		byKey[overrides[i].EventType+"/"+string(overrides[i].Channel)] = &overrides[i]
+	}
+
+	var templates []EffectiveNotificationTemplate
+	for _, schema := range s.ListEventSchemas() {
+		for _, channel := range TemplateChannels {
+			templates = append(templates, effectiveTemplate(schema, channel, byKey[schema.EventType+"/"+string(channel)]))
+		}
+	}
+
+	return templates, nil
+}
+
+// GetTemplate returns the effective template for an event on a channel
+func (s *NotificationTemplateService) GetTemplate(ctx context.Context, tenantID uuid.UUID, eventType string, channel models.NotificationChannel) (*EffectiveNotificationTemplate, error) {
+	schema, err := validateTemplateTarget(eventType, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	override, err := s.templateRepo.Get(ctx, tenantID, eventType, channel)
+	if err != nil {
+		override = nil
+	}
+
+	template := effectiveTemplate(schema, channel, override)
+	return &template, nil
+}
+
+// SaveTemplate creates or replaces a tenant's template after validating its
+// placeholders against the event schema
+func (s *NotificationTemplateService) SaveTemplate(ctx context.Context, params SaveNotificationTemplateParams) (*models.NotificationTemplate, error) {
+	schema, err := validateTemplateTarget(params.EventType, params.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Subject = strings.TrimSpace(params.Subject)
+	params.Body = strings.TrimSpace(params.Body)
+	if params.Subject == "" || params.Body == "" {
+		return nil, fmt.Errorf("%w: subject and body are required", ErrInvalidNotificationTemplate)
+	}
+	if len(params.Subject) > 255 {
+		return nil, fmt.Errorf("%w: subject must be at most 255 characters", ErrInvalidNotificationTemplate)
+	}
+	if err := validateTemplateText(schema, params.Subject); err != nil {
+		return nil, err
+	}
+	if err := validateTemplateText(schema, params.Body); err != nil {
+		return nil, err
+	}
+
+	template := &models.NotificationTemplate{
+		TenantID:  params.TenantID,
+		EventType: params.EventType,
+		Channel:   params.Channel,
+		Subject:   params.Subject,
+		Body:      params.Body,
+		UpdatedBy: params.UpdatedBy,
+	}
+	if err := s.templateRepo.Upsert(ctx, template); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.UpdatedBy, template.ID, models.AuditUpdate,
+		fmt.Sprintf("Notification template updated: %s/%s", params.EventType, params.Channel))
+
+	return template, nil
+}
+
+// ResetTemplate removes a tenant's override so the system default applies again
+func (s *NotificationTemplateService) ResetTemplate(ctx context.Context, tenantID uuid.UUID, eventType string, channel models.NotificationChannel, userID uuid.UUID) error {
+	if _, err := validateTemplateTarget(eventType, channel); err != nil {
+		return err
+	}
+
+	if err := s.templateRepo.Delete(ctx, tenantID, eventType, channel); err != nil {
+		return ErrNotificationTemplateNotFound
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, tenantID, models.AuditDelete,
+		fmt.Sprintf("Notification template reset to default: %s/%s", eventType, channel))
+
+	return nil
+}
+
+// PreviewTemplate renders a template with example (or supplied) variables
+// without saving it
+func (s *NotificationTemplateService) PreviewTemplate(ctx context.Context, params PreviewNotificationTemplateParams) (*RenderedNotification, error) {
+	schema, err := validateTemplateTarget(params.EventType, params.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Subject == "" || params.Body == "" {
+		current, err := s.GetTemplate(ctx, params.TenantID, params.EventType, params.Channel)
+		if err != nil {
+			return nil, err
+		}
+		if params.Subject == "" {
+			params.Subject = current.Subject
+		}
+		if params.Body == "" {
+			params.Body = current.Body
+		}
+	}
+
+	if err := validateTemplateText(schema, params.Subject); err != nil {
+		return nil, err
+	}
+	if err := validateTemplateText(schema, params.Body); err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string, len(schema.Variables)+len(params.Variables))
+	for name, example := range schema.Variables {
+		vars[name] = example
+	}
+	for name, value := range params.Variables {
+		if _, ok := schema.Variables[name]; ok {
+			vars[name] = value
+		}
+	}
+
+	return &RenderedNotification{
+		Subject: renderTemplateText(params.Subject, vars),
+		Body:    renderTemplateText(params.Body, vars),
+	}, nil
+}
+
+// Render produces the wording for a notification, preferring the tenant's
+// template and falling back to the system default. Returns nil for event types
+// without a schema.
+func (s *NotificationTemplateService) Render(ctx context.Context, tenantID uuid.UUID, eventType string, channel models.NotificationChannel, vars map[string]string) *RenderedNotification {
+	if template, err := s.templateRepo.Get(ctx, tenantID, eventType, channel); err == nil {
+		return &RenderedNotification{
+			Subject: renderTemplateText(template.Subject, vars),
+			Body:    renderTemplateText(template.Body, vars),
+		}
+	}
+	return renderSystemNotification(eventType, vars)
+}
+
+// Helper methods
+
+func (s *NotificationTemplateService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "notification_template",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+// renderSystemNotification renders an event's default wording
+func renderSystemNotification(eventType string, vars map[string]string) *RenderedNotification {
+	schema, ok := notificationEventSchemas[eventType]
+	if !ok {
+		return nil
+	}
+	return &RenderedNotification{
+		Subject: renderTemplateText(schema.DefaultSubject, vars),
+		Body:    renderTemplateText(schema.DefaultBody, vars),
+	}
+}
+
+// notificationEventSchema returns an event's schema including the common variables
+func notificationEventSchema(eventType string) (NotificationEventSchema, bool) {
+	schema, ok := notificationEventSchemas[eventType]
+	if !ok {
+		return NotificationEventSchema{}, false
+	}
+
+	variables := make(map[string]string, len(schema.Variables)+len(commonTemplateVariables))
+	for name, example := range commonTemplateVariables {
+		variables[name] = example
+	}
+	for name, example := range schema.Variables {
+		variables[name] = example
+	}
+
+	schema.EventType = eventType
+	schema.Variables = variables
+	return schema, true
+}
+
+func validateTemplateTarget(eventType string, channel models.NotificationChannel) (NotificationEventSchema, error) {
+	schema, ok := notificationEventSchema(eventType)
+	if !ok {
+		return NotificationEventSchema{}, ErrUnknownNotificationEvent
+	}
+	for _, supported := range TemplateChannels {
+		if channel == supported {
+			return schema, nil
+		}
+	}
+	return NotificationEventSchema{}, ErrUnsupportedTemplateChannel
+}
+
+// validateTemplateText rejects unknown variables and malformed placeholders
+func validateTemplateText(schema NotificationEventSchema, text string) error {
+	for _, match := range templatePlaceholder.FindAllStringSubmatch(text, -1) {
+		if _, ok := schema.Variables[match[1]]; !ok {
+			return fmt.Errorf("%w: unknown variable {{%s}} for %s", ErrInvalidNotificationTemplate, match[1], schema.EventType)
+		}
+	}
+
+	// Anything still containing braces after removing valid placeholders is malformed
+	stripped := templatePlaceholder.ReplaceAllString(text, "")
+	if strings.Contains(stripped, "{{") || strings.Contains(stripped, "}}") {
+		return fmt.Errorf("%w: unbalanced placeholder braces", ErrInvalidNotificationTemplate)
+	}
+
+	return nil
+}
+
+func renderTemplateText(text string, vars map[string]string) string {
+	return templatePlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := templatePlaceholder.FindStringSubmatch(placeholder)[1]
+		return vars[name]
+	})
+}
+
+func effectiveTemplate(schema NotificationEventSchema, channel models.NotificationChannel, override *models.NotificationTemplate) EffectiveNotificationTemplate {
+	if override != nil {
+		updatedAt := override.UpdatedAt
+		return EffectiveNotificationTemplate{
+			EventType: schema.EventType,
+			Channel:   channel,
+			Subject:   override.Subject,
+			Body:      override.Body,
+			UpdatedAt: &updatedAt,
+		}
+	}
+	return EffectiveNotificationTemplate{
+		EventType: schema.EventType,
+		Channel:   channel,
+		Subject:   schema.DefaultSubject,
+		Body:      schema.DefaultBody,
+		IsDefault: true,
+	}
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNumberingSequenceNotFound = errors.New("numbering sequence not found")
+	ErrNumberingSequenceExists   = errors.New("document type already has a numbering sequence")
+	ErrInvalidNumberingSequence  = errors.New("invalid numbering sequence")
+	ErrNumberingSequenceRewind   = errors.New("next number must follow the numbers already issued, unless the prefix changes")
+)
+
+const (
+	defaultNumberingPadding = 5
+	maxNumberingPadding     = 12
+	maxNumberingPrefix      = 50
+)
+
+// numberingToken matches the placeholders a prefix may hold
+var numberingToken = regexp.MustCompile(`\{[^{}]*\}`)
+
+// NumberingService manages the sequences document numbers are issued from.
+// Documents are numbered as they are uploaded, by DocumentService.
+type NumberingService struct {
+	sequenceRepo repositories.NumberingSequenceRepository
+	auditRepo    repositories.AuditLogRepository
+}
+
+// NewNumberingService creates a new numbering service
+func NewNumberingService(sequenceRepo repositories.NumberingSequenceRepository, auditRepo repositories.AuditLogRepository) *NumberingService {
+	return &NumberingService{
+		sequenceRepo: sequenceRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+// NumberingSequenceParams contains parameters for creating a numbering sequence
+type NumberingSequenceParams struct {
+	TenantID      uuid.UUID             `json:"tenant_id"`
+	CreatedBy     uuid.UUID             `json:"created_by"`
+	Name          string                `json:"name"`
+	DocumentType  models.DocumentType   `json:"document_type"`
+	Prefix        string                `json:"prefix"`
+	Padding       int                   `json:"padding"`        // Defaults to 5
+	ResetInterval models.NumberingReset `json:"reset_interval"` // Defaults to never
+	NextNumber    *int64                `json:"next_number"`    // Defaults to 1, e.g. to continue a legacy system's numbering
+}
+
+// UpdateNumberingSequenceParams contains sequence changes; nil fields are unchanged
+type UpdateNumberingSequenceParams struct {
+	Name          *string                `json:"name"`
+	Prefix        *string                `json:"prefix"`
+	Padding       *int                   `json:"padding"`
+	ResetInterval *models.NumberingReset `json:"reset_interval"`
+	IsActive      *bool                  `json:"is_active"`
+	NextNumber    *int64                 `json:"next_number"`
+}
+
+// CreateSequence starts numbering the tenant's uploads of a document type
+func (s *NumberingService) CreateSequence(ctx context.Context, params NumberingSequenceParams) (*models.NumberingSequence, error) {
+	sequence := &models.NumberingSequence{
+		TenantID:      params.TenantID,
+		DocumentType:  params.DocumentType,
+		Name:          strings.TrimSpace(params.Name),
+		Prefix:        params.Prefix,
+		Padding:       params.Padding,
+		ResetInterval: params.ResetInterval,
+		IsActive:      true,
+		CreatedBy:     params.CreatedBy,
+	}
+	if sequence.Padding == 0 {
+		sequence.Padding = defaultNumberingPadding
+	}
+	if sequence.ResetInterval == "" {
+		sequence.ResetInterval = models.NumberingResetNever
+	}
+	if !isKnownDocumentType(sequence.DocumentType) {
+		return nil, fmt.Errorf("%w: unknown document type %q", ErrInvalidNumberingSequence, sequence.DocumentType)
+	}
+	if err := validateNumberingSequence(sequence); err != nil {
+		return nil, err
+	}
+	if params.NextNumber != nil {
+		if *params.NextNumber < 1 {
+			return nil, fmt.Errorf("%w: next number must be at least 1", ErrInvalidNumberingSequence)
+		}
+		sequence.LastValue = *params.NextNumber - 1
+		sequence.CurrentPeriod = numberingPeriod(sequence.ResetInterval, time.Now())
+	}
+
+	if _, err := s.sequenceRepo.GetByDocumentType(ctx, params.TenantID, params.DocumentType); err == nil {
+		return nil, ErrNumberingSequenceExists
+	}
+	if err := s.sequenceRepo.Create(ctx, sequence); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.CreatedBy, sequence.ID, models.AuditCreate, "Numbering sequence created: "+sequence.Name)
+
+	return sequence, nil
+}
+
+// ListSequences lists the tenant's numbering sequences by document type
+func (s *NumberingService) ListSequences(ctx context.Context, tenantID uuid.UUID) ([]models.NumberingSequence, error) {
+	return s.sequenceRepo.ListByTenant(ctx, tenantID)
+}
+
+// GetSequence returns a numbering sequence belonging to the tenant
+func (s *NumberingService) GetSequence(ctx context.Context, sequenceID, tenantID uuid.UUID) (*models.NumberingSequence, error) {
+	sequence, err := s.sequenceRepo.GetByID(ctx, sequenceID)
+	if err != nil || sequence.TenantID != tenantID {
+		return nil, ErrNumberingSequenceNotFound
+	}
+	return sequence, nil
+}
+
+// UpdateSequence changes a sequence's format, pauses or resumes it, or moves
+// its counter. Sequences aren't deleted, since a new sequence for the type
+// would issue its numbers again; deactivated ones stop numbering uploads.
+func (s *NumberingService) UpdateSequence(ctx context.Context, sequenceID, tenantID, updatedBy uuid.UUID, params UpdateNumberingSequenceParams) (*models.NumberingSequence, error) {
+	sequence, err := s.GetSequence(ctx, sequenceID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	issued := sequence.LastValue
+	if sequence.CurrentPeriod != numberingPeriod(sequence.ResetInterval, now) {
+		issued = 0
+	}
+	prefixChanged := params.Prefix != nil && *params.Prefix != sequence.Prefix
+	resetChanged := params.ResetInterval != nil && *params.ResetInterval != sequence.ResetInterval
+
+	if params.Name != nil {
+		sequence.Name = strings.TrimSpace(*params.Name)
+	}
+	if params.Prefix != nil {
+		sequence.Prefix = *params.Prefix
+	}
+	if params.Padding != nil {
+		sequence.Padding = *params.Padding
+	}
+	if params.ResetInterval != nil {
+		sequence.ResetInterval = *params.ResetInterval
+	}
+	if params.IsActive != nil {
+		sequence.IsActive = *params.IsActive
+	}
+	if err := validateNumberingSequence(sequence); err != nil {
+		return nil, err
+	}
+
+	// The counter moves when asked to, and carries over into the new
+	// interval's period when the reset interval changes; starting over
+	// there could issue numbers again
+	moveTo := int64(-1)
+	if params.NextNumber != nil {
+		if *params.NextNumber < 1 {
+			return nil, fmt.Errorf("%w: next number must be at least 1", ErrInvalidNumberingSequence)
+		}
+		if *params.NextNumber <= issued && !prefixChanged {
+			return nil, ErrNumberingSequenceRewind
+		}
+		moveTo = *params.NextNumber - 1
+	} else if resetChanged {
+		moveTo = issued
+	}
+
+	sequence.UpdatedAt = now
+	if err := s.sequenceRepo.Update(ctx, sequence); err != nil {
+		return nil, err
+	}
+	if moveTo >= 0 {
+		period := numberingPeriod(sequence.ResetInterval, now)
+		if err := s.sequenceRepo.SetLastValue(ctx, sequence.ID, moveTo, period); err != nil {
+			return nil, err
+		}
+		sequence.LastValue = moveTo
+		sequence.CurrentPeriod = period
+	}
+
+	s.createAuditLog(ctx, tenantID, updatedBy, sequence.ID, models.AuditUpdate, "Numbering sequence updated: "+sequence.Name)
+
+	return sequence, nil
+}
+
+// NextNumber returns the number the sequence issues next, without issuing it
+func (s *NumberingService) NextNumber(sequence *models.NumberingSequence) string {
+	now := time.Now()
+	value := int64(1)
+	if sequence.CurrentPeriod == numberingPeriod(sequence.ResetInterval, now) {
+		value = sequence.LastValue + 1
+	}
+	return formatDocumentNumber(sequence, value, now)
+}
+
+// Helper methods
+
+func (s *NumberingService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "numbering_sequence",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+// Helper functions
+
+// allocateDocumentNumber issues the sequence's next document number
+func allocateDocumentNumber(ctx context.Context, sequenceRepo repositories.NumberingSequenceRepository, sequence *models.NumberingSequence, now time.Time) (string, error) {
+	value, err := sequenceRepo.Allocate(ctx, sequence.ID, numberingPeriod(sequence.ResetInterval, now))
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate document number: %w", err)
+	}
+	return formatDocumentNumber(sequence, value, now), nil
+}
+
+// numberingPeriod is the period a sequence counts in at now: the year or
+// month for sequences that reset, empty for those that don't
+func numberingPeriod(reset models.NumberingReset, now time.Time) string {
+	switch reset {
+	case models.NumberingResetYearly:
+		return now.UTC().Format("2006")
+	case models.NumberingResetMonthly:
+		return now.UTC().Format("2006-01")
+	}
+	return ""
+}
+
+// formatDocumentNumber fills in the prefix's date placeholders and appends
+// the zero-padded value
+func formatDocumentNumber(sequence *models.NumberingSequence, value int64, now time.Time) string {
+	now = now.UTC()
+	prefix := strings.NewReplacer(
+		"{YYYY}", now.Format("2006"),
+		"{YY}", now.Format("06"),
+		"{MM}", now.Format("01"),
+	).Replace(sequence.Prefix)
+	return fmt.Sprintf("%s%0*d", prefix, sequence.Padding, value)
+}
+
+// validateNumberingSequence checks a sequence's settings. Sequences that
+// reset must put the period in their prefix, or every period would issue
+// the same numbers.
+func validateNumberingSequence(sequence *models.NumberingSequence) error {
+	if sequence.Name == "" || len(sequence.Name) > 100 {
+		return fmt.Errorf("%w: name is required, up to 100 characters", ErrInvalidNumberingSequence)
+	}
+	if len(sequence.Prefix) > maxNumberingPrefix {
+		return fmt.Errorf("%w: prefix can be up to %d characters", ErrInvalidNumberingSequence, maxNumberingPrefix)
+	}
+	if sequence.Padding < 1 || sequence.Padding > maxNumberingPadding {
+		return fmt.Errorf("%w: padding must be 1 to %d digits", ErrInvalidNumberingSequence, maxNumberingPadding)
+	}
+
+	tokens := map[string]bool{}
+	for _, token := range numberingToken.FindAllString(sequence.Prefix, -1) {
+		if token != "{YYYY}" && token != "{YY}" && token != "{MM}" {
+			return fmt.Errorf("%w: unknown placeholder %s; use {YYYY}, {YY} or {MM}", ErrInvalidNumberingSequence, token)
+		}
+		tokens[token] = true
+	}
+	if strings.ContainsAny(numberingToken.ReplaceAllString(sequence.Prefix, ""), "{}") {
+		return fmt.Errorf("%w: unbalanced braces in prefix", ErrInvalidNumberingSequence)
+	}
+
+	hasYear := tokens["{YYYY}"] || tokens["{YY}"]
+	switch sequence.ResetInterval {
+	case models.NumberingResetNever:
+	case models.NumberingResetYearly:
+		if !hasYear {
+			return fmt.Errorf("%w: yearly sequences need {YYYY} or {YY} in the prefix", ErrInvalidNumberingSequence)
+		}
+	case models.NumberingResetMonthly:
+		if !hasYear || !tokens["{MM}"] {
+			return fmt.Errorf("%w: monthly sequences need the year and {MM} in the prefix", ErrInvalidNumberingSequence)
+		}
+	default:
+		return fmt.Errorf("%w: reset interval must be never, yearly or monthly", ErrInvalidNumberingSequence)
+	}
+	return nil
+}
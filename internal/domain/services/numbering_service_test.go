@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDocumentNumber(t *testing.T) {
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC)
+
+	sequence := &models.NumberingSequence{Prefix: "INV-{YYYY}-", Padding: 5}
+	assert.Equal(t, "INV-2024-00042", formatDocumentNumber(sequence, 42, now))
+
+	sequence = &models.NumberingSequence{Prefix: "PO{YY}{MM}/", Padding: 3}
+	assert.Equal(t, "PO2403/007", formatDocumentNumber(sequence, 7, now))
+
+	// Values outgrowing the padding aren't truncated
+	assert.Equal(t, "PO2403/1234", formatDocumentNumber(sequence, 1234, now))
+}
+
+func TestNumberingPeriod(t *testing.T) {
+	now := time.Date(2024, 12, 31, 23, 30, 0, 0, time.UTC)
+	assert.Empty(t, numberingPeriod(models.NumberingResetNever, now))
+	assert.Equal(t, "2024", numberingPeriod(models.NumberingResetYearly, now))
+	assert.Equal(t, "2024-12", numberingPeriod(models.NumberingResetMonthly, now))
+}
+
+func TestValidateNumberingSequence(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		reset  models.NumberingReset
+		valid  bool
+	}{
+		{"plain", "INV-", models.NumberingResetNever, true},
+		{"yearly", "INV-{YYYY}-", models.NumberingResetYearly, true},
+		{"monthly", "INV-{YY}{MM}-", models.NumberingResetMonthly, true},
+		{"yearly without year", "INV-", models.NumberingResetYearly, false},
+		{"monthly without month", "INV-{YYYY}-", models.NumberingResetMonthly, false},
+		{"unknown placeholder", "INV-{DD}-", models.NumberingResetNever, false},
+		{"unbalanced brace", "INV-{YYYY-", models.NumberingResetNever, false},
+		{"unknown interval", "INV-", "weekly", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNumberingSequence(&models.NumberingSequence{
+				Name: "Invoices", Prefix: tt.prefix, Padding: 5, ResetInterval: tt.reset,
+			})
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidNumberingSequence)
+			}
+		})
+	}
+}
+
+func TestNumberingNextNumber(t *testing.T) {
+	service := NewNumberingService(nil, nil)
+	year := time.Now().UTC().Format("2006")
+
+	// Continues in the period it was last used in
+	sequence := &models.NumberingSequence{
+		Prefix: "INV-{YYYY}-", Padding: 5, ResetInterval: models.NumberingResetYearly,
+		LastValue: 41, CurrentPeriod: year,
+	}
+	assert.Equal(t, "INV-"+year+"-00042", service.NextNumber(sequence))
+
+	// and starts over in a new one
+	sequence.CurrentPeriod = "1999"
+	assert.Equal(t, "INV-"+year+"-00001", service.NextNumber(sequence))
+}
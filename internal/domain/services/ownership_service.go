@@ -0,0 +1,456 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// Ownership transfer errors
+var (
+	ErrInvalidOwnershipTransfer  = errors.New("ownership transfer requires a new owner and exactly one of document IDs, folder IDs, search filters or a previous owner")
+	ErrInvalidNewOwner           = errors.New("new owner must be an active user of the tenant")
+	ErrOwnershipTransferEmpty    = errors.New("nothing to transfer; the new owner already owns everything selected")
+	ErrOwnershipTransferTooLarge = errors.New("ownership transfer covers too many items")
+	ErrOwnershipTransferDenied   = errors.New("only owners and admins can transfer ownership")
+)
+
+// ownershipPageSize is how many documents are read per page when resolving a transfer
+const ownershipPageSize = 500
+
+// OwnershipNotifier tells both parties of a transfer what changed hands
+type OwnershipNotifier interface {
+	SendOwnershipTransferred(ctx context.Context, userID uuid.UUID, items, fromName, toName string) error
+}
+
+// OwnershipService transfers documents and folders to another user, e.g.
+// when a team is reorganized or someone leaves
+type OwnershipService struct {
+	ownershipRepo repositories.OwnershipRepository
+	docRepo       repositories.DocumentRepository
+	folderRepo    repositories.FolderRepository
+	userRepo      repositories.UserRepository
+	auditRepo     repositories.AuditLogRepository
+
+	notifier OwnershipNotifier
+	config   OwnershipServiceConfig
+}
+
+// OwnershipServiceConfig holds configuration for ownership transfers
+type OwnershipServiceConfig struct {
+	MaxItems int // Most documents and folders one transfer may cover; defaults to 10000
+}
+
+// NewOwnershipService creates a new ownership service. notifier may be nil.
+func NewOwnershipService(
+	ownershipRepo repositories.OwnershipRepository,
+	docRepo repositories.DocumentRepository,
+	folderRepo repositories.FolderRepository,
+	userRepo repositories.UserRepository,
+	auditRepo repositories.AuditLogRepository,
+	notifier OwnershipNotifier,
+	config OwnershipServiceConfig,
+) *OwnershipService {
+	if config.MaxItems <= 0 {
+		config.MaxItems = 10000
+	}
+
+	return &OwnershipService{
+		ownershipRepo: ownershipRepo,
+		docRepo:       docRepo,
+		folderRepo:    folderRepo,
+		userRepo:      userRepo,
+		auditRepo:     auditRepo,
+		notifier:      notifier,
+		config:        config,
+	}
+}
+
+// TransferOwnershipParams selects what to transfer: explicit documents,
+// folders (optionally with everything inside them), the results of a search,
+// or everything a user owns
+type TransferOwnershipParams struct {
+	TenantID      uuid.UUID
+	TransferredBy uuid.UUID
+	ToUserID      uuid.UUID
+	IPAddress     string
+
+	DocumentIDs     []uuid.UUID
+	FolderIDs       []uuid.UUID
+	IncludeContents bool
+	Filters         *repositories.DocumentFilters
+	FromUserID      *uuid.UUID
+}
+
+// OwnershipTransferResult lists what changed hands. Items the new owner
+// already owned are left out.
+type OwnershipTransferResult struct {
+	TransferID  uuid.UUID   `json:"transfer_id"`
+	ToUserID    uuid.UUID   `json:"to_user_id"`
+	DocumentIDs []uuid.UUID `json:"document_ids"`
+	FolderIDs   []uuid.UUID `json:"folder_ids"`
+}
+
+// ownedItem is a document or folder and the owner it is transferred from
+type ownedItem struct {
+	id           uuid.UUID
+	resourceType string
+	owner        uuid.UUID
+}
+
+// TransferOwnership resolves the selection, moves it to the new owner, and
+// records and announces the transfer. Admins can transfer anything; other
+// users only what they own, and only by ID.
+func (s *OwnershipService) TransferOwnership(ctx context.Context, params TransferOwnershipParams) (*OwnershipTransferResult, error) {
+	selectors := 0
+	if len(params.DocumentIDs) > 0 {
+		selectors++
+	}
+	if len(params.FolderIDs) > 0 {
+		selectors++
+	}
+	if params.Filters != nil {
+		selectors++
+	}
+	if params.FromUserID != nil {
+		selectors++
+	}
+	if selectors != 1 || params.ToUserID == uuid.Nil {
+		return nil, ErrInvalidOwnershipTransfer
+	}
+
+	actor, err := s.userRepo.GetByID(ctx, params.TransferredBy)
+	if err != nil || actor.TenantID != params.TenantID {
+		return nil, ErrOwnershipTransferDenied
+	}
+	isAdmin := actor.Role == models.UserRoleAdmin
+	if !isAdmin && (params.Filters != nil || params.FromUserID != nil) {
+		return nil, ErrOwnershipTransferDenied
+	}
+
+	newOwner, err := s.userRepo.GetByID(ctx, params.ToUserID)
+	if err != nil || newOwner.TenantID != params.TenantID || !newOwner.IsActive {
+		return nil, ErrInvalidNewOwner
+	}
+
+	var items []ownedItem
+	switch {
+	case len(params.DocumentIDs) > 0:
+		items, err = s.resolveDocuments(ctx, params.TenantID, params.DocumentIDs)
+	case len(params.FolderIDs) > 0:
+		items, err = s.resolveFolders(ctx, params.TenantID, params.FolderIDs, params.IncludeContents)
+	case params.Filters != nil:
+		filters := *params.Filters
+		// Admins transfer everything that matches, not only what they can see
+		filters.ExcludeFolderIDs = nil
+		filters.ViewerID = nil
+		items, err = s.collectDocuments(ctx, params.TenantID, filters, s.config.MaxItems)
+	default:
+		items, err = s.resolveOwner(ctx, params.TenantID, *params.FromUserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OwnershipTransferResult{
+		TransferID:  uuid.New(),
+		ToUserID:    params.ToUserID,
+		DocumentIDs: []uuid.UUID{},
+		FolderIDs:   []uuid.UUID{},
+	}
+	transferred := make([]ownedItem, 0, len(items))
+	for _, item := range items {
+		if !isAdmin && item.owner != params.TransferredBy {
+			return nil, ErrOwnershipTransferDenied
+		}
+		if item.owner == params.ToUserID {
+			continue
+		}
+		transferred = append(transferred, item)
+		if item.resourceType == "folder" {
+			result.FolderIDs = append(result.FolderIDs, item.id)
+		} else {
+			result.DocumentIDs = append(result.DocumentIDs, item.id)
+		}
+	}
+	if len(transferred) == 0 {
+		return nil, ErrOwnershipTransferEmpty
+	}
+
+	if err := s.ownershipRepo.Transfer(ctx, params.TenantID, result.DocumentIDs, result.FolderIDs, params.ToUserID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLogs(params, result, transferred)
+	s.notifyParties(params, newOwner, transferred)
+
+	return result, nil
+}
+
+// TransferDocument transfers a single document
+func (s *OwnershipService) TransferDocument(ctx context.Context, tenantID, documentID, transferredBy, toUserID uuid.UUID, ipAddress string) (*OwnershipTransferResult, error) {
+	return s.TransferOwnership(ctx, TransferOwnershipParams{
+		TenantID:      tenantID,
+		TransferredBy: transferredBy,
+		ToUserID:      toUserID,
+		IPAddress:     ipAddress,
+		DocumentIDs:   []uuid.UUID{documentID},
+	})
+}
+
+// TransferFolder transfers a single folder, and with includeContents its
+// subfolders and every document in them
+func (s *OwnershipService) TransferFolder(ctx context.Context, tenantID, folderID, transferredBy, toUserID uuid.UUID, includeContents bool, ipAddress string) (*OwnershipTransferResult, error) {
+	return s.TransferOwnership(ctx, TransferOwnershipParams{
+		TenantID:        tenantID,
+		TransferredBy:   transferredBy,
+		ToUserID:        toUserID,
+		IPAddress:       ipAddress,
+		FolderIDs:       []uuid.UUID{folderID},
+		IncludeContents: includeContents,
+	})
+}
+
+// Helper methods
+
+func (s *OwnershipService) resolveDocuments(ctx context.Context, tenantID uuid.UUID, documentIDs []uuid.UUID) ([]ownedItem, error) {
+	if len(documentIDs) > s.config.MaxItems {
+		return nil, ErrOwnershipTransferTooLarge
+	}
+
+	seen := make(map[uuid.UUID]bool, len(documentIDs))
+	items := make([]ownedItem, 0, len(documentIDs))
+	for _, documentID := range documentIDs {
+		if seen[documentID] {
+			continue
+		}
+		seen[documentID] = true
+
+		document, err := s.docRepo.GetForTenant(ctx, tenantID, documentID)
+		if err != nil {
+			return nil, fmt.Errorf("document %s: %w", documentID, ErrDocumentNotFound)
+		}
+		items = append(items, ownedItem{id: document.ID, resourceType: "document", owner: document.Owner()})
+	}
+	return items, nil
+}
+
+func (s *OwnershipService) resolveFolders(ctx context.Context, tenantID uuid.UUID, folderIDs []uuid.UUID, includeContents bool) ([]ownedItem, error) {
+	folders, err := s.folderRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	byID := make(map[uuid.UUID]models.Folder, len(folders))
+	for _, folder := range folders {
+		byID[folder.ID] = folder
+	}
+
+	selected := make([]uuid.UUID, 0, len(folderIDs))
+	seen := make(map[uuid.UUID]bool)
+	for _, folderID := range folderIDs {
+		if _, ok := byID[folderID]; !ok {
+			return nil, fmt.Errorf("folder %s: %w", folderID, ErrFolderNotFound)
+		}
+		ids := []uuid.UUID{folderID}
+		if includeContents {
+			ids = descendantFolderIDs(folderID, folders)
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				selected = append(selected, id)
+			}
+		}
+	}
+	if len(selected) > s.config.MaxItems {
+		return nil, ErrOwnershipTransferTooLarge
+	}
+
+	items := make([]ownedItem, 0, len(selected))
+	for _, id := range selected {
+		folder := byID[id]
+		items = append(items, ownedItem{id: id, resourceType: "folder", owner: folder.Owner()})
+	}
+	if !includeContents {
+		return items, nil
+	}
+
+	for _, id := range selected {
+		id := id
+		documents, err := s.collectDocuments(ctx, tenantID, repositories.DocumentFilters{FolderID: &id}, s.config.MaxItems-len(items))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, documents...)
+	}
+	return items, nil
+}
+
+// resolveOwner selects every document and folder the user owns
+func (s *OwnershipService) resolveOwner(ctx context.Context, tenantID, ownerID uuid.UUID) ([]ownedItem, error) {
+	folders, err := s.folderRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+
+	var items []ownedItem
+	for i := range folders {
+		if folders[i].Owner() == ownerID {
+			items = append(items, ownedItem{id: folders[i].ID, resourceType: "folder", owner: ownerID})
+		}
+	}
+	if len(items) > s.config.MaxItems {
+		return nil, ErrOwnershipTransferTooLarge
+	}
+
+	documents, err := s.collectDocuments(ctx, tenantID, repositories.DocumentFilters{OwnedBy: []uuid.UUID{ownerID}}, s.config.MaxItems-len(items))
+	if err != nil {
+		return nil, err
+	}
+	return append(items, documents...), nil
+}
+
+// collectDocuments pages through every document matching filters, refusing
+// sets larger than limit
+func (s *OwnershipService) collectDocuments(ctx context.Context, tenantID uuid.UUID, filters repositories.DocumentFilters, limit int) ([]ownedItem, error) {
+	filters.PageSize = ownershipPageSize
+	filters.SortBy = "id"
+	filters.SortDesc = false
+
+	var items []ownedItem
+	for page := 1; ; page++ {
+		filters.Page = page
+		batch, total, err := s.docRepo.List(ctx, tenantID, filters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		if total > int64(limit) {
+			return nil, ErrOwnershipTransferTooLarge
+		}
+		for i := range batch {
+			items = append(items, ownedItem{id: batch[i].ID, resourceType: "document", owner: batch[i].Owner()})
+		}
+		if len(batch) < ownershipPageSize {
+			return items, nil
+		}
+	}
+}
+
+// createAuditLogs records the transfer on every document and folder it moved
+func (s *OwnershipService) createAuditLogs(params TransferOwnershipParams, result *OwnershipTransferResult, items []ownedItem) {
+	logs := make([]*models.AuditLog, 0, len(items))
+	for _, item := range items {
+		logs = append(logs, &models.AuditLog{
+			TenantID:     params.TenantID,
+			UserID:       params.TransferredBy,
+			ResourceID:   item.id,
+			Action:       models.AuditUpdate,
+			ResourceType: item.resourceType,
+			IPAddress:    params.IPAddress,
+			Details: models.JSONB{
+				"message":        "Ownership transferred",
+				"transfer_id":    result.TransferID.String(),
+				"previous_owner": item.owner.String(),
+				"new_owner":      params.ToUserID.String(),
+			},
+		})
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		for _, log := range logs {
+			s.auditRepo.Create(context.Background(), log)
+		}
+	}()
+}
+
+// notifyParties tells each previous owner what they gave up and the new
+// owner what they received, in the background. Nobody is notified about
+// their own action.
+func (s *OwnershipService) notifyParties(params TransferOwnershipParams, newOwner *models.User, items []ownedItem) {
+	if s.notifier == nil {
+		return
+	}
+
+	type counts struct{ documents, folders int }
+	byOwner := make(map[uuid.UUID]*counts)
+	var owners []uuid.UUID
+	total := &counts{}
+	for _, item := range items {
+		if byOwner[item.owner] == nil {
+			byOwner[item.owner] = &counts{}
+			owners = append(owners, item.owner)
+		}
+		if item.resourceType == "folder" {
+			byOwner[item.owner].folders++
+			total.folders++
+		} else {
+			byOwner[item.owner].documents++
+			total.documents++
+		}
+	}
+
+	go func() {
+		ctx := context.Background()
+		toName := userDisplayName(newOwner)
+
+		fromNames := make([]string, 0, len(owners))
+		for _, ownerID := range owners {
+			fromName := "a former owner"
+			if owner, err := s.userRepo.GetByID(ctx, ownerID); err == nil {
+				fromName = userDisplayName(owner)
+			}
+			fromNames = append(fromNames, fromName)
+
+			if ownerID == params.TransferredBy {
+				continue
+			}
+			given := byOwner[ownerID]
+			if err := s.notifier.SendOwnershipTransferred(ctx, ownerID, transferSummary(given.documents, given.folders), fromName, toName); err != nil {
+				// Log but don't fail
+			}
+		}
+
+		if newOwner.ID != params.TransferredBy {
+			fromName := strings.Join(fromNames, ", ")
+			if len(fromNames) > 3 {
+				fromName = fmt.Sprintf("%d previous owners", len(fromNames))
+			}
+			if err := s.notifier.SendOwnershipTransferred(ctx, newOwner.ID, transferSummary(total.documents, total.folders), fromName, toName); err != nil {
+				// Log but don't fail
+			}
+		}
+	}()
+}
+
+// transferSummary describes what a transfer moved, e.g. "3 documents and 1 folder"
+func transferSummary(documents, folders int) string {
+	plural := func(n int, noun string) string {
+		if n == 1 {
+			return "1 " + noun
+		}
+		return fmt.Sprintf("%d %ss", n, noun)
+	}
+
+	switch {
+	case folders == 0:
+		return plural(documents, "document")
+	case documents == 0:
+		return plural(folders, "folder")
+	default:
+		return plural(documents, "document") + " and " + plural(folders, "folder")
+	}
+}
+
+func userDisplayName(user *models.User) string {
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		return name
+	}
+	return user.Email
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferOwnershipRequiresOneSelector(t *testing.T) {
+	service := NewOwnershipService(nil, nil, nil, nil, nil, nil, OwnershipServiceConfig{})
+	fromUserID := uuid.New()
+
+	tests := []TransferOwnershipParams{
+		{ToUserID: uuid.New()},
+		{DocumentIDs: []uuid.UUID{uuid.New()}},
+		{ToUserID: uuid.New(), DocumentIDs: []uuid.UUID{uuid.New()}, FolderIDs: []uuid.UUID{uuid.New()}},
+		{ToUserID: uuid.New(), Filters: &repositories.DocumentFilters{}, FromUserID: &fromUserID},
+	}
+
+	for _, params := range tests {
+		_, err := service.TransferOwnership(context.Background(), params)
+		assert.ErrorIs(t, err, ErrInvalidOwnershipTransfer)
+	}
+}
+
+func TestTransferSummary(t *testing.T) {
+	assert.Equal(t, "1 document", transferSummary(1, 0))
+	assert.Equal(t, "2 folders", transferSummary(0, 2))
+	assert.Equal(t, "3 documents and 1 folder", transferSummary(3, 1))
+}
@@ -0,0 +1,497 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownPromptKind      = errors.New("unknown prompt kind")
+	ErrInvalidPromptTemplate  = errors.New("invalid prompt template")
+	ErrPromptTemplateNotFound = errors.New("prompt template not found")
+	ErrPromptTestNoText       = errors.New("document has no extracted text to test the prompt on")
+)
+
+const (
+	maxPromptTemplateLength = 4000
+	maxPromptNoteLength     = 255
+)
+
+// PromptKindSchema describes an AI call's default instructions and the
+// variables its templates may reference (name -> what it holds). The reply
+// format is appended to every template so replies still parse.
+type PromptKindSchema struct {
+	Kind        models.PromptKind `json:"kind"`
+	Description string            `json:"description"`
+	JobType     string            `json:"job_type"`
+	Variables   map[string]string `json:"variables"`
+	DefaultBody string            `json:"default_body"`
+	ReplyFormat string            `json:"reply_format"`
+
+	output string // What language_hint asks to be written in the document's language
+}
+
+var promptKindSchemas = map[models.PromptKind]PromptKindSchema{
+	models.PromptSummarization: {
+		Description: "Summaries of documents",
+		JobType:     "summarization",
+		Variables: map[string]string{
+			"language":      "The document's language, e.g. German, when detected",
+			"language_hint": "A sentence asking for the summary in the document's language, when detected",
+		},
+		DefaultBody: "Summarize this document in two to four sentences: what it is, who it involves and its key figures and dates.{{language_hint}}",
+		ReplyFormat: "Reply with the summary only.",
+		output:      "summary",
+	},
+	models.PromptClassification: {
+		Description: "The document type documents are classified as",
+		JobType:     "categorization",
+		Variables: map[string]string{
+			"language":       "The document's language, e.g. German, when detected",
+			"document_types": "The document types to choose from, comma separated; the tenant's own follow the built-in ones with their names and descriptions",
+		},
+		DefaultBody: "Classify this document.",
+		ReplyFormat: `Reply with a JSON object with "document_type", one of {{document_types}}, and "confidence", between 0 and 1.`,
+	},
+	models.PromptExtraction: {
+		Description: "The people, organizations, locations, dates, amounts and emails extracted from documents",
+		JobType:     "entity_extraction",
+		Variables: map[string]string{
+			"language": "The document's language, e.g. German, when detected",
+		},
+		DefaultBody: "List the people, organizations, locations, dates, amounts and emails this document mentions.",
+		ReplyFormat: `Reply with a JSON object with the keys "people", "organizations", "locations", "dates", "amounts" and "emails", each a list of strings. Leave out keys with nothing found.`,
+	},
+	models.PromptTagging: {
+		Description: "Tags suggested for filing documents",
+		JobType:     "tagging",
+		Variables: map[string]string{
+			"language":      "The document's language, e.g. German, when detected",
+			"language_hint": "A sentence asking for the tags in the document's language, when detected",
+			"max_tags":      "How many tags are kept at most",
+		},
+		DefaultBody: "Suggest up to {{max_tags}} short lowercase tags for filing this document.{{language_hint}}",
+		ReplyFormat: `Reply with a JSON object with "tags", a list of strings.`,
+		output:      "tags",
+	},
+}
+
+type aiPromptContextKey struct{}
+
+// WithAIPromptTemplate returns a context carrying a tenant's template for
+// the AI calls of its kind made with it
+func WithAIPromptTemplate(ctx context.Context, template *models.PromptTemplate) context.Context {
+	return context.WithValue(ctx, aiPromptContextKey{}, template)
+}
+
+// AIPromptTemplateFromContext returns the prompt template AI clients use
+// instead of a call's default instructions, if any
+func AIPromptTemplateFromContext(ctx context.Context) *models.PromptTemplate {
+	template, _ := ctx.Value(aiPromptContextKey{}).(*models.PromptTemplate)
+	return template
+}
+
+// PromptTemplateService manages tenants' wording of the prompts AI jobs
+// send, falling back to the defaults above, and tries templates out on a
+// document before they are saved
+type PromptTemplateService struct {
+	promptRepo      repositories.PromptTemplateRepository
+	auditRepo       repositories.AuditLogRepository
+	documentService *DocumentService
+	chatService     *ChatService // Test calls go to providers, and are billed, the way chat's are
+}
+
+// NewPromptTemplateService creates a new prompt template service
+func NewPromptTemplateService(
+	promptRepo repositories.PromptTemplateRepository,
+	auditRepo repositories.AuditLogRepository,
+	documentService *DocumentService,
+	chatService *ChatService,
+) *PromptTemplateService {
+	return &PromptTemplateService{
+		promptRepo:      promptRepo,
+		auditRepo:       auditRepo,
+		documentService: documentService,
+		chatService:     chatService,
+	}
+}
+
+// EffectivePromptTemplate is the wording used for a kind of AI call.
+// Version is nil for the default.
+type EffectivePromptTemplate struct {
+	Kind      models.PromptKind `json:"kind"`
+	Body      string            `json:"body"`
+	IsDefault bool              `json:"is_default"`
+	Version   *int              `json:"version,omitempty"`
+	CreatedAt *time.Time        `json:"created_at,omitempty"`
+}
+
+// SavePromptTemplateParams contains parameters for saving a new version of
+// a tenant's template
+type SavePromptTemplateParams struct {
+	TenantID  uuid.UUID         `json:"tenant_id"`
+	Kind      models.PromptKind `json:"kind"`
+	Body      string            `json:"body"`
+	Note      string            `json:"note"`
+	CreatedBy uuid.UUID         `json:"created_by"`
+}
+
+// PromptTestParams runs a template on a document without saving it. Body
+// defaults to the kind's current template. The tenant's corrections aren't
+// given as examples, so the template's own effect shows.
+type PromptTestParams struct {
+	TenantID   uuid.UUID         `json:"tenant_id"`
+	UserID     uuid.UUID         `json:"user_id"`
+	Kind       models.PromptKind `json:"kind"`
+	Body       string            `json:"body"`
+	DocumentID uuid.UUID         `json:"document_id"`
+}
+
+// PromptTestResult is what a template produced for a document
+type PromptTestResult struct {
+	Kind         models.PromptKind `json:"kind"`
+	Instructions string            `json:"instructions"` // As sent, ahead of the document's text
+	Output       interface{}       `json:"output"`
+	Truncated    bool              `json:"truncated"` // Only the start of the document was sent
+	Provider     string            `json:"provider"`
+	Model        string            `json:"model,omitempty"`
+	InputTokens  int               `json:"input_tokens"`
+	OutputTokens int               `json:"output_tokens"`
+}
+
+// ListSchemas returns every kind of prompt tenants can reword, ordered by kind
+func (s *PromptTemplateService) ListSchemas() []PromptKindSchema {
+	schemas := make([]PromptKindSchema, 0, len(promptKindSchemas))
+	for kind := range promptKindSchemas {
+		schema, _ := promptKindSchema(kind)
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Kind < schemas[j].Kind })
+	return schemas
+}
+
+// ListTemplates returns the effective template for every kind
+func (s *PromptTemplateService) ListTemplates(ctx context.Context, tenantID uuid.UUID) ([]EffectivePromptTemplate, error) {
+	active, err := s.promptRepo.ListActive(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	byKind := make(map[models.PromptKind]*models.PromptTemplate, len(active))
+	for i := range active {
+		byKind[active[i].Kind] = &active[i]
+	}
+
+	templates := make([]EffectivePromptTemplate, 0, len(promptKindSchemas))
+	for _, schema := range s.ListSchemas() {
+		templates = append(templates, effectivePromptTemplate(schema, byKind[schema.Kind]))
+	}
+	return templates, nil
+}
+
+// GetTemplate returns the effective template for a kind
+func (s *PromptTemplateService) GetTemplate(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind) (*EffectivePromptTemplate, error) {
+	schema, ok := promptKindSchema(kind)
+	if !ok {
+		return nil, ErrUnknownPromptKind
+	}
+
+	active, err := s.promptRepo.GetActive(ctx, tenantID, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	template := effectivePromptTemplate(schema, active)
+	return &template, nil
+}
+
+// ListVersions returns every version of a tenant's template for a kind,
+// newest first
+func (s *PromptTemplateService) ListVersions(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind) ([]models.PromptTemplate, error) {
+	if _, ok := promptKindSchema(kind); !ok {
+		return nil, ErrUnknownPromptKind
+	}
+	return s.promptRepo.ListVersions(ctx, tenantID, kind)
+}
+
+// SaveTemplate validates a template's placeholders and saves it as the
+// kind's next version, which jobs use from then on
+func (s *PromptTemplateService) SaveTemplate(ctx context.Context, params SavePromptTemplateParams) (*models.PromptTemplate, error) {
+	schema, ok := promptKindSchema(params.Kind)
+	if !ok {
+		return nil, ErrUnknownPromptKind
+	}
+
+	params.Body = strings.TrimSpace(params.Body)
+	params.Note = strings.TrimSpace(params.Note)
+	if err := validatePromptTemplate(schema, params.Body); err != nil {
+		return nil, err
+	}
+	if len(params.Note) > maxPromptNoteLength {
+		return nil, fmt.Errorf("%w: note must be at most %d characters", ErrInvalidPromptTemplate, maxPromptNoteLength)
+	}
+
+	template := &models.PromptTemplate{
+		TenantID:  params.TenantID,
+		Kind:      params.Kind,
+		Body:      params.Body,
+		Note:      params.Note,
+		CreatedBy: params.CreatedBy,
+	}
+	if err := s.promptRepo.Create(ctx, template); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.CreatedBy, template.ID, models.AuditUpdate,
+		fmt.Sprintf("Prompt template saved: %s version %d", params.Kind, template.Version))
+
+	return template, nil
+}
+
+// ActivateVersion makes an earlier version the one jobs use, e.g. to roll
+// back a change
+func (s *PromptTemplateService) ActivateVersion(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind, version int, userID uuid.UUID) (*models.PromptTemplate, error) {
+	if _, ok := promptKindSchema(kind); !ok {
+		return nil, ErrUnknownPromptKind
+	}
+
+	template, err := s.promptRepo.Activate(ctx, tenantID, kind, version)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrPromptTemplateNotFound
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, template.ID, models.AuditUpdate,
+		fmt.Sprintf("Prompt template activated: %s version %d", kind, version))
+
+	return template, nil
+}
+
+// ResetTemplate deactivates the tenant's template so the default applies
+// again; its versions are kept
+func (s *PromptTemplateService) ResetTemplate(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind, userID uuid.UUID) error {
+	if _, ok := promptKindSchema(kind); !ok {
+		return ErrUnknownPromptKind
+	}
+
+	reset, err := s.promptRepo.Deactivate(ctx, tenantID, kind)
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return ErrPromptTemplateNotFound
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, tenantID, models.AuditUpdate,
+		fmt.Sprintf("Prompt template reset to default: %s", kind))
+
+	return nil
+}
+
+// TestTemplate runs a template on a document the user can read and returns
+// what the provider made of it, without saving anything. The call goes to
+// the provider the kind's job would run on and counts against the tenant's
+// AI quota and budget.
+func (s *PromptTemplateService) TestTemplate(ctx context.Context, params PromptTestParams) (*PromptTestResult, error) {
+	schema, ok := promptKindSchema(params.Kind)
+	if !ok {
+		return nil, ErrUnknownPromptKind
+	}
+
+	params.Body = strings.TrimSpace(params.Body)
+	if params.Body == "" {
+		current, err := s.GetTemplate(ctx, params.TenantID, params.Kind)
+		if err != nil {
+			return nil, err
+		}
+		params.Body = current.Body
+	}
+	if err := validatePromptTemplate(schema, params.Body); err != nil {
+		return nil, err
+	}
+
+	document, err := s.documentService.getTenantDocument(ctx, params.DocumentID, params.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.documentService.CheckDocumentAccess(ctx, document, params.UserID, models.DocPermRead); err != nil {
+		return nil, err
+	}
+	text := document.ExtractedText
+	if text == "" {
+		text = document.OCRText
+	}
+	if text == "" {
+		return nil, ErrPromptTestNoText
+	}
+
+	// Long documents are cut to what one job call would take
+	route := &aiJobRoute{budget: defaultAIMaxInputTokens, maxChunks: 1}
+	text = route.plan(text, AIStrategyTruncated)[0]
+
+	provider, err := s.chatService.provider(ctx, params.TenantID, schema.JobType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.chatService.checkLimits(ctx, params.TenantID, provider); err != nil {
+		return nil, err
+	}
+
+	if params.Kind == models.PromptClassification {
+		docTypes, err := loadDocumentTypes(ctx, s.documentService.docTypeRepo, params.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		ctx = WithAIDocumentTypes(ctx, docTypes.active())
+	}
+	ctx = WithAILanguage(ctx, document.Language)
+	ctx = WithAIPromptTemplate(ctx, &models.PromptTemplate{Kind: params.Kind, Body: params.Body})
+	call := &aiCallUsage{}
+	callCtx := context.WithValue(ctx, aiUsageContextKey{}, call)
+
+	client := llmClient{provider: provider, maxTokens: s.chatService.config.MaxTokens}
+	var output interface{}
+	switch params.Kind {
+	case models.PromptSummarization:
+		output, err = client.GenerateSummary(callCtx, text)
+	case models.PromptClassification:
+		var docType models.DocumentType
+		var confidence float64
+		docType, confidence, err = client.ClassifyDocument(callCtx, text)
+		output = map[string]interface{}{"document_type": docType, "confidence": confidence}
+	case models.PromptExtraction:
+		output, err = client.ExtractEntities(callCtx, text)
+	case models.PromptTagging:
+		output, err = client.GenerateTags(callCtx, text)
+	}
+	s.chatService.recordKeyUse(ctx, provider, err)
+
+	result := &PromptTestResult{
+		Kind:         params.Kind,
+		Instructions: aiPromptInstructions(ctx, params.Kind),
+		Output:       output,
+		Truncated:    route.Strategy == AIStrategyTruncated,
+		Provider:     provider.Name(),
+	}
+	for _, request := range call.requests {
+		if request.Model != "" {
+			result.Model = request.Model
+		}
+		result.InputTokens += request.InputTokens
+		result.OutputTokens += request.OutputTokens
+	}
+
+	// Failed calls are only recorded when the provider reported what they used
+	if err == nil || len(call.requests) > 0 {
+		s.chatService.recordUsage(ctx, params.TenantID, provider, "prompt_test", result.Instructions+text, fmt.Sprint(output),
+			&LLMResponse{Model: result.Model, InputTokens: result.InputTokens, OutputTokens: result.OutputTokens})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Helper methods
+
+func (s *PromptTemplateService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "prompt_template",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+// Helper functions
+
+// aiPromptInstructions renders the instructions of an AI call: the tenant's
+// template the call's context carries, or the default, followed by the
+// reply format the call parses
+func aiPromptInstructions(ctx context.Context, kind models.PromptKind) string {
+	schema := promptKindSchemas[kind]
+	body := schema.DefaultBody
+	if template := AIPromptTemplateFromContext(ctx); template != nil && template.Kind == kind {
+		body = template.Body
+	}
+
+	vars := promptVariables(ctx, schema)
+	return strings.TrimSpace(renderTemplateText(body, vars)) + " " + renderTemplateText(schema.ReplyFormat, vars)
+}
+
+// promptVariables are the values of a kind's variables for a call
+func promptVariables(ctx context.Context, schema PromptKindSchema) map[string]string {
+	return map[string]string{
+		"language":       languageNames[AILanguageFromContext(ctx)],
+		"language_hint":  aiLanguageHint(ctx, schema.output),
+		"document_types": aiDocumentTypeChoices(ctx),
+		"max_tags":       strconv.Itoa(maxLLMTags),
+	}
+}
+
+func promptKindSchema(kind models.PromptKind) (PromptKindSchema, bool) {
+	schema, ok := promptKindSchemas[kind]
+	if !ok {
+		return PromptKindSchema{}, false
+	}
+	schema.Kind = kind
+	return schema, true
+}
+
+// validatePromptTemplate rejects empty or overlong templates, unknown
+// variables and malformed placeholders
+func validatePromptTemplate(schema PromptKindSchema, body string) error {
+	if body == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidPromptTemplate)
+	}
+	if len(body) > maxPromptTemplateLength {
+		return fmt.Errorf("%w: body must be at most %d characters", ErrInvalidPromptTemplate, maxPromptTemplateLength)
+	}
+	for _, match := range templatePlaceholder.FindAllStringSubmatch(body, -1) {
+		if _, ok := schema.Variables[match[1]]; !ok {
+			return fmt.Errorf("%w: unknown variable {{%s}} for %s", ErrInvalidPromptTemplate, match[1], schema.Kind)
+		}
+	}
+
+	stripped := templatePlaceholder.ReplaceAllString(body, "")
+	if strings.Contains(stripped, "{{") || strings.Contains(stripped, "}}") {
+		return fmt.Errorf("%w: unbalanced placeholder braces", ErrInvalidPromptTemplate)
+	}
+	return nil
+}
+
+func effectivePromptTemplate(schema PromptKindSchema, active *models.PromptTemplate) EffectivePromptTemplate {
+	if active != nil {
+		version, createdAt := active.Version, active.CreatedAt
+		return EffectivePromptTemplate{
+			Kind:      schema.Kind,
+			Body:      active.Body,
+			Version:   &version,
+			CreatedAt: &createdAt,
+		}
+	}
+	return EffectivePromptTemplate{
+		Kind:      schema.Kind,
+		Body:      schema.DefaultBody,
+		IsDefault: true,
+	}
+}
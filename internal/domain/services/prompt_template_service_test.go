@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAIPromptInstructions(t *testing.T) {
+	ctx := WithAILanguage(context.Background(), "de")
+
+	assert.Equal(t,
+		"Summarize this document in two to four sentences: what it is, who it involves and its key figures and dates."+
+			" The document is in German; write the summary in German. Reply with the summary only.",
+		aiPromptInstructions(ctx, models.PromptSummarization))
+	assert.Equal(t,
+		`Suggest up to 5 short lowercase tags for filing this document. Reply with a JSON object with "tags", a list of strings.`,
+		aiPromptInstructions(context.Background(), models.PromptTagging))
+	assert.Contains(t, aiPromptInstructions(ctx, models.PromptClassification), `"document_type", one of invoice, receipt`)
+
+	// A tenant's template replaces the instructions of its kind only; the
+	// reply format is kept
+	ctx = WithAIPromptTemplate(ctx, &models.PromptTemplate{
+		Kind: models.PromptClassification,
+		Body: "Classify this {{language}} document. Bank letters are general.",
+	})
+	instructions := aiPromptInstructions(ctx, models.PromptClassification)
+	assert.True(t, strings.HasPrefix(instructions, "Classify this German document. Bank letters are general. Reply with a JSON object"))
+	assert.Equal(t,
+		"List the people, organizations, locations, dates, amounts and emails this document mentions."+
+			` Reply with a JSON object with the keys "people", "organizations", "locations", "dates", "amounts" and "emails", each a list of strings. Leave out keys with nothing found.`,
+		aiPromptInstructions(ctx, models.PromptExtraction))
+}
+
+func TestValidatePromptTemplate(t *testing.T) {
+	tagging, _ := promptKindSchema(models.PromptTagging)
+	assert.NoError(t, validatePromptTemplate(tagging, "Suggest {{ max_tags }} tags in {{language}}."))
+	assert.ErrorIs(t, validatePromptTemplate(tagging, ""), ErrInvalidPromptTemplate)
+	assert.ErrorIs(t, validatePromptTemplate(tagging, "Use {{document_types}}"), ErrInvalidPromptTemplate)
+	assert.ErrorIs(t, validatePromptTemplate(tagging, "Suggest {{max_tags} tags"), ErrInvalidPromptTemplate)
+	assert.ErrorIs(t, validatePromptTemplate(tagging, strings.Repeat("a", maxPromptTemplateLength+1)), ErrInvalidPromptTemplate)
+
+	// Every default is a valid template of its kind
+	service := &PromptTemplateService{}
+	for _, schema := range service.ListSchemas() {
+		assert.NoError(t, validatePromptTemplate(schema, schema.DefaultBody), schema.Kind)
+		assert.NotEmpty(t, schema.JobType)
+	}
+}
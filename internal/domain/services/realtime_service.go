@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Realtime-only event types. Realtime clients also receive every webhook
+// event, so AI job completion arrives as document.processed.
+const (
+	RealtimeEventDocumentStatusChanged = "document.status_changed"
+	RealtimeEventJobFailed             = "ai_job.failed"
+	RealtimeEventNotificationCreated   = "notification.created"
+	RealtimeEventCommentCreated        = "comment.created"
+	RealtimeEventHeartbeat             = "heartbeat"
+)
+
+// Realtime metrics, published through expvar
+var (
+	realtimeConnections   = expvar.NewInt("realtime_connections")
+	realtimeEventsDropped = expvar.NewInt("realtime_events_dropped_total")
+)
+
+// RealtimeBroker carries realtime events between server instances, e.g. over
+// Redis pub/sub. Subscribe's channel is closed when ctx is cancelled or the
+// connection drops.
+type RealtimeBroker interface {
+	Publish(ctx context.Context, channel string, payload []byte) error
+	Subscribe(ctx context.Context, channel string) (<-chan []byte, error)
+}
+
+// UserEventPublisher publishes events meant for a single user's sessions
+type UserEventPublisher interface {
+	PublishToUser(ctx context.Context, tenantID, userID uuid.UUID, eventType string, data map[string]interface{})
+}
+
+// RealtimeHubConfig holds configuration for the realtime hub
+type RealtimeHubConfig struct {
+	Channel           string        // Broker channel shared by every server instance
+	ClientBuffer      int           // Events buffered per connection before new ones are dropped
+	HeartbeatInterval time.Duration // Keeps idle connections open through proxies
+}
+
+// RealtimeEvent is pushed to connected clients
+type RealtimeEvent struct {
+	ID        uuid.UUID              `json:"id"`
+	Type      string                 `json:"type"`
+	TenantID  uuid.UUID              `json:"tenant_id"`
+	UserID    *uuid.UUID             `json:"user_id,omitempty"` // Only this user receives the event
+	Data      map[string]interface{} `json:"data"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// RealtimeSubscription is one client connection's view of its tenant's events
+type RealtimeSubscription struct {
+	Events <-chan RealtimeEvent
+
+	hub      *RealtimeHub
+	tenantID uuid.UUID
+	userID   uuid.UUID
+	events   chan RealtimeEvent
+	once     sync.Once
+}
+
+// Close unsubscribes the connection
+func (sub *RealtimeSubscription) Close() {
+	sub.hub.unsubscribe(sub)
+}
+
+// RealtimeHub pushes per-tenant events to connected clients. Events go
+// through the broker so every instance sees them; without a broker the hub
+// only reaches clients connected to this instance.
+type RealtimeHub struct {
+	broker RealtimeBroker
+	config RealtimeHubConfig
+
+	mu          sync.RWMutex
+	subscribers map[uuid.UUID]map[*RealtimeSubscription]struct{}
+}
+
+// NewRealtimeHub creates a new realtime hub; broker may be nil for a single instance
+func NewRealtimeHub(broker RealtimeBroker, config RealtimeHubConfig) *RealtimeHub {
+	if config.Channel == "" {
+		config.Channel = "archivus:realtime"
+	}
+	if config.ClientBuffer <= 0 {
+		config.ClientBuffer = 64
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 25 * time.Second
+	}
+
+	return &RealtimeHub{
+		broker:      broker,
+		config:      config,
+		subscribers: make(map[uuid.UUID]map[*RealtimeSubscription]struct{}),
+	}
+}
+
+// HeartbeatInterval is how often streams should send a heartbeat
+func (h *RealtimeHub) HeartbeatInterval() time.Duration {
+	return h.config.HeartbeatInterval
+}
+
+// Subscribe registers a client connection for its tenant's events
+func (h *RealtimeHub) Subscribe(tenantID, userID uuid.UUID) *RealtimeSubscription {
+	events := make(chan RealtimeEvent, h.config.ClientBuffer)
+	sub := &RealtimeSubscription{
+		Events:   events,
+		hub:      h,
+		tenantID: tenantID,
+		userID:   userID,
+		events:   events,
+	}
+
+	h.mu.Lock()
+	if h.subscribers[tenantID] == nil {
+		h.subscribers[tenantID] = make(map[*RealtimeSubscription]struct{})
+	}
+	h.subscribers[tenantID][sub] = struct{}{}
+	h.mu.Unlock()
+
+	realtimeConnections.Add(1)
+	return sub
+}
+
+// Publish sends an event to every connected user of the tenant
+func (h *RealtimeHub) Publish(ctx context.Context, tenantID uuid.UUID, eventType string, data map[string]interface{}) {
+	h.publish(ctx, RealtimeEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		TenantID:  tenantID,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// PublishToUser sends an event to one user's connections only
+func (h *RealtimeHub) PublishToUser(ctx context.Context, tenantID, userID uuid.UUID, eventType string, data map[string]interface{}) {
+	h.publish(ctx, RealtimeEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		TenantID:  tenantID,
+		UserID:    &userID,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// Run relays broker events to local clients until the context is cancelled,
+// resubscribing when the broker connection drops
+func (h *RealtimeHub) Run(ctx context.Context) {
+	if h.broker == nil {
+		return
+	}
+
+	retry := time.Second
+	for {
+		messages, err := h.broker.Subscribe(ctx, h.config.Channel)
+		if err == nil {
+			retry = time.Second
+			for payload := range messages {
+				var event RealtimeEvent
+				if err := json.Unmarshal(payload, &event); err != nil {
+					continue
+				}
+				h.dispatch(event)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+		if retry < 30*time.Second {
+			retry *= 2
+		}
+	}
+}
+
+// Close disconnects every client so open streams end during shutdown
+func (h *RealtimeHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for tenantID, subs := range h.subscribers {
+		for sub := range subs {
+			sub.once.Do(func() {
+				close(sub.events)
+				realtimeConnections.Add(-1)
+			})
+		}
+		delete(h.subscribers, tenantID)
+	}
+}
+
+// Helper methods
+
+func (h *RealtimeHub) publish(ctx context.Context, event RealtimeEvent) {
+	if h.broker != nil {
+		payload, err := json.Marshal(event)
+		if err == nil {
+			if err := h.broker.Publish(ctx, h.config.Channel, payload); err == nil {
+				return
+			}
+		}
+		// Fall through - local clients still get the event
+	}
+	h.dispatch(event)
+}
+
+func (h *RealtimeHub) dispatch(event RealtimeEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscribers[event.TenantID] {
+		if event.UserID != nil && *event.UserID != sub.userID {
+			continue
+		}
+
+		// Never block publishers on a slow client
+		select {
+		case sub.events <- event:
+		default:
+			realtimeEventsDropped.Add(1)
+		}
+	}
+}
+
+func (h *RealtimeHub) unsubscribe(sub *RealtimeSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if subs := h.subscribers[sub.tenantID]; subs != nil {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(h.subscribers, sub.tenantID)
+		}
+	}
+
+	sub.once.Do(func() {
+		close(sub.events)
+		realtimeConnections.Add(-1)
+	})
+}
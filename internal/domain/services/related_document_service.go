@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// Limits for related documents
+const (
+	defaultRelatedDocuments = 10
+	maxRelatedDocuments     = 50
+	relatedCandidateFactor  = 3 // Candidates fetched per document returned, leaving room for ones the user can't read
+)
+
+// How much the similarity of documents' text and the entities they share
+// count towards how related they are
+const (
+	relatedSimilarityWeight = 0.7
+	relatedEntityWeight     = 0.3
+)
+
+// relatedEntityTypes are the entities that relate documents sharing them.
+// Dates and amounts are shared by unrelated documents too often.
+var relatedEntityTypes = []models.EntityType{
+	models.EntityPerson,
+	models.EntityOrganization,
+	models.EntityLocation,
+	models.EntityEmail,
+}
+
+// RelatedDocumentService finds the documents related to a document: those
+// whose passages are nearest to it by embedding and those mentioning the
+// same people, organizations, places and emails, such as earlier contracts
+// and invoices from the same vendor.
+type RelatedDocumentService struct {
+	chunkRepo       repositories.DocumentChunkRepository
+	entityRepo      repositories.EntityRepository
+	documentService *DocumentService
+}
+
+// RelatedDocument is a document related to another. Score weighs the
+// similarity of their text, when both are embedded, and the share of the
+// document's entities the related one mentions too.
+type RelatedDocument struct {
+	Document       *models.Document `json:"document"`
+	Score          float64          `json:"score"`
+	Similarity     *float64         `json:"similarity,omitempty"`
+	SharedEntities []RelatedEntity  `json:"shared_entities"`
+}
+
+// RelatedEntity is an entity two documents mention
+type RelatedEntity struct {
+	Type  models.EntityType `json:"type"`
+	Value string            `json:"value"`
+}
+
+// relatedCandidate is a document that may be related, before it is loaded
+type relatedCandidate struct {
+	documentID uuid.UUID
+	similarity *float64
+	shared     int64
+	score      float64
+}
+
+// NewRelatedDocumentService creates a new related document service
+func NewRelatedDocumentService(
+	chunkRepo repositories.DocumentChunkRepository,
+	entityRepo repositories.EntityRepository,
+	documentService *DocumentService,
+) *RelatedDocumentService {
+	return &RelatedDocumentService{
+		chunkRepo:       chunkRepo,
+		entityRepo:      entityRepo,
+		documentService: documentService,
+	}
+}
+
+// ListRelated returns the documents the user can read that are most related
+// to a document, most related first. Documents neither embedded nor with
+// entities have none.
+func (s *RelatedDocumentService) ListRelated(ctx context.Context, tenantID, userID, documentID uuid.UUID, limit int) ([]RelatedDocument, error) {
+	document, err := s.documentService.getTenantDocument(ctx, documentID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.documentService.CheckDocumentAccess(ctx, document, userID, models.DocPermRead); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultRelatedDocuments
+	}
+	if limit > maxRelatedDocuments {
+		limit = maxRelatedDocuments
+	}
+
+	candidates := make(map[uuid.UUID]*relatedCandidate)
+	candidate := func(id uuid.UUID) *relatedCandidate {
+		if candidates[id] == nil {
+			candidates[id] = &relatedCandidate{documentID: id}
+		}
+		return candidates[id]
+	}
+
+	chunks, err := s.chunkRepo.ListByDocument(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if centroid := chunkCentroid(chunks); centroid != nil {
+		nearest, err := s.chunkRepo.NearestDocuments(ctx, tenantID, centroid, documentID, limit*relatedCandidateFactor)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range nearest {
+			similarity := 1 - match.Distance
+			candidate(match.DocumentID).similarity = &similarity
+		}
+	}
+
+	entities, err := s.entityRepo.ListByDocuments(ctx, tenantID, []uuid.UUID{documentID})
+	if err != nil {
+		return nil, err
+	}
+	own := relatedEntities(entities)
+	if len(own) > 0 {
+		overlaps, err := s.entityRepo.SharedWith(ctx, tenantID, documentID, relatedEntityTypes, limit*relatedCandidateFactor)
+		if err != nil {
+			return nil, err
+		}
+		for _, overlap := range overlaps {
+			candidate(overlap.DocumentID).shared = overlap.Shared
+		}
+	}
+
+	ranked := make([]*relatedCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		c.score = relatedScore(c.similarity, c.shared, len(own))
+		ranked = append(ranked, c)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	related := make([]RelatedDocument, 0, limit)
+	for _, c := range ranked {
+		if len(related) == limit {
+			break
+		}
+		other, err := s.documentService.getTenantDocument(ctx, c.documentID, tenantID)
+		if err != nil || s.documentService.CheckDocumentAccess(ctx, other, userID, models.DocPermRead) != nil {
+			continue
+		}
+		related = append(related, RelatedDocument{
+			Document:       other,
+			Score:          c.score,
+			Similarity:     c.similarity,
+			SharedEntities: []RelatedEntity{},
+		})
+	}
+
+	if len(own) > 0 && len(related) > 0 {
+		ids := make([]uuid.UUID, len(related))
+		for i := range related {
+			ids[i] = related[i].Document.ID
+		}
+		others, err := s.entityRepo.ListByDocuments(ctx, tenantID, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range related {
+			for _, entity := range others {
+				if entity.DocumentID != related[i].Document.ID {
+					continue
+				}
+				if value, ok := own[relatedEntityKey(entity)]; ok {
+					related[i].SharedEntities = append(related[i].SharedEntities, RelatedEntity{Type: entity.Type, Value: value})
+				}
+			}
+		}
+	}
+
+	return related, nil
+}
+
+// Helper functions
+
+// chunkCentroid averages a document's passage embeddings into one for the
+// whole document. Passages embedded with other dimensions than the first
+// are left out; documents without embedded passages have none.
+func chunkCentroid(chunks []models.DocumentChunk) []float32 {
+	var centroid []float32
+	count := 0
+	for _, chunk := range chunks {
+		if chunk.Embedding == nil {
+			continue
+		}
+		vector := chunk.Embedding.Slice()
+		if centroid == nil {
+			centroid = make([]float32, len(vector))
+		}
+		if len(vector) != len(centroid) {
+			continue
+		}
+		for i, value := range vector {
+			centroid[i] += value
+		}
+		count++
+	}
+	for i := range centroid {
+		centroid[i] /= float32(count)
+	}
+	return centroid
+}
+
+// relatedEntities maps the entities that relate documents to how the
+// document writes them
+func relatedEntities(entities []models.Entity) map[string]string {
+	own := make(map[string]string)
+	for _, entity := range entities {
+		for _, entityType := range relatedEntityTypes {
+			if entity.Type == entityType {
+				own[relatedEntityKey(entity)] = entity.Value
+			}
+		}
+	}
+	return own
+}
+
+func relatedEntityKey(entity models.Entity) string {
+	return string(entity.Type) + ":" + entity.NormalizedValue
+}
+
+// relatedScore weighs how similar a document's text is, when it was
+// compared, and the share of the entities it shares
+func relatedScore(similarity *float64, shared int64, entities int) float64 {
+	score := 0.0
+	if similarity != nil && *similarity > 0 {
+		score += relatedSimilarityWeight * *similarity
+	}
+	if entities > 0 {
+		overlap := float64(shared) / float64(entities)
+		if overlap > 1 {
+			overlap = 1
+		}
+		score += relatedEntityWeight * overlap
+	}
+	return score
+}
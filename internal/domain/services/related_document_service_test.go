@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/pgvector/pgvector-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkCentroid(t *testing.T) {
+	first := pgvector.NewVector([]float32{1, 0})
+	second := pgvector.NewVector([]float32{0, 1})
+	other := pgvector.NewVector([]float32{1, 1, 1})
+	centroid := chunkCentroid([]models.DocumentChunk{
+		{Embedding: &first}, {}, {Embedding: &second}, {Embedding: &other},
+	})
+	assert.Equal(t, []float32{0.5, 0.5}, centroid)
+
+	assert.Nil(t, chunkCentroid([]models.DocumentChunk{{Content: "Not embedded"}}))
+}
+
+func TestRelatedEntities(t *testing.T) {
+	own := relatedEntities([]models.Entity{
+		{Type: models.EntityOrganization, Value: "Acme Corp", NormalizedValue: "acme corp"},
+		{Type: models.EntityDate, Value: "1 March", NormalizedValue: "1 march"},
+	})
+	assert.Equal(t, map[string]string{"organization:acme corp": "Acme Corp"}, own)
+}
+
+func TestRelatedScore(t *testing.T) {
+	similarity := 0.9
+	assert.InDelta(t, 0.7*0.9+0.3*0.5, relatedScore(&similarity, 1, 2), 1e-9)
+	assert.InDelta(t, 0.3, relatedScore(nil, 3, 2), 1e-9)
+
+	opposite := -0.2
+	assert.Zero(t, relatedScore(&opposite, 0, 0))
+}
@@ -0,0 +1,646 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownRepairCheck     = errors.New("unknown repair check")
+	ErrStorageListUnsupported = errors.New("storage backend can't list files")
+)
+
+// Repair checks, in the order they run
+const (
+	RepairCheckStuckDocuments   = "stuck_documents"
+	RepairCheckMissingAnalytics = "missing_analytics"
+	RepairCheckStorageUsage     = "storage_usage"
+	RepairCheckDocumentCount    = "document_count"
+	RepairCheckTagUsage         = "tag_usage"
+	RepairCheckOrphanedFiles    = "orphaned_files"
+	RepairCheckMissingFiles     = "missing_files"
+)
+
+// AllRepairChecks lists every check the repair service knows about
+var AllRepairChecks = []string{
+	RepairCheckStuckDocuments,
+	RepairCheckMissingAnalytics,
+	RepairCheckStorageUsage,
+	RepairCheckDocumentCount,
+	RepairCheckTagUsage,
+	RepairCheckOrphanedFiles,
+	RepairCheckMissingFiles,
+}
+
+// StorageRepairChecks compare the files in storage with the rows pointing at them
+var StorageRepairChecks = []string{
+	RepairCheckOrphanedFiles,
+	RepairCheckMissingFiles,
+}
+
+// defaultStuckAfter is how long a document may sit in processing with no
+// active job before it is considered stuck
+const defaultStuckAfter = time.Hour
+
+// defaultOrphanMinAge is how old an unreferenced file must be before it's an
+// orphan; younger files may belong to an upload that hasn't written its row
+const defaultOrphanMinAge = 24 * time.Hour
+
+// Storage drift metrics, published through expvar. Drift is the counter minus
+// the recomputed usage at the last reconciliation, keyed by tenant ID.
+var (
+	storageDriftBytes      = expvar.NewMap("tenant_storage_drift_bytes")
+	storageReconciledTotal = expvar.NewInt("tenant_storage_reconciliations_total")
+	storageDriftDetected   = expvar.NewInt("tenant_storage_drift_detected_total")
+)
+
+// RepairService scans for inconsistent data and fixes it. Each fix only
+// applies while the inconsistency still exists, so repeated runs converge.
+type RepairService struct {
+	repairRepo     repositories.RepairRepository
+	analyticsRepo  repositories.AnalyticsRepository
+	storageService StorageService
+	config         RepairServiceConfig
+}
+
+// RepairServiceConfig holds configuration for background reconciliation
+type RepairServiceConfig struct {
+	StorageReconcileInterval time.Duration // How often RunStorageReconciler runs; defaults to six hours
+}
+
+// NewRepairService creates a new repair service. storageService may be nil,
+// in which case the storage checks fail.
+func NewRepairService(
+	repairRepo repositories.RepairRepository,
+	analyticsRepo repositories.AnalyticsRepository,
+	storageService StorageService,
+	config RepairServiceConfig,
+) *RepairService {
+	return &RepairService{
+		repairRepo:     repairRepo,
+		analyticsRepo:  analyticsRepo,
+		storageService: storageService,
+		config:         config,
+	}
+}
+
+// StorageReconciliation is the result of recomputing one tenant's usage
+type StorageReconciliation struct {
+	TenantID      uuid.UUID `json:"tenant_id"`
+	PreviousUsage int64     `json:"previous_storage_used"`
+	StorageUsed   int64     `json:"storage_used"`
+	Drift         int64     `json:"drift"` // Previous counter minus actual usage
+}
+
+// RepairOptions controls a repair run
+type RepairOptions struct {
+	TenantID     *uuid.UUID    // Limit the run to one tenant; nil scans all tenants
+	Checks       []string      // Checks to run; empty runs all of them
+	DryRun       bool          // Report problems without fixing them
+	StuckAfter   time.Duration // Minimum age of a stuck document; defaults to one hour
+	OrphanMinAge time.Duration // Minimum age of an orphaned file; defaults to a day
+}
+
+// RepairReport describes what a repair run found and did
+type RepairReport struct {
+	DryRun      bool                `json:"dry_run"`
+	TenantID    *uuid.UUID          `json:"tenant_id,omitempty"`
+	StartedAt   time.Time           `json:"started_at"`
+	CompletedAt time.Time           `json:"completed_at"`
+	Checks      []RepairCheckResult `json:"checks"`
+}
+
+// RepairCheckResult is the outcome of one check
+type RepairCheckResult struct {
+	Check  string        `json:"check"`
+	Found  int           `json:"found"`
+	Fixed  int           `json:"fixed"`
+	Failed int           `json:"failed"`
+	Issues []RepairIssue `json:"issues"`
+	Error  string        `json:"error,omitempty"` // Set when the scan itself failed
+}
+
+// RepairIssue is one inconsistency and what was done about it
+type RepairIssue struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	EntityID uuid.UUID `json:"entity_id"`
+	Detail   string    `json:"detail"`
+	Path     string    `json:"path,omitempty"` // Storage path, for storage checks
+	Action   string    `json:"action"`
+	Fixed    bool      `json:"fixed"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// TotalFound returns the number of issues found across all checks
+func (r *RepairReport) TotalFound() int {
+	total := 0
+	for _, check := range r.Checks {
+		total += check.Found
+	}
+	return total
+}
+
+// TotalFixed returns the number of issues fixed across all checks
+func (r *RepairReport) TotalFixed() int {
+	total := 0
+	for _, check := range r.Checks {
+		total += check.Fixed
+	}
+	return total
+}
+
+// HasErrors reports whether any scan or fix failed
+func (r *RepairReport) HasErrors() bool {
+	for _, check := range r.Checks {
+		if check.Error != "" || check.Failed > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes the requested checks and returns a report. A failing check is
+// recorded in the report and does not stop the remaining checks.
+func (s *RepairService) Run(ctx context.Context, opts RepairOptions) (*RepairReport, error) {
+	checks := opts.Checks
+	if len(checks) == 0 {
+		checks = AllRepairChecks
+	}
+	for _, check := range checks {
+		if !isRepairCheck(check) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRepairCheck, check)
+		}
+	}
+	if opts.StuckAfter <= 0 {
+		opts.StuckAfter = defaultStuckAfter
+	}
+	if opts.OrphanMinAge <= 0 {
+		opts.OrphanMinAge = defaultOrphanMinAge
+	}
+
+	report := &RepairReport{
+		DryRun:    opts.DryRun,
+		TenantID:  opts.TenantID,
+		StartedAt: time.Now(),
+	}
+
+	// Both storage checks work from one listing of storage and references
+	var scans []tenantStorageScan
+	var scanErr error
+	scanned := false
+
+	for _, check := range AllRepairChecks {
+		if !containsString(checks, check) {
+			continue
+		}
+
+		result := RepairCheckResult{Check: check, Issues: []RepairIssue{}}
+		var err error
+		switch check {
+		case RepairCheckStuckDocuments:
+			err = s.repairStuckDocuments(ctx, opts, &result)
+		case RepairCheckMissingAnalytics:
+			err = s.repairMissingAnalytics(ctx, opts, &result)
+		case RepairCheckStorageUsage:
+			err = s.repairStorageUsage(ctx, opts, &result)
+		case RepairCheckDocumentCount:
+			err = s.repairDocumentCount(ctx, opts, &result)
+		case RepairCheckTagUsage:
+			err = s.repairTagUsage(ctx, opts, &result)
+		case RepairCheckOrphanedFiles, RepairCheckMissingFiles:
+			if !scanned {
+				scans, scanErr = s.scanStorage(ctx, opts)
+				scanned = true
+			}
+			err = scanErr
+			if err == nil && check == RepairCheckOrphanedFiles {
+				s.repairOrphanedFiles(ctx, opts, scans, &result)
+			} else if err == nil {
+				reportMissingFiles(scans, &result)
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		report.Checks = append(report.Checks, result)
+	}
+
+	report.CompletedAt = time.Now()
+	return report, nil
+}
+
+// ReconcileStorageUsage recomputes a tenant's exact storage usage from its
+// document and version sizes and resets the counter to it
+func (s *RepairService) ReconcileStorageUsage(ctx context.Context, tenantID uuid.UUID) (*StorageReconciliation, error) {
+	reconciled, err := s.repairRepo.ReconcileStorageUsed(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StorageReconciliation{
+		TenantID:      tenantID,
+		PreviousUsage: reconciled.StorageUsed,
+		StorageUsed:   reconciled.ActualUsage,
+		Drift:         reconciled.StorageUsed - reconciled.ActualUsage,
+	}
+	recordStorageDrift(result)
+
+	return result, nil
+}
+
+// ReconcileAllStorageUsage reconciles every tenant whose counter has drifted
+func (s *RepairService) ReconcileAllStorageUsage(ctx context.Context) ([]StorageReconciliation, error) {
+	drift, err := s.repairRepo.FindStorageDrift(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]StorageReconciliation, 0, len(drift))
+	for _, tenant := range drift {
+		result, err := s.ReconcileStorageUsage(ctx, tenant.TenantID)
+		if err != nil {
+			// Log but continue - the next run retries this tenant
+			continue
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+// ReconcileAllDocumentCounts resets every drifted document counter and
+// returns how many were fixed
+func (s *RepairService) ReconcileAllDocumentCounts(ctx context.Context) (int, error) {
+	drift, err := s.repairRepo.FindDocumentCountDrift(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	fixed := 0
+	for _, tenant := range drift {
+		if _, err := s.repairRepo.ReconcileDocumentCount(ctx, tenant.TenantID); err != nil {
+			// Log but continue - the next run retries this tenant
+			continue
+		}
+		fixed++
+	}
+
+	return fixed, nil
+}
+
+// AnalyticsRollupJob is the tenant job that recomputes the tenant's analytics
+// rows, storage usage, document count and tag usage counters
+func (s *RepairService) AnalyticsRollupJob() JobHandler {
+	return func(ctx context.Context, tenantID uuid.UUID, params models.JSONB) error {
+		report, err := s.Run(ctx, RepairOptions{
+			TenantID: &tenantID,
+			Checks:   []string{RepairCheckMissingAnalytics, RepairCheckStorageUsage, RepairCheckDocumentCount, RepairCheckTagUsage},
+		})
+		if err != nil {
+			return err
+		}
+		if report.HasErrors() {
+			return fmt.Errorf("analytics rollup fixed %d of %d problems", report.TotalFixed(), report.TotalFound())
+		}
+		return nil
+	}
+}
+
+// StorageReconcileTask is the scheduled task that resets drifted storage and
+// document counters to their recomputed values. An upload caught between its
+// quota reservation and its document row is undercounted until the next run.
+func (s *RepairService) StorageReconcileTask() ScheduledTask {
+	interval := s.config.StorageReconcileInterval
+	if interval <= 0 {
+		interval = 6 * time.Hour
+	}
+
+	return ScheduledTask{
+		Name:     "storage_reconcile",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, storageErr := s.ReconcileAllStorageUsage(ctx)
+			_, countErr := s.ReconcileAllDocumentCounts(ctx)
+			return errors.Join(storageErr, countErr)
+		},
+	}
+}
+
+// Check implementations
+
+func (s *RepairService) repairStuckDocuments(ctx context.Context, opts RepairOptions, result *RepairCheckResult) error {
+	stuck, err := s.repairRepo.FindStuckDocuments(ctx, opts.TenantID, time.Now().Add(-opts.StuckAfter))
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range stuck {
+		status := stuckDocumentStatus(doc)
+		issue := RepairIssue{
+			TenantID: doc.TenantID,
+			EntityID: doc.DocumentID,
+			Detail: fmt.Sprintf("%s in processing since %s with no active jobs (%d completed, %d failed)",
+				doc.FileName, doc.UpdatedAt.Format(time.RFC3339), doc.CompletedJobs, doc.FailedJobs),
+			Action: fmt.Sprintf("set status to %s", status),
+		}
+
+		if !opts.DryRun {
+			resolved, err := s.repairRepo.ResolveStuckDocument(ctx, doc.DocumentID, status)
+			switch {
+			case err != nil:
+				issue.Error = err.Error()
+			case !resolved:
+				issue.Action = "skipped, document is no longer stuck"
+			default:
+				issue.Fixed = true
+			}
+		}
+
+		result.record(issue)
+	}
+	return nil
+}
+
+func (s *RepairService) repairMissingAnalytics(ctx context.Context, opts RepairOptions, result *RepairCheckResult) error {
+	documents, err := s.repairRepo.FindDocumentsMissingAnalytics(ctx, opts.TenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range documents {
+		issue := RepairIssue{
+			TenantID: doc.TenantID,
+			EntityID: doc.ID,
+			Detail:   fmt.Sprintf("%s has no analytics row", doc.FileName),
+			Action:   "create analytics row",
+		}
+
+		if !opts.DryRun {
+			err := s.analyticsRepo.CreateDocumentAnalytics(ctx, &models.DocumentAnalytics{
+				ID:         uuid.New(),
+				TenantID:   doc.TenantID,
+				DocumentID: doc.ID,
+			})
+			if err != nil {
+				issue.Error = err.Error()
+			} else {
+				issue.Fixed = true
+			}
+		}
+
+		result.record(issue)
+	}
+	return nil
+}
+
+func (s *RepairService) repairStorageUsage(ctx context.Context, opts RepairOptions, result *RepairCheckResult) error {
+	drift, err := s.repairRepo.FindStorageDrift(ctx, opts.TenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, tenant := range drift {
+		issue := RepairIssue{
+			TenantID: tenant.TenantID,
+			EntityID: tenant.TenantID,
+			Detail: fmt.Sprintf("%s storage_used is %d bytes, documents total %d bytes (drift %+d)",
+				tenant.TenantName, tenant.StorageUsed, tenant.ActualUsage, tenant.StorageUsed-tenant.ActualUsage),
+			Action: fmt.Sprintf("set storage_used to %d", tenant.ActualUsage),
+		}
+
+		if !opts.DryRun {
+			// Recomputed in the update itself so uploads since the scan are counted
+			reconciled, err := s.ReconcileStorageUsage(ctx, tenant.TenantID)
+			if err != nil {
+				issue.Error = err.Error()
+			} else {
+				issue.Action = fmt.Sprintf("set storage_used to %d", reconciled.StorageUsed)
+				issue.Fixed = true
+			}
+		}
+
+		result.record(issue)
+	}
+	return nil
+}
+
+func (s *RepairService) repairDocumentCount(ctx context.Context, opts RepairOptions, result *RepairCheckResult) error {
+	drift, err := s.repairRepo.FindDocumentCountDrift(ctx, opts.TenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, tenant := range drift {
+		issue := RepairIssue{
+			TenantID: tenant.TenantID,
+			EntityID: tenant.TenantID,
+			Detail: fmt.Sprintf("%s document_count is %d, tenant holds %d documents",
+				tenant.TenantName, tenant.DocumentCount, tenant.ActualCount),
+			Action: fmt.Sprintf("set document_count to %d", tenant.ActualCount),
+		}
+
+		if !opts.DryRun {
+			count, err := s.repairRepo.ReconcileDocumentCount(ctx, tenant.TenantID)
+			if err != nil {
+				issue.Error = err.Error()
+			} else {
+				issue.Action = fmt.Sprintf("set document_count to %d", count)
+				issue.Fixed = true
+			}
+		}
+
+		result.record(issue)
+	}
+	return nil
+}
+
+func (s *RepairService) repairTagUsage(ctx context.Context, opts RepairOptions, result *RepairCheckResult) error {
+	drift, err := s.repairRepo.FindTagUsageDrift(ctx, opts.TenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range drift {
+		issue := RepairIssue{
+			TenantID: tag.TenantID,
+			EntityID: tag.TagID,
+			Detail:   fmt.Sprintf("tag %q usage_count is %d, attached to %d documents", tag.Name, tag.UsageCount, tag.ActualCount),
+			Action:   fmt.Sprintf("set usage_count to %d", tag.ActualCount),
+		}
+
+		if !opts.DryRun {
+			count, err := s.repairRepo.ReconcileTagUsage(ctx, tag.TagID)
+			if err != nil {
+				issue.Error = err.Error()
+			} else {
+				issue.Action = fmt.Sprintf("set usage_count to %d", count)
+				issue.Fixed = true
+			}
+		}
+
+		result.record(issue)
+	}
+	return nil
+}
+
+// tenantStorageScan is one tenant's files in storage and the paths its rows
+// reference
+type tenantStorageScan struct {
+	tenantID   uuid.UUID
+	objects    []StoredObject
+	references []repositories.StorageReference
+}
+
+// scanStorage lists the files under each tenant's storage prefix along with
+// the paths its rows reference
+func (s *RepairService) scanStorage(ctx context.Context, opts RepairOptions) ([]tenantStorageScan, error) {
+	lister, ok := s.storageService.(StorageLister)
+	if !ok {
+		return nil, ErrStorageListUnsupported
+	}
+
+	tenantIDs := []uuid.UUID{}
+	if opts.TenantID != nil {
+		tenantIDs = append(tenantIDs, *opts.TenantID)
+	} else {
+		var err error
+		if tenantIDs, err = s.repairRepo.ListTenantIDs(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	scans := make([]tenantStorageScan, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		// Every backend stores a tenant's files under its ID
+		objects, err := lister.List(ctx, tenantID.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list storage of tenant %s: %w", tenantID, err)
+		}
+		references, err := s.repairRepo.ListStorageReferences(ctx, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		scans = append(scans, tenantStorageScan{tenantID: tenantID, objects: objects, references: references})
+	}
+	return scans, nil
+}
+
+// repairOrphanedFiles deletes files no row references, such as uploads whose
+// document row failed to save. Files whose age storage didn't report are left
+// alone, as they might still be uploading.
+func (s *RepairService) repairOrphanedFiles(ctx context.Context, opts RepairOptions, scans []tenantStorageScan, result *RepairCheckResult) {
+	cutoff := time.Now().Add(-opts.OrphanMinAge)
+
+	for _, scan := range scans {
+		referenced := make(map[string]bool, len(scan.references))
+		for _, reference := range scan.references {
+			referenced[reference.Path] = true
+		}
+
+		for _, object := range scan.objects {
+			if referenced[object.Path] || object.ModifiedAt.IsZero() || object.ModifiedAt.After(cutoff) {
+				continue
+			}
+
+			issue := RepairIssue{
+				TenantID: scan.tenantID,
+				Path:     object.Path,
+				Detail:   fmt.Sprintf("file (%d bytes, modified %s) is not referenced by any row", object.Size, object.ModifiedAt.Format(time.RFC3339)),
+				Action:   "delete file",
+			}
+
+			if !opts.DryRun {
+				if err := s.storageService.Delete(ctx, object.Path); err != nil {
+					issue.Error = err.Error()
+				} else {
+					issue.Fixed = true
+				}
+			}
+
+			result.record(issue)
+		}
+	}
+}
+
+// reportMissingFiles reports rows pointing at files that aren't in storage.
+// Nothing can bring the file back, so these are never fixed. Upload sessions
+// are skipped: their file doesn't exist until the client has uploaded it.
+func reportMissingFiles(scans []tenantStorageScan, result *RepairCheckResult) {
+	for _, scan := range scans {
+		stored := make(map[string]bool, len(scan.objects))
+		for _, object := range scan.objects {
+			stored[object.Path] = true
+		}
+
+		for _, reference := range scan.references {
+			if stored[reference.Path] || reference.Resource == "upload_session" {
+				continue
+			}
+			result.record(RepairIssue{
+				TenantID: scan.tenantID,
+				EntityID: reference.ResourceID,
+				Path:     reference.Path,
+				Detail:   fmt.Sprintf("%s file %s is missing from storage", reference.Resource, reference.Path),
+				Action:   "none, restore the file from a backup",
+			})
+		}
+	}
+}
+
+// Helper functions
+
+func (r *RepairCheckResult) record(issue RepairIssue) {
+	r.Found++
+	if issue.Fixed {
+		r.Fixed++
+	}
+	if issue.Error != "" {
+		r.Failed++
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+func recordStorageDrift(result *StorageReconciliation) {
+	storageReconciledTotal.Add(1)
+	if result.Drift != 0 {
+		storageDriftDetected.Add(1)
+	}
+	drift := new(expvar.Int)
+	drift.Set(result.Drift)
+	storageDriftBytes.Set(result.TenantID.String(), drift)
+}
+
+// stuckDocumentStatus picks where a stuck document should land: failed jobs
+// surface it for retry, otherwise completed work is kept, otherwise it goes
+// back to pending
+func stuckDocumentStatus(doc repositories.StuckDocument) models.DocStatus {
+	switch {
+	case doc.FailedJobs > 0:
+		return models.DocStatusError
+	case doc.CompletedJobs > 0:
+		return models.DocStatusCompleted
+	default:
+		return models.DocStatusPending
+	}
+}
+
+func isRepairCheck(check string) bool {
+	return containsString(AllRepairChecks, check)
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleExists        = errors.New("a role with this name already exists")
+	ErrRoleInUse         = errors.New("role is still assigned to users")
+	ErrInvalidRoleName   = errors.New("role name is required and must be at most 50 characters")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+// PermissionDefinition describes one entry in the permission catalog
+type PermissionDefinition struct {
+	Key         string `json:"key"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+}
+
+// PermissionCatalog lists every permission that can be granted to a custom role
+var PermissionCatalog = []PermissionDefinition{
+	{Key: "documents.create", Category: "documents", Description: "Upload documents"},
+	{Key: "documents.read", Category: "documents", Description: "View and download documents"},
+	{Key: "documents.update", Category: "documents", Description: "Edit document metadata"},
+	{Key: "documents.delete", Category: "documents", Description: "Delete documents and revoke others' share links"},
+	{Key: "users.create", Category: "users", Description: "Invite and create users"},
+	{Key: "users.read", Category: "users", Description: "View users in the tenant"},
+	{Key: "users.update", Category: "users", Description: "Edit users in the tenant"},
+	{Key: "roles.manage", Category: "users", Description: "Create, edit and assign custom roles"},
+	{Key: "api_keys.manage", Category: "integrations", Description: "Create, rotate and revoke API keys"},
+	{Key: "ai_keys.manage", Category: "integrations", Description: "Manage the tenant's own AI provider keys"},
+	{Key: "webhooks.manage", Category: "integrations", Description: "Create and manage webhooks"},
+	{Key: "scim.provision", Category: "integrations", Description: "Provision users and groups through SCIM"},
+	{Key: "workflows.create", Category: "workflows", Description: "Create workflows"},
+	{Key: "workflows.read", Category: "workflows", Description: "View workflows"},
+	{Key: "workflows.update", Category: "workflows", Description: "Edit workflows"},
+	{Key: "tasks.complete", Category: "workflows", Description: "Complete assigned workflow tasks"},
+	{Key: "reports.read", Category: "reporting", Description: "View reports"},
+	{Key: "analytics.read", Category: "reporting", Description: "View analytics dashboards"},
+	{Key: "financial.read", Category: "reporting", Description: "View financial document data"},
+	{Key: "audit.read", Category: "compliance", Description: "View audit logs"},
+	{Key: "compliance.read", Category: "compliance", Description: "View compliance status"},
+	{Key: "legal_holds.manage", Category: "compliance", Description: "Place and lift legal holds"},
+}
+
+// RoleService manages tenant-defined custom roles
+type RoleService struct {
+	roleRepo  repositories.RoleRepository
+	userRepo  repositories.UserRepository
+	auditRepo repositories.AuditLogRepository
+
+	cacheService CacheService
+}
+
+// NewRoleService creates a new role service instance
+func NewRoleService(
+	roleRepo repositories.RoleRepository,
+	userRepo repositories.UserRepository,
+	auditRepo repositories.AuditLogRepository,
+	cacheService CacheService,
+) *RoleService {
+	return &RoleService{
+		roleRepo:     roleRepo,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		cacheService: cacheService,
+	}
+}
+
+// RoleParams contains parameters for creating or updating a custom role
+type RoleParams struct {
+	TenantID    uuid.UUID `json:"tenant_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	ActorID     uuid.UUID `json:"actor_id"`
+}
+
+// ListPermissions returns the permission catalog
+func (s *RoleService) ListPermissions() []PermissionDefinition {
+	return PermissionCatalog
+}
+
+// ListRoles lists a tenant's custom roles
+func (s *RoleService) ListRoles(ctx context.Context, tenantID uuid.UUID) ([]models.Role, error) {
+	return s.roleRepo.ListByTenant(ctx, tenantID)
+}
+
+// GetRole returns a custom role belonging to the tenant
+func (s *RoleService) GetRole(ctx context.Context, roleID, tenantID uuid.UUID) (*models.Role, error) {
+	role, err := s.roleRepo.GetByID(ctx, roleID)
+	if err != nil {
+		return nil, ErrRoleNotFound
+	}
+	if role.TenantID != tenantID {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// CreateRole creates a custom role after validating its permissions against the catalog
+func (s *RoleService) CreateRole(ctx context.Context, params RoleParams) (*models.Role, error) {
+	name, permissions, err := validateRoleParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.roleRepo.GetByName(ctx, params.TenantID, name); err == nil {
+		return nil, ErrRoleExists
+	}
+
+	role := &models.Role{
+		TenantID:    params.TenantID,
+		Name:        name,
+		Description: params.Description,
+		CreatedBy:   params.ActorID,
+		Permissions: permissions,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.ActorID, role.ID, models.AuditCreate, "Role created: "+name)
+
+	return role, nil
+}
+
+// UpdateRole renames a custom role and replaces its permission set
+func (s *RoleService) UpdateRole(ctx context.Context, roleID uuid.UUID, params RoleParams) (*models.Role, error) {
+	role, err := s.GetRole(ctx, roleID, params.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, permissions, err := validateRoleParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.roleRepo.GetByName(ctx, params.TenantID, name); err == nil && existing.ID != role.ID {
+		return nil, ErrRoleExists
+	}
+
+	role.Name = name
+	role.Description = params.Description
+	role.Permissions = permissions
+	role.UpdatedAt = time.Now()
+
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+
+	s.invalidateRolePermissions(ctx, role.ID)
+	s.createAuditLog(ctx, params.TenantID, params.ActorID, role.ID, models.AuditUpdate, "Role updated: "+name)
+
+	return role, nil
+}
+
+// DeleteRole deletes a custom role that is no longer assigned to anyone
+func (s *RoleService) DeleteRole(ctx context.Context, roleID, tenantID, deletedBy uuid.UUID) error {
+	role, err := s.GetRole(ctx, roleID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.roleRepo.CountUsers(ctx, role.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	if err := s.roleRepo.Delete(ctx, role.ID); err != nil {
+		return err
+	}
+
+	s.invalidateRolePermissions(ctx, role.ID)
+	s.createAuditLog(ctx, tenantID, deletedBy, role.ID, models.AuditDelete, "Role deleted: "+role.Name)
+
+	return nil
+}
+
+// AssignRole assigns a custom role to a user, overriding their built-in role's permissions
+func (s *RoleService) AssignRole(ctx context.Context, userID, roleID, tenantID, assignedBy uuid.UUID) (*models.User, error) {
+	role, err := s.GetRole(ctx, roleID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.setCustomRole(ctx, userID, &role.ID, tenantID, assignedBy, "Custom role assigned: "+role.Name)
+}
+
+// UnassignRole removes a custom role from a user so their built-in role applies again
+func (s *RoleService) UnassignRole(ctx context.Context, userID, roleID, tenantID, unassignedBy uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrUserNotFound
+	}
+	if user.CustomRoleID == nil || *user.CustomRoleID != roleID {
+		return nil, ErrRoleNotFound
+	}
+
+	return s.setCustomRole(ctx, userID, nil, tenantID, unassignedBy, "Custom role removed")
+}
+
+// Helper methods
+
+func (s *RoleService) setCustomRole(ctx context.Context, userID uuid.UUID, roleID *uuid.UUID, tenantID, actorID uuid.UUID, details string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrUserNotFound
+	}
+
+	user.CustomRoleID = roleID
+	user.UpdatedAt = time.Now()
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	// Cached profiles embed the user's permission list
+	s.cacheService.Delete(ctx, fmt.Sprintf(UserCacheKeyPattern, userID.String()))
+
+	s.createAuditLog(ctx, tenantID, actorID, userID, models.AuditUpdate, details)
+
+	return user, nil
+}
+
+func (s *RoleService) invalidateRolePermissions(ctx context.Context, roleID uuid.UUID) {
+	if err := s.cacheService.Delete(ctx, fmt.Sprintf(RolePermissionsKeyPattern, roleID.String())); err != nil {
+		// Log but don't fail - the cache entry expires on its own
+	}
+}
+
+func (s *RoleService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "role",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+func validateRoleParams(params RoleParams) (string, []models.RolePermission, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" || len(name) > 50 {
+		return "", nil, ErrInvalidRoleName
+	}
+
+	seen := make(map[string]bool, len(params.Permissions))
+	permissions := make([]models.RolePermission, 0, len(params.Permissions))
+	for _, permission := range params.Permissions {
+		if !isCatalogPermission(permission) {
+			return "", nil, fmt.Errorf("%w: %s", ErrUnknownPermission, permission)
+		}
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+		permissions = append(permissions, models.RolePermission{Permission: permission})
+	}
+
+	return name, permissions, nil
+}
+
+func isCatalogPermission(permission string) bool {
+	for _, definition := range PermissionCatalog {
+		if definition.Key == permission {
+			return true
+		}
+	}
+	return false
+}
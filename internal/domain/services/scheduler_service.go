@@ -0,0 +1,503 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrScheduledJobNotFound = errors.New("scheduled job not found")
+	ErrUnknownJobType       = errors.New("unknown job type")
+)
+
+// Scheduled job types
+const (
+	JobTypeRetentionSweep     = "retention_sweep"
+	JobTypeExpiringDocuments  = "expiring_documents"
+	JobTypeWorkflowAutomation = "workflow_automation"
+	JobTypeAnalyticsRollup    = "analytics_rollup"
+)
+
+// ScheduledJobTypes lists the job types tenants can schedule
+var ScheduledJobTypes = []string{
+	JobTypeRetentionSweep,
+	JobTypeExpiringDocuments,
+	JobTypeWorkflowAutomation,
+	JobTypeAnalyticsRollup,
+}
+
+// Tenant job metrics, published through expvar
+var (
+	tenantJobsEnqueued = expvar.NewMap("tenant_jobs_enqueued_total")
+	tenantJobRuns      = expvar.NewMap("tenant_job_runs_total")
+	tenantJobFailures  = expvar.NewMap("tenant_job_failures_total")
+)
+
+// JobHandler runs one scheduled job for a tenant with the job's parameters
+type JobHandler func(ctx context.Context, tenantID uuid.UUID, params models.JSONB) error
+
+// SchedulerServiceConfig holds configuration for the scheduler service
+type SchedulerServiceConfig struct {
+	LeaderKey    string        // Lock key of the leading scheduler, defaults to "archivus:scheduler:leader"
+	LeaderTTL    time.Duration // Leadership lease, refreshed while leading
+	PollInterval time.Duration // How often due jobs and queued runs are checked
+	BatchSize    int
+	RunLease     time.Duration // How long a run may take before another worker retries it
+	MaxAttempts  int           // Runs whose worker died this many times are failed
+}
+
+// SchedulerService manages tenants' recurring jobs. The scheduler (one leader
+// across all scheduler processes) queues a run each time a job's cron
+// expression fires; workers in the server claim queued runs and execute them
+// with the handler registered for the job type. Enqueueing and claiming are
+// conditional updates, so a stale leader or a second worker can't fire or run
+// the same slot twice.
+type SchedulerService struct {
+	jobRepo   repositories.ScheduledJobRepository
+	auditRepo repositories.AuditLogRepository
+	locker    DistributedLocker
+	config    SchedulerServiceConfig
+
+	mu       sync.RWMutex
+	handlers map[string]JobHandler
+}
+
+// NewSchedulerService creates a new scheduler service. locker may be nil when
+// only one scheduler process runs.
+func NewSchedulerService(
+	jobRepo repositories.ScheduledJobRepository,
+	auditRepo repositories.AuditLogRepository,
+	locker DistributedLocker,
+	config SchedulerServiceConfig,
+) *SchedulerService {
+	if config.LeaderKey == "" {
+		config.LeaderKey = "archivus:scheduler:leader"
+	}
+	if config.LeaderTTL <= 0 {
+		config.LeaderTTL = 30 * time.Second
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 15 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.RunLease <= 0 {
+		config.RunLease = 30 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+
+	return &SchedulerService{
+		jobRepo:   jobRepo,
+		auditRepo: auditRepo,
+		locker:    locker,
+		config:    config,
+		handlers:  make(map[string]JobHandler),
+	}
+}
+
+// RegisterHandler sets the handler workers use for a job type
+func (s *SchedulerService) RegisterHandler(jobType string, handler JobHandler) error {
+	if !isScheduledJobType(jobType) {
+		return fmt.Errorf("%w: %s", ErrUnknownJobType, jobType)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[jobType] = handler
+	return nil
+}
+
+// CreateScheduledJobParams contains parameters for scheduling a job
+type CreateScheduledJobParams struct {
+	TenantID       uuid.UUID    `json:"tenant_id"`
+	CreatedBy      uuid.UUID    `json:"created_by"`
+	JobType        string       `json:"job_type"`
+	CronExpression string       `json:"cron_expression"`
+	Timezone       string       `json:"timezone"`
+	Parameters     models.JSONB `json:"parameters"`
+	IsEnabled      bool         `json:"is_enabled"`
+}
+
+// CreateJob schedules a recurring job for a tenant
+func (s *SchedulerService) CreateJob(ctx context.Context, params CreateScheduledJobParams) (*models.ScheduledJob, error) {
+	if !isScheduledJobType(params.JobType) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobType, params.JobType)
+	}
+	if params.Timezone == "" {
+		params.Timezone = "UTC"
+	}
+
+	job := &models.ScheduledJob{
+		ID:             uuid.New(),
+		TenantID:       params.TenantID,
+		JobType:        params.JobType,
+		CronExpression: params.CronExpression,
+		Timezone:       params.Timezone,
+		Parameters:     params.Parameters,
+		IsEnabled:      params.IsEnabled,
+		CreatedBy:      params.CreatedBy,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.schedule(job, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create scheduled job: %w", err)
+	}
+
+	s.createAuditLog(ctx, job.TenantID, params.CreatedBy, job.ID, models.AuditCreate,
+		fmt.Sprintf("Scheduled %s job (%s %s)", job.JobType, job.CronExpression, job.Timezone))
+
+	return job, nil
+}
+
+// ListJobs lists the tenant's scheduled jobs
+func (s *SchedulerService) ListJobs(ctx context.Context, tenantID uuid.UUID) ([]models.ScheduledJob, error) {
+	return s.jobRepo.ListByTenant(ctx, tenantID)
+}
+
+// GetJob returns a scheduled job of the tenant
+func (s *SchedulerService) GetJob(ctx context.Context, tenantID, jobID uuid.UUID) (*models.ScheduledJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil || job.TenantID != tenantID {
+		return nil, ErrScheduledJobNotFound
+	}
+	return job, nil
+}
+
+// UpdateScheduledJobParams contains scheduled job changes; nil fields are left as they are
+type UpdateScheduledJobParams struct {
+	TenantID       uuid.UUID     `json:"tenant_id"`
+	JobID          uuid.UUID     `json:"job_id"`
+	UpdatedBy      uuid.UUID     `json:"updated_by"`
+	CronExpression *string       `json:"cron_expression,omitempty"`
+	Timezone       *string       `json:"timezone,omitempty"`
+	Parameters     *models.JSONB `json:"parameters,omitempty"`
+	IsEnabled      *bool         `json:"is_enabled,omitempty"`
+}
+
+// UpdateJob changes a scheduled job. Its next run is recomputed from now, so
+// runs missed while it was disabled are not caught up.
+func (s *SchedulerService) UpdateJob(ctx context.Context, params UpdateScheduledJobParams) (*models.ScheduledJob, error) {
+	job, err := s.GetJob(ctx, params.TenantID, params.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.CronExpression != nil {
+		job.CronExpression = *params.CronExpression
+	}
+	if params.Timezone != nil {
+		job.Timezone = *params.Timezone
+		if job.Timezone == "" {
+			job.Timezone = "UTC"
+		}
+	}
+	if params.Parameters != nil {
+		job.Parameters = *params.Parameters
+	}
+	if params.IsEnabled != nil {
+		job.IsEnabled = *params.IsEnabled
+	}
+	if err := s.schedule(job, time.Now()); err != nil {
+		return nil, err
+	}
+	job.UpdatedAt = time.Now()
+
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to update scheduled job: %w", err)
+	}
+
+	s.createAuditLog(ctx, job.TenantID, params.UpdatedBy, job.ID, models.AuditUpdate,
+		fmt.Sprintf("Updated %s job (%s %s, enabled: %t)", job.JobType, job.CronExpression, job.Timezone, job.IsEnabled))
+
+	return job, nil
+}
+
+// DeleteJob deletes a scheduled job and its run history
+func (s *SchedulerService) DeleteJob(ctx context.Context, tenantID, jobID, deletedBy uuid.UUID) error {
+	job, err := s.GetJob(ctx, tenantID, jobID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.jobRepo.Delete(ctx, jobID); err != nil {
+		return fmt.Errorf("failed to delete scheduled job: %w", err)
+	}
+
+	s.createAuditLog(ctx, tenantID, deletedBy, jobID, models.AuditDelete,
+		fmt.Sprintf("Deleted %s job", job.JobType))
+
+	return nil
+}
+
+// ListRuns lists a scheduled job's runs, newest first
+func (s *SchedulerService) ListRuns(ctx context.Context, tenantID, jobID uuid.UUID, params repositories.ListParams) ([]models.ScheduledJobRun, int64, error) {
+	if _, err := s.GetJob(ctx, tenantID, jobID); err != nil {
+		return nil, 0, err
+	}
+	return s.jobRepo.ListRuns(ctx, jobID, params)
+}
+
+// RunScheduler campaigns for leadership and, while leading, queues the runs
+// of due jobs until the context is cancelled
+func (s *SchedulerService) RunScheduler(ctx context.Context) {
+	ctx = startWorker(ctx, "scheduler", s.config.PollInterval)
+	for {
+		s.lead(ctx)
+		// Standing by: the leader's polls are reported from its enqueue loop
+		reportPoll(ctx, nil)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.config.PollInterval):
+		}
+	}
+}
+
+// EnqueueDue queues a run for every due job and returns how many were queued.
+// A job that missed several slots (e.g. while no scheduler was running) gets
+// one run for the oldest, then continues from now.
+func (s *SchedulerService) EnqueueDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	jobs, err := s.jobRepo.ListDue(ctx, now, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	enqueued := 0
+	var lastErr error
+	for i := range jobs {
+		job := &jobs[i]
+
+		var nextRunAt *time.Time
+		if schedule, err := ParseCronSchedule(job.CronExpression, job.Timezone); err == nil {
+			if next := schedule.Next(now); !next.IsZero() {
+				nextRunAt = &next
+			}
+		}
+
+		run := &models.ScheduledJobRun{
+			ID:             uuid.New(),
+			TenantID:       job.TenantID,
+			ScheduledJobID: job.ID,
+			JobType:        job.JobType,
+			ScheduledFor:   *job.NextRunAt,
+			Status:         models.ScheduledJobRunQueued,
+			CreatedAt:      now,
+		}
+
+		queued, err := s.jobRepo.Enqueue(ctx, run, nextRunAt)
+		if err != nil {
+			// Log but continue - the job stays due and is retried next poll
+			lastErr = err
+			continue
+		}
+		if queued {
+			tenantJobsEnqueued.Add(job.JobType, 1)
+			enqueued++
+		}
+	}
+
+	return enqueued, lastErr
+}
+
+// RunWorker executes queued runs until the context is cancelled
+func (s *SchedulerService) RunWorker(ctx context.Context) {
+	ctx = startWorker(ctx, "scheduled_job_runs", s.config.PollInterval)
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := s.ProcessRuns(ctx)
+		// Log errors but keep running - the next tick retries
+		reportPoll(ctx, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessRuns claims and executes every due run once and returns how many ran
+func (s *SchedulerService) ProcessRuns(ctx context.Context) (int, error) {
+	now := time.Now()
+	runs, err := s.jobRepo.ListDueRuns(ctx, now, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	executed := 0
+	for i := range runs {
+		run := &runs[i]
+
+		claimed, err := s.jobRepo.ClaimRun(ctx, run.ID, now, now.Add(s.config.RunLease))
+		if err != nil || !claimed {
+			continue
+		}
+		run.Attempts++
+
+		s.execute(ctx, run)
+		executed++
+	}
+
+	return executed, nil
+}
+
+// Helper methods
+
+// lead holds leadership for as long as the lease can be refreshed. Without a
+// locker this process is always the leader.
+func (s *SchedulerService) lead(ctx context.Context) {
+	if s.locker == nil {
+		s.enqueueLoop(ctx)
+		return
+	}
+
+	lease, acquired, err := s.locker.TryLock(ctx, s.config.LeaderKey, s.config.LeaderTTL)
+	if err != nil || !acquired {
+		return
+	}
+	defer lease.Release(context.Background())
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(s.config.LeaderTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-leaderCtx.Done():
+				return
+			case <-ticker.C:
+				// Step down on any refresh failure; another scheduler takes over
+				// once the lease expires
+				if err := lease.Refresh(leaderCtx, s.config.LeaderTTL); err != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	s.enqueueLoop(leaderCtx)
+}
+
+func (s *SchedulerService) enqueueLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := s.EnqueueDue(ctx)
+		// Log errors but keep running - the next tick retries
+		reportPoll(ctx, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *SchedulerService) execute(ctx context.Context, run *models.ScheduledJobRun) {
+	s.mu.RLock()
+	handler, ok := s.handlers[run.JobType]
+	s.mu.RUnlock()
+
+	var runErr error
+	switch {
+	case !ok:
+		runErr = fmt.Errorf("no handler registered for %s jobs", run.JobType)
+	case run.Attempts > s.config.MaxAttempts:
+		runErr = fmt.Errorf("gave up after %d interrupted attempts", s.config.MaxAttempts)
+	default:
+		job, err := s.jobRepo.GetByID(ctx, run.ScheduledJobID)
+		if err != nil {
+			runErr = fmt.Errorf("failed to load scheduled job: %w", err)
+			break
+		}
+
+		// Stop before the lease runs out and another worker picks the run up
+		runCtx, cancel := context.WithTimeout(ctx, s.config.RunLease)
+		runErr = handler(runCtx, run.TenantID, job.Parameters)
+		cancel()
+	}
+
+	completedAt := time.Now()
+	run.CompletedAt = &completedAt
+	run.Status = models.ScheduledJobRunSucceeded
+	run.Error = ""
+	tenantJobRuns.Add(run.JobType, 1)
+	if runErr != nil {
+		run.Status = models.ScheduledJobRunFailed
+		run.Error = runErr.Error()
+		tenantJobFailures.Add(run.JobType, 1)
+	}
+
+	if err := s.jobRepo.FinishRun(ctx, run); err != nil {
+		// Log but continue - the lease expires and the run is retried
+	}
+}
+
+// schedule validates the job's cron expression and sets its next run from now
+func (s *SchedulerService) schedule(job *models.ScheduledJob, now time.Time) error {
+	schedule, err := ParseCronSchedule(job.CronExpression, job.Timezone)
+	if err != nil {
+		return err
+	}
+
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return fmt.Errorf("%w: it never fires", ErrInvalidCronExpression)
+	}
+
+	job.NextRunAt = nil
+	if job.IsEnabled {
+		job.NextRunAt = &next
+	}
+	return nil
+}
+
+func (s *SchedulerService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "scheduled_job",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+func isScheduledJobType(jobType string) bool {
+	for _, known := range ScheduledJobTypes {
+		if known == jobType {
+			return true
+		}
+	}
+	return false
+}
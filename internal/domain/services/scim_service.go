@@ -0,0 +1,538 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSCIMUserNotFound    = errors.New("SCIM user not found")
+	ErrSCIMGroupNotFound   = errors.New("SCIM group not found")
+	ErrSCIMUserNameChanged = errors.New("userName cannot be changed")
+	ErrSCIMInvalidGroup    = errors.New("built-in groups cannot be created or deleted")
+)
+
+// SCIMService provisions users and role memberships for identity providers.
+// SCIM groups map onto the built-in roles and the tenant's custom roles.
+type SCIMService struct {
+	userRepo  repositories.UserRepository
+	roleRepo  repositories.RoleRepository
+	auditRepo repositories.AuditLogRepository
+
+	userService  *UserService
+	roleService  *RoleService
+	cacheService CacheService
+}
+
+// NewSCIMService creates a new SCIM provisioning service
+func NewSCIMService(
+	userRepo repositories.UserRepository,
+	roleRepo repositories.RoleRepository,
+	auditRepo repositories.AuditLogRepository,
+	userService *UserService,
+	roleService *RoleService,
+	cacheService CacheService,
+) *SCIMService {
+	return &SCIMService{
+		userRepo:     userRepo,
+		roleRepo:     roleRepo,
+		auditRepo:    auditRepo,
+		userService:  userService,
+		roleService:  roleService,
+		cacheService: cacheService,
+	}
+}
+
+// SCIMUserAttributes are the user attributes an identity provider manages.
+// Nil fields are left unchanged on update.
+type SCIMUserAttributes struct {
+	UserName   string           `json:"user_name"`
+	ExternalID *string          `json:"external_id,omitempty"`
+	FirstName  *string          `json:"first_name,omitempty"`
+	LastName   *string          `json:"last_name,omitempty"`
+	Department *string          `json:"department,omitempty"`
+	JobTitle   *string          `json:"job_title,omitempty"`
+	Active     *bool            `json:"active,omitempty"`
+	Role       *models.UserRole `json:"role,omitempty"`
+}
+
+// SCIMGroup is a role exposed as a SCIM group
+type SCIMGroup struct {
+	ID          string        `json:"id"`
+	DisplayName string        `json:"display_name"`
+	BuiltIn     bool          `json:"built_in"`
+	Members     []models.User `json:"members,omitempty"`
+	CreatedAt   *time.Time    `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time    `json:"updated_at,omitempty"`
+}
+
+// SCIMUserFilter narrows a user listing to an exact attribute match
+type SCIMUserFilter struct {
+	UserName   string `json:"user_name,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+}
+
+// scimBuiltInGroups are the built-in roles exposed as groups, by group ID
+var scimBuiltInGroups = []models.UserRole{
+	models.UserRoleAdmin,
+	models.UserRoleManager,
+	models.UserRoleUser,
+	models.UserRoleViewer,
+	models.UserRoleAccountant,
+	models.UserRoleCompliance,
+}
+
+// ListUsers lists the tenant's users, optionally filtered by userName or externalId
+func (s *SCIMService) ListUsers(ctx context.Context, tenantID uuid.UUID, filter SCIMUserFilter, params repositories.ListParams) ([]models.User, int64, error) {
+	var (
+		user *models.User
+		err  error
+	)
+	switch {
+	case filter.UserName != "":
+		user, err = s.userRepo.GetByEmail(ctx, tenantID, strings.ToLower(filter.UserName))
+	case filter.ExternalID != "":
+		user, err = s.userRepo.GetByExternalID(ctx, tenantID, filter.ExternalID)
+	default:
+		params.SortBy = "created_at"
+		return s.userRepo.ListByTenant(ctx, tenantID, params)
+	}
+
+	if err != nil {
+		return []models.User{}, 0, nil
+	}
+	return []models.User{*user}, 1, nil
+}
+
+// GetUser returns a user belonging to the tenant
+func (s *SCIMService) GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrSCIMUserNotFound
+	}
+	return user, nil
+}
+
+// CreateUser provisions a user. Provisioned users sign in through the identity
+// provider, so they get a random password they never see.
+func (s *SCIMService) CreateUser(ctx context.Context, tenantID, actorID uuid.UUID, attrs SCIMUserAttributes) (*models.User, error) {
+	password, err := generateProvisioningPassword()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	params := CreateUserParams{
+		TenantID:  tenantID,
+		Email:     strings.ToLower(strings.TrimSpace(attrs.UserName)),
+		Password:  password,
+		Role:      models.UserRoleUser,
+		CreatedBy: actorID,
+	}
+	if attrs.FirstName != nil {
+		params.FirstName = *attrs.FirstName
+	}
+	if attrs.LastName != nil {
+		params.LastName = *attrs.LastName
+	}
+	if attrs.Department != nil {
+		params.Department = *attrs.Department
+	}
+	if attrs.JobTitle != nil {
+		params.JobTitle = *attrs.JobTitle
+	}
+	if attrs.Role != nil {
+		params.Role = *attrs.Role
+	}
+
+	user, err := s.userService.CreateUser(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// CreateUser covers the common fields; apply the rest as an update
+	attrs.FirstName, attrs.LastName, attrs.Department, attrs.JobTitle, attrs.Role = nil, nil, nil, nil, nil
+	if attrs.ExternalID != nil || (attrs.Active != nil && !*attrs.Active) {
+		if user, err = s.applyUserAttributes(ctx, user, actorID, attrs); err != nil {
+			return nil, err
+		}
+	}
+
+	s.createAuditLog(ctx, tenantID, actorID, user.ID, models.AuditCreate, "User provisioned via SCIM")
+
+	return user, nil
+}
+
+// UpdateUser applies identity provider changes to a user. Deactivation
+// disables the account rather than deleting it.
+func (s *SCIMService) UpdateUser(ctx context.Context, tenantID, userID, actorID uuid.UUID, attrs SCIMUserAttributes) (*models.User, error) {
+	user, err := s.GetUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attrs.UserName != "" && !strings.EqualFold(attrs.UserName, user.Email) {
+		return nil, ErrSCIMUserNameChanged
+	}
+
+	user, err = s.applyUserAttributes(ctx, user, actorID, attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, tenantID, actorID, user.ID, models.AuditUpdate, "User updated via SCIM")
+
+	return user, nil
+}
+
+// DeprovisionUser deactivates a user removed from the identity provider.
+// Their documents and audit history are kept.
+func (s *SCIMService) DeprovisionUser(ctx context.Context, tenantID, userID, actorID uuid.UUID) error {
+	user, err := s.GetUser(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+	return s.userService.DeactivateUser(ctx, user.ID, actorID)
+}
+
+// ListGroups returns the built-in roles followed by the tenant's custom roles
+func (s *SCIMService) ListGroups(ctx context.Context, tenantID uuid.UUID, withMembers bool) ([]SCIMGroup, error) {
+	groups := make([]SCIMGroup, 0, len(scimBuiltInGroups))
+	for _, role := range scimBuiltInGroups {
+		group := SCIMGroup{ID: string(role), DisplayName: string(role), BuiltIn: true}
+		if withMembers {
+			members, err := s.userRepo.ListByRole(ctx, tenantID, role)
+			if err != nil {
+				return nil, err
+			}
+			group.Members = members
+		}
+		groups = append(groups, group)
+	}
+
+	roles, err := s.roleRepo.ListByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		group := customRoleGroup(&roles[i])
+		if withMembers {
+			members, err := s.userRepo.ListByCustomRole(ctx, roles[i].ID)
+			if err != nil {
+				return nil, err
+			}
+			group.Members = members
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// GetGroup returns a group with its members
+func (s *SCIMService) GetGroup(ctx context.Context, tenantID uuid.UUID, groupID string) (*SCIMGroup, error) {
+	if role, ok := builtInGroupRole(groupID); ok {
+		members, err := s.userRepo.ListByRole(ctx, tenantID, role)
+		if err != nil {
+			return nil, err
+		}
+		return &SCIMGroup{ID: groupID, DisplayName: groupID, BuiltIn: true, Members: members}, nil
+	}
+
+	role, err := s.getCustomRole(ctx, tenantID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.userRepo.ListByCustomRole(ctx, role.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	group := customRoleGroup(role)
+	group.Members = members
+	return &group, nil
+}
+
+// CreateGroup creates a custom role with no permissions; admins grant
+// permissions in the application
+func (s *SCIMService) CreateGroup(ctx context.Context, tenantID, actorID uuid.UUID, displayName string) (*SCIMGroup, error) {
+	if _, ok := builtInGroupRole(displayName); ok {
+		return nil, ErrSCIMInvalidGroup
+	}
+
+	role, err := s.roleService.CreateRole(ctx, RoleParams{
+		TenantID: tenantID,
+		Name:     displayName,
+		ActorID:  actorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	group := customRoleGroup(role)
+	return &group, nil
+}
+
+// DeleteGroup deletes a custom role; it fails while users are still assigned
+func (s *SCIMService) DeleteGroup(ctx context.Context, tenantID, actorID uuid.UUID, groupID string) error {
+	if _, ok := builtInGroupRole(groupID); ok {
+		return ErrSCIMInvalidGroup
+	}
+
+	role, err := s.getCustomRole(ctx, tenantID, groupID)
+	if err != nil {
+		return err
+	}
+	return s.roleService.DeleteRole(ctx, role.ID, tenantID, actorID)
+}
+
+// AddGroupMembers assigns the group's role to users
+func (s *SCIMService) AddGroupMembers(ctx context.Context, tenantID, actorID uuid.UUID, groupID string, userIDs []uuid.UUID) error {
+	if role, ok := builtInGroupRole(groupID); ok {
+		for _, userID := range userIDs {
+			if err := s.setBuiltInRole(ctx, tenantID, userID, actorID, role); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	role, err := s.getCustomRole(ctx, tenantID, groupID)
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		if _, err := s.roleService.AssignRole(ctx, userID, role.ID, tenantID, actorID); err != nil {
+			return mapSCIMRoleError(err)
+		}
+	}
+	return nil
+}
+
+// RemoveGroupMembers takes the group's role away from users. Users removed
+// from a built-in group drop to the least privileged viewer role.
+func (s *SCIMService) RemoveGroupMembers(ctx context.Context, tenantID, actorID uuid.UUID, groupID string, userIDs []uuid.UUID) error {
+	if role, ok := builtInGroupRole(groupID); ok {
+		for _, userID := range userIDs {
+			user, err := s.GetUser(ctx, tenantID, userID)
+			if err != nil {
+				return err
+			}
+			if user.Role != role || role == models.UserRoleViewer {
+				continue
+			}
+			if err := s.setBuiltInRole(ctx, tenantID, userID, actorID, models.UserRoleViewer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	role, err := s.getCustomRole(ctx, tenantID, groupID)
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		if _, err := s.roleService.UnassignRole(ctx, userID, role.ID, tenantID, actorID); err != nil {
+			if errors.Is(err, ErrRoleNotFound) {
+				continue // Not a member
+			}
+			return mapSCIMRoleError(err)
+		}
+	}
+	return nil
+}
+
+// ReplaceGroupMembers makes the given users the group's complete membership
+func (s *SCIMService) ReplaceGroupMembers(ctx context.Context, tenantID, actorID uuid.UUID, groupID string, userIDs []uuid.UUID) error {
+	group, err := s.GetGroup(ctx, tenantID, groupID)
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[uuid.UUID]bool, len(userIDs))
+	for _, userID := range userIDs {
+		keep[userID] = true
+	}
+
+	var removed []uuid.UUID
+	for _, member := range group.Members {
+		if !keep[member.ID] {
+			removed = append(removed, member.ID)
+		}
+	}
+
+	if err := s.RemoveGroupMembers(ctx, tenantID, actorID, groupID, removed); err != nil {
+		return err
+	}
+	return s.AddGroupMembers(ctx, tenantID, actorID, groupID, userIDs)
+}
+
+// Helper methods
+
+func (s *SCIMService) applyUserAttributes(ctx context.Context, user *models.User, actorID uuid.UUID, attrs SCIMUserAttributes) (*models.User, error) {
+	if attrs.ExternalID != nil {
+		user.ExternalID = *attrs.ExternalID
+	}
+	if attrs.FirstName != nil {
+		user.FirstName = *attrs.FirstName
+	}
+	if attrs.LastName != nil {
+		user.LastName = *attrs.LastName
+	}
+	if attrs.Department != nil {
+		user.Department = *attrs.Department
+	}
+	if attrs.JobTitle != nil {
+		user.JobTitle = *attrs.JobTitle
+	}
+	if attrs.Role != nil {
+		if !s.userService.isValidRole(*attrs.Role) {
+			return nil, ErrInvalidRole
+		}
+		user.Role = *attrs.Role
+	}
+
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	s.invalidateUserCache(ctx, user.ID)
+
+	if attrs.Active != nil && *attrs.Active != user.IsActive {
+		var err error
+		if *attrs.Active {
+			err = s.userService.ReactivateUser(ctx, user.ID, actorID)
+		} else {
+			err = s.userService.DeactivateUser(ctx, user.ID, actorID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		user.IsActive = *attrs.Active
+	}
+
+	return user, nil
+}
+
+func (s *SCIMService) setBuiltInRole(ctx context.Context, tenantID, userID, actorID uuid.UUID, role models.UserRole) error {
+	user, err := s.GetUser(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if user.Role == role {
+		return nil
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+	s.invalidateUserCache(ctx, user.ID)
+
+	s.createAuditLog(ctx, tenantID, actorID, user.ID, models.AuditUpdate, "Role set via SCIM: "+string(role))
+
+	return nil
+}
+
+func (s *SCIMService) getCustomRole(ctx context.Context, tenantID uuid.UUID, groupID string) (*models.Role, error) {
+	roleID, err := uuid.Parse(groupID)
+	if err != nil {
+		return nil, ErrSCIMGroupNotFound
+	}
+	role, err := s.roleService.GetRole(ctx, roleID, tenantID)
+	if err != nil {
+		return nil, ErrSCIMGroupNotFound
+	}
+	return role, nil
+}
+
+func (s *SCIMService) invalidateUserCache(ctx context.Context, userID uuid.UUID) {
+	if s.cacheService == nil {
+		return
+	}
+	if err := s.cacheService.Delete(ctx, fmt.Sprintf(UserCacheKeyPattern, userID.String())); err != nil {
+		// Log but don't fail - the cached profile expires on its own
+	}
+}
+
+func (s *SCIMService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "user",
+		Details:      models.JSONB{"message": details, "source": "scim"},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+func builtInGroupRole(groupID string) (models.UserRole, bool) {
+	for _, role := range scimBuiltInGroups {
+		if string(role) == groupID {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+func customRoleGroup(role *models.Role) SCIMGroup {
+	createdAt, updatedAt := role.CreatedAt, role.UpdatedAt
+	return SCIMGroup{
+		ID:          role.ID.String(),
+		DisplayName: role.Name,
+		CreatedAt:   &createdAt,
+		UpdatedAt:   &updatedAt,
+	}
+}
+
+func mapSCIMRoleError(err error) error {
+	if errors.Is(err, ErrUserNotFound) {
+		return ErrSCIMUserNotFound
+	}
+	return err
+}
+
+// generateProvisioningPassword returns a random password that satisfies the
+// password policy; SCIM users authenticate through their identity provider
+func generateProvisioningPassword() (string, error) {
+	const (
+		lower   = "abcdefghijkmnopqrstuvwxyz"
+		upper   = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+		digits  = "23456789"
+		special = "!@#$%^&*"
+	)
+	sets := []string{lower, upper, digits, special}
+	all := lower + upper + digits + special
+
+	password := make([]byte, 32)
+	for i := range password {
+		charset := all
+		if i < len(sets) {
+			charset = sets[i]
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = charset[n.Int64()]
+	}
+	return string(password), nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidSuggestionType = errors.New("invalid suggestion type")
+
+// SuggestionType is a kind of typeahead suggestion
+type SuggestionType string
+
+const (
+	SuggestDocuments SuggestionType = "documents" // Document titles
+	SuggestTags      SuggestionType = "tags"
+	SuggestVendors   SuggestionType = "vendors"
+	SuggestFolders   SuggestionType = "folders"
+)
+
+// SuggestionTypes are all suggestion types, in the order they are looked up
+var SuggestionTypes = []SuggestionType{SuggestDocuments, SuggestTags, SuggestVendors, SuggestFolders}
+
+// SearchSuggestionConfig holds configuration for typeahead suggestions
+type SearchSuggestionConfig struct {
+	DefaultLimit   int           // Suggestions per type; defaults to 5
+	MaxLimit       int           // Defaults to 20
+	MinPrefixRunes int           // Shorter prefixes get no suggestions; defaults to 2
+	CacheTTL       time.Duration // How long a user's suggestions are cached; defaults to a minute
+}
+
+// SearchSuggestions are typeahead suggestions grouped by type. Only the
+// requested types are present.
+type SearchSuggestions struct {
+	Query  string                                       `json:"query"`
+	Groups map[SuggestionType][]repositories.Suggestion `json:"groups"`
+}
+
+// SearchSuggestionService serves prefix suggestions for typeahead from
+// trigram-indexed lookups, caching each user's results briefly in Redis so
+// repeated keystrokes don't reach the database. Suggestions follow the same
+// visibility rules as document listing.
+type SearchSuggestionService struct {
+	documentService *DocumentService
+	suggestionRepo  repositories.SearchSuggestionRepository
+	cacheService    CacheService
+	config          SearchSuggestionConfig
+}
+
+// NewSearchSuggestionService creates a new search suggestion service
+func NewSearchSuggestionService(
+	documentService *DocumentService,
+	suggestionRepo repositories.SearchSuggestionRepository,
+	cacheService CacheService,
+	config SearchSuggestionConfig,
+) *SearchSuggestionService {
+	if config.DefaultLimit <= 0 {
+		config.DefaultLimit = 5
+	}
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = 20
+	}
+	if config.MinPrefixRunes <= 0 {
+		config.MinPrefixRunes = 2
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = time.Minute
+	}
+
+	return &SearchSuggestionService{
+		documentService: documentService,
+		suggestionRepo:  suggestionRepo,
+		cacheService:    cacheService,
+		config:          config,
+	}
+}
+
+// ParseSuggestionTypes parses a comma-separated list of suggestion types.
+// An empty list means all types.
+func ParseSuggestionTypes(value string) ([]SuggestionType, error) {
+	if strings.TrimSpace(value) == "" {
+		return SuggestionTypes, nil
+	}
+
+	requested := make(map[SuggestionType]bool)
+	for _, name := range strings.Split(value, ",") {
+		suggestionType := SuggestionType(strings.ToLower(strings.TrimSpace(name)))
+		if !isSuggestionType(suggestionType) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSuggestionType, name)
+		}
+		requested[suggestionType] = true
+	}
+
+	// Keep the lookup order stable so equal requests share a cache entry
+	types := make([]SuggestionType, 0, len(requested))
+	for _, suggestionType := range SuggestionTypes {
+		if requested[suggestionType] {
+			types = append(types, suggestionType)
+		}
+	}
+	return types, nil
+}
+
+// Suggest returns up to limit suggestions of each type for the prefix.
+// Prefixes shorter than the configured minimum get empty groups.
+func (s *SearchSuggestionService) Suggest(ctx context.Context, tenantID, userID uuid.UUID, prefix string, types []SuggestionType, limit int) (*SearchSuggestions, error) {
+	prefix = strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+	if len(types) == 0 {
+		types = SuggestionTypes
+	}
+	if limit <= 0 {
+		limit = s.config.DefaultLimit
+	}
+	limit = min(limit, s.config.MaxLimit)
+
+	result := &SearchSuggestions{Query: prefix, Groups: make(map[SuggestionType][]repositories.Suggestion, len(types))}
+	for _, suggestionType := range types {
+		result.Groups[suggestionType] = []repositories.Suggestion{}
+	}
+	if utf8.RuneCountInString(prefix) < s.config.MinPrefixRunes {
+		return result, nil
+	}
+
+	cacheKey := s.cacheKey(tenantID, userID, prefix, types, limit)
+	if s.cacheService != nil {
+		if cached, err := s.cacheService.Get(ctx, cacheKey); err == nil {
+			var suggestions SearchSuggestions
+			if json.Unmarshal([]byte(cached), &suggestions) == nil {
+				return &suggestions, nil
+			}
+		}
+	}
+
+	query := repositories.SuggestionQuery{Prefix: prefix, Limit: limit}
+	if needsVisibility(types) {
+		denied, viewerID, err := s.documentService.documentVisibility(ctx, tenantID, userID)
+		if err != nil {
+			return nil, err
+		}
+		query.ExcludeFolderIDs = denied
+		query.ViewerID = viewerID
+	}
+
+	for _, suggestionType := range types {
+		suggestions, err := s.lookup(ctx, tenantID, suggestionType, query)
+		if err != nil {
+			return nil, err
+		}
+		if suggestions != nil {
+			result.Groups[suggestionType] = suggestions
+		}
+	}
+
+	if s.cacheService != nil {
+		if data, err := json.Marshal(result); err == nil {
+			s.cacheService.Set(ctx, cacheKey, string(data), s.config.CacheTTL)
+		}
+	}
+
+	return result, nil
+}
+
+// Helper methods
+
+func (s *SearchSuggestionService) lookup(ctx context.Context, tenantID uuid.UUID, suggestionType SuggestionType, query repositories.SuggestionQuery) ([]repositories.Suggestion, error) {
+	switch suggestionType {
+	case SuggestDocuments:
+		return s.suggestionRepo.DocumentTitles(ctx, tenantID, query)
+	case SuggestTags:
+		return s.suggestionRepo.Tags(ctx, tenantID, query)
+	case SuggestVendors:
+		return s.suggestionRepo.Vendors(ctx, tenantID, query)
+	case SuggestFolders:
+		return s.suggestionRepo.Folders(ctx, tenantID, query)
+	}
+	return nil, ErrInvalidSuggestionType
+}
+
+// cacheKey is per user, since what a user may see depends on folder ACLs
+// and private documents
+func (s *SearchSuggestionService) cacheKey(tenantID, userID uuid.UUID, prefix string, types []SuggestionType, limit int) string {
+	names := make([]string, len(types))
+	for i, suggestionType := range types {
+		names[i] = string(suggestionType)
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d", prefix, strings.Join(names, ","), limit)))
+	return fmt.Sprintf(SearchSuggestKeyPattern, tenantID.String(), userID.String(), hex.EncodeToString(sum[:16]))
+}
+
+// needsVisibility reports whether any of the types depend on document or
+// folder access; tags are visible to everyone in the tenant
+func needsVisibility(types []SuggestionType) bool {
+	for _, suggestionType := range types {
+		if suggestionType != SuggestTags {
+			return true
+		}
+	}
+	return false
+}
+
+func isSuggestionType(suggestionType SuggestionType) bool {
+	for _, known := range SuggestionTypes {
+		if suggestionType == known {
+			return true
+		}
+	}
+	return false
+}
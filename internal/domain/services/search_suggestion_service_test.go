@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tagSuggestions serves tag suggestions and counts lookups
+type tagSuggestions struct {
+	repositories.SearchSuggestionRepository
+	lookups int
+	queries []repositories.SuggestionQuery
+}
+
+func (r *tagSuggestions) Tags(ctx context.Context, tenantID uuid.UUID, query repositories.SuggestionQuery) ([]repositories.Suggestion, error) {
+	r.lookups++
+	r.queries = append(r.queries, query)
+	return []repositories.Suggestion{{Text: "invoices", Count: 12}}, nil
+}
+
+func TestParseSuggestionTypes(t *testing.T) {
+	types, err := ParseSuggestionTypes("")
+	require.NoError(t, err)
+	assert.Equal(t, SuggestionTypes, types)
+
+	// Normalized and put in lookup order
+	types, err = ParseSuggestionTypes(" Folders,tags,folders ")
+	require.NoError(t, err)
+	assert.Equal(t, []SuggestionType{SuggestTags, SuggestFolders}, types)
+
+	_, err = ParseSuggestionTypes("tags,users")
+	assert.ErrorIs(t, err, ErrInvalidSuggestionType)
+}
+
+func TestSearchSuggestionService_Suggest(t *testing.T) {
+	repo := &tagSuggestions{}
+	service := NewSearchSuggestionService(nil, repo, newMemoryCache(), SearchSuggestionConfig{})
+	ctx := context.Background()
+	tenantID, userID := uuid.New(), uuid.New()
+
+	// Too short a prefix gets empty groups without a lookup
+	result, err := service.Suggest(ctx, tenantID, userID, " i ", []SuggestionType{SuggestTags}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, result.Groups[SuggestTags])
+	assert.NotNil(t, result.Groups[SuggestTags])
+	assert.Zero(t, repo.lookups)
+
+	result, err = service.Suggest(ctx, tenantID, userID, "  INV  ", []SuggestionType{SuggestTags}, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "inv", result.Query)
+	require.Len(t, result.Groups[SuggestTags], 1)
+	assert.Equal(t, "invoices", result.Groups[SuggestTags][0].Text)
+	assert.NotContains(t, result.Groups, SuggestDocuments)
+
+	require.Len(t, repo.queries, 1)
+	assert.Equal(t, "inv", repo.queries[0].Prefix)
+	assert.Equal(t, 20, repo.queries[0].Limit)
+
+	// The next keystroke with the same prefix is served from the cache
+	_, err = service.Suggest(ctx, tenantID, userID, "inv", []SuggestionType{SuggestTags}, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.lookups)
+
+	// but not for another user
+	_, err = service.Suggest(ctx, tenantID, uuid.New(), "inv", []SuggestionType{SuggestTags}, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.lookups)
+}
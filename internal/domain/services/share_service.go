@@ -0,0 +1,508 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrShareNotFound         = errors.New("share not found")
+	ErrShareExpired          = errors.New("share link has expired")
+	ErrShareDownloadLimit    = errors.New("share download limit reached")
+	ErrSharePasswordRequired = errors.New("share password required")
+	ErrSharePasswordInvalid  = errors.New("invalid share password")
+	ErrSharePasswordTooLong  = errors.New("share password must be at most 72 bytes")
+	ErrShareInvalidExpiry    = errors.New("share expiry must be in the future")
+	ErrShareAccessRevoked    = errors.New("share creator no longer has access to the document")
+)
+
+// Share access actions recorded in the access log
+const (
+	ShareActionView     = "view"
+	ShareActionDownload = "download"
+)
+
+// ShareActivityNotifier notifies share owners when their links are used
+type ShareActivityNotifier interface {
+	SendShareActivity(ctx context.Context, share *models.Share, activity string) error
+}
+
+// DocumentAccessChecker enforces private document and folder ACLs on shares
+type DocumentAccessChecker interface {
+	CheckDocumentAccess(ctx context.Context, document *models.Document, userID uuid.UUID, required models.DocumentPermission) error
+}
+
+// ShareNetworkGuard enforces tenant network restrictions on public share links
+type ShareNetworkGuard interface {
+	CheckShareAccess(ctx context.Context, share *models.Share, ipAddress, country string) error
+}
+
+// ShareServiceConfig holds configuration for the share service
+type ShareServiceConfig struct {
+	DownloadURLExpiry time.Duration
+	NotifyOnAccess    bool
+	RequiredChecks    []models.DocumentCheck // Must pass before a document can be shared or downloaded
+}
+
+// ShareService manages public share links and their access log
+type ShareService struct {
+	shareRepo       repositories.ShareRepository
+	shareAccessRepo repositories.ShareAccessRepository
+	docRepo         repositories.DocumentRepository
+	auditRepo       repositories.AuditLogRepository
+	analyticsRepo   repositories.AnalyticsRepository
+
+	storageService StorageService
+	accessChecker  DocumentAccessChecker
+	networkGuard   ShareNetworkGuard
+	abuseGuard     AttemptGuard
+	notifier       ShareActivityNotifier
+	mailer         EmailService
+	events         EventPublisher
+	config         ShareServiceConfig
+}
+
+// NewShareService creates a new share service instance
+func NewShareService(
+	shareRepo repositories.ShareRepository,
+	shareAccessRepo repositories.ShareAccessRepository,
+	docRepo repositories.DocumentRepository,
+	auditRepo repositories.AuditLogRepository,
+	analyticsRepo repositories.AnalyticsRepository,
+	storageService StorageService,
+	accessChecker DocumentAccessChecker,
+	networkGuard ShareNetworkGuard,
+	abuseGuard AttemptGuard,
+	notifier ShareActivityNotifier,
+	mailer EmailService,
+	events EventPublisher,
+	config ShareServiceConfig,
+) *ShareService {
+	if config.DownloadURLExpiry == 0 {
+		config.DownloadURLExpiry = 15 * time.Minute
+	}
+
+	return &ShareService{
+		shareRepo:       shareRepo,
+		shareAccessRepo: shareAccessRepo,
+		docRepo:         docRepo,
+		auditRepo:       auditRepo,
+		analyticsRepo:   analyticsRepo,
+		storageService:  storageService,
+		accessChecker:   accessChecker,
+		networkGuard:    networkGuard,
+		abuseGuard:      abuseGuard,
+		notifier:        notifier,
+		mailer:          mailer,
+		events:          events,
+		config:          config,
+	}
+}
+
+// CreateShareParams contains parameters for creating a share link
+type CreateShareParams struct {
+	TenantID     uuid.UUID  `json:"tenant_id"`
+	DocumentID   uuid.UUID  `json:"document_id"`
+	CreatedBy    uuid.UUID  `json:"created_by"`
+	Password     string     `json:"-"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	MaxDownloads int        `json:"max_downloads"`
+
+	// Recipients are emailed the link when email is configured
+	Recipients []string `json:"recipients,omitempty"`
+	SenderName string   `json:"sender_name,omitempty"`
+	Message    string   `json:"message,omitempty"`
+}
+
+// AccessShareParams describes a request to open a public share link
+type AccessShareParams struct {
+	Token        string `json:"token"`
+	Password     string `json:"-"`
+	CaptchaToken string `json:"-"`
+	Action       string `json:"action"`
+	IPAddress    string `json:"ip_address"`
+	Country      string `json:"country,omitempty"`
+	UserAgent    string `json:"user_agent"`
+}
+
+// ShareAccessResult is returned when a share link is successfully opened
+type ShareAccessResult struct {
+	Share       *models.Share    `json:"share"`
+	Document    *models.Document `json:"document"`
+	DownloadURL string           `json:"download_url,omitempty"`
+}
+
+// CreateShare creates a public share link for a document
+func (s *ShareService) CreateShare(ctx context.Context, params CreateShareParams) (*models.Share, error) {
+	document, err := s.docRepo.GetForTenant(ctx, params.TenantID, params.DocumentID)
+	if err != nil {
+		return nil, ErrDocumentNotFound
+	}
+	if err := s.checkAccess(ctx, document, params.CreatedBy, models.DocPermWrite); err != nil {
+		return nil, err
+	}
+	if err := checkDownloadGate(document, s.config.RequiredChecks); err != nil {
+		return nil, err
+	}
+
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, ErrShareInvalidExpiry
+	}
+	if len(params.Password) > maxSharePasswordBytes {
+		return nil, ErrSharePasswordTooLong
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+
+	share := &models.Share{
+		TenantID:     params.TenantID,
+		DocumentID:   params.DocumentID,
+		CreatedBy:    params.CreatedBy,
+		Token:        token,
+		ExpiresAt:    params.ExpiresAt,
+		MaxDownloads: params.MaxDownloads,
+		IsActive:     true,
+	}
+
+	if params.Password != "" {
+		hash, err := hashSharePassword(params.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash share password: %w", err)
+		}
+		share.Password = hash
+	}
+
+	if err := s.shareRepo.Create(ctx, share); err != nil {
+		return nil, fmt.Errorf("failed to create share: %w", err)
+	}
+
+	s.analyticsRepo.UpdateDocumentShare(ctx, params.DocumentID)
+	s.createAuditLog(ctx, params.TenantID, params.CreatedBy, share.ID, models.AuditShare, "Share link created")
+
+	if s.events != nil {
+		s.events.Publish(ctx, params.TenantID, WebhookEventShareCreated, map[string]interface{}{
+			"share_id":    share.ID.String(),
+			"document_id": share.DocumentID.String(),
+			"created_by":  params.CreatedBy.String(),
+			"expires_at":  share.ExpiresAt,
+		})
+	}
+
+	if s.mailer != nil && len(params.Recipients) > 0 {
+		link := ShareLinkEmail{
+			SenderName:   params.SenderName,
+			DocumentName: documentLabel(document),
+			Token:        share.Token,
+			Message:      params.Message,
+			ExpiresAt:    share.ExpiresAt,
+		}
+		go func() {
+			for _, recipient := range params.Recipients {
+				if err := s.mailer.SendShareLink(context.Background(), params.TenantID, recipient, link); err != nil {
+					// Log but don't fail - the link was created
+				}
+			}
+		}()
+	}
+
+	return share, nil
+}
+
+// ListActiveShares returns the unexpired, unrevoked share links for a document
+func (s *ShareService) ListActiveShares(ctx context.Context, documentID, tenantID, userID uuid.UUID) ([]models.Share, error) {
+	document, err := s.docRepo.GetForTenant(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, ErrDocumentNotFound
+	}
+	if err := s.checkAccess(ctx, document, userID, models.DocPermRead); err != nil {
+		return nil, err
+	}
+
+	return s.shareRepo.ListActiveByDocument(ctx, documentID)
+}
+
+// GetShare returns a share link belonging to the tenant
+func (s *ShareService) GetShare(ctx context.Context, shareID, tenantID uuid.UUID) (*models.Share, error) {
+	share, err := s.shareRepo.GetByID(ctx, shareID)
+	if err != nil {
+		return nil, ErrShareNotFound
+	}
+	if share.TenantID != tenantID {
+		return nil, ErrShareNotFound
+	}
+	return share, nil
+}
+
+// RevokeShare deactivates a share link so it can no longer be opened
+func (s *ShareService) RevokeShare(ctx context.Context, shareID, tenantID, revokedBy uuid.UUID) error {
+	share, err := s.GetShare(ctx, shareID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if !share.IsActive {
+		return nil
+	}
+
+	if err := s.shareRepo.ExpireShare(ctx, share.ID); err != nil {
+		return fmt.Errorf("failed to revoke share: %w", err)
+	}
+
+	s.createAuditLog(ctx, tenantID, revokedBy, share.ID, models.AuditShare, "Share link revoked")
+
+	return nil
+}
+
+// GetShareStats returns aggregate access statistics for a share link. The
+// caller must be able to read the shared document.
+func (s *ShareService) GetShareStats(ctx context.Context, shareID, tenantID, userID uuid.UUID) (*repositories.ShareAccessStats, error) {
+	share, err := s.getReadableShare(ctx, shareID, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.shareAccessRepo.GetStats(ctx, share.ID)
+}
+
+// ListShareAccesses returns the access log for a share link, newest first.
+// The caller must be able to read the shared document.
+func (s *ShareService) ListShareAccesses(ctx context.Context, shareID, tenantID, userID uuid.UUID, params repositories.ListParams) ([]models.ShareAccess, int64, error) {
+	share, err := s.getReadableShare(ctx, shareID, tenantID, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.shareAccessRepo.ListByShare(ctx, share.ID, params)
+}
+
+// AccessShare opens a public share link, enforcing expiry, password and download
+// limits. Every attempt, successful or not, is recorded in the access log.
+func (s *ShareService) AccessShare(ctx context.Context, params AccessShareParams) (*ShareAccessResult, error) {
+	share, err := s.shareRepo.GetByToken(ctx, params.Token)
+	if err != nil {
+		return nil, ErrShareNotFound
+	}
+
+	if params.Action != ShareActionDownload {
+		params.Action = ShareActionView
+	}
+
+	if share.ExpiresAt != nil && time.Now().After(*share.ExpiresAt) {
+		if err := s.shareRepo.ExpireShare(ctx, share.ID); err != nil {
+			// Log but don't fail
+		}
+		s.recordAccess(ctx, share, params, ErrShareExpired)
+		return nil, ErrShareExpired
+	}
+
+	if s.abuseGuard != nil {
+		if err := s.abuseGuard.CheckRateLimit(ctx, share.TenantID, params.IPAddress); err != nil {
+			s.recordAccess(ctx, share, params, err)
+			return nil, err
+		}
+	}
+
+	if s.networkGuard != nil {
+		if err := s.networkGuard.CheckShareAccess(ctx, share, params.IPAddress, params.Country); err != nil {
+			s.recordAccess(ctx, share, params, err)
+			return nil, err
+		}
+	}
+
+	// Links stop working once their creator loses access, e.g. when the
+	// document is made private
+	if err := s.checkAccess(ctx, &share.Document, share.CreatedBy, models.DocPermRead); err != nil {
+		s.recordAccess(ctx, share, params, ErrShareAccessRevoked)
+		return nil, ErrShareAccessRevoked
+	}
+
+	if share.Password != "" {
+		if params.Password == "" {
+			s.recordAccess(ctx, share, params, ErrSharePasswordRequired)
+			return nil, ErrSharePasswordRequired
+		}
+
+		// Password guesses are delayed exponentially and may need a CAPTCHA
+		attempt := Attempt{
+			TenantID:     share.TenantID,
+			Scope:        AttemptScopeSharePassword,
+			Subject:      share.ID.String(),
+			IPAddress:    params.IPAddress,
+			CaptchaToken: params.CaptchaToken,
+		}
+		if s.abuseGuard != nil {
+			if err := s.abuseGuard.CheckAttempt(ctx, attempt); err != nil {
+				s.recordAccess(ctx, share, params, err)
+				return nil, err
+			}
+		}
+
+		if !verifySharePassword(share.Password, params.Password) {
+			if s.abuseGuard != nil {
+				s.abuseGuard.RecordFailure(ctx, attempt)
+			}
+			s.recordAccess(ctx, share, params, ErrSharePasswordInvalid)
+			return nil, ErrSharePasswordInvalid
+		}
+		if s.abuseGuard != nil {
+			s.abuseGuard.ClearFailures(ctx, attempt)
+		}
+	}
+
+	result := &ShareAccessResult{
+		Share:    share,
+		Document: &share.Document,
+	}
+
+	if params.Action == ShareActionDownload {
+		// Shared before a check failed, or under an override since removed
+		if err := checkDownloadGate(&share.Document, s.config.RequiredChecks); err != nil {
+			s.recordAccess(ctx, share, params, ErrDocumentGated)
+			return nil, err
+		}
+
+		url, err := s.storageService.GeneratePresignedURL(ctx, share.Document.StoragePath, s.config.DownloadURLExpiry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate download URL: %w", err)
+		}
+
+		// Counted in one conditional update, so concurrent downloads can't
+		// go past the limit
+		claimed, err := s.shareRepo.ClaimDownload(ctx, share.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !claimed {
+			s.recordAccess(ctx, share, params, ErrShareDownloadLimit)
+			return nil, ErrShareDownloadLimit
+		}
+		result.DownloadURL = url
+
+		s.analyticsRepo.UpdateDocumentDownload(ctx, share.DocumentID)
+	}
+
+	s.recordAccess(ctx, share, params, nil)
+
+	if s.notifier != nil && s.config.NotifyOnAccess {
+		activity := "viewed"
+		if params.Action == ShareActionDownload {
+			activity = "downloaded"
+		}
+		go func() {
+			s.notifier.SendShareActivity(context.Background(), share, activity)
+		}()
+	}
+
+	return result, nil
+}
+
+// Helper methods
+
+// getReadableShare returns a tenant's share link if the user can read its document
+func (s *ShareService) getReadableShare(ctx context.Context, shareID, tenantID, userID uuid.UUID) (*models.Share, error) {
+	share, err := s.GetShare(ctx, shareID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(ctx, &share.Document, userID, models.DocPermRead); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+func (s *ShareService) checkAccess(ctx context.Context, document *models.Document, userID uuid.UUID, required models.DocumentPermission) error {
+	if s.accessChecker == nil {
+		return nil
+	}
+	if err := s.accessChecker.CheckDocumentAccess(ctx, document, userID, required); err != nil {
+		return ErrUnauthorizedAccess
+	}
+	return nil
+}
+
+func (s *ShareService) recordAccess(ctx context.Context, share *models.Share, params AccessShareParams, accessErr error) {
+	access := &models.ShareAccess{
+		TenantID:   share.TenantID,
+		ShareID:    share.ID,
+		DocumentID: share.DocumentID,
+		Action:     params.Action,
+		Success:    accessErr == nil,
+		IPAddress:  params.IPAddress,
+		UserAgent:  params.UserAgent,
+		AccessedAt: time.Now(),
+	}
+	if accessErr != nil {
+		access.Reason = accessErr.Error()
+	}
+
+	if err := s.shareAccessRepo.Create(ctx, access); err != nil {
+		// Log but don't fail
+	}
+}
+
+func (s *ShareService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "share",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+func generateShareToken() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// maxSharePasswordBytes is the most bcrypt hashes
+const maxSharePasswordBytes = 72
+
+// hashSharePassword hashes a share password with bcrypt
+func hashSharePassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// verifySharePassword checks a password against a bcrypt hash, or against
+// the "salt$sha256(salt+password)" hashes of links created before bcrypt
+func verifySharePassword(stored, password string) bool {
+	if strings.HasPrefix(stored, "$2") {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+
+	saltHex, hash, ok := strings.Cut(stored, "$")
+	if !ok {
+		return false
+	}
+	sum := sha256.Sum256([]byte(saltHex + password))
+	return subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(hash)) == 1
+}
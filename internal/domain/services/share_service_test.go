@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryShareRepo holds one share and counts its downloads like the
+// conditional update does
+type memoryShareRepo struct {
+	repositories.ShareRepository
+	mu    sync.Mutex
+	share models.Share
+}
+
+func (m *memoryShareRepo) GetByToken(ctx context.Context, token string) (*models.Share, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	share := m.share
+	return &share, nil
+}
+
+func (m *memoryShareRepo) ClaimDownload(ctx context.Context, shareID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.share.MaxDownloads > 0 && m.share.DownloadCount >= m.share.MaxDownloads {
+		return false, nil
+	}
+	m.share.DownloadCount++
+	return true, nil
+}
+
+type discardShareAccessRepo struct {
+	repositories.ShareAccessRepository
+}
+
+func (discardShareAccessRepo) Create(ctx context.Context, access *models.ShareAccess) error {
+	return nil
+}
+
+type discardAnalyticsRepo struct {
+	repositories.AnalyticsRepository
+}
+
+func (discardAnalyticsRepo) UpdateDocumentDownload(ctx context.Context, documentID uuid.UUID) error {
+	return nil
+}
+
+type presigningStorage struct {
+	StorageService
+}
+
+func (presigningStorage) GeneratePresignedURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
+	return "https://storage.example/" + path, nil
+}
+
+func TestSharePassword(t *testing.T) {
+	hash, err := hashSharePassword("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$2"))
+	assert.True(t, verifySharePassword(hash, "correct horse"))
+	assert.False(t, verifySharePassword(hash, "correct horse "))
+
+	// Links created before bcrypt keep working
+	sum := sha256.Sum256([]byte("00112233445566778899aabbccddeeff" + "secret"))
+	legacy := "00112233445566778899aabbccddeeff$" + hex.EncodeToString(sum[:])
+	assert.True(t, verifySharePassword(legacy, "secret"))
+	assert.False(t, verifySharePassword(legacy, "Secret"))
+	assert.False(t, verifySharePassword("garbage", "secret"))
+}
+
+func TestAccessShare_DownloadLimitUnderConcurrency(t *testing.T) {
+	shareRepo := &memoryShareRepo{share: models.Share{
+		ID:           uuid.New(),
+		TenantID:     uuid.New(),
+		DocumentID:   uuid.New(),
+		Token:        "token",
+		MaxDownloads: 3,
+		IsActive:     true,
+		Document:     models.Document{StoragePath: "tenant/file.pdf"},
+	}}
+	service := NewShareService(shareRepo, discardShareAccessRepo{}, nil, nil, discardAnalyticsRepo{},
+		presigningStorage{}, nil, nil, nil, nil, nil, nil, ShareServiceConfig{})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	downloads, limited := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := service.AccessShare(context.Background(), AccessShareParams{Token: "token", Action: ShareActionDownload})
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				assert.NotEmpty(t, result.DownloadURL)
+				downloads++
+			} else {
+				assert.ErrorIs(t, err, ErrShareDownloadLimit)
+				limited++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, downloads)
+	assert.Equal(t, 7, limited)
+	assert.Equal(t, 3, shareRepo.share.DownloadCount)
+}
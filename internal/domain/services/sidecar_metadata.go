@@ -0,0 +1,441 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+)
+
+var (
+	ErrInvalidSidecar         = errors.New("invalid sidecar metadata")
+	ErrInvalidSidecarTemplate = errors.New("invalid sidecar template")
+)
+
+// Sidecar formats
+const (
+	SidecarJSON = "json"
+	SidecarXML  = "xml"
+)
+
+// maxSidecarSize bounds the sidecar files read
+const maxSidecarSize = 1 << 20
+
+// documentSidecarFieldsKey is the extracted data key listing the fields a
+// sidecar set, which AI extraction leaves alone
+const documentSidecarFieldsKey = "sidecar_fields"
+
+// sidecarTargets are the document fields a sidecar can fill. Custom fields
+// are named custom.<name>.
+var sidecarTargets = []string{
+	"title", "description", "document_type", "tags", "categories",
+	"amount", "currency", "tax_amount", "vendor_name", "customer_name",
+	"document_date", "due_date", "expiry_date",
+}
+
+// SidecarTemplate maps the metadata files scanners and DMS exports write
+// next to documents, such as invoice.pdf.json or invoice.xml, onto document
+// fields. Paths are dot-separated keys below the top-level object or the
+// XML root element; numbers index arrays and @name reads an XML attribute.
+type SidecarTemplate struct {
+	Formats []string `json:"formats,omitempty"` // json, xml; both by default
+	// Document field to the path of its value; fields map to the same
+	// names without any
+	Fields     map[string]string `json:"fields,omitempty"`
+	DateFormat string            `json:"date_format,omitempty"` // One of VendorDateFormats; ISO dates are always read
+	Required   bool              `json:"required"`              // Wait for each file's sidecar before uploading it
+}
+
+// normalizeSidecarTemplate validates a template and fills in its defaults
+func normalizeSidecarTemplate(template *SidecarTemplate) error {
+	formats := make([]string, 0, 2)
+	for _, format := range template.Formats {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format != SidecarJSON && format != SidecarXML {
+			return fmt.Errorf("%w: format must be json or xml", ErrInvalidSidecarTemplate)
+		}
+		if !containsString(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	if len(formats) == 0 {
+		formats = []string{SidecarJSON, SidecarXML}
+	}
+	template.Formats = formats
+
+	template.DateFormat = strings.ToUpper(strings.TrimSpace(template.DateFormat))
+	if _, ok := vendorDateFormats[template.DateFormat]; template.DateFormat != "" && !ok {
+		return fmt.Errorf("%w: date_format must be one of %s", ErrInvalidSidecarTemplate, strings.Join(VendorDateFormats, ", "))
+	}
+
+	fields := make(map[string]string, len(template.Fields))
+	for field, fieldPath := range template.Fields {
+		field = strings.TrimSpace(field)
+		fieldPath = strings.TrimSpace(fieldPath)
+		custom, isCustom := strings.CutPrefix(field, "custom.")
+		if (!isCustom || custom == "") && !containsString(sidecarTargets, field) {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidSidecarTemplate, field)
+		}
+		if fieldPath == "" || strings.Contains(fieldPath, "..") || strings.HasPrefix(fieldPath, ".") || strings.HasSuffix(fieldPath, ".") {
+			return fmt.Errorf("%w: invalid path for %s", ErrInvalidSidecarTemplate, field)
+		}
+		fields[field] = fieldPath
+	}
+	if len(fields) == 0 {
+		fields = nil
+	}
+	template.Fields = fields
+	return nil
+}
+
+// isSidecar reports whether a file is a sidecar rather than a document
+func (t *SidecarTemplate) isSidecar(name string) bool {
+	return containsString(t.Formats, strings.ToLower(strings.TrimPrefix(path.Ext(name), ".")))
+}
+
+// find returns the sidecar of a file among the listed files, preferring
+// one named after the whole file name, like invoice.pdf.json
+func (t *SidecarTemplate) find(name string, files map[string]IngestionFile) *IngestionFile {
+	base := strings.TrimSuffix(name, path.Ext(name))
+	for _, stem := range []string{name, base} {
+		for _, format := range t.Formats {
+			if file, ok := files[stem+"."+format]; ok {
+				return &file
+			}
+		}
+	}
+	return nil
+}
+
+// apply fills upload parameters from a parsed sidecar and returns the
+// fields it set. Fields the sidecar doesn't have are left alone.
+func (t *SidecarTemplate) apply(data map[string]interface{}, params *UploadDocumentParams) ([]string, error) {
+	fields := t.Fields
+	if fields == nil {
+		fields = make(map[string]string, len(sidecarTargets))
+		for _, field := range sidecarTargets {
+			fields[field] = field
+		}
+	}
+
+	set := make([]string, 0, len(fields))
+	for field, fieldPath := range fields {
+		value, ok := sidecarValue(data, fieldPath)
+		if !ok || value == nil {
+			continue
+		}
+		if err := t.applyField(field, value, params); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSidecar, field, err)
+		}
+		set = append(set, field)
+	}
+	sort.Strings(set)
+	return set, nil
+}
+
+func (t *SidecarTemplate) applyField(field string, value interface{}, params *UploadDocumentParams) error {
+	if custom, ok := strings.CutPrefix(field, "custom."); ok {
+		if number, ok := value.(json.Number); ok {
+			value, _ = number.Float64()
+		}
+		if params.CustomFields == nil {
+			params.CustomFields = make(map[string]interface{})
+		}
+		params.CustomFields[custom] = value
+		return nil
+	}
+
+	switch field {
+	case "tags", "categories":
+		names := sidecarList(value)
+		if field == "tags" {
+			params.Tags = names
+		} else {
+			params.Categories = names
+		}
+		return nil
+	case "amount", "tax_amount":
+		amount, err := sidecarNumber(value)
+		if err != nil {
+			return err
+		}
+		if field == "amount" {
+			params.Amount = &amount
+		} else {
+			params.TaxAmount = &amount
+		}
+		return nil
+	case "document_date", "due_date", "expiry_date":
+		date, err := t.parseDate(sidecarString(value))
+		if err != nil {
+			return err
+		}
+		switch field {
+		case "document_date":
+			params.DocumentDate = &date
+		case "due_date":
+			params.DueDate = &date
+		default:
+			params.ExpiryDate = &date
+		}
+		return nil
+	}
+
+	text := strings.TrimSpace(sidecarString(value))
+	switch field {
+	case "title":
+		params.Title = text
+	case "description":
+		params.Description = text
+	case "vendor_name":
+		params.VendorName = text
+	case "customer_name":
+		params.CustomerName = text
+	case "currency":
+		text = strings.ToUpper(text)
+		if !currencyCodePattern.MatchString(text) {
+			return fmt.Errorf("%q is not an ISO 4217 code", text)
+		}
+		params.Currency = text
+	case "document_type":
+		docType := models.DocumentType(strings.ToLower(text))
+		if !isKnownDocumentType(docType) {
+			return fmt.Errorf("unknown document type %q", text)
+		}
+		params.DocumentType = docType
+	}
+	return nil
+}
+
+// parseDate reads a date in the template's format or as an ISO date
+func (t *SidecarTemplate) parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	var layouts []string
+	if format, ok := vendorDateFormats[t.DateFormat]; ok {
+		layouts = append(layouts, format.layouts...)
+	}
+	layouts = append(layouts, "2006-01-02", time.RFC3339)
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// readSidecar reads and parses a watched folder file's sidecar
+func readSidecar(directory IngestionDirectory, file IngestionFile) (map[string]interface{}, error) {
+	if file.Size > maxSidecarSize {
+		return nil, fmt.Errorf("%w: %s is larger than 1MB", ErrInvalidSidecar, file.Name)
+	}
+	reader, err := directory.Open(file.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sidecar: %w", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(io.LimitReader(reader, maxSidecarSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sidecar: %w", err)
+	}
+	if len(content) > maxSidecarSize {
+		return nil, fmt.Errorf("%w: %s is larger than 1MB", ErrInvalidSidecar, file.Name)
+	}
+	return parseSidecar(strings.ToLower(strings.TrimPrefix(path.Ext(file.Name), ".")), content)
+}
+
+// parseSidecar parses a JSON object or XML document into nested maps
+func parseSidecar(format string, content []byte) (map[string]interface{}, error) {
+	if format == SidecarXML {
+		return parseXMLSidecar(content)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var data map[string]interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSidecar, err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%w: expected a JSON object", ErrInvalidSidecar)
+	}
+	return data, nil
+}
+
+// xmlElement collects an element while its document is parsed
+type xmlElement struct {
+	values   map[string]interface{}
+	text     strings.Builder
+	children bool
+}
+
+// parseXMLSidecar turns the root element's children and attributes into a
+// map. Elements with only text become strings, repeated elements slices.
+func parseXMLSidecar(content []byte) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var stack []*xmlElement
+	var names []string
+	var root map[string]interface{}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSidecar, err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			if root != nil {
+				return nil, fmt.Errorf("%w: more than one root element", ErrInvalidSidecar)
+			}
+			element := &xmlElement{values: make(map[string]interface{})}
+			for _, attr := range token.Attr {
+				element.values["@"+attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				stack[len(stack)-1].children = true
+			}
+			stack = append(stack, element)
+			names = append(names, token.Name.Local)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(token)
+			}
+		case xml.EndElement:
+			element := stack[len(stack)-1]
+			name := names[len(names)-1]
+			stack, names = stack[:len(stack)-1], names[:len(names)-1]
+
+			if len(stack) == 0 {
+				root = element.values
+				continue
+			}
+
+			var value interface{} = strings.TrimSpace(element.text.String())
+			if element.children || len(element.values) > 0 {
+				if text := value.(string); text != "" {
+					element.values["#text"] = text
+				}
+				value = element.values
+			}
+			parent := stack[len(stack)-1].values
+			switch existing := parent[name].(type) {
+			case nil:
+				parent[name] = value
+			case []interface{}:
+				parent[name] = append(existing, value)
+			default:
+				parent[name] = []interface{}{existing, value}
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("%w: no root element", ErrInvalidSidecar)
+	}
+	return root, nil
+}
+
+// sidecarValue follows a dot-separated path through parsed sidecar data
+func sidecarValue(data map[string]interface{}, fieldPath string) (interface{}, bool) {
+	var current interface{} = data
+	for _, key := range strings.Split(fieldPath, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func sidecarString(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	return ""
+}
+
+// sidecarNumber reads a number, or a string of one with thousands separators
+func sidecarNumber(value interface{}) (float64, error) {
+	text := strings.TrimSpace(sidecarString(value))
+	text = strings.NewReplacer(",", "", " ", "").Replace(text)
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", sidecarString(value))
+	}
+	return number, nil
+}
+
+// sidecarList reads an array, or a comma or semicolon separated string
+func sidecarList(value interface{}) []string {
+	var items []string
+	switch value := value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			items = append(items, sidecarString(item))
+		}
+	default:
+		items = strings.FieldsFunc(sidecarString(value), func(r rune) bool { return r == ',' || r == ';' })
+	}
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			names = append(names, item)
+		}
+	}
+	return names
+}
+
+// sidecarFields returns the fields of a document that came from a sidecar
+func sidecarFields(document *models.Document) map[string]bool {
+	return extractedDataFields(document, documentSidecarFieldsKey)
+}
+
+// extractedDataFields reads a list of field names stored in a document's
+// extracted data
+func extractedDataFields(document *models.Document, key string) map[string]bool {
+	fields := make(map[string]bool)
+	switch stored := document.ExtractedData[key].(type) {
+	case []string:
+		for _, field := range stored {
+			fields[field] = true
+		}
+	case []interface{}:
+		for _, field := range stored {
+			if name, ok := field.(string); ok {
+				fields[name] = true
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSidecarTemplate_ApplyJSON(t *testing.T) {
+	template := &SidecarTemplate{
+		Fields: map[string]string{
+			"title":         "doc.title",
+			"vendor_name":   "supplier.name",
+			"amount":        "totals.gross",
+			"currency":      "totals.currency",
+			"document_date": "doc.date",
+			"tags":          "keywords",
+			"custom.batch":  "scan.batch",
+			"description":   "missing.path",
+		},
+		DateFormat: "dd/mm/yyyy",
+	}
+	require.NoError(t, normalizeSidecarTemplate(template))
+	assert.Equal(t, []string{SidecarJSON, SidecarXML}, template.Formats)
+
+	data, err := parseSidecar(SidecarJSON, []byte(`{
+		"doc": {"title": "March invoice", "date": "03/04/2024"},
+		"supplier": {"name": "Acme Ltd"},
+		"totals": {"gross": "1,250.50", "currency": "eur"},
+		"keywords": ["finance", " q1 "],
+		"scan": {"batch": 42}
+	}`))
+	require.NoError(t, err)
+
+	params := UploadDocumentParams{}
+	fields, err := template.apply(data, &params)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"amount", "currency", "custom.batch", "document_date", "tags", "title", "vendor_name"}, fields)
+	assert.Equal(t, "March invoice", params.Title)
+	assert.Equal(t, "Acme Ltd", params.VendorName)
+	assert.Equal(t, 1250.50, *params.Amount)
+	assert.Equal(t, "EUR", params.Currency)
+	assert.Equal(t, time.Date(2024, 4, 3, 0, 0, 0, 0, time.UTC), *params.DocumentDate)
+	assert.Equal(t, []string{"finance", "q1"}, params.Tags)
+	assert.Equal(t, 42.0, params.CustomFields["batch"])
+	assert.Empty(t, params.Description)
+
+	// Values that can't be read reject the sidecar
+	data, err = parseSidecar(SidecarJSON, []byte(`{"totals": {"gross": "a lot"}}`))
+	require.NoError(t, err)
+	_, err = template.apply(data, &UploadDocumentParams{})
+	assert.ErrorIs(t, err, ErrInvalidSidecar)
+}
+
+func TestSidecarTemplate_ApplyXMLWithDefaultFields(t *testing.T) {
+	template := &SidecarTemplate{Formats: []string{"XML"}}
+	require.NoError(t, normalizeSidecarTemplate(template))
+
+	data, err := parseSidecar(SidecarXML, []byte(`<?xml version="1.0"?>
+		<document id="17">
+			<title>Lease agreement</title>
+			<document_type>Contract</document_type>
+			<categories>Legal; Property</categories>
+			<expiry_date>2030-01-31</expiry_date>
+			<party role="landlord">Jane</party>
+			<party role="tenant">John</party>
+		</document>`))
+	require.NoError(t, err)
+	assert.Equal(t, "17", data["@id"])
+	value, ok := sidecarValue(data, "party.1.@role")
+	assert.True(t, ok)
+	assert.Equal(t, "tenant", value)
+
+	params := UploadDocumentParams{}
+	fields, err := template.apply(data, &params)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"categories", "document_type", "expiry_date", "title"}, fields)
+	assert.Equal(t, models.DocTypeContract, params.DocumentType)
+	assert.Equal(t, []string{"Legal", "Property"}, params.Categories)
+
+	_, err = parseSidecar(SidecarXML, []byte(`<a><b></a>`))
+	assert.ErrorIs(t, err, ErrInvalidSidecar)
+}
+
+func TestSidecarTemplate_Find(t *testing.T) {
+	template := &SidecarTemplate{}
+	require.NoError(t, normalizeSidecarTemplate(template))
+
+	files := map[string]IngestionFile{
+		"invoice.pdf":      {Name: "invoice.pdf"},
+		"invoice.xml":      {Name: "invoice.xml"},
+		"invoice.pdf.json": {Name: "invoice.pdf.json"},
+		"scan.tiff":        {Name: "scan.tiff"},
+		"scan.xml":         {Name: "scan.xml"},
+	}
+	assert.Equal(t, "invoice.pdf.json", template.find("invoice.pdf", files).Name)
+	assert.Equal(t, "scan.xml", template.find("scan.tiff", files).Name)
+	assert.Nil(t, template.find("other.pdf", files))
+	assert.True(t, template.isSidecar("scan.XML"))
+	assert.False(t, template.isSidecar("scan.tiff"))
+
+	for _, invalid := range []SidecarTemplate{
+		{Formats: []string{"csv"}},
+		{Fields: map[string]string{"owner": "owner"}},
+		{Fields: map[string]string{"custom.": "x"}},
+		{Fields: map[string]string{"title": "a..b"}},
+		{DateFormat: "YYYY.MM.DD"},
+	} {
+		assert.ErrorIs(t, normalizeSidecarTemplate(&invalid), ErrInvalidSidecarTemplate)
+	}
+}
+
+func TestApplyFinancialData_KeepsSidecarFields(t *testing.T) {
+	amount := 10.0
+	document := &models.Document{
+		VendorName:    "Acme Ltd",
+		ExtractedData: models.JSONB{documentSidecarFieldsKey: []interface{}{"vendor_name"}},
+	}
+	service := &AIProcessingService{}
+	service.applyFinancialData(document, &FinancialExtractionResult{VendorName: "ACME", Amount: &amount})
+
+	assert.Equal(t, "Acme Ltd", document.VendorName)
+	assert.Equal(t, 10.0, *document.Amount)
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var ErrLockLost = errors.New("distributed lock lost")
+
+// Scheduled task metrics, published through expvar
+var (
+	scheduledJobRuns     = expvar.NewMap("scheduled_job_runs_total")
+	scheduledJobFailures = expvar.NewMap("scheduled_job_failures_total")
+)
+
+// DistributedLocker hands out locks shared by every server instance, e.g.
+// backed by Redis. Locks expire after their TTL unless refreshed.
+type DistributedLocker interface {
+	// TryLock acquires key for ttl, returning false if another holder has it
+	TryLock(ctx context.Context, key string, ttl time.Duration) (DistributedLock, bool, error)
+}
+
+// DistributedLock is a held lock. Refresh returns ErrLockLost once the lock
+// has expired or been taken over.
+type DistributedLock interface {
+	Refresh(ctx context.Context, ttl time.Duration) error
+	Release(ctx context.Context) error
+}
+
+// ScheduledTask is periodic work that must run once per interval across the
+// fleet, however many instances are running
+type ScheduledTask struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// TaskRegistryConfig holds configuration for the task registry
+type TaskRegistryConfig struct {
+	KeyPrefix string        // Prefix of lock keys, defaults to "archivus:tasks:"
+	LeaseTTL  time.Duration // Lock lease of a running task, refreshed while it runs
+}
+
+// TaskRegistry runs singleton scheduled tasks. Every instance polls every
+// task, but each interval slot is claimed with a distributed lock, so only
+// one instance runs it; a second lock keeps a long run from overlapping the
+// next slot. Without a locker (single instance) tasks simply run on their
+// interval.
+type TaskRegistry struct {
+	locker DistributedLocker
+	config TaskRegistryConfig
+
+	mu    sync.Mutex
+	tasks []ScheduledTask
+}
+
+// NewTaskRegistry creates a new task registry. locker may be nil.
+func NewTaskRegistry(locker DistributedLocker, config TaskRegistryConfig) *TaskRegistry {
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = "archivus:tasks:"
+	}
+	if config.LeaseTTL <= 0 {
+		config.LeaseTTL = time.Minute
+	}
+
+	return &TaskRegistry{
+		locker: locker,
+		config: config,
+	}
+}
+
+// Register adds a task. Tasks registered after Run has started are ignored.
+func (r *TaskRegistry) Register(task ScheduledTask) error {
+	if task.Name == "" || task.Interval <= 0 || task.Run == nil {
+		return fmt.Errorf("scheduled task needs a name, interval and run function")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.tasks {
+		if existing.Name == task.Name {
+			return fmt.Errorf("scheduled task %q is already registered", task.Name)
+		}
+	}
+	r.tasks = append(r.tasks, task)
+	return nil
+}
+
+// Tasks returns the registered tasks
+func (r *TaskRegistry) Tasks() []ScheduledTask {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ScheduledTask(nil), r.tasks...)
+}
+
+// Run schedules every registered task until the context is cancelled
+func (r *TaskRegistry) Run(ctx context.Context) {
+	// Each task polls at least once a minute
+	ctx = startWorker(ctx, "scheduled_tasks", time.Minute)
+
+	var wg sync.WaitGroup
+	for _, task := range r.Tasks() {
+		wg.Add(1)
+		go func(task ScheduledTask) {
+			defer wg.Done()
+			r.schedule(ctx, task)
+		}(task)
+	}
+	wg.Wait()
+}
+
+// Helper methods
+
+func (r *TaskRegistry) schedule(ctx context.Context, task ScheduledTask) {
+	// Poll well within the interval so a slot is picked up soon after it opens
+	poll := task.Interval / 10
+	if poll < time.Second {
+		poll = time.Second
+	}
+	if poll > time.Minute {
+		poll = time.Minute
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	var lastSlot time.Time
+	for {
+		slot := time.Now().UTC().Truncate(task.Interval)
+		if slot.After(lastSlot) {
+			if r.runSlot(ctx, task, slot) {
+				lastSlot = slot
+			}
+		}
+		// Task failures are counted in scheduled_job_failures_total
+		reportPoll(ctx, nil)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runSlot runs the task for the slot unless another instance claimed it. It
+// returns false when the claim could not be decided, so the next poll retries.
+func (r *TaskRegistry) runSlot(ctx context.Context, task ScheduledTask, slot time.Time) bool {
+	if r.locker == nil {
+		r.execute(ctx, task)
+		return true
+	}
+
+	slotKey := fmt.Sprintf("%s%s:%d", r.config.KeyPrefix, task.Name, slot.Unix())
+	if _, claimed, err := r.locker.TryLock(ctx, slotKey, task.Interval); err != nil {
+		return false
+	} else if !claimed {
+		return true // Another instance has this slot
+	}
+
+	// Skip the slot if the previous run is still going somewhere
+	lease, acquired, err := r.locker.TryLock(ctx, r.config.KeyPrefix+task.Name+":running", r.config.LeaseTTL)
+	if err != nil || !acquired {
+		return true
+	}
+	defer lease.Release(context.Background())
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go r.keepLease(runCtx, cancel, lease)
+
+	r.execute(runCtx, task)
+	return true
+}
+
+// keepLease refreshes the running lease until the run ends, cancelling the
+// run if the lease is lost
+func (r *TaskRegistry) keepLease(ctx context.Context, cancel context.CancelFunc, lease DistributedLock) {
+	ticker := time.NewTicker(r.config.LeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := lease.Refresh(ctx, r.config.LeaseTTL); errors.Is(err, ErrLockLost) {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (r *TaskRegistry) execute(ctx context.Context, task ScheduledTask) {
+	ctx, span := startSpan(ctx, "ScheduledTask "+task.Name,
+		attribute.String("task.name", task.Name))
+
+	scheduledJobRuns.Add(task.Name, 1)
+	err := task.Run(ctx)
+	if err != nil {
+		// Log but keep running - the next slot retries
+		scheduledJobFailures.Add(task.Name, 1)
+	}
+	endSpan(span, err)
+}
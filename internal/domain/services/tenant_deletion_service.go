@@ -0,0 +1,772 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTenantDeletionNotFound = errors.New("no tenant deletion requested")
+	ErrTenantDeletionPending  = errors.New("tenant deletion already requested")
+	ErrTenantDeletionStarted  = errors.New("tenant deletion has started and can't be cancelled")
+	ErrTenantDeletionConfirm  = errors.New("confirmation doesn't match the tenant's subdomain")
+	ErrTenantPendingDeletion  = errors.New("tenant account is scheduled for deletion")
+	ErrTenantUnderLegalHold   = errors.New("tenant has documents under an active legal hold; lift the holds before deleting it")
+)
+
+// Teardown stages, in order
+const (
+	TenantDeletionStageAccounts = "accounts"
+	TenantDeletionStageFiles    = "files"
+	TenantDeletionStageRows     = "rows"
+	TenantDeletionStageDone     = "done"
+)
+
+var tenantDeletionStages = []string{
+	TenantDeletionStageAccounts,
+	TenantDeletionStageFiles,
+	TenantDeletionStageRows,
+	TenantDeletionStageDone,
+}
+
+const (
+	// tenantExportPageSize is how many documents or users are read per page
+	// while building a tenant export
+	tenantExportPageSize = 500
+
+	// tenantDeletionsPerRun is how many due deletions one run picks up
+	tenantDeletionsPerRun = 10
+)
+
+// TenantDeletionConfig holds configuration for tenant deletions
+type TenantDeletionConfig struct {
+	GracePeriod time.Duration // How long admins can export or cancel; defaults to 30 days
+	Interval    time.Duration // How often due deletions are processed; defaults to one minute
+	Lease       time.Duration // How long a worker owns a deletion without progress; defaults to 30 minutes
+	MaxAttempts int           // Attempts per stage before a deletion fails; defaults to 3
+	BatchSize   int           // Rows deleted per statement; defaults to 1000
+}
+
+// TenantDeletionService tears a tenant down in stages. Requesting a deletion
+// suspends the tenant: everyone but admins is signed out and can't sign in.
+// A worker builds an export of the tenant's documents, which admins can
+// download during the grace period, and the deletion can be cancelled until
+// the grace period ends. Then the worker deletes the tenant's identity
+// provider accounts, files and rows, and records a deletion certificate.
+// Tenants with active legal holds are never deleted.
+type TenantDeletionService struct {
+	repo           repositories.TenantDeletionRepository
+	tenantRepo     repositories.TenantRepository
+	userRepo       repositories.UserRepository
+	repairRepo     repositories.RepairRepository
+	legalHoldRepo  repositories.LegalHoldRepository
+	auditRepo      repositories.AuditLogRepository
+	storageService StorageService
+	supabaseAuth   SupabaseAuthService
+	sessions       SessionRevoker
+	config         TenantDeletionConfig
+}
+
+// NewTenantDeletionService creates a new tenant deletion service
+func NewTenantDeletionService(
+	repo repositories.TenantDeletionRepository,
+	tenantRepo repositories.TenantRepository,
+	userRepo repositories.UserRepository,
+	repairRepo repositories.RepairRepository,
+	legalHoldRepo repositories.LegalHoldRepository,
+	auditRepo repositories.AuditLogRepository,
+	storageService StorageService,
+	supabaseAuth SupabaseAuthService,
+	sessions SessionRevoker,
+	config TenantDeletionConfig,
+) *TenantDeletionService {
+	if config.GracePeriod <= 0 {
+		config.GracePeriod = 30 * 24 * time.Hour
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.Lease <= 0 {
+		config.Lease = 30 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+
+	return &TenantDeletionService{
+		repo:           repo,
+		tenantRepo:     tenantRepo,
+		userRepo:       userRepo,
+		repairRepo:     repairRepo,
+		legalHoldRepo:  legalHoldRepo,
+		auditRepo:      auditRepo,
+		storageService: storageService,
+		supabaseAuth:   supabaseAuth,
+		sessions:       sessions,
+		config:         config,
+	}
+}
+
+// RequestTenantDeletionParams contains parameters for requesting a tenant deletion
+type RequestTenantDeletionParams struct {
+	TenantID    uuid.UUID `json:"tenant_id"`
+	RequestedBy uuid.UUID `json:"requested_by"`
+	Confirm     string    `json:"confirm"` // Must be the tenant's subdomain
+	Reason      string    `json:"reason"`
+}
+
+// TenantDeletionProgress counts what a teardown has deleted so far
+type TenantDeletionProgress struct {
+	AccountsDeleted int64            `json:"accounts_deleted"`
+	AccountsFailed  int64            `json:"accounts_failed"`
+	FilesDeleted    int64            `json:"files_deleted"`
+	BytesDeleted    int64            `json:"bytes_deleted"`
+	RowsDeleted     map[string]int64 `json:"rows_deleted"`
+}
+
+// RequestDeletion suspends the tenant and schedules its deletion after the
+// grace period. It's refused while any legal hold is active.
+func (s *TenantDeletionService) RequestDeletion(ctx context.Context, params RequestTenantDeletionParams) (*models.TenantDeletion, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, params.TenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	if !strings.EqualFold(strings.TrimSpace(params.Confirm), tenant.Subdomain) {
+		return nil, ErrTenantDeletionConfirm
+	}
+	if latest, err := s.repo.GetLatest(ctx, params.TenantID); err == nil && isActiveTenantDeletion(latest) {
+		return nil, ErrTenantDeletionPending
+	}
+	if err := s.checkLegalHolds(ctx, tenant.ID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deletion := &models.TenantDeletion{
+		ID:          uuid.New(),
+		TenantID:    tenant.ID,
+		TenantName:  tenant.Name,
+		Subdomain:   tenant.Subdomain,
+		Status:      models.TenantDeletionExporting,
+		Reason:      strings.TrimSpace(params.Reason),
+		RequestedBy: params.RequestedBy,
+		RequestedAt: now,
+		PurgeAfter:  now.Add(s.config.GracePeriod),
+	}
+	if err := s.repo.Create(ctx, deletion); err != nil {
+		return nil, err
+	}
+
+	tenant.DeletionScheduledFor = &deletion.PurgeAfter
+	tenant.UpdatedAt = now
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, fmt.Errorf("failed to suspend tenant: %w", err)
+	}
+
+	s.signOutMembers(ctx, tenant.ID, params.RequestedBy)
+
+	s.createAuditLog(deletion, params.RequestedBy, models.AuditDelete,
+		fmt.Sprintf("Tenant deletion requested, data will be deleted after %s", deletion.PurgeAfter.Format(time.RFC3339)))
+
+	return deletion, nil
+}
+
+// CancelDeletion lifts the suspension, as long as deletion hasn't started
+func (s *TenantDeletionService) CancelDeletion(ctx context.Context, tenantID, cancelledBy uuid.UUID) (*models.TenantDeletion, error) {
+	deletion, err := s.repo.GetLatest(ctx, tenantID)
+	if err != nil || deletion.Status == models.TenantDeletionCancelled {
+		return nil, ErrTenantDeletionNotFound
+	}
+	if !isCancellableTenantDeletion(deletion) {
+		return nil, ErrTenantDeletionStarted
+	}
+
+	now := time.Now()
+	cancelled, err := s.repo.Cancel(ctx, deletion.ID, cancelledBy, now)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, ErrTenantDeletionStarted
+	}
+	deletion.Status = models.TenantDeletionCancelled
+	deletion.CancelledBy = &cancelledBy
+	deletion.CancelledAt = &now
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	tenant.DeletionScheduledFor = nil
+	tenant.UpdatedAt = now
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, fmt.Errorf("failed to reactivate tenant: %w", err)
+	}
+
+	// The export holds all of the tenant's documents; don't leave a copy around
+	if deletion.ExportPath != "" {
+		if err := s.storageService.Delete(ctx, deletion.ExportPath); err != nil {
+			// Log but don't fail - storage reconciliation finds orphans
+		}
+	}
+
+	s.createAuditLog(deletion, cancelledBy, models.AuditUpdate, "Tenant deletion cancelled")
+
+	return deletion, nil
+}
+
+// GetDeletion returns the tenant's latest deletion request
+func (s *TenantDeletionService) GetDeletion(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletion, error) {
+	deletion, err := s.repo.GetLatest(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantDeletionNotFound
+	}
+	return deletion, nil
+}
+
+// OpenExport returns the export of a tenant waiting to be deleted. The
+// caller must close the reader.
+func (s *TenantDeletionService) OpenExport(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletion, io.ReadCloser, error) {
+	deletion, err := s.GetDeletion(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if deletion.Status != models.TenantDeletionScheduled || deletion.ExportPath == "" {
+		return nil, nil, ErrExportNotReady
+	}
+
+	reader, err := s.storageService.Get(ctx, deletion.ExportPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read tenant export: %w", err)
+	}
+	return deletion, reader, nil
+}
+
+// GetCertificate returns the certificate of a deleted tenant
+func (s *TenantDeletionService) GetCertificate(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletionCertificate, error) {
+	certificate, err := s.repo.GetCertificate(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantDeletionNotFound
+	}
+	return certificate, nil
+}
+
+// ProcessDue builds waiting exports and tears down tenants whose grace period
+// is over. It returns how many tenants were deleted.
+func (s *TenantDeletionService) ProcessDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.repo.ListDue(ctx, now, tenantDeletionsPerRun)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for i := range due {
+		deletion := &due[i]
+		claimed, err := s.repo.Claim(ctx, deletion.ID, now, now.Add(s.config.Lease))
+		if err != nil {
+			return deleted, err
+		}
+		if !claimed {
+			// Another instance got it first, or it was cancelled
+			continue
+		}
+		deletion.Attempts++
+
+		switch deletion.Status {
+		case models.TenantDeletionExporting:
+			if err := s.finishExport(ctx, deletion); err != nil {
+				return deleted, err
+			}
+		case models.TenantDeletionScheduled, models.TenantDeletionDeleting:
+			// Claiming a scheduled deletion moved it to deleting
+			if deletion.Status == models.TenantDeletionScheduled {
+				deletion.Status = models.TenantDeletionDeleting
+			}
+			if err := s.finishTeardown(ctx, deletion); err != nil {
+				return deleted, err
+			}
+			if deletion.Status == models.TenantDeletionCompleted {
+				deleted++
+			}
+		}
+	}
+
+	return deleted, nil
+}
+
+// DeletionTask is the scheduled task that processes tenant deletions
+func (s *TenantDeletionService) DeletionTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "tenant_deletions",
+		Interval: s.config.Interval,
+		Run: func(ctx context.Context) error {
+			_, err := s.ProcessDue(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+// finishExport builds the export and moves the deletion into its grace
+// period. An export that keeps failing is given up on; the deletion still
+// goes ahead.
+func (s *TenantDeletionService) finishExport(ctx context.Context, deletion *models.TenantDeletion) error {
+	exportPath, size, err := s.export(ctx, deletion)
+	if err != nil {
+		deletion.Error = fmt.Sprintf("export failed: %v", err)
+		if deletion.Attempts >= s.config.MaxAttempts {
+			deletion.Status = models.TenantDeletionScheduled
+			deletion.Attempts = 0
+		}
+	} else {
+		deletion.Status = models.TenantDeletionScheduled
+		deletion.ExportPath = exportPath
+		deletion.ExportSize = size
+		deletion.Error = ""
+		deletion.Attempts = 0
+	}
+
+	saved, err := s.repo.Finish(ctx, deletion, models.TenantDeletionExporting)
+	if err != nil {
+		return err
+	}
+	if !saved && exportPath != "" {
+		// Cancelled while exporting
+		s.storageService.Delete(ctx, exportPath)
+	}
+	if saved && deletion.Status == models.TenantDeletionScheduled {
+		message := "Tenant export is ready for download"
+		if deletion.ExportPath == "" {
+			message = "Tenant export failed: " + deletion.Error
+		}
+		s.createAuditLog(deletion, deletion.RequestedBy, models.AuditUpdate, message)
+	}
+	return nil
+}
+
+// finishTeardown runs the teardown and records its outcome
+func (s *TenantDeletionService) finishTeardown(ctx context.Context, deletion *models.TenantDeletion) error {
+	if err := s.teardown(ctx, deletion); err != nil {
+		deletion.Error = err.Error()
+		if deletion.Attempts >= s.config.MaxAttempts {
+			deletion.Status = models.TenantDeletionFailed
+		}
+	} else {
+		completedAt := time.Now()
+		deletion.Status = models.TenantDeletionCompleted
+		deletion.Error = ""
+		deletion.CompletedAt = &completedAt
+	}
+
+	_, err := s.repo.Finish(ctx, deletion, models.TenantDeletionDeleting)
+	return err
+}
+
+// teardown deletes the tenant stage by stage, saving progress as it goes so
+// a retry continues where the last attempt stopped. A hold placed during the
+// grace period stops it until the deletion fails.
+func (s *TenantDeletionService) teardown(ctx context.Context, deletion *models.TenantDeletion) error {
+	if err := s.checkLegalHolds(ctx, deletion.TenantID); err != nil {
+		return err
+	}
+	progress, err := decodeTenantDeletionProgress(deletion.Progress)
+	if err != nil {
+		return err
+	}
+	if deletion.StartedAt == nil {
+		startedAt := time.Now()
+		deletion.StartedAt = &startedAt
+	}
+	if deletion.Stage == "" {
+		deletion.Stage = TenantDeletionStageAccounts
+	}
+
+	save := func() error {
+		encoded, err := encodeTenantDeletionProgress(progress)
+		if err != nil {
+			return err
+		}
+		deletion.Progress = encoded
+		return s.repo.SaveProgress(ctx, deletion, time.Now().Add(s.config.Lease))
+	}
+	if err := save(); err != nil {
+		return err
+	}
+
+	for deletion.Stage != TenantDeletionStageDone {
+		var err error
+		switch deletion.Stage {
+		case TenantDeletionStageAccounts:
+			err = s.deleteAccounts(ctx, deletion.TenantID, progress)
+		case TenantDeletionStageFiles:
+			err = s.deleteFiles(ctx, deletion.TenantID, progress)
+		case TenantDeletionStageRows:
+			err = s.repo.DeleteTenantRows(ctx, deletion.TenantID, s.config.BatchSize, func(table string, deleted int64) error {
+				if deleted == 0 {
+					return nil
+				}
+				progress.RowsDeleted[table] += deleted
+				return save()
+			})
+		default:
+			err = fmt.Errorf("unknown tenant deletion stage %q", deletion.Stage)
+		}
+		if err != nil {
+			return fmt.Errorf("%s stage failed: %w", deletion.Stage, err)
+		}
+
+		deletion.Stage = nextTenantDeletionStage(deletion.Stage)
+		if err := save(); err != nil {
+			return err
+		}
+	}
+
+	return s.certify(ctx, deletion, progress)
+}
+
+// deleteAccounts removes the tenant's users from the identity provider. A
+// failure is counted rather than retried: an account deleted by an earlier
+// attempt would fail again.
+func (s *TenantDeletionService) deleteAccounts(ctx context.Context, tenantID uuid.UUID, progress *TenantDeletionProgress) error {
+	if s.supabaseAuth == nil {
+		return nil
+	}
+
+	params := repositories.ListParams{Page: 1, PageSize: tenantExportPageSize}
+	for {
+		users, total, err := s.userRepo.ListByTenant(ctx, tenantID, params)
+		if err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+		for _, user := range users {
+			if err := s.supabaseAuth.AdminDeleteUser(user.ID.String()); err != nil {
+				progress.AccountsFailed++
+			} else {
+				progress.AccountsDeleted++
+			}
+		}
+		if int64(params.Page*params.PageSize) >= total || len(users) == 0 {
+			return nil
+		}
+		params.Page++
+	}
+}
+
+// deleteFiles deletes everything stored under the tenant, plus any file its
+// rows reference elsewhere
+func (s *TenantDeletionService) deleteFiles(ctx context.Context, tenantID uuid.UUID, progress *TenantDeletionProgress) error {
+	stored := []StoredObject{}
+	if lister, ok := s.storageService.(StorageLister); ok {
+		var err error
+		if stored, err = lister.List(ctx, tenantID.String()); err != nil {
+			return err
+		}
+	}
+
+	listed := make(map[string]bool, len(stored))
+	for _, object := range stored {
+		if err := s.storageService.Delete(ctx, object.Path); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", object.Path, err)
+		}
+		listed[object.Path] = true
+		progress.FilesDeleted++
+		progress.BytesDeleted += object.Size
+	}
+
+	references, err := s.repairRepo.ListStorageReferences(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	for _, reference := range references {
+		if listed[reference.Path] {
+			continue
+		}
+		// Most of these are already gone, so a failure isn't fatal
+		if err := s.storageService.Delete(ctx, reference.Path); err == nil {
+			progress.FilesDeleted++
+		}
+		listed[reference.Path] = true
+	}
+
+	return nil
+}
+
+// certify records the deletion certificate, once
+func (s *TenantDeletionService) certify(ctx context.Context, deletion *models.TenantDeletion, progress *TenantDeletionProgress) error {
+	if existing, err := s.repo.GetCertificate(ctx, deletion.TenantID); err == nil && existing.DeletionID == deletion.ID {
+		return nil
+	}
+
+	rows := make(models.JSONB, len(progress.RowsDeleted))
+	for table, deleted := range progress.RowsDeleted {
+		rows[table] = deleted
+	}
+
+	// Postgres keeps microseconds; truncate so the hash survives a round trip
+	certificate := &models.TenantDeletionCertificate{
+		ID:           uuid.New(),
+		DeletionID:   deletion.ID,
+		TenantID:     deletion.TenantID,
+		TenantName:   deletion.TenantName,
+		Subdomain:    deletion.Subdomain,
+		RequestedBy:  deletion.RequestedBy,
+		RequestedAt:  deletion.RequestedAt.UTC().Truncate(time.Microsecond),
+		StartedAt:    deletion.StartedAt.UTC().Truncate(time.Microsecond),
+		CompletedAt:  time.Now().UTC().Truncate(time.Microsecond),
+		RowsDeleted:  rows,
+		FilesDeleted: progress.FilesDeleted,
+		BytesDeleted: progress.BytesDeleted,
+		Accounts:     progress.AccountsDeleted,
+	}
+	certificate.Hash = certificate.ComputeHash()
+
+	return s.repo.CreateCertificate(ctx, certificate)
+}
+
+// export writes the tenant, its users, its documents and their files and its
+// audit trail into a zip archive in object storage
+func (s *TenantDeletionService) export(ctx context.Context, deletion *models.TenantDeletion) (string, int64, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, deletion.TenantID)
+	if err != nil {
+		return "", 0, ErrTenantNotFound
+	}
+
+	file, err := os.CreateTemp("", "tenant-export-*.zip")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create tenant export: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	if err := s.writeExport(ctx, archive, tenant); err != nil {
+		return "", 0, err
+	}
+	if err := archive.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to write tenant export: %w", err)
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write tenant export: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", 0, fmt.Errorf("failed to write tenant export: %w", err)
+	}
+
+	storagePath, err := s.storageService.Store(ctx, StorageParams{
+		TenantID:    tenant.ID,
+		FileReader:  file,
+		Filename:    fmt.Sprintf("tenant-export-%s.zip", tenant.Subdomain),
+		ContentType: "application/zip",
+		Size:        size,
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to store tenant export: %w", err)
+	}
+	return storagePath, size, nil
+}
+
+func (s *TenantDeletionService) writeExport(ctx context.Context, archive *zip.Writer, tenant *models.Tenant) error {
+	if err := writeZipJSON(archive, "tenant.json", tenant); err != nil {
+		return err
+	}
+
+	users := []models.User{}
+	params := repositories.ListParams{Page: 1, PageSize: tenantExportPageSize}
+	for {
+		page, total, err := s.userRepo.ListByTenant(ctx, tenant.ID, params)
+		if err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+		users = append(users, page...)
+		if int64(params.Page*params.PageSize) >= total || len(page) == 0 {
+			break
+		}
+		params.Page++
+	}
+	if err := writeZipJSON(archive, "users.json", users); err != nil {
+		return err
+	}
+
+	// Document metadata goes out as JSON lines, since there may be many
+	entry, err := archive.Create("documents.jsonl")
+	if err != nil {
+		return fmt.Errorf("failed to write tenant export: %w", err)
+	}
+	encoder := json.NewEncoder(entry)
+	documents := []models.Document{}
+	for after := uuid.Nil; ; {
+		page, err := s.repo.ListDocuments(ctx, tenant.ID, after, tenantExportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, document := range page {
+			if err := encoder.Encode(document); err != nil {
+				return fmt.Errorf("failed to write tenant export: %w", err)
+			}
+		}
+		documents = append(documents, page...)
+		if len(page) < tenantExportPageSize {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+	for _, document := range documents {
+		if err := writeZipDocument(ctx, s.storageService, archive, document); err != nil {
+			return err
+		}
+	}
+
+	return s.writeAuditTrail(ctx, archive, tenant.ID)
+}
+
+// writeAuditTrail writes the tenant's audit log, in chain order, as JSON lines
+func (s *TenantDeletionService) writeAuditTrail(ctx context.Context, archive *zip.Writer, tenantID uuid.UUID) error {
+	entry, err := archive.Create("audit_log.jsonl")
+	if err != nil {
+		return fmt.Errorf("failed to write tenant export: %w", err)
+	}
+	encoder := json.NewEncoder(entry)
+
+	var after int64
+	for {
+		logs, err := s.auditRepo.ListChain(ctx, tenantID, after, auditExportPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list audit entries: %w", err)
+		}
+		for _, log := range logs {
+			if err := encoder.Encode(log); err != nil {
+				return fmt.Errorf("failed to write tenant export: %w", err)
+			}
+		}
+		if len(logs) < auditExportPageSize {
+			return nil
+		}
+		after = logs[len(logs)-1].Sequence
+	}
+}
+
+// signOutMembers ends the sessions of everyone but the tenant's admins
+func (s *TenantDeletionService) signOutMembers(ctx context.Context, tenantID, requestedBy uuid.UUID) {
+	if s.sessions == nil {
+		return
+	}
+
+	params := repositories.ListParams{Page: 1, PageSize: tenantExportPageSize}
+	for {
+		users, total, err := s.userRepo.ListByTenant(ctx, tenantID, params)
+		if err != nil {
+			return
+		}
+		for _, user := range users {
+			if user.Role == models.UserRoleAdmin {
+				continue
+			}
+			if err := s.sessions.RevokeAllSessions(ctx, user.ID, requestedBy); err != nil {
+				// Log but don't fail - they can't sign in again
+			}
+		}
+		if int64(params.Page*params.PageSize) >= total || len(users) == 0 {
+			return
+		}
+		params.Page++
+	}
+}
+
+func (s *TenantDeletionService) createAuditLog(deletion *models.TenantDeletion, userID uuid.UUID, action models.AuditAction, message string) {
+	log := &models.AuditLog{
+		TenantID:     deletion.TenantID,
+		UserID:       userID,
+		ResourceID:   deletion.TenantID,
+		Action:       action,
+		ResourceType: "tenant",
+		Details: models.JSONB{
+			"message":     message,
+			"deletion_id": deletion.ID,
+			"reason":      deletion.Reason,
+		},
+	}
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+// checkLegalHolds refuses to delete a tenant with active legal holds
+func (s *TenantDeletionService) checkLegalHolds(ctx context.Context, tenantID uuid.UUID) error {
+	_, active, err := s.legalHoldRepo.ListByTenant(ctx, tenantID, true, repositories.ListParams{Page: 1, PageSize: 1})
+	if err != nil {
+		return fmt.Errorf("failed to check legal holds: %w", err)
+	}
+	if active > 0 {
+		return ErrTenantUnderLegalHold
+	}
+	return nil
+}
+
+func isActiveTenantDeletion(deletion *models.TenantDeletion) bool {
+	switch deletion.Status {
+	case models.TenantDeletionExporting, models.TenantDeletionScheduled, models.TenantDeletionDeleting:
+		return true
+	}
+	return false
+}
+
+func isCancellableTenantDeletion(deletion *models.TenantDeletion) bool {
+	return deletion.Status == models.TenantDeletionExporting || deletion.Status == models.TenantDeletionScheduled
+}
+
+func nextTenantDeletionStage(stage string) string {
+	for i, candidate := range tenantDeletionStages[:len(tenantDeletionStages)-1] {
+		if candidate == stage {
+			return tenantDeletionStages[i+1]
+		}
+	}
+	return TenantDeletionStageDone
+}
+
+func encodeTenantDeletionProgress(progress *TenantDeletionProgress) (models.JSONB, error) {
+	raw, err := json.Marshal(progress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tenant deletion progress: %w", err)
+	}
+	encoded := make(models.JSONB)
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to encode tenant deletion progress: %w", err)
+	}
+	return encoded, nil
+}
+
+func decodeTenantDeletionProgress(encoded models.JSONB) (*TenantDeletionProgress, error) {
+	progress := &TenantDeletionProgress{}
+	if len(encoded) > 0 {
+		raw, err := json.Marshal(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tenant deletion progress: %w", err)
+		}
+		if err := json.Unmarshal(raw, progress); err != nil {
+			return nil, fmt.Errorf("failed to decode tenant deletion progress: %w", err)
+		}
+	}
+	if progress.RowsDeleted == nil {
+		progress.RowsDeleted = make(map[string]int64)
+	}
+	return progress, nil
+}
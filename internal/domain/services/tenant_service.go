@@ -14,14 +14,15 @@ import (
 )
 
 var (
-	ErrTenantNotFound       = errors.New("tenant not found")
-	ErrTenantExists         = errors.New("tenant already exists")
-	ErrInvalidSubdomain     = errors.New("invalid subdomain")
-	ErrSubdomainTaken       = errors.New("subdomain already taken")
-	ErrTrialExpired         = errors.New("trial period expired")
-	ErrSubscriptionInactive = errors.New("subscription inactive")
-	ErrQuotaExceeded        = errors.New("quota exceeded")
-	ErrInvalidBusinessInfo  = errors.New("invalid business information")
+	ErrTenantNotFound        = errors.New("tenant not found")
+	ErrTenantExists          = errors.New("tenant already exists")
+	ErrInvalidSubdomain      = errors.New("invalid subdomain")
+	ErrSubdomainTaken        = errors.New("subdomain already taken")
+	ErrTrialExpired          = errors.New("trial period expired")
+	ErrSubscriptionInactive  = errors.New("subscription inactive")
+	ErrQuotaExceeded         = errors.New("quota exceeded")
+	ErrDocumentQuotaExceeded = errors.New("document quota exceeded")
+	ErrInvalidBusinessInfo   = errors.New("invalid business information")
 )
 
 // TenantService manages multi-tenant functionality
@@ -31,6 +32,7 @@ type TenantService struct {
 	documentRepo repositories.DocumentRepository
 	auditRepo    repositories.AuditLogRepository
 
+	cacheService        CacheService
 	subscriptionService SubscriptionService
 	config              TenantServiceConfig
 }
@@ -42,6 +44,7 @@ type TenantServiceConfig struct {
 	MinSubdomainLength    int
 	ReservedSubdomains    []string
 	DefaultStorageQuota   int64 // bytes
+	DefaultDocumentQuota  int64
 	DefaultAPIQuota       int
 	RequireBusinessInfo   bool
 	EnableCompliance      bool
@@ -55,6 +58,7 @@ func NewTenantService(
 	userRepo repositories.UserRepository,
 	documentRepo repositories.DocumentRepository,
 	auditRepo repositories.AuditLogRepository,
+	cacheService CacheService,
 	subscriptionService SubscriptionService,
 	config TenantServiceConfig,
 ) *TenantService {
@@ -63,6 +67,7 @@ func NewTenantService(
 		userRepo:            userRepo,
 		documentRepo:        documentRepo,
 		auditRepo:           auditRepo,
+		cacheService:        cacheService,
 		subscriptionService: subscriptionService,
 		config:              config,
 	}
@@ -127,6 +132,7 @@ func (s *TenantService) CreateTenant(ctx context.Context, params CreateTenantPar
 		Subdomain:        strings.ToLower(params.Subdomain),
 		SubscriptionTier: params.SubscriptionTier,
 		StorageQuota:     s.getStorageQuotaForTier(params.SubscriptionTier),
+		DocumentQuota:    s.getDocumentQuotaForTier(params.SubscriptionTier),
 		APIQuota:         s.getAPIQuotaForTier(params.SubscriptionTier),
 		Settings:         models.JSONB(params.Settings),
 		IsActive:         true,
@@ -271,7 +277,26 @@ func (s *TenantService) UpdateTenant(ctx context.Context, tenantID uuid.UUID, up
 	if settings, ok := updates["settings"].(map[string]interface{}); ok {
 		// Merge with existing settings
 		existingSettings := map[string]interface{}(tenant.Settings)
+		if existingSettings == nil {
+			existingSettings = map[string]interface{}{}
+		}
 		for key, value := range settings {
+			// Settings with APIs of their own are validated there
+			if managedTenantSettings[key] {
+				continue
+			}
+			// Known settings are validated as the settings API does
+			if definition, ok := tenantSettingDefinition(key); ok {
+				stored, err := normalizeTenantSetting(definition, value)
+				if err != nil {
+					return nil, err
+				}
+				if stored == nil {
+					delete(existingSettings, key)
+					continue
+				}
+				value = stored
+			}
 			existingSettings[key] = value
 		}
 		tenant.Settings = models.JSONB(existingSettings)
@@ -282,6 +307,7 @@ func (s *TenantService) UpdateTenant(ctx context.Context, tenantID uuid.UUID, up
 	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
 		return nil, fmt.Errorf("failed to update tenant: %w", err)
 	}
+	s.invalidateSettings(ctx, tenantID)
 
 	// Create audit log
 	s.createAuditLog(ctx, tenantID, updatedBy, tenantID, models.AuditUpdate, "Tenant updated")
@@ -304,6 +330,7 @@ func (s *TenantService) UpgradeSubscription(ctx context.Context, tenantID uuid.U
 	// Update tenant subscription
 	tenant.SubscriptionTier = newTier
 	tenant.StorageQuota = s.getStorageQuotaForTier(newTier)
+	tenant.DocumentQuota = s.getDocumentQuotaForTier(newTier)
 	tenant.APIQuota = s.getAPIQuotaForTier(newTier)
 
 	// Remove trial if upgrading from starter
@@ -439,10 +466,17 @@ func (s *TenantService) CheckTenantHealth(ctx context.Context, tenantID uuid.UUI
 		if quotaStatus.APIPercent > 90 {
 			health.Warnings = append(health.Warnings, "API quota nearly exceeded")
 		}
+		if quotaStatus.DocumentPercent > 90 {
+			health.Warnings = append(health.Warnings, "Document quota nearly exceeded")
+		}
 		if !quotaStatus.CanUpload {
 			health.IsHealthy = false
 			health.Issues = append(health.Issues, "Storage quota exceeded")
 		}
+		if !quotaStatus.CanAddDocument {
+			health.IsHealthy = false
+			health.Issues = append(health.Issues, "Document quota exceeded")
+		}
 	}
 
 	return health, nil
@@ -518,17 +552,18 @@ func (s *TenantService) getStorageQuotaForTier(tier models.SubscriptionTier) int
 	}
 }
 
+func (s *TenantService) getDocumentQuotaForTier(tier models.SubscriptionTier) int64 {
+	if quota, ok := models.TierDocumentQuotas[tier]; ok {
+		return quota
+	}
+	return s.config.DefaultDocumentQuota
+}
+
 func (s *TenantService) getAPIQuotaForTier(tier models.SubscriptionTier) int {
-	switch tier {
-	case models.SubscriptionStarter:
-		return 1000
-	case models.SubscriptionProfessional:
-		return 10000
-	case models.SubscriptionEnterprise:
-		return 100000
-	default:
-		return s.config.DefaultAPIQuota
+	if quota, ok := models.TierAPIRequestQuotas[tier]; ok {
+		return int(quota)
 	}
+	return s.config.DefaultAPIQuota
 }
 
 func (s *TenantService) getDefaultRetentionPolicy(industry string) models.JSONB {
@@ -640,7 +675,7 @@ func (s *TenantService) createAuditLog(ctx context.Context, tenantID, userID, re
 	}
 
 	go func() {
-		s.auditRepo.Create(context.Background(), log)
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
 	}()
 }
 
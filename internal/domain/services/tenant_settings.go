@@ -0,0 +1,502 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownTenantSetting = errors.New("unknown tenant setting")
+	ErrInvalidTenantSetting = errors.New("invalid tenant setting")
+)
+
+// Tenant settings keys that only the settings API writes. Older keys read
+// elsewhere (sms_enabled, ai_dry_run, the email sender) keep their names.
+const (
+	tenantUploadDocumentTypeSetting   = "upload_default_document_type"
+	tenantUploadTagsSetting           = "upload_default_tags"
+	tenantAIProcessingSetting         = "ai_processing_enabled"
+	tenantAIProviderSetting           = "ai_provider"
+	tenantAIFilingSuggestionsSetting  = "ai_filing_suggestions"
+	tenantNotificationChannelsSetting = "notification_default_channels"
+	tenantBrandingDisplayNameSetting  = "branding_display_name"
+	tenantBrandingLogoURLSetting      = "branding_logo_url"
+	tenantBrandingPrimaryColorSetting = "branding_primary_color"
+)
+
+// Bounds of setting values without limits of their own
+const (
+	maxTenantSettingListItems = 20
+	maxTenantSettingLength    = 255
+)
+
+// Tenant setting value types
+const (
+	TenantSettingBool       = "bool"
+	TenantSettingString     = "string"
+	TenantSettingURL        = "url" // https only
+	TenantSettingEmail      = "email"
+	TenantSettingColor      = "color" // #rrggbb
+	TenantSettingEnum       = "enum"
+	TenantSettingStringList = "string_list"
+)
+
+// Tenant setting groups, for laying settings out in the UI
+const (
+	TenantSettingGroupUploads       = "uploads"
+	TenantSettingGroupAI            = "ai"
+	TenantSettingGroupNotifications = "notifications"
+	TenantSettingGroupBranding      = "branding"
+)
+
+var tenantSettingColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// TenantSettingDefinition describes a known tenant setting: its type, the
+// value tenants that never set it get, and what it accepts
+type TenantSettingDefinition struct {
+	Key         string      `json:"key"`
+	Group       string      `json:"group"`
+	Type        string      `json:"type"`
+	Default     interface{} `json:"default"`
+	Values      []string    `json:"values,omitempty"`     // Allowed values of enums and lists; any when empty
+	MaxLength   int         `json:"max_length,omitempty"` // Of strings and list items
+	Secret      bool        `json:"secret,omitempty"`     // Kept out of audit logs
+	Description string      `json:"description"`
+}
+
+// tenantSettingSchema lists the settings the settings API accepts. Each key
+// matches a TenantSettings field's JSON name. Settings with APIs of their own,
+// such as the network policy or preview rules, aren't part of it.
+var tenantSettingSchema = []TenantSettingDefinition{
+	{
+		Key:   tenantUploadDocumentTypeSetting,
+		Group: TenantSettingGroupUploads,
+		Type:  TenantSettingEnum,
+		Values: []string{
+			string(models.DocTypeInvoice), string(models.DocTypeReceipt), string(models.DocTypeContract),
+			string(models.DocTypeSpreadsheet), string(models.DocTypePresentationn), string(models.DocTypeReport),
+			string(models.DocTypeTaxDocument), string(models.DocTypePayroll), string(models.DocTypeBankStatement),
+			string(models.DocTypeInsurance), string(models.DocTypeLegal), string(models.DocTypeHR),
+			string(models.DocTypeMarketing), string(models.DocTypeGeneral),
+		},
+		Description: "Document type given to uploads that don't name one and whose file name suggests none",
+	},
+	{
+		Key:         tenantUploadTagsSetting,
+		Group:       TenantSettingGroupUploads,
+		Type:        TenantSettingStringList,
+		Default:     []string{},
+		MaxLength:   50,
+		Description: "Tags given to uploads that don't name any",
+	},
+	{
+		Key:         tenantAIProcessingSetting,
+		Group:       TenantSettingGroupAI,
+		Type:        TenantSettingBool,
+		Default:     true,
+		Description: "Queue AI processing for uploads that ask for it; off skips it for every upload",
+	},
+	{
+		Key:         tenantAIProviderSetting,
+		Group:       TenantSettingGroupAI,
+		Type:        TenantSettingEnum,
+		Values:      []string{AIProviderOpenAI, AIProviderAnthropic, AIProviderGemini, AIProviderOllama},
+		Description: "Provider every AI job runs on, e.g. ollama to keep documents on the deployment's own models; defaults to the deployment's choice per job type",
+	},
+	{
+		Key:         tenantAIFilingSuggestionsSetting,
+		Group:       TenantSettingGroupAI,
+		Type:        TenantSettingBool,
+		Default:     false,
+		Description: "Suggest a folder, category and tags for AI-processed uploads that aren't filed in a folder",
+	},
+	{
+		Key:         tenantAIDryRunSetting,
+		Group:       TenantSettingGroupAI,
+		Type:        TenantSettingBool,
+		Default:     false,
+		Description: "Run AI jobs with canned results instead of calling providers",
+	},
+	{
+		Key:   tenantNotificationChannelsSetting,
+		Group: TenantSettingGroupNotifications,
+		Type:  TenantSettingStringList,
+		Default: []string{
+			string(models.NotifyInApp), string(models.NotifyPush), string(models.NotifyEmail), string(models.NotifySlack),
+		},
+		Values: []string{
+			string(models.NotifyInApp), string(models.NotifyPush), string(models.NotifyEmail), string(models.NotifySlack),
+		},
+		Description: "Channels notifications go out on when they don't name any; users can still opt out of each",
+	},
+	{
+		Key:         tenantSMSEnabledSetting,
+		Group:       TenantSettingGroupNotifications,
+		Type:        TenantSettingBool,
+		Default:     false,
+		Description: "Text critical notifications to users with a verified phone number",
+	},
+	{
+		Key:         tenantSlackWebhookSetting,
+		Group:       TenantSettingGroupNotifications,
+		Type:        TenantSettingURL,
+		Secret:      true,
+		Description: "Slack incoming webhook receiving the tenant's Slack notifications",
+	},
+	{
+		Key:         tenantEmailFromNameSetting,
+		Group:       TenantSettingGroupNotifications,
+		Type:        TenantSettingString,
+		MaxLength:   100,
+		Description: "Sender name of the tenant's emails; defaults to the tenant's name",
+	},
+	{
+		Key:         tenantEmailFromAddressSetting,
+		Group:       TenantSettingGroupNotifications,
+		Type:        TenantSettingEmail,
+		Description: "Sender address of the tenant's emails; only used on a domain verified for this server",
+	},
+	{
+		Key:         tenantEmailReplyToSetting,
+		Group:       TenantSettingGroupNotifications,
+		Type:        TenantSettingEmail,
+		Description: "Where replies to the tenant's emails go",
+	},
+	{
+		Key:         tenantBrandingDisplayNameSetting,
+		Group:       TenantSettingGroupBranding,
+		Type:        TenantSettingString,
+		MaxLength:   100,
+		Description: "Name shown in the app instead of the tenant's name",
+	},
+	{
+		Key:         tenantBrandingLogoURLSetting,
+		Group:       TenantSettingGroupBranding,
+		Type:        TenantSettingURL,
+		MaxLength:   2048,
+		Description: "Logo shown in the app",
+	},
+	{
+		Key:         tenantBrandingPrimaryColorSetting,
+		Group:       TenantSettingGroupBranding,
+		Type:        TenantSettingColor,
+		Description: "Primary color of the app, as #rrggbb",
+	},
+}
+
+// managedTenantSettings are the settings keys written through their own
+// APIs, which validate them and clear their caches. Generic settings
+// updates leave them alone.
+var managedTenantSettings = map[string]bool{
+	tenantNetworkPolicySetting:       true,
+	tenantAbuseProtectionSetting:     true,
+	tenantEmailInboxSetting:          true,
+	tenantPreviewRulesSetting:        true,
+	tenantWatchedFoldersSetting:      true,
+	tenantBusinessCalendarSetting:    true,
+	tenantAccountingSetting:          true,
+	tenantIntegrationMappingsSetting: true,
+	tenantAuditRetentionSetting:      true,
+	tenantAIBudgetSetting:            true,
+}
+
+// TenantSettings are a tenant's known settings, with the defaults filled in
+// for those it never set
+type TenantSettings struct {
+	UploadDefaultDocumentType   models.DocumentType          `json:"upload_default_document_type"`
+	UploadDefaultTags           []string                     `json:"upload_default_tags"`
+	AIProcessingEnabled         bool                         `json:"ai_processing_enabled"`
+	AIProvider                  string                       `json:"ai_provider"`
+	AIFilingSuggestions         bool                         `json:"ai_filing_suggestions"`
+	AIDryRun                    bool                         `json:"ai_dry_run"`
+	NotificationDefaultChannels []models.NotificationChannel `json:"notification_default_channels"`
+	SMSEnabled                  bool                         `json:"sms_enabled"`
+	SlackWebhookURL             string                       `json:"slack_webhook_url"`
+	EmailFromName               string                       `json:"email_from_name"`
+	EmailFromAddress            string                       `json:"email_from_address"`
+	EmailReplyTo                string                       `json:"email_reply_to"`
+	BrandingDisplayName         string                       `json:"branding_display_name"`
+	BrandingLogoURL             string                       `json:"branding_logo_url"`
+	BrandingPrimaryColor        string                       `json:"branding_primary_color"`
+}
+
+// TenantSettingsSchema returns the settings the settings API accepts
+func TenantSettingsSchema() []TenantSettingDefinition {
+	return tenantSettingSchema
+}
+
+// GetSettings returns the tenant's known settings
+func (s *TenantService) GetSettings(ctx context.Context, tenantID uuid.UUID) (*TenantSettings, error) {
+	return loadTenantSettings(ctx, s.tenantRepo, s.cacheService, tenantID)
+}
+
+// UpdateSettings validates and applies changes to the tenant's known
+// settings. A null value resets a setting to its default; settings left out
+// keep their values.
+func (s *TenantService) UpdateSettings(ctx context.Context, tenantID, userID uuid.UUID, changes map[string]interface{}) (*TenantSettings, error) {
+	normalized := make(map[string]interface{}, len(changes))
+	for key, value := range changes {
+		definition, ok := tenantSettingDefinition(key)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTenantSetting, key)
+		}
+		stored, err := normalizeTenantSetting(definition, value)
+		if err != nil {
+			return nil, err
+		}
+		normalized[key] = stored
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	if tenant.Settings == nil {
+		tenant.Settings = models.JSONB{}
+	}
+
+	audited := make(map[string]interface{})
+	for key, value := range normalized {
+		previous, had := tenant.Settings[key]
+		if value == nil {
+			if !had {
+				continue
+			}
+			delete(tenant.Settings, key)
+		} else {
+			if had && reflect.DeepEqual(jsonValue(previous), jsonValue(value)) {
+				continue
+			}
+			tenant.Settings[key] = value
+		}
+		audited[key] = auditedTenantSetting(key, previous, value)
+	}
+
+	if len(audited) > 0 {
+		tenant.UpdatedAt = time.Now()
+		if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+			return nil, fmt.Errorf("failed to update tenant settings: %w", err)
+		}
+		s.invalidateSettings(ctx, tenantID)
+
+		log := &models.AuditLog{
+			TenantID:     tenantID,
+			UserID:       userID,
+			ResourceID:   tenantID,
+			Action:       models.AuditUpdate,
+			ResourceType: "tenant_settings",
+			Details: models.JSONB{
+				"message": "Tenant settings updated",
+				"changes": audited,
+			},
+		}
+		// Don't block on audit log creation
+		go func() {
+			s.auditRepo.Create(context.WithoutCancel(ctx), log)
+		}()
+	}
+
+	return resolveTenantSettings(tenant), nil
+}
+
+func (s *TenantService) invalidateSettings(ctx context.Context, tenantID uuid.UUID) {
+	if s.cacheService != nil {
+		if err := s.cacheService.Delete(ctx, fmt.Sprintf(TenantSettingsKeyPattern, tenantID.String())); err != nil {
+			// Log but don't fail - the cached settings expire on their own
+		}
+	}
+}
+
+// Helper functions
+
+// loadTenantSettings reads a tenant's known settings, through the cache
+// when there is one. Services reading settings on every upload or
+// notification use it rather than loading the tenant.
+func loadTenantSettings(ctx context.Context, tenantRepo repositories.TenantRepository, cacheService CacheService, tenantID uuid.UUID) (*TenantSettings, error) {
+	cacheKey := fmt.Sprintf(TenantSettingsKeyPattern, tenantID.String())
+	if cacheService != nil {
+		if cached, err := cacheService.Get(ctx, cacheKey); err == nil {
+			var settings TenantSettings
+			if json.Unmarshal([]byte(cached), &settings) == nil {
+				return &settings, nil
+			}
+		}
+	}
+
+	tenant, err := tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	settings := resolveTenantSettings(tenant)
+
+	if cacheService != nil {
+		if data, err := json.Marshal(settings); err == nil {
+			cacheService.Set(ctx, cacheKey, string(data), CacheShortTerm)
+		}
+	}
+	return settings, nil
+}
+
+// resolveTenantSettings fills in the defaults of the settings the tenant
+// never set. Stored values that no longer validate, such as ones written
+// before the schema existed, fall back to the default too.
+func resolveTenantSettings(tenant *models.Tenant) *TenantSettings {
+	values := make(map[string]interface{}, len(tenantSettingSchema))
+	for _, definition := range tenantSettingSchema {
+		values[definition.Key] = definition.Default
+		if raw, ok := tenant.Settings[definition.Key]; ok {
+			if value, err := normalizeTenantSetting(definition, raw); err == nil && value != nil {
+				values[definition.Key] = value
+			}
+		}
+	}
+
+	settings := &TenantSettings{}
+	if data, err := json.Marshal(values); err == nil {
+		json.Unmarshal(data, settings)
+	}
+	return settings
+}
+
+func tenantSettingDefinition(key string) (TenantSettingDefinition, bool) {
+	for _, definition := range tenantSettingSchema {
+		if definition.Key == key {
+			return definition, true
+		}
+	}
+	return TenantSettingDefinition{}, false
+}
+
+// normalizeTenantSetting validates a setting's value and returns it as it's
+// stored. Nil, empty strings and empty lists mean the setting is reset.
+func normalizeTenantSetting(definition TenantSettingDefinition, value interface{}) (interface{}, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: %s %s", ErrInvalidTenantSetting, definition.Key, reason)
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	if definition.Type == TenantSettingBool {
+		enabled, ok := value.(bool)
+		if !ok {
+			return nil, invalid("must be true or false")
+		}
+		return enabled, nil
+	}
+
+	if definition.Type == TenantSettingStringList {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, invalid("must be a list of strings")
+		}
+		if len(items) > maxTenantSettingListItems {
+			return nil, invalid(fmt.Sprintf("can have at most %d items", maxTenantSettingListItems))
+		}
+		list := make([]string, 0, len(items))
+		for _, item := range items {
+			str, ok := item.(string)
+			if !ok {
+				return nil, invalid("must be a list of strings")
+			}
+			str = strings.TrimSpace(str)
+			if str == "" || slices.Contains(list, str) {
+				continue
+			}
+			if err := checkTenantSettingString(definition, str); err != nil {
+				return nil, invalid(err.Error())
+			}
+			list = append(list, str)
+		}
+		if len(list) == 0 {
+			return nil, nil
+		}
+		return list, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, invalid("must be a string")
+	}
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return nil, nil
+	}
+	if err := checkTenantSettingString(definition, str); err != nil {
+		return nil, invalid(err.Error())
+	}
+
+	switch definition.Type {
+	case TenantSettingURL:
+		parsed, err := url.Parse(str)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return nil, invalid("must be an https URL")
+		}
+	case TenantSettingEmail:
+		address, err := mail.ParseAddress(str)
+		if err != nil {
+			return nil, invalid("must be an email address")
+		}
+		str = address.Address
+	case TenantSettingColor:
+		if !tenantSettingColorPattern.MatchString(str) {
+			return nil, invalid("must be a color like #1a2b3c")
+		}
+		str = strings.ToLower(str)
+	}
+	return str, nil
+}
+
+// checkTenantSettingString checks a string or list item against the
+// setting's allowed values and length
+func checkTenantSettingString(definition TenantSettingDefinition, value string) error {
+	if len(definition.Values) > 0 && !slices.Contains(definition.Values, value) {
+		return fmt.Errorf("must be one of %s", strings.Join(definition.Values, ", "))
+	}
+	maxLength := definition.MaxLength
+	if maxLength == 0 {
+		maxLength = maxTenantSettingLength
+	}
+	if len([]rune(value)) > maxLength {
+		return fmt.Errorf("can be at most %d characters", maxLength)
+	}
+	return nil
+}
+
+// auditedTenantSetting records a setting's change, without the values of secrets
+func auditedTenantSetting(key string, previous, value interface{}) map[string]interface{} {
+	if definition, ok := tenantSettingDefinition(key); ok && definition.Secret {
+		if previous != nil {
+			previous = "[redacted]"
+		}
+		if value != nil {
+			value = "[redacted]"
+		}
+	}
+	return map[string]interface{}{"from": previous, "to": value}
+}
+
+// jsonValue is a value as it reads back from JSONB, so stored and new
+// values compare equal
+func jsonValue(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded interface{}
+	json.Unmarshal(data, &decoded)
+	return decoded
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantSettingsSchema_MatchesSettings(t *testing.T) {
+	data, err := json.Marshal(TenantSettings{})
+	require.NoError(t, err)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &fields))
+
+	require.Len(t, tenantSettingSchema, len(fields))
+	for _, definition := range tenantSettingSchema {
+		assert.Contains(t, fields, definition.Key)
+		assert.False(t, managedTenantSettings[definition.Key], "%s has an API of its own", definition.Key)
+		if definition.Default != nil {
+			value, err := normalizeTenantSetting(definition, jsonValue(definition.Default))
+			require.NoError(t, err, definition.Key)
+			if value != nil {
+				assert.Equal(t, jsonValue(definition.Default), jsonValue(value), definition.Key)
+			}
+		}
+	}
+}
+
+func TestNormalizeTenantSetting(t *testing.T) {
+	definition := func(key string) TenantSettingDefinition {
+		definition, ok := tenantSettingDefinition(key)
+		require.True(t, ok)
+		return definition
+	}
+
+	value, err := normalizeTenantSetting(definition(tenantBrandingPrimaryColorSetting), " #1A2B3C ")
+	require.NoError(t, err)
+	assert.Equal(t, "#1a2b3c", value)
+	_, err = normalizeTenantSetting(definition(tenantBrandingPrimaryColorSetting), "blue")
+	assert.ErrorIs(t, err, ErrInvalidTenantSetting)
+
+	value, err = normalizeTenantSetting(definition(tenantEmailReplyToSetting), "Accounts <ap@example.com>")
+	require.NoError(t, err)
+	assert.Equal(t, "ap@example.com", value)
+
+	_, err = normalizeTenantSetting(definition(tenantSlackWebhookSetting), "http://hooks.slack.com/x")
+	assert.ErrorIs(t, err, ErrInvalidTenantSetting)
+
+	_, err = normalizeTenantSetting(definition(tenantAIProcessingSetting), "yes")
+	assert.ErrorIs(t, err, ErrInvalidTenantSetting)
+
+	_, err = normalizeTenantSetting(definition(tenantUploadDocumentTypeSetting), "memo")
+	assert.ErrorIs(t, err, ErrInvalidTenantSetting)
+
+	value, err = normalizeTenantSetting(definition(tenantUploadTagsSetting), []interface{}{"inbox", " inbox ", ""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"inbox"}, value)
+
+	_, err = normalizeTenantSetting(definition(tenantNotificationChannelsSetting), []interface{}{"email", "fax"})
+	assert.ErrorIs(t, err, ErrInvalidTenantSetting)
+
+	// Empty values reset the setting
+	value, err = normalizeTenantSetting(definition(tenantBrandingLogoURLSetting), "  ")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	value, err = normalizeTenantSetting(definition(tenantNotificationChannelsSetting), []interface{}{})
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func TestResolveTenantSettings(t *testing.T) {
+	settings := resolveTenantSettings(&models.Tenant{})
+	assert.True(t, settings.AIProcessingEnabled)
+	assert.Empty(t, settings.UploadDefaultTags)
+	assert.Equal(t, []models.NotificationChannel{models.NotifyInApp, models.NotifyPush, models.NotifyEmail, models.NotifySlack},
+		settings.NotificationDefaultChannels)
+
+	settings = resolveTenantSettings(&models.Tenant{Settings: models.JSONB{
+		tenantAIProcessingSetting:         false,
+		tenantNotificationChannelsSetting: []interface{}{"email"},
+		tenantSMSEnabledSetting:           true,
+		// Written before the schema existed; falls back to the default
+		tenantBrandingPrimaryColorSetting: "blue",
+	}})
+	assert.False(t, settings.AIProcessingEnabled)
+	assert.Equal(t, []models.NotificationChannel{models.NotifyEmail}, settings.NotificationDefaultChannels)
+	assert.True(t, settings.SMSEnabled)
+	assert.Empty(t, settings.BrandingPrimaryColor)
+}
+
+func TestAuditedTenantSetting_RedactsSecrets(t *testing.T) {
+	change := auditedTenantSetting(tenantSlackWebhookSetting, nil, "https://hooks.slack.com/services/x")
+	assert.Equal(t, map[string]interface{}{"from": nil, "to": "[redacted]"}, change)
+
+	change = auditedTenantSetting(tenantSMSEnabledSetting, false, true)
+	assert.Equal(t, map[string]interface{}{"from": false, "to": true}, change)
+}
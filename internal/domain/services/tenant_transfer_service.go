@@ -0,0 +1,831 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidTenantArchive     = errors.New("not a valid tenant archive")
+	ErrUnsupportedTenantArchive = errors.New("tenant archive format is not supported by this version")
+	ErrTenantNotEmpty           = errors.New("tenant already has documents")
+)
+
+// TenantArchiveFormat is the version of the archive layout Export writes
+const TenantArchiveFormat = 1
+
+var importSubdomainPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// TenantTransferService moves a tenant between Archivus instances. Export
+// writes the tenant's users, folders, tags, categories, workflows and
+// documents with their files into a zip archive; Import recreates them
+// under new IDs, either as a new tenant or into an existing one.
+type TenantTransferService struct {
+	repo           repositories.TenantTransferRepository
+	tenantRepo     repositories.TenantRepository
+	userRepo       repositories.UserRepository
+	auditRepo      repositories.AuditLogRepository
+	storageService StorageService
+}
+
+// NewTenantTransferService creates a new tenant transfer service
+func NewTenantTransferService(
+	repo repositories.TenantTransferRepository,
+	tenantRepo repositories.TenantRepository,
+	userRepo repositories.UserRepository,
+	auditRepo repositories.AuditLogRepository,
+	storageService StorageService,
+) *TenantTransferService {
+	return &TenantTransferService{
+		repo:           repo,
+		tenantRepo:     tenantRepo,
+		userRepo:       userRepo,
+		auditRepo:      auditRepo,
+		storageService: storageService,
+	}
+}
+
+// TenantArchiveManifest describes a tenant archive
+type TenantArchiveManifest struct {
+	Format     int       `json:"format"`
+	TenantID   uuid.UUID `json:"tenant_id"`
+	Subdomain  string    `json:"subdomain"`
+	ExportedAt time.Time `json:"exported_at"`
+	Users      int       `json:"users"`
+	Folders    int       `json:"folders"`
+	Tags       int       `json:"tags"`
+	Categories int       `json:"categories"`
+	Workflows  int       `json:"workflows"`
+	Documents  int       `json:"documents"`
+	FileBytes  int64     `json:"file_bytes"`
+}
+
+// Archive rows embed the models. Relationships are shadowed so they aren't
+// written as empty objects; rows refer to each other by ID.
+//
+// Users are archived without their credentials: whoever holds an archive
+// could otherwise crack the password hashes and generate MFA codes. Imported
+// users reset their password and enrol in MFA again.
+type archivedUser struct {
+	models.User
+	Tenant *struct{} `json:"tenant,omitempty"`
+}
+
+type archivedFolder struct {
+	models.Folder
+	Tenant  *struct{} `json:"tenant,omitempty"`
+	Creator *struct{} `json:"creator,omitempty"`
+}
+
+type archivedTag struct {
+	models.Tag
+	Tenant *struct{} `json:"tenant,omitempty"`
+}
+
+type archivedCategory struct {
+	models.Category
+	Tenant *struct{} `json:"tenant,omitempty"`
+}
+
+type archivedWorkflow struct {
+	models.Workflow
+	Tenant  *struct{} `json:"tenant,omitempty"`
+	Creator *struct{} `json:"creator,omitempty"`
+}
+
+type archivedDocument struct {
+	models.Document
+	Tenant     *struct{}   `json:"tenant,omitempty"`
+	Creator    *struct{}   `json:"creator,omitempty"`
+	Tags       []uuid.UUID `json:"tags,omitempty"`
+	Categories []uuid.UUID `json:"categories,omitempty"`
+}
+
+// tenantArchiveFile is where a document's file is kept in the archive
+func tenantArchiveFile(documentID uuid.UUID) string {
+	return "files/" + documentID.String()
+}
+
+// Export writes the tenant's archive to w. Documents in the trash are left
+// out.
+func (s *TenantTransferService) Export(ctx context.Context, tenantID, exportedBy uuid.UUID, w io.Writer) (*TenantArchiveManifest, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	users, err := s.listUsers(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	folders, err := s.repo.ListFolders(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.repo.ListTags(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.repo.ListCategories(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	workflows, err := s.repo.ListWorkflows(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &TenantArchiveManifest{
+		Format:     TenantArchiveFormat,
+		TenantID:   tenant.ID,
+		Subdomain:  tenant.Subdomain,
+		ExportedAt: time.Now(),
+		Users:      len(users),
+		Folders:    len(folders),
+		Tags:       len(tags),
+		Categories: len(categories),
+		Workflows:  len(workflows),
+	}
+
+	archive := zip.NewWriter(w)
+	if err := writeZipJSON(archive, "tenant.json", tenant); err != nil {
+		return nil, err
+	}
+
+	archivedUsers := make([]archivedUser, len(users))
+	for i, user := range users {
+		archivedUsers[i] = archivedUser{User: user}
+	}
+	if err := writeZipJSON(archive, "users.json", archivedUsers); err != nil {
+		return nil, err
+	}
+	archivedFolders := make([]archivedFolder, len(folders))
+	for i, folder := range folders {
+		archivedFolders[i] = archivedFolder{Folder: folder}
+	}
+	if err := writeZipJSON(archive, "folders.json", archivedFolders); err != nil {
+		return nil, err
+	}
+	archivedTags := make([]archivedTag, len(tags))
+	for i, tag := range tags {
+		archivedTags[i] = archivedTag{Tag: tag}
+	}
+	if err := writeZipJSON(archive, "tags.json", archivedTags); err != nil {
+		return nil, err
+	}
+	archivedCategories := make([]archivedCategory, len(categories))
+	for i, category := range categories {
+		archivedCategories[i] = archivedCategory{Category: category}
+	}
+	if err := writeZipJSON(archive, "categories.json", archivedCategories); err != nil {
+		return nil, err
+	}
+	archivedWorkflows := make([]archivedWorkflow, len(workflows))
+	for i, workflow := range workflows {
+		archivedWorkflows[i] = archivedWorkflow{Workflow: workflow}
+	}
+	if err := writeZipJSON(archive, "workflows.json", archivedWorkflows); err != nil {
+		return nil, err
+	}
+
+	// Document metadata goes out as JSON lines, since there may be many;
+	// their files follow once it's written
+	entry, err := archive.Create("documents.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write tenant archive: %w", err)
+	}
+	encoder := json.NewEncoder(entry)
+	documents := []models.Document{}
+	for after := uuid.Nil; ; {
+		page, err := s.repo.ListDocuments(ctx, tenantID, after, tenantExportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, document := range page {
+			if err := encoder.Encode(archiveDocument(document)); err != nil {
+				return nil, fmt.Errorf("failed to write tenant archive: %w", err)
+			}
+			documents = append(documents, models.Document{ID: document.ID, StoragePath: document.StoragePath, FileSize: document.FileSize})
+		}
+		if len(page) < tenantExportPageSize {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+	manifest.Documents = len(documents)
+
+	for _, document := range documents {
+		if err := s.writeFile(ctx, archive, document); err != nil {
+			return nil, err
+		}
+		manifest.FileBytes += document.FileSize
+	}
+
+	if err := writeZipJSON(archive, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write tenant archive: %w", err)
+	}
+
+	s.createAuditLog(tenantID, exportedBy, models.AuditDownload,
+		fmt.Sprintf("Tenant exported with %d users and %d documents", manifest.Users, manifest.Documents))
+
+	return manifest, nil
+}
+
+// TenantImportOptions chooses where an archive is imported. With a
+// TenantID the archive goes into that tenant, which must not hold any
+// documents yet; otherwise a new tenant is created on Subdomain, named Name
+// or after the exported tenant.
+type TenantImportOptions struct {
+	TenantID   *uuid.UUID
+	Subdomain  string
+	Name       string
+	ImportedBy uuid.UUID // uuid.Nil for imports run by an operator
+}
+
+// TenantImportCount counts rows created by an import, and those matched to
+// rows the target tenant already had
+type TenantImportCount struct {
+	Created int `json:"created"`
+	Matched int `json:"matched"`
+}
+
+// TenantImportResult summarizes an import
+type TenantImportResult struct {
+	TenantID   uuid.UUID         `json:"tenant_id"`
+	Subdomain  string            `json:"subdomain"`
+	Source     uuid.UUID         `json:"source_tenant_id"`
+	Users      TenantImportCount `json:"users"`
+	Folders    TenantImportCount `json:"folders"`
+	Tags       TenantImportCount `json:"tags"`
+	Categories TenantImportCount `json:"categories"`
+	Workflows  int               `json:"workflows"`
+	Documents  int               `json:"documents"`
+	FileBytes  int64             `json:"file_bytes"`
+}
+
+// Import recreates an archived tenant. Every row gets a new ID, except
+// users, who keep theirs unless the target instance already uses it.
+// Users, folders, tags and categories the target already has, matched by
+// email, path or name, are reused. Workflow rules naming users by ID are
+// pointed at the users' new IDs. Created users have no password or MFA until
+// they reset their password and enrol again.
+func (s *TenantTransferService) Import(ctx context.Context, archive io.ReaderAt, size int64, opts TenantImportOptions) (*TenantImportResult, error) {
+	reader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return nil, ErrInvalidTenantArchive
+	}
+	entries := make(map[string]*zip.File, len(reader.File))
+	for _, file := range reader.File {
+		entries[file.Name] = file
+	}
+
+	var manifest TenantArchiveManifest
+	if err := readZipJSON(entries, "manifest.json", &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != TenantArchiveFormat {
+		return nil, fmt.Errorf("%w: format %d", ErrUnsupportedTenantArchive, manifest.Format)
+	}
+
+	var source models.Tenant
+	var users []archivedUser
+	var folders []archivedFolder
+	var tags []archivedTag
+	var categories []archivedCategory
+	var workflows []archivedWorkflow
+	for name, value := range map[string]interface{}{
+		"tenant.json":     &source,
+		"users.json":      &users,
+		"folders.json":    &folders,
+		"tags.json":       &tags,
+		"categories.json": &categories,
+		"workflows.json":  &workflows,
+	} {
+		if err := readZipJSON(entries, name, value); err != nil {
+			return nil, err
+		}
+	}
+	documents, err := readArchivedDocuments(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileBytes int64
+	for _, document := range documents {
+		if entries[tenantArchiveFile(document.ID)] == nil {
+			return nil, fmt.Errorf("%w: file of document %s is missing", ErrInvalidTenantArchive, document.ID)
+		}
+		fileBytes += document.FileSize
+	}
+
+	// The target: an existing tenant without documents, or a new one
+	data := &repositories.TenantImportData{}
+	var target *models.Tenant
+	if opts.TenantID != nil {
+		target, err = s.tenantRepo.GetByID(ctx, *opts.TenantID)
+		if err != nil {
+			return nil, ErrTenantNotFound
+		}
+		count, err := s.repo.CountDocuments(ctx, target.ID)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrTenantNotEmpty
+		}
+	} else {
+		target, err = s.newTenant(ctx, &source, opts, int64(len(documents)), fileBytes)
+		if err != nil {
+			return nil, err
+		}
+		data.Tenant = target
+	}
+
+	plan, err := s.planImport(ctx, target.ID, opts.ImportedBy, users, folders, tags, categories, workflows, documents)
+	if err != nil {
+		return nil, err
+	}
+	data.Users = plan.users
+	data.Folders = plan.folders
+	data.Tags = plan.tags
+	data.Categories = plan.categories
+	data.Workflows = plan.workflows
+	data.Documents = plan.documents
+
+	// Imports into an existing tenant count against its quotas
+	if data.Tenant == nil {
+		reserved, err := s.tenantRepo.ReserveDocumentSlots(ctx, target.ID, int64(len(documents)))
+		if err != nil {
+			return nil, err
+		}
+		if !reserved {
+			return nil, ErrDocumentQuotaExceeded
+		}
+		reserved, err = s.tenantRepo.ReserveStorage(ctx, target.ID, fileBytes)
+		if err != nil || !reserved {
+			s.tenantRepo.ReleaseDocumentSlots(ctx, target.ID, int64(len(documents)))
+			if err != nil {
+				return nil, err
+			}
+			return nil, ErrQuotaExceeded
+		}
+	}
+
+	stored, err := s.storeFiles(ctx, entries, target.ID, documents, data.Documents)
+	if err == nil {
+		err = s.repo.Import(ctx, data)
+	}
+	if err != nil {
+		for _, path := range stored {
+			// Log but don't fail - repair removes orphaned files
+			s.storageService.Delete(ctx, path)
+		}
+		if data.Tenant == nil {
+			s.tenantRepo.ReleaseDocumentSlots(ctx, target.ID, int64(len(documents)))
+			s.tenantRepo.ReleaseStorage(ctx, target.ID, fileBytes)
+		}
+		return nil, err
+	}
+
+	plan.result.TenantID = target.ID
+	plan.result.Subdomain = target.Subdomain
+	plan.result.Source = manifest.TenantID
+	plan.result.Documents = len(documents)
+	plan.result.FileBytes = fileBytes
+
+	s.createAuditLog(target.ID, opts.ImportedBy, models.AuditCreate,
+		fmt.Sprintf("Tenant archive of %s imported with %d users and %d documents",
+			manifest.Subdomain, plan.result.Users.Created+plan.result.Users.Matched, plan.result.Documents))
+
+	return &plan.result, nil
+}
+
+// Helper methods
+
+func (s *TenantTransferService) listUsers(ctx context.Context, tenantID uuid.UUID) ([]models.User, error) {
+	users := []models.User{}
+	params := repositories.ListParams{Page: 1, PageSize: tenantExportPageSize}
+	for {
+		page, total, err := s.userRepo.ListByTenant(ctx, tenantID, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+		users = append(users, page...)
+		if int64(params.Page*params.PageSize) >= total || len(page) == 0 {
+			return users, nil
+		}
+		params.Page++
+	}
+}
+
+func (s *TenantTransferService) writeFile(ctx context.Context, archive *zip.Writer, document models.Document) error {
+	reader, err := s.storageService.Get(ctx, document.StoragePath)
+	if err != nil {
+		return fmt.Errorf("failed to read document %s: %w", document.ID, err)
+	}
+	defer reader.Close()
+
+	entry, err := archive.Create(tenantArchiveFile(document.ID))
+	if err != nil {
+		return fmt.Errorf("failed to write tenant archive: %w", err)
+	}
+	if _, err := io.Copy(entry, reader); err != nil {
+		return fmt.Errorf("failed to copy document %s: %w", document.ID, err)
+	}
+	return nil
+}
+
+// newTenant builds the tenant an import creates, with the exported tenant's
+// plan, quotas, settings and policies
+func (s *TenantTransferService) newTenant(ctx context.Context, source *models.Tenant, opts TenantImportOptions, documents, fileBytes int64) (*models.Tenant, error) {
+	subdomain := strings.ToLower(strings.TrimSpace(opts.Subdomain))
+	if !importSubdomainPattern.MatchString(subdomain) || len(subdomain) > 100 {
+		return nil, ErrInvalidSubdomain
+	}
+	if existing, err := s.tenantRepo.GetBySubdomain(ctx, subdomain); err == nil && existing != nil {
+		return nil, ErrSubdomainTaken
+	}
+
+	name := strings.TrimSpace(opts.Name)
+	if name == "" {
+		name = source.Name
+	}
+
+	return &models.Tenant{
+		ID:               uuid.New(),
+		Name:             name,
+		Subdomain:        subdomain,
+		SubscriptionTier: source.SubscriptionTier,
+		StorageQuota:     source.StorageQuota,
+		StorageUsed:      fileBytes,
+		DocumentQuota:    source.DocumentQuota,
+		DocumentCount:    documents,
+		APIQuota:         source.APIQuota,
+		Settings:         source.Settings,
+		IsActive:         true,
+		TrialEndsAt:      source.TrialEndsAt,
+		BusinessType:     source.BusinessType,
+		Industry:         source.Industry,
+		CompanySize:      source.CompanySize,
+		TaxID:            source.TaxID,
+		Address:          source.Address,
+		RetentionPolicy:  source.RetentionPolicy,
+		ComplianceRules:  source.ComplianceRules,
+	}, nil
+}
+
+// tenantImportPlan holds an archive's rows rewritten for the target tenant
+type tenantImportPlan struct {
+	users      []models.User
+	folders    []models.Folder
+	tags       []models.Tag
+	categories []models.Category
+	workflows  []models.Workflow
+	documents  []models.Document
+	result     TenantImportResult
+}
+
+// planImport gives every archived row its ID in the target and points
+// references at the new IDs. Rows the target already has are matched, not
+// created.
+func (s *TenantTransferService) planImport(ctx context.Context, tenantID, importedBy uuid.UUID,
+	users []archivedUser, folders []archivedFolder, tags []archivedTag, categories []archivedCategory,
+	workflows []archivedWorkflow, documents []archivedDocument,
+) (*tenantImportPlan, error) {
+	plan := &tenantImportPlan{}
+
+	existingUsers, err := s.listUsers(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	existingFolders, err := s.repo.ListFolders(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	existingTags, err := s.repo.ListTags(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	existingCategories, err := s.repo.ListCategories(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users keep their IDs when they're free in the target instance
+	byEmail := make(map[string]uuid.UUID, len(existingUsers))
+	for _, user := range existingUsers {
+		byEmail[strings.ToLower(user.Email)] = user.ID
+	}
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	usedIDs, err := s.repo.UsedUserIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make(map[uuid.UUID]uuid.UUID, len(users))
+	var created []models.User
+	for _, archived := range users {
+		if id, ok := byEmail[strings.ToLower(archived.Email)]; ok {
+			userIDs[archived.ID] = id
+			plan.result.Users.Matched++
+			continue
+		}
+
+		// Without credentials: they sign in after resetting their password
+		user := archived.User
+		user.PasswordHash, user.MFASecret, user.MFAEnabled = "", "", false
+		if usedIDs[user.ID] {
+			user.ID = uuid.New()
+		}
+		userIDs[archived.ID] = user.ID
+		created = append(created, user)
+	}
+	// Custom roles aren't part of the archive
+	for i := range created {
+		user := &created[i]
+		user.TenantID = tenantID
+		user.CustomRoleID = nil
+		user.ManagerID = remapID(userIDs, user.ManagerID)
+	}
+	plan.users = created
+	plan.result.Users.Created = len(created)
+
+	// Rows pointing at users missing from the archive fall back to the
+	// importer, or to the first user imported
+	fallback := importedBy
+	if fallback == uuid.Nil {
+		for _, id := range userIDs {
+			fallback = id
+			break
+		}
+	}
+	userID := func(id uuid.UUID) uuid.UUID {
+		if mapped, ok := userIDs[id]; ok {
+			return mapped
+		}
+		return fallback
+	}
+
+	folderByPath := make(map[string]uuid.UUID, len(existingFolders))
+	for _, folder := range existingFolders {
+		folderByPath[folder.Path] = folder.ID
+	}
+	folderIDs := make(map[uuid.UUID]uuid.UUID, len(folders))
+	for _, archived := range folders {
+		if id, ok := folderByPath[archived.Path]; ok {
+			folderIDs[archived.ID] = id
+			plan.result.Folders.Matched++
+			continue
+		}
+
+		folder := archived.Folder
+		folder.ID = uuid.New()
+		folder.TenantID = tenantID
+		folder.ParentID = remapID(folderIDs, folder.ParentID)
+		folder.CreatedBy = userID(folder.CreatedBy)
+		folder.OwnerID = remapUserID(userID, folder.OwnerID)
+		folderIDs[archived.ID] = folder.ID
+		plan.folders = append(plan.folders, folder)
+	}
+	plan.result.Folders.Created = len(plan.folders)
+
+	tagByName := make(map[string]uuid.UUID, len(existingTags))
+	for _, tag := range existingTags {
+		tagByName[tag.Name] = tag.ID
+	}
+	tagIDs := make(map[uuid.UUID]uuid.UUID, len(tags))
+	for _, archived := range tags {
+		if id, ok := tagByName[archived.Name]; ok {
+			tagIDs[archived.ID] = id
+			plan.result.Tags.Matched++
+			continue
+		}
+
+		tag := archived.Tag
+		tag.ID = uuid.New()
+		tag.TenantID = tenantID
+		tagIDs[archived.ID] = tag.ID
+		plan.tags = append(plan.tags, tag)
+	}
+	plan.result.Tags.Created = len(plan.tags)
+
+	categoryByName := make(map[string]uuid.UUID, len(existingCategories))
+	for _, category := range existingCategories {
+		categoryByName[category.Name] = category.ID
+	}
+	categoryIDs := make(map[uuid.UUID]uuid.UUID, len(categories))
+	for _, archived := range categories {
+		if id, ok := categoryByName[archived.Name]; ok {
+			categoryIDs[archived.ID] = id
+			plan.result.Categories.Matched++
+			continue
+		}
+
+		category := archived.Category
+		category.ID = uuid.New()
+		category.TenantID = tenantID
+		categoryIDs[archived.ID] = category.ID
+		plan.categories = append(plan.categories, category)
+	}
+	plan.result.Categories.Created = len(plan.categories)
+
+	for _, archived := range workflows {
+		workflow := archived.Workflow
+		workflow.ID = uuid.New()
+		workflow.TenantID = tenantID
+		workflow.CreatedBy = userID(workflow.CreatedBy)
+		remapRuleUsers(workflow.Rules, userIDs)
+		plan.workflows = append(plan.workflows, workflow)
+	}
+	plan.result.Workflows = len(plan.workflows)
+
+	for _, archived := range documents {
+		document := archived.Document
+		document.ID = uuid.New()
+		document.TenantID = tenantID
+		document.FolderID = remapID(folderIDs, document.FolderID)
+		document.CreatedBy = userID(document.CreatedBy)
+		document.UpdatedBy = remapUserID(userID, document.UpdatedBy)
+		document.OwnerID = remapUserID(userID, document.OwnerID)
+		document.DeletedAt, document.DeletedBy = nil, nil
+		// Thumbnails and previews are generated again
+		document.ThumbnailPath, document.PreviewPath = "", ""
+		document.StoragePath = ""
+
+		document.Tags = nil
+		for _, id := range archived.Tags {
+			if mapped, ok := tagIDs[id]; ok {
+				document.Tags = append(document.Tags, models.Tag{ID: mapped})
+			}
+		}
+		document.Categories = nil
+		for _, id := range archived.Categories {
+			if mapped, ok := categoryIDs[id]; ok {
+				document.Categories = append(document.Categories, models.Category{ID: mapped})
+			}
+		}
+		plan.documents = append(plan.documents, document)
+	}
+
+	return plan, nil
+}
+
+// storeFiles copies the archived documents' files into storage for the
+// target tenant, setting the imported documents' storage paths. It returns
+// the paths stored, so they can be removed if the import fails.
+func (s *TenantTransferService) storeFiles(ctx context.Context, entries map[string]*zip.File, tenantID uuid.UUID, archived []archivedDocument, documents []models.Document) ([]string, error) {
+	stored := make([]string, 0, len(documents))
+	for i := range documents {
+		entry := entries[tenantArchiveFile(archived[i].ID)]
+		reader, err := entry.Open()
+		if err != nil {
+			return stored, fmt.Errorf("%w: %v", ErrInvalidTenantArchive, err)
+		}
+
+		path, err := s.storageService.Store(ctx, StorageParams{
+			TenantID:    tenantID,
+			FileReader:  reader,
+			Filename:    documents[i].FileName,
+			ContentType: documents[i].ContentType,
+			Size:        int64(entry.UncompressedSize64),
+		})
+		reader.Close()
+		if err != nil {
+			return stored, fmt.Errorf("failed to store document %s: %w", archived[i].ID, err)
+		}
+		stored = append(stored, path)
+		documents[i].StoragePath = path
+	}
+	return stored, nil
+}
+
+func (s *TenantTransferService) createAuditLog(tenantID, userID uuid.UUID, action models.AuditAction, message string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   tenantID,
+		Action:       action,
+		ResourceType: "tenant",
+		Details:      models.JSONB{"message": message},
+	}
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+func archiveDocument(document models.Document) archivedDocument {
+	archived := archivedDocument{Document: document}
+	for _, tag := range document.Tags {
+		archived.Tags = append(archived.Tags, tag.ID)
+	}
+	for _, category := range document.Categories {
+		archived.Categories = append(archived.Categories, category.ID)
+	}
+	return archived
+}
+
+func readArchivedDocuments(entries map[string]*zip.File) ([]archivedDocument, error) {
+	entry, ok := entries["documents.jsonl"]
+	if !ok {
+		return nil, fmt.Errorf("%w: documents.jsonl is missing", ErrInvalidTenantArchive)
+	}
+	reader, err := entry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTenantArchive, err)
+	}
+	defer reader.Close()
+
+	documents := []archivedDocument{}
+	decoder := json.NewDecoder(reader)
+	for {
+		var document archivedDocument
+		if err := decoder.Decode(&document); err == io.EOF {
+			return documents, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: documents.jsonl: %v", ErrInvalidTenantArchive, err)
+		}
+		documents = append(documents, document)
+	}
+}
+
+func readZipJSON(entries map[string]*zip.File, name string, value interface{}) error {
+	entry, ok := entries[name]
+	if !ok {
+		return fmt.Errorf("%w: %s is missing", ErrInvalidTenantArchive, name)
+	}
+	reader, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTenantArchive, err)
+	}
+	defer reader.Close()
+
+	if err := json.NewDecoder(reader).Decode(value); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidTenantArchive, name, err)
+	}
+	return nil
+}
+
+// remapID returns the new ID of an optional reference; references to rows
+// that weren't imported are dropped
+func remapID(ids map[uuid.UUID]uuid.UUID, id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	if mapped, ok := ids[*id]; ok {
+		return &mapped
+	}
+	return nil
+}
+
+func remapUserID(userID func(uuid.UUID) uuid.UUID, id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	mapped := userID(*id)
+	return &mapped
+}
+
+// remapRuleUsers points workflow steps and escalations that name users by ID
+// at the users' new IDs. Users named by email need no change.
+func remapRuleUsers(rules models.JSONB, userIDs map[uuid.UUID]uuid.UUID) {
+	remap := func(items interface{}, typeKey, valueKey string) {
+		list, _ := items.([]interface{})
+		for _, item := range list {
+			entry, ok := item.(map[string]interface{})
+			if !ok || entry[typeKey] != "user" {
+				continue
+			}
+			value, _ := entry[valueKey].(string)
+			parts := strings.Split(value, ",")
+			for i, part := range parts {
+				parts[i] = strings.TrimSpace(part)
+				if id, err := uuid.Parse(parts[i]); err == nil {
+					if mapped, ok := userIDs[id]; ok {
+						parts[i] = mapped.String()
+					}
+				}
+			}
+			entry[valueKey] = strings.Join(parts, ",")
+		}
+	}
+	remap(rules["approval_steps"], "assignee_type", "assignee_value")
+	remap(rules["escalation_rules"], "escalate_to_type", "escalate_to_value")
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemapRuleUsers(t *testing.T) {
+	alice, bob, stranger := uuid.New(), uuid.New(), uuid.New()
+	newAlice, newBob := uuid.New(), uuid.New()
+	userIDs := map[uuid.UUID]uuid.UUID{alice: newAlice, bob: newBob}
+
+	rules := models.JSONB{
+		"approval_steps": []interface{}{
+			map[string]interface{}{"assignee_type": "user", "assignee_value": alice.String() + ", " + stranger.String()},
+			map[string]interface{}{"assignee_type": "role", "assignee_value": "admin"},
+		},
+		"escalation_rules": []interface{}{
+			map[string]interface{}{"escalate_to_type": "user", "escalate_to_value": bob.String()},
+		},
+	}
+
+	remapRuleUsers(rules, userIDs)
+
+	steps := rules["approval_steps"].([]interface{})
+	assert.Equal(t, newAlice.String()+","+stranger.String(), steps[0].(map[string]interface{})["assignee_value"])
+	assert.Equal(t, "admin", steps[1].(map[string]interface{})["assignee_value"])
+	escalations := rules["escalation_rules"].([]interface{})
+	assert.Equal(t, newBob.String(), escalations[0].(map[string]interface{})["escalate_to_value"])
+}
+
+func TestTenantTransferService_ImportRejectsBadArchives(t *testing.T) {
+	service := &TenantTransferService{}
+	ctx := context.Background()
+	opts := TenantImportOptions{Subdomain: "acme"}
+
+	notZip := []byte("not a zip")
+	_, err := service.Import(ctx, bytes.NewReader(notZip), int64(len(notZip)), opts)
+	assert.ErrorIs(t, err, ErrInvalidTenantArchive)
+
+	archive := func(manifest *TenantArchiveManifest) []byte {
+		var buf bytes.Buffer
+		writer := zip.NewWriter(&buf)
+		if manifest != nil {
+			require.NoError(t, writeZipJSON(writer, "manifest.json", manifest))
+		}
+		require.NoError(t, writer.Close())
+		return buf.Bytes()
+	}
+
+	noManifest := archive(nil)
+	_, err = service.Import(ctx, bytes.NewReader(noManifest), int64(len(noManifest)), opts)
+	assert.ErrorIs(t, err, ErrInvalidTenantArchive)
+
+	future := archive(&TenantArchiveManifest{Format: TenantArchiveFormat + 1})
+	_, err = service.Import(ctx, bytes.NewReader(future), int64(len(future)), opts)
+	assert.ErrorIs(t, err, ErrUnsupportedTenantArchive)
+
+	// Valid manifest, but the documents' files are missing
+	missingFile := func() []byte {
+		var buf bytes.Buffer
+		writer := zip.NewWriter(&buf)
+		require.NoError(t, writeZipJSON(writer, "manifest.json", &TenantArchiveManifest{Format: TenantArchiveFormat}))
+		for _, name := range []string{"tenant.json", "users.json", "folders.json", "tags.json", "categories.json", "workflows.json"} {
+			value := interface{}([]interface{}{})
+			if name == "tenant.json" {
+				value = models.Tenant{}
+			}
+			require.NoError(t, writeZipJSON(writer, name, value))
+		}
+		entry, err := writer.Create("documents.jsonl")
+		require.NoError(t, err)
+		_, err = entry.Write([]byte(`{"id":"` + uuid.New().String() + `","file_size":10}` + "\n"))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		return buf.Bytes()
+	}()
+	_, err = service.Import(ctx, bytes.NewReader(missingFile), int64(len(missingFile)), opts)
+	assert.ErrorIs(t, err, ErrInvalidTenantArchive)
+}
+
+func TestArchivedUser_LeavesOutCredentials(t *testing.T) {
+	user := models.User{ID: uuid.New(), Email: "alice@example.com", PasswordHash: "$2a$10$hash", MFAEnabled: true, MFASecret: "JBSWY3DPEHPK3PXP"}
+
+	encoded, err := json.Marshal(archivedUser{User: user})
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), "alice@example.com")
+	assert.NotContains(t, string(encoded), "$2a$10$hash")
+	assert.NotContains(t, string(encoded), "JBSWY3DPEHPK3PXP")
+}
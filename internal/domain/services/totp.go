@@ -0,0 +1,94 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238 defaults, which authenticator apps assume)
+const (
+	totpPeriod    = 30
+	totpDigits    = 6
+	totpSkewSteps = 1 // Accept one step either side for clock drift
+
+	mfaRecoveryCodeCount  = 10
+	mfaRecoveryCodeLength = 10
+)
+
+// recoveryCodeAlphabet has 32 symbols without look-alikes (0/O, 1/I)
+const recoveryCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpCode computes the HOTP value (RFC 4226) for a time step
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// validateTOTP checks a code against the secret within the drift window and
+// returns the matching time step so callers can reject replays
+func validateTOTP(secret, code string, at time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(key) == 0 {
+		return 0, false
+	}
+
+	current := at.Unix() / totpPeriod
+	for drift := int64(-totpSkewSteps); drift <= totpSkewSteps; drift++ {
+		step := current + drift
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// generateRecoveryCodes returns plaintext codes formatted XXXXX-XXXXX
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, mfaRecoveryCodeCount)
+	buf := make([]byte, mfaRecoveryCodeLength)
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		var code strings.Builder
+		for j, b := range buf {
+			if j == mfaRecoveryCodeLength/2 {
+				code.WriteByte('-')
+			}
+			// 256 is a multiple of 32, so this is unbiased
+			code.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+		}
+		codes = append(codes, code.String())
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode normalizes user input (case, dashes, spaces) before hashing
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the shared secret from RFC 4226 and RFC 6238 (SHA-1)
+const rfcSecret = "12345678901234567890"
+
+func TestTOTPCode_RFC4226Vectors(t *testing.T) {
+	// RFC 4226 Appendix D, HOTP values by counter
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range expected {
+		assert.Equal(t, code, totpCode([]byte(rfcSecret), int64(counter)), "counter %d", counter)
+	}
+}
+
+func TestValidateTOTP_RFC6238Vectors(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfcSecret))
+
+	// RFC 6238 Appendix B (SHA-1), truncated to six digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		step, ok := validateTOTP(secret, tt.code, time.Unix(tt.unix, 0))
+		assert.True(t, ok, "time %d", tt.unix)
+		assert.Equal(t, tt.unix/totpPeriod, step, "time %d", tt.unix)
+	}
+}
+
+func TestValidateTOTP_Drift(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfcSecret))
+	key := []byte(rfcSecret)
+	at := time.Unix(1234567890, 0)
+	current := at.Unix() / totpPeriod
+
+	tests := []struct {
+		name  string
+		drift int64
+		valid bool
+	}{
+		{"current step", 0, true},
+		{"one step behind", -1, true},
+		{"one step ahead", 1, true},
+		{"two steps behind", -2, false},
+		{"two steps ahead", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := validateTOTP(secret, totpCode(key, current+tt.drift), at)
+			assert.Equal(t, tt.valid, ok)
+			if tt.valid {
+				assert.Equal(t, current+tt.drift, step)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP_RejectsMalformedInput(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfcSecret))
+	at := time.Unix(59, 0)
+
+	_, ok := validateTOTP(secret, "28708", at)
+	assert.False(t, ok, "short code")
+
+	_, ok = validateTOTP(secret, "2870820", at)
+	assert.False(t, ok, "long code")
+
+	_, ok = validateTOTP("not base32!", "287082", at)
+	assert.False(t, ok, "invalid secret")
+
+	_, ok = validateTOTP("", "287082", at)
+	assert.False(t, ok, "empty secret")
+
+	// Lowercase and padded secrets are accepted as authenticator apps show them
+	_, ok = validateTOTP("gezdgnbvgy3tqojqgezdgnbvgy3tqojq====", "287082", at)
+	assert.True(t, ok, "lowercase padded secret")
+}
+
+func TestVerifyTOTPCode_RejectsReplay(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repos := postgresql.NewRepositories(db.DB)
+	service := NewUserService(repos.UserRepo, repos.RoleRepo, repos.TenantRepo, repos.AuditRepo, repos.MFACodeRepo, nil, nil, UserServiceConfig{}, nil, nil)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	user.MFAEnabled = true
+	user.MFASecret = totpEncoding.EncodeToString([]byte(rfcSecret))
+	require.NoError(t, repos.UserRepo.Update(ctx, user))
+
+	code := totpCode([]byte(rfcSecret), time.Now().Unix()/totpPeriod)
+	require.NoError(t, service.verifyTOTPCode(ctx, user, code))
+
+	// The stale user still passes the in-memory check; the claim rejects it
+	assert.ErrorIs(t, service.verifyTOTPCode(ctx, user, code), ErrInvalidMFACode)
+
+	reloaded, err := repos.UserRepo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.verifyTOTPCode(ctx, reloaded, code), ErrInvalidMFACode)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, mfaRecoveryCodeCount)
+
+	format := regexp.MustCompile(`^[A-HJ-NP-Z2-9]{5}-[A-HJ-NP-Z2-9]{5}$`)
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		assert.Regexp(t, format, code)
+		assert.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+	}
+
+	// Case, dashes and spaces don't change the hash
+	hash := hashRecoveryCode("ABCDE-FGH23")
+	assert.Len(t, hash, 64)
+	for _, input := range []string{"abcde-fgh23", "ABCDEFGH23", "abcde fgh23", " ABCDE-FGH23 ", "ab-cde-fg h23"} {
+		assert.Equal(t, hash, hashRecoveryCode(input), input)
+	}
+	assert.NotEqual(t, hash, hashRecoveryCode("ABCDE-FGH24"))
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer names the spans of the service layer
+var tracer = otel.Tracer("github.com/archivus/archivus/internal/domain/services")
+
+// startSpan starts a span for a service operation, e.g.
+// "DocumentService.UploadDocument"
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on the span, if any, and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceParent returns the W3C traceparent of the span in ctx, so work queued
+// in the database continues the trace when another process picks it up
+func traceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// contextWithTraceParent continues the trace a traceParent was taken from
+func contextWithTraceParent(ctx context.Context, parent string) context.Context {
+	if parent == "" {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier{"traceparent": parent})
+}
+
+// tracedAIClient traces the calls a job makes to its AI provider
+type tracedAIClient struct {
+	OpenAIService
+	provider string
+}
+
+func (c tracedAIClient) start(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "ai."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.system", c.provider),
+			attribute.String("gen_ai.operation.name", operation),
+		),
+	)
+}
+
+func (c tracedAIClient) ExtractText(ctx context.Context, text string) (string, error) {
+	ctx, span := c.start(ctx, "extract_text")
+	result, err := c.OpenAIService.ExtractText(ctx, text)
+	endSpan(span, err)
+	return result, err
+}
+
+func (c tracedAIClient) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	ctx, span := c.start(ctx, "generate_embedding")
+	result, err := c.OpenAIService.GenerateEmbedding(ctx, text)
+	endSpan(span, err)
+	return result, err
+}
+
+func (c tracedAIClient) GenerateSummary(ctx context.Context, text string) (string, error) {
+	ctx, span := c.start(ctx, "generate_summary")
+	result, err := c.OpenAIService.GenerateSummary(ctx, text)
+	endSpan(span, err)
+	return result, err
+}
+
+func (c tracedAIClient) ExtractEntities(ctx context.Context, text string) (map[string]interface{}, error) {
+	ctx, span := c.start(ctx, "extract_entities")
+	result, err := c.OpenAIService.ExtractEntities(ctx, text)
+	endSpan(span, err)
+	return result, err
+}
+
+func (c tracedAIClient) ClassifyDocument(ctx context.Context, text string) (models.DocumentType, float64, error) {
+	ctx, span := c.start(ctx, "classify_document")
+	documentType, confidence, err := c.OpenAIService.ClassifyDocument(ctx, text)
+	endSpan(span, err)
+	return documentType, confidence, err
+}
+
+func (c tracedAIClient) GenerateTags(ctx context.Context, text string) ([]string, error) {
+	ctx, span := c.start(ctx, "generate_tags")
+	result, err := c.OpenAIService.GenerateTags(ctx, text)
+	endSpan(span, err)
+	return result, err
+}
+
+func (c tracedAIClient) ExtractFinancialData(ctx context.Context, text string, docType models.DocumentType) (map[string]interface{}, error) {
+	ctx, span := c.start(ctx, "extract_financial_data")
+	result, err := c.OpenAIService.ExtractFinancialData(ctx, text, docType)
+	endSpan(span, err)
+	return result, err
+}
+
+func (c tracedAIClient) SuggestFiling(ctx context.Context, text string, taxonomy FilingTaxonomy) (*FilingAdvice, error) {
+	ctx, span := c.start(ctx, "suggest_filing")
+	result, err := c.OpenAIService.SuggestFiling(ctx, text, taxonomy)
+	endSpan(span, err)
+	return result, err
+}
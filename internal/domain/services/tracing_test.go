@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceParentContinuesTrace(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	provider := sdktrace.NewTracerProvider()
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "upload")
+	parent := traceParent(ctx)
+	span.End()
+	assert.NotEmpty(t, parent)
+
+	// A worker picking up the job continues the upload's trace
+	jobCtx := contextWithTraceParent(context.Background(), parent)
+	assert.Equal(t, span.SpanContext().TraceID(), trace.SpanContextFromContext(jobCtx).TraceID())
+	assert.True(t, trace.SpanContextFromContext(jobCtx).IsRemote())
+
+	assert.Empty(t, traceParent(context.Background()))
+	assert.Equal(t, context.Background(), contextWithTraceParent(context.Background(), ""))
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidTrialExpiryAction = errors.New("invalid trial expiry action")
+	ErrTrialNotExpired          = errors.New("tenant trial has not expired")
+	ErrInvalidSubscriptionTier  = errors.New("invalid subscription tier")
+	ErrBillingNotConfigured     = errors.New("billing is not configured; an operator must change the tenant's plan")
+)
+
+// TrialExpiryAction is what happens to a tenant when its trial ends
+type TrialExpiryAction string
+
+const (
+	// TrialExpirySuspend suspends the tenant; only admins may sign in, to
+	// choose a plan
+	TrialExpirySuspend TrialExpiryAction = "suspend"
+	// TrialExpiryDowngrade moves the tenant to the starter tier and its
+	// quotas. Tenants already on the starter tier are suspended instead.
+	TrialExpiryDowngrade TrialExpiryAction = "downgrade"
+)
+
+// ParseTrialExpiryAction parses a trial expiry action; empty means suspend
+func ParseTrialExpiryAction(value string) (TrialExpiryAction, error) {
+	switch action := TrialExpiryAction(strings.ToLower(strings.TrimSpace(value))); action {
+	case "":
+		return TrialExpirySuspend, nil
+	case TrialExpirySuspend, TrialExpiryDowngrade:
+		return action, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidTrialExpiryAction, value)
+}
+
+// TrialNotifier tells tenant admins about their trial ending
+type TrialNotifier interface {
+	SendTrialExpiring(ctx context.Context, userID uuid.UUID, tenantName string, endsAt time.Time) error
+	SendTrialExpired(ctx context.Context, userID uuid.UUID, tenantName, outcome string) error
+}
+
+// TrialConfig holds configuration for the trial expiry job
+type TrialConfig struct {
+	CheckInterval time.Duration     // How often trials are checked; defaults to an hour
+	WarnBefore    []time.Duration   // When admins are warned before expiry; defaults to 7 days and 1 day
+	ExpiryAction  TrialExpiryAction // Defaults to suspend
+}
+
+// TrialStatus describes a tenant's trial
+type TrialStatus struct {
+	SubscriptionTier models.SubscriptionTier `json:"subscription_tier"`
+	InTrial          bool                    `json:"in_trial"`
+	TrialEndsAt      *time.Time              `json:"trial_ends_at,omitempty"`
+	DaysRemaining    *int                    `json:"days_remaining,omitempty"`
+	Expired          bool                    `json:"expired"`
+	ExpiredAt        *time.Time              `json:"expired_at,omitempty"`
+	Suspended        bool                    `json:"suspended"`
+	ExpiryAction     TrialExpiryAction       `json:"expiry_action"` // What happens at expiry
+}
+
+// TrialRunResult summarises one pass of the trial expiry job
+type TrialRunResult struct {
+	Warned     int `json:"warned"`
+	Suspended  int `json:"suspended"`
+	Downgraded int `json:"downgraded"`
+}
+
+// TrialService automates the end of tenant trials: admins are warned ahead
+// of expiry, and at expiry the tenant is suspended or downgraded. Admins of
+// a suspended tenant can still sign in and reactivate it by choosing a plan.
+type TrialService struct {
+	tenantRepo    repositories.TenantRepository
+	userRepo      repositories.UserRepository
+	auditRepo     repositories.AuditLogRepository
+	tenantService *TenantService // Tier quotas and billing
+
+	notifier TrialNotifier
+	sessions SessionRevoker
+	config   TrialConfig
+}
+
+// NewTrialService creates a new trial service
+func NewTrialService(
+	tenantRepo repositories.TenantRepository,
+	userRepo repositories.UserRepository,
+	auditRepo repositories.AuditLogRepository,
+	tenantService *TenantService,
+	notifier TrialNotifier,
+	sessions SessionRevoker,
+	config TrialConfig,
+) *TrialService {
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = time.Hour
+	}
+	if len(config.WarnBefore) == 0 {
+		config.WarnBefore = []time.Duration{7 * 24 * time.Hour, 24 * time.Hour}
+	}
+	if config.ExpiryAction == "" {
+		config.ExpiryAction = TrialExpirySuspend
+	}
+	// Longest first, so the last window a trial is in is the tightest
+	sort.Slice(config.WarnBefore, func(i, j int) bool { return config.WarnBefore[i] > config.WarnBefore[j] })
+
+	return &TrialService{
+		tenantRepo:    tenantRepo,
+		userRepo:      userRepo,
+		auditRepo:     auditRepo,
+		tenantService: tenantService,
+		notifier:      notifier,
+		sessions:      sessions,
+		config:        config,
+	}
+}
+
+// GetTrialStatus returns the state of the tenant's trial
+func (s *TrialService) GetTrialStatus(ctx context.Context, tenantID uuid.UUID) (*TrialStatus, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	status := &TrialStatus{
+		SubscriptionTier: tenant.SubscriptionTier,
+		TrialEndsAt:      tenant.TrialEndsAt,
+		ExpiredAt:        tenant.TrialExpiredAt,
+		Expired:          tenant.TrialExpiredAt != nil,
+		Suspended:        isTrialSuspended(tenant),
+		ExpiryAction:     s.config.ExpiryAction,
+	}
+	if tenant.TrialEndsAt != nil && tenant.TrialExpiredAt == nil {
+		status.InTrial = true
+		days := max(int((time.Until(*tenant.TrialEndsAt)+24*time.Hour-1)/(24*time.Hour)), 0)
+		status.DaysRemaining = &days
+	}
+
+	return status, nil
+}
+
+// Reactivate ends an expired trial by putting the tenant on a plan. The
+// tenant is reactivated if it was suspended and gets the tier's quotas.
+// Without billing nothing would charge for the plan, so tenants can't choose
+// one themselves.
+func (s *TrialService) Reactivate(ctx context.Context, tenantID uuid.UUID, tier models.SubscriptionTier, reactivatedBy uuid.UUID) (*TrialStatus, error) {
+	if _, ok := models.TierDocumentQuotas[tier]; !ok {
+		return nil, ErrInvalidSubscriptionTier
+	}
+	if s.tenantService == nil || s.tenantService.subscriptionService == nil {
+		return nil, ErrBillingNotConfigured
+	}
+
+	tenant, err := s.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	if tenant.TrialExpiredAt == nil {
+		return nil, ErrTrialNotExpired
+	}
+
+	// Billing first, so a failed payment leaves the tenant as it was
+	if err := s.tenantService.subscriptionService.UpgradeSubscription(ctx, tenantID, tier); err != nil {
+		return nil, fmt.Errorf("failed to start subscription: %w", err)
+	}
+
+	wasSuspended := isTrialSuspended(tenant)
+	s.applyTier(tenant, tier)
+	tenant.IsActive = true
+	tenant.TrialEndsAt = nil
+	tenant.TrialWarnedAt = nil
+	tenant.TrialExpiredAt = nil
+	tenant.UpdatedAt = time.Now()
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return nil, fmt.Errorf("failed to reactivate tenant: %w", err)
+	}
+
+	message := fmt.Sprintf("Subscription started on the %s tier after the trial", tier)
+	if wasSuspended {
+		message = fmt.Sprintf("Tenant reactivated on the %s tier after the trial", tier)
+	}
+	s.createAuditLog(tenantID, reactivatedBy, message)
+
+	return s.GetTrialStatus(ctx, tenantID)
+}
+
+// ProcessTrials warns admins of trials ending soon and applies the expiry
+// action to trials that have ended
+func (s *TrialService) ProcessTrials(ctx context.Context) (*TrialRunResult, error) {
+	now := time.Now()
+	tenants, err := s.tenantRepo.ListTrialsEndingBefore(ctx, now.Add(s.config.WarnBefore[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TrialRunResult{}
+	var errs []error
+	for i := range tenants {
+		tenant := &tenants[i]
+		if !tenant.TrialEndsAt.After(now) {
+			outcome, err := s.expireTrial(ctx, tenant, now)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			switch outcome {
+			case TrialExpirySuspend:
+				result.Suspended++
+			case TrialExpiryDowngrade:
+				result.Downgraded++
+			}
+			continue
+		}
+
+		if s.warningDue(tenant, now) {
+			s.notifyAdmins(ctx, tenant.ID, func(userID uuid.UUID) error {
+				return s.notifier.SendTrialExpiring(ctx, userID, tenant.Name, *tenant.TrialEndsAt)
+			})
+			if err := s.tenantRepo.MarkTrialWarned(ctx, tenant.ID, now); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			result.Warned++
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// ExpiryTask is the scheduled task that warns about and expires trials
+func (s *TrialService) ExpiryTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "trial_expiry",
+		Interval: s.config.CheckInterval,
+		Run: func(ctx context.Context) error {
+			_, err := s.ProcessTrials(ctx)
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+// warningDue reports whether admins haven't been warned yet in the tightest
+// warning window the trial is in
+func (s *TrialService) warningDue(tenant *models.Tenant, now time.Time) bool {
+	remaining := tenant.TrialEndsAt.Sub(now)
+	window := time.Duration(0)
+	for _, before := range s.config.WarnBefore {
+		if remaining <= before {
+			window = before
+		}
+	}
+	if window == 0 {
+		return false
+	}
+	return tenant.TrialWarnedAt == nil || tenant.TrialWarnedAt.Before(tenant.TrialEndsAt.Add(-window))
+}
+
+// expireTrial applies the expiry action, returning the action taken. The
+// claim makes sure only one instance acts on a trial.
+func (s *TrialService) expireTrial(ctx context.Context, tenant *models.Tenant, now time.Time) (TrialExpiryAction, error) {
+	claimed, err := s.tenantRepo.ClaimTrialExpiry(ctx, tenant.ID, now)
+	if err != nil || !claimed {
+		return "", err
+	}
+
+	// Reload: the tenant may have changed since it was listed
+	tenant, err = s.tenantRepo.GetByID(ctx, tenant.ID)
+	if err != nil {
+		return "", ErrTenantNotFound
+	}
+
+	action := s.config.ExpiryAction
+	if action == TrialExpiryDowngrade && tenant.SubscriptionTier == models.SubscriptionStarter {
+		action = TrialExpirySuspend
+	}
+
+	outcome := "suspended"
+	switch action {
+	case TrialExpiryDowngrade:
+		s.applyTier(tenant, models.SubscriptionStarter)
+		outcome = "moved to the starter plan"
+	default:
+		tenant.IsActive = false
+	}
+	tenant.UpdatedAt = now
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		return "", fmt.Errorf("failed to expire trial: %w", err)
+	}
+
+	if action == TrialExpirySuspend {
+		s.signOutMembers(ctx, tenant.ID)
+	}
+	s.notifyAdmins(ctx, tenant.ID, func(userID uuid.UUID) error {
+		return s.notifier.SendTrialExpired(ctx, userID, tenant.Name, outcome)
+	})
+	s.createAuditLog(tenant.ID, uuid.Nil, "Trial expired, tenant "+outcome)
+
+	return action, nil
+}
+
+// applyTier sets the tier and its quotas
+func (s *TrialService) applyTier(tenant *models.Tenant, tier models.SubscriptionTier) {
+	tenant.SubscriptionTier = tier
+	tenant.StorageQuota = s.tenantService.getStorageQuotaForTier(tier)
+	tenant.DocumentQuota = s.tenantService.getDocumentQuotaForTier(tier)
+	tenant.APIQuota = s.tenantService.getAPIQuotaForTier(tier)
+}
+
+func (s *TrialService) notifyAdmins(ctx context.Context, tenantID uuid.UUID, send func(userID uuid.UUID) error) {
+	if s.notifier == nil {
+		return
+	}
+
+	admins, err := s.userRepo.ListByRole(ctx, tenantID, models.UserRoleAdmin)
+	if err != nil {
+		return
+	}
+	for _, admin := range admins {
+		if !admin.IsActive {
+			continue
+		}
+		if err := send(admin.ID); err != nil {
+			// Log but don't fail - the trial status endpoint shows the same
+		}
+	}
+}
+
+// signOutMembers ends the sessions of everyone but the tenant's admins
+func (s *TrialService) signOutMembers(ctx context.Context, tenantID uuid.UUID) {
+	if s.sessions == nil {
+		return
+	}
+
+	params := repositories.ListParams{Page: 1, PageSize: 100}
+	for {
+		users, total, err := s.userRepo.ListByTenant(ctx, tenantID, params)
+		if err != nil {
+			return
+		}
+		for _, user := range users {
+			if user.Role == models.UserRoleAdmin {
+				continue
+			}
+			if err := s.sessions.RevokeAllSessions(ctx, user.ID, uuid.Nil); err != nil {
+				// Log but don't fail - they can't sign in again
+			}
+		}
+		if int64(params.Page*params.PageSize) >= total || len(users) == 0 {
+			return
+		}
+		params.Page++
+	}
+}
+
+func (s *TrialService) createAuditLog(tenantID, userID uuid.UUID, message string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   tenantID,
+		Action:       models.AuditUpdate,
+		ResourceType: "tenant",
+		Details:      models.JSONB{"message": message},
+	}
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+// isTrialSuspended reports whether the tenant was suspended because its
+// trial expired
+func isTrialSuspended(tenant *models.Tenant) bool {
+	return !tenant.IsActive && tenant.TrialExpiredAt != nil
+}
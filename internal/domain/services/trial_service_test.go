@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrialExpiryAction(t *testing.T) {
+	action, err := ParseTrialExpiryAction("")
+	require.NoError(t, err)
+	assert.Equal(t, TrialExpirySuspend, action)
+
+	action, err = ParseTrialExpiryAction(" Downgrade ")
+	require.NoError(t, err)
+	assert.Equal(t, TrialExpiryDowngrade, action)
+
+	_, err = ParseTrialExpiryAction("delete")
+	assert.ErrorIs(t, err, ErrInvalidTrialExpiryAction)
+}
+
+func TestTrialService_WarningDue(t *testing.T) {
+	service := NewTrialService(nil, nil, nil, nil, nil, nil, TrialConfig{})
+	now := time.Now()
+	endsAt := now.Add(5 * 24 * time.Hour)
+	tenant := &models.Tenant{TrialEndsAt: &endsAt}
+
+	// Inside the 7 day window and never warned
+	assert.True(t, service.warningDue(tenant, now))
+
+	// Already warned in this window
+	warnedAt := endsAt.Add(-6 * 24 * time.Hour)
+	tenant.TrialWarnedAt = &warnedAt
+	assert.False(t, service.warningDue(tenant, now))
+
+	// The last day is a new window
+	assert.True(t, service.warningDue(tenant, endsAt.Add(-12*time.Hour)))
+
+	// Too early for any warning
+	farOff := now.Add(30 * 24 * time.Hour)
+	assert.False(t, service.warningDue(&models.Tenant{TrialEndsAt: &farOff}, now))
+}
+
+func TestTrialService_ReactivateNeedsBilling(t *testing.T) {
+	// Without billing a tenant could pick any tier for free
+	service := NewTrialService(nil, nil, nil, nil, nil, nil, TrialConfig{})
+	_, err := service.Reactivate(context.Background(), uuid.New(), models.SubscriptionEnterprise, uuid.New())
+	assert.ErrorIs(t, err, ErrBillingNotConfigured)
+
+	_, err = service.Reactivate(context.Background(), uuid.New(), "platinum", uuid.New())
+	assert.ErrorIs(t, err, ErrInvalidSubscriptionTier)
+}
@@ -3,11 +3,16 @@ package services
 import (
 	"context"
 	"crypto/rand"
-	"encoding/base32"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,18 +31,48 @@ var (
 	ErrUserInactive           = errors.New("user account is inactive")
 	ErrMFARequired            = errors.New("MFA verification required")
 	ErrInvalidMFACode         = errors.New("invalid MFA code")
+	ErrMFAAlreadyEnabled      = errors.New("MFA already enabled")
+	ErrMFANotEnabled          = errors.New("MFA not enabled")
+	ErrMFAEnrollmentNotFound  = errors.New("no MFA enrollment in progress")
+	ErrSessionRevoked         = errors.New("session has been revoked")
+	ErrSessionNotFound        = errors.New("session not found")
+	ErrAccountLocked          = errors.New("too many failed login attempts")
 	ErrInsufficientPrivileges = errors.New("insufficient privileges")
+	ErrInvalidManager         = errors.New("manager must be another active user of the tenant, without a reporting loop")
 )
 
+// maxReportingDepth bounds walks up the reporting line
+const maxReportingDepth = 50
+
+// defaultLockoutDuration applies when MaxLoginAttempts is set without a duration
+const defaultLockoutDuration = 15 * time.Minute
+
+// AccountLockedError is returned by Login while a user is locked out from an
+// IP address. It matches ErrAccountLocked with errors.Is.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("%s, try again after %s", ErrAccountLocked, e.Until.Format(time.RFC3339))
+}
+
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
 // UserService handles user management and authentication with Supabase
 type UserService struct {
 	userRepo     repositories.UserRepository
+	roleRepo     repositories.RoleRepository
 	tenantRepo   repositories.TenantRepository
 	auditRepo    repositories.AuditLogRepository
+	mfaCodeRepo  repositories.MFARecoveryCodeRepository
 	supabaseAuth SupabaseAuthService
 	emailService EmailService
 	config       UserServiceConfig
 	cacheService CacheService
+	abuseGuard   AttemptGuard
 }
 
 // UserServiceConfig holds configuration for user management
@@ -57,21 +92,27 @@ type UserServiceConfig struct {
 // NewUserService creates a new user service with Supabase
 func NewUserService(
 	userRepo repositories.UserRepository,
+	roleRepo repositories.RoleRepository,
 	tenantRepo repositories.TenantRepository,
 	auditRepo repositories.AuditLogRepository,
+	mfaCodeRepo repositories.MFARecoveryCodeRepository,
 	supabaseAuth SupabaseAuthService,
 	emailService EmailService,
 	config UserServiceConfig,
 	cacheService CacheService,
+	abuseGuard AttemptGuard,
 ) *UserService {
 	return &UserService{
 		userRepo:     userRepo,
+		roleRepo:     roleRepo,
 		tenantRepo:   tenantRepo,
 		auditRepo:    auditRepo,
+		mfaCodeRepo:  mfaCodeRepo,
 		supabaseAuth: supabaseAuth,
 		emailService: emailService,
 		config:       config,
 		cacheService: cacheService,
+		abuseGuard:   abuseGuard,
 	}
 }
 
@@ -94,6 +135,7 @@ type LoginParams struct {
 	Email           string `json:"email"`
 	Password        string `json:"password"`
 	MFACode         string `json:"mfa_code,omitempty"`
+	CaptchaToken    string `json:"-"`
 	IPAddress       string `json:"ip_address,omitempty"`
 	UserAgent       string `json:"user_agent,omitempty"`
 }
@@ -185,7 +227,7 @@ func (s *UserService) CreateUser(ctx context.Context, params CreateUserParams) (
 	// Send welcome email
 	if s.emailService != nil {
 		go func() {
-			s.emailService.SendWelcomeEmail(context.Background(), user.Email, user.FirstName)
+			s.emailService.SendWelcomeEmail(context.Background(), user.TenantID, user.Email, user.FirstName)
 		}()
 	}
 
@@ -203,14 +245,41 @@ func (s *UserService) Login(ctx context.Context, params LoginParams) (*LoginResu
 		return nil, ErrInvalidCredentials
 	}
 
-	// Check if tenant is active
-	if !tenant.IsActive {
+	// Check if tenant is active; admins of a tenant suspended at the end of
+	// its trial may still sign in, to choose a plan
+	if !tenant.IsActive && !isTrialSuspended(tenant) {
 		return nil, errors.New("tenant account suspended")
 	}
 
+	// Refuse locked out user+IP pairs before touching Supabase
+	attemptKey := loginAttemptKey(tenant.ID, params.Email, params.IPAddress)
+	if err := s.checkLoginLockout(ctx, attemptKey); err != nil {
+		return nil, err
+	}
+
+	// Tenant rate limits, and a CAPTCHA after repeated failures
+	attempt := Attempt{
+		TenantID:     tenant.ID,
+		Scope:        AttemptScopeLogin,
+		Subject:      strings.ToLower(strings.TrimSpace(params.Email)),
+		IPAddress:    params.IPAddress,
+		CaptchaToken: params.CaptchaToken,
+	}
+	if s.abuseGuard != nil {
+		if err := s.abuseGuard.CheckRateLimit(ctx, tenant.ID, params.IPAddress); err != nil {
+			return nil, err
+		}
+		if err := s.abuseGuard.CheckAttempt(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+
 	// Authenticate with Supabase
 	authResponse, err := s.supabaseAuth.SignInWithEmail(params.Email, params.Password)
 	if err != nil {
+		if err := s.recordFailedLogin(ctx, tenant.ID, params, attempt, attemptKey); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
 
@@ -225,6 +294,14 @@ func (s *UserService) Login(ctx context.Context, params LoginParams) (*LoginResu
 		return nil, ErrUserInactive
 	}
 
+	// Only admins may sign in to a tenant waiting to be deleted, to export or cancel
+	if tenant.DeletionScheduledFor != nil && user.Role != models.UserRoleAdmin {
+		return nil, ErrTenantPendingDeletion
+	}
+	if isTrialSuspended(tenant) && user.Role != models.UserRoleAdmin {
+		return nil, ErrTrialExpired
+	}
+
 	// Handle MFA if enabled
 	if user.MFAEnabled && params.MFACode == "" {
 		return &LoginResult{
@@ -232,13 +309,23 @@ func (s *UserService) Login(ctx context.Context, params LoginParams) (*LoginResu
 		}, nil
 	}
 
-	// Verify MFA code if provided
+	// Verify MFA code (TOTP or recovery code) if provided
 	if user.MFAEnabled && params.MFACode != "" {
-		if !s.verifyMFACode(user.MFASecret, params.MFACode) {
-			return nil, ErrInvalidMFACode
+		if err := s.verifyMFACode(ctx, user, params.MFACode); err != nil {
+			if err == ErrInvalidMFACode {
+				if err := s.recordFailedLogin(ctx, tenant.ID, params, attempt, attemptKey); err != nil {
+					return nil, err
+				}
+			}
+			return nil, err
 		}
 	}
 
+	// A successful login starts the failure count over
+	if s.abuseGuard != nil {
+		s.abuseGuard.ClearFailures(ctx, attempt)
+	}
+
 	// Update last login
 	now := time.Now()
 	user.LastLoginAt = &now
@@ -301,8 +388,8 @@ func (s *UserService) GetUserProfile(ctx context.Context, userID uuid.UUID) (*Us
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	// Get user permissions based on role
-	permissions := s.getRolePermissions(user.Role)
+	// Get user permissions based on role (custom roles resolve from the database)
+	permissions := s.resolvePermissions(ctx, user)
 
 	// Calculate password expiry
 	var passwordExpiry *time.Time
@@ -371,6 +458,52 @@ func (s *UserService) UpdateUser(ctx context.Context, userID uuid.UUID, updates
 	return user, nil
 }
 
+// SetManager sets the user a user reports to, or clears it with a nil
+// manager. Workflow tasks escalate along these reporting lines.
+func (s *UserService) SetManager(ctx context.Context, tenantID, userID uuid.UUID, managerID *uuid.UUID, updatedByID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrUserNotFound
+	}
+
+	if managerID != nil {
+		if *managerID == userID {
+			return nil, ErrInvalidManager
+		}
+		manager, err := s.userRepo.GetByID(ctx, *managerID)
+		if err != nil || manager.TenantID != tenantID || !manager.IsActive {
+			return nil, ErrInvalidManager
+		}
+
+		// The user must not already be above the new manager
+		current := manager
+		for depth := 0; current.ManagerID != nil && depth < maxReportingDepth; depth++ {
+			if *current.ManagerID == userID {
+				return nil, ErrInvalidManager
+			}
+			if current, err = s.userRepo.GetByID(ctx, *current.ManagerID); err != nil {
+				break
+			}
+		}
+	}
+
+	user.ManagerID = managerID
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if s.cacheService != nil {
+		s.cacheService.Delete(ctx, fmt.Sprintf(UserCacheKeyPattern, userID.String()))
+	}
+
+	details := "Manager cleared"
+	if managerID != nil {
+		details = "Manager set to " + managerID.String()
+	}
+	s.createAuditLog(ctx, tenantID, updatedByID, userID, models.AuditUpdate, details)
+
+	return user, nil
+}
+
 // ChangePassword changes a user's password via Supabase
 func (s *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, accessToken, newPassword string) error {
 	// Validate new password
@@ -398,36 +531,140 @@ func (s *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, acce
 	return nil
 }
 
-// EnableMFA enables multi-factor authentication for a user
-func (s *UserService) EnableMFA(ctx context.Context, userID uuid.UUID) (string, error) {
+// MFAEnrollment is returned when a user starts MFA enrollment
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// MFAStatus describes a user's MFA state
+type MFAStatus struct {
+	Enabled                bool  `json:"enabled"`
+	EnrollmentPending      bool  `json:"enrollment_pending"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// GetMFAStatus returns the user's MFA state
+func (s *UserService) GetMFAStatus(ctx context.Context, userID uuid.UUID) (*MFAStatus, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return "", ErrUserNotFound
+		return nil, ErrUserNotFound
 	}
 
+	status := &MFAStatus{
+		Enabled:           user.MFAEnabled,
+		EnrollmentPending: !user.MFAEnabled && user.MFAPendingSecret != "",
+	}
 	if user.MFAEnabled {
-		return "", errors.New("MFA already enabled")
+		if status.RecoveryCodesRemaining, err = s.mfaCodeRepo.CountUnused(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// StartMFAEnrollment generates a TOTP secret for the user to add to an
+// authenticator app. MFA stays off until ConfirmMFAEnrollment succeeds;
+// starting again replaces any unconfirmed secret.
+func (s *UserService) StartMFAEnrollment(ctx context.Context, userID uuid.UUID) (*MFAEnrollment, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
 	}
 
 	// Generate MFA secret
 	secret, err := s.generateMFASecret()
 	if err != nil {
-		return "", fmt.Errorf("failed to generate MFA secret: %w", err)
+		return nil, fmt.Errorf("failed to generate MFA secret: %w", err)
 	}
 
-	// Save MFA secret
-	if err := s.userRepo.SetMFA(ctx, userID, true, secret); err != nil {
-		return "", fmt.Errorf("failed to enable MFA: %w", err)
+	if err := s.userRepo.SetPendingMFA(ctx, userID, secret); err != nil {
+		return nil, fmt.Errorf("failed to start MFA enrollment: %w", err)
+	}
+
+	// Return QR code data for user to scan
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURL: s.generateMFAQRCode(user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFAEnrollment enables MFA once the user proves their authenticator
+// produces valid codes, and returns a fresh set of recovery codes. The
+// plaintext codes are shown once; only their hashes are stored.
+func (s *UserService) ConfirmMFAEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFAPendingSecret == "" {
+		return nil, ErrMFAEnrollmentNotFound
+	}
+
+	step, ok := validateTOTP(user.MFAPendingSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	// Enable MFA
+	if err := s.userRepo.SetMFA(ctx, userID, true, user.MFAPendingSecret); err != nil {
+		return nil, fmt.Errorf("failed to enable MFA: %w", err)
+	}
+
+	// The confirming code is spent
+	if _, err := s.userRepo.ClaimMFAStep(ctx, userID, step); err != nil {
+		// Log but don't fail
+	}
+
+	recoveryCodes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create audit log
 	s.createAuditLog(ctx, user.TenantID, userID, userID, models.AuditUpdate, "MFA enabled")
+	s.cacheService.Delete(ctx, fmt.Sprintf(UserCacheKeyPattern, userID.String()))
 
-	// Return QR code data for user to scan
-	return s.generateMFAQRCode(user.Email, secret), nil
+	return recoveryCodes, nil
 }
 
-// DisableMFA disables multi-factor authentication for a user
+// RegenerateRecoveryCodes replaces the user's recovery codes after verifying
+// a current authenticator code
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	if err := s.verifyTOTPCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create audit log
+	s.createAuditLog(ctx, user.TenantID, userID, userID, models.AuditUpdate, "MFA recovery codes regenerated")
+
+	return recoveryCodes, nil
+}
+
+// DisableMFA disables multi-factor authentication for a user. Either an
+// authenticator code or a recovery code is accepted.
 func (s *UserService) DisableMFA(ctx context.Context, userID uuid.UUID, mfaCode string) error {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
@@ -435,12 +672,12 @@ func (s *UserService) DisableMFA(ctx context.Context, userID uuid.UUID, mfaCode
 	}
 
 	if !user.MFAEnabled {
-		return errors.New("MFA not enabled")
+		return ErrMFANotEnabled
 	}
 
 	// Verify MFA code before disabling
-	if !s.verifyMFACode(user.MFASecret, mfaCode) {
-		return ErrInvalidMFACode
+	if err := s.verifyMFACode(ctx, user, mfaCode); err != nil {
+		return err
 	}
 
 	// Disable MFA
@@ -448,8 +685,13 @@ func (s *UserService) DisableMFA(ctx context.Context, userID uuid.UUID, mfaCode
 		return fmt.Errorf("failed to disable MFA: %w", err)
 	}
 
+	if err := s.mfaCodeRepo.DeleteByUser(ctx, userID); err != nil {
+		// Log but don't fail
+	}
+
 	// Create audit log
 	s.createAuditLog(ctx, user.TenantID, userID, userID, models.AuditUpdate, "MFA disabled")
+	s.cacheService.Delete(ctx, fmt.Sprintf(UserCacheKeyPattern, userID.String()))
 
 	return nil
 }
@@ -531,7 +773,7 @@ func (s *UserService) CheckPermission(ctx context.Context, userID uuid.UUID, per
 		return false, ErrUserNotFound
 	}
 
-	permissions := s.getRolePermissions(user.Role)
+	permissions := s.resolvePermissions(ctx, user)
 	for _, p := range permissions {
 		if p == permission || p == "*" {
 			return true, nil
@@ -560,6 +802,11 @@ func (s *UserService) DeactivateUser(ctx context.Context, userID, deactivatedBy
 		"user_disabled": true,
 	})
 
+	// Cut off tokens already handed out
+	if err := s.RevokeAllSessions(ctx, userID, deactivatedBy); err != nil {
+		// Log but don't fail; inactive users are also rejected at token validation
+	}
+
 	// Create audit log
 	s.createAuditLog(ctx, user.TenantID, deactivatedBy, userID, models.AuditUpdate, "User deactivated")
 
@@ -645,18 +892,104 @@ func (s *UserService) generateMFASecret() (string, error) {
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
-	return base32.StdEncoding.EncodeToString(bytes), nil
+	return totpEncoding.EncodeToString(bytes), nil
 }
 
 func (s *UserService) generateMFAQRCode(email, secret string) string {
 	// Generate QR code data for TOTP
-	return fmt.Sprintf("otpauth://totp/Archivus:%s?secret=%s&issuer=Archivus", email, secret)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", "Archivus")
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(totpDigits))
+	query.Set("period", strconv.Itoa(totpPeriod))
+	return fmt.Sprintf("otpauth://totp/Archivus:%s?%s", url.PathEscape(email), query.Encode())
+}
+
+// verifyMFACode accepts a current authenticator code or an unused recovery code
+func (s *UserService) verifyMFACode(ctx context.Context, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits {
+		return s.verifyTOTPCode(ctx, user, code)
+	}
+
+	consumed, err := s.mfaCodeRepo.Consume(ctx, user.ID, hashRecoveryCode(code), time.Now())
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+
+	s.createAuditLog(ctx, user.TenantID, user.ID, user.ID, models.AuditUpdate, "MFA recovery code used")
+	return nil
 }
 
-func (s *UserService) verifyMFACode(secret, code string) bool {
-	// Implementation would verify TOTP code
-	// This is a simplified version
-	return len(code) == 6 && regexp.MustCompile(`^[0-9]{6}$`).MatchString(code)
+// verifyTOTPCode checks an authenticator code and claims its time step so
+// the same code can't be used twice
+func (s *UserService) verifyTOTPCode(ctx context.Context, user *models.User, code string) error {
+	step, ok := validateTOTP(user.MFASecret, strings.TrimSpace(code), time.Now())
+	if !ok || step <= user.MFALastUsedStep {
+		return ErrInvalidMFACode
+	}
+
+	claimed, err := s.userRepo.ClaimMFAStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *UserService) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	if err := s.mfaCodeRepo.ReplaceForUser(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// resolvePermissions returns a user's effective permissions. Users with a custom
+// role get that role's permissions from the database, cached in Redis; everyone
+// else gets the defaults for their built-in role.
+func (s *UserService) resolvePermissions(ctx context.Context, user *models.User) []string {
+	if user.CustomRoleID == nil || s.roleRepo == nil {
+		return s.getRolePermissions(user.Role)
+	}
+
+	cacheKey := fmt.Sprintf(RolePermissionsKeyPattern, user.CustomRoleID.String())
+	if cached, err := s.cacheService.Get(ctx, cacheKey); err == nil {
+		var permissions []string
+		if json.Unmarshal([]byte(cached), &permissions) == nil {
+			return permissions
+		}
+	}
+
+	role, err := s.roleRepo.GetByID(ctx, *user.CustomRoleID)
+	if err != nil || role.TenantID != user.TenantID {
+		return s.getRolePermissions(user.Role)
+	}
+
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Permission)
+	}
+
+	if permissionsJSON, err := json.Marshal(permissions); err == nil {
+		s.cacheService.Set(ctx, cacheKey, string(permissionsJSON), CacheMediumTerm)
+	}
+
+	return permissions
 }
 
 func (s *UserService) getRolePermissions(role models.UserRole) []string {
@@ -686,7 +1019,7 @@ func (s *UserService) getRolePermissions(role models.UserRole) []string {
 		}
 	case models.UserRoleCompliance:
 		return []string{
-			"documents.read", "audit.read", "compliance.read",
+			"documents.read", "audit.read", "compliance.read", "legal_holds.manage",
 			"reports.read", "analytics.read",
 		}
 	default:
@@ -734,7 +1067,7 @@ func (s *UserService) createAuditLog(ctx context.Context, tenantID, userID, reso
 	}
 
 	go func() {
-		s.auditRepo.Create(context.Background(), log)
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
 	}()
 }
 
@@ -810,10 +1143,262 @@ func (s *UserService) IncrementUserLoginCount(ctx context.Context, userID uuid.U
 	return s.cacheService.Increment(ctx, counterKey)
 }
 
-// GetActiveUserSessions gets list of active sessions for a user (for security/admin purposes)
-func (s *UserService) GetActiveUserSessions(ctx context.Context, userID uuid.UUID) ([]string, error) {
-	sessionsKey := fmt.Sprintf("user_sessions:%s", userID.String())
-	return s.cacheService.SMembers(ctx, sessionsKey)
+// checkLoginLockout returns an AccountLockedError while a lockout is active.
+// Cache outages fail open so Redis being down doesn't block every login.
+func (s *UserService) checkLoginLockout(ctx context.Context, attemptKey string) error {
+	if s.config.MaxLoginAttempts <= 0 {
+		return nil
+	}
+
+	value, err := s.cacheService.Get(ctx, fmt.Sprintf(LoginLockoutKeyPattern, attemptKey))
+	if err != nil {
+		return nil
+	}
+
+	until, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || time.Now().Unix() >= until {
+		return nil
+	}
+	return &AccountLockedError{Until: time.Unix(until, 0)}
+}
+
+// recordFailedLogin counts a failed attempt with the abuse guard, which also
+// drives its CAPTCHA threshold, and locks the user+IP out once
+// MaxLoginAttempts failures fall within the guard's failure window. Further
+// failures in that window renew the lockout. It returns the
+// AccountLockedError for the attempt that triggers the lockout.
+func (s *UserService) recordFailedLogin(ctx context.Context, tenantID uuid.UUID, params LoginParams, attempt Attempt, attemptKey string) error {
+	if s.abuseGuard == nil {
+		return nil
+	}
+
+	count := s.abuseGuard.RecordFailure(ctx, attempt)
+	if s.config.MaxLoginAttempts <= 0 || count < int64(s.config.MaxLoginAttempts) {
+		return nil
+	}
+
+	window := time.Duration(s.config.LockoutDurationMins) * time.Minute
+	if window <= 0 {
+		window = defaultLockoutDuration
+	}
+
+	until := time.Now().Add(window)
+	if err := s.cacheService.Set(ctx, fmt.Sprintf(LoginLockoutKeyPattern, attemptKey), until.Unix(), window); err != nil {
+		return nil
+	}
+
+	// Audit against the account when it exists locally
+	if user, err := s.userRepo.GetByEmail(ctx, tenantID, params.Email); err == nil {
+		s.createAuditLog(ctx, tenantID, user.ID, user.ID, models.AuditBlocked,
+			fmt.Sprintf("Login locked until %s after %d failed attempts from %s",
+				until.Format(time.RFC3339), count, params.IPAddress))
+	}
+
+	return &AccountLockedError{Until: until}
+}
+
+// SessionInfo describes one of a user's active sessions
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// TrackSession records activity on an access token's session and rejects
+// tokens whose session was revoked, or that were issued before the user's
+// last "log out everywhere". It returns the session ID. Cache outages don't
+// lock users out: revocation is enforced whenever Redis is reachable.
+func (s *UserService) TrackSession(ctx context.Context, userID uuid.UUID, accessToken, ipAddress, userAgent string) (string, error) {
+	sessionID, issuedAt := sessionFromToken(accessToken)
+
+	if revoked, err := s.cacheService.Exists(ctx, fmt.Sprintf(SessionRevokedKeyPattern, sessionID)); err == nil && revoked {
+		return "", ErrSessionRevoked
+	}
+
+	sessionKey := fmt.Sprintf(SessionKeyPattern, sessionID)
+	exists, err := s.cacheService.Exists(ctx, sessionKey)
+	if err != nil {
+		return sessionID, nil
+	}
+
+	if cutoff, err := s.cacheService.Get(ctx, fmt.Sprintf(UserSessionsRevokedKeyPattern, userID.String())); err == nil {
+		revokedBefore, _ := strconv.ParseInt(cutoff, 10, 64)
+		// Without an iat claim, a session first seen after the cutoff is new
+		if issuedAt.IsZero() && !exists {
+			issuedAt = time.Now()
+		}
+		if !issuedAt.IsZero() && issuedAt.Unix() < revokedBefore {
+			return "", ErrSessionRevoked
+		}
+	}
+
+	now := time.Now().Unix()
+	if !exists {
+		fields := map[string]interface{}{
+			"user_id":    userID.String(),
+			"created_at": now,
+			"ip_address": ipAddress,
+			"user_agent": userAgent,
+		}
+		for field, value := range fields {
+			s.cacheService.HSet(ctx, sessionKey, field, value)
+		}
+		s.cacheService.SAdd(ctx, fmt.Sprintf(UserSessionsKeyPattern, userID.String()), sessionID)
+	}
+	s.cacheService.HSet(ctx, sessionKey, "last_seen", now)
+
+	return sessionID, nil
+}
+
+// ListUserSessions returns a user's sessions seen within the session lifetime
+func (s *UserService) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]SessionInfo, error) {
+	sessionIDs, err := s.cacheService.SMembers(ctx, fmt.Sprintf(UserSessionsKeyPattern, userID.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	cutoff := time.Now().Add(-SessionDuration)
+	sessions := make([]SessionInfo, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		if revoked, _ := s.cacheService.Exists(ctx, fmt.Sprintf(SessionRevokedKeyPattern, sessionID)); revoked {
+			continue
+		}
+
+		fields, err := s.cacheService.HGetAll(ctx, fmt.Sprintf(SessionKeyPattern, sessionID))
+		if err != nil || fields["user_id"] != userID.String() {
+			continue
+		}
+
+		session := SessionInfo{
+			ID:        sessionID,
+			IPAddress: fields["ip_address"],
+			UserAgent: fields["user_agent"],
+			CreatedAt: unixField(fields["created_at"]),
+			LastSeen:  unixField(fields["last_seen"]),
+		}
+		if session.LastSeen.Before(cutoff) {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions immediately
+func (s *UserService) RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string, revokedBy uuid.UUID) error {
+	sessionKey := fmt.Sprintf(SessionKeyPattern, sessionID)
+	owner, err := s.cacheService.HGet(ctx, sessionKey, "user_id")
+	if err != nil || owner != userID.String() {
+		return ErrSessionNotFound
+	}
+
+	if err := s.revokeSessionID(ctx, sessionID); err != nil {
+		return err
+	}
+
+	if user, err := s.userRepo.GetByID(ctx, userID); err == nil {
+		s.createAuditLog(ctx, user.TenantID, revokedBy, userID, models.AuditUpdate, "Session revoked")
+	}
+
+	return nil
+}
+
+// RevokeSessionByToken ends the session an access token belongs to (logout).
+// The token must already have been validated by the auth provider.
+func (s *UserService) RevokeSessionByToken(ctx context.Context, accessToken string) error {
+	sessionID, _ := sessionFromToken(accessToken)
+	return s.revokeSessionID(ctx, sessionID)
+}
+
+// RevokeAllSessions logs the user out everywhere: every token issued before
+// now is rejected, including sessions this service never saw
+func (s *UserService) RevokeAllSessions(ctx context.Context, userID, revokedBy uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	// Tokens carry whole-second iat claims; round up so one issued in this
+	// second is rejected too
+	cutoff := time.Now().Unix() + 1
+	if err := s.cacheService.Set(ctx, fmt.Sprintf(UserSessionsRevokedKeyPattern, userID.String()), cutoff, SessionDuration); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	sessionsKey := fmt.Sprintf(UserSessionsKeyPattern, userID.String())
+	if sessionIDs, err := s.cacheService.SMembers(ctx, sessionsKey); err == nil {
+		for _, sessionID := range sessionIDs {
+			if err := s.revokeSessionID(ctx, sessionID); err != nil {
+				// Log but don't fail; the cutoff already covers this session
+			}
+		}
+	}
+	s.cacheService.Delete(ctx, sessionsKey)
+
+	// Refresh tokens would otherwise mint new access tokens past the cutoff
+	if s.supabaseAuth != nil {
+		if err := s.supabaseAuth.AdminSignOutAll(userID.String()); err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+	}
+
+	s.createAuditLog(ctx, user.TenantID, revokedBy, userID, models.AuditUpdate, "All sessions revoked")
+
+	return nil
+}
+
+func (s *UserService) revokeSessionID(ctx context.Context, sessionID string) error {
+	if err := s.cacheService.Set(ctx, fmt.Sprintf(SessionRevokedKeyPattern, sessionID), "1", SessionDuration); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	s.cacheService.Delete(ctx, fmt.Sprintf(SessionKeyPattern, sessionID))
+	return nil
+}
+
+// sessionFromToken identifies the session behind an already-validated access
+// token. Supabase tokens carry a session_id claim that survives refreshes;
+// other tokens are identified by their hash. iat is zero when absent.
+func sessionFromToken(accessToken string) (string, time.Time) {
+	var claims struct {
+		SessionID string `json:"session_id"`
+		IssuedAt  int64  `json:"iat"`
+	}
+
+	if parts := strings.Split(accessToken, "."); len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil {
+			json.Unmarshal(payload, &claims)
+		}
+	}
+
+	var issuedAt time.Time
+	if claims.IssuedAt > 0 {
+		issuedAt = time.Unix(claims.IssuedAt, 0)
+	}
+
+	if claims.SessionID != "" {
+		return claims.SessionID, issuedAt
+	}
+	sum := sha256.Sum256([]byte(accessToken))
+	return hex.EncodeToString(sum[:16]), issuedAt
+}
+
+func unixField(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+func loginAttemptKey(tenantID uuid.UUID, email, ipAddress string) string {
+	return fmt.Sprintf("%s:%s:%s", tenantID, strings.ToLower(strings.TrimSpace(email)), ipAddress)
 }
 
 // AddUserToActiveList adds user to active users set
@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_SetManager(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repos := postgresql.NewRepositories(db.DB)
+	service := services.NewUserService(repos.UserRepo, repos.RoleRepo, repos.TenantRepo, repos.AuditRepo, repos.MFACodeRepo, nil, nil, services.UserServiceConfig{}, nil, nil)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	admin := db.CreateTestUser(t, tenant)
+	director := db.CreateTestUser(t, tenant)
+	manager := db.CreateTestUser(t, tenant)
+	employee := db.CreateTestUser(t, tenant)
+	other := db.CreateTestTenant(t)
+	outsider := db.CreateTestUser(t, other)
+
+	_, err := service.SetManager(ctx, tenant.ID, manager.ID, &director.ID, admin.ID)
+	require.NoError(t, err)
+	updated, err := service.SetManager(ctx, tenant.ID, employee.ID, &manager.ID, admin.ID)
+	require.NoError(t, err)
+	assert.Equal(t, manager.ID, *updated.ManagerID)
+
+	t.Run("self", func(t *testing.T) {
+		_, err := service.SetManager(ctx, tenant.ID, employee.ID, &employee.ID, admin.ID)
+		assert.ErrorIs(t, err, services.ErrInvalidManager)
+	})
+
+	t.Run("reporting loop", func(t *testing.T) {
+		_, err := service.SetManager(ctx, tenant.ID, director.ID, &employee.ID, admin.ID)
+		assert.ErrorIs(t, err, services.ErrInvalidManager)
+	})
+
+	t.Run("manager of another tenant", func(t *testing.T) {
+		_, err := service.SetManager(ctx, tenant.ID, employee.ID, &outsider.ID, admin.ID)
+		assert.ErrorIs(t, err, services.ErrInvalidManager)
+	})
+
+	t.Run("user of another tenant", func(t *testing.T) {
+		_, err := service.SetManager(ctx, other.ID, employee.ID, &outsider.ID, outsider.ID)
+		assert.ErrorIs(t, err, services.ErrUserNotFound)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		updated, err := service.SetManager(ctx, tenant.ID, employee.ID, nil, admin.ID)
+		require.NoError(t, err)
+		assert.Nil(t, updated.ManagerID)
+	})
+}
@@ -0,0 +1,736 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrVendorProfileNotFound = errors.New("vendor profile not found")
+	ErrVendorProfileExists   = errors.New("vendor profile already exists")
+	ErrVendorNameRequired    = errors.New("vendor name is required")
+	ErrInvalidDateFormat     = errors.New("unsupported date format")
+	ErrInvalidCurrency       = errors.New("currency must be an ISO 4217 code")
+	ErrInvalidFeedbackDate   = errors.New("dates must be YYYY-MM-DD")
+)
+
+// vendorDateFormat is a date format vendors print: the layouts it is
+// written in, padded first, and a pattern finding it in text
+type vendorDateFormat struct {
+	layouts []string
+	pattern *regexp.Regexp
+}
+
+var (
+	slashDatePattern = regexp.MustCompile(`\b\d{1,2}/\d{1,2}/\d{4}\b`)
+	dotDatePattern   = regexp.MustCompile(`\b\d{1,2}\.\d{1,2}\.\d{4}\b`)
+	dashDatePattern  = regexp.MustCompile(`\b\d{1,2}-\d{1,2}-\d{4}\b`)
+
+	vendorDateFormats = map[string]vendorDateFormat{
+		"DD/MM/YYYY": {[]string{"02/01/2006", "2/1/2006"}, slashDatePattern},
+		"MM/DD/YYYY": {[]string{"01/02/2006", "1/2/2006"}, slashDatePattern},
+		"DD.MM.YYYY": {[]string{"02.01.2006", "2.1.2006"}, dotDatePattern},
+		"DD-MM-YYYY": {[]string{"02-01-2006", "2-1-2006"}, dashDatePattern},
+		"MM-DD-YYYY": {[]string{"01-02-2006", "1-2-2006"}, dashDatePattern},
+		"YYYY-MM-DD": {[]string{"2006-01-02"}, regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`)},
+		"YYYY/MM/DD": {[]string{"2006/01/02"}, regexp.MustCompile(`\b\d{4}/\d{2}/\d{2}\b`)},
+	}
+)
+
+// VendorDateFormats lists the date formats a vendor profile can name
+var VendorDateFormats = []string{"DD/MM/YYYY", "MM/DD/YYYY", "DD.MM.YYYY", "DD-MM-YYYY", "MM-DD-YYYY", "YYYY-MM-DD", "YYYY/MM/DD"}
+
+// VendorExtractionHints is what a vendor profile tells the AI provider
+type VendorExtractionHints struct {
+	VendorName          string `json:"vendor_name"`
+	DateFormat          string `json:"date_format,omitempty"`
+	Currency            string `json:"currency,omitempty"`
+	DocumentNumberLabel string `json:"document_number_label,omitempty"`
+	Notes               string `json:"notes,omitempty"`
+}
+
+// ProfiledFinancialExtractor is implemented by AI clients that can take a
+// vendor profile into account. Other clients extract without it, and the
+// profile is only applied to their output.
+type ProfiledFinancialExtractor interface {
+	ExtractFinancialDataWithProfile(ctx context.Context, text string, docType models.DocumentType, hints VendorExtractionHints) (map[string]interface{}, error)
+}
+
+// VendorProfileService manages vendor extraction profiles and refines them
+// from corrections to extracted financial data
+type VendorProfileService struct {
+	profileRepo     repositories.VendorProfileRepository
+	documentRepo    repositories.DocumentRepository
+	auditRepo       repositories.AuditLogRepository
+	feedbackRepo    repositories.AIFeedbackRepository
+	documentService *DocumentService
+}
+
+// NewVendorProfileService creates a new vendor profile service
+func NewVendorProfileService(
+	profileRepo repositories.VendorProfileRepository,
+	documentRepo repositories.DocumentRepository,
+	auditRepo repositories.AuditLogRepository,
+	feedbackRepo repositories.AIFeedbackRepository,
+	documentService *DocumentService,
+) *VendorProfileService {
+	return &VendorProfileService{
+		profileRepo:     profileRepo,
+		documentRepo:    documentRepo,
+		auditRepo:       auditRepo,
+		feedbackRepo:    feedbackRepo,
+		documentService: documentService,
+	}
+}
+
+// VendorProfileParams contains parameters for creating a vendor profile
+type VendorProfileParams struct {
+	TenantID            uuid.UUID `json:"tenant_id"`
+	CreatedBy           uuid.UUID `json:"created_by"`
+	VendorName          string    `json:"vendor_name"`
+	Aliases             []string  `json:"aliases"`
+	DateFormat          string    `json:"date_format"`
+	Currency            string    `json:"currency"`
+	DocumentNumberLabel string    `json:"document_number_label"`
+	Hints               string    `json:"hints"`
+}
+
+// UpdateVendorProfileParams contains profile changes; nil fields are unchanged
+type UpdateVendorProfileParams struct {
+	VendorName          *string  `json:"vendor_name"`
+	Aliases             []string `json:"aliases"`
+	DateFormat          *string  `json:"date_format"`
+	Currency            *string  `json:"currency"`
+	DocumentNumberLabel *string  `json:"document_number_label"`
+	Hints               *string  `json:"hints"`
+}
+
+// ExtractionFeedbackParams are a user's corrections to a document's
+// extracted financial data; nil fields were not corrected
+type ExtractionFeedbackParams struct {
+	VendorName     *string  `json:"vendor_name"`
+	DocumentNumber *string  `json:"document_number"`
+	DocumentDate   *string  `json:"document_date"` // YYYY-MM-DD
+	DueDate        *string  `json:"due_date"`      // YYYY-MM-DD
+	Currency       *string  `json:"currency"`
+	Amount         *float64 `json:"amount"`
+}
+
+// ExtractionFeedbackResult is the vendor profile after learning from a
+// correction, with the profile settings the correction changed
+type ExtractionFeedbackResult struct {
+	DocumentID uuid.UUID             `json:"document_id"`
+	Profile    *models.VendorProfile `json:"profile"`
+	Created    bool                  `json:"created"`
+	Learned    []string              `json:"learned"`
+}
+
+// CreateProfile configures a vendor profile
+func (s *VendorProfileService) CreateProfile(ctx context.Context, params VendorProfileParams) (*models.VendorProfile, error) {
+	name := strings.TrimSpace(params.VendorName)
+	normalized := normalizeVendorName(name)
+	if normalized == "" {
+		return nil, ErrVendorNameRequired
+	}
+	if _, err := s.profileRepo.GetByName(ctx, params.TenantID, normalized); err == nil {
+		return nil, ErrVendorProfileExists
+	}
+
+	profile := &models.VendorProfile{
+		TenantID:       params.TenantID,
+		VendorName:     name,
+		NormalizedName: normalized,
+		Source:         models.VendorProfileConfigured,
+		CreatedBy:      params.CreatedBy,
+	}
+	if err := s.applySettings(profile, &params.DateFormat, &params.Currency, &params.DocumentNumberLabel, &params.Hints); err != nil {
+		return nil, err
+	}
+	aliases, err := s.resolveAliases(ctx, profile, params.Aliases)
+	if err != nil {
+		return nil, err
+	}
+	profile.Aliases = aliases
+
+	if err := s.profileRepo.Create(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(params.TenantID, params.CreatedBy, profile.ID, models.AuditCreate, "Vendor profile created: "+name)
+
+	return profile, nil
+}
+
+// ListProfiles lists the tenant's vendor profiles by name
+func (s *VendorProfileService) ListProfiles(ctx context.Context, tenantID uuid.UUID) ([]models.VendorProfile, error) {
+	return s.profileRepo.ListByTenant(ctx, tenantID)
+}
+
+// GetProfile returns a vendor profile belonging to the tenant
+func (s *VendorProfileService) GetProfile(ctx context.Context, profileID, tenantID uuid.UUID) (*models.VendorProfile, error) {
+	profile, err := s.profileRepo.GetByID(ctx, profileID)
+	if err != nil || profile.TenantID != tenantID {
+		return nil, ErrVendorProfileNotFound
+	}
+	return profile, nil
+}
+
+// UpdateProfile changes a vendor profile's name, aliases or extraction settings
+func (s *VendorProfileService) UpdateProfile(ctx context.Context, profileID, tenantID, updatedBy uuid.UUID, params UpdateVendorProfileParams) (*models.VendorProfile, error) {
+	profile, err := s.GetProfile(ctx, profileID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.VendorName != nil {
+		name := strings.TrimSpace(*params.VendorName)
+		normalized := normalizeVendorName(name)
+		if normalized == "" {
+			return nil, ErrVendorNameRequired
+		}
+		if existing, err := s.profileRepo.GetByName(ctx, tenantID, normalized); err == nil && existing.ID != profile.ID {
+			return nil, ErrVendorProfileExists
+		}
+		profile.VendorName = name
+		profile.NormalizedName = normalized
+	}
+	if err := s.applySettings(profile, params.DateFormat, params.Currency, params.DocumentNumberLabel, params.Hints); err != nil {
+		return nil, err
+	}
+	if params.Aliases != nil {
+		if profile.Aliases, err = s.resolveAliases(ctx, profile, params.Aliases); err != nil {
+			return nil, err
+		}
+	}
+
+	profile.UpdatedAt = time.Now()
+	if err := s.profileRepo.Update(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(tenantID, updatedBy, profile.ID, models.AuditUpdate, "Vendor profile updated: "+profile.VendorName)
+
+	return profile, nil
+}
+
+// DeleteProfile deletes a vendor profile; its vendor is extracted without one
+func (s *VendorProfileService) DeleteProfile(ctx context.Context, profileID, tenantID, deletedBy uuid.UUID) error {
+	profile, err := s.GetProfile(ctx, profileID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.profileRepo.Delete(ctx, profile.ID); err != nil {
+		return err
+	}
+
+	s.createAuditLog(tenantID, deletedBy, profile.ID, models.AuditDelete, "Vendor profile deleted: "+profile.VendorName)
+
+	return nil
+}
+
+// MatchProfile finds the profile for a document's vendor: by the vendor
+// name when one is known, otherwise by the longest profile name or alias
+// appearing in the text. Returns nil when no profile matches.
+func (s *VendorProfileService) MatchProfile(ctx context.Context, tenantID uuid.UUID, vendorName, text string) *models.VendorProfile {
+	if normalized := normalizeVendorName(vendorName); normalized != "" {
+		if profile, err := s.profileRepo.GetByName(ctx, tenantID, normalized); err == nil {
+			return profile
+		}
+	}
+
+	profiles, err := s.profileRepo.ListByTenant(ctx, tenantID)
+	if err != nil || len(profiles) == 0 {
+		return nil
+	}
+
+	words := " " + normalizeVendorName(text) + " "
+	var match *models.VendorProfile
+	longest := 0
+	for i := range profiles {
+		names := []string{profiles[i].NormalizedName}
+		for _, alias := range profiles[i].Aliases {
+			names = append(names, alias.Alias)
+		}
+		for _, name := range names {
+			if len(name) > longest && strings.Contains(words, " "+name+" ") {
+				match, longest = &profiles[i], len(name)
+			}
+		}
+	}
+	return match
+}
+
+// SubmitFeedback applies a user's corrections to a document's extracted
+// financial data and refines the vendor's profile from them, creating the
+// profile if the vendor has none. A profile learns the names the AI read
+// the vendor as, its date format, currency and document number label.
+func (s *VendorProfileService) SubmitFeedback(ctx context.Context, tenantID, documentID, userID uuid.UUID, params ExtractionFeedbackParams) (*ExtractionFeedbackResult, error) {
+	document, err := s.documentService.getTenantDocument(ctx, documentID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.documentService.CheckDocumentAccess(ctx, document, userID, models.DocPermWrite); err != nil {
+		return nil, err
+	}
+
+	if err := params.normalize(); err != nil {
+		return nil, err
+	}
+
+	// What the AI extracted, before the corrections overwrite it
+	extracted := extractedFinancialData(document)
+	original, corrected := params.feedback(extracted)
+	feedback := newAIFeedback(document, userID, models.AIFeedbackFinancial, original, corrected)
+	applyExtractionCorrections(document, params)
+	for field := range corrected {
+		markVerifiedFields(document, field)
+	}
+
+	normalized := normalizeVendorName(document.VendorName)
+	if normalized == "" {
+		return nil, ErrVendorNameRequired
+	}
+
+	profile, err := s.profileRepo.GetByName(ctx, tenantID, normalized)
+	created := err != nil
+	if created {
+		profile = &models.VendorProfile{
+			TenantID:       tenantID,
+			VendorName:     strings.TrimSpace(document.VendorName),
+			NormalizedName: normalized,
+			Source:         models.VendorProfileLearned,
+			CreatedBy:      userID,
+		}
+	}
+
+	learned := s.learn(ctx, profile, documentText(document), extracted, params)
+
+	now := time.Now()
+	profile.CorrectionCount++
+	profile.LastCorrectedAt = &now
+	profile.UpdatedAt = now
+	if created {
+		err = s.profileRepo.Create(ctx, profile)
+	} else {
+		err = s.profileRepo.Update(ctx, profile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	document.UpdatedBy = &userID
+	document.UpdatedAt = now
+	if err := s.documentRepo.Update(ctx, document); err != nil {
+		return nil, fmt.Errorf("failed to update document: %w", err)
+	}
+
+	if err := s.feedbackRepo.Create(ctx, feedback); err != nil {
+		// Log but continue - the document has the corrections
+	}
+
+	s.documentService.createAuditLog(ctx, tenantID, userID, document.ID, models.AuditUpdate, "Financial extraction corrected")
+	if len(learned) > 0 {
+		s.createAuditLog(tenantID, userID, profile.ID, models.AuditUpdate,
+			fmt.Sprintf("Vendor profile learned %s: %s", strings.Join(learned, ", "), profile.VendorName))
+	}
+
+	return &ExtractionFeedbackResult{
+		DocumentID: document.ID,
+		Profile:    profile,
+		Created:    created,
+		Learned:    learned,
+	}, nil
+}
+
+// ExtractionHints returns the hints a profile gives the AI provider
+func ExtractionHints(profile *models.VendorProfile) VendorExtractionHints {
+	return VendorExtractionHints{
+		VendorName:          profile.VendorName,
+		DateFormat:          profile.DateFormat,
+		Currency:            profile.Currency,
+		DocumentNumberLabel: profile.DocumentNumberLabel,
+		Notes:               profile.Hints,
+	}
+}
+
+// Helper methods
+
+// learn refines a profile from corrections, returning the settings changed
+func (s *VendorProfileService) learn(ctx context.Context, profile *models.VendorProfile, text string, extracted *FinancialExtractionResult, corrections ExtractionFeedbackParams) []string {
+	learned := []string{}
+
+	// The name the AI read becomes an alias, unless another profile has it
+	if corrections.VendorName != nil {
+		if alias := normalizeVendorName(extracted.VendorName); alias != "" && alias != profile.NormalizedName && !hasVendorAlias(profile, alias) {
+			if owner, err := s.profileRepo.GetByName(ctx, profile.TenantID, alias); err != nil || owner.ID == profile.ID {
+				profile.Aliases = append(profile.Aliases, models.VendorProfileAlias{ProfileID: profile.ID, Alias: alias})
+				learned = append(learned, "aliases")
+			}
+		}
+	}
+
+	if corrections.DocumentNumber != nil && *corrections.DocumentNumber != "" {
+		if label := documentNumberLabel(text, *corrections.DocumentNumber); label != "" && label != profile.DocumentNumberLabel {
+			profile.DocumentNumberLabel = label
+			learned = append(learned, "document_number_label")
+		}
+	}
+
+	// Only a date printed in exactly one format tells the format apart
+	for _, date := range []*string{corrections.DocumentDate, corrections.DueDate} {
+		if date == nil || *date == "" {
+			continue
+		}
+		parsed, _ := time.Parse("2006-01-02", *date)
+		if format := detectDateFormat(text, parsed); format != "" {
+			if format != profile.DateFormat {
+				profile.DateFormat = format
+				learned = append(learned, "date_format")
+			}
+			break
+		}
+	}
+
+	if corrections.Currency != nil && *corrections.Currency != "" && *corrections.Currency != profile.Currency {
+		profile.Currency = *corrections.Currency
+		learned = append(learned, "currency")
+	}
+
+	return learned
+}
+
+// applySettings validates and sets a profile's extraction settings; nil
+// values are unchanged
+func (s *VendorProfileService) applySettings(profile *models.VendorProfile, dateFormat, currency, numberLabel, hints *string) error {
+	if dateFormat != nil {
+		format := strings.ToUpper(strings.TrimSpace(*dateFormat))
+		if _, ok := vendorDateFormats[format]; format != "" && !ok {
+			return fmt.Errorf("%w: %q", ErrInvalidDateFormat, *dateFormat)
+		}
+		profile.DateFormat = format
+	}
+	if currency != nil {
+		code := strings.ToUpper(strings.TrimSpace(*currency))
+		if code != "" && !currencyCodePattern.MatchString(code) {
+			return ErrInvalidCurrency
+		}
+		profile.Currency = code
+	}
+	if numberLabel != nil {
+		profile.DocumentNumberLabel = strings.TrimSpace(*numberLabel)
+	}
+	if hints != nil {
+		profile.Hints = strings.TrimSpace(*hints)
+	}
+	return nil
+}
+
+// resolveAliases normalizes aliases, dropping duplicates and the profile's
+// own name. An alias of another profile is a conflict.
+func (s *VendorProfileService) resolveAliases(ctx context.Context, profile *models.VendorProfile, names []string) ([]models.VendorProfileAlias, error) {
+	aliases := []models.VendorProfileAlias{}
+	seen := map[string]bool{profile.NormalizedName: true}
+	for _, name := range names {
+		alias := normalizeVendorName(name)
+		if alias == "" || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+
+		if owner, err := s.profileRepo.GetByName(ctx, profile.TenantID, alias); err == nil && owner.ID != profile.ID {
+			return nil, fmt.Errorf("%w: %q belongs to %s", ErrVendorProfileExists, name, owner.VendorName)
+		}
+		aliases = append(aliases, models.VendorProfileAlias{ProfileID: profile.ID, Alias: alias})
+	}
+	return aliases, nil
+}
+
+func (s *VendorProfileService) createAuditLog(tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "vendor_profile",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.Background(), log)
+	}()
+}
+
+// normalize trims the corrections and checks their format
+func (p *ExtractionFeedbackParams) normalize() error {
+	for _, field := range []*string{p.VendorName, p.DocumentNumber, p.DocumentDate, p.DueDate, p.Currency} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+	for _, date := range []*string{p.DocumentDate, p.DueDate} {
+		if date == nil || *date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", *date); err != nil {
+			return ErrInvalidFeedbackDate
+		}
+	}
+	if p.Currency != nil {
+		*p.Currency = strings.ToUpper(*p.Currency)
+		if *p.Currency != "" && !currencyCodePattern.MatchString(*p.Currency) {
+			return ErrInvalidCurrency
+		}
+	}
+	return nil
+}
+
+// feedback returns the corrected fields as they were extracted and as
+// corrected
+func (p *ExtractionFeedbackParams) feedback(extracted *FinancialExtractionResult) (original, corrected models.JSONB) {
+	original, corrected = models.JSONB{}, models.JSONB{}
+	for _, field := range []struct {
+		name      string
+		value     *string
+		extracted string
+	}{
+		{"vendor_name", p.VendorName, extracted.VendorName},
+		{"document_number", p.DocumentNumber, extracted.DocumentNumber},
+		{"document_date", p.DocumentDate, extracted.DocumentDate},
+		{"due_date", p.DueDate, extracted.DueDate},
+		{"currency", p.Currency, extracted.Currency},
+	} {
+		if field.value != nil {
+			original[field.name] = field.extracted
+			corrected[field.name] = *field.value
+		}
+	}
+	if p.Amount != nil {
+		original["amount"] = extracted.Amount
+		corrected["amount"] = *p.Amount
+	}
+	return original, corrected
+}
+
+// applyVendorProfile corrects extracted financial data with what the
+// vendor's profile knows, checking values against the document text
+func applyVendorProfile(profile *models.VendorProfile, result *FinancialExtractionResult, text string) {
+	profileID := profile.ID
+	result.VendorProfileID = &profileID
+
+	// The profile's name is canonical for all of the vendor's aliases
+	result.VendorName = profile.VendorName
+
+	if profile.Currency != "" && result.Currency == "" {
+		result.Currency = profile.Currency
+	}
+
+	if profile.DocumentNumberLabel != "" {
+		if number := labelledDocumentNumber(text, profile.DocumentNumberLabel); number != "" {
+			result.DocumentNumber = number
+		}
+	}
+
+	if profile.DateFormat != "" {
+		if result.DocumentDate == "" {
+			result.DocumentDate = firstDateInFormat(text, profile.DateFormat)
+		} else {
+			result.DocumentDate = correctDateOrder(result.DocumentDate, text, profile.DateFormat)
+		}
+		if result.DueDate != "" {
+			result.DueDate = correctDateOrder(result.DueDate, text, profile.DateFormat)
+		}
+	}
+}
+
+// applyExtractionCorrections writes corrected values to the document
+func applyExtractionCorrections(document *models.Document, corrections ExtractionFeedbackParams) {
+	if corrections.VendorName != nil {
+		document.VendorName = *corrections.VendorName
+	}
+	if corrections.DocumentNumber != nil {
+		document.DocumentNumber = *corrections.DocumentNumber
+	}
+	if corrections.Currency != nil && *corrections.Currency != "" {
+		document.Currency = *corrections.Currency
+	}
+	if corrections.Amount != nil {
+		amount := *corrections.Amount
+		document.Amount = &amount
+	}
+	for _, date := range []struct {
+		value  *string
+		target **time.Time
+	}{{corrections.DocumentDate, &document.DocumentDate}, {corrections.DueDate, &document.DueDate}} {
+		if date.value == nil {
+			continue
+		}
+		*date.target = nil
+		if parsed, err := time.Parse("2006-01-02", *date.value); err == nil {
+			*date.target = &parsed
+		}
+	}
+}
+
+// extractedFinancialData returns the financial data extracted for a
+// document, empty when there is none
+func extractedFinancialData(document *models.Document) *FinancialExtractionResult {
+	result := &FinancialExtractionResult{}
+	if data, ok := document.ExtractedData["financial_data"]; ok {
+		// Stored as a map once read back from the database
+		if encoded, err := json.Marshal(data); err == nil {
+			json.Unmarshal(encoded, result)
+		}
+	}
+	return result
+}
+
+// normalizeVendorName lowercases a name and reduces it to its words, so
+// "ACME Corp." and "Acme corp" are the same vendor
+func normalizeVendorName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func hasVendorAlias(profile *models.VendorProfile, alias string) bool {
+	for _, existing := range profile.Aliases {
+		if existing.Alias == alias {
+			return true
+		}
+	}
+	return false
+}
+
+func documentText(document *models.Document) string {
+	if document.ExtractedText != "" {
+		return document.ExtractedText
+	}
+	return document.OCRText
+}
+
+// documentNumberLabel returns the words printed before a document number
+// on its line, e.g. "PO Number" for "PO Number: 4711". Words of an earlier
+// field on the same line are not part of the label.
+func documentNumberLabel(text, number string) string {
+	index := strings.Index(text, number)
+	if index < 0 {
+		return ""
+	}
+	line := text[strings.LastIndex(text[:index], "\n")+1 : index]
+
+	words := strings.Fields(line)
+	label := []string{}
+	for i := len(words) - 1; i >= 0 && len(label) < 3; i-- {
+		word := strings.TrimRight(words[i], ":#.")
+		if word == "" {
+			continue
+		}
+		if strings.ContainsAny(word, "0123456789") || (len(label) > 0 && strings.HasSuffix(words[i], ":")) {
+			break
+		}
+		label = append([]string{word}, label...)
+	}
+	return strings.Join(label, " ")
+}
+
+// labelledDocumentNumber finds the first number printed after a label
+func labelledDocumentNumber(text, label string) string {
+	words := strings.Fields(label)
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	pattern, err := regexp.Compile(`(?i)\b` + strings.Join(words, `\s+`) + `[\s:#.]*([A-Za-z0-9][A-Za-z0-9/_-]*)`)
+	if err != nil {
+		return ""
+	}
+	for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+		if strings.ContainsAny(match[1], "0123456789") {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// detectDateFormat returns the one format the date is printed in within
+// the text, or "" when it isn't found or the format is ambiguous, as for
+// 03/03/2024
+func detectDateFormat(text string, date time.Time) string {
+	found := ""
+	for _, format := range VendorDateFormats {
+		if dateAppearsIn(text, date, format) {
+			if found != "" {
+				return ""
+			}
+			found = format
+		}
+	}
+	return found
+}
+
+// correctDateOrder swaps the day and month of an extracted YYYY-MM-DD date
+// when only the swapped date is printed in the vendor's format
+func correctDateOrder(value, text, format string) string {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil || dateAppearsIn(text, date, format) || date.Day() > 12 {
+		return value
+	}
+	swapped := time.Date(date.Year(), time.Month(date.Day()), int(date.Month()), 0, 0, 0, 0, time.UTC)
+	if dateAppearsIn(text, swapped, format) {
+		return swapped.Format("2006-01-02")
+	}
+	return value
+}
+
+// firstDateInFormat returns the first date printed in the format as
+// YYYY-MM-DD, or ""
+func firstDateInFormat(text, format string) string {
+	dateFormat := vendorDateFormats[format]
+	layout := dateFormat.layouts[len(dateFormat.layouts)-1]
+	for _, match := range dateFormat.pattern.FindAllString(text, -1) {
+		if date, err := time.Parse(layout, match); err == nil {
+			return date.Format("2006-01-02")
+		}
+	}
+	return ""
+}
+
+func dateAppearsIn(text string, date time.Time, format string) bool {
+	for _, layout := range vendorDateFormats[format].layouts {
+		if containsNumberToken(text, date.Format(layout)) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsNumberToken reports whether token appears in text without
+// adjoining digits, so 4/3/2024 is not found in 14/3/2024
+func containsNumberToken(text, token string) bool {
+	for offset := 0; ; {
+		index := strings.Index(text[offset:], token)
+		if index < 0 {
+			return false
+		}
+		start, end := offset+index, offset+index+len(token)
+		if (start == 0 || !isASCIIDigit(text[start-1])) && (end == len(text) || !isASCIIDigit(text[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isASCIIDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
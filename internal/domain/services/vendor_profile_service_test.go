@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const acmeInvoice = `ACME Corp.
+Invoice date: 04/03/2024      PO Number: PO-4711
+Due 05/04/2024
+Total: 1,250.00`
+
+func TestNormalizeVendorName(t *testing.T) {
+	assert.Equal(t, "acme corp", normalizeVendorName("  ACME  Corp. "))
+	assert.Equal(t, "müller gmbh co kg", normalizeVendorName("Müller GmbH & Co. KG"))
+	assert.Empty(t, normalizeVendorName(" - "))
+}
+
+func TestDocumentNumberLabel(t *testing.T) {
+	// The date before it on the line belongs to another field
+	assert.Equal(t, "PO Number", documentNumberLabel(acmeInvoice, "PO-4711"))
+	assert.Equal(t, "Order No", documentNumberLabel("Order No. 889\n", "889"))
+	assert.Empty(t, documentNumberLabel(acmeInvoice, "missing"))
+
+	assert.Equal(t, "PO-4711", labelledDocumentNumber(acmeInvoice, "po number"))
+	assert.Equal(t, "889", labelledDocumentNumber("Order No. 889", "Order No"))
+	assert.Empty(t, labelledDocumentNumber(acmeInvoice, "Reference"))
+}
+
+func TestDetectDateFormat(t *testing.T) {
+	date := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "DD/MM/YYYY", detectDateFormat("Invoice date: 04/03/2024", date))
+	assert.Equal(t, "MM/DD/YYYY", detectDateFormat("Invoice date: 3/4/2024", date))
+	assert.Equal(t, "DD.MM.YYYY", detectDateFormat("Datum: 4.3.2024", date))
+
+	// Day equal to month can't tell the order apart
+	assert.Empty(t, detectDateFormat("03/03/2024", time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)))
+	// 4/3/2024 is not within 14/3/2024
+	assert.Empty(t, detectDateFormat("14/3/2024", date))
+}
+
+func TestCorrectDateOrder(t *testing.T) {
+	// Read as March 4th but printed as 3 April in a DD/MM vendor's format
+	assert.Equal(t, "2024-04-03", correctDateOrder("2024-03-04", "Due 03/04/2024", "DD/MM/YYYY"))
+	assert.Equal(t, "2024-03-04", correctDateOrder("2024-03-04", "Due 04/03/2024", "DD/MM/YYYY"))
+	// Unambiguous days are left alone
+	assert.Equal(t, "2024-03-14", correctDateOrder("2024-03-14", "Due 03/14/2024", "DD/MM/YYYY"))
+
+	assert.Equal(t, "2024-03-04", firstDateInFormat(acmeInvoice, "DD/MM/YYYY"))
+}
+
+func TestApplyVendorProfile(t *testing.T) {
+	profile := &models.VendorProfile{
+		ID:                  uuid.New(),
+		VendorName:          "Acme Corporation",
+		DateFormat:          "DD/MM/YYYY",
+		Currency:            "EUR",
+		DocumentNumberLabel: "PO Number",
+	}
+	result := &FinancialExtractionResult{
+		VendorName:     "ACME Corp.",
+		DocumentNumber: "1250",
+		DueDate:        "2024-05-04",
+	}
+
+	applyVendorProfile(profile, result, acmeInvoice)
+
+	assert.Equal(t, "Acme Corporation", result.VendorName)
+	assert.Equal(t, "EUR", result.Currency)
+	assert.Equal(t, "PO-4711", result.DocumentNumber)
+	assert.Equal(t, "2024-03-04", result.DocumentDate)
+	assert.Equal(t, "2024-04-05", result.DueDate)
+	require.NotNil(t, result.VendorProfileID)
+	assert.Equal(t, profile.ID, *result.VendorProfileID)
+	assert.NoError(t, result.Validate())
+}
@@ -0,0 +1,808 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWebhookNotFound      = errors.New("webhook not found")
+	ErrInvalidWebhookURL    = errors.New("webhook URL must be an absolute https URL")
+	ErrWebhookEventRequired = errors.New("webhook needs at least one event")
+	ErrUnknownWebhookEvent  = errors.New("unknown webhook event")
+	ErrWebhookDestination   = errors.New("webhook destination is not allowed")
+
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookInactive         = errors.New("webhook is inactive")
+	ErrInvalidWebhookReplay    = errors.New("invalid webhook replay")
+)
+
+// Webhook event types
+const (
+	WebhookEventDocumentUploaded  = "document.uploaded"
+	WebhookEventDocumentProcessed = "document.processed"
+	WebhookEventDocumentDeleted   = "document.deleted"
+	WebhookEventDocumentRestored  = "document.restored"
+	WebhookEventTaskCompleted     = "workflow.task.completed"
+	WebhookEventShareCreated      = "share.created"
+	WebhookEventQuotaExceeded     = "tenant.quota_exceeded"
+	WebhookEventAIBudgetWarning   = "tenant.ai_budget_warning"
+	WebhookEventPing              = "webhook.ping"
+
+	// WebhookEventAll subscribes a webhook to every event type
+	WebhookEventAll = "*"
+)
+
+// WebhookEventCatalog lists the event types a webhook can subscribe to
+var WebhookEventCatalog = []string{
+	WebhookEventDocumentUploaded,
+	WebhookEventDocumentProcessed,
+	WebhookEventDocumentDeleted,
+	WebhookEventDocumentRestored,
+	WebhookEventTaskCompleted,
+	WebhookEventShareCreated,
+	WebhookEventQuotaExceeded,
+	WebhookEventAIBudgetWarning,
+}
+
+// Webhook request headers. The signature is "t=<unix>,v1=<hex>" where v1 is
+// HMAC-SHA256 over "<unix>.<body>" keyed with the webhook secret.
+const (
+	WebhookSignatureHeader = "X-Archivus-Signature"
+	WebhookEventHeader     = "X-Archivus-Event"
+	WebhookDeliveryHeader  = "X-Archivus-Delivery"
+
+	webhookSecretPrefix = "whsec_"
+	webhookUserAgent    = "Archivus-Webhooks/1.0"
+)
+
+const (
+	// maxWebhookReplay bounds the deliveries one replay re-sends
+	maxWebhookReplay = 500
+
+	// webhookResponseLogSize bounds the response body kept in the delivery log
+	webhookResponseLogSize = 1024
+)
+
+// webhookRedactedKeys are payload keys whose values never enter the delivery
+// log, matched as substrings of the lowercased key
+var webhookRedactedKeys = []string{"password", "secret", "token", "authorization", "api_key", "apikey", "credential"}
+
+// EventPublisher publishes domain events to external subscribers. Publishing
+// is best effort and never fails the operation that produced the event.
+type EventPublisher interface {
+	Publish(ctx context.Context, tenantID uuid.UUID, eventType string, data map[string]interface{})
+}
+
+// EventPublishers fans each event out to several publishers
+type EventPublishers []EventPublisher
+
+// Publish sends the event to every publisher
+func (p EventPublishers) Publish(ctx context.Context, tenantID uuid.UUID, eventType string, data map[string]interface{}) {
+	for _, publisher := range p {
+		publisher.Publish(ctx, tenantID, eventType, data)
+	}
+}
+
+// WebhookServiceConfig holds configuration for webhook delivery
+type WebhookServiceConfig struct {
+	PollInterval         time.Duration // How often the worker looks for due deliveries
+	BatchSize            int           // Deliveries handled per poll
+	RequestTimeout       time.Duration // Per-request timeout
+	MaxAttempts          int           // Attempts before a delivery is marked failed
+	RetryBaseDelay       time.Duration // First retry delay; doubles on each attempt
+	RetryMaxDelay        time.Duration // Cap on the retry delay
+	AllowInsecureURLs    bool          // Allow http:// endpoints (development only)
+	AllowPrivateNetworks bool          // Allow loopback and private addresses (development only)
+	DeliveryLogRetention time.Duration // How long delivery attempts are logged; defaults to 30 days
+}
+
+// WebhookService manages tenant webhooks and delivers events to them
+type WebhookService struct {
+	webhookRepo repositories.WebhookRepository
+	auditRepo   repositories.AuditLogRepository
+
+	client *http.Client
+	config WebhookServiceConfig
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(
+	webhookRepo repositories.WebhookRepository,
+	auditRepo repositories.AuditLogRepository,
+	config WebhookServiceConfig,
+) *WebhookService {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 10 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 50
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = 10 * time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.RetryBaseDelay <= 0 {
+		config.RetryBaseDelay = 30 * time.Second
+	}
+	if config.RetryMaxDelay <= 0 {
+		config.RetryMaxDelay = 6 * time.Hour
+	}
+	if config.DeliveryLogRetention <= 0 {
+		config.DeliveryLogRetention = 30 * 24 * time.Hour
+	}
+
+	dialer := &net.Dialer{Timeout: config.RequestTimeout}
+	if !config.AllowPrivateNetworks {
+		// Checked on the resolved address so DNS cannot point a webhook inside the network
+		dialer.Control = rejectPrivateAddress
+	}
+
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		auditRepo:   auditRepo,
+		client: &http.Client{
+			Timeout:   config.RequestTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// Redirects could bypass the destination check and signature scope
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		config: config,
+	}
+}
+
+// CreateWebhookParams contains parameters for creating a webhook
+type CreateWebhookParams struct {
+	TenantID    uuid.UUID `json:"tenant_id"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events"`
+}
+
+// UpdateWebhookParams contains parameters for updating a webhook; nil fields are unchanged
+type UpdateWebhookParams struct {
+	URL         *string  `json:"url"`
+	Description *string  `json:"description"`
+	Events      []string `json:"events"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// CreateWebhook creates a webhook and returns its signing secret, which is
+// only shown once
+func (s *WebhookService) CreateWebhook(ctx context.Context, params CreateWebhookParams) (*models.Webhook, string, error) {
+	endpoint, err := s.validateURL(params.URL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	events, err := validateWebhookEvents(params.Events)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	webhook := &models.Webhook{
+		TenantID:    params.TenantID,
+		URL:         endpoint,
+		Description: strings.TrimSpace(params.Description),
+		Secret:      secret,
+		IsActive:    true,
+		CreatedBy:   params.CreatedBy,
+		Events:      events,
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, "", err
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.CreatedBy, webhook.ID, models.AuditCreate, "Webhook created: "+endpoint)
+
+	return webhook, secret, nil
+}
+
+// ListWebhooks lists the tenant's webhooks
+func (s *WebhookService) ListWebhooks(ctx context.Context, tenantID uuid.UUID) ([]models.Webhook, error) {
+	return s.webhookRepo.ListByTenant(ctx, tenantID)
+}
+
+// GetWebhook returns a webhook belonging to the tenant
+func (s *WebhookService) GetWebhook(ctx context.Context, webhookID, tenantID uuid.UUID) (*models.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil || webhook.TenantID != tenantID {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook changes a webhook's URL, description, events or active flag
+func (s *WebhookService) UpdateWebhook(ctx context.Context, webhookID, tenantID, updatedBy uuid.UUID, params UpdateWebhookParams) (*models.Webhook, error) {
+	webhook, err := s.GetWebhook(ctx, webhookID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.URL != nil {
+		endpoint, err := s.validateURL(*params.URL)
+		if err != nil {
+			return nil, err
+		}
+		webhook.URL = endpoint
+	}
+	if params.Description != nil {
+		webhook.Description = strings.TrimSpace(*params.Description)
+	}
+	if params.Events != nil {
+		events, err := validateWebhookEvents(params.Events)
+		if err != nil {
+			return nil, err
+		}
+		webhook.Events = events
+	}
+	if params.IsActive != nil {
+		webhook.IsActive = *params.IsActive
+		if webhook.IsActive {
+			webhook.FailureCount = 0
+		}
+	}
+
+	webhook.UpdatedAt = time.Now()
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, tenantID, updatedBy, webhook.ID, models.AuditUpdate, "Webhook updated: "+webhook.URL)
+
+	return webhook, nil
+}
+
+// RotateSecret replaces a webhook's signing secret and returns the new one
+func (s *WebhookService) RotateSecret(ctx context.Context, webhookID, tenantID, rotatedBy uuid.UUID) (*models.Webhook, string, error) {
+	webhook, err := s.GetWebhook(ctx, webhookID, tenantID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	webhook.Secret = secret
+	webhook.UpdatedAt = time.Now()
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, "", err
+	}
+
+	s.createAuditLog(ctx, tenantID, rotatedBy, webhook.ID, models.AuditUpdate, "Webhook secret rotated: "+webhook.URL)
+
+	return webhook, secret, nil
+}
+
+// DeleteWebhook deletes a webhook and drops its undelivered events
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookID, tenantID, deletedBy uuid.UUID) error {
+	webhook, err := s.GetWebhook(ctx, webhookID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.webhookRepo.Delete(ctx, webhook.ID); err != nil {
+		return err
+	}
+
+	s.createAuditLog(ctx, tenantID, deletedBy, webhook.ID, models.AuditDelete, "Webhook deleted: "+webhook.URL)
+
+	return nil
+}
+
+// SendTestEvent queues a webhook.ping event for a single webhook
+func (s *WebhookService) SendTestEvent(ctx context.Context, webhookID, tenantID uuid.UUID) (*models.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(ctx, webhookID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := s.newDeliveries(tenantID, WebhookEventPing, map[string]interface{}{
+		"webhook_id": webhook.ID.String(),
+	}, []models.Webhook{*webhook})
+	if err := s.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+
+	return &deliveries[0], nil
+}
+
+// ReplayWebhookParams selects deliveries to re-send, either by ID or by the
+// filters; the filters must at least bound the time range
+type ReplayWebhookParams struct {
+	DeliveryIDs []uuid.UUID                         `json:"delivery_ids"`
+	Filters     repositories.WebhookDeliveryFilters `json:"filters"`
+}
+
+// ListDeliveries returns a page of the webhook's delivery log, newest first
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookID, tenantID uuid.UUID, filters repositories.WebhookDeliveryFilters, params repositories.ListParams) ([]models.WebhookDelivery, int64, error) {
+	webhook, err := s.GetWebhook(ctx, webhookID, tenantID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.webhookRepo.ListDeliveries(ctx, webhook.ID, filters, params)
+}
+
+// GetDelivery returns one of the webhook's deliveries with its attempts
+func (s *WebhookService) GetDelivery(ctx context.Context, webhookID, deliveryID, tenantID uuid.UUID) (*models.WebhookDelivery, []models.WebhookDeliveryAttempt, error) {
+	webhook, err := s.GetWebhook(ctx, webhookID, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil || delivery.WebhookID != webhook.ID {
+		return nil, nil, ErrWebhookDeliveryNotFound
+	}
+
+	attempts, err := s.webhookRepo.ListDeliveryAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return delivery, attempts, nil
+}
+
+// ReplayDeliveries queues the selected deliveries again, e.g. once the
+// receiving system has recovered from an outage. Replays carry the original
+// event ID, so receivers can tell a replayed event from a new one. Deliveries
+// still pending are skipped, and an event is replayed at most once per call.
+func (s *WebhookService) ReplayDeliveries(ctx context.Context, webhookID, tenantID, replayedBy uuid.UUID, params ReplayWebhookParams) ([]models.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(ctx, webhookID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.IsActive {
+		return nil, ErrWebhookInactive
+	}
+
+	var originals []models.WebhookDelivery
+	switch {
+	case len(params.DeliveryIDs) > maxWebhookReplay:
+		return nil, fmt.Errorf("%w: at most %d deliveries can be replayed at once", ErrInvalidWebhookReplay, maxWebhookReplay)
+	case len(params.DeliveryIDs) > 0:
+		for _, id := range params.DeliveryIDs {
+			delivery, err := s.webhookRepo.GetDelivery(ctx, id)
+			if err != nil || delivery.WebhookID != webhook.ID {
+				return nil, fmt.Errorf("%w: %s", ErrWebhookDeliveryNotFound, id)
+			}
+			originals = append(originals, *delivery)
+		}
+	case params.Filters.From != nil:
+		deliveries, total, err := s.webhookRepo.ListDeliveries(ctx, webhook.ID, params.Filters, repositories.ListParams{Page: 1, PageSize: maxWebhookReplay})
+		if err != nil {
+			return nil, err
+		}
+		if total > maxWebhookReplay {
+			return nil, fmt.Errorf("%w: %d deliveries match; narrow the selection to at most %d", ErrInvalidWebhookReplay, total, maxWebhookReplay)
+		}
+		originals = deliveries
+	default:
+		return nil, fmt.Errorf("%w: select deliveries by ID or give the start of a time range", ErrInvalidWebhookReplay)
+	}
+
+	now := time.Now().UTC()
+	replayed := make(map[uuid.UUID]bool, len(originals))
+	replays := make([]models.WebhookDelivery, 0, len(originals))
+	for _, original := range originals {
+		if original.Status == models.WebhookDeliveryPending || replayed[original.EventID] {
+			continue
+		}
+		replayed[original.EventID] = true
+
+		replayOf := original.ID
+		replays = append(replays, models.WebhookDelivery{
+			ID:            uuid.New(),
+			TenantID:      tenantID,
+			WebhookID:     webhook.ID,
+			EventID:       original.EventID,
+			EventType:     original.EventType,
+			Payload:       original.Payload,
+			Status:        models.WebhookDeliveryPending,
+			MaxAttempts:   s.config.MaxAttempts,
+			NextAttemptAt: now,
+			ReplayOf:      &replayOf,
+			CreatedAt:     now,
+		})
+	}
+
+	if err := s.webhookRepo.CreateDeliveries(ctx, replays); err != nil {
+		return nil, err
+	}
+
+	s.createAuditLog(ctx, tenantID, replayedBy, webhook.ID, models.AuditCreate,
+		fmt.Sprintf("Replayed %d webhook deliveries: %s", len(replays), webhook.URL))
+
+	return replays, nil
+}
+
+// DeliveryLogRetentionTask is the scheduled task that deletes delivery
+// attempts older than the retention period
+func (s *WebhookService) DeliveryLogRetentionTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "webhook_delivery_log_retention",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := s.webhookRepo.DeleteDeliveryAttemptsBefore(ctx, time.Now().Add(-s.config.DeliveryLogRetention))
+			return err
+		},
+	}
+}
+
+// Publish queues an event for every active webhook of the tenant subscribed
+// to it. Delivery happens asynchronously in RunDeliveryWorker. Events outside
+// the catalog are internal and never leave the system.
+func (s *WebhookService) Publish(ctx context.Context, tenantID uuid.UUID, eventType string, data map[string]interface{}) {
+	if !containsString(WebhookEventCatalog, eventType) {
+		return
+	}
+
+	webhooks, err := s.webhookRepo.ListSubscribed(ctx, tenantID, eventType)
+	if err != nil || len(webhooks) == 0 {
+		return
+	}
+
+	if err := s.webhookRepo.CreateDeliveries(ctx, s.newDeliveries(tenantID, eventType, data, webhooks)); err != nil {
+		// Log but don't fail - events are best effort for the producer
+	}
+}
+
+// RunDeliveryWorker delivers due webhook events on every tick until the
+// context is cancelled
+func (s *WebhookService) RunDeliveryWorker(ctx context.Context) {
+	ctx = startWorker(ctx, "webhook_delivery", s.config.PollInterval)
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := s.DeliverDue(ctx)
+		// Log errors but keep running - the next tick retries
+		reportPoll(ctx, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts every due delivery once and returns how many were attempted
+func (s *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	deliveries, err := s.webhookRepo.ListDueDeliveries(ctx, now, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// The lease outlives a request so a crashed worker's deliveries are retried
+	leaseUntil := now.Add(2 * s.config.RequestTimeout)
+
+	attempted := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		claimed, err := s.webhookRepo.ClaimDelivery(ctx, delivery.ID, now, leaseUntil)
+		if err != nil || !claimed {
+			continue
+		}
+
+		s.attemptDelivery(ctx, delivery)
+		attempted++
+	}
+
+	return attempted, nil
+}
+
+// Helper methods
+
+func (s *WebhookService) newDeliveries(tenantID uuid.UUID, eventType string, data map[string]interface{}, webhooks []models.Webhook) []models.WebhookDelivery {
+	eventID := uuid.New()
+	now := time.Now().UTC()
+
+	payload := models.JSONB{
+		"id":         eventID.String(),
+		"type":       eventType,
+		"tenant_id":  tenantID.String(),
+		"created_at": now.Format(time.RFC3339),
+		"data":       data,
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			ID:            uuid.New(),
+			TenantID:      tenantID,
+			WebhookID:     webhook.ID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			MaxAttempts:   s.config.MaxAttempts,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	return deliveries
+}
+
+// attemptDelivery sends one delivery and schedules a retry or records the
+// final outcome
+func (s *WebhookService) attemptDelivery(ctx context.Context, delivery *models.WebhookDelivery) {
+	webhook, err := s.webhookRepo.GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+
+	attempt := &models.WebhookDeliveryAttempt{
+		ID:          uuid.New(),
+		TenantID:    delivery.TenantID,
+		WebhookID:   delivery.WebhookID,
+		DeliveryID:  delivery.ID,
+		Attempt:     delivery.Attempts,
+		Payload:     redactWebhookPayload(delivery.Payload),
+		AttemptedAt: now,
+	}
+
+	// Deactivated webhooks keep their queue but stop receiving events
+	if !webhook.IsActive {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = "webhook is inactive"
+		s.webhookRepo.UpdateDelivery(ctx, delivery)
+		attempt.Error = delivery.LastError
+		s.webhookRepo.CreateDeliveryAttempt(ctx, attempt)
+		return
+	}
+
+	status, responseBody, sendErr := s.send(ctx, webhook, delivery)
+	delivery.ResponseStatus = status
+
+	attempt.ResponseStatus = status
+	attempt.ResponseBody = redactPersonalData(responseBody)
+	attempt.LatencyMS = time.Since(now).Milliseconds()
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := s.webhookRepo.CreateDeliveryAttempt(ctx, attempt); err != nil {
+		// Log but continue - the log is secondary to the delivery itself
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= delivery.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = sendErr.Error()
+	default:
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = now.Add(s.retryDelay(delivery.Attempts))
+	}
+
+	if err := s.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		// Log but continue - the lease expires and the attempt is retried
+	}
+
+	if delivery.Status != models.WebhookDeliveryPending {
+		lastError := ""
+		if delivery.Status == models.WebhookDeliveryFailed {
+			lastError = delivery.LastError
+		}
+		if err := s.webhookRepo.RecordDeliveryResult(ctx, webhook.ID, now, lastError); err != nil {
+			// Log but don't fail
+		}
+	}
+}
+
+// send posts a signed delivery and returns the response status and the
+// start of the response body
+func (s *WebhookService) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to build request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLogSize))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(responseBody), fmt.Errorf("endpoint responded with HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(responseBody), nil
+}
+
+// retryDelay doubles from RetryBaseDelay on each attempt, capped at RetryMaxDelay
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.config.RetryBaseDelay
+	for i := 1; i < attempts && delay < s.config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.config.RetryMaxDelay {
+		delay = s.config.RetryMaxDelay
+	}
+	return delay
+}
+
+func (s *WebhookService) validateURL(raw string) (string, error) {
+	endpoint := strings.TrimSpace(raw)
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" || len(endpoint) > 500 {
+		return "", ErrInvalidWebhookURL
+	}
+	if parsed.Scheme != "https" && !(s.config.AllowInsecureURLs && parsed.Scheme == "http") {
+		return "", ErrInvalidWebhookURL
+	}
+	if parsed.User != nil {
+		return "", ErrInvalidWebhookURL
+	}
+	return endpoint, nil
+}
+
+func (s *WebhookService) createAuditLog(ctx context.Context, tenantID, userID, resourceID uuid.UUID, action models.AuditAction, details string) {
+	log := &models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       userID,
+		ResourceID:   resourceID,
+		Action:       action,
+		ResourceType: "webhook",
+		Details:      models.JSONB{"message": details},
+	}
+
+	// Don't block on audit log creation
+	go func() {
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
+	}()
+}
+
+// SignWebhookPayload returns the signature header value for a request body.
+// Receivers recompute it with their copy of the secret and should reject
+// timestamps more than a few minutes old.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func validateWebhookEvents(events []string) ([]models.WebhookEvent, error) {
+	if len(events) == 0 {
+		return nil, ErrWebhookEventRequired
+	}
+
+	seen := make(map[string]bool, len(events))
+	validated := make([]models.WebhookEvent, 0, len(events))
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if seen[event] {
+			continue
+		}
+		if event != WebhookEventAll && !containsString(WebhookEventCatalog, event) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownWebhookEvent, event)
+		}
+		seen[event] = true
+		validated = append(validated, models.WebhookEvent{EventType: event})
+	}
+	return validated, nil
+}
+
+// redactWebhookPayload copies a payload for the delivery log, replacing the
+// values of secret-looking keys and masking personal data in strings
+func redactWebhookPayload(payload models.JSONB) models.JSONB {
+	var redact func(value interface{}) interface{}
+	redact = func(value interface{}) interface{} {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			redacted := make(map[string]interface{}, len(value))
+			for key, field := range value {
+				if isRedactedWebhookKey(key) {
+					redacted[key] = "[REDACTED]"
+					continue
+				}
+				redacted[key] = redact(field)
+			}
+			return redacted
+		case models.JSONB:
+			return redact(map[string]interface{}(value))
+		case []interface{}:
+			redacted := make([]interface{}, len(value))
+			for i, item := range value {
+				redacted[i] = redact(item)
+			}
+			return redacted
+		case string:
+			return redactPersonalData(value)
+		default:
+			return value
+		}
+	}
+
+	if payload == nil {
+		return nil
+	}
+	return models.JSONB(redact(map[string]interface{}(payload)).(map[string]interface{}))
+}
+
+func isRedactedWebhookKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, redacted := range webhookRedactedKeys {
+		if strings.Contains(key, redacted) {
+			return true
+		}
+	}
+	return false
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + hex.EncodeToString(secret), nil
+}
+
+// rejectPrivateAddress refuses connections to loopback, private, link-local
+// and unspecified addresses
+func rejectPrivateAddress(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrWebhookDestination
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return ErrWebhookDestination
+	}
+	return nil
+}
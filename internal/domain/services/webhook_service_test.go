@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactWebhookPayload(t *testing.T) {
+	payload := models.JSONB{
+		"event": "document.shared",
+		"data": map[string]interface{}{
+			"share_token": "abc123",
+			"recipients":  []interface{}{"Employee SSN 123-45-6789"},
+			"size":        42,
+		},
+		"Authorization": "Bearer xyz",
+	}
+
+	redacted := redactWebhookPayload(payload)
+
+	assert.Equal(t, models.JSONB{
+		"event": "document.shared",
+		"data": map[string]interface{}{
+			"share_token": "[REDACTED]",
+			"recipients":  []interface{}{"Employee SSN [REDACTED]"},
+			"size":        42,
+		},
+		"Authorization": "[REDACTED]",
+	}, redacted)
+	// The delivered payload itself is untouched
+	assert.Equal(t, "abc123", payload["data"].(map[string]interface{})["share_token"])
+	assert.Nil(t, redactWebhookPayload(nil))
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+// WorkerHeartbeatConfig holds configuration for worker heartbeats
+type WorkerHeartbeatConfig struct {
+	FlushInterval time.Duration // How often polls are written to the database; defaults to 15 seconds
+	Retention     time.Duration // How long heartbeats of silent workers are kept; defaults to a day
+}
+
+// WorkerStatus is a worker's heartbeat as operators see it. A worker is live
+// while its polls keep coming at about its poll interval.
+type WorkerStatus struct {
+	models.WorkerHeartbeat
+	Live bool `json:"live"`
+}
+
+// WorkerHeartbeatService keeps track of the background workers running on
+// every instance. Workers report each poll through the context they run
+// with (see Attach); polls are collected in memory and flushed to the
+// database, so fast pollers don't write on every tick.
+type WorkerHeartbeatService struct {
+	heartbeatRepo repositories.WorkerHeartbeatRepository
+	config        WorkerHeartbeatConfig
+
+	instanceID string
+	hostname   string
+	pid        int
+
+	mu      sync.Mutex
+	workers map[string]*trackedWorker
+}
+
+type trackedWorker struct {
+	heartbeat models.WorkerHeartbeat
+	dirty     bool // Polled since the last flush
+}
+
+// NewWorkerHeartbeatService creates a new worker heartbeat service
+func NewWorkerHeartbeatService(heartbeatRepo repositories.WorkerHeartbeatRepository, config WorkerHeartbeatConfig) *WorkerHeartbeatService {
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 15 * time.Second
+	}
+	if config.Retention <= 0 {
+		config.Retention = 24 * time.Hour
+	}
+
+	hostname, _ := os.Hostname()
+	return &WorkerHeartbeatService{
+		heartbeatRepo: heartbeatRepo,
+		config:        config,
+		// Restarted instances get a new ID; the old one's rows age out
+		instanceID: fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8]),
+		hostname:   hostname,
+		pid:        os.Getpid(),
+		workers:    make(map[string]*trackedWorker),
+	}
+}
+
+type heartbeatContextKey struct{}
+
+type workerContextKey struct{}
+
+// Attach returns a context that workers started with report their polls to
+// this service
+func (s *WorkerHeartbeatService) Attach(ctx context.Context) context.Context {
+	return context.WithValue(ctx, heartbeatContextKey{}, s)
+}
+
+// Run flushes heartbeats to the database until the context is cancelled
+func (s *WorkerHeartbeatService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				// Log but keep running - the next tick retries
+			}
+		}
+	}
+}
+
+// Flush writes the workers that polled since the last flush
+func (s *WorkerHeartbeatService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	var pending []models.WorkerHeartbeat
+	for _, tracked := range s.workers {
+		if tracked.dirty {
+			pending = append(pending, tracked.heartbeat)
+			tracked.dirty = false
+		}
+	}
+	s.mu.Unlock()
+
+	var lastErr error
+	for i := range pending {
+		if err := s.heartbeatRepo.Upsert(ctx, &pending[i]); err != nil {
+			// Keep the poll for the next flush unless a newer one replaced it
+			s.mu.Lock()
+			if tracked, ok := s.workers[pending[i].Worker]; ok && !tracked.heartbeat.LastPollAt.After(pending[i].LastPollAt) {
+				tracked.dirty = true
+			}
+			s.mu.Unlock()
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// ListWorkers returns the workers of every instance. Workers that stopped
+// polling stay listed, no longer live, until their heartbeat is cleaned up.
+func (s *WorkerHeartbeatService) ListWorkers(ctx context.Context) ([]WorkerStatus, error) {
+	heartbeats, err := s.heartbeatRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	workers := make([]WorkerStatus, 0, len(heartbeats))
+	for _, heartbeat := range heartbeats {
+		workers = append(workers, WorkerStatus{
+			WorkerHeartbeat: heartbeat,
+			Live:            now.Sub(heartbeat.LastPollAt) <= s.staleAfter(heartbeat),
+		})
+	}
+	return workers, nil
+}
+
+// CleanupTask is the scheduled task that deletes heartbeats of workers
+// silent for longer than the retention period
+func (s *WorkerHeartbeatService) CleanupTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     "worker_heartbeat_cleanup",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := s.heartbeatRepo.DeleteBefore(ctx, time.Now().Add(-s.config.Retention))
+			return err
+		},
+	}
+}
+
+// Helper methods
+
+func (s *WorkerHeartbeatService) beat(worker string, pollErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tracked, ok := s.workers[worker]
+	if !ok {
+		return
+	}
+	tracked.heartbeat.LastPollAt = time.Now()
+	tracked.heartbeat.LastError = ""
+	if pollErr != nil {
+		tracked.heartbeat.LastError = pollErr.Error()
+	}
+	tracked.dirty = true
+}
+
+// staleAfter is how long a worker may go without a recorded poll before it
+// is presumed dead: a few missed polls, plus the flush delay
+func (s *WorkerHeartbeatService) staleAfter(heartbeat models.WorkerHeartbeat) time.Duration {
+	return 3*time.Duration(heartbeat.PollInterval)*time.Second + 2*s.config.FlushInterval
+}
+
+// Helper functions
+
+// startWorker registers a worker polling every pollInterval with the
+// heartbeat service attached to the context, if any, and returns the context
+// it reports its polls with
+func startWorker(ctx context.Context, worker string, pollInterval time.Duration) context.Context {
+	s, ok := ctx.Value(heartbeatContextKey{}).(*WorkerHeartbeatService)
+	if !ok {
+		return ctx
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	s.workers[worker] = &trackedWorker{
+		heartbeat: models.WorkerHeartbeat{
+			InstanceID:   s.instanceID,
+			Worker:       worker,
+			Hostname:     s.hostname,
+			PID:          s.pid,
+			PollInterval: int(pollInterval / time.Second),
+			StartedAt:    now,
+			LastPollAt:   now,
+		},
+		dirty: true,
+	}
+	s.mu.Unlock()
+
+	return context.WithValue(ctx, workerContextKey{}, worker)
+}
+
+// reportPoll records a poll of the worker the context was started for
+func reportPoll(ctx context.Context, err error) {
+	s, ok := ctx.Value(heartbeatContextKey{}).(*WorkerHeartbeatService)
+	if !ok {
+		return
+	}
+	if worker, ok := ctx.Value(workerContextKey{}).(string); ok {
+		s.beat(worker, err)
+	}
+}
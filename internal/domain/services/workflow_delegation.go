@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrOutOfOfficeNotFound = errors.New("no out-of-office period set")
+	ErrInvalidOutOfOffice  = errors.New("out-of-office period must end after it starts and in the future, within a year")
+	ErrSelfDelegation      = errors.New("cannot delegate to yourself")
+)
+
+const (
+	// maxOutOfOfficePeriod caps how long a user can be away in one period
+	maxOutOfOfficePeriod = 365 * 24 * time.Hour
+
+	// maxDelegationReportRange caps the window one delegation report covers
+	maxDelegationReportRange = 366 * 24 * time.Hour
+)
+
+// SetOutOfOfficeParams marks a user away. Approval tasks created for them
+// while they are away are assigned to the delegate.
+type SetOutOfOfficeParams struct {
+	TenantID   uuid.UUID `json:"tenant_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	DelegateID uuid.UUID `json:"delegate_id"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Note       string    `json:"note"`
+}
+
+// SetOutOfOffice records the user's out-of-office period, replacing the one
+// in progress or upcoming
+func (s *WorkflowService) SetOutOfOffice(ctx context.Context, params SetOutOfOfficeParams) (*models.OutOfOffice, error) {
+	now := time.Now()
+	if params.StartsAt.IsZero() {
+		params.StartsAt = now
+	}
+	if !params.EndsAt.After(params.StartsAt) || !params.EndsAt.After(now) ||
+		params.EndsAt.Sub(params.StartsAt) > maxOutOfOfficePeriod {
+		return nil, ErrInvalidOutOfOffice
+	}
+	if params.DelegateID == params.UserID {
+		return nil, ErrSelfDelegation
+	}
+
+	// Tasks can only go to active users of the same tenant
+	delegate, err := s.userRepo.GetByID(ctx, params.DelegateID)
+	if err != nil || delegate.TenantID != params.TenantID || !delegate.IsActive {
+		return nil, ErrUserNotFound
+	}
+
+	if current, err := s.outOfOfficeRepo.GetCurrent(ctx, params.UserID, now); err == nil {
+		if err := s.endOutOfOffice(ctx, current, now); err != nil {
+			return nil, err
+		}
+	}
+
+	period := &models.OutOfOffice{
+		ID:         uuid.New(),
+		TenantID:   params.TenantID,
+		UserID:     params.UserID,
+		DelegateID: params.DelegateID,
+		StartsAt:   params.StartsAt,
+		EndsAt:     params.EndsAt,
+		Note:       params.Note,
+	}
+	if err := s.outOfOfficeRepo.Create(ctx, period); err != nil {
+		return nil, fmt.Errorf("failed to set out of office: %w", err)
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.UserID, period.ID, models.AuditCreate,
+		fmt.Sprintf("Out of office from %s to %s, delegating to user %s",
+			period.StartsAt.Format(time.RFC3339), period.EndsAt.Format(time.RFC3339), params.DelegateID))
+
+	return period, nil
+}
+
+// GetOutOfOffice returns the user's out-of-office period in progress or upcoming
+func (s *WorkflowService) GetOutOfOffice(ctx context.Context, userID uuid.UUID) (*models.OutOfOffice, error) {
+	period, err := s.outOfOfficeRepo.GetCurrent(ctx, userID, time.Now())
+	if err != nil {
+		return nil, ErrOutOfOfficeNotFound
+	}
+	return period, nil
+}
+
+// CancelOutOfOffice ends the user's period in progress now, or cancels the
+// upcoming one
+func (s *WorkflowService) CancelOutOfOffice(ctx context.Context, tenantID, userID uuid.UUID) error {
+	now := time.Now()
+	period, err := s.outOfOfficeRepo.GetCurrent(ctx, userID, now)
+	if err != nil {
+		return ErrOutOfOfficeNotFound
+	}
+
+	if err := s.endOutOfOffice(ctx, period, now); err != nil {
+		return err
+	}
+
+	s.createAuditLog(ctx, tenantID, userID, period.ID, models.AuditUpdate, "Out of office ended")
+	return nil
+}
+
+// endOutOfOffice ends a period that has started, keeping the time the user
+// was away, and cancels one that has not
+func (s *WorkflowService) endOutOfOffice(ctx context.Context, period *models.OutOfOffice, now time.Time) error {
+	if period.StartsAt.After(now) {
+		period.CancelledAt = &now
+	} else {
+		period.EndsAt = now
+	}
+	if err := s.outOfOfficeRepo.Update(ctx, period); err != nil {
+		return fmt.Errorf("failed to end out of office: %w", err)
+	}
+	return nil
+}
+
+// outOfOfficeHandovers returns the periods of the step's approvers who are
+// away, keyed by approver. A delegate who already approves in the step, or
+// who covers for another approver, would get a second vote, so those
+// approvers keep their task.
+func (s *WorkflowService) outOfOfficeHandovers(ctx context.Context, tenantID uuid.UUID, approvers []uuid.UUID, now time.Time) map[uuid.UUID]*models.OutOfOffice {
+	if s.outOfOfficeRepo == nil {
+		return nil
+	}
+
+	voters := make(map[uuid.UUID]bool, len(approvers))
+	for _, approverID := range approvers {
+		voters[approverID] = true
+	}
+
+	handovers := make(map[uuid.UUID]*models.OutOfOffice)
+	for _, approverID := range approvers {
+		period, err := s.outOfOfficeRepo.GetCurrent(ctx, approverID, now)
+		if err != nil || period.StartsAt.After(now) || voters[period.DelegateID] {
+			continue
+		}
+
+		delegate, err := s.userRepo.GetByID(ctx, period.DelegateID)
+		if err != nil || delegate.TenantID != tenantID || !delegate.IsActive {
+			continue
+		}
+
+		voters[period.DelegateID] = true
+		handovers[approverID] = period
+	}
+	return handovers
+}
+
+// recordDelegation keeps a delegation for the delegation report
+func (s *WorkflowService) recordDelegation(ctx context.Context, tenantID, taskID, fromUserID, toUserID uuid.UUID, kind models.DelegationKind, reason string) {
+	delegation := &models.WorkflowTaskDelegation{
+		ID:         uuid.New(),
+		TenantID:   tenantID,
+		TaskID:     taskID,
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Kind:       kind,
+		Reason:     reason,
+	}
+	if err := s.taskRepo.CreateDelegation(ctx, delegation); err != nil {
+		// Log but don't fail - the task is already reassigned and audited
+	}
+}
+
+// DelegationPair summarizes the tasks one user delegated to another
+type DelegationPair struct {
+	FromUserID uuid.UUID                       `json:"from_user_id"`
+	FromName   string                          `json:"from_name"`
+	ToUserID   uuid.UUID                       `json:"to_user_id"`
+	ToName     string                          `json:"to_name"`
+	Count      int64                           `json:"count"`
+	ByKind     map[models.DelegationKind]int64 `json:"by_kind"`
+	Outcomes   map[models.WorkflowStatus]int64 `json:"outcomes"`
+}
+
+// OutOfOfficeUsage summarizes one user's time away within the report window
+type OutOfOfficeUsage struct {
+	UserID         uuid.UUID `json:"user_id"`
+	UserName       string    `json:"user_name"`
+	Periods        int       `json:"periods"`
+	Days           float64   `json:"days"`
+	TasksDelegated int64     `json:"tasks_delegated"`
+}
+
+// DelegationReport summarizes who delegated approval tasks to whom, how the
+// delegated tasks ended, and how out-of-office was used, for internal
+// controls reviews
+type DelegationReport struct {
+	From             time.Time                       `json:"from"`
+	To               time.Time                       `json:"to"`
+	TotalDelegations int64                           `json:"total_delegations"`
+	ByKind           map[models.DelegationKind]int64 `json:"by_kind"`
+	Outcomes         map[models.WorkflowStatus]int64 `json:"outcomes"`
+	Pairs            []DelegationPair                `json:"pairs"`
+	OutOfOffice      []OutOfOfficeUsage              `json:"out_of_office"`
+	GeneratedAt      time.Time                       `json:"generated_at"`
+}
+
+// DelegationReport reports the tenant's delegations made in [from, to) and
+// the out-of-office periods overlapping it
+func (s *WorkflowService) DelegationReport(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*DelegationReport, error) {
+	if !to.After(from) || to.Sub(from) > maxDelegationReportRange {
+		return nil, ErrInvalidDateRange
+	}
+
+	stats, err := s.taskRepo.SummarizeDelegations(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize delegations: %w", err)
+	}
+	periods, err := s.outOfOfficeRepo.ListOverlapping(ctx, tenantID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list out-of-office periods: %w", err)
+	}
+
+	report := buildDelegationReport(stats, periods, from, to)
+
+	// Name the users involved
+	names := make(map[uuid.UUID]string)
+	name := func(userID uuid.UUID) string {
+		if _, ok := names[userID]; !ok {
+			if user, err := s.userRepo.GetByID(ctx, userID); err == nil {
+				names[userID] = userDisplayName(user)
+			} else {
+				names[userID] = userID.String()
+			}
+		}
+		return names[userID]
+	}
+	for i := range report.Pairs {
+		report.Pairs[i].FromName = name(report.Pairs[i].FromUserID)
+		report.Pairs[i].ToName = name(report.Pairs[i].ToUserID)
+	}
+	for i := range report.OutOfOffice {
+		report.OutOfOffice[i].UserName = name(report.OutOfOffice[i].UserID)
+	}
+
+	return report, nil
+}
+
+// buildDelegationReport aggregates delegation counts and out-of-office
+// periods. Pairs are ordered by count, users away by days away.
+func buildDelegationReport(stats []repositories.DelegationStats, periods []models.OutOfOffice, from, to time.Time) *DelegationReport {
+	report := &DelegationReport{
+		From:        from,
+		To:          to,
+		ByKind:      make(map[models.DelegationKind]int64),
+		Outcomes:    make(map[models.WorkflowStatus]int64),
+		Pairs:       []DelegationPair{},
+		OutOfOffice: []OutOfOfficeUsage{},
+		GeneratedAt: time.Now(),
+	}
+
+	type pairKey struct{ from, to uuid.UUID }
+	pairs := make(map[pairKey]*DelegationPair)
+	autoDelegated := make(map[uuid.UUID]int64)
+	for _, stat := range stats {
+		report.TotalDelegations += stat.Count
+		report.ByKind[stat.Kind] += stat.Count
+		report.Outcomes[stat.Status] += stat.Count
+
+		key := pairKey{stat.FromUserID, stat.ToUserID}
+		pair, ok := pairs[key]
+		if !ok {
+			pair = &DelegationPair{
+				FromUserID: stat.FromUserID,
+				ToUserID:   stat.ToUserID,
+				ByKind:     make(map[models.DelegationKind]int64),
+				Outcomes:   make(map[models.WorkflowStatus]int64),
+			}
+			pairs[key] = pair
+		}
+		pair.Count += stat.Count
+		pair.ByKind[stat.Kind] += stat.Count
+		pair.Outcomes[stat.Status] += stat.Count
+
+		if stat.Kind == models.DelegationOutOfOffice {
+			autoDelegated[stat.FromUserID] += stat.Count
+		}
+	}
+	for _, pair := range pairs {
+		report.Pairs = append(report.Pairs, *pair)
+	}
+	sort.Slice(report.Pairs, func(i, j int) bool {
+		if report.Pairs[i].Count != report.Pairs[j].Count {
+			return report.Pairs[i].Count > report.Pairs[j].Count
+		}
+		if report.Pairs[i].FromUserID != report.Pairs[j].FromUserID {
+			return report.Pairs[i].FromUserID.String() < report.Pairs[j].FromUserID.String()
+		}
+		return report.Pairs[i].ToUserID.String() < report.Pairs[j].ToUserID.String()
+	})
+
+	// Only the part of each period within the window counts
+	usage := make(map[uuid.UUID]*OutOfOfficeUsage)
+	for _, period := range periods {
+		start, end := period.StartsAt, period.EndsAt
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		user, ok := usage[period.UserID]
+		if !ok {
+			user = &OutOfOfficeUsage{UserID: period.UserID, TasksDelegated: autoDelegated[period.UserID]}
+			usage[period.UserID] = user
+		}
+		user.Periods++
+		user.Days += end.Sub(start).Hours() / 24
+	}
+	for _, user := range usage {
+		user.Days = math.Round(user.Days*10) / 10
+		report.OutOfOffice = append(report.OutOfOffice, *user)
+	}
+	sort.Slice(report.OutOfOffice, func(i, j int) bool {
+		if report.OutOfOffice[i].Days != report.OutOfOffice[j].Days {
+			return report.OutOfOffice[i].Days > report.OutOfOffice[j].Days
+		}
+		return report.OutOfOffice[i].UserID.String() < report.OutOfOffice[j].UserID.String()
+	})
+
+	return report
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDelegationReport(t *testing.T) {
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+
+	stats := []repositories.DelegationStats{
+		{FromUserID: alice, ToUserID: bob, Kind: models.DelegationManual, Status: models.WorkflowApproved, Count: 2},
+		{FromUserID: alice, ToUserID: bob, Kind: models.DelegationOutOfOffice, Status: models.WorkflowPending, Count: 3},
+		{FromUserID: carol, ToUserID: bob, Kind: models.DelegationManual, Status: models.WorkflowRejected, Count: 1},
+	}
+	periods := []models.OutOfOffice{
+		// Started before the window: only March counts
+		{UserID: alice, StartsAt: from.AddDate(0, 0, -3), EndsAt: from.Add(36 * time.Hour)},
+		{UserID: alice, StartsAt: from.AddDate(0, 0, 10), EndsAt: from.AddDate(0, 0, 12)},
+		// Runs past the window
+		{UserID: carol, StartsAt: to.AddDate(0, 0, -1), EndsAt: to.AddDate(0, 0, 5)},
+	}
+
+	report := buildDelegationReport(stats, periods, from, to)
+
+	assert.Equal(t, int64(6), report.TotalDelegations)
+	assert.Equal(t, int64(3), report.ByKind[models.DelegationManual])
+	assert.Equal(t, int64(3), report.ByKind[models.DelegationOutOfOffice])
+	assert.Equal(t, int64(2), report.Outcomes[models.WorkflowApproved])
+	assert.Equal(t, int64(1), report.Outcomes[models.WorkflowRejected])
+
+	require.Len(t, report.Pairs, 2)
+	assert.Equal(t, alice, report.Pairs[0].FromUserID)
+	assert.Equal(t, int64(5), report.Pairs[0].Count)
+	assert.Equal(t, int64(3), report.Pairs[0].Outcomes[models.WorkflowPending])
+	assert.Equal(t, carol, report.Pairs[1].FromUserID)
+
+	require.Len(t, report.OutOfOffice, 2)
+	assert.Equal(t, alice, report.OutOfOffice[0].UserID)
+	assert.Equal(t, 2, report.OutOfOffice[0].Periods)
+	assert.Equal(t, 3.5, report.OutOfOffice[0].Days)
+	assert.Equal(t, int64(3), report.OutOfOffice[0].TasksDelegated)
+	assert.Equal(t, carol, report.OutOfOffice[1].UserID)
+	assert.Equal(t, 1.0, report.OutOfOffice[1].Days)
+	assert.Zero(t, report.OutOfOffice[1].TasksDelegated)
+}
+
+func TestWorkflowService_SetOutOfOfficeValidation(t *testing.T) {
+	service := &WorkflowService{}
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Now()
+
+	_, err := service.SetOutOfOffice(ctx, SetOutOfOfficeParams{UserID: userID, DelegateID: userID, EndsAt: now.Add(time.Hour)})
+	assert.ErrorIs(t, err, ErrSelfDelegation)
+
+	for _, params := range []SetOutOfOfficeParams{
+		{StartsAt: now.Add(48 * time.Hour), EndsAt: now.Add(24 * time.Hour)},
+		{StartsAt: now.Add(-48 * time.Hour), EndsAt: now.Add(-24 * time.Hour)},
+		{EndsAt: now.AddDate(2, 0, 0)},
+	} {
+		params.UserID, params.DelegateID = userID, uuid.New()
+		_, err := service.SetOutOfOffice(ctx, params)
+		assert.ErrorIs(t, err, ErrInvalidOutOfOffice)
+	}
+}
+
+func TestWorkflowService_DelegationReportRange(t *testing.T) {
+	service := &WorkflowService{}
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := service.DelegationReport(context.Background(), uuid.New(), from, from)
+	assert.ErrorIs(t, err, ErrInvalidDateRange)
+
+	_, err = service.DelegationReport(context.Background(), uuid.New(), from, from.AddDate(2, 0, 0))
+	assert.ErrorIs(t, err, ErrInvalidDateRange)
+}
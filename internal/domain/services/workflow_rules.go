@@ -0,0 +1,182 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+)
+
+// Condition types
+const (
+	ConditionAmountThreshold = "amount_threshold"
+	ConditionVendorName      = "vendor_name"
+	ConditionDocumentType    = "document_type"
+)
+
+// conditionOperators lists the operators each condition type supports
+var conditionOperators = map[string][]string{
+	ConditionAmountThreshold: {"gt", "gte", "lt", "lte", "eq", "neq"},
+	ConditionVendorName:      {"eq", "neq", "contains"},
+	ConditionDocumentType:    {"", "eq", "neq"},
+}
+
+// ShouldTriggerWorkflow reports whether a document meets a workflow's
+// trigger conditions. Only mandatory conditions can prevent the trigger.
+func ShouldTriggerWorkflow(document *models.Document, conditions []TriggerCondition) bool {
+	for _, condition := range conditions {
+		if !EvaluateCondition(document, condition) {
+			if condition.Mandatory {
+				return false // Mandatory condition not met
+			}
+		}
+	}
+	return true
+}
+
+// StepApplies reports whether a step runs for the document: every one of its
+// conditions must hold. Steps without conditions always apply.
+func StepApplies(document *models.Document, step ApprovalStep) bool {
+	for _, condition := range step.Conditions {
+		if !EvaluateCondition(document, condition) {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplicableSteps returns the steps that run for the document
+func ApplicableSteps(document *models.Document, steps []ApprovalStep) []ApprovalStep {
+	var applicable []ApprovalStep
+	for _, step := range steps {
+		if StepApplies(document, step) {
+			applicable = append(applicable, step)
+		}
+	}
+	return applicable
+}
+
+// EvaluateCondition evaluates one condition against a document. Conditions
+// on missing fields (e.g. no amount) are false.
+func EvaluateCondition(document *models.Document, condition TriggerCondition) bool {
+	switch condition.Type {
+	case ConditionAmountThreshold:
+		if document.Amount == nil {
+			return false
+		}
+		threshold, ok := numberValue(condition.Value)
+		if !ok {
+			return false
+		}
+
+		switch condition.Operator {
+		case "gt":
+			return *document.Amount > threshold
+		case "gte":
+			return *document.Amount >= threshold
+		case "lt":
+			return *document.Amount < threshold
+		case "lte":
+			return *document.Amount <= threshold
+		case "eq":
+			return *document.Amount == threshold
+		case "neq":
+			return *document.Amount != threshold
+		}
+
+	case ConditionVendorName:
+		vendorName, ok := condition.Value.(string)
+		if !ok || document.VendorName == "" {
+			return false
+		}
+
+		switch condition.Operator {
+		case "eq":
+			return strings.EqualFold(document.VendorName, vendorName)
+		case "neq":
+			return !strings.EqualFold(document.VendorName, vendorName)
+		case "contains":
+			return vendorName != "" && strings.Contains(strings.ToLower(document.VendorName), strings.ToLower(vendorName))
+		}
+
+	case ConditionDocumentType:
+		docType, ok := condition.Value.(string)
+		if !ok {
+			return false
+		}
+		if condition.Operator == "neq" {
+			return string(document.DocumentType) != docType
+		}
+		return string(document.DocumentType) == docType
+	}
+
+	return false
+}
+
+// ValidateCondition checks a condition's type, operator and value
+func ValidateCondition(condition TriggerCondition) error {
+	operators, ok := conditionOperators[condition.Type]
+	if !ok {
+		return fmt.Errorf("unknown condition type %q", condition.Type)
+	}
+
+	supported := false
+	for _, operator := range operators {
+		if condition.Operator == operator {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("condition %s doesn't support operator %q", condition.Type, condition.Operator)
+	}
+
+	switch condition.Type {
+	case ConditionAmountThreshold:
+		if _, ok := numberValue(condition.Value); !ok {
+			return fmt.Errorf("condition %s needs a numeric value", condition.Type)
+		}
+	default:
+		if value, ok := condition.Value.(string); !ok || value == "" {
+			return fmt.Errorf("condition %s needs a text value", condition.Type)
+		}
+	}
+
+	return nil
+}
+
+// Helper functions
+
+func hasStepConditions(steps []ApprovalStep) bool {
+	for _, step := range steps {
+		if len(step.Conditions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDefaultStep(steps []ApprovalStep) bool {
+	for _, step := range steps {
+		if len(step.Conditions) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// numberValue reads a numeric condition value, which JSON decodes as float64
+// but API clients sometimes send as a string
+func numberValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
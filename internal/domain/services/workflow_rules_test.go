@@ -0,0 +1,164 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestNumberValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   float64
+		wantOK bool
+	}{
+		{"float64 from JSON", 1500.5, 1500.5, true},
+		{"int", 42, 42, true},
+		{"numeric string", "1500.5", 1500.5, true},
+		{"negative string", "-3", -3, true},
+		{"non-numeric string", "lots", 0, false},
+		{"empty string", "", 0, false},
+		{"bool", true, 0, false},
+		{"nil", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := numberValue(tt.value)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	invoice := &models.Document{
+		DocumentType: models.DocTypeInvoice,
+		Amount:       floatPtr(1000),
+		VendorName:   "Acme Supplies Ltd",
+	}
+	bare := &models.Document{DocumentType: models.DocTypeReceipt}
+
+	tests := []struct {
+		name      string
+		document  *models.Document
+		condition TriggerCondition
+		want      bool
+	}{
+		{"amount gt below", invoice, TriggerCondition{Type: ConditionAmountThreshold, Operator: "gt", Value: 1000.0}, false},
+		{"amount gte equal", invoice, TriggerCondition{Type: ConditionAmountThreshold, Operator: "gte", Value: 1000.0}, true},
+		{"amount lt", invoice, TriggerCondition{Type: ConditionAmountThreshold, Operator: "lt", Value: 5000.0}, true},
+		{"amount lte below", invoice, TriggerCondition{Type: ConditionAmountThreshold, Operator: "lte", Value: 999.0}, false},
+		{"amount eq", invoice, TriggerCondition{Type: ConditionAmountThreshold, Operator: "eq", Value: 1000.0}, true},
+		{"amount neq", invoice, TriggerCondition{Type: ConditionAmountThreshold, Operator: "neq", Value: 1000.0}, false},
+		{"amount as string", invoice, TriggerCondition{Type: ConditionAmountThreshold, Operator: "gt", Value: "500"}, true},
+		{"amount non-numeric value", invoice, TriggerCondition{Type: ConditionAmountThreshold, Operator: "gt", Value: "five"}, false},
+		{"amount missing on document", bare, TriggerCondition{Type: ConditionAmountThreshold, Operator: "lt", Value: 100.0}, false},
+		{"amount unknown operator", invoice, TriggerCondition{Type: ConditionAmountThreshold, Operator: "between", Value: 1.0}, false},
+
+		{"vendor eq ignores case", invoice, TriggerCondition{Type: ConditionVendorName, Operator: "eq", Value: "acme supplies ltd"}, true},
+		{"vendor neq", invoice, TriggerCondition{Type: ConditionVendorName, Operator: "neq", Value: "Globex"}, true},
+		{"vendor contains", invoice, TriggerCondition{Type: ConditionVendorName, Operator: "contains", Value: "SUPPLIES"}, true},
+		{"vendor contains empty", invoice, TriggerCondition{Type: ConditionVendorName, Operator: "contains", Value: ""}, false},
+		{"vendor missing on document", bare, TriggerCondition{Type: ConditionVendorName, Operator: "neq", Value: "Globex"}, false},
+		{"vendor non-string value", invoice, TriggerCondition{Type: ConditionVendorName, Operator: "eq", Value: 7.0}, false},
+
+		{"type default operator", invoice, TriggerCondition{Type: ConditionDocumentType, Value: "invoice"}, true},
+		{"type eq mismatch", invoice, TriggerCondition{Type: ConditionDocumentType, Operator: "eq", Value: "contract"}, false},
+		{"type neq", invoice, TriggerCondition{Type: ConditionDocumentType, Operator: "neq", Value: "contract"}, true},
+
+		{"unknown type", invoice, TriggerCondition{Type: "page_count", Operator: "gt", Value: 1.0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EvaluateCondition(tt.document, tt.condition))
+		})
+	}
+}
+
+func TestValidateCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition TriggerCondition
+		wantErr   bool
+	}{
+		{"amount numeric", TriggerCondition{Type: ConditionAmountThreshold, Operator: "gte", Value: 100.0}, false},
+		{"amount numeric string", TriggerCondition{Type: ConditionAmountThreshold, Operator: "gte", Value: "100"}, false},
+		{"amount text", TriggerCondition{Type: ConditionAmountThreshold, Operator: "gte", Value: "a lot"}, true},
+		{"amount contains", TriggerCondition{Type: ConditionAmountThreshold, Operator: "contains", Value: 100.0}, true},
+		{"vendor contains", TriggerCondition{Type: ConditionVendorName, Operator: "contains", Value: "Acme"}, false},
+		{"vendor gt", TriggerCondition{Type: ConditionVendorName, Operator: "gt", Value: "Acme"}, true},
+		{"vendor empty", TriggerCondition{Type: ConditionVendorName, Operator: "eq", Value: ""}, true},
+		{"vendor number", TriggerCondition{Type: ConditionVendorName, Operator: "eq", Value: 3.0}, true},
+		{"type without operator", TriggerCondition{Type: ConditionDocumentType, Value: "invoice"}, false},
+		{"type missing value", TriggerCondition{Type: ConditionDocumentType, Operator: "eq"}, true},
+		{"unknown type", TriggerCondition{Type: "page_count", Operator: "gt", Value: 1.0}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCondition(tt.condition)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestApplicableSteps(t *testing.T) {
+	large := TriggerCondition{Type: ConditionAmountThreshold, Operator: "gt", Value: 10000.0}
+	invoice := TriggerCondition{Type: ConditionDocumentType, Operator: "eq", Value: "invoice"}
+	steps := []ApprovalStep{
+		{StepNumber: 1, Name: "Manager"},
+		{StepNumber: 2, Name: "Finance", Conditions: []TriggerCondition{large}},
+		{StepNumber: 3, Name: "Accounts payable", Conditions: []TriggerCondition{large, invoice}},
+	}
+
+	tests := []struct {
+		name     string
+		document *models.Document
+		want     []int
+	}{
+		{"small invoice", &models.Document{DocumentType: models.DocTypeInvoice, Amount: floatPtr(50)}, []int{1}},
+		{"large receipt", &models.Document{DocumentType: models.DocTypeReceipt, Amount: floatPtr(20000)}, []int{1, 2}},
+		{"large invoice", &models.Document{DocumentType: models.DocTypeInvoice, Amount: floatPtr(20000)}, []int{1, 2, 3}},
+		{"no amount", &models.Document{DocumentType: models.DocTypeInvoice}, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, step := range ApplicableSteps(tt.document, steps) {
+				got = append(got, step.StepNumber)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateWorkflowRulesRequiresDefaultBranch(t *testing.T) {
+	service := &WorkflowService{}
+	large := TriggerCondition{Type: ConditionAmountThreshold, Operator: "gt", Value: 10000.0}
+	small := TriggerCondition{Type: ConditionAmountThreshold, Operator: "lte", Value: 10000.0}
+
+	err := service.validateWorkflowRules(WorkflowRules{ApprovalSteps: []ApprovalStep{
+		{StepNumber: 1, Conditions: []TriggerCondition{small}},
+		{StepNumber: 2, Conditions: []TriggerCondition{large}},
+	}})
+	assert.Error(t, err)
+
+	err = service.validateWorkflowRules(WorkflowRules{ApprovalSteps: []ApprovalStep{
+		{StepNumber: 1},
+		{StepNumber: 2, Conditions: []TriggerCondition{large}},
+	}})
+	assert.NoError(t, err)
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/archivus/archivus/internal/domain/repositories"
@@ -19,41 +22,100 @@ var (
 	ErrInvalidTaskStatus    = errors.New("invalid task status")
 	ErrUnauthorizedTask     = errors.New("unauthorized to complete task")
 	ErrWorkflowNotActive    = errors.New("workflow is not active")
+	ErrChecklistIncomplete  = errors.New("all required checklist items must be ticked before approving")
+	ErrChecklistItemMissing = errors.New("checklist item not found")
+	ErrInstanceNotFound     = errors.New("workflow instance not found")
+	ErrInvalidWorkflowRules = errors.New("invalid workflow rules")
+	ErrDelegationNotAllowed = errors.New("delegation not allowed for this task")
+	ErrDelegateAlreadyVotes = errors.New("delegate already holds a task in this step")
+	ErrWorkflowInUse        = errors.New("workflow has pending tasks")
+	ErrBulkTaskEmpty        = errors.New("bulk action needs at least one task")
+	ErrBulkTaskTooLarge     = errors.New("too many tasks in one bulk action")
+	ErrWorkflowVersionGone  = errors.New("workflow version not found")
+	ErrWorkflowEditConflict = errors.New("workflow was changed by someone else; reload and retry")
+	ErrInvalidMigration     = errors.New("invalid migration policy")
 )
 
+// Workflow step types
+const (
+	StepTypeApproval  = "approval"
+	StepTypeChecklist = "checklist"
+)
+
+// Bulk task actions
+const (
+	BulkTaskApprove  = "approve"
+	BulkTaskReject   = "reject"
+	BulkTaskDelegate = "delegate"
+
+	// MaxBulkTaskActions caps the tasks handled in one bulk action
+	MaxBulkTaskActions = 100
+)
+
+// Workflow automation settings
+const (
+	workflowAutomationInterval  = 15 * time.Minute
+	workflowAutomationBatchSize = 200
+)
+
+// Automation metrics, published through expvar
+var workflowAutomationActions = expvar.NewMap("workflow_automation_actions_total")
+
 // WorkflowService handles business process automation and document approval workflows
 type WorkflowService struct {
 	workflowRepo     repositories.WorkflowRepository
 	taskRepo         repositories.WorkflowTaskRepository
+	instanceRepo     repositories.WorkflowInstanceRepository
+	checklistRepo    repositories.WorkflowChecklistRepository
 	documentRepo     repositories.DocumentRepository
 	userRepo         repositories.UserRepository
 	tenantRepo       repositories.TenantRepository
 	auditRepo        repositories.AuditLogRepository
 	notificationRepo repositories.NotificationRepository
+	outOfOfficeRepo  repositories.OutOfOfficeRepository
+	docTypeRepo      repositories.DocumentTypeRepository
 
 	notificationService NotificationService
+	events              EventPublisher
+	calendars           BusinessCalendarProvider
+	approvalStamps      *ApprovalStampService
 }
 
-// NewWorkflowService creates a new workflow service
+// NewWorkflowService creates a new workflow service. approvalStamps may be
+// nil, in which case workflows' approval stamps are skipped.
 func NewWorkflowService(
 	workflowRepo repositories.WorkflowRepository,
 	taskRepo repositories.WorkflowTaskRepository,
+	instanceRepo repositories.WorkflowInstanceRepository,
+	checklistRepo repositories.WorkflowChecklistRepository,
 	documentRepo repositories.DocumentRepository,
 	userRepo repositories.UserRepository,
 	tenantRepo repositories.TenantRepository,
 	auditRepo repositories.AuditLogRepository,
 	notificationRepo repositories.NotificationRepository,
+	outOfOfficeRepo repositories.OutOfOfficeRepository,
+	docTypeRepo repositories.DocumentTypeRepository,
 	notificationService NotificationService,
+	events EventPublisher,
+	calendars BusinessCalendarProvider,
+	approvalStamps *ApprovalStampService,
 ) *WorkflowService {
 	return &WorkflowService{
 		workflowRepo:        workflowRepo,
 		taskRepo:            taskRepo,
+		instanceRepo:        instanceRepo,
+		checklistRepo:       checklistRepo,
 		documentRepo:        documentRepo,
 		userRepo:            userRepo,
 		tenantRepo:          tenantRepo,
 		auditRepo:           auditRepo,
 		notificationRepo:    notificationRepo,
+		outOfOfficeRepo:     outOfOfficeRepo,
+		docTypeRepo:         docTypeRepo,
 		notificationService: notificationService,
+		events:              events,
+		calendars:           calendars,
+		approvalStamps:      approvalStamps,
 	}
 }
 
@@ -84,31 +146,47 @@ type WorkflowRules struct {
 
 	// Notification settings
 	NotificationSettings NotificationSettings `json:"notification_settings"`
+
+	// Stamp approved PDFs with the approver and date
+	ApprovalStamp *ApprovalStampSettings `json:"approval_stamp,omitempty"`
 }
 
 type TriggerCondition struct {
-	Type      string      `json:"type"`     // "amount_threshold", "document_type", "vendor", etc.
+	Type      string      `json:"type"`     // "amount_threshold", "document_type", "vendor_name"
 	Operator  string      `json:"operator"` // "gt", "lt", "eq", "contains", etc.
 	Value     interface{} `json:"value"`
-	Mandatory bool        `json:"mandatory"`
+	Mandatory bool        `json:"mandatory"` // Trigger conditions only; step conditions must all hold
 }
 
 type ApprovalStep struct {
-	StepNumber    int    `json:"step_number"`
-	Name          string `json:"name"`
-	Description   string `json:"description"`
-	AssigneeType  string `json:"assignee_type"` // "user", "role", "department"
-	AssigneeValue string `json:"assignee_value"`
-	RequiredVotes int    `json:"required_votes"` // For multi-approver steps
-	DueDays       int    `json:"due_days"`       // Days from creation
-	CanDelegate   bool   `json:"can_delegate"`
-	IsOptional    bool   `json:"is_optional"`
+	StepNumber     int                       `json:"step_number"`
+	Name           string                    `json:"name"`
+	Description    string                    `json:"description"`
+	StepType       string                    `json:"step_type,omitempty"` // "approval" (default), "checklist"
+	AssigneeType   string                    `json:"assignee_type"`       // "user", "role", "department"
+	AssigneeValue  string                    `json:"assignee_value"`
+	RequiredVotes  int                       `json:"required_votes"` // For multi-approver steps
+	DueDays        int                       `json:"due_days"`       // Business days from creation
+	CanDelegate    bool                      `json:"can_delegate"`
+	IsOptional     bool                      `json:"is_optional"`
+	ChecklistItems []ChecklistItemDefinition `json:"checklist_items,omitempty"` // For checklist steps
+
+	// Branching: the step only runs for documents matching every condition,
+	// e.g. a CFO step for amounts over 10000 and a manager step otherwise
+	Conditions []TriggerCondition `json:"conditions,omitempty"`
+}
+
+// ChecklistItemDefinition is a predefined item the assignee ticks on a checklist step
+type ChecklistItemDefinition struct {
+	Key      string `json:"key"`   // e.g. "vendor_verified"
+	Label    string `json:"label"` // e.g. "Vendor verified"
+	Optional bool   `json:"optional"`
 }
 
 type EscalationRule struct {
 	StepNumber      int    `json:"step_number"`      // Which step to escalate
-	EscalationDays  int    `json:"escalation_days"`  // Days before escalation
-	EscalateToType  string `json:"escalate_to_type"` // "user", "role", "manager"
+	EscalationDays  int    `json:"escalation_days"`  // Business days before escalation
+	EscalateToType  string `json:"escalate_to_type"` // "user", "role", "manager" (the assignee's)
 	EscalateToValue string `json:"escalate_to_value"`
 	NotifyOriginal  bool   `json:"notify_original"` // Keep original assignee notified
 }
@@ -124,25 +202,18 @@ type NotificationSettings struct {
 	NotifyOnCompletion bool  `json:"notify_on_completion"`
 	NotifyOnEscalation bool  `json:"notify_on_escalation"`
 	NotifyOnRejection  bool  `json:"notify_on_rejection"`
-	ReminderDays       []int `json:"reminder_days"` // Days to send reminders
+	ReminderDays       []int `json:"reminder_days"` // Business days before the due date to send reminders
 }
 
 // CreateWorkflow creates a new workflow template
 func (s *WorkflowService) CreateWorkflow(ctx context.Context, params CreateWorkflowParams) (*models.Workflow, error) {
-	// Validate rules
-	if err := s.validateWorkflowRules(params.Rules); err != nil {
-		return nil, fmt.Errorf("invalid workflow rules: %w", err)
+	if err := checkDocumentType(ctx, s.docTypeRepo, params.TenantID, params.DocumentType); err != nil {
+		return nil, err
 	}
 
-	// Marshal rules to JSON then to map for JSONB
-	rulesJSON, err := json.Marshal(params.Rules)
+	rulesMap, err := s.encodeRules(params.Rules)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal workflow rules: %w", err)
-	}
-
-	var rulesMap models.JSONB
-	if err := json.Unmarshal(rulesJSON, &rulesMap); err != nil {
-		return nil, fmt.Errorf("failed to convert workflow rules to JSONB: %w", err)
+		return nil, err
 	}
 
 	workflow := &models.Workflow{
@@ -166,20 +237,192 @@ func (s *WorkflowService) CreateWorkflow(ctx context.Context, params CreateWorkf
 	return workflow, nil
 }
 
-// TriggerWorkflow initiates a workflow for a document
-func (s *WorkflowService) TriggerWorkflow(ctx context.Context, documentID uuid.UUID, triggeredBy uuid.UUID) error {
+// GetWorkflow returns a workflow of the tenant
+func (s *WorkflowService) GetWorkflow(ctx context.Context, tenantID, workflowID uuid.UUID) (*models.Workflow, error) {
+	workflow, err := s.workflowRepo.GetByID(ctx, workflowID)
+	if err != nil || workflow.TenantID != tenantID {
+		return nil, ErrWorkflowNotFound
+	}
+	return workflow, nil
+}
+
+// ListWorkflows lists the tenant's workflows by name
+func (s *WorkflowService) ListWorkflows(ctx context.Context, tenantID uuid.UUID) ([]models.Workflow, error) {
+	return s.workflowRepo.ListByTenant(ctx, tenantID)
+}
+
+// UpdateWorkflowParams contains the workflow changes; nil fields are left as they are
+type UpdateWorkflowParams struct {
+	TenantID     uuid.UUID            `json:"tenant_id"`
+	WorkflowID   uuid.UUID            `json:"workflow_id"`
+	UpdatedBy    uuid.UUID            `json:"updated_by"`
+	Name         *string              `json:"name,omitempty"`
+	Description  *string              `json:"description,omitempty"`
+	DocumentType *models.DocumentType `json:"document_type,omitempty"`
+	Rules        *WorkflowRules       `json:"rules,omitempty"`
+	IsActive     *bool                `json:"is_active,omitempty"`
+
+	// MigrationPolicy applies when Rules change: runs in progress either
+	// finish on the version they started on (the default) or migrate
+	MigrationPolicy models.WorkflowMigrationPolicy `json:"migration_policy,omitempty"`
+}
+
+// UpdateWorkflow changes a workflow template. A rules change creates a new
+// version: new runs use it, and runs in progress stay on the version they
+// started on unless the migration policy moves them. Migrated runs continue
+// from their current step under the new rules; runs whose current step no
+// longer exists finish on their old version.
+func (s *WorkflowService) UpdateWorkflow(ctx context.Context, params UpdateWorkflowParams) (*models.Workflow, error) {
+	switch params.MigrationPolicy {
+	case "":
+		params.MigrationPolicy = models.WorkflowFinishOnOld
+	case models.WorkflowFinishOnOld, models.WorkflowMigrate:
+	default:
+		return nil, ErrInvalidMigration
+	}
+
+	workflow, err := s.GetWorkflow(ctx, params.TenantID, params.WorkflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	var version *models.WorkflowVersion
+	var migrateSteps []int
+	if params.Rules != nil {
+		rulesMap, err := s.encodeRules(*params.Rules)
+		if err != nil {
+			return nil, err
+		}
+		workflow.Rules = rulesMap
+
+		version = &models.WorkflowVersion{
+			ID:              uuid.New(),
+			TenantID:        workflow.TenantID,
+			WorkflowID:      workflow.ID,
+			Version:         workflow.Version + 1,
+			Rules:           rulesMap,
+			MigrationPolicy: params.MigrationPolicy,
+			CreatedBy:       params.UpdatedBy,
+		}
+		if params.MigrationPolicy == models.WorkflowMigrate {
+			for _, step := range params.Rules.ApprovalSteps {
+				migrateSteps = append(migrateSteps, step.StepNumber)
+			}
+		}
+	}
+	if params.Name != nil {
+		workflow.Name = *params.Name
+	}
+	if params.Description != nil {
+		workflow.Description = *params.Description
+	}
+	if params.DocumentType != nil && *params.DocumentType != workflow.DocType {
+		if err := checkDocumentType(ctx, s.docTypeRepo, params.TenantID, *params.DocumentType); err != nil {
+			return nil, err
+		}
+		workflow.DocType = *params.DocumentType
+	}
+	if params.IsActive != nil {
+		workflow.IsActive = *params.IsActive
+	}
+	workflow.UpdatedAt = time.Now()
+
+	if version == nil {
+		if err := s.workflowRepo.Update(ctx, workflow); err != nil {
+			return nil, fmt.Errorf("failed to update workflow: %w", err)
+		}
+		s.createAuditLog(ctx, params.TenantID, params.UpdatedBy, workflow.ID, models.AuditUpdate, "Workflow updated")
+		return workflow, nil
+	}
+
+	updated, err := s.workflowRepo.UpdateRules(ctx, workflow, version, migrateSteps)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrWorkflowEditConflict
+	}
+
+	s.createAuditLog(ctx, params.TenantID, params.UpdatedBy, workflow.ID, models.AuditUpdate,
+		fmt.Sprintf("Workflow updated to version %d (%s, %d runs migrated)", version.Version, version.MigrationPolicy, version.MigratedInstances))
+
+	return workflow, nil
+}
+
+// ListWorkflowVersions returns a workflow's rules versions, newest first
+func (s *WorkflowService) ListWorkflowVersions(ctx context.Context, tenantID, workflowID uuid.UUID) ([]models.WorkflowVersion, error) {
+	if _, err := s.GetWorkflow(ctx, tenantID, workflowID); err != nil {
+		return nil, err
+	}
+	return s.workflowRepo.ListVersions(ctx, workflowID)
+}
+
+// GetWorkflowVersion returns one rules version of a workflow
+func (s *WorkflowService) GetWorkflowVersion(ctx context.Context, tenantID, workflowID uuid.UUID, version int) (*models.WorkflowVersion, error) {
+	if _, err := s.GetWorkflow(ctx, tenantID, workflowID); err != nil {
+		return nil, err
+	}
+	workflowVersion, err := s.workflowRepo.GetVersion(ctx, workflowID, version)
+	if err != nil {
+		return nil, ErrWorkflowVersionGone
+	}
+	return workflowVersion, nil
+}
+
+// DeleteWorkflow deletes a workflow template and its finished tasks.
+// Workflows with pending tasks can't be deleted; deactivate them instead.
+func (s *WorkflowService) DeleteWorkflow(ctx context.Context, tenantID, workflowID, deletedBy uuid.UUID) error {
+	if _, err := s.GetWorkflow(ctx, tenantID, workflowID); err != nil {
+		return err
+	}
+
+	pending, err := s.taskRepo.GetPendingTasks(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to check pending tasks: %w", err)
+	}
+	for _, task := range pending {
+		if task.WorkflowID == workflowID {
+			return ErrWorkflowInUse
+		}
+	}
+
+	if err := s.workflowRepo.Delete(ctx, workflowID); err != nil {
+		return fmt.Errorf("failed to delete workflow: %w", err)
+	}
+
+	s.createAuditLog(ctx, tenantID, deletedBy, workflowID, models.AuditDelete, "Workflow deleted")
+
+	return nil
+}
+
+// TriggerWorkflow starts every active workflow of the document's type whose
+// trigger conditions the document meets, and returns the started runs.
+// Documents of a tenant's own type without workflows of their own get the
+// workflows of the built-in type it maps to.
+func (s *WorkflowService) TriggerWorkflow(ctx context.Context, documentID, tenantID, triggeredBy uuid.UUID) ([]models.WorkflowInstance, error) {
 	// Get document
-	document, err := s.documentRepo.GetByID(ctx, documentID)
+	document, err := s.documentRepo.GetForTenant(ctx, tenantID, documentID)
 	if err != nil {
-		return fmt.Errorf("failed to get document: %w", err)
+		return nil, ErrDocumentNotFound
 	}
 
 	// Find applicable workflows
 	workflows, err := s.workflowRepo.GetByDocumentType(ctx, document.TenantID, document.DocumentType)
 	if err != nil {
-		return fmt.Errorf("failed to get workflows: %w", err)
+		return nil, fmt.Errorf("failed to get workflows: %w", err)
+	}
+	if len(workflows) == 0 {
+		builtin := builtinDocumentType(ctx, s.docTypeRepo, document.TenantID, document.DocumentType)
+		if builtin != "" && builtin != document.DocumentType {
+			workflows, err = s.workflowRepo.GetByDocumentType(ctx, document.TenantID, builtin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get workflows: %w", err)
+			}
+		}
 	}
 
+	var started []models.WorkflowInstance
+
 	// Check which workflows should be triggered
 	for _, workflow := range workflows {
 		if !workflow.IsActive {
@@ -192,27 +435,47 @@ func (s *WorkflowService) TriggerWorkflow(ctx context.Context, documentID uuid.U
 		}
 
 		// Check trigger conditions
-		if s.shouldTriggerWorkflow(document, rules.TriggerConditions) {
-			if err := s.initiateWorkflowExecution(ctx, &workflow, document, triggeredBy); err != nil {
+		if ShouldTriggerWorkflow(document, rules.TriggerConditions) {
+			instance, err := s.initiateWorkflowExecution(ctx, &workflow, document, triggeredBy)
+			if err != nil {
 				// Log error but don't fail - other workflows might still work
 				continue
 			}
+			started = append(started, *instance)
 		}
 	}
 
-	return nil
+	return started, nil
+}
+
+// GetTask returns a workflow task of the tenant
+func (s *WorkflowService) GetTask(ctx context.Context, tenantID, taskID uuid.UUID) (*models.WorkflowTask, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil || task.Document.TenantID != tenantID {
+		return nil, ErrTaskNotFound
+	}
+	return task, nil
 }
 
 // CompleteTask marks a workflow task as completed
-func (s *WorkflowService) CompleteTask(ctx context.Context, taskID uuid.UUID, completedBy uuid.UUID, action string, comments string) error {
+func (s *WorkflowService) CompleteTask(ctx context.Context, taskID, tenantID, completedBy uuid.UUID, action string, comments string) error {
 	// Get task
-	task, err := s.taskRepo.GetByID(ctx, taskID)
+	task, err := s.GetTask(ctx, tenantID, taskID)
 	if err != nil {
-		return ErrTaskNotFound
+		return err
 	}
 
 	// Verify authorization
 	if task.AssignedTo != completedBy {
+		// Votes in a shared step count per approver, so nobody casts another's
+		shared, err := s.isSharedStep(ctx, task)
+		if err != nil {
+			return err
+		}
+		if shared {
+			return ErrUnauthorizedTask
+		}
+
 		// Check if user has admin role or can delegate
 		user, err := s.userRepo.GetByID(ctx, completedBy)
 		if err != nil || (user.Role != models.UserRoleAdmin && user.Role != models.UserRoleManager) {
@@ -225,6 +488,11 @@ func (s *WorkflowService) CompleteTask(ctx context.Context, taskID uuid.UUID, co
 		return ErrTaskAlreadyCompleted
 	}
 
+	return s.finishTask(ctx, task, completedBy, action, comments)
+}
+
+// finishTask completes a pending task and moves its workflow on to the next step
+func (s *WorkflowService) finishTask(ctx context.Context, task *models.WorkflowTask, completedBy uuid.UUID, action string, comments string) error {
 	// Update task status
 	var newStatus models.WorkflowStatus
 	switch action {
@@ -236,8 +504,15 @@ func (s *WorkflowService) CompleteTask(ctx context.Context, taskID uuid.UUID, co
 		return ErrInvalidTaskStatus
 	}
 
+	// Checklist steps can only be approved once every required item is ticked
+	if action == "approve" {
+		if err := s.ensureChecklistComplete(ctx, task.ID); err != nil {
+			return err
+		}
+	}
+
 	// Complete the task
-	if err := s.taskRepo.Complete(ctx, taskID, completedBy, comments); err != nil {
+	if err := s.taskRepo.Complete(ctx, task.ID, completedBy, comments); err != nil {
 		return fmt.Errorf("failed to complete task: %w", err)
 	}
 
@@ -246,6 +521,7 @@ func (s *WorkflowService) CompleteTask(ctx context.Context, taskID uuid.UUID, co
 	task.Comments = comments
 	now := time.Now()
 	task.CompletedAt = &now
+	task.NextReminderAt = nil // No reminders for completed tasks
 
 	if err := s.taskRepo.Update(ctx, task); err != nil {
 		return fmt.Errorf("failed to update task: %w", err)
@@ -263,6 +539,17 @@ func (s *WorkflowService) CompleteTask(ctx context.Context, taskID uuid.UUID, co
 	s.createAuditLog(ctx, task.Document.TenantID, completedBy, task.DocumentID, models.AuditApprove,
 		fmt.Sprintf("Workflow task %s: %s", action, comments))
 
+	if s.events != nil {
+		s.events.Publish(ctx, task.Document.TenantID, WebhookEventTaskCompleted, map[string]interface{}{
+			"task_id":      task.ID.String(),
+			"workflow_id":  task.WorkflowID.String(),
+			"document_id":  task.DocumentID.String(),
+			"action":       action,
+			"status":       string(newStatus),
+			"completed_by": completedBy.String(),
+		})
+	}
+
 	return nil
 }
 
@@ -276,28 +563,255 @@ func (s *WorkflowService) GetPendingTasks(ctx context.Context, tenantID uuid.UUI
 	return s.taskRepo.GetPendingTasks(ctx, tenantID)
 }
 
-// GetOverdueTasks gets all overdue tasks for a tenant
-func (s *WorkflowService) GetOverdueTasks(ctx context.Context, tenantID uuid.UUID) ([]models.WorkflowTask, error) {
-	return s.taskRepo.GetOverdueTasks(ctx, tenantID)
+// OverdueTask is a pending task past its due date
+type OverdueTask struct {
+	models.WorkflowTask
+	BusinessDaysOverdue int `json:"business_days_overdue"` // Counted on the tenant's business calendar
+}
+
+// GetOverdueTasks gets all overdue tasks for a tenant with how many business
+// days each is past its due date, most overdue first
+func (s *WorkflowService) GetOverdueTasks(ctx context.Context, tenantID uuid.UUID) ([]OverdueTask, error) {
+	tasks, err := s.taskRepo.GetOverdueTasks(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := s.calendarFor(ctx, tenantID)
+	now := time.Now()
+	overdue := make([]OverdueTask, 0, len(tasks))
+	for _, task := range tasks {
+		entry := OverdueTask{WorkflowTask: task}
+		if task.DueDate != nil {
+			entry.BusinessDaysOverdue = calendar.BusinessDaysBetween(*task.DueDate, now)
+		}
+		overdue = append(overdue, entry)
+	}
+	return overdue, nil
 }
 
-// GetDocumentWorkflow gets the workflow status for a document
-func (s *WorkflowService) GetDocumentWorkflow(ctx context.Context, documentID uuid.UUID) ([]models.WorkflowTask, error) {
+// GetDocumentWorkflow gets the workflow tasks of a document, its approval history
+func (s *WorkflowService) GetDocumentWorkflow(ctx context.Context, documentID, tenantID uuid.UUID) ([]models.WorkflowTask, error) {
+	if _, err := s.documentRepo.GetForTenant(ctx, tenantID, documentID); err != nil {
+		return nil, ErrDocumentNotFound
+	}
 	return s.taskRepo.ListByDocument(ctx, documentID)
 }
 
+// ListInstances lists the tenant's workflow runs, optionally by status
+func (s *WorkflowService) ListInstances(ctx context.Context, tenantID uuid.UUID, status models.WorkflowStatus, params repositories.ListParams) ([]models.WorkflowInstance, int64, error) {
+	return s.instanceRepo.ListByTenant(ctx, tenantID, status, params)
+}
+
+// ListDocumentInstances lists the workflow runs of a document, newest first
+func (s *WorkflowService) ListDocumentInstances(ctx context.Context, tenantID, documentID uuid.UUID) ([]models.WorkflowInstance, error) {
+	if _, err := s.documentRepo.GetForTenant(ctx, tenantID, documentID); err != nil {
+		return nil, ErrDocumentNotFound
+	}
+	return s.instanceRepo.ListByDocument(ctx, documentID)
+}
+
+// WorkflowTimelineStep is one step of a workflow run
+type WorkflowTimelineStep struct {
+	StepNumber  int                   `json:"step_number"`
+	Names       []string              `json:"names"`
+	Status      string                `json:"status"` // upcoming, active, approved, rejected or skipped
+	StartedAt   *time.Time            `json:"started_at,omitempty"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+	Tasks       []models.WorkflowTask `json:"tasks"`
+}
+
+// Timeline step statuses
+const (
+	TimelineStepUpcoming = "upcoming"
+	TimelineStepActive   = "active"
+	TimelineStepApproved = "approved"
+	TimelineStepRejected = "rejected"
+	TimelineStepSkipped  = "skipped"
+)
+
+// WorkflowInstanceTimeline is a workflow run with every step of its workflow,
+// for rendering progress
+type WorkflowInstanceTimeline struct {
+	Instance *models.WorkflowInstance `json:"instance"`
+	Steps    []WorkflowTimelineStep   `json:"steps"`
+}
+
+// GetInstanceTimeline returns a workflow run and the state of each of its steps
+func (s *WorkflowService) GetInstanceTimeline(ctx context.Context, tenantID, instanceID uuid.UUID) (*WorkflowInstanceTimeline, error) {
+	instance, err := s.instanceRepo.GetByID(ctx, instanceID)
+	if err != nil || instance.TenantID != tenantID {
+		return nil, ErrInstanceNotFound
+	}
+
+	tasks, err := s.taskRepo.ListByInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load instance tasks: %w", err)
+	}
+
+	rules, err := s.versionRules(ctx, &instance.Workflow, instance.WorkflowVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only show the branch this document takes
+	steps := rules.ApprovalSteps
+	if hasStepConditions(steps) {
+		document, err := s.documentRepo.GetForTenant(ctx, instance.TenantID, instance.DocumentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get document: %w", err)
+		}
+		steps = ApplicableSteps(document, steps)
+	}
+
+	return &WorkflowInstanceTimeline{
+		Instance: instance,
+		Steps:    buildTimelineSteps(instance, steps, tasks),
+	}, nil
+}
+
+// GetTaskChecklist returns the checklist state of a task
+func (s *WorkflowService) GetTaskChecklist(ctx context.Context, taskID, tenantID uuid.UUID) ([]models.WorkflowChecklistItem, error) {
+	if _, err := s.GetTask(ctx, tenantID, taskID); err != nil {
+		return nil, err
+	}
+	return s.checklistRepo.ListByTask(ctx, taskID)
+}
+
+// UpdateChecklistItemParams contains parameters for ticking a checklist item
+type UpdateChecklistItemParams struct {
+	TenantID  uuid.UUID `json:"tenant_id"`
+	TaskID    uuid.UUID `json:"task_id"`
+	ItemKey   string    `json:"item_key"`
+	UpdatedBy uuid.UUID `json:"updated_by"`
+	Checked   bool      `json:"checked"`
+	Note      string    `json:"note"`
+}
+
+// UpdateChecklistItem ticks or unticks a checklist item on a pending task
+func (s *WorkflowService) UpdateChecklistItem(ctx context.Context, params UpdateChecklistItemParams) (*models.WorkflowChecklistItem, error) {
+	task, err := s.GetTask(ctx, params.TenantID, params.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Same authorization as completing the task
+	if task.AssignedTo != params.UpdatedBy {
+		user, err := s.userRepo.GetByID(ctx, params.UpdatedBy)
+		if err != nil || (user.Role != models.UserRoleAdmin && user.Role != models.UserRoleManager) {
+			return nil, ErrUnauthorizedTask
+		}
+	}
+
+	if task.Status != models.WorkflowPending {
+		return nil, ErrTaskAlreadyCompleted
+	}
+
+	items, err := s.checklistRepo.ListByTask(ctx, task.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task checklist: %w", err)
+	}
+
+	var item *models.WorkflowChecklistItem
+	for i := range items {
+		if items[i].ItemKey == params.ItemKey {
+			item = &items[i]
+			break
+		}
+	}
+	if item == nil {
+		return nil, ErrChecklistItemMissing
+	}
+
+	item.IsChecked = params.Checked
+	item.Note = params.Note
+	item.UpdatedAt = time.Now()
+	if params.Checked {
+		now := time.Now()
+		item.CheckedBy = &params.UpdatedBy
+		item.CheckedAt = &now
+	} else {
+		item.CheckedBy = nil
+		item.CheckedAt = nil
+	}
+
+	if err := s.checklistRepo.Update(ctx, item); err != nil {
+		return nil, err
+	}
+
+	state := "unticked"
+	if params.Checked {
+		state = "ticked"
+	}
+	s.createAuditLog(ctx, item.TenantID, params.UpdatedBy, task.DocumentID, models.AuditUpdate,
+		fmt.Sprintf("Checklist item %s %s", item.Label, state))
+
+	return item, nil
+}
+
+// WorkflowEvidencePackage collects the approval trail of a document
+type WorkflowEvidencePackage struct {
+	DocumentID  uuid.UUID             `json:"document_id"`
+	Tasks       []models.WorkflowTask `json:"tasks"`
+	GeneratedAt time.Time             `json:"generated_at"`
+}
+
+// GetEvidencePackage returns every workflow task of a document together with its checklist state
+func (s *WorkflowService) GetEvidencePackage(ctx context.Context, documentID, tenantID uuid.UUID) (*WorkflowEvidencePackage, error) {
+	if _, err := s.documentRepo.GetForTenant(ctx, tenantID, documentID); err != nil {
+		return nil, ErrDocumentNotFound
+	}
+
+	tasks, err := s.taskRepo.ListByDocument(ctx, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow tasks: %w", err)
+	}
+
+	for i := range tasks {
+		checklist, err := s.checklistRepo.ListByTask(ctx, tasks[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load task checklist: %w", err)
+		}
+		tasks[i].Checklist = checklist
+	}
+
+	return &WorkflowEvidencePackage{
+		DocumentID:  documentID,
+		Tasks:       tasks,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
 // DelegateTask allows a user to delegate their task to another user
-func (s *WorkflowService) DelegateTask(ctx context.Context, taskID, fromUserID, toUserID uuid.UUID, reason string) error {
+func (s *WorkflowService) DelegateTask(ctx context.Context, taskID, tenantID, fromUserID, toUserID uuid.UUID, reason string) error {
 	// Get task
-	task, err := s.taskRepo.GetByID(ctx, taskID)
+	task, err := s.GetTask(ctx, tenantID, taskID)
 	if err != nil {
-		return ErrTaskNotFound
+		return err
 	}
 
 	// Verify authorization
 	if task.AssignedTo != fromUserID {
 		return ErrUnauthorizedTask
 	}
+	if task.Status != models.WorkflowPending {
+		return ErrTaskAlreadyCompleted
+	}
+
+	// Tasks can only go to active users of the same tenant
+	delegate, err := s.userRepo.GetByID(ctx, toUserID)
+	if err != nil || delegate.TenantID != tenantID || !delegate.IsActive {
+		return ErrUserNotFound
+	}
+
+	// A delegate who already votes in the step would get a second vote
+	votes, err := s.holdsStepTask(ctx, task, toUserID)
+	if err != nil {
+		return err
+	}
+	if votes {
+		return ErrDelegateAlreadyVotes
+	}
 
 	// Check if delegation is allowed (would need to check workflow rules)
 	workflow, err := s.workflowRepo.GetByID(ctx, task.WorkflowID)
@@ -305,22 +819,22 @@ func (s *WorkflowService) DelegateTask(ctx context.Context, taskID, fromUserID,
 		return ErrWorkflowNotFound
 	}
 
-	var rules WorkflowRules
-	if err := s.unmarshalRules(workflow.Rules, &rules); err != nil {
+	rules, err := s.versionRules(ctx, workflow, task.WorkflowVersion)
+	if err != nil {
 		return fmt.Errorf("invalid workflow rules: %w", err)
 	}
 
 	// Find the step and check if delegation is allowed
 	canDelegate := false
 	for _, step := range rules.ApprovalSteps {
-		if step.StepNumber == task.Priority { // Assuming priority maps to step number
+		if step.StepNumber == task.StepNumber {
 			canDelegate = step.CanDelegate
 			break
 		}
 	}
 
 	if !canDelegate {
-		return errors.New("delegation not allowed for this task")
+		return ErrDelegationNotAllowed
 	}
 
 	// Update task assignment
@@ -329,6 +843,8 @@ func (s *WorkflowService) DelegateTask(ctx context.Context, taskID, fromUserID,
 		return fmt.Errorf("failed to delegate task: %w", err)
 	}
 
+	s.recordDelegation(ctx, tenantID, task.ID, fromUserID, toUserID, models.DelegationManual, reason)
+
 	// Send notification to new assignee
 	s.sendTaskAssignmentNotification(ctx, task, toUserID)
 
@@ -339,151 +855,395 @@ func (s *WorkflowService) DelegateTask(ctx context.Context, taskID, fromUserID,
 	return nil
 }
 
-// ProcessAutomation handles automated workflow processing
-func (s *WorkflowService) ProcessAutomation(ctx context.Context) error {
-	// This would be called periodically to handle:
-	// 1. Escalations
-	// 2. Auto-completions
-	// 3. Reminders
-	// Implementation would depend on your scheduler (cron, etc.)
+// BulkTaskActionParams applies one decision to several tasks
+type BulkTaskActionParams struct {
+	TenantID   uuid.UUID   `json:"tenant_id"`
+	UserID     uuid.UUID   `json:"user_id"`
+	TaskIDs    []uuid.UUID `json:"task_ids"`
+	Action     string      `json:"action"`      // approve, reject or delegate
+	Comments   string      `json:"comments"`    // Shared by every approval or rejection
+	DelegateTo uuid.UUID   `json:"delegate_to"` // Delegate only
+	Reason     string      `json:"reason"`      // Delegate only
+}
 
-	// Handle escalations
-	if err := s.processEscalations(ctx); err != nil {
-		// Log error but continue
-	}
+// BulkTaskOutcome is the result of a bulk action on one task
+type BulkTaskOutcome struct {
+	TaskID uuid.UUID `json:"task_id"`
+	Err    error     `json:"-"` // nil when the action succeeded
+}
 
-	// Handle auto-completions
-	if err := s.processAutoCompletions(ctx); err != nil {
-		// Log error but continue
-	}
+// BulkTaskActionResult lists the outcome for each task, in request order
+type BulkTaskActionResult struct {
+	Action    string            `json:"action"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Outcomes  []BulkTaskOutcome `json:"outcomes"`
+}
 
-	// Send reminders
-	if err := s.sendReminders(ctx); err != nil {
-		// Log error but continue
+// BulkTaskAction approves, rejects or delegates several tasks. Each task is
+// re-read and checked on its own exactly as a single action would be, so a
+// task that was completed, reassigned or blocked since the caller listed it
+// fails alone without stopping the rest. Tasks left when ctx is cancelled
+// fail with its error.
+func (s *WorkflowService) BulkTaskAction(ctx context.Context, params BulkTaskActionParams) (*BulkTaskActionResult, error) {
+	switch params.Action {
+	case BulkTaskApprove, BulkTaskReject, BulkTaskDelegate:
+	default:
+		return nil, ErrInvalidTaskStatus
 	}
 
-	return nil
-}
-
-// Helper methods
+	// Duplicates would act on the same task twice
+	seen := make(map[uuid.UUID]bool, len(params.TaskIDs))
+	taskIDs := make([]uuid.UUID, 0, len(params.TaskIDs))
+	for _, taskID := range params.TaskIDs {
+		if !seen[taskID] {
+			seen[taskID] = true
+			taskIDs = append(taskIDs, taskID)
+		}
+	}
+	if len(taskIDs) == 0 {
+		return nil, ErrBulkTaskEmpty
+	}
+	if len(taskIDs) > MaxBulkTaskActions {
+		return nil, fmt.Errorf("%w: at most %d", ErrBulkTaskTooLarge, MaxBulkTaskActions)
+	}
 
-func (s *WorkflowService) validateWorkflowRules(rules WorkflowRules) error {
-	// Validate approval steps
-	if len(rules.ApprovalSteps) == 0 {
-		return errors.New("at least one approval step is required")
+	if params.Action == BulkTaskDelegate {
+		delegate, err := s.userRepo.GetByID(ctx, params.DelegateTo)
+		if err != nil || delegate.TenantID != params.TenantID || !delegate.IsActive {
+			return nil, ErrUserNotFound
+		}
 	}
 
-	// Check step numbering
-	stepNumbers := make(map[int]bool)
-	for _, step := range rules.ApprovalSteps {
-		if step.StepNumber <= 0 {
-			return errors.New("step numbers must be positive")
+	result := &BulkTaskActionResult{Action: params.Action, Outcomes: make([]BulkTaskOutcome, 0, len(taskIDs))}
+	for _, taskID := range taskIDs {
+		// Once the request is cancelled the remaining tasks aren't attempted,
+		// but the ones already acted on are still reported
+		err := ctx.Err()
+		switch {
+		case err != nil:
+		case params.Action == BulkTaskDelegate:
+			err = s.DelegateTask(ctx, taskID, params.TenantID, params.UserID, params.DelegateTo, params.Reason)
+		default:
+			err = s.CompleteTask(ctx, taskID, params.TenantID, params.UserID, params.Action, params.Comments)
 		}
-		if stepNumbers[step.StepNumber] {
-			return errors.New("duplicate step numbers not allowed")
+
+		result.Outcomes = append(result.Outcomes, BulkTaskOutcome{TaskID: taskID, Err: err})
+		if err != nil {
+			result.Failed++
+		} else {
+			result.Succeeded++
 		}
-		stepNumbers[step.StepNumber] = true
 	}
 
-	return nil
+	return result, nil
 }
 
-func (s *WorkflowService) shouldTriggerWorkflow(document *models.Document, conditions []TriggerCondition) bool {
-	for _, condition := range conditions {
-		if !s.evaluateCondition(document, condition) {
-			if condition.Mandatory {
-				return false // Mandatory condition not met
-			}
-		}
-	}
-	return true
+// WorkflowAutomationResult summarizes one automation run
+type WorkflowAutomationResult struct {
+	AutoCompleted int `json:"auto_completed"`
+	Escalated     int `json:"escalated"`
+	Reminded      int `json:"reminded"`
+}
+
+// ProcessAutomation applies the workflow rules of every pending task:
+// auto-completion conditions, escalation rules and reminder days. Each action
+// is claimed in the database, so concurrent runs don't repeat it.
+func (s *WorkflowService) ProcessAutomation(ctx context.Context) (*WorkflowAutomationResult, error) {
+	return s.processAutomation(ctx, nil)
+}
+
+// ProcessTenantAutomation runs workflow automation for one tenant's pending tasks
+func (s *WorkflowService) ProcessTenantAutomation(ctx context.Context, tenantID uuid.UUID) (*WorkflowAutomationResult, error) {
+	return s.processAutomation(ctx, &tenantID)
 }
 
-func (s *WorkflowService) evaluateCondition(document *models.Document, condition TriggerCondition) bool {
-	switch condition.Type {
-	case "amount_threshold":
-		if document.Amount == nil {
-			return false
+func (s *WorkflowService) processAutomation(ctx context.Context, tenantID *uuid.UUID) (*WorkflowAutomationResult, error) {
+	result := &WorkflowAutomationResult{}
+	rulesByVersion := make(map[workflowVersionKey]*WorkflowRules)
+	var lastErr error
+
+	afterID := uuid.Nil
+	for {
+		tasks, err := s.taskRepo.ListPending(ctx, tenantID, afterID, workflowAutomationBatchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to list pending tasks: %w", err)
 		}
-		threshold, ok := condition.Value.(float64)
-		if !ok {
-			return false
+		if len(tasks) == 0 {
+			break
 		}
+		afterID = tasks[len(tasks)-1].ID
+
+		now := time.Now()
+		for i := range tasks {
+			task := &tasks[i]
+			rules := s.automationRules(ctx, task, rulesByVersion)
+			if rules == nil {
+				continue // Skip workflow with invalid rules
+			}
 
-		switch condition.Operator {
-		case "gt":
-			return *document.Amount > threshold
-		case "gte":
-			return *document.Amount >= threshold
-		case "lt":
-			return *document.Amount < threshold
-		case "lte":
-			return *document.Amount <= threshold
-		}
+			// Auto-completion ends the task, so nothing else applies to it
+			completed, err := s.processAutoCompletions(ctx, task, rules, now)
+			if err != nil {
+				lastErr = err
+			}
+			if completed {
+				result.AutoCompleted++
+				continue
+			}
 
-	case "vendor_name":
-		vendorName, ok := condition.Value.(string)
-		if !ok {
-			return false
-		}
+			escalated, err := s.processEscalations(ctx, task, rules, now)
+			if err != nil {
+				lastErr = err
+			}
+			if escalated {
+				result.Escalated++
+			}
 
-		switch condition.Operator {
-		case "eq":
-			return document.VendorName == vendorName
-		case "contains":
-			return fmt.Sprintf("%s", document.VendorName) != "" &&
-				fmt.Sprintf("%s", vendorName) != "" &&
-				fmt.Sprintf("%s", document.VendorName) == vendorName
+			reminded, err := s.sendReminders(ctx, task, rules, now)
+			if err != nil {
+				lastErr = err
+			}
+			if reminded {
+				result.Reminded++
+			}
 		}
 
-	case "document_type":
-		docType, ok := condition.Value.(string)
-		if !ok {
-			return false
+		if len(tasks) < workflowAutomationBatchSize {
+			break
 		}
-		return string(document.DocumentType) == docType
 	}
 
-	return false
+	workflowAutomationActions.Add("auto_completed", int64(result.AutoCompleted))
+	workflowAutomationActions.Add("escalated", int64(result.Escalated))
+	workflowAutomationActions.Add("reminded", int64(result.Reminded))
+
+	return result, lastErr
 }
 
-func (s *WorkflowService) initiateWorkflowExecution(ctx context.Context, workflow *models.Workflow, document *models.Document, triggeredBy uuid.UUID) error {
-	var rules WorkflowRules
-	if err := s.unmarshalRules(workflow.Rules, &rules); err != nil {
+// AutomationTask is the scheduled task that runs workflow automation
+func (s *WorkflowService) AutomationTask() ScheduledTask {
+	return ScheduledTask{
+		Name:     JobTypeWorkflowAutomation,
+		Interval: workflowAutomationInterval,
+		Run: func(ctx context.Context) error {
+			_, err := s.ProcessAutomation(ctx)
+			return err
+		},
+	}
+}
+
+// AutomationJob is the tenant job that runs workflow automation for the
+// tenant's pending tasks
+func (s *WorkflowService) AutomationJob() JobHandler {
+	return func(ctx context.Context, tenantID uuid.UUID, params models.JSONB) error {
+		_, err := s.ProcessTenantAutomation(ctx, tenantID)
 		return err
 	}
+}
 
-	// Create tasks for the first step
-	firstSteps := s.getFirstSteps(rules.ApprovalSteps)
+// Helper methods
 
-	for _, step := range firstSteps {
-		// Resolve assignee
-		assigneeID, err := s.resolveAssignee(ctx, document.TenantID, step.AssigneeType, step.AssigneeValue)
-		if err != nil {
-			continue // Skip this step if assignee can't be resolved
+// encodeRules validates workflow rules and converts them to JSONB
+func (s *WorkflowService) encodeRules(rules WorkflowRules) (models.JSONB, error) {
+	if err := s.validateWorkflowRules(rules); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflowRules, err)
+	}
+
+	// Marshal rules to JSON then to map for JSONB
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workflow rules: %w", err)
+	}
+
+	var rulesMap models.JSONB
+	if err := json.Unmarshal(rulesJSON, &rulesMap); err != nil {
+		return nil, fmt.Errorf("failed to convert workflow rules to JSONB: %w", err)
+	}
+	return rulesMap, nil
+}
+
+func (s *WorkflowService) validateWorkflowRules(rules WorkflowRules) error {
+	// Validate approval steps
+	if len(rules.ApprovalSteps) == 0 {
+		return errors.New("at least one approval step is required")
+	}
+
+	// Check step numbering
+	stepNumbers := make(map[int]bool)
+	for _, step := range rules.ApprovalSteps {
+		if step.StepNumber <= 0 {
+			return errors.New("step numbers must be positive")
+		}
+		if stepNumbers[step.StepNumber] {
+			return errors.New("duplicate step numbers not allowed")
 		}
+		stepNumbers[step.StepNumber] = true
+
+		if step.RequiredVotes < 0 {
+			return fmt.Errorf("step %d: required votes can't be negative", step.StepNumber)
+		}
+
+		if err := validateChecklistStep(step); err != nil {
+			return err
+		}
+
+		for _, condition := range step.Conditions {
+			if err := ValidateCondition(condition); err != nil {
+				return fmt.Errorf("step %d: %w", step.StepNumber, err)
+			}
+		}
+	}
+
+	// Some step must run whatever the document, or a document matching no
+	// branch would have nobody to approve it
+	if hasStepConditions(rules.ApprovalSteps) && !hasDefaultStep(rules.ApprovalSteps) {
+		return errors.New("conditional steps need at least one step without conditions as the default branch")
+	}
 
-		// Calculate due date
-		dueDate := time.Now().AddDate(0, 0, step.DueDays)
+	for _, condition := range rules.TriggerConditions {
+		if err := ValidateCondition(condition); err != nil {
+			return fmt.Errorf("trigger condition: %w", err)
+		}
+	}
 
-		// Create task
-		task := &models.WorkflowTask{
-			ID:         uuid.New(),
-			WorkflowID: workflow.ID,
-			DocumentID: document.ID,
-			AssignedTo: assigneeID,
-			TaskType:   step.Name,
-			Status:     models.WorkflowPending,
-			Priority:   step.StepNumber,
-			DueDate:    &dueDate,
+	if rules.ApprovalStamp != nil {
+		if err := rules.ApprovalStamp.Validate(); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
-		if err := s.taskRepo.Create(ctx, task); err != nil {
-			return fmt.Errorf("failed to create workflow task: %w", err)
+func validateChecklistStep(step ApprovalStep) error {
+	switch step.StepType {
+	case "", StepTypeApproval:
+		if len(step.ChecklistItems) > 0 {
+			return fmt.Errorf("step %d: checklist items require step_type %q", step.StepNumber, StepTypeChecklist)
 		}
+		return nil
+	case StepTypeChecklist:
+	default:
+		return fmt.Errorf("step %d: unknown step type %q", step.StepNumber, step.StepType)
+	}
 
-		// Send assignment notification
-		s.sendTaskAssignmentNotification(ctx, task, assigneeID)
+	if len(step.ChecklistItems) == 0 {
+		return fmt.Errorf("step %d: checklist steps need at least one item", step.StepNumber)
+	}
+
+	keys := make(map[string]bool, len(step.ChecklistItems))
+	for _, item := range step.ChecklistItems {
+		if item.Key == "" || len(item.Key) > 100 || item.Label == "" || len(item.Label) > 255 {
+			return fmt.Errorf("step %d: checklist items need a key and label", step.StepNumber)
+		}
+		if keys[item.Key] {
+			return fmt.Errorf("step %d: duplicate checklist item %q", step.StepNumber, item.Key)
+		}
+		keys[item.Key] = true
+	}
+
+	return nil
+}
+
+func (s *WorkflowService) initiateWorkflowExecution(ctx context.Context, workflow *models.Workflow, document *models.Document, triggeredBy uuid.UUID) (*models.WorkflowInstance, error) {
+	var rules WorkflowRules
+	if err := s.unmarshalRules(workflow.Rules, &rules); err != nil {
+		return nil, err
+	}
+
+	firstSteps := s.getFirstSteps(ApplicableSteps(document, rules.ApprovalSteps))
+
+	instance := &models.WorkflowInstance{
+		ID:         uuid.New(),
+		TenantID:   document.TenantID,
+		WorkflowID: workflow.ID,
+		DocumentID: document.ID,
+		Status:     models.WorkflowPending,
+		StartedBy:  triggeredBy,
+		StartedAt:  time.Now(),
+
+		WorkflowVersion: workflow.Version,
+	}
+	// Rules saved before a default branch was required can match no step.
+	// Such instances stay pending without tasks rather than approving a
+	// document nobody reviewed.
+	if len(firstSteps) > 0 {
+		instance.CurrentStep = firstSteps[0].StepNumber
+	}
+	if err := s.instanceRepo.Create(ctx, instance); err != nil {
+		return nil, fmt.Errorf("failed to create workflow instance: %w", err)
+	}
+
+	// Create tasks for the first step
+	if err := s.createStepTasks(ctx, workflow.ID, document.ID, document.TenantID, &instance.ID, instance.WorkflowVersion, firstSteps, rules.NotificationSettings.ReminderDays); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// createStepTasks creates the tasks of one workflow step. Steps that need
+// several votes get a parallel task per eligible approver; all tasks share a
+// step group so their votes can be counted together. Each task's first
+// reminder is scheduled from reminderDays.
+func (s *WorkflowService) createStepTasks(ctx context.Context, workflowID, documentID, tenantID uuid.UUID, instanceID *uuid.UUID, version int, steps []ApprovalStep, reminderDays []int) error {
+	groupID := uuid.New()
+	calendar := s.calendarFor(ctx, tenantID)
+
+	for _, step := range steps {
+		assignees, err := s.resolveStepAssignees(ctx, tenantID, step)
+		if err != nil {
+			continue // Skip this step if assignee can't be resolved
+		}
+
+		// Calculate due date in business days
+		now := time.Now()
+		dueDate := calendar.AddBusinessDays(now, step.DueDays)
+		nextReminder := nextReminderAt(calendar, &dueDate, reminderDays, now)
+
+		// Approvers who are away hand their task to their delegate
+		handovers := s.outOfOfficeHandovers(ctx, tenantID, assignees, now)
+
+		for _, approverID := range assignees {
+			assigneeID := approverID
+			handover, away := handovers[approverID]
+			if away {
+				assigneeID = handover.DelegateID
+			}
+
+			task := &models.WorkflowTask{
+				ID:          uuid.New(),
+				WorkflowID:  workflowID,
+				DocumentID:  documentID,
+				AssignedTo:  assigneeID,
+				TaskType:    step.Name,
+				Status:      models.WorkflowPending,
+				StepNumber:  step.StepNumber,
+				Priority:    step.StepNumber,
+				DueDate:     &dueDate,
+				InstanceID:  instanceID,
+				StepGroupID: &groupID,
+
+				WorkflowVersion: version,
+
+				NextReminderAt: nextReminder,
+			}
+
+			if err := s.taskRepo.Create(ctx, task); err != nil {
+				return fmt.Errorf("failed to create workflow task: %w", err)
+			}
+
+			if away {
+				s.recordDelegation(ctx, tenantID, task.ID, approverID, assigneeID, models.DelegationOutOfOffice, handover.Note)
+			}
+
+			if err := s.createChecklistItems(ctx, task, tenantID, step); err != nil {
+				return err
+			}
+
+			// Send assignment notification
+			s.sendTaskAssignmentNotification(ctx, task, assigneeID)
+		}
 	}
 
 	return nil
@@ -558,58 +1318,230 @@ func (s *WorkflowService) resolveAssignee(ctx context.Context, tenantID uuid.UUI
 	return uuid.Nil, errors.New("assignee not found")
 }
 
-func (s *WorkflowService) handleWorkflowProgression(ctx context.Context, completedTask *models.WorkflowTask, action string) error {
-	if action == "reject" {
-		// Workflow is rejected, no further steps
-		return s.completeWorkflow(ctx, completedTask.DocumentID, "rejected")
+// resolveStepAssignees returns the approvers of a step: a single assignee,
+// or every eligible user when the step needs more than one vote. A "user"
+// step lists its approvers comma-separated.
+func (s *WorkflowService) resolveStepAssignees(ctx context.Context, tenantID uuid.UUID, step ApprovalStep) ([]uuid.UUID, error) {
+	if step.RequiredVotes <= 1 {
+		assigneeID, err := s.resolveAssignee(ctx, tenantID, step.AssigneeType, step.AssigneeValue)
+		if err != nil {
+			return nil, err
+		}
+		return []uuid.UUID{assigneeID}, nil
+	}
+
+	var assignees []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	add := func(userID uuid.UUID) {
+		if !seen[userID] {
+			seen[userID] = true
+			assignees = append(assignees, userID)
+		}
+	}
+
+	switch step.AssigneeType {
+	case "user":
+		for _, value := range strings.Split(step.AssigneeValue, ",") {
+			if userID, err := s.resolveAssignee(ctx, tenantID, "user", strings.TrimSpace(value)); err == nil {
+				add(userID)
+			}
+		}
+
+	case "role":
+		users, err := s.userRepo.ListByRole(ctx, tenantID, models.UserRole(step.AssigneeValue))
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			if user.IsActive {
+				add(user.ID)
+			}
+		}
+
+	case "department":
+		users, _, err := s.userRepo.ListByTenant(ctx, tenantID, repositories.ListParams{})
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			if user.IsActive && user.Department == step.AssigneeValue {
+				add(user.ID)
+			}
+		}
 	}
 
+	if len(assignees) == 0 {
+		return nil, errors.New("assignee not found")
+	}
+	return assignees, nil
+}
+
+func (s *WorkflowService) handleWorkflowProgression(ctx context.Context, completedTask *models.WorkflowTask, action string) error {
 	// Get workflow
 	workflow, err := s.workflowRepo.GetByID(ctx, completedTask.WorkflowID)
 	if err != nil {
 		return err
 	}
 
-	var rules WorkflowRules
-	if err := s.unmarshalRules(workflow.Rules, &rules); err != nil {
+	rules, err := s.versionRules(ctx, workflow, completedTask.WorkflowVersion)
+	if err != nil {
+		return err
+	}
+
+	outcome, err := s.evaluateStep(ctx, completedTask, rules, action)
+	if err != nil {
 		return err
 	}
 
-	// Check if there are next steps
-	nextSteps := s.getNextSteps(rules.ApprovalSteps, completedTask.Priority)
+	switch outcome {
+	case stepPending:
+		// Waiting for more votes
+		return nil
+	case stepRejected:
+		// Workflow is rejected, no further steps
+		s.cancelStepGroup(ctx, completedTask, "", "Step rejected")
+		return s.completeWorkflow(ctx, completedTask, "rejected", rules)
+	}
+
+	s.cancelStepGroup(ctx, completedTask, "", "Step approved")
+
+	// Check if there are next steps, skipping branches that don't apply
+	steps := rules.ApprovalSteps
+	if hasStepConditions(steps) {
+		// Tasks only preload a few document columns; conditions need the rest
+		document, err := s.documentRepo.GetByID(ctx, completedTask.DocumentID)
+		if err != nil {
+			return fmt.Errorf("failed to get document: %w", err)
+		}
+		steps = ApplicableSteps(document, steps)
+	}
+	nextSteps := s.getNextSteps(steps, completedTask.StepNumber)
 	if len(nextSteps) == 0 {
 		// No more steps, workflow is completed
-		return s.completeWorkflow(ctx, completedTask.DocumentID, "approved")
+		return s.completeWorkflow(ctx, completedTask, "approved", rules)
 	}
 
-	// Create tasks for next steps
-	for _, step := range nextSteps {
-		assigneeID, err := s.resolveAssignee(ctx, completedTask.Document.TenantID, step.AssigneeType, step.AssigneeValue)
+	// Concurrent final votes both see the quorum; only the one that moves
+	// the instance off this step creates the next step's tasks
+	if completedTask.InstanceID != nil {
+		advanced, err := s.instanceRepo.AdvanceStep(ctx, *completedTask.InstanceID, completedTask.StepNumber, nextSteps[0].StepNumber)
 		if err != nil {
-			continue
+			return err
 		}
+		if !advanced {
+			return nil
+		}
+	}
+
+	// Create tasks for next steps
+	return s.createStepTasks(ctx, workflow.ID, completedTask.DocumentID, completedTask.Document.TenantID, completedTask.InstanceID, completedTask.WorkflowVersion, nextSteps, rules.NotificationSettings.ReminderDays)
+}
 
-		dueDate := time.Now().AddDate(0, 0, step.DueDays)
+// Step outcomes
+const (
+	stepPending  = "pending"
+	stepApproved = "approved"
+	stepRejected = "rejected"
+)
 
-		task := &models.WorkflowTask{
-			ID:         uuid.New(),
-			WorkflowID: workflow.ID,
-			DocumentID: completedTask.DocumentID,
-			AssignedTo: assigneeID,
-			TaskType:   step.Name,
-			Status:     models.WorkflowPending,
-			Priority:   step.StepNumber,
-			DueDate:    &dueDate,
+// evaluateStep counts the votes of the completed task's step. Each step
+// definition is approved once its required votes are in and rejected as soon
+// as that is out of reach, including when fewer approvers were resolved than
+// votes required. Optional definitions never block the step.
+func (s *WorkflowService) evaluateStep(ctx context.Context, completedTask *models.WorkflowTask, rules WorkflowRules, action string) (string, error) {
+	if completedTask.StepGroupID == nil {
+		// Tasks created before step groups were single-approver
+		if action == "reject" {
+			return stepRejected, nil
 		}
+		return stepApproved, nil
+	}
+
+	tasks, err := s.taskRepo.ListByStepGroup(ctx, *completedTask.StepGroupID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load step tasks: %w", err)
+	}
 
-		if err := s.taskRepo.Create(ctx, task); err != nil {
+	outcome := stepApproved
+	for _, step := range rules.ApprovalSteps {
+		if step.StepNumber != completedTask.StepNumber {
 			continue
 		}
 
-		s.sendTaskAssignmentNotification(ctx, task, assigneeID)
+		var total, approvals, rejections int
+		for _, task := range tasks {
+			if task.TaskType != step.Name {
+				continue
+			}
+			total++
+			switch task.Status {
+			case models.WorkflowApproved:
+				approvals++
+			case models.WorkflowRejected:
+				rejections++
+			}
+		}
+		if total == 0 {
+			continue // No approver could be resolved for this definition
+		}
+
+		required := step.RequiredVotes
+		if required < 1 {
+			required = 1
+		}
+
+		switch {
+		case approvals >= required:
+			// Remaining votes are no longer needed
+			s.cancelStepGroup(ctx, completedTask, step.Name, "Quorum reached")
+		case total-rejections < required:
+			if !step.IsOptional {
+				return stepRejected, nil
+			}
+		case !step.IsOptional:
+			outcome = stepPending
+		}
 	}
 
-	return nil
+	return outcome, nil
+}
+
+// isSharedStep reports whether a task's step is voted on by several approvers
+func (s *WorkflowService) isSharedStep(ctx context.Context, task *models.WorkflowTask) (bool, error) {
+	if task.StepGroupID == nil {
+		return false, nil
+	}
+	tasks, err := s.taskRepo.ListByStepGroup(ctx, *task.StepGroupID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load step tasks: %w", err)
+	}
+	return len(tasks) > 1, nil
+}
+
+// holdsStepTask reports whether a user is assigned another task in the task's step
+func (s *WorkflowService) holdsStepTask(ctx context.Context, task *models.WorkflowTask, userID uuid.UUID) (bool, error) {
+	if task.StepGroupID == nil {
+		return false, nil
+	}
+	tasks, err := s.taskRepo.ListByStepGroup(ctx, *task.StepGroupID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load step tasks: %w", err)
+	}
+	for _, other := range tasks {
+		if other.ID != task.ID && other.AssignedTo == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *WorkflowService) cancelStepGroup(ctx context.Context, task *models.WorkflowTask, taskType, reason string) {
+	if task.StepGroupID == nil {
+		return
+	}
+	if _, err := s.taskRepo.CancelPendingInGroup(ctx, *task.StepGroupID, taskType, reason); err != nil {
+		// Log but don't fail - leftover tasks can still be completed by hand
+	}
 }
 
 func (s *WorkflowService) getNextSteps(steps []ApprovalStep, currentStep int) []ApprovalStep {
@@ -638,7 +1570,7 @@ func (s *WorkflowService) getNextSteps(steps []ApprovalStep, currentStep int) []
 	return nextSteps
 }
 
-func (s *WorkflowService) completeWorkflow(ctx context.Context, documentID uuid.UUID, result string) error {
+func (s *WorkflowService) completeWorkflow(ctx context.Context, task *models.WorkflowTask, result string, rules WorkflowRules) error {
 	// Update document status based on workflow result
 	var newStatus models.DocStatus
 	switch result {
@@ -650,31 +1582,392 @@ func (s *WorkflowService) completeWorkflow(ctx context.Context, documentID uuid.
 		newStatus = models.DocStatusCompleted
 	}
 
-	return s.documentRepo.UpdateStatus(ctx, documentID, newStatus)
+	if task.InstanceID != nil {
+		instanceStatus := models.WorkflowApproved
+		if result == "rejected" {
+			instanceStatus = models.WorkflowRejected
+		}
+		completed, err := s.instanceRepo.Complete(ctx, *task.InstanceID, instanceStatus)
+		if err != nil {
+			return err
+		}
+		if !completed {
+			return nil // Another vote already settled the instance
+		}
+	}
+
+	if err := s.documentRepo.UpdateStatus(ctx, task.DocumentID, newStatus); err != nil {
+		return err
+	}
+
+	if result == "approved" && rules.ApprovalStamp != nil && rules.ApprovalStamp.Enabled && s.approvalStamps != nil {
+		approvedAt := time.Now()
+		if task.CompletedAt != nil {
+			approvedAt = *task.CompletedAt
+		}
+		if _, err := s.approvalStamps.StampApproval(ctx, task.DocumentID, task.AssignedTo, approvedAt, *rules.ApprovalStamp); err != nil {
+			// Log but continue - the approval stands without its stamp
+			s.createAuditLog(ctx, task.Document.TenantID, task.AssignedTo, task.WorkflowID, models.AuditUpdate,
+				fmt.Sprintf("Approval stamp not applied: %v", err))
+		}
+	}
+
+	if s.events != nil {
+		s.events.Publish(ctx, task.Document.TenantID, RealtimeEventDocumentStatusChanged, map[string]interface{}{
+			"document_id": task.DocumentID.String(),
+			"status":      string(newStatus),
+			"workflow_id": task.WorkflowID.String(),
+		})
+	}
+	return nil
 }
 
-func (s *WorkflowService) unmarshalRules(jsonRules models.JSONB, rules *WorkflowRules) error {
-	// This would unmarshal the JSONB rules into the WorkflowRules struct
-	// Implementation depends on your JSON marshaling approach
-	return nil // Placeholder
+// versionRules returns the rules of one version of the workflow, reading
+// older versions from their snapshot
+func (s *WorkflowService) versionRules(ctx context.Context, workflow *models.Workflow, version int) (WorkflowRules, error) {
+	raw := workflow.Rules
+	if version != workflow.Version {
+		snapshot, err := s.workflowRepo.GetVersion(ctx, workflow.ID, version)
+		if err != nil {
+			return WorkflowRules{}, fmt.Errorf("%w: %d", ErrWorkflowVersionGone, version)
+		}
+		raw = snapshot.Rules
+	}
+
+	var rules WorkflowRules
+	if err := s.unmarshalRules(raw, &rules); err != nil {
+		return WorkflowRules{}, err
+	}
+	return rules, nil
 }
 
-func (s *WorkflowService) processEscalations(ctx context.Context) error {
-	// Get overdue tasks and escalate them according to workflow rules
-	// Implementation would query overdue tasks and apply escalation rules
+func (s *WorkflowService) unmarshalRules(jsonRules models.JSONB, rules *WorkflowRules) error {
+	data, err := json.Marshal(jsonRules)
+	if err != nil {
+		return fmt.Errorf("failed to marshal workflow rules: %w", err)
+	}
+	if err := json.Unmarshal(data, rules); err != nil {
+		return fmt.Errorf("failed to unmarshal workflow rules: %w", err)
+	}
 	return nil
 }
 
-func (s *WorkflowService) processAutoCompletions(ctx context.Context) error {
-	// Check for tasks that meet auto-completion conditions
+// buildTimelineSteps lays the instance's tasks out over the workflow's steps
+func buildTimelineSteps(instance *models.WorkflowInstance, approvalSteps []ApprovalStep, tasks []models.WorkflowTask) []WorkflowTimelineStep {
+	byNumber := make(map[int]*WorkflowTimelineStep)
+	var numbers []int
+	for _, step := range approvalSteps {
+		entry, ok := byNumber[step.StepNumber]
+		if !ok {
+			entry = &WorkflowTimelineStep{StepNumber: step.StepNumber, Tasks: []models.WorkflowTask{}}
+			byNumber[step.StepNumber] = entry
+			numbers = append(numbers, step.StepNumber)
+		}
+		entry.Names = append(entry.Names, step.Name)
+	}
+
+	for _, task := range tasks {
+		entry, ok := byNumber[task.StepNumber]
+		if !ok {
+			// The workflow's steps were edited after this run started
+			entry = &WorkflowTimelineStep{StepNumber: task.StepNumber, Names: []string{task.TaskType}}
+			byNumber[task.StepNumber] = entry
+			numbers = append(numbers, task.StepNumber)
+		}
+		entry.Tasks = append(entry.Tasks, task)
+
+		if entry.StartedAt == nil || task.CreatedAt.Before(*entry.StartedAt) {
+			createdAt := task.CreatedAt
+			entry.StartedAt = &createdAt
+		}
+		if task.CompletedAt != nil && (entry.CompletedAt == nil || task.CompletedAt.After(*entry.CompletedAt)) {
+			entry.CompletedAt = task.CompletedAt
+		}
+	}
+
+	sort.Ints(numbers)
+	steps := make([]WorkflowTimelineStep, 0, len(numbers))
+	for _, number := range numbers {
+		entry := byNumber[number]
+		switch {
+		case len(entry.Tasks) == 0 && (number < instance.CurrentStep || instance.Status != models.WorkflowPending):
+			entry.Status = TimelineStepSkipped
+		case len(entry.Tasks) == 0:
+			entry.Status = TimelineStepUpcoming
+		case number < instance.CurrentStep:
+			entry.Status = TimelineStepApproved
+		case number > instance.CurrentStep:
+			entry.Status = TimelineStepUpcoming
+		case instance.Status == models.WorkflowPending:
+			entry.Status = TimelineStepActive
+			entry.CompletedAt = nil
+		case instance.Status == models.WorkflowApproved:
+			entry.Status = TimelineStepApproved
+		default:
+			entry.Status = TimelineStepRejected
+		}
+		steps = append(steps, *entry)
+	}
+	return steps
+}
+
+// createChecklistItems seeds the task's checklist from the step definition
+func (s *WorkflowService) createChecklistItems(ctx context.Context, task *models.WorkflowTask, tenantID uuid.UUID, step ApprovalStep) error {
+	if step.StepType != StepTypeChecklist {
+		return nil
+	}
+
+	items := make([]models.WorkflowChecklistItem, 0, len(step.ChecklistItems))
+	for i, definition := range step.ChecklistItems {
+		items = append(items, models.WorkflowChecklistItem{
+			TenantID:   tenantID,
+			WorkflowID: task.WorkflowID,
+			TaskID:     task.ID,
+			ItemKey:    definition.Key,
+			Label:      definition.Label,
+			Required:   !definition.Optional,
+			Position:   i,
+		})
+	}
+
+	if err := s.checklistRepo.CreateBatch(ctx, items); err != nil {
+		return fmt.Errorf("failed to create task checklist: %w", err)
+	}
 	return nil
 }
 
-func (s *WorkflowService) sendReminders(ctx context.Context) error {
-	// Send reminder notifications for tasks approaching due dates
+func (s *WorkflowService) ensureChecklistComplete(ctx context.Context, taskID uuid.UUID) error {
+	items, err := s.checklistRepo.ListByTask(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to load task checklist: %w", err)
+	}
+
+	for _, item := range items {
+		if item.Required && !item.IsChecked {
+			return ErrChecklistIncomplete
+		}
+	}
 	return nil
 }
 
+// workflowVersionKey identifies one rules version of a workflow
+type workflowVersionKey struct {
+	workflowID uuid.UUID
+	version    int
+}
+
+// automationRules returns the rules of the task's workflow version, decoding
+// each version once per run
+func (s *WorkflowService) automationRules(ctx context.Context, task *models.WorkflowTask, cache map[workflowVersionKey]*WorkflowRules) *WorkflowRules {
+	key := workflowVersionKey{workflowID: task.WorkflowID, version: task.WorkflowVersion}
+	if rules, ok := cache[key]; ok {
+		return rules
+	}
+
+	var rules *WorkflowRules
+	if decoded, err := s.versionRules(ctx, &task.Workflow, task.WorkflowVersion); err == nil {
+		rules = &decoded
+	}
+	cache[key] = rules
+	return rules
+}
+
+// processAutoCompletions approves or rejects a task once a "time_passed"
+// condition's number of days has elapsed since it was created. Approval
+// still requires a complete checklist.
+func (s *WorkflowService) processAutoCompletions(ctx context.Context, task *models.WorkflowTask, rules *WorkflowRules, now time.Time) (bool, error) {
+	for _, condition := range rules.AutoCompleteConditions {
+		if condition.Type != "time_passed" || (condition.Action != "approve" && condition.Action != "reject") {
+			continue
+		}
+		days, ok := condition.Value.(float64)
+		if !ok || days <= 0 || now.Before(task.CreatedAt.Add(time.Duration(days*float64(24*time.Hour)))) {
+			continue
+		}
+
+		outcome := "approved"
+		if condition.Action == "reject" {
+			outcome = "rejected"
+		}
+		comments := fmt.Sprintf("Automatically %s after %g days without a decision", outcome, days)
+
+		// The assignee stays the recorded actor; the comment marks it as automatic
+		if err := s.finishTask(ctx, task, task.AssignedTo, condition.Action, comments); err != nil {
+			if errors.Is(err, ErrChecklistIncomplete) {
+				continue
+			}
+			return false, fmt.Errorf("failed to auto-complete task %s: %w", task.ID, err)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// processEscalations reassigns a task whose step has an escalation rule once
+// the rule's number of business days has passed since the task was created
+func (s *WorkflowService) processEscalations(ctx context.Context, task *models.WorkflowTask, rules *WorkflowRules, now time.Time) (bool, error) {
+	if task.EscalatedAt != nil {
+		return false, nil
+	}
+
+	for _, rule := range rules.EscalationRules {
+		if rule.StepNumber != task.StepNumber || rule.EscalationDays <= 0 {
+			continue
+		}
+		tenantID := task.Workflow.TenantID
+		if now.Before(s.calendarFor(ctx, tenantID).AddBusinessDays(task.CreatedAt, rule.EscalationDays)) {
+			return false, nil
+		}
+
+		escalateTo, err := s.resolveEscalationTarget(ctx, tenantID, task.AssignedTo, rule)
+		if err != nil {
+			return false, fmt.Errorf("failed to resolve escalation for task %s: %w", task.ID, err)
+		}
+		if escalateTo == task.AssignedTo {
+			return false, nil
+		}
+		if votes, err := s.holdsStepTask(ctx, task, escalateTo); err != nil || votes {
+			return false, err // The target would get a second vote in the step
+		}
+
+		original := task.AssignedTo
+		escalated, err := s.taskRepo.Escalate(ctx, task.ID, original, escalateTo)
+		if err != nil || !escalated {
+			return false, err
+		}
+
+		task.AssignedTo = escalateTo
+		task.EscalatedAt = &now
+		task.EscalatedFrom = &original
+
+		if s.notificationService != nil {
+			s.notificationService.SendTaskEscalation(ctx, task, escalateTo)
+			if rule.NotifyOriginal {
+				s.notificationService.SendTaskEscalatedAway(ctx, task, original)
+			}
+		}
+
+		s.createAuditLog(ctx, tenantID, original, task.DocumentID, models.AuditUpdate,
+			fmt.Sprintf("Task escalated to user %s after %d business days", escalateTo.String(), rule.EscalationDays))
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// sendReminders reminds the assignee on each of the workflow's reminder days,
+// counted in business days before the due date. Only the most recent reminder day that has passed is
+// sent; the task's next reminder is then scheduled after now.
+func (s *WorkflowService) sendReminders(ctx context.Context, task *models.WorkflowTask, rules *WorkflowRules, now time.Time) (bool, error) {
+	if task.DueDate == nil || len(rules.NotificationSettings.ReminderDays) == 0 {
+		return false, nil
+	}
+
+	calendar := s.calendarFor(ctx, task.Workflow.TenantID)
+	remindAt := task.NextReminderAt
+	if remindAt == nil {
+		// Tasks created before reminders were scheduled derive theirs from
+		// the last reminder sent, skipping days that fell before the task existed
+		after := task.CreatedAt
+		if task.LastReminderAt != nil && task.LastReminderAt.After(after) {
+			after = *task.LastReminderAt
+		}
+		remindAt = nextReminderAt(calendar, task.DueDate, rules.NotificationSettings.ReminderDays, after)
+	}
+	if remindAt == nil || remindAt.After(now) {
+		return false, nil
+	}
+
+	next := nextReminderAt(calendar, task.DueDate, rules.NotificationSettings.ReminderDays, now)
+	claimed, err := s.taskRepo.ClaimReminder(ctx, task.ID, *remindAt, next)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if s.notificationService != nil {
+		if err := s.notificationService.SendTaskReminder(ctx, task); err != nil {
+			return false, fmt.Errorf("failed to send reminder for task %s: %w", task.ID, err)
+		}
+	}
+	return true, nil
+}
+
+// nextReminderAt returns the first reminder after the given time, reminders
+// falling the given numbers of business days before the due date, or nil if
+// none is left
+func nextReminderAt(calendar *BusinessCalendar, dueDate *time.Time, reminderDays []int, after time.Time) *time.Time {
+	if dueDate == nil {
+		return nil
+	}
+
+	var next *time.Time
+	for _, days := range reminderDays {
+		if days < 0 {
+			continue
+		}
+		at := calendar.AddBusinessDays(*dueDate, -days)
+		if !at.After(after) {
+			continue
+		}
+		if next == nil || at.Before(*next) {
+			next = &at
+		}
+	}
+	return next
+}
+
+// calendarFor returns the calendar the tenant's deadlines are counted on.
+// Without a calendar provider, or when the tenant's calendar can't be read,
+// that is the default Monday to Friday one.
+func (s *WorkflowService) calendarFor(ctx context.Context, tenantID uuid.UUID) *BusinessCalendar {
+	if s.calendars == nil {
+		return DefaultBusinessCalendar()
+	}
+	calendar, err := s.calendars.GetCalendar(ctx, tenantID)
+	if err != nil {
+		return DefaultBusinessCalendar()
+	}
+	return calendar
+}
+
+// resolveEscalationTarget finds the user a task escalates to
+func (s *WorkflowService) resolveEscalationTarget(ctx context.Context, tenantID, assigneeID uuid.UUID, rule EscalationRule) (uuid.UUID, error) {
+	if rule.EscalateToType != "manager" {
+		return s.resolveAssignee(ctx, tenantID, rule.EscalateToType, rule.EscalateToValue)
+	}
+
+	// Walk up the assignee's reporting line to the first active manager
+	current, err := s.userRepo.GetByID(ctx, assigneeID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for depth := 0; current.ManagerID != nil && depth < maxReportingDepth; depth++ {
+		manager, err := s.userRepo.GetByID(ctx, *current.ManagerID)
+		if err != nil || manager.TenantID != tenantID {
+			break
+		}
+		if manager.IsActive && manager.ID != assigneeID {
+			return manager.ID, nil
+		}
+		current = manager
+	}
+
+	// Without a reporting line the task goes to a tenant admin
+	admins, err := s.userRepo.ListByRole(ctx, tenantID, models.UserRoleAdmin)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, admin := range admins {
+		if admin.ID != assigneeID && admin.IsActive {
+			return admin.ID, nil
+		}
+	}
+
+	return uuid.Nil, errors.New("no manager to escalate to")
+}
+
 func (s *WorkflowService) sendTaskAssignmentNotification(ctx context.Context, task *models.WorkflowTask, userID uuid.UUID) {
 	// Implementation would send notification to the assigned user
 	if s.notificationService != nil {
@@ -700,7 +1993,7 @@ func (s *WorkflowService) createAuditLog(ctx context.Context, tenantID, userID,
 	}
 
 	go func() {
-		s.auditRepo.Create(context.Background(), log)
+		s.auditRepo.Create(context.WithoutCancel(ctx), log)
 	}()
 }
 
@@ -710,4 +2003,5 @@ type NotificationService interface {
 	SendTaskCompletion(ctx context.Context, task *models.WorkflowTask, completedBy uuid.UUID, action string) error
 	SendTaskReminder(ctx context.Context, task *models.WorkflowTask) error
 	SendTaskEscalation(ctx context.Context, task *models.WorkflowTask, escalatedTo uuid.UUID) error
+	SendTaskEscalatedAway(ctx context.Context, task *models.WorkflowTask, previousAssignee uuid.UUID) error
 }
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextReminderAt(t *testing.T) {
+	due := time.Date(2024, time.March, 20, 17, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		calendar *BusinessCalendar
+		dueDate  *time.Time
+		days     []int
+		after    time.Time
+		expected *time.Time
+	}{
+		{
+			name:     "no due date",
+			dueDate:  nil,
+			days:     []int{1},
+			after:    due.AddDate(0, 0, -5),
+			expected: nil,
+		},
+		{
+			name:     "negative days are skipped",
+			dueDate:  &due,
+			days:     []int{-1, -3},
+			after:    due.AddDate(0, 0, -5),
+			expected: nil,
+		},
+		{
+			name:     "every day already passed",
+			dueDate:  &due,
+			days:     []int{3, 2, 1},
+			after:    due.AddDate(0, 0, -1),
+			expected: nil,
+		},
+		{
+			name:     "earliest future day is chosen",
+			dueDate:  &due,
+			days:     []int{1, 3, 7},
+			after:    due.AddDate(0, 0, -5),
+			expected: timePtr(due.AddDate(0, 0, -3)),
+		},
+		{
+			name:     "negative days ignored among valid ones",
+			dueDate:  &due,
+			days:     []int{-2, 1},
+			after:    due.AddDate(0, 0, -5),
+			expected: timePtr(due.AddDate(0, 0, -1)),
+		},
+		{
+			// Wednesday the 20th; three business days earlier skips the weekend
+			name:     "business days before the due date",
+			calendar: DefaultBusinessCalendar(),
+			dueDate:  &due,
+			days:     []int{3},
+			after:    due.AddDate(0, 0, -10),
+			expected: timePtr(time.Date(2024, time.March, 15, 17, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar := tt.calendar
+			if calendar == nil {
+				calendar = everyDayCalendar()
+			}
+			next := nextReminderAt(calendar, tt.dueDate, tt.days, tt.after)
+			if tt.expected == nil {
+				assert.Nil(t, next)
+				return
+			}
+			require.NotNil(t, next)
+			assert.True(t, tt.expected.Equal(*next), "expected %s, got %s", tt.expected, next)
+		})
+	}
+}
+
+// everyDayCalendar counts every day as a business day, in UTC
+func everyDayCalendar() *BusinessCalendar {
+	calendar := &BusinessCalendar{
+		Timezone: "UTC",
+		Workdays: []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+	}
+	calendar.prepare()
+	return calendar
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestBulkTaskActionGuardrails(t *testing.T) {
+	service := &WorkflowService{}
+	ctx := context.Background()
+
+	_, err := service.BulkTaskAction(ctx, BulkTaskActionParams{Action: "archive", TaskIDs: []uuid.UUID{uuid.New()}})
+	assert.ErrorIs(t, err, ErrInvalidTaskStatus)
+
+	_, err = service.BulkTaskAction(ctx, BulkTaskActionParams{Action: BulkTaskApprove})
+	assert.ErrorIs(t, err, ErrBulkTaskEmpty)
+
+	tooMany := make([]uuid.UUID, MaxBulkTaskActions+1)
+	for i := range tooMany {
+		tooMany[i] = uuid.New()
+	}
+	_, err = service.BulkTaskAction(ctx, BulkTaskActionParams{Action: BulkTaskReject, TaskIDs: tooMany})
+	assert.ErrorIs(t, err, ErrBulkTaskTooLarge)
+
+	// Repeated IDs count once
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	repeated := make([]uuid.UUID, MaxBulkTaskActions+1)
+	for i := range repeated {
+		repeated[i] = tooMany[0]
+	}
+	result, err := service.BulkTaskAction(cancelled, BulkTaskActionParams{Action: BulkTaskApprove, TaskIDs: repeated})
+	require.NoError(t, err)
+	require.Len(t, result.Outcomes, 1)
+	assert.Equal(t, 1, result.Failed)
+	assert.ErrorIs(t, result.Outcomes[0].Err, context.Canceled)
+}
+
+func TestValidateApprovalStamp(t *testing.T) {
+	service := &WorkflowService{}
+	rules := WorkflowRules{ApprovalSteps: []ApprovalStep{{StepNumber: 1, Name: "review"}}}
+
+	rules.ApprovalStamp = &ApprovalStampSettings{Enabled: true}
+	assert.NoError(t, service.validateWorkflowRules(rules))
+
+	rules.ApprovalStamp = &ApprovalStampSettings{Enabled: true, Position: StampPositionBottomLeft, Pages: StampPagesAll}
+	assert.NoError(t, service.validateWorkflowRules(rules))
+
+	rules.ApprovalStamp = &ApprovalStampSettings{Enabled: true, Position: "middle"}
+	assert.ErrorIs(t, service.validateWorkflowRules(rules), ErrInvalidStampConfig)
+
+	rules.ApprovalStamp = &ApprovalStampSettings{Enabled: true, Pages: "odd"}
+	assert.ErrorIs(t, service.validateWorkflowRules(rules), ErrInvalidStampConfig)
+}
+
+// escalatingTaskRepo escalates every task it is asked to
+type escalatingTaskRepo struct {
+	repositories.WorkflowTaskRepository
+}
+
+func (escalatingTaskRepo) Escalate(ctx context.Context, taskID, fromUserID, toUserID uuid.UUID) (bool, error) {
+	return true, nil
+}
+
+type discardAuditRepo struct {
+	repositories.AuditLogRepository
+}
+
+func (discardAuditRepo) Create(ctx context.Context, log *models.AuditLog) error {
+	return nil
+}
+
+// recordingTaskNotifier keeps who was told about escalations
+type recordingTaskNotifier struct {
+	NotificationService
+	escalatedTo, escalatedAway []uuid.UUID
+}
+
+func (n *recordingTaskNotifier) SendTaskEscalation(ctx context.Context, task *models.WorkflowTask, escalatedTo uuid.UUID) error {
+	n.escalatedTo = append(n.escalatedTo, escalatedTo)
+	return nil
+}
+
+func (n *recordingTaskNotifier) SendTaskEscalatedAway(ctx context.Context, task *models.WorkflowTask, previousAssignee uuid.UUID) error {
+	n.escalatedAway = append(n.escalatedAway, previousAssignee)
+	return nil
+}
+
+func TestProcessEscalations(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	manager := models.User{ID: uuid.New(), TenantID: tenantID, IsActive: true}
+	assignee := models.User{ID: uuid.New(), TenantID: tenantID, ManagerID: &manager.ID}
+	notifier := &recordingTaskNotifier{}
+	service := &WorkflowService{
+		taskRepo:            escalatingTaskRepo{},
+		userRepo:            &memoryUserRepo{users: map[uuid.UUID]models.User{manager.ID: manager, assignee.ID: assignee}},
+		auditRepo:           discardAuditRepo{},
+		notificationService: notifier,
+	}
+	rules := &WorkflowRules{EscalationRules: []EscalationRule{
+		{StepNumber: 2, EscalationDays: 3, EscalateToType: "manager", NotifyOriginal: true},
+	}}
+	// Three business days from Friday the 1st have passed by Thursday
+	createdAt := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+	now := createdAt.AddDate(0, 0, 6)
+
+	// Matched on the task's step, not its priority
+	task := &models.WorkflowTask{
+		ID: uuid.New(), Workflow: models.Workflow{TenantID: tenantID},
+		AssignedTo: assignee.ID, StepNumber: 1, Priority: 2, CreatedAt: createdAt,
+	}
+	escalated, err := service.processEscalations(ctx, task, rules, now)
+	require.NoError(t, err)
+	assert.False(t, escalated)
+
+	task.StepNumber = 2
+	escalated, err = service.processEscalations(ctx, task, rules, now)
+	require.NoError(t, err)
+	assert.True(t, escalated)
+	assert.Equal(t, manager.ID, task.AssignedTo)
+	assert.Equal(t, []uuid.UUID{manager.ID}, notifier.escalatedTo)
+	assert.Equal(t, []uuid.UUID{assignee.ID}, notifier.escalatedAway)
+}
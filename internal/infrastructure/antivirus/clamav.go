@@ -0,0 +1,114 @@
+package antivirus
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// clamdChunkSize is how much of the file each INSTREAM chunk carries
+const clamdChunkSize = 64 * 1024
+
+// ClamAVScanner scans files with a clamd daemon over its INSTREAM command
+type ClamAVScanner struct {
+	address string
+	timeout time.Duration
+}
+
+// ClamAVConfig configures the scanner
+type ClamAVConfig struct {
+	Address string        // host:port of clamd's TCP socket
+	Timeout time.Duration // Per scan; defaults to 5 minutes
+}
+
+func NewClamAVScanner(config ClamAVConfig) (*ClamAVScanner, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("clamd address is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Minute
+	}
+
+	return &ClamAVScanner{
+		address: config.Address,
+		timeout: config.Timeout,
+	}, nil
+}
+
+func (s *ClamAVScanner) Scan(ctx context.Context, content io.Reader) (*services.VirusScanResult, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("failed to start scan: %w", err)
+	}
+
+	// Each chunk is prefixed with its length; a zero length ends the stream
+	buf := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := content.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return nil, fmt.Errorf("failed to send file to clamd: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return nil, fmt.Errorf("failed to send file to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read file: %w", readErr)
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return nil, fmt.Errorf("failed to send file to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamdReply(reply)
+}
+
+// parseClamdReply reads replies like "stream: OK" and
+// "stream: Eicar-Signature FOUND"
+func parseClamdReply(reply string) (*services.VirusScanResult, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	verdict := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+
+	switch {
+	case verdict == "OK":
+		return &services.VirusScanResult{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &services.VirusScanResult{
+			Infected: true,
+			Threat:   strings.TrimSuffix(verdict, " FOUND"),
+		}, nil
+	default:
+		// e.g. "INSTREAM size limit exceeded. ERROR"
+		return nil, fmt.Errorf("clamd could not scan the file: %s", reply)
+	}
+}
@@ -2,7 +2,14 @@ package supabase
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/archivus/archivus/internal/domain/services"
@@ -11,12 +18,17 @@ import (
 )
 
 type AuthService struct {
-	client *supabase.Client
+	client     *supabase.Client
+	config     Config
+	httpClient *http.Client
 }
 
 type Config struct {
 	URL    string
 	APIKey string
+
+	// JWTSecret signs the short-lived tokens used for admin sign-out
+	JWTSecret string
 }
 
 func NewAuthService(config Config) (*AuthService, error) {
@@ -26,7 +38,9 @@ func NewAuthService(config Config) (*AuthService, error) {
 	}
 
 	return &AuthService{
-		client: client,
+		client:     client,
+		config:     config,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
 	}, nil
 }
 
@@ -217,6 +231,84 @@ func (s *AuthService) AdminDeleteUser(userID string) error {
 	return fmt.Errorf("admin delete user not implemented in nedpals client")
 }
 
+// AdminSignOutAll revokes every session and refresh token of a user, so
+// clients can't refresh their way past a "log out everywhere". GoTrue only
+// signs out on behalf of the user, so a short-lived token is minted for them.
+func (s *AuthService) AdminSignOutAll(userID string) error {
+	if s.config.JWTSecret == "" {
+		return fmt.Errorf("admin sign out requires the Supabase JWT secret")
+	}
+
+	now := time.Now()
+	token, err := signHS256(map[string]interface{}{
+		"sub":  userID,
+		"aud":  "authenticated",
+		"role": "authenticated",
+		"iat":  now.Unix(),
+		"exp":  now.Add(time.Minute).Unix(),
+	}, []byte(s.config.JWTSecret))
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimRight(s.config.URL, "/") + "/auth/v1/logout?scope=global"
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build sign out request: %w", err)
+	}
+	req.Header.Set("apikey", s.config.APIKey)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to sign out user everywhere: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to sign out user everywhere: status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// HealthCheck checks that Supabase Auth is up
+func (s *AuthService) HealthCheck(ctx context.Context) error {
+	endpoint := strings.TrimRight(s.config.URL, "/") + "/auth/v1/health"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build health request: %w", err)
+	}
+	req.Header.Set("apikey", s.config.APIKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth API unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth API unhealthy: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signHS256 builds a compact JWS signed with the project's JWT secret
+func signHS256(claims map[string]interface{}, secret []byte) (string, error) {
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode jwt claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
 // Helper function to convert nedpals User to our domain model
 func convertToSupabaseUser(user *supabase.User) *services.SupabaseUser {
 	if user == nil {
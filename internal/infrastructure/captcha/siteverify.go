@@ -0,0 +1,72 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SiteVerifier checks CAPTCHA tokens against a "siteverify" endpoint, the API
+// shared by reCAPTCHA, hCaptcha and Cloudflare Turnstile
+type SiteVerifier struct {
+	verifyURL  string
+	secret     string
+	httpClient *http.Client
+}
+
+// SiteVerifierConfig configures the verifier
+type SiteVerifierConfig struct {
+	VerifyURL string // e.g. https://challenges.cloudflare.com/turnstile/v0/siteverify
+	Secret    string
+}
+
+func NewSiteVerifier(config SiteVerifierConfig) (*SiteVerifier, error) {
+	if config.VerifyURL == "" || config.Secret == "" {
+		return nil, fmt.Errorf("captcha verify URL and secret are required")
+	}
+
+	return &SiteVerifier{
+		verifyURL:  config.VerifyURL,
+		secret:     config.Secret,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (v *SiteVerifier) VerifyCaptcha(ctx context.Context, token, ipAddress string) (bool, error) {
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+	}
+	if ipAddress != "" {
+		form.Set("remoteip", ipAddress)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, fmt.Errorf("failed to create captcha request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify captcha: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("captcha provider returned %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode captcha response: %w", err)
+	}
+
+	return result.Success, nil
+}
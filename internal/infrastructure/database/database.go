@@ -93,6 +93,7 @@ func enableExtensions(db *gorm.DB) error {
 	extensions := []string{
 		"CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"",
 		"CREATE EXTENSION IF NOT EXISTS \"vector\"",
+		"CREATE EXTENSION IF NOT EXISTS \"pg_trgm\"",
 	}
 
 	for _, ext := range extensions {
@@ -0,0 +1,419 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SchemaVersion is the schema version this build expects. Bump it with every
+// change to the models or indexes, which ships as a versioned migration of
+// that number (see the migrations package), and run `migrate up` before
+// deploying.
+//
+// Changes must be backwards compatible (expand, deploy, then contract) so
+// instances still running the previous build keep working. When a change
+// is not, raise SchemaMinCompatibleVersion to the new version as well; older
+// builds will then refuse to start against the migrated schema.
+const (
+	SchemaVersion              = 46
+	SchemaMinCompatibleVersion = 1
+)
+
+// migrationLockKey identifies the advisory lock held while migrating
+const migrationLockKey int64 = 0x61726368697675 // "archivu"
+
+var (
+	ErrSchemaNotInitialized = errors.New("database schema is not initialized; run the migrate up command")
+	ErrSchemaOutdated       = errors.New("database schema is older than this build; run the migrate up command")
+	ErrSchemaTooNew         = errors.New("database schema is newer than this build supports; deploy a newer build")
+	ErrSchemaDirty          = errors.New("the last database migration failed; check the schema, then run the migrate force command")
+)
+
+// Index is an index created online, without blocking writes to the table
+type Index struct {
+	Name       string
+	Table      string
+	Expression string // e.g. "(tenant_id, status)" or "USING gin(...)"
+}
+
+// SchemaIndexes are the indexes beyond those declared on the models. Add
+// indexes on large tables here rather than in gorm tags: auto-migration
+// builds tag indexes with a plain CREATE INDEX, which blocks writes.
+var SchemaIndexes = []Index{
+	{Name: "idx_documents_tenant_status", Table: "documents", Expression: "(tenant_id, status)"},
+	{Name: "idx_documents_created_at", Table: "documents", Expression: "(created_at)"},
+	{Name: "idx_documents_file_size", Table: "documents", Expression: "(file_size)"},
+	{Name: "idx_ai_jobs_status_priority", Table: "ai_processing_jobs", Expression: "(status, priority)"},
+	{Name: "idx_audit_logs_created_at", Table: "audit_logs", Expression: "(created_at)"},
+	{Name: "idx_shares_expires_at", Table: "shares", Expression: "(expires_at)"},
+	{Name: "idx_documents_scan_pending", Table: "documents", Expression: "(created_at) WHERE coalesce(scan_status, '') IN ('', 'pending') AND deleted_at IS NULL"},
+	{Name: "idx_documents_dlp_pending", Table: "documents", Expression: "(created_at) WHERE coalesce(dlp_status, '') IN ('', 'pending') AND deleted_at IS NULL"},
+	{Name: "idx_folders_path_gin", Table: "folders", Expression: "USING gin(to_tsvector('english', path))"},
+	{Name: "idx_documents_search_gin", Table: "documents", Expression: "USING gin(" + DocumentSearchVector + ")"},
+
+	// Trigram indexes for typeahead suggestions (pg_trgm)
+	{Name: "idx_documents_title_trgm", Table: "documents", Expression: "USING gin(lower(title) gin_trgm_ops)"},
+	{Name: "idx_documents_vendor_trgm", Table: "documents", Expression: "USING gin(lower(vendor_name) gin_trgm_ops)"},
+	{Name: "idx_tags_name_trgm", Table: "tags", Expression: "USING gin(lower(name) gin_trgm_ops)"},
+	{Name: "idx_folders_name_trgm", Table: "folders", Expression: "USING gin(lower(name) gin_trgm_ops)"},
+}
+
+// DataMigration backfills existing rows for a schema version. It runs once,
+// in the schema transaction, when a database older than Version is migrated.
+type DataMigration struct {
+	Version     int
+	Description string
+	Apply       func(tx *gorm.DB) error
+}
+
+// DataMigrations are applied in order after the tables and columns
+var DataMigrations = []DataMigration{
+	{Version: 4, Description: "set document quotas from tiers and counts from documents", Apply: backfillDocumentQuotas},
+	{Version: 9, Description: "snapshot workflow rules as version 1", Apply: backfillWorkflowVersions},
+	{Version: 10, Description: "make creators the owners of documents and folders", Apply: backfillOwners},
+	{Version: 11, Description: "move deleted documents to the trash", Apply: backfillTrash},
+	{Version: 46, Description: "record the step of workflow tasks", Apply: backfillTaskSteps},
+}
+
+// MigrationOptions configures a schema migration
+type MigrationOptions struct {
+	// LockTimeout bounds how long a DDL statement waits for a table lock, so
+	// a long-running transaction can't make the migration stall all traffic
+	// queued behind it. Defaults to 5 seconds.
+	LockTimeout time.Duration
+}
+
+// schemaVersionRecord is the single row recording the applied schema version
+type schemaVersionRecord struct {
+	ID            int       `gorm:"primaryKey"`
+	Version       int       `gorm:"not null"`
+	MinCompatible int       `gorm:"not null"`
+	AppliedAt     time.Time `gorm:"not null"`
+}
+
+func (schemaVersionRecord) TableName() string {
+	return "schema_version"
+}
+
+// SchemaStatus describes the applied schema version
+type SchemaStatus struct {
+	Version       int
+	MinCompatible int
+	AppliedAt     time.Time
+}
+
+// Migrate brings the schema up to SchemaVersion by auto-migrating the
+// models. Only one migrator runs at a time (PostgreSQL advisory lock). Table
+// and column changes are applied in a single transaction with a lock
+// timeout; indexes are then built concurrently, and the version is recorded
+// last. PostgreSQL databases are otherwise migrated with the versioned
+// migrations of MigrateUp; this remains for development databases and for
+// rebuilding model indexes after partitioning.
+func (db *DB) Migrate(ctx context.Context, models []interface{}, options MigrationOptions) error {
+	if options.LockTimeout <= 0 {
+		options.LockTimeout = 5 * time.Second
+	}
+
+	if !db.isPostgres() {
+		// Development databases (SQLite) have no locks or online indexes
+		previous := db.appliedSchemaVersion(ctx)
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(append(models, &schemaVersionRecord{})...); err != nil {
+				return fmt.Errorf("failed to migrate schema: %w", err)
+			}
+			return applyDataMigrations(tx, previous)
+		})
+		if err != nil {
+			return err
+		}
+		return db.recordSchemaVersion(ctx)
+	}
+
+	unlock, err := db.acquireMigrationLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return db.autoMigrate(ctx, models, options)
+}
+
+// autoMigrate is Migrate on PostgreSQL, run by a caller holding the
+// migration lock
+func (db *DB) autoMigrate(ctx context.Context, models []interface{}, options MigrationOptions) error {
+	// Read under the lock so a concurrent migrator can't apply backfills twice
+	previous := db.appliedSchemaVersion(ctx)
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL lock_timeout = '%dms'", options.LockTimeout.Milliseconds())).Error; err != nil {
+			return fmt.Errorf("failed to set lock timeout: %w", err)
+		}
+		if err := tx.AutoMigrate(append(models, &schemaVersionRecord{})...); err != nil {
+			return fmt.Errorf("failed to migrate schema: %w", err)
+		}
+		return applyDataMigrations(tx, previous)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, index := range SchemaIndexes {
+		if err := db.createIndexConcurrently(ctx, index); err != nil {
+			return err
+		}
+	}
+
+	return db.recordSchemaVersion(ctx)
+}
+
+// CheckSchemaVersion verifies the applied schema works with this build
+func (db *DB) CheckSchemaVersion(ctx context.Context) error {
+	status, err := db.SchemaStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if db.isPostgres() && db.Migrator().HasTable(migrationsTable) {
+		var dirty bool
+		if err := db.WithContext(ctx).Raw("SELECT dirty FROM " + migrationsTable + " LIMIT 1").Scan(&dirty).Error; err != nil {
+			return fmt.Errorf("failed to read migration state: %w", err)
+		}
+		if dirty {
+			return ErrSchemaDirty
+		}
+	}
+
+	if status.Version < SchemaVersion {
+		return fmt.Errorf("%w (schema version %d, build expects %d)", ErrSchemaOutdated, status.Version, SchemaVersion)
+	}
+	if status.MinCompatible > SchemaVersion {
+		return fmt.Errorf("%w (schema version %d requires build version %d or later, this build is %d)",
+			ErrSchemaTooNew, status.Version, status.MinCompatible, SchemaVersion)
+	}
+	return nil
+}
+
+// SchemaStatus returns the applied schema version
+func (db *DB) SchemaStatus(ctx context.Context) (*SchemaStatus, error) {
+	if !db.Migrator().HasTable(&schemaVersionRecord{}) {
+		return nil, ErrSchemaNotInitialized
+	}
+
+	var record schemaVersionRecord
+	if err := db.WithContext(ctx).Where("id = ?", 1).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSchemaNotInitialized
+		}
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return &SchemaStatus{
+		Version:       record.Version,
+		MinCompatible: record.MinCompatible,
+		AppliedAt:     record.AppliedAt,
+	}, nil
+}
+
+// Helper methods
+
+// appliedSchemaVersion returns the recorded schema version, or 0 for a
+// database that predates version tracking
+func (db *DB) appliedSchemaVersion(ctx context.Context) int {
+	status, err := db.SchemaStatus(ctx)
+	if err != nil {
+		return 0
+	}
+	return status.Version
+}
+
+// applyDataMigrations runs the backfills introduced after the previous version
+func applyDataMigrations(tx *gorm.DB, previous int) error {
+	for _, migration := range DataMigrations {
+		if migration.Version <= previous || migration.Version > SchemaVersion {
+			continue
+		}
+		if err := migration.Apply(tx); err != nil {
+			return fmt.Errorf("failed to apply data migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+	}
+	return nil
+}
+
+// backfillWorkflowVersions records each existing workflow's rules as its
+// first version; runs in progress were already pinned to 1 by the column default
+func backfillWorkflowVersions(tx *gorm.DB) error {
+	var workflows []models.Workflow
+	return tx.Model(&models.Workflow{}).
+		Select("id", "tenant_id", "rules", "created_by", "created_at").
+		FindInBatches(&workflows, 200, func(_ *gorm.DB, _ int) error {
+			versions := make([]models.WorkflowVersion, 0, len(workflows))
+			for _, workflow := range workflows {
+				versions = append(versions, models.WorkflowVersion{
+					ID:              uuid.New(),
+					TenantID:        workflow.TenantID,
+					WorkflowID:      workflow.ID,
+					Version:         1,
+					Rules:           workflow.Rules,
+					MigrationPolicy: models.WorkflowFinishOnOld,
+					CreatedBy:       workflow.CreatedBy,
+					CreatedAt:       workflow.CreatedAt,
+				})
+			}
+			insert := tx.Session(&gorm.Session{NewDB: true}).Clauses(clause.OnConflict{DoNothing: true})
+			if err := insert.Create(&versions).Error; err != nil {
+				return fmt.Errorf("failed to snapshot workflow versions: %w", err)
+			}
+			return nil
+		}).Error
+}
+
+// backfillOwners sets the owner of existing documents and folders to their
+// creator. Builds from before owners existed may still write rows without
+// one, so readers keep falling back to the creator.
+func backfillOwners(tx *gorm.DB) error {
+	for _, model := range []interface{}{&models.Document{}, &models.Folder{}} {
+		if err := tx.Model(model).Where("owner_id IS NULL").
+			Update("owner_id", gorm.Expr("created_by")).Error; err != nil {
+			return fmt.Errorf("failed to backfill owners: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillTrash moves documents deleted before the trash existed, which were
+// only archived, into it. They get a full grace period from now rather than
+// being purged on the first run.
+func backfillTrash(tx *gorm.DB) error {
+	if err := tx.Model(&models.Document{}).
+		Where("status = ? AND deleted_at IS NULL", models.DocStatusArchived).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": gorm.Expr("updated_by"),
+		}).Error; err != nil {
+		return fmt.Errorf("failed to backfill trash: %w", err)
+	}
+	return nil
+}
+
+// backfillTaskSteps sets the step of existing workflow tasks, which were
+// created with their step number as their priority
+func backfillTaskSteps(tx *gorm.DB) error {
+	if err := tx.Model(&models.WorkflowTask{}).Where("step_number = 0").
+		Update("step_number", gorm.Expr("priority")).Error; err != nil {
+		return fmt.Errorf("failed to backfill workflow task steps: %w", err)
+	}
+	return nil
+}
+
+// backfillDocumentQuotas fixes tenants created before document quotas
+// existed, which got the starter quota and a zero count whatever their tier
+func backfillDocumentQuotas(tx *gorm.DB) error {
+	for tier, quota := range models.TierDocumentQuotas {
+		if err := tx.Model(&models.Tenant{}).
+			Where("subscription_tier = ?", tier).
+			Update("document_quota", quota).Error; err != nil {
+			return fmt.Errorf("failed to set %s document quotas: %w", tier, err)
+		}
+	}
+
+	// Archived documents were the deleted ones then, which don't count
+	if err := tx.Model(&models.Tenant{}).Where("1 = 1").
+		Update("document_count", gorm.Expr(`(SELECT COUNT(*) FROM documents
+			WHERE documents.tenant_id = tenants.id AND documents.status <> 'archived')`)).Error; err != nil {
+		return fmt.Errorf("failed to recount tenant documents: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) isPostgres() bool {
+	return db.Dialector != nil && db.Dialector.Name() == "postgres"
+}
+
+// acquireMigrationLock takes a session advisory lock on a dedicated
+// connection, waiting for any other migrator to finish
+func (db *DB) acquireMigrationLock(ctx context.Context) (func(), error) {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration connection: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+		conn.Close()
+	}, nil
+}
+
+// createIndexConcurrently builds an index without blocking writes. An
+// invalid index left behind by an interrupted build is dropped and rebuilt.
+func (db *DB) createIndexConcurrently(ctx context.Context, index Index) error {
+	var invalid bool
+	if err := db.WithContext(ctx).Raw(
+		"SELECT EXISTS (SELECT 1 FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid WHERE c.relname = ? AND NOT i.indisvalid)",
+		index.Name,
+	).Scan(&invalid).Error; err != nil {
+		return fmt.Errorf("failed to inspect index %s: %w", index.Name, err)
+	}
+	if invalid {
+		if err := db.WithContext(ctx).Exec(fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s", quoteIdent(index.Name))).Error; err != nil {
+			return fmt.Errorf("failed to drop invalid index %s: %w", index.Name, err)
+		}
+	}
+
+	// Partitioned tables don't support CONCURRENTLY, so those get a plain build
+	concurrently := "CONCURRENTLY "
+	var partitioned bool
+	if err := db.WithContext(ctx).Raw(
+		"SELECT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass(?))", index.Table,
+	).Scan(&partitioned).Error; err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", index.Table, err)
+	}
+	if partitioned {
+		concurrently = ""
+	}
+
+	if err := db.WithContext(ctx).Exec(fmt.Sprintf("CREATE INDEX %sIF NOT EXISTS %s ON %s %s",
+		concurrently, quoteIdent(index.Name), quoteIdent(index.Table), index.Expression)).Error; err != nil {
+		return fmt.Errorf("failed to create index %s: %w", index.Name, err)
+	}
+	return nil
+}
+
+func (db *DB) recordSchemaVersion(ctx context.Context) error {
+	// Never move the recorded version backwards when an older build migrates
+	status, err := db.SchemaStatus(ctx)
+	if err == nil && status.Version > SchemaVersion {
+		return nil
+	}
+	return db.saveSchemaVersion(ctx, SchemaVersion)
+}
+
+// saveSchemaVersion records version as the applied schema version
+func (db *DB) saveSchemaVersion(ctx context.Context, version int) error {
+	record := schemaVersionRecord{
+		ID:            1,
+		Version:       version,
+		MinCompatible: SchemaMinCompatibleVersion,
+		AppliedAt:     time.Now().UTC(),
+	}
+
+	if err := db.WithContext(ctx).Save(&record).Error; err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return nil
+}
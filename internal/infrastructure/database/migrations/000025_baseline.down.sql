@@ -0,0 +1,61 @@
+-- Drops every table of the baseline. The extensions stay, since other
+-- databases on the server may use them.
+
+DROP TABLE IF EXISTS "numbering_sequences" CASCADE;
+DROP TABLE IF EXISTS "bulk_label_jobs" CASCADE;
+DROP TABLE IF EXISTS "worker_heartbeats" CASCADE;
+DROP TABLE IF EXISTS "upload_sessions" CASCADE;
+DROP TABLE IF EXISTS "vendor_profile_aliases" CASCADE;
+DROP TABLE IF EXISTS "vendor_profiles" CASCADE;
+DROP TABLE IF EXISTS "api_usages" CASCADE;
+DROP TABLE IF EXISTS "tenant_deletion_certificates" CASCADE;
+DROP TABLE IF EXISTS "tenant_deletions" CASCADE;
+DROP TABLE IF EXISTS "data_subject_requests" CASCADE;
+DROP TABLE IF EXISTS "legal_hold_documents" CASCADE;
+DROP TABLE IF EXISTS "legal_holds" CASCADE;
+DROP TABLE IF EXISTS "scheduled_job_runs" CASCADE;
+DROP TABLE IF EXISTS "scheduled_jobs" CASCADE;
+DROP TABLE IF EXISTS "webhook_delivery_attempts" CASCADE;
+DROP TABLE IF EXISTS "webhook_deliveries" CASCADE;
+DROP TABLE IF EXISTS "webhook_events" CASCADE;
+DROP TABLE IF EXISTS "webhooks" CASCADE;
+DROP TABLE IF EXISTS "share_accesses" CASCADE;
+DROP TABLE IF EXISTS "shares" CASCADE;
+DROP TABLE IF EXISTS "audit_streams" CASCADE;
+DROP TABLE IF EXISTS "audit_archives" CASCADE;
+DROP TABLE IF EXISTS "audit_chain_heads" CASCADE;
+DROP TABLE IF EXISTS "audit_logs" CASCADE;
+DROP TABLE IF EXISTS "a_iprocessing_jobs" CASCADE;
+DROP TABLE IF EXISTS "notification_templates" CASCADE;
+DROP TABLE IF EXISTS "sms_messages" CASCADE;
+DROP TABLE IF EXISTS "device_tokens" CASCADE;
+DROP TABLE IF EXISTS "notification_deliveries" CASCADE;
+DROP TABLE IF EXISTS "notifications" CASCADE;
+DROP TABLE IF EXISTS "out_of_offices" CASCADE;
+DROP TABLE IF EXISTS "workflow_task_delegations" CASCADE;
+DROP TABLE IF EXISTS "workflow_checklist_items" CASCADE;
+DROP TABLE IF EXISTS "workflow_tasks" CASCADE;
+DROP TABLE IF EXISTS "workflow_instances" CASCADE;
+DROP TABLE IF EXISTS "workflow_versions" CASCADE;
+DROP TABLE IF EXISTS "workflows" CASCADE;
+DROP TABLE IF EXISTS "document_analytics" CASCADE;
+DROP TABLE IF EXISTS "document_comments" CASCADE;
+DROP TABLE IF EXISTS "document_templates" CASCADE;
+DROP TABLE IF EXISTS "document_versions" CASCADE;
+DROP TABLE IF EXISTS "document_acls" CASCADE;
+DROP TABLE IF EXISTS "document_tags" CASCADE;
+DROP TABLE IF EXISTS "tags" CASCADE;
+DROP TABLE IF EXISTS "document_categories" CASCADE;
+DROP TABLE IF EXISTS "documents" CASCADE;
+DROP TABLE IF EXISTS "categories" CASCADE;
+DROP TABLE IF EXISTS "folder_acls" CASCADE;
+DROP TABLE IF EXISTS "folders" CASCADE;
+DROP TABLE IF EXISTS "api_key_scopes" CASCADE;
+DROP TABLE IF EXISTS "mfa_recovery_codes" CASCADE;
+DROP TABLE IF EXISTS "tenant_ai_keys" CASCADE;
+DROP TABLE IF EXISTS "api_keys" CASCADE;
+DROP TABLE IF EXISTS "users" CASCADE;
+DROP TABLE IF EXISTS "role_permissions" CASCADE;
+DROP TABLE IF EXISTS "roles" CASCADE;
+DROP TABLE IF EXISTS "tenants" CASCADE;
+DROP TABLE IF EXISTS "schema_version";
@@ -0,0 +1,1125 @@
+-- Baseline: the schema as of version 25, when migrations moved from
+-- AutoMigrate to versioned SQL. Databases AutoMigrate built at version 25
+-- are adopted at this version without running it.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE EXTENSION IF NOT EXISTS "vector";
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
+
+CREATE TABLE IF NOT EXISTS "schema_version" (
+    "id" bigserial,
+    "version" bigint NOT NULL,
+    "min_compatible" bigint NOT NULL,
+    "applied_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE "tenants" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(255) NOT NULL,
+    "subdomain" varchar(100) NOT NULL,
+    "subscription_tier" varchar(20) NOT NULL DEFAULT 'starter',
+    "storage_quota" bigint NOT NULL DEFAULT 5368709120,
+    "storage_used" bigint NOT NULL DEFAULT 0,
+    "document_quota" bigint NOT NULL DEFAULT 10000,
+    "document_count" bigint NOT NULL DEFAULT 0,
+    "api_quota" bigint NOT NULL DEFAULT 1000,
+    "api_used" bigint NOT NULL DEFAULT 0,
+    "settings" jsonb DEFAULT '{}',
+    "is_active" boolean NOT NULL DEFAULT true,
+    "trial_ends_at" timestamptz,
+    "deletion_scheduled_for" timestamptz,
+    "trial_warned_at" timestamptz,
+    "trial_expired_at" timestamptz,
+    "business_type" varchar(100),
+    "industry" varchar(100),
+    "company_size" varchar(50),
+    "tax_id" varchar(50),
+    "address" jsonb,
+    "retention_policy" jsonb,
+    "compliance_rules" jsonb,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_tenants_subdomain" UNIQUE ("subdomain")
+);
+
+CREATE TABLE "roles" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "name" varchar(50) NOT NULL,
+    "description" text,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_roles_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tenant_role_name" ON "roles" ("tenant_id","name");
+
+CREATE TABLE "role_permissions" (
+    "role_id" uuid,
+    "permission" varchar(100),
+    PRIMARY KEY ("role_id","permission"),
+    CONSTRAINT "fk_roles_permissions" FOREIGN KEY ("role_id") REFERENCES "roles"("id")
+);
+
+CREATE TABLE "users" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "email" varchar(320) NOT NULL,
+    "password_hash" varchar(255) NOT NULL,
+    "first_name" varchar(100) NOT NULL,
+    "last_name" varchar(100) NOT NULL,
+    "role" varchar(20) NOT NULL DEFAULT 'user',
+    "custom_role_id" uuid,
+    "department" varchar(100),
+    "job_title" varchar(100),
+    "manager_id" uuid,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "email_verified" boolean NOT NULL DEFAULT false,
+    "last_login_at" timestamptz,
+    "password_changed_at" timestamptz NOT NULL DEFAULT now(),
+    "mfa_enabled" boolean NOT NULL DEFAULT false,
+    "mfa_secret" varchar(32),
+    "mfa_pending_secret" varchar(32),
+    "mfa_last_used_step" bigint NOT NULL DEFAULT 0,
+    "external_id" varchar(255),
+    "phone_number" varchar(20),
+    "phone_verified" boolean NOT NULL DEFAULT false,
+    "phone_verification_hash" varchar(64),
+    "phone_verification_expires_at" timestamptz,
+    "preferences" jsonb DEFAULT '{}',
+    "notification_settings" jsonb DEFAULT '{}',
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_tenants_users" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "fk_users_custom_role" FOREIGN KEY ("custom_role_id") REFERENCES "roles"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_external_id" ON "users" ("external_id");
+CREATE INDEX IF NOT EXISTS "idx_users_manager_id" ON "users" ("manager_id");
+CREATE INDEX IF NOT EXISTS "idx_users_custom_role_id" ON "users" ("custom_role_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tenant_email" ON "users" ("email");
+CREATE INDEX IF NOT EXISTS "idx_users_tenant_id" ON "users" ("tenant_id");
+
+CREATE TABLE "api_keys" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "prefix" varchar(20) NOT NULL,
+    "key_hash" varchar(64) NOT NULL,
+    "created_by" uuid NOT NULL,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_api_keys_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "fk_api_keys_creator" FOREIGN KEY ("created_by") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_api_keys_created_by" ON "api_keys" ("created_by");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_keys_prefix" ON "api_keys" ("prefix");
+CREATE INDEX IF NOT EXISTS "idx_api_keys_tenant_id" ON "api_keys" ("tenant_id");
+
+CREATE TABLE "tenant_ai_keys" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "provider" varchar(20) NOT NULL,
+    "encrypted_key" text NOT NULL,
+    "key_hint" varchar(10),
+    "is_enabled" boolean NOT NULL DEFAULT true,
+    "created_by" uuid NOT NULL,
+    "last_used_at" timestamptz,
+    "last_error" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_tenant_ai_keys_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tenant_ai_provider" ON "tenant_ai_keys" ("tenant_id","provider");
+
+CREATE TABLE "mfa_recovery_codes" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "code_hash" varchar(64) NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_mfa_recovery_codes_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_mfa_recovery_codes_user_id" ON "mfa_recovery_codes" ("user_id");
+
+CREATE TABLE "api_key_scopes" (
+    "api_key_id" uuid,
+    "permission" varchar(100),
+    PRIMARY KEY ("api_key_id","permission"),
+    CONSTRAINT "fk_api_keys_scopes" FOREIGN KEY ("api_key_id") REFERENCES "api_keys"("id")
+);
+
+CREATE TABLE "folders" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "parent_id" uuid,
+    "name" varchar(255) NOT NULL,
+    "description" text,
+    "path" varchar(2048) NOT NULL,
+    "level" bigint NOT NULL DEFAULT 0,
+    "is_system" boolean NOT NULL DEFAULT false,
+    "color" varchar(7) DEFAULT '#6B7280',
+    "icon" varchar(50),
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    "owner_id" uuid,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_folders_children" FOREIGN KEY ("parent_id") REFERENCES "folders"("id"),
+    CONSTRAINT "fk_users_created_folders" FOREIGN KEY ("created_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_tenants_folders" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_folders_owner_id" ON "folders" ("owner_id");
+CREATE INDEX IF NOT EXISTS "idx_folders_created_by" ON "folders" ("created_by");
+CREATE INDEX IF NOT EXISTS "idx_folders_parent_id" ON "folders" ("parent_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tenant_folder_path" ON "folders" ("tenant_id","path");
+
+CREATE TABLE "folder_acls" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "folder_id" uuid NOT NULL,
+    "user_id" uuid,
+    "role" varchar(20),
+    "permission" varchar(20) NOT NULL,
+    "granted_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    "expires_at" timestamptz,
+    "expiry_notified_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_folder_acls_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_folder_acls_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "fk_folder_acls_folder" FOREIGN KEY ("folder_id") REFERENCES "folders"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_folder_acls_expires_at" ON "folder_acls" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_folder_acls_user_id" ON "folder_acls" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_folder_acls_folder_id" ON "folder_acls" ("folder_id");
+CREATE INDEX IF NOT EXISTS "idx_folder_acls_tenant_id" ON "folder_acls" ("tenant_id");
+
+CREATE TABLE "categories" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "description" text,
+    "color" varchar(7) DEFAULT '#6B7280',
+    "icon" varchar(50),
+    "is_system" boolean NOT NULL DEFAULT false,
+    "sort_order" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_tenants_categories" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tenant_category_name" ON "categories" ("name");
+CREATE INDEX IF NOT EXISTS "idx_categories_tenant_id" ON "categories" ("tenant_id");
+
+CREATE TABLE "documents" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "folder_id" uuid,
+    "file_name" varchar(255) NOT NULL,
+    "original_name" varchar(255) NOT NULL,
+    "content_type" varchar(100) NOT NULL,
+    "file_size" bigint NOT NULL,
+    "storage_path" varchar(500) NOT NULL,
+    "thumbnail_path" varchar(500),
+    "preview_path" varchar(500),
+    "extracted_text" text,
+    "content_hash" varchar(64) NOT NULL,
+    "ocr_text" text,
+    "summary" text,
+    "ai_confidence" decimal(3,2),
+    "embedding" vector(1536),
+    "title" varchar(255),
+    "description" text,
+    "document_type" varchar(50),
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "version" bigint NOT NULL DEFAULT 1,
+    "language" varchar(10) DEFAULT 'en',
+    "document_number" varchar(100),
+    "reference_number" varchar(100),
+    "external_id" varchar(100),
+    "numbering_sequence_id" uuid,
+    "amount" decimal(15,2),
+    "currency" varchar(3) DEFAULT 'USD',
+    "tax_amount" decimal(15,2),
+    "vendor_name" varchar(255),
+    "customer_name" varchar(255),
+    "document_date" timestamptz,
+    "due_date" timestamptz,
+    "expiry_date" timestamptz,
+    "compliance_status" varchar(20) DEFAULT 'pending',
+    "retention_date" timestamptz,
+    "legal_hold" boolean NOT NULL DEFAULT false,
+    "is_private" boolean NOT NULL DEFAULT false,
+    "scan_status" varchar(20),
+    "dlp_status" varchar(20),
+    "check_findings" jsonb,
+    "gate_override_by" uuid,
+    "gate_override_at" timestamptz,
+    "gate_override_reason" text,
+    "extracted_data" jsonb,
+    "custom_fields" jsonb,
+    "created_by" uuid NOT NULL,
+    "updated_by" uuid,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    "owner_id" uuid,
+    "deleted_at" timestamptz,
+    "deleted_by" uuid,
+    "author" varchar(255),
+    "subject" varchar(255),
+    "keywords" text,
+    "document_created_at" timestamptz,
+    "document_modified_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_created_documents" FOREIGN KEY ("created_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_users_updated_documents" FOREIGN KEY ("updated_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_tenants_documents" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "fk_folders_documents" FOREIGN KEY ("folder_id") REFERENCES "folders"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_documents_deleted_at" ON "documents" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_documents_owner_id" ON "documents" ("owner_id");
+CREATE INDEX IF NOT EXISTS "idx_documents_updated_by" ON "documents" ("updated_by");
+CREATE INDEX IF NOT EXISTS "idx_documents_created_by" ON "documents" ("created_by");
+CREATE INDEX IF NOT EXISTS "idx_documents_is_private" ON "documents" ("is_private");
+CREATE INDEX IF NOT EXISTS "idx_documents_retention_date" ON "documents" ("retention_date");
+CREATE INDEX IF NOT EXISTS "idx_documents_expiry_date" ON "documents" ("expiry_date");
+CREATE INDEX IF NOT EXISTS "idx_documents_due_date" ON "documents" ("due_date");
+CREATE INDEX IF NOT EXISTS "idx_documents_document_date" ON "documents" ("document_date");
+CREATE INDEX IF NOT EXISTS "idx_documents_customer_name" ON "documents" ("customer_name");
+CREATE INDEX IF NOT EXISTS "idx_documents_vendor_name" ON "documents" ("vendor_name");
+CREATE INDEX IF NOT EXISTS "idx_documents_external_id" ON "documents" ("external_id");
+CREATE INDEX IF NOT EXISTS "idx_documents_reference_number" ON "documents" ("reference_number");
+CREATE INDEX IF NOT EXISTS "idx_documents_document_number" ON "documents" ("document_number");
+CREATE INDEX IF NOT EXISTS "idx_documents_document_type" ON "documents" ("document_type");
+CREATE INDEX IF NOT EXISTS "idx_documents_content_hash" ON "documents" ("content_hash");
+CREATE INDEX IF NOT EXISTS "idx_documents_folder_id" ON "documents" ("folder_id");
+CREATE INDEX IF NOT EXISTS "idx_documents_tenant_id" ON "documents" ("tenant_id");
+
+CREATE TABLE "document_categories" (
+    "document_id" uuid DEFAULT uuid_generate_v4(),
+    "category_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("document_id","category_id"),
+    CONSTRAINT "fk_document_categories_document" FOREIGN KEY ("document_id") REFERENCES "documents"("id"),
+    CONSTRAINT "fk_document_categories_category" FOREIGN KEY ("category_id") REFERENCES "categories"("id")
+);
+
+CREATE TABLE "tags" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "name" varchar(50) NOT NULL,
+    "color" varchar(7) DEFAULT '#6B7280',
+    "is_ai_generated" boolean NOT NULL DEFAULT false,
+    "usage_count" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_tenants_tags" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tenant_tag_name" ON "tags" ("name");
+CREATE INDEX IF NOT EXISTS "idx_tags_tenant_id" ON "tags" ("tenant_id");
+
+CREATE TABLE "document_tags" (
+    "document_id" uuid DEFAULT uuid_generate_v4(),
+    "tag_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("document_id","tag_id"),
+    CONSTRAINT "fk_document_tags_document" FOREIGN KEY ("document_id") REFERENCES "documents"("id"),
+    CONSTRAINT "fk_document_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+
+CREATE TABLE "document_acls" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "permission" varchar(20) NOT NULL,
+    "granted_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    "expires_at" timestamptz,
+    "expiry_notified_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_document_acls_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "fk_document_acls_document" FOREIGN KEY ("document_id") REFERENCES "documents"("id"),
+    CONSTRAINT "fk_document_acls_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_document_acls_expires_at" ON "document_acls" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_document_acls_user_id" ON "document_acls" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_document_acl_user" ON "document_acls" ("document_id","user_id");
+CREATE INDEX IF NOT EXISTS "idx_document_acls_tenant_id" ON "document_acls" ("tenant_id");
+
+CREATE TABLE "document_versions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "document_id" uuid NOT NULL,
+    "version_number" bigint NOT NULL,
+    "storage_path" varchar(500) NOT NULL,
+    "file_size" bigint NOT NULL,
+    "content_hash" varchar(64) NOT NULL,
+    "changes" text,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_document_versions_creator" FOREIGN KEY ("created_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_documents_versions" FOREIGN KEY ("document_id") REFERENCES "documents"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_document_versions_document_id" ON "document_versions" ("document_id");
+
+CREATE TABLE "document_templates" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "name" varchar(255) NOT NULL,
+    "description" text,
+    "doc_type" varchar(50) NOT NULL,
+    "template" jsonb NOT NULL,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_document_templates_creator" FOREIGN KEY ("created_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_tenants_templates" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_document_templates_tenant_id" ON "document_templates" ("tenant_id");
+
+CREATE TABLE "document_comments" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "document_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "content" text NOT NULL,
+    "is_resolved" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_document_comments_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_documents_comments" FOREIGN KEY ("document_id") REFERENCES "documents"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_document_comments_user_id" ON "document_comments" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_document_comments_document_id" ON "document_comments" ("document_id");
+
+CREATE TABLE "document_analytics" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "view_count" bigint NOT NULL DEFAULT 0,
+    "download_count" bigint NOT NULL DEFAULT 0,
+    "share_count" bigint NOT NULL DEFAULT 0,
+    "last_accessed_at" timestamptz,
+    "processing_time" bigint,
+    "storage_cost" decimal(10,4),
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_document_analytics_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "fk_document_analytics_document" FOREIGN KEY ("document_id") REFERENCES "documents"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_document_analytics_document_id" ON "document_analytics" ("document_id");
+CREATE INDEX IF NOT EXISTS "idx_document_analytics_tenant_id" ON "document_analytics" ("tenant_id");
+
+CREATE TABLE "workflows" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "name" varchar(255) NOT NULL,
+    "description" text,
+    "doc_type" varchar(50) NOT NULL,
+    "rules" jsonb NOT NULL,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    "version" bigint NOT NULL DEFAULT 1,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_workflows_creator" FOREIGN KEY ("created_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_tenants_workflows" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workflows_tenant_id" ON "workflows" ("tenant_id");
+
+CREATE TABLE "workflow_versions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "workflow_id" uuid NOT NULL,
+    "version" bigint NOT NULL,
+    "rules" jsonb NOT NULL,
+    "migration_policy" varchar(20) NOT NULL DEFAULT 'finish',
+    "migrated_instances" bigint NOT NULL DEFAULT 0,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_workflow_version" ON "workflow_versions" ("workflow_id","version");
+CREATE INDEX IF NOT EXISTS "idx_workflow_versions_tenant_id" ON "workflow_versions" ("tenant_id");
+
+CREATE TABLE "workflow_instances" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "workflow_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "current_step" bigint NOT NULL DEFAULT 0,
+    "started_by" uuid NOT NULL,
+    "started_at" timestamptz NOT NULL DEFAULT now(),
+    "completed_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    "workflow_version" bigint NOT NULL DEFAULT 1,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_workflow_instances_workflow" FOREIGN KEY ("workflow_id") REFERENCES "workflows"("id"),
+    CONSTRAINT "fk_workflow_instances_document" FOREIGN KEY ("document_id") REFERENCES "documents"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workflow_instances_status" ON "workflow_instances" ("status");
+CREATE INDEX IF NOT EXISTS "idx_workflow_instances_document_id" ON "workflow_instances" ("document_id");
+CREATE INDEX IF NOT EXISTS "idx_workflow_instances_workflow_id" ON "workflow_instances" ("workflow_id");
+CREATE INDEX IF NOT EXISTS "idx_workflow_instances_tenant_id" ON "workflow_instances" ("tenant_id");
+
+CREATE TABLE "workflow_tasks" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "workflow_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "assigned_to" uuid NOT NULL,
+    "task_type" varchar(50) NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "priority" bigint NOT NULL DEFAULT 5,
+    "due_date" timestamptz,
+    "comments" text,
+    "completed_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    "instance_id" uuid,
+    "workflow_version" bigint NOT NULL DEFAULT 1,
+    "step_group_id" uuid,
+    "last_reminder_at" timestamptz,
+    "next_reminder_at" timestamptz,
+    "escalated_at" timestamptz,
+    "escalated_from" uuid,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_workflows_tasks" FOREIGN KEY ("workflow_id") REFERENCES "workflows"("id"),
+    CONSTRAINT "fk_documents_workflow_tasks" FOREIGN KEY ("document_id") REFERENCES "documents"("id"),
+    CONSTRAINT "fk_users_workflow_tasks" FOREIGN KEY ("assigned_to") REFERENCES "users"("id"),
+    CONSTRAINT "fk_workflow_instances_tasks" FOREIGN KEY ("instance_id") REFERENCES "workflow_instances"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workflow_tasks_next_reminder_at" ON "workflow_tasks" ("next_reminder_at");
+CREATE INDEX IF NOT EXISTS "idx_workflow_tasks_step_group_id" ON "workflow_tasks" ("step_group_id");
+CREATE INDEX IF NOT EXISTS "idx_workflow_tasks_instance_id" ON "workflow_tasks" ("instance_id");
+CREATE INDEX IF NOT EXISTS "idx_workflow_tasks_assigned_to" ON "workflow_tasks" ("assigned_to");
+CREATE INDEX IF NOT EXISTS "idx_workflow_tasks_document_id" ON "workflow_tasks" ("document_id");
+CREATE INDEX IF NOT EXISTS "idx_workflow_tasks_workflow_id" ON "workflow_tasks" ("workflow_id");
+
+CREATE TABLE "workflow_checklist_items" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "workflow_id" uuid NOT NULL,
+    "task_id" uuid NOT NULL,
+    "item_key" varchar(100) NOT NULL,
+    "label" varchar(255) NOT NULL,
+    "required" boolean NOT NULL DEFAULT true,
+    "position" bigint NOT NULL DEFAULT 0,
+    "is_checked" boolean NOT NULL DEFAULT false,
+    "checked_by" uuid,
+    "checked_at" timestamptz,
+    "note" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_workflow_tasks_checklist" FOREIGN KEY ("task_id") REFERENCES "workflow_tasks"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_task_checklist_item" ON "workflow_checklist_items" ("task_id","item_key");
+CREATE INDEX IF NOT EXISTS "idx_workflow_checklist_items_workflow_id" ON "workflow_checklist_items" ("workflow_id");
+CREATE INDEX IF NOT EXISTS "idx_workflow_checklist_items_tenant_id" ON "workflow_checklist_items" ("tenant_id");
+
+CREATE TABLE "workflow_task_delegations" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "task_id" uuid NOT NULL,
+    "from_user_id" uuid NOT NULL,
+    "to_user_id" uuid NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "reason" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_workflow_task_delegations_task" FOREIGN KEY ("task_id") REFERENCES "workflow_tasks"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workflow_task_delegations_task_id" ON "workflow_task_delegations" ("task_id");
+CREATE INDEX IF NOT EXISTS "idx_task_delegation_tenant_time" ON "workflow_task_delegations" ("tenant_id","created_at");
+
+CREATE TABLE "out_of_offices" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "delegate_id" uuid NOT NULL,
+    "starts_at" timestamptz NOT NULL,
+    "ends_at" timestamptz NOT NULL,
+    "note" varchar(255),
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "cancelled_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_out_of_offices_user_id" ON "out_of_offices" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_out_of_offices_tenant_id" ON "out_of_offices" ("tenant_id");
+
+CREATE TABLE "notifications" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "type" varchar(50) NOT NULL,
+    "title" varchar(255) NOT NULL,
+    "message" text NOT NULL,
+    "channel" varchar(20) NOT NULL,
+    "is_read" boolean NOT NULL DEFAULT false,
+    "data" jsonb,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_notifications_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "fk_notifications_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notifications_user_id" ON "notifications" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_notifications_tenant_id" ON "notifications" ("tenant_id");
+
+CREATE TABLE "notification_deliveries" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "channel" varchar(20) NOT NULL,
+    "recipient" text NOT NULL,
+    "type" varchar(50) NOT NULL,
+    "title" varchar(255) NOT NULL,
+    "message" text NOT NULL,
+    "data" jsonb,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "max_attempts" bigint NOT NULL DEFAULT 5,
+    "next_attempt_at" timestamptz NOT NULL DEFAULT now(),
+    "last_error" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "sent_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_notification_deliveries_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notification_delivery_due" ON "notification_deliveries" ("status","next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_notification_deliveries_user_id" ON "notification_deliveries" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_notification_deliveries_tenant_id" ON "notification_deliveries" ("tenant_id");
+
+CREATE TABLE "device_tokens" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "platform" varchar(20) NOT NULL,
+    "token" varchar(512) NOT NULL,
+    "device_name" varchar(255),
+    "app_version" varchar(50),
+    "is_active" boolean NOT NULL DEFAULT true,
+    "last_seen_at" timestamptz NOT NULL DEFAULT now(),
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_device_tokens_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "fk_device_tokens_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "uni_device_tokens_token" UNIQUE ("token")
+);
+CREATE INDEX IF NOT EXISTS "idx_device_tokens_user_id" ON "device_tokens" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_device_tokens_tenant_id" ON "device_tokens" ("tenant_id");
+
+CREATE TABLE "sms_messages" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "event_type" varchar(50) NOT NULL,
+    "to_number" varchar(20) NOT NULL,
+    "provider_message_id" varchar(100),
+    "segments" bigint NOT NULL DEFAULT 1,
+    "cost" decimal(10,4) NOT NULL DEFAULT 0,
+    "currency" varchar(3) DEFAULT 'USD',
+    "status" varchar(20) NOT NULL,
+    "error" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_sms_messages_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "fk_sms_messages_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_sms_messages_user_id" ON "sms_messages" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_sms_tenant_created" ON "sms_messages" ("tenant_id","created_at");
+
+CREATE TABLE "notification_templates" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "event_type" varchar(50) NOT NULL,
+    "channel" varchar(20) NOT NULL,
+    "subject" varchar(255) NOT NULL,
+    "body" text NOT NULL,
+    "updated_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_notification_templates_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_notification_template_event" ON "notification_templates" ("tenant_id","event_type","channel");
+
+CREATE TABLE "a_iprocessing_jobs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "job_type" varchar(50) NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'queued',
+    "priority" bigint NOT NULL DEFAULT 5,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "max_attempts" bigint NOT NULL DEFAULT 3,
+    "error_message" text,
+    "result" jsonb,
+    "processing_time_ms" bigint,
+    "provider" varchar(20),
+    "key_source" varchar(20),
+    "dry_run" boolean NOT NULL DEFAULT false,
+    "trace_parent" varchar(64),
+    "request_id" varchar(64),
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "started_at" timestamptz,
+    "completed_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_documents_ai_jobs" FOREIGN KEY ("document_id") REFERENCES "documents"("id"),
+    CONSTRAINT "fk_tenants_ai_jobs" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_a_iprocessing_jobs_request_id" ON "a_iprocessing_jobs" ("request_id");
+CREATE INDEX IF NOT EXISTS "idx_a_iprocessing_jobs_status" ON "a_iprocessing_jobs" ("status");
+CREATE INDEX IF NOT EXISTS "idx_a_iprocessing_jobs_document_id" ON "a_iprocessing_jobs" ("document_id");
+CREATE INDEX IF NOT EXISTS "idx_a_iprocessing_jobs_tenant_id" ON "a_iprocessing_jobs" ("tenant_id");
+
+CREATE TABLE "audit_logs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "resource_id" uuid NOT NULL,
+    "action" varchar(20) NOT NULL,
+    "resource_type" varchar(50) NOT NULL,
+    "ip_address" varchar(45),
+    "user_agent" text,
+    "details" jsonb,
+    "request_id" varchar(64),
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "sequence" bigint NOT NULL DEFAULT 0,
+    "prev_hash" varchar(64),
+    "hash" varchar(64),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_audit_logs_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "fk_audit_logs_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_sequence" ON "audit_logs" ("sequence");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_request_id" ON "audit_logs" ("request_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_resource_id" ON "audit_logs" ("resource_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_user_id" ON "audit_logs" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_tenant_id" ON "audit_logs" ("tenant_id");
+
+CREATE TABLE "audit_chain_heads" (
+    "tenant_id" uuid,
+    "sequence" bigint NOT NULL DEFAULT 0,
+    "hash" varchar(64),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("tenant_id")
+);
+
+CREATE TABLE "audit_archives" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "storage_path" text NOT NULL,
+    "first_sequence" bigint NOT NULL,
+    "last_sequence" bigint NOT NULL,
+    "first_prev_hash" varchar(64),
+    "last_hash" varchar(64),
+    "from_time" timestamptz NOT NULL,
+    "to_time" timestamptz NOT NULL,
+    "entry_count" bigint NOT NULL,
+    "size_bytes" bigint NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_audit_archives_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_archives_last_sequence" ON "audit_archives" ("last_sequence");
+CREATE INDEX IF NOT EXISTS "idx_audit_archives_tenant_id" ON "audit_archives" ("tenant_id");
+
+CREATE TABLE "audit_streams" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "endpoint" varchar(500) NOT NULL,
+    "secret" varchar(100),
+    "is_active" boolean NOT NULL DEFAULT true,
+    "last_sequence" bigint NOT NULL DEFAULT 0,
+    "last_delivery_at" timestamptz,
+    "last_error" text,
+    "failure_count" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_audit_streams_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_streams_tenant_id" ON "audit_streams" ("tenant_id");
+
+CREATE TABLE "shares" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "created_by" uuid NOT NULL,
+    "token" varchar(255) NOT NULL,
+    "password" varchar(255),
+    "expires_at" timestamptz,
+    "max_downloads" bigint DEFAULT 0,
+    "download_count" bigint DEFAULT 0,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_shares_document" FOREIGN KEY ("document_id") REFERENCES "documents"("id"),
+    CONSTRAINT "fk_shares_creator" FOREIGN KEY ("created_by") REFERENCES "users"("id"),
+    CONSTRAINT "fk_shares_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id"),
+    CONSTRAINT "uni_shares_token" UNIQUE ("token")
+);
+CREATE INDEX IF NOT EXISTS "idx_shares_created_by" ON "shares" ("created_by");
+CREATE INDEX IF NOT EXISTS "idx_shares_document_id" ON "shares" ("document_id");
+CREATE INDEX IF NOT EXISTS "idx_shares_tenant_id" ON "shares" ("tenant_id");
+
+CREATE TABLE "share_accesses" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "share_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "action" varchar(20) NOT NULL,
+    "success" boolean NOT NULL DEFAULT true,
+    "reason" varchar(100),
+    "ip_address" varchar(45),
+    "user_agent" text,
+    "accessed_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_share_accesses_share" FOREIGN KEY ("share_id") REFERENCES "shares"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_share_accesses_document_id" ON "share_accesses" ("document_id");
+CREATE INDEX IF NOT EXISTS "idx_share_access_share_time" ON "share_accesses" ("share_id","accessed_at");
+CREATE INDEX IF NOT EXISTS "idx_share_accesses_tenant_id" ON "share_accesses" ("tenant_id");
+
+CREATE TABLE "webhooks" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "url" varchar(500) NOT NULL,
+    "description" varchar(255),
+    "secret" varchar(100) NOT NULL,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "created_by" uuid NOT NULL,
+    "last_delivery_at" timestamptz,
+    "last_error" text,
+    "failure_count" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhooks_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhooks_tenant_id" ON "webhooks" ("tenant_id");
+
+CREATE TABLE "webhook_events" (
+    "webhook_id" uuid,
+    "event_type" varchar(100),
+    PRIMARY KEY ("webhook_id","event_type"),
+    CONSTRAINT "fk_webhooks_events" FOREIGN KEY ("webhook_id") REFERENCES "webhooks"("id")
+);
+
+CREATE TABLE "webhook_deliveries" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "webhook_id" uuid NOT NULL,
+    "event_id" uuid NOT NULL,
+    "event_type" varchar(100) NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "max_attempts" bigint NOT NULL DEFAULT 8,
+    "next_attempt_at" timestamptz NOT NULL DEFAULT now(),
+    "response_status" bigint,
+    "last_error" text,
+    "replay_of" uuid,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "delivered_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_deliveries_webhook" FOREIGN KEY ("webhook_id") REFERENCES "webhooks"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_created_at" ON "webhook_deliveries" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_due" ON "webhook_deliveries" ("status","next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_event_id" ON "webhook_deliveries" ("event_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_tenant_id" ON "webhook_deliveries" ("tenant_id");
+
+CREATE TABLE "webhook_delivery_attempts" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "webhook_id" uuid NOT NULL,
+    "delivery_id" uuid NOT NULL,
+    "attempt" bigint NOT NULL,
+    "payload" jsonb,
+    "response_status" bigint,
+    "response_body" text,
+    "latency_ms" bigint NOT NULL DEFAULT 0,
+    "error" text,
+    "attempted_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_attempts_attempted_at" ON "webhook_delivery_attempts" ("attempted_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_attempts_delivery_id" ON "webhook_delivery_attempts" ("delivery_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_attempts_tenant_id" ON "webhook_delivery_attempts" ("tenant_id");
+
+CREATE TABLE "scheduled_jobs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "job_type" varchar(50) NOT NULL,
+    "cron_expression" varchar(100) NOT NULL,
+    "timezone" varchar(64) NOT NULL DEFAULT 'UTC',
+    "parameters" jsonb,
+    "is_enabled" boolean NOT NULL DEFAULT true,
+    "next_run_at" timestamptz,
+    "last_run_at" timestamptz,
+    "last_status" varchar(20),
+    "last_error" text,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_scheduled_jobs_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_scheduled_job_due" ON "scheduled_jobs" ("is_enabled","next_run_at");
+CREATE INDEX IF NOT EXISTS "idx_scheduled_jobs_tenant_id" ON "scheduled_jobs" ("tenant_id");
+
+CREATE TABLE "scheduled_job_runs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "scheduled_job_id" uuid NOT NULL,
+    "job_type" varchar(50) NOT NULL,
+    "scheduled_for" timestamptz NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'queued',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "lease_until" timestamptz,
+    "started_at" timestamptz,
+    "completed_at" timestamptz,
+    "error" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_scheduled_job_run_due" ON "scheduled_job_runs" ("status","lease_until");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_scheduled_job_run_slot" ON "scheduled_job_runs" ("scheduled_job_id","scheduled_for");
+CREATE INDEX IF NOT EXISTS "idx_scheduled_job_runs_tenant_id" ON "scheduled_job_runs" ("tenant_id");
+
+CREATE TABLE "legal_holds" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "name" varchar(255) NOT NULL,
+    "reason" text NOT NULL,
+    "scope" varchar(20) NOT NULL,
+    "criteria" jsonb,
+    "placed_by" uuid NOT NULL,
+    "placed_at" timestamptz NOT NULL DEFAULT now(),
+    "lifted_by" uuid,
+    "lifted_at" timestamptz,
+    "lift_reason" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_legal_holds_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_legal_holds_lifted_at" ON "legal_holds" ("lifted_at");
+CREATE INDEX IF NOT EXISTS "idx_legal_holds_tenant_id" ON "legal_holds" ("tenant_id");
+
+CREATE TABLE "legal_hold_documents" (
+    "legal_hold_id" uuid,
+    "document_id" uuid,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("legal_hold_id","document_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_legal_hold_documents_document_id" ON "legal_hold_documents" ("document_id");
+
+CREATE TABLE "data_subject_requests" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "subject_id" uuid NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "lease_until" timestamptz,
+    "storage_path" text,
+    "size_bytes" bigint,
+    "error" text,
+    "requested_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "started_at" timestamptz,
+    "completed_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_data_subject_requests_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_data_subject_due" ON "data_subject_requests" ("status","lease_until");
+CREATE INDEX IF NOT EXISTS "idx_data_subject_requests_subject_id" ON "data_subject_requests" ("subject_id");
+CREATE INDEX IF NOT EXISTS "idx_data_subject_requests_tenant_id" ON "data_subject_requests" ("tenant_id");
+
+CREATE TABLE "tenant_deletions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "tenant_name" varchar(255) NOT NULL,
+    "subdomain" varchar(100) NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'exporting',
+    "reason" text,
+    "requested_by" uuid NOT NULL,
+    "requested_at" timestamptz NOT NULL DEFAULT now(),
+    "purge_after" timestamptz NOT NULL,
+    "export_path" text,
+    "export_size" bigint,
+    "stage" varchar(20),
+    "progress" jsonb,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "lease_until" timestamptz,
+    "error" text,
+    "cancelled_by" uuid,
+    "cancelled_at" timestamptz,
+    "started_at" timestamptz,
+    "completed_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tenant_deletion_due" ON "tenant_deletions" ("status","lease_until");
+CREATE INDEX IF NOT EXISTS "idx_tenant_deletions_tenant_id" ON "tenant_deletions" ("tenant_id");
+
+CREATE TABLE "tenant_deletion_certificates" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "deletion_id" uuid NOT NULL,
+    "tenant_id" uuid NOT NULL,
+    "tenant_name" varchar(255) NOT NULL,
+    "subdomain" varchar(100) NOT NULL,
+    "requested_by" uuid NOT NULL,
+    "requested_at" timestamptz NOT NULL,
+    "started_at" timestamptz NOT NULL,
+    "completed_at" timestamptz NOT NULL,
+    "rows_deleted" jsonb,
+    "files_deleted" bigint NOT NULL DEFAULT 0,
+    "bytes_deleted" bigint NOT NULL DEFAULT 0,
+    "accounts" bigint NOT NULL DEFAULT 0,
+    "hash" varchar(64) NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tenant_deletion_certificates_tenant_id" ON "tenant_deletion_certificates" ("tenant_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tenant_deletion_certificates_deletion_id" ON "tenant_deletion_certificates" ("deletion_id");
+
+CREATE TABLE "api_usages" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "period" date NOT NULL,
+    "requests" bigint NOT NULL DEFAULT 0,
+    "quota_rejected" bigint NOT NULL DEFAULT 0,
+    "rate_limited" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_usage_tenant_period" ON "api_usages" ("tenant_id","period");
+
+CREATE TABLE "vendor_profiles" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "vendor_name" varchar(255) NOT NULL,
+    "normalized_name" varchar(255) NOT NULL,
+    "date_format" varchar(20),
+    "currency" varchar(3),
+    "document_number_label" varchar(100),
+    "hints" text,
+    "source" varchar(20) NOT NULL DEFAULT 'configured',
+    "correction_count" bigint NOT NULL DEFAULT 0,
+    "last_corrected_at" timestamptz,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_vendor_profile_tenant_name" ON "vendor_profiles" ("tenant_id","normalized_name");
+
+CREATE TABLE "vendor_profile_aliases" (
+    "profile_id" uuid,
+    "tenant_id" uuid NOT NULL,
+    "alias" varchar(255),
+    PRIMARY KEY ("profile_id","alias"),
+    CONSTRAINT "fk_vendor_profiles_aliases" FOREIGN KEY ("profile_id") REFERENCES "vendor_profiles"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_vendor_profile_alias_tenant" ON "vendor_profile_aliases" ("tenant_id","alias");
+
+CREATE TABLE "upload_sessions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "storage_path" varchar(1000) NOT NULL,
+    "filename" varchar(255) NOT NULL,
+    "content_type" varchar(100) NOT NULL,
+    "file_size" bigint NOT NULL,
+    "content_hash" varchar(64) NOT NULL,
+    "params" jsonb,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "failure_reason" text,
+    "document_id" uuid,
+    "expires_at" timestamptz NOT NULL,
+    "finalized_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_upload_sessions_expires_at" ON "upload_sessions" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_upload_sessions_status" ON "upload_sessions" ("status");
+CREATE INDEX IF NOT EXISTS "idx_upload_sessions_tenant_id" ON "upload_sessions" ("tenant_id");
+
+CREATE TABLE "worker_heartbeats" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "instance_id" varchar(100) NOT NULL,
+    "worker" varchar(100) NOT NULL,
+    "hostname" varchar(255),
+    "p_id" bigint,
+    "poll_interval" bigint NOT NULL DEFAULT 0,
+    "last_error" text,
+    "started_at" timestamptz NOT NULL,
+    "last_poll_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_worker_heartbeats_last_poll_at" ON "worker_heartbeats" ("last_poll_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_worker_heartbeat_instance_worker" ON "worker_heartbeats" ("instance_id","worker");
+
+CREATE TABLE "bulk_label_jobs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "action" varchar(10) NOT NULL,
+    "criteria" jsonb,
+    "labels" jsonb,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "matched" bigint,
+    "processed" bigint,
+    "cursor" uuid,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "lease_until" timestamptz,
+    "error" text,
+    "requested_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "started_at" timestamptz,
+    "completed_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_bulk_label_due" ON "bulk_label_jobs" ("status","lease_until");
+CREATE INDEX IF NOT EXISTS "idx_bulk_label_jobs_tenant_id" ON "bulk_label_jobs" ("tenant_id");
+
+CREATE TABLE "numbering_sequences" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_type" varchar(50) NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "prefix" varchar(50),
+    "padding" bigint NOT NULL DEFAULT 5,
+    "reset_interval" varchar(20) NOT NULL DEFAULT 'never',
+    "last_value" bigint NOT NULL DEFAULT 0,
+    "current_period" varchar(7),
+    "is_active" boolean NOT NULL DEFAULT true,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_numbering_sequence_tenant_type" ON "numbering_sequences" ("tenant_id","document_type");
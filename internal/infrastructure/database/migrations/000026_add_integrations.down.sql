@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "import_sync_items" CASCADE;
+DROP TABLE IF EXISTS "import_syncs" CASCADE;
+DROP TABLE IF EXISTS "integrations" CASCADE;
@@ -0,0 +1,61 @@
+-- Integrations: OAuth connections to external storage and the import syncs
+-- copying their folders into documents
+
+CREATE TABLE IF NOT EXISTS "integrations" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "provider" varchar(50) NOT NULL,
+    "account_email" varchar(255),
+    "encrypted_token" text NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'active',
+    "last_error" text,
+    "connected_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_integrations_tenant_id" ON "integrations" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "import_syncs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "integration_id" uuid NOT NULL,
+    "remote_folder_id" varchar(255) NOT NULL,
+    "remote_folder_name" varchar(500),
+    "target_folder_id" uuid,
+    "uploader_id" uuid NOT NULL,
+    "enable_ai" boolean NOT NULL DEFAULT false,
+    "mode" varchar(20) NOT NULL DEFAULT 'once',
+    "interval_minutes" bigint NOT NULL DEFAULT 0,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "next_run_at" timestamptz,
+    "last_run_at" timestamptz,
+    "files_imported" bigint NOT NULL DEFAULT 0,
+    "files_updated" bigint NOT NULL DEFAULT 0,
+    "files_skipped" bigint NOT NULL DEFAULT 0,
+    "last_error" text,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_import_syncs_integration_id" ON "import_syncs" ("integration_id");
+CREATE INDEX IF NOT EXISTS "idx_import_syncs_next_run_at" ON "import_syncs" ("next_run_at");
+CREATE INDEX IF NOT EXISTS "idx_import_syncs_tenant_id" ON "import_syncs" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "import_sync_items" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "sync_id" uuid NOT NULL,
+    "remote_id" varchar(255) NOT NULL,
+    "is_folder" boolean NOT NULL DEFAULT false,
+    "version" varchar(255),
+    "document_id" uuid,
+    "folder_id" uuid,
+    "skipped" varchar(255),
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_import_sync_items_tenant_id" ON "import_sync_items" ("tenant_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_import_sync_item_remote" ON "import_sync_items" ("sync_id","remote_id");
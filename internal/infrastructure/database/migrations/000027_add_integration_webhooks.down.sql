@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "idx_import_syncs_webhook_id";
+ALTER TABLE "import_syncs" DROP COLUMN IF EXISTS "webhook_id";
+ALTER TABLE "import_syncs" DROP COLUMN IF EXISTS "files_deleted";
+
+DROP INDEX IF EXISTS "idx_integrations_external_account_id";
+ALTER TABLE "integrations" DROP COLUMN IF EXISTS "external_account_id";
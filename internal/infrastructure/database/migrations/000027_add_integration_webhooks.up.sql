@@ -0,0 +1,9 @@
+-- Change webhooks name accounts and subscriptions; import syncs count the
+-- documents trashed when their remote file was deleted
+
+ALTER TABLE "integrations" ADD COLUMN IF NOT EXISTS "external_account_id" varchar(255);
+CREATE INDEX IF NOT EXISTS "idx_integrations_external_account_id" ON "integrations" ("external_account_id");
+
+ALTER TABLE "import_syncs" ADD COLUMN IF NOT EXISTS "files_deleted" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "import_syncs" ADD COLUMN IF NOT EXISTS "webhook_id" varchar(255);
+CREATE INDEX IF NOT EXISTS "idx_import_syncs_webhook_id" ON "import_syncs" ("webhook_id");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "archival_run_documents" CASCADE;
+DROP TABLE IF EXISTS "archival_runs" CASCADE;
+DROP TABLE IF EXISTS "archival_schedules" CASCADE;
@@ -0,0 +1,57 @@
+-- Archival schedules: per-folder schedules archiving documents, and the runs
+-- that archived them, kept so a run can be undone
+
+CREATE TABLE IF NOT EXISTS "archival_schedules" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "folder_id" uuid NOT NULL,
+    "name" varchar(255) NOT NULL,
+    "include_subfolders" boolean NOT NULL DEFAULT true,
+    "min_age_days" bigint NOT NULL DEFAULT 0,
+    "cold_storage" boolean NOT NULL DEFAULT false,
+    "recurrence" varchar(20) NOT NULL DEFAULT 'once',
+    "enabled" boolean NOT NULL DEFAULT true,
+    "next_run_at" timestamptz,
+    "last_run_at" timestamptz,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_archival_schedules_folder_id" ON "archival_schedules" ("folder_id");
+CREATE INDEX IF NOT EXISTS "idx_archival_schedules_next_run_at" ON "archival_schedules" ("next_run_at");
+CREATE INDEX IF NOT EXISTS "idx_archival_schedules_tenant_id" ON "archival_schedules" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "archival_runs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "schedule_id" uuid NOT NULL,
+    "folder_id" uuid NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'running',
+    "documents_archived" bigint NOT NULL DEFAULT 0,
+    "bytes_archived" bigint NOT NULL DEFAULT 0,
+    "documents_held" bigint NOT NULL DEFAULT 0,
+    "cold_storage" boolean NOT NULL DEFAULT false,
+    "undo_until" timestamptz NOT NULL,
+    "cold_stored_at" timestamptz,
+    "undone_at" timestamptz,
+    "undone_by" uuid,
+    "last_error" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_archival_runs_schedule_id" ON "archival_runs" ("schedule_id");
+CREATE INDEX IF NOT EXISTS "idx_archival_runs_tenant_id" ON "archival_runs" ("tenant_id");
+
+CREATE TABLE IF NOT EXISTS "archival_run_documents" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "run_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "previous_status" varchar(20) NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_archival_run_documents_tenant_id" ON "archival_run_documents" ("tenant_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_archival_run_document" ON "archival_run_documents" ("run_id","document_id");
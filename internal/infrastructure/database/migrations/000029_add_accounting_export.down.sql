@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "idx_documents_accounting_status";
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "accounting_updated_at";
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "accounting_error";
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "accounting_bill_id";
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "accounting_integration_id";
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "accounting_status";
@@ -0,0 +1,8 @@
+-- Documents record the bill booked for them in the tenant's accounting system
+
+ALTER TABLE "documents" ADD COLUMN IF NOT EXISTS "accounting_status" varchar(20);
+ALTER TABLE "documents" ADD COLUMN IF NOT EXISTS "accounting_integration_id" uuid;
+ALTER TABLE "documents" ADD COLUMN IF NOT EXISTS "accounting_bill_id" varchar(255);
+ALTER TABLE "documents" ADD COLUMN IF NOT EXISTS "accounting_error" text;
+ALTER TABLE "documents" ADD COLUMN IF NOT EXISTS "accounting_updated_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_documents_accounting_status" ON "documents" ("accounting_status");
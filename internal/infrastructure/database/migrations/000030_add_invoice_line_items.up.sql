@@ -0,0 +1,22 @@
+-- Invoice line items: the lines of invoices and receipts, filled in by
+-- financial extraction and corrected by users
+
+CREATE TABLE IF NOT EXISTS "invoice_line_items" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "position" bigint NOT NULL DEFAULT 0,
+    "description" text,
+    "quantity" decimal(15,4),
+    "unit_price" decimal(15,4),
+    "tax_amount" decimal(15,2),
+    "amount" decimal(15,2) NOT NULL,
+    "gl_code" varchar(100),
+    "source" varchar(20) NOT NULL DEFAULT 'extracted',
+    "updated_by" uuid,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_invoice_line_items_document_id" ON "invoice_line_items" ("document_id");
+CREATE INDEX IF NOT EXISTS "idx_invoice_line_items_tenant_id" ON "invoice_line_items" ("tenant_id");
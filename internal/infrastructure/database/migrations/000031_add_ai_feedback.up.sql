@@ -0,0 +1,18 @@
+-- AI feedback: users' corrections of AI output, shown to the AI provider as
+-- examples for the tenant's later jobs
+
+CREATE TABLE IF NOT EXISTS "ai_feedbacks" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "kind" varchar(20) NOT NULL,
+    "original" jsonb,
+    "corrected" jsonb,
+    "excerpt" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_ai_feedbacks_created_at" ON "ai_feedbacks" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_ai_feedbacks_document_id" ON "ai_feedbacks" ("document_id");
+CREATE INDEX IF NOT EXISTS "idx_ai_feedbacks_tenant_id" ON "ai_feedbacks" ("tenant_id");
@@ -0,0 +1,21 @@
+-- AI usage: the tokens and cost of each AI provider call, reported per
+-- tenant and checked against the tenant's monthly AI budget
+
+CREATE TABLE IF NOT EXISTS "ai_usages" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "job_id" uuid,
+    "document_id" uuid,
+    "provider" varchar(50) NOT NULL,
+    "model" varchar(100),
+    "operation" varchar(50) NOT NULL,
+    "key_source" varchar(20),
+    "input_tokens" bigint NOT NULL DEFAULT 0,
+    "output_tokens" bigint NOT NULL DEFAULT 0,
+    "estimated" boolean NOT NULL DEFAULT false,
+    "cost" decimal(12,6) NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_ai_usage_tenant_created" ON "ai_usages" ("tenant_id", "created_at");
+CREATE INDEX IF NOT EXISTS "idx_ai_usages_job_id" ON "ai_usages" ("job_id");
@@ -0,0 +1,17 @@
+-- Document chunks: passages of documents' text with their embeddings,
+-- retrieved to answer questions about documents. Embedding dimensions
+-- depend on the model, so the column is untyped and searches compare only
+-- vectors of the query's dimensions within a tenant.
+
+CREATE TABLE IF NOT EXISTS "document_chunks" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "chunk_index" bigint NOT NULL,
+    "content" text NOT NULL,
+    "embedding" vector NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_document_chunks_tenant_id" ON "document_chunks" ("tenant_id");
+CREATE INDEX IF NOT EXISTS "idx_document_chunks_document_id" ON "document_chunks" ("document_id");
@@ -0,0 +1,5 @@
+DELETE FROM "document_chunks" WHERE "embedding" IS NULL;
+ALTER TABLE "document_chunks" ALTER COLUMN "embedding" SET NOT NULL;
+ALTER TABLE "document_chunks" DROP COLUMN IF EXISTS "end_offset";
+ALTER TABLE "document_chunks" DROP COLUMN IF EXISTS "start_offset";
+ALTER TABLE "document_chunks" DROP COLUMN IF EXISTS "page";
@@ -0,0 +1,9 @@
+-- Document chunks record where their passage lies in the document's text,
+-- and its page when the text has form-feed page breaks. Passages are stored
+-- by a chunking job before they are embedded, so embeddings may be missing;
+-- searches skip passages without one.
+
+ALTER TABLE "document_chunks" ADD COLUMN IF NOT EXISTS "page" bigint;
+ALTER TABLE "document_chunks" ADD COLUMN IF NOT EXISTS "start_offset" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "document_chunks" ADD COLUMN IF NOT EXISTS "end_offset" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "document_chunks" ALTER COLUMN "embedding" DROP NOT NULL;
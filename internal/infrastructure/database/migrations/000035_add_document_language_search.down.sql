@@ -0,0 +1,3 @@
+-- The previous build creates idx_documents_text_gin again with its
+-- SchemaIndexes
+DROP INDEX IF EXISTS "idx_documents_search_gin";
@@ -0,0 +1,6 @@
+-- Documents are full-text indexed in the text search configuration of their
+-- language. idx_documents_search_gin replaces idx_documents_text_gin, which
+-- didn't cover titles; it is built concurrently after the migrations, with
+-- the other SchemaIndexes.
+
+DROP INDEX IF EXISTS "idx_documents_text_gin";
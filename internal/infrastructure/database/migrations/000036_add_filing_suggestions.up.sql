@@ -0,0 +1,22 @@
+-- Filing suggestions: the folder, category and tags AI suggests filing a
+-- document under, one per document, until a user accepts them
+
+CREATE TABLE IF NOT EXISTS "filing_suggestions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "job_id" uuid,
+    "folder_id" uuid,
+    "category_id" uuid,
+    "tags" jsonb,
+    "confidence" decimal(3,2),
+    "reason" text,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "reviewed_by" uuid,
+    "reviewed_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_filing_suggestions_document_id" ON "filing_suggestions" ("document_id");
+CREATE INDEX IF NOT EXISTS "idx_filing_suggestions_tenant_id" ON "filing_suggestions" ("tenant_id");
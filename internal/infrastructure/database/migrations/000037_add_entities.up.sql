@@ -0,0 +1,16 @@
+-- Entities: the people, organizations, locations, dates, amounts and emails
+-- documents mention, indexed from entity extraction for browsing documents
+-- by entity
+
+CREATE TABLE IF NOT EXISTS "entities" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "type" varchar(20) NOT NULL,
+    "value" varchar(255) NOT NULL,
+    "normalized_value" varchar(255) NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_entity_tenant_value" ON "entities" ("tenant_id","type","normalized_value");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_entity_document_value" ON "entities" ("document_id","type","normalized_value");
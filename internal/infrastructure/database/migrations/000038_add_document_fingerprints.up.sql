@@ -0,0 +1,15 @@
+-- Document fingerprints: fuzzy hashes of documents' text and images,
+-- compared bit by bit to find near-duplicate documents
+
+CREATE TABLE IF NOT EXISTS "document_fingerprints" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "text_hash" bigint,
+    "image_hash" bigint,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_document_fingerprints_document_id" ON "document_fingerprints" ("document_id");
+CREATE INDEX IF NOT EXISTS "idx_document_fingerprints_tenant_id" ON "document_fingerprints" ("tenant_id");
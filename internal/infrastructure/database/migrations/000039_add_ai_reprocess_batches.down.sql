@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "idx_ai_processing_jobs_batch_id";
+ALTER TABLE "ai_processing_jobs" DROP COLUMN IF EXISTS "batch_id";
+DROP TABLE IF EXISTS "ai_reprocess_batches" CASCADE;
@@ -0,0 +1,29 @@
+-- AI reprocessing batches: AI jobs queued again for every document matching
+-- a search, queued by a background worker a few documents at a time. Jobs
+-- record the batch that queued them so its progress can be followed.
+
+CREATE TABLE IF NOT EXISTS "ai_reprocess_batches" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "job_types" jsonb,
+    "criteria" jsonb,
+    "priority" bigint NOT NULL DEFAULT 9,
+    "max_in_flight" bigint NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'pending',
+    "matched" bigint,
+    "queued" bigint,
+    "cursor" uuid,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "lease_until" timestamptz,
+    "error" text,
+    "requested_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "started_at" timestamptz,
+    "completed_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_ai_reprocess_batches_tenant_id" ON "ai_reprocess_batches" ("tenant_id");
+CREATE INDEX IF NOT EXISTS "idx_ai_reprocess_due" ON "ai_reprocess_batches" ("status","lease_until");
+
+ALTER TABLE "ai_processing_jobs" ADD COLUMN IF NOT EXISTS "batch_id" uuid;
+CREATE INDEX IF NOT EXISTS "idx_ai_processing_jobs_batch_id" ON "ai_processing_jobs" ("batch_id");
@@ -0,0 +1,17 @@
+-- Prompt templates: tenants' rewordings of the instructions AI jobs send,
+-- versioned, with at most one version per kind active.
+
+CREATE TABLE IF NOT EXISTS "prompt_templates" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "kind" varchar(30) NOT NULL,
+    "version" bigint NOT NULL,
+    "body" text NOT NULL,
+    "note" varchar(255),
+    "is_active" boolean NOT NULL DEFAULT false,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_prompt_templates_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_prompt_template_version" ON "prompt_templates" ("tenant_id","kind","version");
@@ -0,0 +1,18 @@
+-- Document type definitions: tenants' own document types, optionally mapped
+-- to the built-in type they behave like.
+
+CREATE TABLE IF NOT EXISTS "document_type_definitions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "tenant_id" uuid NOT NULL,
+    "key" varchar(50) NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "description" text,
+    "maps_to" varchar(50),
+    "is_active" boolean NOT NULL DEFAULT true,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_document_type_definitions_tenant" FOREIGN KEY ("tenant_id") REFERENCES "tenants"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_document_type_key" ON "document_type_definitions" ("tenant_id","key");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_sms_to_number_created";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "phone_verification_attempts";
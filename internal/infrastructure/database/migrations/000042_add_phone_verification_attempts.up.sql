@@ -0,0 +1,5 @@
+-- Phone verification codes allow a few guesses, and verification texts are
+-- rate limited per number as well as per user.
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "phone_verification_attempts" bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS "idx_sms_to_number_created" ON "sms_messages" ("to_number","created_at");
@@ -0,0 +1,25 @@
+-- Document fingerprint bands: the 8-bit bands of each fingerprint hash, so
+-- near-duplicates are looked for among documents sharing a band rather than
+-- across every pair of the tenant's documents
+
+CREATE TABLE IF NOT EXISTS "document_fingerprint_bands" (
+    "tenant_id" uuid NOT NULL,
+    "document_id" uuid NOT NULL,
+    "method" varchar(10) NOT NULL,
+    "band" smallint NOT NULL,
+    "value" smallint NOT NULL,
+    PRIMARY KEY ("document_id","method","band")
+);
+CREATE INDEX IF NOT EXISTS "idx_fingerprint_band_bucket" ON "document_fingerprint_bands" ("tenant_id","method","band","value");
+
+INSERT INTO "document_fingerprint_bands" ("tenant_id","document_id","method","band","value")
+SELECT "tenant_id", "document_id", 'text', band, ("text_hash" >> (band * 8)) & 255
+FROM "document_fingerprints", generate_series(0, 7) AS band
+WHERE "text_hash" IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO "document_fingerprint_bands" ("tenant_id","document_id","method","band","value")
+SELECT "tenant_id", "document_id", 'image', band, ("image_hash" >> (band * 8)) & 255
+FROM "document_fingerprints", generate_series(0, 7) AS band
+WHERE "image_hash" IS NOT NULL
+ON CONFLICT DO NOTHING;
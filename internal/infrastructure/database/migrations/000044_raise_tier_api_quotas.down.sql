@@ -0,0 +1,3 @@
+UPDATE "tenants" SET "api_quota" = 1000 WHERE "subscription_tier" = 'starter' AND "api_quota" = 100000;
+UPDATE "tenants" SET "api_quota" = 10000 WHERE "subscription_tier" = 'professional' AND "api_quota" = 1000000;
+UPDATE "tenants" SET "api_quota" = 100000 WHERE "subscription_tier" = 'enterprise' AND "api_quota" = 10000000;
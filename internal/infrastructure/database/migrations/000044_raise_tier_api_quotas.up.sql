@@ -0,0 +1,9 @@
+-- Monthly API quotas are enforced from tenants' api_quota. Tenants still on
+-- their tier's old default get the tier's request quota; quotas set by hand
+-- are kept. api_used now holds the month's metered requests, which the next
+-- usage flush sets.
+
+UPDATE "tenants" SET "api_quota" = 100000 WHERE "subscription_tier" = 'starter' AND "api_quota" = 1000;
+UPDATE "tenants" SET "api_quota" = 1000000 WHERE "subscription_tier" = 'professional' AND "api_quota" = 10000;
+UPDATE "tenants" SET "api_quota" = 10000000 WHERE "subscription_tier" = 'enterprise' AND "api_quota" = 100000;
+UPDATE "tenants" SET "api_used" = 0;
@@ -0,0 +1,10 @@
+UPDATE "tenants" SET
+    "document_count" = GREATEST("document_count" - archived.documents, 0),
+    "storage_used" = GREATEST("storage_used" - archived.bytes, 0)
+FROM (
+    SELECT "tenant_id", COUNT(*) AS documents, COALESCE(SUM("file_size"), 0) AS bytes
+    FROM "documents"
+    WHERE "status" = 'archived' AND "deleted_at" IS NULL
+    GROUP BY "tenant_id"
+) AS archived
+WHERE "tenants"."id" = archived."tenant_id";
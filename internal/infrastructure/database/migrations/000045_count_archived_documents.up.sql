@@ -0,0 +1,14 @@
+-- Archived documents count against the tenant's quota again, since their
+-- files stay in storage. Archiving gave their storage and slots back, so
+-- add the documents still archived back to the tenants' usage.
+
+UPDATE "tenants" SET
+    "document_count" = "document_count" + archived.documents,
+    "storage_used" = "storage_used" + archived.bytes
+FROM (
+    SELECT "tenant_id", COUNT(*) AS documents, COALESCE(SUM("file_size"), 0) AS bytes
+    FROM "documents"
+    WHERE "status" = 'archived' AND "deleted_at" IS NULL
+    GROUP BY "tenant_id"
+) AS archived
+WHERE "tenants"."id" = archived."tenant_id";
@@ -0,0 +1 @@
+ALTER TABLE "workflow_tasks" DROP COLUMN IF EXISTS "step_number";
@@ -0,0 +1,6 @@
+-- Workflow tasks record the step they are for instead of reusing their
+-- priority, which escalation rules and delegation matched steps against.
+-- Tasks were created with the step number as their priority.
+
+ALTER TABLE "workflow_tasks" ADD COLUMN IF NOT EXISTS "step_number" bigint NOT NULL DEFAULT 0;
+UPDATE "workflow_tasks" SET "step_number" = "priority" WHERE "step_number" = 0;
@@ -0,0 +1,102 @@
+// Package migrations holds the versioned SQL migrations applied to
+// PostgreSQL databases by the migrate command, embedded in the binary.
+//
+// Each version is a pair of files, NNNNNN_name.up.sql and
+// NNNNNN_name.down.sql, run in a single transaction. The version numbers
+// continue database.SchemaVersion, which must be raised to the new version
+// along with the models. Development databases (SQLite) and databases
+// upgraded from before the baseline are built from the models instead, so
+// migrations must not fail on objects that already exist: use IF NOT EXISTS
+// and IF EXISTS.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Files are the migration files
+//
+//go:embed *.sql
+var Files embed.FS
+
+// fileName matches migration file names, e.g. 000026_add_invoice_terms.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_[a-z0-9_]+\.(up|down)\.sql$`)
+
+// Versions returns the migration versions in order. Every version must have
+// both an up and a down file.
+func Versions() ([]uint, error) {
+	entries, err := fs.ReadDir(Files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	directions := map[uint]map[string]bool{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s is not named NNNNNN_name.up.sql or NNNNNN_name.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version: %w", entry.Name(), err)
+		}
+		if directions[uint(version)] == nil {
+			directions[uint(version)] = map[string]bool{}
+		}
+		if directions[uint(version)][match[2]] {
+			return nil, fmt.Errorf("migration version %d has more than one %s file", version, match[2])
+		}
+		directions[uint(version)][match[2]] = true
+	}
+
+	versions := make([]uint, 0, len(directions))
+	for version, files := range directions {
+		if !files["up"] || !files["down"] {
+			return nil, fmt.Errorf("migration version %d needs both an up and a down file", version)
+		}
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// Latest returns the highest migration version
+func Latest() (uint, error) {
+	versions, err := Versions()
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, fmt.Errorf("no migrations found")
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Pending returns the up files of the migrations after the applied version
+func Pending(applied uint) ([]string, error) {
+	// Checks every file is named properly, so the names can be parsed below
+	if _, err := Versions(); err != nil {
+		return nil, err
+	}
+
+	// Sorted by name, so by version
+	entries, err := fs.ReadDir(Files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var pending []string
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		version, _ := strconv.ParseUint(match[1], 10, 32)
+		if match[2] == "up" && uint(version) > applied {
+			pending = append(pending, entry.Name())
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,22 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationVersions(t *testing.T) {
+	versions, err := migrations.Versions()
+	require.NoError(t, err)
+	require.NotEmpty(t, versions)
+
+	// Versioning starts at the baseline and the build expects the latest
+	assert.Equal(t, uint(database.BaselineVersion), versions[0])
+	latest, err := migrations.Latest()
+	require.NoError(t, err)
+	assert.Equal(t, uint(database.SchemaVersion), latest)
+}
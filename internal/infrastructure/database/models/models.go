@@ -1,9 +1,12 @@
 package models
 
 import (
+	"crypto/sha256"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,8 +21,23 @@ type ProcessingStatus string
 type AuditAction string
 type DocumentType string
 type WorkflowStatus string
+type WorkflowMigrationPolicy string
 type NotificationChannel string
 type ComplianceStatus string
+type DevicePlatform string
+type FolderPermission string
+type DocumentPermission string
+type WebhookDeliveryStatus string
+type NotificationDeliveryStatus string
+type ScheduledJobRunStatus string
+type LegalHoldScope string
+type DataSubjectRequestType string
+type DataSubjectRequestStatus string
+type AuditStreamType string
+type TenantDeletionStatus string
+type DocumentCheck string
+type CheckStatus string
+type AccountingSyncStatus string
 
 const (
 	// Document Status
@@ -59,6 +77,7 @@ const (
 	AuditShare    AuditAction = "share"
 	AuditApprove  AuditAction = "approve"
 	AuditReject   AuditAction = "reject"
+	AuditBlocked  AuditAction = "blocked"
 
 	// Document Types for SMB
 	DocTypeInvoice       DocumentType = "invoice"
@@ -81,20 +100,120 @@ const (
 	WorkflowApproved  WorkflowStatus = "approved"
 	WorkflowRejected  WorkflowStatus = "rejected"
 	WorkflowEscalated WorkflowStatus = "escalated"
+	WorkflowCancelled WorkflowStatus = "cancelled" // No longer needed, e.g. its step already reached quorum
+
+	// Workflow Migration Policies: what happens to runs in progress when rules change
+	WorkflowFinishOnOld WorkflowMigrationPolicy = "finish"  // Runs finish on the version they started on
+	WorkflowMigrate     WorkflowMigrationPolicy = "migrate" // Runs move to the new version from their current step
 
 	// Notification Channels
 	NotifyEmail   NotificationChannel = "email"
 	NotifySlack   NotificationChannel = "slack"
 	NotifyWebhook NotificationChannel = "webhook"
 	NotifyInApp   NotificationChannel = "in_app"
+	NotifyPush    NotificationChannel = "push"
+	NotifySMS     NotificationChannel = "sms"
 
 	// Compliance Status
 	ComplianceCompliant    ComplianceStatus = "compliant"
 	ComplianceNonCompliant ComplianceStatus = "non_compliant"
 	CompliancePending      ComplianceStatus = "pending"
 	ComplianceExempt       ComplianceStatus = "exempt"
+
+	// Device Platforms (push notifications)
+	DevicePlatformIOS     DevicePlatform = "ios"
+	DevicePlatformAndroid DevicePlatform = "android"
+	DevicePlatformWeb     DevicePlatform = "web"
+
+	// Folder Permissions (ordered: manage implies write implies read)
+	FolderPermRead   FolderPermission = "read"
+	FolderPermWrite  FolderPermission = "write"
+	FolderPermManage FolderPermission = "manage"
+
+	// Document Permissions (write implies read)
+	DocPermRead  DocumentPermission = "read"
+	DocPermWrite DocumentPermission = "write"
+
+	// Webhook Delivery Status
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+
+	// Notification Delivery Status (email/Slack outbox)
+	NotificationDeliveryPending NotificationDeliveryStatus = "pending"
+	NotificationDeliverySent    NotificationDeliveryStatus = "sent"
+	NotificationDeliveryFailed  NotificationDeliveryStatus = "failed"
+
+	// Scheduled Job Run Status
+	ScheduledJobRunQueued    ScheduledJobRunStatus = "queued"
+	ScheduledJobRunRunning   ScheduledJobRunStatus = "running"
+	ScheduledJobRunSucceeded ScheduledJobRunStatus = "succeeded"
+	ScheduledJobRunFailed    ScheduledJobRunStatus = "failed"
+
+	// Legal Hold Scopes
+	LegalHoldScopeDocuments LegalHoldScope = "documents"
+	LegalHoldScopeFolder    LegalHoldScope = "folder"
+	LegalHoldScopeSearch    LegalHoldScope = "search"
+
+	// Data Subject Request Types
+	DataSubjectExport  DataSubjectRequestType = "export"
+	DataSubjectErasure DataSubjectRequestType = "erasure"
+
+	// Data Subject Request Status
+	DataSubjectPending   DataSubjectRequestStatus = "pending"
+	DataSubjectRunning   DataSubjectRequestStatus = "running"
+	DataSubjectCompleted DataSubjectRequestStatus = "completed"
+	DataSubjectFailed    DataSubjectRequestStatus = "failed"
+
+	// Audit Stream Types
+	AuditStreamWebhook AuditStreamType = "webhook"
+	AuditStreamSyslog  AuditStreamType = "syslog"
+
+	// Tenant Deletion Status
+	TenantDeletionExporting TenantDeletionStatus = "exporting"
+	TenantDeletionScheduled TenantDeletionStatus = "scheduled"
+	TenantDeletionDeleting  TenantDeletionStatus = "deleting"
+	TenantDeletionCompleted TenantDeletionStatus = "completed"
+	TenantDeletionFailed    TenantDeletionStatus = "failed"
+	TenantDeletionCancelled TenantDeletionStatus = "cancelled"
+
+	// Document Checks, required before downloads and shares when configured
+	DocCheckVirusScan DocumentCheck = "virus_scan"
+	DocCheckDLP       DocumentCheck = "dlp" // Personal data in the document's text
+
+	// Check Status; documents from before a check was required have none
+	CheckStatusPending CheckStatus = "pending"
+	CheckStatusPassed  CheckStatus = "passed"
+	CheckStatusFailed  CheckStatus = "failed"
+
+	// Accounting Sync Status, of a document's bill in the tenant's accounting
+	// system; documents never exported have none
+	AccountingSyncExporting AccountingSyncStatus = "exporting"
+	AccountingSyncSynced    AccountingSyncStatus = "synced"
+	AccountingSyncFailed    AccountingSyncStatus = "failed"
 )
 
+// TierDocumentQuotas is the number of documents a tenant on each tier may hold
+var TierDocumentQuotas = map[SubscriptionTier]int64{
+	SubscriptionStarter:      10000,
+	SubscriptionProfessional: 100000,
+	SubscriptionEnterprise:   1000000,
+}
+
+// TierAPIRateLimits is how many API requests per minute a tenant on each tier may make
+var TierAPIRateLimits = map[SubscriptionTier]int{
+	SubscriptionStarter:      120,
+	SubscriptionProfessional: 600,
+	SubscriptionEnterprise:   3000,
+}
+
+// TierAPIRequestQuotas is the monthly API quota tenants on each tier are given
+var TierAPIRequestQuotas = map[SubscriptionTier]int64{
+	SubscriptionStarter:      100000,
+	SubscriptionProfessional: 1000000,
+	SubscriptionEnterprise:   10000000,
+}
+
 // JSONB type for PostgreSQL jsonb columns
 type JSONB map[string]interface{}
 
@@ -118,12 +237,22 @@ type Tenant struct {
 	SubscriptionTier SubscriptionTier `json:"subscription_tier" gorm:"type:varchar(20);not null;default:'starter'"`
 	StorageQuota     int64            `json:"storage_quota" gorm:"not null;default:5368709120"` // 5GB default
 	StorageUsed      int64            `json:"storage_used" gorm:"not null;default:0"`
-	APIQuota         int              `json:"api_quota" gorm:"not null;default:1000"`
-	APIUsed          int              `json:"api_used" gorm:"not null;default:0"`
+	DocumentQuota    int64            `json:"document_quota" gorm:"not null;default:10000"` // Starter tier default
+	DocumentCount    int64            `json:"document_count" gorm:"not null;default:0"`
+	APIQuota         int              `json:"api_quota" gorm:"not null;default:1000"` // API requests per calendar month
+	APIUsed          int              `json:"api_used" gorm:"not null;default:0"`     // API requests this month, as last flushed
 	Settings         JSONB            `json:"settings" gorm:"type:jsonb;default:'{}'"`
 	IsActive         bool             `json:"is_active" gorm:"not null;default:true"`
 	TrialEndsAt      *time.Time       `json:"trial_ends_at"`
 
+	// Set while the tenant waits to be deleted; only admins may sign in
+	DeletionScheduledFor *time.Time `json:"deletion_scheduled_for,omitempty"`
+
+	// Trial lifecycle, kept by the trial expiry job. Once the trial has
+	// expired and the tenant is suspended, only admins may sign in.
+	TrialWarnedAt  *time.Time `json:"trial_warned_at,omitempty"`  // Last expiry warning sent to admins
+	TrialExpiredAt *time.Time `json:"trial_expired_at,omitempty"` // When the expiry action was taken
+
 	// Business Information
 	BusinessType string `json:"business_type" gorm:"type:varchar(100)"`
 	Industry     string `json:"industry" gorm:"type:varchar(100)"`
@@ -157,14 +286,26 @@ type User struct {
 	FirstName         string     `json:"first_name" gorm:"type:varchar(100);not null"`
 	LastName          string     `json:"last_name" gorm:"type:varchar(100);not null"`
 	Role              UserRole   `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
+	CustomRoleID      *uuid.UUID `json:"custom_role_id,omitempty" gorm:"type:uuid;index"` // Overrides Role's permissions
 	Department        string     `json:"department" gorm:"type:varchar(100)"`
 	JobTitle          string     `json:"job_title" gorm:"type:varchar(100)"`
+	ManagerID         *uuid.UUID `json:"manager_id,omitempty" gorm:"type:uuid;index"` // Reporting line, used for escalations
 	IsActive          bool       `json:"is_active" gorm:"not null;default:true"`
 	EmailVerified     bool       `json:"email_verified" gorm:"not null;default:false"`
 	LastLoginAt       *time.Time `json:"last_login_at"`
 	PasswordChangedAt time.Time  `json:"password_changed_at" gorm:"not null;default:now()"`
 	MFAEnabled        bool       `json:"mfa_enabled" gorm:"not null;default:false"`
 	MFASecret         string     `json:"-" gorm:"type:varchar(32)"`
+	MFAPendingSecret  string     `json:"-" gorm:"type:varchar(32)"`                            // Awaiting enrollment confirmation
+	MFALastUsedStep   int64      `json:"-" gorm:"not null;default:0"`                          // Last accepted TOTP time step (replay guard)
+	ExternalID        string     `json:"external_id,omitempty" gorm:"type:varchar(255);index"` // Identity provider ID (SCIM)
+
+	// Phone (SMS notifications for critical events)
+	PhoneNumber                string     `json:"phone_number" gorm:"type:varchar(20)"`
+	PhoneVerified              bool       `json:"phone_verified" gorm:"not null;default:false"`
+	PhoneVerificationHash      string     `json:"-" gorm:"type:varchar(64)"`
+	PhoneVerificationExpiresAt *time.Time `json:"-"`
+	PhoneVerificationAttempts  int        `json:"-" gorm:"not null;default:0"` // Guesses at the current code
 
 	// User Preferences
 	Preferences          JSONB `json:"preferences" gorm:"type:jsonb;default:'{}'"`
@@ -175,12 +316,93 @@ type User struct {
 
 	// Relationships
 	Tenant           Tenant         `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+	CustomRole       *Role          `json:"custom_role,omitempty" gorm:"foreignKey:CustomRoleID"`
 	CreatedDocuments []Document     `json:"created_documents,omitempty" gorm:"foreignKey:CreatedBy"`
 	UpdatedDocuments []Document     `json:"updated_documents,omitempty" gorm:"foreignKey:UpdatedBy"`
 	CreatedFolders   []Folder       `json:"created_folders,omitempty" gorm:"foreignKey:CreatedBy"`
 	WorkflowTasks    []WorkflowTask `json:"workflow_tasks,omitempty" gorm:"foreignKey:AssignedTo"`
 }
 
+// Role is a tenant-defined role with an explicit permission set. Users assigned
+// a custom role get its permissions instead of their built-in role's.
+type Role struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID    uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_tenant_role_name"`
+	Name        string    `json:"name" gorm:"type:varchar(50);not null;uniqueIndex:idx_tenant_role_name"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedBy   uuid.UUID `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant      Tenant           `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+	Permissions []RolePermission `json:"permissions,omitempty" gorm:"foreignKey:RoleID"`
+}
+
+// RolePermission grants one catalog permission (e.g. "documents.read") to a custom role
+type RolePermission struct {
+	RoleID     uuid.UUID `json:"role_id" gorm:"type:uuid;primary_key"`
+	Permission string    `json:"permission" gorm:"type:varchar(100);primary_key"`
+}
+
+// APIKey lets an external integration call the API on behalf of a tenant.
+// Only a SHA-256 hash of the key is stored; the plaintext is shown once.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(20);uniqueIndex;not null"`
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);not null"`
+	CreatedBy  uuid.UUID  `json:"created_by" gorm:"type:uuid;not null;index"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant  Tenant        `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+	Creator User          `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
+	Scopes  []APIKeyScope `json:"scopes,omitempty" gorm:"foreignKey:APIKeyID"`
+}
+
+// APIKeyScope grants one catalog permission to an API key
+type APIKeyScope struct {
+	APIKeyID   uuid.UUID `json:"api_key_id" gorm:"type:uuid;primary_key"`
+	Permission string    `json:"permission" gorm:"type:varchar(100);primary_key"`
+}
+
+// MFARecoveryCode is a single-use backup code for MFA. Only its hash is stored.
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TenantAIKey is a tenant-supplied AI provider key (bring your own key).
+// The key is encrypted at rest; only its last characters are kept in clear.
+type TenantAIKey struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID     uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_tenant_ai_provider"`
+	Provider     string     `json:"provider" gorm:"type:varchar(20);not null;uniqueIndex:idx_tenant_ai_provider"`
+	EncryptedKey string     `json:"-" gorm:"type:text;not null"`
+	KeyHint      string     `json:"key_hint" gorm:"type:varchar(10)"`
+	IsEnabled    bool       `json:"is_enabled" gorm:"not null;default:true"`
+	CreatedBy    uuid.UUID  `json:"created_by" gorm:"type:uuid;not null"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	LastError    string     `json:"last_error" gorm:"type:text"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
 // Enhanced Document Model - The Beast!
 type Document struct {
 	ID       uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -210,12 +432,13 @@ type Document struct {
 	DocumentType DocumentType `json:"document_type" gorm:"type:varchar(50);index"`
 	Status       DocStatus    `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
 	Version      int          `json:"version" gorm:"not null;default:1"`
-	Language     string       `json:"language" gorm:"type:varchar(10);default:'en'"`
+	Language     string       `json:"language" gorm:"type:varchar(10);default:'en'"` // ISO 639-1; detected from the text when it is extracted
 
 	// Business Document Fields
-	DocumentNumber  string `json:"document_number" gorm:"type:varchar(100);index"`
-	ReferenceNumber string `json:"reference_number" gorm:"type:varchar(100);index"`
-	ExternalID      string `json:"external_id" gorm:"type:varchar(100);index"`
+	DocumentNumber      string     `json:"document_number" gorm:"type:varchar(100);index"`
+	ReferenceNumber     string     `json:"reference_number" gorm:"type:varchar(100);index"`
+	ExternalID          string     `json:"external_id" gorm:"type:varchar(100);index"`
+	NumberingSequenceID *uuid.UUID `json:"numbering_sequence_id,omitempty" gorm:"type:uuid"` // Set when DocumentNumber was issued by a sequence
 
 	// Financial Data (for invoices, receipts, etc.)
 	Amount       *float64 `json:"amount" gorm:"type:decimal(15,2)"`
@@ -234,6 +457,26 @@ type Document struct {
 	RetentionDate    *time.Time       `json:"retention_date" gorm:"index"`
 	LegalHold        bool             `json:"legal_hold" gorm:"not null;default:false"`
 
+	// Access Control
+	IsPrivate bool `json:"is_private" gorm:"not null;default:false;index"` // Restricted to creator + DocumentACL grants
+
+	// Download gate: required checks must pass before the document can be
+	// downloaded or shared, unless an admin overrides the gate
+	ScanStatus         CheckStatus `json:"scan_status,omitempty" gorm:"type:varchar(20)"`
+	DLPStatus          CheckStatus `json:"dlp_status,omitempty" gorm:"type:varchar(20)"`
+	CheckFindings      JSONB       `json:"check_findings,omitempty" gorm:"type:jsonb"` // Per check, what a failed check found
+	GateOverrideBy     *uuid.UUID  `json:"gate_override_by,omitempty" gorm:"type:uuid"`
+	GateOverrideAt     *time.Time  `json:"gate_override_at,omitempty"`
+	GateOverrideReason string      `json:"gate_override_reason,omitempty" gorm:"type:text"`
+
+	// Accounting export: the bill booked for the document in the tenant's
+	// QuickBooks or Xero company
+	AccountingStatus        AccountingSyncStatus `json:"accounting_status,omitempty" gorm:"type:varchar(20);index"`
+	AccountingIntegrationID *uuid.UUID           `json:"accounting_integration_id,omitempty" gorm:"type:uuid"`
+	AccountingBillID        string               `json:"accounting_bill_id,omitempty" gorm:"type:varchar(255)"`
+	AccountingError         string               `json:"accounting_error,omitempty" gorm:"type:text"`
+	AccountingUpdatedAt     *time.Time           `json:"accounting_updated_at,omitempty"` // When the status last changed
+
 	// Structured Data Extraction
 	ExtractedData JSONB `json:"extracted_data" gorm:"type:jsonb"` // AI-extracted structured data
 	CustomFields  JSONB `json:"custom_fields" gorm:"type:jsonb"`  // Tenant-specific fields
@@ -244,6 +487,14 @@ type Document struct {
 	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:now()"`
 	UpdatedAt time.Time  `json:"updated_at" gorm:"not null;default:now()"`
 
+	// Owner, changed by ownership transfers; rows written before owners
+	// existed have none and are owned by their creator
+	OwnerID *uuid.UUID `json:"owner_id" gorm:"type:uuid;index"`
+
+	// Trash: deleted documents are hidden until restored or purged
+	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"`
+	DeletedBy *uuid.UUID `json:"deleted_by,omitempty" gorm:"type:uuid"`
+
 	// Legacy Fields (keeping for compatibility)
 	Author             string     `json:"author" gorm:"type:varchar(255)"`
 	Subject            string     `json:"subject" gorm:"type:varchar(255)"`
@@ -314,12 +565,29 @@ type Workflow struct {
 	CreatedAt   time.Time    `json:"created_at" gorm:"not null;default:now()"`
 	UpdatedAt   time.Time    `json:"updated_at" gorm:"not null;default:now()"`
 
+	// Version of the current Rules; every rules change adds a WorkflowVersion
+	Version int `json:"version" gorm:"not null;default:1"`
+
 	// Relationships
 	Tenant  Tenant         `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
 	Creator User           `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
 	Tasks   []WorkflowTask `json:"tasks,omitempty" gorm:"foreignKey:WorkflowID"`
 }
 
+// WorkflowVersion is a snapshot of a workflow's rules. Runs are pinned to the
+// version they started on unless a change migrates them.
+type WorkflowVersion struct {
+	ID                uuid.UUID               `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID          uuid.UUID               `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	WorkflowID        uuid.UUID               `json:"workflow_id" gorm:"type:uuid;not null;uniqueIndex:idx_workflow_version"`
+	Version           int                     `json:"version" gorm:"not null;uniqueIndex:idx_workflow_version"`
+	Rules             JSONB                   `json:"rules" gorm:"type:jsonb;not null"`
+	MigrationPolicy   WorkflowMigrationPolicy `json:"migration_policy" gorm:"type:varchar(20);not null;default:'finish'"`
+	MigratedInstances int                     `json:"migrated_instances" gorm:"not null;default:0"`
+	CreatedBy         uuid.UUID               `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt         time.Time               `json:"created_at" gorm:"not null;default:now()"`
+}
+
 type WorkflowTask struct {
 	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	WorkflowID  uuid.UUID      `json:"workflow_id" gorm:"type:uuid;not null;index"`
@@ -334,10 +602,117 @@ type WorkflowTask struct {
 	CreatedAt   time.Time      `json:"created_at" gorm:"not null;default:now()"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"not null;default:now()"`
 
+	// Run of the workflow this task belongs to; nil for tasks created
+	// before instances were tracked
+	InstanceID *uuid.UUID `json:"instance_id,omitempty" gorm:"type:uuid;index"`
+
+	// Workflow version whose rules govern the task; follows its instance
+	WorkflowVersion int `json:"workflow_version" gorm:"not null;default:1"`
+
+	// Workflow step the task is for. Tasks created together for one step
+	// share a group; multi-approver steps complete once enough tasks in the
+	// group are approved.
+	StepNumber  int        `json:"step_number" gorm:"not null;default:0"`
+	StepGroupID *uuid.UUID `json:"step_group_id,omitempty" gorm:"type:uuid;index"`
+
+	// Workflow automation state; NextReminderAt is scheduled from the
+	// workflow's reminder days when the task is created
+	LastReminderAt *time.Time `json:"last_reminder_at,omitempty"`
+	NextReminderAt *time.Time `json:"next_reminder_at,omitempty" gorm:"index"`
+	EscalatedAt    *time.Time `json:"escalated_at,omitempty"`
+	EscalatedFrom  *uuid.UUID `json:"escalated_from,omitempty" gorm:"type:uuid"`
+
 	// Relationships
-	Workflow Workflow `json:"workflow,omitempty" gorm:"foreignKey:WorkflowID"`
-	Document Document `json:"document,omitempty" gorm:"foreignKey:DocumentID"`
-	Assignee User     `json:"assignee,omitempty" gorm:"foreignKey:AssignedTo"`
+	Workflow  Workflow                `json:"workflow,omitempty" gorm:"foreignKey:WorkflowID"`
+	Document  Document                `json:"document,omitempty" gorm:"foreignKey:DocumentID"`
+	Assignee  User                    `json:"assignee,omitempty" gorm:"foreignKey:AssignedTo"`
+	Checklist []WorkflowChecklistItem `json:"checklist,omitempty" gorm:"foreignKey:TaskID"`
+}
+
+// WorkflowInstance is one run of a workflow on a document. Status stays
+// pending while the run is in progress and ends approved, rejected or
+// cancelled; CurrentStep is the step number whose tasks are open (or the
+// last step reached, once completed).
+type WorkflowInstance struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID    uuid.UUID      `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	WorkflowID  uuid.UUID      `json:"workflow_id" gorm:"type:uuid;not null;index"`
+	DocumentID  uuid.UUID      `json:"document_id" gorm:"type:uuid;not null;index"`
+	Status      WorkflowStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	CurrentStep int            `json:"current_step" gorm:"not null;default:0"`
+	StartedBy   uuid.UUID      `json:"started_by" gorm:"type:uuid;not null"`
+	StartedAt   time.Time      `json:"started_at" gorm:"not null;default:now()"`
+	CompletedAt *time.Time     `json:"completed_at"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Workflow version the run follows; pinned at start
+	WorkflowVersion int `json:"workflow_version" gorm:"not null;default:1"`
+
+	// Relationships
+	Workflow Workflow       `json:"workflow,omitempty" gorm:"foreignKey:WorkflowID"`
+	Document Document       `json:"-" gorm:"foreignKey:DocumentID"`
+	Tasks    []WorkflowTask `json:"tasks,omitempty" gorm:"foreignKey:InstanceID"`
+}
+
+// WorkflowChecklistItem is an item the assignee of a checklist step must tick before approving
+type WorkflowChecklistItem struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	WorkflowID uuid.UUID  `json:"workflow_id" gorm:"type:uuid;not null;index"`
+	TaskID     uuid.UUID  `json:"task_id" gorm:"type:uuid;not null;uniqueIndex:idx_task_checklist_item"`
+	ItemKey    string     `json:"item_key" gorm:"type:varchar(100);not null;uniqueIndex:idx_task_checklist_item"`
+	Label      string     `json:"label" gorm:"type:varchar(255);not null"`
+	Required   bool       `json:"required" gorm:"not null;default:true"`
+	Position   int        `json:"position" gorm:"not null;default:0"`
+	IsChecked  bool       `json:"is_checked" gorm:"not null;default:false"`
+	CheckedBy  *uuid.UUID `json:"checked_by" gorm:"type:uuid"`
+	CheckedAt  *time.Time `json:"checked_at"`
+	Note       string     `json:"note" gorm:"type:text"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Task WorkflowTask `json:"-" gorm:"foreignKey:TaskID"`
+}
+
+// DelegationKind says how a workflow task came to be delegated
+type DelegationKind string
+
+const (
+	DelegationManual      DelegationKind = "manual"        // The assignee handed it over
+	DelegationOutOfOffice DelegationKind = "out_of_office" // Assigned while the assignee was away
+)
+
+// WorkflowTaskDelegation records a task handed from one user to another,
+// so changes to approval chains can be reviewed
+type WorkflowTaskDelegation struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID      `json:"tenant_id" gorm:"type:uuid;not null;index:idx_task_delegation_tenant_time"`
+	TaskID     uuid.UUID      `json:"task_id" gorm:"type:uuid;not null;index"`
+	FromUserID uuid.UUID      `json:"from_user_id" gorm:"type:uuid;not null"`
+	ToUserID   uuid.UUID      `json:"to_user_id" gorm:"type:uuid;not null"`
+	Kind       DelegationKind `json:"kind" gorm:"type:varchar(20);not null"`
+	Reason     string         `json:"reason" gorm:"type:text"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"not null;default:now();index:idx_task_delegation_tenant_time"`
+
+	// Relationships
+	Task WorkflowTask `json:"-" gorm:"foreignKey:TaskID"`
+}
+
+// OutOfOffice is a period a user is away. Approval tasks assigned to them
+// meanwhile go to their delegate. Ending a period early moves EndsAt;
+// CancelledAt is set on periods cancelled before they started.
+type OutOfOffice struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID    uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	DelegateID  uuid.UUID  `json:"delegate_id" gorm:"type:uuid;not null"`
+	StartsAt    time.Time  `json:"starts_at" gorm:"not null"`
+	EndsAt      time.Time  `json:"ends_at" gorm:"not null"`
+	Note        string     `json:"note" gorm:"type:varchar(255)"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
 }
 
 // Document Comments/Collaboration
@@ -392,6 +767,86 @@ type Notification struct {
 	User   User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// NotificationDelivery is an outbox entry for a notification sent over an
+// external channel (email, Slack), drained by the dispatcher's outbox worker
+type NotificationDelivery struct {
+	ID            uuid.UUID                  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID      uuid.UUID                  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	UserID        uuid.UUID                  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Channel       NotificationChannel        `json:"channel" gorm:"type:varchar(20);not null"`
+	Recipient     string                     `json:"recipient" gorm:"type:text;not null"` // Email address or Slack webhook URL
+	Type          string                     `json:"type" gorm:"type:varchar(50);not null"`
+	Title         string                     `json:"title" gorm:"type:varchar(255);not null"`
+	Message       string                     `json:"message" gorm:"type:text;not null"`
+	Data          JSONB                      `json:"data" gorm:"type:jsonb"`
+	Status        NotificationDeliveryStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index:idx_notification_delivery_due"`
+	Attempts      int                        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts   int                        `json:"max_attempts" gorm:"not null;default:5"`
+	NextAttemptAt time.Time                  `json:"next_attempt_at" gorm:"not null;default:now();index:idx_notification_delivery_due"`
+	LastError     string                     `json:"last_error" gorm:"type:text"`
+	CreatedAt     time.Time                  `json:"created_at" gorm:"not null;default:now()"`
+	SentAt        *time.Time                 `json:"sent_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// DeviceToken registers a mobile device for push notifications
+type DeviceToken struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID      `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
+	Platform   DevicePlatform `json:"platform" gorm:"type:varchar(20);not null"`
+	Token      string         `json:"-" gorm:"type:varchar(512);unique;not null"`
+	DeviceName string         `json:"device_name" gorm:"type:varchar(255)"`
+	AppVersion string         `json:"app_version" gorm:"type:varchar(50)"`
+	IsActive   bool           `json:"is_active" gorm:"not null;default:true"`
+	LastSeenAt time.Time      `json:"last_seen_at" gorm:"not null;default:now()"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt  time.Time      `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+	User   User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// SMSMessage records every SMS sent for a tenant, for delivery auditing and cost tracking
+type SMSMessage struct {
+	ID                uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID          uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index:idx_sms_tenant_created"`
+	UserID            uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	EventType         string    `json:"event_type" gorm:"type:varchar(50);not null"`
+	ToNumber          string    `json:"to_number" gorm:"type:varchar(20);not null;index:idx_sms_to_number_created"`
+	ProviderMessageID string    `json:"provider_message_id" gorm:"type:varchar(100)"`
+	Segments          int       `json:"segments" gorm:"not null;default:1"`
+	Cost              float64   `json:"cost" gorm:"type:decimal(10,4);not null;default:0"`
+	Currency          string    `json:"currency" gorm:"type:varchar(3);default:'USD'"`
+	Status            string    `json:"status" gorm:"type:varchar(20);not null"` // sent, failed
+	Error             string    `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt         time.Time `json:"created_at" gorm:"not null;default:now();index:idx_sms_tenant_created;index:idx_sms_to_number_created"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+	User   User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// NotificationTemplate overrides the system default wording of a notification
+// for one tenant, event type and channel. Placeholders use {{variable}} syntax.
+type NotificationTemplate struct {
+	ID        uuid.UUID           `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID  uuid.UUID           `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_notification_template_event"`
+	EventType string              `json:"event_type" gorm:"type:varchar(50);not null;uniqueIndex:idx_notification_template_event"`
+	Channel   NotificationChannel `json:"channel" gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_template_event"`
+	Subject   string              `json:"subject" gorm:"type:varchar(255);not null"`
+	Body      string              `json:"body" gorm:"type:text;not null"`
+	UpdatedBy uuid.UUID           `json:"updated_by" gorm:"type:uuid;not null"`
+	CreatedAt time.Time           `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt time.Time           `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
 // Keep existing models with minor enhancements
 type Folder struct {
 	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -408,6 +863,9 @@ type Folder struct {
 	CreatedAt   time.Time  `json:"created_at" gorm:"not null;default:now()"`
 	UpdatedAt   time.Time  `json:"updated_at" gorm:"not null;default:now()"`
 
+	// Owner, changed by ownership transfers; falls back to the creator
+	OwnerID *uuid.UUID `json:"owner_id" gorm:"type:uuid;index"`
+
 	// Relationships
 	Tenant    Tenant     `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
 	Parent    *Folder    `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
@@ -416,6 +874,77 @@ type Folder struct {
 	Documents []Document `json:"documents,omitempty" gorm:"foreignKey:FolderID"`
 }
 
+// FolderACL grants a user or a role access to a folder and its subfolders.
+// Folders without any ACL entries in their ancestry stay open to the whole tenant.
+type FolderACL struct {
+	ID         uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID        `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	FolderID   uuid.UUID        `json:"folder_id" gorm:"type:uuid;not null;index"`
+	UserID     *uuid.UUID       `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	Role       UserRole         `json:"role,omitempty" gorm:"type:varchar(20)"`
+	Permission FolderPermission `json:"permission" gorm:"type:varchar(20);not null"`
+	GrantedBy  uuid.UUID        `json:"granted_by" gorm:"type:uuid;not null"`
+	CreatedAt  time.Time        `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt  time.Time        `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Temporary grants stop applying at ExpiresAt and are removed by the expiry worker
+	ExpiresAt        *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	ExpiryNotifiedAt *time.Time `json:"expiry_notified_at,omitempty"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+	Folder Folder `json:"folder,omitempty" gorm:"foreignKey:FolderID"`
+	User   *User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// DocumentACL grants a user explicit access to a private document.
+// Grants are ignored while the document is not private.
+type DocumentACL struct {
+	ID         uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID          `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	DocumentID uuid.UUID          `json:"document_id" gorm:"type:uuid;not null;uniqueIndex:idx_document_acl_user"`
+	UserID     uuid.UUID          `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_document_acl_user;index"`
+	Permission DocumentPermission `json:"permission" gorm:"type:varchar(20);not null"`
+	GrantedBy  uuid.UUID          `json:"granted_by" gorm:"type:uuid;not null"`
+	CreatedAt  time.Time          `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt  time.Time          `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Temporary grants stop applying at ExpiresAt and are removed by the expiry worker
+	ExpiresAt        *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	ExpiryNotifiedAt *time.Time `json:"expiry_notified_at,omitempty"`
+
+	// Relationships
+	Tenant   Tenant   `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+	Document Document `json:"document,omitempty" gorm:"foreignKey:DocumentID"`
+	User     User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// Owner returns the user owning the document
+func (d *Document) Owner() uuid.UUID {
+	if d.OwnerID != nil {
+		return *d.OwnerID
+	}
+	return d.CreatedBy
+}
+
+// Owner returns the user owning the folder
+func (f *Folder) Owner() uuid.UUID {
+	if f.OwnerID != nil {
+		return *f.OwnerID
+	}
+	return f.CreatedBy
+}
+
+// IsExpired reports whether a temporary folder grant has lapsed
+func (a *FolderACL) IsExpired(now time.Time) bool {
+	return a.ExpiresAt != nil && !now.Before(*a.ExpiresAt)
+}
+
+// IsExpired reports whether a temporary document grant has lapsed
+func (a *DocumentACL) IsExpired(now time.Time) bool {
+	return a.ExpiresAt != nil && !now.Before(*a.ExpiresAt)
+}
+
 type Category struct {
 	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	TenantID    uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
@@ -458,6 +987,12 @@ type AIProcessingJob struct {
 	ErrorMessage     string           `json:"error_message" gorm:"type:text"`
 	Result           JSONB            `json:"result" gorm:"type:jsonb"`
 	ProcessingTimeMs int              `json:"processing_time_ms"`
+	Provider         string           `json:"provider" gorm:"type:varchar(20)"`
+	KeySource        string           `json:"key_source" gorm:"type:varchar(20)"`                 // platform, tenant or dry_run
+	DryRun           bool             `json:"dry_run" gorm:"not null;default:false"`              // Deterministic results, no provider calls
+	TraceParent      string           `json:"-" gorm:"type:varchar(64)"`                          // W3C trace context of the request that queued the job
+	RequestID        string           `json:"request_id,omitempty" gorm:"type:varchar(64);index"` // X-Request-ID of the request that queued the job
+	BatchID          *uuid.UUID       `json:"batch_id,omitempty" gorm:"type:uuid;index"`          // The reprocessing batch that queued the job
 	CreatedAt        time.Time        `json:"created_at" gorm:"not null;default:now()"`
 	StartedAt        *time.Time       `json:"started_at"`
 	CompletedAt      *time.Time       `json:"completed_at"`
@@ -478,13 +1013,103 @@ type AuditLog struct {
 	IPAddress    string      `json:"ip_address" gorm:"type:varchar(45)"`
 	UserAgent    string      `json:"user_agent" gorm:"type:text"`
 	Details      JSONB       `json:"details" gorm:"type:jsonb"`
+	RequestID    string      `json:"request_id,omitempty" gorm:"type:varchar(64);index"` // X-Request-ID of the request that caused the entry
 	CreatedAt    time.Time   `json:"created_at" gorm:"not null;default:now()"`
 
+	// Tamper-evident hash chain, per tenant. Entries written before the chain
+	// existed keep sequence 0 and are not verified.
+	Sequence int64  `json:"sequence" gorm:"not null;default:0;index"`
+	PrevHash string `json:"prev_hash" gorm:"type:varchar(64)"`
+	Hash     string `json:"hash" gorm:"type:varchar(64)"`
+
 	// Relationships
 	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
 	User   User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// ComputeHash returns the entry's chain hash over PrevHash and its content
+func (a *AuditLog) ComputeHash() string {
+	details, _ := json.Marshal(a.Details)
+
+	fields := []string{
+		a.PrevHash,
+		strconv.FormatInt(a.Sequence, 10),
+		a.TenantID.String(),
+		a.UserID.String(),
+		a.ResourceID.String(),
+		string(a.Action),
+		a.ResourceType,
+		a.IPAddress,
+		a.UserAgent,
+		string(details),
+		a.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	// Entries written before request IDs were recorded keep their hashes
+	if a.RequestID != "" {
+		fields = append(fields, a.RequestID)
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		h.Write([]byte(field))
+		h.Write([]byte{'|'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditChainHead is the last link of a tenant's audit hash chain. It outlives
+// archived entries so the chain continues across archive boundaries.
+type AuditChainHead struct {
+	TenantID  uuid.UUID `json:"tenant_id" gorm:"type:uuid;primary_key"`
+	Sequence  int64     `json:"sequence" gorm:"not null;default:0"`
+	Hash      string    `json:"hash" gorm:"type:varchar(64)"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// AuditArchive is a batch of audit entries moved out of the hot table into
+// compressed object storage (gzip JSON lines)
+type AuditArchive struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID      uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	StoragePath   string    `json:"-" gorm:"type:text;not null"`
+	FirstSequence int64     `json:"first_sequence" gorm:"not null"`
+	LastSequence  int64     `json:"last_sequence" gorm:"not null;index"`
+	FirstPrevHash string    `json:"first_prev_hash" gorm:"type:varchar(64)"` // Links the archive to the entry before it
+	LastHash      string    `json:"last_hash" gorm:"type:varchar(64)"`
+	FromTime      time.Time `json:"from_time" gorm:"not null"`
+	ToTime        time.Time `json:"to_time" gorm:"not null"`
+	EntryCount    int       `json:"entry_count" gorm:"not null"`
+	SizeBytes     int64     `json:"size_bytes" gorm:"not null"`
+	CreatedAt     time.Time `json:"created_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
+// AuditStream mirrors a tenant's audit entries to an external SIEM, by signed
+// webhook or syslog. LastSequence is the last chain entry delivered, so every
+// entry is sent at least once and in chain order.
+type AuditStream struct {
+	ID             uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID       uuid.UUID       `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	Name           string          `json:"name" gorm:"type:varchar(100);not null"`
+	Type           AuditStreamType `json:"type" gorm:"type:varchar(20);not null"`
+	Endpoint       string          `json:"endpoint" gorm:"type:varchar(500);not null"` // https URL, or tcp:// / udp:// / tls:// host:port for syslog
+	Secret         string          `json:"-" gorm:"type:varchar(100)"`                 // Webhook signing secret
+	IsActive       bool            `json:"is_active" gorm:"not null;default:true"`
+	LastSequence   int64           `json:"last_sequence" gorm:"not null;default:0"`
+	LastDeliveryAt *time.Time      `json:"last_delivery_at"`
+	LastError      string          `json:"last_error" gorm:"type:text"`
+	FailureCount   int             `json:"failure_count" gorm:"not null;default:0"` // Consecutive failed deliveries
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	CreatedBy      uuid.UUID       `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt      time.Time       `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
 type Share struct {
 	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
 	TenantID      uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
@@ -505,24 +1130,907 @@ type Share struct {
 	Creator  User     `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
 }
 
+// ShareAccess records each attempt to open a public share link
+type ShareAccess struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	ShareID    uuid.UUID `json:"share_id" gorm:"type:uuid;not null;index:idx_share_access_share_time"`
+	DocumentID uuid.UUID `json:"document_id" gorm:"type:uuid;not null;index"`
+	Action     string    `json:"action" gorm:"type:varchar(20);not null"` // view, download
+	Success    bool      `json:"success" gorm:"not null;default:true"`
+	Reason     string    `json:"reason,omitempty" gorm:"type:varchar(100)"` // denial reason when unsuccessful
+	IPAddress  string    `json:"ip_address" gorm:"type:varchar(45)"`
+	UserAgent  string    `json:"user_agent" gorm:"type:text"`
+	AccessedAt time.Time `json:"accessed_at" gorm:"not null;default:now();index:idx_share_access_share_time"`
+
+	// Relationships
+	Share Share `json:"share,omitempty" gorm:"foreignKey:ShareID"`
+}
+
+// Webhook delivers tenant events to an external endpoint. Requests are
+// signed with HMAC-SHA256 using Secret.
+type Webhook struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID       uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	URL            string     `json:"url" gorm:"type:varchar(500);not null"`
+	Description    string     `json:"description" gorm:"type:varchar(255)"`
+	Secret         string     `json:"-" gorm:"type:varchar(100);not null"`
+	IsActive       bool       `json:"is_active" gorm:"not null;default:true"`
+	CreatedBy      uuid.UUID  `json:"created_by" gorm:"type:uuid;not null"`
+	LastDeliveryAt *time.Time `json:"last_delivery_at"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+	FailureCount   int        `json:"failure_count" gorm:"not null;default:0"` // Consecutive failed deliveries
+	CreatedAt      time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant Tenant         `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+	Events []WebhookEvent `json:"events,omitempty" gorm:"foreignKey:WebhookID"`
+}
+
+// WebhookEvent subscribes a webhook to one event type
+type WebhookEvent struct {
+	WebhookID uuid.UUID `json:"webhook_id" gorm:"type:uuid;primary_key"`
+	EventType string    `json:"event_type" gorm:"type:varchar(100);primary_key"`
+}
+
+// WebhookDelivery is one event queued for delivery to one webhook
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID       uuid.UUID             `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	WebhookID      uuid.UUID             `json:"webhook_id" gorm:"type:uuid;not null;index"`
+	EventID        uuid.UUID             `json:"event_id" gorm:"type:uuid;not null;index"`
+	EventType      string                `json:"event_type" gorm:"type:varchar(100);not null"`
+	Payload        JSONB                 `json:"payload" gorm:"type:jsonb;not null"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index:idx_webhook_delivery_due"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts    int                   `json:"max_attempts" gorm:"not null;default:8"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" gorm:"not null;default:now();index:idx_webhook_delivery_due"`
+	ResponseStatus int                   `json:"response_status"`
+	LastError      string                `json:"last_error" gorm:"type:text"`
+	ReplayOf       *uuid.UUID            `json:"replay_of,omitempty" gorm:"type:uuid"` // Delivery this one re-delivers
+	CreatedAt      time.Time             `json:"created_at" gorm:"not null;default:now();index"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+
+	// Relationships
+	Webhook Webhook `json:"webhook,omitempty" gorm:"foreignKey:WebhookID"`
+}
+
+// WebhookDeliveryAttempt logs one attempt at a webhook delivery. The payload
+// is a snapshot with secrets and personal data redacted; the response body
+// is truncated.
+type WebhookDeliveryAttempt struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID       uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	WebhookID      uuid.UUID `json:"webhook_id" gorm:"type:uuid;not null"`
+	DeliveryID     uuid.UUID `json:"delivery_id" gorm:"type:uuid;not null;index"`
+	Attempt        int       `json:"attempt" gorm:"not null"`
+	Payload        JSONB     `json:"payload" gorm:"type:jsonb"`
+	ResponseStatus int       `json:"response_status"` // 0 when no response was received
+	ResponseBody   string    `json:"response_body,omitempty" gorm:"type:text"`
+	LatencyMS      int64     `json:"latency_ms" gorm:"not null;default:0"`
+	Error          string    `json:"error,omitempty" gorm:"type:text"`
+	AttemptedAt    time.Time `json:"attempted_at" gorm:"not null;index"`
+}
+
+// ScheduledJob is a tenant's recurring job, fired on a cron expression
+// evaluated in Timezone. NextRunAt is nil while the job is disabled.
+type ScheduledJob struct {
+	ID             uuid.UUID             `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID       uuid.UUID             `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	JobType        string                `json:"job_type" gorm:"type:varchar(50);not null"`
+	CronExpression string                `json:"cron_expression" gorm:"type:varchar(100);not null"`
+	Timezone       string                `json:"timezone" gorm:"type:varchar(64);not null;default:'UTC'"`
+	Parameters     JSONB                 `json:"parameters" gorm:"type:jsonb"`
+	IsEnabled      bool                  `json:"is_enabled" gorm:"not null;default:true;index:idx_scheduled_job_due"`
+	NextRunAt      *time.Time            `json:"next_run_at" gorm:"index:idx_scheduled_job_due"`
+	LastRunAt      *time.Time            `json:"last_run_at"`
+	LastStatus     ScheduledJobRunStatus `json:"last_status" gorm:"type:varchar(20)"`
+	LastError      string                `json:"last_error" gorm:"type:text"`
+	CreatedBy      uuid.UUID             `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt      time.Time             `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt      time.Time             `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
+// ScheduledJobRun is one firing of a scheduled job, queued for the workers.
+// A job fires at most once per ScheduledFor.
+type ScheduledJobRun struct {
+	ID             uuid.UUID             `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID       uuid.UUID             `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	ScheduledJobID uuid.UUID             `json:"scheduled_job_id" gorm:"type:uuid;not null;uniqueIndex:idx_scheduled_job_run_slot"`
+	JobType        string                `json:"job_type" gorm:"type:varchar(50);not null"`
+	ScheduledFor   time.Time             `json:"scheduled_for" gorm:"not null;uniqueIndex:idx_scheduled_job_run_slot"`
+	Status         ScheduledJobRunStatus `json:"status" gorm:"type:varchar(20);not null;default:'queued';index:idx_scheduled_job_run_due"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	LeaseUntil     *time.Time            `json:"lease_until" gorm:"index:idx_scheduled_job_run_due"`
+	StartedAt      *time.Time            `json:"started_at"`
+	CompletedAt    *time.Time            `json:"completed_at"`
+	Error          string                `json:"error" gorm:"type:text"`
+	CreatedAt      time.Time             `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// LegalHold preserves a set of documents for litigation or investigation.
+// Criteria records how the set was chosen; the documents themselves are
+// fixed when the hold is placed. A hold is active until LiftedAt is set.
+type LegalHold struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID      `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	Name       string         `json:"name" gorm:"type:varchar(255);not null"`
+	Reason     string         `json:"reason" gorm:"type:text;not null"`
+	Scope      LegalHoldScope `json:"scope" gorm:"type:varchar(20);not null"`
+	Criteria   JSONB          `json:"criteria" gorm:"type:jsonb"`
+	PlacedBy   uuid.UUID      `json:"placed_by" gorm:"type:uuid;not null"`
+	PlacedAt   time.Time      `json:"placed_at" gorm:"not null;default:now()"`
+	LiftedBy   *uuid.UUID     `json:"lifted_by,omitempty" gorm:"type:uuid"`
+	LiftedAt   *time.Time     `json:"lifted_at,omitempty" gorm:"index"`
+	LiftReason string         `json:"lift_reason,omitempty" gorm:"type:text"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
+// LegalHoldDocument is a document preserved by a legal hold
+type LegalHoldDocument struct {
+	LegalHoldID uuid.UUID `json:"legal_hold_id" gorm:"type:uuid;primaryKey"`
+	DocumentID  uuid.UUID `json:"document_id" gorm:"type:uuid;primaryKey;index"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// DataSubjectRequest is a GDPR export or erasure of one user's data, carried
+// out by a background worker. A completed export's archive is at StoragePath.
+type DataSubjectRequest struct {
+	ID          uuid.UUID                `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID    uuid.UUID                `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	SubjectID   uuid.UUID                `json:"subject_id" gorm:"type:uuid;not null;index"`
+	Type        DataSubjectRequestType   `json:"type" gorm:"type:varchar(20);not null"`
+	Status      DataSubjectRequestStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index:idx_data_subject_due"`
+	Attempts    int                      `json:"attempts" gorm:"not null;default:0"`
+	LeaseUntil  *time.Time               `json:"-" gorm:"index:idx_data_subject_due"`
+	StoragePath string                   `json:"-" gorm:"type:text"`
+	SizeBytes   int64                    `json:"size_bytes"`
+	Error       string                   `json:"error,omitempty" gorm:"type:text"`
+	RequestedBy uuid.UUID                `json:"requested_by" gorm:"type:uuid;not null"`
+	CreatedAt   time.Time                `json:"created_at" gorm:"not null;default:now()"`
+	StartedAt   *time.Time               `json:"started_at,omitempty"`
+	CompletedAt *time.Time               `json:"completed_at,omitempty"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
+// TenantDeletion is the staged teardown of a tenant. Requesting it suspends
+// the tenant while a worker builds an export; admins can download the export
+// or cancel until PurgeAfter, after which the worker deletes the tenant's
+// files and rows. It has no foreign key to the tenant, so it outlives it.
+type TenantDeletion struct {
+	ID          uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID    uuid.UUID            `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	TenantName  string               `json:"tenant_name" gorm:"type:varchar(255);not null"`
+	Subdomain   string               `json:"subdomain" gorm:"type:varchar(100);not null"`
+	Status      TenantDeletionStatus `json:"status" gorm:"type:varchar(20);not null;default:'exporting';index:idx_tenant_deletion_due"`
+	Reason      string               `json:"reason,omitempty" gorm:"type:text"`
+	RequestedBy uuid.UUID            `json:"requested_by" gorm:"type:uuid;not null"`
+	RequestedAt time.Time            `json:"requested_at" gorm:"not null;default:now()"`
+	PurgeAfter  time.Time            `json:"purge_after" gorm:"not null"`
+	ExportPath  string               `json:"-" gorm:"type:text"`
+	ExportSize  int64                `json:"export_size_bytes"`
+	Stage       string               `json:"stage,omitempty" gorm:"type:varchar(20)"` // Teardown stage reached
+	Progress    JSONB                `json:"progress,omitempty" gorm:"type:jsonb"`
+	Attempts    int                  `json:"attempts" gorm:"not null;default:0"`
+	LeaseUntil  *time.Time           `json:"-" gorm:"index:idx_tenant_deletion_due"`
+	Error       string               `json:"error,omitempty" gorm:"type:text"`
+	CancelledBy *uuid.UUID           `json:"cancelled_by,omitempty" gorm:"type:uuid"`
+	CancelledAt *time.Time           `json:"cancelled_at,omitempty"`
+	StartedAt   *time.Time           `json:"started_at,omitempty"`
+	CompletedAt *time.Time           `json:"completed_at,omitempty"`
+}
+
+// TenantDeletionCertificate records that a tenant's data was deleted: what
+// was removed and when. Hash is the SHA-256 of the certificate's other
+// fields, so a copy handed to the customer can be checked against it.
+type TenantDeletionCertificate struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	DeletionID   uuid.UUID `json:"deletion_id" gorm:"type:uuid;not null;uniqueIndex"`
+	TenantID     uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	TenantName   string    `json:"tenant_name" gorm:"type:varchar(255);not null"`
+	Subdomain    string    `json:"subdomain" gorm:"type:varchar(100);not null"`
+	RequestedBy  uuid.UUID `json:"requested_by" gorm:"type:uuid;not null"`
+	RequestedAt  time.Time `json:"requested_at" gorm:"not null"`
+	StartedAt    time.Time `json:"started_at" gorm:"not null"`
+	CompletedAt  time.Time `json:"completed_at" gorm:"not null"`
+	RowsDeleted  JSONB     `json:"rows_deleted" gorm:"type:jsonb"` // Rows deleted per table
+	FilesDeleted int64     `json:"files_deleted" gorm:"not null;default:0"`
+	BytesDeleted int64     `json:"bytes_deleted" gorm:"not null;default:0"`
+	Accounts     int64     `json:"accounts_deleted" gorm:"not null;default:0"` // Identity provider accounts
+	Hash         string    `json:"hash" gorm:"type:varchar(64);not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// ComputeHash returns the SHA-256 of the certificate's content
+func (c *TenantDeletionCertificate) ComputeHash() string {
+	rows, _ := json.Marshal(c.RowsDeleted)
+
+	h := sha256.New()
+	for _, field := range []string{
+		c.DeletionID.String(),
+		c.TenantID.String(),
+		c.TenantName,
+		c.Subdomain,
+		c.RequestedBy.String(),
+		c.RequestedAt.UTC().Format(time.RFC3339Nano),
+		c.StartedAt.UTC().Format(time.RFC3339Nano),
+		c.CompletedAt.UTC().Format(time.RFC3339Nano),
+		string(rows),
+		strconv.FormatInt(c.FilesDeleted, 10),
+		strconv.FormatInt(c.BytesDeleted, 10),
+		strconv.FormatInt(c.Accounts, 10),
+	} {
+		h.Write([]byte(field))
+		h.Write([]byte{'|'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// APIUsage counts a tenant's API requests in a calendar month, as flushed
+// from the Redis meter. Requests are the ones served; requests over the
+// monthly quota and rate limited ones are counted separately.
+type APIUsage struct {
+	ID            uuid.UUID `json:"-" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID      uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_api_usage_tenant_period"`
+	Period        time.Time `json:"period" gorm:"type:date;not null;uniqueIndex:idx_api_usage_tenant_period"` // First day of the month, UTC
+	Requests      int64     `json:"requests" gorm:"not null;default:0"`
+	QuotaRejected int64     `json:"quota_rejected" gorm:"not null;default:0"`
+	RateLimited   int64     `json:"rate_limited" gorm:"not null;default:0"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// VendorProfileSource says how a vendor extraction profile came about
+type VendorProfileSource string
+
+const (
+	VendorProfileConfigured VendorProfileSource = "configured" // Created by a user
+	VendorProfileLearned    VendorProfileSource = "learned"    // Created from extraction corrections
+)
+
+// VendorProfile tells financial extraction how one vendor lays out its
+// documents. Corrections to extracted fields keep refining it.
+type VendorProfile struct {
+	ID                  uuid.UUID           `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID            uuid.UUID           `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_vendor_profile_tenant_name"`
+	VendorName          string              `json:"vendor_name" gorm:"type:varchar(255);not null"`
+	NormalizedName      string              `json:"-" gorm:"type:varchar(255);not null;uniqueIndex:idx_vendor_profile_tenant_name"`
+	DateFormat          string              `json:"date_format,omitempty" gorm:"type:varchar(20)"`            // e.g. DD/MM/YYYY
+	Currency            string              `json:"currency,omitempty" gorm:"type:varchar(3)"`                // Used when none is extracted
+	DocumentNumberLabel string              `json:"document_number_label,omitempty" gorm:"type:varchar(100)"` // Printed before the document number, e.g. "PO Number"
+	Hints               string              `json:"hints,omitempty" gorm:"type:text"`                         // Layout notes for the AI provider
+	Source              VendorProfileSource `json:"source" gorm:"type:varchar(20);not null;default:'configured'"`
+	CorrectionCount     int                 `json:"correction_count" gorm:"not null;default:0"`
+	LastCorrectedAt     *time.Time          `json:"last_corrected_at,omitempty"`
+	CreatedBy           uuid.UUID           `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt           time.Time           `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt           time.Time           `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Aliases []VendorProfileAlias `json:"aliases,omitempty" gorm:"foreignKey:ProfileID"`
+}
+
+// VendorProfileAlias is another name a vendor's documents go by. Aliases
+// are normalized and unique within a tenant.
+type VendorProfileAlias struct {
+	ProfileID uuid.UUID `json:"-" gorm:"type:uuid;primary_key"`
+	TenantID  uuid.UUID `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_vendor_profile_alias_tenant"`
+	Alias     string    `json:"alias" gorm:"type:varchar(255);primary_key;uniqueIndex:idx_vendor_profile_alias_tenant"`
+}
+
+// UploadSessionStatus is where a direct upload is in its lifecycle
+type UploadSessionStatus string
+
+const (
+	UploadSessionPending    UploadSessionStatus = "pending"    // Waiting for the client to upload and finalize
+	UploadSessionFinalizing UploadSessionStatus = "finalizing" // Being verified and turned into a document
+	UploadSessionCompleted  UploadSessionStatus = "completed"  // Finalized into a document
+	UploadSessionFailed     UploadSessionStatus = "failed"     // The file was rejected and deleted
+	UploadSessionExpired    UploadSessionStatus = "expired"    // Never finalized
+)
+
+// UploadSession tracks a file the client uploads straight to storage. The
+// declared size and checksum are verified when the upload is finalized.
+type UploadSession struct {
+	ID            uuid.UUID           `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID      uuid.UUID           `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	UserID        uuid.UUID           `json:"user_id" gorm:"type:uuid;not null"`
+	StoragePath   string              `json:"-" gorm:"type:varchar(1000);not null"`
+	Filename      string              `json:"filename" gorm:"type:varchar(255);not null"`
+	ContentType   string              `json:"content_type" gorm:"type:varchar(100);not null"`
+	FileSize      int64               `json:"file_size" gorm:"not null"`
+	ContentHash   string              `json:"content_hash" gorm:"type:varchar(64);not null"` // SHA-256, hex
+	Params        JSONB               `json:"-" gorm:"type:jsonb"`                           // Document metadata to create the document with
+	Status        UploadSessionStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	FailureReason string              `json:"failure_reason,omitempty" gorm:"type:text"`
+	DocumentID    *uuid.UUID          `json:"document_id,omitempty" gorm:"type:uuid"`
+	ExpiresAt     time.Time           `json:"expires_at" gorm:"not null;index"`
+	FinalizedAt   *time.Time          `json:"finalized_at,omitempty"`
+	CreatedAt     time.Time           `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt     time.Time           `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// BulkLabelAction is what a bulk label job does to the matching documents
+type BulkLabelAction string
+
+const (
+	BulkLabelAdd    BulkLabelAction = "add"
+	BulkLabelRemove BulkLabelAction = "remove"
+)
+
+// BulkLabelJobStatus is where a bulk label job is in its lifecycle
+type BulkLabelJobStatus string
+
+const (
+	BulkLabelPending   BulkLabelJobStatus = "pending"
+	BulkLabelRunning   BulkLabelJobStatus = "running"
+	BulkLabelCompleted BulkLabelJobStatus = "completed"
+	BulkLabelFailed    BulkLabelJobStatus = "failed"
+)
+
+// BulkLabelJob adds or removes tags and categories on every document that
+// matches a search, in batches run by a background worker. Criteria records
+// the search filters, Labels the tag_ids and category_ids. Cursor is the last
+// document done, so a job picked up after a crash resumes where it stopped.
+type BulkLabelJob struct {
+	ID          uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID    uuid.UUID          `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	Action      BulkLabelAction    `json:"action" gorm:"type:varchar(10);not null"`
+	Criteria    JSONB              `json:"criteria" gorm:"type:jsonb"`
+	Labels      JSONB              `json:"labels" gorm:"type:jsonb"`
+	Status      BulkLabelJobStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index:idx_bulk_label_due"`
+	Matched     int64              `json:"matched"` // Documents matching when the job was created
+	Processed   int64              `json:"processed"`
+	Cursor      *uuid.UUID         `json:"-" gorm:"type:uuid"`
+	Attempts    int                `json:"attempts" gorm:"not null;default:0"`
+	LeaseUntil  *time.Time         `json:"-" gorm:"index:idx_bulk_label_due"`
+	Error       string             `json:"error,omitempty" gorm:"type:text"`
+	RequestedBy uuid.UUID          `json:"requested_by" gorm:"type:uuid;not null"`
+	CreatedAt   time.Time          `json:"created_at" gorm:"not null;default:now()"`
+	StartedAt   *time.Time         `json:"started_at,omitempty"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+}
+
+// NumberingReset is how often a numbering sequence starts over at 1
+type NumberingReset string
+
+const (
+	NumberingResetNever   NumberingReset = "never"
+	NumberingResetYearly  NumberingReset = "yearly"
+	NumberingResetMonthly NumberingReset = "monthly"
+)
+
+// NumberingSequence issues document numbers to a tenant's uploads of one
+// document type, e.g. INV-2024-00042 from prefix "INV-{YYYY}-" and padding
+// 5. LastValue is the last number issued in CurrentPeriod, the year or month
+// the sequence was last used in when it resets.
+type NumberingSequence struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID      uuid.UUID      `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_numbering_sequence_tenant_type"`
+	DocumentType  DocumentType   `json:"document_type" gorm:"type:varchar(50);not null;uniqueIndex:idx_numbering_sequence_tenant_type"`
+	Name          string         `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix        string         `json:"prefix" gorm:"type:varchar(50)"` // May hold {YYYY}, {YY} and {MM}
+	Padding       int            `json:"padding" gorm:"not null;default:5"`
+	ResetInterval NumberingReset `json:"reset_interval" gorm:"type:varchar(20);not null;default:'never'"`
+	LastValue     int64          `json:"last_value" gorm:"not null;default:0"`
+	CurrentPeriod string         `json:"current_period,omitempty" gorm:"type:varchar(7)"` // YYYY or YYYY-MM
+	IsActive      bool           `json:"is_active" gorm:"not null;default:true"`
+	CreatedBy     uuid.UUID      `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// WorkerHeartbeat is a background worker's last poll, as reported by the
+// server instance running it. Each instance has one row per worker.
+type WorkerHeartbeat struct {
+	ID           uuid.UUID `json:"-" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	InstanceID   string    `json:"instance_id" gorm:"type:varchar(100);not null;uniqueIndex:idx_worker_heartbeat_instance_worker"`
+	Worker       string    `json:"worker" gorm:"type:varchar(100);not null;uniqueIndex:idx_worker_heartbeat_instance_worker"`
+	Hostname     string    `json:"hostname" gorm:"type:varchar(255)"`
+	PID          int       `json:"pid"`
+	PollInterval int       `json:"poll_interval_seconds" gorm:"not null;default:0"` // Seconds between the worker's polls
+	LastError    string    `json:"last_error,omitempty" gorm:"type:text"`           // Of the last poll; empty when it succeeded
+	StartedAt    time.Time `json:"started_at" gorm:"not null"`
+	LastPollAt   time.Time `json:"last_poll_at" gorm:"not null;index"`
+}
+
+// IntegrationStatus is whether an integration's connection works
+type IntegrationStatus string
+
+const (
+	IntegrationActive IntegrationStatus = "active"
+	IntegrationError  IntegrationStatus = "error" // The provider refused the token; the tenant must reconnect
+)
+
+// Integration is a tenant's OAuth connection to an external service, such
+// as a Google Drive or OneDrive account. Only the refresh token is kept,
+// encrypted; access tokens are requested as they're needed.
+type Integration struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID     uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	Provider     string    `json:"provider" gorm:"type:varchar(50);not null"`
+	AccountEmail string    `json:"account_email" gorm:"type:varchar(255)"`
+	// The provider's ID for the account, which its change webhooks name
+	ExternalAccountID string            `json:"external_account_id,omitempty" gorm:"type:varchar(255);index"`
+	EncryptedToken    string            `json:"-" gorm:"type:text;not null"`
+	Status            IntegrationStatus `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+	LastError         string            `json:"last_error,omitempty" gorm:"type:text"`
+	ConnectedBy       uuid.UUID         `json:"connected_by" gorm:"type:uuid;not null"`
+	CreatedAt         time.Time         `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt         time.Time         `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// ImportSyncMode is whether an import runs once or keeps the folder in sync
+type ImportSyncMode string
+
+const (
+	ImportSyncOnce      ImportSyncMode = "once"
+	ImportSyncScheduled ImportSyncMode = "scheduled"
+)
+
+// ImportSyncStatus is where an import sync is in its lifecycle
+type ImportSyncStatus string
+
+const (
+	ImportSyncPending   ImportSyncStatus = "pending"
+	ImportSyncActive    ImportSyncStatus = "active" // Scheduled syncs between runs
+	ImportSyncCompleted ImportSyncStatus = "completed"
+	ImportSyncFailed    ImportSyncStatus = "failed"
+)
+
+// ImportSync copies a folder of an integration's account, with its
+// subfolders, into an Archivus folder. Scheduled syncs run again every
+// IntervalMinutes and import what was added or changed since.
+type ImportSync struct {
+	ID               uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID         uuid.UUID        `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	IntegrationID    uuid.UUID        `json:"integration_id" gorm:"type:uuid;not null;index"`
+	RemoteFolderID   string           `json:"remote_folder_id" gorm:"type:varchar(255);not null"`
+	RemoteFolderName string           `json:"remote_folder_name" gorm:"type:varchar(500)"`
+	TargetFolderID   *uuid.UUID       `json:"target_folder_id,omitempty" gorm:"type:uuid"` // The root without one
+	UploaderID       uuid.UUID        `json:"uploader_id" gorm:"type:uuid;not null"`
+	EnableAI         bool             `json:"enable_ai" gorm:"not null;default:false"`
+	Mode             ImportSyncMode   `json:"mode" gorm:"type:varchar(20);not null;default:'once'"`
+	IntervalMinutes  int              `json:"interval_minutes" gorm:"not null;default:0"`
+	Status           ImportSyncStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	NextRunAt        *time.Time       `json:"next_run_at,omitempty" gorm:"index"` // Unset once a sync is done
+	LastRunAt        *time.Time       `json:"last_run_at,omitempty"`
+	FilesImported    int64            `json:"files_imported" gorm:"not null;default:0"`
+	FilesUpdated     int64            `json:"files_updated" gorm:"not null;default:0"`
+	FilesSkipped     int64            `json:"files_skipped" gorm:"not null;default:0"`             // Too large or not supported
+	FilesDeleted     int64            `json:"files_deleted" gorm:"not null;default:0"`             // Trashed after their remote file was deleted
+	WebhookID        string           `json:"webhook_id,omitempty" gorm:"type:varchar(255);index"` // The provider's change webhook for the folder, if any
+	LastError        string           `json:"last_error,omitempty" gorm:"type:text"`
+	CreatedBy        uuid.UUID        `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt        time.Time        `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt        time.Time        `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// ImportSyncItem is a remote file or folder an import sync has handled.
+// Version is the remote revision imported; a file is imported again when
+// its revision changes.
+type ImportSyncItem struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	SyncID     uuid.UUID  `json:"sync_id" gorm:"type:uuid;not null;uniqueIndex:idx_import_sync_item_remote"`
+	RemoteID   string     `json:"remote_id" gorm:"type:varchar(255);not null;uniqueIndex:idx_import_sync_item_remote"`
+	IsFolder   bool       `json:"is_folder" gorm:"not null;default:false"`
+	Version    string     `json:"version" gorm:"type:varchar(255)"`
+	DocumentID *uuid.UUID `json:"document_id,omitempty" gorm:"type:uuid"`
+	FolderID   *uuid.UUID `json:"folder_id,omitempty" gorm:"type:uuid"`
+	Skipped    string     `json:"skipped,omitempty" gorm:"type:varchar(255)"` // Why the file wasn't imported
+	CreatedAt  time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// ArchivalRecurrence is whether an archival schedule runs once or every year
+type ArchivalRecurrence string
+
+const (
+	ArchivalOnce   ArchivalRecurrence = "once"
+	ArchivalYearly ArchivalRecurrence = "yearly"
+)
+
+// ArchivalSchedule archives a folder's documents when it comes due, e.g. a
+// project folder once its fiscal year has closed
+type ArchivalSchedule struct {
+	ID                uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID          uuid.UUID          `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	FolderID          uuid.UUID          `json:"folder_id" gorm:"type:uuid;not null;index"`
+	Name              string             `json:"name" gorm:"type:varchar(255);not null"`
+	IncludeSubfolders bool               `json:"include_subfolders" gorm:"not null;default:true"`
+	MinAgeDays        int                `json:"min_age_days" gorm:"not null;default:0"` // Only documents dated at least this long ago; 0 archives all
+	ColdStorage       bool               `json:"cold_storage" gorm:"not null;default:false"`
+	Recurrence        ArchivalRecurrence `json:"recurrence" gorm:"type:varchar(20);not null;default:'once'"`
+	Enabled           bool               `json:"enabled" gorm:"not null;default:true"`
+	NextRunAt         *time.Time         `json:"next_run_at" gorm:"index"` // Unset once a one-time schedule has run
+	LastRunAt         *time.Time         `json:"last_run_at"`
+	CreatedBy         uuid.UUID          `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt         time.Time          `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt         time.Time          `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// ArchivalRunStatus is where an archival run is in its lifecycle
+type ArchivalRunStatus string
+
+const (
+	ArchivalRunRunning   ArchivalRunStatus = "running"
+	ArchivalRunCompleted ArchivalRunStatus = "completed"
+	ArchivalRunFailed    ArchivalRunStatus = "failed"
+	ArchivalRunUndone    ArchivalRunStatus = "undone"
+)
+
+// ArchivalRun is one execution of an archival schedule. It can be undone
+// until UndoUntil; cold storage moves wait for that window to close.
+type ArchivalRun struct {
+	ID                uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID          uuid.UUID         `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	ScheduleID        uuid.UUID         `json:"schedule_id" gorm:"type:uuid;not null;index"`
+	FolderID          uuid.UUID         `json:"folder_id" gorm:"type:uuid;not null"`
+	Status            ArchivalRunStatus `json:"status" gorm:"type:varchar(20);not null;default:'running'"`
+	DocumentsArchived int64             `json:"documents_archived" gorm:"not null;default:0"`
+	BytesArchived     int64             `json:"bytes_archived" gorm:"not null;default:0"`
+	DocumentsHeld     int64             `json:"documents_held" gorm:"not null;default:0"` // Left alone for their legal hold
+	ColdStorage       bool              `json:"cold_storage" gorm:"not null;default:false"`
+	UndoUntil         time.Time         `json:"undo_until" gorm:"not null"`
+	ColdStoredAt      *time.Time        `json:"cold_stored_at"`
+	UndoneAt          *time.Time        `json:"undone_at"`
+	UndoneBy          *uuid.UUID        `json:"undone_by" gorm:"type:uuid"`
+	LastError         string            `json:"last_error,omitempty" gorm:"type:text"`
+	CreatedAt         time.Time         `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt         time.Time         `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// ArchivalRunDocument is a document an archival run archived and the status
+// it had before, which undoing the run restores
+type ArchivalRunDocument struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID       uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	RunID          uuid.UUID `json:"run_id" gorm:"type:uuid;not null;uniqueIndex:idx_archival_run_document"`
+	DocumentID     uuid.UUID `json:"document_id" gorm:"type:uuid;not null;uniqueIndex:idx_archival_run_document"`
+	PreviousStatus DocStatus `json:"previous_status" gorm:"type:varchar(20);not null"`
+	CreatedAt      time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// InvoiceLineItemSource says where an invoice line item came from
+type InvoiceLineItemSource string
+
+const (
+	LineItemExtracted InvoiceLineItemSource = "extracted" // From financial extraction
+	LineItemManual    InvoiceLineItemSource = "manual"    // Entered or corrected by a user
+)
+
+// InvoiceLineItem is one line of an invoice or receipt. Financial extraction
+// fills a document's lines in until a user corrects them; corrected lines
+// are kept when the document is extracted again.
+type InvoiceLineItem struct {
+	ID          uuid.UUID             `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID    uuid.UUID             `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	DocumentID  uuid.UUID             `json:"document_id" gorm:"type:uuid;not null;index"`
+	Position    int                   `json:"position" gorm:"not null;default:0"` // Order on the document, from 0
+	Description string                `json:"description" gorm:"type:text"`
+	Quantity    *float64              `json:"quantity,omitempty" gorm:"type:decimal(15,4)"`
+	UnitPrice   *float64              `json:"unit_price,omitempty" gorm:"type:decimal(15,4)"`
+	TaxAmount   *float64              `json:"tax_amount,omitempty" gorm:"type:decimal(15,2)"`
+	Amount      float64               `json:"amount" gorm:"type:decimal(15,2);not null"`  // Before tax
+	GLCode      string                `json:"gl_code,omitempty" gorm:"type:varchar(100)"` // Booked on the tenant's expense account when empty
+	Source      InvoiceLineItemSource `json:"source" gorm:"type:varchar(20);not null;default:'extracted'"`
+	UpdatedBy   *uuid.UUID            `json:"updated_by,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time             `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt   time.Time             `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// AIFeedbackKind is the AI output a correction is for
+type AIFeedbackKind string
+
+const (
+	AIFeedbackSummary        AIFeedbackKind = "summary"
+	AIFeedbackClassification AIFeedbackKind = "classification"
+	AIFeedbackEntities       AIFeedbackKind = "entities"
+	AIFeedbackFinancial      AIFeedbackKind = "financial"
+)
+
+// AIFeedback is a user's correction of what AI produced for a document.
+// The tenant's recent corrections are shown to the AI provider as examples.
+type AIFeedback struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID      `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	DocumentID uuid.UUID      `json:"document_id" gorm:"type:uuid;not null;index"`
+	UserID     uuid.UUID      `json:"user_id" gorm:"type:uuid;not null"`
+	Kind       AIFeedbackKind `json:"kind" gorm:"type:varchar(20);not null"`
+	Original   JSONB          `json:"original" gorm:"type:jsonb"`         // The corrected fields as AI produced them
+	Corrected  JSONB          `json:"corrected" gorm:"type:jsonb"`        // The same fields as the user corrected them
+	Excerpt    string         `json:"excerpt,omitempty" gorm:"type:text"` // Start of the document's text, the example's input
+	CreatedAt  time.Time      `json:"created_at" gorm:"not null;default:now();index"`
+}
+
+// AIUsage is one call to an AI provider: the tokens it used and what it
+// cost. Tokens are the provider's counts where the client reported them and
+// estimated from the text otherwise.
+type AIUsage struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID     uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index:idx_ai_usage_tenant_created"`
+	JobID        *uuid.UUID `json:"job_id,omitempty" gorm:"type:uuid;index"`
+	DocumentID   *uuid.UUID `json:"document_id,omitempty" gorm:"type:uuid"`
+	Provider     string     `json:"provider" gorm:"type:varchar(50);not null"`
+	Model        string     `json:"model" gorm:"type:varchar(100)"`
+	Operation    string     `json:"operation" gorm:"type:varchar(50);not null"` // e.g. generate_summary
+	KeySource    string     `json:"key_source" gorm:"type:varchar(20)"`         // platform or tenant
+	InputTokens  int        `json:"input_tokens" gorm:"not null;default:0"`
+	OutputTokens int        `json:"output_tokens" gorm:"not null;default:0"`
+	Estimated    bool       `json:"estimated" gorm:"not null;default:false"`           // Tokens estimated from the text
+	Cost         float64    `json:"cost" gorm:"type:decimal(12,6);not null;default:0"` // USD
+	CreatedAt    time.Time  `json:"created_at" gorm:"not null;default:now();index:idx_ai_usage_tenant_created"`
+}
+
+// DocumentChunk is a passage of a document's text and its embedding,
+// searched to answer questions about documents and to find passages.
+// Chunking jobs store the passages and embedding jobs embed them.
+// Embeddings have the dimensions of the model that made them, so the
+// column has none.
+type DocumentChunk struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	DocumentID uuid.UUID `json:"document_id" gorm:"type:uuid;not null;index"`
+	ChunkIndex int       `json:"chunk_index" gorm:"not null"` // Position in the document's text
+	Content    string    `json:"content" gorm:"type:text;not null"`
+	Page       *int      `json:"page,omitempty"` // From 1; only for text with form-feed page breaks
+
+	// Where the passage lies in the document's text, in characters
+	StartOffset int `json:"start_offset" gorm:"not null;default:0"`
+	EndOffset   int `json:"end_offset" gorm:"not null;default:0"`
+
+	Embedding *pgvector.Vector `json:"-" gorm:"type:vector"` // Nil until the passage is embedded
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// FilingSuggestionStatus is where a filing suggestion stands
+type FilingSuggestionStatus string
+
+const (
+	FilingSuggestionPending  FilingSuggestionStatus = "pending"
+	FilingSuggestionAccepted FilingSuggestionStatus = "accepted"
+)
+
+// FilingSuggestion is where AI suggests filing a document: a folder and
+// category of the tenant's, and tags. A document has one, replaced each
+// time its filing_suggestion job runs.
+type FilingSuggestion struct {
+	ID         uuid.UUID              `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID              `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	DocumentID uuid.UUID              `json:"document_id" gorm:"type:uuid;not null;uniqueIndex"`
+	JobID      *uuid.UUID             `json:"job_id,omitempty" gorm:"type:uuid"`
+	FolderID   *uuid.UUID             `json:"folder_id,omitempty" gorm:"type:uuid"`
+	CategoryID *uuid.UUID             `json:"category_id,omitempty" gorm:"type:uuid"`
+	Tags       JSONB                  `json:"tags" gorm:"type:jsonb"` // Tag names under "names", existing tags' and new ones
+	Confidence float64                `json:"confidence" gorm:"type:decimal(3,2)"`
+	Reason     string                 `json:"reason,omitempty" gorm:"type:text"`
+	Status     FilingSuggestionStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	ReviewedBy *uuid.UUID             `json:"reviewed_by,omitempty" gorm:"type:uuid"`
+	ReviewedAt *time.Time             `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time              `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt  time.Time              `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// EntityType is the kind of thing a named entity is
+type EntityType string
+
+const (
+	EntityPerson       EntityType = "person"
+	EntityOrganization EntityType = "organization"
+	EntityLocation     EntityType = "location"
+	EntityDate         EntityType = "date"
+	EntityAmount       EntityType = "amount"
+	EntityEmail        EntityType = "email"
+)
+
+// Entity is a named entity a document mentions, indexed from its entity
+// extraction so documents can be browsed by who and what they mention.
+// Mentions are matched on their normalized value, so "ACME Corp." and
+// "Acme Corp" are the same entity.
+type Entity struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID        uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index:idx_entity_tenant_value"`
+	DocumentID      uuid.UUID  `json:"document_id" gorm:"type:uuid;not null;uniqueIndex:idx_entity_document_value"`
+	Type            EntityType `json:"type" gorm:"type:varchar(20);not null;index:idx_entity_tenant_value;uniqueIndex:idx_entity_document_value"`
+	Value           string     `json:"value" gorm:"type:varchar(255);not null"` // As the document has it
+	NormalizedValue string     `json:"normalized_value" gorm:"type:varchar(255);not null;index:idx_entity_tenant_value;uniqueIndex:idx_entity_document_value"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// DocumentFingerprint holds a document's fuzzy hashes, computed by the
+// fingerprint job so re-scanned and slightly edited copies are found as
+// near-duplicates. Both are 64-bit hashes compared by how many bits differ:
+// a simhash of the extracted text and a perceptual hash of images.
+type DocumentFingerprint struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID   uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	DocumentID uuid.UUID `json:"document_id" gorm:"type:uuid;not null;uniqueIndex"`
+	TextHash   *int64    `json:"text_hash,omitempty"`  // Nil for documents with too little text
+	ImageHash  *int64    `json:"image_hash,omitempty"` // Nil for documents that aren't images
+	CreatedAt  time.Time `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// DocumentFingerprintBand is one 8-bit band of a fingerprint hash. Near-
+// duplicates are looked for only among documents sharing a band, so pairs
+// differing in up to 7 bits are always compared and further ones often are.
+type DocumentFingerprintBand struct {
+	TenantID   uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index:idx_fingerprint_band_bucket"`
+	DocumentID uuid.UUID `json:"document_id" gorm:"type:uuid;primaryKey"`
+	Method     string    `json:"method" gorm:"type:varchar(10);primaryKey;index:idx_fingerprint_band_bucket"` // text or image
+	Band       int16     `json:"band" gorm:"primaryKey;autoIncrement:false;index:idx_fingerprint_band_bucket"`
+	Value      int16     `json:"value" gorm:"not null;index:idx_fingerprint_band_bucket"`
+}
+
+// AIReprocessStatus is where a reprocessing batch is in queuing its jobs
+type AIReprocessStatus string
+
+const (
+	AIReprocessPending   AIReprocessStatus = "pending"
+	AIReprocessRunning   AIReprocessStatus = "running"
+	AIReprocessCompleted AIReprocessStatus = "completed" // Every job is queued
+	AIReprocessFailed    AIReprocessStatus = "failed"
+)
+
+// AIReprocessBatch queues AI jobs again for every document matching a
+// search, such as after prompts improved or embeddings were turned on. A
+// background worker queues the jobs a few documents at a time, holding
+// back while MaxInFlight of the batch's jobs are still waiting or running.
+// Criteria records the search filters, JobTypes the job_types. Cursor is
+// the last document queued, so a batch picked up after a crash resumes
+// where it stopped.
+type AIReprocessBatch struct {
+	ID          uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID    uuid.UUID         `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	JobTypes    JSONB             `json:"job_types" gorm:"type:jsonb"`
+	Criteria    JSONB             `json:"criteria" gorm:"type:jsonb"`
+	Priority    int               `json:"priority" gorm:"not null;default:9"`
+	MaxInFlight int               `json:"max_in_flight" gorm:"not null"`
+	Status      AIReprocessStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index:idx_ai_reprocess_due"`
+	Matched     int64             `json:"matched"` // Documents matching when the batch was created
+	Queued      int64             `json:"queued"`  // Documents whose jobs are queued
+	Cursor      *uuid.UUID        `json:"-" gorm:"type:uuid"`
+	Attempts    int               `json:"attempts" gorm:"not null;default:0"`
+	LeaseUntil  *time.Time        `json:"-" gorm:"index:idx_ai_reprocess_due"`
+	Error       string            `json:"error,omitempty" gorm:"type:text"`
+	RequestedBy uuid.UUID         `json:"requested_by" gorm:"type:uuid;not null"`
+	CreatedAt   time.Time         `json:"created_at" gorm:"not null;default:now()"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+}
+
+// PromptKind is an AI call whose prompt tenants can reword
+type PromptKind string
+
+const (
+	PromptSummarization  PromptKind = "summarization"
+	PromptClassification PromptKind = "classification"
+	PromptExtraction     PromptKind = "extraction" // Entity extraction
+	PromptTagging        PromptKind = "tagging"
+)
+
+// PromptTemplate overrides the system default instructions of an AI call
+// for one tenant. Placeholders use {{variable}} syntax; the reply format
+// the call parses is always appended. Every save is a new version and at
+// most one version per kind is active; with none active the default applies.
+type PromptTemplate struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID  uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_prompt_template_version"`
+	Kind      PromptKind `json:"kind" gorm:"type:varchar(30);not null;uniqueIndex:idx_prompt_template_version"`
+	Version   int        `json:"version" gorm:"not null;uniqueIndex:idx_prompt_template_version"`
+	Body      string     `json:"body" gorm:"type:text;not null"`
+	Note      string     `json:"note,omitempty" gorm:"type:varchar(255)"` // What changed
+	IsActive  bool       `json:"is_active" gorm:"not null;default:false"`
+	CreatedBy uuid.UUID  `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
+// DocumentTypeDefinition is a tenant-defined document type, used alongside
+// the built-in ones. MapsTo names the built-in type it behaves like: its
+// documents get financial extraction if the built-in's do, and its retention
+// rule and workflows unless the type has its own. Inactive types are kept on
+// the documents that have them but can't be assigned.
+type DocumentTypeDefinition struct {
+	ID          uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	TenantID    uuid.UUID     `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_document_type_key"`
+	Key         DocumentType  `json:"key" gorm:"type:varchar(50);not null;uniqueIndex:idx_document_type_key"`
+	Name        string        `json:"name" gorm:"type:varchar(100);not null"`
+	Description string        `json:"description,omitempty" gorm:"type:text"` // Shown to the AI when classifying
+	MapsTo      *DocumentType `json:"maps_to,omitempty" gorm:"type:varchar(50)"`
+	IsActive    bool          `json:"is_active" gorm:"not null;default:true"`
+	CreatedBy   uuid.UUID     `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt   time.Time     `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt   time.Time     `json:"updated_at" gorm:"not null;default:now()"`
+
+	// Relationships
+	Tenant Tenant `json:"tenant,omitempty" gorm:"foreignKey:TenantID"`
+}
+
 // GetAllModels returns all models for migration
 func GetAllModels() []interface{} {
 	return []interface{}{
 		&Tenant{},
+		&Role{},
+		&RolePermission{},
 		&User{},
+		&APIKey{},
+		&TenantAIKey{},
+		&MFARecoveryCode{},
+		&APIKeyScope{},
 		&Folder{},
+		&FolderACL{},
 		&Category{},
 		&Tag{},
 		&Document{},
+		&DocumentACL{},
 		&DocumentVersion{},
 		&DocumentTemplate{},
 		&DocumentComment{},
 		&DocumentAnalytics{},
 		&Workflow{},
+		&WorkflowVersion{},
+		&WorkflowInstance{},
 		&WorkflowTask{},
+		&WorkflowChecklistItem{},
+		&WorkflowTaskDelegation{},
+		&OutOfOffice{},
 		&Notification{},
+		&NotificationDelivery{},
+		&DeviceToken{},
+		&SMSMessage{},
+		&NotificationTemplate{},
 		&AIProcessingJob{},
 		&AuditLog{},
+		&AuditChainHead{},
+		&AuditArchive{},
+		&AuditStream{},
 		&Share{},
+		&ShareAccess{},
+		&Webhook{},
+		&WebhookEvent{},
+		&WebhookDelivery{},
+		&WebhookDeliveryAttempt{},
+		&ScheduledJob{},
+		&ScheduledJobRun{},
+		&LegalHold{},
+		&LegalHoldDocument{},
+		&DataSubjectRequest{},
+		&TenantDeletion{},
+		&TenantDeletionCertificate{},
+		&APIUsage{},
+		&VendorProfile{},
+		&VendorProfileAlias{},
+		&UploadSession{},
+		&WorkerHeartbeat{},
+		&BulkLabelJob{},
+		&NumberingSequence{},
+		&Integration{},
+		&ImportSync{},
+		&ImportSyncItem{},
+		&ArchivalSchedule{},
+		&ArchivalRun{},
+		&ArchivalRunDocument{},
+		&InvoiceLineItem{},
+		&AIFeedback{},
+		&AIUsage{},
+		&DocumentChunk{},
+		&FilingSuggestion{},
+		&Entity{},
+		&DocumentFingerprint{},
+		&DocumentFingerprintBand{},
+		&AIReprocessBatch{},
+		&PromptTemplate{},
+		&DocumentTypeDefinition{},
 	}
 }
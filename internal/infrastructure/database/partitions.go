@@ -0,0 +1,428 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PartitionStrategy is how a partitioned table splits its rows
+type PartitionStrategy string
+
+const (
+	PartitionByMonth  PartitionStrategy = "month"  // RANGE on a timestamp column, one partition per month
+	PartitionByTenant PartitionStrategy = "tenant" // HASH on tenant_id
+)
+
+// PartitionedTable describes a high-volume table managed by the partition manager
+type PartitionedTable struct {
+	Name       string
+	Column     string   // Partition key
+	PrimaryKey []string // Must include the partition key
+	Strategy   PartitionStrategy
+
+	// Tenant strategy
+	Modulus int
+
+	// Month strategy. Partitions that ended more than RetentionMonths ago are
+	// detached; 0 keeps every partition. With DetachEmptyOnly, a partition is
+	// only detached (and dropped) once its rows have been removed elsewhere.
+	RetentionMonths int
+	DetachEmptyOnly bool
+}
+
+// PartitionConfig holds configuration for partition maintenance
+type PartitionConfig struct {
+	Interval             time.Duration // How often maintenance runs
+	MonthsAhead          int           // Monthly partitions created ahead of time
+	AIJobRetentionMonths int           // Months before emptied AI job partitions are dropped
+	TenantModulus        int           // Hash partitions for tenant-partitioned tables
+}
+
+// PartitionInfo describes one partition of a table
+type PartitionInfo struct {
+	Table         string
+	Partition     string
+	Bound         string
+	EstimatedRows int64
+}
+
+// PartitionManager converts high-volume tables to partitioned tables and
+// keeps their partitions current: future months are created ahead of time
+// and expired months are detached.
+type PartitionManager struct {
+	db     *DB
+	tables []PartitionedTable
+	config PartitionConfig
+}
+
+// NewPartitionManager creates a new partition manager
+func NewPartitionManager(db *DB, config PartitionConfig) *PartitionManager {
+	if config.Interval <= 0 {
+		config.Interval = 6 * time.Hour
+	}
+	if config.MonthsAhead <= 0 {
+		config.MonthsAhead = 3
+	}
+	if config.AIJobRetentionMonths <= 0 {
+		config.AIJobRetentionMonths = 6
+	}
+	if config.TenantModulus <= 0 {
+		config.TenantModulus = 16
+	}
+
+	return &PartitionManager{
+		db:     db,
+		config: config,
+		tables: []PartitionedTable{
+			{
+				// Audit entries leave the table through audit archival, which
+				// keeps the hash chain intact; only emptied months are detached.
+				Name:            "audit_logs",
+				Column:          "created_at",
+				PrimaryKey:      []string{"id", "created_at"},
+				Strategy:        PartitionByMonth,
+				RetentionMonths: 1,
+				DetachEmptyOnly: true,
+			},
+			{
+				// Job rows hold the AI results documents are read with, and
+				// old months may still have queued jobs, so a month is only
+				// detached once its rows have been removed.
+				Name:            "ai_processing_jobs",
+				Column:          "created_at",
+				PrimaryKey:      []string{"id", "created_at"},
+				Strategy:        PartitionByMonth,
+				RetentionMonths: config.AIJobRetentionMonths,
+				DetachEmptyOnly: true,
+			},
+			{
+				// One row per document, updated in place, so split by tenant
+				Name:       "document_analytics",
+				Column:     "tenant_id",
+				PrimaryKey: []string{"id", "tenant_id"},
+				Strategy:   PartitionByTenant,
+				Modulus:    config.TenantModulus,
+			},
+		},
+	}
+}
+
+// Tables returns the tables the manager is responsible for
+func (m *PartitionManager) Tables() []PartitionedTable {
+	return m.tables
+}
+
+// Supported reports whether the database supports declarative partitioning
+func (m *PartitionManager) Supported() bool {
+	return m.db.isPostgres()
+}
+
+// IsPartitioned reports whether the table is already a partitioned table
+func (m *PartitionManager) IsPartitioned(ctx context.Context, table string) (bool, error) {
+	var count int64
+	err := m.db.WithContext(ctx).Raw(
+		"SELECT COUNT(*) FROM pg_partitioned_table pt JOIN pg_class c ON c.oid = pt.partrelid WHERE c.relname = ? AND pg_table_is_visible(c.oid)",
+		table,
+	).Scan(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check partitioning: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Convert rebuilds an existing table as a partitioned table and copies its
+// rows across. The table is locked for the duration, so run it from the
+// migration tool during a maintenance window. Indexes and foreign keys are
+// recreated by the auto-migration that follows.
+func (m *PartitionManager) Convert(ctx context.Context, table PartitionedTable, now time.Time) error {
+	if !m.Supported() {
+		return fmt.Errorf("table partitioning requires PostgreSQL")
+	}
+
+	partitioned, err := m.IsPartitioned(ctx, table.Name)
+	if err != nil {
+		return err
+	}
+	if partitioned {
+		return nil
+	}
+
+	legacy := table.Name + "_unpartitioned"
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE", quoteIdent(table.Name))).Error; err != nil {
+			return fmt.Errorf("failed to lock %s: %w", table.Name, err)
+		}
+
+		// Free the primary key name for the new table
+		var primaryKey string
+		if err := tx.Raw(
+			"SELECT conname FROM pg_constraint WHERE conrelid = ?::regclass AND contype = 'p'", table.Name,
+		).Scan(&primaryKey).Error; err != nil {
+			return fmt.Errorf("failed to read primary key of %s: %w", table.Name, err)
+		}
+
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE %s RENAME TO %s", quoteIdent(table.Name), quoteIdent(legacy)),
+		}
+		if primaryKey != "" {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s RENAME CONSTRAINT %s TO %s",
+				quoteIdent(legacy), quoteIdent(primaryKey), quoteIdent(legacy+"_pkey")))
+		}
+		statements = append(statements,
+			fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS) PARTITION BY %s",
+				quoteIdent(table.Name), quoteIdent(legacy), partitionClause(table)),
+			fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s)", quoteIdent(table.Name), quoteIdents(table.PrimaryKey)),
+		)
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to convert %s: %w", table.Name, err)
+			}
+		}
+
+		switch table.Strategy {
+		case PartitionByMonth:
+			// Cover every month that already has rows, then the months ahead
+			var oldest *time.Time
+			if err := tx.Raw(fmt.Sprintf("SELECT MIN(%s) FROM %s", quoteIdent(table.Column), quoteIdent(legacy))).
+				Scan(&oldest).Error; err != nil {
+				return fmt.Errorf("failed to find oldest row in %s: %w", table.Name, err)
+			}
+			from := monthStart(now)
+			if oldest != nil && oldest.Before(from) {
+				from = monthStart(*oldest)
+			}
+			for month := from; !month.After(monthStart(now).AddDate(0, m.config.MonthsAhead, 0)); month = month.AddDate(0, 1, 0) {
+				if err := createMonthPartition(tx, table, month); err != nil {
+					return err
+				}
+			}
+			if err := tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s DEFAULT",
+				quoteIdent(table.Name+"_default"), quoteIdent(table.Name))).Error; err != nil {
+				return fmt.Errorf("failed to create default partition of %s: %w", table.Name, err)
+			}
+		case PartitionByTenant:
+			for remainder := 0; remainder < table.Modulus; remainder++ {
+				if err := tx.Exec(fmt.Sprintf("CREATE TABLE %s PARTITION OF %s FOR VALUES WITH (MODULUS %d, REMAINDER %d)",
+					quoteIdent(fmt.Sprintf("%s_h%02d", table.Name, remainder)), quoteIdent(table.Name), table.Modulus, remainder)).Error; err != nil {
+					return fmt.Errorf("failed to create hash partition of %s: %w", table.Name, err)
+				}
+			}
+		default:
+			return fmt.Errorf("unknown partition strategy %q", table.Strategy)
+		}
+
+		if err := tx.Exec(fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", quoteIdent(table.Name), quoteIdent(legacy))).Error; err != nil {
+			return fmt.Errorf("failed to copy rows into %s: %w", table.Name, err)
+		}
+		if err := tx.Exec(fmt.Sprintf("DROP TABLE %s", quoteIdent(legacy))).Error; err != nil {
+			return fmt.Errorf("failed to drop %s: %w", legacy, err)
+		}
+		return nil
+	})
+}
+
+// EnsurePartitions creates the current and upcoming monthly partitions of
+// every partitioned table, returning the partitions it created
+func (m *PartitionManager) EnsurePartitions(ctx context.Context, now time.Time) ([]string, error) {
+	if !m.Supported() {
+		return nil, nil
+	}
+
+	var created []string
+	for _, table := range m.tables {
+		if table.Strategy != PartitionByMonth {
+			continue
+		}
+		partitioned, err := m.IsPartitioned(ctx, table.Name)
+		if err != nil {
+			return created, err
+		}
+		if !partitioned {
+			continue
+		}
+
+		for i := 0; i <= m.config.MonthsAhead; i++ {
+			month := monthStart(now).AddDate(0, i, 0)
+			name := monthPartitionName(table.Name, month)
+
+			exists, err := m.tableExists(ctx, name)
+			if err != nil {
+				return created, err
+			}
+			if exists {
+				continue
+			}
+			if err := createMonthPartition(m.db.WithContext(ctx), table, month); err != nil {
+				return created, err
+			}
+			created = append(created, name)
+		}
+	}
+
+	return created, nil
+}
+
+// DetachExpiredPartitions detaches monthly partitions past their table's
+// retention, returning the partitions it detached. Empty partitions are
+// dropped; others are left as standalone tables to be exported or dropped.
+func (m *PartitionManager) DetachExpiredPartitions(ctx context.Context, now time.Time) ([]string, error) {
+	if !m.Supported() {
+		return nil, nil
+	}
+
+	var detached []string
+	for _, table := range m.tables {
+		if table.Strategy != PartitionByMonth || table.RetentionMonths <= 0 {
+			continue
+		}
+
+		partitions, err := m.listPartitions(ctx, table.Name)
+		if err != nil {
+			return detached, err
+		}
+
+		cutoff := monthStart(now).AddDate(0, -table.RetentionMonths, 0)
+		for _, partition := range partitions {
+			month, ok := parseMonthPartition(table.Name, partition.Partition)
+			if !ok || month.AddDate(0, 1, 0).After(cutoff) {
+				continue
+			}
+
+			var hasRows bool
+			if err := m.db.WithContext(ctx).Raw(fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", quoteIdent(partition.Partition))).
+				Scan(&hasRows).Error; err != nil {
+				return detached, fmt.Errorf("failed to inspect partition %s: %w", partition.Partition, err)
+			}
+			if hasRows && table.DetachEmptyOnly {
+				continue
+			}
+
+			if err := m.db.WithContext(ctx).Exec(fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s",
+				quoteIdent(table.Name), quoteIdent(partition.Partition))).Error; err != nil {
+				return detached, fmt.Errorf("failed to detach partition %s: %w", partition.Partition, err)
+			}
+			if !hasRows {
+				if err := m.db.WithContext(ctx).Exec(fmt.Sprintf("DROP TABLE %s", quoteIdent(partition.Partition))).Error; err != nil {
+					return detached, fmt.Errorf("failed to drop partition %s: %w", partition.Partition, err)
+				}
+			}
+			detached = append(detached, partition.Partition)
+		}
+	}
+
+	return detached, nil
+}
+
+// Maintain creates upcoming partitions and detaches expired ones
+func (m *PartitionManager) Maintain(ctx context.Context) error {
+	now := time.Now().UTC()
+	if _, err := m.EnsurePartitions(ctx, now); err != nil {
+		return err
+	}
+	_, err := m.DetachExpiredPartitions(ctx, now)
+	return err
+}
+
+// Interval returns how often maintenance should run
+func (m *PartitionManager) Interval() time.Duration {
+	return m.config.Interval
+}
+
+// Status lists the partitions of every managed table that is partitioned
+func (m *PartitionManager) Status(ctx context.Context) ([]PartitionInfo, error) {
+	if !m.Supported() {
+		return nil, nil
+	}
+
+	var status []PartitionInfo
+	for _, table := range m.tables {
+		partitions, err := m.listPartitions(ctx, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		status = append(status, partitions...)
+	}
+	return status, nil
+}
+
+// Helper methods
+
+func (m *PartitionManager) listPartitions(ctx context.Context, table string) ([]PartitionInfo, error) {
+	var partitions []PartitionInfo
+	err := m.db.WithContext(ctx).Raw(`
+		SELECT parent.relname AS "table", child.relname AS "partition",
+			pg_get_expr(child.relpartbound, child.oid) AS bound,
+			GREATEST(child.reltuples, 0)::bigint AS estimated_rows
+		FROM pg_inherits i
+		JOIN pg_class parent ON parent.oid = i.inhparent
+		JOIN pg_class child ON child.oid = i.inhrelid
+		WHERE parent.relname = ? AND pg_table_is_visible(parent.oid)
+		ORDER BY child.relname`, table,
+	).Scan(&partitions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", table, err)
+	}
+	return partitions, nil
+}
+
+func (m *PartitionManager) tableExists(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	if err := m.db.WithContext(ctx).Raw("SELECT to_regclass(?) IS NOT NULL", name).Scan(&exists).Error; err != nil {
+		return false, fmt.Errorf("failed to check table %s: %w", name, err)
+	}
+	return exists, nil
+}
+
+func createMonthPartition(db *gorm.DB, table PartitionedTable, month time.Time) error {
+	err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+		quoteIdent(monthPartitionName(table.Name, month)), quoteIdent(table.Name),
+		month.Format(time.RFC3339), month.AddDate(0, 1, 0).Format(time.RFC3339))).Error
+	if err != nil {
+		return fmt.Errorf("failed to create partition of %s for %s: %w", table.Name, month.Format("2006-01"), err)
+	}
+	return nil
+}
+
+func partitionClause(table PartitionedTable) string {
+	if table.Strategy == PartitionByTenant {
+		return fmt.Sprintf("HASH (%s)", quoteIdent(table.Column))
+	}
+	return fmt.Sprintf("RANGE (%s)", quoteIdent(table.Column))
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func monthPartitionName(table string, month time.Time) string {
+	return fmt.Sprintf("%s_p%s", table, month.Format("200601"))
+}
+
+func parseMonthPartition(table, partition string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(partition, table+"_p")
+	if !ok {
+		return time.Time{}, false
+	}
+	month, err := time.Parse("200601", suffix)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/migrations"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var ErrSchemaDrift = errors.New("database schema differs from the models; run the migrate plan command to see how")
+
+// SchemaPlan is what migrating would change, worked out without changing
+// anything
+type SchemaPlan struct {
+	Migrations []string // Versioned migrations not yet applied, by up file
+	Statements []string // DDL auto-migration would run to match the models
+	Indexes    []string // SchemaIndexes missing or left invalid by an interrupted build
+}
+
+// Empty reports whether the schema matches the models
+func (p *SchemaPlan) Empty() bool {
+	return len(p.Migrations) == 0 && len(p.Statements) == 0 && len(p.Indexes) == 0
+}
+
+// PlanMigration diffs the models against the live schema. Auto-migration
+// runs as usual, reading the catalog, but the statements it would execute
+// are recorded instead.
+func (db *DB) PlanMigration(ctx context.Context, models []interface{}) (*SchemaPlan, error) {
+	plan := &SchemaPlan{}
+
+	if db.isPostgres() {
+		applied := uint(0)
+		state, err := db.MigrationState(ctx)
+		if err != nil && !errors.Is(err, ErrSchemaNotInitialized) {
+			return nil, err
+		}
+		if state != nil {
+			applied = state.Version
+		}
+		if plan.Migrations, err = migrations.Pending(applied); err != nil {
+			return nil, err
+		}
+
+		for _, index := range SchemaIndexes {
+			var valid bool
+			if err := db.WithContext(ctx).Raw(
+				"SELECT EXISTS (SELECT 1 FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid WHERE c.relname = ? AND i.indisvalid)",
+				index.Name,
+			).Scan(&valid).Error; err != nil {
+				return nil, fmt.Errorf("failed to inspect index %s: %w", index.Name, err)
+			}
+			if !valid {
+				plan.Indexes = append(plan.Indexes, fmt.Sprintf("CREATE INDEX CONCURRENTLY %s ON %s %s",
+					quoteIdent(index.Name), quoteIdent(index.Table), index.Expression))
+			}
+		}
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+	pool := &planPool{ConnPool: sqlDB, dialector: db.Dialector}
+
+	// A session of its own, so the pool swap doesn't reach other queries
+	session := db.Session(&gorm.Session{NewDB: true, Context: ctx, Logger: logger.Discard})
+	session.Statement.ConnPool = pool
+	if err := session.AutoMigrate(append(models, &schemaVersionRecord{})...); err != nil {
+		return nil, fmt.Errorf("failed to diff schema: %w", err)
+	}
+
+	// Statements about tables that don't exist yet are planned more than once,
+	// since the catalog never shows them created
+	seen := map[string]bool{}
+	for _, statement := range pool.statements {
+		if !seen[statement] {
+			seen[statement] = true
+			plan.Statements = append(plan.Statements, statement)
+		}
+	}
+	return plan, nil
+}
+
+// VerifySchema checks the schema version is compatible and the schema
+// matches the models, returning the plan that would fix any drift
+func (db *DB) VerifySchema(ctx context.Context, models []interface{}) (*SchemaPlan, error) {
+	if err := db.CheckSchemaVersion(ctx); err != nil {
+		return nil, err
+	}
+
+	plan, err := db.PlanMigration(ctx, models)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.Empty() {
+		return plan, fmt.Errorf("%w (%d migrations, %d statements and %d indexes pending)",
+			ErrSchemaDrift, len(plan.Migrations), len(plan.Statements), len(plan.Indexes))
+	}
+	return plan, nil
+}
+
+// planPool passes the migrator's catalog queries through to the database
+// and records the statements it executes instead of running them
+type planPool struct {
+	gorm.ConnPool
+	dialector  gorm.Dialector
+	statements []string
+}
+
+func (p *planPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.statements = append(p.statements, p.dialector.Explain(query, args...))
+	return driver.RowsAffected(0), nil
+}
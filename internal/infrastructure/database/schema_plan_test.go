@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type planWidget struct {
+	ID   uint
+	Name string
+}
+
+func (planWidget) TableName() string { return "plan_widgets" }
+
+// planWidgetV2 is planWidget after a column was added to the model
+type planWidgetV2 struct {
+	ID    uint
+	Name  string
+	Color string
+}
+
+func (planWidgetV2) TableName() string { return "plan_widgets" }
+
+func TestPlanMigration(t *testing.T) {
+	db, err := New("file:plan_migration?mode=memory&cache=shared")
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	// A new table is planned, not created
+	plan, err := db.PlanMigration(ctx, []interface{}{&planWidget{}})
+	require.NoError(t, err)
+	assert.False(t, plan.Empty())
+	assert.True(t, containsStatement(plan.Statements, "CREATE TABLE `plan_widgets`"))
+	assert.False(t, db.Migrator().HasTable("plan_widgets"))
+
+	// Nothing is pending once migrated
+	require.NoError(t, db.AutoMigrate(&planWidget{}, &schemaVersionRecord{}))
+	require.NoError(t, db.saveSchemaVersion(ctx, SchemaVersion))
+	plan, err = db.PlanMigration(ctx, []interface{}{&planWidget{}})
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), "unexpected statements: %v", plan.Statements)
+	_, err = db.VerifySchema(ctx, []interface{}{&planWidget{}})
+	assert.NoError(t, err)
+
+	// A column added to the model is drift
+	plan, err = db.VerifySchema(ctx, []interface{}{&planWidgetV2{}})
+	assert.ErrorIs(t, err, ErrSchemaDrift)
+	require.NotNil(t, plan)
+	assert.True(t, containsStatement(plan.Statements, "`color`"))
+	assert.False(t, db.Migrator().HasColumn(&planWidgetV2{}, "color"))
+}
+
+func containsStatement(statements []string, fragment string) bool {
+	for _, statement := range statements {
+		if strings.Contains(statement, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TextSearchConfigs are the Postgres text search configurations documents
+// are full-text indexed and searched with, by document language. Documents
+// in other languages use "simple", which lowercases words without stemming
+// them or dropping stop words.
+var TextSearchConfigs = map[string]string{
+	"da": "danish",
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fi": "finnish",
+	"fr": "french",
+	"hu": "hungarian",
+	"it": "italian",
+	"nl": "dutch",
+	"no": "norwegian",
+	"pt": "portuguese",
+	"ru": "russian",
+	"sv": "swedish",
+	"tr": "turkish",
+}
+
+// DocumentSearchConfig is the text search configuration for a document's
+// language, as an SQL expression over the documents table
+var DocumentSearchConfig = documentSearchConfig()
+
+// DocumentSearchVector is the tsvector documents are full-text searched by.
+// Queries must use this expression as is for idx_documents_search_gin to
+// serve them.
+var DocumentSearchVector = fmt.Sprintf(
+	"to_tsvector(%s, coalesce(title, '') || ' ' || coalesce(extracted_text, '') || ' ' || coalesce(ocr_text, ''))",
+	DocumentSearchConfig)
+
+// documentSearchConfig maps languages to configurations in a CASE whose
+// branches are in a stable order, so the expression is the same every build
+func documentSearchConfig() string {
+	languages := make([]string, 0, len(TextSearchConfigs))
+	for language := range TextSearchConfigs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	var expression strings.Builder
+	expression.WriteString("CASE language")
+	for _, language := range languages {
+		fmt.Fprintf(&expression, " WHEN '%s' THEN '%s'::regconfig", language, TextSearchConfigs[language])
+	}
+	expression.WriteString(" ELSE 'simple'::regconfig END")
+	return expression.String()
+}
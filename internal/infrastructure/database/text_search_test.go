@@ -0,0 +1,16 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentSearchConfig(t *testing.T) {
+	// The expression must not change between builds, or the index stops
+	// matching queries
+	assert.Equal(t, documentSearchConfig(), DocumentSearchConfig)
+	assert.Contains(t, DocumentSearchConfig, "WHEN 'de' THEN 'german'::regconfig WHEN 'en' THEN 'english'::regconfig")
+	assert.Contains(t, DocumentSearchConfig, "ELSE 'simple'::regconfig END")
+	assert.Contains(t, DocumentSearchVector, "to_tsvector(CASE language")
+}
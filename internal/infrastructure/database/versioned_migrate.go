@@ -0,0 +1,252 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/migrations"
+	"github.com/golang-migrate/migrate/v4"
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/jackc/pgx/v5/stdlib" // Registers the "pgx" driver migrations connect with
+	"gorm.io/driver/postgres"
+)
+
+// BaselineVersion is the first versioned migration, the schema auto-migration
+// had built when migrations became versioned. Databases auto-migrated before
+// then are adopted at it rather than having it applied.
+const BaselineVersion = 25
+
+// migrationsTable records the applied migration version and whether the
+// last migration failed
+const migrationsTable = "schema_migrations"
+
+var ErrVersionedMigrationsUnsupported = errors.New("versioned migrations require PostgreSQL; development databases are migrated from the models")
+
+// MigrationState is the applied versioned migration
+type MigrationState struct {
+	Version uint
+	Dirty   bool // The migration to Version failed; fix the schema, then force the version
+}
+
+// MigrateUp applies the pending versioned migrations, then builds
+// SchemaIndexes concurrently and records the schema version. Development
+// databases (SQLite) are auto-migrated from the models instead.
+func (db *DB) MigrateUp(ctx context.Context, models []interface{}, options MigrationOptions) error {
+	if !db.isPostgres() {
+		return db.Migrate(ctx, models, options)
+	}
+	if options.LockTimeout <= 0 {
+		options.LockTimeout = 5 * time.Second
+	}
+
+	unlock, err := db.acquireMigrationLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	migrator, err := db.newVersionedMigrator(options)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err := db.adoptAutoMigratedSchema(ctx, migrator, models, options); err != nil {
+		return err
+	}
+
+	if err := migrator.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	for _, index := range SchemaIndexes {
+		if err := db.createIndexConcurrently(ctx, index); err != nil {
+			return err
+		}
+	}
+
+	return db.recordSchemaVersion(ctx)
+}
+
+// MigrateDown reverts the last steps versioned migrations, or all of them
+// when steps is 0, which drops every table
+func (db *DB) MigrateDown(ctx context.Context, steps int) error {
+	if !db.isPostgres() {
+		return ErrVersionedMigrationsUnsupported
+	}
+
+	unlock, err := db.acquireMigrationLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	migrator, err := db.newVersionedMigrator(MigrationOptions{LockTimeout: 5 * time.Second})
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if steps > 0 {
+		err = migrator.Steps(-steps)
+	} else {
+		err = migrator.Down()
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to revert migrations: %w", err)
+	}
+
+	return db.recordMigratedVersion(ctx, migrator)
+}
+
+// ForceMigrationVersion marks the database as at version without running
+// any migration, clearing a failed migration once the schema has been fixed
+// by hand. A version of -1 marks it as having no migrations applied.
+func (db *DB) ForceMigrationVersion(ctx context.Context, version int) error {
+	if !db.isPostgres() {
+		return ErrVersionedMigrationsUnsupported
+	}
+
+	unlock, err := db.acquireMigrationLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	migrator, err := db.newVersionedMigrator(MigrationOptions{LockTimeout: 5 * time.Second})
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err := migrator.Force(version); err != nil {
+		return fmt.Errorf("failed to force migration version %d: %w", version, err)
+	}
+
+	return db.recordMigratedVersion(ctx, migrator)
+}
+
+// MigrationState returns the applied versioned migration
+func (db *DB) MigrationState(ctx context.Context) (*MigrationState, error) {
+	if !db.isPostgres() {
+		return nil, ErrVersionedMigrationsUnsupported
+	}
+
+	migrator, err := db.newVersionedMigrator(MigrationOptions{LockTimeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	defer migrator.Close()
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return nil, ErrSchemaNotInitialized
+		}
+		return nil, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return &MigrationState{Version: version, Dirty: dirty}, nil
+}
+
+// Helper methods
+
+// newVersionedMigrator opens a migrator over the embedded migrations. It gets
+// a connection pool of its own, since closing it closes the pool.
+func (db *DB) newVersionedMigrator(options MigrationOptions) (*migrate.Migrate, error) {
+	dialector, ok := db.Dialector.(*postgres.Dialector)
+	if !ok || dialector.Config == nil || dialector.DSN == "" {
+		return nil, fmt.Errorf("versioned migrations need a PostgreSQL connection string")
+	}
+
+	sqlDB, err := sql.Open("pgx", withLockTimeout(dialector.DSN, options.LockTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migration connection: %w", err)
+	}
+
+	source, err := iofs.New(migrations.Files, ".")
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	driver, err := migratepgx.WithInstance(sqlDB, &migratepgx.Config{MigrationsTable: migrationsTable})
+	if err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to prepare migrations: %w", err)
+	}
+
+	migrator, err := migrate.NewWithInstance("iofs", source, "pgx", driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("failed to prepare migrations: %w", err)
+	}
+	return migrator, nil
+}
+
+// adoptAutoMigratedSchema moves databases auto-migrated before migrations
+// were versioned onto them. They already have the baseline's tables, so
+// older ones are auto-migrated up to it and all are then marked as at it.
+// New databases are left for the baseline to create.
+func (db *DB) adoptAutoMigratedSchema(ctx context.Context, migrator *migrate.Migrate, models []interface{}, options MigrationOptions) error {
+	if _, _, err := migrator.Version(); !errors.Is(err, migrate.ErrNilVersion) {
+		return err
+	}
+
+	previous := db.appliedSchemaVersion(ctx)
+	if previous == 0 && !db.Migrator().HasTable("tenants") {
+		return nil
+	}
+
+	if previous < BaselineVersion {
+		if err := db.autoMigrate(ctx, models, options); err != nil {
+			return err
+		}
+	}
+
+	if err := migrator.Force(BaselineVersion); err != nil {
+		return fmt.Errorf("failed to adopt schema at version %d: %w", BaselineVersion, err)
+	}
+	return nil
+}
+
+// recordMigratedVersion records the version a migration was moved to, even
+// when lower than SchemaVersion, so builds expecting more refuse to start
+func (db *DB) recordMigratedVersion(ctx context.Context, migrator *migrate.Migrate) error {
+	version, _, err := migrator.Version()
+	if err != nil {
+		if errors.Is(err, migrate.ErrNilVersion) {
+			// Reverting the baseline dropped the schema_version table too
+			return nil
+		}
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+	if !db.Migrator().HasTable(&schemaVersionRecord{}) {
+		return nil
+	}
+	return db.saveSchemaVersion(ctx, int(version))
+}
+
+// withLockTimeout sets lock_timeout on the migration connections, so a
+// long-running transaction can't make a migration stall all traffic queued
+// behind it
+func withLockTimeout(dsn string, timeout time.Duration) string {
+	value := fmt.Sprintf("%d", timeout.Milliseconds())
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		parsed, err := url.Parse(dsn)
+		if err != nil {
+			return dsn
+		}
+		query := parsed.Query()
+		query.Set("lock_timeout", value)
+		parsed.RawQuery = query.Encode()
+		return parsed.String()
+	}
+	return dsn + " lock_timeout=" + value
+}
@@ -0,0 +1,113 @@
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+)
+
+// Connector opens watched folders on the local filesystem and over SFTP
+type Connector struct {
+	root              string
+	signer            ssh.Signer
+	dialTimeout       time.Duration
+	allowPrivateHosts bool
+}
+
+// Config configures the connector
+type Config struct {
+	WatchRoot  string // Local folders live under it, in a directory per tenant ID; empty disables them
+	SSHKeyFile string // Private key used to sign in to SFTP servers; empty disables SFTP
+	// SFTP servers on loopback and private networks are refused unless set
+	AllowPrivateHosts bool
+	DialTimeout       time.Duration // Defaults to 30 seconds
+}
+
+func NewConnector(config Config) (*Connector, error) {
+	if config.WatchRoot == "" && config.SSHKeyFile == "" {
+		return nil, fmt.Errorf("a watch root or SSH key file is required")
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 30 * time.Second
+	}
+
+	connector := &Connector{
+		dialTimeout:       config.DialTimeout,
+		allowPrivateHosts: config.AllowPrivateHosts,
+	}
+
+	if config.WatchRoot != "" {
+		// Resolved up front so symlinks inside it can be checked against it
+		root, err := filepath.EvalSymlinks(config.WatchRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve watch root: %w", err)
+		}
+		if root, err = filepath.Abs(root); err != nil {
+			return nil, fmt.Errorf("failed to resolve watch root: %w", err)
+		}
+		connector.root = root
+	}
+
+	if config.SSHKeyFile != "" {
+		key, err := os.ReadFile(config.SSHKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH key: %w", err)
+		}
+		if connector.signer, err = ssh.ParsePrivateKey(key); err != nil {
+			return nil, fmt.Errorf("failed to parse SSH key: %w", err)
+		}
+	}
+
+	return connector, nil
+}
+
+func (c *Connector) Supports(folderType string) bool {
+	switch folderType {
+	case services.WatchedFolderLocal:
+		return c.root != ""
+	case services.WatchedFolderSFTP:
+		return c.signer != nil
+	}
+	return false
+}
+
+func (c *Connector) PublicKey() string {
+	if c.signer == nil {
+		return ""
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(c.signer.PublicKey())))
+}
+
+func (c *Connector) Open(ctx context.Context, tenantID uuid.UUID, folder services.WatchedFolder) (services.IngestionDirectory, error) {
+	if !c.Supports(folder.Type) {
+		return nil, services.ErrFolderIngestionDisabled
+	}
+	if folder.Type == services.WatchedFolderSFTP {
+		return c.openSFTP(ctx, folder)
+	}
+	return c.openLocal(tenantID, folder)
+}
+
+// rejectPrivateAddress refuses connections to loopback, private, link-local
+// and unspecified addresses
+func rejectPrivateAddress(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid SFTP address %q", address)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("SFTP server %s is on a private network", host)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package ingestion
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalDirectory(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	tenantID, otherTenantID := uuid.New(), uuid.New()
+	tenantRoot := filepath.Join(root, tenantID.String())
+	require.NoError(t, os.MkdirAll(filepath.Join(tenantRoot, "scans", "sub"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(tenantRoot, "scans", "invoice.pdf"), []byte("%PDF-1.4"), 0o640))
+	require.NoError(t, os.Symlink(outside, filepath.Join(tenantRoot, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(root, otherTenantID.String()), filepath.Join(tenantRoot, "neighbour")))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, otherTenantID.String(), "scans"), 0o750))
+
+	connector, err := NewConnector(Config{WatchRoot: root})
+	require.NoError(t, err)
+	assert.True(t, connector.Supports(services.WatchedFolderLocal))
+	assert.False(t, connector.Supports(services.WatchedFolderSFTP))
+
+	directory, err := connector.Open(context.Background(), tenantID, services.WatchedFolder{Type: services.WatchedFolderLocal, Path: "../scans"})
+	require.NoError(t, err)
+	files, err := directory.List()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "invoice.pdf", files[0].Name)
+	assert.Equal(t, int64(8), files[0].Size)
+
+	require.NoError(t, directory.Move("invoice.pdf", "../../scans/processed", "done.pdf"))
+	assert.FileExists(t, filepath.Join(tenantRoot, "scans", "processed", "done.pdf"))
+
+	_, err = directory.Open("../escape")
+	assert.Error(t, err)
+	_, err = connector.Open(context.Background(), tenantID, services.WatchedFolder{Type: services.WatchedFolderLocal, Path: "escape"})
+	assert.ErrorContains(t, err, "outside the watch root")
+	_, err = connector.Open(context.Background(), tenantID, services.WatchedFolder{Type: services.WatchedFolderLocal, Path: "neighbour/scans"})
+	assert.ErrorContains(t, err, "outside the watch root")
+
+	// The root of a tenant's folders is its own directory, created on first use
+	directory, err = connector.Open(context.Background(), uuid.New(), services.WatchedFolder{Type: services.WatchedFolderLocal, Path: "."})
+	require.NoError(t, err)
+	files, err = directory.List()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+	_, err = connector.Open(context.Background(), uuid.Nil, services.WatchedFolder{Type: services.WatchedFolderLocal, Path: "."})
+	assert.Error(t, err)
+}
+
+func TestSFTPClient(t *testing.T) {
+	server := &fakeSFTPServer{files: map[string]string{
+		"/in/a.pdf":   strings.Repeat("a", sftpReadSize+10),
+		"/in/b.txt":   "hello",
+		"/in/nested/": "",
+	}}
+	client := server.start(t)
+
+	files, err := client.list("/in")
+	require.NoError(t, err)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	require.Len(t, files, 2)
+	assert.Equal(t, "a.pdf", files[0].Name)
+	assert.Equal(t, int64(sftpReadSize+10), files[0].Size)
+	assert.Equal(t, int64(1700000000), files[0].ModTime.Unix())
+
+	file, err := client.open("/in/a.pdf")
+	require.NoError(t, err)
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	assert.Equal(t, server.files["/in/a.pdf"], string(content))
+
+	client.mkdirAll("/in/processed/2024")
+	require.NoError(t, client.rename("/in/b.txt", "/in/processed/2024/b.txt"))
+	assert.Contains(t, server.files, "/in/processed/2024/b.txt")
+	assert.True(t, server.dirs["/in/processed"])
+
+	_, err = client.open("/in/missing.pdf")
+	var statusErr *sftpStatusError
+	assert.ErrorAs(t, err, &statusErr)
+}
+
+// fakeSFTPServer answers the requests the client sends from an in-memory
+// file tree; directories are names ending in a slash
+type fakeSFTPServer struct {
+	files   map[string]string
+	dirs    map[string]bool
+	handles map[string]string
+}
+
+func (s *fakeSFTPServer) start(t *testing.T) *sftpClient {
+	s.dirs = map[string]bool{}
+	s.handles = map[string]string{}
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	t.Cleanup(func() {
+		clientWriter.Close()
+		serverWriter.Close()
+	})
+
+	go s.serve(&sftpClient{r: serverReader, w: serverWriter})
+
+	client, err := newSFTPClient(clientReader, clientWriter)
+	require.NoError(t, err)
+	return client
+}
+
+func (s *fakeSFTPServer) serve(conn *sftpClient) {
+	for {
+		packetType, data, err := conn.read()
+		if err != nil {
+			return
+		}
+		if packetType == sftpInit {
+			var version sftpPacket
+			version.byte(sftpVersion)
+			version.uint32(3)
+			conn.write(version)
+			continue
+		}
+
+		id, data, _ := readUint32(data)
+		var reply sftpPacket
+		status := func(code uint32) {
+			reply.byte(sftpStatus)
+			reply.uint32(id)
+			reply.uint32(code)
+			reply.string("")
+			reply.string("")
+		}
+		handle := func(value string) {
+			reply.byte(sftpHandle)
+			reply.uint32(id)
+			reply.string(value)
+		}
+
+		first, rest, _ := readString(data)
+		switch packetType {
+		case sftpOpendir:
+			handle("dir:" + first)
+		case sftpOpen:
+			if _, ok := s.files[first]; ok {
+				handle("file:" + first)
+			} else {
+				status(2)
+			}
+		case sftpReaddir:
+			if s.handles[first] == "listed" {
+				status(sftpStatusEOF)
+				break
+			}
+			s.handles[first] = "listed"
+			dir := strings.TrimPrefix(first, "dir:") + "/"
+			var entries sftpPacket
+			count := uint32(0)
+			for name, content := range s.files {
+				child, ok := strings.CutPrefix(name, dir)
+				if !ok || strings.Contains(strings.TrimSuffix(child, "/"), "/") {
+					continue
+				}
+				mode := uint32(modeRegular | 0o644)
+				if strings.HasSuffix(child, "/") {
+					mode = 0o040755
+				}
+				entries.string(strings.TrimSuffix(child, "/"))
+				entries.string(child)
+				entries.uint32(sftpAttrSize | sftpAttrPermissions | sftpAttrACModTime)
+				entries.uint64(uint64(len(content)))
+				entries.uint32(mode)
+				entries.uint32(0)
+				entries.uint32(1700000000)
+				count++
+			}
+			reply.byte(sftpName)
+			reply.uint32(id)
+			reply.uint32(count)
+			reply = append(reply, entries...)
+		case sftpRead:
+			offset, rest, _ := readUint64(rest)
+			length, _, _ := readUint32(rest)
+			content := s.files[strings.TrimPrefix(first, "file:")]
+			if offset >= uint64(len(content)) {
+				status(sftpStatusEOF)
+				break
+			}
+			end := min(offset+uint64(length), uint64(len(content)))
+			reply.byte(sftpData)
+			reply.uint32(id)
+			reply.string(content[offset:end])
+		case sftpMkdir:
+			s.dirs[first] = true
+			status(sftpStatusOK)
+		case sftpRename:
+			to, _, _ := readString(rest)
+			if content, ok := s.files[first]; ok && s.dirs[path.Dir(to)] {
+				delete(s.files, first)
+				s.files[to] = content
+				status(sftpStatusOK)
+			} else {
+				status(2)
+			}
+		case sftpClose:
+			delete(s.handles, first)
+			status(sftpStatusOK)
+		default:
+			status(8) // Unsupported
+		}
+		conn.write(reply)
+	}
+}
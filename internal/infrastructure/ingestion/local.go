@@ -0,0 +1,128 @@
+package ingestion
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/google/uuid"
+)
+
+// localDirectory is a watched folder in a tenant's directory under the
+// watch root. root is the tenant's directory; folders, archive and failed
+// paths never leave it.
+type localDirectory struct {
+	root string
+	dir  string
+}
+
+func (c *Connector) openLocal(tenantID uuid.UUID, folder services.WatchedFolder) (*localDirectory, error) {
+	if tenantID == uuid.Nil {
+		return nil, fmt.Errorf("watched folder has no tenant")
+	}
+	if err := os.MkdirAll(filepath.Join(c.root, tenantID.String()), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create tenant watch directory: %w", err)
+	}
+	root, err := resolveUnder(c.root, tenantID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := resolveUnder(root, folder.Path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open watched folder: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("watched folder %s is not a directory", folder.Path)
+	}
+	return &localDirectory{root: root, dir: dir}, nil
+}
+
+// resolveUnder maps a folder path onto the watch root, refusing symlinks
+// that lead out of it
+func resolveUnder(root, relative string) (string, error) {
+	joined := filepath.Join(root, filepath.FromSlash(path.Clean("/"+relative)))
+	resolved, err := filepath.EvalSymlinks(joined)
+	if err != nil {
+		return "", fmt.Errorf("failed to open watched folder: %w", err)
+	}
+	if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("watched folder %s is outside the watch root", relative)
+	}
+	return resolved, nil
+}
+
+func (d *localDirectory) List() ([]services.IngestionFile, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watched folder: %w", err)
+	}
+
+	files := make([]services.IngestionFile, 0, len(entries))
+	for _, entry := range entries {
+		// Symlinks and subdirectories, including the archive, are left alone
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed since it was listed
+		}
+		files = append(files, services.IngestionFile{
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+func (d *localDirectory) Open(name string) (io.ReadCloser, error) {
+	if err := checkFileName(name); err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(d.dir, name))
+}
+
+func (d *localDirectory) Move(name, dir, newName string) error {
+	if err := checkFileName(name); err != nil {
+		return err
+	}
+	if err := checkFileName(newName); err != nil {
+		return err
+	}
+
+	target := filepath.Join(d.root, filepath.FromSlash(path.Clean("/"+dir)))
+	if err := os.MkdirAll(target, 0o750); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	resolved, err := resolveUnder(d.root, dir)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(filepath.Join(d.dir, name), filepath.Join(resolved, newName)); err != nil {
+		return fmt.Errorf("failed to move %s: %w", name, err)
+	}
+	return nil
+}
+
+func (d *localDirectory) Close() error {
+	return nil
+}
+
+// checkFileName refuses names that aren't a single path element
+func checkFileName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,555 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTP version 3 packet types (draft-ietf-secsh-filexfer-02), the version
+// every server speaks
+const (
+	sftpInit    = 1
+	sftpVersion = 2
+	sftpOpen    = 3
+	sftpClose   = 4
+	sftpRead    = 5
+	sftpOpendir = 11
+	sftpReaddir = 12
+	sftpMkdir   = 14
+	sftpRename  = 18
+	sftpStatus  = 101
+	sftpHandle  = 102
+	sftpData    = 103
+	sftpName    = 104
+)
+
+// SFTP status codes
+const (
+	sftpStatusOK  = 0
+	sftpStatusEOF = 1
+)
+
+// SFTP attribute flags
+const (
+	sftpAttrSize        = 0x00000001
+	sftpAttrUIDGID      = 0x00000002
+	sftpAttrPermissions = 0x00000004
+	sftpAttrACModTime   = 0x00000008
+	sftpAttrExtended    = 0x80000000
+)
+
+const (
+	sftpOpenRead = 0x00000001
+
+	// File type bits of a file's permissions
+	modeTypeMask = 0o170000
+	modeRegular  = 0o100000
+
+	// sftpReadSize is how much of a file each READ asks for
+	sftpReadSize = 32 * 1024
+	// sftpMaxPacket bounds the packets accepted from the server
+	sftpMaxPacket = 1 << 20
+)
+
+// errSFTPMalformed is returned for packets that can't be parsed
+var errSFTPMalformed = errors.New("malformed SFTP packet")
+
+// sftpStatusError is a failure the SFTP server reported
+type sftpStatusError struct {
+	Code    uint32
+	Message string
+}
+
+func (e *sftpStatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("sftp: status %d", e.Code)
+	}
+	return fmt.Sprintf("sftp: %s (status %d)", e.Message, e.Code)
+}
+
+// sftpDirectory is a watched folder on an SFTP server
+type sftpDirectory struct {
+	client  *sftpClient
+	conn    *ssh.Client
+	session *ssh.Session
+	dir     string
+}
+
+func (c *Connector) openSFTP(ctx context.Context, folder services.WatchedFolder) (*sftpDirectory, error) {
+	address := net.JoinHostPort(folder.Host, strconv.Itoa(folder.Port))
+	dialer := net.Dialer{Timeout: c.dialTimeout}
+	if !c.allowPrivateHosts {
+		dialer.Control = rejectPrivateAddress
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	// The deadline covers the whole poll, so a stalled server can't hold it
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, &ssh.ClientConfig{
+		User: folder.Username,
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(c.signer)},
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			if fingerprint := ssh.FingerprintSHA256(key); fingerprint != folder.HostKey {
+				return fmt.Errorf("host key %s does not match the configured fingerprint", fingerprint)
+			}
+			return nil
+		},
+		Timeout: c.dialTimeout,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to sign in to %s: %w", address, err)
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to open SSH session: %w", err)
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("server does not offer SFTP: %w", err)
+	}
+
+	sftp, err := newSFTPClient(stdout, stdin)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &sftpDirectory{
+		client:  sftp,
+		conn:    client,
+		session: session,
+		dir:     folder.Path,
+	}, nil
+}
+
+func (d *sftpDirectory) List() ([]services.IngestionFile, error) {
+	return d.client.list(d.dir)
+}
+
+func (d *sftpDirectory) Open(name string) (io.ReadCloser, error) {
+	if err := checkFileName(name); err != nil {
+		return nil, err
+	}
+	return d.client.open(path.Join(d.dir, name))
+}
+
+func (d *sftpDirectory) Move(name, dir, newName string) error {
+	if err := checkFileName(name); err != nil {
+		return err
+	}
+	if err := checkFileName(newName); err != nil {
+		return err
+	}
+	d.client.mkdirAll(dir)
+	return d.client.rename(path.Join(d.dir, name), path.Join(dir, newName))
+}
+
+func (d *sftpDirectory) Close() error {
+	d.session.Close()
+	return d.conn.Close()
+}
+
+// sftpClient speaks SFTP version 3 over a subsystem's stdin and stdout.
+// Requests are sent one at a time, so responses arrive in order.
+type sftpClient struct {
+	r      io.Reader
+	w      io.Writer
+	nextID uint32
+}
+
+func newSFTPClient(r io.Reader, w io.Writer) (*sftpClient, error) {
+	c := &sftpClient{r: r, w: w}
+
+	// INIT carries no request ID
+	var init sftpPacket
+	init.byte(sftpInit)
+	init.uint32(3)
+	if err := c.write(init); err != nil {
+		return nil, fmt.Errorf("failed to start SFTP: %w", err)
+	}
+	packetType, _, err := c.read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start SFTP: %w", err)
+	}
+	if packetType != sftpVersion {
+		return nil, fmt.Errorf("failed to start SFTP: unexpected packet %d", packetType)
+	}
+	return c, nil
+}
+
+func (c *sftpClient) list(dir string) ([]services.IngestionFile, error) {
+	handle, err := c.handle(sftpOpendir, func(p *sftpPacket) { p.string(dir) })
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", dir, err)
+	}
+	defer c.close(handle)
+
+	var files []services.IngestionFile
+	for {
+		packetType, data, err := c.request(sftpReaddir, func(p *sftpPacket) { p.string(handle) })
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+		}
+		if packetType == sftpStatus {
+			if err := statusError(data); err != io.EOF {
+				return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+			}
+			return files, nil
+		}
+		if packetType != sftpName {
+			return nil, fmt.Errorf("failed to list %s: unexpected packet %d", dir, packetType)
+		}
+
+		entries, err := parseNames(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			// Without permissions the type is unknown, so it isn't taken
+			if entry.flags&sftpAttrPermissions == 0 || entry.permissions&modeTypeMask != modeRegular {
+				continue
+			}
+			files = append(files, services.IngestionFile{
+				Name:    entry.name,
+				Size:    int64(entry.size),
+				ModTime: time.Unix(int64(entry.mtime), 0),
+			})
+		}
+	}
+}
+
+func (c *sftpClient) open(filename string) (*sftpFile, error) {
+	handle, err := c.handle(sftpOpen, func(p *sftpPacket) {
+		p.string(filename)
+		p.uint32(sftpOpenRead)
+		p.uint32(0) // No attributes
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filename, err)
+	}
+	return &sftpFile{client: c, handle: handle}, nil
+}
+
+func (c *sftpClient) rename(from, to string) error {
+	if err := c.status(sftpRename, func(p *sftpPacket) {
+		p.string(from)
+		p.string(to)
+	}); err != nil {
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	return nil
+}
+
+// mkdirAll creates dir and its parents. Servers don't say why MKDIR failed,
+// so failures are left for the move into the directory to report.
+func (c *sftpClient) mkdirAll(dir string) {
+	current := ""
+	if strings.HasPrefix(dir, "/") {
+		current = "/"
+	}
+	for _, part := range strings.Split(dir, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		current = path.Join(current, part)
+		c.status(sftpMkdir, func(p *sftpPacket) {
+			p.string(current)
+			p.uint32(0) // No attributes
+		})
+	}
+}
+
+func (c *sftpClient) close(handle string) error {
+	return c.status(sftpClose, func(p *sftpPacket) { p.string(handle) })
+}
+
+// handle sends a request answered with a handle
+func (c *sftpClient) handle(packetType byte, build func(*sftpPacket)) (string, error) {
+	responseType, data, err := c.request(packetType, build)
+	if err != nil {
+		return "", err
+	}
+	switch responseType {
+	case sftpHandle:
+		handle, _, ok := readString(data)
+		if !ok {
+			return "", errSFTPMalformed
+		}
+		return handle, nil
+	case sftpStatus:
+		if err := statusError(data); err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("unexpected packet %d", responseType)
+}
+
+// status sends a request answered with a status
+func (c *sftpClient) status(packetType byte, build func(*sftpPacket)) error {
+	responseType, data, err := c.request(packetType, build)
+	if err != nil {
+		return err
+	}
+	if responseType != sftpStatus {
+		return fmt.Errorf("unexpected packet %d", responseType)
+	}
+	return statusError(data)
+}
+
+// request sends a request and returns the response's type and the data
+// after its request ID
+func (c *sftpClient) request(packetType byte, build func(*sftpPacket)) (byte, []byte, error) {
+	c.nextID++
+	id := c.nextID
+
+	var p sftpPacket
+	p.byte(packetType)
+	p.uint32(id)
+	build(&p)
+	if err := c.write(p); err != nil {
+		return 0, nil, err
+	}
+
+	responseType, data, err := c.read()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) < 4 || binary.BigEndian.Uint32(data) != id {
+		return 0, nil, errSFTPMalformed
+	}
+	return responseType, data[4:], nil
+}
+
+func (c *sftpClient) write(p sftpPacket) error {
+	frame := make([]byte, 4, 4+len(p))
+	binary.BigEndian.PutUint32(frame, uint32(len(p)))
+	_, err := c.w.Write(append(frame, p...))
+	return err
+}
+
+func (c *sftpClient) read() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > sftpMaxPacket {
+		return 0, nil, errSFTPMalformed
+	}
+	data := make([]byte, length-1)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return 0, nil, err
+	}
+	return header[4], data, nil
+}
+
+// sftpFile reads a remote file in order
+type sftpFile struct {
+	client *sftpClient
+	handle string
+	offset uint64
+	eof    bool
+}
+
+func (f *sftpFile) Read(buf []byte) (int, error) {
+	if f.eof {
+		return 0, io.EOF
+	}
+	if len(buf) > sftpReadSize {
+		buf = buf[:sftpReadSize]
+	}
+
+	responseType, data, err := f.client.request(sftpRead, func(p *sftpPacket) {
+		p.string(f.handle)
+		p.uint64(f.offset)
+		p.uint32(uint32(len(buf)))
+	})
+	if err != nil {
+		return 0, err
+	}
+	switch responseType {
+	case sftpData:
+		chunk, _, ok := readString(data)
+		if !ok || len(chunk) > len(buf) {
+			return 0, errSFTPMalformed
+		}
+		n := copy(buf, chunk)
+		f.offset += uint64(n)
+		return n, nil
+	case sftpStatus:
+		err := statusError(data)
+		if err == nil {
+			return 0, errSFTPMalformed
+		}
+		if err == io.EOF {
+			f.eof = true
+		}
+		return 0, err
+	}
+	return 0, fmt.Errorf("unexpected packet %d", responseType)
+}
+
+func (f *sftpFile) Close() error {
+	return f.client.close(f.handle)
+}
+
+// sftpPacket builds a packet's body
+type sftpPacket []byte
+
+func (p *sftpPacket) byte(v byte) {
+	*p = append(*p, v)
+}
+
+func (p *sftpPacket) uint32(v uint32) {
+	*p = binary.BigEndian.AppendUint32(*p, v)
+}
+
+func (p *sftpPacket) uint64(v uint64) {
+	*p = binary.BigEndian.AppendUint64(*p, v)
+}
+
+func (p *sftpPacket) string(v string) {
+	p.uint32(uint32(len(v)))
+	*p = append(*p, v...)
+}
+
+// sftpEntry is a NAME packet entry with the attributes used here
+type sftpEntry struct {
+	name        string
+	flags       uint32
+	size        uint64
+	permissions uint32
+	mtime       uint32
+}
+
+func parseNames(data []byte) ([]sftpEntry, error) {
+	count, data, ok := readUint32(data)
+	if !ok {
+		return nil, errSFTPMalformed
+	}
+
+	entries := make([]sftpEntry, 0, min(count, 1024))
+	for i := uint32(0); i < count; i++ {
+		var entry sftpEntry
+		if entry.name, data, ok = readString(data); !ok {
+			return nil, errSFTPMalformed
+		}
+		if _, data, ok = readString(data); !ok { // The ls -l style long name
+			return nil, errSFTPMalformed
+		}
+		if entry.flags, data, ok = readUint32(data); !ok {
+			return nil, errSFTPMalformed
+		}
+		if entry.flags&sftpAttrSize != 0 {
+			if entry.size, data, ok = readUint64(data); !ok {
+				return nil, errSFTPMalformed
+			}
+		}
+		if entry.flags&sftpAttrUIDGID != 0 {
+			if data, ok = skip(data, 8); !ok {
+				return nil, errSFTPMalformed
+			}
+		}
+		if entry.flags&sftpAttrPermissions != 0 {
+			if entry.permissions, data, ok = readUint32(data); !ok {
+				return nil, errSFTPMalformed
+			}
+		}
+		if entry.flags&sftpAttrACModTime != 0 {
+			if data, ok = skip(data, 4); !ok { // atime
+				return nil, errSFTPMalformed
+			}
+			if entry.mtime, data, ok = readUint32(data); !ok {
+				return nil, errSFTPMalformed
+			}
+		}
+		if entry.flags&sftpAttrExtended != 0 {
+			var extended uint32
+			if extended, data, ok = readUint32(data); !ok {
+				return nil, errSFTPMalformed
+			}
+			for j := uint32(0); j < extended*2; j++ {
+				if _, data, ok = readString(data); !ok {
+					return nil, errSFTPMalformed
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// statusError turns a STATUS packet into nil, io.EOF or an sftpStatusError
+func statusError(data []byte) error {
+	code, data, ok := readUint32(data)
+	if !ok {
+		return errSFTPMalformed
+	}
+	switch code {
+	case sftpStatusOK:
+		return nil
+	case sftpStatusEOF:
+		return io.EOF
+	}
+	message, _, _ := readString(data) // Optional in some servers
+	return &sftpStatusError{Code: code, Message: message}
+}
+
+func readUint32(data []byte) (uint32, []byte, bool) {
+	if len(data) < 4 {
+		return 0, nil, false
+	}
+	return binary.BigEndian.Uint32(data), data[4:], true
+}
+
+func readUint64(data []byte) (uint64, []byte, bool) {
+	if len(data) < 8 {
+		return 0, nil, false
+	}
+	return binary.BigEndian.Uint64(data), data[8:], true
+}
+
+func readString(data []byte) (string, []byte, bool) {
+	length, data, ok := readUint32(data)
+	if !ok || uint64(length) > uint64(len(data)) {
+		return "", nil, false
+	}
+	return string(data[:length]), data[length:], true
+}
+
+func skip(data []byte, n int) ([]byte, bool) {
+	if len(data) < n {
+		return nil, false
+	}
+	return data[n:], true
+}
@@ -0,0 +1,249 @@
+package integrations
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// BoxName is the Box provider's name
+const BoxName = "box"
+
+// boxRootFolder is Box's ID for the top of an account
+const boxRootFolder = "0"
+
+// boxWebhookMaxAge is how old a webhook delivery may be; older ones are
+// treated as replays
+const boxWebhookMaxAge = 10 * time.Minute
+
+// boxWebhookTriggers are the folder events that bring a sync forward
+var boxWebhookTriggers = []string{
+	"FILE.UPLOADED", "FILE.TRASHED", "FILE.DELETED", "FILE.RESTORED", "FILE.MOVED",
+	"FOLDER.CREATED", "FOLDER.TRASHED", "FOLDER.DELETED", "FOLDER.RESTORED", "FOLDER.MOVED",
+}
+
+// Box imports from Box. Its webhooks are created per folder, for scheduled
+// syncs, and signed with the app's two signature keys so either can be
+// rotated at a time.
+type Box struct {
+	oauth         oauthClient
+	apiURL        string
+	webURL        string
+	signatureKeys [2]string // Primary and secondary
+	client        *http.Client
+}
+
+// BoxConfig holds a Box app
+type BoxConfig struct {
+	ClientID     string
+	ClientSecret string
+	// The primary and secondary webhook signature keys from the app's
+	// console; without them webhooks are refused
+	WebhookPrimaryKey   string
+	WebhookSecondaryKey string
+}
+
+func NewBox(config BoxConfig) *Box {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	return &Box{
+		oauth: oauthClient{
+			clientID:     config.ClientID,
+			clientSecret: config.ClientSecret,
+			authURL:      "https://account.box.com/api/oauth2/authorize",
+			tokenURL:     "https://api.box.com/oauth2/token",
+			scopes:       []string{"root_readonly", "manage_webhook"},
+			httpClient:   client,
+		},
+		apiURL:        "https://api.box.com/2.0",
+		webURL:        "https://app.box.com",
+		signatureKeys: [2]string{config.WebhookPrimaryKey, config.WebhookSecondaryKey},
+		client:        client,
+	}
+}
+
+func (b *Box) Name() string {
+	return BoxName
+}
+
+func (b *Box) AuthURL(state, codeChallenge, redirectURL string) string {
+	return b.oauth.authCodeURL(state, codeChallenge, redirectURL, nil)
+}
+
+func (b *Box) Exchange(ctx context.Context, code, codeVerifier, redirectURL string) (*services.OAuthToken, error) {
+	return b.oauth.exchange(ctx, code, codeVerifier, redirectURL)
+}
+
+// Refresh returns a new refresh token each time; Box's can be used once
+func (b *Box) Refresh(ctx context.Context, refreshToken string) (*services.OAuthToken, error) {
+	return b.oauth.refresh(ctx, refreshToken)
+}
+
+func (b *Box) Account(ctx context.Context, accessToken string) (*services.RemoteAccount, error) {
+	var me struct {
+		ID    string `json:"id"`
+		Login string `json:"login"`
+	}
+	if err := getJSON(ctx, b.client, accessToken, b.apiURL+"/users/me?fields=id,login", &me); err != nil {
+		return nil, err
+	}
+	return &services.RemoteAccount{ID: me.ID, Email: me.Login}, nil
+}
+
+type boxItem struct {
+	Type              string     `json:"type"`
+	ID                string     `json:"id"`
+	Name              string     `json:"name"`
+	Size              int64      `json:"size"`
+	SHA1              string     `json:"sha1"`
+	ETag              string     `json:"etag"`
+	ContentCreatedAt  *time.Time `json:"content_created_at"`
+	ContentModifiedAt *time.Time `json:"content_modified_at"`
+	CreatedBy         struct {
+		Login string `json:"login"`
+	} `json:"created_by"`
+}
+
+func (b *Box) ListFolder(ctx context.Context, accessToken, folderID string) ([]services.RemoteItem, error) {
+	if folderID == services.RemoteRootFolder {
+		folderID = boxRootFolder
+	}
+
+	var items []services.RemoteItem
+	const limit = 1000
+	for offset := 0; ; offset += limit {
+		query := url.Values{
+			"fields": {"type,id,name,size,sha1,etag,content_created_at,content_modified_at,created_by"},
+			"limit":  {strconv.Itoa(limit)},
+			"offset": {strconv.Itoa(offset)},
+		}
+		var page struct {
+			TotalCount int       `json:"total_count"`
+			Entries    []boxItem `json:"entries"`
+		}
+		endpoint := b.apiURL + "/folders/" + url.PathEscape(folderID) + "/items?" + query.Encode()
+		if err := getJSON(ctx, b.client, accessToken, endpoint, &page); err != nil {
+			return nil, err
+		}
+		for _, entry := range page.Entries {
+			items = append(items, b.remoteItem(entry))
+		}
+		if len(page.Entries) == 0 || offset+len(page.Entries) >= page.TotalCount {
+			return items, nil
+		}
+	}
+}
+
+// Download follows Box's redirect to the file's download URL, which needs
+// no authorization
+func (b *Box) Download(ctx context.Context, accessToken string, item services.RemoteItem) (io.ReadCloser, error) {
+	resp, err := get(ctx, b.client, accessToken, b.apiURL+"/files/"+url.PathEscape(item.ID)+"/content")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Subscribe creates a webhook on the folder. Box allows none on the root
+// folder, so syncs of a whole account are polled only.
+func (b *Box) Subscribe(ctx context.Context, accessToken, folderID, address string) (string, error) {
+	if folderID == services.RemoteRootFolder || folderID == boxRootFolder {
+		return "", fmt.Errorf("box has no webhooks for the root folder")
+	}
+	request := map[string]interface{}{
+		"target":   map[string]string{"id": folderID, "type": "folder"},
+		"address":  address,
+		"triggers": boxWebhookTriggers,
+	}
+	var webhook struct {
+		ID string `json:"id"`
+	}
+	if err := postJSON(ctx, b.client, accessToken, b.apiURL+"/webhooks", request, &webhook); err != nil {
+		return "", err
+	}
+	return webhook.ID, nil
+}
+
+func (b *Box) Unsubscribe(ctx context.Context, accessToken, webhookID string) error {
+	resp, err := do(ctx, b.client, accessToken, http.MethodDelete, b.apiURL+"/webhooks/"+url.PathEscape(webhookID), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ParseWebhook verifies a delivery against either signature key: the
+// base64 HMAC-SHA256 of the body followed by the delivery timestamp
+func (b *Box) ParseWebhook(header http.Header, body []byte) (*services.IntegrationWebhook, error) {
+	timestamp := header.Get("BOX-DELIVERY-TIMESTAMP")
+	delivered, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil || time.Since(delivered) > boxWebhookMaxAge || time.Until(delivered) > boxWebhookMaxAge {
+		return nil, services.ErrInvalidWebhookSignature
+	}
+
+	signatures := [2]string{header.Get("BOX-SIGNATURE-PRIMARY"), header.Get("BOX-SIGNATURE-SECONDARY")}
+	verified := false
+	for i, key := range b.signatureKeys {
+		if key == "" {
+			continue
+		}
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(body)
+		mac.Write([]byte(timestamp))
+		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		// Each key signs its own header
+		if hmac.Equal([]byte(signatures[i]), []byte(expected)) {
+			verified = true
+		}
+	}
+	if !verified {
+		return nil, services.ErrInvalidWebhookSignature
+	}
+
+	var notification struct {
+		Webhook struct {
+			ID string `json:"id"`
+		} `json:"webhook"`
+	}
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("failed to decode notification: %w", err)
+	}
+	if notification.Webhook.ID == "" {
+		return &services.IntegrationWebhook{}, nil
+	}
+	return &services.IntegrationWebhook{WebhookIDs: []string{notification.Webhook.ID}}, nil
+}
+
+// remoteItem converts a folder entry. The SHA-1 changes with the content
+// only, unlike the etag, which renames also change.
+func (b *Box) remoteItem(i boxItem) services.RemoteItem {
+	item := services.RemoteItem{
+		ID:         i.ID,
+		Name:       i.Name,
+		IsFolder:   i.Type == "folder",
+		Version:    i.SHA1,
+		Size:       i.Size,
+		CreatedAt:  i.ContentCreatedAt,
+		ModifiedAt: i.ContentModifiedAt,
+		Owner:      i.CreatedBy.Login,
+		WebURL:     b.webURL + "/" + i.Type + "/" + i.ID,
+	}
+	if item.Version == "" {
+		item.Version = i.ETag
+	}
+	// Web links are bookmarks, with no file behind them
+	if i.Type == "web_link" {
+		item.Unsupported = true
+	}
+	return item
+}
@@ -0,0 +1,182 @@
+package integrations
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// DropboxName is the Dropbox provider's name
+const DropboxName = "dropbox"
+
+// Dropbox imports from Dropbox, with read-only access. Its webhook is set
+// once for the app, in the app console, and names the accounts with changes.
+type Dropbox struct {
+	oauth      oauthClient
+	apiURL     string
+	contentURL string
+	client     *http.Client
+}
+
+// DropboxConfig holds a Dropbox app; its secret also signs webhooks
+type DropboxConfig struct {
+	ClientID     string
+	ClientSecret string
+}
+
+func NewDropbox(config DropboxConfig) *Dropbox {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	return &Dropbox{
+		oauth: oauthClient{
+			clientID:     config.ClientID,
+			clientSecret: config.ClientSecret,
+			authURL:      "https://www.dropbox.com/oauth2/authorize",
+			tokenURL:     "https://api.dropboxapi.com/oauth2/token",
+			scopes:       []string{"account_info.read", "files.metadata.read", "files.content.read"},
+			httpClient:   client,
+		},
+		apiURL:     "https://api.dropboxapi.com/2",
+		contentURL: "https://content.dropboxapi.com/2",
+		client:     client,
+	}
+}
+
+func (d *Dropbox) Name() string {
+	return DropboxName
+}
+
+func (d *Dropbox) AuthURL(state, codeChallenge, redirectURL string) string {
+	// Offline access, so a refresh token is issued
+	return d.oauth.authCodeURL(state, codeChallenge, redirectURL, url.Values{
+		"token_access_type": {"offline"},
+	})
+}
+
+func (d *Dropbox) Exchange(ctx context.Context, code, codeVerifier, redirectURL string) (*services.OAuthToken, error) {
+	return d.oauth.exchange(ctx, code, codeVerifier, redirectURL)
+}
+
+func (d *Dropbox) Refresh(ctx context.Context, refreshToken string) (*services.OAuthToken, error) {
+	return d.oauth.refresh(ctx, refreshToken)
+}
+
+func (d *Dropbox) Account(ctx context.Context, accessToken string) (*services.RemoteAccount, error) {
+	var account struct {
+		AccountID string `json:"account_id"`
+		Email     string `json:"email"`
+	}
+	if err := postJSON(ctx, d.client, accessToken, d.apiURL+"/users/get_current_account", nil, &account); err != nil {
+		return nil, err
+	}
+	return &services.RemoteAccount{ID: account.AccountID, Email: account.Email}, nil
+}
+
+type dropboxEntry struct {
+	Tag            string     `json:".tag"`
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Size           int64      `json:"size"`
+	Rev            string     `json:"rev"`
+	ContentHash    string     `json:"content_hash"`
+	ClientModified *time.Time `json:"client_modified"` // When the file was last changed, as the uploader's device had it
+	IsDownloadable *bool      `json:"is_downloadable"`
+}
+
+func (d *Dropbox) ListFolder(ctx context.Context, accessToken, folderID string) ([]services.RemoteItem, error) {
+	// The root is the empty path; other folders are listed by ID
+	if folderID == services.RemoteRootFolder {
+		folderID = ""
+	}
+
+	var items []services.RemoteItem
+	endpoint := d.apiURL + "/files/list_folder"
+	var request interface{} = map[string]interface{}{"path": folderID, "limit": 2000}
+	for {
+		var page struct {
+			Entries []dropboxEntry `json:"entries"`
+			Cursor  string         `json:"cursor"`
+			HasMore bool           `json:"has_more"`
+		}
+		if err := postJSON(ctx, d.client, accessToken, endpoint, request, &page); err != nil {
+			return nil, err
+		}
+		for _, entry := range page.Entries {
+			if entry.Tag == "file" || entry.Tag == "folder" {
+				items = append(items, entry.remoteItem())
+			}
+		}
+		if !page.HasMore || page.Cursor == "" {
+			return items, nil
+		}
+		endpoint = d.apiURL + "/files/list_folder/continue"
+		request = map[string]string{"cursor": page.Cursor}
+	}
+}
+
+func (d *Dropbox) Download(ctx context.Context, accessToken string, item services.RemoteItem) (io.ReadCloser, error) {
+	arg, err := json.Marshal(map[string]string{"path": item.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	header := http.Header{}
+	header.Set("Dropbox-API-Arg", string(arg))
+	resp, err := do(ctx, d.client, accessToken, http.MethodPost, d.contentURL+"/files/download", header, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ParseWebhook verifies a notification's signature, the hex HMAC-SHA256 of
+// the body keyed with the app secret
+func (d *Dropbox) ParseWebhook(header http.Header, body []byte) (*services.IntegrationWebhook, error) {
+	signature, err := hex.DecodeString(header.Get("X-Dropbox-Signature"))
+	if err != nil || d.oauth.clientSecret == "" {
+		return nil, services.ErrInvalidWebhookSignature
+	}
+	mac := hmac.New(sha256.New, []byte(d.oauth.clientSecret))
+	mac.Write(body)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, services.ErrInvalidWebhookSignature
+	}
+
+	var notification struct {
+		ListFolder struct {
+			Accounts []string `json:"accounts"`
+		} `json:"list_folder"`
+	}
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("failed to decode notification: %w", err)
+	}
+	return &services.IntegrationWebhook{AccountIDs: notification.ListFolder.Accounts}, nil
+}
+
+// remoteItem converts a folder entry. The content hash changes with the
+// content only, unlike the revision, which moves also change.
+func (e dropboxEntry) remoteItem() services.RemoteItem {
+	item := services.RemoteItem{
+		ID:         e.ID,
+		Name:       e.Name,
+		IsFolder:   e.Tag == "folder",
+		Version:    e.ContentHash,
+		Size:       e.Size,
+		ModifiedAt: e.ClientModified,
+	}
+	if item.Version == "" {
+		item.Version = e.Rev
+	}
+	// Paper docs and other cloud files can't be downloaded
+	if e.IsDownloadable != nil && !*e.IsDownloadable {
+		item.Unsupported = true
+	}
+	return item
+}
@@ -0,0 +1,193 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// GoogleDriveName is the Google Drive provider's name
+const GoogleDriveName = "google_drive"
+
+const googleFolderType = "application/vnd.google-apps.folder"
+
+// googleExports maps the Google Docs types to what they're exported as;
+// other Google types, like forms and sites, have no file to import
+var googleExports = map[string]struct{ contentType, extension string }{
+	"application/vnd.google-apps.document":     {"application/pdf", ".pdf"},
+	"application/vnd.google-apps.presentation": {"application/pdf", ".pdf"},
+	"application/vnd.google-apps.drawing":      {"application/pdf", ".pdf"},
+	"application/vnd.google-apps.spreadsheet":  {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ".xlsx"},
+}
+
+// GoogleDrive imports from Google Drive, including shared drives, with
+// read-only access
+type GoogleDrive struct {
+	oauth  oauthClient
+	apiURL string
+	client *http.Client
+}
+
+// GoogleDriveConfig holds a Google OAuth client
+type GoogleDriveConfig struct {
+	ClientID     string
+	ClientSecret string
+}
+
+func NewGoogleDrive(config GoogleDriveConfig) *GoogleDrive {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	return &GoogleDrive{
+		oauth: oauthClient{
+			clientID:     config.ClientID,
+			clientSecret: config.ClientSecret,
+			authURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+			tokenURL:     "https://oauth2.googleapis.com/token",
+			scopes:       []string{"https://www.googleapis.com/auth/drive.readonly"},
+			httpClient:   client,
+		},
+		apiURL: "https://www.googleapis.com/drive/v3",
+		client: client,
+	}
+}
+
+func (d *GoogleDrive) Name() string {
+	return GoogleDriveName
+}
+
+func (d *GoogleDrive) AuthURL(state, codeChallenge, redirectURL string) string {
+	// Offline access with consent, so a refresh token is always issued
+	return d.oauth.authCodeURL(state, codeChallenge, redirectURL, url.Values{
+		"access_type": {"offline"},
+		"prompt":      {"consent"},
+	})
+}
+
+func (d *GoogleDrive) Exchange(ctx context.Context, code, codeVerifier, redirectURL string) (*services.OAuthToken, error) {
+	return d.oauth.exchange(ctx, code, codeVerifier, redirectURL)
+}
+
+func (d *GoogleDrive) Refresh(ctx context.Context, refreshToken string) (*services.OAuthToken, error) {
+	return d.oauth.refresh(ctx, refreshToken)
+}
+
+func (d *GoogleDrive) Account(ctx context.Context, accessToken string) (*services.RemoteAccount, error) {
+	var about struct {
+		User struct {
+			PermissionID string `json:"permissionId"`
+			EmailAddress string `json:"emailAddress"`
+		} `json:"user"`
+	}
+	if err := getJSON(ctx, d.client, accessToken, d.apiURL+"/about?fields=user(permissionId,emailAddress)", &about); err != nil {
+		return nil, err
+	}
+	return &services.RemoteAccount{ID: about.User.PermissionID, Email: about.User.EmailAddress}, nil
+}
+
+type driveFile struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	MimeType     string `json:"mimeType"`
+	Size         string `json:"size"`
+	Version      string `json:"version"`
+	MD5Checksum  string `json:"md5Checksum"`
+	CreatedTime  string `json:"createdTime"`
+	ModifiedTime string `json:"modifiedTime"`
+	WebViewLink  string `json:"webViewLink"`
+	Owners       []struct {
+		EmailAddress string `json:"emailAddress"`
+	} `json:"owners"`
+}
+
+func (d *GoogleDrive) ListFolder(ctx context.Context, accessToken, folderID string) ([]services.RemoteItem, error) {
+	var items []services.RemoteItem
+	pageToken := ""
+	for {
+		query := url.Values{
+			"q":                         {fmt.Sprintf("'%s' in parents and trashed = false", strings.ReplaceAll(folderID, "'", `\'`))},
+			"fields":                    {"nextPageToken,files(id,name,mimeType,size,version,md5Checksum,createdTime,modifiedTime,webViewLink,owners(emailAddress))"},
+			"pageSize":                  {"1000"},
+			"supportsAllDrives":         {"true"},
+			"includeItemsFromAllDrives": {"true"},
+		}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		var page struct {
+			NextPageToken string      `json:"nextPageToken"`
+			Files         []driveFile `json:"files"`
+		}
+		if err := getJSON(ctx, d.client, accessToken, d.apiURL+"/files?"+query.Encode(), &page); err != nil {
+			return nil, err
+		}
+		for _, file := range page.Files {
+			items = append(items, file.remoteItem())
+		}
+
+		if page.NextPageToken == "" {
+			return items, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func (d *GoogleDrive) Download(ctx context.Context, accessToken string, item services.RemoteItem) (io.ReadCloser, error) {
+	endpoint := d.apiURL + "/files/" + url.PathEscape(item.ID) + "?alt=media&supportsAllDrives=true"
+	if export, ok := googleExports[item.SourceType]; ok {
+		endpoint = d.apiURL + "/files/" + url.PathEscape(item.ID) + "/export?mimeType=" + url.QueryEscape(export.contentType)
+	}
+
+	resp, err := get(ctx, d.client, accessToken, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// remoteItem converts a Drive file. Google Docs are named and typed as
+// their export. Their version changes with every edit; other files go by
+// checksum, as their version changes with renames too.
+func (f driveFile) remoteItem() services.RemoteItem {
+	item := services.RemoteItem{
+		ID:          f.ID,
+		Name:        f.Name,
+		IsFolder:    f.MimeType == googleFolderType,
+		Version:     f.Version,
+		ContentType: f.MimeType,
+		SourceType:  f.MimeType,
+		WebURL:      f.WebViewLink,
+	}
+	if f.MD5Checksum != "" {
+		item.Version = "md5:" + f.MD5Checksum
+	}
+	item.Size, _ = strconv.ParseInt(f.Size, 10, 64)
+	if created, err := time.Parse(time.RFC3339, f.CreatedTime); err == nil {
+		item.CreatedAt = &created
+	}
+	if modified, err := time.Parse(time.RFC3339, f.ModifiedTime); err == nil {
+		item.ModifiedAt = &modified
+	}
+	if len(f.Owners) > 0 {
+		item.Owner = f.Owners[0].EmailAddress
+	}
+
+	if !item.IsFolder && strings.HasPrefix(f.MimeType, "application/vnd.google-apps.") {
+		export, ok := googleExports[f.MimeType]
+		if !ok {
+			item.Unsupported = true
+			return item
+		}
+		item.ContentType = export.contentType
+		if !strings.HasSuffix(strings.ToLower(item.Name), export.extension) {
+			item.Name += export.extension
+		}
+	}
+	return item
+}
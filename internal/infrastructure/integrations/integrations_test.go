@@ -0,0 +1,363 @@
+package integrations
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoogleDrive_ListAndDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/files":
+			assert.Equal(t, "'folder1' in parents and trashed = false", r.URL.Query().Get("q"))
+			if r.URL.Query().Get("pageToken") == "" {
+				w.Write([]byte(`{"nextPageToken": "p2", "files": [
+					{"id": "a", "name": "Invoice.pdf", "mimeType": "application/pdf", "size": "120", "version": "7", "md5Checksum": "abc",
+					 "createdTime": "2024-01-02T03:04:05Z", "owners": [{"emailAddress": "jane@example.com"}]},
+					{"id": "b", "name": "Budget", "mimeType": "application/vnd.google-apps.spreadsheet", "version": "3"}]}`))
+				return
+			}
+			w.Write([]byte(`{"files": [
+				{"id": "c", "name": "Survey", "mimeType": "application/vnd.google-apps.form"},
+				{"id": "d", "name": "Contracts", "mimeType": "application/vnd.google-apps.folder"}]}`))
+		case "/files/b/export":
+			assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", r.URL.Query().Get("mimeType"))
+			w.Write([]byte("xlsx"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	drive := NewGoogleDrive(GoogleDriveConfig{ClientID: "id"})
+	drive.apiURL = server.URL
+
+	items, err := drive.ListFolder(context.Background(), "token", "folder1")
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	assert.Equal(t, "md5:abc", items[0].Version)
+	assert.Equal(t, int64(120), items[0].Size)
+	assert.Equal(t, "jane@example.com", items[0].Owner)
+	assert.Equal(t, 2024, items[0].CreatedAt.Year())
+	assert.Equal(t, "Budget.xlsx", items[1].Name)
+	assert.Equal(t, "3", items[1].Version)
+	assert.True(t, items[2].Unsupported)
+	assert.True(t, items[3].IsFolder)
+
+	reader, err := drive.Download(context.Background(), "token", items[1])
+	require.NoError(t, err)
+	content, _ := io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, "xlsx", string(content))
+
+	_, err = drive.Download(context.Background(), "token", items[0])
+	assert.ErrorContains(t, err, "provider returned 404")
+
+	authURL, err := url.Parse(drive.AuthURL("state1", "challenge", "https://app.example.com/callback"))
+	require.NoError(t, err)
+	assert.Equal(t, "offline", authURL.Query().Get("access_type"))
+	assert.Equal(t, "S256", authURL.Query().Get("code_challenge_method"))
+	assert.Equal(t, "state1", authURL.Query().Get("state"))
+}
+
+func TestOneDrive_ListFollowsNextLink(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/me/drive/items/root/children" && r.URL.Query().Get("page") == "":
+			w.Write([]byte(`{"@odata.nextLink": "` + server.URL + `/me/drive/items/root/children?page=2", "value": [
+				{"id": "1", "name": "Scans", "folder": {"childCount": 2}, "cTag": "c1"},
+				{"id": "2", "name": "a.pdf", "size": 10, "cTag": "c2", "eTag": "e2", "file": {"mimeType": "application/pdf"},
+				 "createdBy": {"user": {"displayName": "Jane"}}}]}`))
+		case r.URL.Path == "/me/drive/items/root/children":
+			w.Write([]byte(`{"@odata.nextLink": "https://elsewhere.example.com/next", "value": [
+				{"id": "3", "name": "Notebook", "package": {"type": "oneNote"}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	drive := NewOneDrive(OneDriveConfig{ClientID: "id"})
+	drive.apiURL = server.URL
+
+	items, err := drive.ListFolder(context.Background(), "token", services.RemoteRootFolder)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.True(t, items[0].IsFolder)
+	assert.Equal(t, "c2", items[1].Version)
+	assert.Equal(t, "application/pdf", items[1].ContentType)
+	assert.Equal(t, "Jane", items[1].Owner)
+	assert.True(t, items[2].Unsupported)
+}
+
+func TestOAuthClient_Refresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+		if r.PostForm.Get("refresh_token") == "revoked" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token": "access", "refresh_token": "rotated", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	client := oauthClient{clientID: "id", clientSecret: "secret", tokenURL: server.URL, httpClient: server.Client()}
+
+	token, err := client.refresh(context.Background(), "current")
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+	assert.Equal(t, "rotated", token.RefreshToken)
+
+	_, err = client.refresh(context.Background(), "revoked")
+	assert.ErrorIs(t, err, services.ErrIntegrationTokenRevoked)
+}
+
+func TestDropbox_ListAndWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/files/list_folder":
+			assert.JSONEq(t, `{"path": "", "limit": 2000}`, string(body))
+			w.Write([]byte(`{"has_more": true, "cursor": "c1", "entries": [
+				{".tag": "folder", "id": "id:f", "name": "Scans"},
+				{".tag": "file", "id": "id:a", "name": "a.pdf", "size": 10, "rev": "r1", "content_hash": "h1"}]}`))
+		case "/files/list_folder/continue":
+			assert.JSONEq(t, `{"cursor": "c1"}`, string(body))
+			w.Write([]byte(`{"has_more": false, "entries": [
+				{".tag": "file", "id": "id:b", "name": "Notes.paper", "rev": "r2", "is_downloadable": false},
+				{".tag": "deleted", "name": "old.pdf"}]}`))
+		case "/files/download":
+			assert.Equal(t, `{"path":"id:a"}`, r.Header.Get("Dropbox-API-Arg"))
+			w.Write([]byte("pdf"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dropbox := NewDropbox(DropboxConfig{ClientID: "id", ClientSecret: "secret"})
+	dropbox.apiURL = server.URL
+	dropbox.contentURL = server.URL
+
+	items, err := dropbox.ListFolder(context.Background(), "token", services.RemoteRootFolder)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.True(t, items[0].IsFolder)
+	assert.Equal(t, "h1", items[1].Version)
+	assert.Equal(t, "r2", items[2].Version)
+	assert.True(t, items[2].Unsupported)
+
+	reader, err := dropbox.Download(context.Background(), "token", items[1])
+	require.NoError(t, err)
+	content, _ := io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, "pdf", string(content))
+
+	body := []byte(`{"list_folder": {"accounts": ["dbid:1"]}, "delta": {"users": [1]}}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	header := http.Header{}
+	header.Set("X-Dropbox-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	notice, err := dropbox.ParseWebhook(header, body)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dbid:1"}, notice.AccountIDs)
+
+	_, err = dropbox.ParseWebhook(header, []byte(`{"list_folder": {"accounts": ["dbid:2"]}}`))
+	assert.ErrorIs(t, err, services.ErrInvalidWebhookSignature)
+}
+
+func TestBox_ListAndWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/folders/0/items" && r.URL.Query().Get("offset") == "0":
+			w.Write([]byte(`{"total_count": 3, "entries": [
+				{"type": "folder", "id": "1", "name": "Contracts", "etag": "0"},
+				{"type": "file", "id": "2", "name": "a.pdf", "size": 10, "sha1": "s1", "etag": "3", "created_by": {"login": "jane@example.com"}}]}`))
+		case r.URL.Path == "/folders/0/items":
+			w.Write([]byte(`{"total_count": 3, "entries": [{"type": "web_link", "id": "3", "name": "Wiki", "etag": "1"}]}`))
+		case r.URL.Path == "/webhooks" && r.Method == http.MethodPost:
+			var request struct {
+				Target  map[string]string `json:"target"`
+				Address string            `json:"address"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.Equal(t, "1", request.Target["id"])
+			assert.Equal(t, "https://app.example.com/hooks/box", request.Address)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "w1"}`))
+		case r.URL.Path == "/webhooks/w1" && r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	box := NewBox(BoxConfig{ClientID: "id", WebhookSecondaryKey: "secondary"})
+	box.apiURL = server.URL
+
+	items, err := box.ListFolder(context.Background(), "token", services.RemoteRootFolder)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.True(t, items[0].IsFolder)
+	assert.Equal(t, "s1", items[1].Version)
+	assert.Equal(t, "jane@example.com", items[1].Owner)
+	assert.Equal(t, "https://app.box.com/file/2", items[1].WebURL)
+	assert.True(t, items[2].Unsupported)
+
+	_, err = box.Subscribe(context.Background(), "token", services.RemoteRootFolder, "https://app.example.com/hooks/box")
+	assert.Error(t, err)
+	webhookID, err := box.Subscribe(context.Background(), "token", "1", "https://app.example.com/hooks/box")
+	require.NoError(t, err)
+	assert.Equal(t, "w1", webhookID)
+	require.NoError(t, box.Unsubscribe(context.Background(), "token", webhookID))
+
+	sign := func(key string, body []byte, timestamp string) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(body)
+		mac.Write([]byte(timestamp))
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	body := []byte(`{"type": "webhook_event", "trigger": "FILE.UPLOADED", "webhook": {"id": "w1"}}`)
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	header := http.Header{}
+	header.Set("BOX-DELIVERY-TIMESTAMP", timestamp)
+	header.Set("BOX-SIGNATURE-PRIMARY", sign("secondary", body, timestamp))
+	_, err = box.ParseWebhook(header, body)
+	assert.ErrorIs(t, err, services.ErrInvalidWebhookSignature, "a key only verifies its own header")
+
+	header.Set("BOX-SIGNATURE-SECONDARY", sign("secondary", body, timestamp))
+	notice, err := box.ParseWebhook(header, body)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"w1"}, notice.WebhookIDs)
+
+	stale := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	header.Set("BOX-DELIVERY-TIMESTAMP", stale)
+	header.Set("BOX-SIGNATURE-SECONDARY", sign("secondary", body, stale))
+	_, err = box.ParseWebhook(header, body)
+	assert.ErrorIs(t, err, services.ErrInvalidWebhookSignature)
+}
+
+func TestQuickBooks_CreateBill(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "70", r.URL.Query().Get("minorversion"))
+		switch {
+		case r.URL.Path == "/123/query":
+			assert.Equal(t, `select Id from Vendor where DisplayName = 'O\'Brien Ltd'`, r.URL.Query().Get("query"))
+			w.Write([]byte(`{"QueryResponse": {}}`))
+		case r.URL.Path == "/123/vendor":
+			w.Write([]byte(`{"Vendor": {"Id": "56"}}`))
+		case r.URL.Path == "/123/bill":
+			var request map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.Equal(t, map[string]interface{}{"value": "56"}, request["VendorRef"])
+			assert.Equal(t, "INV-1", request["DocNumber"])
+			assert.Equal(t, "2026-03-01", request["TxnDate"])
+			assert.Equal(t, map[string]interface{}{"TotalTax": 19.0}, request["TxnTaxDetail"])
+			line := request["Line"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, 100.0, line["Amount"])
+			w.Write([]byte(`{"Bill": {"Id": "789"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	quickBooks := NewQuickBooks(QuickBooksConfig{ClientID: "id", ClientSecret: "secret"})
+	quickBooks.apiURL = server.URL
+	assert.Equal(t, "123", quickBooks.CallbackAccountID(url.Values{"realmId": {"123"}}))
+
+	date := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tax := 19.0
+	billID, err := quickBooks.CreateBill(context.Background(), "token", "123", services.AccountingBill{
+		VendorName:     "O'Brien Ltd",
+		Number:         "INV-1",
+		Date:           &date,
+		Total:          119,
+		Tax:            &tax,
+		ExpenseAccount: "7",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "789", billID)
+}
+
+func TestXero_CreateBill(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/connections":
+			w.Write([]byte(`[{"tenantId": "t0", "tenantType": "PRACTICEMANAGER"}, {"tenantId": "t1", "tenantType": "ORGANISATION", "tenantName": "Acme"}]`))
+		case "/Invoices":
+			assert.Equal(t, "t1", r.Header.Get("Xero-Tenant-Id"))
+			var request struct {
+				Invoices []map[string]interface{} `json:"Invoices"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			require.Len(t, request.Invoices, 1)
+			invoice := request.Invoices[0]
+			assert.Equal(t, "ACCPAY", invoice["Type"])
+			assert.Equal(t, "DRAFT", invoice["Status"])
+			assert.Equal(t, "NoTax", invoice["LineAmountTypes"])
+			assert.Equal(t, map[string]interface{}{"Name": "Acme Supplies"}, invoice["Contact"])
+			w.Write([]byte(`{"Invoices": [{"InvoiceID": "inv-1"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	xero := NewXero(XeroConfig{ClientID: "id", ClientSecret: "secret"})
+	xero.apiURL = server.URL
+	xero.connectionsURL = server.URL + "/connections"
+
+	account, err := xero.Account(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, "t1", account.ID)
+	assert.Equal(t, "Acme", account.Email)
+
+	billID, err := xero.CreateBill(context.Background(), "token", account.ID, services.AccountingBill{
+		VendorName:     "Acme Supplies",
+		Total:          50,
+		ExpenseAccount: "400",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "inv-1", billID)
+}
+
+func TestOAuthClient_BasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		id, secret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "id", id)
+		assert.Equal(t, "secret", secret)
+		assert.Empty(t, r.PostForm.Get("client_secret"))
+		w.Write([]byte(`{"access_token": "access", "refresh_token": "rotated", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	client := oauthClient{clientID: "id", clientSecret: "secret", tokenURL: server.URL, httpClient: server.Client(), basicAuth: true}
+
+	_, err := client.refresh(context.Background(), "current")
+	require.NoError(t, err)
+}
@@ -0,0 +1,179 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// oauthClient is a provider's OAuth 2.0 client registration
+type oauthClient struct {
+	clientID     string
+	clientSecret string
+	authURL      string
+	tokenURL     string
+	scopes       []string
+	httpClient   *http.Client
+	// basicAuth sends the client's credentials in an Authorization header
+	// rather than the form, for token endpoints that accept only that
+	basicAuth bool
+}
+
+func (c *oauthClient) authCodeURL(state, codeChallenge, redirectURL string, extra url.Values) string {
+	query := url.Values{
+		"client_id":             {c.clientID},
+		"redirect_uri":          {redirectURL},
+		"response_type":         {"code"},
+		"scope":                 {strings.Join(c.scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	for key, values := range extra {
+		query[key] = values
+	}
+	return c.authURL + "?" + query.Encode()
+}
+
+func (c *oauthClient) exchange(ctx context.Context, code, codeVerifier, redirectURL string) (*services.OAuthToken, error) {
+	return c.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"code_verifier": {codeVerifier},
+		"redirect_uri":  {redirectURL},
+	})
+}
+
+// refresh reports a grant the provider no longer honours as
+// ErrIntegrationTokenRevoked
+func (c *oauthClient) refresh(ctx context.Context, refreshToken string) (*services.OAuthToken, error) {
+	return c.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (c *oauthClient) token(ctx context.Context, form url.Values) (*services.OAuthToken, error) {
+	if !c.basicAuth {
+		form.Set("client_id", c.clientID)
+		form.Set("client_secret", c.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	if c.basicAuth {
+		req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+	if body.Error == "invalid_grant" {
+		return nil, services.ErrIntegrationTokenRevoked
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+
+	return &services.OAuthToken{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// getJSON calls a provider API with the access token and decodes the response
+func getJSON(ctx context.Context, client *http.Client, accessToken, endpoint string, out interface{}) error {
+	resp, err := get(ctx, client, accessToken, endpoint)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(resp, out)
+}
+
+// postJSON sends in as JSON to a provider API and decodes the response; a
+// nil in sends no body
+func postJSON(ctx context.Context, client *http.Client, accessToken, endpoint string, in, out interface{}) error {
+	return requestJSON(ctx, client, accessToken, http.MethodPost, endpoint, http.Header{}, in, out)
+}
+
+// requestJSON is postJSON for any method, with extra headers
+func requestJSON(ctx context.Context, client *http.Client, accessToken, method, endpoint string, header http.Header, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+		header.Set("Content-Type", "application/json")
+	}
+	resp, err := do(ctx, client, accessToken, method, endpoint, header, body)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(resp, out)
+}
+
+func decodeJSON(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// get calls a provider API, turning error statuses into errors. The caller
+// closes the body.
+func get(ctx context.Context, client *http.Client, accessToken, endpoint string) (*http.Response, error) {
+	return do(ctx, client, accessToken, http.MethodGet, endpoint, nil, nil)
+}
+
+// do calls a provider API with the access token, turning error statuses
+// into errors. The caller closes the body.
+func do(ctx context.Context, client *http.Client, accessToken, method, endpoint string, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
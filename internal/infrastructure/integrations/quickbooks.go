@@ -0,0 +1,214 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// QuickBooksName is the QuickBooks Online provider's name
+const QuickBooksName = "quickbooks"
+
+// quickBooksMinorVersion pins the API's response shapes
+const quickBooksMinorVersion = "70"
+
+// QuickBooks books bills in QuickBooks Online companies. The company
+// connected is the one chosen while granting access, which the callback
+// names as realmId.
+type QuickBooks struct {
+	oauth       oauthClient
+	apiURL      string
+	userInfoURL string
+	client      *http.Client
+}
+
+// QuickBooksConfig holds an Intuit app; Sandbox uses the sandbox companies
+type QuickBooksConfig struct {
+	ClientID     string
+	ClientSecret string
+	Sandbox      bool
+}
+
+func NewQuickBooks(config QuickBooksConfig) *QuickBooks {
+	client := &http.Client{Timeout: time.Minute}
+	apiURL := "https://quickbooks.api.intuit.com/v3/company"
+	if config.Sandbox {
+		apiURL = "https://sandbox-quickbooks.api.intuit.com/v3/company"
+	}
+	return &QuickBooks{
+		oauth: oauthClient{
+			clientID:     config.ClientID,
+			clientSecret: config.ClientSecret,
+			authURL:      "https://appcenter.intuit.com/connect/oauth2",
+			tokenURL:     "https://oauth.platform.intuit.com/oauth2/v1/tokens/bearer",
+			scopes:       []string{"com.intuit.quickbooks.accounting", "openid", "email"},
+			httpClient:   client,
+			basicAuth:    true,
+		},
+		apiURL:      apiURL,
+		userInfoURL: "https://accounts.platform.intuit.com/v1/openid_connect/userinfo",
+		client:      client,
+	}
+}
+
+func (q *QuickBooks) Name() string {
+	return QuickBooksName
+}
+
+func (q *QuickBooks) AuthURL(state, codeChallenge, redirectURL string) string {
+	return q.oauth.authCodeURL(state, codeChallenge, redirectURL, nil)
+}
+
+func (q *QuickBooks) Exchange(ctx context.Context, code, codeVerifier, redirectURL string) (*services.OAuthToken, error) {
+	return q.oauth.exchange(ctx, code, codeVerifier, redirectURL)
+}
+
+func (q *QuickBooks) Refresh(ctx context.Context, refreshToken string) (*services.OAuthToken, error) {
+	return q.oauth.refresh(ctx, refreshToken)
+}
+
+// Account names the user who connected the company; the company's ID comes
+// from the callback
+func (q *QuickBooks) Account(ctx context.Context, accessToken string) (*services.RemoteAccount, error) {
+	var user struct {
+		Email string `json:"email"`
+	}
+	if err := getJSON(ctx, q.client, accessToken, q.userInfoURL, &user); err != nil {
+		return nil, err
+	}
+	return &services.RemoteAccount{Email: user.Email}, nil
+}
+
+func (q *QuickBooks) CallbackAccountID(callback url.Values) string {
+	return strings.TrimSpace(callback.Get("realmId"))
+}
+
+type quickBooksRef struct {
+	Value string `json:"value"`
+}
+
+// CreateBill books the bill, finding its vendor by display name or
+// creating it. Bills are booked in the company's home currency unless the
+// document names another. Tax is booked on the bill as a whole.
+func (q *QuickBooks) CreateBill(ctx context.Context, accessToken, accountID string, bill services.AccountingBill) (string, error) {
+	if accountID == "" {
+		return "", fmt.Errorf("integration has no QuickBooks company")
+	}
+	vendorID, err := q.vendor(ctx, accessToken, accountID, bill.VendorName)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []map[string]interface{}
+	for _, line := range bill.BillLines() {
+		lines = append(lines, map[string]interface{}{
+			"Amount":      roundCents(line.Amount),
+			"Description": truncate(line.Description, 4000),
+			"DetailType":  "AccountBasedExpenseLineDetail",
+			"AccountBasedExpenseLineDetail": map[string]interface{}{
+				"AccountRef": quickBooksRef{Value: line.Account},
+			},
+		})
+	}
+	request := map[string]interface{}{
+		"VendorRef":   quickBooksRef{Value: vendorID},
+		"PrivateNote": bill.Memo,
+		"Line":        lines,
+	}
+	if bill.Number != "" {
+		request["DocNumber"] = truncate(bill.Number, 21)
+	}
+	if bill.Date != nil {
+		request["TxnDate"] = bill.Date.Format("2006-01-02")
+	}
+	if bill.DueDate != nil {
+		request["DueDate"] = bill.DueDate.Format("2006-01-02")
+	}
+	if bill.Currency != "" {
+		request["CurrencyRef"] = quickBooksRef{Value: bill.Currency}
+	}
+	if bill.Tax != nil {
+		request["GlobalTaxCalculation"] = "TaxExcluded"
+		request["TxnTaxDetail"] = map[string]interface{}{"TotalTax": roundCents(*bill.Tax)}
+	}
+
+	var created struct {
+		Bill struct {
+			ID string `json:"Id"`
+		} `json:"Bill"`
+	}
+	if err := requestJSON(ctx, q.client, accessToken, http.MethodPost, q.endpoint(accountID, "bill", nil), quickBooksHeader(), request, &created); err != nil {
+		return "", err
+	}
+	if created.Bill.ID == "" {
+		return "", fmt.Errorf("QuickBooks returned no bill")
+	}
+	return created.Bill.ID, nil
+}
+
+func (q *QuickBooks) vendor(ctx context.Context, accessToken, accountID, name string) (string, error) {
+	// Display names are unique in a company; quotes are escaped with a backslash
+	query := fmt.Sprintf("select Id from Vendor where DisplayName = '%s'", strings.ReplaceAll(name, "'", `\'`))
+	var found struct {
+		QueryResponse struct {
+			Vendor []struct {
+				ID string `json:"Id"`
+			} `json:"Vendor"`
+		} `json:"QueryResponse"`
+	}
+	endpoint := q.endpoint(accountID, "query", url.Values{"query": {query}})
+	if err := requestJSON(ctx, q.client, accessToken, http.MethodGet, endpoint, quickBooksHeader(), nil, &found); err != nil {
+		return "", err
+	}
+	if len(found.QueryResponse.Vendor) > 0 {
+		return found.QueryResponse.Vendor[0].ID, nil
+	}
+
+	var created struct {
+		Vendor struct {
+			ID string `json:"Id"`
+		} `json:"Vendor"`
+	}
+	request := map[string]string{"DisplayName": truncate(name, 500)}
+	if err := requestJSON(ctx, q.client, accessToken, http.MethodPost, q.endpoint(accountID, "vendor", nil), quickBooksHeader(), request, &created); err != nil {
+		return "", err
+	}
+	if created.Vendor.ID == "" {
+		return "", fmt.Errorf("QuickBooks returned no vendor")
+	}
+	return created.Vendor.ID, nil
+}
+
+func (q *QuickBooks) endpoint(accountID, resource string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("minorversion", quickBooksMinorVersion)
+	return q.apiURL + "/" + url.PathEscape(accountID) + "/" + resource + "?" + query.Encode()
+}
+
+// quickBooksHeader asks for JSON, which QuickBooks doesn't answer with by default
+func quickBooksHeader() http.Header {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	return header
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// truncate cuts a value to the longest the provider accepts
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
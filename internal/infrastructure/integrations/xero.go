@@ -0,0 +1,145 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// XeroName is the Xero provider's name
+const XeroName = "xero"
+
+// Xero books bills in Xero organisations. Bills are saved as drafts, for
+// the accountant to approve.
+type Xero struct {
+	oauth          oauthClient
+	apiURL         string
+	connectionsURL string
+	client         *http.Client
+}
+
+// XeroConfig holds a Xero app
+type XeroConfig struct {
+	ClientID     string
+	ClientSecret string
+}
+
+func NewXero(config XeroConfig) *Xero {
+	client := &http.Client{Timeout: time.Minute}
+	return &Xero{
+		oauth: oauthClient{
+			clientID:     config.ClientID,
+			clientSecret: config.ClientSecret,
+			authURL:      "https://login.xero.com/identity/connect/authorize",
+			tokenURL:     "https://identity.xero.com/connect/token",
+			scopes:       []string{"openid", "email", "offline_access", "accounting.transactions", "accounting.contacts"},
+			httpClient:   client,
+			basicAuth:    true,
+		},
+		apiURL:         "https://api.xero.com/api.xro/2.0",
+		connectionsURL: "https://api.xero.com/connections",
+		client:         client,
+	}
+}
+
+func (x *Xero) Name() string {
+	return XeroName
+}
+
+func (x *Xero) AuthURL(state, codeChallenge, redirectURL string) string {
+	return x.oauth.authCodeURL(state, codeChallenge, redirectURL, nil)
+}
+
+func (x *Xero) Exchange(ctx context.Context, code, codeVerifier, redirectURL string) (*services.OAuthToken, error) {
+	return x.oauth.exchange(ctx, code, codeVerifier, redirectURL)
+}
+
+func (x *Xero) Refresh(ctx context.Context, refreshToken string) (*services.OAuthToken, error) {
+	return x.oauth.refresh(ctx, refreshToken)
+}
+
+// Account returns the organisation access was granted to. A user who
+// grants access to several is connected to the first; each can be connected
+// on its own.
+func (x *Xero) Account(ctx context.Context, accessToken string) (*services.RemoteAccount, error) {
+	var connections []struct {
+		TenantID   string `json:"tenantId"`
+		TenantType string `json:"tenantType"`
+		TenantName string `json:"tenantName"`
+	}
+	if err := getJSON(ctx, x.client, accessToken, x.connectionsURL, &connections); err != nil {
+		return nil, err
+	}
+	for _, connection := range connections {
+		if connection.TenantType == "ORGANISATION" {
+			return &services.RemoteAccount{ID: connection.TenantID, Email: connection.TenantName}, nil
+		}
+	}
+	return nil, fmt.Errorf("no Xero organisation was connected")
+}
+
+// CreateBill saves the bill as a draft accounts payable invoice, with each
+// line's share of the tax on it. Xero matches the contact by name, creating
+// it when there's none.
+func (x *Xero) CreateBill(ctx context.Context, accessToken, accountID string, bill services.AccountingBill) (string, error) {
+	if accountID == "" {
+		return "", fmt.Errorf("integration has no Xero organisation")
+	}
+
+	var lines []map[string]interface{}
+	for _, billLine := range bill.BillLines() {
+		line := map[string]interface{}{
+			"Description": truncate(billLine.Description, 4000),
+			"Quantity":    1,
+			"UnitAmount":  roundCents(billLine.Amount),
+			"AccountCode": billLine.Account,
+		}
+		if billLine.Tax != nil {
+			line["TaxAmount"] = roundCents(*billLine.Tax)
+		}
+		lines = append(lines, line)
+	}
+	invoice := map[string]interface{}{
+		"Type":            "ACCPAY",
+		"Status":          "DRAFT",
+		"Contact":         map[string]string{"Name": truncate(bill.VendorName, 255)},
+		"LineAmountTypes": "NoTax",
+		"LineItems":       lines,
+		"Reference":       truncate(bill.Memo, 255),
+	}
+	if bill.Tax != nil {
+		invoice["LineAmountTypes"] = "Exclusive"
+	}
+	if bill.Number != "" {
+		invoice["InvoiceNumber"] = truncate(bill.Number, 255)
+	}
+	if bill.Date != nil {
+		invoice["Date"] = bill.Date.Format("2006-01-02")
+	}
+	if bill.DueDate != nil {
+		invoice["DueDate"] = bill.DueDate.Format("2006-01-02")
+	}
+	if bill.Currency != "" {
+		invoice["CurrencyCode"] = bill.Currency
+	}
+
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("Xero-Tenant-Id", accountID)
+	var created struct {
+		Invoices []struct {
+			InvoiceID string `json:"InvoiceID"`
+		} `json:"Invoices"`
+	}
+	request := map[string]interface{}{"Invoices": []map[string]interface{}{invoice}}
+	if err := requestJSON(ctx, x.client, accessToken, http.MethodPost, x.apiURL+"/Invoices", header, request, &created); err != nil {
+		return "", err
+	}
+	if len(created.Invoices) == 0 || created.Invoices[0].InvoiceID == "" {
+		return "", fmt.Errorf("Xero returned no bill")
+	}
+	return created.Invoices[0].InvoiceID, nil
+}
@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// Anthropic runs prompts on Claude through the Messages API. It has no
+// embeddings; route embedding_generation jobs to another provider.
+type Anthropic struct {
+	apiKey string
+	model  string
+	apiURL string
+	client *http.Client
+}
+
+func newAnthropic(config ProviderConfig, apiKey string, client *http.Client) *Anthropic {
+	return &Anthropic{
+		apiKey: apiKey,
+		model:  withDefault(config.Model, "claude-3-5-sonnet-latest"),
+		apiURL: withDefault(config.BaseURL, "https://api.anthropic.com"),
+		client: client,
+	}
+}
+
+func (a *Anthropic) Name() string {
+	return services.AIProviderAnthropic
+}
+
+// Complete sends the prompt as one user message. JSON replies are asked
+// for by starting Claude's answer with the object's opening brace.
+func (a *Anthropic) Complete(ctx context.Context, request services.LLMRequest) (*services.LLMResponse, error) {
+	var response struct {
+		Model   string `json:"model"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := postJSON(ctx, a.client, a.apiURL+"/v1/messages", a.header(), a.messagesBody(request), &response); err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	if request.JSON {
+		text.WriteString("{")
+	}
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("Anthropic returned no text")
+	}
+	return &services.LLMResponse{
+		Text:         text.String(),
+		Model:        response.Model,
+		InputTokens:  response.Usage.InputTokens,
+		OutputTokens: response.Usage.OutputTokens,
+	}, nil
+}
+
+// Stream streams the reply as server-sent events: the input tokens come
+// with message_start, the text in content_block_delta events and the output
+// tokens with message_delta
+func (a *Anthropic) Stream(ctx context.Context, request services.LLMRequest, onText func(text string)) (*services.LLMResponse, error) {
+	body := a.messagesBody(request)
+	body["stream"] = true
+
+	response := &services.LLMResponse{Model: a.model}
+	var text strings.Builder
+	if request.JSON {
+		text.WriteString("{")
+		onText("{")
+	}
+	err := postStream(ctx, a.client, a.apiURL+"/v1/messages", a.header(), body, func(line []byte) error {
+		var event struct {
+			Type    string `json:"type"`
+			Message struct {
+				Model string `json:"model"`
+				Usage struct {
+					InputTokens int `json:"input_tokens"`
+				} `json:"usage"`
+			} `json:"message"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Usage struct {
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(line, &event); err != nil {
+			return fmt.Errorf("failed to decode stream: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			response.Model = withDefault(event.Message.Model, response.Model)
+			response.InputTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				text.WriteString(event.Delta.Text)
+				onText(event.Delta.Text)
+			}
+		case "message_delta":
+			response.OutputTokens = event.Usage.OutputTokens
+		case "error":
+			return fmt.Errorf("Anthropic stream failed: %s", event.Error.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.Text = text.String()
+	return response, nil
+}
+
+func (a *Anthropic) Embed(ctx context.Context, text string) (*services.LLMEmbedding, error) {
+	return nil, services.ErrLLMEmbeddingsUnsupported
+}
+
+func (a *Anthropic) messagesBody(request services.LLMRequest) map[string]interface{} {
+	messages := []map[string]string{{"role": "user", "content": request.Prompt}}
+	if request.JSON {
+		messages = append(messages, map[string]string{"role": "assistant", "content": "{"})
+	}
+	body := map[string]interface{}{
+		"model":      a.model,
+		"max_tokens": request.MaxTokens,
+		"messages":   messages,
+	}
+	if request.System != "" {
+		body["system"] = request.System
+	}
+	return body
+}
+
+func (a *Anthropic) header() http.Header {
+	header := http.Header{}
+	header.Set("x-api-key", a.apiKey)
+	header.Set("anthropic-version", "2023-06-01")
+	return header
+}
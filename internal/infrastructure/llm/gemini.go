@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// Gemini runs prompts and embeds text through the Gemini API
+type Gemini struct {
+	apiKey         string
+	model          string
+	embeddingModel string
+	apiURL         string
+	client         *http.Client
+}
+
+func newGemini(config ProviderConfig, apiKey string, client *http.Client) *Gemini {
+	return &Gemini{
+		apiKey:         apiKey,
+		model:          withDefault(config.Model, "gemini-1.5-flash"),
+		embeddingModel: withDefault(config.EmbeddingModel, "text-embedding-004"),
+		apiURL:         withDefault(config.BaseURL, "https://generativelanguage.googleapis.com"),
+		client:         client,
+	}
+}
+
+func (g *Gemini) Name() string {
+	return services.AIProviderGemini
+}
+
+// geminiResponse is a reply from generateContent, or a part of a streamed one
+type geminiResponse struct {
+	ModelVersion string `json:"modelVersion"`
+	Candidates   []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+func (r *geminiResponse) text() string {
+	var text strings.Builder
+	if len(r.Candidates) > 0 {
+		for _, part := range r.Candidates[0].Content.Parts {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
+
+func (g *Gemini) Complete(ctx context.Context, request services.LLMRequest) (*services.LLMResponse, error) {
+	var response geminiResponse
+	if err := postJSON(ctx, g.client, g.endpoint(g.model, "generateContent"), g.header(), g.contentBody(request), &response); err != nil {
+		return nil, err
+	}
+	if len(response.Candidates) == 0 {
+		return nil, fmt.Errorf("Gemini returned no candidates")
+	}
+	return &services.LLMResponse{
+		Text:         response.text(),
+		Model:        withDefault(response.ModelVersion, g.model),
+		InputTokens:  response.UsageMetadata.PromptTokenCount,
+		OutputTokens: response.UsageMetadata.CandidatesTokenCount,
+	}, nil
+}
+
+// Stream streams the reply as server-sent events, each a part of the
+// reply with the usage so far
+func (g *Gemini) Stream(ctx context.Context, request services.LLMRequest, onText func(text string)) (*services.LLMResponse, error) {
+	response := &services.LLMResponse{Model: g.model}
+	var text strings.Builder
+	err := postStream(ctx, g.client, g.endpoint(g.model, "streamGenerateContent")+"?alt=sse", g.header(), g.contentBody(request), func(line []byte) error {
+		var chunk geminiResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream: %w", err)
+		}
+		response.Model = withDefault(chunk.ModelVersion, response.Model)
+		if part := chunk.text(); part != "" {
+			text.WriteString(part)
+			onText(part)
+		}
+		if chunk.UsageMetadata.PromptTokenCount > 0 {
+			response.InputTokens = chunk.UsageMetadata.PromptTokenCount
+			response.OutputTokens = chunk.UsageMetadata.CandidatesTokenCount
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.Text = text.String()
+	return response, nil
+}
+
+// Embed embeds the text. The API doesn't report the tokens used; they're
+// estimated from the text.
+func (g *Gemini) Embed(ctx context.Context, text string) (*services.LLMEmbedding, error) {
+	var response struct {
+		Embedding struct {
+			Values []float32 `json:"values"`
+		} `json:"embedding"`
+	}
+	body := map[string]interface{}{"content": map[string]interface{}{"parts": []map[string]string{{"text": text}}}}
+	if err := postJSON(ctx, g.client, g.endpoint(g.embeddingModel, "embedContent"), g.header(), body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Embedding.Values) == 0 {
+		return nil, fmt.Errorf("Gemini returned no embedding")
+	}
+	return &services.LLMEmbedding{Vector: response.Embedding.Values, Model: g.embeddingModel}, nil
+}
+
+func (g *Gemini) contentBody(request services.LLMRequest) map[string]interface{} {
+	generation := map[string]interface{}{"maxOutputTokens": request.MaxTokens}
+	if request.JSON {
+		generation["responseMimeType"] = "application/json"
+	}
+	body := map[string]interface{}{
+		"contents":         []map[string]interface{}{{"role": "user", "parts": []map[string]string{{"text": request.Prompt}}}},
+		"generationConfig": generation,
+	}
+	if request.System != "" {
+		body["systemInstruction"] = map[string]interface{}{"parts": []map[string]string{{"text": request.System}}}
+	}
+	return body
+}
+
+func (g *Gemini) endpoint(model, method string) string {
+	return fmt.Sprintf("%s/v1beta/models/%s:%s", g.apiURL, url.PathEscape(model), method)
+}
+
+func (g *Gemini) header() http.Header {
+	header := http.Header{}
+	header.Set("x-goog-api-key", g.apiKey)
+	return header
+}
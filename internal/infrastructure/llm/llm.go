@@ -0,0 +1,184 @@
+// Package llm implements the AI providers jobs run on: Anthropic, OpenAI,
+// Gemini and Ollama for local models
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// Config holds the platform's provider accounts and the models jobs use
+type Config struct {
+	MaxTokens int // Per reply; defaults to 1024
+	OpenAI    ProviderConfig
+	Anthropic ProviderConfig
+	Gemini    ProviderConfig
+	Ollama    ProviderConfig // Needs no key; BaseURL is the Ollama server
+}
+
+// ProviderConfig holds a provider's key and models. Empty fields take the
+// provider's defaults.
+type ProviderConfig struct {
+	APIKey         string
+	Model          string
+	EmbeddingModel string
+	BaseURL        string
+}
+
+// Factory builds AI clients on the configured providers, with the
+// platform's keys or tenants' own
+type Factory struct {
+	config Config
+	client *http.Client
+}
+
+func NewFactory(config Config) *Factory {
+	return &Factory{
+		config: config,
+		// Local models can take minutes on long documents; jobs bound each
+		// call with their own timeout
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// NewClient returns a client on the provider with a tenant's key
+func (f *Factory) NewClient(provider, apiKey string) (services.OpenAIService, error) {
+	llmProvider, err := f.Provider(provider, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return services.NewLLMClient(llmProvider, f.config.MaxTokens), nil
+}
+
+// Provider returns the provider's API with the given key
+func (f *Factory) Provider(provider, apiKey string) (services.LLMProvider, error) {
+	switch provider {
+	case services.AIProviderOpenAI:
+		return newOpenAI(f.config.OpenAI, apiKey, f.client), nil
+	case services.AIProviderAnthropic:
+		return newAnthropic(f.config.Anthropic, apiKey, f.client), nil
+	case services.AIProviderGemini:
+		return newGemini(f.config.Gemini, apiKey, f.client), nil
+	case services.AIProviderOllama:
+		return newOllama(f.config.Ollama, f.client), nil
+	}
+	return nil, fmt.Errorf("%w: %s", services.ErrInvalidAIProvider, provider)
+}
+
+// PlatformClients returns clients with the platform's keys for the
+// providers it has
+func (f *Factory) PlatformClients() map[string]services.OpenAIService {
+	clients := make(map[string]services.OpenAIService)
+	for name, provider := range f.PlatformProviders() {
+		clients[name] = services.NewLLMClient(provider, f.config.MaxTokens)
+	}
+	return clients
+}
+
+// PlatformProviders returns the APIs of the providers the platform has:
+// those with a key, and Ollama when a server is set
+func (f *Factory) PlatformProviders() map[string]services.LLMProvider {
+	providers := make(map[string]services.LLMProvider)
+	for name, config := range map[string]ProviderConfig{
+		services.AIProviderOpenAI:    f.config.OpenAI,
+		services.AIProviderAnthropic: f.config.Anthropic,
+		services.AIProviderGemini:    f.config.Gemini,
+	} {
+		if config.APIKey != "" {
+			providers[name], _ = f.Provider(name, config.APIKey)
+		}
+	}
+	if f.config.Ollama.BaseURL != "" {
+		providers[services.AIProviderOllama], _ = f.Provider(services.AIProviderOllama, "")
+	}
+	return providers
+}
+
+// Helper functions
+
+func withDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// postJSON sends in as JSON and decodes the response
+func postJSON(ctx context.Context, client *http.Client, endpoint string, header http.Header, in, out interface{}) error {
+	resp, err := send(ctx, client, endpoint, header, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// postStream sends in as JSON and passes each line of the streamed
+// response to onLine. Server-sent events are passed their data only.
+func postStream(ctx context.Context, client *http.Client, endpoint string, header http.Header, in interface{}, onLine func(line []byte) error) error {
+	resp, err := send(ctx, client, endpoint, header, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if data, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			line = bytes.TrimSpace(data)
+		} else if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			continue // Event names and comments
+		}
+		if len(line) == 0 || string(line) == "[DONE]" {
+			continue
+		}
+		if err := onLine(line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+	return nil
+}
+
+// send posts in as JSON, turning error statuses into errors
+func send(ctx context.Context, client *http.Client, endpoint string, header http.Header, in interface{}) (*http.Response, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", services.ErrAIServiceUnavailable, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,213 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jsonRequest = services.LLMRequest{System: "system", Prompt: "prompt", MaxTokens: 100, JSON: true}
+
+// decodeBody reads a request's JSON body
+func decodeBody(t *testing.T, r *http.Request) map[string]interface{} {
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	return body
+}
+
+func TestAnthropic_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get("x-api-key"))
+		assert.NotEmpty(t, r.Header.Get("anthropic-version"))
+		body := decodeBody(t, r)
+		assert.Equal(t, "system", body["system"])
+		// JSON replies are prefilled with the opening brace
+		messages := body["messages"].([]interface{})
+		assert.Equal(t, "{", messages[1].(map[string]interface{})["content"])
+		w.Write([]byte(`{"model": "claude-3-5-sonnet-20241022", "content": [{"type": "text", "text": "\"ok\": true}"}],
+			"usage": {"input_tokens": 12, "output_tokens": 4}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewFactory(Config{Anthropic: ProviderConfig{BaseURL: server.URL}}).Provider(services.AIProviderAnthropic, "key")
+	require.NoError(t, err)
+	response, err := provider.Complete(context.Background(), jsonRequest)
+	require.NoError(t, err)
+	assert.Equal(t, &services.LLMResponse{Text: `{"ok": true}`, Model: "claude-3-5-sonnet-20241022", InputTokens: 12, OutputTokens: 4}, response)
+
+	_, err = provider.Embed(context.Background(), "text")
+	assert.ErrorIs(t, err, services.ErrLLMEmbeddingsUnsupported)
+}
+
+func TestOpenAI_CompleteAndEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		body := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			assert.Equal(t, "gpt-4o", body["model"])
+			assert.Equal(t, map[string]interface{}{"type": "json_object"}, body["response_format"])
+			w.Write([]byte(`{"model": "gpt-4o-2024-08-06", "choices": [{"message": {"content": "{}"}}],
+				"usage": {"prompt_tokens": 10, "completion_tokens": 2}}`))
+		case "/v1/embeddings":
+			assert.Equal(t, "text-embedding-3-small", body["model"])
+			w.Write([]byte(`{"model": "text-embedding-3-small", "data": [{"embedding": [0.5, 0.25]}], "usage": {"prompt_tokens": 3}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewFactory(Config{OpenAI: ProviderConfig{Model: "gpt-4o", BaseURL: server.URL}}).Provider(services.AIProviderOpenAI, "key")
+	require.NoError(t, err)
+	response, err := provider.Complete(context.Background(), jsonRequest)
+	require.NoError(t, err)
+	assert.Equal(t, &services.LLMResponse{Text: "{}", Model: "gpt-4o-2024-08-06", InputTokens: 10, OutputTokens: 2}, response)
+
+	embedding, err := provider.Embed(context.Background(), "text")
+	require.NoError(t, err)
+	assert.Equal(t, &services.LLMEmbedding{Vector: []float32{0.5, 0.25}, Model: "text-embedding-3-small", InputTokens: 3}, embedding)
+}
+
+func TestGemini_CompleteAndEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("x-goog-api-key"))
+		body := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/v1beta/models/gemini-1.5-flash:generateContent":
+			assert.Equal(t, "application/json", body["generationConfig"].(map[string]interface{})["responseMimeType"])
+			w.Write([]byte(`{"modelVersion": "gemini-1.5-flash-002", "candidates": [{"content": {"parts": [{"text": "{}"}]}}],
+				"usageMetadata": {"promptTokenCount": 8, "candidatesTokenCount": 1}}`))
+		case "/v1beta/models/text-embedding-004:embedContent":
+			w.Write([]byte(`{"embedding": {"values": [1, 2]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewFactory(Config{Gemini: ProviderConfig{BaseURL: server.URL}}).Provider(services.AIProviderGemini, "key")
+	require.NoError(t, err)
+	response, err := provider.Complete(context.Background(), jsonRequest)
+	require.NoError(t, err)
+	assert.Equal(t, &services.LLMResponse{Text: "{}", Model: "gemini-1.5-flash-002", InputTokens: 8, OutputTokens: 1}, response)
+
+	embedding, err := provider.Embed(context.Background(), "text")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1, 2}, embedding.Vector)
+	assert.Zero(t, embedding.InputTokens)
+}
+
+func TestOllama_CompleteAndEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		body := decodeBody(t, r)
+		switch r.URL.Path {
+		case "/api/chat":
+			assert.Equal(t, "json", body["format"])
+			assert.Equal(t, false, body["stream"])
+			w.Write([]byte(`{"model": "llama3.1", "message": {"content": "{}"}, "prompt_eval_count": 20, "eval_count": 5}`))
+		case "/api/embed":
+			w.Write([]byte(`{"model": "nomic-embed-text", "embeddings": [[0.1, 0.2]], "prompt_eval_count": 2}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	factory := NewFactory(Config{Ollama: ProviderConfig{BaseURL: server.URL + "/"}})
+	provider, err := factory.Provider(services.AIProviderOllama, "")
+	require.NoError(t, err)
+	response, err := provider.Complete(context.Background(), jsonRequest)
+	require.NoError(t, err)
+	assert.Equal(t, &services.LLMResponse{Text: "{}", Model: "llama3.1", InputTokens: 20, OutputTokens: 5}, response)
+
+	embedding, err := provider.Embed(context.Background(), "text")
+	require.NoError(t, err)
+	assert.Equal(t, &services.LLMEmbedding{Vector: []float32{0.1, 0.2}, Model: "nomic-embed-text", InputTokens: 2}, embedding)
+}
+
+func TestFactory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "invalid key"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	factory := NewFactory(Config{
+		OpenAI: ProviderConfig{BaseURL: server.URL},
+		Gemini: ProviderConfig{APIKey: "AIza-key"},
+		Ollama: ProviderConfig{BaseURL: "http://ollama:11434"},
+	})
+
+	// Only providers the platform can call get a client
+	clients := factory.PlatformClients()
+	assert.Len(t, clients, 2)
+	assert.Contains(t, clients, services.AIProviderGemini)
+	assert.Contains(t, clients, services.AIProviderOllama)
+
+	_, err := factory.NewClient("mistral", "key")
+	assert.ErrorIs(t, err, services.ErrInvalidAIProvider)
+
+	client, err := factory.NewClient(services.AIProviderOpenAI, "bad-key")
+	require.NoError(t, err)
+	_, err = client.GenerateSummary(context.Background(), "text")
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestProviders_Stream(t *testing.T) {
+	streams := map[string]string{
+		"/v1/chat/completions": "data: {\"model\": \"gpt-4o\", \"choices\": [{\"delta\": {\"content\": \"Due \"}}]}\n\n" +
+			"data: {\"choices\": [{\"delta\": {\"content\": \"1 March [1].\"}}]}\n\n" +
+			"data: {\"choices\": [], \"usage\": {\"prompt_tokens\": 50, \"completion_tokens\": 6}}\n\ndata: [DONE]\n\n",
+		"/v1/messages": "event: message_start\ndata: {\"type\": \"message_start\", \"message\": {\"model\": \"claude-3-5-sonnet-20241022\", \"usage\": {\"input_tokens\": 50}}}\n\n" +
+			"event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"delta\": {\"type\": \"text_delta\", \"text\": \"Due \"}}\n\n" +
+			"event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"delta\": {\"type\": \"text_delta\", \"text\": \"1 March [1].\"}}\n\n" +
+			"event: message_delta\ndata: {\"type\": \"message_delta\", \"usage\": {\"output_tokens\": 6}}\n\n",
+		"/v1beta/models/gemini-1.5-flash:streamGenerateContent": "data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"Due \"}]}}]}\n\n" +
+			"data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"1 March [1].\"}]}}], \"usageMetadata\": {\"promptTokenCount\": 50, \"candidatesTokenCount\": 6}}\n\n",
+		"/api/chat": "{\"model\": \"llama3.1\", \"message\": {\"content\": \"Due \"}, \"done\": false}\n" +
+			"{\"model\": \"llama3.1\", \"message\": {\"content\": \"1 March [1].\"}, \"done\": false}\n" +
+			"{\"model\": \"llama3.1\", \"message\": {\"content\": \"\"}, \"done\": true, \"prompt_eval_count\": 50, \"eval_count\": 6}\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, ok := streams[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		body := decodeBody(t, r)
+		if r.URL.Path == "/api/chat" {
+			assert.Equal(t, true, body["stream"])
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		} else {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		w.Write([]byte(stream))
+	}))
+	defer server.Close()
+
+	provider := ProviderConfig{BaseURL: server.URL}
+	factory := NewFactory(Config{OpenAI: provider, Anthropic: provider, Gemini: provider, Ollama: provider})
+	for _, name := range []string{services.AIProviderOpenAI, services.AIProviderAnthropic, services.AIProviderGemini, services.AIProviderOllama} {
+		llmProvider, err := factory.Provider(name, "key")
+		require.NoError(t, err)
+
+		var parts []string
+		response, err := llmProvider.Stream(context.Background(), services.LLMRequest{Prompt: "When is it due?", MaxTokens: 100}, func(text string) {
+			parts = append(parts, text)
+		})
+		require.NoError(t, err, name)
+		assert.Equal(t, []string{"Due ", "1 March [1]."}, parts, name)
+		assert.Equal(t, "Due 1 March [1].", response.Text, name)
+		assert.Equal(t, 50, response.InputTokens, name)
+		assert.Equal(t, 6, response.OutputTokens, name)
+		assert.NotEmpty(t, response.Model, name)
+	}
+}
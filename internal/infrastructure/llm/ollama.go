@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// Ollama runs prompts and embeds text on models served by an Ollama
+// server, so documents never leave the deployment. Its calls cost nothing.
+type Ollama struct {
+	model          string
+	embeddingModel string
+	apiURL         string
+	client         *http.Client
+}
+
+func newOllama(config ProviderConfig, client *http.Client) *Ollama {
+	return &Ollama{
+		model:          withDefault(config.Model, "llama3.1"),
+		embeddingModel: withDefault(config.EmbeddingModel, "nomic-embed-text"),
+		apiURL:         strings.TrimSuffix(withDefault(config.BaseURL, "http://localhost:11434"), "/"),
+		client:         client,
+	}
+}
+
+func (o *Ollama) Name() string {
+	return services.AIProviderOllama
+}
+
+// ollamaChatResponse is a reply from /api/chat, or a part of a streamed one
+type ollamaChatResponse struct {
+	Model   string `json:"model"`
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+func (o *Ollama) Complete(ctx context.Context, request services.LLMRequest) (*services.LLMResponse, error) {
+	var response ollamaChatResponse
+	if err := postJSON(ctx, o.client, o.apiURL+"/api/chat", http.Header{}, o.chatBody(request, false), &response); err != nil {
+		return nil, err
+	}
+	return &services.LLMResponse{
+		Text:         response.Message.Content,
+		Model:        withDefault(response.Model, o.model),
+		InputTokens:  response.PromptEvalCount,
+		OutputTokens: response.EvalCount,
+	}, nil
+}
+
+// Stream streams the reply as lines of JSON, the last with the usage
+func (o *Ollama) Stream(ctx context.Context, request services.LLMRequest, onText func(text string)) (*services.LLMResponse, error) {
+	response := &services.LLMResponse{Model: o.model}
+	var text strings.Builder
+	err := postStream(ctx, o.client, o.apiURL+"/api/chat", http.Header{}, o.chatBody(request, true), func(line []byte) error {
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream: %w", err)
+		}
+		response.Model = withDefault(chunk.Model, response.Model)
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			onText(chunk.Message.Content)
+		}
+		if chunk.EvalCount > 0 {
+			response.InputTokens = chunk.PromptEvalCount
+			response.OutputTokens = chunk.EvalCount
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.Text = text.String()
+	return response, nil
+}
+
+func (o *Ollama) Embed(ctx context.Context, text string) (*services.LLMEmbedding, error) {
+	var response struct {
+		Model           string      `json:"model"`
+		Embeddings      [][]float32 `json:"embeddings"`
+		PromptEvalCount int         `json:"prompt_eval_count"`
+	}
+	body := map[string]interface{}{"model": o.embeddingModel, "input": text}
+	if err := postJSON(ctx, o.client, o.apiURL+"/api/embed", http.Header{}, body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Embeddings) == 0 {
+		return nil, fmt.Errorf("Ollama returned no embedding")
+	}
+	return &services.LLMEmbedding{
+		Vector:      response.Embeddings[0],
+		Model:       withDefault(response.Model, o.embeddingModel),
+		InputTokens: response.PromptEvalCount,
+	}, nil
+}
+
+func (o *Ollama) chatBody(request services.LLMRequest, stream bool) map[string]interface{} {
+	var messages []map[string]string
+	if request.System != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": request.System})
+	}
+	messages = append(messages, map[string]string{"role": "user", "content": request.Prompt})
+	body := map[string]interface{}{
+		"model":    o.model,
+		"messages": messages,
+		"stream":   stream,
+		"options":  map[string]interface{}{"num_predict": request.MaxTokens},
+	}
+	if request.JSON {
+		body["format"] = "json"
+	}
+	return body
+}
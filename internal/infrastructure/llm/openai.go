@@ -0,0 +1,153 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// OpenAI runs prompts through the Chat Completions API and embeds text
+// through the Embeddings API
+type OpenAI struct {
+	apiKey         string
+	model          string
+	embeddingModel string
+	apiURL         string
+	client         *http.Client
+}
+
+func newOpenAI(config ProviderConfig, apiKey string, client *http.Client) *OpenAI {
+	return &OpenAI{
+		apiKey:         apiKey,
+		model:          withDefault(config.Model, "gpt-4o-mini"),
+		embeddingModel: withDefault(config.EmbeddingModel, "text-embedding-3-small"),
+		apiURL:         withDefault(config.BaseURL, "https://api.openai.com"),
+		client:         client,
+	}
+}
+
+func (o *OpenAI) Name() string {
+	return services.AIProviderOpenAI
+}
+
+func (o *OpenAI) Complete(ctx context.Context, request services.LLMRequest) (*services.LLMResponse, error) {
+	var response struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := postJSON(ctx, o.client, o.apiURL+"/v1/chat/completions", o.header(), o.chatBody(request), &response); err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("OpenAI returned no choices")
+	}
+	return &services.LLMResponse{
+		Text:         response.Choices[0].Message.Content,
+		Model:        response.Model,
+		InputTokens:  response.Usage.PromptTokens,
+		OutputTokens: response.Usage.CompletionTokens,
+	}, nil
+}
+
+func (o *OpenAI) Stream(ctx context.Context, request services.LLMRequest, onText func(text string)) (*services.LLMResponse, error) {
+	body := o.chatBody(request)
+	body["stream"] = true
+	body["stream_options"] = map[string]bool{"include_usage": true}
+
+	response := &services.LLMResponse{Model: o.model}
+	var text strings.Builder
+	err := postStream(ctx, o.client, o.apiURL+"/v1/chat/completions", o.header(), body, func(line []byte) error {
+		var chunk struct {
+			Model   string `json:"model"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream: %w", err)
+		}
+		response.Model = withDefault(chunk.Model, response.Model)
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				onText(choice.Delta.Content)
+			}
+		}
+		// The last chunk has the usage and no choices
+		if chunk.Usage != nil {
+			response.InputTokens = chunk.Usage.PromptTokens
+			response.OutputTokens = chunk.Usage.CompletionTokens
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.Text = text.String()
+	return response, nil
+}
+
+func (o *OpenAI) Embed(ctx context.Context, text string) (*services.LLMEmbedding, error) {
+	var response struct {
+		Model string `json:"model"`
+		Data  []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		} `json:"usage"`
+	}
+	body := map[string]interface{}{"model": o.embeddingModel, "input": text}
+	if err := postJSON(ctx, o.client, o.apiURL+"/v1/embeddings", o.header(), body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Data) == 0 {
+		return nil, fmt.Errorf("OpenAI returned no embedding")
+	}
+	return &services.LLMEmbedding{
+		Vector:      response.Data[0].Embedding,
+		Model:       response.Model,
+		InputTokens: response.Usage.PromptTokens,
+	}, nil
+}
+
+func (o *OpenAI) chatBody(request services.LLMRequest) map[string]interface{} {
+	var messages []map[string]string
+	if request.System != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": request.System})
+	}
+	messages = append(messages, map[string]string{"role": "user", "content": request.Prompt})
+	body := map[string]interface{}{
+		"model":      o.model,
+		"max_tokens": request.MaxTokens,
+		"messages":   messages,
+	}
+	if request.JSON {
+		body["response_format"] = map[string]string{"type": "json_object"}
+	}
+	return body
+}
+
+func (o *OpenAI) header() http.Header {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+o.apiKey)
+	return header
+}
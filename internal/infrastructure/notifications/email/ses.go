@@ -0,0 +1,181 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+const sesSendEmailPath = "/v2/email/outbound-emails"
+
+// SESTransport delivers email through the Amazon SES v2 SendEmail API
+type SESTransport struct {
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	endpoint        string
+	httpClient      *http.Client
+}
+
+// SESConfig configures the SES transport
+type SESConfig struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Optional, for temporary credentials
+}
+
+var _ services.EmailTransport = (*SESTransport)(nil)
+
+func NewSESTransport(config SESConfig) (*SESTransport, error) {
+	if config.Region == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("ses region, access key ID and secret access key are required")
+	}
+
+	return &SESTransport{
+		region:          config.Region,
+		accessKeyID:     config.AccessKeyID,
+		secretAccessKey: config.SecretAccessKey,
+		sessionToken:    config.SessionToken,
+		endpoint:        fmt.Sprintf("https://email.%s.amazonaws.com", config.Region),
+		httpClient:      &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+func (s *SESTransport) SendEmail(ctx context.Context, message services.EmailMessage) error {
+	body := map[string]interface{}{
+		"FromEmailAddress": message.From,
+		"Destination":      map[string][]string{"ToAddresses": {message.To}},
+		"Content": map[string]interface{}{
+			"Simple": map[string]interface{}{
+				"Subject": sesContent{Data: sanitizeHeader(message.Subject), Charset: "UTF-8"},
+				"Body":    sesBody(message),
+			},
+		},
+	}
+	if message.ReplyTo != "" {
+		body["ReplyToAddresses"] = []string{message.ReplyTo}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode ses request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+sesSendEmailPath, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create ses request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.sign(req, payload, time.Now().UTC())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(reply, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("ses responded with HTTP %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("ses responded with HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func sesBody(message services.EmailMessage) map[string]sesContent {
+	body := make(map[string]sesContent)
+	if message.TextBody != "" {
+		body["Text"] = sesContent{Data: message.TextBody, Charset: "UTF-8"}
+	}
+	if message.HTMLBody != "" {
+		body["Html"] = sesContent{Data: message.HTMLBody, Charset: "UTF-8"}
+	}
+	return body
+}
+
+// sign adds an AWS Signature Version 4 Authorization header
+func (s *SESTransport) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := []string{"content-type", "host", "x-amz-date"}
+	values := map[string]string{
+		"content-type": req.Header.Get("Content-Type"),
+		"host":         req.URL.Host,
+		"x-amz-date":   amzDate,
+	}
+	if s.sessionToken != "" {
+		headers = append(headers, "x-amz-security-token")
+		values["x-amz-security-token"] = s.sessionToken
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headers {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(values[name]) + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	scope := dateStamp + "/" + s.region + "/ses/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), dateStamp)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "ses")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,149 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+const (
+	apnsProductionHost  = "https://api.push.apple.com"
+	apnsDevelopmentHost = "https://api.sandbox.push.apple.com"
+)
+
+// APNsProvider delivers push notifications to iOS devices using token-based (.p8) authentication
+type APNsProvider struct {
+	keyID      string
+	teamID     string
+	topic      string
+	host       string
+	privateKey crypto.PrivateKey
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	authToken string
+	issuedAt  time.Time
+}
+
+// APNsConfig configures the APNs provider
+type APNsConfig struct {
+	KeyFile    string // .p8 auth key downloaded from the Apple developer portal
+	KeyID      string
+	TeamID     string
+	Topic      string // App bundle identifier
+	Production bool
+}
+
+func NewAPNsProvider(config APNsConfig) (*APNsProvider, error) {
+	raw, err := os.ReadFile(config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read APNs key: %w", err)
+	}
+
+	key, err := parsePrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse APNs key: %w", err)
+	}
+
+	host := apnsDevelopmentHost
+	if config.Production {
+		host = apnsProductionHost
+	}
+
+	return &APNsProvider{
+		keyID:      config.KeyID,
+		teamID:     config.TeamID,
+		topic:      config.Topic,
+		host:       host,
+		privateKey: key,
+		// net/http negotiates HTTP/2 over TLS, which APNs requires
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (p *APNsProvider) SendPush(ctx context.Context, deviceToken string, message services.PushMessage) error {
+	authToken, err := p.getAuthToken()
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{
+				"title": message.Title,
+				"body":  message.Body,
+			},
+			"sound": "default",
+		},
+	}
+	for key, value := range message.Data {
+		payload[key] = value
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode APNs payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.host+"/3/device/"+deviceToken, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create APNs request: %w", err)
+	}
+	req.Header.Set("authorization", "bearer "+authToken)
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "alert")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send APNs notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var apnsErr struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(resp.Body).Decode(&apnsErr)
+
+	if resp.StatusCode == http.StatusGone || apnsErr.Reason == "BadDeviceToken" || apnsErr.Reason == "Unregistered" {
+		return services.ErrPushTokenExpired
+	}
+
+	return fmt.Errorf("APNs returned %d: %s", resp.StatusCode, apnsErr.Reason)
+}
+
+// getAuthToken returns the provider JWT; Apple rejects tokens older than an hour
+// and throttles tokens refreshed more often than every 20 minutes
+func (p *APNsProvider) getAuthToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.authToken != "" && time.Since(p.issuedAt) < 40*time.Minute {
+		return p.authToken, nil
+	}
+
+	now := time.Now()
+	token, err := signJWT(
+		map[string]interface{}{"alg": "ES256", "kid": p.keyID},
+		map[string]interface{}{"iss": p.teamID, "iat": now.Unix()},
+		p.privateKey,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	p.authToken = token
+	p.issuedAt = now
+	return p.authToken, nil
+}
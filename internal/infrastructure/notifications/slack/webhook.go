@@ -0,0 +1,66 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+// slackWebhookHost is the only host incoming webhooks are posted to, so a
+// tenant setting can't point deliveries at arbitrary URLs
+const slackWebhookHost = "hooks.slack.com"
+
+// WebhookSender delivers notifications to Slack incoming webhooks
+type WebhookSender struct {
+	httpClient *http.Client
+}
+
+var _ services.ChannelSender = (*WebhookSender)(nil)
+
+func NewWebhookSender() *WebhookSender {
+	return &WebhookSender{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *WebhookSender) Send(ctx context.Context, message services.ChannelMessage) error {
+	endpoint, err := url.Parse(message.Recipient)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host != slackWebhookHost {
+		return fmt.Errorf("invalid slack webhook url")
+	}
+
+	text := fmt.Sprintf("*%s*\n%s", message.Title, message.Message)
+	if message.RecipientName != "" {
+		text = fmt.Sprintf("*%s* (for %s)\n%s", message.Title, message.RecipientName, message.Message)
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("failed to encode slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send slack message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("slack responded with HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(reply))
+	}
+	return nil
+}
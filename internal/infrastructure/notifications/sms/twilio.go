@@ -0,0 +1,110 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+const twilioAPIBase = "https://api.twilio.com/2010-04-01"
+
+// TwilioProvider delivers SMS through the Twilio Messages API
+type TwilioProvider struct {
+	accountSID     string
+	authToken      string
+	fromNumber     string
+	costPerSegment float64
+	httpClient     *http.Client
+}
+
+// TwilioConfig configures the Twilio provider
+type TwilioConfig struct {
+	AccountSID     string
+	AuthToken      string
+	FromNumber     string
+	CostPerSegment float64 // used when Twilio has not priced the message yet
+}
+
+func NewTwilioProvider(config TwilioConfig) (*TwilioProvider, error) {
+	if config.AccountSID == "" || config.AuthToken == "" || config.FromNumber == "" {
+		return nil, fmt.Errorf("twilio account SID, auth token and from number are required")
+	}
+
+	return &TwilioProvider{
+		accountSID:     config.AccountSID,
+		authToken:      config.AuthToken,
+		fromNumber:     config.FromNumber,
+		costPerSegment: config.CostPerSegment,
+		httpClient:     &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (p *TwilioProvider) SendSMS(ctx context.Context, to, body string) (*services.SMSResult, error) {
+	form := url.Values{
+		"To":   {to},
+		"From": {p.fromNumber},
+		"Body": {body},
+	}
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioAPIBase, p.accountSID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create twilio request: %w", err)
+	}
+	req.SetBasicAuth(p.accountSID, p.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send sms: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var message struct {
+		SID         string  `json:"sid"`
+		NumSegments string  `json:"num_segments"`
+		Price       *string `json:"price"`
+		PriceUnit   string  `json:"price_unit"`
+		Code        int     `json:"code"`
+		Message     string  `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		return nil, fmt.Errorf("failed to decode twilio response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("twilio returned %d (code %d): %s", resp.StatusCode, message.Code, message.Message)
+	}
+
+	segments, err := strconv.Atoi(message.NumSegments)
+	if err != nil || segments < 1 {
+		segments = 1
+	}
+
+	result := &services.SMSResult{
+		MessageID: message.SID,
+		Segments:  segments,
+		Cost:      float64(segments) * p.costPerSegment,
+		Currency:  "USD",
+	}
+
+	// Twilio reports prices as negative amounts once the message is rated
+	if message.Price != nil {
+		if price, err := strconv.ParseFloat(*message.Price, 64); err == nil {
+			result.Cost = math.Abs(price)
+			if message.PriceUnit != "" {
+				result.Currency = strings.ToUpper(message.PriceUnit)
+			}
+		}
+	}
+
+	return result, nil
+}
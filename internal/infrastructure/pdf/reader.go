@@ -0,0 +1,812 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Just enough of PDF to find the pages of a document and read their
+// dictionaries: classic cross-reference tables, cross-reference streams,
+// object streams and Flate-compressed data.
+
+var (
+	ErrMalformed   = errors.New("malformed PDF")
+	ErrUnsupported = errors.New("unsupported PDF feature")
+)
+
+// maxDecodedStream bounds decompressed cross-reference and object streams
+const maxDecodedStream = 64 << 20
+
+// PDF values: nil, bool, int64, float64, pdfString, name, array, dict,
+// ref and, at the top level of an object only, *stream
+type (
+	name      string
+	pdfString string
+	array     []interface{}
+	dict      map[name]interface{}
+)
+
+type ref struct {
+	num, gen int
+}
+
+type stream struct {
+	dict dict
+	data []byte // As stored, still filtered
+}
+
+// xrefEntry locates an object: at an offset in the file, or as the index'th
+// object of an object stream
+type xrefEntry struct {
+	free       bool
+	offset     int64
+	gen        int
+	compressed bool
+	objStream  int
+	index      int
+}
+
+// document is a parsed PDF file
+type document struct {
+	data       []byte
+	xref       map[int]xrefEntry
+	trailer    dict  // Of the latest update
+	startxref  int64 // Of the latest update
+	xrefStream bool  // The latest update uses a cross-reference stream
+	objects    map[int]interface{}
+}
+
+func openDocument(data []byte) (*document, error) {
+	doc := &document{
+		data:    data,
+		xref:    make(map[int]xrefEntry),
+		objects: make(map[int]interface{}),
+	}
+
+	tail := data
+	if len(tail) > 2048 {
+		tail = tail[len(tail)-2048:]
+	}
+	at := bytes.LastIndex(tail, []byte("startxref"))
+	if at < 0 {
+		return nil, fmt.Errorf("%w: no startxref", ErrMalformed)
+	}
+	lex := &lexer{data: tail, pos: at + len("startxref")}
+	startxref, ok := lex.next().(int64)
+	if !ok {
+		return nil, fmt.Errorf("%w: bad startxref", ErrMalformed)
+	}
+	doc.startxref = startxref
+
+	// Sections are read newest first; older entries never replace newer ones
+	visited := map[int64]bool{}
+	for offset, first := startxref, true; ; first = false {
+		if offset <= 0 || offset >= int64(len(data)) || visited[offset] {
+			if first {
+				return nil, fmt.Errorf("%w: bad cross-reference offset", ErrMalformed)
+			}
+			break
+		}
+		visited[offset] = true
+
+		trailer, isStream, err := doc.readXrefSection(offset)
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			doc.trailer = trailer
+			doc.xrefStream = isStream
+		}
+
+		// Hybrid files point their table at a stream of the compressed objects
+		if extra, ok := trailer["XRefStm"].(int64); ok && !isStream {
+			if _, _, err := doc.readXrefSection(extra); err != nil {
+				return nil, err
+			}
+		}
+
+		prev, ok := trailer["Prev"].(int64)
+		if !ok {
+			break
+		}
+		offset = prev
+	}
+
+	if _, ok := doc.trailer["Root"].(ref); !ok {
+		return nil, fmt.Errorf("%w: trailer has no root", ErrMalformed)
+	}
+	return doc, nil
+}
+
+// readXrefSection reads the table or stream at offset into the
+// cross-reference, returning its trailer
+func (d *document) readXrefSection(offset int64) (dict, bool, error) {
+	lex := &lexer{data: d.data, pos: int(offset)}
+	lex.skipSpace()
+	if bytes.HasPrefix(d.data[lex.pos:], []byte("xref")) {
+		lex.pos += len("xref")
+		trailer, err := d.readXrefTable(lex)
+		return trailer, false, err
+	}
+
+	_, value, err := lex.indirectObject(d)
+	if err != nil {
+		return nil, false, err
+	}
+	xrefStream, ok := value.(*stream)
+	if !ok || xrefStream.dict["Type"] != name("XRef") {
+		return nil, false, fmt.Errorf("%w: no cross-reference at %d", ErrMalformed, offset)
+	}
+	return xrefStream.dict, true, d.readXrefStream(xrefStream)
+}
+
+func (d *document) readXrefTable(lex *lexer) (dict, error) {
+	for {
+		token := lex.next()
+		if token == keyword("trailer") {
+			value, err := lex.value()
+			trailer, ok := value.(dict)
+			if err != nil || !ok {
+				return nil, fmt.Errorf("%w: bad trailer", ErrMalformed)
+			}
+			return trailer, nil
+		}
+
+		start, ok := token.(int64)
+		count, ok2 := lex.next().(int64)
+		if !ok || !ok2 || start < 0 || count < 0 {
+			return nil, fmt.Errorf("%w: bad cross-reference table", ErrMalformed)
+		}
+		for i := int64(0); i < count; i++ {
+			offset, ok := lex.next().(int64)
+			gen, ok2 := lex.next().(int64)
+			kind := lex.next()
+			if !ok || !ok2 || (kind != keyword("n") && kind != keyword("f")) {
+				return nil, fmt.Errorf("%w: bad cross-reference entry", ErrMalformed)
+			}
+			num := int(start + i)
+			if _, seen := d.xref[num]; !seen {
+				d.xref[num] = xrefEntry{free: kind == keyword("f"), offset: offset, gen: int(gen)}
+			}
+		}
+	}
+}
+
+func (d *document) readXrefStream(xrefStream *stream) error {
+	data, err := d.decode(xrefStream)
+	if err != nil {
+		return err
+	}
+
+	widths, ok := xrefStream.dict["W"].(array)
+	if !ok || len(widths) != 3 {
+		return fmt.Errorf("%w: bad cross-reference stream widths", ErrMalformed)
+	}
+	var w [3]int
+	rowSize := 0
+	for i, width := range widths {
+		n, ok := width.(int64)
+		if !ok || n < 0 || n > 8 {
+			return fmt.Errorf("%w: bad cross-reference stream widths", ErrMalformed)
+		}
+		w[i] = int(n)
+		rowSize += int(n)
+	}
+	if rowSize == 0 {
+		return fmt.Errorf("%w: bad cross-reference stream widths", ErrMalformed)
+	}
+
+	index, _ := xrefStream.dict["Index"].(array)
+	if index == nil {
+		size, _ := xrefStream.dict["Size"].(int64)
+		index = array{int64(0), size}
+	}
+
+	row := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, ok := index[i].(int64)
+		count, ok2 := index[i+1].(int64)
+		if !ok || !ok2 {
+			return fmt.Errorf("%w: bad cross-reference stream index", ErrMalformed)
+		}
+		for j := int64(0); j < count; j++ {
+			if (row+1)*rowSize > len(data) {
+				return fmt.Errorf("%w: short cross-reference stream", ErrMalformed)
+			}
+			fields := data[row*rowSize : (row+1)*rowSize]
+			row++
+
+			kind := int64(1) // Type defaults to 1 when its width is 0
+			if w[0] > 0 {
+				kind = bigEndian(fields[:w[0]])
+			}
+			second := bigEndian(fields[w[0] : w[0]+w[1]])
+			third := bigEndian(fields[w[0]+w[1]:])
+
+			num := int(start + j)
+			if _, seen := d.xref[num]; seen {
+				continue
+			}
+			switch kind {
+			case 0:
+				d.xref[num] = xrefEntry{free: true}
+			case 1:
+				d.xref[num] = xrefEntry{offset: second, gen: int(third)}
+			case 2:
+				d.xref[num] = xrefEntry{compressed: true, objStream: int(second), index: int(third)}
+			}
+		}
+	}
+	return nil
+}
+
+// resolve follows references to the object they point at; missing objects
+// are null
+func (d *document) resolve(value interface{}) (interface{}, error) {
+	for depth := 0; depth < 32; depth++ {
+		r, ok := value.(ref)
+		if !ok {
+			return value, nil
+		}
+		object, err := d.object(r.num)
+		if err != nil {
+			return nil, err
+		}
+		value = object
+	}
+	return nil, fmt.Errorf("%w: reference loop", ErrMalformed)
+}
+
+func (d *document) resolveDict(value interface{}) (dict, error) {
+	resolved, err := d.resolve(value)
+	if err != nil {
+		return nil, err
+	}
+	switch v := resolved.(type) {
+	case dict:
+		return v, nil
+	case *stream:
+		return v.dict, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%w: expected a dictionary", ErrMalformed)
+}
+
+func (d *document) object(num int) (interface{}, error) {
+	if object, ok := d.objects[num]; ok {
+		return object, nil
+	}
+
+	entry, ok := d.xref[num]
+	if !ok || entry.free {
+		return nil, nil
+	}
+
+	var object interface{}
+	if entry.compressed {
+		objects, err := d.objectStream(entry.objStream)
+		if err != nil {
+			return nil, err
+		}
+		if entry.index < 0 || entry.index >= len(objects) {
+			return nil, fmt.Errorf("%w: object %d not in its object stream", ErrMalformed, num)
+		}
+		object = objects[entry.index]
+	} else {
+		if entry.offset <= 0 || entry.offset >= int64(len(d.data)) {
+			return nil, fmt.Errorf("%w: object %d out of range", ErrMalformed, num)
+		}
+		lex := &lexer{data: d.data, pos: int(entry.offset)}
+		header, value, err := lex.indirectObject(d)
+		if err != nil {
+			return nil, err
+		}
+		if header.num != num {
+			return nil, fmt.Errorf("%w: expected object %d at %d, found %d", ErrMalformed, num, entry.offset, header.num)
+		}
+		object = value
+	}
+
+	d.objects[num] = object
+	return object, nil
+}
+
+// objectStream parses the objects compressed into an object stream
+func (d *document) objectStream(num int) ([]interface{}, error) {
+	entry, ok := d.xref[num]
+	if !ok || entry.free || entry.compressed {
+		return nil, fmt.Errorf("%w: bad object stream %d", ErrMalformed, num)
+	}
+	value, err := d.object(num)
+	if err != nil {
+		return nil, err
+	}
+	objStream, ok := value.(*stream)
+	if !ok {
+		return nil, fmt.Errorf("%w: object stream %d is not a stream", ErrMalformed, num)
+	}
+
+	data, err := d.decode(objStream)
+	if err != nil {
+		return nil, err
+	}
+	count, _ := objStream.dict["N"].(int64)
+	first, _ := objStream.dict["First"].(int64)
+	if count < 0 || first < 0 || first > int64(len(data)) {
+		return nil, fmt.Errorf("%w: bad object stream %d", ErrMalformed, num)
+	}
+
+	header := &lexer{data: data[:first]}
+	offsets := make([]int64, count)
+	for i := range offsets {
+		header.next() // Object number
+		offset, ok := header.next().(int64)
+		if !ok || first+offset > int64(len(data)) {
+			return nil, fmt.Errorf("%w: bad object stream %d", ErrMalformed, num)
+		}
+		offsets[i] = offset
+	}
+
+	objects := make([]interface{}, count)
+	for i, offset := range offsets {
+		lex := &lexer{data: data, pos: int(first + offset)}
+		object, err := lex.value()
+		if err != nil {
+			return nil, err
+		}
+		objects[i] = object
+	}
+	return objects, nil
+}
+
+// decode undoes a stream's filters; only Flate, with or without PNG
+// predictors, is supported
+func (d *document) decode(s *stream) ([]byte, error) {
+	filter, err := d.resolve(s.dict["Filter"])
+	if err != nil {
+		return nil, err
+	}
+	if filters, ok := filter.(array); ok {
+		if len(filters) > 1 {
+			return nil, fmt.Errorf("%w: chained stream filters", ErrUnsupported)
+		}
+		filter = nil
+		if len(filters) == 1 {
+			filter = filters[0]
+		}
+	}
+
+	switch filter {
+	case nil:
+		return s.data, nil
+	case name("FlateDecode"):
+	default:
+		return nil, fmt.Errorf("%w: %v stream filter", ErrUnsupported, filter)
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(s.data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, maxDecodedStream+1))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if len(data) > maxDecodedStream {
+		return nil, fmt.Errorf("%w: stream too large", ErrUnsupported)
+	}
+
+	params, err := d.resolveDict(s.dict["DecodeParms"])
+	if err != nil {
+		return nil, err
+	}
+	predictor, _ := params["Predictor"].(int64)
+	if predictor <= 1 {
+		return data, nil
+	}
+	if predictor < 10 {
+		return nil, fmt.Errorf("%w: TIFF predictor", ErrUnsupported)
+	}
+	columns, ok := params["Columns"].(int64)
+	if !ok {
+		columns = 1
+	}
+	return unpredictPNG(data, int(columns))
+}
+
+// unpredictPNG reverses PNG row filters, each row led by its filter type
+func unpredictPNG(data []byte, columns int) ([]byte, error) {
+	if columns <= 0 {
+		return nil, fmt.Errorf("%w: bad predictor columns", ErrMalformed)
+	}
+	rowSize := columns + 1
+	out := make([]byte, 0, len(data)/rowSize*columns)
+	prior := make([]byte, columns)
+	for start := 0; start+rowSize <= len(data); start += rowSize {
+		filter, row := data[start], append([]byte(nil), data[start+1:start+rowSize]...)
+		for i := range row {
+			var left, upLeft byte
+			if i > 0 {
+				left, upLeft = row[i-1], prior[i-1]
+			}
+			up := prior[i]
+			switch filter {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			default:
+				return nil, fmt.Errorf("%w: bad PNG filter %d", ErrMalformed, filter)
+			}
+		}
+		out = append(out, row...)
+		prior = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func bigEndian(b []byte) int64 {
+	var n int64
+	for _, c := range b {
+		n = n<<8 | int64(c)
+	}
+	return n
+}
+
+// Lexing and parsing
+
+// keyword is a bare word: obj, endobj, stream, R, true, trailer and so on
+type keyword string
+
+// delimiter is one of the structural tokens: << >> [ ]
+type delimiter string
+
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isSpace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isDelimiter(c byte) bool {
+	return c == '(' || c == ')' || c == '<' || c == '>' || c == '[' || c == ']' || c == '{' || c == '}' || c == '/' || c == '%'
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// next returns the next token: a number, name, string, delimiter or
+// keyword, or nil at the end of the data
+func (l *lexer) next() interface{} {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		return name(unescapeName(l.data[start:l.pos]))
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return delimiter("<<")
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return delimiter(">>")
+	case c == '[' || c == ']':
+		l.pos++
+		return delimiter(string(c))
+	case c == '<':
+		return l.hexString()
+	case c == '(':
+		return l.literalString()
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.number()
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		l.pos++ // A stray delimiter
+	}
+	return keyword(l.data[start:l.pos])
+}
+
+func (l *lexer) number() interface{} {
+	start := l.pos
+	real := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '.' {
+			real = true
+		} else if !(c >= '0' && c <= '9') && !((c == '+' || c == '-') && l.pos == start) {
+			break
+		}
+		l.pos++
+	}
+	text := string(l.data[start:l.pos])
+	if !real {
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n
+		}
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return float64(0)
+	}
+	return f
+}
+
+func (l *lexer) hexString() interface{} {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		n, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(n)
+	}
+	return pdfString(out)
+}
+
+func (l *lexer) literalString() interface{} {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(out)
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				continue
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					n := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						n = n*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(n)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return pdfString(out)
+}
+
+func unescapeName(raw []byte) string {
+	if !bytes.Contains(raw, []byte("#")) {
+		return string(raw)
+	}
+	var out []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			if n, err := strconv.ParseUint(string(raw[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(n))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, raw[i])
+	}
+	return string(out)
+}
+
+// value parses one direct value
+func (l *lexer) value() (interface{}, error) {
+	token := l.next()
+	switch t := token.(type) {
+	case nil:
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrMalformed)
+	case int64:
+		// "num gen R" is a reference
+		save := l.pos
+		if gen, ok := l.next().(int64); ok {
+			if l.next() == keyword("R") {
+				return ref{num: int(t), gen: int(gen)}, nil
+			}
+		}
+		l.pos = save
+		return t, nil
+	case delimiter:
+		switch t {
+		case "<<":
+			d := dict{}
+			for {
+				l.skipSpace()
+				if bytes.HasPrefix(l.data[l.pos:], []byte(">>")) {
+					l.pos += 2
+					return d, nil
+				}
+				key, ok := l.next().(name)
+				if !ok {
+					return nil, fmt.Errorf("%w: dictionary key is not a name", ErrMalformed)
+				}
+				value, err := l.value()
+				if err != nil {
+					return nil, err
+				}
+				d[key] = value
+			}
+		case "[":
+			a := array{}
+			for {
+				l.skipSpace()
+				if l.pos < len(l.data) && l.data[l.pos] == ']' {
+					l.pos++
+					return a, nil
+				}
+				value, err := l.value()
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, value)
+			}
+		}
+		return nil, fmt.Errorf("%w: unexpected %s", ErrMalformed, t)
+	case keyword:
+		switch t {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: unexpected %q", ErrMalformed, string(t))
+	}
+	return token, nil
+}
+
+// indirectObject parses "num gen obj value [stream] endobj"
+func (l *lexer) indirectObject(doc *document) (ref, interface{}, error) {
+	num, ok := l.next().(int64)
+	gen, ok2 := l.next().(int64)
+	if !ok || !ok2 || l.next() != keyword("obj") {
+		return ref{}, nil, fmt.Errorf("%w: expected an object at %d", ErrMalformed, l.pos)
+	}
+	header := ref{num: int(num), gen: int(gen)}
+
+	value, err := l.value()
+	if err != nil {
+		return ref{}, nil, err
+	}
+
+	save := l.pos
+	if l.next() != keyword("stream") {
+		l.pos = save
+		return header, value, nil
+	}
+	streamDict, ok := value.(dict)
+	if !ok {
+		return ref{}, nil, fmt.Errorf("%w: stream without a dictionary", ErrMalformed)
+	}
+
+	// The data starts after the end of line following the keyword
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+	start := l.pos
+
+	length := int64(-1)
+	if doc != nil {
+		if resolved, err := doc.resolve(streamDict["Length"]); err == nil {
+			if n, ok := resolved.(int64); ok {
+				length = n
+			}
+		}
+	} else if n, ok := streamDict["Length"].(int64); ok {
+		length = n
+	}
+
+	end := start + int(length)
+	if length < 0 || end > len(l.data) || !bytes.HasPrefix(bytes.TrimLeft(l.data[end:], "\r\n \t"), []byte("endstream")) {
+		// A wrong length: fall back to the endstream keyword
+		at := bytes.Index(l.data[start:], []byte("endstream"))
+		if at < 0 {
+			return ref{}, nil, fmt.Errorf("%w: unterminated stream", ErrMalformed)
+		}
+		end = start + at
+		for end > start && (l.data[end-1] == '\n' || l.data[end-1] == '\r') {
+			end--
+		}
+	}
+	l.pos = end
+	return header, &stream{dict: streamDict, data: l.data[start:end]}, nil
+}
@@ -0,0 +1,612 @@
+// Package pdf stamps PDF documents, drawing a box of text over their pages
+// as an incremental update: the original bytes are kept as they are and the
+// changed pages are appended after them.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/services"
+)
+
+var ErrEncrypted = errors.New("encrypted PDFs can't be stamped")
+
+// Stamp layout, in points
+const (
+	titleSize   = 18.0
+	lineSize    = 9.0
+	lineLeading = lineSize * 1.3
+	padding     = 8.0
+	margin      = 24.0
+	borderWidth = 2.0
+	ascent      = 0.72 // Of Helvetica, as a fraction of the font size
+	descent     = 0.22
+)
+
+// stampColor is the border and text colour, as RGB
+const stampColor = "0.78 0.1 0.1"
+
+// Stamper draws stamps onto PDFs with the standard Helvetica Bold font,
+// which every viewer has, so nothing needs embedding
+type Stamper struct{}
+
+func NewStamper() *Stamper {
+	return &Stamper{}
+}
+
+// Stamp draws the stamp onto the selected pages, returning the stamped PDF
+func (s *Stamper) Stamp(ctx context.Context, content []byte, stamp services.PDFStamp) ([]byte, error) {
+	if len(stamp.Lines) == 0 {
+		return nil, fmt.Errorf("stamp has no text")
+	}
+
+	doc, err := openDocument(content)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, ErrEncrypted
+	}
+
+	pages, err := doc.pages()
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("%w: document has no pages", ErrMalformed)
+	}
+	switch stamp.Pages {
+	case "", services.StampPagesFirst:
+		pages = pages[:1]
+	case services.StampPagesLast:
+		pages = pages[len(pages)-1:]
+	case services.StampPagesAll:
+	default:
+		return nil, fmt.Errorf("unknown stamp pages %q", stamp.Pages)
+	}
+
+	size, ok := doc.trailer["Size"].(int64)
+	if !ok || size <= 0 {
+		return nil, fmt.Errorf("%w: trailer has no size", ErrMalformed)
+	}
+	update := &incrementalUpdate{doc: doc, next: int(size), objects: map[int]updatedObject{}}
+
+	font := update.add(dict{
+		"Type":     name("Font"),
+		"Subtype":  name("Type1"),
+		"BaseFont": name("Helvetica-Bold"),
+		"Encoding": name("WinAnsiEncoding"),
+	}, nil)
+	// Saves the graphics state before the page's own content, so the stamp
+	// is drawn in default coordinates whatever that content leaves behind
+	save := update.add(dict{}, []byte("q\n"))
+
+	for _, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		resources, fontName, err := page.resourcesWithFont(doc, font)
+		if err != nil {
+			return nil, err
+		}
+		drawing := update.add(dict{}, stampContent(page, stamp, fontName))
+
+		contents := array{save}
+		existing, err := doc.resolve(page.dict["Contents"])
+		if err != nil {
+			return nil, err
+		}
+		switch c := existing.(type) {
+		case array:
+			contents = append(contents, c...)
+		case *stream:
+			contents = append(contents, page.dict["Contents"])
+		}
+		contents = append(contents, drawing)
+
+		updated := make(dict, len(page.dict)+2)
+		for key, value := range page.dict {
+			updated[key] = value
+		}
+		updated["Contents"] = contents
+		updated["Resources"] = resources
+		update.replace(page.ref, updated)
+	}
+
+	return update.write()
+}
+
+// Pages
+
+type page struct {
+	ref       ref
+	dict      dict
+	resources interface{} // Inherited when not on the page itself
+	box       [4]float64  // The crop box, or media box when there is none
+	rotate    int
+}
+
+// inherited are the page attributes a page tree node passes down
+type inherited struct {
+	resources interface{}
+	mediaBox  interface{}
+	cropBox   interface{}
+	rotate    interface{}
+}
+
+func (d *document) pages() ([]*page, error) {
+	catalog, err := d.resolveDict(d.trailer["Root"])
+	if err != nil || catalog == nil {
+		return nil, fmt.Errorf("%w: no document catalog", ErrMalformed)
+	}
+	root, ok := catalog["Pages"].(ref)
+	if !ok {
+		return nil, fmt.Errorf("%w: no page tree", ErrMalformed)
+	}
+
+	var pages []*page
+	visited := map[int]bool{}
+	var walk func(node ref, attrs inherited, depth int) error
+	walk = func(node ref, attrs inherited, depth int) error {
+		if depth > 64 || visited[node.num] {
+			return fmt.Errorf("%w: page tree loop", ErrMalformed)
+		}
+		visited[node.num] = true
+
+		nodeDict, err := d.resolveDict(node)
+		if err != nil {
+			return err
+		}
+		if nodeDict == nil {
+			return nil
+		}
+		if value, ok := nodeDict["Resources"]; ok {
+			attrs.resources = value
+		}
+		if value, ok := nodeDict["MediaBox"]; ok {
+			attrs.mediaBox = value
+		}
+		if value, ok := nodeDict["CropBox"]; ok {
+			attrs.cropBox = value
+		}
+		if value, ok := nodeDict["Rotate"]; ok {
+			attrs.rotate = value
+		}
+
+		if kids, ok := nodeDict["Kids"]; ok || nodeDict["Type"] == name("Pages") {
+			kidArray, err := d.resolve(kids)
+			if err != nil {
+				return err
+			}
+			list, _ := kidArray.(array)
+			for _, kid := range list {
+				kidRef, ok := kid.(ref)
+				if !ok {
+					return fmt.Errorf("%w: page tree kid is not a reference", ErrMalformed)
+				}
+				if err := walk(kidRef, attrs, depth+1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		p := &page{ref: node, dict: nodeDict, resources: attrs.resources, box: [4]float64{0, 0, 612, 792}}
+		if box, ok := d.rectangle(attrs.mediaBox); ok {
+			p.box = box
+		}
+		if box, ok := d.rectangle(attrs.cropBox); ok {
+			p.box = box
+		}
+		rotate, _ := d.resolve(attrs.rotate)
+		if degrees, ok := rotate.(int64); ok {
+			p.rotate = int(((degrees%360)+360)%360) / 90 * 90
+		}
+		pages = append(pages, p)
+		return nil
+	}
+
+	if err := walk(root, inherited{}, 0); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// rectangle reads a rectangle, normalised so the lower left corner is first
+func (d *document) rectangle(value interface{}) ([4]float64, bool) {
+	resolved, err := d.resolve(value)
+	if err != nil {
+		return [4]float64{}, false
+	}
+	corners, ok := resolved.(array)
+	if !ok || len(corners) != 4 {
+		return [4]float64{}, false
+	}
+	var r [4]float64
+	for i, corner := range corners {
+		n, err := d.resolve(corner)
+		if err != nil {
+			return [4]float64{}, false
+		}
+		switch v := n.(type) {
+		case int64:
+			r[i] = float64(v)
+		case float64:
+			r[i] = v
+		default:
+			return [4]float64{}, false
+		}
+	}
+	return [4]float64{
+		math.Min(r[0], r[2]), math.Min(r[1], r[3]),
+		math.Max(r[0], r[2]), math.Max(r[1], r[3]),
+	}, true
+}
+
+// resourcesWithFont copies the page's resources with the stamp font added
+// under a name the page doesn't use, returning that name
+func (p *page) resourcesWithFont(doc *document, font ref) (dict, name, error) {
+	resources, err := doc.resolveDict(p.resources)
+	if err != nil {
+		return nil, "", err
+	}
+	fonts, err := doc.resolveDict(resources["Font"])
+	if err != nil {
+		return nil, "", err
+	}
+
+	fontName := name("ArchivusStamp")
+	for i := 1; fonts[fontName] != nil; i++ {
+		fontName = name("ArchivusStamp" + strconv.Itoa(i))
+	}
+
+	updatedFonts := dict{fontName: font}
+	for key, value := range fonts {
+		updatedFonts[key] = value
+	}
+	updated := dict{"Font": updatedFonts}
+	for key, value := range resources {
+		if key != "Font" {
+			updated[key] = value
+		}
+	}
+	return updated, fontName, nil
+}
+
+// Drawing
+
+// stampContent draws the stamp onto a page. It first restores the state
+// saved before the page's content, then works in the page as displayed:
+// rotated and with the origin at the lower left corner of the visible box.
+func stampContent(p *page, stamp services.PDFStamp, fontName name) []byte {
+	llx, lly := p.box[0], p.box[1]
+	width, height := p.box[2]-p.box[0], p.box[3]-p.box[1]
+	visibleWidth, visibleHeight := width, height
+	if p.rotate == 90 || p.rotate == 270 {
+		visibleWidth, visibleHeight = height, width
+	}
+
+	title, lines := winAnsi(stamp.Lines[0]), make([]string, 0, len(stamp.Lines)-1)
+	for _, line := range stamp.Lines[1:] {
+		lines = append(lines, winAnsi(line))
+	}
+
+	boxWidth := textWidth(title, titleSize)
+	for _, line := range lines {
+		boxWidth = math.Max(boxWidth, textWidth(line, lineSize))
+	}
+	boxWidth += 2 * padding
+	boxHeight := 2*padding + ascent*titleSize + descent*titleSize
+	if len(lines) > 0 {
+		boxHeight = 2*padding + ascent*titleSize + 6 + ascent*lineSize + float64(len(lines)-1)*lineLeading + descent*lineSize
+	}
+
+	// Shrunk to fit small pages
+	scale := math.Min(1, math.Min((visibleWidth-2*margin)/boxWidth, (visibleHeight-2*margin)/boxHeight))
+	if scale <= 0 {
+		scale = 1
+	}
+	scaledWidth, scaledHeight := boxWidth*scale, boxHeight*scale
+
+	x, y := visibleWidth-margin-scaledWidth, visibleHeight-margin-scaledHeight
+	switch stamp.Position {
+	case services.StampPositionTopLeft:
+		x = margin
+	case services.StampPositionBottomLeft:
+		x, y = margin, margin
+	case services.StampPositionBottomRight:
+		y = margin
+	}
+
+	var b strings.Builder
+	b.WriteString("Q q\n")
+	switch p.rotate {
+	case 90:
+		fmt.Fprintf(&b, "0 1 -1 0 %s %s cm\n", number(width+llx), number(lly))
+	case 180:
+		fmt.Fprintf(&b, "-1 0 0 -1 %s %s cm\n", number(width+llx), number(height+lly))
+	case 270:
+		fmt.Fprintf(&b, "0 -1 1 0 %s %s cm\n", number(llx), number(height+lly))
+	default:
+		fmt.Fprintf(&b, "1 0 0 1 %s %s cm\n", number(llx), number(lly))
+	}
+	fmt.Fprintf(&b, "%s 0 0 %s %s %s cm\n", number(scale), number(scale), number(x), number(y))
+
+	fmt.Fprintf(&b, "%s RG %s rg %s w\n", stampColor, stampColor, number(borderWidth))
+	fmt.Fprintf(&b, "%s %s %s %s re S\n", number(borderWidth/2), number(borderWidth/2),
+		number(boxWidth-borderWidth), number(boxHeight-borderWidth))
+
+	baseline := boxHeight - padding - ascent*titleSize
+	fmt.Fprintf(&b, "BT /%s %s Tf %s %s Td %s Tj ET\n", fontName, number(titleSize),
+		number(padding), number(baseline), literal(title))
+	baseline -= 6 + ascent*lineSize
+	for _, line := range lines {
+		fmt.Fprintf(&b, "BT /%s %s Tf %s %s Td %s Tj ET\n", fontName, number(lineSize),
+			number(padding), number(baseline), literal(line))
+		baseline -= lineLeading
+	}
+	b.WriteString("Q\n")
+	return []byte(b.String())
+}
+
+// winAnsi encodes text for the stamp font, replacing what it can't show
+func winAnsi(text string) string {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 0x20 && r <= 0x7e, r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		case winAnsiExtras[r] != 0:
+			out = append(out, winAnsiExtras[r])
+		case r == '\t' || r == '\n' || r == '\r':
+			out = append(out, ' ')
+		default:
+			out = append(out, '?')
+		}
+	}
+	return string(out)
+}
+
+// winAnsiExtras are the characters WinAnsiEncoding places in 0x80-0x9f
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// helveticaBoldWidths are the widths of the printable ASCII characters in
+// Helvetica Bold, from its font metrics, per 1000 units of font size
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 278, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611, // 0 to ?
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556, // P to _
+	278, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611, // ` to o
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584, // p to ~
+}
+
+func textWidth(encoded string, size float64) float64 {
+	units := 0
+	for i := 0; i < len(encoded); i++ {
+		c := encoded[i]
+		if c >= 0x20 && c <= 0x7e {
+			units += helveticaBoldWidths[c-0x20]
+		} else {
+			units += 611 // Near enough for accented letters and punctuation
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// Writing
+
+type updatedObject struct {
+	gen    int
+	value  interface{}
+	stream []byte
+}
+
+// incrementalUpdate collects new and replaced objects and appends them to
+// the original file with a cross-reference section chained to its own
+type incrementalUpdate struct {
+	doc     *document
+	next    int
+	objects map[int]updatedObject
+}
+
+// add adds a new object, a stream when data isn't nil
+func (u *incrementalUpdate) add(value dict, data []byte) ref {
+	r := ref{num: u.next}
+	u.next++
+	u.objects[r.num] = updatedObject{value: value, stream: data}
+	return r
+}
+
+func (u *incrementalUpdate) replace(r ref, value dict) {
+	u.objects[r.num] = updatedObject{gen: r.gen, value: value}
+}
+
+func (u *incrementalUpdate) write() ([]byte, error) {
+	var out bytes.Buffer
+	out.Write(u.doc.data)
+	if !bytes.HasSuffix(u.doc.data, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+
+	nums := make([]int, 0, len(u.objects)+1)
+	for num := range u.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	offsets := map[int]int64{}
+	for _, num := range nums {
+		object := u.objects[num]
+		offsets[num] = int64(out.Len())
+		fmt.Fprintf(&out, "%d %d obj\n", num, object.gen)
+		if object.stream != nil {
+			streamDict := object.value.(dict)
+			streamDict["Length"] = int64(len(object.stream))
+			writeValue(&out, streamDict)
+			out.WriteString("\nstream\n")
+			out.Write(object.stream)
+			out.WriteString("\nendstream")
+		} else {
+			writeValue(&out, object.value)
+		}
+		out.WriteString("\nendobj\n")
+	}
+
+	trailer := dict{"Prev": u.doc.startxref}
+	for _, key := range []name{"Root", "Info", "ID"} {
+		if value, ok := u.doc.trailer[key]; ok {
+			trailer[key] = value
+		}
+	}
+
+	if u.doc.xrefStream {
+		// Readers of a file that started with a cross-reference stream may
+		// not understand tables, so the update uses a stream too
+		xrefNum := u.next
+		u.next++
+		nums = append(nums, xrefNum)
+		offsets[xrefNum] = int64(out.Len())
+
+		var rows bytes.Buffer
+		for _, num := range nums {
+			offset := offsets[num]
+			if offset > math.MaxUint32 {
+				return nil, fmt.Errorf("%w: file too large", ErrUnsupported)
+			}
+			gen := u.objects[num].gen
+			rows.Write([]byte{1, byte(offset >> 24), byte(offset >> 16), byte(offset >> 8), byte(offset), byte(gen >> 8), byte(gen)})
+		}
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(rows.Bytes())
+		zw.Close()
+
+		trailer["Type"] = name("XRef")
+		trailer["Size"] = int64(u.next)
+		trailer["W"] = array{int64(1), int64(4), int64(2)}
+		trailer["Index"] = xrefIndex(nums)
+		trailer["Filter"] = name("FlateDecode")
+		trailer["Length"] = int64(compressed.Len())
+
+		fmt.Fprintf(&out, "%d 0 obj\n", xrefNum)
+		writeValue(&out, trailer)
+		out.WriteString("\nstream\n")
+		out.Write(compressed.Bytes())
+		out.WriteString("\nendstream\nendobj\n")
+	} else {
+		offsets[-1] = int64(out.Len())
+		out.WriteString("xref\n")
+		index := xrefIndex(nums)
+		for i, at := 0, 0; i < len(index); i += 2 {
+			start, count := index[i].(int64), index[i+1].(int64)
+			fmt.Fprintf(&out, "%d %d\n", start, count)
+			for j := int64(0); j < count; j++ {
+				num := nums[at]
+				at++
+				fmt.Fprintf(&out, "%010d %05d n\r\n", offsets[num], u.objects[num].gen)
+			}
+		}
+		trailer["Size"] = int64(u.next)
+		out.WriteString("trailer\n")
+		writeValue(&out, trailer)
+		out.WriteString("\n")
+	}
+
+	xrefOffset := offsets[nums[len(nums)-1]]
+	if !u.doc.xrefStream {
+		xrefOffset = offsets[-1]
+	}
+	fmt.Fprintf(&out, "startxref\n%d\n%%%%EOF\n", xrefOffset)
+	return out.Bytes(), nil
+}
+
+// xrefIndex groups sorted object numbers into runs, as start and count pairs
+func xrefIndex(nums []int) array {
+	var index array
+	for i := 0; i < len(nums); {
+		j := i + 1
+		for j < len(nums) && nums[j] == nums[j-1]+1 {
+			j++
+		}
+		index = append(index, int64(nums[i]), int64(j-i))
+		i = j
+	}
+	return index
+}
+
+func writeValue(out *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		out.WriteString("null")
+	case bool:
+		out.WriteString(strconv.FormatBool(v))
+	case int64:
+		out.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		out.WriteString(number(v))
+	case pdfString:
+		fmt.Fprintf(out, "<%x>", string(v))
+	case name:
+		out.WriteByte('/')
+		for i := 0; i < len(v); i++ {
+			c := v[i]
+			if c <= ' ' || c >= 0x7f || c == '#' || isDelimiter(c) {
+				fmt.Fprintf(out, "#%02x", c)
+			} else {
+				out.WriteByte(c)
+			}
+		}
+	case ref:
+		fmt.Fprintf(out, "%d %d R", v.num, v.gen)
+	case array:
+		out.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				out.WriteByte(' ')
+			}
+			writeValue(out, item)
+		}
+		out.WriteByte(']')
+	case dict:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, string(key))
+		}
+		sort.Strings(keys)
+		out.WriteString("<<")
+		for _, key := range keys {
+			out.WriteByte(' ')
+			writeValue(out, name(key))
+			out.WriteByte(' ')
+			writeValue(out, v[name(key)])
+		}
+		out.WriteString(" >>")
+	}
+}
+
+// number formats a number for content streams and objects, which don't
+// allow exponents
+func number(f float64) string {
+	return strconv.FormatFloat(math.Round(f*1000)/1000, 'f', -1, 64)
+}
+
+// literal quotes text as a PDF literal string
+func literal(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return "(" + replacer.Replace(text) + ")"
+}
@@ -0,0 +1,221 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pageContent = "BT /F1 12 Tf 72 720 Td (Hello) Tj ET"
+
+// classicPDF builds a one page PDF with a cross-reference table
+func classicPDF() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(pageContent), pageContent),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f\r\n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n\r\n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// compressedPDF builds a two page PDF with its pages in an object stream
+// and a predicted cross-reference stream, the second page rotated
+func compressedPDF(t *testing.T) []byte {
+	compressed := []string{
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Rotate 90 >>",
+	}
+	nums := []int{3, 5, 7}
+	var header, body bytes.Buffer
+	for i, object := range compressed {
+		fmt.Fprintf(&header, "%d %d ", nums[i], body.Len())
+		body.WriteString(object + "\n")
+	}
+	objStream := deflate(t, append(header.Bytes(), body.Bytes()...))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.5\n")
+	offsets := map[int]int{}
+	write := func(num int, object string) {
+		offsets[num] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", num, object)
+	}
+	write(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	write(2, "<< /Type /Pages /Kids [3 0 R 7 0 R] /Count 2 /MediaBox [0 0 595 842] >>")
+	write(4, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(pageContent), pageContent))
+	write(6, fmt.Sprintf("<< /Type /ObjStm /N 3 /First %d /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+		header.Len(), len(objStream), objStream))
+
+	// Rows of type, 2 byte offset and index, each led by the PNG Up filter
+	xref := out.Len()
+	rows := [][]byte{
+		{0, 0, 0, 0},
+		{1, byte(offsets[1] >> 8), byte(offsets[1]), 0},
+		{1, byte(offsets[2] >> 8), byte(offsets[2]), 0},
+		{2, 0, 6, 0},
+		{1, byte(offsets[4] >> 8), byte(offsets[4]), 0},
+		{2, 0, 6, 1},
+		{1, byte(offsets[6] >> 8), byte(offsets[6]), 0},
+		{2, 0, 6, 2},
+		{1, byte(xref >> 8), byte(xref), 0},
+	}
+	var predicted []byte
+	prior := make([]byte, 4)
+	for _, row := range rows {
+		predicted = append(predicted, 2)
+		for i := range row {
+			predicted = append(predicted, row[i]-prior[i])
+		}
+		prior = row
+	}
+	xrefData := deflate(t, predicted)
+	fmt.Fprintf(&out, "8 0 obj\n<< /Type /XRef /Size 9 /Root 1 0 R /W [1 2 1] /Filter /FlateDecode "+
+		"/DecodeParms << /Predictor 12 /Columns 4 >> /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(xrefData), xrefData)
+	fmt.Fprintf(&out, "startxref\n%d\n%%%%EOF\n", xref)
+	return out.Bytes()
+}
+
+func deflate(t *testing.T, data []byte) []byte {
+	var out bytes.Buffer
+	w := zlib.NewWriter(&out)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return out.Bytes()
+}
+
+// pageContents reads back the decoded content streams of each page
+func pageContents(t *testing.T, data []byte) [][]string {
+	doc, err := openDocument(data)
+	require.NoError(t, err)
+	pages, err := doc.pages()
+	require.NoError(t, err)
+
+	var all [][]string
+	for _, p := range pages {
+		contents, err := doc.resolve(p.dict["Contents"])
+		require.NoError(t, err)
+		list, ok := contents.(array)
+		if !ok {
+			list = array{p.dict["Contents"]}
+		}
+		var streams []string
+		for _, item := range list {
+			value, err := doc.resolve(item)
+			require.NoError(t, err)
+			s, ok := value.(*stream)
+			require.True(t, ok)
+			decoded, err := doc.decode(s)
+			require.NoError(t, err)
+			streams = append(streams, string(decoded))
+		}
+		all = append(all, streams)
+	}
+	return all
+}
+
+func TestStamp(t *testing.T) {
+	stamper := NewStamper()
+	ctx := context.Background()
+	stamp := services.PDFStamp{Lines: []string{"APPROVED", "By Ada Lovelace (Finance)", "2026-10-16 14:05 UTC"}}
+
+	t.Run("cross-reference table", func(t *testing.T) {
+		original := classicPDF()
+		stamped, err := stamper.Stamp(ctx, original, stamp)
+		require.NoError(t, err)
+
+		// An incremental update keeps the original bytes
+		assert.True(t, bytes.HasPrefix(stamped, original))
+
+		contents := pageContents(t, stamped)
+		require.Len(t, contents, 1)
+		require.Len(t, contents[0], 3)
+		assert.Equal(t, "q\n", contents[0][0])
+		assert.Equal(t, pageContent, contents[0][1])
+		assert.Contains(t, contents[0][2], "(APPROVED) Tj")
+		assert.Contains(t, contents[0][2], `(By Ada Lovelace \(Finance\)) Tj`)
+
+		// The page keeps its own font next to the stamp's
+		doc, err := openDocument(stamped)
+		require.NoError(t, err)
+		pages, err := doc.pages()
+		require.NoError(t, err)
+		resources, err := doc.resolveDict(pages[0].resources)
+		require.NoError(t, err)
+		fonts := resources["Font"].(dict)
+		assert.Contains(t, fonts, name("F1"))
+		assert.Contains(t, fonts, name("ArchivusStamp"))
+
+		// A stamped PDF can be stamped again
+		restamped, err := stamper.Stamp(ctx, stamped, services.PDFStamp{Lines: []string{"APPROVED"}})
+		require.NoError(t, err)
+		assert.Len(t, pageContents(t, restamped)[0], 5)
+	})
+
+	t.Run("cross-reference stream", func(t *testing.T) {
+		original := compressedPDF(t)
+		stamped, err := stamper.Stamp(ctx, original, services.PDFStamp{
+			Lines:    stamp.Lines,
+			Position: services.StampPositionBottomLeft,
+			Pages:    services.StampPagesAll,
+		})
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(stamped, original))
+
+		doc, err := openDocument(stamped)
+		require.NoError(t, err)
+		assert.True(t, doc.xrefStream)
+
+		contents := pageContents(t, stamped)
+		require.Len(t, contents, 2)
+		for _, page := range contents {
+			require.Len(t, page, 3)
+			assert.Contains(t, page[2], "(APPROVED) Tj")
+		}
+		// The rotated page is drawn on turned back upright
+		assert.True(t, strings.Contains(contents[1][2], "0 1 -1 0 595 0 cm"), contents[1][2])
+	})
+
+	t.Run("last page only", func(t *testing.T) {
+		stamped, err := stamper.Stamp(ctx, compressedPDF(t), services.PDFStamp{Lines: []string{"APPROVED"}, Pages: services.StampPagesLast})
+		require.NoError(t, err)
+		contents := pageContents(t, stamped)
+		assert.Len(t, contents[0], 1)
+		assert.Len(t, contents[1], 3)
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		encrypted := bytes.Replace(classicPDF(), []byte("/Root 1 0 R >>"), []byte("/Root 1 0 R /Encrypt << /Filter /Standard >> >>"), 1)
+		_, err := stamper.Stamp(ctx, encrypted, stamp)
+		assert.ErrorIs(t, err, ErrEncrypted)
+	})
+
+	t.Run("not a PDF", func(t *testing.T) {
+		_, err := stamper.Stamp(ctx, []byte("hello"), stamp)
+		assert.ErrorIs(t, err, ErrMalformed)
+	})
+}
@@ -0,0 +1,78 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBroker relays realtime events between server instances over Redis pub/sub
+type RedisBroker struct {
+	client *redis.Client
+}
+
+var _ services.RealtimeBroker = (*RedisBroker)(nil)
+
+func NewRedisBroker(redisURL string) (*RedisBroker, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &RedisBroker{client: client}, nil
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	if err := b.client.Publish(ctx, channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish realtime event: %w", err)
+	}
+	return nil
+}
+
+// Subscribe returns the channel's messages until ctx is cancelled or the
+// subscription fails
+func (b *RedisBroker) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	pubsub := b.client.Subscribe(ctx, channel)
+	// Receive confirms the subscription before any event is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to realtime events: %w", err)
+	}
+
+	messages := make(chan []byte)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+
+		incoming := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-incoming:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+func (b *RedisBroker) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,96 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+type AccountingRepository struct {
+	db *database.DB
+}
+
+func NewAccountingRepository(db *database.DB) repositories.AccountingRepository {
+	return &AccountingRepository{db: db}
+}
+
+// ListExportCandidates reads the tenants' accounting_export setting, as the
+// AccountingService stores it
+func (r *AccountingRepository) ListExportCandidates(ctx context.Context, limit int) ([]models.Document, error) {
+	var documents []models.Document
+	err := r.db.WithContext(ctx).
+		Joins("JOIN tenants ON tenants.id = documents.tenant_id").
+		Where("tenants.is_active = ? AND tenants.settings->'accounting_export'->>'auto_export' = 'true'", true).
+		Where("documents.created_at >= (tenants.settings->'accounting_export'->>'auto_export_since')::timestamptz").
+		Where("documents.deleted_at IS NULL AND documents.document_type = ?", models.DocTypeInvoice).
+		Where("documents.amount IS NOT NULL AND documents.vendor_name <> ''").
+		Where("documents.accounting_status IS NULL OR documents.accounting_status = ''").
+		Order("documents.created_at ASC").
+		Limit(limit).
+		Find(&documents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounting export candidates: %w", err)
+	}
+	return documents, nil
+}
+
+func (r *AccountingRepository) ClaimExport(ctx context.Context, tenantID, documentID, integrationID uuid.UUID, now, staleBefore time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Document{}).
+		Where("id = ? AND tenant_id = ?", documentID, tenantID).
+		Where("accounting_status IS NULL OR accounting_status IN ? OR (accounting_status = ? AND accounting_updated_at < ?)",
+			[]models.AccountingSyncStatus{"", models.AccountingSyncFailed}, models.AccountingSyncExporting, staleBefore).
+		Updates(map[string]interface{}{
+			"accounting_status":         models.AccountingSyncExporting,
+			"accounting_integration_id": integrationID,
+			"accounting_updated_at":     now,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim accounting export: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *AccountingRepository) SaveExport(ctx context.Context, document *models.Document) error {
+	err := r.db.WithContext(ctx).Model(&models.Document{}).
+		Where("id = ?", document.ID).
+		Updates(map[string]interface{}{
+			"accounting_status":         document.AccountingStatus,
+			"accounting_integration_id": document.AccountingIntegrationID,
+			"accounting_bill_id":        document.AccountingBillID,
+			"accounting_error":          document.AccountingError,
+			"accounting_updated_at":     document.AccountingUpdatedAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save accounting export: %w", err)
+	}
+	return nil
+}
+
+func (r *AccountingRepository) ListExports(ctx context.Context, tenantID uuid.UUID, status models.AccountingSyncStatus, params repositories.ListParams) ([]models.Document, int64, error) {
+	var documents []models.Document
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Document{}).
+		Where("tenant_id = ? AND deleted_at IS NULL", tenantID)
+	if status != "" {
+		query = query.Where("accounting_status = ?", status)
+	} else {
+		query = query.Where("accounting_status IS NOT NULL AND accounting_status <> ''")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count accounting exports: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	err := query.Order("accounting_updated_at DESC").Offset(offset).Limit(params.PageSize).Find(&documents).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list accounting exports: %w", err)
+	}
+
+	return documents, total, nil
+}
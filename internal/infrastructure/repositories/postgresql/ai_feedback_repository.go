@@ -0,0 +1,51 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+type AIFeedbackRepository struct {
+	db *database.DB
+}
+
+func NewAIFeedbackRepository(db *database.DB) repositories.AIFeedbackRepository {
+	return &AIFeedbackRepository{db: db}
+}
+
+func (r *AIFeedbackRepository) Create(ctx context.Context, feedback *models.AIFeedback) error {
+	if err := r.db.WithContext(ctx).Create(feedback).Error; err != nil {
+		return fmt.Errorf("failed to create AI feedback: %w", err)
+	}
+	return nil
+}
+
+func (r *AIFeedbackRepository) ListByDocument(ctx context.Context, tenantID, documentID uuid.UUID) ([]models.AIFeedback, error) {
+	var feedback []models.AIFeedback
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND document_id = ?", tenantID, documentID).
+		Order("created_at DESC").
+		Find(&feedback).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AI feedback: %w", err)
+	}
+	return feedback, nil
+}
+
+func (r *AIFeedbackRepository) ListRecent(ctx context.Context, tenantID uuid.UUID, kind models.AIFeedbackKind, limit int) ([]models.AIFeedback, error) {
+	var feedback []models.AIFeedback
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND kind = ?", tenantID, kind).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&feedback).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AI feedback: %w", err)
+	}
+	return feedback, nil
+}
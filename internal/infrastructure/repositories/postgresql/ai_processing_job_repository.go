@@ -158,6 +158,52 @@ func (r *AIProcessingJobRepository) RetryJob(ctx context.Context, jobID uuid.UUI
 	return nil
 }
 
+func (r *AIProcessingJobRepository) Requeue(ctx context.Context, jobID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.AIProcessingJob{}).
+		Where("id = ? AND status = ?", jobID, models.ProcessingFailed).
+		Updates(map[string]interface{}{
+			"status":        models.ProcessingQueued,
+			"attempts":      0,
+			"error_message": "",
+			"started_at":    nil,
+			"completed_at":  nil,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to requeue AI processing job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *AIProcessingJobRepository) CountByBatch(ctx context.Context, batchID uuid.UUID) (map[models.ProcessingStatus]int64, error) {
+	var rows []struct {
+		Status models.ProcessingStatus
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&models.AIProcessingJob{}).
+		Select("status, COUNT(*) AS count").
+		Where("batch_id = ?", batchID).
+		Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count batch AI processing jobs: %w", err)
+	}
+
+	counts := make(map[models.ProcessingStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *AIProcessingJobRepository) Prioritize(ctx context.Context, jobID uuid.UUID, priority int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.AIProcessingJob{}).
+		Where("id = ? AND status = ?", jobID, models.ProcessingQueued).
+		Update("priority", gorm.Expr("LEAST(priority, ?)", priority))
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to prioritize AI processing job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (r *AIProcessingJobRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	// Only allow deletion of completed or failed jobs
 	var job models.AIProcessingJob
@@ -0,0 +1,124 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AIReprocessBatchRepository struct {
+	db *database.DB
+}
+
+func NewAIReprocessBatchRepository(db *database.DB) repositories.AIReprocessBatchRepository {
+	return &AIReprocessBatchRepository{db: db}
+}
+
+func (r *AIReprocessBatchRepository) Create(ctx context.Context, batch *models.AIReprocessBatch) error {
+	if err := r.db.WithContext(ctx).Create(batch).Error; err != nil {
+		return fmt.Errorf("failed to create AI reprocess batch: %w", err)
+	}
+	return nil
+}
+
+func (r *AIReprocessBatchRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AIReprocessBatch, error) {
+	var batch models.AIReprocessBatch
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&batch).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("AI reprocess batch not found")
+		}
+		return nil, fmt.Errorf("failed to get AI reprocess batch: %w", err)
+	}
+	return &batch, nil
+}
+
+func (r *AIReprocessBatchRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID, params repositories.ListParams) ([]models.AIReprocessBatch, int64, error) {
+	var batches []models.AIReprocessBatch
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.AIReprocessBatch{}).Where("tenant_id = ?", tenantID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count AI reprocess batches: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(params.PageSize).Find(&batches).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list AI reprocess batches: %w", err)
+	}
+
+	return batches, total, nil
+}
+
+// ListDue returns pending batches and batches whose worker's lease expired,
+// oldest first
+func (r *AIReprocessBatchRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.AIReprocessBatch, error) {
+	var batches []models.AIReprocessBatch
+	err := r.db.WithContext(ctx).
+		Where("status = ? OR (status = ? AND lease_until <= ?)", models.AIReprocessPending, models.AIReprocessRunning, now).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&batches).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due AI reprocess batches: %w", err)
+	}
+	return batches, nil
+}
+
+// Claim leases a due batch to one worker, reporting false when another got it
+// first. StartedAt keeps the first claim's time.
+func (r *AIReprocessBatchRepository) Claim(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.AIReprocessBatch{}).
+		Where("id = ? AND (status = ? OR (status = ? AND lease_until <= ?))",
+			id, models.AIReprocessPending, models.AIReprocessRunning, now).
+		Updates(map[string]interface{}{
+			"status":      models.AIReprocessRunning,
+			"lease_until": leaseUntil,
+			"started_at":  gorm.Expr("COALESCE(started_at, ?)", now),
+			"attempts":    gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim AI reprocess batch: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *AIReprocessBatchRepository) SaveProgress(ctx context.Context, id uuid.UUID, cursor uuid.UUID, queued int64, leaseUntil time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.AIReprocessBatch{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"cursor":      cursor,
+			"queued":      queued,
+			"lease_until": leaseUntil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save AI reprocess batch progress: %w", err)
+	}
+	return nil
+}
+
+// Finish saves the batch's outcome and releases its lease
+func (r *AIReprocessBatchRepository) Finish(ctx context.Context, batch *models.AIReprocessBatch) error {
+	err := r.db.WithContext(ctx).Model(&models.AIReprocessBatch{}).
+		Where("id = ?", batch.ID).
+		Updates(map[string]interface{}{
+			"status":       batch.Status,
+			"queued":       batch.Queued,
+			"attempts":     batch.Attempts,
+			"error":        batch.Error,
+			"completed_at": batch.CompletedAt,
+			"lease_until":  nil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update AI reprocess batch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+type AIUsageRepository struct {
+	db *database.DB
+}
+
+func NewAIUsageRepository(db *database.DB) repositories.AIUsageRepository {
+	return &AIUsageRepository{db: db}
+}
+
+func (r *AIUsageRepository) CreateBatch(ctx context.Context, usage []models.AIUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).CreateInBatches(usage, 100).Error; err != nil {
+		return fmt.Errorf("failed to record AI usage: %w", err)
+	}
+	return nil
+}
+
+func (r *AIUsageRepository) CostSince(ctx context.Context, tenantID uuid.UUID, since time.Time) (float64, error) {
+	var cost float64
+	err := r.db.WithContext(ctx).Model(&models.AIUsage{}).
+		Select("COALESCE(SUM(cost), 0)").
+		Where("tenant_id = ? AND created_at >= ?", tenantID, since).
+		Scan(&cost).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum AI usage: %w", err)
+	}
+	return cost, nil
+}
+
+func (r *AIUsageRepository) MonthlyTotals(ctx context.Context, tenantID uuid.UUID, from time.Time) ([]repositories.AIUsageTotal, error) {
+	var totals []repositories.AIUsageTotal
+	err := r.db.WithContext(ctx).Model(&models.AIUsage{}).
+		Select(`
+			date_trunc('month', created_at AT TIME ZONE 'UTC') as period,
+			provider,
+			model,
+			COUNT(*) as calls,
+			COALESCE(SUM(input_tokens), 0) as input_tokens,
+			COALESCE(SUM(output_tokens), 0) as output_tokens,
+			COALESCE(SUM(cost), 0) as cost
+		`).
+		Where("tenant_id = ? AND created_at >= ?", tenantID, from).
+		Group("period, provider, model").
+		Order("period DESC, cost DESC").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to total AI usage: %w", err)
+	}
+	return totals, nil
+}
@@ -313,3 +313,79 @@ func (r *AnalyticsRepository) GetUserActivity(ctx context.Context, tenantID uuid
 
 	return activities, nil
 }
+
+func (r *AnalyticsRepository) GetUserActivityReport(ctx context.Context, tenantID uuid.UUID, userIDs []uuid.UUID, from, to time.Time) ([]repositories.UserActivityTotals, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	totals := make(map[uuid.UUID]*repositories.UserActivityTotals)
+	get := func(userID uuid.UUID) *repositories.UserActivityTotals {
+		if totals[userID] == nil {
+			totals[userID] = &repositories.UserActivityTotals{UserID: userID}
+		}
+		return totals[userID]
+	}
+
+	var uploads []struct {
+		UserID uuid.UUID
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Table("documents").
+		Select("created_by as user_id, COUNT(*) as count").
+		Where("tenant_id = ? AND created_by IN ? AND created_at >= ? AND created_at < ?", tenantID, userIDs, from, to).
+		Group("created_by").
+		Scan(&uploads).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count uploads: %w", err)
+	}
+	for _, row := range uploads {
+		get(row.UserID).DocumentsUploaded = row.Count
+	}
+
+	var approvals []struct {
+		UserID     uuid.UUID
+		Count      int64
+		Turnaround float64
+	}
+	err = r.db.WithContext(ctx).Table("workflow_tasks").
+		Select(`workflow_tasks.assigned_to as user_id, COUNT(*) as count,
+			AVG(EXTRACT(EPOCH FROM workflow_tasks.completed_at - workflow_tasks.created_at)) as turnaround`).
+		Joins("JOIN workflows ON workflows.id = workflow_tasks.workflow_id").
+		Where("workflows.tenant_id = ? AND workflow_tasks.assigned_to IN ?", tenantID, userIDs).
+		Where("workflow_tasks.status IN ?", []models.WorkflowStatus{models.WorkflowApproved, models.WorkflowRejected}).
+		Where("workflow_tasks.completed_at >= ? AND workflow_tasks.completed_at < ?", from, to).
+		Group("workflow_tasks.assigned_to").
+		Scan(&approvals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count approvals: %w", err)
+	}
+	for _, row := range approvals {
+		get(row.UserID).ApprovalsCompleted = row.Count
+		get(row.UserID).TurnaroundSeconds = row.Turnaround
+	}
+
+	var comments []struct {
+		UserID uuid.UUID
+		Count  int64
+	}
+	err = r.db.WithContext(ctx).Table("document_comments").
+		Select("document_comments.user_id, COUNT(*) as count").
+		Joins("JOIN documents ON documents.id = document_comments.document_id").
+		Where("documents.tenant_id = ? AND document_comments.user_id IN ?", tenantID, userIDs).
+		Where("document_comments.created_at >= ? AND document_comments.created_at < ?", from, to).
+		Group("document_comments.user_id").
+		Scan(&comments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+	for _, row := range comments {
+		get(row.UserID).Comments = row.Count
+	}
+
+	report := make([]repositories.UserActivityTotals, 0, len(totals))
+	for _, row := range totals {
+		report = append(report, *row)
+	}
+	return report, nil
+}
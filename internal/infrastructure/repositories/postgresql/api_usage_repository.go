@@ -0,0 +1,55 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type APIUsageRepository struct {
+	db *database.DB
+}
+
+func NewAPIUsageRepository(db *database.DB) repositories.APIUsageRepository {
+	return &APIUsageRepository{db: db}
+}
+
+// Upsert records a tenant's usage for a month. The meter's counters only
+// grow, so a stored count is never lowered; this keeps the history intact
+// when Redis loses its counters mid-month.
+func (r *APIUsageRepository) Upsert(ctx context.Context, usage *models.APIUsage) error {
+	usage.UpdatedAt = time.Now()
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":       gorm.Expr("GREATEST(api_usages.requests, EXCLUDED.requests)"),
+			"quota_rejected": gorm.Expr("GREATEST(api_usages.quota_rejected, EXCLUDED.quota_rejected)"),
+			"rate_limited":   gorm.Expr("GREATEST(api_usages.rate_limited, EXCLUDED.rate_limited)"),
+			"updated_at":     usage.UpdatedAt,
+		}),
+	}).Create(usage).Error
+	if err != nil {
+		return fmt.Errorf("failed to record api usage: %w", err)
+	}
+	return nil
+}
+
+// ListByTenant returns the tenant's monthly usage from the given month on, newest first
+func (r *APIUsageRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID, from time.Time) ([]models.APIUsage, error) {
+	var usage []models.APIUsage
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND period >= ?", tenantID, from).
+		Order("period DESC").
+		Find(&usage).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api usage: %w", err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,38 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIUsageRepository_Upsert(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewAPIUsageRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	period := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.Upsert(ctx, &models.APIUsage{TenantID: tenant.ID, Period: period, Requests: 100, RateLimited: 5}))
+	require.NoError(t, repo.Upsert(ctx, &models.APIUsage{TenantID: tenant.ID, Period: period, Requests: 150, RateLimited: 5}))
+
+	// A meter that lost its counters doesn't lower the stored usage
+	require.NoError(t, repo.Upsert(ctx, &models.APIUsage{TenantID: tenant.ID, Period: period, Requests: 10}))
+
+	require.NoError(t, repo.Upsert(ctx, &models.APIUsage{TenantID: tenant.ID, Period: period.AddDate(0, 1, 0), Requests: 20}))
+
+	usage, err := repo.ListByTenant(ctx, tenant.ID, period)
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	assert.Equal(t, int64(20), usage[0].Requests)
+	assert.Equal(t, int64(150), usage[1].Requests)
+	assert.Equal(t, int64(5), usage[1].RateLimited)
+}
@@ -0,0 +1,364 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// archivableStatuses are the statuses of documents that may be archived;
+// documents still being processed or reviewed are left for a later run
+var archivableStatuses = []models.DocStatus{models.DocStatusCompleted, models.DocStatusError, models.DocStatusExpired}
+
+type ArchivalRepository struct {
+	db *database.DB
+}
+
+func NewArchivalRepository(db *database.DB) repositories.ArchivalRepository {
+	return &ArchivalRepository{db: db}
+}
+
+func (r *ArchivalRepository) CreateSchedule(ctx context.Context, schedule *models.ArchivalSchedule) error {
+	if err := r.db.WithContext(ctx).Create(schedule).Error; err != nil {
+		return fmt.Errorf("failed to create archival schedule: %w", err)
+	}
+	return nil
+}
+
+func (r *ArchivalRepository) GetSchedule(ctx context.Context, id uuid.UUID) (*models.ArchivalSchedule, error) {
+	var schedule models.ArchivalSchedule
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&schedule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("archival schedule not found")
+		}
+		return nil, fmt.Errorf("failed to get archival schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+func (r *ArchivalRepository) ListSchedules(ctx context.Context, tenantID uuid.UUID) ([]models.ArchivalSchedule, error) {
+	var schedules []models.ArchivalSchedule
+	err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("created_at ASC").Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archival schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+func (r *ArchivalRepository) UpdateSchedule(ctx context.Context, schedule *models.ArchivalSchedule) error {
+	err := r.db.WithContext(ctx).Model(&models.ArchivalSchedule{}).
+		Where("id = ?", schedule.ID).
+		Updates(map[string]interface{}{
+			"name":               schedule.Name,
+			"folder_id":          schedule.FolderID,
+			"include_subfolders": schedule.IncludeSubfolders,
+			"min_age_days":       schedule.MinAgeDays,
+			"cold_storage":       schedule.ColdStorage,
+			"recurrence":         schedule.Recurrence,
+			"enabled":            schedule.Enabled,
+			"next_run_at":        schedule.NextRunAt,
+			"last_run_at":        schedule.LastRunAt,
+			"updated_at":         time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update archival schedule: %w", err)
+	}
+	return nil
+}
+
+// DeleteSchedule deletes the schedule; its runs are kept so they can still
+// be undone
+func (r *ArchivalRepository) DeleteSchedule(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.ArchivalSchedule{}).Error; err != nil {
+		return fmt.Errorf("failed to delete archival schedule: %w", err)
+	}
+	return nil
+}
+
+// ListDueSchedules returns enabled schedules whose run time has come,
+// earliest first
+func (r *ArchivalRepository) ListDueSchedules(ctx context.Context, now time.Time, limit int) ([]models.ArchivalSchedule, error) {
+	var schedules []models.ArchivalSchedule
+	err := r.db.WithContext(ctx).
+		Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due archival schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+func (r *ArchivalRepository) SummarizeCandidates(ctx context.Context, tenantID uuid.UUID, folderIDs []uuid.UUID, datedBefore *time.Time) ([]repositories.ArchivalCandidateStats, error) {
+	if len(folderIDs) == 0 {
+		return nil, nil
+	}
+
+	var stats []repositories.ArchivalCandidateStats
+	err := r.candidates(r.db.WithContext(ctx), tenantID, folderIDs, datedBefore).
+		Select("folder_id, legal_hold, COUNT(*) AS count, COALESCE(SUM(file_size), 0) AS bytes").
+		Group("folder_id, legal_hold").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize archival candidates: %w", err)
+	}
+	return stats, nil
+}
+
+// ListCandidates returns candidates that aren't held, oldest first
+func (r *ArchivalRepository) ListCandidates(ctx context.Context, tenantID uuid.UUID, folderIDs []uuid.UUID, datedBefore *time.Time, limit int) ([]models.Document, error) {
+	if len(folderIDs) == 0 {
+		return nil, nil
+	}
+
+	var documents []models.Document
+	err := r.candidates(r.db.WithContext(ctx), tenantID, folderIDs, datedBefore).
+		Where("legal_hold = ?", false).
+		Order("COALESCE(document_date, created_at) ASC, id ASC").
+		Limit(limit).
+		Find(&documents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archival candidates: %w", err)
+	}
+	return documents, nil
+}
+
+// ArchiveDocuments locks the documents first, so a legal hold placed since
+// they were listed keeps them out
+func (r *ArchivalRepository) ArchiveDocuments(ctx context.Context, run *models.ArchivalRun, documentIDs []uuid.UUID) ([]models.Document, error) {
+	if len(documentIDs) == 0 {
+		return nil, nil
+	}
+
+	var documents []models.Document
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Document{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "folder_id", "status", "file_size").
+			Where("tenant_id = ? AND id IN (?) AND deleted_at IS NULL AND legal_hold = ? AND status IN (?)",
+				run.TenantID, documentIDs, false, archivableStatuses).
+			Find(&documents).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock documents: %w", err)
+		}
+		if len(documents) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(documents))
+		entries := make([]models.ArchivalRunDocument, len(documents))
+		for i, document := range documents {
+			ids[i] = document.ID
+			entries[i] = models.ArchivalRunDocument{
+				TenantID:       run.TenantID,
+				RunID:          run.ID,
+				DocumentID:     document.ID,
+				PreviousStatus: document.Status,
+			}
+		}
+		if err := tx.Create(&entries).Error; err != nil {
+			return fmt.Errorf("failed to record archived documents: %w", err)
+		}
+
+		err = tx.Model(&models.Document{}).
+			Where("id IN (?)", ids).
+			Updates(map[string]interface{}{
+				"status":     models.DocStatusArchived,
+				"updated_at": time.Now(),
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to archive documents: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func (r *ArchivalRepository) CreateRun(ctx context.Context, run *models.ArchivalRun) error {
+	if err := r.db.WithContext(ctx).Create(run).Error; err != nil {
+		return fmt.Errorf("failed to create archival run: %w", err)
+	}
+	return nil
+}
+
+func (r *ArchivalRepository) GetRun(ctx context.Context, id uuid.UUID) (*models.ArchivalRun, error) {
+	var run models.ArchivalRun
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&run).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("archival run not found")
+		}
+		return nil, fmt.Errorf("failed to get archival run: %w", err)
+	}
+	return &run, nil
+}
+
+func (r *ArchivalRepository) ListRuns(ctx context.Context, tenantID uuid.UUID, scheduleID *uuid.UUID, params repositories.ListParams) ([]models.ArchivalRun, int64, error) {
+	var runs []models.ArchivalRun
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.ArchivalRun{}).Where("tenant_id = ?", tenantID)
+	if scheduleID != nil {
+		query = query.Where("schedule_id = ?", *scheduleID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count archival runs: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(params.PageSize).Find(&runs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list archival runs: %w", err)
+	}
+
+	return runs, total, nil
+}
+
+func (r *ArchivalRepository) UpdateRun(ctx context.Context, run *models.ArchivalRun) error {
+	err := r.db.WithContext(ctx).Model(&models.ArchivalRun{}).
+		Where("id = ?", run.ID).
+		Updates(map[string]interface{}{
+			"status":             run.Status,
+			"documents_archived": run.DocumentsArchived,
+			"bytes_archived":     run.BytesArchived,
+			"documents_held":     run.DocumentsHeld,
+			"undo_until":         run.UndoUntil,
+			"last_error":         run.LastError,
+			"updated_at":         time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update archival run: %w", err)
+	}
+	return nil
+}
+
+// UndoRun restores documents by their previous status. Documents trashed
+// or given another status since the run are left as they are.
+func (r *ArchivalRepository) UndoRun(ctx context.Context, id, undoneBy uuid.UUID, at time.Time) (int64, int64, bool, error) {
+	var restored, bytes int64
+	undone := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ArchivalRun{}).
+			Where("id = ? AND status IN (?)", id, []models.ArchivalRunStatus{models.ArchivalRunCompleted, models.ArchivalRunFailed}).
+			Updates(map[string]interface{}{
+				"status":     models.ArchivalRunUndone,
+				"undone_at":  at,
+				"undone_by":  undoneBy,
+				"updated_at": at,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to undo archival run: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		undone = true
+
+		var entries []struct {
+			DocumentID     uuid.UUID
+			PreviousStatus models.DocStatus
+			FileSize       int64
+		}
+		err := tx.Table("archival_run_documents").
+			Select("documents.id AS document_id, archival_run_documents.previous_status, documents.file_size").
+			Joins("JOIN documents ON documents.id = archival_run_documents.document_id").
+			Where("archival_run_documents.run_id = ? AND documents.status = ? AND documents.deleted_at IS NULL",
+				id, models.DocStatusArchived).
+			Scan(&entries).Error
+		if err != nil {
+			return fmt.Errorf("failed to list archived documents: %w", err)
+		}
+
+		byStatus := make(map[models.DocStatus][]uuid.UUID)
+		for _, entry := range entries {
+			byStatus[entry.PreviousStatus] = append(byStatus[entry.PreviousStatus], entry.DocumentID)
+			bytes += entry.FileSize
+		}
+		for status, ids := range byStatus {
+			err := tx.Model(&models.Document{}).
+				Where("id IN (?) AND status = ? AND deleted_at IS NULL", ids, models.DocStatusArchived).
+				Updates(map[string]interface{}{
+					"status":     status,
+					"updated_by": undoneBy,
+					"updated_at": at,
+				}).Error
+			if err != nil {
+				return fmt.Errorf("failed to restore documents: %w", err)
+			}
+		}
+		restored = int64(len(entries))
+		return nil
+	})
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return restored, bytes, undone, nil
+}
+
+// ListColdStorageDue returns completed runs asking for cold storage whose
+// undo window has closed, oldest first
+func (r *ArchivalRepository) ListColdStorageDue(ctx context.Context, now time.Time, limit int) ([]models.ArchivalRun, error) {
+	var runs []models.ArchivalRun
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND cold_storage = ? AND cold_stored_at IS NULL AND undo_until <= ?",
+			models.ArchivalRunCompleted, true, now).
+		Order("undo_until ASC").
+		Limit(limit).
+		Find(&runs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archival runs due for cold storage: %w", err)
+	}
+	return runs, nil
+}
+
+// ListArchivedPaths returns the files of the run's documents that are
+// still archived
+func (r *ArchivalRepository) ListArchivedPaths(ctx context.Context, runID uuid.UUID) ([]string, error) {
+	var paths []string
+	err := r.db.WithContext(ctx).Table("archival_run_documents").
+		Joins("JOIN documents ON documents.id = archival_run_documents.document_id").
+		Where("archival_run_documents.run_id = ? AND documents.status = ? AND documents.deleted_at IS NULL",
+			runID, models.DocStatusArchived).
+		Pluck("documents.storage_path", &paths).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived files: %w", err)
+	}
+	return paths, nil
+}
+
+func (r *ArchivalRepository) MarkColdStored(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.ArchivalRun{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"cold_stored_at": at,
+			"updated_at":     at,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update archival run: %w", err)
+	}
+	return nil
+}
+
+// candidates selects the tenant's live documents in the folders that may be
+// archived, dated before datedBefore when it's set
+func (r *ArchivalRepository) candidates(db *gorm.DB, tenantID uuid.UUID, folderIDs []uuid.UUID, datedBefore *time.Time) *gorm.DB {
+	query := db.Model(&models.Document{}).
+		Where("tenant_id = ? AND folder_id IN (?) AND deleted_at IS NULL AND status IN (?)", tenantID, folderIDs, archivableStatuses)
+	if datedBefore != nil {
+		query = query.Where("COALESCE(document_date, created_at) < ?", *datedBefore)
+	}
+	return query
+}
@@ -2,12 +2,14 @@ package postgresql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/archivus/archivus/internal/domain/repositories"
 	"github.com/archivus/archivus/internal/infrastructure/database"
 	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/pkg/logger"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -20,10 +22,60 @@ func NewAuditLogRepository(db *database.DB) repositories.AuditLogRepository {
 	return &AuditLogRepository{db: db}
 }
 
+// Create appends the entry to its tenant's hash chain. The chain head row is
+// locked for the duration, so concurrent writers are serialized per tenant.
 func (r *AuditLogRepository) Create(ctx context.Context, log *models.AuditLog) error {
-	if err := r.db.WithContext(ctx).Create(log).Error; err != nil {
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	if log.RequestID == "" {
+		log.RequestID = logger.RequestIDFromContext(ctx)
+	}
+	// Postgres stores microseconds; the hash must match what is read back
+	log.CreatedAt = log.CreatedAt.UTC().Truncate(time.Microsecond)
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Exec(`INSERT INTO audit_chain_heads (tenant_id, sequence, hash, updated_at)
+		VALUES (?, 0, '', now()) ON CONFLICT (tenant_id) DO NOTHING`, log.TenantID).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to initialize audit chain: %w", err)
+	}
+
+	var head models.AuditChainHead
+	if err := tx.Raw(`SELECT tenant_id, sequence, hash, updated_at FROM audit_chain_heads
+		WHERE tenant_id = ? FOR UPDATE`, log.TenantID).Scan(&head).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to lock audit chain: %w", err)
+	}
+
+	log.Sequence = head.Sequence + 1
+	log.PrevHash = head.Hash
+	log.Hash = log.ComputeHash()
+
+	if err := tx.Create(log).Error; err != nil {
+		tx.Rollback()
 		return fmt.Errorf("failed to create audit log: %w", err)
 	}
+
+	if err := tx.Model(&models.AuditChainHead{}).Where("tenant_id = ?", log.TenantID).
+		Updates(map[string]interface{}{
+			"sequence":   log.Sequence,
+			"hash":       log.Hash,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to advance audit chain: %w", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit audit log: %w", err)
+	}
 	return nil
 }
 
@@ -64,7 +116,7 @@ func (r *AuditLogRepository) ListByResource(ctx context.Context, resourceID uuid
 		Preload("Tenant", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "subdomain")
 		}).
-		Select("id", "tenant_id", "user_id", "resource_id", "action", "resource_type", "ip_address", "user_agent", "details", "created_at").
+		Select("id", "tenant_id", "user_id", "resource_id", "action", "resource_type", "ip_address", "user_agent", "details", "request_id", "created_at", "sequence", "prev_hash", "hash").
 		Order(orderBy).Offset(offset).Limit(params.PageSize).Find(&logs).Error
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list audit logs by resource: %w", err)
@@ -107,7 +159,7 @@ func (r *AuditLogRepository) ListByUser(ctx context.Context, userID uuid.UUID, p
 		Preload("Tenant", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "subdomain")
 		}).
-		Select("id", "tenant_id", "user_id", "resource_id", "action", "resource_type", "ip_address", "user_agent", "details", "created_at").
+		Select("id", "tenant_id", "user_id", "resource_id", "action", "resource_type", "ip_address", "user_agent", "details", "request_id", "created_at", "sequence", "prev_hash", "hash").
 		Order(orderBy).Offset(offset).Limit(params.PageSize).Find(&logs).Error
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list audit logs by user: %w", err)
@@ -123,10 +175,10 @@ func (r *AuditLogRepository) ListByTenant(ctx context.Context, tenantID uuid.UUI
 	query := r.db.WithContext(ctx).Model(&models.AuditLog{}).
 		Where("tenant_id = ?", tenantID)
 
-	// Apply search filter if provided
+	// Apply search filter if provided; a request ID finds everything the request did
 	if params.Search != "" {
-		query = query.Where("action ILIKE ? OR resource_type ILIKE ? OR user_agent ILIKE ? OR details::text ILIKE ?",
-			"%"+params.Search+"%", "%"+params.Search+"%", "%"+params.Search+"%", "%"+params.Search+"%")
+		query = query.Where("action ILIKE ? OR resource_type ILIKE ? OR user_agent ILIKE ? OR details::text ILIKE ? OR request_id = ?",
+			"%"+params.Search+"%", "%"+params.Search+"%", "%"+params.Search+"%", "%"+params.Search+"%", params.Search)
 	}
 
 	// Get total count
@@ -150,7 +202,7 @@ func (r *AuditLogRepository) ListByTenant(ctx context.Context, tenantID uuid.UUI
 		Preload("User", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email", "role")
 		}).
-		Select("id", "tenant_id", "user_id", "resource_id", "action", "resource_type", "ip_address", "user_agent", "details", "created_at").
+		Select("id", "tenant_id", "user_id", "resource_id", "action", "resource_type", "ip_address", "user_agent", "details", "request_id", "created_at", "sequence", "prev_hash", "hash").
 		Order(orderBy).Offset(offset).Limit(params.PageSize).Find(&logs).Error
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list audit logs by tenant: %w", err)
@@ -176,7 +228,7 @@ func (r *AuditLogRepository) GetSecurityEvents(ctx context.Context, tenantID uui
 		Preload("User", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email", "role")
 		}).
-		Select("id", "tenant_id", "user_id", "resource_id", "action", "resource_type", "ip_address", "user_agent", "details", "created_at").
+		Select("id", "tenant_id", "user_id", "resource_id", "action", "resource_type", "ip_address", "user_agent", "details", "request_id", "created_at", "sequence", "prev_hash", "hash").
 		Where("tenant_id = ? AND created_at >= ? AND action IN ?", tenantID, since, securityActions).
 		Order("created_at DESC").Find(&logs).Error
 	if err != nil {
@@ -185,3 +237,112 @@ func (r *AuditLogRepository) GetSecurityEvents(ctx context.Context, tenantID uui
 
 	return logs, nil
 }
+
+func (r *AuditLogRepository) GetChainHead(ctx context.Context, tenantID uuid.UUID) (*models.AuditChainHead, error) {
+	var head models.AuditChainHead
+	err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).First(&head).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("audit chain not found")
+		}
+		return nil, fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+	return &head, nil
+}
+
+// ListChain returns the tenant's chained entries after the given sequence, in
+// chain order
+func (r *AuditLogRepository) ListChain(ctx context.Context, tenantID uuid.UUID, afterSequence int64, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND sequence > ?", tenantID, afterSequence).
+		Order("sequence ASC").Limit(limit).Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit chain: %w", err)
+	}
+	return logs, nil
+}
+
+// ListArchivable returns the tenant's oldest entries created before the cutoff,
+// in chain order. Unchained legacy entries come first.
+func (r *AuditLogRepository) ListArchivable(ctx context.Context, tenantID uuid.UUID, before time.Time, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND created_at < ?", tenantID, before).
+		Order("sequence ASC, created_at ASC").Limit(limit).Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archivable audit logs: %w", err)
+	}
+	return logs, nil
+}
+
+// ArchiveEntries records the archive and prunes its entries from the hot table
+// in one transaction
+func (r *AuditLogRepository) ArchiveEntries(ctx context.Context, archive *models.AuditArchive, entryIDs []uuid.UUID) error {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Create(archive).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to create audit archive: %w", err)
+	}
+
+	result := tx.Where("tenant_id = ? AND id IN ?", archive.TenantID, entryIDs).Delete(&models.AuditLog{})
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to prune audit logs: %w", result.Error)
+	}
+	if result.RowsAffected != int64(len(entryIDs)) {
+		tx.Rollback()
+		return fmt.Errorf("failed to prune audit logs: %d of %d entries deleted", result.RowsAffected, len(entryIDs))
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit audit archive: %w", err)
+	}
+	return nil
+}
+
+func (r *AuditLogRepository) ListArchives(ctx context.Context, tenantID uuid.UUID) ([]models.AuditArchive, error) {
+	var archives []models.AuditArchive
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("last_sequence ASC, from_time ASC").Find(&archives).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit archives: %w", err)
+	}
+	return archives, nil
+}
+
+func (r *AuditLogRepository) GetArchive(ctx context.Context, id uuid.UUID) (*models.AuditArchive, error) {
+	var archive models.AuditArchive
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&archive).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("audit archive not found")
+		}
+		return nil, fmt.Errorf("failed to get audit archive: %w", err)
+	}
+	return &archive, nil
+}
+
+// ListRange returns the tenant's entries created in [from, to) in chain
+// order, after the cursor. Unchained legacy entries come first.
+func (r *AuditLogRepository) ListRange(ctx context.Context, tenantID uuid.UUID, from, to time.Time, after *repositories.AuditLogCursor, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	query := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, from, to)
+	if after != nil {
+		query = query.Where("sequence > ? OR (sequence = ? AND id > ?)", after.Sequence, after.Sequence, after.ID)
+	}
+
+	err := query.Order("sequence ASC, id ASC").Limit(limit).Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	return logs, nil
+}
@@ -0,0 +1,156 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestAuditLog(t *testing.T, repo *AuditLogRepository, tenant *models.Tenant, user *models.User, createdAt time.Time) *models.AuditLog {
+	t.Helper()
+
+	log := &models.AuditLog{
+		ID:           uuid.New(),
+		TenantID:     tenant.ID,
+		UserID:       user.ID,
+		ResourceID:   uuid.New(),
+		Action:       models.AuditCreate,
+		ResourceType: "document",
+		Details:      models.JSONB{"name": "invoice.pdf"},
+		CreatedAt:    createdAt,
+	}
+	require.NoError(t, repo.Create(context.Background(), log))
+	return log
+}
+
+func TestAuditLogRepository_CreateChainsEntries(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewAuditLogRepository(db.DB).(*AuditLogRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	first := createTestAuditLog(t, repo, tenant, user, time.Now())
+	second := createTestAuditLog(t, repo, tenant, user, time.Now())
+
+	assert.Equal(t, int64(1), first.Sequence)
+	assert.Empty(t, first.PrevHash)
+	assert.Equal(t, int64(2), second.Sequence)
+	assert.Equal(t, first.Hash, second.PrevHash)
+
+	// Stored entries still hash to the recorded value
+	chain, err := repo.ListChain(ctx, tenant.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, chain, 2)
+	for _, entry := range chain {
+		assert.Equal(t, entry.Hash, entry.ComputeHash())
+	}
+
+	head, err := repo.GetChainHead(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), head.Sequence)
+	assert.Equal(t, second.Hash, head.Hash)
+
+	// Each tenant has its own chain
+	otherTenant := db.CreateTestTenant(t)
+	otherUser := db.CreateTestUser(t, otherTenant)
+	other := createTestAuditLog(t, repo, otherTenant, otherUser, time.Now())
+	assert.Equal(t, int64(1), other.Sequence)
+}
+
+func TestAuditLogRepository_ArchiveEntries(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewAuditLogRepository(db.DB).(*AuditLogRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	old := createTestAuditLog(t, repo, tenant, user, time.Now().AddDate(0, 0, -400))
+	recent := createTestAuditLog(t, repo, tenant, user, time.Now())
+
+	archivable, err := repo.ListArchivable(ctx, tenant.ID, time.Now().AddDate(0, 0, -365), 10)
+	require.NoError(t, err)
+	require.Len(t, archivable, 1)
+	assert.Equal(t, old.ID, archivable[0].ID)
+
+	archive := &models.AuditArchive{
+		ID:            uuid.New(),
+		TenantID:      tenant.ID,
+		StoragePath:   tenant.ID.String() + "/audit.jsonl.gz",
+		FirstSequence: old.Sequence,
+		LastSequence:  old.Sequence,
+		FirstPrevHash: old.PrevHash,
+		LastHash:      old.Hash,
+		FromTime:      old.CreatedAt,
+		ToTime:        old.CreatedAt,
+		EntryCount:    1,
+		SizeBytes:     128,
+	}
+	require.NoError(t, repo.ArchiveEntries(ctx, archive, []uuid.UUID{old.ID}))
+
+	archives, err := repo.ListArchives(ctx, tenant.ID)
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+	assert.Equal(t, old.Hash, archives[0].LastHash)
+
+	// Only the recent entry is left in the hot table
+	chain, err := repo.ListChain(ctx, tenant.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, chain, 1)
+	assert.Equal(t, recent.ID, chain[0].ID)
+
+	// The chain continues across the archive boundary
+	next := createTestAuditLog(t, repo, tenant, user, time.Now())
+	assert.Equal(t, int64(3), next.Sequence)
+	assert.Equal(t, recent.Hash, next.PrevHash)
+	assert.Equal(t, archives[0].LastHash, chain[0].PrevHash)
+
+	// A partial prune rolls back the archive record
+	failed := &models.AuditArchive{ID: uuid.New(), TenantID: tenant.ID, StoragePath: "missing", EntryCount: 1}
+	assert.Error(t, repo.ArchiveEntries(ctx, failed, []uuid.UUID{uuid.New()}))
+	_, err = repo.GetArchive(ctx, failed.ID)
+	assert.Error(t, err)
+}
+
+func TestAuditLogRepository_ListRange(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewAuditLogRepository(db.DB).(*AuditLogRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	now := time.Now()
+	createTestAuditLog(t, repo, tenant, user, now.Add(-48*time.Hour))
+	first := createTestAuditLog(t, repo, tenant, user, now.Add(-3*time.Hour))
+	second := createTestAuditLog(t, repo, tenant, user, now.Add(-2*time.Hour))
+	third := createTestAuditLog(t, repo, tenant, user, now.Add(-time.Hour))
+
+	from, to := now.Add(-24*time.Hour), now
+	page, err := repo.ListRange(ctx, tenant.ID, from, to, nil, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, first.ID, page[0].ID)
+	assert.Equal(t, second.ID, page[1].ID)
+
+	cursor := &repositories.AuditLogCursor{Sequence: page[1].Sequence, ID: page[1].ID}
+	page, err = repo.ListRange(ctx, tenant.ID, from, to, cursor, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, third.ID, page[0].ID)
+}
@@ -0,0 +1,126 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditStreamRepository struct {
+	db *database.DB
+}
+
+func NewAuditStreamRepository(db *database.DB) repositories.AuditStreamRepository {
+	return &AuditStreamRepository{db: db}
+}
+
+func (r *AuditStreamRepository) Create(ctx context.Context, stream *models.AuditStream) error {
+	if err := r.db.WithContext(ctx).Omit("Tenant").Create(stream).Error; err != nil {
+		return fmt.Errorf("failed to create audit stream: %w", err)
+	}
+	return nil
+}
+
+func (r *AuditStreamRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AuditStream, error) {
+	var stream models.AuditStream
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&stream).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("audit stream not found")
+		}
+		return nil, fmt.Errorf("failed to get audit stream: %w", err)
+	}
+	return &stream, nil
+}
+
+func (r *AuditStreamRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.AuditStream, error) {
+	var streams []models.AuditStream
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").Find(&streams).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit streams: %w", err)
+	}
+	return streams, nil
+}
+
+// ListDue returns active streams that aren't backing off after a failure
+func (r *AuditStreamRepository) ListDue(ctx context.Context, now time.Time) ([]models.AuditStream, error) {
+	var streams []models.AuditStream
+	err := r.db.WithContext(ctx).
+		Where("is_active = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", true, now).
+		Order("created_at ASC").Find(&streams).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due audit streams: %w", err)
+	}
+	return streams, nil
+}
+
+// Update saves the stream's settings. The delivery cursor and health are
+// only changed by Advance and RecordFailure.
+func (r *AuditStreamRepository) Update(ctx context.Context, stream *models.AuditStream) error {
+	err := r.db.WithContext(ctx).Model(&models.AuditStream{}).
+		Where("id = ?", stream.ID).
+		Updates(map[string]interface{}{
+			"name":            stream.Name,
+			"endpoint":        stream.Endpoint,
+			"secret":          stream.Secret,
+			"is_active":       stream.IsActive,
+			"next_attempt_at": stream.NextAttemptAt,
+			"updated_at":      stream.UpdatedAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update audit stream: %w", err)
+	}
+	return nil
+}
+
+func (r *AuditStreamRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.AuditStream{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete audit stream: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("audit stream not found")
+	}
+	return nil
+}
+
+// Advance moves the cursor past a delivered batch and resets the stream's health
+func (r *AuditStreamRepository) Advance(ctx context.Context, id uuid.UUID, fromSequence, toSequence int64, deliveredAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.AuditStream{}).
+		Where("id = ? AND last_sequence = ?", id, fromSequence).
+		UpdateColumns(map[string]interface{}{
+			"last_sequence":    toSequence,
+			"last_delivery_at": deliveredAt,
+			"last_error":       "",
+			"failure_count":    0,
+			"next_attempt_at":  nil,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to advance audit stream: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RecordFailure notes a failed delivery and when to try again
+func (r *AuditStreamRepository) RecordFailure(ctx context.Context, id uuid.UUID, message string, nextAttemptAt time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.AuditStream{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"last_error":      message,
+			"failure_count":   gorm.Expr("failure_count + 1"),
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record audit stream failure: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BulkLabelJobRepository struct {
+	db *database.DB
+}
+
+func NewBulkLabelJobRepository(db *database.DB) repositories.BulkLabelJobRepository {
+	return &BulkLabelJobRepository{db: db}
+}
+
+func (r *BulkLabelJobRepository) Create(ctx context.Context, job *models.BulkLabelJob) error {
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create bulk label job: %w", err)
+	}
+	return nil
+}
+
+func (r *BulkLabelJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BulkLabelJob, error) {
+	var job models.BulkLabelJob
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("bulk label job not found")
+		}
+		return nil, fmt.Errorf("failed to get bulk label job: %w", err)
+	}
+	return &job, nil
+}
+
+func (r *BulkLabelJobRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID, params repositories.ListParams) ([]models.BulkLabelJob, int64, error) {
+	var jobs []models.BulkLabelJob
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.BulkLabelJob{}).Where("tenant_id = ?", tenantID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count bulk label jobs: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(params.PageSize).Find(&jobs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list bulk label jobs: %w", err)
+	}
+
+	return jobs, total, nil
+}
+
+// ListDue returns pending jobs and jobs whose worker's lease expired, oldest
+// first
+func (r *BulkLabelJobRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.BulkLabelJob, error) {
+	var jobs []models.BulkLabelJob
+	err := r.db.WithContext(ctx).
+		Where("status = ? OR (status = ? AND lease_until <= ?)", models.BulkLabelPending, models.BulkLabelRunning, now).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due bulk label jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Claim leases a due job to one worker, reporting false when another got it
+// first. StartedAt keeps the first claim's time.
+func (r *BulkLabelJobRepository) Claim(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.BulkLabelJob{}).
+		Where("id = ? AND (status = ? OR (status = ? AND lease_until <= ?))",
+			id, models.BulkLabelPending, models.BulkLabelRunning, now).
+		Updates(map[string]interface{}{
+			"status":      models.BulkLabelRunning,
+			"lease_until": leaseUntil,
+			"started_at":  gorm.Expr("COALESCE(started_at, ?)", now),
+			"attempts":    gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim bulk label job: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *BulkLabelJobRepository) SaveProgress(ctx context.Context, id uuid.UUID, cursor uuid.UUID, processed int64, leaseUntil time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.BulkLabelJob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"cursor":      cursor,
+			"processed":   processed,
+			"lease_until": leaseUntil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save bulk label job progress: %w", err)
+	}
+	return nil
+}
+
+// Finish saves the job's outcome and releases its lease
+func (r *BulkLabelJobRepository) Finish(ctx context.Context, job *models.BulkLabelJob) error {
+	err := r.db.WithContext(ctx).Model(&models.BulkLabelJob{}).
+		Where("id = ?", job.ID).
+		Updates(map[string]interface{}{
+			"status":       job.Status,
+			"processed":    job.Processed,
+			"error":        job.Error,
+			"completed_at": job.CompletedAt,
+			"lease_until":  nil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update bulk label job: %w", err)
+	}
+	return nil
+}
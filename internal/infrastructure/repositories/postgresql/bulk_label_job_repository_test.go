@@ -0,0 +1,104 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkLabelJobRepository_ClaimProgressFinish(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewBulkLabelJobRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	admin := db.CreateTestUser(t, tenant)
+
+	job := &models.BulkLabelJob{
+		ID: uuid.New(), TenantID: tenant.ID, Action: models.BulkLabelAdd,
+		Status: models.BulkLabelPending, Matched: 3, RequestedBy: admin.ID,
+	}
+	require.NoError(t, repo.Create(ctx, job))
+
+	now := time.Now()
+	claimed, err := repo.Claim(ctx, job.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = repo.Claim(ctx, job.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	cursor := uuid.New()
+	require.NoError(t, repo.SaveProgress(ctx, job.ID, cursor, 2, now.Add(time.Minute)))
+
+	stored, err := repo.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Cursor)
+	assert.Equal(t, cursor, *stored.Cursor)
+	assert.Equal(t, int64(2), stored.Processed)
+
+	completedAt := now
+	stored.Status = models.BulkLabelCompleted
+	stored.Processed = 3
+	stored.CompletedAt = &completedAt
+	require.NoError(t, repo.Finish(ctx, stored))
+
+	due, err := repo.ListDue(ctx, now.Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+}
+
+func TestDocumentRepository_BulkLabels(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewDocumentRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	first := db.CreateTestDocument(t, tenant, user)
+	second := db.CreateTestDocument(t, tenant, user)
+
+	otherTenant := db.CreateTestTenant(t)
+	otherTag := &models.Tag{ID: uuid.New(), TenantID: otherTenant.ID, Name: "foreign"}
+	tag := &models.Tag{ID: uuid.New(), TenantID: tenant.ID, Name: "archived-fy23"}
+	require.NoError(t, db.DB.Create(otherTag).Error)
+	require.NoError(t, db.DB.Create(tag).Error)
+
+	documentIDs := []uuid.UUID{first.ID, second.ID}
+	require.NoError(t, repo.AddLabels(ctx, tenant.ID, documentIDs, []uuid.UUID{tag.ID, otherTag.ID}, nil))
+	// Adding again is a no-op
+	require.NoError(t, repo.AddLabels(ctx, tenant.ID, documentIDs, []uuid.UUID{tag.ID}, nil))
+
+	tagged, err := repo.ListIDsAfter(ctx, tenant.ID, repositories.DocumentFilters{TagIDs: []uuid.UUID{tag.ID}}, nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, tagged, 2)
+
+	foreign, err := repo.ListIDsAfter(ctx, tenant.ID, repositories.DocumentFilters{TagIDs: []uuid.UUID{otherTag.ID}}, nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, foreign)
+
+	var stored models.Tag
+	require.NoError(t, db.DB.First(&stored, "id = ?", tag.ID).Error)
+	assert.Equal(t, 2, stored.UsageCount)
+
+	require.NoError(t, repo.RemoveLabels(ctx, tenant.ID, []uuid.UUID{first.ID}, []uuid.UUID{tag.ID}, nil))
+
+	tagged, err = repo.ListIDsAfter(ctx, tenant.ID, repositories.DocumentFilters{TagIDs: []uuid.UUID{tag.ID}}, nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{second.ID}, tagged)
+
+	require.NoError(t, db.DB.First(&stored, "id = ?", tag.ID).Error)
+	assert.Equal(t, 1, stored.UsageCount)
+}
@@ -31,9 +31,18 @@ func (r *CategoryRepository) Create(ctx context.Context, category *models.Catego
 }
 
 func (r *CategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	return r.get(r.db.WithContext(ctx), id)
+}
+
+// GetForTenant loads a category only if it belongs to the tenant
+func (r *CategoryRepository) GetForTenant(ctx context.Context, tenantID, id uuid.UUID) (*models.Category, error) {
+	return r.get(r.db.WithContext(ctx).Scopes(tenantScope(tenantID)), id)
+}
+
+func (r *CategoryRepository) get(db *gorm.DB, id uuid.UUID) (*models.Category, error) {
 	var category models.Category
 	// Use selective preloading to optimize performance
-	err := r.db.WithContext(ctx).
+	err := db.
 		Preload("Tenant", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "subdomain")
 		}).
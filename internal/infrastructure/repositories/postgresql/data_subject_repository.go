@@ -0,0 +1,228 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// erasedCommentContent replaces the text of an erased user's comments
+const erasedCommentContent = "[erased]"
+
+type DataSubjectRepository struct {
+	db *database.DB
+}
+
+func NewDataSubjectRepository(db *database.DB) repositories.DataSubjectRepository {
+	return &DataSubjectRepository{db: db}
+}
+
+func (r *DataSubjectRepository) Create(ctx context.Context, request *models.DataSubjectRequest) error {
+	if err := r.db.WithContext(ctx).Omit("Tenant").Create(request).Error; err != nil {
+		return fmt.Errorf("failed to create data subject request: %w", err)
+	}
+	return nil
+}
+
+func (r *DataSubjectRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DataSubjectRequest, error) {
+	var request models.DataSubjectRequest
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&request).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("data subject request not found")
+		}
+		return nil, fmt.Errorf("failed to get data subject request: %w", err)
+	}
+	return &request, nil
+}
+
+func (r *DataSubjectRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID, params repositories.ListParams) ([]models.DataSubjectRequest, int64, error) {
+	var requests []models.DataSubjectRequest
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.DataSubjectRequest{}).Where("tenant_id = ?", tenantID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count data subject requests: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(params.PageSize).Find(&requests).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list data subject requests: %w", err)
+	}
+
+	return requests, total, nil
+}
+
+func (r *DataSubjectRepository) ListBySubject(ctx context.Context, subjectID uuid.UUID) ([]models.DataSubjectRequest, error) {
+	var requests []models.DataSubjectRequest
+	err := r.db.WithContext(ctx).
+		Where("subject_id = ?", subjectID).
+		Order("created_at ASC").Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data subject requests: %w", err)
+	}
+	return requests, nil
+}
+
+// ListDue returns pending requests and requests whose worker's lease
+// expired, oldest first
+func (r *DataSubjectRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.DataSubjectRequest, error) {
+	var requests []models.DataSubjectRequest
+	err := r.db.WithContext(ctx).
+		Where("status = ? OR (status = ? AND lease_until <= ?)", models.DataSubjectPending, models.DataSubjectRunning, now).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due data subject requests: %w", err)
+	}
+	return requests, nil
+}
+
+// Claim leases a due request to one worker, reporting false when another got it first
+func (r *DataSubjectRepository) Claim(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.DataSubjectRequest{}).
+		Where("id = ? AND (status = ? OR (status = ? AND lease_until <= ?))",
+			id, models.DataSubjectPending, models.DataSubjectRunning, now).
+		Updates(map[string]interface{}{
+			"status":      models.DataSubjectRunning,
+			"lease_until": leaseUntil,
+			"started_at":  now,
+			"attempts":    gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim data subject request: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Finish saves the request's outcome and releases its lease
+func (r *DataSubjectRepository) Finish(ctx context.Context, request *models.DataSubjectRequest) error {
+	err := r.db.WithContext(ctx).Model(&models.DataSubjectRequest{}).
+		Where("id = ?", request.ID).
+		Updates(map[string]interface{}{
+			"status":       request.Status,
+			"storage_path": request.StoragePath,
+			"size_bytes":   request.SizeBytes,
+			"error":        request.Error,
+			"completed_at": request.CompletedAt,
+			"lease_until":  nil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update data subject request: %w", err)
+	}
+	return nil
+}
+
+func (r *DataSubjectRepository) ListCreatedDocuments(ctx context.Context, userID uuid.UUID) ([]models.Document, error) {
+	var documents []models.Document
+	err := r.db.WithContext(ctx).
+		Where("created_by = ?", userID).
+		Order("created_at ASC").Find(&documents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list created documents: %w", err)
+	}
+	return documents, nil
+}
+
+func (r *DataSubjectRepository) ListComments(ctx context.Context, userID uuid.UUID) ([]models.DocumentComment, error) {
+	var comments []models.DocumentComment
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").Find(&comments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	return comments, nil
+}
+
+// EraseUser anonymizes the user's account and removes their personal data in
+// one transaction. Documents, workflow history and audit entries stay with
+// the tenant and keep pointing at the anonymized account; the audit hash
+// chain would break if entries were rewritten.
+func (r *DataSubjectRepository) EraseUser(ctx context.Context, userID uuid.UUID, erasedAt time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"email":                         fmt.Sprintf("erased-%s@erased.invalid", userID),
+				"password_hash":                 "",
+				"first_name":                    "Erased",
+				"last_name":                     "User",
+				"department":                    "",
+				"job_title":                     "",
+				"manager_id":                    nil,
+				"is_active":                     false,
+				"mfa_enabled":                   false,
+				"mfa_secret":                    "",
+				"mfa_pending_secret":            "",
+				"mfa_last_used_step":            0,
+				"external_id":                   "",
+				"phone_number":                  "",
+				"phone_verified":                false,
+				"phone_verification_hash":       "",
+				"phone_verification_expires_at": nil,
+				"preferences":                   models.JSONB{},
+				"notification_settings":         models.JSONB{},
+				"updated_at":                    erasedAt,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to anonymize user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("user not found")
+		}
+
+		steps := []struct {
+			name string
+			run  func() error
+		}{
+			{"reporting lines", func() error {
+				return tx.Model(&models.User{}).Where("manager_id = ?", userID).Update("manager_id", nil).Error
+			}},
+			{"comments", func() error {
+				return tx.Model(&models.DocumentComment{}).Where("user_id = ?", userID).
+					Updates(map[string]interface{}{"content": erasedCommentContent, "updated_at": erasedAt}).Error
+			}},
+			{"sms messages", func() error {
+				return tx.Model(&models.SMSMessage{}).Where("user_id = ?", userID).Update("to_number", "").Error
+			}},
+			{"api keys", func() error {
+				return tx.Model(&models.APIKey{}).Where("created_by = ? AND revoked_at IS NULL", userID).
+					Updates(map[string]interface{}{"revoked_at": erasedAt, "updated_at": erasedAt}).Error
+			}},
+			{"recovery codes", func() error {
+				return tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+			}},
+			{"devices", func() error {
+				return tx.Where("user_id = ?", userID).Delete(&models.DeviceToken{}).Error
+			}},
+			{"notifications", func() error {
+				return tx.Where("user_id = ?", userID).Delete(&models.Notification{}).Error
+			}},
+			{"notification deliveries", func() error {
+				return tx.Where("user_id = ?", userID).Delete(&models.NotificationDelivery{}).Error
+			}},
+			{"folder grants", func() error {
+				return tx.Where("user_id = ?", userID).Delete(&models.FolderACL{}).Error
+			}},
+			{"document grants", func() error {
+				return tx.Where("user_id = ?", userID).Delete(&models.DocumentACL{}).Error
+			}},
+		}
+		for _, step := range steps {
+			if err := step.run(); err != nil {
+				return fmt.Errorf("failed to erase %s: %w", step.name, err)
+			}
+		}
+		return nil
+	})
+}
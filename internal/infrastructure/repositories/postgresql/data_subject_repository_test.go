@@ -0,0 +1,106 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSubjectRepository_ClaimAndFinish(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewDataSubjectRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	admin := db.CreateTestUser(t, tenant)
+	subject := db.CreateTestUser(t, tenant)
+
+	request := &models.DataSubjectRequest{
+		ID: uuid.New(), TenantID: tenant.ID, SubjectID: subject.ID,
+		Type: models.DataSubjectExport, Status: models.DataSubjectPending, RequestedBy: admin.ID,
+	}
+	require.NoError(t, repo.Create(ctx, request))
+
+	now := time.Now()
+	due, err := repo.ListDue(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+
+	claimed, err := repo.Claim(ctx, request.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// A second worker can't claim a leased request
+	claimed, err = repo.Claim(ctx, request.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// Once the lease lapses the request is due again
+	later := now.Add(2 * time.Minute)
+	due, err = repo.ListDue(ctx, later, 10)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	completedAt := later
+	request.Status = models.DataSubjectCompleted
+	request.StoragePath = "exports/archive.zip"
+	request.SizeBytes = 42
+	request.CompletedAt = &completedAt
+	require.NoError(t, repo.Finish(ctx, request))
+
+	stored, err := repo.GetByID(ctx, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DataSubjectCompleted, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Nil(t, stored.LeaseUntil)
+
+	due, err = repo.ListDue(ctx, later, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+}
+
+func TestDataSubjectRepository_EraseUser(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewDataSubjectRepository(db.DB)
+	userRepo := NewUserRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	subject := db.CreateTestUser(t, tenant)
+	document := db.CreateTestDocument(t, tenant, subject)
+
+	comment := &models.DocumentComment{
+		ID: uuid.New(), DocumentID: document.ID, UserID: subject.ID, Content: "Call me on 555-0100",
+	}
+	require.NoError(t, db.DB.Create(comment).Error)
+
+	require.NoError(t, repo.EraseUser(ctx, subject.ID, time.Now()))
+
+	erased, err := userRepo.GetByID(ctx, subject.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Erased", erased.FirstName)
+	assert.NotEqual(t, subject.Email, erased.Email)
+	assert.False(t, erased.IsActive)
+
+	comments, err := repo.ListComments(ctx, subject.ID)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, erasedCommentContent, comments[0].Content)
+
+	// The tenant keeps the documents the user created
+	documents, err := repo.ListCreatedDocuments(ctx, subject.ID)
+	require.NoError(t, err)
+	assert.Len(t, documents, 1)
+
+	assert.Error(t, repo.EraseUser(ctx, uuid.New(), time.Now()))
+}
@@ -0,0 +1,110 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DeviceTokenRepository struct {
+	db *database.DB
+}
+
+func NewDeviceTokenRepository(db *database.DB) repositories.DeviceTokenRepository {
+	return &DeviceTokenRepository{db: db}
+}
+
+// Upsert registers a device token. A token the same user registered before is
+// refreshed. A token registered by another user (e.g. a shared device after
+// logout/login) is deleted and registered afresh for the caller; the other
+// user's registration is never carried over.
+func (r *DeviceTokenRepository) Upsert(ctx context.Context, device *models.DeviceToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.DeviceToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token = ?", device.Token).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to look up device token: %w", err)
+		}
+
+		if err == nil && existing.UserID == device.UserID && existing.TenantID == device.TenantID {
+			existing.Platform = device.Platform
+			existing.DeviceName = device.DeviceName
+			existing.AppVersion = device.AppVersion
+			existing.IsActive = true
+			existing.LastSeenAt = time.Now()
+
+			if err := tx.Save(&existing).Error; err != nil {
+				return fmt.Errorf("failed to update device token: %w", err)
+			}
+			*device = existing
+			return nil
+		}
+
+		if err == nil {
+			if err := tx.Delete(&models.DeviceToken{}, "id = ?", existing.ID).Error; err != nil {
+				return fmt.Errorf("failed to remove previous device token: %w", err)
+			}
+		}
+		if err := tx.Create(device).Error; err != nil {
+			return fmt.Errorf("failed to create device token: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *DeviceTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.DeviceToken, error) {
+	var device models.DeviceToken
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&device).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("device token not found")
+		}
+		return nil, fmt.Errorf("failed to get device token: %w", err)
+	}
+	return &device, nil
+}
+
+func (r *DeviceTokenRepository) ListActiveByUser(ctx context.Context, userID uuid.UUID) ([]models.DeviceToken, error) {
+	var devices []models.DeviceToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND is_active = ?", userID, true).
+		Order("last_seen_at DESC").Find(&devices).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list device tokens: %w", err)
+	}
+	return devices, nil
+}
+
+func (r *DeviceTokenRepository) Deactivate(ctx context.Context, token string) error {
+	result := r.db.WithContext(ctx).Model(&models.DeviceToken{}).
+		Where("token = ?", token).
+		Update("is_active", false)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to deactivate device token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("device token not found")
+	}
+	return nil
+}
+
+func (r *DeviceTokenRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.DeviceToken{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete device token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("device token not found")
+	}
+	return nil
+}
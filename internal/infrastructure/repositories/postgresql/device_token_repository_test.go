@@ -0,0 +1,51 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceTokenRepository_Upsert(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewDeviceTokenRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	owner := db.CreateTestUser(t, tenant)
+	other := db.CreateTestUser(t, tenant)
+
+	device := &models.DeviceToken{TenantID: tenant.ID, UserID: owner.ID, Platform: models.DevicePlatformIOS,
+		Token: "apns-token", DeviceName: "Phone", IsActive: true}
+	require.NoError(t, repo.Upsert(ctx, device))
+
+	// The same user refreshes their registration in place
+	refresh := &models.DeviceToken{TenantID: tenant.ID, UserID: owner.ID, Platform: models.DevicePlatformIOS,
+		Token: "apns-token", DeviceName: "Phone", AppVersion: "2.0", IsActive: true}
+	require.NoError(t, repo.Upsert(ctx, refresh))
+	assert.Equal(t, device.ID, refresh.ID)
+	assert.Equal(t, "2.0", refresh.AppVersion)
+
+	// Another user gets a new registration; the owner's is gone, not moved
+	taken := &models.DeviceToken{TenantID: tenant.ID, UserID: other.ID, Platform: models.DevicePlatformIOS,
+		Token: "apns-token", DeviceName: "Shared phone", IsActive: true}
+	require.NoError(t, repo.Upsert(ctx, taken))
+	assert.NotEqual(t, device.ID, taken.ID)
+	assert.Equal(t, other.ID, taken.UserID)
+
+	_, err := repo.GetByID(ctx, device.ID)
+	assert.Error(t, err)
+	devices, err := repo.ListActiveByUser(ctx, owner.ID)
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+	devices, err = repo.ListActiveByUser(ctx, other.ID)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "Shared phone", devices[0].DeviceName)
+}
@@ -0,0 +1,125 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DocumentACLRepository struct {
+	db *database.DB
+}
+
+func NewDocumentACLRepository(db *database.DB) repositories.DocumentACLRepository {
+	return &DocumentACLRepository{db: db}
+}
+
+// Upsert grants a user a permission on a document, replacing any existing grant
+func (r *DocumentACLRepository) Upsert(ctx context.Context, acl *models.DocumentACL) error {
+	var existing models.DocumentACL
+	err := r.db.WithContext(ctx).
+		Where("document_id = ? AND user_id = ?", acl.DocumentID, acl.UserID).
+		First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to look up document ACL: %w", err)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := r.db.WithContext(ctx).Create(acl).Error; err != nil {
+			return fmt.Errorf("failed to create document ACL: %w", err)
+		}
+		return nil
+	}
+
+	existing.Permission = acl.Permission
+	existing.GrantedBy = acl.GrantedBy
+	existing.ExpiresAt = acl.ExpiresAt
+	existing.ExpiryNotifiedAt = nil
+
+	if err := r.db.WithContext(ctx).Save(&existing).Error; err != nil {
+		return fmt.Errorf("failed to update document ACL: %w", err)
+	}
+
+	*acl = existing
+	return nil
+}
+
+func (r *DocumentACLRepository) GetForUser(ctx context.Context, documentID, userID uuid.UUID) (*models.DocumentACL, error) {
+	var acl models.DocumentACL
+	err := r.db.WithContext(ctx).
+		Where("document_id = ? AND user_id = ?", documentID, userID).
+		First(&acl).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("document ACL not found")
+		}
+		return nil, fmt.Errorf("failed to get document ACL: %w", err)
+	}
+	return &acl, nil
+}
+
+func (r *DocumentACLRepository) ListByDocument(ctx context.Context, documentID uuid.UUID) ([]models.DocumentACL, error) {
+	var acls []models.DocumentACL
+	err := r.db.WithContext(ctx).Preload("User").
+		Where("document_id = ?", documentID).
+		Order("created_at ASC").Find(&acls).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document ACLs: %w", err)
+	}
+	return acls, nil
+}
+
+// ListTemporary lists a tenant's time-limited grants that have not yet expired
+func (r *DocumentACLRepository) ListTemporary(ctx context.Context, tenantID uuid.UUID) ([]models.DocumentACL, error) {
+	var acls []models.DocumentACL
+	err := r.db.WithContext(ctx).Preload("User").Preload("Document").
+		Where("tenant_id = ? AND expires_at > ?", tenantID, time.Now()).
+		Order("expires_at ASC").Find(&acls).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list temporary document ACLs: %w", err)
+	}
+	return acls, nil
+}
+
+// ListExpiringBefore lists grants across all tenants that expire before the cutoff,
+// including grants that have already expired
+func (r *DocumentACLRepository) ListExpiringBefore(ctx context.Context, before time.Time) ([]models.DocumentACL, error) {
+	var acls []models.DocumentACL
+	err := r.db.WithContext(ctx).
+		Where("expires_at IS NOT NULL AND expires_at <= ?", before).
+		Order("expires_at ASC").Find(&acls).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring document ACLs: %w", err)
+	}
+	return acls, nil
+}
+
+func (r *DocumentACLRepository) MarkExpiryNotified(ctx context.Context, id uuid.UUID, notifiedAt time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.DocumentACL{}).
+		Where("id = ?", id).
+		Update("expiry_notified_at", notifiedAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark document ACL notified: %w", err)
+	}
+	return nil
+}
+
+func (r *DocumentACLRepository) Delete(ctx context.Context, documentID, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Where("document_id = ? AND user_id = ?", documentID, userID).
+		Delete(&models.DocumentACL{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete document ACL: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("document ACL not found")
+	}
+	return nil
+}
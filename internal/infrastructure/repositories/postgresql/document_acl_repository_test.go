@@ -0,0 +1,149 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentACLRepository_PrivateDocumentVisibility(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	docRepo := NewDocumentRepository(db.DB)
+	repo := NewDocumentACLRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	owner := db.CreateTestUser(t, tenant)
+	viewer := db.CreateTestUser(t, tenant)
+
+	_ = db.CreateTestDocument(t, tenant, owner)
+	private := db.CreateTestDocument(t, tenant, owner)
+	private.IsPrivate = true
+	require.NoError(t, docRepo.Update(ctx, private))
+
+	filters := repositories.DocumentFilters{
+		ViewerID: &viewer.ID,
+		ListParams: repositories.ListParams{
+			Page:     1,
+			PageSize: 10,
+		},
+	}
+
+	// Private documents are hidden until the viewer is granted access
+	_, total, err := docRepo.List(ctx, tenant.ID, filters)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	acl := &models.DocumentACL{
+		TenantID:   tenant.ID,
+		DocumentID: private.ID,
+		UserID:     viewer.ID,
+		Permission: models.DocPermRead,
+		GrantedBy:  owner.ID,
+	}
+	require.NoError(t, repo.Upsert(ctx, acl))
+
+	_, total, err = docRepo.List(ctx, tenant.ID, filters)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	// A read grant doesn't let the viewer change the document
+	editable := repositories.DocumentFilters{EditorID: &viewer.ID, ListParams: filters.ListParams}
+	_, total, err = docRepo.List(ctx, tenant.ID, editable)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// Granting again replaces the permission
+	update := &models.DocumentACL{
+		TenantID:   tenant.ID,
+		DocumentID: private.ID,
+		UserID:     viewer.ID,
+		Permission: models.DocPermWrite,
+		GrantedBy:  owner.ID,
+	}
+	require.NoError(t, repo.Upsert(ctx, update))
+	assert.Equal(t, acl.ID, update.ID)
+
+	found, err := repo.GetForUser(ctx, private.ID, viewer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DocPermWrite, found.Permission)
+
+	_, total, err = docRepo.List(ctx, tenant.ID, editable)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	// The creator always sees their private documents
+	filters.ViewerID = &owner.ID
+	_, total, err = docRepo.List(ctx, tenant.ID, filters)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	require.NoError(t, repo.Delete(ctx, private.ID, viewer.ID))
+	assert.Error(t, repo.Delete(ctx, private.ID, viewer.ID))
+}
+
+func TestDocumentACLRepository_TemporaryGrants(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	docRepo := NewDocumentRepository(db.DB)
+	repo := NewDocumentACLRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	owner := db.CreateTestUser(t, tenant)
+	auditor := db.CreateTestUser(t, tenant)
+
+	private := db.CreateTestDocument(t, tenant, owner)
+	private.IsPrivate = true
+	require.NoError(t, docRepo.Update(ctx, private))
+
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, repo.Upsert(ctx, &models.DocumentACL{
+		TenantID:   tenant.ID,
+		DocumentID: private.ID,
+		UserID:     auditor.ID,
+		Permission: models.DocPermRead,
+		GrantedBy:  owner.ID,
+		ExpiresAt:  &expiresAt,
+	}))
+
+	active, err := repo.ListTemporary(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Len(t, active, 1)
+
+	expiring, err := repo.ListExpiringBefore(ctx, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, expiring, 1)
+
+	// Once expired the grant no longer exposes the private document
+	expired := time.Now().Add(-time.Minute)
+	require.NoError(t, repo.Upsert(ctx, &models.DocumentACL{
+		TenantID:   tenant.ID,
+		DocumentID: private.ID,
+		UserID:     auditor.ID,
+		Permission: models.DocPermRead,
+		GrantedBy:  owner.ID,
+		ExpiresAt:  &expired,
+	}))
+
+	filters := repositories.DocumentFilters{
+		ViewerID:   &auditor.ID,
+		ListParams: repositories.ListParams{Page: 1, PageSize: 10},
+	}
+	_, total, err := docRepo.List(ctx, tenant.ID, filters)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	active, err = repo.ListTemporary(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Empty(t, active)
+}
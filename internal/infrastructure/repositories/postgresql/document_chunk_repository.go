@@ -0,0 +1,85 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+	"gorm.io/gorm"
+)
+
+type DocumentChunkRepository struct {
+	db *database.DB
+}
+
+func NewDocumentChunkRepository(db *database.DB) repositories.DocumentChunkRepository {
+	return &DocumentChunkRepository{db: db}
+}
+
+func (r *DocumentChunkRepository) ReplaceForDocument(ctx context.Context, tenantID, documentID uuid.UUID, chunks []models.DocumentChunk) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND document_id = ?", tenantID, documentID).Delete(&models.DocumentChunk{}).Error; err != nil {
+			return fmt.Errorf("failed to delete document chunks: %w", err)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		for i := range chunks {
+			chunks[i].TenantID = tenantID
+			chunks[i].DocumentID = documentID
+		}
+		if err := tx.CreateInBatches(chunks, 100).Error; err != nil {
+			return fmt.Errorf("failed to create document chunks: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *DocumentChunkRepository) ListByDocument(ctx context.Context, tenantID, documentID uuid.UUID) ([]models.DocumentChunk, error) {
+	var chunks []models.DocumentChunk
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND document_id = ?", tenantID, documentID).
+		Order("chunk_index").Find(&chunks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document chunks: %w", err)
+	}
+	return chunks, nil
+}
+
+func (r *DocumentChunkRepository) Search(ctx context.Context, tenantID uuid.UUID, embedding []float32, documentIDs []uuid.UUID, limit int) ([]repositories.DocumentChunkMatch, error) {
+	query := r.db.WithContext(ctx).Table("document_chunks").
+		Select(`document_chunks.id, document_chunks.tenant_id, document_chunks.document_id, document_chunks.chunk_index,
+			document_chunks.content, document_chunks.page, document_chunks.start_offset, document_chunks.end_offset,
+			document_chunks.created_at, COALESCE(NULLIF(documents.title, ''), documents.file_name) AS title,
+			document_chunks.embedding <=> ? AS distance`, pgvector.NewVector(embedding)).
+		Joins("JOIN documents ON documents.id = document_chunks.document_id AND documents.deleted_at IS NULL").
+		Where("document_chunks.tenant_id = ? AND vector_dims(document_chunks.embedding) = ?", tenantID, len(embedding))
+	if len(documentIDs) > 0 {
+		query = query.Where("document_chunks.document_id IN ?", documentIDs)
+	}
+
+	var matches []repositories.DocumentChunkMatch
+	if err := query.Order("distance").Limit(limit).Scan(&matches).Error; err != nil {
+		return nil, fmt.Errorf("failed to search document chunks: %w", err)
+	}
+	return matches, nil
+}
+
+func (r *DocumentChunkRepository) NearestDocuments(ctx context.Context, tenantID uuid.UUID, embedding []float32, excludeID uuid.UUID, limit int) ([]repositories.DocumentDistance, error) {
+	var distances []repositories.DocumentDistance
+	err := r.db.WithContext(ctx).Table("document_chunks").
+		Select("document_chunks.document_id, MIN(document_chunks.embedding <=> ?) AS distance", pgvector.NewVector(embedding)).
+		Joins("JOIN documents ON documents.id = document_chunks.document_id AND documents.deleted_at IS NULL").
+		Where("document_chunks.tenant_id = ? AND document_chunks.document_id <> ? AND vector_dims(document_chunks.embedding) = ?",
+			tenantID, excludeID, len(embedding)).
+		Group("document_chunks.document_id").
+		Order("distance").Limit(limit).Scan(&distances).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nearest documents: %w", err)
+	}
+	return distances, nil
+}
@@ -0,0 +1,143 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// fingerprintBits is the length of the fuzzy hashes
+	fingerprintBits = 64
+	// fingerprintBandBits is the width of the bands hashes are bucketed by
+	fingerprintBandBits = 8
+)
+
+type DocumentFingerprintRepository struct {
+	db *database.DB
+}
+
+func NewDocumentFingerprintRepository(db *database.DB) repositories.DocumentFingerprintRepository {
+	return &DocumentFingerprintRepository{db: db}
+}
+
+// Upsert stores the fingerprint and replaces the document's bands with its
+// hashes' bands
+func (r *DocumentFingerprintRepository) Upsert(ctx context.Context, fingerprint *models.DocumentFingerprint) error {
+	fingerprint.UpdatedAt = time.Now()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "document_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"text_hash", "image_hash", "updated_at"}),
+		}).Create(fingerprint).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("document_id = ?", fingerprint.DocumentID).Delete(&models.DocumentFingerprintBand{}).Error; err != nil {
+			return err
+		}
+		bands := fingerprintBands(fingerprint)
+		if len(bands) == 0 {
+			return nil
+		}
+		return tx.Create(&bands).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save document fingerprint: %w", err)
+	}
+	return nil
+}
+
+func (r *DocumentFingerprintRepository) NearDuplicates(ctx context.Context, tenantID uuid.UUID, maxDistance, limit int) ([]repositories.DocumentDuplicate, error) {
+	type nearPair struct {
+		OriginalID  uuid.UUID
+		DuplicateID uuid.UUID
+		Distance    int
+		Method      string
+	}
+
+	var pairs []nearPair
+	for _, method := range []string{repositories.DuplicateByText, repositories.DuplicateByImage} {
+		column := method + "_hash"
+		distance := fmt.Sprintf("bit_count((fa.%s # fb.%s)::bit(64))", column, column)
+
+		// Candidates share at least one band of their hashes
+		candidates := r.db.WithContext(ctx).Table("document_fingerprint_bands AS a").
+			Distinct("a.document_id AS a_id", "b.document_id AS b_id").
+			Joins("JOIN document_fingerprint_bands AS b ON b.tenant_id = a.tenant_id AND b.method = a.method AND b.band = a.band AND b.value = a.value AND b.document_id <> a.document_id").
+			Where("a.tenant_id = ? AND a.method = ?", tenantID, method)
+
+		var found []nearPair
+		err := r.db.WithContext(ctx).Table("(?) AS c", candidates).
+			Select("c.a_id AS original_id, c.b_id AS duplicate_id, "+distance+" AS distance").
+			Joins("JOIN document_fingerprints AS fa ON fa.document_id = c.a_id").
+			Joins("JOIN document_fingerprints AS fb ON fb.document_id = c.b_id").
+			Joins("JOIN documents AS da ON da.id = c.a_id AND da.deleted_at IS NULL").
+			Joins("JOIN documents AS db ON db.id = c.b_id AND db.deleted_at IS NULL").
+			Where("(da.created_at, da.id) < (db.created_at, db.id)").
+			Where(distance+" <= ?", maxDistance).
+			Order("distance").Limit(limit).Scan(&found).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to find near-duplicate documents: %w", err)
+		}
+		for _, pair := range found {
+			pair.Method = method
+			pairs = append(pairs, pair)
+		}
+	}
+
+	// The closest pairs of either method
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Distance < pairs[j].Distance })
+	if len(pairs) > limit {
+		pairs = pairs[:limit]
+	}
+
+	duplicates := make([]repositories.DocumentDuplicate, 0, len(pairs))
+	for _, pair := range pairs {
+		duplicates = append(duplicates, repositories.DocumentDuplicate{
+			OriginalID:  pair.OriginalID,
+			DuplicateID: pair.DuplicateID,
+			Similarity:  1 - float64(pair.Distance)/fingerprintBits,
+			Method:      pair.Method,
+		})
+	}
+	return duplicates, nil
+}
+
+// fingerprintBands splits a fingerprint's hashes into their bands. Hashes
+// differing in fewer bits than there are bands share at least one.
+func fingerprintBands(fingerprint *models.DocumentFingerprint) []models.DocumentFingerprintBand {
+	var bands []models.DocumentFingerprintBand
+	hashes := []struct {
+		method string
+		hash   *int64
+	}{
+		{repositories.DuplicateByText, fingerprint.TextHash},
+		{repositories.DuplicateByImage, fingerprint.ImageHash},
+	}
+	for _, h := range hashes {
+		method, hash := h.method, h.hash
+		if hash == nil {
+			continue
+		}
+		for band := 0; band < fingerprintBits/fingerprintBandBits; band++ {
+			bands = append(bands, models.DocumentFingerprintBand{
+				TenantID:   fingerprint.TenantID,
+				DocumentID: fingerprint.DocumentID,
+				Method:     method,
+				Band:       int16(band),
+				Value:      int16(uint64(*hash) >> (band * fingerprintBandBits) & (1<<fingerprintBandBits - 1)),
+			})
+		}
+	}
+	return bands
+}
@@ -0,0 +1,34 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprintBands(t *testing.T) {
+	text := int64(-0x0123456789abcdf0)
+	// Seven bits differ, each in a different band
+	near := text ^ 0x0001010101010101
+
+	bands := fingerprintBands(&models.DocumentFingerprint{TextHash: &text})
+	require.Len(t, bands, fingerprintBits/fingerprintBandBits)
+	for _, band := range bands {
+		assert.Equal(t, repositories.DuplicateByText, band.Method)
+		assert.GreaterOrEqual(t, band.Value, int16(0))
+		assert.Less(t, band.Value, int16(256))
+	}
+
+	nearBands := fingerprintBands(&models.DocumentFingerprint{TextHash: &near, ImageHash: &near})
+	require.Len(t, nearBands, 2*fingerprintBits/fingerprintBandBits)
+	shared := 0
+	for i, band := range bands {
+		if band.Value == nearBands[i].Value {
+			shared++
+		}
+	}
+	assert.Equal(t, 1, shared)
+}
@@ -5,14 +5,24 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/archivus/archivus/internal/domain/repositories"
 	"github.com/archivus/archivus/internal/infrastructure/database"
 	"github.com/archivus/archivus/internal/infrastructure/database/models"
 	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
 	"gorm.io/gorm"
 )
 
+// privateDocumentFilter keeps public documents plus private ones the viewer
+// owns or holds an unexpired grant on
+const privateDocumentFilter = "is_private = ? OR COALESCE(owner_id, created_by) = ? OR id IN (SELECT document_id FROM document_acls WHERE user_id = ? AND (expires_at IS NULL OR expires_at > now()))"
+
+// privateEditableFilter is privateDocumentFilter for changes: grants must
+// allow writing
+const privateEditableFilter = "is_private = ? OR COALESCE(owner_id, created_by) = ? OR id IN (SELECT document_id FROM document_acls WHERE user_id = ? AND permission = ? AND (expires_at IS NULL OR expires_at > now()))"
+
 type DocumentRepository struct {
 	db *database.DB
 }
@@ -42,9 +52,18 @@ func (r *DocumentRepository) Create(ctx context.Context, document *models.Docume
 }
 
 func (r *DocumentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Document, error) {
+	return r.get(r.db.WithContext(ctx), id)
+}
+
+// GetForTenant loads a document only if it belongs to the tenant
+func (r *DocumentRepository) GetForTenant(ctx context.Context, tenantID, id uuid.UUID) (*models.Document, error) {
+	return r.get(r.db.WithContext(ctx).Scopes(tenantScope(tenantID)), id)
+}
+
+func (r *DocumentRepository) get(db *gorm.DB, id uuid.UUID) (*models.Document, error) {
 	var document models.Document
 	// For single document details, preload relationships with selective fields to optimize performance
-	err := r.db.WithContext(ctx).
+	err := db.
 		Preload("Tenant", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "subdomain", "subscription_tier")
 		}).
@@ -101,13 +120,86 @@ func (r *DocumentRepository) List(ctx context.Context, tenantID uuid.UUID, filte
 	var documents []models.Document
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Document{}).Where("tenant_id = ?", tenantID)
+	query := applyDocumentFilters(r.db.WithContext(ctx).Model(&models.Document{}), tenantID, filters)
+
+	// Get total count
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	// Apply pagination and sorting
+	offset := (filters.Page - 1) * filters.PageSize
+	orderBy := "created_at DESC"
+	if filters.SortBy != "" {
+		direction := "ASC"
+		if filters.SortDesc {
+			direction = "DESC"
+		}
+		orderBy = fmt.Sprintf("%s %s", filters.SortBy, direction)
+	}
+
+	// For listing, only preload essential fields to avoid performance issues
+	err := query.
+		Preload("Creator", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "first_name", "last_name", "email")
+		}).
+		Preload("Folder", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "name", "path")
+		}).
+		Select("id", "title", "file_name", "document_type", "status", "file_size", "created_at", "created_by", "owner_id", "folder_id", "tenant_id", "deleted_at", "deleted_by").
+		Order(orderBy).Offset(offset).Limit(filters.PageSize).Find(&documents).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	return documents, total, nil
+}
+
+// ListIDsAfter pages through the IDs of matching documents in ID order, so
+// callers changing the documents as they go don't skip or repeat any
+func (r *DocumentRepository) ListIDsAfter(ctx context.Context, tenantID uuid.UUID, filters repositories.DocumentFilters, after *uuid.UUID, limit int) ([]uuid.UUID, error) {
+	query := applyDocumentFilters(r.db.WithContext(ctx).Model(&models.Document{}), tenantID, filters)
+	if after != nil {
+		query = query.Where("id > ?", *after)
+	}
+
+	var ids []uuid.UUID
+	if err := query.Order("id ASC").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	return ids, nil
+}
+
+// applyDocumentFilters narrows query to the tenant's documents matching filters
+func applyDocumentFilters(query *gorm.DB, tenantID uuid.UUID, filters repositories.DocumentFilters) *gorm.DB {
+	query = query.Where("tenant_id = ?", tenantID)
+
+	if filters.Trashed {
+		query = query.Where("deleted_at IS NOT NULL")
+	} else {
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	if filters.TrashViewerID != nil {
+		query = query.Where("deleted_by = ? OR COALESCE(owner_id, created_by) = ?", *filters.TrashViewerID, *filters.TrashViewerID)
+	}
 
-	// Apply filters
 	if filters.FolderID != nil {
 		query = query.Where("folder_id = ?", *filters.FolderID)
 	}
 
+	if len(filters.ExcludeFolderIDs) > 0 {
+		query = query.Where("folder_id IS NULL OR folder_id NOT IN ?", filters.ExcludeFolderIDs)
+	}
+
+	if filters.ViewerID != nil {
+		query = query.Where(privateDocumentFilter, false, *filters.ViewerID, *filters.ViewerID)
+	}
+
+	if filters.EditorID != nil {
+		query = query.Where(privateEditableFilter, false, *filters.EditorID, *filters.EditorID, models.DocPermWrite)
+	}
+
 	if len(filters.Status) > 0 {
 		query = query.Where("status IN ?", filters.Status)
 	}
@@ -116,10 +208,27 @@ func (r *DocumentRepository) List(ctx context.Context, tenantID uuid.UUID, filte
 		query = query.Where("document_type IN ?", filters.DocumentType)
 	}
 
+	if len(filters.TagIDs) > 0 {
+		query = query.Where("id IN (SELECT document_id FROM document_tags WHERE tag_id IN ?)", filters.TagIDs)
+	}
+
+	if len(filters.CategoryIDs) > 0 {
+		query = query.Where("id IN (SELECT document_id FROM document_categories WHERE category_id IN ?)", filters.CategoryIDs)
+	}
+
+	if filters.Entity != "" {
+		query = query.Where("id IN (SELECT document_id FROM entities WHERE tenant_id = ? AND type = ? AND normalized_value = ?)",
+			tenantID, filters.EntityType, filters.Entity)
+	}
+
 	if len(filters.CreatedBy) > 0 {
 		query = query.Where("created_by IN ?", filters.CreatedBy)
 	}
 
+	if len(filters.OwnedBy) > 0 {
+		query = query.Where("COALESCE(owner_id, created_by) IN ?", filters.OwnedBy)
+	}
+
 	if filters.DateFrom != nil {
 		query = query.Where("created_at >= ?", *filters.DateFrom)
 	}
@@ -128,6 +237,10 @@ func (r *DocumentRepository) List(ctx context.Context, tenantID uuid.UUID, filte
 		query = query.Where("created_at <= ?", *filters.DateTo)
 	}
 
+	if filters.MissingSummary {
+		query = query.Where("summary IS NULL OR summary = ''")
+	}
+
 	if filters.MinSize != nil {
 		query = query.Where("file_size >= ?", *filters.MinSize)
 	}
@@ -142,50 +255,19 @@ func (r *DocumentRepository) List(ctx context.Context, tenantID uuid.UUID, filte
 			searchTerm, searchTerm, searchTerm)
 	}
 
-	// Get total count
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to count documents: %w", err)
-	}
-
-	// Apply pagination and sorting
-	offset := (filters.Page - 1) * filters.PageSize
-	orderBy := "created_at DESC"
-	if filters.SortBy != "" {
-		direction := "ASC"
-		if filters.SortDesc {
-			direction = "DESC"
-		}
-		orderBy = fmt.Sprintf("%s %s", filters.SortBy, direction)
-	}
-
-	// For listing, only preload essential fields to avoid performance issues
-	err := query.
-		Preload("Creator", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id", "first_name", "last_name", "email")
-		}).
-		Preload("Folder", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id", "name", "path")
-		}).
-		Select("id", "title", "file_name", "document_type", "status", "file_size", "created_at", "created_by", "folder_id", "tenant_id").
-		Order(orderBy).Offset(offset).Limit(filters.PageSize).Find(&documents).Error
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list documents: %w", err)
-	}
-
-	return documents, total, nil
+	return query
 }
 
 func (r *DocumentRepository) Search(ctx context.Context, tenantID uuid.UUID, query repositories.SearchQuery) ([]models.Document, error) {
 	var documents []models.Document
 
-	db := r.db.WithContext(ctx).Model(&models.Document{}).Where("tenant_id = ?", tenantID)
+	db := r.db.WithContext(ctx).Model(&models.Document{}).Where("tenant_id = ? AND deleted_at IS NULL", tenantID)
 
 	if query.Query != "" {
 		if query.Fuzzy {
-			// Use PostgreSQL full-text search
-			searchVector := "to_tsvector('english', coalesce(title, '') || ' ' || coalesce(extracted_text, '') || ' ' || coalesce(ocr_text, ''))"
-			searchQuery := "plainto_tsquery('english', ?)"
-			db = db.Where(fmt.Sprintf("%s @@ %s", searchVector, searchQuery), query.Query)
+			// Use PostgreSQL full-text search, in each document's language
+			searchQuery := fmt.Sprintf("plainto_tsquery(%s, ?)", database.DocumentSearchConfig)
+			db = db.Where(fmt.Sprintf("%s @@ %s", database.DocumentSearchVector, searchQuery), query.Query)
 		} else {
 			// Exact search
 			searchTerm := "%" + query.Query + "%"
@@ -202,6 +284,14 @@ func (r *DocumentRepository) Search(ctx context.Context, tenantID uuid.UUID, que
 		db = db.Where("folder_id IN ?", query.FolderIDs)
 	}
 
+	if len(query.ExcludeFolderIDs) > 0 {
+		db = db.Where("folder_id IS NULL OR folder_id NOT IN ?", query.ExcludeFolderIDs)
+	}
+
+	if query.ViewerID != nil {
+		db = db.Where(privateDocumentFilter, false, *query.ViewerID, *query.ViewerID)
+	}
+
 	if query.DateFrom != nil {
 		db = db.Where("created_at >= ?", *query.DateFrom)
 	}
@@ -223,7 +313,7 @@ func (r *DocumentRepository) Search(ctx context.Context, tenantID uuid.UUID, que
 		Preload("Folder", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "path")
 		}).
-		Select("id", "title", "file_name", "document_type", "status", "file_size", "created_at", "created_by", "folder_id", "tenant_id", "extracted_text").
+		Select("id", "title", "file_name", "document_type", "status", "file_size", "created_at", "created_by", "owner_id", "folder_id", "tenant_id", "is_private", "extracted_text").
 		Order("created_at DESC").Limit(limit).Find(&documents).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to search documents: %w", err)
@@ -233,21 +323,39 @@ func (r *DocumentRepository) Search(ctx context.Context, tenantID uuid.UUID, que
 }
 
 func (r *DocumentRepository) SemanticSearch(ctx context.Context, tenantID uuid.UUID, embedding []float32, limit int) ([]models.Document, error) {
-	// This would use pgvector for semantic search
-	// For now, fallback to regular search
-	query := repositories.SearchQuery{
-		Query: "",
-		Limit: limit,
+	if limit == 0 {
+		limit = 50
 	}
 
-	return r.Search(ctx, tenantID, query)
+	// Documents rank by their passage nearest to the embedding
+	nearest := r.db.WithContext(ctx).Table("document_chunks").
+		Select("document_id, MIN(embedding <=> ?) AS distance", pgvector.NewVector(embedding)).
+		Where("tenant_id = ? AND vector_dims(embedding) = ?", tenantID, len(embedding)).
+		Group("document_id")
+
+	var documents []models.Document
+	err := r.db.WithContext(ctx).Model(&models.Document{}).
+		Joins("JOIN (?) AS nearest ON nearest.document_id = documents.id", nearest).
+		Where("documents.tenant_id = ? AND documents.deleted_at IS NULL", tenantID).
+		Preload("Creator", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "first_name", "last_name", "email")
+		}).
+		Preload("Folder", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "name", "path")
+		}).
+		Order("nearest.distance").Limit(limit).Find(&documents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search documents semantically: %w", err)
+	}
+
+	return documents, nil
 }
 
 func (r *DocumentRepository) GetByFolder(ctx context.Context, folderID uuid.UUID, params repositories.ListParams) ([]models.Document, int64, error) {
 	var documents []models.Document
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Document{}).Where("folder_id = ?", folderID)
+	query := r.db.WithContext(ctx).Model(&models.Document{}).Where("folder_id = ? AND deleted_at IS NULL", folderID)
 
 	if params.Search != "" {
 		searchTerm := "%" + params.Search + "%"
@@ -275,7 +383,7 @@ func (r *DocumentRepository) GetByFolder(ctx context.Context, folderID uuid.UUID
 		Preload("Creator", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email")
 		}).
-		Select("id", "title", "file_name", "document_type", "status", "file_size", "created_at", "created_by", "folder_id", "tenant_id").
+		Select("id", "title", "file_name", "document_type", "status", "file_size", "created_at", "created_by", "owner_id", "folder_id", "tenant_id").
 		Order(orderBy).Offset(offset).Limit(params.PageSize).Find(&documents).Error
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get documents by folder: %w", err)
@@ -290,14 +398,14 @@ func (r *DocumentRepository) GetByTags(ctx context.Context, tenantID uuid.UUID,
 	// For documents by tags, use selective preloading to optimize performance
 	err := r.db.WithContext(ctx).
 		Joins("JOIN document_tags ON documents.id = document_tags.document_id").
-		Where("documents.tenant_id = ? AND document_tags.tag_id IN ?", tenantID, tagIDs).
+		Where("documents.tenant_id = ? AND documents.deleted_at IS NULL AND document_tags.tag_id IN ?", tenantID, tagIDs).
 		Preload("Creator", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email")
 		}).
 		Preload("Folder", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "path")
 		}).
-		Select("id", "title", "file_name", "document_type", "status", "file_size", "created_at", "created_by", "folder_id", "tenant_id").
+		Select("id", "title", "file_name", "document_type", "status", "file_size", "created_at", "created_by", "owner_id", "folder_id", "tenant_id").
 		Find(&documents).Error
 
 	if err != nil {
@@ -313,14 +421,14 @@ func (r *DocumentRepository) GetByCategories(ctx context.Context, tenantID uuid.
 	// For documents by categories, use selective preloading to optimize performance
 	err := r.db.WithContext(ctx).
 		Joins("JOIN document_categories ON documents.id = document_categories.document_id").
-		Where("documents.tenant_id = ? AND document_categories.category_id IN ?", tenantID, categoryIDs).
+		Where("documents.tenant_id = ? AND documents.deleted_at IS NULL AND document_categories.category_id IN ?", tenantID, categoryIDs).
 		Preload("Creator", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email")
 		}).
 		Preload("Folder", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "path")
 		}).
-		Select("id", "title", "file_name", "document_type", "status", "file_size", "created_at", "created_by", "folder_id", "tenant_id").
+		Select("id", "title", "file_name", "document_type", "status", "file_size", "created_at", "created_by", "owner_id", "folder_id", "tenant_id").
 		Find(&documents).Error
 
 	if err != nil {
@@ -343,7 +451,7 @@ func (r *DocumentRepository) GetDuplicates(ctx context.Context, tenantID uuid.UU
 	err := r.db.WithContext(ctx).Raw(`
 		SELECT content_hash, array_agg(id) as documents 
 		FROM documents 
-		WHERE tenant_id = ? 
+		WHERE tenant_id = ? AND deleted_at IS NULL
 		GROUP BY content_hash 
 		HAVING count(*) > 1
 	`, tenantID).Scan(&results).Error
@@ -360,6 +468,7 @@ func (r *DocumentRepository) GetDuplicates(ctx context.Context, tenantID uuid.UU
 					DuplicateID:  result.Documents[i],
 					Similarity:   1.0, // 100% for exact hash match
 					ContentMatch: true,
+					Method:       repositories.DuplicateByContentHash,
 				})
 			}
 		}
@@ -373,14 +482,14 @@ func (r *DocumentRepository) GetExpiring(ctx context.Context, tenantID uuid.UUID
 
 	// For expiring documents, use selective preloading to optimize performance
 	err := r.db.WithContext(ctx).
-		Where("tenant_id = ? AND expiry_date IS NOT NULL AND expiry_date <= NOW() + INTERVAL '%d days'", tenantID, days).
+		Where("tenant_id = ? AND deleted_at IS NULL AND expiry_date IS NOT NULL AND expiry_date <= ?", tenantID, time.Now().AddDate(0, 0, days)).
 		Preload("Creator", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email")
 		}).
 		Preload("Folder", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "path")
 		}).
-		Select("id", "title", "file_name", "document_type", "status", "expiry_date", "created_at", "created_by", "folder_id", "tenant_id").
+		Select("id", "title", "file_name", "document_type", "status", "expiry_date", "created_at", "created_by", "owner_id", "folder_id", "tenant_id").
 		Order("expiry_date ASC").
 		Find(&documents).Error
 
@@ -395,7 +504,7 @@ func (r *DocumentRepository) GetFinancialDocuments(ctx context.Context, tenantID
 	var documents []models.Document
 
 	query := r.db.WithContext(ctx).Model(&models.Document{}).
-		Where("tenant_id = ? AND document_type IN ?", tenantID, []models.DocumentType{
+		Where("tenant_id = ? AND deleted_at IS NULL AND document_type IN ?", tenantID, []models.DocumentType{
 			models.DocTypeInvoice,
 			models.DocTypeReceipt,
 			models.DocTypeBankStatement,
@@ -449,7 +558,7 @@ func (r *DocumentRepository) GetFinancialDocuments(ctx context.Context, tenantID
 		Preload("Folder", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "path")
 		}).
-		Select("id", "title", "file_name", "document_type", "status", "amount", "currency", "vendor_name", "customer_name", "document_date", "created_at", "created_by", "folder_id", "tenant_id").
+		Select("id", "title", "file_name", "document_type", "status", "amount", "currency", "vendor_name", "customer_name", "document_date", "created_at", "created_by", "owner_id", "folder_id", "tenant_id").
 		Order(orderBy).Offset(offset).Limit(filters.PageSize).Find(&documents).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to get financial documents: %w", err)
@@ -471,14 +580,101 @@ func (r *DocumentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, sta
 	return nil
 }
 
+// checkStatusColumns maps each document check to the column holding its status
+var checkStatusColumns = map[models.DocumentCheck]string{
+	models.DocCheckVirusScan: "scan_status",
+	models.DocCheckDLP:       "dlp_status",
+}
+
+// ListPendingCheck returns documents the check hasn't run on yet, oldest
+// first, starting after the given document so callers can page past ones
+// they skip
+func (r *DocumentRepository) ListPendingCheck(ctx context.Context, check models.DocumentCheck, after *models.Document, limit int) ([]models.Document, error) {
+	column, ok := checkStatusColumns[check]
+	if !ok {
+		return nil, fmt.Errorf("unknown document check: %s", check)
+	}
+
+	query := r.db.WithContext(ctx).
+		Where(fmt.Sprintf("coalesce(%s, '') IN ?", column), []models.CheckStatus{"", models.CheckStatusPending}).
+		Where("deleted_at IS NULL")
+	if after != nil {
+		query = query.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	var documents []models.Document
+	err := query.
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&documents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents pending %s: %w", check, err)
+	}
+	return documents, nil
+}
+
+// SetCheckResult records a check's outcome. Only the check's status and its
+// entry in the findings are written, so concurrent updates to the document
+// and other checks' results survive. Nil findings remove the entry.
+func (r *DocumentRepository) SetCheckResult(ctx context.Context, id uuid.UUID, check models.DocumentCheck, status models.CheckStatus, findings models.JSONB) error {
+	column, ok := checkStatusColumns[check]
+	if !ok {
+		return fmt.Errorf("unknown document check: %s", check)
+	}
+
+	merged := gorm.Expr("coalesce(check_findings, '{}'::jsonb) - ?::text", string(check))
+	if findings != nil {
+		merged = gorm.Expr("coalesce(check_findings, '{}'::jsonb) || jsonb_build_object(?::text, ?::jsonb)", string(check), findings)
+	}
+
+	result := r.db.WithContext(ctx).Model(&models.Document{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			column:           status,
+			"check_findings": merged,
+			"updated_at":     time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to record document check: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("document not found")
+	}
+	return nil
+}
+
+// SetGateOverride lets the document past the download gate, or with a nil
+// overriddenBy puts it back behind the gate
+func (r *DocumentRepository) SetGateOverride(ctx context.Context, id uuid.UUID, overriddenBy *uuid.UUID, reason string, at time.Time) error {
+	updates := map[string]interface{}{
+		"gate_override_by":     overriddenBy,
+		"gate_override_at":     &at,
+		"gate_override_reason": reason,
+		"updated_at":           time.Now(),
+	}
+	if overriddenBy == nil {
+		updates["gate_override_at"] = nil
+	}
+
+	result := r.db.WithContext(ctx).Model(&models.Document{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update download gate override: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("document not found")
+	}
+	return nil
+}
+
 func (r *DocumentRepository) AssociateTags(ctx context.Context, documentID uuid.UUID, tagIDs []uuid.UUID) error {
 	var document models.Document
 	if err := r.db.WithContext(ctx).First(&document, documentID).Error; err != nil {
 		return fmt.Errorf("document not found: %w", err)
 	}
 
+	// Only the document's tenant's tags can be attached
 	var tags []models.Tag
-	if err := r.db.WithContext(ctx).Find(&tags, tagIDs).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(tenantScope(document.TenantID)).Find(&tags, tagIDs).Error; err != nil {
 		return fmt.Errorf("failed to find tags: %w", err)
 	}
 
@@ -496,7 +692,7 @@ func (r *DocumentRepository) AssociateCategories(ctx context.Context, documentID
 	}
 
 	var categories []models.Category
-	if err := r.db.WithContext(ctx).Find(&categories, categoryIDs).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(tenantScope(document.TenantID)).Find(&categories, categoryIDs).Error; err != nil {
 		return fmt.Errorf("failed to find categories: %w", err)
 	}
 
@@ -507,12 +703,86 @@ func (r *DocumentRepository) AssociateCategories(ctx context.Context, documentID
 	return nil
 }
 
+// AddLabels attaches the tags and categories to every document that lacks
+// them. Only the tenant's documents, tags and categories are linked.
+func (r *DocumentRepository) AddLabels(ctx context.Context, tenantID uuid.UUID, documentIDs, tagIDs, categoryIDs []uuid.UUID) error {
+	if len(documentIDs) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(tagIDs) > 0 {
+			err := tx.Exec(`INSERT INTO document_tags (document_id, tag_id)
+				SELECT d.id, t.id FROM documents d, tags t
+				WHERE d.id IN ? AND d.tenant_id = ? AND t.id IN ? AND t.tenant_id = ?
+				ON CONFLICT DO NOTHING`, documentIDs, tenantID, tagIDs, tenantID).Error
+			if err != nil {
+				return fmt.Errorf("failed to add tags: %w", err)
+			}
+			if err := recountTagUsage(tx, tagIDs); err != nil {
+				return err
+			}
+		}
+		if len(categoryIDs) > 0 {
+			err := tx.Exec(`INSERT INTO document_categories (document_id, category_id)
+				SELECT d.id, c.id FROM documents d, categories c
+				WHERE d.id IN ? AND d.tenant_id = ? AND c.id IN ? AND c.tenant_id = ?
+				ON CONFLICT DO NOTHING`, documentIDs, tenantID, categoryIDs, tenantID).Error
+			if err != nil {
+				return fmt.Errorf("failed to add categories: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveLabels detaches the tags and categories from the documents
+func (r *DocumentRepository) RemoveLabels(ctx context.Context, tenantID uuid.UUID, documentIDs, tagIDs, categoryIDs []uuid.UUID) error {
+	if len(documentIDs) == 0 {
+		return nil
+	}
+
+	tenantDocuments := r.db.Model(&models.Document{}).Select("id").Where("id IN ? AND tenant_id = ?", documentIDs, tenantID)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(tagIDs) > 0 {
+			err := tx.Exec("DELETE FROM document_tags WHERE tag_id IN ? AND document_id IN (?)", tagIDs, tenantDocuments).Error
+			if err != nil {
+				return fmt.Errorf("failed to remove tags: %w", err)
+			}
+			if err := recountTagUsage(tx, tagIDs); err != nil {
+				return err
+			}
+		}
+		if len(categoryIDs) > 0 {
+			err := tx.Exec("DELETE FROM document_categories WHERE category_id IN ? AND document_id IN (?)", categoryIDs, tenantDocuments).Error
+			if err != nil {
+				return fmt.Errorf("failed to remove categories: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// recountTagUsage sets the tags' usage counts to the documents carrying them
+func recountTagUsage(tx *gorm.DB, tagIDs []uuid.UUID) error {
+	err := tx.Model(&models.Tag{}).Where("id IN ?", tagIDs).
+		Update("usage_count", gorm.Expr("(SELECT COUNT(*) FROM document_tags WHERE document_tags.tag_id = tags.id)")).Error
+	if err != nil {
+		return fmt.Errorf("failed to update tag usage: %w", err)
+	}
+	return nil
+}
+
 func (r *DocumentRepository) SoftDelete(ctx context.Context, id uuid.UUID, deletedBy uuid.UUID) error {
+	// Held documents are never deleted; the service reports why. Deleted
+	// documents are still archived so counts that predate the trash agree.
 	result := r.db.WithContext(ctx).Model(&models.Document{}).
-		Where("id = ?", id).
+		Where("id = ? AND legal_hold = ? AND deleted_at IS NULL", id, false).
 		Updates(map[string]interface{}{
 			"status":     models.DocStatusArchived,
 			"updated_by": deletedBy,
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
 		})
 
 	if result.Error != nil {
@@ -524,8 +794,207 @@ func (r *DocumentRepository) SoftDelete(ctx context.Context, id uuid.UUID, delet
 	return nil
 }
 
+// Restore takes a document out of the trash. Its status before deletion
+// isn't kept, so it comes back completed.
+func (r *DocumentRepository) Restore(ctx context.Context, id uuid.UUID, restoredBy uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&models.Document{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"status":     models.DocStatusCompleted,
+			"updated_by": restoredBy,
+			"updated_at": time.Now(),
+			"deleted_at": nil,
+			"deleted_by": nil,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to restore document: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("document not found")
+	}
+	return nil
+}
+
+// ReplaceFile swaps the document's file in one transaction with the
+// version row keeping the old one, guarded by the file and version the
+// caller read
+func (r *DocumentRepository) ReplaceFile(ctx context.Context, document *models.Document, file repositories.DocumentFile, changes string, replacedBy uuid.UUID) (bool, error) {
+	replaced := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.Document{}).
+			Where("id = ? AND storage_path = ? AND version = ? AND deleted_at IS NULL", document.ID, document.StoragePath, document.Version).
+			Updates(map[string]interface{}{
+				"storage_path": file.StoragePath,
+				"file_size":    file.FileSize,
+				"content_hash": file.ContentHash,
+				"version":      document.Version + 1,
+				"updated_by":   replacedBy,
+				"updated_at":   now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to replace document file: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		version := &models.DocumentVersion{
+			ID:            uuid.New(),
+			DocumentID:    document.ID,
+			VersionNumber: document.Version,
+			StoragePath:   document.StoragePath,
+			FileSize:      document.FileSize,
+			ContentHash:   document.ContentHash,
+			Changes:       changes,
+			CreatedBy:     replacedBy,
+			CreatedAt:     now,
+		}
+		if err := tx.Create(version).Error; err != nil {
+			return fmt.Errorf("failed to create document version: %w", err)
+		}
+		replaced = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return replaced, nil
+}
+
+// ListTrashedBefore returns documents of every tenant deleted before the
+// cutoff, oldest first. Held documents are left out; they can't be purged.
+func (r *DocumentRepository) ListTrashedBefore(ctx context.Context, before time.Time, limit int) ([]models.Document, error) {
+	var documents []models.Document
+	err := r.db.WithContext(ctx).
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND legal_hold = ?", before, false).
+		Select("id", "tenant_id", "title", "file_size", "storage_path", "thumbnail_path", "preview_path", "deleted_at", "deleted_by").
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&documents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trashed documents: %w", err)
+	}
+	return documents, nil
+}
+
+// SummarizeRetentionDue groups the due documents by folder and type. A
+// document's age runs from its document date, or its upload when it has
+// none. Trashed documents are left out.
+func (r *DocumentRepository) SummarizeRetentionDue(ctx context.Context, tenantID uuid.UUID, cutoffs repositories.RetentionCutoffs, now time.Time) ([]repositories.RetentionStats, error) {
+	cutoff := "NULL"
+	args := []interface{}{}
+	if cutoffs.Default != nil {
+		cutoff = "?"
+		args = append(args, *cutoffs.Default)
+	}
+	if len(cutoffs.ByType) > 0 {
+		cutoff = "CASE document_type"
+		for documentType, before := range cutoffs.ByType {
+			cutoff += " WHEN ? THEN ?"
+			args = append(args, documentType, before)
+		}
+		if cutoffs.Default != nil {
+			cutoff += " ELSE ? END"
+			args = append(args, *cutoffs.Default)
+		} else {
+			cutoff += " END"
+		}
+	}
+
+	due := "((retention_date IS NOT NULL AND retention_date <= ?) OR " +
+		"(retention_date IS NULL AND COALESCE(document_date, created_at) < " + cutoff + "))"
+	args = append([]interface{}{now}, args...)
+
+	var stats []repositories.RetentionStats
+	err := r.db.WithContext(ctx).Model(&models.Document{}).
+		Select("folder_id, document_type, legal_hold, status = ? AS archived, COUNT(*) AS count, COALESCE(SUM(file_size), 0) AS bytes", models.DocStatusArchived).
+		Where("tenant_id = ? AND deleted_at IS NULL", tenantID).
+		Where(due, args...).
+		Group("folder_id, document_type, legal_hold, archived").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize retention: %w", err)
+	}
+	return stats, nil
+}
+
+func (r *DocumentRepository) Purge(ctx context.Context, id uuid.UUID) ([]string, error) {
+	var paths []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var document models.Document
+		if err := tx.Select("id", "storage_path", "thumbnail_path", "preview_path").
+			Where("id = ? AND deleted_at IS NOT NULL AND legal_hold = ?", id, false).
+			First(&document).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("document not found")
+			}
+			return fmt.Errorf("failed to get document: %w", err)
+		}
+
+		var versionPaths []string
+		if err := tx.Model(&models.DocumentVersion{}).
+			Where("document_id = ?", id).
+			Pluck("storage_path", &versionPaths).Error; err != nil {
+			return fmt.Errorf("failed to list document versions: %w", err)
+		}
+
+		// Children before parents, so foreign keys hold throughout
+		tasks := tx.Model(&models.WorkflowTask{}).Select("id").Where("document_id = ?", id)
+		shares := tx.Model(&models.Share{}).Select("id").Where("document_id = ?", id)
+		deletes := []struct {
+			query *gorm.DB
+			model interface{}
+		}{
+			{tx.Where("task_id IN (?)", tasks), &models.WorkflowChecklistItem{}},
+			{tx.Where("document_id = ?", id), &models.WorkflowTask{}},
+			{tx.Where("document_id = ?", id), &models.WorkflowInstance{}},
+			{tx.Where("share_id IN (?)", shares), &models.ShareAccess{}},
+			{tx.Where("document_id = ?", id), &models.Share{}},
+			{tx.Where("document_id = ?", id), &models.DocumentACL{}},
+			{tx.Where("document_id = ?", id), &models.DocumentComment{}},
+			{tx.Where("document_id = ?", id), &models.DocumentAnalytics{}},
+			{tx.Where("document_id = ?", id), &models.DocumentVersion{}},
+			{tx.Where("document_id = ?", id), &models.AIProcessingJob{}},
+			{tx.Where("document_id = ?", id), &models.LegalHoldDocument{}},
+			{tx.Where("document_id = ?", id), &models.InvoiceLineItem{}},
+			{tx.Where("document_id = ?", id), &models.AIFeedback{}},
+			{tx.Where("document_id = ?", id), &models.DocumentChunk{}},
+			{tx.Where("document_id = ?", id), &models.FilingSuggestion{}},
+			{tx.Where("document_id = ?", id), &models.Entity{}},
+			{tx.Where("document_id = ?", id), &models.DocumentFingerprintBand{}},
+			{tx.Where("document_id = ?", id), &models.DocumentFingerprint{}},
+		}
+		for _, d := range deletes {
+			if err := d.query.Delete(d.model).Error; err != nil {
+				return fmt.Errorf("failed to purge document: %w", err)
+			}
+		}
+		for _, joinTable := range []string{"document_tags", "document_categories"} {
+			if err := tx.Exec("DELETE FROM "+joinTable+" WHERE document_id = ?", id).Error; err != nil {
+				return fmt.Errorf("failed to purge document: %w", err)
+			}
+		}
+		if err := tx.Delete(&models.Document{}, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to purge document: %w", err)
+		}
+
+		for _, path := range append([]string{document.StoragePath, document.ThumbnailPath, document.PreviewPath}, versionPaths...) {
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
 func (r *DocumentRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	result := r.db.WithContext(ctx).Delete(&models.Document{}, id)
+	result := r.db.WithContext(ctx).Where("legal_hold = ?", false).Delete(&models.Document{}, id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete document: %w", result.Error)
 	}
@@ -3,6 +3,7 @@ package postgresql
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/archivus/archivus/internal/domain/repositories"
 	"github.com/archivus/archivus/internal/infrastructure/database/models"
@@ -123,6 +124,54 @@ func TestDocumentRepository_GetByID_NotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "not found")
 }
 
+func TestDocumentRepository_GetForTenant(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewDocumentRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	other := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	document := db.CreateTestDocument(t, tenant, user)
+
+	found, err := repo.GetForTenant(ctx, tenant.ID, document.ID)
+	require.NoError(t, err)
+	assert.Equal(t, document.ID, found.ID)
+
+	// Another tenant's ID finds nothing
+	_, err = repo.GetForTenant(ctx, other.ID, document.ID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestDocumentRepository_AssociateTags_OtherTenant(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewDocumentRepository(db.DB)
+	tagRepo := NewTagRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	other := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	document := db.CreateTestDocument(t, tenant, user)
+
+	own := &models.Tag{ID: uuid.New(), TenantID: tenant.ID, Name: "own"}
+	foreign := &models.Tag{ID: uuid.New(), TenantID: other.ID, Name: "foreign"}
+	require.NoError(t, tagRepo.Create(ctx, own))
+	require.NoError(t, tagRepo.Create(ctx, foreign))
+
+	// Tags of other tenants are ignored
+	require.NoError(t, repo.AssociateTags(ctx, document.ID, []uuid.UUID{own.ID, foreign.ID}))
+	found, err := repo.GetForTenant(ctx, tenant.ID, document.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Tags, 1)
+	assert.Equal(t, own.ID, found.Tags[0].ID)
+}
+
 func TestDocumentRepository_Update(t *testing.T) {
 	db := testutil.NewTestDB(t)
 	defer db.Cleanup(t)
@@ -446,3 +495,53 @@ func TestDocumentRepository_MultiTenant_Isolation(t *testing.T) {
 	assert.Equal(t, tenant1.ID, docs1[0].TenantID)
 	assert.Equal(t, tenant2.ID, docs2[0].TenantID)
 }
+
+func TestDocumentRepository_Trash(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewDocumentRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	kept := db.CreateTestDocument(t, tenant, user)
+	restored := db.CreateTestDocument(t, tenant, user)
+	purged := db.CreateTestDocument(t, tenant, user)
+
+	require.NoError(t, repo.SoftDelete(ctx, restored.ID, user.ID))
+	require.NoError(t, repo.SoftDelete(ctx, purged.ID, user.ID))
+
+	filters := repositories.DocumentFilters{ListParams: repositories.ListParams{Page: 1, PageSize: 10}}
+	live, total, err := repo.List(ctx, tenant.ID, filters)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, kept.ID, live[0].ID)
+
+	filters.Trashed = true
+	_, total, err = repo.List(ctx, tenant.ID, filters)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	// Only trashed documents can be restored or purged
+	assert.Error(t, repo.Restore(ctx, kept.ID, user.ID))
+	_, err = repo.Purge(ctx, kept.ID)
+	assert.Error(t, err)
+
+	require.NoError(t, repo.Restore(ctx, restored.ID, user.ID))
+	document, err := repo.GetByID(ctx, restored.ID)
+	require.NoError(t, err)
+	assert.Nil(t, document.DeletedAt)
+	assert.Equal(t, models.DocStatusCompleted, document.Status)
+
+	expired, err := repo.ListTrashedBefore(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, purged.ID, expired[0].ID)
+
+	paths, err := repo.Purge(ctx, purged.ID)
+	require.NoError(t, err)
+	assert.Contains(t, paths, purged.StoragePath)
+	_, err = repo.GetByID(ctx, purged.ID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DocumentTypeRepository struct {
+	db *database.DB
+}
+
+func NewDocumentTypeRepository(db *database.DB) repositories.DocumentTypeRepository {
+	return &DocumentTypeRepository{db: db}
+}
+
+func (r *DocumentTypeRepository) Create(ctx context.Context, definition *models.DocumentTypeDefinition) error {
+	if err := r.db.WithContext(ctx).Create(definition).Error; err != nil {
+		return fmt.Errorf("failed to create document type: %w", err)
+	}
+	return nil
+}
+
+func (r *DocumentTypeRepository) GetByKey(ctx context.Context, tenantID uuid.UUID, key models.DocumentType) (*models.DocumentTypeDefinition, error) {
+	var definition models.DocumentTypeDefinition
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND key = ?", tenantID, key).
+		First(&definition).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get document type: %w", err)
+	}
+	return &definition, nil
+}
+
+func (r *DocumentTypeRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.DocumentTypeDefinition, error) {
+	var definitions []models.DocumentTypeDefinition
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("key ASC").Find(&definitions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document types: %w", err)
+	}
+	return definitions, nil
+}
+
+// Update saves the type's settings; its key never changes
+func (r *DocumentTypeRepository) Update(ctx context.Context, definition *models.DocumentTypeDefinition) error {
+	err := r.db.WithContext(ctx).Model(&models.DocumentTypeDefinition{}).
+		Where("id = ?", definition.ID).
+		Updates(map[string]interface{}{
+			"name":        definition.Name,
+			"description": definition.Description,
+			"maps_to":     definition.MapsTo,
+			"is_active":   definition.IsActive,
+			"updated_at":  definition.UpdatedAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update document type: %w", err)
+	}
+	return nil
+}
+
+func (r *DocumentTypeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.DocumentTypeDefinition{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete document type: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("document type not found")
+	}
+	return nil
+}
+
+func (r *DocumentTypeRepository) CountUsage(ctx context.Context, tenantID uuid.UUID, key models.DocumentType) (int64, error) {
+	var documents, workflows int64
+	if err := r.db.WithContext(ctx).Model(&models.Document{}).
+		Where("tenant_id = ? AND document_type = ?", tenantID, key).
+		Count(&documents).Error; err != nil {
+		return 0, fmt.Errorf("failed to count documents of type: %w", err)
+	}
+	if err := r.db.WithContext(ctx).Model(&models.Workflow{}).
+		Where("tenant_id = ? AND doc_type = ?", tenantID, key).
+		Count(&workflows).Error; err != nil {
+		return 0, fmt.Errorf("failed to count workflows of type: %w", err)
+	}
+	return documents + workflows, nil
+}
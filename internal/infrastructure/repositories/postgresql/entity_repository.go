@@ -0,0 +1,98 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EntityRepository struct {
+	db *database.DB
+}
+
+func NewEntityRepository(db *database.DB) repositories.EntityRepository {
+	return &EntityRepository{db: db}
+}
+
+func (r *EntityRepository) ReplaceForDocument(ctx context.Context, tenantID, documentID uuid.UUID, entities []models.Entity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND document_id = ?", tenantID, documentID).Delete(&models.Entity{}).Error; err != nil {
+			return fmt.Errorf("failed to delete document entities: %w", err)
+		}
+		if len(entities) == 0 {
+			return nil
+		}
+		for i := range entities {
+			entities[i].TenantID = tenantID
+			entities[i].DocumentID = documentID
+		}
+		if err := tx.CreateInBatches(entities, 100).Error; err != nil {
+			return fmt.Errorf("failed to create document entities: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *EntityRepository) Facets(ctx context.Context, tenantID uuid.UUID, query repositories.EntityFacetQuery) ([]repositories.EntityFacet, error) {
+	// Only documents the caller can see are counted
+	visible := applyDocumentFilters(r.db.WithContext(ctx).Model(&models.Document{}).Select("id"), tenantID, repositories.DocumentFilters{
+		ExcludeFolderIDs: query.ExcludeFolderIDs,
+		ViewerID:         query.ViewerID,
+	})
+
+	db := r.db.WithContext(ctx).Model(&models.Entity{}).
+		Select("type, normalized_value, MIN(value) AS value, COUNT(DISTINCT document_id) AS documents").
+		Where("tenant_id = ? AND document_id IN (?)", tenantID, visible)
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if query.Search != "" {
+		db = db.Where("normalized_value LIKE ?", "%"+query.Search+"%")
+	}
+
+	var facets []repositories.EntityFacet
+	err := db.Group("type, normalized_value").
+		Order("documents DESC, normalized_value").
+		Limit(query.Limit).
+		Scan(&facets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count entities: %w", err)
+	}
+	return facets, nil
+}
+
+func (r *EntityRepository) ListByDocuments(ctx context.Context, tenantID uuid.UUID, documentIDs []uuid.UUID) ([]models.Entity, error) {
+	var entities []models.Entity
+	if len(documentIDs) == 0 {
+		return entities, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND document_id IN ?", tenantID, documentIDs).
+		Order("type, normalized_value").Find(&entities).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document entities: %w", err)
+	}
+	return entities, nil
+}
+
+func (r *EntityRepository) SharedWith(ctx context.Context, tenantID, documentID uuid.UUID, types []models.EntityType, limit int) ([]repositories.EntityOverlap, error) {
+	var overlaps []repositories.EntityOverlap
+	err := r.db.WithContext(ctx).Table("entities AS other").
+		Select("other.document_id, COUNT(*) AS shared").
+		Joins(`JOIN entities AS own ON own.tenant_id = other.tenant_id AND own.type = other.type
+			AND own.normalized_value = other.normalized_value`).
+		Joins("JOIN documents ON documents.id = other.document_id AND documents.deleted_at IS NULL").
+		Where("own.tenant_id = ? AND own.document_id = ? AND other.document_id <> ? AND own.type IN ?",
+			tenantID, documentID, documentID, types).
+		Group("other.document_id").
+		Order("shared DESC").Limit(limit).Scan(&overlaps).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find documents sharing entities: %w", err)
+	}
+	return overlaps, nil
+}
@@ -0,0 +1,60 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FilingSuggestionRepository struct {
+	db *database.DB
+}
+
+func NewFilingSuggestionRepository(db *database.DB) repositories.FilingSuggestionRepository {
+	return &FilingSuggestionRepository{db: db}
+}
+
+func (r *FilingSuggestionRepository) Upsert(ctx context.Context, suggestion *models.FilingSuggestion) error {
+	suggestion.UpdatedAt = time.Now()
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "document_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"job_id", "folder_id", "category_id", "tags", "confidence", "reason",
+			"status", "reviewed_by", "reviewed_at", "updated_at",
+		}),
+	}).Create(suggestion).Error
+	if err != nil {
+		return fmt.Errorf("failed to save filing suggestion: %w", err)
+	}
+	return nil
+}
+
+func (r *FilingSuggestionRepository) GetByDocument(ctx context.Context, tenantID, documentID uuid.UUID) (*models.FilingSuggestion, error) {
+	var suggestion models.FilingSuggestion
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND document_id = ?", tenantID, documentID).
+		First(&suggestion).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get filing suggestion: %w", err)
+	}
+	return &suggestion, nil
+}
+
+func (r *FilingSuggestionRepository) Update(ctx context.Context, suggestion *models.FilingSuggestion) error {
+	suggestion.UpdatedAt = time.Now()
+	if err := r.db.WithContext(ctx).Save(suggestion).Error; err != nil {
+		return fmt.Errorf("failed to update filing suggestion: %w", err)
+	}
+	return nil
+}
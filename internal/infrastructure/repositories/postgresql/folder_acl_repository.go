@@ -0,0 +1,137 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FolderACLRepository struct {
+	db *database.DB
+}
+
+func NewFolderACLRepository(db *database.DB) repositories.FolderACLRepository {
+	return &FolderACLRepository{db: db}
+}
+
+// Upsert grants a permission on a folder, replacing any existing grant for the
+// same user (or role) on that folder
+func (r *FolderACLRepository) Upsert(ctx context.Context, acl *models.FolderACL) error {
+	query := r.db.WithContext(ctx).Where("folder_id = ?", acl.FolderID)
+	if acl.UserID != nil {
+		query = query.Where("user_id = ?", *acl.UserID)
+	} else {
+		query = query.Where("user_id IS NULL AND role = ?", acl.Role)
+	}
+
+	var existing models.FolderACL
+	err := query.First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to look up folder ACL: %w", err)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := r.db.WithContext(ctx).Create(acl).Error; err != nil {
+			return fmt.Errorf("failed to create folder ACL: %w", err)
+		}
+		return nil
+	}
+
+	existing.Permission = acl.Permission
+	existing.GrantedBy = acl.GrantedBy
+	existing.ExpiresAt = acl.ExpiresAt
+	existing.ExpiryNotifiedAt = nil
+
+	if err := r.db.WithContext(ctx).Save(&existing).Error; err != nil {
+		return fmt.Errorf("failed to update folder ACL: %w", err)
+	}
+
+	*acl = existing
+	return nil
+}
+
+func (r *FolderACLRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.FolderACL, error) {
+	var acl models.FolderACL
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&acl).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("folder ACL not found")
+		}
+		return nil, fmt.Errorf("failed to get folder ACL: %w", err)
+	}
+	return &acl, nil
+}
+
+func (r *FolderACLRepository) ListByFolder(ctx context.Context, folderID uuid.UUID) ([]models.FolderACL, error) {
+	var acls []models.FolderACL
+	err := r.db.WithContext(ctx).Preload("User").
+		Where("folder_id = ?", folderID).
+		Order("created_at ASC").Find(&acls).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folder ACLs: %w", err)
+	}
+	return acls, nil
+}
+
+func (r *FolderACLRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.FolderACL, error) {
+	var acls []models.FolderACL
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).Find(&acls).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant folder ACLs: %w", err)
+	}
+	return acls, nil
+}
+
+// ListTemporary lists a tenant's time-limited grants that have not yet expired
+func (r *FolderACLRepository) ListTemporary(ctx context.Context, tenantID uuid.UUID) ([]models.FolderACL, error) {
+	var acls []models.FolderACL
+	err := r.db.WithContext(ctx).Preload("User").Preload("Folder").
+		Where("tenant_id = ? AND expires_at > ?", tenantID, time.Now()).
+		Order("expires_at ASC").Find(&acls).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list temporary folder ACLs: %w", err)
+	}
+	return acls, nil
+}
+
+// ListExpiringBefore lists grants across all tenants that expire before the cutoff,
+// including grants that have already expired
+func (r *FolderACLRepository) ListExpiringBefore(ctx context.Context, before time.Time) ([]models.FolderACL, error) {
+	var acls []models.FolderACL
+	err := r.db.WithContext(ctx).
+		Where("expires_at IS NOT NULL AND expires_at <= ?", before).
+		Order("expires_at ASC").Find(&acls).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring folder ACLs: %w", err)
+	}
+	return acls, nil
+}
+
+func (r *FolderACLRepository) MarkExpiryNotified(ctx context.Context, id uuid.UUID, notifiedAt time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.FolderACL{}).
+		Where("id = ?", id).
+		Update("expiry_notified_at", notifiedAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark folder ACL notified: %w", err)
+	}
+	return nil
+}
+
+func (r *FolderACLRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.FolderACL{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete folder ACL: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("folder ACL not found")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolderACLRepository_Upsert(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	folderRepo := NewFolderRepository(db.DB)
+	repo := NewFolderACLRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	folder := &models.Folder{
+		TenantID:  tenant.ID,
+		Name:      "Finance",
+		Path:      "/finance",
+		CreatedBy: user.ID,
+	}
+	require.NoError(t, folderRepo.Create(ctx, folder))
+
+	acl := &models.FolderACL{
+		TenantID:   tenant.ID,
+		FolderID:   folder.ID,
+		UserID:     &user.ID,
+		Permission: models.FolderPermRead,
+		GrantedBy:  user.ID,
+	}
+	require.NoError(t, repo.Upsert(ctx, acl))
+
+	// Granting again to the same user replaces the permission
+	update := &models.FolderACL{
+		TenantID:   tenant.ID,
+		FolderID:   folder.ID,
+		UserID:     &user.ID,
+		Permission: models.FolderPermManage,
+		GrantedBy:  user.ID,
+	}
+	require.NoError(t, repo.Upsert(ctx, update))
+	assert.Equal(t, acl.ID, update.ID)
+
+	acls, err := repo.ListByFolder(ctx, folder.ID)
+	require.NoError(t, err)
+	require.Len(t, acls, 1)
+	assert.Equal(t, models.FolderPermManage, acls[0].Permission)
+
+	// Deleting the folder removes its grants
+	require.NoError(t, folderRepo.Delete(ctx, folder.ID))
+	acls, err = repo.ListByTenant(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Empty(t, acls)
+}
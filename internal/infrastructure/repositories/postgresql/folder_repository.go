@@ -36,9 +36,18 @@ func (r *FolderRepository) Create(ctx context.Context, folder *models.Folder) er
 }
 
 func (r *FolderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Folder, error) {
+	return r.get(r.db.WithContext(ctx), id)
+}
+
+// GetForTenant loads a folder only if it belongs to the tenant
+func (r *FolderRepository) GetForTenant(ctx context.Context, tenantID, id uuid.UUID) (*models.Folder, error) {
+	return r.get(r.db.WithContext(ctx).Scopes(tenantScope(tenantID)), id)
+}
+
+func (r *FolderRepository) get(db *gorm.DB, id uuid.UUID) (*models.Folder, error) {
 	var folder models.Folder
 	// Use selective preloading to optimize performance
-	err := r.db.WithContext(ctx).
+	err := db.
 		Preload("Tenant", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "subdomain")
 		}).
@@ -137,7 +146,7 @@ func (r *FolderRepository) GetTree(ctx context.Context, tenantID uuid.UUID) ([]r
 func (r *FolderRepository) GetDocumentCount(ctx context.Context, folderID uuid.UUID) (int64, error) {
 	var count int64
 	err := r.db.WithContext(ctx).Model(&models.Document{}).
-		Where("folder_id = ?", folderID).Count(&count).Error
+		Where("folder_id = ? AND deleted_at IS NULL", folderID).Count(&count).Error
 	if err != nil {
 		return 0, fmt.Errorf("failed to count documents in folder: %w", err)
 	}
@@ -235,8 +244,14 @@ func (r *FolderRepository) Delete(ctx context.Context, id uuid.UUID) error {
 		return fmt.Errorf("failed to check for documents in folder: %w", err)
 	}
 
+	// Trashed documents count too; restoring them needs their folder
 	if docCount > 0 {
-		return fmt.Errorf("cannot delete folder containing documents")
+		return fmt.Errorf("cannot delete folder containing documents, including ones in the trash")
+	}
+
+	// Remove access grants along with the folder
+	if err := r.db.WithContext(ctx).Where("folder_id = ?", id).Delete(&models.FolderACL{}).Error; err != nil {
+		return fmt.Errorf("failed to delete folder ACLs: %w", err)
 	}
 
 	// Delete the folder
@@ -249,3 +264,15 @@ func (r *FolderRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+// ListByTenant returns every folder of a tenant with just the fields needed to walk the hierarchy
+func (r *FolderRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Folder, error) {
+	var folders []models.Folder
+	err := r.db.WithContext(ctx).
+		Select("id", "parent_id", "tenant_id", "created_by", "owner_id").
+		Where("tenant_id = ?", tenantID).Find(&folders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	return folders, nil
+}
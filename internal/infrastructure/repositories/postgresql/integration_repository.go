@@ -0,0 +1,252 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IntegrationRepository struct {
+	db *database.DB
+}
+
+func NewIntegrationRepository(db *database.DB) repositories.IntegrationRepository {
+	return &IntegrationRepository{db: db}
+}
+
+func (r *IntegrationRepository) Create(ctx context.Context, integration *models.Integration) error {
+	if err := r.db.WithContext(ctx).Create(integration).Error; err != nil {
+		return fmt.Errorf("failed to create integration: %w", err)
+	}
+	return nil
+}
+
+func (r *IntegrationRepository) GetForTenant(ctx context.Context, tenantID, id uuid.UUID) (*models.Integration, error) {
+	var integration models.Integration
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&integration).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("integration not found")
+		}
+		return nil, fmt.Errorf("failed to get integration: %w", err)
+	}
+	return &integration, nil
+}
+
+func (r *IntegrationRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Integration, error) {
+	var integrations []models.Integration
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at ASC").Find(&integrations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
+	}
+	return integrations, nil
+}
+
+// Update saves the connection's state; an integration deleted meanwhile
+// stays deleted
+func (r *IntegrationRepository) Update(ctx context.Context, integration *models.Integration) error {
+	err := r.db.WithContext(ctx).Model(&models.Integration{}).
+		Where("id = ?", integration.ID).
+		Updates(map[string]interface{}{
+			"account_email":       integration.AccountEmail,
+			"external_account_id": integration.ExternalAccountID,
+			"encrypted_token":     integration.EncryptedToken,
+			"status":              integration.Status,
+			"last_error":          integration.LastError,
+			"connected_by":        integration.ConnectedBy,
+			"updated_at":          integration.UpdatedAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update integration: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the integration with its syncs and their items; the
+// documents they imported stay
+func (r *IntegrationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		syncs := tx.Model(&models.ImportSync{}).Select("id").Where("integration_id = ?", id)
+		if err := tx.Where("sync_id IN (?)", syncs).Delete(&models.ImportSyncItem{}).Error; err != nil {
+			return fmt.Errorf("failed to delete import sync items: %w", err)
+		}
+		if err := tx.Where("integration_id = ?", id).Delete(&models.ImportSync{}).Error; err != nil {
+			return fmt.Errorf("failed to delete import syncs: %w", err)
+		}
+		if err := tx.Where("id = ?", id).Delete(&models.Integration{}).Error; err != nil {
+			return fmt.Errorf("failed to delete integration: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListByExternalAccount returns every tenant's integrations of the
+// provider's account
+func (r *IntegrationRepository) ListByExternalAccount(ctx context.Context, provider, accountID string) ([]models.Integration, error) {
+	var integrations []models.Integration
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND external_account_id = ?", provider, accountID).
+		Find(&integrations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
+	}
+	return integrations, nil
+}
+
+func (r *IntegrationRepository) CreateSync(ctx context.Context, sync *models.ImportSync) error {
+	if err := r.db.WithContext(ctx).Create(sync).Error; err != nil {
+		return fmt.Errorf("failed to create import sync: %w", err)
+	}
+	return nil
+}
+
+func (r *IntegrationRepository) GetSync(ctx context.Context, tenantID, id uuid.UUID) (*models.ImportSync, error) {
+	var sync models.ImportSync
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&sync).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("import sync not found")
+		}
+		return nil, fmt.Errorf("failed to get import sync: %w", err)
+	}
+	return &sync, nil
+}
+
+func (r *IntegrationRepository) ListSyncs(ctx context.Context, tenantID uuid.UUID) ([]models.ImportSync, error) {
+	var syncs []models.ImportSync
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at ASC").Find(&syncs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list import syncs: %w", err)
+	}
+	return syncs, nil
+}
+
+// UpdateSync saves the sync's schedule and progress; a sync deleted while
+// it ran stays deleted
+func (r *IntegrationRepository) UpdateSync(ctx context.Context, sync *models.ImportSync) error {
+	err := r.db.WithContext(ctx).Model(&models.ImportSync{}).
+		Where("id = ?", sync.ID).
+		Updates(map[string]interface{}{
+			"status":         sync.Status,
+			"next_run_at":    sync.NextRunAt,
+			"last_run_at":    sync.LastRunAt,
+			"files_imported": sync.FilesImported,
+			"files_updated":  sync.FilesUpdated,
+			"files_skipped":  sync.FilesSkipped,
+			"files_deleted":  sync.FilesDeleted,
+			"webhook_id":     sync.WebhookID,
+			"last_error":     sync.LastError,
+			"updated_at":     sync.UpdatedAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update import sync: %w", err)
+	}
+	return nil
+}
+
+func (r *IntegrationRepository) DeleteSync(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("sync_id = ?", id).Delete(&models.ImportSyncItem{}).Error; err != nil {
+			return fmt.Errorf("failed to delete import sync items: %w", err)
+		}
+		if err := tx.Where("id = ?", id).Delete(&models.ImportSync{}).Error; err != nil {
+			return fmt.Errorf("failed to delete import sync: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListDueSyncs returns the syncs of every tenant due to run, longest due first
+func (r *IntegrationRepository) ListDueSyncs(ctx context.Context, now time.Time, limit int) ([]models.ImportSync, error) {
+	var syncs []models.ImportSync
+	err := r.db.WithContext(ctx).
+		Where("next_run_at IS NOT NULL AND next_run_at <= ?", now).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&syncs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due import syncs: %w", err)
+	}
+	return syncs, nil
+}
+
+// TriggerSyncs makes the integrations' scheduled syncs due now. Finished
+// and failed syncs have no next run and stay as they are.
+func (r *IntegrationRepository) TriggerSyncs(ctx context.Context, integrationIDs []uuid.UUID, now time.Time) (int64, error) {
+	if len(integrationIDs) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).Model(&models.ImportSync{}).
+		Where("integration_id IN ? AND next_run_at IS NOT NULL AND next_run_at > ?", integrationIDs, now).
+		Updates(map[string]interface{}{"next_run_at": now, "updated_at": now})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to trigger import syncs: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// TriggerWebhookSync makes the sync with the webhook due now, unless it's
+// finished or failed
+func (r *IntegrationRepository) TriggerWebhookSync(ctx context.Context, webhookID string, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.ImportSync{}).
+		Where("webhook_id = ? AND next_run_at IS NOT NULL AND next_run_at > ?", webhookID, now).
+		Updates(map[string]interface{}{"next_run_at": now, "updated_at": now})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to trigger import sync: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *IntegrationRepository) ListItems(ctx context.Context, syncID uuid.UUID) ([]models.ImportSyncItem, error) {
+	var items []models.ImportSyncItem
+	err := r.db.WithContext(ctx).
+		Where("sync_id = ?", syncID).
+		Find(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list import sync items: %w", err)
+	}
+	return items, nil
+}
+
+// SaveItem creates or replaces the sync's item for its remote ID
+func (r *IntegrationRepository) SaveItem(ctx context.Context, item *models.ImportSyncItem) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "sync_id"}, {Name: "remote_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"is_folder", "version", "document_id", "folder_id", "skipped", "updated_at",
+		}),
+	}).Create(item).Error
+	if err != nil {
+		return fmt.Errorf("failed to save import sync item: %w", err)
+	}
+	return nil
+}
+
+func (r *IntegrationRepository) DeleteItems(ctx context.Context, syncID uuid.UUID, remoteIDs []string) error {
+	if len(remoteIDs) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("sync_id = ? AND remote_id IN ?", syncID, remoteIDs).
+		Delete(&models.ImportSyncItem{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete import sync items: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceLineItemRepository struct {
+	db *database.DB
+}
+
+func NewInvoiceLineItemRepository(db *database.DB) repositories.InvoiceLineItemRepository {
+	return &InvoiceLineItemRepository{db: db}
+}
+
+func (r *InvoiceLineItemRepository) ListByDocument(ctx context.Context, tenantID, documentID uuid.UUID) ([]models.InvoiceLineItem, error) {
+	var items []models.InvoiceLineItem
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND document_id = ?", tenantID, documentID).
+		Order("position ASC").
+		Find(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list line items: %w", err)
+	}
+	return items, nil
+}
+
+func (r *InvoiceLineItemRepository) Replace(ctx context.Context, tenantID, documentID uuid.UUID, items []models.InvoiceLineItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockLineItemDocument(tx, tenantID, documentID); err != nil {
+			return err
+		}
+		return replaceLineItems(tx, tenantID, documentID, items)
+	})
+}
+
+// ReplaceExtracted locks the document first, so a correction saved at the
+// same time isn't overwritten
+func (r *InvoiceLineItemRepository) ReplaceExtracted(ctx context.Context, tenantID, documentID uuid.UUID, items []models.InvoiceLineItem) (bool, error) {
+	replaced := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockLineItemDocument(tx, tenantID, documentID); err != nil {
+			return err
+		}
+
+		var corrected int64
+		err := tx.Model(&models.InvoiceLineItem{}).
+			Where("tenant_id = ? AND document_id = ? AND source = ?", tenantID, documentID, models.LineItemManual).
+			Count(&corrected).Error
+		if err != nil {
+			return fmt.Errorf("failed to check line items: %w", err)
+		}
+		if corrected > 0 {
+			return nil
+		}
+
+		replaced = true
+		return replaceLineItems(tx, tenantID, documentID, items)
+	})
+	if err != nil {
+		return false, err
+	}
+	return replaced, nil
+}
+
+func lockLineItemDocument(tx *gorm.DB, tenantID, documentID uuid.UUID) error {
+	var ids []uuid.UUID
+	err := tx.Model(&models.Document{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", documentID, tenantID).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock document: %w", err)
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("document not found")
+	}
+	return nil
+}
+
+func replaceLineItems(tx *gorm.DB, tenantID, documentID uuid.UUID, items []models.InvoiceLineItem) error {
+	err := tx.Where("tenant_id = ? AND document_id = ?", tenantID, documentID).
+		Delete(&models.InvoiceLineItem{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to replace line items: %w", err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	for i := range items {
+		items[i].ID = uuid.Nil
+		items[i].TenantID = tenantID
+		items[i].DocumentID = documentID
+		items[i].Position = i
+	}
+	if err := tx.Create(&items).Error; err != nil {
+		return fmt.Errorf("failed to replace line items: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legalHoldBatchSize bounds the rows inserted per statement when placing a hold
+const legalHoldBatchSize = 500
+
+type LegalHoldRepository struct {
+	db *database.DB
+}
+
+func NewLegalHoldRepository(db *database.DB) repositories.LegalHoldRepository {
+	return &LegalHoldRepository{db: db}
+}
+
+// Create stores the hold and flags its documents as held in one transaction
+func (r *LegalHoldRepository) Create(ctx context.Context, hold *models.LegalHold, documentIDs []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tenant").Create(hold).Error; err != nil {
+			return fmt.Errorf("failed to create legal hold: %w", err)
+		}
+		if len(documentIDs) == 0 {
+			return nil
+		}
+
+		links := make([]models.LegalHoldDocument, len(documentIDs))
+		for i, documentID := range documentIDs {
+			links[i] = models.LegalHoldDocument{LegalHoldID: hold.ID, DocumentID: documentID}
+		}
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(links, legalHoldBatchSize).Error
+		if err != nil {
+			return fmt.Errorf("failed to add documents to legal hold: %w", err)
+		}
+
+		err = tx.Model(&models.Document{}).
+			Where("tenant_id = ? AND id IN (?)", hold.TenantID, documentIDs).
+			Update("legal_hold", true).Error
+		if err != nil {
+			return fmt.Errorf("failed to flag held documents: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *LegalHoldRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.LegalHold, error) {
+	var hold models.LegalHold
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&hold).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("legal hold not found")
+		}
+		return nil, fmt.Errorf("failed to get legal hold: %w", err)
+	}
+	return &hold, nil
+}
+
+func (r *LegalHoldRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID, activeOnly bool, params repositories.ListParams) ([]models.LegalHold, int64, error) {
+	var holds []models.LegalHold
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.LegalHold{}).Where("tenant_id = ?", tenantID)
+	if activeOnly {
+		query = query.Where("lifted_at IS NULL")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count legal holds: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	err := query.Order("placed_at DESC").Offset(offset).Limit(params.PageSize).Find(&holds).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list legal holds: %w", err)
+	}
+
+	return holds, total, nil
+}
+
+// ListByDocument returns every hold, active or lifted, that covers a document
+func (r *LegalHoldRepository) ListByDocument(ctx context.Context, documentID uuid.UUID) ([]models.LegalHold, error) {
+	var holds []models.LegalHold
+	err := r.db.WithContext(ctx).
+		Joins("JOIN legal_hold_documents ON legal_hold_documents.legal_hold_id = legal_holds.id").
+		Where("legal_hold_documents.document_id = ?", documentID).
+		Order("legal_holds.placed_at DESC").
+		Find(&holds).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document legal holds: %w", err)
+	}
+	return holds, nil
+}
+
+func (r *LegalHoldRepository) ListDocumentIDs(ctx context.Context, holdID uuid.UUID) ([]uuid.UUID, error) {
+	var documentIDs []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.LegalHoldDocument{}).
+		Where("legal_hold_id = ?", holdID).
+		Order("document_id").
+		Pluck("document_id", &documentIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legal hold documents: %w", err)
+	}
+	return documentIDs, nil
+}
+
+// Lift releases the hold and clears legal_hold on its documents unless
+// another active hold still covers them
+func (r *LegalHoldRepository) Lift(ctx context.Context, id, liftedBy uuid.UUID, reason string, liftedAt time.Time) (bool, error) {
+	lifted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.LegalHold{}).
+			Where("id = ? AND lifted_at IS NULL", id).
+			Updates(map[string]interface{}{
+				"lifted_by":   liftedBy,
+				"lifted_at":   liftedAt,
+				"lift_reason": reason,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to lift legal hold: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		lifted = true
+
+		stillHeld := tx.Table("legal_hold_documents AS other").
+			Select("1").
+			Joins("JOIN legal_holds ON legal_holds.id = other.legal_hold_id").
+			Where("other.document_id = documents.id AND legal_holds.lifted_at IS NULL")
+		err := tx.Model(&models.Document{}).
+			Where("id IN (?)", tx.Model(&models.LegalHoldDocument{}).Select("document_id").Where("legal_hold_id = ?", id)).
+			Where("NOT EXISTS (?)", stillHeld).
+			Update("legal_hold", false).Error
+		if err != nil {
+			return fmt.Errorf("failed to release held documents: %w", err)
+		}
+		return nil
+	})
+	return lifted, err
+}
@@ -0,0 +1,89 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLegalHoldRepository_PlaceAndLift(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewLegalHoldRepository(db.DB)
+	docRepo := NewDocumentRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	shared := db.CreateTestDocument(t, tenant, user)
+	only := db.CreateTestDocument(t, tenant, user)
+	free := db.CreateTestDocument(t, tenant, user)
+
+	first := &models.LegalHold{
+		ID: uuid.New(), TenantID: tenant.ID, Name: "Smith v. Acme", Reason: "Litigation",
+		Scope: models.LegalHoldScopeDocuments, PlacedBy: user.ID,
+	}
+	require.NoError(t, repo.Create(ctx, first, []uuid.UUID{shared.ID, only.ID}))
+	second := &models.LegalHold{
+		ID: uuid.New(), TenantID: tenant.ID, Name: "Audit 2024", Reason: "Regulator request",
+		Scope: models.LegalHoldScopeDocuments, PlacedBy: user.ID,
+	}
+	require.NoError(t, repo.Create(ctx, second, []uuid.UUID{shared.ID}))
+
+	held := func(id uuid.UUID) bool {
+		doc, err := docRepo.GetByID(ctx, id)
+		require.NoError(t, err)
+		return doc.LegalHold
+	}
+	assert.True(t, held(shared.ID))
+	assert.True(t, held(only.ID))
+	assert.False(t, held(free.ID))
+
+	// Held documents can't be deleted
+	assert.Error(t, docRepo.SoftDelete(ctx, only.ID, user.ID))
+	assert.Error(t, docRepo.Delete(ctx, only.ID))
+	assert.NoError(t, docRepo.SoftDelete(ctx, free.ID, user.ID))
+
+	holds, err := repo.ListByDocument(ctx, shared.ID)
+	require.NoError(t, err)
+	assert.Len(t, holds, 2)
+
+	documentIDs, err := repo.ListDocumentIDs(ctx, first.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{shared.ID, only.ID}, documentIDs)
+
+	lifted, err := repo.Lift(ctx, first.ID, user.ID, "Case settled", time.Now())
+	require.NoError(t, err)
+	assert.True(t, lifted)
+
+	// The second hold still covers the shared document
+	assert.True(t, held(shared.ID))
+	assert.False(t, held(only.ID))
+
+	lifted, err = repo.Lift(ctx, first.ID, user.ID, "Again", time.Now())
+	require.NoError(t, err)
+	assert.False(t, lifted)
+
+	hold, err := repo.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	require.NotNil(t, hold.LiftedAt)
+	assert.Equal(t, "Case settled", hold.LiftReason)
+
+	active, total, err := repo.ListByTenant(ctx, tenant.ID, true, repositories.ListParams{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, active, 1)
+	assert.Equal(t, second.ID, active[0].ID)
+
+	_, total, err = repo.ListByTenant(ctx, tenant.ID, false, repositories.ListParams{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+}
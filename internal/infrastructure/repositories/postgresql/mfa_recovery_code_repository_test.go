@@ -0,0 +1,41 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMFARecoveryCodeRepository_ConsumeIsSingleUse(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewMFARecoveryCodeRepository(db.DB)
+	ctx := context.Background()
+
+	user := db.CreateTestUser(t, db.CreateTestTenant(t))
+	require.NoError(t, repo.ReplaceForUser(ctx, user.ID, []string{"hash-a", "hash-b"}))
+
+	consumed, err := repo.Consume(ctx, user.ID, "hash-a", time.Now())
+	require.NoError(t, err)
+	assert.True(t, consumed)
+
+	// A used code can't be replayed
+	consumed, err = repo.Consume(ctx, user.ID, "hash-a", time.Now())
+	require.NoError(t, err)
+	assert.False(t, consumed)
+
+	count, err := repo.CountUnused(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Regenerating discards the old set
+	require.NoError(t, repo.ReplaceForUser(ctx, user.ID, []string{"hash-c"}))
+	consumed, err = repo.Consume(ctx, user.ID, "hash-b", time.Now())
+	require.NoError(t, err)
+	assert.False(t, consumed)
+}
@@ -0,0 +1,64 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+type NotificationDeliveryRepository struct {
+	db *database.DB
+}
+
+func NewNotificationDeliveryRepository(db *database.DB) repositories.NotificationDeliveryRepository {
+	return &NotificationDeliveryRepository{db: db}
+}
+
+func (r *NotificationDeliveryRepository) Create(ctx context.Context, delivery *models.NotificationDelivery) error {
+	if err := r.db.WithContext(ctx).Create(delivery).Error; err != nil {
+		return fmt.Errorf("failed to queue notification delivery: %w", err)
+	}
+	return nil
+}
+
+// ListDue returns pending deliveries whose next attempt is due, oldest first
+func (r *NotificationDeliveryRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.NotificationDelivery, error) {
+	var deliveries []models.NotificationDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.NotificationDeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Claim leases a due delivery to the caller by pushing its next attempt to
+// leaseUntil. It returns false when another worker claimed it first.
+func (r *NotificationDeliveryRepository) Claim(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.NotificationDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, models.NotificationDeliveryPending, now).
+		Update("next_attempt_at", leaseUntil)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim notification delivery: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *NotificationDeliveryRepository) Update(ctx context.Context, delivery *models.NotificationDelivery) error {
+	result := r.db.WithContext(ctx).Omit("User").Save(delivery)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update notification delivery: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("notification delivery not found")
+	}
+	return nil
+}
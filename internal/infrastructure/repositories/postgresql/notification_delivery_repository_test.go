@@ -0,0 +1,63 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationDeliveryRepository_Outbox(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewNotificationDeliveryRepository(db.DB).(*NotificationDeliveryRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	now := time.Now()
+	due := &models.NotificationDelivery{
+		ID: uuid.New(), TenantID: tenant.ID, UserID: user.ID, Channel: models.NotifyEmail, Recipient: user.Email,
+		Type: "task_assignment", Title: "New task", Message: "Please review", Status: models.NotificationDeliveryPending,
+		MaxAttempts: 5, NextAttemptAt: now.Add(-time.Minute),
+	}
+	require.NoError(t, repo.Create(ctx, due))
+
+	later := *due
+	later.ID = uuid.New()
+	later.NextAttemptAt = now.Add(time.Hour)
+	require.NoError(t, repo.Create(ctx, &later))
+
+	deliveries, err := repo.ListDue(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, due.ID, deliveries[0].ID)
+
+	claimed, err := repo.Claim(ctx, due.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// A second worker loses the race
+	claimed, err = repo.Claim(ctx, due.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// Sent deliveries leave the queue
+	sentAt := time.Now()
+	deliveries[0].Status = models.NotificationDeliverySent
+	deliveries[0].Attempts = 1
+	deliveries[0].SentAt = &sentAt
+	require.NoError(t, repo.Update(ctx, &deliveries[0]))
+
+	deliveries, err = repo.ListDue(ctx, now.Add(2*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, later.ID, deliveries[0].ID)
+}
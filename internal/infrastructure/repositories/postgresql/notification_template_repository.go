@@ -0,0 +1,89 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationTemplateRepository struct {
+	db *database.DB
+}
+
+func NewNotificationTemplateRepository(db *database.DB) repositories.NotificationTemplateRepository {
+	return &NotificationTemplateRepository{db: db}
+}
+
+// Upsert stores a tenant template, replacing any existing one for the same
+// event type and channel
+func (r *NotificationTemplateRepository) Upsert(ctx context.Context, template *models.NotificationTemplate) error {
+	var existing models.NotificationTemplate
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND event_type = ? AND channel = ?", template.TenantID, template.EventType, template.Channel).
+		First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to look up notification template: %w", err)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := r.db.WithContext(ctx).Create(template).Error; err != nil {
+			return fmt.Errorf("failed to create notification template: %w", err)
+		}
+		return nil
+	}
+
+	existing.Subject = template.Subject
+	existing.Body = template.Body
+	existing.UpdatedBy = template.UpdatedBy
+
+	if err := r.db.WithContext(ctx).Save(&existing).Error; err != nil {
+		return fmt.Errorf("failed to update notification template: %w", err)
+	}
+
+	*template = existing
+	return nil
+}
+
+func (r *NotificationTemplateRepository) Get(ctx context.Context, tenantID uuid.UUID, eventType string, channel models.NotificationChannel) (*models.NotificationTemplate, error) {
+	var template models.NotificationTemplate
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND event_type = ? AND channel = ?", tenantID, eventType, channel).
+		First(&template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("notification template not found")
+		}
+		return nil, fmt.Errorf("failed to get notification template: %w", err)
+	}
+	return &template, nil
+}
+
+func (r *NotificationTemplateRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.NotificationTemplate, error) {
+	var templates []models.NotificationTemplate
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("event_type ASC, channel ASC").Find(&templates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (r *NotificationTemplateRepository) Delete(ctx context.Context, tenantID uuid.UUID, eventType string, channel models.NotificationChannel) error {
+	result := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND event_type = ? AND channel = ?", tenantID, eventType, channel).
+		Delete(&models.NotificationTemplate{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete notification template: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("notification template not found")
+	}
+	return nil
+}
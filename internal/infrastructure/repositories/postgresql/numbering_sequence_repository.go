@@ -0,0 +1,145 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NumberingSequenceRepository struct {
+	db *database.DB
+}
+
+func NewNumberingSequenceRepository(db *database.DB) repositories.NumberingSequenceRepository {
+	return &NumberingSequenceRepository{db: db}
+}
+
+func (r *NumberingSequenceRepository) Create(ctx context.Context, sequence *models.NumberingSequence) error {
+	if err := r.db.WithContext(ctx).Create(sequence).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("numbering sequence for '%s' already exists", sequence.DocumentType)
+		}
+		return fmt.Errorf("failed to create numbering sequence: %w", err)
+	}
+	return nil
+}
+
+func (r *NumberingSequenceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.NumberingSequence, error) {
+	var sequence models.NumberingSequence
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&sequence).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("numbering sequence not found")
+		}
+		return nil, fmt.Errorf("failed to get numbering sequence: %w", err)
+	}
+	return &sequence, nil
+}
+
+func (r *NumberingSequenceRepository) GetByDocumentType(ctx context.Context, tenantID uuid.UUID, documentType models.DocumentType) (*models.NumberingSequence, error) {
+	var sequence models.NumberingSequence
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND document_type = ?", tenantID, documentType).
+		First(&sequence).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("numbering sequence not found")
+		}
+		return nil, fmt.Errorf("failed to get numbering sequence by document type: %w", err)
+	}
+	return &sequence, nil
+}
+
+func (r *NumberingSequenceRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.NumberingSequence, error) {
+	var sequences []models.NumberingSequence
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("document_type ASC").Find(&sequences).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list numbering sequences: %w", err)
+	}
+	return sequences, nil
+}
+
+// Update saves the settings only; writing the counter back could undo
+// numbers issued since the sequence was read
+func (r *NumberingSequenceRepository) Update(ctx context.Context, sequence *models.NumberingSequence) error {
+	err := r.db.WithContext(ctx).Model(&models.NumberingSequence{}).
+		Where("id = ?", sequence.ID).
+		Updates(map[string]interface{}{
+			"name":           sequence.Name,
+			"prefix":         sequence.Prefix,
+			"padding":        sequence.Padding,
+			"reset_interval": sequence.ResetInterval,
+			"is_active":      sequence.IsActive,
+			"updated_at":     sequence.UpdatedAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update numbering sequence: %w", err)
+	}
+	return nil
+}
+
+// Allocate locks the sequence's row for the increment, so concurrent
+// uploads are numbered one after another
+func (r *NumberingSequenceRepository) Allocate(ctx context.Context, id uuid.UUID, period string) (int64, error) {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var sequence models.NumberingSequence
+	if err := tx.Raw(`SELECT id, last_value, current_period FROM numbering_sequences
+		WHERE id = ? FOR UPDATE`, id).Scan(&sequence).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to lock numbering sequence: %w", err)
+	}
+	if sequence.ID == uuid.Nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("numbering sequence not found")
+	}
+
+	value := sequence.LastValue + 1
+	if sequence.CurrentPeriod != period {
+		value = 1
+	}
+
+	if err := tx.Model(&models.NumberingSequence{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_value":     value,
+			"current_period": period,
+			"updated_at":     time.Now(),
+		}).Error; err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to advance numbering sequence: %w", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, fmt.Errorf("failed to allocate document number: %w", err)
+	}
+	return value, nil
+}
+
+func (r *NumberingSequenceRepository) SetLastValue(ctx context.Context, id uuid.UUID, lastValue int64, period string) error {
+	err := r.db.WithContext(ctx).Model(&models.NumberingSequence{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_value":     lastValue,
+			"current_period": period,
+			"updated_at":     time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to set numbering sequence value: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package postgresql
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumberingSequenceRepository_Allocate(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewNumberingSequenceRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	admin := db.CreateTestUser(t, tenant)
+
+	sequence := &models.NumberingSequence{
+		ID: uuid.New(), TenantID: tenant.ID, DocumentType: models.DocTypeInvoice,
+		Name: "Invoices", Prefix: "INV-{YYYY}-", Padding: 5,
+		ResetInterval: models.NumberingResetYearly, IsActive: true, CreatedBy: admin.ID,
+	}
+	require.NoError(t, repo.Create(ctx, sequence))
+
+	found, err := repo.GetByDocumentType(ctx, tenant.ID, models.DocTypeInvoice)
+	require.NoError(t, err)
+	assert.Equal(t, sequence.ID, found.ID)
+
+	// One sequence per document type
+	duplicate := *sequence
+	duplicate.ID = uuid.New()
+	assert.Error(t, repo.Create(ctx, &duplicate))
+
+	// Concurrent allocations never share a value
+	values := make(chan int64, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := repo.Allocate(ctx, sequence.ID, "2024")
+			assert.NoError(t, err)
+			values <- value
+		}()
+	}
+	wg.Wait()
+	close(values)
+
+	seen := map[int64]bool{}
+	for value := range values {
+		assert.False(t, seen[value], "value %d issued twice", value)
+		seen[value] = true
+	}
+	assert.Len(t, seen, 10)
+
+	// A new period starts over
+	value, err := repo.Allocate(ctx, sequence.ID, "2025")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+
+	require.NoError(t, repo.SetLastValue(ctx, sequence.ID, 99, "2025"))
+	value, err = repo.Allocate(ctx, sequence.ID, "2025")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), value)
+
+	// Saving settings leaves the counter alone
+	found.Name = "Sales invoices"
+	require.NoError(t, repo.Update(ctx, found))
+	found, err = repo.GetByID(ctx, sequence.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Sales invoices", found.Name)
+	assert.Equal(t, int64(100), found.LastValue)
+}
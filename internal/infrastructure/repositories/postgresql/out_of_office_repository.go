@@ -0,0 +1,63 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OutOfOfficeRepository struct {
+	db *database.DB
+}
+
+func NewOutOfOfficeRepository(db *database.DB) repositories.OutOfOfficeRepository {
+	return &OutOfOfficeRepository{db: db}
+}
+
+func (r *OutOfOfficeRepository) Create(ctx context.Context, period *models.OutOfOffice) error {
+	if err := r.db.WithContext(ctx).Create(period).Error; err != nil {
+		return fmt.Errorf("failed to create out-of-office period: %w", err)
+	}
+	return nil
+}
+
+func (r *OutOfOfficeRepository) Update(ctx context.Context, period *models.OutOfOffice) error {
+	if err := r.db.WithContext(ctx).Save(period).Error; err != nil {
+		return fmt.Errorf("failed to update out-of-office period: %w", err)
+	}
+	return nil
+}
+
+func (r *OutOfOfficeRepository) GetCurrent(ctx context.Context, userID uuid.UUID, at time.Time) (*models.OutOfOffice, error) {
+	var period models.OutOfOffice
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND cancelled_at IS NULL AND ends_at > ?", userID, at).
+		Order("starts_at ASC").First(&period).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("out-of-office period not found")
+		}
+		return nil, fmt.Errorf("failed to get out-of-office period: %w", err)
+	}
+	return &period, nil
+}
+
+// ListOverlapping returns the tenant's periods, other than cancelled ones,
+// that overlap [from, to)
+func (r *OutOfOfficeRepository) ListOverlapping(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]models.OutOfOffice, error) {
+	var periods []models.OutOfOffice
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND cancelled_at IS NULL AND starts_at < ? AND ends_at > ?", tenantID, to, from).
+		Order("starts_at ASC").Find(&periods).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list out-of-office periods: %w", err)
+	}
+	return periods, nil
+}
@@ -0,0 +1,51 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ownershipBatchSize bounds the IDs in one UPDATE statement
+const ownershipBatchSize = 1000
+
+type OwnershipRepository struct {
+	db *database.DB
+}
+
+func NewOwnershipRepository(db *database.DB) repositories.OwnershipRepository {
+	return &OwnershipRepository{db: db}
+}
+
+// Transfer makes ownerID the owner of the tenant's documents and folders in
+// one transaction, so a transfer never applies halfway
+func (r *OwnershipRepository) Transfer(ctx context.Context, tenantID uuid.UUID, documentIDs, folderIDs []uuid.UUID, ownerID uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(documentIDs); start += ownershipBatchSize {
+			end := min(start+ownershipBatchSize, len(documentIDs))
+			err := tx.Model(&models.Document{}).
+				Where("tenant_id = ? AND id IN ?", tenantID, documentIDs[start:end]).
+				Updates(map[string]interface{}{"owner_id": ownerID, "updated_at": at}).Error
+			if err != nil {
+				return fmt.Errorf("failed to transfer documents: %w", err)
+			}
+		}
+
+		for start := 0; start < len(folderIDs); start += ownershipBatchSize {
+			end := min(start+ownershipBatchSize, len(folderIDs))
+			err := tx.Model(&models.Folder{}).
+				Where("tenant_id = ? AND id IN ?", tenantID, folderIDs[start:end]).
+				Updates(map[string]interface{}{"owner_id": ownerID, "updated_at": at}).Error
+			if err != nil {
+				return fmt.Errorf("failed to transfer folders: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,120 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PromptTemplateRepository struct {
+	db *database.DB
+}
+
+func NewPromptTemplateRepository(db *database.DB) repositories.PromptTemplateRepository {
+	return &PromptTemplateRepository{db: db}
+}
+
+// Create saves a template as the next version of its kind and makes it the
+// active one. Concurrent saves of a kind collide on the version index.
+func (r *PromptTemplateRepository) Create(ctx context.Context, template *models.PromptTemplate) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.PromptTemplate{}).
+			Where("tenant_id = ? AND kind = ?", template.TenantID, template.Kind).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PromptTemplate{}).
+			Where("tenant_id = ? AND kind = ? AND is_active", template.TenantID, template.Kind).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+
+		template.Version = latest + 1
+		template.IsActive = true
+		return tx.Create(template).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create prompt template: %w", err)
+	}
+	return nil
+}
+
+// GetActive returns the kind's active template, nil when the default applies
+func (r *PromptTemplateRepository) GetActive(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind) (*models.PromptTemplate, error) {
+	var template models.PromptTemplate
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND kind = ? AND is_active", tenantID, kind).
+		First(&template).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get prompt template: %w", err)
+	}
+	return &template, nil
+}
+
+func (r *PromptTemplateRepository) ListActive(ctx context.Context, tenantID uuid.UUID) ([]models.PromptTemplate, error) {
+	var templates []models.PromptTemplate
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND is_active", tenantID).
+		Order("kind ASC").Find(&templates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (r *PromptTemplateRepository) ListVersions(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind) ([]models.PromptTemplate, error) {
+	var templates []models.PromptTemplate
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND kind = ?", tenantID, kind).
+		Order("version DESC").Find(&templates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt template versions: %w", err)
+	}
+	return templates, nil
+}
+
+// Activate makes a version the kind's active one; returns nil when the
+// version doesn't exist
+func (r *PromptTemplateRepository) Activate(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind, version int) (*models.PromptTemplate, error) {
+	var template models.PromptTemplate
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND kind = ? AND version = ?", tenantID, kind, version).
+			First(&template).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PromptTemplate{}).
+			Where("tenant_id = ? AND kind = ? AND is_active AND id <> ?", tenantID, kind, template.ID).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+		template.IsActive = true
+		return tx.Model(&template).Update("is_active", true).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to activate prompt template: %w", err)
+	}
+	return &template, nil
+}
+
+func (r *PromptTemplateRepository) Deactivate(ctx context.Context, tenantID uuid.UUID, kind models.PromptKind) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.PromptTemplate{}).
+		Where("tenant_id = ? AND kind = ? AND is_active", tenantID, kind).
+		Update("is_active", false)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to deactivate prompt template: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,285 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	activeJobsSubquery = `SELECT 1 FROM ai_processing_jobs
+		WHERE ai_processing_jobs.document_id = documents.id AND ai_processing_jobs.status IN ?`
+	// Trashed documents gave their storage back when deleted; archived ones
+	// still count
+	actualStorageExpr = `((SELECT COALESCE(SUM(documents.file_size), 0) FROM documents
+		WHERE documents.tenant_id = tenants.id AND documents.deleted_at IS NULL) +
+		(SELECT COALESCE(SUM(document_versions.file_size), 0) FROM document_versions
+		JOIN documents ON documents.id = document_versions.document_id
+		WHERE documents.tenant_id = tenants.id AND documents.deleted_at IS NULL))`
+	actualTagUsageExpr = `(SELECT COUNT(*) FROM document_tags
+		WHERE document_tags.tag_id = tags.id)`
+	// Trashed documents no longer count against the quota
+	actualDocumentCountExpr = `(SELECT COUNT(*) FROM documents
+		WHERE documents.tenant_id = tenants.id AND documents.deleted_at IS NULL)`
+)
+
+var activeJobStatuses = []models.ProcessingStatus{models.ProcessingQueued, models.ProcessingInProgress}
+
+type RepairRepository struct {
+	db *database.DB
+}
+
+func NewRepairRepository(db *database.DB) repositories.RepairRepository {
+	return &RepairRepository{db: db}
+}
+
+// FindStuckDocuments returns documents left in processing with no queued or
+// running AI job, last touched before staleBefore
+func (r *RepairRepository) FindStuckDocuments(ctx context.Context, tenantID *uuid.UUID, staleBefore time.Time) ([]repositories.StuckDocument, error) {
+	var stuck []repositories.StuckDocument
+
+	query := r.db.WithContext(ctx).Table("documents").
+		Select(`documents.id AS document_id, documents.tenant_id, documents.file_name, documents.updated_at,
+			(SELECT COUNT(*) FROM ai_processing_jobs WHERE ai_processing_jobs.document_id = documents.id AND ai_processing_jobs.status = ?) AS completed_jobs,
+			(SELECT COUNT(*) FROM ai_processing_jobs WHERE ai_processing_jobs.document_id = documents.id AND ai_processing_jobs.status = ?) AS failed_jobs`,
+			models.ProcessingCompleted, models.ProcessingFailed).
+		Where("documents.status = ?", models.DocStatusProcessing).
+		Where("documents.updated_at < ?", staleBefore).
+		Where("NOT EXISTS ("+activeJobsSubquery+")", activeJobStatuses)
+	if tenantID != nil {
+		query = query.Where("documents.tenant_id = ?", *tenantID)
+	}
+
+	if err := query.Order("documents.updated_at ASC").Scan(&stuck).Error; err != nil {
+		return nil, fmt.Errorf("failed to find stuck documents: %w", err)
+	}
+	return stuck, nil
+}
+
+// ResolveStuckDocument moves a document out of processing, but only if it is
+// still stuck; returns false when another process got there first
+func (r *RepairRepository) ResolveStuckDocument(ctx context.Context, documentID uuid.UUID, status models.DocStatus) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Document{}).
+		Where("id = ? AND status = ?", documentID, models.DocStatusProcessing).
+		Where("NOT EXISTS ("+activeJobsSubquery+")", activeJobStatuses).
+		Update("status", status)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to resolve stuck document: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindDocumentsMissingAnalytics returns documents with no analytics row
+func (r *RepairRepository) FindDocumentsMissingAnalytics(ctx context.Context, tenantID *uuid.UUID) ([]models.Document, error) {
+	var documents []models.Document
+
+	query := r.db.WithContext(ctx).Model(&models.Document{}).
+		Select("documents.id", "documents.tenant_id", "documents.file_name").
+		Where("NOT EXISTS (SELECT 1 FROM document_analytics WHERE document_analytics.document_id = documents.id)")
+	if tenantID != nil {
+		query = query.Where("documents.tenant_id = ?", *tenantID)
+	}
+
+	if err := query.Order("documents.created_at ASC").Find(&documents).Error; err != nil {
+		return nil, fmt.Errorf("failed to find documents missing analytics: %w", err)
+	}
+	return documents, nil
+}
+
+// FindStorageDrift returns tenants whose StorageUsed counter differs from the
+// sum of their document and version sizes
+func (r *RepairRepository) FindStorageDrift(ctx context.Context, tenantID *uuid.UUID) ([]repositories.StorageDrift, error) {
+	var drift []repositories.StorageDrift
+
+	query := r.db.WithContext(ctx).Table("tenants").
+		Select("tenants.id AS tenant_id, tenants.name AS tenant_name, tenants.storage_used, " + actualStorageExpr + " AS actual_usage").
+		Where("tenants.storage_used <> " + actualStorageExpr)
+	if tenantID != nil {
+		query = query.Where("tenants.id = ?", *tenantID)
+	}
+
+	if err := query.Order("tenants.name ASC").Scan(&drift).Error; err != nil {
+		return nil, fmt.Errorf("failed to find storage drift: %w", err)
+	}
+	return drift, nil
+}
+
+// ReconcileStorageUsed sets a tenant's StorageUsed to the sum of its document
+// and version sizes. The returned drift holds the counter before the update
+// and the recomputed usage.
+func (r *RepairRepository) ReconcileStorageUsed(ctx context.Context, tenantID uuid.UUID) (*repositories.StorageDrift, error) {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var before models.Tenant
+	if err := tx.Select("id", "name", "storage_used").Where("id = ?", tenantID).First(&before).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("tenant not found")
+		}
+		return nil, fmt.Errorf("failed to get tenant storage usage: %w", err)
+	}
+
+	// Recomputed inside the update so concurrent uploads are not lost
+	if err := tx.Model(&models.Tenant{}).Where("id = ?", tenantID).
+		Update("storage_used", gorm.Expr(actualStorageExpr)).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to reconcile storage usage: %w", err)
+	}
+
+	var after models.Tenant
+	if err := tx.Select("storage_used").Where("id = ?", tenantID).First(&after).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to get tenant storage usage: %w", err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit storage reconciliation: %w", err)
+	}
+
+	return &repositories.StorageDrift{
+		TenantID:    tenantID,
+		TenantName:  before.Name,
+		StorageUsed: before.StorageUsed,
+		ActualUsage: after.StorageUsed,
+	}, nil
+}
+
+// FindTagUsageDrift returns tags whose UsageCount differs from the number of
+// documents they are attached to
+func (r *RepairRepository) FindTagUsageDrift(ctx context.Context, tenantID *uuid.UUID) ([]repositories.TagUsageDrift, error) {
+	var drift []repositories.TagUsageDrift
+
+	query := r.db.WithContext(ctx).Table("tags").
+		Select("tags.id AS tag_id, tags.tenant_id, tags.name, tags.usage_count, " + actualTagUsageExpr + " AS actual_count").
+		Where("tags.usage_count <> " + actualTagUsageExpr)
+	if tenantID != nil {
+		query = query.Where("tags.tenant_id = ?", *tenantID)
+	}
+
+	if err := query.Order("tags.name ASC").Scan(&drift).Error; err != nil {
+		return nil, fmt.Errorf("failed to find tag usage drift: %w", err)
+	}
+	return drift, nil
+}
+
+// ReconcileTagUsage sets a tag's UsageCount to its attachment count and
+// returns the new value
+func (r *RepairRepository) ReconcileTagUsage(ctx context.Context, tagID uuid.UUID) (int, error) {
+	result := r.db.WithContext(ctx).Model(&models.Tag{}).
+		Where("id = ?", tagID).
+		Update("usage_count", gorm.Expr(actualTagUsageExpr))
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to reconcile tag usage: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf("tag not found")
+	}
+
+	var tag models.Tag
+	if err := r.db.WithContext(ctx).Select("usage_count").Where("id = ?", tagID).First(&tag).Error; err != nil {
+		return 0, fmt.Errorf("failed to get tag usage: %w", err)
+	}
+	return tag.UsageCount, nil
+}
+
+// FindDocumentCountDrift returns tenants whose DocumentCount differs from the
+// number of documents they hold
+func (r *RepairRepository) FindDocumentCountDrift(ctx context.Context, tenantID *uuid.UUID) ([]repositories.DocumentCountDrift, error) {
+	var drift []repositories.DocumentCountDrift
+
+	query := r.db.WithContext(ctx).Table("tenants").
+		Select("tenants.id AS tenant_id, tenants.name AS tenant_name, tenants.document_count, " + actualDocumentCountExpr + " AS actual_count").
+		Where("tenants.document_count <> " + actualDocumentCountExpr)
+	if tenantID != nil {
+		query = query.Where("tenants.id = ?", *tenantID)
+	}
+
+	if err := query.Order("tenants.name ASC").Scan(&drift).Error; err != nil {
+		return nil, fmt.Errorf("failed to find document count drift: %w", err)
+	}
+	return drift, nil
+}
+
+// ReconcileDocumentCount sets a tenant's DocumentCount to the number of
+// documents it holds and returns the new value
+func (r *RepairRepository) ReconcileDocumentCount(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Tenant{}).
+		Where("id = ?", tenantID).
+		Update("document_count", gorm.Expr(actualDocumentCountExpr))
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to reconcile document count: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf("tenant not found")
+	}
+
+	var tenant models.Tenant
+	if err := r.db.WithContext(ctx).Select("document_count").Where("id = ?", tenantID).First(&tenant).Error; err != nil {
+		return 0, fmt.Errorf("failed to get tenant document count: %w", err)
+	}
+	return tenant.DocumentCount, nil
+}
+
+// ListTenantIDs returns every tenant's ID
+func (r *RepairRepository) ListTenantIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var tenantIDs []uuid.UUID
+	if err := r.db.WithContext(ctx).Model(&models.Tenant{}).Order("created_at ASC").Pluck("id", &tenantIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	return tenantIDs, nil
+}
+
+// ListStorageReferences returns every storage path the tenant's rows point
+// at: documents, versions, audit archives, data exports, tenant deletion
+// exports and upload sessions. Trashed documents still reference their files
+// until they're purged. A new storage path column must be added here, or the
+// repair job deletes its files as orphans.
+func (r *RepairRepository) ListStorageReferences(ctx context.Context, tenantID uuid.UUID) ([]repositories.StorageReference, error) {
+	var references []repositories.StorageReference
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT storage_path AS path, 'document' AS resource, id AS resource_id
+			FROM documents WHERE tenant_id = @tenant
+		UNION ALL
+		SELECT thumbnail_path, 'document', id
+			FROM documents WHERE tenant_id = @tenant AND thumbnail_path <> ''
+		UNION ALL
+		SELECT preview_path, 'document', id
+			FROM documents WHERE tenant_id = @tenant AND preview_path <> ''
+		UNION ALL
+		SELECT document_versions.storage_path, 'document_version', document_versions.id
+			FROM document_versions JOIN documents ON documents.id = document_versions.document_id
+			WHERE documents.tenant_id = @tenant
+		UNION ALL
+		SELECT storage_path, 'audit_archive', id
+			FROM audit_archives WHERE tenant_id = @tenant
+		UNION ALL
+		SELECT storage_path, 'data_subject_request', id
+			FROM data_subject_requests WHERE tenant_id = @tenant AND storage_path <> ''
+		UNION ALL
+		SELECT export_path, 'tenant_deletion', id
+			FROM tenant_deletions WHERE tenant_id = @tenant AND export_path <> ''
+		UNION ALL
+		SELECT storage_path, 'upload_session', id
+			FROM upload_sessions WHERE tenant_id = @tenant AND storage_path <> ''`,
+		sql.Named("tenant", tenantID)).Scan(&references).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage references: %w", err)
+	}
+	return references, nil
+}
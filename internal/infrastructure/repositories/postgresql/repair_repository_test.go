@@ -0,0 +1,249 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairRepository_StuckDocuments(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewRepairRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	stale := time.Now().Add(-2 * time.Hour)
+
+	// Stuck: processing, old, only a failed job
+	stuck := db.CreateTestDocument(t, tenant, user)
+	require.NoError(t, db.Model(stuck).Updates(map[string]interface{}{"status": models.DocStatusProcessing, "updated_at": stale}).Error)
+	require.NoError(t, db.Create(&models.AIProcessingJob{
+		ID: uuid.New(), TenantID: tenant.ID, DocumentID: stuck.ID, JobType: "summarization", Status: models.ProcessingFailed,
+	}).Error)
+
+	// Not stuck: still has a queued job
+	active := db.CreateTestDocument(t, tenant, user)
+	require.NoError(t, db.Model(active).Updates(map[string]interface{}{"status": models.DocStatusProcessing, "updated_at": stale}).Error)
+	require.NoError(t, db.Create(&models.AIProcessingJob{
+		ID: uuid.New(), TenantID: tenant.ID, DocumentID: active.ID, JobType: "summarization", Status: models.ProcessingQueued,
+	}).Error)
+
+	found, err := repo.FindStuckDocuments(ctx, &tenant.ID, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, stuck.ID, found[0].DocumentID)
+	assert.Equal(t, int64(1), found[0].FailedJobs)
+	assert.Equal(t, int64(0), found[0].CompletedJobs)
+
+	resolved, err := repo.ResolveStuckDocument(ctx, stuck.ID, models.DocStatusError)
+	require.NoError(t, err)
+	assert.True(t, resolved)
+
+	// Second run is a no-op
+	resolved, err = repo.ResolveStuckDocument(ctx, stuck.ID, models.DocStatusError)
+	require.NoError(t, err)
+	assert.False(t, resolved)
+
+	resolved, err = repo.ResolveStuckDocument(ctx, active.ID, models.DocStatusError)
+	require.NoError(t, err)
+	assert.False(t, resolved)
+}
+
+func TestRepairRepository_FindDocumentsMissingAnalytics(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewRepairRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	tracked := db.CreateTestDocument(t, tenant, user)
+	missing := db.CreateTestDocument(t, tenant, user)
+	require.NoError(t, db.Create(&models.DocumentAnalytics{ID: uuid.New(), TenantID: tenant.ID, DocumentID: tracked.ID}).Error)
+
+	documents, err := repo.FindDocumentsMissingAnalytics(ctx, &tenant.ID)
+	require.NoError(t, err)
+	require.Len(t, documents, 1)
+	assert.Equal(t, missing.ID, documents[0].ID)
+	assert.Equal(t, tenant.ID, documents[0].TenantID)
+}
+
+func TestRepairRepository_StorageDrift(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewRepairRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	db.CreateTestDocument(t, tenant, user)
+	db.CreateTestDocument(t, tenant, user)
+	require.NoError(t, db.Model(tenant).Update("storage_used", 5000).Error)
+
+	drift, err := repo.FindStorageDrift(ctx, &tenant.ID)
+	require.NoError(t, err)
+	require.Len(t, drift, 1)
+	assert.Equal(t, int64(5000), drift[0].StorageUsed)
+	assert.Equal(t, int64(2048), drift[0].ActualUsage)
+
+	reconciled, err := repo.ReconcileStorageUsed(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), reconciled.StorageUsed)
+	assert.Equal(t, int64(2048), reconciled.ActualUsage)
+
+	drift, err = repo.FindStorageDrift(ctx, &tenant.ID)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	_, err = repo.ReconcileStorageUsed(ctx, uuid.New())
+	assert.Error(t, err)
+}
+
+func TestRepairRepository_StorageDrift_ExcludesTrash(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewRepairRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	db.CreateTestDocument(t, tenant, user)
+	trashed := db.CreateTestDocument(t, tenant, user)
+	require.NoError(t, db.Model(trashed).Updates(map[string]interface{}{"status": models.DocStatusArchived, "deleted_at": time.Now()}).Error)
+	require.NoError(t, db.Model(tenant).Update("storage_used", 1024).Error)
+
+	// Deleting released the trashed document's storage, so there is no drift
+	drift, err := repo.FindStorageDrift(ctx, &tenant.ID)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+}
+
+func TestRepairRepository_StorageDrift_IncludesVersions(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewRepairRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	document := db.CreateTestDocument(t, tenant, user)
+	require.NoError(t, db.Create(&models.DocumentVersion{
+		ID: uuid.New(), DocumentID: document.ID, VersionNumber: 1, StoragePath: "/test/path/v1.pdf",
+		FileSize: 512, ContentHash: "hash-v1", CreatedBy: user.ID,
+	}).Error)
+
+	reconciled, err := repo.ReconcileStorageUsed(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1536), reconciled.ActualUsage)
+}
+
+func TestRepairRepository_DocumentCountDrift(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewRepairRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	db.CreateTestDocument(t, tenant, user)
+	trashed := db.CreateTestDocument(t, tenant, user)
+	require.NoError(t, db.Model(trashed).Updates(map[string]interface{}{"status": models.DocStatusArchived, "deleted_at": time.Now()}).Error)
+	// Archived documents still count; their files are still stored
+	archived := db.CreateTestDocument(t, tenant, user)
+	require.NoError(t, db.Model(archived).Update("status", models.DocStatusArchived).Error)
+	require.NoError(t, db.Model(tenant).Update("document_count", 7).Error)
+
+	drift, err := repo.FindDocumentCountDrift(ctx, &tenant.ID)
+	require.NoError(t, err)
+	require.Len(t, drift, 1)
+	assert.Equal(t, int64(7), drift[0].DocumentCount)
+	assert.Equal(t, int64(2), drift[0].ActualCount)
+
+	count, err := repo.ReconcileDocumentCount(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	drift, err = repo.FindDocumentCountDrift(ctx, &tenant.ID)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+}
+
+func TestRepairRepository_TagUsageDrift(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewRepairRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	document := db.CreateTestDocument(t, tenant, user)
+
+	tag := &models.Tag{ID: uuid.New(), TenantID: tenant.ID, Name: "drifted", UsageCount: 7}
+	require.NoError(t, db.Create(tag).Error)
+	require.NoError(t, db.Model(document).Association("Tags").Append(tag))
+
+	drift, err := repo.FindTagUsageDrift(ctx, &tenant.ID)
+	require.NoError(t, err)
+	require.Len(t, drift, 1)
+	assert.Equal(t, tag.ID, drift[0].TagID)
+	assert.Equal(t, 7, drift[0].UsageCount)
+	assert.Equal(t, 1, drift[0].ActualCount)
+
+	count, err := repo.ReconcileTagUsage(ctx, tag.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	drift, err = repo.FindTagUsageDrift(ctx, &tenant.ID)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+}
+
+func TestRepairRepository_ListStorageReferences(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewRepairRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	other := db.CreateTestTenant(t)
+	otherUser := db.CreateTestUser(t, other)
+
+	document := db.CreateTestDocument(t, tenant, user)
+	require.NoError(t, db.Model(document).Update("thumbnail_path", "thumbs/document.png").Error)
+	require.NoError(t, db.Create(&models.DocumentVersion{
+		ID: uuid.New(), DocumentID: document.ID, VersionNumber: 1, StoragePath: "versions/document-v1.pdf",
+		FileSize: 512, ContentHash: "hash-v1", CreatedBy: user.ID,
+	}).Error)
+	db.CreateTestDocument(t, other, otherUser)
+
+	references, err := repo.ListStorageReferences(ctx, tenant.ID)
+	require.NoError(t, err)
+
+	paths := make(map[string]string, len(references))
+	for _, reference := range references {
+		paths[reference.Path] = reference.Resource
+	}
+	assert.Equal(t, map[string]string{
+		document.StoragePath:       "document",
+		"thumbs/document.png":      "document",
+		"versions/document-v1.pdf": "document_version",
+	}, paths)
+}
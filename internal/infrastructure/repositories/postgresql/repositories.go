@@ -10,19 +10,62 @@ import (
 
 // Repositories holds all repository implementations
 type Repositories struct {
-	TenantRepo       repositories.TenantRepository
-	UserRepo         repositories.UserRepository
-	DocumentRepo     repositories.DocumentRepository
-	FolderRepo       repositories.FolderRepository
-	TagRepo          repositories.TagRepository
-	CategoryRepo     repositories.CategoryRepository
-	WorkflowRepo     repositories.WorkflowRepository
-	WorkflowTaskRepo repositories.WorkflowTaskRepository
-	AIJobRepo        repositories.AIProcessingJobRepository
-	AuditRepo        repositories.AuditLogRepository
-	ShareRepo        repositories.ShareRepository
-	AnalyticsRepo    repositories.AnalyticsRepository
-	NotificationRepo repositories.NotificationRepository
+	TenantRepo         repositories.TenantRepository
+	UserRepo           repositories.UserRepository
+	RoleRepo           repositories.RoleRepository
+	APIKeyRepo         repositories.APIKeyRepository
+	AIKeyRepo          repositories.TenantAIKeyRepository
+	MFACodeRepo        repositories.MFARecoveryCodeRepository
+	DocumentRepo       repositories.DocumentRepository
+	FolderRepo         repositories.FolderRepository
+	FolderACLRepo      repositories.FolderACLRepository
+	DocumentACLRepo    repositories.DocumentACLRepository
+	TagRepo            repositories.TagRepository
+	CategoryRepo       repositories.CategoryRepository
+	WorkflowRepo       repositories.WorkflowRepository
+	WorkflowTaskRepo   repositories.WorkflowTaskRepository
+	InstanceRepo       repositories.WorkflowInstanceRepository
+	ChecklistRepo      repositories.WorkflowChecklistRepository
+	AIJobRepo          repositories.AIProcessingJobRepository
+	AuditRepo          repositories.AuditLogRepository
+	ShareRepo          repositories.ShareRepository
+	ShareAccessRepo    repositories.ShareAccessRepository
+	AnalyticsRepo      repositories.AnalyticsRepository
+	NotificationRepo   repositories.NotificationRepository
+	DeliveryRepo       repositories.NotificationDeliveryRepository
+	DeviceTokenRepo    repositories.DeviceTokenRepository
+	SMSMessageRepo     repositories.SMSMessageRepository
+	TemplateRepo       repositories.NotificationTemplateRepository
+	RepairRepo         repositories.RepairRepository
+	WebhookRepo        repositories.WebhookRepository
+	ScheduledJobRepo   repositories.ScheduledJobRepository
+	LegalHoldRepo      repositories.LegalHoldRepository
+	DataSubjectRepo    repositories.DataSubjectRepository
+	AuditStreamRepo    repositories.AuditStreamRepository
+	OwnershipRepo      repositories.OwnershipRepository
+	TenantDeletionRepo repositories.TenantDeletionRepository
+	APIUsageRepo       repositories.APIUsageRepository
+	SuggestionRepo     repositories.SearchSuggestionRepository
+	VendorProfileRepo  repositories.VendorProfileRepository
+	UploadSessionRepo  repositories.UploadSessionRepository
+	OutOfOfficeRepo    repositories.OutOfOfficeRepository
+	TransferRepo       repositories.TenantTransferRepository
+	HeartbeatRepo      repositories.WorkerHeartbeatRepository
+	BulkLabelRepo      repositories.BulkLabelJobRepository
+	NumberingRepo      repositories.NumberingSequenceRepository
+	IntegrationRepo    repositories.IntegrationRepository
+	ArchivalRepo       repositories.ArchivalRepository
+	AccountingRepo     repositories.AccountingRepository
+	LineItemRepo       repositories.InvoiceLineItemRepository
+	AIFeedbackRepo     repositories.AIFeedbackRepository
+	AIUsageRepo        repositories.AIUsageRepository
+	DocumentChunkRepo  repositories.DocumentChunkRepository
+	FilingRepo         repositories.FilingSuggestionRepository
+	EntityRepo         repositories.EntityRepository
+	FingerprintRepo    repositories.DocumentFingerprintRepository
+	ReprocessRepo      repositories.AIReprocessBatchRepository
+	PromptRepo         repositories.PromptTemplateRepository
+	DocTypeRepo        repositories.DocumentTypeRepository
 
 	// Internal reference to database for health checks
 	db *database.DB
@@ -31,20 +74,63 @@ type Repositories struct {
 // NewRepositories creates a new repositories container
 func NewRepositories(db *database.DB) *Repositories {
 	return &Repositories{
-		TenantRepo:       NewTenantRepository(db),
-		UserRepo:         NewUserRepository(db),
-		DocumentRepo:     NewDocumentRepository(db),
-		FolderRepo:       NewFolderRepository(db),
-		TagRepo:          NewTagRepository(db),
-		CategoryRepo:     NewCategoryRepository(db),
-		WorkflowRepo:     NewWorkflowRepository(db),
-		WorkflowTaskRepo: NewWorkflowTaskRepository(db),
-		AIJobRepo:        NewAIProcessingJobRepository(db),
-		AuditRepo:        NewAuditLogRepository(db),
-		ShareRepo:        NewShareRepository(db),
-		AnalyticsRepo:    NewAnalyticsRepository(db),
-		NotificationRepo: NewNotificationRepository(db),
-		db:               db,
+		TenantRepo:         NewTenantRepository(db),
+		UserRepo:           NewUserRepository(db),
+		RoleRepo:           NewRoleRepository(db),
+		APIKeyRepo:         NewAPIKeyRepository(db),
+		AIKeyRepo:          NewTenantAIKeyRepository(db),
+		MFACodeRepo:        NewMFARecoveryCodeRepository(db),
+		DocumentRepo:       NewDocumentRepository(db),
+		FolderRepo:         NewFolderRepository(db),
+		FolderACLRepo:      NewFolderACLRepository(db),
+		DocumentACLRepo:    NewDocumentACLRepository(db),
+		TagRepo:            NewTagRepository(db),
+		CategoryRepo:       NewCategoryRepository(db),
+		WorkflowRepo:       NewWorkflowRepository(db),
+		WorkflowTaskRepo:   NewWorkflowTaskRepository(db),
+		InstanceRepo:       NewWorkflowInstanceRepository(db),
+		ChecklistRepo:      NewWorkflowChecklistRepository(db),
+		AIJobRepo:          NewAIProcessingJobRepository(db),
+		AuditRepo:          NewAuditLogRepository(db),
+		ShareRepo:          NewShareRepository(db),
+		ShareAccessRepo:    NewShareAccessRepository(db),
+		AnalyticsRepo:      NewAnalyticsRepository(db),
+		NotificationRepo:   NewNotificationRepository(db),
+		DeliveryRepo:       NewNotificationDeliveryRepository(db),
+		DeviceTokenRepo:    NewDeviceTokenRepository(db),
+		SMSMessageRepo:     NewSMSMessageRepository(db),
+		TemplateRepo:       NewNotificationTemplateRepository(db),
+		RepairRepo:         NewRepairRepository(db),
+		WebhookRepo:        NewWebhookRepository(db),
+		ScheduledJobRepo:   NewScheduledJobRepository(db),
+		LegalHoldRepo:      NewLegalHoldRepository(db),
+		DataSubjectRepo:    NewDataSubjectRepository(db),
+		AuditStreamRepo:    NewAuditStreamRepository(db),
+		OwnershipRepo:      NewOwnershipRepository(db),
+		TenantDeletionRepo: NewTenantDeletionRepository(db),
+		APIUsageRepo:       NewAPIUsageRepository(db),
+		SuggestionRepo:     NewSearchSuggestionRepository(db),
+		VendorProfileRepo:  NewVendorProfileRepository(db),
+		UploadSessionRepo:  NewUploadSessionRepository(db),
+		OutOfOfficeRepo:    NewOutOfOfficeRepository(db),
+		TransferRepo:       NewTenantTransferRepository(db),
+		HeartbeatRepo:      NewWorkerHeartbeatRepository(db),
+		BulkLabelRepo:      NewBulkLabelJobRepository(db),
+		NumberingRepo:      NewNumberingSequenceRepository(db),
+		IntegrationRepo:    NewIntegrationRepository(db),
+		ArchivalRepo:       NewArchivalRepository(db),
+		AccountingRepo:     NewAccountingRepository(db),
+		LineItemRepo:       NewInvoiceLineItemRepository(db),
+		AIFeedbackRepo:     NewAIFeedbackRepository(db),
+		AIUsageRepo:        NewAIUsageRepository(db),
+		DocumentChunkRepo:  NewDocumentChunkRepository(db),
+		FilingRepo:         NewFilingSuggestionRepository(db),
+		EntityRepo:         NewEntityRepository(db),
+		FingerprintRepo:    NewDocumentFingerprintRepository(db),
+		ReprocessRepo:      NewAIReprocessBatchRepository(db),
+		PromptRepo:         NewPromptTemplateRepository(db),
+		DocTypeRepo:        NewDocumentTypeRepository(db),
+		db:                 db,
 	}
 }
 
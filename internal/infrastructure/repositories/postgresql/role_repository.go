@@ -0,0 +1,132 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	db *database.DB
+}
+
+func NewRoleRepository(db *database.DB) repositories.RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) Create(ctx context.Context, role *models.Role) error {
+	if err := r.db.WithContext(ctx).Create(role).Error; err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+	return nil
+}
+
+func (r *RoleRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Role, error) {
+	var role models.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Where("id = ?", id).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("role not found")
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) GetByName(ctx context.Context, tenantID uuid.UUID, name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").
+		Where("tenant_id = ? AND name = ?", tenantID, name).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("role not found")
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").
+		Where("tenant_id = ?", tenantID).
+		Order("name ASC").Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// Update saves the role and replaces its permission set
+func (r *RoleRepository) Update(ctx context.Context, role *models.Role) error {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermission{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to clear role permissions: %w", err)
+	}
+
+	if len(role.Permissions) > 0 {
+		for i := range role.Permissions {
+			role.Permissions[i].RoleID = role.ID
+		}
+		if err := tx.Create(&role.Permissions).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to save role permissions: %w", err)
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Where("role_id = ?", id).Delete(&models.RolePermission{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete role permissions: %w", err)
+	}
+
+	result := tx.Delete(&models.Role{}, id)
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete role: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("role not found")
+	}
+
+	return tx.Commit().Error
+}
+
+func (r *RoleRepository) CountUsers(ctx context.Context, roleID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("custom_role_id = ?", roleID).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count role users: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,61 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleRepository_PermissionLifecycle(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewRoleRepository(db.DB)
+	userRepo := NewUserRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	admin := db.CreateTestUser(t, tenant)
+
+	role := &models.Role{
+		TenantID:  tenant.ID,
+		Name:      "Auditor",
+		CreatedBy: admin.ID,
+		Permissions: []models.RolePermission{
+			{Permission: "audit.read"},
+			{Permission: "documents.read"},
+		},
+	}
+	require.NoError(t, repo.Create(ctx, role))
+
+	found, err := repo.GetByName(ctx, tenant.ID, "Auditor")
+	require.NoError(t, err)
+	assert.Len(t, found.Permissions, 2)
+
+	// Updating replaces the permission set
+	found.Permissions = []models.RolePermission{{Permission: "compliance.read"}}
+	require.NoError(t, repo.Update(ctx, found))
+
+	found, err = repo.GetByID(ctx, role.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Permissions, 1)
+	assert.Equal(t, "compliance.read", found.Permissions[0].Permission)
+
+	admin.CustomRoleID = &role.ID
+	require.NoError(t, userRepo.Update(ctx, admin))
+
+	count, err := repo.CountUsers(ctx, role.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	admin.CustomRoleID = nil
+	require.NoError(t, userRepo.Update(ctx, admin))
+
+	require.NoError(t, repo.Delete(ctx, role.ID))
+	_, err = repo.GetByID(ctx, role.ID)
+	assert.Error(t, err)
+}
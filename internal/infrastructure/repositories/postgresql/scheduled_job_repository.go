@@ -0,0 +1,229 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduledJobRepository struct {
+	db *database.DB
+}
+
+func NewScheduledJobRepository(db *database.DB) repositories.ScheduledJobRepository {
+	return &ScheduledJobRepository{db: db}
+}
+
+func (r *ScheduledJobRepository) Create(ctx context.Context, job *models.ScheduledJob) error {
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create scheduled job: %w", err)
+	}
+	return nil
+}
+
+func (r *ScheduledJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ScheduledJob, error) {
+	var job models.ScheduledJob
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("scheduled job not found")
+		}
+		return nil, fmt.Errorf("failed to get scheduled job: %w", err)
+	}
+	return &job, nil
+}
+
+func (r *ScheduledJobRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.ScheduledJob, error) {
+	var jobs []models.ScheduledJob
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at ASC").Find(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *ScheduledJobRepository) Update(ctx context.Context, job *models.ScheduledJob) error {
+	result := r.db.WithContext(ctx).Omit("Tenant").Save(job)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update scheduled job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("scheduled job not found")
+	}
+	return nil
+}
+
+// Delete removes a job together with its run history
+func (r *ScheduledJobRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Where("scheduled_job_id = ?", id).Delete(&models.ScheduledJobRun{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete scheduled job runs: %w", err)
+	}
+
+	result := tx.Where("id = ?", id).Delete(&models.ScheduledJob{})
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete scheduled job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("scheduled job not found")
+	}
+
+	return tx.Commit().Error
+}
+
+// ListDue returns enabled jobs whose next run is due, most overdue first
+func (r *ScheduledJobRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.ScheduledJob, error) {
+	var jobs []models.ScheduledJob
+	err := r.db.WithContext(ctx).
+		Where("is_enabled = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due scheduled jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Enqueue queues the run for the job's current slot (run.ScheduledFor) and
+// moves the job on to nextRunAt, nil when it never fires again. It returns
+// false when the slot was already enqueued or the job changed since it was read.
+func (r *ScheduledJobRepository) Enqueue(ctx context.Context, run *models.ScheduledJobRun, nextRunAt *time.Time) (bool, error) {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result := tx.Model(&models.ScheduledJob{}).
+		Where("id = ? AND is_enabled = ? AND next_run_at = ?", run.ScheduledJobID, true, run.ScheduledFor).
+		Update("next_run_at", nextRunAt)
+	if result.Error != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("failed to advance scheduled job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(run)
+	if result.Error != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("failed to queue scheduled job run: %w", result.Error)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, fmt.Errorf("failed to queue scheduled job run: %w", err)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *ScheduledJobRepository) ListRuns(ctx context.Context, jobID uuid.UUID, params repositories.ListParams) ([]models.ScheduledJobRun, int64, error) {
+	var runs []models.ScheduledJobRun
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.ScheduledJobRun{}).Where("scheduled_job_id = ?", jobID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count scheduled job runs: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	err := query.Order("scheduled_for DESC").Offset(offset).Limit(params.PageSize).Find(&runs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list scheduled job runs: %w", err)
+	}
+
+	return runs, total, nil
+}
+
+// ListDueRuns returns queued runs and runs whose worker's lease expired,
+// oldest slot first
+func (r *ScheduledJobRepository) ListDueRuns(ctx context.Context, now time.Time, limit int) ([]models.ScheduledJobRun, error) {
+	var runs []models.ScheduledJobRun
+	err := r.db.WithContext(ctx).
+		Where("status = ? OR (status = ? AND lease_until <= ?)", models.ScheduledJobRunQueued, models.ScheduledJobRunRunning, now).
+		Order("scheduled_for ASC").
+		Limit(limit).
+		Find(&runs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due scheduled job runs: %w", err)
+	}
+	return runs, nil
+}
+
+// ClaimRun leases a due run to the caller until leaseUntil. It returns false
+// when another worker claimed it first.
+func (r *ScheduledJobRepository) ClaimRun(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.ScheduledJobRun{}).
+		Where("id = ? AND (status = ? OR (status = ? AND lease_until <= ?))",
+			id, models.ScheduledJobRunQueued, models.ScheduledJobRunRunning, now).
+		Updates(map[string]interface{}{
+			"status":      models.ScheduledJobRunRunning,
+			"lease_until": leaseUntil,
+			"started_at":  now,
+			"attempts":    gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim scheduled job run: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FinishRun saves the run's outcome and records it as the job's last run
+func (r *ScheduledJobRepository) FinishRun(ctx context.Context, run *models.ScheduledJobRun) error {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Updates rather than Save, so a run of a job deleted meanwhile isn't recreated
+	err := tx.Model(&models.ScheduledJobRun{}).
+		Where("id = ?", run.ID).
+		Updates(map[string]interface{}{
+			"status":       run.Status,
+			"error":        run.Error,
+			"completed_at": run.CompletedAt,
+			"lease_until":  nil,
+		}).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to update scheduled job run: %w", err)
+	}
+
+	err = tx.Model(&models.ScheduledJob{}).
+		Where("id = ?", run.ScheduledJobID).
+		Updates(map[string]interface{}{
+			"last_run_at": run.CompletedAt,
+			"last_status": run.Status,
+			"last_error":  run.Error,
+		}).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record scheduled job result: %w", err)
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,110 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledJobRepository_EnqueueAndClaim(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewScheduledJobRepository(db.DB).(*ScheduledJobRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	slot := now.Add(-time.Minute)
+	job := &models.ScheduledJob{
+		ID: uuid.New(), TenantID: tenant.ID, JobType: "retention_sweep", CronExpression: "* * * * *",
+		Timezone: "UTC", IsEnabled: true, NextRunAt: &slot, CreatedBy: user.ID,
+	}
+	require.NoError(t, repo.Create(ctx, job))
+
+	disabled := *job
+	disabled.ID = uuid.New()
+	disabled.IsEnabled = false
+	require.NoError(t, repo.Create(ctx, &disabled))
+
+	jobs, err := repo.ListDue(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, job.ID, jobs[0].ID)
+
+	next := now.Add(time.Minute)
+	run := &models.ScheduledJobRun{
+		ID: uuid.New(), TenantID: tenant.ID, ScheduledJobID: job.ID, JobType: job.JobType,
+		ScheduledFor: *jobs[0].NextRunAt, Status: models.ScheduledJobRunQueued,
+	}
+	queued, err := repo.Enqueue(ctx, run, &next)
+	require.NoError(t, err)
+	assert.True(t, queued)
+
+	// A second scheduler that read the same slot doesn't fire it again
+	duplicate := *run
+	duplicate.ID = uuid.New()
+	queued, err = repo.Enqueue(ctx, &duplicate, &next)
+	require.NoError(t, err)
+	assert.False(t, queued)
+
+	jobs, err = repo.ListDue(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	runs, err := repo.ListDueRuns(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, run.ID, runs[0].ID)
+
+	claimed, err := repo.ClaimRun(ctx, run.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// A second worker loses the race
+	claimed, err = repo.ClaimRun(ctx, run.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// An expired lease makes the run claimable again
+	runs, err = repo.ListDueRuns(ctx, now.Add(2*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, 1, runs[0].Attempts)
+
+	completedAt := time.Now()
+	runs[0].Status = models.ScheduledJobRunSucceeded
+	runs[0].CompletedAt = &completedAt
+	require.NoError(t, repo.FinishRun(ctx, &runs[0]))
+
+	runs, err = repo.ListDueRuns(ctx, now.Add(2*time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	found, err := repo.GetByID(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ScheduledJobRunSucceeded, found.LastStatus)
+	require.NotNil(t, found.LastRunAt)
+	require.NotNil(t, found.NextRunAt)
+	assert.True(t, found.NextRunAt.Equal(next))
+
+	history, total, err := repo.ListRuns(ctx, job.ID, repositories.ListParams{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, history, 1)
+
+	// Deleting the job removes its history
+	require.NoError(t, repo.Delete(ctx, job.ID))
+	_, total, err = repo.ListRuns(ctx, job.ID, repositories.ListParams{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
@@ -0,0 +1,127 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// likeEscaper escapes LIKE wildcards in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type SearchSuggestionRepository struct {
+	db *database.DB
+}
+
+func NewSearchSuggestionRepository(db *database.DB) repositories.SearchSuggestionRepository {
+	return &SearchSuggestionRepository{db: db}
+}
+
+// DocumentTitles suggests titles of live documents the viewer can read
+func (r *SearchSuggestionRepository) DocumentTitles(ctx context.Context, tenantID uuid.UUID, query repositories.SuggestionQuery) ([]repositories.Suggestion, error) {
+	var suggestions []repositories.Suggestion
+	err := r.visibleDocuments(ctx, tenantID, query).
+		Where(prefixMatch("title"), prefixArgs(query.Prefix)...).
+		Select("id, title AS text, "+prefixRank("title"), startsWith(query.Prefix)).
+		Order("match_rank, updated_at DESC").
+		Limit(query.Limit).
+		Scan(&suggestions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest document titles: %w", err)
+	}
+	return suggestions, nil
+}
+
+// Tags suggests the tenant's tags, most used first
+func (r *SearchSuggestionRepository) Tags(ctx context.Context, tenantID uuid.UUID, query repositories.SuggestionQuery) ([]repositories.Suggestion, error) {
+	var suggestions []repositories.Suggestion
+	err := r.db.WithContext(ctx).Model(&models.Tag{}).
+		Where("tenant_id = ?", tenantID).
+		Where(prefixMatch("name"), prefixArgs(query.Prefix)...).
+		Select("id, name AS text, usage_count AS count, "+prefixRank("name"), startsWith(query.Prefix)).
+		Order("match_rank, usage_count DESC").
+		Limit(query.Limit).
+		Scan(&suggestions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest tags: %w", err)
+	}
+	return suggestions, nil
+}
+
+// Vendors suggests vendor names found on documents the viewer can read, by
+// how many documents name them
+func (r *SearchSuggestionRepository) Vendors(ctx context.Context, tenantID uuid.UUID, query repositories.SuggestionQuery) ([]repositories.Suggestion, error) {
+	var suggestions []repositories.Suggestion
+	err := r.visibleDocuments(ctx, tenantID, query).
+		Where("vendor_name <> ''").
+		Where(prefixMatch("vendor_name"), prefixArgs(query.Prefix)...).
+		Select("vendor_name AS text, COUNT(*) AS count, "+prefixRank("vendor_name"), startsWith(query.Prefix)).
+		Group("vendor_name").
+		Order("match_rank, count DESC").
+		Limit(query.Limit).
+		Scan(&suggestions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest vendors: %w", err)
+	}
+	return suggestions, nil
+}
+
+// Folders suggests folders the viewer can read by name
+func (r *SearchSuggestionRepository) Folders(ctx context.Context, tenantID uuid.UUID, query repositories.SuggestionQuery) ([]repositories.Suggestion, error) {
+	db := r.db.WithContext(ctx).Model(&models.Folder{}).Where("tenant_id = ?", tenantID)
+	if len(query.ExcludeFolderIDs) > 0 {
+		db = db.Where("id NOT IN ?", query.ExcludeFolderIDs)
+	}
+
+	var suggestions []repositories.Suggestion
+	err := db.
+		Where(prefixMatch("name"), prefixArgs(query.Prefix)...).
+		Select("id, name AS text, path, "+prefixRank("name"), startsWith(query.Prefix)).
+		Order("match_rank, level, path").
+		Limit(query.Limit).
+		Scan(&suggestions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest folders: %w", err)
+	}
+	return suggestions, nil
+}
+
+// visibleDocuments scopes a query to the tenant's live documents, applying
+// the same visibility rules as document listing
+func (r *SearchSuggestionRepository) visibleDocuments(ctx context.Context, tenantID uuid.UUID, query repositories.SuggestionQuery) *gorm.DB {
+	db := r.db.WithContext(ctx).Model(&models.Document{}).Where("tenant_id = ? AND deleted_at IS NULL", tenantID)
+	if len(query.ExcludeFolderIDs) > 0 {
+		db = db.Where("folder_id IS NULL OR folder_id NOT IN ?", query.ExcludeFolderIDs)
+	}
+	if query.ViewerID != nil {
+		db = db.Where(privateDocumentFilter, false, *query.ViewerID, *query.ViewerID)
+	}
+	return db
+}
+
+// prefixMatch matches the prefix at the start of a column or of any word in
+// it. Both patterns are served by the column's trigram index.
+func prefixMatch(column string) string {
+	return fmt.Sprintf(`lower(%[1]s) LIKE ? ESCAPE '\' OR lower(%[1]s) LIKE ? ESCAPE '\'`, column)
+}
+
+func prefixArgs(prefix string) []interface{} {
+	return []interface{}{startsWith(prefix), "% " + startsWith(prefix)}
+}
+
+// startsWith is the LIKE pattern for text starting with the prefix
+func startsWith(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+// prefixRank selects match_rank, which orders matches at the start of a
+// column before word matches
+func prefixRank(column string) string {
+	return fmt.Sprintf(`CASE WHEN lower(%s) LIKE ? ESCAPE '\' THEN 0 ELSE 1 END AS match_rank`, column)
+}
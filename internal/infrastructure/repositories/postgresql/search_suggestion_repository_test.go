@@ -0,0 +1,86 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchSuggestionRepository_DocumentTitles(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewSearchSuggestionRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	for title, vendor := range map[string]string{
+		"Office rent March":   "Acme Properties",
+		"Invoice 2024-17":     "Acme Consulting",
+		"Acme service report": "",
+		"100% refund":         "",
+	} {
+		document := db.CreateTestDocument(t, tenant, user)
+		require.NoError(t, db.Model(document).Updates(map[string]interface{}{"title": title, "vendor_name": vendor}).Error)
+	}
+
+	// Another tenant's documents are never suggested
+	other := db.CreateTestTenant(t)
+	otherDocument := db.CreateTestDocument(t, other, db.CreateTestUser(t, other))
+	require.NoError(t, db.Model(otherDocument).Update("title", "Acme secret").Error)
+
+	suggestions, err := repo.DocumentTitles(ctx, tenant.ID, repositories.SuggestionQuery{Prefix: "ac", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, "Acme service report", suggestions[0].Text)
+
+	// Word prefixes match after those at the start
+	suggestions, err = repo.DocumentTitles(ctx, tenant.ID, repositories.SuggestionQuery{Prefix: "re", Limit: 10})
+	require.NoError(t, err)
+	texts := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		texts[i] = suggestion.Text
+	}
+	assert.ElementsMatch(t, []string{"Office rent March", "Acme service report", "100% refund"}, texts)
+
+	// Wildcards are matched literally
+	suggestions, err = repo.DocumentTitles(ctx, tenant.ID, repositories.SuggestionQuery{Prefix: "100%", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	suggestions, err = repo.DocumentTitles(ctx, tenant.ID, repositories.SuggestionQuery{Prefix: "1%", Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+
+	vendors, err := repo.Vendors(ctx, tenant.ID, repositories.SuggestionQuery{Prefix: "acme", Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, vendors, 2)
+}
+
+func TestSearchSuggestionRepository_Tags(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewSearchSuggestionRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	require.NoError(t, db.Create(&[]models.Tag{
+		{TenantID: tenant.ID, Name: "tax", UsageCount: 2},
+		{TenantID: tenant.ID, Name: "taxes 2024", UsageCount: 9},
+		{TenantID: tenant.ID, Name: "sales tax", UsageCount: 30},
+	}).Error)
+
+	suggestions, err := repo.Tags(ctx, tenant.ID, repositories.SuggestionQuery{Prefix: "tax", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, suggestions, 3)
+	assert.Equal(t, "taxes 2024", suggestions[0].Text)
+	assert.Equal(t, int64(9), suggestions[0].Count)
+	assert.Equal(t, "tax", suggestions[1].Text)
+	assert.Equal(t, "sales tax", suggestions[2].Text)
+}
@@ -0,0 +1,67 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+type ShareAccessRepository struct {
+	db *database.DB
+}
+
+func NewShareAccessRepository(db *database.DB) repositories.ShareAccessRepository {
+	return &ShareAccessRepository{db: db}
+}
+
+func (r *ShareAccessRepository) Create(ctx context.Context, access *models.ShareAccess) error {
+	if err := r.db.WithContext(ctx).Create(access).Error; err != nil {
+		return fmt.Errorf("failed to record share access: %w", err)
+	}
+	return nil
+}
+
+func (r *ShareAccessRepository) ListByShare(ctx context.Context, shareID uuid.UUID, params repositories.ListParams) ([]models.ShareAccess, int64, error) {
+	var accesses []models.ShareAccess
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.ShareAccess{}).
+		Where("share_id = ?", shareID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count share accesses: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	err := query.Order("accessed_at DESC").Offset(offset).Limit(params.PageSize).Find(&accesses).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list share accesses: %w", err)
+	}
+
+	return accesses, total, nil
+}
+
+func (r *ShareAccessRepository) GetStats(ctx context.Context, shareID uuid.UUID) (*repositories.ShareAccessStats, error) {
+	var stats repositories.ShareAccessStats
+
+	err := r.db.WithContext(ctx).Model(&models.ShareAccess{}).
+		Select(`
+			COUNT(*) as total_accesses,
+			COUNT(*) FILTER (WHERE success AND action = 'view') as views,
+			COUNT(*) FILTER (WHERE success AND action = 'download') as downloads,
+			COUNT(*) FILTER (WHERE NOT success) as denied_attempts,
+			COUNT(DISTINCT ip_address) as unique_ips,
+			MAX(accessed_at) as last_accessed_at
+		`).
+		Where("share_id = ?", shareID).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share access stats: %w", err)
+	}
+
+	return &stats, nil
+}
@@ -62,18 +62,17 @@ func (r *ShareRepository) Update(ctx context.Context, share *models.Share) error
 	return nil
 }
 
-func (r *ShareRepository) IncrementDownload(ctx context.Context, shareID uuid.UUID) error {
+// ClaimDownload checks the limit and counts the download in one conditional
+// update, so concurrent downloads can't overshoot max_downloads
+func (r *ShareRepository) ClaimDownload(ctx context.Context, shareID uuid.UUID) (bool, error) {
 	result := r.db.WithContext(ctx).Model(&models.Share{}).
-		Where("id = ?", shareID).
-		Update("download_count", gorm.Expr("download_count + 1"))
+		Where("id = ? AND (COALESCE(max_downloads, 0) = 0 OR COALESCE(download_count, 0) < max_downloads)", shareID).
+		Update("download_count", gorm.Expr("COALESCE(download_count, 0) + 1"))
 
 	if result.Error != nil {
-		return fmt.Errorf("failed to increment download count: %w", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("share not found")
+		return false, fmt.Errorf("failed to count download: %w", result.Error)
 	}
-	return nil
+	return result.RowsAffected > 0, nil
 }
 
 func (r *ShareRepository) ExpireShare(ctx context.Context, shareID uuid.UUID) error {
@@ -111,3 +110,28 @@ func (r *ShareRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+func (r *ShareRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Share, error) {
+	var share models.Share
+	err := r.db.WithContext(ctx).Preload("Document").
+		Where("id = ?", id).First(&share).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("share not found")
+		}
+		return nil, fmt.Errorf("failed to get share: %w", err)
+	}
+	return &share, nil
+}
+
+func (r *ShareRepository) ListActiveByDocument(ctx context.Context, documentID uuid.UUID) ([]models.Share, error) {
+	var shares []models.Share
+	err := r.db.WithContext(ctx).Preload("Creator").
+		Where("document_id = ? AND is_active = ?", documentID, true).
+		Where("expires_at IS NULL OR expires_at > NOW()").
+		Order("created_at DESC").Find(&shares).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active shares: %w", err)
+	}
+	return shares, nil
+}
@@ -0,0 +1,50 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareRepository_ClaimDownload(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewShareRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	document := db.CreateTestDocument(t, tenant, user)
+
+	limited := &models.Share{TenantID: tenant.ID, DocumentID: document.ID, CreatedBy: user.ID,
+		Token: uuid.New().String(), MaxDownloads: 2, IsActive: true}
+	unlimited := &models.Share{TenantID: tenant.ID, DocumentID: document.ID, CreatedBy: user.ID,
+		Token: uuid.New().String(), IsActive: true}
+	require.NoError(t, repo.Create(ctx, limited))
+	require.NoError(t, repo.Create(ctx, unlimited))
+
+	for i := 0; i < 2; i++ {
+		claimed, err := repo.ClaimDownload(ctx, limited.ID)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+	}
+	claimed, err := repo.ClaimDownload(ctx, limited.ID)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	for i := 0; i < 5; i++ {
+		claimed, err := repo.ClaimDownload(ctx, unlimited.ID)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+	}
+
+	found, err := repo.GetByID(ctx, limited.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, found.DownloadCount)
+}
@@ -0,0 +1,63 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+type SMSMessageRepository struct {
+	db *database.DB
+}
+
+func NewSMSMessageRepository(db *database.DB) repositories.SMSMessageRepository {
+	return &SMSMessageRepository{db: db}
+}
+
+func (r *SMSMessageRepository) Create(ctx context.Context, message *models.SMSMessage) error {
+	if err := r.db.WithContext(ctx).Create(message).Error; err != nil {
+		return fmt.Errorf("failed to record sms message: %w", err)
+	}
+	return nil
+}
+
+func (r *SMSMessageRepository) GetUsage(ctx context.Context, tenantID uuid.UUID, from, to time.Time) (*repositories.SMSUsage, error) {
+	var usage repositories.SMSUsage
+
+	err := r.db.WithContext(ctx).Model(&models.SMSMessage{}).
+		Select(`
+			COUNT(*) FILTER (WHERE status = 'sent') as message_count,
+			COUNT(*) FILTER (WHERE status = 'failed') as failed_count,
+			COALESCE(SUM(segments) FILTER (WHERE status = 'sent'), 0) as segments,
+			COALESCE(SUM(cost), 0) as total_cost
+		`).
+		Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, from, to).
+		Scan(&usage).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sms usage: %w", err)
+	}
+
+	return &usage, nil
+}
+
+func (r *SMSMessageRepository) CountSince(ctx context.Context, eventType string, userID uuid.UUID, toNumber string, since time.Time) (int64, int64, error) {
+	var counts struct {
+		ByUser   int64
+		ByNumber int64
+	}
+
+	err := r.db.WithContext(ctx).Model(&models.SMSMessage{}).
+		Select("COUNT(*) FILTER (WHERE user_id = ?) as by_user, COUNT(*) FILTER (WHERE to_number = ?) as by_number", userID, toNumber).
+		Where("event_type = ? AND created_at >= ? AND (user_id = ? OR to_number = ?)", eventType, since, userID, toNumber).
+		Scan(&counts).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count sms messages: %w", err)
+	}
+
+	return counts.ByUser, counts.ByNumber, nil
+}
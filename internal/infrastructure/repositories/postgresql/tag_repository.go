@@ -31,8 +31,17 @@ func (r *TagRepository) Create(ctx context.Context, tag *models.Tag) error {
 }
 
 func (r *TagRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Tag, error) {
+	return r.get(r.db.WithContext(ctx), id)
+}
+
+// GetForTenant loads a tag only if it belongs to the tenant
+func (r *TagRepository) GetForTenant(ctx context.Context, tenantID, id uuid.UUID) (*models.Tag, error) {
+	return r.get(r.db.WithContext(ctx).Scopes(tenantScope(tenantID)), id)
+}
+
+func (r *TagRepository) get(db *gorm.DB, id uuid.UUID) (*models.Tag, error) {
 	var tag models.Tag
-	err := r.db.WithContext(ctx).
+	err := db.
 		Preload("Tenant", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "name", "subdomain")
 		}).
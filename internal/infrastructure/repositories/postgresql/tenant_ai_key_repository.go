@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TenantAIKeyRepository struct {
+	db *database.DB
+}
+
+func NewTenantAIKeyRepository(db *database.DB) repositories.TenantAIKeyRepository {
+	return &TenantAIKeyRepository{db: db}
+}
+
+// Upsert stores the tenant's key for a provider, replacing any existing one
+func (r *TenantAIKeyRepository) Upsert(ctx context.Context, key *models.TenantAIKey) error {
+	var existing models.TenantAIKey
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND provider = ?", key.TenantID, key.Provider).
+		First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to look up AI key: %w", err)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+			return fmt.Errorf("failed to create AI key: %w", err)
+		}
+		return nil
+	}
+
+	existing.EncryptedKey = key.EncryptedKey
+	existing.KeyHint = key.KeyHint
+	existing.IsEnabled = key.IsEnabled
+	existing.CreatedBy = key.CreatedBy
+	existing.LastUsedAt = nil
+	existing.LastError = ""
+
+	if err := r.db.WithContext(ctx).Save(&existing).Error; err != nil {
+		return fmt.Errorf("failed to update AI key: %w", err)
+	}
+
+	*key = existing
+	return nil
+}
+
+func (r *TenantAIKeyRepository) GetByProvider(ctx context.Context, tenantID uuid.UUID, provider string) (*models.TenantAIKey, error) {
+	var key models.TenantAIKey
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND provider = ?", tenantID, provider).
+		First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("AI key not found")
+		}
+		return nil, fmt.Errorf("failed to get AI key: %w", err)
+	}
+	return &key, nil
+}
+
+func (r *TenantAIKeyRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.TenantAIKey, error) {
+	var keys []models.TenantAIKey
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("provider ASC").Find(&keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AI keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *TenantAIKeyRepository) Update(ctx context.Context, key *models.TenantAIKey) error {
+	if err := r.db.WithContext(ctx).Save(key).Error; err != nil {
+		return fmt.Errorf("failed to update AI key: %w", err)
+	}
+	return nil
+}
+
+// RecordUse stamps the key's last use and the error from that call, if any
+func (r *TenantAIKeyRepository) RecordUse(ctx context.Context, id uuid.UUID, usedAt time.Time, lastError string) error {
+	err := r.db.WithContext(ctx).Model(&models.TenantAIKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_used_at": usedAt,
+			"last_error":   lastError,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record AI key use: %w", err)
+	}
+	return nil
+}
+
+func (r *TenantAIKeyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&models.TenantAIKey{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete AI key: %w", err)
+	}
+	return nil
+}
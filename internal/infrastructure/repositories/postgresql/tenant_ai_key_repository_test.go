@@ -0,0 +1,61 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantAIKeyRepository_UpsertReplacesProviderKey(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewTenantAIKeyRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	admin := db.CreateTestUser(t, tenant)
+
+	key := &models.TenantAIKey{
+		TenantID:     tenant.ID,
+		Provider:     "openai",
+		EncryptedKey: "v1:first",
+		KeyHint:      "aaaa",
+		IsEnabled:    true,
+		CreatedBy:    admin.ID,
+	}
+	require.NoError(t, repo.Upsert(ctx, key))
+	require.NoError(t, repo.RecordUse(ctx, key.ID, time.Now(), "invalid api key"))
+
+	// Setting the same provider again replaces the key and clears its usage state
+	replacement := &models.TenantAIKey{
+		TenantID:     tenant.ID,
+		Provider:     "openai",
+		EncryptedKey: "v1:second",
+		KeyHint:      "bbbb",
+		IsEnabled:    true,
+		CreatedBy:    admin.ID,
+	}
+	require.NoError(t, repo.Upsert(ctx, replacement))
+	assert.Equal(t, key.ID, replacement.ID)
+
+	found, err := repo.GetByProvider(ctx, tenant.ID, "openai")
+	require.NoError(t, err)
+	assert.Equal(t, "v1:second", found.EncryptedKey)
+	assert.Equal(t, "bbbb", found.KeyHint)
+	assert.Empty(t, found.LastError)
+	assert.Nil(t, found.LastUsedAt)
+
+	keys, err := repo.ListByTenant(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	require.NoError(t, repo.Delete(ctx, found.ID))
+	_, err = repo.GetByProvider(ctx, tenant.ID, "openai")
+	assert.Error(t, err)
+}
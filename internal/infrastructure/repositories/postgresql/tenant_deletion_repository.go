@@ -0,0 +1,332 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// documentChildren selects rows belonging to the tenant's documents
+const documentChildren = "document_id IN (SELECT id FROM documents WHERE tenant_id = ?)"
+
+// tenantRowStep deletes one table's rows of a tenant. Exactly one of model
+// and table is set; table is for join tables without a model. where selects
+// the tenant's rows with ? standing for the tenant ID, and defaults to
+// "tenant_id = ?".
+type tenantRowStep struct {
+	model interface{}
+	table string
+	where string
+}
+
+// tenantRowSteps delete a tenant, referencing rows before the rows they
+// reference. Every model must be listed here; a test checks it.
+var tenantRowSteps = []tenantRowStep{
+	{model: &models.ShareAccess{}},
+	{model: &models.Share{}},
+	{model: &models.LegalHoldDocument{}, where: "legal_hold_id IN (SELECT id FROM legal_holds WHERE tenant_id = ?)"},
+	{model: &models.LegalHold{}},
+	{model: &models.DataSubjectRequest{}},
+	{model: &models.BulkLabelJob{}},
+	{model: &models.NumberingSequence{}},
+	{model: &models.APIUsage{}},
+	{model: &models.VendorProfileAlias{}},
+	{model: &models.VendorProfile{}},
+	{model: &models.UploadSession{}},
+	{model: &models.ArchivalRunDocument{}},
+	{model: &models.ArchivalRun{}},
+	{model: &models.ArchivalSchedule{}},
+	{model: &models.ImportSyncItem{}},
+	{model: &models.ImportSync{}},
+	{model: &models.Integration{}},
+	{model: &models.ScheduledJobRun{}},
+	{model: &models.ScheduledJob{}},
+	{model: &models.WebhookDeliveryAttempt{}},
+	{model: &models.WebhookDelivery{}},
+	{model: &models.WebhookEvent{}, where: "webhook_id IN (SELECT id FROM webhooks WHERE tenant_id = ?)"},
+	{model: &models.Webhook{}},
+	{model: &models.AuditStream{}},
+	{model: &models.AuditArchive{}},
+	{model: &models.AuditChainHead{}},
+	{model: &models.AuditLog{}},
+	{model: &models.AIProcessingJob{}},
+	{model: &models.NotificationDelivery{}},
+	{model: &models.Notification{}},
+	{model: &models.NotificationTemplate{}},
+	{model: &models.PromptTemplate{}},
+	{model: &models.DocumentTypeDefinition{}},
+	{model: &models.SMSMessage{}},
+	{model: &models.DeviceToken{}},
+	{model: &models.WorkflowTaskDelegation{}},
+	{model: &models.OutOfOffice{}},
+	{model: &models.WorkflowChecklistItem{}},
+	{model: &models.WorkflowTask{}, where: "workflow_id IN (SELECT id FROM workflows WHERE tenant_id = ?)"},
+	{model: &models.WorkflowInstance{}},
+	{model: &models.WorkflowVersion{}},
+	{model: &models.Workflow{}},
+	{model: &models.DocumentAnalytics{}},
+	{model: &models.InvoiceLineItem{}},
+	{model: &models.AIFeedback{}},
+	{model: &models.AIUsage{}},
+	{model: &models.DocumentChunk{}},
+	{model: &models.FilingSuggestion{}},
+	{model: &models.Entity{}},
+	{model: &models.DocumentFingerprintBand{}},
+	{model: &models.DocumentFingerprint{}},
+	{model: &models.AIReprocessBatch{}},
+	{model: &models.DocumentComment{}, where: documentChildren},
+	{model: &models.DocumentVersion{}, where: documentChildren},
+	{model: &models.DocumentACL{}},
+	{table: "document_tags", where: documentChildren},
+	{table: "document_categories", where: documentChildren},
+	{model: &models.Document{}},
+	{model: &models.DocumentTemplate{}},
+	{model: &models.Tag{}},
+	{model: &models.Category{}},
+	{model: &models.FolderACL{}},
+	{model: &models.Folder{}},
+	{model: &models.APIKeyScope{}, where: "api_key_id IN (SELECT id FROM api_keys WHERE tenant_id = ?)"},
+	{model: &models.APIKey{}},
+	{model: &models.TenantAIKey{}},
+	{model: &models.MFARecoveryCode{}, where: "user_id IN (SELECT id FROM users WHERE tenant_id = ?)"},
+	{model: &models.User{}},
+	{model: &models.RolePermission{}, where: "role_id IN (SELECT id FROM roles WHERE tenant_id = ?)"},
+	{model: &models.Role{}},
+	{model: &models.Tenant{}, where: "id = ?"},
+}
+
+type TenantDeletionRepository struct {
+	db *database.DB
+}
+
+func NewTenantDeletionRepository(db *database.DB) repositories.TenantDeletionRepository {
+	return &TenantDeletionRepository{db: db}
+}
+
+func (r *TenantDeletionRepository) Create(ctx context.Context, deletion *models.TenantDeletion) error {
+	if err := r.db.WithContext(ctx).Create(deletion).Error; err != nil {
+		return fmt.Errorf("failed to create tenant deletion: %w", err)
+	}
+	return nil
+}
+
+// GetLatest returns the tenant's most recent deletion request
+func (r *TenantDeletionRepository) GetLatest(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletion, error) {
+	var deletion models.TenantDeletion
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("requested_at DESC").
+		First(&deletion).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("tenant deletion not found")
+		}
+		return nil, fmt.Errorf("failed to get tenant deletion: %w", err)
+	}
+	return &deletion, nil
+}
+
+// Cancel stops a deletion that hasn't started deleting, reporting false when
+// it already has
+func (r *TenantDeletionRepository) Cancel(ctx context.Context, id, cancelledBy uuid.UUID, cancelledAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.TenantDeletion{}).
+		Where("id = ? AND status IN ?", id, []models.TenantDeletionStatus{models.TenantDeletionExporting, models.TenantDeletionScheduled}).
+		Updates(map[string]interface{}{
+			"status":       models.TenantDeletionCancelled,
+			"cancelled_by": cancelledBy,
+			"cancelled_at": cancelledAt,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to cancel tenant deletion: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListDue returns deletions waiting for their export, deletions whose grace
+// period is over and deletions in progress, unless a worker holds them
+func (r *TenantDeletionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.TenantDeletion, error) {
+	var deletions []models.TenantDeletion
+	err := r.dueScope(r.db.WithContext(ctx), now).
+		Order("requested_at ASC").
+		Limit(limit).
+		Find(&deletions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due tenant deletions: %w", err)
+	}
+	return deletions, nil
+}
+
+// Claim leases a due deletion to one worker, reporting false when another got
+// it first or it was cancelled
+func (r *TenantDeletionRepository) Claim(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error) {
+	result := r.dueScope(r.db.WithContext(ctx).Model(&models.TenantDeletion{}), now).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status": gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END",
+				models.TenantDeletionScheduled, models.TenantDeletionDeleting),
+			"lease_until": leaseUntil,
+			"attempts":    gorm.Expr("attempts + 1"),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim tenant deletion: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SaveProgress records how far the teardown got and extends the lease
+func (r *TenantDeletionRepository) SaveProgress(ctx context.Context, deletion *models.TenantDeletion, leaseUntil time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.TenantDeletion{}).
+		Where("id = ?", deletion.ID).
+		Updates(map[string]interface{}{
+			"stage":       deletion.Stage,
+			"progress":    deletion.Progress,
+			"started_at":  deletion.StartedAt,
+			"lease_until": leaseUntil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save tenant deletion progress: %w", err)
+	}
+	return nil
+}
+
+// Finish saves the outcome of a worker run and releases its lease. It
+// reports false, saving nothing, when the deletion left the from status in
+// the meantime (it was cancelled).
+func (r *TenantDeletionRepository) Finish(ctx context.Context, deletion *models.TenantDeletion, from models.TenantDeletionStatus) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.TenantDeletion{}).
+		Where("id = ? AND status = ?", deletion.ID, from).
+		Updates(map[string]interface{}{
+			"status":       deletion.Status,
+			"export_path":  deletion.ExportPath,
+			"export_size":  deletion.ExportSize,
+			"stage":        deletion.Stage,
+			"progress":     deletion.Progress,
+			"attempts":     deletion.Attempts,
+			"error":        deletion.Error,
+			"started_at":   deletion.StartedAt,
+			"completed_at": deletion.CompletedAt,
+			"lease_until":  nil,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update tenant deletion: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListDocuments pages through all of the tenant's documents, including those
+// in the trash, in ID order
+func (r *TenantDeletionRepository) ListDocuments(ctx context.Context, tenantID uuid.UUID, after uuid.UUID, limit int) ([]models.Document, error) {
+	var documents []models.Document
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id > ?", tenantID, after).
+		Order("id ASC").
+		Limit(limit).
+		Find(&documents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	return documents, nil
+}
+
+// DeleteTenantRows deletes every row of the tenant, the tenant itself last.
+// Tables with an id column are deleted batchSize rows at a time, calling
+// progress after each batch. Deleting again after a failure picks up where
+// it stopped.
+func (r *TenantDeletionRepository) DeleteTenantRows(ctx context.Context, tenantID uuid.UUID, batchSize int, progress func(table string, deleted int64) error) error {
+	db := r.db.WithContext(ctx)
+
+	// Folders reference their parents; unlink them so batches can go in any order
+	if err := db.Model(&models.Folder{}).Where("tenant_id = ?", tenantID).Update("parent_id", nil).Error; err != nil {
+		return fmt.Errorf("failed to unlink folders: %w", err)
+	}
+
+	for _, step := range tenantRowSteps {
+		where := step.where
+		if where == "" {
+			where = "tenant_id = ?"
+		}
+
+		if step.table != "" {
+			result := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", step.table, where), tenantID)
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete %s: %w", step.table, result.Error)
+			}
+			if err := progress(step.table, result.RowsAffected); err != nil {
+				return err
+			}
+			continue
+		}
+
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(step.model); err != nil {
+			return fmt.Errorf("failed to parse model: %w", err)
+		}
+		table := stmt.Schema.Table
+
+		if stmt.Schema.LookUpField("id") == nil {
+			result := db.Where(where, tenantID).Delete(step.model)
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete %s: %w", table, result.Error)
+			}
+			if err := progress(table, result.RowsAffected); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for {
+			batch := db.Model(step.model).Select("id").Where(where, tenantID).Limit(batchSize)
+			result := db.Where("id IN (?)", batch).Delete(step.model)
+			if result.Error != nil {
+				return fmt.Errorf("failed to delete %s: %w", table, result.Error)
+			}
+			if err := progress(table, result.RowsAffected); err != nil {
+				return err
+			}
+			if result.RowsAffected < int64(batchSize) {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *TenantDeletionRepository) CreateCertificate(ctx context.Context, certificate *models.TenantDeletionCertificate) error {
+	if err := r.db.WithContext(ctx).Create(certificate).Error; err != nil {
+		return fmt.Errorf("failed to create tenant deletion certificate: %w", err)
+	}
+	return nil
+}
+
+func (r *TenantDeletionRepository) GetCertificate(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletionCertificate, error) {
+	var certificate models.TenantDeletionCertificate
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("completed_at DESC").
+		First(&certificate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("tenant deletion certificate not found")
+		}
+		return nil, fmt.Errorf("failed to get tenant deletion certificate: %w", err)
+	}
+	return &certificate, nil
+}
+
+// Helper methods
+
+func (r *TenantDeletionRepository) dueScope(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.
+		Where("status IN ? OR (status = ? AND purge_after <= ?)",
+			[]models.TenantDeletionStatus{models.TenantDeletionExporting, models.TenantDeletionDeleting},
+			models.TenantDeletionScheduled, now).
+		Where("lease_until IS NULL OR lease_until <= ?", now)
+}
@@ -0,0 +1,90 @@
+package postgresql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantRowSteps_CoverEveryModel(t *testing.T) {
+	// These outlive the tenant on purpose, or aren't tenant data at all
+	kept := map[reflect.Type]bool{
+		reflect.TypeOf(&models.TenantDeletion{}):            true,
+		reflect.TypeOf(&models.TenantDeletionCertificate{}): true,
+		reflect.TypeOf(&models.WorkerHeartbeat{}):           true,
+	}
+
+	deleted := make(map[reflect.Type]bool)
+	for _, step := range tenantRowSteps {
+		if step.model != nil {
+			deleted[reflect.TypeOf(step.model)] = true
+		}
+	}
+
+	for _, model := range models.GetAllModels() {
+		modelType := reflect.TypeOf(model)
+		assert.True(t, deleted[modelType] || kept[modelType], "%s is not deleted with its tenant", modelType.Elem().Name())
+	}
+}
+
+func TestTenantDeletionRepository_DeleteTenantRows(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewTenantDeletionRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	document := db.CreateTestDocument(t, tenant, user)
+	require.NoError(t, db.Create(&models.DocumentVersion{
+		ID: uuid.New(), DocumentID: document.ID, VersionNumber: 1, StoragePath: "versions/document-v1.pdf",
+		FileSize: 512, ContentHash: "hash-v1", CreatedBy: user.ID,
+	}).Error)
+
+	other := db.CreateTestTenant(t)
+	otherUser := db.CreateTestUser(t, other)
+	otherDocument := db.CreateTestDocument(t, other, otherUser)
+
+	deletion := &models.TenantDeletion{
+		ID: uuid.New(), TenantID: tenant.ID, TenantName: tenant.Name, Subdomain: tenant.Subdomain,
+		Status: models.TenantDeletionScheduled, RequestedBy: user.ID, RequestedAt: time.Now(), PurgeAfter: time.Now().Add(-time.Minute),
+	}
+	require.NoError(t, repo.Create(ctx, deletion))
+
+	// Claiming after the grace period starts the teardown, which can't be cancelled
+	claimed, err := repo.Claim(ctx, deletion.ID, time.Now(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, claimed)
+	cancelled, err := repo.Cancel(ctx, deletion.ID, user.ID, time.Now())
+	require.NoError(t, err)
+	assert.False(t, cancelled)
+
+	deletedRows := make(map[string]int64)
+	require.NoError(t, repo.DeleteTenantRows(ctx, tenant.ID, 1, func(table string, deleted int64) error {
+		deletedRows[table] += deleted
+		return nil
+	}))
+	assert.Equal(t, int64(1), deletedRows["tenants"])
+	assert.Equal(t, int64(1), deletedRows["users"])
+	assert.Equal(t, int64(1), deletedRows["documents"])
+	assert.Equal(t, int64(1), deletedRows["document_versions"])
+
+	var remaining int64
+	require.NoError(t, db.Model(&models.Tenant{}).Where("id = ?", tenant.ID).Count(&remaining).Error)
+	assert.Zero(t, remaining)
+	require.NoError(t, db.Model(&models.Document{}).Where("id = ?", otherDocument.ID).Count(&remaining).Error)
+	assert.Equal(t, int64(1), remaining)
+
+	// The deletion record outlives the tenant
+	latest, err := repo.GetLatest(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TenantDeletionDeleting, latest.Status)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/archivus/archivus/internal/domain/repositories"
 	"github.com/archivus/archivus/internal/infrastructure/database"
@@ -55,8 +56,15 @@ func (r *TenantRepository) GetBySubdomain(ctx context.Context, subdomain string)
 	return &tenant, nil
 }
 
+// tenantUsageColumns are the counters kept by atomic increments. Update
+// leaves them alone: a tenant read before an upload finished would otherwise
+// write back stale usage.
+var tenantUsageColumns = []string{"storage_used", "document_count", "api_used"}
+
+// Update saves the tenant's settings and profile; its usage counters are only
+// changed by UpdateUsage, SetAPIUsed and the reserve and release methods
 func (r *TenantRepository) Update(ctx context.Context, tenant *models.Tenant) error {
-	result := r.db.WithContext(ctx).Save(tenant)
+	result := r.db.WithContext(ctx).Model(tenant).Select("*").Omit(tenantUsageColumns...).Updates(tenant)
 	if result.Error != nil {
 		return fmt.Errorf("failed to update tenant: %w", result.Error)
 	}
@@ -83,9 +91,80 @@ func (r *TenantRepository) UpdateUsage(ctx context.Context, tenantID uuid.UUID,
 	return nil
 }
 
+// SetAPIUsed records the tenant's metered API requests this month
+func (r *TenantRepository) SetAPIUsed(ctx context.Context, tenantID uuid.UUID, apiUsed int64) error {
+	result := r.db.WithContext(ctx).Model(&models.Tenant{}).
+		Where("id = ?", tenantID).
+		Update("api_used", apiUsed)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update tenant API usage: %w", result.Error)
+	}
+	return nil
+}
+
+// ReserveDocumentSlots counts documents against the tenant's document quota
+// before they are created. The check and increment are a single conditional
+// update, so concurrent uploads cannot overshoot the quota; it returns false
+// when the reservation would exceed it.
+func (r *TenantRepository) ReserveDocumentSlots(ctx context.Context, tenantID uuid.UUID, count int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Tenant{}).
+		Where("id = ? AND document_count + ? <= document_quota", tenantID, count).
+		Update("document_count", gorm.Expr("document_count + ?", count))
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to reserve document quota: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseDocumentSlots returns reserved or deleted documents to the quota
+func (r *TenantRepository) ReleaseDocumentSlots(ctx context.Context, tenantID uuid.UUID, count int64) error {
+	result := r.db.WithContext(ctx).Model(&models.Tenant{}).
+		Where("id = ?", tenantID).
+		Update("document_count", gorm.Expr("GREATEST(document_count - ?, 0)", count))
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to release document quota: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tenant not found")
+	}
+	return nil
+}
+
+// ReserveStorage counts bytes against the tenant's storage quota before the
+// file is stored, so in-flight uploads already take up their share. Like
+// ReserveDocumentSlots it is a single conditional update and returns false
+// when the upload would exceed the quota.
+func (r *TenantRepository) ReserveStorage(ctx context.Context, tenantID uuid.UUID, bytes int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Tenant{}).
+		Where("id = ? AND storage_used + ? <= storage_quota", tenantID, bytes).
+		Update("storage_used", gorm.Expr("storage_used + ?", bytes))
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to reserve storage quota: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseStorage returns reserved or deleted bytes to the quota
+func (r *TenantRepository) ReleaseStorage(ctx context.Context, tenantID uuid.UUID, bytes int64) error {
+	result := r.db.WithContext(ctx).Model(&models.Tenant{}).
+		Where("id = ?", tenantID).
+		Update("storage_used", gorm.Expr("GREATEST(storage_used - ?, 0)", bytes))
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to release storage quota: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tenant not found")
+	}
+	return nil
+}
+
 func (r *TenantRepository) CheckQuotaLimits(ctx context.Context, tenantID uuid.UUID) (*repositories.QuotaStatus, error) {
 	var tenant models.Tenant
-	err := r.db.WithContext(ctx).Select("storage_used", "storage_quota", "api_used", "api_quota").
+	err := r.db.WithContext(ctx).Select("storage_used", "storage_quota", "api_used", "api_quota", "document_count", "document_quota").
 		Where("id = ?", tenantID).First(&tenant).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -96,16 +175,25 @@ func (r *TenantRepository) CheckQuotaLimits(ctx context.Context, tenantID uuid.U
 
 	storagePercent := float64(tenant.StorageUsed) / float64(tenant.StorageQuota) * 100
 	apiPercent := float64(tenant.APIUsed) / float64(tenant.APIQuota) * 100
+	documentPercent := float64(tenant.DocumentCount) / float64(tenant.DocumentQuota) * 100
 
 	return &repositories.QuotaStatus{
-		StorageUsed:    tenant.StorageUsed,
-		StorageQuota:   tenant.StorageQuota,
-		StoragePercent: storagePercent,
-		APIUsed:        tenant.APIUsed,
-		APIQuota:       tenant.APIQuota,
-		APIPercent:     apiPercent,
-		CanUpload:      storagePercent < 95, // 95% limit
-		CanProcessAI:   apiPercent < 95,     // 95% limit
+		StorageUsed:     tenant.StorageUsed,
+		StorageQuota:    tenant.StorageQuota,
+		StoragePercent:  storagePercent,
+		APIUsed:         tenant.APIUsed,
+		APIQuota:        tenant.APIQuota,
+		APIPercent:      apiPercent,
+		DocumentCount:   tenant.DocumentCount,
+		DocumentQuota:   tenant.DocumentQuota,
+		DocumentPercent: documentPercent,
+		CanUpload:       tenant.StorageUsed < tenant.StorageQuota, // Hard limit, enforced by ReserveStorage
+		CanAddDocument:  tenant.DocumentCount < tenant.DocumentQuota,
+		CanProcessAI:    apiPercent < 95, // 95% limit
+
+		StorageRemaining:   max(tenant.StorageQuota-tenant.StorageUsed, 0),
+		APIRemaining:       max(tenant.APIQuota-tenant.APIUsed, 0),
+		DocumentsRemaining: max(tenant.DocumentQuota-tenant.DocumentCount, 0),
 	}, nil
 }
 
@@ -144,6 +232,63 @@ func (r *TenantRepository) List(ctx context.Context, params repositories.ListPar
 	return tenants, total, nil
 }
 
+// ListTrialsEndingBefore returns tenants whose trial ends before the given
+// time and hasn't been acted on yet, soonest first. Tenants awaiting deletion
+// are left alone.
+func (r *TenantRepository) ListTrialsEndingBefore(ctx context.Context, before time.Time) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := r.db.WithContext(ctx).
+		Where("trial_ends_at IS NOT NULL AND trial_ends_at < ?", before).
+		Where("trial_expired_at IS NULL AND deletion_scheduled_for IS NULL").
+		Order("trial_ends_at").
+		Find(&tenants).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ending trials: %w", err)
+	}
+	return tenants, nil
+}
+
+// ListWithSetting lists the tenants whose settings hold key
+func (r *TenantRepository) ListWithSetting(ctx context.Context, key string) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := r.db.WithContext(ctx).
+		Where("jsonb_exists(settings, ?)", key).
+		Order("created_at").
+		Find(&tenants).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants by setting: %w", err)
+	}
+	return tenants, nil
+}
+
+// MarkTrialWarned records that admins were warned about the trial's end
+func (r *TenantRepository) MarkTrialWarned(ctx context.Context, tenantID uuid.UUID, warnedAt time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.Tenant{}).
+		Where("id = ?", tenantID).
+		Update("trial_warned_at", warnedAt)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark trial warning: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tenant not found")
+	}
+	return nil
+}
+
+// ClaimTrialExpiry marks an ended trial as expired. It reports false when the
+// trial hasn't ended or was already claimed, so only one instance acts on it.
+func (r *TenantRepository) ClaimTrialExpiry(ctx context.Context, tenantID uuid.UUID, expiredAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Tenant{}).
+		Where("id = ? AND trial_ends_at <= ? AND trial_expired_at IS NULL", tenantID, expiredAt).
+		Update("trial_expired_at", expiredAt)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim trial expiry: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (r *TenantRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	result := r.db.WithContext(ctx).Delete(&models.Tenant{}, id)
 	if result.Error != nil {
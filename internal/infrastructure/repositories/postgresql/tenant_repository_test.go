@@ -104,6 +104,34 @@ func TestTenantRepository_GetBySubdomain(t *testing.T) {
 	assert.Equal(t, original.Subdomain, found.Subdomain)
 }
 
+func TestTenantRepository_Update_KeepsUsage(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewTenantRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	stale, err := repo.GetByID(ctx, tenant.ID)
+	require.NoError(t, err)
+
+	// Usage recorded after the tenant was read survives saving its settings
+	require.NoError(t, repo.UpdateUsage(ctx, tenant.ID, 2048, 3))
+	reserved, err := repo.ReserveDocumentSlots(ctx, tenant.ID, 1)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	stale.Settings = models.JSONB{"theme": "dark"}
+	require.NoError(t, repo.Update(ctx, stale))
+
+	found, err := repo.GetByID(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "dark", found.Settings["theme"])
+	assert.Equal(t, stale.StorageUsed+2048, found.StorageUsed)
+	assert.Equal(t, stale.APIUsed+3, found.APIUsed)
+	assert.Equal(t, stale.DocumentCount+1, found.DocumentCount)
+}
+
 func TestTenantRepository_UpdateUsage(t *testing.T) {
 	db := testutil.NewTestDB(t)
 	defer db.Cleanup(t)
@@ -125,6 +153,103 @@ func TestTenantRepository_UpdateUsage(t *testing.T) {
 	assert.Equal(t, 5, updated.APIUsed)
 }
 
+func TestTenantRepository_SetAPIUsed(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewTenantRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	require.NoError(t, repo.UpdateUsage(ctx, tenant.ID, 0, 5))
+
+	// The metered count replaces the old one
+	require.NoError(t, repo.SetAPIUsed(ctx, tenant.ID, 42))
+
+	updated, err := repo.GetByID(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 42, updated.APIUsed)
+
+	status, err := repo.CheckQuotaLimits(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, updated.APIQuota-42, status.APIRemaining)
+}
+
+func TestTenantRepository_ReserveDocumentSlots(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewTenantRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	require.NoError(t, db.Model(tenant).Updates(map[string]interface{}{"document_quota": 2, "document_count": 1}).Error)
+
+	reserved, err := repo.ReserveDocumentSlots(ctx, tenant.ID, 1)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	// Quota is full
+	reserved, err = repo.ReserveDocumentSlots(ctx, tenant.ID, 1)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+
+	quota, err := repo.CheckQuotaLimits(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), quota.DocumentCount)
+	assert.Equal(t, 100.0, quota.DocumentPercent)
+	assert.False(t, quota.CanAddDocument)
+
+	require.NoError(t, repo.ReleaseDocumentSlots(ctx, tenant.ID, 1))
+	reserved, err = repo.ReserveDocumentSlots(ctx, tenant.ID, 1)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	// Releasing never drives the count negative
+	require.NoError(t, repo.ReleaseDocumentSlots(ctx, tenant.ID, 10))
+	updated, err := repo.GetByID(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), updated.DocumentCount)
+}
+
+func TestTenantRepository_ReserveStorage(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewTenantRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	require.NoError(t, db.Model(tenant).Updates(map[string]interface{}{"storage_quota": 1000, "storage_used": 600}).Error)
+
+	reserved, err := repo.ReserveStorage(ctx, tenant.ID, 400)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	// Quota is full
+	reserved, err = repo.ReserveStorage(ctx, tenant.ID, 1)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+
+	quota, err := repo.CheckQuotaLimits(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), quota.StorageUsed)
+	assert.Equal(t, int64(0), quota.StorageRemaining)
+	assert.False(t, quota.CanUpload)
+
+	require.NoError(t, repo.ReleaseStorage(ctx, tenant.ID, 300))
+	quota, err = repo.CheckQuotaLimits(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), quota.StorageRemaining)
+	assert.True(t, quota.CanUpload)
+
+	// Releasing never drives usage negative
+	require.NoError(t, repo.ReleaseStorage(ctx, tenant.ID, 5000))
+	updated, err := repo.GetByID(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), updated.StorageUsed)
+}
+
 func TestTenantRepository_CheckQuotaLimits(t *testing.T) {
 	db := testutil.NewTestDB(t)
 	defer db.Cleanup(t)
@@ -0,0 +1,15 @@
+package postgresql
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// tenantScope constrains a query to one tenant's rows. Lookups made on a
+// tenant's behalf go through it, so the ID of another tenant's row finds
+// nothing rather than relying on every caller to compare tenant IDs.
+func tenantScope(tenantID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("tenant_id = ?", tenantID)
+	}
+}
@@ -0,0 +1,188 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tenantImportBatchSize is how many rows an import inserts per statement
+const tenantImportBatchSize = 500
+
+type TenantTransferRepository struct {
+	db *database.DB
+}
+
+func NewTenantTransferRepository(db *database.DB) repositories.TenantTransferRepository {
+	return &TenantTransferRepository{db: db}
+}
+
+func (r *TenantTransferRepository) ListFolders(ctx context.Context, tenantID uuid.UUID) ([]models.Folder, error) {
+	var folders []models.Folder
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("level ASC, path ASC").Find(&folders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	return folders, nil
+}
+
+func (r *TenantTransferRepository) ListTags(ctx context.Context, tenantID uuid.UUID) ([]models.Tag, error) {
+	var tags []models.Tag
+	if err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("name ASC").Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	return tags, nil
+}
+
+func (r *TenantTransferRepository) ListCategories(ctx context.Context, tenantID uuid.UUID) ([]models.Category, error) {
+	var categories []models.Category
+	if err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("sort_order ASC, name ASC").Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return categories, nil
+}
+
+func (r *TenantTransferRepository) ListWorkflows(ctx context.Context, tenantID uuid.UUID) ([]models.Workflow, error) {
+	var workflows []models.Workflow
+	if err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("created_at ASC").Find(&workflows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+	return workflows, nil
+}
+
+func (r *TenantTransferRepository) ListDocuments(ctx context.Context, tenantID uuid.UUID, after uuid.UUID, limit int) ([]models.Document, error) {
+	selectID := func(db *gorm.DB) *gorm.DB {
+		return db.Select("id")
+	}
+
+	var documents []models.Document
+	err := r.db.WithContext(ctx).
+		Preload("Tags", selectID).
+		Preload("Categories", selectID).
+		Where("tenant_id = ? AND deleted_at IS NULL AND id > ?", tenantID, after).
+		Order("id ASC").Limit(limit).Find(&documents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	return documents, nil
+}
+
+func (r *TenantTransferRepository) CountDocuments(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.Document{}).Where("tenant_id = ?", tenantID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	return count, nil
+}
+
+func (r *TenantTransferRepository) UsedUserIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	used := make(map[uuid.UUID]bool)
+	if len(ids) == 0 {
+		return used, nil
+	}
+
+	var found []uuid.UUID
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+		return nil, fmt.Errorf("failed to check user IDs: %w", err)
+	}
+	for _, id := range found {
+		used[id] = true
+	}
+	return used, nil
+}
+
+// Import inserts rows in dependency order, so parents exist before the
+// rows that point at them. Workflows start over at version 1.
+func (r *TenantTransferRepository) Import(ctx context.Context, data *repositories.TenantImportData) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Relationships are linked by ID, never created through the rows
+		insert := func() *gorm.DB {
+			return tx.Omit(clause.Associations)
+		}
+
+		if data.Tenant != nil {
+			if err := insert().Create(data.Tenant).Error; err != nil {
+				if isDuplicateKeyError(err) {
+					return fmt.Errorf("tenant with subdomain '%s' already exists", data.Tenant.Subdomain)
+				}
+				return fmt.Errorf("failed to create tenant: %w", err)
+			}
+		}
+
+		if len(data.Users) > 0 {
+			if err := insert().CreateInBatches(data.Users, tenantImportBatchSize).Error; err != nil {
+				return fmt.Errorf("failed to import users: %w", err)
+			}
+		}
+		// One at a time, so every parent is in before its children
+		for i := range data.Folders {
+			if err := insert().Create(&data.Folders[i]).Error; err != nil {
+				return fmt.Errorf("failed to import folder %s: %w", data.Folders[i].Path, err)
+			}
+		}
+		if len(data.Tags) > 0 {
+			if err := insert().CreateInBatches(data.Tags, tenantImportBatchSize).Error; err != nil {
+				return fmt.Errorf("failed to import tags: %w", err)
+			}
+		}
+		if len(data.Categories) > 0 {
+			if err := insert().CreateInBatches(data.Categories, tenantImportBatchSize).Error; err != nil {
+				return fmt.Errorf("failed to import categories: %w", err)
+			}
+		}
+
+		for i := range data.Workflows {
+			workflow := &data.Workflows[i]
+			workflow.Version = 1
+			if err := insert().Create(workflow).Error; err != nil {
+				return fmt.Errorf("failed to import workflow: %w", err)
+			}
+			version := &models.WorkflowVersion{
+				ID:              uuid.New(),
+				TenantID:        workflow.TenantID,
+				WorkflowID:      workflow.ID,
+				Version:         workflow.Version,
+				Rules:           workflow.Rules,
+				MigrationPolicy: models.WorkflowFinishOnOld,
+				CreatedBy:       workflow.CreatedBy,
+			}
+			if err := tx.Create(version).Error; err != nil {
+				return fmt.Errorf("failed to import workflow version: %w", err)
+			}
+		}
+
+		if len(data.Documents) > 0 {
+			if err := insert().CreateInBatches(data.Documents, tenantImportBatchSize).Error; err != nil {
+				return fmt.Errorf("failed to import documents: %w", err)
+			}
+		}
+		var documentTags, documentCategories []map[string]interface{}
+		for _, document := range data.Documents {
+			for _, tag := range document.Tags {
+				documentTags = append(documentTags, map[string]interface{}{"document_id": document.ID, "tag_id": tag.ID})
+			}
+			for _, category := range document.Categories {
+				documentCategories = append(documentCategories, map[string]interface{}{"document_id": document.ID, "category_id": category.ID})
+			}
+		}
+		if len(documentTags) > 0 {
+			if err := tx.Table("document_tags").CreateInBatches(documentTags, tenantImportBatchSize).Error; err != nil {
+				return fmt.Errorf("failed to import document tags: %w", err)
+			}
+		}
+		if len(documentCategories) > 0 {
+			if err := tx.Table("document_categories").CreateInBatches(documentCategories, tenantImportBatchSize).Error; err != nil {
+				return fmt.Errorf("failed to import document categories: %w", err)
+			}
+		}
+		return nil
+	})
+}
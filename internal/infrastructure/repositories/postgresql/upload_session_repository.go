@@ -0,0 +1,71 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UploadSessionRepository struct {
+	db *database.DB
+}
+
+func NewUploadSessionRepository(db *database.DB) repositories.UploadSessionRepository {
+	return &UploadSessionRepository{db: db}
+}
+
+func (r *UploadSessionRepository) Create(ctx context.Context, session *models.UploadSession) error {
+	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create upload session: %w", err)
+	}
+	return nil
+}
+
+func (r *UploadSessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.UploadSession, error) {
+	var session models.UploadSession
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("upload session not found")
+		}
+		return nil, fmt.Errorf("failed to get upload session: %w", err)
+	}
+	return &session, nil
+}
+
+func (r *UploadSessionRepository) Transition(ctx context.Context, session *models.UploadSession, from models.UploadSessionStatus) (bool, error) {
+	session.UpdatedAt = time.Now()
+	result := r.db.WithContext(ctx).Model(&models.UploadSession{}).
+		Where("id = ? AND status = ?", session.ID, from).
+		Updates(map[string]interface{}{
+			"status":         session.Status,
+			"failure_reason": session.FailureReason,
+			"document_id":    session.DocumentID,
+			"finalized_at":   session.FinalizedAt,
+			"updated_at":     session.UpdatedAt,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update upload session: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ListExpired returns pending sessions that expired before the given time,
+// oldest first
+func (r *UploadSessionRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]models.UploadSession, error) {
+	var sessions []models.UploadSession
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", models.UploadSessionPending, before).
+		Order("expires_at ASC").Limit(limit).Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired upload sessions: %w", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,62 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadSessionRepository_Transition(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewUploadSessionRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	session := &models.UploadSession{
+		ID:          uuid.New(),
+		TenantID:    tenant.ID,
+		UserID:      user.ID,
+		StoragePath: tenant.ID.String() + "/large.pdf",
+		Filename:    "large.pdf",
+		ContentType: "application/pdf",
+		FileSize:    1 << 30,
+		ContentHash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		Status:      models.UploadSessionPending,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	}
+	require.NoError(t, repo.Create(ctx, session))
+
+	expired, err := repo.ListExpired(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+
+	session.Status = models.UploadSessionFinalizing
+	ok, err := repo.Transition(ctx, session, models.UploadSessionPending)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// A second finalize or the expiry worker loses the race
+	session.Status = models.UploadSessionExpired
+	ok, err = repo.Transition(ctx, session, models.UploadSessionPending)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	found, err := repo.GetByID(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.UploadSessionFinalizing, found.Status)
+
+	// Only pending sessions expire
+	expired, err = repo.ListExpired(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+}
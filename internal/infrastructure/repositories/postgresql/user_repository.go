@@ -87,6 +87,19 @@ func (r *UserRepository) UpdateLastLogin(ctx context.Context, userID uuid.UUID)
 	return nil
 }
 
+func (r *UserRepository) GetByExternalID(ctx context.Context, tenantID uuid.UUID, externalID string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND external_id = ?", tenantID, externalID).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
 func (r *UserRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID, params repositories.ListParams) ([]models.User, int64, error) {
 	var users []models.User
 	var total int64
@@ -123,10 +136,36 @@ func (r *UserRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID, p
 	return users, total, nil
 }
 
+// ListByRole returns the tenant's users holding a built-in role
+func (r *UserRepository) ListByRole(ctx context.Context, tenantID uuid.UUID, role models.UserRole) ([]models.User, error) {
+	var users []models.User
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND role = ?", tenantID, role).
+		Order("email ASC").Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users by role: %w", err)
+	}
+	return users, nil
+}
+
+// ListByCustomRole returns the users assigned a custom role
+func (r *UserRepository) ListByCustomRole(ctx context.Context, roleID uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	err := r.db.WithContext(ctx).
+		Where("custom_role_id = ?", roleID).
+		Order("email ASC").Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users by custom role: %w", err)
+	}
+	return users, nil
+}
+
 func (r *UserRepository) SetMFA(ctx context.Context, userID uuid.UUID, enabled bool, secret string) error {
 	updates := map[string]interface{}{
-		"mfa_enabled": enabled,
-		"mfa_secret":  secret,
+		"mfa_enabled":        enabled,
+		"mfa_secret":         secret,
+		"mfa_pending_secret": "",
+		"mfa_last_used_step": 0,
 	}
 
 	result := r.db.WithContext(ctx).Model(&models.User{}).
@@ -151,3 +190,41 @@ func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+// SetPendingMFA stores a secret awaiting enrollment confirmation
+func (r *UserRepository) SetPendingMFA(ctx context.Context, userID uuid.UUID, secret string) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userID).Update("mfa_pending_secret", secret)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update pending MFA secret: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// ClaimMFAStep records a TOTP time step as used. It returns false when the
+// step, or a later one, was already accepted so a code can't be replayed.
+func (r *UserRepository) RecordPhoneCodeAttempt(ctx context.Context, userID uuid.UUID, maxAttempts int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND phone_verification_attempts < ?", userID, maxAttempts).
+		Update("phone_verification_attempts", gorm.Expr("phone_verification_attempts + 1"))
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record phone code attempt: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *UserRepository) ClaimMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND mfa_last_used_step < ?", userID, step).
+		Update("mfa_last_used_step", step)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record MFA step: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -217,6 +217,48 @@ func TestUserRepository_ListByTenant(t *testing.T) {
 	}
 }
 
+func TestUserRepository_GetByExternalID(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewUserRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	user.ExternalID = "idp-00u1abcd"
+	require.NoError(t, repo.Update(ctx, user))
+
+	found, err := repo.GetByExternalID(ctx, tenant.ID, "idp-00u1abcd")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	// External IDs are scoped to the tenant
+	_, err = repo.GetByExternalID(ctx, db.CreateTestTenant(t).ID, "idp-00u1abcd")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestUserRepository_ListByRole(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewUserRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	viewer := db.CreateTestUser(t, tenant)
+	viewer.Role = models.UserRoleViewer
+	require.NoError(t, repo.Update(ctx, viewer))
+	_ = db.CreateTestUser(t, tenant)
+
+	users, err := repo.ListByRole(ctx, tenant.ID, models.UserRoleViewer)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, viewer.ID, users[0].ID)
+}
+
 func TestUserRepository_SetMFA(t *testing.T) {
 	db := testutil.NewTestDB(t)
 	defer db.Cleanup(t)
@@ -239,6 +281,29 @@ func TestUserRepository_SetMFA(t *testing.T) {
 	assert.Equal(t, secret, updated.MFASecret)
 }
 
+func TestUserRepository_ClaimMFAStep(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewUserRepository(db.DB)
+	ctx := context.Background()
+
+	user := db.CreateTestUser(t, db.CreateTestTenant(t))
+
+	claimed, err := repo.ClaimMFAStep(ctx, user.ID, 100)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// The same or an earlier step is a replay
+	claimed, err = repo.ClaimMFAStep(ctx, user.ID, 100)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	claimed, err = repo.ClaimMFAStep(ctx, user.ID, 99)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
+
 func TestUserRepository_Delete(t *testing.T) {
 	db := testutil.NewTestDB(t)
 	defer db.Cleanup(t)
@@ -0,0 +1,141 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type VendorProfileRepository struct {
+	db *database.DB
+}
+
+func NewVendorProfileRepository(db *database.DB) repositories.VendorProfileRepository {
+	return &VendorProfileRepository{db: db}
+}
+
+func (r *VendorProfileRepository) Create(ctx context.Context, profile *models.VendorProfile) error {
+	for i := range profile.Aliases {
+		profile.Aliases[i].TenantID = profile.TenantID
+	}
+	if err := r.db.WithContext(ctx).Create(profile).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("vendor profile for '%s' already exists", profile.VendorName)
+		}
+		return fmt.Errorf("failed to create vendor profile: %w", err)
+	}
+	return nil
+}
+
+func (r *VendorProfileRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.VendorProfile, error) {
+	var profile models.VendorProfile
+	err := r.db.WithContext(ctx).Preload("Aliases").Where("id = ?", id).First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("vendor profile not found")
+		}
+		return nil, fmt.Errorf("failed to get vendor profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// GetByName finds the profile whose name or one of whose aliases is the
+// normalized name
+func (r *VendorProfileRepository) GetByName(ctx context.Context, tenantID uuid.UUID, normalizedName string) (*models.VendorProfile, error) {
+	var profile models.VendorProfile
+	err := r.db.WithContext(ctx).Preload("Aliases").
+		Where("tenant_id = ?", tenantID).
+		Where("normalized_name = ? OR id IN (SELECT profile_id FROM vendor_profile_aliases WHERE tenant_id = ? AND alias = ?)",
+			normalizedName, tenantID, normalizedName).
+		First(&profile).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("vendor profile not found")
+		}
+		return nil, fmt.Errorf("failed to get vendor profile by name: %w", err)
+	}
+	return &profile, nil
+}
+
+func (r *VendorProfileRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.VendorProfile, error) {
+	var profiles []models.VendorProfile
+	err := r.db.WithContext(ctx).Preload("Aliases").
+		Where("tenant_id = ?", tenantID).
+		Order("vendor_name ASC").Find(&profiles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vendor profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+// Update saves the profile and replaces its aliases
+func (r *VendorProfileRepository) Update(ctx context.Context, profile *models.VendorProfile) error {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Omit("Aliases").Save(profile).Error; err != nil {
+		tx.Rollback()
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("vendor profile for '%s' already exists", profile.VendorName)
+		}
+		return fmt.Errorf("failed to update vendor profile: %w", err)
+	}
+
+	if err := tx.Where("profile_id = ?", profile.ID).Delete(&models.VendorProfileAlias{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to clear vendor profile aliases: %w", err)
+	}
+
+	if len(profile.Aliases) > 0 {
+		for i := range profile.Aliases {
+			profile.Aliases[i].ProfileID = profile.ID
+			profile.Aliases[i].TenantID = profile.TenantID
+		}
+		if err := tx.Create(&profile.Aliases).Error; err != nil {
+			tx.Rollback()
+			if isDuplicateKeyError(err) {
+				return fmt.Errorf("vendor alias already belongs to another profile")
+			}
+			return fmt.Errorf("failed to save vendor profile aliases: %w", err)
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// Delete removes a profile with its aliases
+func (r *VendorProfileRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Where("profile_id = ?", id).Delete(&models.VendorProfileAlias{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete vendor profile aliases: %w", err)
+	}
+
+	result := tx.Where("id = ?", id).Delete(&models.VendorProfile{})
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete vendor profile: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("vendor profile not found")
+	}
+
+	return tx.Commit().Error
+}
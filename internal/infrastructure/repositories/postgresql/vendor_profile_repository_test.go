@@ -0,0 +1,62 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVendorProfileRepository_GetByName(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewVendorProfileRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	profile := &models.VendorProfile{
+		ID:             uuid.New(),
+		TenantID:       tenant.ID,
+		VendorName:     "Acme Corporation",
+		NormalizedName: "acme corporation",
+		DateFormat:     "DD/MM/YYYY",
+		Source:         models.VendorProfileConfigured,
+		CreatedBy:      user.ID,
+		Aliases:        []models.VendorProfileAlias{{Alias: "acme corp"}},
+	}
+	require.NoError(t, repo.Create(ctx, profile))
+
+	found, err := repo.GetByName(ctx, tenant.ID, "acme corporation")
+	require.NoError(t, err)
+	assert.Equal(t, profile.ID, found.ID)
+
+	// Aliases find the profile too
+	found, err = repo.GetByName(ctx, tenant.ID, "acme corp")
+	require.NoError(t, err)
+	assert.Equal(t, profile.ID, found.ID)
+	require.Len(t, found.Aliases, 1)
+
+	// but not in another tenant
+	other := db.CreateTestTenant(t)
+	_, err = repo.GetByName(ctx, other.ID, "acme corp")
+	assert.Error(t, err)
+
+	// Updating replaces the aliases
+	found.Aliases = []models.VendorProfileAlias{{Alias: "acme inc"}}
+	require.NoError(t, repo.Update(ctx, found))
+	_, err = repo.GetByName(ctx, tenant.ID, "acme corp")
+	assert.Error(t, err)
+	_, err = repo.GetByName(ctx, tenant.ID, "acme inc")
+	assert.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, profile.ID))
+	_, err = repo.GetByName(ctx, tenant.ID, "acme inc")
+	assert.Error(t, err)
+}
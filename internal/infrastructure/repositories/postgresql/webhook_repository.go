@@ -0,0 +1,281 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// webhookEventWildcard subscribes a webhook to every event type
+const webhookEventWildcard = "*"
+
+type WebhookRepository struct {
+	db *database.DB
+}
+
+func NewWebhookRepository(db *database.DB) repositories.WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	if err := r.db.WithContext(ctx).Create(webhook).Error; err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.db.WithContext(ctx).Preload("Events").Where("id = ?", id).First(&webhook).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return &webhook, nil
+}
+
+func (r *WebhookRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).Preload("Events").
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").Find(&webhooks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// ListSubscribed returns the tenant's active webhooks subscribed to an event
+// type, directly or through the wildcard
+func (r *WebhookRepository) ListSubscribed(ctx context.Context, tenantID uuid.UUID, eventType string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Where("EXISTS (SELECT 1 FROM webhook_events WHERE webhook_events.webhook_id = webhooks.id AND webhook_events.event_type IN ?)",
+			[]string{eventType, webhookEventWildcard}).
+		Find(&webhooks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscribed webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// Update saves the webhook and replaces its event subscriptions
+func (r *WebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Omit("Events").Save(webhook).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookEvent{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to clear webhook events: %w", err)
+	}
+
+	if len(webhook.Events) > 0 {
+		for i := range webhook.Events {
+			webhook.Events[i].WebhookID = webhook.ID
+		}
+		if err := tx.Create(&webhook.Events).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to save webhook events: %w", err)
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// Delete removes a webhook with its subscriptions and delivery log
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookEvent{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete webhook events: %w", err)
+	}
+	if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDeliveryAttempt{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete webhook delivery attempts: %w", err)
+	}
+	if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+
+	result := tx.Where("id = ?", id).Delete(&models.Webhook{})
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete webhook: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("webhook not found")
+	}
+
+	return tx.Commit().Error
+}
+
+// RecordDeliveryResult updates the webhook's health after a delivery attempt
+// finishes. An empty lastError resets the consecutive failure count.
+func (r *WebhookRepository) RecordDeliveryResult(ctx context.Context, id uuid.UUID, deliveredAt time.Time, lastError string) error {
+	updates := map[string]interface{}{
+		"last_delivery_at": deliveredAt,
+		"last_error":       lastError,
+		"failure_count":    0,
+	}
+	if lastError != "" {
+		updates["failure_count"] = gorm.Expr("failure_count + 1")
+	}
+
+	err := r.db.WithContext(ctx).Model(&models.Webhook{}).
+		Where("id = ?", id).
+		UpdateColumns(updates).Error
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery result: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ListDueDeliveries returns pending deliveries whose next attempt is due,
+// oldest first
+func (r *WebhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// ClaimDelivery leases a due delivery to the caller by pushing its next
+// attempt to leaseUntil. It returns false when another worker claimed it first.
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, id uuid.UUID, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, models.WebhookDeliveryPending, now).
+		Update("next_attempt_at", leaseUntil)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	result := r.db.WithContext(ctx).Omit("Webhook").Save(delivery)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook delivery not found")
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&delivery).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("webhook delivery not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns a page of the webhook's deliveries, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, filters repositories.WebhookDeliveryFilters, params repositories.ListParams) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.EventType != "" {
+		query = query.Where("event_type = ?", filters.EventType)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at < ?", *filters.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(params.PageSize).Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, total, nil
+}
+
+func (r *WebhookRepository) CreateDeliveryAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error {
+	if err := r.db.WithContext(ctx).Create(attempt).Error; err != nil {
+		return fmt.Errorf("failed to log webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveryAttempts returns a delivery's attempts, first attempt first
+func (r *WebhookRepository) ListDeliveryAttempts(ctx context.Context, deliveryID uuid.UUID) ([]models.WebhookDeliveryAttempt, error) {
+	var attempts []models.WebhookDeliveryAttempt
+	err := r.db.WithContext(ctx).
+		Where("delivery_id = ?", deliveryID).
+		Order("attempted_at ASC").
+		Find(&attempts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook delivery attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// DeleteDeliveryAttemptsBefore removes attempts logged before the given time
+func (r *WebhookRepository) DeleteDeliveryAttemptsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("attempted_at < ?", before).
+		Delete(&models.WebhookDeliveryAttempt{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete webhook delivery attempts: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,204 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestWebhook(t *testing.T, repo *WebhookRepository, tenant *models.Tenant, user *models.User, events ...string) *models.Webhook {
+	t.Helper()
+
+	webhook := &models.Webhook{
+		ID:        uuid.New(),
+		TenantID:  tenant.ID,
+		URL:       "https://example.com/hooks/" + uuid.NewString(),
+		Secret:    "whsec_test",
+		IsActive:  true,
+		CreatedBy: user.ID,
+	}
+	for _, event := range events {
+		webhook.Events = append(webhook.Events, models.WebhookEvent{EventType: event})
+	}
+	require.NoError(t, repo.Create(context.Background(), webhook))
+	return webhook
+}
+
+func TestWebhookRepository_ListSubscribed(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWebhookRepository(db.DB).(*WebhookRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+
+	uploads := createTestWebhook(t, repo, tenant, user, "document.uploaded")
+	all := createTestWebhook(t, repo, tenant, user, "*")
+	createTestWebhook(t, repo, tenant, user, "share.created")
+
+	inactive := createTestWebhook(t, repo, tenant, user, "document.uploaded")
+	inactive.IsActive = false
+	require.NoError(t, repo.Update(ctx, inactive))
+
+	webhooks, err := repo.ListSubscribed(ctx, tenant.ID, "document.uploaded")
+	require.NoError(t, err)
+
+	ids := make([]uuid.UUID, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		ids = append(ids, webhook.ID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{uploads.ID, all.ID}, ids)
+
+	// Other tenants never receive the event
+	otherTenant := db.CreateTestTenant(t)
+	webhooks, err = repo.ListSubscribed(ctx, otherTenant.ID, "document.uploaded")
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+}
+
+func TestWebhookRepository_UpdateReplacesEvents(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWebhookRepository(db.DB).(*WebhookRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	webhook := createTestWebhook(t, repo, tenant, user, "document.uploaded", "document.deleted")
+
+	webhook.Events = []models.WebhookEvent{{EventType: "share.created"}}
+	require.NoError(t, repo.Update(ctx, webhook))
+
+	found, err := repo.GetByID(ctx, webhook.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Events, 1)
+	assert.Equal(t, "share.created", found.Events[0].EventType)
+}
+
+func TestWebhookRepository_Deliveries(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWebhookRepository(db.DB).(*WebhookRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	webhook := createTestWebhook(t, repo, tenant, user, "*")
+
+	now := time.Now()
+	due := models.WebhookDelivery{
+		ID: uuid.New(), TenantID: tenant.ID, WebhookID: webhook.ID, EventID: uuid.New(), EventType: "document.uploaded",
+		Payload: models.JSONB{"type": "document.uploaded"}, Status: models.WebhookDeliveryPending, MaxAttempts: 8,
+		NextAttemptAt: now.Add(-time.Minute),
+	}
+	later := due
+	later.ID = uuid.New()
+	later.NextAttemptAt = now.Add(time.Hour)
+	require.NoError(t, repo.CreateDeliveries(ctx, []models.WebhookDelivery{due, later}))
+
+	deliveries, err := repo.ListDueDeliveries(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, due.ID, deliveries[0].ID)
+
+	claimed, err := repo.ClaimDelivery(ctx, due.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// A second worker loses the race
+	claimed, err = repo.ClaimDelivery(ctx, due.ID, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	require.NoError(t, repo.RecordDeliveryResult(ctx, webhook.ID, now, "endpoint responded with HTTP 500"))
+	require.NoError(t, repo.RecordDeliveryResult(ctx, webhook.ID, now, "endpoint responded with HTTP 500"))
+	found, err := repo.GetByID(ctx, webhook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, found.FailureCount)
+
+	require.NoError(t, repo.RecordDeliveryResult(ctx, webhook.ID, now, ""))
+	found, err = repo.GetByID(ctx, webhook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, found.FailureCount)
+
+	require.NoError(t, repo.Delete(ctx, webhook.ID))
+	_, err = repo.GetByID(ctx, webhook.ID)
+	assert.Error(t, err)
+
+	var remaining int64
+	require.NoError(t, db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhook.ID).Count(&remaining).Error)
+	assert.Equal(t, int64(0), remaining)
+}
+
+func TestWebhookRepository_DeliveryLog(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWebhookRepository(db.DB).(*WebhookRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	webhook := createTestWebhook(t, repo, tenant, user, "*")
+
+	now := time.Now()
+	failed := models.WebhookDelivery{
+		ID: uuid.New(), TenantID: tenant.ID, WebhookID: webhook.ID, EventID: uuid.New(), EventType: "document.uploaded",
+		Payload: models.JSONB{"type": "document.uploaded"}, Status: models.WebhookDeliveryFailed, MaxAttempts: 8,
+		NextAttemptAt: now, CreatedAt: now.Add(-2 * time.Hour),
+	}
+	succeeded := failed
+	succeeded.ID = uuid.New()
+	succeeded.EventType = "share.created"
+	succeeded.Status = models.WebhookDeliverySucceeded
+	succeeded.CreatedAt = now.Add(-time.Hour)
+	require.NoError(t, repo.CreateDeliveries(ctx, []models.WebhookDelivery{failed, succeeded}))
+
+	deliveries, total, err := repo.ListDeliveries(ctx, webhook.ID, repositories.WebhookDeliveryFilters{}, repositories.ListParams{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, succeeded.ID, deliveries[0].ID)
+
+	from := now.Add(-3 * time.Hour)
+	to := now.Add(-90 * time.Minute)
+	deliveries, total, err = repo.ListDeliveries(ctx, webhook.ID, repositories.WebhookDeliveryFilters{
+		Status: models.WebhookDeliveryFailed, From: &from, To: &to,
+	}, repositories.ListParams{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, failed.ID, deliveries[0].ID)
+
+	for i, attemptedAt := range []time.Time{now.Add(-48 * time.Hour), now} {
+		require.NoError(t, repo.CreateDeliveryAttempt(ctx, &models.WebhookDeliveryAttempt{
+			ID: uuid.New(), TenantID: tenant.ID, WebhookID: webhook.ID, DeliveryID: failed.ID,
+			Attempt: i + 1, ResponseStatus: 503, LatencyMS: 120, AttemptedAt: attemptedAt,
+		}))
+	}
+
+	attempts, err := repo.ListDeliveryAttempts(ctx, failed.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, 1, attempts[0].Attempt)
+
+	deleted, err := repo.DeleteDeliveryAttemptsBefore(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	require.NoError(t, repo.Delete(ctx, webhook.ID))
+	var remaining int64
+	require.NoError(t, db.Model(&models.WebhookDeliveryAttempt{}).Where("webhook_id = ?", webhook.ID).Count(&remaining).Error)
+	assert.Equal(t, int64(0), remaining)
+}
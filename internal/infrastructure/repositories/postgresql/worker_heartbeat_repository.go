@@ -0,0 +1,58 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"gorm.io/gorm/clause"
+)
+
+type WorkerHeartbeatRepository struct {
+	db *database.DB
+}
+
+func NewWorkerHeartbeatRepository(db *database.DB) repositories.WorkerHeartbeatRepository {
+	return &WorkerHeartbeatRepository{db: db}
+}
+
+// Upsert records a worker's latest poll on its instance
+func (r *WorkerHeartbeatRepository) Upsert(ctx context.Context, heartbeat *models.WorkerHeartbeat) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "instance_id"}, {Name: "worker"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"hostname", "pid", "poll_interval", "last_error", "started_at", "last_poll_at",
+		}),
+	}).Create(heartbeat).Error
+	if err != nil {
+		return fmt.Errorf("failed to record worker heartbeat: %w", err)
+	}
+	return nil
+}
+
+// List returns every worker's heartbeat, by worker then instance
+func (r *WorkerHeartbeatRepository) List(ctx context.Context) ([]models.WorkerHeartbeat, error) {
+	var heartbeats []models.WorkerHeartbeat
+	err := r.db.WithContext(ctx).
+		Order("worker ASC, instance_id ASC").
+		Find(&heartbeats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worker heartbeats: %w", err)
+	}
+	return heartbeats, nil
+}
+
+// DeleteBefore removes the heartbeats of workers that haven't polled since
+// before, i.e. of instances long gone
+func (r *WorkerHeartbeatRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("last_poll_at < ?", before).
+		Delete(&models.WorkerHeartbeat{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete worker heartbeats: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,47 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerHeartbeatRepository_UpsertAndDelete(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWorkerHeartbeatRepository(db.DB)
+	ctx := context.Background()
+
+	started := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, repo.Upsert(ctx, &models.WorkerHeartbeat{
+		InstanceID: "instance-a", Worker: "webhook_delivery", StartedAt: started, LastPollAt: started,
+	}))
+	require.NoError(t, repo.Upsert(ctx, &models.WorkerHeartbeat{
+		InstanceID: "instance-a", Worker: "webhook_delivery", StartedAt: started, LastPollAt: started.Add(time.Hour), LastError: "timeout",
+	}))
+	require.NoError(t, repo.Upsert(ctx, &models.WorkerHeartbeat{
+		InstanceID: "instance-b", Worker: "notification_outbox", StartedAt: started, LastPollAt: started,
+	}))
+
+	heartbeats, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, heartbeats, 2)
+	assert.Equal(t, "notification_outbox", heartbeats[0].Worker)
+	assert.Equal(t, "webhook_delivery", heartbeats[1].Worker)
+	assert.Equal(t, "timeout", heartbeats[1].LastError)
+
+	deleted, err := repo.DeleteBefore(ctx, started.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	heartbeats, err = repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, heartbeats, 1)
+	assert.Equal(t, "instance-a", heartbeats[0].InstanceID)
+}
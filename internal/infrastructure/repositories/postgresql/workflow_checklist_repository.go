@@ -0,0 +1,78 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+)
+
+type WorkflowChecklistRepository struct {
+	db *database.DB
+}
+
+func NewWorkflowChecklistRepository(db *database.DB) repositories.WorkflowChecklistRepository {
+	return &WorkflowChecklistRepository{db: db}
+}
+
+func (r *WorkflowChecklistRepository) CreateBatch(ctx context.Context, items []models.WorkflowChecklistItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(&items).Error; err != nil {
+		return fmt.Errorf("failed to create checklist items: %w", err)
+	}
+	return nil
+}
+
+func (r *WorkflowChecklistRepository) ListByTask(ctx context.Context, taskID uuid.UUID) ([]models.WorkflowChecklistItem, error) {
+	var items []models.WorkflowChecklistItem
+	err := r.db.WithContext(ctx).
+		Where("task_id = ?", taskID).
+		Order("position ASC").
+		Find(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checklist items: %w", err)
+	}
+	return items, nil
+}
+
+func (r *WorkflowChecklistRepository) Update(ctx context.Context, item *models.WorkflowChecklistItem) error {
+	result := r.db.WithContext(ctx).Save(item)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update checklist item: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("checklist item not found")
+	}
+	return nil
+}
+
+// GetStats reports how often each checklist item was ticked, grouped by workflow
+func (r *WorkflowChecklistRepository) GetStats(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]repositories.ChecklistItemStats, error) {
+	var stats []repositories.ChecklistItemStats
+
+	err := r.db.WithContext(ctx).Table("workflow_checklist_items AS i").
+		Select(`
+			i.workflow_id,
+			w.name as workflow_name,
+			i.item_key,
+			MAX(i.label) as label,
+			COUNT(*) as total_tasks,
+			COUNT(*) FILTER (WHERE i.is_checked) as checked_tasks
+		`).
+		Joins("JOIN workflows w ON w.id = i.workflow_id").
+		Where("i.tenant_id = ? AND i.created_at >= ?", tenantID, since).
+		Group("i.workflow_id, w.name, i.item_key").
+		Order("w.name ASC, i.item_key ASC").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checklist stats: %w", err)
+	}
+
+	return stats, nil
+}
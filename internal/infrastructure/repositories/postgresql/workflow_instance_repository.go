@@ -0,0 +1,120 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WorkflowInstanceRepository struct {
+	db *database.DB
+}
+
+func NewWorkflowInstanceRepository(db *database.DB) repositories.WorkflowInstanceRepository {
+	return &WorkflowInstanceRepository{db: db}
+}
+
+func (r *WorkflowInstanceRepository) Create(ctx context.Context, instance *models.WorkflowInstance) error {
+	if err := r.db.WithContext(ctx).Create(instance).Error; err != nil {
+		return fmt.Errorf("failed to create workflow instance: %w", err)
+	}
+	return nil
+}
+
+func (r *WorkflowInstanceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.WorkflowInstance, error) {
+	var instance models.WorkflowInstance
+	err := r.db.WithContext(ctx).
+		Preload("Workflow", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "tenant_id", "name", "doc_type", "rules", "version")
+		}).
+		Where("id = ?", id).First(&instance).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("workflow instance not found")
+		}
+		return nil, fmt.Errorf("failed to get workflow instance: %w", err)
+	}
+	return &instance, nil
+}
+
+func (r *WorkflowInstanceRepository) ListByDocument(ctx context.Context, documentID uuid.UUID) ([]models.WorkflowInstance, error) {
+	var instances []models.WorkflowInstance
+	err := r.db.WithContext(ctx).
+		Preload("Workflow", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "name", "doc_type")
+		}).
+		Where("document_id = ?", documentID).
+		Order("started_at DESC").Find(&instances).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow instances by document: %w", err)
+	}
+	return instances, nil
+}
+
+// ListByTenant lists the tenant's instances, newest first. An empty status
+// lists every status.
+func (r *WorkflowInstanceRepository) ListByTenant(ctx context.Context, tenantID uuid.UUID, status models.WorkflowStatus, params repositories.ListParams) ([]models.WorkflowInstance, int64, error) {
+	var instances []models.WorkflowInstance
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.WorkflowInstance{}).Where("tenant_id = ?", tenantID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count workflow instances: %w", err)
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	err := query.
+		Preload("Workflow", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "name", "doc_type")
+		}).
+		Order("started_at DESC").Offset(offset).Limit(params.PageSize).Find(&instances).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list workflow instances: %w", err)
+	}
+
+	return instances, total, nil
+}
+
+// AdvanceStep moves an in-progress instance from one step on to the next. It
+// returns false if the instance was no longer on fromStep, so only one of
+// several concurrent completions of a step advances it.
+func (r *WorkflowInstanceRepository) AdvanceStep(ctx context.Context, id uuid.UUID, fromStep, toStep int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.WorkflowInstance{}).
+		Where("id = ? AND status = ? AND current_step = ?", id, models.WorkflowPending, fromStep).
+		Updates(map[string]interface{}{
+			"current_step": toStep,
+			"updated_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to advance workflow instance: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Complete records the outcome of an in-progress instance. It returns false
+// if the instance had already completed.
+func (r *WorkflowInstanceRepository) Complete(ctx context.Context, id uuid.UUID, status models.WorkflowStatus) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.WorkflowInstance{}).
+		Where("id = ? AND status = ?", id, models.WorkflowPending).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"completed_at": now,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to complete workflow instance: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
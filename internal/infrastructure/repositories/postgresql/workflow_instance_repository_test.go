@@ -0,0 +1,79 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/domain/repositories"
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowInstanceRepository_Lifecycle(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWorkflowInstanceRepository(db.DB).(*WorkflowInstanceRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	task := createTestWorkflowTask(t, db, tenant, user)
+
+	instance := &models.WorkflowInstance{
+		ID:          uuid.New(),
+		TenantID:    tenant.ID,
+		WorkflowID:  task.WorkflowID,
+		DocumentID:  task.DocumentID,
+		Status:      models.WorkflowPending,
+		CurrentStep: 1,
+		StartedBy:   user.ID,
+		StartedAt:   time.Now(),
+	}
+	require.NoError(t, repo.Create(ctx, instance))
+
+	advanced, err := repo.AdvanceStep(ctx, instance.ID, 1, 2)
+	require.NoError(t, err)
+	assert.True(t, advanced)
+
+	// A second completion of step 1 doesn't advance it again
+	advanced, err = repo.AdvanceStep(ctx, instance.ID, 1, 2)
+	require.NoError(t, err)
+	assert.False(t, advanced)
+
+	completed, err := repo.Complete(ctx, instance.ID, models.WorkflowApproved)
+	require.NoError(t, err)
+	assert.True(t, completed)
+
+	// A completed instance keeps its outcome and step
+	completed, err = repo.Complete(ctx, instance.ID, models.WorkflowRejected)
+	require.NoError(t, err)
+	assert.False(t, completed)
+	advanced, err = repo.AdvanceStep(ctx, instance.ID, 2, 3)
+	require.NoError(t, err)
+	assert.False(t, advanced)
+
+	found, err := repo.GetByID(ctx, instance.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WorkflowApproved, found.Status)
+	assert.Equal(t, 2, found.CurrentStep)
+	assert.NotNil(t, found.CompletedAt)
+
+	byDocument, err := repo.ListByDocument(ctx, task.DocumentID)
+	require.NoError(t, err)
+	assert.Len(t, byDocument, 1)
+
+	pending, total, err := repo.ListByTenant(ctx, tenant.ID, models.WorkflowPending, repositories.ListParams{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	assert.Equal(t, int64(0), total)
+
+	all, total, err := repo.ListByTenant(ctx, tenant.ID, "", repositories.ListParams{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+	assert.Equal(t, int64(1), total)
+}
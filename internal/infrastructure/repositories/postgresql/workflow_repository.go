@@ -20,11 +20,27 @@ func NewWorkflowRepository(db *database.DB) repositories.WorkflowRepository {
 	return &WorkflowRepository{db: db}
 }
 
+// Create stores the workflow and records its rules as version 1
 func (r *WorkflowRepository) Create(ctx context.Context, workflow *models.Workflow) error {
-	if err := r.db.WithContext(ctx).Create(workflow).Error; err != nil {
-		return fmt.Errorf("failed to create workflow: %w", err)
-	}
-	return nil
+	workflow.Version = 1
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workflow).Error; err != nil {
+			return fmt.Errorf("failed to create workflow: %w", err)
+		}
+		version := &models.WorkflowVersion{
+			ID:              uuid.New(),
+			TenantID:        workflow.TenantID,
+			WorkflowID:      workflow.ID,
+			Version:         workflow.Version,
+			Rules:           workflow.Rules,
+			MigrationPolicy: models.WorkflowFinishOnOld,
+			CreatedBy:       workflow.CreatedBy,
+		}
+		if err := tx.Create(version).Error; err != nil {
+			return fmt.Errorf("failed to create workflow version: %w", err)
+		}
+		return nil
+	})
 }
 
 func (r *WorkflowRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Workflow, error) {
@@ -47,8 +63,10 @@ func (r *WorkflowRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	return &workflow, nil
 }
 
+// Update saves the workflow's settings. Rules only change through
+// UpdateRules, so every change of them is versioned.
 func (r *WorkflowRepository) Update(ctx context.Context, workflow *models.Workflow) error {
-	result := r.db.WithContext(ctx).Save(workflow)
+	result := r.db.WithContext(ctx).Omit("Rules", "Version").Save(workflow)
 	if result.Error != nil {
 		return fmt.Errorf("failed to update workflow: %w", result.Error)
 	}
@@ -65,7 +83,7 @@ func (r *WorkflowRepository) ListByTenant(ctx context.Context, tenantID uuid.UUI
 		Preload("Creator", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email")
 		}).
-		Select("id", "tenant_id", "name", "description", "doc_type", "is_active", "created_by", "created_at", "updated_at").
+		Select("id", "tenant_id", "name", "description", "doc_type", "is_active", "version", "created_by", "created_at", "updated_at").
 		Where("tenant_id = ?", tenantID).
 		Order("name ASC").Find(&workflows).Error
 	if err != nil {
@@ -81,7 +99,7 @@ func (r *WorkflowRepository) GetByDocumentType(ctx context.Context, tenantID uui
 		Preload("Creator", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email")
 		}).
-		Select("id", "tenant_id", "name", "description", "doc_type", "rules", "created_by", "created_at").
+		Select("id", "tenant_id", "name", "description", "doc_type", "rules", "is_active", "version", "created_by", "created_at").
 		Where("tenant_id = ? AND doc_type = ? AND is_active = ?", tenantID, docType, true).
 		Order("name ASC").Find(&workflows).Error
 	if err != nil {
@@ -120,6 +138,11 @@ func (r *WorkflowRepository) Delete(ctx context.Context, id uuid.UUID) error {
 		return fmt.Errorf("failed to delete workflow tasks: %w", err)
 	}
 
+	if err := tx.Where("workflow_id = ?", id).Delete(&models.WorkflowVersion{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete workflow versions: %w", err)
+	}
+
 	// Delete the workflow
 	result := tx.Delete(&models.Workflow{}, id)
 	if result.Error != nil {
@@ -133,3 +156,89 @@ func (r *WorkflowRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return tx.Commit().Error
 }
+
+// UpdateRules saves the workflow's changes together with its new rules
+// version. The workflow must still be on the version before it, so two
+// concurrent edits can't both claim the same number. With migrateSteps, runs
+// still in progress on an earlier version whose current step is one of them
+// move to the new version along with their pending tasks.
+func (r *WorkflowRepository) UpdateRules(ctx context.Context, workflow *models.Workflow, version *models.WorkflowVersion, migrateSteps []int) (bool, error) {
+	updated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Workflow{}).
+			Where("id = ? AND version = ?", workflow.ID, version.Version-1).
+			Updates(map[string]interface{}{
+				"name":        workflow.Name,
+				"description": workflow.Description,
+				"doc_type":    workflow.DocType,
+				"rules":       workflow.Rules,
+				"is_active":   workflow.IsActive,
+				"version":     version.Version,
+				"updated_at":  workflow.UpdatedAt,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update workflow: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if len(migrateSteps) > 0 {
+			var instanceIDs []uuid.UUID
+			err := tx.Model(&models.WorkflowInstance{}).
+				Where("workflow_id = ? AND status = ? AND workflow_version < ? AND current_step IN ?",
+					workflow.ID, models.WorkflowPending, version.Version, migrateSteps).
+				Pluck("id", &instanceIDs).Error
+			if err != nil {
+				return fmt.Errorf("failed to find workflow instances to migrate: %w", err)
+			}
+
+			if len(instanceIDs) > 0 {
+				if err := tx.Model(&models.WorkflowInstance{}).Where("id IN ?", instanceIDs).
+					Updates(map[string]interface{}{"workflow_version": version.Version, "updated_at": workflow.UpdatedAt}).Error; err != nil {
+					return fmt.Errorf("failed to migrate workflow instances: %w", err)
+				}
+				if err := tx.Model(&models.WorkflowTask{}).
+					Where("instance_id IN ? AND status = ?", instanceIDs, models.WorkflowPending).
+					Update("workflow_version", version.Version).Error; err != nil {
+					return fmt.Errorf("failed to migrate workflow tasks: %w", err)
+				}
+			}
+			version.MigratedInstances = len(instanceIDs)
+		}
+
+		if err := tx.Create(version).Error; err != nil {
+			return fmt.Errorf("failed to create workflow version: %w", err)
+		}
+		workflow.Version = version.Version
+		updated = true
+		return nil
+	})
+	return updated, err
+}
+
+func (r *WorkflowRepository) GetVersion(ctx context.Context, workflowID uuid.UUID, version int) (*models.WorkflowVersion, error) {
+	var workflowVersion models.WorkflowVersion
+	err := r.db.WithContext(ctx).
+		Where("workflow_id = ? AND version = ?", workflowID, version).
+		First(&workflowVersion).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("workflow version not found")
+		}
+		return nil, fmt.Errorf("failed to get workflow version: %w", err)
+	}
+	return &workflowVersion, nil
+}
+
+// ListVersions returns the workflow's versions, newest first
+func (r *WorkflowRepository) ListVersions(ctx context.Context, workflowID uuid.UUID) ([]models.WorkflowVersion, error) {
+	var versions []models.WorkflowVersion
+	err := r.db.WithContext(ctx).
+		Where("workflow_id = ?", workflowID).
+		Order("version DESC").Find(&versions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow versions: %w", err)
+	}
+	return versions, nil
+}
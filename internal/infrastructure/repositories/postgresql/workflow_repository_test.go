@@ -0,0 +1,98 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowRepository_UpdateRules(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWorkflowRepository(db.DB).(*WorkflowRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	document := db.CreateTestDocument(t, tenant, user)
+
+	workflow := &models.Workflow{
+		ID:        uuid.New(),
+		TenantID:  tenant.ID,
+		Name:      "Invoice approval",
+		DocType:   models.DocTypeInvoice,
+		Rules:     models.JSONB{"approval_steps": []interface{}{}},
+		IsActive:  true,
+		CreatedBy: user.ID,
+	}
+	require.NoError(t, repo.Create(ctx, workflow))
+
+	first, err := repo.GetVersion(ctx, workflow.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.Rules, first.Rules)
+
+	// One run on step 1, one on step 3, which the new rules drop
+	runs := make([]*models.WorkflowInstance, 0, 2)
+	for _, step := range []int{1, 3} {
+		instance := &models.WorkflowInstance{
+			ID:              uuid.New(),
+			TenantID:        tenant.ID,
+			WorkflowID:      workflow.ID,
+			DocumentID:      document.ID,
+			Status:          models.WorkflowPending,
+			CurrentStep:     step,
+			StartedBy:       user.ID,
+			StartedAt:       time.Now(),
+			WorkflowVersion: 1,
+		}
+		require.NoError(t, db.Create(instance).Error)
+		runs = append(runs, instance)
+	}
+
+	workflow.Rules = models.JSONB{"approval_steps": []interface{}{map[string]interface{}{"step_number": 1}}}
+	workflow.UpdatedAt = time.Now()
+	version := &models.WorkflowVersion{
+		ID:              uuid.New(),
+		TenantID:        tenant.ID,
+		WorkflowID:      workflow.ID,
+		Version:         2,
+		Rules:           workflow.Rules,
+		MigrationPolicy: models.WorkflowMigrate,
+		CreatedBy:       user.ID,
+	}
+	updated, err := repo.UpdateRules(ctx, workflow, version, []int{1})
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, 1, version.MigratedInstances)
+
+	var migrated, kept models.WorkflowInstance
+	require.NoError(t, db.First(&migrated, "id = ?", runs[0].ID).Error)
+	require.NoError(t, db.First(&kept, "id = ?", runs[1].ID).Error)
+	assert.Equal(t, 2, migrated.WorkflowVersion)
+	assert.Equal(t, 1, kept.WorkflowVersion)
+
+	// A second edit based on version 1 lost the race
+	stale := &models.WorkflowVersion{
+		ID:         uuid.New(),
+		TenantID:   tenant.ID,
+		WorkflowID: workflow.ID,
+		Version:    2,
+		Rules:      workflow.Rules,
+		CreatedBy:  user.ID,
+	}
+	updated, err = repo.UpdateRules(ctx, workflow, stale, nil)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	versions, err := repo.ListVersions(ctx, workflow.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version)
+}
@@ -72,7 +72,7 @@ func (r *WorkflowTaskRepository) ListByAssignee(ctx context.Context, userID uuid
 		Preload("Document", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "title", "file_name", "document_type", "status")
 		}).
-		Select("id", "workflow_id", "document_id", "assigned_to", "task_type", "status", "priority", "due_date", "comments", "created_at").
+		Select("id", "workflow_id", "document_id", "assigned_to", "task_type", "status", "priority", "step_number", "due_date", "comments", "created_at").
 		Where("assigned_to = ?", userID)
 
 	// Filter by status if provided
@@ -97,7 +97,7 @@ func (r *WorkflowTaskRepository) ListByDocument(ctx context.Context, documentID
 		Preload("Assignee", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email", "role")
 		}).
-		Select("id", "workflow_id", "document_id", "assigned_to", "task_type", "status", "priority", "due_date", "comments", "created_at", "completed_at").
+		Select("id", "workflow_id", "document_id", "assigned_to", "task_type", "status", "priority", "step_number", "due_date", "comments", "created_at", "completed_at").
 		Where("document_id = ?", documentID).
 		Order("created_at DESC").Find(&tasks).Error
 	if err != nil {
@@ -119,7 +119,7 @@ func (r *WorkflowTaskRepository) GetPendingTasks(ctx context.Context, tenantID u
 		Preload("Assignee", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email")
 		}).
-		Select("workflow_tasks.id", "workflow_tasks.workflow_id", "workflow_tasks.document_id", "workflow_tasks.assigned_to", "workflow_tasks.task_type", "workflow_tasks.status", "workflow_tasks.priority", "workflow_tasks.due_date", "workflow_tasks.created_at").
+		Select("workflow_tasks.id", "workflow_tasks.workflow_id", "workflow_tasks.document_id", "workflow_tasks.assigned_to", "workflow_tasks.task_type", "workflow_tasks.status", "workflow_tasks.priority", "workflow_tasks.step_number", "workflow_tasks.due_date", "workflow_tasks.created_at").
 		Joins("JOIN workflows ON workflow_tasks.workflow_id = workflows.id").
 		Where("workflows.tenant_id = ? AND workflow_tasks.status = ?", tenantID, models.WorkflowPending).
 		Order("workflow_tasks.priority ASC, workflow_tasks.due_date ASC").Find(&tasks).Error
@@ -143,7 +143,7 @@ func (r *WorkflowTaskRepository) GetOverdueTasks(ctx context.Context, tenantID u
 		Preload("Assignee", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "first_name", "last_name", "email")
 		}).
-		Select("workflow_tasks.id", "workflow_tasks.workflow_id", "workflow_tasks.document_id", "workflow_tasks.assigned_to", "workflow_tasks.task_type", "workflow_tasks.status", "workflow_tasks.priority", "workflow_tasks.due_date", "workflow_tasks.created_at").
+		Select("workflow_tasks.id", "workflow_tasks.workflow_id", "workflow_tasks.document_id", "workflow_tasks.assigned_to", "workflow_tasks.task_type", "workflow_tasks.status", "workflow_tasks.priority", "workflow_tasks.step_number", "workflow_tasks.due_date", "workflow_tasks.created_at").
 		Joins("JOIN workflows ON workflow_tasks.workflow_id = workflows.id").
 		Where("workflows.tenant_id = ? AND workflow_tasks.status = ? AND workflow_tasks.due_date < ?",
 			tenantID, models.WorkflowPending, now).
@@ -175,6 +175,109 @@ func (r *WorkflowTaskRepository) Complete(ctx context.Context, taskID uuid.UUID,
 	return nil
 }
 
+func (r *WorkflowTaskRepository) ListByInstance(ctx context.Context, instanceID uuid.UUID) ([]models.WorkflowTask, error) {
+	var tasks []models.WorkflowTask
+	err := r.db.WithContext(ctx).
+		Preload("Assignee", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "first_name", "last_name", "email", "role")
+		}).
+		Where("instance_id = ?", instanceID).
+		Order("priority ASC, created_at ASC").Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow tasks by instance: %w", err)
+	}
+	return tasks, nil
+}
+
+func (r *WorkflowTaskRepository) ListByStepGroup(ctx context.Context, groupID uuid.UUID) ([]models.WorkflowTask, error) {
+	var tasks []models.WorkflowTask
+	err := r.db.WithContext(ctx).
+		Where("step_group_id = ?", groupID).
+		Order("created_at ASC").Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow tasks by step group: %w", err)
+	}
+	return tasks, nil
+}
+
+// CancelPendingInGroup cancels the group's pending tasks of the given task
+// type, or of every type when taskType is empty
+func (r *WorkflowTaskRepository) CancelPendingInGroup(ctx context.Context, groupID uuid.UUID, taskType, reason string) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.WorkflowTask{}).
+		Where("step_group_id = ? AND status = ?", groupID, models.WorkflowPending)
+	if taskType != "" {
+		query = query.Where("task_type = ?", taskType)
+	}
+
+	now := time.Now()
+	result := query.Updates(map[string]interface{}{
+		"status":       models.WorkflowCancelled,
+		"comments":     reason,
+		"completed_at": now,
+		"updated_at":   now,
+	})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to cancel workflow tasks: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ListPending returns pending tasks across all tenants in ID order, starting
+// after afterID, with their workflow rules loaded
+func (r *WorkflowTaskRepository) ListPending(ctx context.Context, tenantID *uuid.UUID, afterID uuid.UUID, limit int) ([]models.WorkflowTask, error) {
+	var tasks []models.WorkflowTask
+	query := r.db.WithContext(ctx).
+		Preload("Workflow").
+		Preload("Document", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "title", "file_name", "document_type", "status", "tenant_id")
+		}).
+		Where("status = ?", models.WorkflowPending)
+	if tenantID != nil {
+		query = query.Where("workflow_id IN (?)", r.db.Model(&models.Workflow{}).Select("id").Where("tenant_id = ?", *tenantID))
+	}
+	if afterID != uuid.Nil {
+		query = query.Where("id > ?", afterID)
+	}
+
+	if err := query.Order("id ASC").Limit(limit).Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list pending workflow tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// ClaimReminder records a reminder for a pending task unless one was already
+// sent at or after remindAt, so concurrent workers send it only once, and
+// schedules the task's next reminder (nil when none is left)
+func (r *WorkflowTaskRepository) ClaimReminder(ctx context.Context, taskID uuid.UUID, remindAt time.Time, next *time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.WorkflowTask{}).
+		Where("id = ? AND status = ?", taskID, models.WorkflowPending).
+		Where("last_reminder_at IS NULL OR last_reminder_at < ?", remindAt).
+		Updates(map[string]interface{}{
+			"last_reminder_at": time.Now(),
+			"next_reminder_at": next,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to claim workflow task reminder: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Escalate reassigns a pending task that has not been escalated yet
+func (r *WorkflowTaskRepository) Escalate(ctx context.Context, taskID, fromUserID, toUserID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.WorkflowTask{}).
+		Where("id = ? AND status = ? AND assigned_to = ? AND escalated_at IS NULL", taskID, models.WorkflowPending, fromUserID).
+		Updates(map[string]interface{}{
+			"assigned_to":    toUserID,
+			"escalated_from": fromUserID,
+			"escalated_at":   time.Now(),
+			"updated_at":     time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to escalate workflow task: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (r *WorkflowTaskRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	// Check if task is in a state that can be deleted
 	var task models.WorkflowTask
@@ -200,3 +303,26 @@ func (r *WorkflowTaskRepository) Delete(ctx context.Context, id uuid.UUID) error
 	}
 	return nil
 }
+
+func (r *WorkflowTaskRepository) CreateDelegation(ctx context.Context, delegation *models.WorkflowTaskDelegation) error {
+	if err := r.db.WithContext(ctx).Create(delegation).Error; err != nil {
+		return fmt.Errorf("failed to record workflow task delegation: %w", err)
+	}
+	return nil
+}
+
+// SummarizeDelegations counts the tenant's delegations made in [from, to) by
+// pair of users, kind and the current status of the delegated task
+func (r *WorkflowTaskRepository) SummarizeDelegations(ctx context.Context, tenantID uuid.UUID, from, to time.Time) ([]repositories.DelegationStats, error) {
+	var stats []repositories.DelegationStats
+	err := r.db.WithContext(ctx).Table("workflow_task_delegations AS d").
+		Select("d.from_user_id, d.to_user_id, d.kind, t.status, COUNT(*) AS count").
+		Joins("JOIN workflow_tasks t ON t.id = d.task_id").
+		Where("d.tenant_id = ? AND d.created_at >= ? AND d.created_at < ?", tenantID, from, to).
+		Group("d.from_user_id, d.to_user_id, d.kind, t.status").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize workflow task delegations: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,220 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/archivus/archivus/internal/infrastructure/database/models"
+	"github.com/archivus/archivus/internal/infrastructure/repositories/postgresql/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestWorkflowTask(t *testing.T, db *testutil.TestDB, tenant *models.Tenant, user *models.User) *models.WorkflowTask {
+	t.Helper()
+
+	document := db.CreateTestDocument(t, tenant, user)
+	workflow := &models.Workflow{
+		ID:        uuid.New(),
+		TenantID:  tenant.ID,
+		Name:      "Invoice approval",
+		DocType:   models.DocTypeInvoice,
+		Rules:     models.JSONB{"approval_steps": []interface{}{}},
+		IsActive:  true,
+		CreatedBy: user.ID,
+	}
+	require.NoError(t, db.Create(workflow).Error)
+
+	dueDate := time.Now().AddDate(0, 0, 3)
+	task := &models.WorkflowTask{
+		ID:         uuid.New(),
+		WorkflowID: workflow.ID,
+		DocumentID: document.ID,
+		AssignedTo: user.ID,
+		TaskType:   "Review",
+		Status:     models.WorkflowPending,
+		Priority:   1,
+		DueDate:    &dueDate,
+	}
+	require.NoError(t, db.Create(task).Error)
+	return task
+}
+
+func TestWorkflowTaskRepository_ListPending(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWorkflowTaskRepository(db.DB).(*WorkflowTaskRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	pending := createTestWorkflowTask(t, db, tenant, user)
+	completed := createTestWorkflowTask(t, db, tenant, user)
+	require.NoError(t, repo.Complete(ctx, completed.ID, user.ID, "done"))
+
+	tasks, err := repo.ListPending(ctx, nil, uuid.Nil, 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, pending.ID, tasks[0].ID)
+	assert.Equal(t, tenant.ID, tasks[0].Workflow.TenantID)
+	assert.Equal(t, tenant.ID, tasks[0].Document.TenantID)
+
+	tasks, err = repo.ListPending(ctx, nil, pending.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	// Scoped to a tenant
+	other := db.CreateTestTenant(t)
+	tasks, err = repo.ListPending(ctx, &tenant.ID, uuid.Nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	tasks, err = repo.ListPending(ctx, &other.ID, uuid.Nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+}
+
+func TestWorkflowTaskRepository_ClaimReminder(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWorkflowTaskRepository(db.DB).(*WorkflowTaskRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	task := createTestWorkflowTask(t, db, tenant, user)
+
+	remindAt := time.Now().Add(-time.Hour)
+	next := time.Now().Add(24 * time.Hour)
+	claimed, err := repo.ClaimReminder(ctx, task.ID, remindAt, &next)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	updated, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	require.NotNil(t, updated.NextReminderAt)
+	assert.WithinDuration(t, next, *updated.NextReminderAt, time.Second)
+
+	// The same reminder is only sent once
+	claimed, err = repo.ClaimReminder(ctx, task.ID, remindAt, &next)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// A later reminder day is claimable again, and the last one clears the schedule
+	claimed, err = repo.ClaimReminder(ctx, task.ID, time.Now().Add(time.Minute), nil)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	updated, err = repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Nil(t, updated.NextReminderAt)
+}
+
+func TestWorkflowTaskRepository_Escalate(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWorkflowTaskRepository(db.DB).(*WorkflowTaskRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	manager := db.CreateTestUser(t, tenant)
+	task := createTestWorkflowTask(t, db, tenant, user)
+
+	escalated, err := repo.Escalate(ctx, task.ID, user.ID, manager.ID)
+	require.NoError(t, err)
+	assert.True(t, escalated)
+
+	updated, err := repo.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, manager.ID, updated.AssignedTo)
+
+	// A task is only escalated once
+	escalated, err = repo.Escalate(ctx, task.ID, manager.ID, user.ID)
+	require.NoError(t, err)
+	assert.False(t, escalated)
+}
+
+func TestWorkflowTaskRepository_CancelPendingInGroup(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWorkflowTaskRepository(db.DB).(*WorkflowTaskRepository)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	groupID := uuid.New()
+
+	approved := createTestWorkflowTask(t, db, tenant, user)
+	pending := createTestWorkflowTask(t, db, tenant, user)
+	other := createTestWorkflowTask(t, db, tenant, user)
+	for _, task := range []*models.WorkflowTask{approved, pending, other} {
+		require.NoError(t, db.Model(task).Update("step_group_id", groupID).Error)
+	}
+	require.NoError(t, db.Model(other).Update("task_type", "Sign-off").Error)
+	require.NoError(t, repo.Complete(ctx, approved.ID, user.ID, "approved"))
+
+	tasks, err := repo.ListByStepGroup(ctx, groupID)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 3)
+
+	// Only pending tasks of the given type are cancelled
+	cancelled, err := repo.CancelPendingInGroup(ctx, groupID, "Review", "Quorum reached")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cancelled)
+
+	updated, err := repo.GetByID(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WorkflowPending, updated.Status)
+
+	// An empty type cancels the rest of the group
+	cancelled, err = repo.CancelPendingInGroup(ctx, groupID, "", "Step approved")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cancelled)
+}
+
+func TestWorkflowTaskRepository_SummarizeDelegations(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	defer db.Cleanup(t)
+
+	repo := NewWorkflowTaskRepository(db.DB)
+	ctx := context.Background()
+
+	tenant := db.CreateTestTenant(t)
+	user := db.CreateTestUser(t, tenant)
+	delegate := db.CreateTestUser(t, tenant)
+
+	approved := createTestWorkflowTask(t, db, tenant, delegate)
+	pending := createTestWorkflowTask(t, db, tenant, delegate)
+	old := createTestWorkflowTask(t, db, tenant, delegate)
+	require.NoError(t, repo.Complete(ctx, approved.ID, delegate.ID, "approved"))
+
+	for _, task := range []*models.WorkflowTask{approved, pending, old} {
+		require.NoError(t, repo.CreateDelegation(ctx, &models.WorkflowTaskDelegation{
+			TenantID:   tenant.ID,
+			TaskID:     task.ID,
+			FromUserID: user.ID,
+			ToUserID:   delegate.ID,
+			Kind:       models.DelegationManual,
+		}))
+	}
+	require.NoError(t, db.Model(&models.WorkflowTaskDelegation{}).Where("task_id = ?", old.ID).
+		Update("created_at", time.Now().AddDate(0, -2, 0)).Error)
+
+	stats, err := repo.SummarizeDelegations(ctx, tenant.ID, time.Now().AddDate(0, -1, 0), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	byStatus := make(map[models.WorkflowStatus]int64)
+	for _, stat := range stats {
+		assert.Equal(t, user.ID, stat.FromUserID)
+		assert.Equal(t, delegate.ID, stat.ToUserID)
+		byStatus[stat.Status] += stat.Count
+	}
+	assert.Equal(t, map[models.WorkflowStatus]int64{models.WorkflowApproved: 1, models.WorkflowPending: 1}, byStatus)
+}
@@ -2,13 +2,16 @@ package local
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/tracing"
 	"github.com/google/uuid"
 )
 
@@ -22,7 +25,10 @@ func NewStorageService(basePath string) *StorageService {
 	}
 }
 
-func (s *StorageService) Store(ctx context.Context, params services.StorageParams) (string, error) {
+func (s *StorageService) Store(ctx context.Context, params services.StorageParams) (_ string, err error) {
+	_, span := tracing.StartStorage(ctx, "local", "store", params.TenantID.String())
+	defer func() { tracing.End(span, err) }()
+
 	// Create tenant directory if it doesn't exist
 	tenantDir := filepath.Join(s.basePath, params.TenantID.String())
 	if err := os.MkdirAll(tenantDir, 0755); err != nil {
@@ -52,7 +58,10 @@ func (s *StorageService) Store(ctx context.Context, params services.StorageParam
 	return relativePath, nil
 }
 
-func (s *StorageService) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+func (s *StorageService) Get(ctx context.Context, path string) (_ io.ReadCloser, err error) {
+	_, span := tracing.StartStorage(ctx, "local", "get", path)
+	defer func() { tracing.End(span, err) }()
+
 	fullPath := filepath.Join(s.basePath, path)
 
 	file, err := os.Open(fullPath)
@@ -63,10 +72,13 @@ func (s *StorageService) Get(ctx context.Context, path string) (io.ReadCloser, e
 	return file, nil
 }
 
-func (s *StorageService) Delete(ctx context.Context, path string) error {
+func (s *StorageService) Delete(ctx context.Context, path string) (err error) {
+	_, span := tracing.StartStorage(ctx, "local", "delete", path)
+	defer func() { tracing.End(span, err) }()
+
 	fullPath := filepath.Join(s.basePath, path)
 
-	err := os.Remove(fullPath)
+	err = os.Remove(fullPath)
 	if err != nil {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
@@ -84,3 +96,62 @@ func (s *StorageService) GetPublicURL(bucketName, filePath string) string {
 	// For local storage, return a URL that the application can serve
 	return fmt.Sprintf("/api/v1/files/%s", filePath)
 }
+
+// List returns every file under prefix, with paths relative to the base path
+// like the ones Store returns
+func (s *StorageService) List(ctx context.Context, prefix string) (_ []services.StoredObject, err error) {
+	ctx, span := tracing.StartStorage(ctx, "local", "list", prefix)
+	defer func() { tracing.End(span, err) }()
+
+	objects := []services.StoredObject{}
+	root := filepath.Join(s.basePath, prefix)
+
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(s.basePath, path)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, services.StoredObject{
+			Path:       relativePath,
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	return objects, nil
+}
+
+// HealthCheck checks that files can be written to the storage directory
+func (s *StorageService) HealthCheck(ctx context.Context) error {
+	if err := os.MkdirAll(s.basePath, 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	probe, err := os.CreateTemp(s.basePath, ".healthcheck-*")
+	if err != nil {
+		return fmt.Errorf("storage directory is not writable: %w", err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,123 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/tracing"
+)
+
+// TieredStorageService is local storage with a cold tier, typically a
+// cheaper, slower disk. New files go to the hot tier; archived files are
+// moved to the cold one under the same relative path, and reads and
+// deletes look there when a file isn't hot.
+type TieredStorageService struct {
+	*StorageService
+	cold *StorageService
+}
+
+func NewTieredStorageService(basePath, coldPath string) *TieredStorageService {
+	return &TieredStorageService{
+		StorageService: NewStorageService(basePath),
+		cold:           NewStorageService(coldPath),
+	}
+}
+
+func (s *TieredStorageService) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	file, err := s.StorageService.Get(ctx, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s.cold.Get(ctx, path)
+	}
+	return file, err
+}
+
+func (s *TieredStorageService) Delete(ctx context.Context, path string) error {
+	err := s.StorageService.Delete(ctx, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s.cold.Delete(ctx, path)
+	}
+	return err
+}
+
+// List returns the files of both tiers
+func (s *TieredStorageService) List(ctx context.Context, prefix string) ([]services.StoredObject, error) {
+	hot, err := s.StorageService.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	cold, err := s.cold.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return append(hot, cold...), nil
+}
+
+// MoveToCold moves a file to the cold tier. Files already there are left
+// alone. Across disks the file is copied, then removed.
+func (s *TieredStorageService) MoveToCold(ctx context.Context, path string) (err error) {
+	_, span := tracing.StartStorage(ctx, "local", "move_to_cold", path)
+	defer func() { tracing.End(span, err) }()
+
+	hotPath := filepath.Join(s.basePath, path)
+	coldPath := filepath.Join(s.cold.basePath, path)
+	if _, err := os.Stat(hotPath); errors.Is(err, fs.ErrNotExist) {
+		if _, err := os.Stat(coldPath); err == nil {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(coldPath), 0755); err != nil {
+		return fmt.Errorf("failed to create cold storage directory: %w", err)
+	}
+	if err := os.Rename(hotPath, coldPath); err == nil {
+		return nil
+	}
+
+	if err := copyFile(hotPath, coldPath); err != nil {
+		os.Remove(coldPath)
+		return err
+	}
+	if err := os.Remove(hotPath); err != nil {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
+	return nil
+}
+
+// HealthCheck checks that files can be written to both tiers
+func (s *TieredStorageService) HealthCheck(ctx context.Context) error {
+	if err := s.StorageService.HealthCheck(ctx); err != nil {
+		return err
+	}
+	if err := s.cold.HealthCheck(ctx); err != nil {
+		return fmt.Errorf("cold storage: %w", err)
+	}
+	return nil
+}
+
+func copyFile(from, to string) error {
+	source, err := os.Open(from)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer source.Close()
+
+	target, err := os.Create(to)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := target.Sync(); err != nil {
+		target.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	return target.Close()
+}
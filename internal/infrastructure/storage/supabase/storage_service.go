@@ -3,12 +3,18 @@ package supabase
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/archivus/archivus/internal/domain/services"
+	"github.com/archivus/archivus/internal/infrastructure/tracing"
 	"github.com/google/uuid"
 	supabase "github.com/nedpals/supabase-go"
 )
@@ -16,6 +22,11 @@ import (
 type StorageService struct {
 	client     *supabase.Client
 	bucketName string
+
+	// The client keeps its key to itself; direct uploads call the storage
+	// API with it
+	url    string
+	apiKey string
 }
 
 type Config struct {
@@ -33,10 +44,15 @@ func NewStorageService(config Config) (*StorageService, error) {
 	return &StorageService{
 		client:     client,
 		bucketName: config.Bucket,
+		url:        strings.TrimRight(config.URL, "/"),
+		apiKey:     config.APIKey,
 	}, nil
 }
 
-func (s *StorageService) Store(ctx context.Context, params services.StorageParams) (string, error) {
+func (s *StorageService) Store(ctx context.Context, params services.StorageParams) (_ string, err error) {
+	_, span := tracing.StartStorage(ctx, "supabase", "store", params.TenantID.String())
+	defer func() { tracing.End(span, err) }()
+
 	// Generate unique file path
 	fileExt := filepath.Ext(params.Filename)
 	fileName := fmt.Sprintf("%s/%s%s", params.TenantID.String(), uuid.New().String(), fileExt)
@@ -61,7 +77,10 @@ func (s *StorageService) Store(ctx context.Context, params services.StorageParam
 	return fileName, nil
 }
 
-func (s *StorageService) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+func (s *StorageService) Get(ctx context.Context, path string) (_ io.ReadCloser, err error) {
+	_, span := tracing.StartStorage(ctx, "supabase", "get", path)
+	defer func() { tracing.End(span, err) }()
+
 	// Download file from Supabase Storage
 	content, err := s.client.Storage.From(s.bucketName).Download(path)
 	if err != nil {
@@ -71,7 +90,10 @@ func (s *StorageService) Get(ctx context.Context, path string) (io.ReadCloser, e
 	return io.NopCloser(bytes.NewReader(content)), nil
 }
 
-func (s *StorageService) Delete(ctx context.Context, path string) error {
+func (s *StorageService) Delete(ctx context.Context, path string) (err error) {
+	_, span := tracing.StartStorage(ctx, "supabase", "delete", path)
+	defer func() { tracing.End(span, err) }()
+
 	// Delete file from Supabase Storage
 	response := s.client.Storage.From(s.bucketName).Remove([]string{path})
 	if response.Key == "" {
@@ -81,7 +103,10 @@ func (s *StorageService) Delete(ctx context.Context, path string) error {
 	return nil
 }
 
-func (s *StorageService) GeneratePresignedURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
+func (s *StorageService) GeneratePresignedURL(ctx context.Context, path string, expiry time.Duration) (_ string, err error) {
+	_, span := tracing.StartStorage(ctx, "supabase", "presign_download", path)
+	defer func() { tracing.End(span, err) }()
+
 	// Generate signed URL for temporary access
 	expirySeconds := int(expiry.Seconds())
 
@@ -99,3 +124,177 @@ func (s *StorageService) GetPublicURL(bucketName, filePath string) string {
 	signedURL := s.client.Storage.From(bucketName).CreateSignedUrl(filePath, 3600) // 1 hour expiry
 	return signedURL.SignedUrl
 }
+
+// listPageSize is how many entries List asks Supabase for at a time
+const listPageSize = 1000
+
+// List returns every file under prefix. Supabase lists one folder level at a
+// time, so folders are walked recursively.
+func (s *StorageService) List(ctx context.Context, prefix string) (_ []services.StoredObject, err error) {
+	ctx, span := tracing.StartStorage(ctx, "supabase", "list", prefix)
+	defer func() { tracing.End(span, err) }()
+
+	objects := []services.StoredObject{}
+	folders := []string{strings.Trim(prefix, "/")}
+
+	for len(folders) > 0 {
+		folder := folders[0]
+		folders = folders[1:]
+
+		for offset := 0; ; offset += listPageSize {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			page := s.client.Storage.From(s.bucketName).List(folder, supabase.FileSearchOptions{
+				Limit:  listPageSize,
+				Offset: offset,
+			})
+			for _, entry := range page {
+				path := entry.Name
+				if folder != "" {
+					path = folder + "/" + entry.Name
+				}
+
+				// Folders are placeholders without an ID
+				if entry.Id == "" {
+					folders = append(folders, path)
+					continue
+				}
+
+				object := services.StoredObject{Path: path}
+				if metadata, ok := entry.Metadata.(map[string]interface{}); ok {
+					if size, ok := metadata["size"].(float64); ok {
+						object.Size = int64(size)
+					}
+				}
+				if updatedAt, err := time.Parse(time.RFC3339, entry.UpdatedAt); err == nil {
+					object.ModifiedAt = updatedAt
+				}
+				objects = append(objects, object)
+			}
+
+			if len(page) < listPageSize {
+				break
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// PresignUpload signs an upload URL for a new path under the tenant's
+// folder. The client PUTs the file to it; the URL works once.
+func (s *StorageService) PresignUpload(ctx context.Context, tenantID uuid.UUID, filename, contentType string, expiry time.Duration) (_ *services.PresignedUpload, err error) {
+	path := fmt.Sprintf("%s/%s%s", tenantID.String(), uuid.New().String(), filepath.Ext(filename))
+	ctx, span := tracing.StartStorage(ctx, "supabase", "presign_upload", path)
+	defer func() { tracing.End(span, err) }()
+
+	body, err := json.Marshal(map[string]int{"expiresIn": int(expiry.Seconds())})
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.storageRequest(ctx, http.MethodPost, "/object/upload/sign/"+s.objectPath(path), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign upload URL: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("failed to sign upload URL: %s: %s", resp.Status, message)
+	}
+
+	var signed struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&signed); err != nil || signed.URL == "" {
+		return nil, fmt.Errorf("failed to sign upload URL: unexpected response")
+	}
+
+	return &services.PresignedUpload{
+		Path:    path,
+		URL:     s.url + "/storage/v1" + signed.URL,
+		Method:  http.MethodPut,
+		Headers: map[string]string{"Content-Type": contentType},
+	}, nil
+}
+
+// Stat returns the size of the file at path
+func (s *StorageService) Stat(ctx context.Context, path string) (_ *services.StoredObject, err error) {
+	ctx, span := tracing.StartStorage(ctx, "supabase", "stat", path)
+	defer func() { tracing.End(span, err) }()
+
+	req, err := s.storageRequest(ctx, http.MethodHead, "/object/authenticated/"+s.objectPath(path), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	// Storage answers 400 rather than 404 for some missing objects
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusBadRequest:
+		return nil, services.ErrStoredObjectNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to stat file: %s", resp.Status)
+	}
+
+	size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: missing size")
+	}
+	object := &services.StoredObject{Path: path, Size: size}
+	if modifiedAt, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		object.ModifiedAt = modifiedAt
+	}
+	return object, nil
+}
+
+// HealthCheck checks that the storage API is up and the bucket exists
+func (s *StorageService) HealthCheck(ctx context.Context) error {
+	req, err := s.storageRequest(ctx, http.MethodGet, "/bucket/"+url.PathEscape(s.bucketName), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("storage API unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get bucket %s: %s", s.bucketName, resp.Status)
+	}
+	return nil
+}
+
+// storageRequest builds an authenticated request to the storage API
+func (s *StorageService) storageRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.url+"/storage/v1"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("apikey", s.apiKey)
+	return req, nil
+}
+
+// objectPath is the bucket and path of a file, escaped for a URL
+func (s *StorageService) objectPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return url.PathEscape(s.bucketName) + "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type tracedRow struct {
+	ID   uint
+	Name string
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&tracedRow{}))
+	require.NoError(t, db.Use(GormPlugin{}))
+
+	// Statements outside a trace aren't recorded
+	require.NoError(t, db.Create(&tracedRow{Name: "untraced"}).Error)
+	assert.Empty(t, recorder.Ended())
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	require.NoError(t, db.WithContext(ctx).Create(&tracedRow{Name: "traced"}).Error)
+	var rows []tracedRow
+	require.NoError(t, db.WithContext(ctx).Where("name = ?", "traced").Find(&rows).Error)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "db.create traced_rows", spans[0].Name())
+	assert.Equal(t, "db.query traced_rows", spans[1].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[1].Parent().SpanID())
+
+	for _, attr := range spans[1].Attributes() {
+		if attr.Key == "db.query.text" {
+			assert.NotContains(t, attr.Value.AsString(), "traced'")
+		}
+	}
+}